@@ -20,9 +20,18 @@
 
 package downsample
 
+import (
+	"time"
+)
+
 // Downsampler is a downsampler.
 type Downsampler interface {
 	NewMetricsAppender() MetricsAppender
+
+	// Match returns the aggregations that samples of a series with the given
+	// tags would be downsampled with at a given time, without writing any
+	// samples, to debug mapping and rollup rules.
+	Match(tags map[string]string, at time.Time) (MatchResult, error)
 }
 
 // MetricsAppender is a metrics appender that can build a samples
@@ -72,6 +81,21 @@ func (d *downsampler) NewMetricsAppender() MetricsAppender {
 	})
 }
 
+func (d *downsampler) Match(
+	tags map[string]string,
+	at time.Time,
+) (MatchResult, error) {
+	tagEncoder := d.agg.pools.tagEncoderPool.Get()
+	defer tagEncoder.Finalize()
+
+	return match(tags, at, matchOptions{
+		defaultStagedMetadatas:  d.agg.defaultStagedMetadatas,
+		tagEncoder:              tagEncoder,
+		matcher:                 d.agg.matcher,
+		encodedTagsIteratorPool: d.agg.pools.encodedTagsIteratorPool,
+	})
+}
+
 func newMetricsAppender(opts metricsAppenderOptions) *metricsAppender {
 	return &metricsAppender{
 		metricsAppenderOptions: opts,
@@ -111,6 +111,71 @@ func TestDownsamplerAggregationWithRulesStore(t *testing.T) {
 	testDownsamplerAggregation(t, testDownsampler)
 }
 
+func TestDownsamplerMatch(t *testing.T) {
+	testDownsampler := newTestDownsampler(t, testDownsamplerOptions{
+		autoMappingRules: []MappingRule{
+			{
+				Aggregations: []aggregation.Type{testAggregationType},
+				Policies:     testAggregationStoragePolicies,
+			},
+		},
+	})
+	rulesStore := testDownsampler.rulesStore
+
+	_, err := rulesStore.CreateNamespace("default", store.NewUpdateOptions())
+	require.NoError(t, err)
+
+	rule := view.MappingRule{
+		ID:              "mappingrule",
+		Name:            "mappingrule",
+		Filter:          "app:test*",
+		AggregationID:   aggregation.MustCompressTypes(aggregation.Max),
+		StoragePolicies: policy.StoragePolicies{policy.MustParseStoragePolicy("1m:30d")},
+	}
+	_, err = rulesStore.CreateMappingRule("default", rule,
+		store.NewUpdateOptions())
+	require.NoError(t, err)
+
+	tags := map[string]string{
+		"__name__": "foo",
+		"app":      "test123",
+	}
+	var result MatchResult
+	for {
+		result, err = testDownsampler.downsampler.Match(tags, time.Now())
+		require.NoError(t, err)
+		if len(result.Mappings) > 0 {
+			break
+		}
+		time.Sleep(100 * time.Millisecond)
+	}
+
+	assert.Equal(t, MatchResult{
+		Defaults: []MatchedPipeline{
+			{
+				Aggregations:    []string{testAggregationType.String()},
+				StoragePolicies: []string{"2s:1d"},
+			},
+		},
+		Mappings: []MatchedPipeline{
+			{
+				Aggregations:    []string{aggregation.Max.String()},
+				StoragePolicies: []string{"1m:30d"},
+			},
+		},
+	}, result)
+
+	// Series that do not match the filter only have the defaults.
+	result, err = testDownsampler.downsampler.Match(map[string]string{
+		"__name__": "foo",
+		"app":      "other",
+	}, time.Now())
+	require.NoError(t, err)
+	assert.Equal(t, 1, len(result.Defaults))
+	assert.Equal(t, 0, len(result.Mappings))
+	assert.Equal(t, 0, len(result.Rollups))
+}
+
 func testDownsamplerAggregation(
 	t *testing.T,
 	testDownsampler testDownsampler,
@@ -46,13 +46,22 @@ type downsamplerFlushHandler struct {
 	storage                 storage.Storage
 	encodedTagsIteratorPool *encodedTagsIteratorPool
 	workerPool              xsync.WorkerPool
+	flushOffsetFn           flushOffsetFn
+	gapFiller               *gapFiller
+	nowFn                   func() time.Time
+	sleepFn                 func(time.Duration)
 	instrumentOpts          instrument.Options
 	metrics                 downsamplerFlushHandlerMetrics
 }
 
+// flushOffsetFn returns the offset from the start of each interval at which
+// aggregations of a resolution are written to storage.
+type flushOffsetFn func(resolution time.Duration) time.Duration
+
 type downsamplerFlushHandlerMetrics struct {
 	flushSuccess tally.Counter
 	flushErrors  tally.Counter
+	gapFilled    tally.Counter
 }
 
 func newDownsamplerFlushHandlerMetrics(
@@ -61,6 +70,7 @@ func newDownsamplerFlushHandlerMetrics(
 	return downsamplerFlushHandlerMetrics{
 		flushSuccess: scope.Counter("flush-success"),
 		flushErrors:  scope.Counter("flush-errors"),
+		gapFilled:    scope.Counter("gap-filled-datapoints"),
 	}
 }
 
@@ -68,6 +78,8 @@ func newDownsamplerFlushHandler(
 	storage storage.Storage,
 	encodedTagsIteratorPool *encodedTagsIteratorPool,
 	workerPool xsync.WorkerPool,
+	flushOffsetFn flushOffsetFn,
+	gapFills GapFills,
 	instrumentOpts instrument.Options,
 ) handler.Handler {
 	scope := instrumentOpts.MetricsScope().SubScope("downsampler-flush-handler")
@@ -75,6 +87,10 @@ func newDownsamplerFlushHandler(
 		storage:                 storage,
 		encodedTagsIteratorPool: encodedTagsIteratorPool,
 		workerPool:              workerPool,
+		flushOffsetFn:           flushOffsetFn,
+		gapFiller:               newGapFiller(gapFills),
+		nowFn:                   time.Now,
+		sleepFn:                 time.Sleep,
 		instrumentOpts:          instrumentOpts,
 		metrics:                 newDownsamplerFlushHandlerMetrics(scope),
 	}
@@ -98,9 +114,30 @@ type downsamplerFlushHandlerWriter struct {
 	handler *downsamplerFlushHandler
 }
 
+// waitForFlushOffset waits until the flush offset of the resolution has
+// passed since the start of the current interval, aggregations flushed
+// after the offset are written immediately.
+func (h *downsamplerFlushHandler) waitForFlushOffset(resolution time.Duration) {
+	if h.flushOffsetFn == nil || resolution <= 0 {
+		return
+	}
+	offset := h.flushOffsetFn(resolution)
+	if offset <= 0 {
+		return
+	}
+	now := h.nowFn()
+	if aligned := now.Truncate(resolution).Add(offset); now.Before(aligned) {
+		h.sleepFn(aligned.Sub(now))
+	}
+}
+
 func (w *downsamplerFlushHandlerWriter) Write(
 	mp aggregated.ChunkedMetricWithStoragePolicy,
 ) error {
+	// NB: Waiting blocks the aggregator flush of this resolution rather
+	// than a storage flush worker.
+	w.handler.waitForFlushOffset(mp.StoragePolicy.Resolution().Window)
+
 	w.wg.Add(1)
 	w.handler.workerPool.Go(func() {
 		defer w.wg.Done()
@@ -135,13 +172,21 @@ func (w *downsamplerFlushHandlerWriter) Write(
 			return
 		}
 
+		tags = models.Normalize(tags)
+		datapoints := ts.Datapoints{ts.Datapoint{
+			Timestamp: time.Unix(0, mp.TimeNanos),
+			Value:     mp.Value,
+		}}
+		if filler := w.handler.gapFiller; filler.filled(mp.StoragePolicy) {
+			datapoints = filler.datapoints(mp.StoragePolicy, tags.ID(),
+				mp.TimeNanos, mp.Value)
+			w.handler.metrics.gapFilled.Inc(int64(len(datapoints) - 1))
+		}
+
 		err = w.handler.storage.Write(w.ctx, &storage.WriteQuery{
-			Tags: models.Normalize(tags),
-			Datapoints: ts.Datapoints{ts.Datapoint{
-				Timestamp: time.Unix(0, mp.TimeNanos),
-				Value:     mp.Value,
-			}},
-			Unit: mp.StoragePolicy.Resolution().Precision,
+			Tags:       tags,
+			Datapoints: datapoints,
+			Unit:       mp.StoragePolicy.Resolution().Precision,
 			Attributes: storage.Attributes{
 				MetricsType: storage.AggregatedMetricsType,
 				Retention:   mp.StoragePolicy.Retention().Duration(),
@@ -164,6 +209,7 @@ func (w *downsamplerFlushHandlerWriter) Flush() error {
 	// NB(r): This is a just simply waiting for inflight requests
 	// to complete since this flush handler isn't connection based.
 	w.wg.Wait()
+	w.handler.gapFiller.expire(w.handler.nowFn())
 	return nil
 }
 
@@ -0,0 +1,282 @@
+// Copyright (c) 2018 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package downsample
+
+import (
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/m3db/m3/src/query/ts"
+	"github.com/m3db/m3metrics/policy"
+)
+
+const (
+	// gapFillExpireInterval is the min interval between expiring the last
+	// datapoints of series that are no longer gap filled.
+	gapFillExpireInterval = time.Minute
+)
+
+var (
+	errGapFillNoResolution    = errors.New("gap fill resolution not set")
+	errGapFillNoRetention     = errors.New("gap fill retention not set")
+	errGapFillModeUnspecified = errors.New("gap fill mode unspecified")
+)
+
+// GapFillMode is how the intervals an aggregated series is missing are
+// written to storage.
+type GapFillMode uint
+
+const (
+	// GapFillSkip writes nothing for missing intervals.
+	GapFillSkip GapFillMode = iota
+	// GapFillZero writes a zero for each missing interval, which suits
+	// aggregated counters that have not been incremented while their source
+	// was not reporting.
+	GapFillZero
+	// GapFillLastValue writes the last value of the series for each missing
+	// interval, which suits aggregated gauges.
+	GapFillLastValue
+)
+
+// ValidGapFillModes returns the valid gap fill modes.
+func ValidGapFillModes() []GapFillMode {
+	return []GapFillMode{GapFillSkip, GapFillZero, GapFillLastValue}
+}
+
+func (m GapFillMode) String() string {
+	switch m {
+	case GapFillSkip:
+		return "skip"
+	case GapFillZero:
+		return "zero"
+	case GapFillLastValue:
+		return "lastValue"
+	}
+	return "unknown"
+}
+
+// ParseGapFillMode parses a GapFillMode from a string.
+func ParseGapFillMode(str string) (GapFillMode, error) {
+	if str == "" {
+		return GapFillSkip, errGapFillModeUnspecified
+	}
+	for _, valid := range ValidGapFillModes() {
+		if str == valid.String() {
+			return valid, nil
+		}
+	}
+	return GapFillSkip, fmt.Errorf("invalid GapFillMode '%s' valid modes are: %v",
+		str, ValidGapFillModes())
+}
+
+// UnmarshalYAML unmarshals a GapFillMode into a valid type from string.
+func (m *GapFillMode) UnmarshalYAML(unmarshal func(interface{}) error) error {
+	var str string
+	if err := unmarshal(&str); err != nil {
+		return err
+	}
+	parsed, err := ParseGapFillMode(str)
+	if err != nil {
+		return err
+	}
+	*m = parsed
+	return nil
+}
+
+// GapFill is how the missing intervals of the aggregations of the storage
+// policy with a resolution and retention are written. A gap is filled once
+// the series is written to again, and only if it is at most MaxIntervals
+// intervals long so that series that went away are not filled.
+type GapFill struct {
+	Resolution   time.Duration
+	Retention    time.Duration
+	Mode         GapFillMode
+	MaxIntervals int
+}
+
+// GapFills is a set of gap fills.
+type GapFills []GapFill
+
+func (g GapFills) validate() error {
+	seen := make(map[gapFillPolicy]struct{}, len(g))
+	for _, fill := range g {
+		if fill.Resolution <= 0 {
+			return errGapFillNoResolution
+		}
+		if fill.Retention <= 0 {
+			return errGapFillNoRetention
+		}
+		if fill.Mode != GapFillSkip && fill.MaxIntervals <= 0 {
+			return fmt.Errorf("gap fill for resolution %v and retention %v has "+
+				"max intervals %d, must be positive", fill.Resolution,
+				fill.Retention, fill.MaxIntervals)
+		}
+		key := gapFillPolicy{resolution: fill.Resolution, retention: fill.Retention}
+		if _, ok := seen[key]; ok {
+			return fmt.Errorf("duplicate gap fill for resolution %v and retention %v",
+				fill.Resolution, fill.Retention)
+		}
+		seen[key] = struct{}{}
+	}
+	return nil
+}
+
+// gapFillPolicy is the resolution and retention of a storage policy, gap
+// fills apply to storage policies regardless of their precision.
+type gapFillPolicy struct {
+	resolution time.Duration
+	retention  time.Duration
+}
+
+func newGapFillPolicy(sp policy.StoragePolicy) gapFillPolicy {
+	return gapFillPolicy{
+		resolution: sp.Resolution().Window,
+		retention:  sp.Retention().Duration(),
+	}
+}
+
+type gapFillKey struct {
+	policy gapFillPolicy
+	id     string
+}
+
+type gapFillLast struct {
+	timeNanos int64
+	value     float64
+}
+
+// gapFiller tracks the last datapoint written for each series of the
+// storage policies that are gap filled.
+type gapFiller struct {
+	sync.Mutex
+
+	fills      map[gapFillPolicy]GapFill
+	last       map[gapFillKey]gapFillLast
+	lastExpire time.Time
+}
+
+// newGapFiller returns a gap filler for the gap fills, or nil if no storage
+// policy is gap filled.
+func newGapFiller(fills GapFills) *gapFiller {
+	f := &gapFiller{
+		fills: make(map[gapFillPolicy]GapFill, len(fills)),
+		last:  make(map[gapFillKey]gapFillLast),
+	}
+	for _, fill := range fills {
+		if fill.Mode == GapFillSkip {
+			continue
+		}
+		key := gapFillPolicy{resolution: fill.Resolution, retention: fill.Retention}
+		f.fills[key] = fill
+	}
+	if len(f.fills) == 0 {
+		return nil
+	}
+	return f
+}
+
+// filled returns whether the aggregations of a storage policy are gap
+// filled.
+func (f *gapFiller) filled(sp policy.StoragePolicy) bool {
+	if f == nil {
+		return false
+	}
+	_, ok := f.fills[newGapFillPolicy(sp)]
+	return ok
+}
+
+// datapoints records a datapoint of a series and returns the datapoints to
+// write for it, which are preceded by a datapoint for each interval missing
+// since the last datapoint of the series if the gap is short enough.
+func (f *gapFiller) datapoints(
+	sp policy.StoragePolicy,
+	id string,
+	timeNanos int64,
+	value float64,
+) ts.Datapoints {
+	var (
+		key  = gapFillKey{policy: newGapFillPolicy(sp), id: id}
+		fill = f.fills[key.policy]
+	)
+
+	f.Lock()
+	last, ok := f.last[key]
+	if !ok || timeNanos > last.timeNanos {
+		f.last[key] = gapFillLast{timeNanos: timeNanos, value: value}
+	}
+	f.Unlock()
+
+	var (
+		resolution = int64(key.policy.resolution)
+		missing    int64
+	)
+	if ok && timeNanos > last.timeNanos {
+		missing = (timeNanos-last.timeNanos)/resolution - 1
+	}
+	if missing <= 0 || missing > int64(fill.MaxIntervals) {
+		return ts.Datapoints{{Timestamp: time.Unix(0, timeNanos), Value: value}}
+	}
+
+	fillValue := 0.0
+	if fill.Mode == GapFillLastValue {
+		fillValue = last.value
+	}
+	datapoints := make(ts.Datapoints, 0, missing+1)
+	for i := int64(1); i <= missing; i++ {
+		datapoints = append(datapoints, ts.Datapoint{
+			Timestamp: time.Unix(0, last.timeNanos+i*resolution),
+			Value:     fillValue,
+		})
+	}
+	return append(datapoints, ts.Datapoint{
+		Timestamp: time.Unix(0, timeNanos),
+		Value:     value,
+	})
+}
+
+// expire stops tracking the series whose gaps have grown too long to be
+// filled, at most once per expire interval.
+func (f *gapFiller) expire(now time.Time) {
+	if f == nil {
+		return
+	}
+
+	f.Lock()
+	defer f.Unlock()
+
+	if now.Sub(f.lastExpire) < gapFillExpireInterval {
+		return
+	}
+	f.lastExpire = now
+
+	nowNanos := now.UnixNano()
+	for key, last := range f.last {
+		// NB: Allow an extra interval for the delay between the start of an
+		// interval and its aggregations being flushed.
+		fill := f.fills[key.policy]
+		horizon := int64(fill.MaxIntervals+2) * int64(key.policy.resolution)
+		if nowNanos-last.timeNanos > horizon {
+			delete(f.last, key)
+		}
+	}
+}
@@ -0,0 +1,200 @@
+// Copyright (c) 2018 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package downsample
+
+import (
+	"fmt"
+	"sort"
+	"time"
+
+	"github.com/m3db/m3/src/dbnode/serialize"
+	"github.com/m3db/m3metrics/aggregation"
+	"github.com/m3db/m3metrics/matcher"
+	"github.com/m3db/m3metrics/metadata"
+)
+
+// MatchResult is the result of matching a series against the auto mapping
+// rules and the mapping and rollup rules of the rules store.
+type MatchResult struct {
+	// Defaults are the aggregations of the auto mapping rules, which apply
+	// to all series.
+	Defaults []MatchedPipeline `json:"defaults"`
+	// Mappings are the aggregations of the series from the mapping rules
+	// that match it.
+	Mappings []MatchedPipeline `json:"mappings"`
+	// Rollups are the series produced by the rollup rules that match it.
+	Rollups []MatchedRollup `json:"rollups"`
+}
+
+// MatchedPipeline is a set of aggregations stored with a set of storage
+// policies, no aggregations means the default aggregations for the metric
+// type are used.
+type MatchedPipeline struct {
+	Aggregations    []string `json:"aggregations"`
+	StoragePolicies []string `json:"storagePolicies"`
+}
+
+// MatchedRollup is a series produced by a rollup rule.
+type MatchedRollup struct {
+	Tags      map[string]string `json:"tags"`
+	Pipelines []MatchedPipeline `json:"pipelines"`
+}
+
+type matchOptions struct {
+	defaultStagedMetadatas  []metadata.StagedMetadatas
+	tagEncoder              serialize.TagEncoder
+	matcher                 matcher.Matcher
+	encodedTagsIteratorPool *encodedTagsIteratorPool
+}
+
+func match(
+	tagsMap map[string]string,
+	at time.Time,
+	opts matchOptions,
+) (MatchResult, error) {
+	tags := newTags()
+	for name, value := range tagsMap {
+		tags.append(name, value)
+	}
+	sort.Sort(tags)
+
+	if err := opts.tagEncoder.Encode(tags); err != nil {
+		return MatchResult{}, err
+	}
+	data, ok := opts.tagEncoder.Data()
+	if !ok {
+		return MatchResult{}, fmt.Errorf("unable to encode tags: names=%v, values=%v",
+			tags.names, tags.values)
+	}
+
+	var (
+		id      = opts.encodedTagsIteratorPool.Get()
+		atNanos = at.UnixNano()
+		result  MatchResult
+		err     error
+	)
+	id.Reset(data.Bytes())
+	matchResult := opts.matcher.ForwardMatch(id, atNanos, atNanos+1)
+	id.Close()
+
+	for _, stagedMetadatas := range opts.defaultStagedMetadatas {
+		pipelines, err := matchedPipelines(stagedMetadatas, atNanos)
+		if err != nil {
+			return MatchResult{}, err
+		}
+		result.Defaults = append(result.Defaults, pipelines...)
+	}
+
+	stagedMetadatas := matchResult.ForExistingIDAt(atNanos)
+	if !stagedMetadatas.IsDefault() {
+		result.Mappings, err = matchedPipelines(stagedMetadatas, atNanos)
+		if err != nil {
+			return MatchResult{}, err
+		}
+	}
+
+	numRollups := matchResult.NumNewRollupIDs()
+	for i := 0; i < numRollups; i++ {
+		rollup := matchResult.ForNewRollupIDsAt(i, atNanos)
+		pipelines, err := matchedPipelines(rollup.Metadatas, atNanos)
+		if err != nil {
+			return MatchResult{}, err
+		}
+
+		rollupTags, err := decodeTags(rollup.ID, opts.encodedTagsIteratorPool)
+		if err != nil {
+			return MatchResult{}, err
+		}
+
+		result.Rollups = append(result.Rollups, MatchedRollup{
+			Tags:      rollupTags,
+			Pipelines: pipelines,
+		})
+	}
+
+	return result, nil
+}
+
+func decodeTags(
+	encodedTags []byte,
+	pool *encodedTagsIteratorPool,
+) (map[string]string, error) {
+	it := pool.Get()
+	defer it.Close()
+
+	it.Reset(encodedTags)
+	tags := make(map[string]string, it.NumTags())
+	for it.Next() {
+		name, value := it.Current()
+		tags[string(name)] = string(value)
+	}
+	return tags, it.Err()
+}
+
+// matchedPipelines returns the pipelines of the staged metadata active at a
+// given time.
+func matchedPipelines(
+	stagedMetadatas metadata.StagedMetadatas,
+	atNanos int64,
+) ([]MatchedPipeline, error) {
+	if len(stagedMetadatas) == 0 {
+		return nil, nil
+	}
+
+	// Staged metadatas are sorted by cutover, use the latest that has cut
+	// over or the earliest if none have.
+	active := stagedMetadatas[0]
+	for _, staged := range stagedMetadatas[1:] {
+		if staged.CutoverNanos > atNanos {
+			break
+		}
+		active = staged
+	}
+	if active.Tombstoned {
+		return nil, nil
+	}
+
+	decompressor := aggregation.NewIDDecompressor()
+	pipelines := make([]MatchedPipeline, 0, len(active.Pipelines))
+	for _, pipeline := range active.Pipelines {
+		var aggregations []string
+		if !pipeline.AggregationID.IsDefault() {
+			types, err := decompressor.Decompress(pipeline.AggregationID)
+			if err != nil {
+				return nil, err
+			}
+			for _, aggType := range types {
+				aggregations = append(aggregations, aggType.String())
+			}
+		}
+
+		storagePolicies := make([]string, 0, len(pipeline.StoragePolicies))
+		for _, storagePolicy := range pipeline.StoragePolicies {
+			storagePolicies = append(storagePolicies, storagePolicy.String())
+		}
+
+		pipelines = append(pipelines, MatchedPipeline{
+			Aggregations:    aggregations,
+			StoragePolicies: storagePolicies,
+		})
+	}
+	return pipelines, nil
+}
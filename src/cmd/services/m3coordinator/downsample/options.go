@@ -71,6 +71,7 @@ var (
 	errNoTagDecoderOptions     = errors.New("dynamic downsampling enabled with tag decoder options not set")
 	errNoTagEncoderPoolOptions = errors.New("dynamic downsampling enabled with tag encoder pool options not set")
 	errNoTagDecoderPoolOptions = errors.New("dynamic downsampling enabled with tag decoder pool options not set")
+	errFlushJitterNoResolution = errors.New("flush jitter resolution not set")
 )
 
 // DownsamplerOptions is a set of required downsampler options.
@@ -87,6 +88,84 @@ type DownsamplerOptions struct {
 	TagEncoderPoolOptions   pool.ObjectPoolOptions
 	TagDecoderPoolOptions   pool.ObjectPoolOptions
 	OpenTimeout             time.Duration
+	FlushJitters            FlushJitters
+	GapFills                GapFills
+}
+
+// FlushJitter is the maximum jitter applied to the flushes of aggregations
+// with a given resolution, as a percentage of the resolution, so that many
+// coordinators do not all flush to storage at the start of each interval.
+// The offset aligns the writes of the aggregations to storage to a fixed
+// offset from the start of each interval instead.
+type FlushJitter struct {
+	Resolution       time.Duration
+	MaxJitterPercent float64
+	Offset           time.Duration
+}
+
+// FlushJitters is a set of flush jitters.
+type FlushJitters []FlushJitter
+
+func (j FlushJitters) validate() error {
+	seen := make(map[time.Duration]struct{}, len(j))
+	for _, jitter := range j {
+		if jitter.Resolution <= 0 {
+			return errFlushJitterNoResolution
+		}
+		if jitter.MaxJitterPercent < 0 || jitter.MaxJitterPercent > 1 {
+			return fmt.Errorf("flush jitter for resolution %v has max jitter percent %f, "+
+				"must be between 0 and 1", jitter.Resolution, jitter.MaxJitterPercent)
+		}
+		if jitter.Offset < 0 || jitter.Offset >= jitter.Resolution {
+			return fmt.Errorf("flush jitter for resolution %v has offset %v, "+
+				"must be at least zero and less than the resolution",
+				jitter.Resolution, jitter.Offset)
+		}
+		if _, ok := seen[jitter.Resolution]; ok {
+			return fmt.Errorf("duplicate flush jitter for resolution %v",
+				jitter.Resolution)
+		}
+		seen[jitter.Resolution] = struct{}{}
+	}
+	return nil
+}
+
+// jitterEnabled returns whether any resolution has a max jitter.
+func (j FlushJitters) jitterEnabled() bool {
+	for _, jitter := range j {
+		if jitter.MaxJitterPercent > 0 {
+			return true
+		}
+	}
+	return false
+}
+
+// maxJitterFn returns the max jitter for a flush interval, flushes of
+// resolutions without a jitter are aligned to the start of each interval.
+func (j FlushJitters) maxJitterFn() aggregator.FlushJitterFn {
+	maxJitterPercents := make(map[time.Duration]float64, len(j))
+	for _, jitter := range j {
+		maxJitterPercents[jitter.Resolution] = jitter.MaxJitterPercent
+	}
+	return func(flushInterval time.Duration) time.Duration {
+		percent, ok := maxJitterPercents[flushInterval]
+		if !ok {
+			return 0
+		}
+		return time.Duration(percent * float64(flushInterval))
+	}
+}
+
+// flushOffsetFn returns the offset from the start of each interval at which
+// aggregations of a resolution are written to storage.
+func (j FlushJitters) flushOffsetFn() flushOffsetFn {
+	offsets := make(map[time.Duration]time.Duration, len(j))
+	for _, jitter := range j {
+		offsets[jitter.Resolution] = jitter.Offset
+	}
+	return func(resolution time.Duration) time.Duration {
+		return offsets[resolution]
+	}
 }
 
 // MappingRule is a mapping rule to apply to metrics.
@@ -142,7 +221,10 @@ func (o DownsamplerOptions) validate() error {
 	if o.TagDecoderPoolOptions == nil {
 		return errNoTagDecoderPoolOptions
 	}
-	return nil
+	if err := o.FlushJitters.validate(); err != nil {
+		return err
+	}
+	return o.GapFills.validate()
 }
 
 type agg struct {
@@ -444,12 +526,17 @@ func (o DownsamplerOptions) newAggregatorFlushManagerAndHandler(
 		SetFlushTimesManager(flushTimesManager).
 		SetElectionManager(electionManager).
 		SetJitterEnabled(false)
+	if o.FlushJitters.jitterEnabled() {
+		flushManagerOpts = flushManagerOpts.
+			SetJitterEnabled(true).
+			SetMaxJitterFn(o.FlushJitters.maxJitterFn())
+	}
 	flushManager := aggregator.NewFlushManager(flushManagerOpts)
 
 	flushWorkers := xsync.NewWorkerPool(storageFlushConcurrency)
 	flushWorkers.Init()
 	handler := newDownsamplerFlushHandler(o.Storage, pools.encodedTagsIteratorPool,
-		flushWorkers, instrumentOpts)
+		flushWorkers, o.FlushJitters.flushOffsetFn(), o.GapFills, instrumentOpts)
 
 	return flushManager, handler
 }
@@ -0,0 +1,203 @@
+// Copyright (c) 2018 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package downsample
+
+import (
+	"testing"
+	"time"
+
+	"github.com/m3db/m3/src/query/ts"
+	"github.com/m3db/m3metrics/policy"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestFlushJittersValidate(t *testing.T) {
+	valid := FlushJitters{
+		{Resolution: time.Minute, MaxJitterPercent: 0.5},
+		{Resolution: time.Hour, MaxJitterPercent: 1},
+	}
+	require.NoError(t, valid.validate())
+
+	invalid := []FlushJitters{
+		{{Resolution: 0, MaxJitterPercent: 0.5}},
+		{{Resolution: time.Minute, MaxJitterPercent: -0.1}},
+		{{Resolution: time.Minute, MaxJitterPercent: 1.1}},
+		{{Resolution: time.Minute, Offset: -time.Second}},
+		{{Resolution: time.Minute, Offset: time.Minute}},
+		{
+			{Resolution: time.Minute, MaxJitterPercent: 0.1},
+			{Resolution: time.Minute, MaxJitterPercent: 0.2},
+		},
+	}
+	for _, jitters := range invalid {
+		assert.Error(t, jitters.validate())
+	}
+}
+
+func TestFlushJittersMaxJitterFn(t *testing.T) {
+	fn := FlushJitters{
+		{Resolution: time.Minute, MaxJitterPercent: 0.5},
+	}.maxJitterFn()
+
+	assert.Equal(t, 30*time.Second, fn(time.Minute))
+	assert.Equal(t, time.Duration(0), fn(10*time.Second))
+}
+
+func TestFlushJittersJitterEnabled(t *testing.T) {
+	assert.False(t, FlushJitters{}.jitterEnabled())
+	assert.False(t, FlushJitters{
+		{Resolution: time.Minute, Offset: 10 * time.Second},
+	}.jitterEnabled())
+	assert.True(t, FlushJitters{
+		{Resolution: time.Minute, MaxJitterPercent: 0.5},
+	}.jitterEnabled())
+}
+
+func TestFlushHandlerWaitsForFlushOffset(t *testing.T) {
+	var (
+		start = time.Date(2018, time.May, 1, 12, 0, 0, 0, time.UTC)
+		now   time.Time
+		slept time.Duration
+	)
+	h := &downsamplerFlushHandler{
+		flushOffsetFn: FlushJitters{
+			{Resolution: time.Minute, Offset: 10 * time.Second},
+		}.flushOffsetFn(),
+		nowFn:   func() time.Time { return now },
+		sleepFn: func(d time.Duration) { slept += d },
+	}
+
+	// Flushes before the offset wait until the offset.
+	now = start.Add(time.Second)
+	h.waitForFlushOffset(time.Minute)
+	assert.Equal(t, 9*time.Second, slept)
+
+	// Flushes after the offset are written immediately.
+	slept = 0
+	now = start.Add(20 * time.Second)
+	h.waitForFlushOffset(time.Minute)
+	assert.Equal(t, time.Duration(0), slept)
+
+	// Resolutions without an offset are written immediately.
+	now = start.Add(time.Second)
+	h.waitForFlushOffset(10 * time.Second)
+	assert.Equal(t, time.Duration(0), slept)
+}
+
+func TestGapFillsValidate(t *testing.T) {
+	valid := GapFills{
+		{Resolution: time.Minute, Retention: 24 * time.Hour, Mode: GapFillZero, MaxIntervals: 5},
+		{Resolution: time.Minute, Retention: 48 * time.Hour, Mode: GapFillLastValue, MaxIntervals: 1},
+		{Resolution: time.Hour, Retention: 48 * time.Hour, Mode: GapFillSkip},
+	}
+	require.NoError(t, valid.validate())
+
+	invalid := []GapFills{
+		{{Retention: time.Hour, Mode: GapFillZero, MaxIntervals: 1}},
+		{{Resolution: time.Minute, Mode: GapFillZero, MaxIntervals: 1}},
+		{{Resolution: time.Minute, Retention: time.Hour, Mode: GapFillZero}},
+		{
+			{Resolution: time.Minute, Retention: time.Hour, Mode: GapFillZero, MaxIntervals: 1},
+			{Resolution: time.Minute, Retention: time.Hour, Mode: GapFillSkip},
+		},
+	}
+	for _, fills := range invalid {
+		assert.Error(t, fills.validate())
+	}
+}
+
+func TestParseGapFillMode(t *testing.T) {
+	for _, mode := range ValidGapFillModes() {
+		parsed, err := ParseGapFillMode(mode.String())
+		require.NoError(t, err)
+		assert.Equal(t, mode, parsed)
+	}
+
+	_, err := ParseGapFillMode("")
+	assert.Error(t, err)
+	_, err = ParseGapFillMode("interpolate")
+	assert.Error(t, err)
+}
+
+func TestGapFillerDatapoints(t *testing.T) {
+	var (
+		start    = time.Date(2018, time.May, 1, 12, 0, 0, 0, time.UTC)
+		zero     = policy.MustParseStoragePolicy("1m:1d")
+		last     = policy.MustParseStoragePolicy("1m:2d")
+		skipped  = policy.MustParseStoragePolicy("1m:3d")
+		unfilled = policy.MustParseStoragePolicy("10s:1d")
+	)
+	filler := newGapFiller(GapFills{
+		{Resolution: time.Minute, Retention: 24 * time.Hour, Mode: GapFillZero, MaxIntervals: 2},
+		{Resolution: time.Minute, Retention: 48 * time.Hour, Mode: GapFillLastValue, MaxIntervals: 2},
+		{Resolution: time.Minute, Retention: 72 * time.Hour, Mode: GapFillSkip},
+	})
+	require.NotNil(t, filler)
+	assert.True(t, filler.filled(zero))
+	assert.True(t, filler.filled(last))
+	assert.False(t, filler.filled(skipped))
+	assert.False(t, filler.filled(unfilled))
+
+	at := func(intervals int) int64 {
+		return start.Add(time.Duration(intervals) * time.Minute).UnixNano()
+	}
+	values := func(datapoints ts.Datapoints) map[int64]float64 {
+		result := make(map[int64]float64, len(datapoints))
+		for _, dp := range datapoints {
+			result[dp.Timestamp.UnixNano()] = dp.Value
+		}
+		return result
+	}
+
+	// Consecutive intervals are written as is.
+	assert.Len(t, filler.datapoints(zero, "a", at(0), 3), 1)
+	assert.Len(t, filler.datapoints(zero, "a", at(1), 4), 1)
+
+	// Short gaps are filled with zeros or the last value.
+	assert.Equal(t, map[int64]float64{at(2): 0, at(3): 0, at(4): 5},
+		values(filler.datapoints(zero, "a", at(4), 5)))
+	assert.Len(t, filler.datapoints(last, "a", at(0), 3), 1)
+	assert.Equal(t, map[int64]float64{at(1): 3, at(2): 3, at(3): 6},
+		values(filler.datapoints(last, "a", at(3), 6)))
+
+	// Series are tracked separately.
+	assert.Len(t, filler.datapoints(zero, "b", at(4), 1), 1)
+
+	// Gaps longer than the max intervals are not filled, and neither are
+	// datapoints older than the last.
+	assert.Len(t, filler.datapoints(zero, "a", at(8), 7), 1)
+	assert.Len(t, filler.datapoints(zero, "a", at(5), 7), 1)
+	assert.Equal(t, map[int64]float64{at(9): 0, at(10): 8},
+		values(filler.datapoints(zero, "a", at(10), 8)))
+
+	// Series are no longer tracked once their gaps are too long to fill,
+	// which is checked at most once per expire interval.
+	filler.expire(time.Unix(0, at(7)))
+	assert.Len(t, filler.last, 3)
+	filler.expire(time.Unix(0, at(7)).Add(30 * time.Second))
+	assert.Len(t, filler.last, 3)
+	filler.expire(time.Unix(0, at(8)))
+	assert.Len(t, filler.last, 2)
+
+	assert.Nil(t, newGapFiller(GapFills{{Mode: GapFillSkip}}))
+}
@@ -33,6 +33,7 @@ import (
 	"github.com/m3db/m3/src/dbnode/storage/bootstrap/bootstrapper/commitlog"
 	bfs "github.com/m3db/m3/src/dbnode/storage/bootstrap/bootstrapper/fs"
 	"github.com/m3db/m3/src/dbnode/storage/bootstrap/bootstrapper/peers"
+	"github.com/m3db/m3/src/dbnode/storage/bootstrap/bootstrapper/restore"
 	"github.com/m3db/m3/src/dbnode/storage/bootstrap/bootstrapper/uninitialized"
 	"github.com/m3db/m3/src/dbnode/storage/bootstrap/result"
 	"github.com/m3db/m3/src/dbnode/storage/index"
@@ -112,6 +113,15 @@ func (bsc BootstrapConfiguration) New(
 		SetIndexMutableSegmentAllocator(mutableSegmentAllocator)
 
 	fsOpts := opts.CommitLogOptions().FilesystemOptions()
+	fsbOpts := bfs.NewOptions().
+		SetInstrumentOptions(opts.InstrumentOptions()).
+		SetResultOptions(rsOpts).
+		SetFilesystemOptions(fsOpts).
+		SetPersistManager(opts.PersistManager()).
+		SetBoostrapDataNumProcessors(bsc.fsNumProcessors()).
+		SetDatabaseBlockRetrieverManager(opts.DatabaseBlockRetrieverManager()).
+		SetRuntimeOptionsManager(opts.RuntimeOptionsManager()).
+		SetIdentifierPool(opts.IdentifierPool())
 
 	// Start from the end of the list because the bootstrappers are ordered by precedence in descending order.
 	for i := len(bsc.Bootstrappers) - 1; i >= 0; i-- {
@@ -121,19 +131,23 @@ func (bsc BootstrapConfiguration) New(
 		case bootstrapper.NoOpNoneBootstrapperName:
 			bs = bootstrapper.NewNoOpNoneBootstrapperProvider()
 		case bfs.FileSystemBootstrapperName:
-			fsbOpts := bfs.NewOptions().
-				SetInstrumentOptions(opts.InstrumentOptions()).
-				SetResultOptions(rsOpts).
-				SetFilesystemOptions(fsOpts).
-				SetPersistManager(opts.PersistManager()).
-				SetBoostrapDataNumProcessors(bsc.fsNumProcessors()).
-				SetDatabaseBlockRetrieverManager(opts.DatabaseBlockRetrieverManager()).
-				SetRuntimeOptionsManager(opts.RuntimeOptionsManager()).
-				SetIdentifierPool(opts.IdentifierPool())
 			bs, err = bfs.NewFileSystemBootstrapperProvider(fsbOpts, bs)
 			if err != nil {
 				return nil, err
 			}
+		case restore.RestoreBootstrapperName:
+			backupMgr := opts.BackupManager()
+			if backupMgr == nil {
+				return nil, fmt.Errorf("%s bootstrapper requires backup to be configured",
+					restore.RestoreBootstrapperName)
+			}
+			rOpts := restore.NewOptions().
+				SetFilesystemBootstrapperOptions(fsbOpts).
+				SetBackupManager(backupMgr)
+			bs, err = restore.NewRestoreBootstrapperProvider(rOpts, bs)
+			if err != nil {
+				return nil, err
+			}
 		case commitlog.CommitLogBootstrapperName:
 			cOpts := commitlog.NewOptions().
 				SetResultOptions(rsOpts).
@@ -182,6 +196,7 @@ func (bsc BootstrapConfiguration) New(
 func ValidateBootstrappersOrder(names []string) error {
 	dataFetchingBootstrappers := []string{
 		bfs.FileSystemBootstrapperName,
+		restore.RestoreBootstrapperName,
 		peers.PeersBootstrapperName,
 		commitlog.CommitLogBootstrapperName,
 	}
@@ -192,20 +207,28 @@ func ValidateBootstrappersOrder(names []string) error {
 		bfs.FileSystemBootstrapperName:        []string{
 			// Filesystem bootstrapper must always appear first
 		},
+		restore.RestoreBootstrapperName: []string{
+			// Restore must always appear directly after filesystem
+			bfs.FileSystemBootstrapperName,
+		},
 		peers.PeersBootstrapperName: []string{
 			// Peers must always appear after filesystem
 			bfs.FileSystemBootstrapperName,
+			// Peers may appear after restore
+			restore.RestoreBootstrapperName,
 			// Peers may appear before OR after commitlog
 			commitlog.CommitLogBootstrapperName,
 		},
 		commitlog.CommitLogBootstrapperName: []string{
-			// Commit log bootstrapper may appear after filesystem or peers
+			// Commit log bootstrapper may appear after filesystem, restore or peers
 			bfs.FileSystemBootstrapperName,
+			restore.RestoreBootstrapperName,
 			peers.PeersBootstrapperName,
 		},
 		uninitialized.UninitializedTopologyBootstrapperName: []string{
-			// Unintialized bootstrapper may appear after filesystem or peers or commitlog
+			// Unintialized bootstrapper may appear after filesystem or restore or peers or commitlog
 			bfs.FileSystemBootstrapperName,
+			restore.RestoreBootstrapperName,
 			commitlog.CommitLogBootstrapperName,
 			peers.PeersBootstrapperName,
 		},
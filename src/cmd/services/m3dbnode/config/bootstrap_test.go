@@ -28,6 +28,7 @@ import (
 	"github.com/m3db/m3/src/dbnode/storage/bootstrap/bootstrapper/commitlog"
 	bfs "github.com/m3db/m3/src/dbnode/storage/bootstrap/bootstrapper/fs"
 	"github.com/m3db/m3/src/dbnode/storage/bootstrap/bootstrapper/peers"
+	"github.com/m3db/m3/src/dbnode/storage/bootstrap/bootstrapper/restore"
 
 	"github.com/stretchr/testify/require"
 )
@@ -38,6 +39,7 @@ var (
 	commitLogBs = commitlog.CommitLogBootstrapperName
 	noOpAllBs   = bootstrapper.NoOpAllBootstrapperName
 	noOpNoneBs  = bootstrapper.NoOpNoneBootstrapperName
+	restoreBs   = restore.RestoreBootstrapperName
 )
 
 func TestValidateBootstrappersOrder(t *testing.T) {
@@ -56,8 +58,15 @@ func TestValidateBootstrappersOrder(t *testing.T) {
 		{true, []string{fsBs, commitLogBs}},
 		{true, []string{noOpNoneBs}},
 		{true, []string{noOpAllBs}},
+		{true, []string{fsBs, restoreBs, commitLogBs, peersBs, noOpNoneBs}},
+		{true, []string{fsBs, restoreBs, peersBs}},
+		{true, []string{restoreBs, commitLogBs}},
 		// Do not allow peers to appear before FS
 		{false, []string{peersBs, fsBs, commitLogBs, noOpNoneBs}},
+		// Do not allow restore to appear before FS or after peers or commitlog
+		{false, []string{restoreBs, fsBs}},
+		{false, []string{fsBs, peersBs, restoreBs}},
+		{false, []string{fsBs, commitLogBs, restoreBs}},
 		// Do not allow a non-data fetching bootstrapper twice
 		{false, []string{commitLogBs, noOpAllBs, noOpNoneBs}},
 		// Do not allow multiple bootstrappers to appear
@@ -26,6 +26,9 @@ import "github.com/m3db/m3/src/dbnode/storage/series"
 type CacheConfigurations struct {
 	// Series cache policy.
 	Series *SeriesCacheConfiguration `yaml:"series"`
+
+	// BlockRead is the recently read block cache configuration.
+	BlockRead *BlockReadCacheConfiguration `yaml:"blockRead"`
 }
 
 // SeriesConfiguration returns the series cache configuration or default
@@ -50,3 +53,22 @@ type LRUSeriesCachePolicyConfiguration struct {
 	MaxBlocks         uint `yaml:"maxBlocks" validate:"nonzero"`
 	EventsChannelSize uint `yaml:"eventsChannelSize" validate:"nonzero"`
 }
+
+// BlockReadCacheConfiguration is the configuration for the LRU cache of
+// recently read sealed blocks, sized in bytes of block data per namespace.
+type BlockReadCacheConfiguration struct {
+	// MaxBytes is the default byte budget of each namespace's cache,
+	// zero disables the cache for namespaces not otherwise configured.
+	MaxBytes int64 `yaml:"maxBytes" validate:"min=0"`
+
+	// Namespaces overrides the byte budget for specific namespaces.
+	Namespaces map[string]int64 `yaml:"namespaces"`
+}
+
+// NamespaceMaxBytes returns the byte budget for the given namespace.
+func (c BlockReadCacheConfiguration) NamespaceMaxBytes(namespace string) int64 {
+	if maxBytes, ok := c.Namespaces[namespace]; ok {
+		return maxBytes
+	}
+	return c.MaxBytes
+}
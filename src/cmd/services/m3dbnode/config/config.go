@@ -24,6 +24,7 @@ import (
 	"bytes"
 	"errors"
 	"fmt"
+	"net"
 	"net/url"
 	"path"
 	"strings"
@@ -31,7 +32,16 @@ import (
 
 	coordinatorcfg "github.com/m3db/m3/src/cmd/services/m3query/config"
 	"github.com/m3db/m3/src/dbnode/client"
+	"github.com/m3db/m3/src/dbnode/encryption"
 	"github.com/m3db/m3/src/dbnode/environment"
+	"github.com/m3db/m3/src/dbnode/runtime"
+	"github.com/m3db/m3/src/dbnode/storage/backup"
+	"github.com/m3db/m3/src/dbnode/storage/index/compaction"
+	"github.com/m3db/m3/src/dbnode/storage/quota"
+	"github.com/m3db/m3/src/x/profiling"
+	"github.com/m3db/m3/src/x/ratelimit"
+	"github.com/m3db/m3/src/x/tracing"
+	"github.com/m3db/m3/src/x/xtls"
 	"github.com/m3db/m3x/config/hostid"
 	"github.com/m3db/m3x/instrument"
 	xlog "github.com/m3db/m3x/log"
@@ -46,6 +56,9 @@ const (
 	defaultEtcdListenHost = "http://0.0.0.0"
 	defaultEtcdClientPort = 2379
 	defaultEtcdServerPort = 2380
+
+	defaultDebugRequestsPerMinute = 6
+	defaultDebugBurst             = 2
 )
 
 // Configuration is the top level configuration that includes both a DB
@@ -81,9 +94,24 @@ type DBConfiguration struct {
 	// The HTTP host and port on which to listen for the cluster service.
 	HTTPClusterListenAddress string `yaml:"httpClusterListenAddress" validate:"nonzero"`
 
+	// The host and port on which to listen for the streaming gRPC node
+	// service, the service is disabled if not set.
+	GRPCListenAddress string `yaml:"grpcListenAddress"`
+
+	// The mutual TLS configuration of the streaming gRPC node service, the
+	// service is plaintext if not set. Only the gRPC service is covered, the
+	// tchannel Thrift and HTTP JSON services stay plaintext and so must listen
+	// on loopback addresses when this is set, remote coordinators should use
+	// the nodeGRPC backend instead.
+	GRPCTLS *xtls.Configuration `yaml:"grpcTLS"`
+
 	// The host and port on which to listen for debug endpoints.
 	DebugListenAddress string `yaml:"debugListenAddress"`
 
+	// The debug endpoints configuration, omit this to serve them without
+	// auth.
+	Debug *DebugConfiguration `yaml:"debug"`
+
 	// HostID is the local host ID configuration.
 	HostID hostid.Configuration `yaml:"hostID"`
 
@@ -102,6 +130,35 @@ type DBConfiguration struct {
 	// The tick configuration, omit this to use default settings.
 	Tick *TickConfiguration `yaml:"tick"`
 
+	// The schedule restricting when flushes, cleanups and index compactions
+	// run, omit this to allow them to run at any time.
+	FileOpsSchedule *FileOpsScheduleConfiguration `yaml:"fileOpsSchedule"`
+
+	// The backup configuration for shipping flushed filesets to object
+	// storage, omit this to disable backups.
+	Backup *BackupConfiguration `yaml:"backup"`
+
+	// The replication configuration, if set writes are asynchronously
+	// replicated to a standby cluster.
+	Replication *ReplicationConfiguration `yaml:"replication"`
+
+	// The disk quota configuration, if set the bytes on disk of each
+	// namespace and shard are tracked and namespaces may have disk quotas.
+	DiskQuota *DiskQuotaConfiguration `yaml:"diskQuota"`
+
+	// The encryption at rest configuration, if set the data of filesets of
+	// the listed namespaces and optionally the commit log are encrypted,
+	// index files and persisted index segments are not encrypted.
+	Encryption *encryption.Configuration `yaml:"encryption"`
+
+	// The tracing configuration, if set the write RPCs received by the node
+	// continue the traces of the clients that sent them.
+	Tracing *tracing.Configuration `yaml:"tracing"`
+
+	// The continuous profiling configuration, if set CPU and heap profiles
+	// of the node are periodically uploaded.
+	Profiling *profiling.Configuration `yaml:"profiling"`
+
 	// Bootstrap configuration.
 	Bootstrap BootstrapConfiguration `yaml:"bootstrap"`
 
@@ -140,6 +197,121 @@ type IndexConfiguration struct {
 	// important to prevent index queries from overloading the database entirely
 	// as they are very CPU-intensive (regex and FST matching.)
 	MaxQueryIDsConcurrency int `yaml:"maxQueryIDsConcurrency" validate:"min=0"`
+
+	// PostingsListCache configures the cache of postings lists resolved for
+	// matchers against immutable index segments.
+	PostingsListCache *PostingsListCacheConfiguration `yaml:"postingsListCache"`
+
+	// LazySegmentCache configures the cache of persisted segments loaded on
+	// demand for index blocks that are outside the resident blocks of their
+	// namespace, if not set all index blocks are kept memory-resident.
+	LazySegmentCache *LazySegmentCacheConfiguration `yaml:"lazySegmentCache"`
+
+	// Compaction configures compaction of the immutable segments held by
+	// sealed index blocks.
+	Compaction *IndexCompactionConfiguration `yaml:"compaction"`
+
+	// QueryLimits configures the maximum limits applied to every index query,
+	// queries stop iterating the index once a limit is reached and return
+	// non-exhaustive results.
+	QueryLimits *IndexQueryLimitsConfiguration `yaml:"queryLimits"`
+}
+
+// IndexQueryLimitsConfiguration is the configuration for index query limits,
+// a zero value for any limit means it is unlimited.
+type IndexQueryLimitsConfiguration struct {
+	// MaxSeries is the maximum number of series a query can return.
+	MaxSeries int `yaml:"maxSeries" validate:"min=0"`
+
+	// MaxDocs is the maximum number of index documents a query can match.
+	MaxDocs int `yaml:"maxDocs" validate:"min=0"`
+
+	// MaxBytes is the maximum number of ID and tag bytes a query can return.
+	MaxBytes int `yaml:"maxBytes" validate:"min=0"`
+}
+
+// PostingsListCacheConfiguration is the configuration for the index postings
+// list cache.
+type PostingsListCacheConfiguration struct {
+	// Size is the maximum number of postings lists held by the cache.
+	Size int `yaml:"size" validate:"min=1"`
+
+	// Warm configures recording the hottest patterns of the cache at
+	// shutdown and warming the cache with them once bootstrapped, if not set
+	// the cache starts cold after a restart.
+	Warm *PostingsListCacheWarmConfiguration `yaml:"warm"`
+}
+
+// PostingsListCacheWarmConfiguration is the configuration for warming the
+// index postings list cache after a restart.
+type PostingsListCacheWarmConfiguration struct {
+	// Size is the maximum number of patterns recorded, defaults to the size
+	// of the cache.
+	Size int `yaml:"size" validate:"min=0"`
+}
+
+// LazySegmentCacheConfiguration is the configuration for the index lazy
+// segment cache.
+type LazySegmentCacheConfiguration struct {
+	// Size is the maximum number of non-resident segments loaded at once.
+	Size int `yaml:"size" validate:"min=1"`
+}
+
+// IndexCompactionConfiguration is the configuration for index segment
+// compaction.
+type IndexCompactionConfiguration struct {
+	// Concurrency is the maximum number of compactions that can run at once.
+	Concurrency int `yaml:"concurrency" validate:"min=0"`
+
+	// BackgroundEnabled enables compactions during each index tick, when
+	// disabled compactions only run when triggered via the admin API.
+	BackgroundEnabled bool `yaml:"backgroundEnabled"`
+
+	// MaxDocsPerSecond throttles the rate at which documents are compacted,
+	// zero disables throttling.
+	MaxDocsPerSecond int `yaml:"maxDocsPerSecond" validate:"min=0"`
+
+	// Levels are the segment size tiers to compact together, if not set the
+	// default levels are used.
+	Levels []IndexCompactionLevelConfiguration `yaml:"levels"`
+}
+
+// IndexCompactionLevelConfiguration is the configuration for a single
+// compaction level.
+type IndexCompactionLevelConfiguration struct {
+	// MinSizeInclusive is the minimum size of segments in the level.
+	MinSizeInclusive int64 `yaml:"minSizeInclusive" validate:"min=0"`
+
+	// MaxSizeExclusive is the size at which segments no longer belong to
+	// the level.
+	MaxSizeExclusive int64 `yaml:"maxSizeExclusive" validate:"min=1"`
+}
+
+// NewManagerOptions returns the compaction manager options for the
+// configuration.
+func (c IndexCompactionConfiguration) NewManagerOptions(
+	iopts instrument.Options,
+) compaction.ManagerOptions {
+	plannerOpts := compaction.DefaultOptions
+	if len(c.Levels) > 0 {
+		levels := make([]compaction.Level, 0, len(c.Levels))
+		for _, level := range c.Levels {
+			levels = append(levels, compaction.Level{
+				MinSizeInclusive: level.MinSizeInclusive,
+				MaxSizeExclusive: level.MaxSizeExclusive,
+			})
+		}
+		plannerOpts.Levels = levels
+	}
+	return compaction.ManagerOptions{
+		Concurrency:       c.Concurrency,
+		BackgroundEnabled: c.BackgroundEnabled,
+		PlannerOptions:    plannerOpts,
+		CompactorOptions: compaction.CompactorOptions{
+			MaxDocsPerSecond: c.MaxDocsPerSecond,
+		},
+		InstrumentOptions: iopts,
+	}
 }
 
 // TickConfiguration is the tick configuration for background processing of
@@ -160,6 +332,187 @@ type TickConfiguration struct {
 	MinimumInterval time.Duration `yaml:"minimumInterval"`
 }
 
+// FileOpsScheduleConfiguration is the configuration restricting heavyweight
+// file operations to daily windows, protecting query latency during peak hours.
+type FileOpsScheduleConfiguration struct {
+	// Windows are the daily windows, in UTC, file operations may run in.
+	Windows []FileOpsWindowConfiguration `yaml:"windows"`
+
+	// MaxDeferral is the longest flushes and cleanups may be deferred waiting
+	// for a window before they are run regardless, defaults to 12 hours.
+	MaxDeferral time.Duration `yaml:"maxDeferral" validate:"min=0"`
+
+	// ThroughputLimitMbps caps the disk throughput of data and index flushes
+	// in megabits per second, zero leaves the persist rate limit unchanged.
+	ThroughputLimitMbps float64 `yaml:"throughputLimitMbps" validate:"min=0"`
+}
+
+// FileOpsWindowConfiguration is a daily window specified as offsets from
+// midnight UTC, e.g. start of 2h and end of 6h allows 02:00 to 06:00 UTC.
+type FileOpsWindowConfiguration struct {
+	Start time.Duration `yaml:"start"`
+	End   time.Duration `yaml:"end"`
+}
+
+// Schedule returns the runtime file ops schedule for the configuration.
+func (c FileOpsScheduleConfiguration) Schedule() runtime.FileOpsSchedule {
+	windows := make([]runtime.FileOpsWindow, 0, len(c.Windows))
+	for _, w := range c.Windows {
+		windows = append(windows, runtime.FileOpsWindow{Start: w.Start, End: w.End})
+	}
+	return runtime.FileOpsSchedule{
+		Windows:     windows,
+		MaxDeferral: c.MaxDeferral,
+	}
+}
+
+// BackupConfiguration is the configuration for backing up flushed data and
+// index filesets, exactly one of a directory or S3 bucket must be set.
+type BackupConfiguration struct {
+	// KeyPrefix is prepended to all object keys, defaults to the host ID.
+	KeyPrefix string `yaml:"keyPrefix"`
+
+	// RestoreKeyPrefixes are the key prefixes searched in order by the
+	// restore bootstrapper, defaults to the key prefix. List the host IDs of
+	// the replicas of a node to restore shards backed up by any of them.
+	RestoreKeyPrefixes []string `yaml:"restoreKeyPrefixes"`
+
+	// Directory backs up to a directory, such as a mounted network volume.
+	Directory string `yaml:"directory"`
+
+	// S3 backs up to an S3 compatible bucket, Google Cloud Storage buckets
+	// may be used with HMAC interoperability keys.
+	S3 *S3BackupConfiguration `yaml:"s3"`
+}
+
+// S3BackupConfiguration is the configuration for an S3 compatible bucket.
+type S3BackupConfiguration struct {
+	// Endpoint defaults to the AWS S3 endpoint of the region, set it to
+	// https://storage.googleapis.com for Google Cloud Storage.
+	Endpoint string `yaml:"endpoint"`
+
+	// Region of the bucket, use "auto" for Google Cloud Storage.
+	Region string `yaml:"region" validate:"nonzero"`
+
+	// Bucket to write backups to.
+	Bucket string `yaml:"bucket" validate:"nonzero"`
+
+	// AccessKeyID used to sign requests.
+	AccessKeyID string `yaml:"accessKeyID" validate:"nonzero"`
+
+	// SecretAccessKey used to sign requests.
+	SecretAccessKey string `yaml:"secretAccessKey" validate:"nonzero"`
+}
+
+// NewObjectStore returns the object store backups are written to.
+func (c BackupConfiguration) NewObjectStore() (backup.ObjectStore, error) {
+	switch {
+	case c.Directory != "" && c.S3 != nil:
+		return nil, errors.New("backup must specify only one of directory or s3")
+	case c.Directory != "":
+		return backup.NewDirectoryObjectStore(c.Directory), nil
+	case c.S3 != nil:
+		return backup.NewS3ObjectStore(backup.S3Options{
+			Endpoint:        c.S3.Endpoint,
+			Region:          c.S3.Region,
+			Bucket:          c.S3.Bucket,
+			AccessKeyID:     c.S3.AccessKeyID,
+			SecretAccessKey: c.S3.SecretAccessKey,
+		})
+	}
+	return nil, errors.New("backup must specify one of directory or s3")
+}
+
+// ReplicationConfiguration is the configuration for asynchronously
+// replicating writes to a standby cluster.
+type ReplicationConfiguration struct {
+	// Client is the client configuration of the standby cluster.
+	Client client.Configuration `yaml:"client"`
+
+	// Namespaces are the namespaces replicated at startup, namespaces can be
+	// enabled and disabled at runtime with the admin API.
+	Namespaces []string `yaml:"namespaces"`
+
+	// QueueSize is the number of writes that can be queued for replication,
+	// once full writes are dropped and caught up from filesets once flushed.
+	QueueSize int `yaml:"queueSize" validate:"min=0"`
+
+	// Concurrency is the number of concurrent writes to the standby cluster.
+	Concurrency int `yaml:"concurrency" validate:"min=0"`
+}
+
+// DebugConfiguration is the configuration of the debug endpoints.
+type DebugConfiguration struct {
+	// Tokens are the bearer tokens of callers allowed to use the debug
+	// endpoints.
+	Tokens []string `yaml:"tokens"`
+
+	// AllowUnauthenticated serves the debug endpoints to callers without a
+	// token when no tokens are set, defaults to true.
+	AllowUnauthenticated *bool `yaml:"allowUnauthenticated"`
+
+	// RequestsPerMinute is the rate of requests to all the debug endpoints
+	// combined, defaults to 6.
+	RequestsPerMinute int `yaml:"requestsPerMinute" validate:"min=0"`
+
+	// Burst is the number of requests served at once before the rate
+	// applies, defaults to 2.
+	Burst int `yaml:"burst" validate:"min=0"`
+}
+
+// AllowUnauthenticatedOrDefault returns whether the debug endpoints are
+// served to callers without a token when no tokens are set.
+func (c DebugConfiguration) AllowUnauthenticatedOrDefault() bool {
+	if c.AllowUnauthenticated == nil {
+		return true
+	}
+	return *c.AllowUnauthenticated
+}
+
+// NewRateLimiter returns the rate limiter of the debug endpoints.
+func (c DebugConfiguration) NewRateLimiter() *ratelimit.TokenBucket {
+	perMinute := c.RequestsPerMinute
+	if perMinute <= 0 {
+		perMinute = defaultDebugRequestsPerMinute
+	}
+	burst := c.Burst
+	if burst <= 0 {
+		burst = defaultDebugBurst
+	}
+	return ratelimit.NewTokenBucket(time.Minute/time.Duration(perMinute), burst)
+}
+
+// DiskQuotaConfiguration is the configuration for tracking disk usage and
+// enforcing disk quotas per namespace.
+type DiskQuotaConfiguration struct {
+	// Namespaces are the disk quotas by namespace, quotas can also be set
+	// at runtime with the admin API.
+	Namespaces map[string]NamespaceDiskQuotaConfiguration `yaml:"namespaces"`
+}
+
+// NamespaceDiskQuotaConfiguration is the disk quota of a namespace, a zero
+// limit is disabled.
+type NamespaceDiskQuotaConfiguration struct {
+	// SoftLimitBytes is the disk usage above which the oldest flushed blocks
+	// are expired before the end of their retention.
+	SoftLimitBytes int64 `yaml:"softLimitBytes" validate:"min=0"`
+
+	// HardLimitBytes is the disk usage above which writes are rejected.
+	HardLimitBytes int64 `yaml:"hardLimitBytes" validate:"min=0"`
+}
+
+// Quotas returns the disk quotas by namespace.
+func (c DiskQuotaConfiguration) Quotas() map[string]quota.Quota {
+	quotas := make(map[string]quota.Quota, len(c.Namespaces))
+	for namespace, cfg := range c.Namespaces {
+		quotas[namespace] = quota.Quota{
+			SoftLimitBytes: cfg.SoftLimitBytes,
+			HardLimitBytes: cfg.HardLimitBytes,
+		}
+	}
+	return quotas
+}
+
 // BlockRetrievePolicy is the block retrieve policy.
 type BlockRetrievePolicy struct {
 	// FetchConcurrency is the concurrency to fetch blocks from disk. For
@@ -180,8 +533,28 @@ type CommitLogPolicy struct {
 
 	// The commit log block size.
 	BlockSize time.Duration `yaml:"blockSize" validate:"nonzero"`
+
+	// The compression applied to commit log chunks, defaults to none.
+	Compression CommitLogCompression `yaml:"compression"`
+
+	// The maximum amount of time flushed commit log chunks wait to be fsynced,
+	// if neither this or fsyncEveryBytes is set chunks are not fsynced.
+	FsyncEvery time.Duration `yaml:"fsyncEvery"`
+
+	// The maximum number of flushed commit log bytes that wait to be fsynced.
+	FsyncEveryBytes int64 `yaml:"fsyncEveryBytes"`
 }
 
+// CommitLogCompression is a type of commit log chunk compression.
+type CommitLogCompression string
+
+const (
+	// CommitLogCompressionNone writes commit log chunks uncompressed.
+	CommitLogCompressionNone CommitLogCompression = "none"
+	// CommitLogCompressionSnappy compresses commit log chunks with snappy.
+	CommitLogCompressionSnappy CommitLogCompression = "snappy"
+)
+
 // CalculationType is a type of configuration parameter.
 type CalculationType string
 
@@ -220,6 +593,44 @@ type RepairPolicy struct {
 
 	// The repair check interval.
 	CheckInterval time.Duration `yaml:"checkInterval" validate:"nonzero"`
+
+	// The depth of the hash trees used to find the series that differ
+	// between replicas, zero disables hash trees.
+	HashTreeDepth *int `yaml:"hashTreeDepth"`
+}
+
+// ValidateGRPCTLS returns an error if the gRPC node service is configured
+// with mutual TLS while any of the plaintext Thrift services listens on an
+// address other than loopback, which would leave an unencrypted path to the
+// node open.
+func (c DBConfiguration) ValidateGRPCTLS() error {
+	if c.GRPCTLS == nil {
+		return nil
+	}
+	for _, addr := range []string{
+		c.ListenAddress,
+		c.ClusterListenAddress,
+		c.HTTPNodeListenAddress,
+		c.HTTPClusterListenAddress,
+	} {
+		if !isLoopbackAddress(addr) {
+			return fmt.Errorf("grpcTLS is set but thrift listen address %s "+
+				"is not a loopback address", addr)
+		}
+	}
+	return nil
+}
+
+func isLoopbackAddress(addr string) bool {
+	host, _, err := net.SplitHostPort(addr)
+	if err != nil {
+		return false
+	}
+	if host == "localhost" {
+		return true
+	}
+	ip := net.ParseIP(host)
+	return ip != nil && ip.IsLoopback()
 }
 
 // HashingConfiguration is the configuration for hashing.
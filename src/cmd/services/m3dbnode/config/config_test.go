@@ -27,6 +27,7 @@ import (
 
 	"github.com/m3db/m3/src/dbnode/environment"
 	xtest "github.com/m3db/m3/src/dbnode/x/test"
+	"github.com/m3db/m3/src/x/xtls"
 	xconfig "github.com/m3db/m3x/config"
 
 	"github.com/stretchr/testify/assert"
@@ -301,6 +302,10 @@ db:
 	expected := `db:
   index:
     maxQueryIDsConcurrency: 0
+    postingsListCache: null
+    lazySegmentCache: null
+    compaction: null
+    queryLimits: null
   logging:
     file: /var/log/m3dbnode.log
     level: info
@@ -322,7 +327,10 @@ db:
   clusterListenAddress: 0.0.0.0:9001
   httpNodeListenAddress: 0.0.0.0:9002
   httpClusterListenAddress: 0.0.0.0:9003
+  grpcListenAddress: ""
+  grpcTLS: null
   debugListenAddress: 0.0.0.0:9004
+  debug: null
   hostID:
     resolver: config
     value: host1
@@ -358,10 +366,18 @@ db:
     backgroundHealthCheckFailThrottleFactor: 0.5
     hashing:
       seed: 42
+    peerStreaming: null
   gcPercentage: 100
   writeNewSeriesLimitPerSecond: 1048576
   writeNewSeriesBackoffDuration: 2ms
   tick: null
+  fileOpsSchedule: null
+  backup: null
+  replication: null
+  diskQuota: null
+  encryption: null
+  tracing: null
+  profiling: null
   bootstrap:
     bootstrappers:
     - filesystem
@@ -374,6 +390,7 @@ db:
   blockRetrieve: null
   cache:
     series: null
+    blockRead: null
   fs:
     filePathPrefix: /var/lib/m3db
     writeBufferSize: 65536
@@ -385,6 +402,7 @@ db:
     newFileMode: null
     newDirectoryMode: null
     mmap: null
+    seekRead: null
   commitlog:
     flushMaxBytes: 524288
     flushEvery: 1s
@@ -392,6 +410,9 @@ db:
       calculationType: fixed
       size: 2097152
     blockSize: 10m0s
+    compression: ""
+    fsyncEvery: 0s
+    fsyncEveryBytes: 0
   repair:
     enabled: false
     interval: 2h0m0s
@@ -399,6 +420,7 @@ db:
     jitter: 1h0m0s
     throttle: 2m0s
     checkInterval: 1m0s
+    hashTreeDepth: null
   pooling:
     blockAllocSize: 16
     type: simple
@@ -524,6 +546,7 @@ db:
       size: 8192
       lowWatermark: 0.01
       highWatermark: 0.02
+    seriesMetadataArena: null
   config:
     service:
       zone: embedded
@@ -682,3 +705,27 @@ func TestIsSeedNode(t *testing.T) {
 	res = IsSeedNode(seedNodes, "host4")
 	assert.Equal(t, false, res)
 }
+
+func TestDBConfigurationValidateGRPCTLS(t *testing.T) {
+	cfg := DBConfiguration{
+		ListenAddress:            "0.0.0.0:9000",
+		ClusterListenAddress:     "0.0.0.0:9001",
+		HTTPNodeListenAddress:    "0.0.0.0:9002",
+		HTTPClusterListenAddress: "0.0.0.0:9003",
+	}
+	require.NoError(t, cfg.ValidateGRPCTLS())
+
+	cfg.GRPCTLS = &xtls.Configuration{}
+	require.Error(t, cfg.ValidateGRPCTLS())
+
+	cfg.ListenAddress = "127.0.0.1:9000"
+	cfg.ClusterListenAddress = "localhost:9001"
+	cfg.HTTPNodeListenAddress = "[::1]:9002"
+	require.Error(t, cfg.ValidateGRPCTLS())
+
+	cfg.HTTPClusterListenAddress = "127.0.0.1:9003"
+	require.NoError(t, cfg.ValidateGRPCTLS())
+
+	cfg.ListenAddress = ":9000"
+	require.Error(t, cfg.ValidateGRPCTLS())
+}
@@ -23,6 +23,8 @@ package config
 import (
 	"fmt"
 	"os"
+
+	"github.com/m3db/m3/src/dbnode/persist/fs"
 )
 
 const (
@@ -75,6 +77,26 @@ type FilesystemConfiguration struct {
 
 	// Mmap is the mmap options which features are primarily platform dependent
 	Mmap *MmapConfiguration `yaml:"mmap"`
+
+	// SeekRead is the strategy used to read fileset data when serving reads
+	SeekRead *SeekReadConfiguration `yaml:"seekRead"`
+}
+
+// SeekReadConfiguration is the fileset seek read strategy configuration.
+type SeekReadConfiguration struct {
+	// Strategy is the default read strategy for all namespaces
+	Strategy fs.ReadStrategy `yaml:"strategy"`
+
+	// Namespaces overrides the read strategy for specific namespaces
+	Namespaces map[string]fs.ReadStrategy `yaml:"namespaces"`
+}
+
+// NamespaceStrategy returns the read strategy for the given namespace.
+func (c SeekReadConfiguration) NamespaceStrategy(namespace string) fs.ReadStrategy {
+	if strategy, ok := c.Namespaces[namespace]; ok {
+		return strategy
+	}
+	return c.Strategy
 }
 
 // MmapConfiguration is the mmap configuration.
@@ -102,6 +102,16 @@ type PoolingPolicy struct {
 
 	// The policy for the TagDecoderPool
 	TagDecoderPool PoolPolicy `yaml:"tagDecoderPool"`
+
+	// The policy for the per shard arenas that series IDs and tags are
+	// allocated from, if not set they are allocated individually
+	SeriesMetadataArena *ArenaPolicy `yaml:"seriesMetadataArena"`
+}
+
+// ArenaPolicy specifies an arena policy.
+type ArenaPolicy struct {
+	// The size of the slabs the arena allocates from, if zero the default
+	SlabSize int `yaml:"slabSize" validate:"min=0"`
 }
 
 // PoolPolicy specifies a single pool policy.
@@ -23,7 +23,22 @@ package config
 import (
 	"time"
 
+	"github.com/m3db/m3/src/cmd/services/m3coordinator/downsample"
+	"github.com/m3db/m3/src/query/api/v1/audit"
+	"github.com/m3db/m3/src/query/api/v1/auth"
+	"github.com/m3db/m3/src/query/api/v1/handler/debug"
+	"github.com/m3db/m3/src/query/api/v1/handler/prometheus/remote"
+	"github.com/m3db/m3/src/query/api/v1/handler/querylog"
+	"github.com/m3db/m3/src/query/api/v1/handler/querymetrics"
+	"github.com/m3db/m3/src/query/models"
+	"github.com/m3db/m3/src/query/storage/access"
+	"github.com/m3db/m3/src/query/storage/deadletter"
 	"github.com/m3db/m3/src/query/storage/local"
+	"github.com/m3db/m3/src/query/storage/sampling"
+	"github.com/m3db/m3/src/query/storage/tenant"
+	"github.com/m3db/m3/src/x/profiling"
+	"github.com/m3db/m3/src/x/tracing"
+	"github.com/m3db/m3/src/x/xtls"
 	etcdclient "github.com/m3db/m3cluster/client/etcd"
 	"github.com/m3db/m3x/config/listenaddress"
 	"github.com/m3db/m3x/instrument"
@@ -37,6 +52,9 @@ const (
 	GRPCStorageType BackendStorageType = "grpc"
 	// M3DBStorageType is for m3db backend
 	M3DBStorageType BackendStorageType = "m3db"
+	// NodeGRPCStorageType is for backends of a namespace of a DB node read
+	// and written through the node gRPC service
+	NodeGRPCStorageType BackendStorageType = "nodeGRPC"
 )
 
 // Configuration is the configuration for the query service.
@@ -56,15 +74,125 @@ type Configuration struct {
 	// endpoints (optional).
 	ClusterManagement *ClusterManagementConfiguration `yaml:"clusterManagement"`
 
+	// DatabaseInit is the configuration for initializing the database
+	// placement and namespaces on first start (optional).
+	DatabaseInit *DatabaseInitConfiguration `yaml:"databaseInit"`
+
+	// AggregatedNamespaceProvisioning for creating aggregated namespaces
+	// on demand for storage policies that have no namespace (optional).
+	AggregatedNamespaceProvisioning *AggregatedNamespaceProvisioningConfiguration `yaml:"aggregatedNamespaceProvisioning"`
+
+	// Downsample is the configuration for the downsampler used with
+	// aggregated cluster namespaces.
+	Downsample DownsampleConfiguration `yaml:"downsample"`
+
+	// Mirror is the configuration for mirroring all writes to a second set
+	// of DB clusters, such as during a cluster migration (optional).
+	Mirror *MirrorConfiguration `yaml:"mirror"`
+
+	// ReadShadow is the configuration for sending a sampled fraction of
+	// queries to a second set of DB clusters and comparing the results
+	// (optional).
+	ReadShadow *ReadShadowConfiguration `yaml:"readShadow"`
+
+	// Canary is the configuration for writing heartbeat series to the
+	// cluster namespaces and querying them back to measure the latency
+	// from ingest to being queryable (optional).
+	Canary *CanaryConfiguration `yaml:"canary"`
+
+	// ShardKeys are the sets of label names of metrics that are the complete
+	// label sets of their series, queries matching the metric name and every
+	// label of its shard key by equality fetch the series they pin by ID from
+	// the nodes owning its shard only (optional).
+	ShardKeys []local.ShardKeyConfiguration `yaml:"shardKeys"`
+
+	// FetchPageSize fetches the series of queries from the DB nodes a page
+	// of at most FetchPageSize series at a time, each node returns every
+	// series of a query in a single response if not set (optional).
+	FetchPageSize int `yaml:"fetchPageSize" validate:"min=0"`
+
 	// ListenAddress is the server listen address.
 	ListenAddress *listenaddress.Configuration `yaml:"listenAddress" validate:"nonzero"`
 
+	// ListenTLS is the TLS configuration of the HTTP server, the server is
+	// plaintext if not set.
+	ListenTLS *xtls.Configuration `yaml:"listenTLS"`
+
+	// Auth is the authentication and authorization configuration of the
+	// HTTP APIs, requests are not authenticated if not set.
+	Auth *auth.Configuration `yaml:"auth"`
+
+	// Audit is the audit log configuration of the mutating admin calls to
+	// the HTTP APIs, calls are not audited if not set.
+	Audit *audit.Configuration `yaml:"audit"`
+
+	// Tenancy is the multi-tenancy configuration, writes and queries are
+	// scoped to the tenant of each request if set.
+	Tenancy *tenant.Configuration `yaml:"tenancy"`
+
+	// NamespaceAccess restricts which tenants and token identities may read
+	// and write each namespace and which labels their queries may touch, all
+	// namespaces and labels are unrestricted if not set. Requests without a
+	// tenant or identity, such as those of remote coordinators over RPC, are
+	// denied access to restricted namespaces.
+	NamespaceAccess *access.Configuration `yaml:"namespaceAccess"`
+
+	// RemoteWrite restricts the sources of Prometheus remote write requests
+	// and optionally requires them to be signed, all requests are accepted
+	// if not set.
+	RemoteWrite *remote.WriteGuardConfiguration `yaml:"remoteWrite"`
+
+	// WriteTimeWindow rejects or clamps the samples of Prometheus remote
+	// write requests outside of a window of time around now, per ingest
+	// source. Samples are only rejected by the database if not set.
+	WriteTimeWindow *remote.WriteTimeWindowConfiguration `yaml:"writeTimeWindow"`
+
+	// Sampling keeps only a fraction of the samples or series written for
+	// high volume, low value metrics, all writes are stored if not set.
+	Sampling *sampling.Configuration `yaml:"sampling"`
+
+	// DeadLetter spills writes that fail after exhausting their retries to
+	// local files, which can be replayed once the database recovers. Failed
+	// writes are only returned to clients if not set.
+	DeadLetter *deadletter.Configuration `yaml:"deadLetter"`
+
+	// Debug is the configuration of the profiling and debug bundle
+	// endpoints, which require the admin role and are rate limited. They
+	// are only served without auth if explicitly allowed.
+	Debug *debug.Configuration `yaml:"debug"`
+
+	// QueryMetrics is the configuration of the per query latency, bytes and
+	// series histograms and their trace exemplars, which are recorded with
+	// the default configuration if not set.
+	QueryMetrics *querymetrics.Configuration `yaml:"queryMetrics"`
+
+	// QueryLog is the configuration of the sampled log of queries with
+	// their fingerprint, tenant, latency, result sizes and limits hit,
+	// queries are not logged if not set.
+	QueryLog *querylog.Configuration `yaml:"queryLog"`
+
+	// Tracing is the configuration of the exporter of write path traces,
+	// requests are not traced if not set.
+	Tracing *tracing.Configuration `yaml:"tracing"`
+
+	// Profiling is the continuous profiling configuration, profiles are
+	// not captured if not set.
+	Profiling *profiling.Configuration `yaml:"profiling"`
+
+	// Lookback is the default duration the last value of each series is
+	// carried forward in queries, by metric type. Values are carried forward
+	// to the next datapoint regardless of their age if not set.
+	Lookback *models.LookbackConfiguration `yaml:"lookback"`
+
 	// RPC is the RPC configuration.
 	RPC *RPCConfiguration `yaml:"rpc"`
 
-	// Backend is the backend store for query service. We currently support grpc and m3db (default).
+	// Backend is the backend store for query service. We currently support grpc, nodeGRPC and m3db (default).
 	Backend BackendStorageType `yaml:"backend"`
 
+	// NodeGRPC is the configuration of the DB node of the nodeGRPC backend.
+	NodeGRPC *NodeGRPCConfiguration `yaml:"nodeGRPC"`
+
 	// DecompressWorkerPoolCount is the number of decompression worker pools.
 	DecompressWorkerPoolCount int `yaml:"workerPoolCount"`
 
@@ -73,6 +201,24 @@ type Configuration struct {
 	DecompressWorkerPoolSize int `yaml:"workerPoolSize"`
 }
 
+// NodeGRPCConfiguration is the configuration of a DB node read and written
+// through the node gRPC service.
+type NodeGRPCConfiguration struct {
+	// Address is the address of the node gRPC service.
+	Address string `yaml:"address" validate:"nonzero"`
+
+	// Namespace is the namespace of the node to read and write.
+	Namespace string `yaml:"namespace" validate:"nonzero"`
+
+	// BatchSize is the number of series or datapoints the node sends in
+	// each streamed response, the node default is used if not set.
+	BatchSize int `yaml:"batchSize" validate:"min=0"`
+
+	// TLS is the mutual TLS configuration of the client, the connection is
+	// plaintext if not set.
+	TLS *xtls.Configuration `yaml:"tls"`
+}
+
 // LocalConfiguration is the local embedded configuration if running
 // coordinator embedded in the DB.
 type LocalConfiguration struct {
@@ -88,6 +234,196 @@ type LocalConfiguration struct {
 type ClusterManagementConfiguration struct {
 	// Etcd is the client configuration for etcd.
 	Etcd etcdclient.Configuration `yaml:"etcd"`
+
+	// RebalanceParallelism is the max number of shards initializing at once
+	// when rebalancing a placement, defaults to one if not set.
+	RebalanceParallelism int `yaml:"rebalanceParallelism" validate:"min=0"`
+
+	// FailureDomains are the failure domains encoded in instance isolation
+	// groups that placement changes must spread replicas across (optional).
+	FailureDomains *FailureDomainsConfiguration `yaml:"failureDomains"`
+}
+
+// FailureDomainsConfiguration is configuration for a failure domain
+// hierarchy encoded in placement instance isolation groups, such as
+// "us-east/us-east-1a/rack1" for a region, zone and rack hierarchy.
+type FailureDomainsConfiguration struct {
+	// Separator separates the levels of an isolation group, defaults to "/".
+	Separator string `yaml:"separator"`
+
+	// Levels are the failure domain levels from the outermost to the
+	// innermost, such as region, zone and rack.
+	Levels []FailureDomainLevelConfiguration `yaml:"levels" validate:"nonzero"`
+}
+
+// FailureDomainLevelConfiguration is configuration for a failure domain level.
+type FailureDomainLevelConfiguration struct {
+	// Name is the name of the level, such as "zone".
+	Name string `yaml:"name" validate:"nonzero"`
+
+	// MaxReplicas is the max number of replicas of a shard that a single
+	// domain of the level may hold, unlimited if not set.
+	MaxReplicas int `yaml:"maxReplicas" validate:"min=0"`
+}
+
+// DatabaseInitConfiguration is configuration for initializing the database on
+// first start: the namespaces of the configured clusters are created if they
+// do not exist and a placement of the hosts is created if there is none,
+// requires cluster management.
+type DatabaseInitConfiguration struct {
+	// Type is the type of database, either "local" for the embedded database
+	// or "cluster" for the configured hosts.
+	Type string `yaml:"type" validate:"nonzero"`
+
+	// ReplicationFactor is the replication factor of a cluster database,
+	// defaults to three if not set.
+	ReplicationFactor int `yaml:"replicationFactor" validate:"min=0"`
+
+	// Hosts are the hosts of a cluster database.
+	Hosts []DatabaseInitHostConfiguration `yaml:"hosts"`
+}
+
+// DatabaseInitHostConfiguration is configuration for a host of a cluster
+// database.
+type DatabaseInitHostConfiguration struct {
+	// ID is the unique ID of the host.
+	ID string `yaml:"id" validate:"nonzero"`
+
+	// Address is the IP address or hostname used to connect to the host.
+	Address string `yaml:"address" validate:"nonzero"`
+
+	// Port is the port of the host's node RPC listen address.
+	Port uint32 `yaml:"port" validate:"nonzero"`
+
+	// IsolationGroup is the isolation group of the host, defaults to
+	// "local" if not set.
+	IsolationGroup string `yaml:"isolationGroup"`
+
+	// Zone is the zone of the host, defaults to "local" if not set.
+	Zone string `yaml:"zone"`
+
+	// Weight is the weight of the host, defaults to one if not set.
+	Weight uint32 `yaml:"weight"`
+}
+
+// AggregatedNamespaceProvisioningConfiguration is configuration for creating
+// aggregated namespaces when a rollup or mapping rule targets a retention and
+// resolution that has no namespace, requires cluster management.
+type AggregatedNamespaceProvisioningConfiguration struct {
+	// Enabled determines if aggregated namespaces are provisioned.
+	Enabled bool `yaml:"enabled"`
+
+	// NamespacePrefix is the prefix of provisioned namespace names, defaults
+	// to "aggregated" if not set.
+	NamespacePrefix string `yaml:"namespacePrefix"`
+}
+
+// DownsampleConfiguration is configuration for the downsampler.
+type DownsampleConfiguration struct {
+	// FlushJitters are the maximum flush jitters and offsets for each
+	// resolution, aggregations of resolutions without a flush jitter are
+	// flushed at the start of each interval.
+	FlushJitters []FlushJitterConfiguration `yaml:"flushJitters"`
+
+	// GapFills are how the missing intervals of the aggregations of each
+	// storage policy are written, missing intervals are skipped for storage
+	// policies without a gap fill.
+	GapFills []GapFillConfiguration `yaml:"gapFills"`
+}
+
+// FlushJitterConfiguration is the maximum flush jitter and the flush
+// alignment for aggregations of a resolution.
+type FlushJitterConfiguration struct {
+	// Resolution is the resolution of the aggregations to jitter flushes of.
+	Resolution time.Duration `yaml:"resolution" validate:"nonzero"`
+
+	// MaxJitterPercent is the maximum jitter as a fraction of the resolution.
+	MaxJitterPercent float64 `yaml:"maxJitterPercent" validate:"min=0.0,max=1.0"`
+
+	// Offset aligns the writes of the aggregations to storage to the offset
+	// from the start of each interval, must be less than the resolution.
+	Offset time.Duration `yaml:"offset" validate:"min=0"`
+}
+
+// GapFillConfiguration is how the missing intervals of the aggregations of
+// a storage policy are written.
+type GapFillConfiguration struct {
+	// Resolution is the resolution of the storage policy.
+	Resolution time.Duration `yaml:"resolution" validate:"nonzero"`
+
+	// Retention is the retention of the storage policy.
+	Retention time.Duration `yaml:"retention" validate:"nonzero"`
+
+	// Mode is "skip", "zero" or "lastValue".
+	Mode downsample.GapFillMode `yaml:"mode"`
+
+	// MaxIntervals is the max number of consecutive missing intervals that
+	// are filled, longer gaps are skipped since the series likely went away.
+	MaxIntervals int `yaml:"maxIntervals" validate:"min=0"`
+}
+
+// MirrorConfiguration is configuration for mirroring writes to a second set
+// of DB clusters, writes are mirrored asynchronously and are dropped if the
+// mirror falls too far behind.
+type MirrorConfiguration struct {
+	// Clusters is the DB cluster configurations that writes are mirrored to.
+	Clusters local.ClustersStaticConfiguration `yaml:"clusters" validate:"nonzero"`
+
+	// QueueSize is the maximum number of writes buffered for the mirror.
+	QueueSize int `yaml:"queueSize" validate:"min=0"`
+
+	// Concurrency is the number of concurrent writes to the mirror.
+	Concurrency int `yaml:"concurrency" validate:"min=0"`
+
+	// WriteTimeout is the timeout for each write to the mirror.
+	WriteTimeout time.Duration `yaml:"writeTimeout"`
+}
+
+// ReadShadowConfiguration is configuration for shadowing reads to a second
+// set of DB clusters, shadow queries run in the background and mismatches
+// with the primary results are reported but never returned.
+type ReadShadowConfiguration struct {
+	// Clusters is the DB cluster configurations that reads are shadowed to.
+	Clusters local.ClustersStaticConfiguration `yaml:"clusters" validate:"nonzero"`
+
+	// SampleRate is the fraction of queries that are shadowed.
+	SampleRate float64 `yaml:"sampleRate" validate:"min=0.0,max=1.0"`
+
+	// Concurrency is the maximum number of shadow queries in flight.
+	Concurrency int `yaml:"concurrency" validate:"min=0"`
+
+	// Timeout is the timeout for each shadow query.
+	Timeout time.Duration `yaml:"timeout"`
+}
+
+// CanaryConfiguration is configuration for writing a heartbeat series to
+// each cluster namespace at an interval and polling for it until it is
+// queryable, latencies and missing heartbeats are reported per namespace.
+type CanaryConfiguration struct {
+	// Namespaces are the names of the cluster namespaces heartbeats are
+	// written to, all cluster namespaces if not set.
+	Namespaces []string `yaml:"namespaces"`
+
+	// Interval is the interval heartbeats are written at, defaults to 10s.
+	Interval time.Duration `yaml:"interval"`
+
+	// PollInterval is the interval pending heartbeats are queried at,
+	// defaults to 1s.
+	PollInterval time.Duration `yaml:"pollInterval"`
+
+	// Timeout is the time after which a heartbeat that is not queryable is
+	// counted as missing, defaults to 2m.
+	Timeout time.Duration `yaml:"timeout"`
+
+	// LatencyTarget is the ingest latency objective, defaults to 30s.
+	LatencyTarget time.Duration `yaml:"latencyTarget"`
+
+	// MetricName is the metric name of the heartbeat series.
+	MetricName string `yaml:"metricName"`
+
+	// Instance is the value of the instance tag of the heartbeat series,
+	// defaults to the hostname.
+	Instance string `yaml:"instance"`
 }
 
 // RPCConfiguration is the RPC configuration for the coordinator for
@@ -102,4 +438,8 @@ type RPCConfiguration struct {
 	// RemoteListenAddresses is the remote listen addresses to call for remote
 	// coordinator calls.
 	RemoteListenAddresses []string `yaml:"remoteListenAddresses"`
+
+	// TLS is the mutual TLS configuration of the RPC server and of the
+	// clients of the remote coordinators, RPC is plaintext if not set.
+	TLS *xtls.Configuration `yaml:"tls"`
 }
@@ -0,0 +1,159 @@
+// Copyright (c) 2018 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+// Package ctl implements the m3ctl management commands against the
+// coordinator admin API.
+package ctl
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/m3db/m3/src/query/api/v1/handler/placement"
+)
+
+const (
+	defaultEndpoint = "http://localhost:7201"
+	defaultTimeout  = 30 * time.Second
+)
+
+// Options are the options for connecting to a coordinator.
+type Options struct {
+	// Endpoint is the base URL of the coordinator, e.g. http://localhost:7201.
+	Endpoint string
+	// ServiceName, Environment and Zone select the placement service
+	// the placement commands apply to, the coordinator defaults are used
+	// if empty.
+	ServiceName string
+	Environment string
+	Zone        string
+	Timeout     time.Duration
+}
+
+// Client is a client of the coordinator admin API.
+type Client struct {
+	endpoint   string
+	headers    http.Header
+	httpClient *http.Client
+}
+
+// NewClient returns a new coordinator admin API client.
+func NewClient(opts Options) *Client {
+	endpoint := strings.TrimSuffix(opts.Endpoint, "/")
+	if endpoint == "" {
+		endpoint = defaultEndpoint
+	}
+	timeout := opts.Timeout
+	if timeout <= 0 {
+		timeout = defaultTimeout
+	}
+
+	headers := make(http.Header)
+	if opts.ServiceName != "" {
+		headers.Set(placement.HeaderClusterServiceName, opts.ServiceName)
+	}
+	if opts.Environment != "" {
+		headers.Set(placement.HeaderClusterEnvironmentName, opts.Environment)
+	}
+	if opts.Zone != "" {
+		headers.Set(placement.HeaderClusterZoneName, opts.Zone)
+	}
+
+	return &Client{
+		endpoint:   endpoint,
+		headers:    headers,
+		httpClient: &http.Client{Timeout: timeout},
+	}
+}
+
+// Do sends a request to the coordinator and returns the response body,
+// non 2xx responses are returned as errors with the error message of the
+// coordinator.
+func (c *Client) Do(
+	method string,
+	path string,
+	query url.Values,
+	body []byte,
+) ([]byte, error) {
+	u := c.endpoint + path
+	if len(query) > 0 {
+		u += "?" + query.Encode()
+	}
+
+	req, err := http.NewRequest(method, u, bytes.NewReader(body))
+	if err != nil {
+		return nil, err
+	}
+	for k, v := range c.headers {
+		req.Header[k] = v
+	}
+	if body != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	respBody, err := ioutil.ReadAll(resp.Body)
+	if err != nil {
+		return nil, err
+	}
+
+	if resp.StatusCode/100 != 2 {
+		var errResp struct {
+			Error string `json:"error"`
+		}
+		if err := json.Unmarshal(respBody, &errResp); err == nil && errResp.Error != "" {
+			return nil, fmt.Errorf("%s %s: %d: %s", method, path, resp.StatusCode, errResp.Error)
+		}
+		return nil, fmt.Errorf("%s %s: %d: %s", method, path, resp.StatusCode,
+			strings.TrimSpace(string(respBody)))
+	}
+
+	return respBody, nil
+}
+
+// DoJSON sends a request to the coordinator and decodes the JSON response
+// into result, result is ignored if nil.
+func (c *Client) DoJSON(
+	method string,
+	path string,
+	query url.Values,
+	body []byte,
+	result interface{},
+) error {
+	respBody, err := c.Do(method, path, query, body)
+	if err != nil {
+		return err
+	}
+	if result == nil || len(respBody) == 0 {
+		return nil
+	}
+	return json.Unmarshal(respBody, result)
+}
@@ -0,0 +1,588 @@
+// Copyright (c) 2018 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package ctl
+
+import (
+	"bufio"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/m3db/m3/src/query/api/v1/handler/namespace"
+	"github.com/m3db/m3/src/query/api/v1/handler/placement"
+	"github.com/m3db/m3/src/query/api/v1/handler/rules"
+)
+
+var (
+	errAborted       = errors.New("aborted")
+	errNoChanges     = errors.New("no changes to apply")
+	errFileRequired  = errors.New("-f is required")
+	errNameRequired  = errors.New("-name is required")
+	errIDRequired    = errors.New("-id is required")
+	errStateRequired = errors.New("-state is required")
+	errTagsRequired  = errors.New("-tags is required")
+)
+
+// command is a management subcommand of a resource.
+type command struct {
+	usage string
+	run   func(r *Runner, args []string) error
+}
+
+var commands = map[string]map[string]command{
+	"placement": {
+		"get": {
+			usage: "print the placement",
+			run:   (*Runner).placementGet,
+		},
+		"init": {
+			usage: "initialize the placement from a placement init request file",
+			run:   (*Runner).placementInit,
+		},
+		"add": {
+			usage: "add the instances of a placement add request file",
+			run:   (*Runner).placementAdd,
+		},
+		"remove": {
+			usage: "remove an instance",
+			run:   (*Runner).placementRemove,
+		},
+		"delete": {
+			usage: "delete the placement",
+			run:   (*Runner).placementDelete,
+		},
+	},
+	"namespace": {
+		"get": {
+			usage: "print the namespaces",
+			run:   (*Runner).namespaceGet,
+		},
+		"add": {
+			usage: "add the namespace of a namespace add request file",
+			run:   (*Runner).namespaceAdd,
+		},
+		"delete": {
+			usage: "delete a namespace",
+			run:   (*Runner).namespaceDelete,
+		},
+		"state": {
+			usage: "set the lifecycle state of a namespace",
+			run:   (*Runner).namespaceState,
+		},
+	},
+	"rules": {
+		"match": {
+			usage: "print the downsampling rules that match a series",
+			run:   (*Runner).rulesMatch,
+		},
+	},
+}
+
+// Runner runs management commands, changes are previewed as a diff and
+// only applied once confirmed.
+type Runner struct {
+	client *Client
+	in     *bufio.Reader
+	out    io.Writer
+}
+
+// NewRunner returns a new command runner that reads confirmations from in
+// and writes output to out.
+func NewRunner(client *Client, in io.Reader, out io.Writer) *Runner {
+	return &Runner{
+		client: client,
+		in:     bufio.NewReader(in),
+		out:    out,
+	}
+}
+
+// Run runs the command with the args "<resource> <action> [flags]".
+func (r *Runner) Run(args []string) error {
+	if len(args) < 2 {
+		return fmt.Errorf("expected <resource> <action>\n%s", Usage())
+	}
+
+	actions, ok := commands[args[0]]
+	if !ok {
+		return fmt.Errorf("unknown resource %q\n%s", args[0], Usage())
+	}
+	cmd, ok := actions[args[1]]
+	if !ok {
+		return fmt.Errorf("unknown %s action %q\n%s", args[0], args[1], Usage())
+	}
+
+	return cmd.run(r, args[2:])
+}
+
+// Usage returns the usage of the commands.
+func Usage() string {
+	resources := make([]string, 0, len(commands))
+	for resource := range commands {
+		resources = append(resources, resource)
+	}
+	sort.Strings(resources)
+
+	var b strings.Builder
+	b.WriteString("commands:\n")
+	for _, resource := range resources {
+		actions := make([]string, 0, len(commands[resource]))
+		for action := range commands[resource] {
+			actions = append(actions, action)
+		}
+		sort.Strings(actions)
+		for _, action := range actions {
+			fmt.Fprintf(&b, "  %-22s %s\n", resource+" "+action,
+				commands[resource][action].usage)
+		}
+	}
+	return b.String()
+}
+
+// changeFlags are the flags shared by the commands that make changes.
+type changeFlags struct {
+	yes    bool
+	dryRun bool
+}
+
+func newFlagSet(name string, change *changeFlags) *flag.FlagSet {
+	fs := flag.NewFlagSet(name, flag.ContinueOnError)
+	if change != nil {
+		fs.BoolVar(&change.yes, "yes", false, "apply the change without confirmation")
+		fs.BoolVar(&change.dryRun, "dry-run", false, "only print the change")
+	}
+	return fs
+}
+
+// confirm prints the preview of a change and returns whether to apply it.
+func (r *Runner) confirm(change changeFlags, preview []string) (bool, error) {
+	for _, line := range preview {
+		fmt.Fprintln(r.out, line)
+	}
+	if change.dryRun {
+		return false, nil
+	}
+	if change.yes {
+		return true, nil
+	}
+
+	fmt.Fprint(r.out, "apply change? [y/N]: ")
+	answer, err := r.in.ReadString('\n')
+	if err != nil && err != io.EOF {
+		return false, err
+	}
+	switch strings.ToLower(strings.TrimSpace(answer)) {
+	case "y", "yes":
+		return true, nil
+	}
+	return false, errAborted
+}
+
+func (r *Runner) printJSON(data []byte) error {
+	var v interface{}
+	if err := json.Unmarshal(data, &v); err != nil {
+		return err
+	}
+	out, err := json.MarshalIndent(v, "", "  ")
+	if err != nil {
+		return err
+	}
+	fmt.Fprintln(r.out, string(out))
+	return nil
+}
+
+func (r *Runner) getAndPrint(path string) error {
+	data, err := r.client.Do(http.MethodGet, path, nil, nil)
+	if err != nil {
+		return err
+	}
+	return r.printJSON(data)
+}
+
+func readRequestFile(file string) ([]byte, interface{}, error) {
+	if file == "" {
+		return nil, nil, errFileRequired
+	}
+	data, err := ioutil.ReadFile(file)
+	if err != nil {
+		return nil, nil, err
+	}
+	var v interface{}
+	if err := json.Unmarshal(data, &v); err != nil {
+		return nil, nil, fmt.Errorf("invalid request file %s: %v", file, err)
+	}
+	return data, v, nil
+}
+
+func (r *Runner) placementGet(args []string) error {
+	if err := newFlagSet("placement get", nil).Parse(args); err != nil {
+		return err
+	}
+	return r.getAndPrint(placement.GetURL)
+}
+
+func (r *Runner) placementInit(args []string) error {
+	var (
+		change changeFlags
+		fs     = newFlagSet("placement init", &change)
+		file   = fs.String("f", "", "placement init request file")
+	)
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+
+	body, req, err := readRequestFile(*file)
+	if err != nil {
+		return err
+	}
+
+	preview, err := DiffJSON(nil, req)
+	if err != nil {
+		return err
+	}
+	if ok, err := r.confirm(change, preview); !ok || err != nil {
+		return err
+	}
+
+	data, err := r.client.Do(http.MethodPost, placement.InitURL, nil, body)
+	if err != nil {
+		return err
+	}
+	return r.printJSON(data)
+}
+
+func (r *Runner) placementAdd(args []string) error {
+	var (
+		change     changeFlags
+		fs         = newFlagSet("placement add", &change)
+		file       = fs.String("f", "", "placement add request file")
+		shardBytes = fs.Int64("shard-bytes", 0, "estimated bytes of a shard replica")
+	)
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+
+	body, _, err := readRequestFile(*file)
+	if err != nil {
+		return err
+	}
+
+	return r.placementChange(http.MethodPost, placement.AddURL, body, change, *shardBytes)
+}
+
+func (r *Runner) placementRemove(args []string) error {
+	var (
+		change     changeFlags
+		fs         = newFlagSet("placement remove", &change)
+		id         = fs.String("id", "", "ID of the instance to remove")
+		shardBytes = fs.Int64("shard-bytes", 0, "estimated bytes of a shard replica")
+	)
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	if *id == "" {
+		return errIDRequired
+	}
+
+	path := placement.GetURL + "/" + url.PathEscape(*id)
+	return r.placementChange(http.MethodDelete, path, nil, change, *shardBytes)
+}
+
+// placementChange previews a placement change with its dry run plan before
+// making it.
+func (r *Runner) placementChange(
+	method string,
+	path string,
+	body []byte,
+	change changeFlags,
+	shardBytes int64,
+) error {
+	query := url.Values{}
+	query.Set("dryRun", "true")
+	query.Set("shardBytes", strconv.FormatInt(shardBytes, 10))
+
+	var plan placement.ChangePlan
+	if err := r.client.DoJSON(method, path, query, body, &plan); err != nil {
+		return err
+	}
+
+	if ok, err := r.confirm(change, formatChangePlan(plan)); !ok || err != nil {
+		return err
+	}
+
+	data, err := r.client.Do(method, path, nil, body)
+	if err != nil {
+		return err
+	}
+	return r.printJSON(data)
+}
+
+func formatChangePlan(plan placement.ChangePlan) []string {
+	lines := make([]string, 0, len(plan.Instances)+1)
+	for _, delta := range plan.Instances {
+		lines = append(lines, fmt.Sprintf("%s: %d -> %d shards, %s%v %s%v",
+			delta.ID, delta.ShardsBefore, delta.ShardsAfter,
+			strings.TrimSpace(diffAddedPrefix), delta.ShardsAdded,
+			strings.TrimSpace(diffRemovedPrefix), delta.ShardsRemoved))
+	}
+	summary := fmt.Sprintf("shards moved: %d", plan.ShardsMoved)
+	if plan.EstimatedBytes > 0 {
+		summary += fmt.Sprintf(", estimated bytes: %d", plan.EstimatedBytes)
+	}
+	return append(lines, summary)
+}
+
+func (r *Runner) placementDelete(args []string) error {
+	var (
+		change changeFlags
+		fs     = newFlagSet("placement delete", &change)
+	)
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+
+	var current interface{}
+	if err := r.client.DoJSON(http.MethodGet, placement.GetURL, nil, nil, &current); err != nil {
+		return err
+	}
+
+	preview, err := DiffJSON(current, nil)
+	if err != nil {
+		return err
+	}
+	if ok, err := r.confirm(change, preview); !ok || err != nil {
+		return err
+	}
+
+	_, err = r.client.Do(http.MethodDelete, placement.DeleteAllURL, nil, nil)
+	return err
+}
+
+// namespaces returns the current namespace options by name.
+func (r *Runner) namespaces() (map[string]interface{}, error) {
+	var resp struct {
+		Registry struct {
+			Namespaces map[string]interface{} `json:"namespaces"`
+		} `json:"registry"`
+	}
+	if err := r.client.DoJSON(http.MethodGet, namespace.GetURL, nil, nil, &resp); err != nil {
+		return nil, err
+	}
+	if resp.Registry.Namespaces == nil {
+		return make(map[string]interface{}), nil
+	}
+	return resp.Registry.Namespaces, nil
+}
+
+func copyNamespaces(namespaces map[string]interface{}) map[string]interface{} {
+	result := make(map[string]interface{}, len(namespaces))
+	for k, v := range namespaces {
+		result[k] = v
+	}
+	return result
+}
+
+func (r *Runner) namespaceGet(args []string) error {
+	if err := newFlagSet("namespace get", nil).Parse(args); err != nil {
+		return err
+	}
+	return r.getAndPrint(namespace.GetURL)
+}
+
+func (r *Runner) namespaceAdd(args []string) error {
+	var (
+		change changeFlags
+		fs     = newFlagSet("namespace add", &change)
+		file   = fs.String("f", "", "namespace add request file")
+	)
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+
+	body, _, err := readRequestFile(*file)
+	if err != nil {
+		return err
+	}
+	var req struct {
+		Name    string      `json:"name"`
+		Options interface{} `json:"options"`
+	}
+	if err := json.Unmarshal(body, &req); err != nil {
+		return err
+	}
+	if req.Name == "" {
+		return errNameRequired
+	}
+
+	before, err := r.namespaces()
+	if err != nil {
+		return err
+	}
+	after := copyNamespaces(before)
+	after[req.Name] = req.Options
+
+	return r.namespaceChange(change, before, after, func() error {
+		_, err := r.client.Do(http.MethodPost, namespace.AddURL, nil, body)
+		return err
+	})
+}
+
+func (r *Runner) namespaceDelete(args []string) error {
+	var (
+		change changeFlags
+		fs     = newFlagSet("namespace delete", &change)
+		name   = fs.String("name", "", "name of the namespace to delete")
+	)
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	if *name == "" {
+		return errNameRequired
+	}
+
+	before, err := r.namespaces()
+	if err != nil {
+		return err
+	}
+	after := copyNamespaces(before)
+	delete(after, *name)
+
+	return r.namespaceChange(change, before, after, func() error {
+		path := namespace.GetURL + "/" + url.PathEscape(*name)
+		_, err := r.client.Do(http.MethodDelete, path, nil, nil)
+		return err
+	})
+}
+
+func (r *Runner) namespaceState(args []string) error {
+	var (
+		change      changeFlags
+		fs          = newFlagSet("namespace state", &change)
+		name        = fs.String("name", "", "name of the namespace")
+		state       = fs.String("state", "", "active, read_only or pending_delete")
+		gracePeriod = fs.String("grace-period", "", "grace period before a pending_delete namespace is deleted, e.g. 48h")
+	)
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	if *name == "" {
+		return errNameRequired
+	}
+	if *state == "" {
+		return errStateRequired
+	}
+
+	before, err := r.namespaces()
+	if err != nil {
+		return err
+	}
+	after := copyNamespaces(before)
+	if opts, ok := before[*name].(map[string]interface{}); ok {
+		updated := make(map[string]interface{}, len(opts))
+		for k, v := range opts {
+			updated[k] = v
+		}
+		updated["state"] = strings.ToUpper(*state)
+		after[*name] = updated
+	}
+
+	body, err := json.Marshal(namespace.StateRequest{
+		Name:        *name,
+		State:       *state,
+		GracePeriod: *gracePeriod,
+	})
+	if err != nil {
+		return err
+	}
+
+	return r.namespaceChange(change, before, after, func() error {
+		_, err := r.client.Do(http.MethodPost, namespace.StateURL, nil, body)
+		return err
+	})
+}
+
+// namespaceChange previews the diff between the namespaces before and after
+// a change before applying it.
+func (r *Runner) namespaceChange(
+	change changeFlags,
+	before, after map[string]interface{},
+	apply func() error,
+) error {
+	preview, err := DiffJSON(before, after)
+	if err != nil {
+		return err
+	}
+	if !HasChanges(preview) {
+		return errNoChanges
+	}
+	if ok, err := r.confirm(change, preview); !ok || err != nil {
+		return err
+	}
+	if err := apply(); err != nil {
+		return err
+	}
+
+	fmt.Fprintln(r.out, "applied")
+	return nil
+}
+
+func (r *Runner) rulesMatch(args []string) error {
+	var (
+		fs        = newFlagSet("rules match", nil)
+		tags      = fs.String("tags", "", "tags of the series, e.g. __name__=requests,env=prod")
+		timestamp = fs.String("at", "", "time to match the rules active at, defaults to now")
+	)
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	if *tags == "" {
+		return errTagsRequired
+	}
+
+	req := rules.MatchRequest{
+		Tags:      make(map[string]string),
+		Timestamp: *timestamp,
+	}
+	for _, pair := range strings.Split(*tags, ",") {
+		kv := strings.SplitN(pair, "=", 2)
+		if len(kv) != 2 || kv[0] == "" {
+			return fmt.Errorf("invalid tag %q, expected name=value", pair)
+		}
+		req.Tags[kv[0]] = kv[1]
+	}
+
+	body, err := json.Marshal(req)
+	if err != nil {
+		return err
+	}
+	data, err := r.client.Do(http.MethodPost, rules.MatchURL, nil, body)
+	if err != nil {
+		return err
+	}
+	return r.printJSON(data)
+}
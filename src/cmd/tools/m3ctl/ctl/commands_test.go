@@ -0,0 +1,195 @@
+// Copyright (c) 2018 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package ctl
+
+import (
+	"bytes"
+	"encoding/json"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/m3db/m3/src/query/api/v1/handler/namespace"
+	"github.com/m3db/m3/src/query/api/v1/handler/placement"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type recordedRequest struct {
+	method string
+	path   string
+	query  string
+	body   string
+}
+
+type testCoordinator struct {
+	*httptest.Server
+	requests []recordedRequest
+}
+
+func newTestCoordinator(t *testing.T, handler http.HandlerFunc) *testCoordinator {
+	c := &testCoordinator{}
+	c.Server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, err := ioutil.ReadAll(r.Body)
+		require.NoError(t, err)
+		c.requests = append(c.requests, recordedRequest{
+			method: r.Method,
+			path:   r.URL.Path,
+			query:  r.URL.RawQuery,
+			body:   string(body),
+		})
+		handler(w, r)
+	}))
+	return c
+}
+
+const testNamespacesResponse = `{"registry":{"namespaces":{"metrics":{"state":"ACTIVE","retentionOptions":{"retentionPeriodNanos":"172800000000000"}}}}}`
+
+func TestRunnerNamespaceDelete(t *testing.T) {
+	coordinator := newTestCoordinator(t, func(w http.ResponseWriter, r *http.Request) {
+		if r.Method == http.MethodGet {
+			w.Write([]byte(testNamespacesResponse))
+			return
+		}
+		w.Write([]byte(`{}`))
+	})
+	defer coordinator.Close()
+
+	var (
+		out    bytes.Buffer
+		runner = NewRunner(NewClient(Options{Endpoint: coordinator.URL}),
+			strings.NewReader("y\n"), &out)
+	)
+	require.NoError(t, runner.Run([]string{"namespace", "delete", "-name", "metrics"}))
+
+	assert.Contains(t, out.String(), `-   "metrics": {`)
+	assert.Contains(t, out.String(), "applied")
+	require.Len(t, coordinator.requests, 2)
+	assert.Equal(t, http.MethodDelete, coordinator.requests[1].method)
+	assert.Equal(t, namespace.GetURL+"/metrics", coordinator.requests[1].path)
+}
+
+func TestRunnerNamespaceStateAborted(t *testing.T) {
+	coordinator := newTestCoordinator(t, func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(testNamespacesResponse))
+	})
+	defer coordinator.Close()
+
+	var (
+		out    bytes.Buffer
+		runner = NewRunner(NewClient(Options{Endpoint: coordinator.URL}),
+			strings.NewReader("n\n"), &out)
+	)
+	err := runner.Run([]string{"namespace", "state", "-name", "metrics", "-state", "read_only"})
+	assert.Equal(t, errAborted, err)
+
+	assert.Contains(t, out.String(), `-     "state": "ACTIVE"`)
+	assert.Contains(t, out.String(), `+     "state": "READ_ONLY"`)
+	require.Len(t, coordinator.requests, 1)
+	assert.Equal(t, http.MethodGet, coordinator.requests[0].method)
+}
+
+func TestRunnerNamespaceAddDryRun(t *testing.T) {
+	coordinator := newTestCoordinator(t, func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(testNamespacesResponse))
+	})
+	defer coordinator.Close()
+
+	dir, err := ioutil.TempDir("", "m3ctl")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+	file := filepath.Join(dir, "namespace.json")
+	require.NoError(t, ioutil.WriteFile(file,
+		[]byte(`{"name":"aggregated","options":{"bootstrapEnabled":true}}`), 0644))
+
+	var (
+		out    bytes.Buffer
+		runner = NewRunner(NewClient(Options{Endpoint: coordinator.URL}),
+			strings.NewReader(""), &out)
+	)
+	require.NoError(t, runner.Run([]string{"namespace", "add", "-f", file, "-dry-run"}))
+
+	assert.Contains(t, out.String(), `+   "aggregated": {`)
+	assert.NotContains(t, out.String(), "applied")
+	require.Len(t, coordinator.requests, 1)
+}
+
+func TestRunnerPlacementRemove(t *testing.T) {
+	coordinator := newTestCoordinator(t, func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Query().Get("dryRun") == "true" {
+			json.NewEncoder(w).Encode(placement.ChangePlan{
+				ShardsMoved: 2,
+				Instances: []placement.InstanceDelta{
+					{ID: "host1", ShardsBefore: 2, ShardsAfter: 4, ShardsAdded: []uint32{2, 3}},
+				},
+			})
+			return
+		}
+		w.Write([]byte(`{"placement":{}}`))
+	})
+	defer coordinator.Close()
+
+	var (
+		out    bytes.Buffer
+		runner = NewRunner(NewClient(Options{
+			Endpoint:    coordinator.URL,
+			ServiceName: "m3db",
+		}), strings.NewReader(""), &out)
+	)
+	require.NoError(t, runner.Run([]string{"placement", "remove", "-id", "host2", "-yes"}))
+
+	assert.Contains(t, out.String(), "host1: 2 -> 4 shards, +[2 3] -[]")
+	assert.Contains(t, out.String(), "shards moved: 2")
+	require.Len(t, coordinator.requests, 2)
+	for _, req := range coordinator.requests {
+		assert.Equal(t, http.MethodDelete, req.method)
+		assert.Equal(t, placement.GetURL+"/host2", req.path)
+	}
+	assert.Contains(t, coordinator.requests[0].query, "dryRun=true")
+	assert.Empty(t, coordinator.requests[1].query)
+}
+
+func TestRunnerErrors(t *testing.T) {
+	coordinator := newTestCoordinator(t, func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusNotFound)
+		w.Write([]byte(`{"error":"placement not found"}`))
+	})
+	defer coordinator.Close()
+
+	var (
+		out    bytes.Buffer
+		runner = NewRunner(NewClient(Options{Endpoint: coordinator.URL}),
+			strings.NewReader(""), &out)
+	)
+	err := runner.Run([]string{"placement", "get"})
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "placement not found")
+
+	assert.Error(t, runner.Run([]string{"placement"}))
+	assert.Error(t, runner.Run([]string{"unknown", "get"}))
+	assert.Error(t, runner.Run([]string{"placement", "unknown"}))
+	assert.Equal(t, errIDRequired, runner.Run([]string{"placement", "remove"}))
+}
@@ -0,0 +1,115 @@
+// Copyright (c) 2018 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package ctl
+
+import (
+	"encoding/json"
+	"strings"
+)
+
+const (
+	diffContextPrefix = "  "
+	diffRemovedPrefix = "- "
+	diffAddedPrefix   = "+ "
+)
+
+// DiffLines returns the line diff between before and after, each line is
+// prefixed with "- " if removed, "+ " if added or "  " if unchanged.
+func DiffLines(before, after []string) []string {
+	// lcs[i][j] is the length of the longest common subsequence of
+	// before[i:] and after[j:].
+	lcs := make([][]int, len(before)+1)
+	for i := range lcs {
+		lcs[i] = make([]int, len(after)+1)
+	}
+	for i := len(before) - 1; i >= 0; i-- {
+		for j := len(after) - 1; j >= 0; j-- {
+			if before[i] == after[j] {
+				lcs[i][j] = lcs[i+1][j+1] + 1
+			} else if lcs[i+1][j] >= lcs[i][j+1] {
+				lcs[i][j] = lcs[i+1][j]
+			} else {
+				lcs[i][j] = lcs[i][j+1]
+			}
+		}
+	}
+
+	var (
+		diff = make([]string, 0, len(before)+len(after))
+		i, j int
+	)
+	for i < len(before) && j < len(after) {
+		switch {
+		case before[i] == after[j]:
+			diff = append(diff, diffContextPrefix+before[i])
+			i++
+			j++
+		case lcs[i+1][j] >= lcs[i][j+1]:
+			diff = append(diff, diffRemovedPrefix+before[i])
+			i++
+		default:
+			diff = append(diff, diffAddedPrefix+after[j])
+			j++
+		}
+	}
+	for ; i < len(before); i++ {
+		diff = append(diff, diffRemovedPrefix+before[i])
+	}
+	for ; j < len(after); j++ {
+		diff = append(diff, diffAddedPrefix+after[j])
+	}
+	return diff
+}
+
+// DiffJSON returns the line diff between the indented JSON encodings of
+// before and after, a nil value encodes as no lines.
+func DiffJSON(before, after interface{}) ([]string, error) {
+	beforeLines, err := jsonLines(before)
+	if err != nil {
+		return nil, err
+	}
+	afterLines, err := jsonLines(after)
+	if err != nil {
+		return nil, err
+	}
+	return DiffLines(beforeLines, afterLines), nil
+}
+
+// HasChanges returns whether a diff has any added or removed lines.
+func HasChanges(diff []string) bool {
+	for _, line := range diff {
+		if !strings.HasPrefix(line, diffContextPrefix) {
+			return true
+		}
+	}
+	return false
+}
+
+func jsonLines(v interface{}) ([]string, error) {
+	if v == nil {
+		return nil, nil
+	}
+	data, err := json.MarshalIndent(v, "", "  ")
+	if err != nil {
+		return nil, err
+	}
+	return strings.Split(string(data), "\n"), nil
+}
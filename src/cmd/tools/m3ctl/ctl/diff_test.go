@@ -0,0 +1,67 @@
+// Copyright (c) 2018 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package ctl
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestDiffLines(t *testing.T) {
+	diff := DiffLines(
+		[]string{"a", "b", "c", "d"},
+		[]string{"a", "c", "e", "d", "f"},
+	)
+	assert.Equal(t, []string{
+		"  a",
+		"- b",
+		"  c",
+		"+ e",
+		"  d",
+		"+ f",
+	}, diff)
+	assert.True(t, HasChanges(diff))
+
+	unchanged := DiffLines([]string{"a", "b"}, []string{"a", "b"})
+	assert.Equal(t, []string{"  a", "  b"}, unchanged)
+	assert.False(t, HasChanges(unchanged))
+}
+
+func TestDiffJSON(t *testing.T) {
+	diff, err := DiffJSON(
+		map[string]interface{}{"a": 1, "b": 2},
+		map[string]interface{}{"a": 1, "b": 3},
+	)
+	require.NoError(t, err)
+	assert.Equal(t, []string{
+		"  {",
+		`    "a": 1,`,
+		`-   "b": 2`,
+		`+   "b": 3`,
+		"  }",
+	}, diff)
+
+	diff, err = DiffJSON(nil, map[string]interface{}{"a": 1})
+	require.NoError(t, err)
+	assert.Equal(t, []string{"+ {", `+   "a": 1`, "+ }"}, diff)
+}
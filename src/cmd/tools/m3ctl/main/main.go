@@ -0,0 +1,64 @@
+// Copyright (c) 2018 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+	"time"
+
+	"github.com/m3db/m3/src/cmd/tools/m3ctl/ctl"
+)
+
+func main() {
+	var (
+		endpoint    = flag.String("endpoint", "http://localhost:7201", "coordinator endpoint")
+		serviceName = flag.String("service", "", "placement service name [e.g. m3db, m3aggregator]")
+		environment = flag.String("env", "", "placement service environment")
+		zone        = flag.String("zone", "", "placement service zone")
+		timeout     = flag.Duration("timeout", 30*time.Second, "request timeout")
+	)
+	flag.Usage = func() {
+		fmt.Fprintf(os.Stderr, "Usage: m3ctl [flags] <resource> <action> [action flags]\n")
+		flag.PrintDefaults()
+		fmt.Fprint(os.Stderr, ctl.Usage())
+	}
+	flag.Parse()
+
+	if flag.NArg() < 2 {
+		flag.Usage()
+		os.Exit(1)
+	}
+
+	client := ctl.NewClient(ctl.Options{
+		Endpoint:    *endpoint,
+		ServiceName: *serviceName,
+		Environment: *environment,
+		Zone:        *zone,
+		Timeout:     *timeout,
+	})
+	runner := ctl.NewRunner(client, os.Stdin, os.Stdout)
+	if err := runner.Run(flag.Args()); err != nil {
+		fmt.Fprintf(os.Stderr, "m3ctl: %v\n", err)
+		os.Exit(1)
+	}
+}
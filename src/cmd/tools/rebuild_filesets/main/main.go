@@ -0,0 +1,95 @@
+// Copyright (c) 2018 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package main
+
+import (
+	"flag"
+	"os"
+	"time"
+
+	"github.com/m3db/m3/src/dbnode/persist/fs"
+	"github.com/m3db/m3/src/dbnode/persist/fs/clone"
+	"github.com/m3db/m3x/ident"
+	xlog "github.com/m3db/m3x/log"
+	xtime "github.com/m3db/m3x/time"
+)
+
+var (
+	optPathPrefix        = flag.String("path-prefix", "/var/lib/m3db", "Path prefix")
+	optScratchPathPrefix = flag.String("scratch-path-prefix", "", "Scratch path prefix, must be on the same filesystem as the path prefix")
+	optNamespace         = flag.String("namespace", "metrics", "Namespace")
+	optShard             = flag.Uint("shard", 0, "Shard ID")
+	optBlockstart        = flag.Int64("block-start", 0, "Block Start Time [in nsec], all blocks if not set")
+	optSummariesPercent  = flag.Float64("index-summaries-percent", 0, "Percent of index summaries to write, filesystem default if not set")
+	optBloomFilterFPP    = flag.Float64("bloom-filter-false-positive-percent", 0, "Bloom filter false positive rate, filesystem default if not set")
+)
+
+func main() {
+	flag.Parse()
+	if *optPathPrefix == "" ||
+		*optScratchPathPrefix == "" ||
+		*optNamespace == "" ||
+		*optBlockstart < 0 ||
+		*optSummariesPercent < 0 || *optSummariesPercent > 1 ||
+		*optBloomFilterFPP < 0 || *optBloomFilterFPP > 1 {
+		flag.Usage()
+		os.Exit(1)
+	}
+
+	log := xlog.NewLogger(os.Stderr)
+	opts := clone.NewOptions().
+		SetIndexSummariesPercent(*optSummariesPercent).
+		SetBloomFilterFalsePositivePercent(*optBloomFilterFPP)
+	cloner := clone.New(opts)
+
+	var (
+		namespace = ident.StringID(*optNamespace)
+		shard     = uint32(*optShard)
+		rebuilt   = 0
+	)
+	infoFiles := fs.ReadInfoFiles(*optPathPrefix, namespace, shard,
+		opts.BufferSize(), opts.DecodingOptions())
+	for _, result := range infoFiles {
+		if err := result.Err.Error(); err != nil {
+			log.Fatalf("unable to read info file %s: %v", result.Err.Filepath(), err)
+		}
+
+		blockStart := xtime.FromNanoseconds(result.Info.BlockStart)
+		if *optBlockstart > 0 && !blockStart.Equal(xtime.FromNanoseconds(*optBlockstart)) {
+			continue
+		}
+
+		fileset := clone.FileSetID{
+			PathPrefix: *optPathPrefix,
+			Namespace:  *optNamespace,
+			Shard:      shard,
+			Blockstart: blockStart,
+		}
+		blockSize := time.Duration(result.Info.BlockSize)
+		if err := cloner.Rebuild(fileset, *optScratchPathPrefix, blockSize); err != nil {
+			log.Fatalf("unable to rebuild fileset %+v: %v", fileset, err)
+		}
+		log.Infof("rebuilt fileset: %+v", fileset)
+		rebuilt++
+	}
+
+	log.Infof("successfully rebuilt %d filesets", rebuilt)
+}
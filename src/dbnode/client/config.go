@@ -60,6 +60,11 @@ type Configuration struct {
 	// FetchTimeout is the fetch request timeout.
 	FetchTimeout time.Duration `yaml:"fetchTimeout" validate:"min=0"`
 
+	// HedgedReadDelay is the delay after which fetches are also sent to the
+	// replicas not required by the read consistency level, zero disables
+	// hedged reads.
+	HedgedReadDelay time.Duration `yaml:"hedgedReadDelay" validate:"min=0"`
+
 	// ConnectTimeout is the cluster connect timeout.
 	ConnectTimeout time.Duration `yaml:"connectTimeout" validate:"min=0"`
 
@@ -79,6 +84,9 @@ type Configuration struct {
 
 	// HashingConfiguration is the configuration for hashing of IDs to shards.
 	HashingConfiguration HashingConfiguration `yaml:"hashing"`
+
+	// PeerStreaming is the configuration for throttling streaming blocks from peers.
+	PeerStreaming *PeerStreamingConfiguration `yaml:"peerStreaming"`
 }
 
 // HashingConfiguration is the configuration for hashing
@@ -87,6 +95,26 @@ type HashingConfiguration struct {
 	Seed uint32 `yaml:"seed"`
 }
 
+// PeerStreamingConfiguration is the configuration for throttling
+// streaming blocks from peers during bootstrap and repair.
+type PeerStreamingConfiguration struct {
+	// LimitMbps is the bandwidth limit for the node in megabits per second.
+	LimitMbps float64 `yaml:"limitMbps" validate:"min=0"`
+
+	// Namespaces are the limits applied to individual namespaces.
+	Namespaces map[string]PeerStreamingNamespaceConfiguration `yaml:"namespaces"`
+}
+
+// PeerStreamingNamespaceConfiguration is the configuration for throttling
+// streaming blocks from peers for a single namespace.
+type PeerStreamingNamespaceConfiguration struct {
+	// LimitMbps is the bandwidth limit for the namespace in megabits per second.
+	LimitMbps float64 `yaml:"limitMbps" validate:"min=0"`
+
+	// Concurrency is the max number of concurrent batch requests for the namespace.
+	Concurrency int `yaml:"concurrency" validate:"min=0"`
+}
+
 // ConfigurationParameters are optional parameters that can be specified
 // when creating a client from configuration, this is specified using
 // a struct so that adding fields do not cause breaking changes to callers.
@@ -184,6 +212,7 @@ func (c Configuration) NewAdminClient(
 		SetBackgroundHealthCheckFailThrottleFactor(c.BackgroundHealthCheckFailThrottleFactor).
 		SetWriteRequestTimeout(c.WriteTimeout).
 		SetFetchRequestTimeout(c.FetchTimeout).
+		SetHedgedReadDelay(c.HedgedReadDelay).
 		SetClusterConnectTimeout(c.ConnectTimeout).
 		SetWriteRetrier(c.WriteRetry.NewRetrier(writeRequestScope)).
 		SetFetchRetrier(c.FetchRetry.NewRetrier(fetchRequestScope)).
@@ -200,8 +229,20 @@ func (c Configuration) NewAdminClient(
 		return m3tsz.NewReaderIterator(r, intOptimized, encodingOpts)
 	})
 
-	// Apply programtic custom options last
 	opts := v.(AdminOptions)
+	if c.PeerStreaming != nil {
+		limits := make(map[string]FetchSeriesBlocksLimit, len(c.PeerStreaming.Namespaces))
+		for ns, cfg := range c.PeerStreaming.Namespaces {
+			limits[ns] = FetchSeriesBlocksLimit{
+				LimitMbps:   cfg.LimitMbps,
+				Concurrency: cfg.Concurrency,
+			}
+		}
+		opts = opts.SetFetchSeriesBlocksLimitMbps(c.PeerStreaming.LimitMbps).
+			SetFetchSeriesBlocksNamespaceLimits(limits)
+	}
+
+	// Apply programtic custom options last
 	for _, opt := range custom {
 		opts = opt(opts)
 	}
@@ -21,7 +21,15 @@
 package client
 
 import (
+	"time"
+
+	"github.com/m3db/m3/src/dbnode/encoding"
+	"github.com/m3db/m3/src/dbnode/storage/index"
 	"github.com/m3db/m3/src/dbnode/topology"
+	"github.com/m3db/m3x/ident"
+	xtime "github.com/m3db/m3x/time"
+
+	opentracing "github.com/opentracing/opentracing-go"
 )
 
 // runtimeReadConsistencyLevel is a queryable value for a
@@ -81,3 +89,212 @@ func (l sessionBootstrapRuntimeReadConsistencyLevel) value() topology.ReadConsis
 	l.s.state.RUnlock()
 	return value
 }
+
+// consistencyLevelOverrides are the consistency levels that the operations
+// of a session view use rather than those of the session, the levels not
+// set are those of the session.
+type consistencyLevelOverrides struct {
+	write    topology.ConsistencyLevel
+	writeSet bool
+	read     topology.ReadConsistencyLevel
+	readSet  bool
+}
+
+var noConsistencyLevelOverrides consistencyLevelOverrides
+
+// NB: must be called with the session state read lock held.
+func (o consistencyLevelOverrides) writeLevelWithRLock(s *session) topology.ConsistencyLevel {
+	if o.writeSet {
+		return o.write
+	}
+	return s.state.writeLevel
+}
+
+// NB: must be called with the session state read lock held.
+func (o consistencyLevelOverrides) readLevelWithRLock(s *session) topology.ReadConsistencyLevel {
+	if o.readSet {
+		return o.read
+	}
+	return s.state.readLevel
+}
+
+func (s *session) WithWriteConsistencyLevel(level topology.ConsistencyLevel) Session {
+	return newConsistencyLevelSession(s, noConsistencyLevelOverrides).
+		WithWriteConsistencyLevel(level)
+}
+
+func (s *session) WithReadConsistencyLevel(level topology.ReadConsistencyLevel) Session {
+	return newConsistencyLevelSession(s, noConsistencyLevelOverrides).
+		WithReadConsistencyLevel(level)
+}
+
+// consistencyLevelSession is a view of a session that reads and writes with
+// consistency levels that override those of the session.
+type consistencyLevelSession struct {
+	s      *session
+	levels consistencyLevelOverrides
+	err    error
+}
+
+func newConsistencyLevelSession(
+	s *session,
+	levels consistencyLevelOverrides,
+) *consistencyLevelSession {
+	return &consistencyLevelSession{s: s, levels: levels}
+}
+
+func (v *consistencyLevelSession) WithWriteConsistencyLevel(
+	level topology.ConsistencyLevel,
+) Session {
+	view := *v
+	if err := topology.ValidateConsistencyLevel(level); err != nil && view.err == nil {
+		view.err = err
+	}
+	view.levels.write, view.levels.writeSet = level, true
+	return &view
+}
+
+func (v *consistencyLevelSession) WithReadConsistencyLevel(
+	level topology.ReadConsistencyLevel,
+) Session {
+	view := *v
+	if err := topology.ValidateReadConsistencyLevel(level); err != nil && view.err == nil {
+		view.err = err
+	}
+	view.levels.read, view.levels.readSet = level, true
+	return &view
+}
+
+func (v *consistencyLevelSession) Write(
+	namespace, id ident.ID,
+	t time.Time,
+	value float64,
+	unit xtime.Unit,
+	annotation []byte,
+) error {
+	if v.err != nil {
+		return v.err
+	}
+	return v.s.write(v.levels, namespace, id, t, value, unit, annotation)
+}
+
+func (v *consistencyLevelSession) WriteTagged(
+	namespace, id ident.ID,
+	tags ident.TagIterator,
+	t time.Time,
+	value float64,
+	unit xtime.Unit,
+	annotation []byte,
+) error {
+	return v.WriteTaggedTraced(nil, namespace, id, tags, t, value, unit, annotation)
+}
+
+func (v *consistencyLevelSession) WriteTaggedTraced(
+	spanCtx opentracing.SpanContext,
+	namespace, id ident.ID,
+	tags ident.TagIterator,
+	t time.Time,
+	value float64,
+	unit xtime.Unit,
+	annotation []byte,
+) error {
+	if v.err != nil {
+		return v.err
+	}
+	return v.s.writeTagged(v.levels, spanCtx, namespace, id, tags,
+		t, value, unit, annotation)
+}
+
+func (v *consistencyLevelSession) WriteAsync(
+	namespace ident.ID,
+	writes []AsyncWrite,
+	fn AsyncWriteCompletionFn,
+) error {
+	if v.err != nil {
+		return v.err
+	}
+	return v.s.writeAsync(v.levels, namespace, writes, fn)
+}
+
+func (v *consistencyLevelSession) Fetch(
+	namespace, id ident.ID,
+	startInclusive, endExclusive time.Time,
+) (encoding.SeriesIterator, error) {
+	if v.err != nil {
+		return nil, v.err
+	}
+	return v.s.fetch(v.levels, namespace, id, startInclusive, endExclusive)
+}
+
+func (v *consistencyLevelSession) FetchIDs(
+	namespace ident.ID,
+	ids ident.Iterator,
+	startInclusive, endExclusive time.Time,
+) (encoding.SeriesIterators, error) {
+	if v.err != nil {
+		return nil, v.err
+	}
+	return v.s.fetchIDs(v.levels, namespace, ids, startInclusive, endExclusive)
+}
+
+func (v *consistencyLevelSession) FetchTagged(
+	namespace ident.ID, q index.Query, opts index.QueryOptions,
+) (encoding.SeriesIterators, bool, error) {
+	if v.err != nil {
+		return nil, false, v.err
+	}
+	return v.s.fetchTagged(v.levels, namespace, q, opts)
+}
+
+func (v *consistencyLevelSession) FetchTaggedIDs(
+	namespace ident.ID, q index.Query, opts index.QueryOptions,
+) (TaggedIDsIterator, bool, error) {
+	if v.err != nil {
+		return nil, false, v.err
+	}
+	return v.s.fetchTaggedIDs(v.levels, namespace, q, opts)
+}
+
+func (v *consistencyLevelSession) FetchTaggedPages(
+	namespace ident.ID, q index.Query, opts index.QueryOptions, fn FetchTaggedPageFn,
+) (bool, error) {
+	if v.err != nil {
+		return false, v.err
+	}
+	return v.s.fetchTaggedPages(v.levels, namespace, q, opts, fn)
+}
+
+func (v *consistencyLevelSession) FetchTaggedIDsPages(
+	namespace ident.ID, q index.Query, opts index.QueryOptions, fn FetchTaggedIDsPageFn,
+) (bool, error) {
+	if v.err != nil {
+		return false, v.err
+	}
+	return v.s.fetchTaggedIDsPages(v.levels, namespace, q, opts, fn)
+}
+
+func (v *consistencyLevelSession) FetchTaggedStream(
+	namespace ident.ID,
+	q index.Query,
+	opts index.QueryOptions,
+	streamOpts FetchTaggedStreamOptions,
+) (FetchTaggedStreamIterator, error) {
+	if v.err != nil {
+		return nil, v.err
+	}
+	return v.s.fetchTaggedStream(v.levels, namespace, q, opts, streamOpts)
+}
+
+func (v *consistencyLevelSession) ShardID(id ident.ID) (uint32, error) {
+	return v.s.ShardID(id)
+}
+
+func (v *consistencyLevelSession) IteratorPools() (encoding.IteratorPools, error) {
+	return v.s.IteratorPools()
+}
+
+// Close does not close the session the view was created from as the view
+// does not own it.
+func (v *consistencyLevelSession) Close() error {
+	return nil
+}
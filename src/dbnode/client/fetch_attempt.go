@@ -43,6 +43,7 @@ type fetchAttempt struct {
 }
 
 type fetchAttemptArgs struct {
+	levels    consistencyLevelOverrides
 	namespace ident.ID
 	ids       ident.Iterator
 	start     time.Time
@@ -55,7 +56,7 @@ func (f *fetchAttempt) reset() {
 }
 
 func (f *fetchAttempt) perform() error {
-	result, err := f.session.fetchIDsAttempt(f.args.namespace,
+	result, err := f.session.fetchIDsAttempt(f.args.levels, f.args.namespace,
 		f.args.ids, f.args.start, f.args.end)
 	f.result = result
 
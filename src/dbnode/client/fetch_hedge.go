@@ -0,0 +1,198 @@
+// Copyright (c) 2018 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package client
+
+import (
+	"errors"
+	"math"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/m3db/m3/src/dbnode/generated/thrift/rpc"
+	"github.com/m3db/m3/src/dbnode/topology"
+	"github.com/m3db/m3x/ident"
+)
+
+var (
+	// errHedgedReadNotRequired is raised for a deferred replica fetch of an
+	// ID whose read completed before the hedged read delay elapsed.
+	errHedgedReadNotRequired = errors.New("hedged read not required")
+	// errHedgedReadTopologyChanged is raised for a deferred replica fetch
+	// when the topology changed before the hedged read delay elapsed.
+	errHedgedReadTopologyChanged = errors.New("hedged read cancelled by topology change")
+)
+
+// hedgedReadRequired returns the number of replicas a fetch is sent to
+// immediately when hedged reads are enabled, the fetches to the remaining
+// replicas are deferred until the hedged read delay elapses.
+func hedgedReadRequired(level topology.ReadConsistencyLevel, majority int) int {
+	switch level {
+	case topology.ReadConsistencyLevelMajority,
+		topology.ReadConsistencyLevelUnstrictMajority:
+		return majority
+	case topology.ReadConsistencyLevelAll:
+		return math.MaxInt32
+	}
+	return 1
+}
+
+type hedgedFetch struct {
+	hostIdx      int
+	id           ident.ID
+	done         *int32
+	completionFn completionFn
+}
+
+// fetchHedger holds the deferred replica fetches of a fetch attempt and
+// enqueues those of the IDs whose read is not done once the hedged read
+// delay elapses, the deferred fetches of the IDs whose read is done are
+// completed with an error so that their accessors are released.
+type fetchHedger struct {
+	sync.Mutex
+
+	session    *session
+	topoMap    topology.Map
+	namespace  ident.ID
+	rangeStart int64
+	rangeEnd   int64
+	fetches    []hedgedFetch
+	timer      *time.Timer
+	taken      bool
+}
+
+func newFetchHedger(
+	s *session,
+	topoMap topology.Map,
+	namespace ident.ID,
+	rangeStart, rangeEnd int64,
+) *fetchHedger {
+	return &fetchHedger{
+		session:    s,
+		topoMap:    topoMap,
+		namespace:  namespace,
+		rangeStart: rangeStart,
+		rangeEnd:   rangeEnd,
+	}
+}
+
+// add defers the fetch of an ID from a replica, it must not be called
+// after start.
+func (h *fetchHedger) add(hostIdx int, id ident.ID, done *int32, fn completionFn) {
+	h.fetches = append(h.fetches, hedgedFetch{
+		hostIdx:      hostIdx,
+		id:           id,
+		done:         done,
+		completionFn: fn,
+	})
+}
+
+// start enqueues the deferred fetches after the delay.
+func (h *fetchHedger) start(delay time.Duration) {
+	h.Lock()
+	if !h.taken && len(h.fetches) > 0 {
+		h.timer = time.AfterFunc(delay, h.hedge)
+	}
+	h.Unlock()
+}
+
+func (h *fetchHedger) take() []hedgedFetch {
+	h.Lock()
+	defer h.Unlock()
+	if h.taken {
+		return nil
+	}
+	h.taken = true
+	if h.timer != nil {
+		h.timer.Stop()
+	}
+	return h.fetches
+}
+
+// cancel completes the deferred fetches not yet enqueued with an error.
+func (h *fetchHedger) cancel(err error) {
+	for _, f := range h.take() {
+		f.completionFn(nil, err)
+	}
+}
+
+// hedge enqueues the deferred fetches of the IDs whose read is not done.
+func (h *fetchHedger) hedge() {
+	fetches := h.take()
+	if len(fetches) == 0 {
+		return
+	}
+
+	s := h.session
+	s.state.RLock()
+	defer s.state.RUnlock()
+
+	var cancelErr error
+	if s.state.status != statusOpen {
+		cancelErr = errSessionStatusNotOpen
+	} else if s.state.topoMap != h.topoMap {
+		// Host indexes are only valid for the topology the fetches
+		// were routed with.
+		cancelErr = errHedgedReadTopologyChanged
+	}
+
+	var (
+		opsByHostIdx = make(map[int][]*fetchBatchOp)
+		hedged       int64
+	)
+	for _, f := range fetches {
+		if cancelErr != nil {
+			f.completionFn(nil, cancelErr)
+			continue
+		}
+		if atomic.LoadInt32(f.done) == 1 {
+			f.completionFn(nil, errHedgedReadNotRequired)
+			continue
+		}
+
+		ops := opsByHostIdx[f.hostIdx]
+		var op *fetchBatchOp
+		if len(ops) > 0 {
+			op = ops[len(ops)-1]
+		}
+		if op == nil || op.Size() >= s.fetchBatchSize {
+			op = s.pools.fetchBatchOp.Get()
+			op.IncRef()
+			op.request.RangeStart = h.rangeStart
+			op.request.RangeEnd = h.rangeEnd
+			op.request.RangeTimeType = rpc.TimeType_UNIX_NANOSECONDS
+			opsByHostIdx[f.hostIdx] = append(ops, op)
+		}
+		op.append(h.namespace.Bytes(), f.id.Bytes(), f.completionFn)
+		hedged++
+	}
+
+	s.metrics.fetchHedged.Inc(hedged)
+	for hostIdx, ops := range opsByHostIdx {
+		for _, op := range ops {
+			// Passing ownership of the op itself to the host queue
+			op.DecRef()
+			if err := s.state.queues[hostIdx].Enqueue(op); err != nil {
+				op.completeAll(nil, err)
+			}
+		}
+	}
+}
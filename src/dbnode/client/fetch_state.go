@@ -28,6 +28,7 @@ import (
 
 	"github.com/m3db/m3/src/dbnode/encoding"
 	"github.com/m3db/m3/src/dbnode/serialize"
+	"github.com/m3db/m3/src/dbnode/storage/index"
 	"github.com/m3db/m3/src/dbnode/topology"
 	"github.com/m3db/m3/src/dbnode/x/xpool"
 	"github.com/m3db/m3x/ident"
@@ -133,36 +134,42 @@ func (f *fetchState) markDoneWithLock(err error) {
 	f.Signal()
 }
 
-func (f *fetchState) asTaggedIDsIterator(pools fetchTaggedPools) (TaggedIDsIterator, bool, error) {
+func (f *fetchState) asTaggedIDsIterator(
+	pools fetchTaggedPools,
+) (TaggedIDsIterator, bool, index.PageToken, error) {
 	f.Lock()
 	defer f.Unlock()
 
 	if !f.done {
-		return nil, false, errFetchStateStillProcessing
+		return nil, false, nil, errFetchStateStillProcessing
 	}
 
 	if err := f.err; err != nil {
-		return nil, false, err
+		return nil, false, nil, err
 	}
 
 	limit := f.op.requestLimit(maxInt)
-	return f.tagResultAccumulator.AsTaggedIDsIterator(limit, pools)
+	iter, exhaustive, err := f.tagResultAccumulator.AsTaggedIDsIterator(limit, pools)
+	return iter, exhaustive, f.tagResultAccumulator.nextPageToken, err
 }
 
-func (f *fetchState) asEncodingSeriesIterators(pools fetchTaggedPools) (encoding.SeriesIterators, bool, error) {
+func (f *fetchState) asEncodingSeriesIterators(
+	pools fetchTaggedPools,
+) (encoding.SeriesIterators, bool, index.PageToken, error) {
 	f.Lock()
 	defer f.Unlock()
 
 	if !f.done {
-		return nil, false, errFetchStateStillProcessing
+		return nil, false, nil, errFetchStateStillProcessing
 	}
 
 	if err := f.err; err != nil {
-		return nil, false, err
+		return nil, false, nil, err
 	}
 
 	limit := f.op.requestLimit(maxInt)
-	return f.tagResultAccumulator.AsEncodingSeriesIterators(limit, pools)
+	iters, exhaustive, err := f.tagResultAccumulator.AsEncodingSeriesIterators(limit, pools)
+	return iters, exhaustive, f.tagResultAccumulator.nextPageToken, err
 }
 
 // NB(prateek): this is backed by the sessionPools struct, but we're restricting it to a narrow
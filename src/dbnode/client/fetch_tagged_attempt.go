@@ -42,12 +42,14 @@ type fetchTaggedAttempt struct {
 	dataResultIters      encoding.SeriesIterators
 	idsResultExhaustive  bool
 	dataResultExhaustive bool
+	resultNextPageToken  index.PageToken
 }
 
 type fetchTaggedAttemptArgs struct {
-	ns    ident.ID
-	query index.Query
-	opts  index.QueryOptions
+	levels consistencyLevelOverrides
+	ns     ident.ID
+	query  index.Query
+	opts   index.QueryOptions
 }
 
 func (f *fetchTaggedAttempt) reset() {
@@ -57,19 +59,20 @@ func (f *fetchTaggedAttempt) reset() {
 	f.idsResultExhaustive = false
 	f.dataResultIters = nil
 	f.dataResultExhaustive = false
+	f.resultNextPageToken = nil
 }
 
 func (f *fetchTaggedAttempt) performIDsAttempt() error {
 	var err error
-	f.idsResultIter, f.idsResultExhaustive, err = f.session.fetchTaggedIDsAttempt(
-		f.args.ns, f.args.query, f.args.opts)
+	f.idsResultIter, f.idsResultExhaustive, f.resultNextPageToken, err = f.session.fetchTaggedIDsAttempt(
+		f.args.levels, f.args.ns, f.args.query, f.args.opts)
 	return err
 }
 
 func (f *fetchTaggedAttempt) performDataAttempt() error {
 	var err error
-	f.dataResultIters, f.dataResultExhaustive, err = f.session.fetchTaggedAttempt(
-		f.args.ns, f.args.query, f.args.opts)
+	f.dataResultIters, f.dataResultExhaustive, f.resultNextPageToken, err = f.session.fetchTaggedAttempt(
+		f.args.levels, f.args.ns, f.args.query, f.args.opts)
 	return err
 }
 
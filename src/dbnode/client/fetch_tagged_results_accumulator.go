@@ -26,8 +26,11 @@ import (
 	"sort"
 	"time"
 
+	"github.com/m3db/m3/src/dbnode/digest"
 	"github.com/m3db/m3/src/dbnode/encoding"
 	"github.com/m3db/m3/src/dbnode/generated/thrift/rpc"
+	"github.com/m3db/m3/src/dbnode/network/server/tchannelthrift/convert"
+	"github.com/m3db/m3/src/dbnode/storage/index"
 	"github.com/m3db/m3/src/dbnode/topology"
 	"github.com/m3db/m3cluster/shard"
 	xerrors "github.com/m3db/m3x/errors"
@@ -61,6 +64,24 @@ type fetchTaggedResultAccumulator struct {
 	majority         int
 	consistencyLevel topology.ReadConsistencyLevel
 	topoMap          topology.Map
+
+	// onReplicaDivergence is called with the blocks of series whose checksum
+	// differs between replicas if set, hosts are the hosts of the responses
+	// to report the checksum of each replica.
+	onReplicaDivergence func(index.ReplicaDivergence)
+	hosts               map[*rpc.FetchTaggedIDResult_]string
+
+	// exceededLimit is the first limit a host reported stopping its query
+	// early for, onLimitExceeded is called with it once results are built.
+	exceededLimit   index.QueryLimitType
+	onLimitExceeded func(index.QueryLimitType)
+
+	// pageSize is the number of series of a page when positive, morePages
+	// is whether a host has series after its page and nextPageToken resumes
+	// the query after the series of the page once results are built.
+	pageSize      int
+	morePages     bool
+	nextPageToken index.PageToken
 }
 
 type fetchTaggedShardConsistencyResult struct {
@@ -104,8 +125,17 @@ func (accum *fetchTaggedResultAccumulator) Add(
 			fmt.Errorf("error fetching tagged from host %s: %v", host.ID(), resultErr)))
 	} else {
 		accum.exhaustive = accum.exhaustive && response.Exhaustive
+		if accum.exceededLimit == index.QueryLimitNone {
+			accum.exceededLimit = convert.FromRPCQueryLimitType(response.ExceededLimit)
+		}
+		if response.NextPageToken != nil {
+			accum.morePages = true
+		}
 		for _, elem := range response.Elements {
 			accum.responses = append(accum.responses, elem)
+			if accum.onReplicaDivergence != nil {
+				accum.hosts[elem] = host.ID()
+			}
 		}
 	}
 
@@ -147,8 +177,10 @@ func (accum *fetchTaggedResultAccumulator) Add(
 		accum.shardConsistencyResults[shardID] = shardResult
 	}
 
-	// success case, sufficient responses for each shard
-	if accum.numShardsPending == 0 {
+	// success case, sufficient responses for each shard. When verifying
+	// replicas the responses of all hosts are needed to compare them.
+	if accum.numShardsPending == 0 &&
+		(accum.onReplicaDivergence == nil || accum.numHostsPending == 0) {
 		doneAccumulating := true
 		return doneAccumulating, nil
 	}
@@ -181,6 +213,15 @@ func (accum *fetchTaggedResultAccumulator) Clear() {
 	accum.startTime, accum.endTime = time.Time{}, time.Time{}
 	accum.topoMap = nil
 	accum.exhaustive = true
+	accum.onReplicaDivergence = nil
+	accum.exceededLimit = index.QueryLimitNone
+	accum.onLimitExceeded = nil
+	accum.pageSize = 0
+	accum.morePages = false
+	accum.nextPageToken = nil
+	for elem := range accum.hosts {
+		delete(accum.hosts, elem)
+	}
 }
 
 func (accum *fetchTaggedResultAccumulator) Reset(
@@ -212,9 +253,121 @@ func (accum *fetchTaggedResultAccumulator) Reset(
 	}
 }
 
+// restrictToShards restricts the accumulator to the responses for the shards
+// from the number of hosts owning them, the other shards are not fetched and
+// so are not waited on.
+func (accum *fetchTaggedResultAccumulator) restrictToShards(
+	shards []uint32,
+	numHosts int,
+) {
+	accum.numHostsPending = int32(numHosts)
+	accum.numShardsPending = 0
+	for id := range accum.shardConsistencyResults {
+		accum.shardConsistencyResults[id].done = true
+	}
+	for _, shard := range shards {
+		id := int(shard)
+		if id >= len(accum.shardConsistencyResults) {
+			continue
+		}
+
+		shardResult := accum.shardConsistencyResults[id]
+		if !shardResult.done || shardResult.enqueued == 0 {
+			// Either a duplicate shard or a shard not in the topology.
+			continue
+		}
+
+		shardResult.done = false
+		accum.shardConsistencyResults[id] = shardResult
+		accum.numShardsPending++
+	}
+}
+
+// verifyReplicas makes the accumulator wait for the responses of all hosts
+// and compare the checksums of the blocks of each series between replicas.
+func (accum *fetchTaggedResultAccumulator) verifyReplicas(
+	fn func(index.ReplicaDivergence),
+) {
+	accum.onReplicaDivergence = fn
+	if accum.hosts == nil {
+		accum.hosts = make(map[*rpc.FetchTaggedIDResult_]string)
+	}
+}
+
+// compareReplicas calls onReplicaDivergence with each block of the series
+// whose checksum differs between the replicas of the responses. Only merged
+// blocks are compared, the unmerged data of blocks being written to may
+// differ between replicas while writes are in flight.
+func (accum *fetchTaggedResultAccumulator) compareReplicas(
+	elems fetchTaggedIDResults,
+) {
+	if len(elems) < 2 {
+		return
+	}
+
+	var (
+		blockStarts []int64
+		checksums   = make(map[int64]map[string]uint32)
+	)
+	for _, elem := range elems {
+		host := accum.hosts[elem]
+		for _, segments := range elem.Segments {
+			seg := segments.Merged
+			if seg == nil || seg.StartTime == nil {
+				continue
+			}
+			blockChecksums, ok := checksums[*seg.StartTime]
+			if !ok {
+				blockChecksums = make(map[string]uint32, len(elems))
+				checksums[*seg.StartTime] = blockChecksums
+				blockStarts = append(blockStarts, *seg.StartTime)
+			}
+			blockChecksums[host] = digest.NewDigest().
+				Update(seg.Head).Update(seg.Tail).Sum32()
+		}
+	}
+
+	for _, blockStart := range blockStarts {
+		blockChecksums := checksums[blockStart]
+		if len(blockChecksums) < 2 || !divergent(blockChecksums) {
+			continue
+		}
+		accum.onReplicaDivergence(index.ReplicaDivergence{
+			ID:         append([]byte(nil), elems[0].ID...),
+			BlockStart: timeConvert(&blockStart),
+			Checksums:  blockChecksums,
+		})
+	}
+}
+
+func divergent(checksums map[string]uint32) bool {
+	var (
+		first uint32
+		seen  bool
+	)
+	for _, checksum := range checksums {
+		if seen && checksum != first {
+			return true
+		}
+		first, seen = checksum, true
+	}
+	return false
+}
+
 func (accum *fetchTaggedResultAccumulator) sliceResponsesAsSeriesIter(
 	pools fetchTaggedPools,
 	elems fetchTaggedIDResults,
+) encoding.SeriesIterator {
+	return newFetchTaggedSeriesIterator(pools, elems, accum.startTime, accum.endTime)
+}
+
+// newFetchTaggedSeriesIterator returns a series iterator over the responses
+// of each replica for a single ID.
+func newFetchTaggedSeriesIterator(
+	pools fetchTaggedPools,
+	elems fetchTaggedIDResults,
+	startTime time.Time,
+	endTime time.Time,
 ) encoding.SeriesIterator {
 	numElems := len(elems)
 	iters := pools.MultiReaderIteratorArray().Get(numElems)[:numElems]
@@ -242,8 +395,8 @@ func (accum *fetchTaggedResultAccumulator) sliceResponsesAsSeriesIter(
 		ID:             pools.ID().BinaryID(tsID),
 		Namespace:      pools.ID().BinaryID(nsID),
 		Tags:           decoder,
-		StartInclusive: accum.startTime,
-		EndExclusive:   accum.endTime,
+		StartInclusive: startTime,
+		EndExclusive:   endTime,
 		Replicas:       iters,
 	})
 
@@ -256,6 +409,7 @@ func (accum *fetchTaggedResultAccumulator) AsEncodingSeriesIterators(
 	results := fetchTaggedIDResultsSortedByID(accum.responses)
 	sort.Sort(results)
 	accum.responses = fetchTaggedIDResults(results)
+	limit = accum.pageLimit(limit)
 
 	numElements := 0
 	accum.responses.forEachID(func(_ fetchTaggedIDResults, _ bool) bool {
@@ -265,17 +419,25 @@ func (accum *fetchTaggedResultAccumulator) AsEncodingSeriesIterators(
 
 	result := pools.MutableSeriesIterators().Get(numElements)
 	result.Reset(numElements)
-	count := 0
-	moreElems := false
+	var (
+		count     = 0
+		moreElems = false
+		lastID    []byte
+	)
 	accum.responses.forEachID(func(elems fetchTaggedIDResults, hasMore bool) bool {
+		if accum.onReplicaDivergence != nil {
+			accum.compareReplicas(elems)
+		}
 		seriesIter := accum.sliceResponsesAsSeriesIter(pools, elems)
 		result.SetAt(count, seriesIter)
 		count++
 		moreElems = hasMore
+		lastID = elems[0].ID
 		return count < limit
 	})
 
-	exhaustive := accum.exhaustive && count <= limit && !moreElems
+	exhaustive := accum.pageExhaustive(count <= limit && !moreElems, lastID)
+	accum.reportExceededLimit(exhaustive)
 	return result, exhaustive, nil
 }
 
@@ -287,21 +449,66 @@ func (accum *fetchTaggedResultAccumulator) AsTaggedIDsIterator(
 		iter      = newTaggedIDsIterator(pools)
 		count     = 0
 		moreElems = false
+		lastID    []byte
 	)
 	results := fetchTaggedIDResultsSortedByID(accum.responses)
 	sort.Sort(results)
 	accum.responses = fetchTaggedIDResults(results)
+	limit = accum.pageLimit(limit)
 	accum.responses.forEachID(func(elems fetchTaggedIDResults, hasMore bool) bool {
 		iter.addBacking(elems[0].NameSpace, elems[0].ID, elems[0].EncodedTags)
 		count++
 		moreElems = hasMore
+		lastID = elems[0].ID
 		return count < limit
 	})
 
-	exhaustive := accum.exhaustive && count <= limit && !moreElems
+	exhaustive := accum.pageExhaustive(count <= limit && !moreElems, lastID)
+	accum.reportExceededLimit(exhaustive)
 	return iter, exhaustive, nil
 }
 
+// pageLimit returns the limit bounded by the page size of a paginated fetch.
+func (accum *fetchTaggedResultAccumulator) pageLimit(limit int) int {
+	if accum.pageSize > 0 && accum.pageSize < limit {
+		return accum.pageSize
+	}
+	return limit
+}
+
+// pageExhaustive returns whether the results are exhaustive given whether
+// every series of the responses fit within the limit. For a paginated fetch
+// the series after the page are not left out but fetched with the next
+// page, which resumes after the last ID of the page. Pages of hosts stopped
+// by a limit also resume after the last ID, but are not exhaustive.
+func (accum *fetchTaggedResultAccumulator) pageExhaustive(
+	withinLimit bool,
+	lastID []byte,
+) bool {
+	accum.nextPageToken = nil
+	if accum.pageSize <= 0 {
+		return accum.exhaustive && withinLimit
+	}
+	if lastID != nil && (!withinLimit || accum.morePages) {
+		accum.nextPageToken = index.NewPageToken(lastID)
+	}
+	return accum.exhaustive
+}
+
+// reportExceededLimit calls onLimitExceeded with the limit that caused the
+// results to not be exhaustive, series results truncated by the client are
+// reported as the series limit.
+func (accum *fetchTaggedResultAccumulator) reportExceededLimit(exhaustive bool) {
+	if exhaustive || accum.onLimitExceeded == nil {
+		return
+	}
+	limit := accum.exceededLimit
+	if limit == index.QueryLimitNone {
+		limit = index.QueryLimitSeries
+	}
+	accum.onLimitExceeded(limit)
+}
+
 type fetchTaggedShardConsistencyResults []fetchTaggedShardConsistencyResult
 
 func (res fetchTaggedShardConsistencyResults) initialize(length int) fetchTaggedShardConsistencyResults {
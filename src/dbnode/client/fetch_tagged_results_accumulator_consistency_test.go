@@ -22,13 +22,17 @@ package client
 
 import (
 	"fmt"
+	"sort"
 	"testing"
 	"time"
 
 	"github.com/m3db/m3/src/dbnode/generated/thrift/rpc"
+	"github.com/m3db/m3/src/dbnode/storage/index"
 	"github.com/m3db/m3/src/dbnode/topology"
 	tu "github.com/m3db/m3/src/dbnode/topology/testutil"
 	"github.com/m3db/m3cluster/shard"
+
+	"github.com/stretchr/testify/require"
 )
 
 var (
@@ -453,3 +457,89 @@ func TestFetchTaggedResultsAccumulatorReadConsitencyLevelMajority(t *testing.T)
 		},
 	}.run()
 }
+
+func TestFetchTaggedResultsAccumulatorRestrictToShards(t *testing.T) {
+	// rf=1, 30 shards total; each host owns half of the shards
+	topoMap := tu.MustNewTopologyMap(1, map[string][]shard.Shard{
+		"testhost0": tu.ShardsRange(0, 14, shard.Available),
+		"testhost1": tu.ShardsRange(15, 29, shard.Available),
+	})
+
+	accum := newFetchTaggedResultAccumulator()
+	accum.Clear()
+	accum.Reset(testStartTime, testEndTime, topoMap, topoMap.MajorityReplicas(),
+		topology.ReadConsistencyLevelAll)
+	accum.restrictToShards([]uint32{3, 3, 7, 100}, 1)
+
+	// the response of the host owning the shards is enough
+	done, err := accum.Add(fetchTaggedResultAccumulatorOpts{
+		host:     host(t, topoMap, "testhost0"),
+		response: &testFetchTaggedSuccessResponse,
+	}, nil)
+	require.NoError(t, err)
+	require.True(t, done)
+}
+
+func TestFetchTaggedResultsAccumulatorVerifyReplicas(t *testing.T) {
+	// rf=2, 30 shards total; two identical hosts
+	topoMap := tu.MustNewTopologyMap(2, map[string][]shard.Shard{
+		"testhost0": tu.ShardsRange(0, 29, shard.Available),
+		"testhost1": tu.ShardsRange(0, 29, shard.Available),
+	})
+
+	var divergences []index.ReplicaDivergence
+	accum := newFetchTaggedResultAccumulator()
+	accum.Clear()
+	accum.Reset(testStartTime, testEndTime, topoMap, topoMap.MajorityReplicas(),
+		topology.ReadConsistencyLevelOne)
+	accum.verifyReplicas(func(d index.ReplicaDivergence) {
+		divergences = append(divergences, d)
+	})
+
+	block := func(start int64, tail string) *rpc.Segments {
+		return &rpc.Segments{Merged: &rpc.Segment{
+			Head:      []byte("head"),
+			Tail:      []byte(tail),
+			StartTime: &start,
+		}}
+	}
+	response := func(tails ...string) *rpc.FetchTaggedResult_ {
+		elem := &rpc.FetchTaggedIDResult_{ID: []byte("foo")}
+		for i, tail := range tails {
+			elem.Segments = append(elem.Segments, block(int64(i), tail))
+		}
+		// unmerged data is not compared
+		elem.Segments = append(elem.Segments, &rpc.Segments{
+			Unmerged: []*rpc.Segment{{Head: []byte(tails[0])}},
+		})
+		return &rpc.FetchTaggedResult_{Elements: []*rpc.FetchTaggedIDResult_{elem}}
+	}
+
+	// the response of a single host is enough for consistency level one but
+	// not to compare the replicas
+	done, err := accum.Add(fetchTaggedResultAccumulatorOpts{
+		host:     host(t, topoMap, "testhost0"),
+		response: response("a", "b"),
+	}, nil)
+	require.NoError(t, err)
+	require.False(t, done)
+	done, err = accum.Add(fetchTaggedResultAccumulatorOpts{
+		host:     host(t, topoMap, "testhost1"),
+		response: response("a", "c"),
+	}, nil)
+	require.NoError(t, err)
+	require.True(t, done)
+
+	results := fetchTaggedIDResultsSortedByID(accum.responses)
+	sort.Sort(results)
+	fetchTaggedIDResults(results).forEachID(func(elems fetchTaggedIDResults, _ bool) bool {
+		accum.compareReplicas(elems)
+		return true
+	})
+	require.Len(t, divergences, 1)
+	require.Equal(t, []byte("foo"), divergences[0].ID)
+	require.Equal(t, time.Unix(0, 1), divergences[0].BlockStart)
+	require.Len(t, divergences[0].Checksums, 2)
+	require.NotEqual(t, divergences[0].Checksums["testhost0"],
+		divergences[0].Checksums["testhost1"])
+}
@@ -28,6 +28,7 @@ import (
 	"github.com/m3db/m3/src/dbnode/encoding"
 	"github.com/m3db/m3/src/dbnode/generated/thrift/rpc"
 	"github.com/m3db/m3/src/dbnode/network/server/tchannelthrift/convert"
+	"github.com/m3db/m3/src/dbnode/storage/index"
 	"github.com/m3db/m3/src/dbnode/topology"
 	"github.com/m3db/m3/src/dbnode/topology/testutil"
 	"github.com/m3db/m3/src/dbnode/ts"
@@ -290,6 +291,40 @@ func TestFetchTaggedResultsAccumulatorSeriesItersDatapointsNSplit(t *testing.T)
 	sg0.assertMatchesEncodingIters(t, iters)
 }
 
+func TestFetchTaggedResultsAccumulatorReportsExceededLimit(t *testing.T) {
+	topoMap := testutil.MustNewTopologyMap(1, map[string][]shard.Shard{
+		"testhost0": testutil.ShardsRange(0, 29, shard.Available),
+	})
+
+	th := newTestFetchTaggedHelper(t)
+	response := newTestSerieses(1, 5).toRPCResult(th, testStartTime, false)
+	response.ExceededLimit = rpc.QueryLimitTypePtr(rpc.QueryLimitType_DOCS)
+	workflow := testFetchTaggedWorkflow{
+		t:         t,
+		topoMap:   topoMap,
+		level:     topology.ReadConsistencyLevelAll,
+		startTime: testStartTime,
+		endTime:   testEndTime,
+		steps: []testFetchTaggedWorklowStep{
+			testFetchTaggedWorklowStep{
+				hostname:     "testhost0",
+				response:     response,
+				expectedDone: true,
+			},
+		},
+	}
+	accum := workflow.run()
+
+	var exceeded []index.QueryLimitType
+	accum.onLimitExceeded = func(limit index.QueryLimitType) {
+		exceeded = append(exceeded, limit)
+	}
+	_, resultsExhaustive, err := accum.AsTaggedIDsIterator(10, th.pools)
+	require.NoError(t, err)
+	require.False(t, resultsExhaustive)
+	require.Equal(t, []index.QueryLimitType{index.QueryLimitDocs}, exceeded)
+}
+
 type testFetchTaggedWorkflow struct {
 	t         *testing.T
 	topoMap   topology.Map
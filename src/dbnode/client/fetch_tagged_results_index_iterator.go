@@ -81,6 +81,17 @@ func (i *taggedIDsIterator) addBacking(nsID, tsID, tags []byte) {
 	i.backing.tags = append(i.backing.tags, tags)
 }
 
+// addBackings appends the IDs of another iterator that has not been iterated.
+func (i *taggedIDsIterator) addBackings(other *taggedIDsIterator) {
+	i.backing.nses = append(i.backing.nses, other.backing.nses...)
+	i.backing.ids = append(i.backing.ids, other.backing.ids...)
+	i.backing.tags = append(i.backing.tags, other.backing.tags...)
+}
+
+func (i *taggedIDsIterator) len() int {
+	return len(i.backing.ids)
+}
+
 func (i *taggedIDsIterator) asIdent(b []byte) ident.ID {
 	wb := i.pools.CheckedBytesWrapper().Get(b)
 	return i.pools.ID().BinaryID(wb)
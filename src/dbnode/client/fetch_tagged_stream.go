@@ -0,0 +1,314 @@
+// Copyright (c) 2018 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package client
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/m3db/m3/src/dbnode/encoding"
+	"github.com/m3db/m3/src/dbnode/storage/index"
+	"github.com/m3db/m3/src/dbnode/topology"
+	"github.com/m3db/m3cluster/shard"
+	xerrors "github.com/m3db/m3x/errors"
+	"github.com/m3db/m3x/ident"
+)
+
+var errFetchTaggedStreamClosed = errors.New("fetch tagged stream is closed")
+
+// FetchTaggedStreamOptions are the options of a streaming tagged fetch.
+type FetchTaggedStreamOptions struct {
+	// RelaxToAvailable yields the series of a shard whose replicas are unable
+	// to meet the read consistency level once every replica has responded,
+	// as long as at least one of them succeeded, rather than failing.
+	RelaxToAvailable bool
+}
+
+type fetchTaggedStreamShard struct {
+	fetchTaggedShardConsistencyResult
+	responses fetchTaggedIDResults
+}
+
+// fetchTaggedStream accumulates the responses of a tagged fetch per shard
+// and yields the series of each shard as soon as its replicas meet the
+// read consistency level.
+type fetchTaggedStream struct {
+	sync.Cond
+	sync.Mutex
+
+	pools            fetchTaggedPools
+	nsID             ident.ID
+	topoMap          topology.Map
+	majority         int
+	consistencyLevel topology.ReadConsistencyLevel
+	relax            bool
+	startTime        time.Time
+	endTime          time.Time
+	limit            int
+
+	shards           []fetchTaggedStreamShard
+	numHostsPending  int
+	numShardsPending int
+	errors           xerrors.Errors
+	exhaustive       bool
+
+	// ready are the series yielded and not yet returned by Next.
+	ready     []encoding.SeriesIterator
+	numSeries int
+	current   encoding.SeriesIterator
+	done      bool
+	err       error
+}
+
+func newFetchTaggedStream(
+	pools fetchTaggedPools,
+	nsID ident.ID,
+	opts index.QueryOptions,
+	limit int,
+	topoMap topology.Map,
+	majority int,
+	consistencyLevel topology.ReadConsistencyLevel,
+	streamOpts FetchTaggedStreamOptions,
+) *fetchTaggedStream {
+	s := &fetchTaggedStream{
+		pools:            pools,
+		nsID:             nsID,
+		topoMap:          topoMap,
+		majority:         majority,
+		consistencyLevel: consistencyLevel,
+		relax:            streamOpts.RelaxToAvailable,
+		startTime:        opts.StartInclusive,
+		endTime:          opts.EndExclusive,
+		limit:            limit,
+		shards:           make([]fetchTaggedStreamShard, 1+int(topoMap.ShardSet().Max())),
+		numHostsPending:  topoMap.HostsLen(),
+		numShardsPending: len(topoMap.ShardSet().All()),
+		exhaustive:       true,
+	}
+	s.L = s // Set the embedded condition locker to the embedded mutex
+	for _, hss := range topoMap.HostShardSets() {
+		for _, hShard := range hss.ShardSet().All() {
+			s.shards[hShard.ID()].enqueued++
+		}
+	}
+	return s
+}
+
+func (s *fetchTaggedStream) completionFn(
+	result interface{},
+	resultErr error,
+) {
+	s.Lock()
+	defer s.Unlock()
+
+	s.numHostsPending--
+	if s.numHostsPending == 0 && s.nsID != nil {
+		// NB: the namespace is referenced by the request until every host
+		// has responded.
+		s.nsID.Finalize()
+		s.nsID = nil
+	}
+
+	if s.done {
+		// i.e. the stream has failed, been closed or yielded every shard, no
+		// need to process any additional responses
+		return
+	}
+
+	opts, ok := result.(fetchTaggedResultAccumulatorOpts)
+	if !ok || opts.host == nil {
+		// should never happen
+		s.finishWithLock(xerrors.NewNonRetryableError(fmt.Errorf(
+			"[invariant violated] expected result to be of type fetchTaggedResultAccumulatorOpts with a host, received: %v", result)))
+		return
+	}
+
+	hostShardSet, ok := s.topoMap.LookupHostShardSet(opts.host.ID())
+	if !ok {
+		// should never happen, as we've taken a reference to the
+		// topology when beginning the request, and the var is immutable.
+		s.finishWithLock(xerrors.NewNonRetryableError(fmt.Errorf(
+			"[invariant violated] missing host shard in fetchTaggedStream completionFn: %s", opts.host.ID())))
+		return
+	}
+
+	if resultErr != nil {
+		s.errors = append(s.errors, xerrors.NewRenamedError(resultErr,
+			fmt.Errorf("error fetching tagged from host %s: %v", opts.host.ID(), resultErr)))
+	} else {
+		s.exhaustive = s.exhaustive && opts.response.Exhaustive
+		shardSet := s.topoMap.ShardSet()
+		for _, elem := range opts.response.Elements {
+			shardID := int(shardSet.Lookup(ident.BytesID(elem.ID)))
+			if shardID >= len(s.shards) || s.shards[shardID].done {
+				continue
+			}
+			s.shards[shardID].responses = append(s.shards[shardID].responses, elem)
+		}
+	}
+
+	for _, hs := range hostShardSet.ShardSet().All() {
+		shardID := int(hs.ID())
+		shardResult := &s.shards[shardID]
+		if shardResult.done {
+			continue
+		}
+
+		// NB: as for fetchTaggedResultAccumulator, only responses from
+		// available shards count towards the consistency of a shard.
+		if hs.State() != shard.Available || resultErr != nil {
+			shardResult.errors++
+		} else {
+			shardResult.success++
+		}
+
+		if !topology.ReadConsistencyTermination(s.consistencyLevel, int32(s.majority),
+			shardResult.pending(), int32(shardResult.success)) {
+			continue
+		}
+
+		shardResult.done = true
+		achieved := topology.ReadConsistencyAchieved(s.consistencyLevel, s.majority,
+			int(shardResult.enqueued), int(shardResult.success))
+		if !achieved && !(s.relax && shardResult.success > 0) {
+			// NB: unlike FetchTagged the stream fails as soon as a shard is
+			// unable to meet the consistency level, since it is not retried.
+			s.finishWithLock(fmt.Errorf(
+				"unable to satisfy consistency requirements for shard %d [ err = %s ]",
+				shardID, s.errors.Error()))
+			return
+		}
+
+		s.numShardsPending--
+		if !s.yieldShardWithLock(shardResult) {
+			// reached the limit
+			s.exhaustive = false
+			s.finishWithLock(nil)
+			return
+		}
+	}
+
+	if s.numShardsPending == 0 {
+		s.finishWithLock(nil)
+		return
+	}
+
+	if s.numHostsPending == 0 {
+		// should never happen, every shard terminates once all of its
+		// replicas have responded.
+		s.finishWithLock(fmt.Errorf(
+			"unable to satisfy consistency requirements for %d shards [ err = %s ]",
+			s.numShardsPending, s.errors.Error()))
+	}
+}
+
+// yieldShardWithLock yields the series of a shard, returning false if the
+// limit was reached before all of them were yielded.
+func (s *fetchTaggedStream) yieldShardWithLock(shardResult *fetchTaggedStreamShard) bool {
+	responses := shardResult.responses
+	shardResult.responses = nil
+	if len(responses) == 0 {
+		return true
+	}
+
+	sort.Sort(fetchTaggedIDResultsSortedByID(responses))
+	withinLimit := true
+	responses.forEachID(func(elems fetchTaggedIDResults, _ bool) bool {
+		if s.numSeries >= s.limit {
+			withinLimit = false
+			return false
+		}
+		s.ready = append(s.ready, newFetchTaggedSeriesIterator(
+			s.pools, elems, s.startTime, s.endTime))
+		s.numSeries++
+		return true
+	})
+	s.Broadcast()
+	return withinLimit
+}
+
+func (s *fetchTaggedStream) finishWithLock(err error) {
+	s.done = true
+	s.err = err
+	for i := range s.shards {
+		s.shards[i].responses = nil
+	}
+	s.Broadcast()
+}
+
+func (s *fetchTaggedStream) Next() bool {
+	s.Lock()
+	defer s.Unlock()
+
+	s.current = nil
+	for len(s.ready) == 0 && !s.done {
+		s.Wait()
+	}
+	if s.err != nil || len(s.ready) == 0 {
+		return false
+	}
+	s.current = s.ready[0]
+	s.ready[0] = nil
+	s.ready = s.ready[1:]
+	return true
+}
+
+func (s *fetchTaggedStream) Current() encoding.SeriesIterator {
+	s.Lock()
+	current := s.current
+	s.Unlock()
+	return current
+}
+
+func (s *fetchTaggedStream) Exhaustive() bool {
+	s.Lock()
+	exhaustive := s.done && s.err == nil && s.exhaustive
+	s.Unlock()
+	return exhaustive
+}
+
+func (s *fetchTaggedStream) Err() error {
+	s.Lock()
+	err := s.err
+	s.Unlock()
+	if err == errFetchTaggedStreamClosed {
+		return nil
+	}
+	return err
+}
+
+func (s *fetchTaggedStream) Close() {
+	s.Lock()
+	defer s.Unlock()
+
+	if !s.done {
+		s.finishWithLock(errFetchTaggedStreamClosed)
+	}
+	for i, iter := range s.ready {
+		iter.Close()
+		s.ready[i] = nil
+	}
+	s.ready = nil
+	s.current = nil
+}
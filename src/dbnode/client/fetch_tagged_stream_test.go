@@ -0,0 +1,186 @@
+// Copyright (c) 2018 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package client
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"testing"
+	"time"
+
+	"github.com/m3db/m3/src/dbnode/encoding"
+	"github.com/m3db/m3/src/dbnode/storage/index"
+	"github.com/m3db/m3/src/dbnode/topology"
+	"github.com/m3db/m3/src/dbnode/topology/testutil"
+	"github.com/m3db/m3cluster/shard"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func newTestFetchTaggedStream(
+	th testFetchTaggedHelper,
+	topoMap topology.Map,
+	level topology.ReadConsistencyLevel,
+	limit int,
+	streamOpts FetchTaggedStreamOptions,
+	start, end time.Time,
+) *fetchTaggedStream {
+	return newFetchTaggedStream(th.pools, nil,
+		index.QueryOptions{StartInclusive: start, EndExclusive: end}, limit,
+		topoMap, topoMap.MajorityReplicas(), level, streamOpts)
+}
+
+// drainTestFetchTaggedStream returns the series of the stream sorted by ID.
+func drainTestFetchTaggedStream(stream *fetchTaggedStream, n int) encoding.SeriesIterators {
+	var iters []encoding.SeriesIterator
+	for (n < 0 || len(iters) < n) && stream.Next() {
+		iters = append(iters, stream.Current())
+	}
+	sort.Slice(iters, func(i, j int) bool {
+		return iters[i].ID().String() < iters[j].ID().String()
+	})
+	return encoding.NewSeriesIterators(iters, nil)
+}
+
+func TestFetchTaggedStreamYieldsShardsAsTheyComplete(t *testing.T) {
+	// rf=1, each host owns half of the shards
+	topoMap := testutil.MustNewTopologyMap(1, map[string][]shard.Shard{
+		"testhost0": testutil.ShardsRange(0, 14, shard.Available),
+		"testhost1": testutil.ShardsRange(15, 29, shard.Available),
+	})
+
+	var (
+		start = time.Now().Add(-time.Hour).Truncate(time.Hour)
+		end   = time.Now().Truncate(time.Hour)
+		th    = newTestFetchTaggedHelper(t)
+
+		sg0, sg1 testSerieses
+	)
+	for _, s := range newTestSerieses(1, 20) {
+		if topoMap.ShardSet().Lookup(s.id) < 15 {
+			sg0 = append(sg0, s)
+		} else {
+			sg1 = append(sg1, s)
+		}
+	}
+	require.NotEmpty(t, sg0)
+	require.NotEmpty(t, sg1)
+	sg0.addDatapoints(10, start, end)
+	sg1.addDatapoints(10, start, end)
+
+	stream := newTestFetchTaggedStream(th, topoMap, topology.ReadConsistencyLevelMajority,
+		maxInt, FetchTaggedStreamOptions{}, start, end)
+	defer stream.Close()
+
+	// The shards of the first host are yielded before the second responds.
+	stream.completionFn(fetchTaggedResultAccumulatorOpts{
+		host:     host(t, topoMap, "testhost0"),
+		response: sg0.toRPCResult(th, start, true),
+	}, nil)
+	sg0.assertMatchesEncodingIters(t, drainTestFetchTaggedStream(stream, len(sg0)))
+
+	stream.completionFn(fetchTaggedResultAccumulatorOpts{
+		host:     host(t, topoMap, "testhost1"),
+		response: sg1.toRPCResult(th, start, true),
+	}, nil)
+	sg1.assertMatchesEncodingIters(t, drainTestFetchTaggedStream(stream, -1))
+
+	require.NoError(t, stream.Err())
+	assert.True(t, stream.Exhaustive())
+}
+
+func TestFetchTaggedStreamRelaxToAvailable(t *testing.T) {
+	// rf=3, 3 identical hosts, with same shards
+	topoMap := testutil.MustNewTopologyMap(3, map[string][]shard.Shard{
+		"testhost0": testutil.ShardsRange(0, 29, shard.Available),
+		"testhost1": testutil.ShardsRange(0, 29, shard.Available),
+		"testhost2": testutil.ShardsRange(0, 29, shard.Available),
+	})
+
+	var (
+		start = time.Now().Add(-time.Hour).Truncate(time.Hour)
+		end   = time.Now().Truncate(time.Hour)
+		th    = newTestFetchTaggedHelper(t)
+		sg    = newTestSerieses(1, 10)
+	)
+	sg.addDatapoints(10, start, end)
+
+	for _, relax := range []bool{false, true} {
+		t.Run(fmt.Sprintf("relax=%v", relax), func(t *testing.T) {
+			stream := newTestFetchTaggedStream(th, topoMap, topology.ReadConsistencyLevelAll,
+				maxInt, FetchTaggedStreamOptions{RelaxToAvailable: relax}, start, end)
+			defer stream.Close()
+
+			stream.completionFn(fetchTaggedResultAccumulatorOpts{
+				host:     host(t, topoMap, "testhost0"),
+				response: sg.toRPCResult(th, start, true),
+			}, nil)
+			stream.completionFn(fetchTaggedResultAccumulatorOpts{
+				host: host(t, topoMap, "testhost1"),
+			}, errors.New("host unavailable"))
+			stream.completionFn(fetchTaggedResultAccumulatorOpts{
+				host:     host(t, topoMap, "testhost2"),
+				response: sg.toRPCResult(th, start, true),
+			}, nil)
+
+			iters := drainTestFetchTaggedStream(stream, -1)
+			if !relax {
+				assert.Equal(t, 0, iters.Len())
+				assert.Error(t, stream.Err())
+				assert.False(t, stream.Exhaustive())
+				return
+			}
+			require.NoError(t, stream.Err())
+			sg.assertMatchesEncodingIters(t, iters)
+			assert.True(t, stream.Exhaustive())
+		})
+	}
+}
+
+func TestFetchTaggedStreamLimit(t *testing.T) {
+	topoMap := testutil.MustNewTopologyMap(1, map[string][]shard.Shard{
+		"testhost0": testutil.ShardsRange(0, 29, shard.Available),
+	})
+
+	var (
+		start = time.Now().Add(-time.Hour).Truncate(time.Hour)
+		end   = time.Now().Truncate(time.Hour)
+		th    = newTestFetchTaggedHelper(t)
+		sg    = newTestSerieses(1, 10)
+	)
+	sg.addDatapoints(10, start, end)
+
+	stream := newTestFetchTaggedStream(th, topoMap, topology.ReadConsistencyLevelOne,
+		3, FetchTaggedStreamOptions{}, start, end)
+	defer stream.Close()
+
+	stream.completionFn(fetchTaggedResultAccumulatorOpts{
+		host:     host(t, topoMap, "testhost0"),
+		response: sg.toRPCResult(th, start, true),
+	}, nil)
+
+	iters := drainTestFetchTaggedStream(stream, -1)
+	assert.Equal(t, 3, iters.Len())
+	require.NoError(t, stream.Err())
+	assert.False(t, stream.Exhaustive())
+}
@@ -21,6 +21,7 @@
 package client
 
 import (
+	"context"
 	"fmt"
 	"math"
 	"sync"
@@ -32,9 +33,19 @@ import (
 	"github.com/m3db/m3x/ident"
 	"github.com/m3db/m3x/pool"
 
+	opentracing "github.com/opentracing/opentracing-go"
+	tchannel "github.com/uber/tchannel-go"
 	"github.com/uber/tchannel-go/thrift"
 )
 
+const (
+	// maxWriteSpanReferences is the max number of traced writes whose spans
+	// the span of a write batch RPC follows from.
+	maxWriteSpanReferences = 32
+
+	writeTaggedBatchSpanName = "m3db.client.write-tagged-batch"
+)
+
 type queue struct {
 	sync.WaitGroup
 	sync.RWMutex
@@ -308,8 +319,14 @@ func (q *queue) asyncTaggedWrite(
 			return
 		}
 
-		ctx, _ := thrift.NewContext(q.opts.WriteRequestTimeout())
+		ctx, span := q.newTracedWriteContext(writeTaggedBatchSpanName, namespace, ops)
 		err = client.WriteTaggedBatchRaw(ctx, req)
+		if span != nil {
+			if err != nil {
+				span.SetTag("error", true)
+			}
+			span.Finish()
+		}
 		if err == nil {
 			// All succeeded
 			callAllCompletionFns(ops, q.host, nil)
@@ -342,6 +359,42 @@ func (q *queue) asyncTaggedWrite(
 	}()
 }
 
+// newTracedWriteContext returns the context of a write batch RPC, with the
+// span of the RPC if any of the writes are traced. The span follows from the
+// spans of the traced writes and must be finished once the RPC completes.
+func (q *queue) newTracedWriteContext(
+	spanName string,
+	namespace ident.ID,
+	ops []op,
+) (thrift.Context, opentracing.Span) {
+	var refs []opentracing.StartSpanOption
+	for _, op := range ops {
+		wop, ok := op.(*writeTaggedOperation)
+		if !ok || wop.spanCtx == nil {
+			continue
+		}
+		refs = append(refs, opentracing.FollowsFrom(wop.spanCtx))
+		if len(refs) == maxWriteSpanReferences {
+			break
+		}
+	}
+	if len(refs) == 0 {
+		ctx, _ := thrift.NewContext(q.opts.WriteRequestTimeout())
+		return ctx, nil
+	}
+
+	span := opentracing.GlobalTracer().StartSpan(spanName, append(refs,
+		opentracing.Tag{Key: "host", Value: q.host.ID()},
+		opentracing.Tag{Key: "namespace", Value: namespace.String()},
+		opentracing.Tag{Key: "batch.size", Value: len(ops)},
+	)...)
+	// The RPC span of the channel is a child of the span in the context.
+	ctx, _ := tchannel.NewContextBuilder(q.opts.WriteRequestTimeout()).
+		SetParentContext(opentracing.ContextWithSpan(context.Background(), span)).
+		Build()
+	return thrift.Wrap(ctx), span
+}
+
 func (q *queue) asyncWrite(
 	namespace ident.ID,
 	ops []op,
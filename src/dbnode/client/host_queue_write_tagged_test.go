@@ -28,10 +28,13 @@ import (
 	"time"
 
 	"github.com/m3db/m3/src/dbnode/generated/thrift/rpc"
+	"github.com/m3db/m3/src/x/tracing"
 	"github.com/m3db/m3x/ident"
 
 	"github.com/golang/mock/gomock"
+	opentracing "github.com/opentracing/opentracing-go"
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 	"github.com/uber/tchannel-go/thrift"
 )
 
@@ -489,6 +492,62 @@ func TestHostQueueDrainOnCloseTaggedWrite(t *testing.T) {
 	}
 }
 
+type testSpanExporter struct {
+	sync.Mutex
+	spans []tracing.SpanData
+}
+
+func (e *testSpanExporter) Export(span tracing.SpanData) {
+	e.Lock()
+	e.spans = append(e.spans, span)
+	e.Unlock()
+}
+
+func (e *testSpanExporter) Close() error {
+	return nil
+}
+
+func TestHostQueueNewTracedWriteContext(t *testing.T) {
+	opts := newHostQueueTestOptions()
+	queue := newTestHostQueue(opts)
+	namespace := ident.StringID("testNs")
+
+	exporter := &testSpanExporter{}
+	prevTracer := opentracing.GlobalTracer()
+	opentracing.SetGlobalTracer(tracing.NewTracer(1, exporter))
+	defer opentracing.SetGlobalTracer(prevTracer)
+
+	untraced := testWriteTaggedOp("testNs", "foo", map[string]string{"a": "b"},
+		1.0, 1000, rpc.TimeType_UNIX_SECONDS, nil)
+
+	// Batches without traced writes are not traced.
+	ctx, span := queue.newTracedWriteContext(writeTaggedBatchSpanName,
+		namespace, []op{untraced})
+	require.NotNil(t, ctx)
+	assert.Nil(t, span)
+
+	parent, err := tracing.ParseTraceParent(
+		"00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01")
+	require.NoError(t, err)
+	traced := testWriteTaggedOp("testNs", "bar", map[string]string{"c": "d"},
+		2.0, 2000, rpc.TimeType_UNIX_SECONDS, nil)
+	traced.spanCtx = parent
+
+	ctx, span = queue.newTracedWriteContext(writeTaggedBatchSpanName,
+		namespace, []op{untraced, traced})
+	require.NotNil(t, span)
+	assert.Equal(t, span, opentracing.SpanFromContext(ctx))
+	span.Finish()
+
+	require.Len(t, exporter.spans, 1)
+	data := exporter.spans[0]
+	assert.Equal(t, writeTaggedBatchSpanName, data.Name)
+	assert.Equal(t, parent.TraceID, data.Context.TraceID)
+	assert.Equal(t, parent.SpanID, data.ParentSpanID)
+	assert.Equal(t, "testNs", data.Tags["namespace"])
+	assert.Equal(t, 2, data.Tags["batch.size"])
+}
+
 func testWriteTaggedOp(
 	namespace string,
 	id string,
@@ -82,6 +82,14 @@ const (
 	// defaultIdentifierPoolSize is the default identifier pool size
 	defaultIdentifierPoolSize = 8192
 
+	// defaultAsyncWriteWorkerPoolSize is the default number of async write
+	// workers
+	defaultAsyncWriteWorkerPoolSize = 256
+
+	// defaultAsyncWriteQueueSize is the default max number of datapoints
+	// pending to be written asynchronously
+	defaultAsyncWriteQueueSize = 65536
+
 	// defaultWriteOpPoolSize is the default write op pool size
 	defaultWriteOpPoolSize = 65536
 
@@ -198,6 +206,7 @@ type options struct {
 	clusterConnectConsistencyLevel          topology.ConnectConsistencyLevel
 	writeRequestTimeout                     time.Duration
 	fetchRequestTimeout                     time.Duration
+	hedgedReadDelay                         time.Duration
 	truncateRequestTimeout                  time.Duration
 	backgroundConnectInterval               time.Duration
 	backgroundConnectStutter                time.Duration
@@ -217,6 +226,8 @@ type options struct {
 	writeTaggedOperationPoolSize            int
 	fetchBatchOpPoolSize                    int
 	writeBatchSize                          int
+	asyncWriteWorkerPoolSize                int
+	asyncWriteQueueSize                     int
 	fetchBatchSize                          int
 	identifierPool                          ident.Pool
 	hostQueueOpsFlushSize                   int
@@ -232,6 +243,8 @@ type options struct {
 	fetchSeriesBlocksMetadataBatchTimeout   time.Duration
 	fetchSeriesBlocksBatchTimeout           time.Duration
 	fetchSeriesBlocksBatchConcurrency       int
+	fetchSeriesBlocksLimitMbps              float64
+	fetchSeriesBlocksNamespaceLimits        map[string]FetchSeriesBlocksLimit
 }
 
 // NewOptions creates a new set of client options with defaults
@@ -296,6 +309,8 @@ func newOptions() *options {
 		writeTaggedOperationPoolSize:            defaultWriteTaggedOpPoolSize,
 		fetchBatchOpPoolSize:                    defaultFetchBatchOpPoolSize,
 		writeBatchSize:                          DefaultWriteBatchSize,
+		asyncWriteWorkerPoolSize:                defaultAsyncWriteWorkerPoolSize,
+		asyncWriteQueueSize:                     defaultAsyncWriteQueueSize,
 		fetchBatchSize:                          defaultFetchBatchSize,
 		identifierPool:                          idPool,
 		hostQueueOpsFlushSize:                   defaultHostQueueOpsFlushSize,
@@ -499,6 +514,16 @@ func (o *options) FetchRequestTimeout() time.Duration {
 	return o.fetchRequestTimeout
 }
 
+func (o *options) SetHedgedReadDelay(value time.Duration) Options {
+	opts := *o
+	opts.hedgedReadDelay = value
+	return &opts
+}
+
+func (o *options) HedgedReadDelay() time.Duration {
+	return o.hedgedReadDelay
+}
+
 func (o *options) SetTruncateRequestTimeout(value time.Duration) Options {
 	opts := *o
 	opts.truncateRequestTimeout = value
@@ -689,6 +714,26 @@ func (o *options) WriteBatchSize() int {
 	return o.writeBatchSize
 }
 
+func (o *options) SetAsyncWriteWorkerPoolSize(value int) Options {
+	opts := *o
+	opts.asyncWriteWorkerPoolSize = value
+	return &opts
+}
+
+func (o *options) AsyncWriteWorkerPoolSize() int {
+	return o.asyncWriteWorkerPoolSize
+}
+
+func (o *options) SetAsyncWriteQueueSize(value int) Options {
+	opts := *o
+	opts.asyncWriteQueueSize = value
+	return &opts
+}
+
+func (o *options) AsyncWriteQueueSize() int {
+	return o.asyncWriteQueueSize
+}
+
 func (o *options) SetFetchBatchSize(value int) Options {
 	opts := *o
 	opts.fetchBatchSize = value
@@ -838,3 +883,23 @@ func (o *options) SetFetchSeriesBlocksBatchConcurrency(value int) AdminOptions {
 func (o *options) FetchSeriesBlocksBatchConcurrency() int {
 	return o.fetchSeriesBlocksBatchConcurrency
 }
+
+func (o *options) SetFetchSeriesBlocksLimitMbps(value float64) AdminOptions {
+	opts := *o
+	opts.fetchSeriesBlocksLimitMbps = value
+	return &opts
+}
+
+func (o *options) FetchSeriesBlocksLimitMbps() float64 {
+	return o.fetchSeriesBlocksLimitMbps
+}
+
+func (o *options) SetFetchSeriesBlocksNamespaceLimits(value map[string]FetchSeriesBlocksLimit) AdminOptions {
+	opts := *o
+	opts.fetchSeriesBlocksNamespaceLimits = value
+	return &opts
+}
+
+func (o *options) FetchSeriesBlocksNamespaceLimits() map[string]FetchSeriesBlocksLimit {
+	return o.fetchSeriesBlocksNamespaceLimits
+}
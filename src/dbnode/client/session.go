@@ -44,6 +44,7 @@ import (
 	"github.com/m3db/m3/src/dbnode/storage/index"
 	idxconvert "github.com/m3db/m3/src/dbnode/storage/index/convert"
 	"github.com/m3db/m3/src/dbnode/storage/namespace"
+	"github.com/m3db/m3/src/dbnode/storage/repair/hashtree"
 	"github.com/m3db/m3/src/dbnode/topology"
 	"github.com/m3db/m3/src/dbnode/ts"
 	"github.com/m3db/m3/src/dbnode/x/xio"
@@ -61,6 +62,7 @@ import (
 	xsync "github.com/m3db/m3x/sync"
 	xtime "github.com/m3db/m3x/time"
 
+	opentracing "github.com/opentracing/opentracing-go"
 	"github.com/uber-go/tally"
 	"github.com/uber/tchannel-go/thrift"
 )
@@ -166,15 +168,20 @@ type session struct {
 	streamBlocksRetrier              xretry.Retrier
 	pools                            sessionPools
 	fetchBatchSize                   int
+	hedgedReadDelay                  time.Duration
 	newPeerBlocksQueueFn             newPeerBlocksQueueFn
 	reattemptStreamBlocksFromPeersFn reattemptStreamBlocksFromPeersFn
 	pickBestPeerFn                   pickBestPeerFn
 	origin                           topology.Host
 	streamBlocksMaxBlockRetries      int
 	streamBlocksWorkers              xsync.WorkerPool
+	streamBlocksThrottle             *streamThrottle
 	streamBlocksBatchSize            int
 	streamBlocksMetadataBatchTimeout time.Duration
 	streamBlocksBatchTimeout         time.Duration
+	asyncWrites                      chan asyncWrite
+	asyncWritesPending               int64
+	asyncWritesWg                    sync.WaitGroup
 	metrics                          sessionMetrics
 }
 
@@ -188,8 +195,11 @@ type sessionMetrics struct {
 	writeSuccess               tally.Counter
 	writeErrors                tally.Counter
 	writeNodesRespondingErrors []tally.Counter
+	asyncWriteSuccess          tally.Counter
+	asyncWriteErrors           tally.Counter
 	fetchSuccess               tally.Counter
 	fetchErrors                tally.Counter
+	fetchHedged                tally.Counter
 	fetchNodesRespondingErrors []tally.Counter
 	topologyUpdatedSuccess     tally.Counter
 	topologyUpdatedError       tally.Counter
@@ -200,8 +210,11 @@ func newSessionMetrics(scope tally.Scope) sessionMetrics {
 	return sessionMetrics{
 		writeSuccess:           scope.Counter("write.success"),
 		writeErrors:            scope.Counter("write.errors"),
+		asyncWriteSuccess:      scope.Counter("write-async.success"),
+		asyncWriteErrors:       scope.Counter("write-async.errors"),
 		fetchSuccess:           scope.Counter("fetch.success"),
 		fetchErrors:            scope.Counter("fetch.errors"),
+		fetchHedged:            scope.Counter("fetch.hedged"),
 		topologyUpdatedSuccess: scope.Counter("topology.updated-success"),
 		topologyUpdatedError:   scope.Counter("topology.updated-error"),
 		streamFromPeersMetrics: make(map[shardMetricsKey]streamFromPeersMetrics),
@@ -224,6 +237,7 @@ type streamFromPeersMetrics struct {
 	fetchBlockRetriesReqError                         tally.Counter
 	fetchBlockRetriesRespError                        tally.Counter
 	fetchBlockRetriesConsistencyLevelNotAchievedError tally.Counter
+	fetchBlockThrottled                               tally.Timer
 	blocksEnqueueChannel                              tally.Gauge
 }
 
@@ -261,6 +275,7 @@ func newSession(opts Options) (clientSession, error) {
 		log:                  opts.InstrumentOptions().Logger(),
 		newHostQueueFn:       newHostQueue,
 		fetchBatchSize:       opts.FetchBatchSize(),
+		hedgedReadDelay:      opts.HedgedReadDelay(),
 		newPeerBlocksQueueFn: newPeerBlocksQueue,
 		writeRetrier:         opts.WriteRetrier(),
 		fetchRetrier:         opts.FetchRetrier(),
@@ -329,6 +344,8 @@ func newSession(opts Options) (clientSession, error) {
 		s.streamBlocksMetadataBatchTimeout = opts.FetchSeriesBlocksMetadataBatchTimeout()
 		s.streamBlocksBatchTimeout = opts.FetchSeriesBlocksBatchTimeout()
 		s.streamBlocksRetrier = opts.StreamBlocksRetrier()
+		s.streamBlocksThrottle = newStreamThrottle(opts.FetchSeriesBlocksLimitMbps(),
+			opts.FetchSeriesBlocksNamespaceLimits(), s.nowFn)
 	}
 
 	if runtimeOptsMgr := opts.RuntimeOptionsManager(); runtimeOptsMgr != nil {
@@ -407,6 +424,7 @@ func (s *session) newPeerMetadataStreamingProgressMetrics(
 		fetchBlockRetriesConsistencyLevelNotAchievedError: scope.Tagged(map[string]string{
 			"reason": "consistency-level-not-achieved-error",
 		}).Counter("fetch-block-retries"),
+		fetchBlockThrottled:  scope.Timer("fetch-block-throttled"),
 		blocksEnqueueChannel: scope.Gauge("fetch-blocks-enqueue-channel-length"),
 	}
 	s.metrics.streamFromPeersMetrics[mKey] = m
@@ -537,6 +555,13 @@ func (s *session) Open() error {
 	s.pools.seriesIterator.Init()
 	s.pools.seriesIterators = encoding.NewMutableSeriesIteratorsPool(s.opts.SeriesIteratorArrayPoolBuckets())
 	s.pools.seriesIterators.Init()
+
+	s.asyncWrites = make(chan asyncWrite, s.opts.AsyncWriteQueueSize())
+	for i := 0; i < s.opts.AsyncWriteWorkerPoolSize(); i++ {
+		s.asyncWritesWg.Add(1)
+		go s.asyncWriteLoop()
+	}
+
 	s.state.status = statusOpen
 	s.state.Unlock()
 
@@ -862,8 +887,20 @@ func (s *session) Write(
 	value float64,
 	unit xtime.Unit,
 	annotation []byte,
+) error {
+	return s.write(noConsistencyLevelOverrides, namespace, id, t, value, unit, annotation)
+}
+
+func (s *session) write(
+	levels consistencyLevelOverrides,
+	namespace, id ident.ID,
+	t time.Time,
+	value float64,
+	unit xtime.Unit,
+	annotation []byte,
 ) error {
 	w := s.pools.writeAttempt.Get()
+	w.args.levels = levels
 	w.args.attemptType = untaggedWriteAttemptType
 	w.args.namespace, w.args.id = namespace, id
 	w.args.tags = ident.EmptyTagIterator
@@ -881,18 +918,48 @@ func (s *session) WriteTagged(
 	value float64,
 	unit xtime.Unit,
 	annotation []byte,
+) error {
+	return s.writeTagged(noConsistencyLevelOverrides, nil, namespace, id, tags,
+		t, value, unit, annotation)
+}
+
+func (s *session) WriteTaggedTraced(
+	spanCtx opentracing.SpanContext,
+	namespace, id ident.ID,
+	tags ident.TagIterator,
+	t time.Time,
+	value float64,
+	unit xtime.Unit,
+	annotation []byte,
+) error {
+	return s.writeTagged(noConsistencyLevelOverrides, spanCtx, namespace, id, tags,
+		t, value, unit, annotation)
+}
+
+func (s *session) writeTagged(
+	levels consistencyLevelOverrides,
+	spanCtx opentracing.SpanContext,
+	namespace, id ident.ID,
+	tags ident.TagIterator,
+	t time.Time,
+	value float64,
+	unit xtime.Unit,
+	annotation []byte,
 ) error {
 	w := s.pools.writeAttempt.Get()
+	w.args.levels = levels
 	w.args.attemptType = taggedWriteAttemptType
 	w.args.namespace, w.args.id, w.args.tags = namespace, id, tags
 	w.args.t, w.args.value, w.args.unit, w.args.annotation =
 		t, value, unit, annotation
+	w.args.spanCtx = spanCtx
 	err := s.writeRetrier.Attempt(w.attemptFn)
 	s.pools.writeAttempt.Put(w)
 	return err
 }
 
 func (s *session) writeAttempt(
+	levels consistencyLevelOverrides,
 	wType writeAttemptType,
 	namespace, id ident.ID,
 	inputTags ident.TagIterator,
@@ -900,6 +967,7 @@ func (s *session) writeAttempt(
 	value float64,
 	unit xtime.Unit,
 	annotation []byte,
+	spanCtx opentracing.SpanContext,
 ) error {
 	timeType, timeTypeErr := convert.ToTimeType(unit)
 	if timeTypeErr != nil {
@@ -918,7 +986,8 @@ func (s *session) writeAttempt(
 	}
 
 	state, majority, enqueued, err := s.writeAttemptWithRLock(
-		wType, namespace, id, inputTags, timestamp, value, timeType, annotation)
+		levels, wType, namespace, id, inputTags, timestamp, value, timeType, annotation,
+		spanCtx)
 	s.state.RUnlock()
 
 	if err != nil {
@@ -946,6 +1015,7 @@ func (s *session) writeAttempt(
 // is transferred to the calling function, and is expected to manage the lifecycle of
 // of the object (including releasing the lock/decRef'ing it).
 func (s *session) writeAttemptWithRLock(
+	levels consistencyLevelOverrides,
 	wType writeAttemptType,
 	namespace, id ident.ID,
 	inputTags ident.TagIterator,
@@ -953,6 +1023,7 @@ func (s *session) writeAttemptWithRLock(
 	value float64,
 	timeType rpc.TimeType,
 	annotation []byte,
+	spanCtx opentracing.SpanContext,
 ) (*writeState, int32, int32, error) {
 	var (
 		majority = int32(s.state.majority)
@@ -1001,6 +1072,7 @@ func (s *session) writeAttemptWithRLock(
 		wop.request.Datapoint.Timestamp = timestamp
 		wop.request.Datapoint.TimestampTimeType = timeType
 		wop.request.Datapoint.Annotation = annotation
+		wop.spanCtx = spanCtx
 		op = wop
 	default:
 		// should never happen
@@ -1008,7 +1080,7 @@ func (s *session) writeAttemptWithRLock(
 	}
 
 	state := s.pools.writeState.Get()
-	state.consistencyLevel = s.state.writeLevel
+	state.consistencyLevel = levels.writeLevelWithRLock(s)
 	state.topoMap = s.state.topoMap
 	state.incRef()
 
@@ -1051,9 +1123,18 @@ func (s *session) Fetch(
 	namespace ident.ID,
 	id ident.ID,
 	startInclusive, endExclusive time.Time,
+) (encoding.SeriesIterator, error) {
+	return s.fetch(noConsistencyLevelOverrides, namespace, id, startInclusive, endExclusive)
+}
+
+func (s *session) fetch(
+	levels consistencyLevelOverrides,
+	namespace ident.ID,
+	id ident.ID,
+	startInclusive, endExclusive time.Time,
 ) (encoding.SeriesIterator, error) {
 	tsIDs := ident.NewIDsIterator(id)
-	results, err := s.FetchIDs(namespace, tsIDs, startInclusive, endExclusive)
+	results, err := s.fetchIDs(levels, namespace, tsIDs, startInclusive, endExclusive)
 	if err != nil {
 		return nil, err
 	}
@@ -1070,8 +1151,18 @@ func (s *session) FetchIDs(
 	namespace ident.ID,
 	ids ident.Iterator,
 	startInclusive, endExclusive time.Time,
+) (encoding.SeriesIterators, error) {
+	return s.fetchIDs(noConsistencyLevelOverrides, namespace, ids, startInclusive, endExclusive)
+}
+
+func (s *session) fetchIDs(
+	levels consistencyLevelOverrides,
+	namespace ident.ID,
+	ids ident.Iterator,
+	startInclusive, endExclusive time.Time,
 ) (encoding.SeriesIterators, error) {
 	f := s.pools.fetchAttempt.Get()
+	f.args.levels = levels
 	f.args.namespace, f.args.ids = namespace, ids
 	f.args.start, f.args.end = startInclusive, endExclusive
 	err := s.fetchRetrier.Attempt(f.attemptFn)
@@ -1083,31 +1174,132 @@ func (s *session) FetchIDs(
 func (s *session) FetchTagged(
 	ns ident.ID, q index.Query, opts index.QueryOptions,
 ) (encoding.SeriesIterators, bool, error) {
+	return s.fetchTagged(noConsistencyLevelOverrides, ns, q, opts)
+}
+
+func (s *session) fetchTagged(
+	levels consistencyLevelOverrides,
+	ns ident.ID, q index.Query, opts index.QueryOptions,
+) (encoding.SeriesIterators, bool, error) {
+	if opts.PageSize <= 0 {
+		iters, exhaustive, _, err := s.fetchTaggedPage(levels, ns, q, opts)
+		return iters, exhaustive, err
+	}
+
+	// NB: the series of every page are held by the result, callers that can
+	// process the series a page at a time use FetchTaggedPages instead.
+	var iters []encoding.SeriesIterator
+	exhaustive, err := s.fetchTaggedPages(levels, ns, q, opts,
+		func(page encoding.SeriesIterators) error {
+			// Take ownership of the iterators of the page before closing it.
+			pageIters := page.Iters()
+			for i, iter := range pageIters {
+				iters = append(iters, iter)
+				pageIters[i] = nil
+			}
+			page.Close()
+			return nil
+		})
+	if err != nil {
+		encoding.NewSeriesIterators(iters, nil).Close()
+		return nil, false, err
+	}
+
+	result := s.pools.MutableSeriesIterators().Get(len(iters))
+	result.Reset(len(iters))
+	for i, iter := range iters {
+		result.SetAt(i, iter)
+	}
+	return result, exhaustive, nil
+}
+
+func (s *session) FetchTaggedPages(
+	ns ident.ID, q index.Query, opts index.QueryOptions, fn FetchTaggedPageFn,
+) (bool, error) {
+	return s.fetchTaggedPages(noConsistencyLevelOverrides, ns, q, opts, fn)
+}
+
+// fetchTaggedPages fetches the series matching the query a page at a time
+// until there are no more pages or the limit is reached, calling fn with
+// each page before fetching the next so that hosts and the caller hold at
+// most a page of series in memory for each request.
+func (s *session) fetchTaggedPages(
+	levels consistencyLevelOverrides,
+	ns ident.ID, q index.Query, opts index.QueryOptions,
+	fn FetchTaggedPageFn,
+) (bool, error) {
+	if opts.PageSize <= 0 {
+		page, exhaustive, _, err := s.fetchTaggedPage(levels, ns, q, opts)
+		if err != nil {
+			return false, err
+		}
+		return exhaustive, fn(page)
+	}
+
+	var (
+		fetched    int
+		exhaustive = true
+		pageOpts   = opts
+	)
+	for {
+		pageOpts.PageSize, pageOpts.Limit = nextPageSize(opts, fetched), 0
+		page, pageExhaustive, nextPageToken, err := s.fetchTaggedPage(levels, ns, q, pageOpts)
+		if err != nil {
+			return false, err
+		}
+		fetched += page.Len()
+		if err := fn(page); err != nil {
+			return false, err
+		}
+
+		// NB: a page stopped by a docs or bytes limit is not exhaustive but
+		// still resumes the query, each page is bounded by the limits.
+		exhaustive = exhaustive && pageExhaustive
+		if len(nextPageToken) == 0 {
+			break
+		}
+		if opts.Limit > 0 && fetched >= opts.Limit {
+			exhaustive = false
+			reportLimitExceeded(opts, index.QueryLimitSeries)
+			break
+		}
+		pageOpts.PageToken = nextPageToken
+	}
+	return exhaustive, nil
+}
+
+func (s *session) fetchTaggedPage(
+	levels consistencyLevelOverrides,
+	ns ident.ID, q index.Query, opts index.QueryOptions,
+) (encoding.SeriesIterators, bool, index.PageToken, error) {
 	f := s.pools.fetchTaggedAttempt.Get()
+	f.args.levels = levels
 	f.args.ns = ns
 	f.args.query = q
 	f.args.opts = opts
 	err := s.fetchRetrier.Attempt(f.dataAttemptFn)
 	iters, exhaustive := f.dataResultIters, f.dataResultExhaustive
+	nextPageToken := f.resultNextPageToken
 	s.pools.fetchTaggedAttempt.Put(f)
-	return iters, exhaustive, err
+	return iters, exhaustive, nextPageToken, err
 }
 
 func (s *session) fetchTaggedAttempt(
+	levels consistencyLevelOverrides,
 	ns ident.ID, q index.Query, opts index.QueryOptions,
-) (encoding.SeriesIterators, bool, error) {
+) (encoding.SeriesIterators, bool, index.PageToken, error) {
 	s.state.RLock()
 	if s.state.status != statusOpen {
 		s.state.RUnlock()
-		return nil, false, errSessionStatusNotOpen
+		return nil, false, nil, errSessionStatusNotOpen
 	}
 
 	const fetchData = true
-	fetchState, err := s.fetchTaggedAttemptWithRLock(ns, q, opts, fetchData)
+	fetchState, err := s.fetchTaggedAttemptWithRLock(levels, ns, q, opts, fetchData)
 	s.state.RUnlock()
 
 	if err != nil {
-		return nil, false, err
+		return nil, false, nil, err
 	}
 
 	// it's safe to Wait() here, as we still hold the lock on fetchState, after it's
@@ -1117,43 +1309,157 @@ func (s *session) fetchTaggedAttempt(
 	// must Unlock before calling `asEncodingSeriesIterators` as the latter needs to acquire
 	// the fetchState Lock
 	fetchState.Unlock()
-	iters, exhaustive, err := fetchState.asEncodingSeriesIterators(s.pools)
+	iters, exhaustive, nextPageToken, err := fetchState.asEncodingSeriesIterators(s.pools)
 
 	// must Unlock() before decRef'ing, as the latter releases the fetchState back into a
 	// pool if ref count == 0.
 	fetchState.decRef()
 
-	return iters, exhaustive, err
+	return iters, exhaustive, nextPageToken, err
 }
 
 func (s *session) FetchTaggedIDs(
 	ns ident.ID, q index.Query, opts index.QueryOptions,
 ) (TaggedIDsIterator, bool, error) {
+	return s.fetchTaggedIDs(noConsistencyLevelOverrides, ns, q, opts)
+}
+
+func (s *session) fetchTaggedIDs(
+	levels consistencyLevelOverrides,
+	ns ident.ID, q index.Query, opts index.QueryOptions,
+) (TaggedIDsIterator, bool, error) {
+	if opts.PageSize <= 0 {
+		iter, exhaustive, _, err := s.fetchTaggedIDsPage(levels, ns, q, opts)
+		return iter, exhaustive, err
+	}
+
+	// NB: the IDs of every page are held by the result, callers that can
+	// process the IDs a page at a time use FetchTaggedIDsPages instead.
+	iter := newTaggedIDsIterator(s.pools)
+	exhaustive, err := s.fetchTaggedIDsPages(levels, ns, q, opts,
+		func(page TaggedIDsIterator) error {
+			pageIter, ok := page.(*taggedIDsIterator)
+			if !ok {
+				return fmt.Errorf(
+					"[invariant violated] unexpected tagged IDs iterator type: %T", page)
+			}
+			iter.addBackings(pageIter)
+			return nil
+		})
+	if err != nil {
+		iter.Finalize()
+		return nil, false, err
+	}
+	return iter, exhaustive, nil
+}
+
+func (s *session) FetchTaggedIDsPages(
+	ns ident.ID, q index.Query, opts index.QueryOptions, fn FetchTaggedIDsPageFn,
+) (bool, error) {
+	return s.fetchTaggedIDsPages(noConsistencyLevelOverrides, ns, q, opts, fn)
+}
+
+// fetchTaggedIDsPages fetches the IDs matching the query a page at a time
+// until there are no more pages or the limit is reached, calling fn with
+// each page before fetching the next.
+func (s *session) fetchTaggedIDsPages(
+	levels consistencyLevelOverrides,
+	ns ident.ID, q index.Query, opts index.QueryOptions,
+	fn FetchTaggedIDsPageFn,
+) (bool, error) {
+	if opts.PageSize <= 0 {
+		page, exhaustive, _, err := s.fetchTaggedIDsPage(levels, ns, q, opts)
+		if err != nil {
+			return false, err
+		}
+		err = fn(page)
+		page.Finalize()
+		return exhaustive, err
+	}
+
+	var (
+		fetched    int
+		exhaustive = true
+		pageOpts   = opts
+	)
+	for {
+		pageOpts.PageSize, pageOpts.Limit = nextPageSize(opts, fetched), 0
+		page, pageExhaustive, nextPageToken, err := s.fetchTaggedIDsPage(levels, ns, q, pageOpts)
+		if err != nil {
+			return false, err
+		}
+		if pageIter, ok := page.(*taggedIDsIterator); ok {
+			fetched += pageIter.len()
+		}
+		err = fn(page)
+		page.Finalize()
+		if err != nil {
+			return false, err
+		}
+
+		exhaustive = exhaustive && pageExhaustive
+		if len(nextPageToken) == 0 {
+			break
+		}
+		if opts.Limit > 0 && fetched >= opts.Limit {
+			exhaustive = false
+			reportLimitExceeded(opts, index.QueryLimitSeries)
+			break
+		}
+		pageOpts.PageToken = nextPageToken
+	}
+	return exhaustive, nil
+}
+
+func (s *session) fetchTaggedIDsPage(
+	levels consistencyLevelOverrides,
+	ns ident.ID, q index.Query, opts index.QueryOptions,
+) (TaggedIDsIterator, bool, index.PageToken, error) {
 	f := s.pools.fetchTaggedAttempt.Get()
+	f.args.levels = levels
 	f.args.ns = ns
 	f.args.query = q
 	f.args.opts = opts
 	err := s.fetchRetrier.Attempt(f.idsAttemptFn)
 	iter, exhaustive := f.idsResultIter, f.idsResultExhaustive
+	nextPageToken := f.resultNextPageToken
 	s.pools.fetchTaggedAttempt.Put(f)
-	return iter, exhaustive, err
+	return iter, exhaustive, nextPageToken, err
+}
+
+// nextPageSize returns the page size of the next page of a paginated fetch,
+// bounded by the series remaining before the limit of the fetch.
+func nextPageSize(opts index.QueryOptions, fetched int) int {
+	if remaining := opts.Limit - fetched; opts.Limit > 0 && remaining < opts.PageSize {
+		return remaining
+	}
+	return opts.PageSize
+}
+
+// reportLimitExceeded calls the limit exceeded callback of the query options
+// with the limit, if set.
+func reportLimitExceeded(opts index.QueryOptions, limit index.QueryLimitType) {
+	if opts.OnLimitExceeded != nil {
+		opts.OnLimitExceeded(limit)
+	}
 }
 
 func (s *session) fetchTaggedIDsAttempt(
+	levels consistencyLevelOverrides,
 	ns ident.ID, q index.Query, opts index.QueryOptions,
-) (TaggedIDsIterator, bool, error) {
+) (TaggedIDsIterator, bool, index.PageToken, error) {
 	s.state.RLock()
 	if s.state.status != statusOpen {
 		s.state.RUnlock()
-		return nil, false, errSessionStatusNotOpen
+		return nil, false, nil, errSessionStatusNotOpen
 	}
 
 	const fetchData = false
-	fetchState, err := s.fetchTaggedAttemptWithRLock(ns, q, opts, fetchData)
+	fetchState, err := s.fetchTaggedAttemptWithRLock(levels, ns, q, opts, fetchData)
 	s.state.RUnlock()
 
 	if err != nil {
-		return nil, false, err
+		return nil, false, nil, err
 	}
 
 	// it's safe to Wait() here, as we still hold the lock on fetchState, after it's
@@ -1163,19 +1469,81 @@ func (s *session) fetchTaggedIDsAttempt(
 	// must Unlock before calling `asIndexQueryResults` as the latter needs to acquire
 	// the fetchState Lock
 	fetchState.Unlock()
-	iter, exhaustive, err := fetchState.asTaggedIDsIterator(s.pools)
+	iter, exhaustive, nextPageToken, err := fetchState.asTaggedIDsIterator(s.pools)
 
 	// must Unlock() before decRef'ing, as the latter releases the fetchState back into a
 	// pool if ref count == 0.
 	fetchState.decRef()
 
-	return iter, exhaustive, err
+	return iter, exhaustive, nextPageToken, err
+}
+
+// FetchTaggedStream is not retried as series may have been returned by the
+// stream by the time it fails.
+func (s *session) FetchTaggedStream(
+	ns ident.ID,
+	q index.Query,
+	opts index.QueryOptions,
+	streamOpts FetchTaggedStreamOptions,
+) (FetchTaggedStreamIterator, error) {
+	return s.fetchTaggedStream(noConsistencyLevelOverrides, ns, q, opts, streamOpts)
+}
+
+func (s *session) fetchTaggedStream(
+	levels consistencyLevelOverrides,
+	ns ident.ID,
+	q index.Query,
+	opts index.QueryOptions,
+	streamOpts FetchTaggedStreamOptions,
+) (FetchTaggedStreamIterator, error) {
+	s.state.RLock()
+	defer s.state.RUnlock()
+	if s.state.status != statusOpen {
+		return nil, errSessionStatusNotOpen
+	}
+
+	// NB: the stream finalizes the namespace once every host has responded.
+	nsClone := s.pools.id.Clone(ns)
+
+	const fetchData = true
+	req, err := convert.ToRPCFetchTaggedRequest(nsClone, q, opts, fetchData)
+	if err != nil {
+		nsClone.Finalize()
+		return nil, xerrors.NewNonRetryableError(err)
+	}
+
+	limit := maxInt
+	if req.Limit != nil {
+		limit = int(*req.Limit)
+	}
+	stream := newFetchTaggedStream(s.pools, nsClone, opts, limit, s.state.topoMap,
+		s.state.majority, levels.readLevelWithRLock(s), streamOpts)
+
+	op := s.pools.fetchTaggedOp.Get()
+	op.incRef() // indicate current go-routine has a reference to the op
+	op.update(req, stream.completionFn)
+	for _, hq := range s.state.queues {
+		if err := hq.Enqueue(op); err != nil {
+			op.decRef() // release the ref for the current go-routine
+			stream.Close()
+
+			// NB: if this happens we have a bug, once we are in the read
+			// lock the current queues should never be closed
+			wrappedErr := fmt.Errorf("[invariant violated] failed to enqueue fetchTagged: %v", err)
+			s.log.Errorf(wrappedErr.Error())
+			return nil, wrappedErr
+		}
+	}
+	op.decRef() // release the ref for the current go-routine
+
+	return stream, nil
 }
 
 // NB(prateek): the returned fetchState, if valid, still holds the lock. Its ownership
 // is transferred to the calling function, and is expected to manage the lifecycle of
 // of the object (including releasing the lock/decRef'ing it).
 func (s *session) fetchTaggedAttemptWithRLock(
+	levels consistencyLevelOverrides,
 	ns ident.ID,
 	q index.Query,
 	opts index.QueryOptions,
@@ -1206,9 +1574,24 @@ func (s *session) fetchTaggedAttemptWithRLock(
 	op.incRef()               // indicate current go-routine has a reference to the op
 	op.update(req, fetchState.completionFn)
 
-	fetchState.Reset(opts.StartInclusive, opts.EndExclusive, op, topoMap, s.state.majority, s.state.readLevel)
+	fetchState.Reset(opts.StartInclusive, opts.EndExclusive, op, topoMap, s.state.majority,
+		levels.readLevelWithRLock(s))
+
+	queues := s.state.queues
+	if len(opts.Shards) > 0 {
+		if shardQueues := queuesOwningShards(queues, topoMap, opts.Shards); len(shardQueues) > 0 {
+			queues = shardQueues
+			fetchState.tagResultAccumulator.restrictToShards(opts.Shards, len(queues))
+		}
+	}
+	if opts.OnReplicaDivergence != nil {
+		fetchState.tagResultAccumulator.verifyReplicas(opts.OnReplicaDivergence)
+	}
+	fetchState.tagResultAccumulator.onLimitExceeded = opts.OnLimitExceeded
+	fetchState.tagResultAccumulator.pageSize = opts.PageSize
+
 	fetchState.Lock()
-	for _, hq := range s.state.queues {
+	for _, hq := range queues {
 		// inc to indicate the hostQueue has a reference to `op` which has a ref to the fetchState
 		fetchState.incRef()
 		if err := hq.Enqueue(op); err != nil {
@@ -1232,7 +1615,33 @@ func (s *session) fetchTaggedAttemptWithRLock(
 	return fetchState, nil
 }
 
+// queuesOwningShards returns the queues of the hosts owning any of the shards.
+func queuesOwningShards(
+	queues []hostQueue,
+	topoMap topology.Map,
+	shards []uint32,
+) []hostQueue {
+	owners := make(map[string]struct{}, len(queues))
+	for _, hss := range topoMap.HostShardSets() {
+		for _, shard := range shards {
+			if _, err := hss.ShardSet().LookupStateByID(shard); err == nil {
+				owners[hss.Host().ID()] = struct{}{}
+				break
+			}
+		}
+	}
+
+	owning := make([]hostQueue, 0, len(owners))
+	for _, hq := range queues {
+		if _, ok := owners[hq.Host().ID()]; ok {
+			owning = append(owning, hq)
+		}
+	}
+	return owning
+}
+
 func (s *session) fetchIDsAttempt(
+	levels consistencyLevelOverrides,
 	inputNamespace ident.ID,
 	inputIDs ident.Iterator,
 	startInclusive, endExclusive time.Time,
@@ -1248,6 +1657,9 @@ func (s *session) fetchIDsAttempt(
 		majority               int32
 		consistencyLevel       topology.ReadConsistencyLevel
 		fetchBatchOpsByHostIdx [][]*fetchBatchOp
+		hedger                 *fetchHedger
+		hedgeRequired          int
+		routes                 []int
 		success                = false
 	)
 
@@ -1294,9 +1706,17 @@ func (s *session) fetchIDsAttempt(
 	// while it is filling.
 	fetchBatchOpsByHostIdx = s.pools.fetchBatchOpArrayArray.Get()
 
-	consistencyLevel = s.state.readLevel
+	consistencyLevel = levels.readLevelWithRLock(s)
 	majority = int32(s.state.majority)
 
+	if s.hedgedReadDelay > 0 {
+		// Only fetch from the replicas required to achieve the consistency
+		// level at first and defer the fetches from the other replicas
+		// until the hedged read delay elapses.
+		hedger = newFetchHedger(s, s.state.topoMap, namespace, rangeStart, rangeEnd)
+		hedgeRequired = hedgedReadRequired(consistencyLevel, s.state.majority)
+	}
+
 	// NB(prateek): namespaceAccessors tracks the number of pending accessors for nsID.
 	// It is set to incremented by `replica` for each requested ID during fetch enqueuing,
 	// and once by initial request, and is decremented for each replica retrieved, inside
@@ -1410,7 +1830,7 @@ func (s *session) fetchIDsAttempt(
 			// to iter.Reset down below before setting the iterator in the results array,
 			// which would cause a nil pointer exception.
 			remaining := atomic.AddInt32(&pending, -1)
-			shouldTerminate := topology.ReadConsistencyTermination(s.state.readLevel, majority, remaining, snapshotSuccess)
+			shouldTerminate := topology.ReadConsistencyTermination(consistencyLevel, majority, remaining, snapshotSuccess)
 			if shouldTerminate && atomic.CompareAndSwapInt32(&wgIsDone, 0, 1) {
 				allCompletionFn()
 			}
@@ -1426,7 +1846,15 @@ func (s *session) fetchIDsAttempt(
 			}
 		}
 
+		routes = routes[:0]
 		if err := s.state.topoMap.RouteForEach(tsID, func(hostIdx int, host topology.Host) {
+			routes = append(routes, hostIdx)
+		}); err != nil {
+			routeErr = err
+			break
+		}
+
+		for replica, hostIdx := range routes {
 			// Inc safely as this for each is sequential
 			enqueued++
 			pending++
@@ -1435,6 +1863,13 @@ func (s *session) fetchIDsAttempt(
 			namespaceAccessors++
 			idAccessors++
 
+			// NB: rotate the replicas fetched from first by the index of
+			// the ID to spread the load of the fetches across replicas.
+			if hedger != nil && (replica+idx)%len(routes) >= hedgeRequired {
+				hedger.add(hostIdx, tsID, &wgIsDone, completionFn)
+				continue
+			}
+
 			ops := fetchBatchOpsByHostIdx[hostIdx]
 
 			var f *fetchBatchOp
@@ -1457,9 +1892,6 @@ func (s *session) fetchIDsAttempt(
 
 			// Append IDWithNamespace to this request
 			f.append(namespace.Bytes(), tsID.Bytes(), completionFn)
-		}); err != nil {
-			routeErr = err
-			break
 		}
 
 		// Once we've enqueued we know how many to expect so retrieve and set length
@@ -1469,6 +1901,9 @@ func (s *session) fetchIDsAttempt(
 
 	if routeErr != nil {
 		s.state.RUnlock()
+		if hedger != nil {
+			hedger.cancel(routeErr)
+		}
 		return nil, routeErr
 	}
 
@@ -1491,11 +1926,24 @@ func (s *session) fetchIDsAttempt(
 
 	if enqueueErr != nil {
 		s.log.Errorf("failed to enqueue fetch: %v", enqueueErr)
+		if hedger != nil {
+			hedger.cancel(enqueueErr)
+		}
 		return nil, enqueueErr
 	}
 
+	if hedger != nil {
+		hedger.start(s.hedgedReadDelay)
+	}
+
 	wg.Wait()
 
+	if hedger != nil {
+		// Release the deferred fetches of the IDs whose read completed
+		// before the hedged read delay elapsed.
+		hedger.cancel(errHedgedReadNotRequired)
+	}
+
 	resultErrLock.RLock()
 	retErr := resultErr
 	resultErrLock.RUnlock()
@@ -1553,6 +2001,11 @@ func (s *session) Close() error {
 	topo := s.state.topo
 	s.state.Unlock()
 
+	// NB: wait for the async writes in flight before closing the queues they
+	// are written to, writes still queued fail as the session is closed.
+	close(s.asyncWrites)
+	s.asyncWritesWg.Wait()
+
 	for _, q := range queues {
 		q.Close()
 	}
@@ -1703,7 +2156,7 @@ func (s *session) FetchBootstrapBlocksMetadataFromPeers(
 ) (PeerBlockMetadataIter, error) {
 	level := newSessionBootstrapRuntimeReadConsistencyLevel(s)
 	return s.fetchBlocksMetadataFromPeers(namespace,
-		shard, start, end, level, resultOpts, version)
+		shard, start, end, level, resultOpts, version, nil)
 }
 
 func (s *session) FetchBlocksMetadataFromPeers(
@@ -1716,7 +2169,103 @@ func (s *session) FetchBlocksMetadataFromPeers(
 ) (PeerBlockMetadataIter, error) {
 	level := newStaticRuntimeReadConsistencyLevel(consistencyLevel)
 	return s.fetchBlocksMetadataFromPeers(namespace,
-		shard, start, end, level, resultOpts, version)
+		shard, start, end, level, resultOpts, version, nil)
+}
+
+func (s *session) FetchBlocksMetadataFromPeersInBuckets(
+	namespace ident.ID,
+	shard uint32,
+	start, end time.Time,
+	consistencyLevel topology.ReadConsistencyLevel,
+	resultOpts result.Options,
+	buckets hashtree.BucketFilter,
+) (PeerBlockMetadataIter, error) {
+	level := newStaticRuntimeReadConsistencyLevel(consistencyLevel)
+	return s.fetchBlocksMetadataFromPeers(namespace, shard, start, end,
+		level, resultOpts, FetchBlocksMetadataEndpointV2, &buckets)
+}
+
+func (s *session) FetchBlocksHashTreesFromPeers(
+	namespace ident.ID,
+	shard uint32,
+	start, end time.Time,
+	depth int,
+) ([]PeerBlocksHashTrees, error) {
+	peers, err := s.peersForShard(shard)
+	if err != nil {
+		return nil, err
+	}
+
+	var (
+		wg      sync.WaitGroup
+		results = make([]PeerBlocksHashTrees, len(peers.peers))
+	)
+	for idx, peer := range peers.peers {
+		idx := idx
+		peer := peer
+
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			trees, err := s.fetchBlocksHashTreesFromPeer(namespace, shard,
+				peer, start, end, depth)
+			results[idx] = PeerBlocksHashTrees{
+				Host:  peer.Host(),
+				Trees: trees,
+				Err:   err,
+			}
+		}()
+	}
+
+	wg.Wait()
+	return results, nil
+}
+
+func (s *session) fetchBlocksHashTreesFromPeer(
+	namespace ident.ID,
+	shard uint32,
+	peer peer,
+	start, end time.Time,
+	depth int,
+) (*hashtree.BlockTrees, error) {
+	trees, err := hashtree.NewBlockTrees(depth)
+	if err != nil {
+		return nil, err
+	}
+
+	var (
+		result     *rpc.FetchBlocksHashTreeRawResult_
+		attemptErr error
+	)
+	checkedAttemptFn := func(client rpc.TChanNode) {
+		tctx, _ := thrift.NewContext(s.streamBlocksMetadataBatchTimeout)
+		req := rpc.NewFetchBlocksHashTreeRawRequest()
+		req.NameSpace = namespace.Bytes()
+		req.Shard = int32(shard)
+		req.RangeStart = start.UnixNano()
+		req.RangeEnd = end.UnixNano()
+		req.Depth = int32(depth)
+		result, attemptErr = client.FetchBlocksHashTreeRaw(tctx, req)
+	}
+
+	fetchFn := func() error {
+		borrowErr := peer.BorrowConnection(checkedAttemptFn)
+		return xerrors.FirstError(borrowErr, attemptErr)
+	}
+	if err := s.streamBlocksRetrier.Attempt(fetchFn); err != nil {
+		return nil, err
+	}
+
+	for _, elem := range result.Elements {
+		tree, err := hashtree.NewTreeFromNodes(depth, elem.Nodes)
+		if err != nil {
+			return nil, err
+		}
+		if err := trees.Set(time.Unix(0, elem.Start), tree); err != nil {
+			return nil, err
+		}
+	}
+	return trees, nil
 }
 
 func (s *session) fetchBlocksMetadataFromPeers(
@@ -1726,6 +2275,7 @@ func (s *session) fetchBlocksMetadataFromPeers(
 	level runtimeReadConsistencyLevel,
 	resultOpts result.Options,
 	version FetchBlocksMetadataEndpointVersion,
+	buckets *hashtree.BucketFilter,
 ) (PeerBlockMetadataIter, error) {
 	peers, err := s.peersForShard(shard)
 	if err != nil {
@@ -1741,7 +2291,7 @@ func (s *session) fetchBlocksMetadataFromPeers(
 	)
 	go func() {
 		errCh <- s.streamBlocksMetadataFromPeers(namespace, shard,
-			peers, start, end, level, metadataCh, resultOpts, m, version)
+			peers, start, end, level, metadataCh, resultOpts, m, version, buckets)
 		close(metadataCh)
 		close(errCh)
 	}()
@@ -1802,7 +2352,7 @@ func (s *session) FetchBootstrapBlocksFromPeers(
 	errCh := make(chan error, 1)
 	go func() {
 		errCh <- s.streamBlocksMetadataFromPeers(nsMetadata.ID(), shard,
-			peers, start, end, level, metadataCh, opts, progress, version)
+			peers, start, end, level, metadataCh, opts, progress, version, nil)
 		close(metadataCh)
 	}()
 
@@ -1913,6 +2463,7 @@ func (s *session) streamBlocksMetadataFromPeers(
 	resultOpts result.Options,
 	progress *streamFromPeersMetrics,
 	version FetchBlocksMetadataEndpointVersion,
+	buckets *hashtree.BucketFilter,
 ) error {
 	var (
 		wg        sync.WaitGroup
@@ -1986,7 +2537,7 @@ func (s *session) streamBlocksMetadataFromPeers(
 						peer, start, end, currPageToken, metadataCh, progress)
 				case FetchBlocksMetadataEndpointV2:
 					currPageToken, err = s.streamBlocksMetadataFromPeerV2(namespace, shardID,
-						peer, start, end, currPageToken, metadataCh, resultOpts, progress)
+						peer, start, end, currPageToken, metadataCh, resultOpts, progress, buckets)
 				default:
 					// Should never happen - we validate the version before this function is
 					// ever called
@@ -2200,6 +2751,7 @@ func (s *session) streamBlocksMetadataFromPeerV2(
 	metadataCh chan<- receivedBlockMetadata,
 	resultOpts result.Options,
 	progress *streamFromPeersMetrics,
+	buckets *hashtree.BucketFilter,
 ) (pageToken, error) {
 	var pageToken []byte
 	if startPageToken != nil {
@@ -2223,7 +2775,18 @@ func (s *session) streamBlocksMetadataFromPeerV2(
 		// Only used for logs
 		peerStr              = peer.Host().ID()
 		metadataCountByBlock = map[xtime.UnixNano]int64{}
+
+		// Only set when restricting the metadata to a set of hash tree buckets
+		optionHashTreeDepth   *int32
+		optionHashTreeBuckets []int32
 	)
+	if buckets != nil {
+		depth := int32(buckets.Depth())
+		optionHashTreeDepth = &depth
+		for _, b := range buckets.Buckets() {
+			optionHashTreeBuckets = append(optionHashTreeBuckets, int32(b))
+		}
+	}
 	defer func() {
 		for block, numMetadata := range metadataCountByBlock {
 			s.log.WithFields(
@@ -2248,6 +2811,8 @@ func (s *session) streamBlocksMetadataFromPeerV2(
 		req.IncludeSizes = &optionIncludeSizes
 		req.IncludeChecksums = &optionIncludeChecksums
 		req.IncludeLastRead = &optionIncludeLastRead
+		req.HashTreeDepth = optionHashTreeDepth
+		req.HashTreeBuckets = optionHashTreeBuckets
 
 		progress.metadataFetchBatchCall.Inc(1)
 		result, err := client.FetchBlocksMetadataRawV2(tctx, req)
@@ -2857,6 +3422,11 @@ func (s *session) streamBlocksBatchFromPeer(
 		return
 	}
 
+	// Respect the namespace concurrency limit for the duration of the
+	// request and any throttling of the response
+	release := s.streamBlocksThrottle.acquire(namespaceMetadata.ID())
+	defer release()
+
 	// Attempt request
 	if err := retrier.Attempt(func() error {
 		var attemptErr error
@@ -2885,6 +3455,13 @@ func (s *session) streamBlocksBatchFromPeer(
 		return
 	}
 
+	// Throttle by the size of the blocks received so that streaming
+	// does not saturate the network or starve other namespaces
+	if throttled := s.streamBlocksThrottle.wait(namespaceMetadata.ID(),
+		fetchBlocksRawResultSize(result)); throttled > 0 {
+		m.fetchBlockThrottled.Record(throttled)
+	}
+
 	// Parse and act on result
 	tooManyIDsLogged := false
 	for i := range result.Elements {
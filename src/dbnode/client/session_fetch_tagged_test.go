@@ -538,6 +538,97 @@ func TestSessionFetchTaggedMergeWithRetriesTest(t *testing.T) {
 	assert.NoError(t, session.Close())
 }
 
+func TestSessionFetchTaggedIDsPaginated(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	opts := newSessionTestOptions()
+	opts = opts.SetReadConsistencyLevel(topology.ReadConsistencyLevelAll)
+	s, err := newSession(opts)
+	assert.NoError(t, err)
+	session := s.(*session)
+
+	start := time.Now().Truncate(time.Hour)
+	end := start.Add(2 * time.Hour)
+
+	var (
+		sg0 = newTestSerieses(1, 5)
+		sg1 = newTestSerieses(6, 8)
+		th  = newTestFetchTaggedHelper(t)
+	)
+
+	topoInit := opts.TopologyInitializer()
+	topoWatch, err := topoInit.Init()
+	require.NoError(t, err)
+	topoMap := topoWatch.Get()
+	require.Equal(t, 3, topoMap.HostsLen()) // the code below assumes this
+
+	// The first host has the series of the first page and more after it,
+	// the second host has the series of the second page.
+	respond := func(
+		response func(req rpc.FetchTaggedRequest) *rpc.FetchTaggedResult_,
+	) testEnqueue {
+		return testEnqueue{
+			enqueueFn: func(idx int, op op) {
+				req := op.(*fetchTaggedOp).request
+				go func() {
+					op.CompletionFn()(fetchTaggedResultAccumulatorOpts{
+						host:     topoMap.Hosts()[idx],
+						response: response(req),
+					}, nil)
+				}()
+			},
+		}
+	}
+	empty := func(rpc.FetchTaggedRequest) *rpc.FetchTaggedResult_ {
+		return testSerieses{}.toRPCResult(th, start, true)
+	}
+	mockExtendedHostQueues(
+		t, ctrl, session, sessionTestReplicas,
+		testHostQueueOpsByHost{
+			testHostName(0): &testHostQueueOps{
+				enqueues: []testEnqueue{
+					respond(func(req rpc.FetchTaggedRequest) *rpc.FetchTaggedResult_ {
+						assert.Equal(t, int64(5), req.GetPageSize())
+						assert.Empty(t, req.PageToken)
+						result := sg0.toRPCResult(th, start, true)
+						result.NextPageToken = index.NewPageToken(sg0[4].id.Bytes())
+						return result
+					}),
+					respond(func(req rpc.FetchTaggedRequest) *rpc.FetchTaggedResult_ {
+						assert.Equal(t, []byte(index.NewPageToken(sg0[4].id.Bytes())),
+							req.PageToken)
+						return testSerieses{}.toRPCResult(th, start, true)
+					}),
+				},
+			},
+			testHostName(1): &testHostQueueOps{
+				enqueues: []testEnqueue{
+					respond(empty),
+					respond(func(rpc.FetchTaggedRequest) *rpc.FetchTaggedResult_ {
+						return sg1.toRPCResult(th, start, true)
+					}),
+				},
+			},
+			testHostName(2): &testHostQueueOps{
+				enqueues: []testEnqueue{respond(empty), respond(empty)},
+			},
+		})
+
+	assert.NoError(t, session.Open())
+
+	queryOpts := testSessionFetchTaggedQueryOpts(start, end)
+	queryOpts.PageSize = 5
+	iter, exhaust, err := session.FetchTaggedIDs(ident.StringID("namespace"),
+		testSessionFetchTaggedQuery, queryOpts)
+	require.NoError(t, err)
+	assert.True(t, exhaust)
+	expected := append(sg0, sg1...)
+	require.True(t, expected.indexMatcher().Matches(iter))
+
+	assert.NoError(t, session.Close())
+}
+
 func injectLeakcheckFetchTaggedAttempPool(session *session) *leakcheckFetchTaggedAttemptPool {
 	leakPool := newLeakcheckFetchTaggedAttemptPool(leakcheckFetchTaggedAttemptPoolOpts{}, session.pools.fetchTaggedAttempt)
 	session.pools.fetchTaggedAttempt = leakPool
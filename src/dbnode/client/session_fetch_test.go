@@ -192,6 +192,65 @@ func TestSessionFetchIDsWithRetries(t *testing.T) {
 	assert.NoError(t, session.Close())
 }
 
+func TestSessionFetchIDsHedgedRead(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	scope := tally.NewTestScope("", nil)
+	opts := newSessionTestOptions().
+		SetReadConsistencyLevel(topology.ReadConsistencyLevelOne).
+		SetHedgedReadDelay(10 * time.Millisecond)
+	opts = opts.SetInstrumentOptions(opts.InstrumentOptions().
+		SetMetricsScope(scope))
+	s, err := newSession(opts)
+	assert.NoError(t, err)
+	session := s.(*session)
+
+	start := time.Now().Truncate(time.Hour)
+	end := start.Add(2 * time.Hour)
+
+	fetches := testFetches([]testFetch{
+		{"foo", []testValue{
+			{1.0, start.Add(1 * time.Second), xtime.Second, []byte{1, 2, 3}},
+			{2.0, start.Add(2 * time.Second), xtime.Second, nil},
+			{3.0, start.Add(3 * time.Second), xtime.Second, nil},
+		}},
+	})
+
+	opsCh := make(chan *fetchBatchOp, sessionTestReplicas)
+	enqueueFn := func(idx int, op op) {
+		fetch, ok := op.(*fetchBatchOp)
+		assert.True(t, ok)
+		opsCh <- fetch
+	}
+	mockHostQueues(ctrl, session, sessionTestReplicas, []testEnqueueFn{enqueueFn})
+
+	// The fetch is sent to a single replica which never responds, the
+	// other replicas are only fetched from once the hedged read delay
+	// elapses.
+	slow := make(chan *fetchBatchOp, 1)
+	hedged := make(chan *fetchBatchOp, 1)
+	go func() {
+		slow <- <-opsCh
+		first := <-opsCh
+		fulfillTszFetchBatchOps(t, fetches, []*fetchBatchOp{first}, 0)
+		hedged <- <-opsCh
+	}()
+
+	assert.NoError(t, session.Open())
+
+	results, err := session.FetchIDs(ident.StringID(testNamespaceName), fetches.IDsIter(), start, end)
+	assert.NoError(t, err)
+	assertFetchResults(t, start, end, fetches, results)
+
+	for _, op := range []*fetchBatchOp{<-slow, <-hedged} {
+		op.completeAll(nil, fmt.Errorf("random failure"))
+	}
+	assert.Equal(t, int64(2), scope.Snapshot().Counters()["fetch.hedged+"].Value())
+
+	assert.NoError(t, session.Close())
+}
+
 func TestSessionFetchIDsTrimsWindowsInTimeWindow(t *testing.T) {
 	ctrl := gomock.NewController(t)
 	defer ctrl.Finish()
@@ -308,6 +367,54 @@ func TestSessionFetchReadConsistencyLevelOne(t *testing.T) {
 	testFetchConsistencyLevel(t, ctrl, topology.ReadConsistencyLevelOne, 3, outcomeFail)
 }
 
+func TestSessionFetchReadConsistencyLevelOverride(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	opts := newSessionTestOptions().
+		SetReadConsistencyLevel(topology.ReadConsistencyLevelAll)
+	s, err := newSession(opts)
+	assert.NoError(t, err)
+	session := s.(*session)
+
+	start := time.Now().Truncate(time.Hour)
+	end := start.Add(2 * time.Hour)
+
+	fetches := testFetches([]testFetch{
+		{"foo", []testValue{
+			{1.0, start.Add(1 * time.Second), xtime.Second, []byte{1, 2, 3}},
+			{2.0, start.Add(2 * time.Second), xtime.Second, nil},
+			{3.0, start.Add(3 * time.Second), xtime.Second, nil},
+		}},
+	})
+
+	fetchBatchOps, enqueueWg := prepareTestFetchEnqueues(t, ctrl, session, fetches)
+
+	go func() {
+		// Fail two of the three replicas which only meets read consistency
+		// level one
+		enqueueWg.Wait()
+		fulfillTszFetchBatchOps(t, fetches, *fetchBatchOps, 2)
+	}()
+
+	assert.NoError(t, session.Open())
+
+	view := session.WithReadConsistencyLevel(topology.ReadConsistencyLevelOne)
+	results, err := view.FetchIDs(ident.StringID(testNamespaceName),
+		fetches.IDsIter(), start, end)
+	assert.NoError(t, err)
+	assertFetchResults(t, start, end, fetches, results)
+
+	// Closing the view does not close the session.
+	assert.NoError(t, view.Close())
+
+	_, err = session.WithReadConsistencyLevel(topology.ReadConsistencyLevel(-1)).
+		FetchIDs(ident.StringID(testNamespaceName), fetches.IDsIter(), start, end)
+	assert.Error(t, err)
+
+	assert.NoError(t, session.Close())
+}
+
 func testFetchConsistencyLevel(
 	t *testing.T,
 	ctrl *gomock.Controller,
@@ -0,0 +1,118 @@
+// Copyright (c) 2018 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package client
+
+import (
+	"testing"
+	"time"
+
+	"github.com/m3db/m3/src/dbnode/topology"
+	"github.com/m3db/m3x/ident"
+	xtest "github.com/m3db/m3x/test"
+	xtime "github.com/m3db/m3x/time"
+
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSessionWriteAsyncNotOpenError(t *testing.T) {
+	s := newDefaultTestSession(t)
+
+	err := s.WriteAsync(ident.StringID("namespace"), []AsyncWrite{
+		{ID: ident.StringID("foo"), Timestamp: time.Now(), Value: 1, Unit: xtime.Second},
+	}, func([]AsyncWrite, []error) {
+		require.FailNow(t, "unexpected completion")
+	})
+	assert.Equal(t, errSessionStatusNotOpen, err)
+}
+
+func TestSessionWriteAsync(t *testing.T) {
+	ctrl := gomock.NewController(xtest.Reporter{t})
+	defer ctrl.Finish()
+
+	s := newDefaultTestSession(t).(*session)
+	var hosts []topology.Host
+	completeFn := func(idx int, op op) {
+		go func() {
+			op.CompletionFn()(hosts[idx], nil)
+		}()
+	}
+	mockHostQueues(ctrl, s, sessionTestReplicas, []testEnqueueFn{completeFn, completeFn})
+	require.NoError(t, s.Open())
+
+	s.state.RLock()
+	hosts = s.state.topoMap.Hosts()
+	s.state.RUnlock()
+
+	var (
+		now    = time.Now()
+		writes = []AsyncWrite{
+			{ID: ident.StringID("foo"), Timestamp: now, Value: 1, Unit: xtime.Second},
+			{
+				ID:        ident.StringID("bar"),
+				Tags:      ident.MustNewTagStringsIterator("baz", "qux"),
+				Timestamp: now,
+				Value:     2,
+				Unit:      xtime.Second,
+			},
+		}
+		doneCh = make(chan []error, 1)
+	)
+	require.NoError(t, s.WriteAsync(ident.StringID("namespace"), writes,
+		func(completed []AsyncWrite, errs []error) {
+			assert.Equal(t, writes, completed)
+			doneCh <- errs
+		}))
+
+	select {
+	case errs := <-doneCh:
+		require.Len(t, errs, 2)
+		assert.NoError(t, errs[0])
+		assert.NoError(t, errs[1])
+	case <-time.After(10 * time.Second):
+		require.FailNow(t, "timed out waiting for async writes")
+	}
+
+	require.NoError(t, s.Close())
+}
+
+func TestSessionWriteAsyncQueueFull(t *testing.T) {
+	ctrl := gomock.NewController(xtest.Reporter{t})
+	defer ctrl.Finish()
+
+	opts := newSessionTestOptions().SetAsyncWriteQueueSize(1)
+	s := newTestSession(t, opts).(*session)
+	mockHostQueues(ctrl, s, sessionTestReplicas, nil)
+	require.NoError(t, s.Open())
+
+	now := time.Now()
+	err := s.WriteAsync(ident.StringID("namespace"), []AsyncWrite{
+		{ID: ident.StringID("foo"), Timestamp: now, Value: 1, Unit: xtime.Second},
+		{ID: ident.StringID("bar"), Timestamp: now, Value: 2, Unit: xtime.Second},
+	}, func([]AsyncWrite, []error) {
+		require.FailNow(t, "unexpected completion")
+	})
+	assert.Equal(t, ErrAsyncWriteQueueFull, err)
+	assert.Equal(t, int64(0), s.asyncWritesPending)
+
+	require.NoError(t, s.Close())
+}
@@ -0,0 +1,180 @@
+// Copyright (c) 2018 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package client
+
+import (
+	"sync"
+	"time"
+
+	"github.com/m3db/m3/src/dbnode/clock"
+	"github.com/m3db/m3/src/dbnode/generated/thrift/rpc"
+	"github.com/m3db/m3x/ident"
+)
+
+const (
+	// bytesPerMegabit is the number of bytes of a megabit of 10^6 bits, as
+	// in Mbps.
+	bytesPerMegabit = 1000 * 1000 / 8
+
+	// bandwidthLimiterWindow is the minimum window bytes are accounted over
+	// before the limiter resets, resetting only once the window has been paid
+	// for keeps an idle period from allowing an unbounded burst
+	bandwidthLimiterWindow = time.Second
+)
+
+var noopRelease = func() {}
+
+// bandwidthLimiter tracks bytes received in the current window and computes
+// how long a caller must wait to stay within the limit.
+type bandwidthLimiter struct {
+	sync.Mutex
+
+	bytesPerSecond float64
+	nowFn          clock.NowFn
+	windowStart    time.Time
+	windowBytes    int64
+}
+
+func newBandwidthLimiter(limitMbps float64, nowFn clock.NowFn) *bandwidthLimiter {
+	if limitMbps <= 0 {
+		return nil
+	}
+	return &bandwidthLimiter{
+		bytesPerSecond: limitMbps * bytesPerMegabit,
+		nowFn:          nowFn,
+	}
+}
+
+func (l *bandwidthLimiter) target() time.Duration {
+	return time.Duration(float64(time.Second) * float64(l.windowBytes) / l.bytesPerSecond)
+}
+
+// reserve accounts for n bytes and returns how long the caller should wait
+// for the bytes received so far to be within the limit.
+func (l *bandwidthLimiter) reserve(n int64) time.Duration {
+	if l == nil {
+		return 0
+	}
+
+	l.Lock()
+	defer l.Unlock()
+
+	now := l.nowFn()
+	elapsed := now.Sub(l.windowStart)
+	if elapsed >= bandwidthLimiterWindow && elapsed >= l.target() {
+		l.windowStart = now
+		l.windowBytes = 0
+		elapsed = 0
+	}
+
+	l.windowBytes += n
+	if target := l.target(); target > elapsed {
+		return target - elapsed
+	}
+	return 0
+}
+
+type namespaceStreamThrottle struct {
+	bandwidth *bandwidthLimiter
+	slots     chan struct{}
+}
+
+// streamThrottle throttles streaming blocks from peers both for the node
+// as a whole and for individual namespaces.
+type streamThrottle struct {
+	node       *bandwidthLimiter
+	namespaces map[string]namespaceStreamThrottle
+	sleepFn    func(time.Duration)
+}
+
+func newStreamThrottle(
+	limitMbps float64,
+	namespaceLimits map[string]FetchSeriesBlocksLimit,
+	nowFn clock.NowFn,
+) *streamThrottle {
+	t := &streamThrottle{
+		node:       newBandwidthLimiter(limitMbps, nowFn),
+		namespaces: make(map[string]namespaceStreamThrottle, len(namespaceLimits)),
+		sleepFn:    time.Sleep,
+	}
+	for ns, limit := range namespaceLimits {
+		nsThrottle := namespaceStreamThrottle{
+			bandwidth: newBandwidthLimiter(limit.LimitMbps, nowFn),
+		}
+		if limit.Concurrency > 0 {
+			nsThrottle.slots = make(chan struct{}, limit.Concurrency)
+		}
+		t.namespaces[ns] = nsThrottle
+	}
+	return t
+}
+
+// acquire blocks until the namespace has capacity for another concurrent
+// batch request and returns a func to release the capacity.
+func (t *streamThrottle) acquire(namespace ident.ID) func() {
+	if t == nil {
+		return noopRelease
+	}
+	ns, ok := t.namespaces[namespace.String()]
+	if !ok || ns.slots == nil {
+		return noopRelease
+	}
+	ns.slots <- struct{}{}
+	return func() {
+		<-ns.slots
+	}
+}
+
+// wait sleeps until n bytes just received are within both the node and
+// the namespace bandwidth limits and returns the duration slept.
+func (t *streamThrottle) wait(namespace ident.ID, n int64) time.Duration {
+	if t == nil {
+		return 0
+	}
+	d := t.node.reserve(n)
+	if ns, ok := t.namespaces[namespace.String()]; ok {
+		if nsWait := ns.bandwidth.reserve(n); nsWait > d {
+			d = nsWait
+		}
+	}
+	if d > 0 {
+		t.sleepFn(d)
+	}
+	return d
+}
+
+func fetchBlocksRawResultSize(result *rpc.FetchBlocksRawResult_) int64 {
+	var size int64
+	for _, elem := range result.Elements {
+		for _, block := range elem.Blocks {
+			if block.Segments == nil {
+				continue
+			}
+			if merged := block.Segments.Merged; merged != nil {
+				size += int64(len(merged.Head) + len(merged.Tail))
+			}
+			for _, unmerged := range block.Segments.Unmerged {
+				size += int64(len(unmerged.Head) + len(unmerged.Tail))
+			}
+		}
+	}
+	return size
+}
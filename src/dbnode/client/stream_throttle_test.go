@@ -0,0 +1,130 @@
+// Copyright (c) 2018 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package client
+
+import (
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/m3db/m3/src/dbnode/generated/thrift/rpc"
+	"github.com/m3db/m3x/ident"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestBandwidthLimiterReserve(t *testing.T) {
+	var (
+		now   = time.Now()
+		nowFn = func() time.Time { return now }
+		l     = newBandwidthLimiter(8, nowFn)
+	)
+	require.NotNil(t, l)
+
+	// 1MB at 8Mbps takes one second to receive
+	assert.Equal(t, time.Second, l.reserve(1000*1000))
+
+	// Half way through the window another 1MB needs another 1.5s
+	now = now.Add(500 * time.Millisecond)
+	assert.Equal(t, 1500*time.Millisecond, l.reserve(1000*1000))
+
+	// Once the window has been paid for the limiter resets
+	now = now.Add(1500 * time.Millisecond)
+	assert.Equal(t, time.Duration(0), l.reserve(0))
+	assert.Equal(t, time.Second, l.reserve(1000*1000))
+}
+
+func TestBandwidthLimiterDisabled(t *testing.T) {
+	l := newBandwidthLimiter(0, time.Now)
+	assert.Nil(t, l)
+	assert.Equal(t, time.Duration(0), l.reserve(1000*1000))
+}
+
+func TestStreamThrottleWaitUsesLongestLimit(t *testing.T) {
+	var (
+		now   = time.Now()
+		nowFn = func() time.Time { return now }
+		slept []time.Duration
+	)
+	throttle := newStreamThrottle(16, map[string]FetchSeriesBlocksLimit{
+		"limited": {LimitMbps: 8},
+	}, nowFn)
+	throttle.sleepFn = func(d time.Duration) {
+		slept = append(slept, d)
+	}
+
+	assert.Equal(t, time.Second, throttle.wait(ident.StringID("limited"), 1000*1000))
+	now = now.Add(2 * time.Second)
+	assert.Equal(t, 500*time.Millisecond, throttle.wait(ident.StringID("other"), 1000*1000))
+	assert.Equal(t, []time.Duration{time.Second, 500 * time.Millisecond}, slept)
+}
+
+func TestStreamThrottleAcquireLimitsConcurrency(t *testing.T) {
+	throttle := newStreamThrottle(0, map[string]FetchSeriesBlocksLimit{
+		"limited": {Concurrency: 1},
+	}, time.Now)
+
+	release := throttle.acquire(ident.StringID("limited"))
+
+	// Unlimited namespaces never block
+	throttle.acquire(ident.StringID("other"))()
+
+	var (
+		wg       sync.WaitGroup
+		acquired = make(chan struct{})
+	)
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		throttle.acquire(ident.StringID("limited"))()
+		close(acquired)
+	}()
+
+	select {
+	case <-acquired:
+		require.FailNow(t, "acquired while namespace at concurrency limit")
+	case <-time.After(50 * time.Millisecond):
+	}
+
+	release()
+	wg.Wait()
+}
+
+func TestFetchBlocksRawResultSize(t *testing.T) {
+	result := &rpc.FetchBlocksRawResult_{
+		Elements: []*rpc.Blocks{
+			{Blocks: []*rpc.Block{
+				{Segments: &rpc.Segments{
+					Merged: &rpc.Segment{Head: make([]byte, 3), Tail: make([]byte, 2)},
+				}},
+				{Err: &rpc.Error{}},
+			}},
+			{Blocks: []*rpc.Block{
+				{Segments: &rpc.Segments{Unmerged: []*rpc.Segment{
+					{Head: make([]byte, 4), Tail: make([]byte, 1)},
+					{Head: make([]byte, 10)},
+				}}},
+			}},
+		},
+	}
+	assert.Equal(t, int64(20), fetchBlocksRawResultSize(result))
+}
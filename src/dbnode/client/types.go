@@ -32,6 +32,7 @@ import (
 	"github.com/m3db/m3/src/dbnode/storage/bootstrap/result"
 	"github.com/m3db/m3/src/dbnode/storage/index"
 	"github.com/m3db/m3/src/dbnode/storage/namespace"
+	"github.com/m3db/m3/src/dbnode/storage/repair/hashtree"
 	"github.com/m3db/m3/src/dbnode/topology"
 	"github.com/m3db/m3x/context"
 	"github.com/m3db/m3x/ident"
@@ -40,6 +41,7 @@ import (
 	xretry "github.com/m3db/m3x/retry"
 	xtime "github.com/m3db/m3x/time"
 
+	opentracing "github.com/opentracing/opentracing-go"
 	tchannel "github.com/uber/tchannel-go"
 )
 
@@ -66,6 +68,17 @@ type Session interface {
 	// WriteTagged value to the database for an ID and given tags.
 	WriteTagged(namespace, id ident.ID, tags ident.TagIterator, t time.Time, value float64, unit xtime.Unit, annotation []byte) error
 
+	// WriteTaggedTraced is WriteTagged as part of a trace, the RPCs that
+	// write the value follow from the span of the span context.
+	WriteTaggedTraced(spanCtx opentracing.SpanContext, namespace, id ident.ID, tags ident.TagIterator, t time.Time, value float64, unit xtime.Unit, annotation []byte) error
+
+	// WriteAsync writes a batch of datapoints without blocking, the writes are
+	// batched with other writes to each host and fn is called with the result
+	// of each datapoint once all of them have been written or failed. An error
+	// is returned if the session is not open or the async write queue is full,
+	// in which case fn is not called.
+	WriteAsync(namespace ident.ID, writes []AsyncWrite, fn AsyncWriteCompletionFn) error
+
 	// Fetch values from the database for an ID
 	Fetch(namespace, id ident.ID, startInclusive, endExclusive time.Time) (encoding.SeriesIterator, error)
 
@@ -78,6 +91,32 @@ type Session interface {
 	// FetchTaggedIDs resolves the provided query to known IDs.
 	FetchTaggedIDs(namespace ident.ID, q index.Query, opts index.QueryOptions) (iter TaggedIDsIterator, exhaustive bool, err error)
 
+	// FetchTaggedPages resolves the provided query to known IDs, and fetches the data
+	// for them a page of at most opts.PageSize series at a time, calling fn with each
+	// page before fetching the next so that at most a page of series is held at once.
+	FetchTaggedPages(namespace ident.ID, q index.Query, opts index.QueryOptions, fn FetchTaggedPageFn) (exhaustive bool, err error)
+
+	// FetchTaggedIDsPages resolves the provided query to known IDs a page of at most
+	// opts.PageSize IDs at a time, calling fn with each page before fetching the next.
+	FetchTaggedIDsPages(namespace ident.ID, q index.Query, opts index.QueryOptions, fn FetchTaggedIDsPageFn) (exhaustive bool, err error)
+
+	// FetchTaggedStream resolves the provided query to known IDs, and streams the data
+	// for them shard by shard as soon as the replicas of each shard meet the read
+	// consistency level, rather than once the replicas of every shard do.
+	FetchTaggedStream(namespace ident.ID, q index.Query, opts index.QueryOptions, streamOpts FetchTaggedStreamOptions) (FetchTaggedStreamIterator, error)
+
+	// WithWriteConsistencyLevel returns a view of the session whose writes use the
+	// write consistency level rather than that of the session, so that the level
+	// can be chosen per operation. The view shares the connections of the session
+	// and closing it does not close the session.
+	WithWriteConsistencyLevel(level topology.ConsistencyLevel) Session
+
+	// WithReadConsistencyLevel returns a view of the session whose fetches use the
+	// read consistency level rather than that of the session, so that the level
+	// can be chosen per operation. The view shares the connections of the session
+	// and closing it does not close the session.
+	WithReadConsistencyLevel(level topology.ReadConsistencyLevel) Session
+
 	// ShardID returns the given shard for an ID for callers
 	// to easily discern what shard is failing when operations
 	// for given IDs begin failing
@@ -106,6 +145,37 @@ type TaggedIDsIterator interface {
 	Finalize()
 }
 
+// FetchTaggedPageFn is called with the series of each page of a paginated tagged
+// fetch, ownership of which is transferred to it, returning an error stops the fetch.
+type FetchTaggedPageFn func(page encoding.SeriesIterators) error
+
+// FetchTaggedIDsPageFn is called with the IDs of each page of a paginated tagged
+// fetch, the iterator is finalized once it returns, returning an error stops the fetch.
+type FetchTaggedIDsPageFn func(page TaggedIDsIterator) error
+
+// FetchTaggedStreamIterator iterates over the series of a streaming tagged fetch
+// as the responses of their replicas arrive.
+type FetchTaggedStreamIterator interface {
+	// Next blocks until the next series is available and returns whether there is one.
+	Next() bool
+
+	// Current returns the current series, ownership of which is transferred to the
+	// caller who must close it.
+	Current() encoding.SeriesIterator
+
+	// Exhaustive returns whether every series matching the query was returned, it is
+	// only valid once Next has returned false.
+	Exhaustive() bool
+
+	// Err returns any error encountered, series returned before the error must be
+	// considered incomplete.
+	Err() error
+
+	// Close stops the stream and closes the series not yet returned, it must be
+	// called once the caller is done with the stream.
+	Close()
+}
+
 // AdminClient can create administration sessions
 type AdminClient interface {
 	Client
@@ -144,6 +214,13 @@ type PeerBlocksIter interface {
 	Err() error
 }
 
+// PeerBlocksHashTrees is the hash trees of the blocks of a shard from a peer
+type PeerBlocksHashTrees struct {
+	Host  topology.Host
+	Trees *hashtree.BlockTrees
+	Err   error
+}
+
 // AdminSession can perform administrative and node-to-node operations
 type AdminSession interface {
 	Session
@@ -191,6 +268,27 @@ type AdminSession interface {
 		version FetchBlocksMetadataEndpointVersion,
 	) (PeerBlockMetadataIter, error)
 
+	// FetchBlocksMetadataFromPeersInBuckets will fetch the blocks metadata of
+	// the series that belong to the hash tree buckets from available peers
+	FetchBlocksMetadataFromPeersInBuckets(
+		namespace ident.ID,
+		shard uint32,
+		start, end time.Time,
+		consistencyLevel topology.ReadConsistencyLevel,
+		result result.Options,
+		buckets hashtree.BucketFilter,
+	) (PeerBlockMetadataIter, error)
+
+	// FetchBlocksHashTreesFromPeers will fetch the hash trees of the blocks
+	// of a shard from available peers, a failure to fetch from a peer is
+	// returned as the error of that peer's result
+	FetchBlocksHashTreesFromPeers(
+		namespace ident.ID,
+		shard uint32,
+		start, end time.Time,
+		depth int,
+	) ([]PeerBlocksHashTrees, error)
+
 	// FetchBlocksFromPeers will fetch the required blocks from the
 	// peers specified
 	FetchBlocksFromPeers(
@@ -294,6 +392,16 @@ type Options interface {
 	// FetchRequestTimeout returns the fetchRequestTimeout
 	FetchRequestTimeout() time.Duration
 
+	// SetHedgedReadDelay sets the delay after which a fetch is also sent to
+	// the replicas not required to achieve the read consistency level if
+	// not yet complete, the first successful responses are used. Zero
+	// disables hedged reads and sends fetches to all replicas at once.
+	SetHedgedReadDelay(value time.Duration) Options
+
+	// HedgedReadDelay returns the delay after which a fetch is also sent to
+	// the replicas not required to achieve the read consistency level.
+	HedgedReadDelay() time.Duration
+
 	// SetTruncateRequestTimeout sets the truncateRequestTimeout
 	SetTruncateRequestTimeout(value time.Duration) Options
 
@@ -393,6 +501,23 @@ type Options interface {
 	// WriteBatchSize returns the writeBatchSize
 	WriteBatchSize() int
 
+	// SetAsyncWriteWorkerPoolSize sets the number of workers performing the
+	// writes of WriteAsync concurrently, the writes of the workers are
+	// batched by the host queues like any other writes.
+	SetAsyncWriteWorkerPoolSize(value int) Options
+
+	// AsyncWriteWorkerPoolSize returns the number of workers performing the
+	// writes of WriteAsync concurrently.
+	AsyncWriteWorkerPoolSize() int
+
+	// SetAsyncWriteQueueSize sets the max number of datapoints pending to be
+	// written by WriteAsync, beyond which WriteAsync returns an error.
+	SetAsyncWriteQueueSize(value int) Options
+
+	// AsyncWriteQueueSize returns the max number of datapoints pending to be
+	// written by WriteAsync.
+	AsyncWriteQueueSize() int
+
 	// SetFetchBatchSize sets the fetchBatchSize
 	// NB(r): for a fetch only application load this should match the host
 	// queue ops flush size so that each time a host queue is flushed it can
@@ -521,6 +646,22 @@ type AdminOptions interface {
 	// FetchSeriesBlocksBatchConcurrency gets the concurrency for fetching series blocks in batch
 	FetchSeriesBlocksBatchConcurrency() int
 
+	// SetFetchSeriesBlocksLimitMbps sets the bandwidth limit in megabits per second
+	// for streaming series blocks from peers, zero disables the limit
+	SetFetchSeriesBlocksLimitMbps(value float64) AdminOptions
+
+	// FetchSeriesBlocksLimitMbps returns the bandwidth limit in megabits per second
+	// for streaming series blocks from peers, zero disables the limit
+	FetchSeriesBlocksLimitMbps() float64
+
+	// SetFetchSeriesBlocksNamespaceLimits sets the per namespace limits for
+	// streaming series blocks from peers
+	SetFetchSeriesBlocksNamespaceLimits(value map[string]FetchSeriesBlocksLimit) AdminOptions
+
+	// FetchSeriesBlocksNamespaceLimits returns the per namespace limits for
+	// streaming series blocks from peers
+	FetchSeriesBlocksNamespaceLimits() map[string]FetchSeriesBlocksLimit
+
 	// SetStreamBlocksRetrier sets the retrier for streaming blocks
 	SetStreamBlocksRetrier(value xretry.Retrier) AdminOptions
 
@@ -528,6 +669,16 @@ type AdminOptions interface {
 	StreamBlocksRetrier() xretry.Retrier
 }
 
+// FetchSeriesBlocksLimit is a limit applied to streaming series blocks
+// from peers for a single namespace.
+type FetchSeriesBlocksLimit struct {
+	// LimitMbps is the bandwidth limit in megabits per second, zero disables the limit.
+	LimitMbps float64
+
+	// Concurrency is the max number of concurrent batch requests, zero disables the limit.
+	Concurrency int
+}
+
 // The rest of these types are internal types that mocks are generated for
 // in file mode and hence need to stay in this file and refer to the other
 // types such as AdminSession.  When mocks are generated in file mode the
@@ -0,0 +1,152 @@
+// Copyright (c) 2018 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package client
+
+import (
+	"errors"
+	"sync/atomic"
+	"time"
+
+	"github.com/m3db/m3x/ident"
+	xtime "github.com/m3db/m3x/time"
+)
+
+// ErrAsyncWriteQueueFull is returned by WriteAsync if the datapoints pending
+// to be written and the datapoints of the batch exceed the async write queue
+// size.
+var ErrAsyncWriteQueueFull = errors.New("async write queue is full")
+
+// AsyncWrite is a datapoint of a batch written by WriteAsync.
+type AsyncWrite struct {
+	ID ident.ID
+
+	// Tags are the tags of the series, the datapoint is written untagged if
+	// they are nil.
+	Tags ident.TagIterator
+
+	Timestamp  time.Time
+	Value      float64
+	Unit       xtime.Unit
+	Annotation []byte
+}
+
+// AsyncWriteCompletionFn is called once every datapoint of a batch written by
+// WriteAsync has been written or failed, errs[i] is the result of writes[i].
+// It is called from an async write worker so it must not block.
+type AsyncWriteCompletionFn func(writes []AsyncWrite, errs []error)
+
+type asyncWriteBatch struct {
+	levels    consistencyLevelOverrides
+	namespace ident.ID
+	writes    []AsyncWrite
+	errs      []error
+	remaining int32
+	fn        AsyncWriteCompletionFn
+}
+
+type asyncWrite struct {
+	batch *asyncWriteBatch
+	idx   int
+}
+
+// WriteAsync queues the datapoints of the batch to be written by the async
+// write workers. The namespace, IDs, tags and annotations of the batch must
+// remain valid until fn is called.
+func (s *session) WriteAsync(
+	namespace ident.ID,
+	writes []AsyncWrite,
+	fn AsyncWriteCompletionFn,
+) error {
+	return s.writeAsync(noConsistencyLevelOverrides, namespace, writes, fn)
+}
+
+func (s *session) writeAsync(
+	levels consistencyLevelOverrides,
+	namespace ident.ID,
+	writes []AsyncWrite,
+	fn AsyncWriteCompletionFn,
+) error {
+	if len(writes) == 0 {
+		fn(writes, nil)
+		return nil
+	}
+
+	s.state.RLock()
+	defer s.state.RUnlock()
+	if s.state.status != statusOpen {
+		return errSessionStatusNotOpen
+	}
+
+	// NB: reserve room for the whole batch before queueing any of it so that
+	// queueing never blocks and a batch is either queued or rejected whole.
+	numWrites := int64(len(writes))
+	if atomic.AddInt64(&s.asyncWritesPending, numWrites) > int64(cap(s.asyncWrites)) {
+		atomic.AddInt64(&s.asyncWritesPending, -numWrites)
+		return ErrAsyncWriteQueueFull
+	}
+
+	batch := &asyncWriteBatch{
+		levels:    levels,
+		namespace: namespace,
+		writes:    writes,
+		errs:      make([]error, len(writes)),
+		remaining: int32(len(writes)),
+		fn:        fn,
+	}
+	for i := range writes {
+		s.asyncWrites <- asyncWrite{batch: batch, idx: i}
+	}
+	return nil
+}
+
+// asyncWriteLoop writes the queued datapoints until the queue is closed,
+// datapoints still queued when the session is closed fail as the session
+// is no longer open.
+func (s *session) asyncWriteLoop() {
+	defer s.asyncWritesWg.Done()
+
+	for w := range s.asyncWrites {
+		atomic.AddInt64(&s.asyncWritesPending, -1)
+
+		var (
+			batch = w.batch
+			write = batch.writes[w.idx]
+			err   error
+		)
+		if write.Tags == nil {
+			err = s.write(batch.levels, batch.namespace, write.ID,
+				write.Timestamp, write.Value, write.Unit, write.Annotation)
+		} else {
+			err = s.writeTagged(batch.levels, nil, batch.namespace, write.ID,
+				write.Tags, write.Timestamp, write.Value, write.Unit, write.Annotation)
+		}
+		if err != nil {
+			s.metrics.asyncWriteErrors.Inc(1)
+		} else {
+			s.metrics.asyncWriteSuccess.Inc(1)
+		}
+
+		batch.errs[w.idx] = err
+		if atomic.AddInt32(&batch.remaining, -1) == 0 {
+			batch.fn(batch.writes, batch.errs)
+		}
+	}
+}
@@ -28,6 +28,8 @@ import (
 	"github.com/m3db/m3x/pool"
 	xretry "github.com/m3db/m3x/retry"
 	xtime "github.com/m3db/m3x/time"
+
+	opentracing "github.com/opentracing/opentracing-go"
 )
 
 type writeAttemptType byte
@@ -50,6 +52,7 @@ type writeAttempt struct {
 }
 
 type writeAttemptArgs struct {
+	levels      consistencyLevelOverrides
 	namespace   ident.ID
 	id          ident.ID
 	tags        ident.TagIterator
@@ -58,6 +61,7 @@ type writeAttemptArgs struct {
 	annotation  []byte
 	unit        xtime.Unit
 	attemptType writeAttemptType
+	spanCtx     opentracing.SpanContext
 }
 
 func (w *writeAttempt) reset() {
@@ -65,9 +69,9 @@ func (w *writeAttempt) reset() {
 }
 
 func (w *writeAttempt) perform() error {
-	err := w.session.writeAttempt(w.args.attemptType,
+	err := w.session.writeAttempt(w.args.levels, w.args.attemptType,
 		w.args.namespace, w.args.id, w.args.tags, w.args.t,
-		w.args.value, w.args.unit, w.args.annotation)
+		w.args.value, w.args.unit, w.args.annotation, w.args.spanCtx)
 
 	if IsBadRequestError(err) {
 		// Do not retry bad request errors
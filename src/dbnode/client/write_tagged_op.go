@@ -26,6 +26,8 @@ import (
 	"github.com/m3db/m3/src/dbnode/generated/thrift/rpc"
 	"github.com/m3db/m3x/ident"
 	"github.com/m3db/m3x/pool"
+
+	opentracing "github.com/opentracing/opentracing-go"
 )
 
 var (
@@ -40,6 +42,10 @@ type writeTaggedOperation struct {
 	datapoint    rpc.Datapoint
 	completionFn completionFn
 	pool         *writeTaggedOperationPool
+
+	// spanCtx is the context of the span the write RPCs follow from, if
+	// the write is traced.
+	spanCtx opentracing.SpanContext
 }
 
 func (w *writeTaggedOperation) reset() {
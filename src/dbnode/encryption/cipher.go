@@ -0,0 +1,122 @@
+// Copyright (c) 2018 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+// Package encryption provides the AES-GCM encryption of the data files
+// written by the database, with keys supplied by pluggable key providers.
+package encryption
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"errors"
+	"io"
+)
+
+const (
+	saltLen  = 32
+	nonceLen = 12
+	tagLen   = 16
+
+	// Overhead is the number of bytes sealing adds to data.
+	Overhead = saltLen + nonceLen + tagLen
+)
+
+var (
+	// sealKeyInfo binds the keys derived for sealing to their use.
+	sealKeyInfo = []byte("m3db encryption seal key")
+
+	errSealedTooShort = errors.New("sealed data shorter than encryption overhead")
+)
+
+// Cipher seals and opens data with a single AES-GCM key. Each seal derives
+// its own key from the key of the cipher and a random salt that is stored
+// with the sealed data, so no key seals more than once and the number of
+// seals is not bounded by the risk of random nonces repeating, across
+// restarts and across processes sharing the key alike.
+type Cipher struct {
+	keyID string
+	key   []byte
+}
+
+// NewCipher returns a new cipher for a 16, 24 or 32 byte key, selecting
+// AES-128, AES-192 or AES-256.
+func NewCipher(keyID string, key []byte) (*Cipher, error) {
+	// Validate the key size up front rather than on the first seal.
+	if _, err := aes.NewCipher(key); err != nil {
+		return nil, err
+	}
+	return &Cipher{keyID: keyID, key: append([]byte(nil), key...)}, nil
+}
+
+// KeyID returns the ID of the key of the cipher.
+func (c *Cipher) KeyID() string {
+	return c.keyID
+}
+
+// Seal appends the salt, the nonce and the encrypted and authenticated
+// plaintext to dst, dst and plaintext must not overlap.
+func (c *Cipher) Seal(dst, plaintext []byte) ([]byte, error) {
+	start := len(dst)
+	dst = append(dst, make([]byte, saltLen+nonceLen)...)
+	saltAndNonce := dst[start:]
+	if _, err := io.ReadFull(rand.Reader, saltAndNonce); err != nil {
+		return nil, err
+	}
+	aead, err := c.sealAEAD(saltAndNonce[:saltLen])
+	if err != nil {
+		return nil, err
+	}
+	return aead.Seal(dst, saltAndNonce[saltLen:], plaintext, nil), nil
+}
+
+// Open appends the decrypted sealed data to dst, returning an error if the
+// data was not sealed with the key of the cipher or was modified.
+func (c *Cipher) Open(dst, sealed []byte) ([]byte, error) {
+	if len(sealed) < Overhead {
+		return nil, errSealedTooShort
+	}
+	aead, err := c.sealAEAD(sealed[:saltLen])
+	if err != nil {
+		return nil, err
+	}
+	nonce := sealed[saltLen : saltLen+nonceLen]
+	return aead.Open(dst, nonce, sealed[saltLen+nonceLen:], nil)
+}
+
+// sealAEAD returns the AES-GCM of the key derived from the key of the cipher
+// and the salt with HKDF-SHA256 (RFC 5869), of the size of the key of the
+// cipher.
+func (c *Cipher) sealAEAD(salt []byte) (cipher.AEAD, error) {
+	extract := hmac.New(sha256.New, salt)
+	extract.Write(c.key)
+	expand := hmac.New(sha256.New, extract.Sum(nil))
+	expand.Write(sealKeyInfo)
+	expand.Write([]byte{1})
+	key := expand.Sum(nil)[:len(c.key)]
+
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, err
+	}
+	return cipher.NewGCM(block)
+}
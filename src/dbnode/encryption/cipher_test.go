@@ -0,0 +1,85 @@
+// Copyright (c) 2018 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package encryption
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCipherSealOpen(t *testing.T) {
+	c, err := NewCipher("k1", bytes.Repeat([]byte{1}, 32))
+	require.NoError(t, err)
+
+	plaintext := []byte("series data")
+	sealed, err := c.Seal([]byte("prefix"), plaintext)
+	require.NoError(t, err)
+	require.Equal(t, len("prefix")+len(plaintext)+Overhead, len(sealed))
+	assert.Equal(t, []byte("prefix"), sealed[:len("prefix")])
+	sealed = sealed[len("prefix"):]
+
+	opened, err := c.Open(nil, sealed)
+	require.NoError(t, err)
+	assert.Equal(t, plaintext, opened)
+
+	// Sealing the same data twice uses different nonces.
+	again, err := c.Seal(nil, plaintext)
+	require.NoError(t, err)
+	assert.NotEqual(t, sealed, again)
+
+	// Modified data and other keys are rejected.
+	sealed[len(sealed)-1] ^= 1
+	_, err = c.Open(nil, sealed)
+	assert.Error(t, err)
+
+	other, err := NewCipher("k2", bytes.Repeat([]byte{2}, 32))
+	require.NoError(t, err)
+	_, err = other.Open(nil, again)
+	assert.Error(t, err)
+
+	_, err = c.Open(nil, make([]byte, Overhead-1))
+	assert.Equal(t, errSealedTooShort, err)
+}
+
+func TestNewCipherInvalidKey(t *testing.T) {
+	_, err := NewCipher("k1", []byte("short"))
+	assert.Error(t, err)
+}
+
+func TestCipherSealDerivesKeyPerSeal(t *testing.T) {
+	c, err := NewCipher("k1", bytes.Repeat([]byte{1}, 32))
+	require.NoError(t, err)
+
+	// Each seal is under a key derived with its own salt.
+	first, err := c.Seal(nil, []byte("series data"))
+	require.NoError(t, err)
+	second, err := c.Seal(nil, []byte("series data"))
+	require.NoError(t, err)
+	assert.NotEqual(t, first[:saltLen], second[:saltLen])
+
+	// Data with a modified salt opens under another key and is rejected.
+	first[0] ^= 1
+	_, err = c.Open(nil, first)
+	assert.Error(t, err)
+}
@@ -0,0 +1,63 @@
+// Copyright (c) 2018 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package encryption
+
+// Configuration is the encryption at rest configuration of a database.
+//
+// Only fileset data files and commit logs are encrypted, the index, summaries
+// and bloom filter files of filesets and the persisted index segments are
+// written in plaintext so that series IDs and tags stay searchable; deploy on
+// encrypted volumes if series IDs and tags are sensitive.
+type Configuration struct {
+	// KeyProvider is the provider of the encryption keys.
+	KeyProvider KeyProviderConfiguration `yaml:"keyProvider"`
+
+	// Namespaces are the namespaces whose filesets are encrypted.
+	Namespaces []string `yaml:"namespaces"`
+
+	// CommitLog sets whether the commit log is encrypted, which holds the
+	// writes of all namespaces.
+	CommitLog bool `yaml:"commitLog"`
+}
+
+// KeyProviderConfiguration is the configuration of a key provider.
+type KeyProviderConfiguration struct {
+	// Type is the registered type of the key provider. The "file" provider
+	// is the only built in type, it reads keys from the files named by their
+	// IDs in the directory of the "path" option, with the current key set by
+	// the "currentKeyID" option, or read from the file at the
+	// "currentKeyIDFile" option every "reloadInterval" (10s by default) so
+	// that keys can be rotated without a restart. Other providers, such as
+	// a KMS, must be registered with RegisterKeyProvider by the binary.
+	Type string `yaml:"type" validate:"nonzero"`
+
+	// Options are the options specific to the type of key provider.
+	Options map[string]string `yaml:"options"`
+}
+
+// NewKeyring returns the keyring of the configured key provider.
+func (c Configuration) NewKeyring() (*Keyring, error) {
+	provider, err := NewKeyProvider(c.KeyProvider.Type, c.KeyProvider.Options)
+	if err != nil {
+		return nil, err
+	}
+	return NewKeyring(provider), nil
+}
@@ -0,0 +1,246 @@
+// Copyright (c) 2018 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package encryption
+
+import (
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	// FileKeyProviderType is the type of the key provider that reads keys
+	// from files.
+	FileKeyProviderType = "file"
+
+	fileKeyProviderPathOption             = "path"
+	fileKeyProviderCurrentKeyIDOption     = "currentKeyID"
+	fileKeyProviderCurrentKeyIDFileOption = "currentKeyIDFile"
+	fileKeyProviderReloadIntervalOption   = "reloadInterval"
+
+	// DefaultCurrentKeyIDReloadInterval is the default interval the file
+	// key provider re-reads the file of the ID of the current key at.
+	DefaultCurrentKeyIDReloadInterval = 10 * time.Second
+
+	maxKeyIDLen = 255
+)
+
+var (
+	errKeyIDEmpty   = errors.New("encryption key ID must not be empty")
+	errKeyIDTooLong = fmt.Errorf("encryption key ID must be at most %d bytes", maxKeyIDLen)
+	errKeyIDInvalid = errors.New("encryption key ID must not contain path separators")
+
+	keyProvidersLock sync.RWMutex
+	keyProviders     = map[string]NewKeyProviderFn{
+		FileKeyProviderType: newFileKeyProviderFromOptions,
+	}
+)
+
+// KeyProvider supplies the keys data is encrypted with. Keys that are no
+// longer current must remain available for as long as data encrypted with
+// them is retained.
+type KeyProvider interface {
+	// CurrentKeyID returns the ID of the key new data is encrypted with.
+	CurrentKeyID() (string, error)
+
+	// Key returns the key with the ID.
+	Key(keyID string) ([]byte, error)
+}
+
+// NewKeyProviderFn returns a key provider from its configuration options.
+type NewKeyProviderFn func(opts map[string]string) (KeyProvider, error)
+
+// RegisterKeyProvider registers a key provider type so it can be selected
+// by configuration, key management services are integrated by registering
+// a provider from an init function of a package linked into the binary.
+func RegisterKeyProvider(providerType string, fn NewKeyProviderFn) {
+	keyProvidersLock.Lock()
+	keyProviders[providerType] = fn
+	keyProvidersLock.Unlock()
+}
+
+// NewKeyProvider returns a key provider of a registered type.
+func NewKeyProvider(providerType string, opts map[string]string) (KeyProvider, error) {
+	keyProvidersLock.RLock()
+	fn, ok := keyProviders[providerType]
+	keyProvidersLock.RUnlock()
+	if !ok {
+		return nil, fmt.Errorf("unknown encryption key provider type '%s', "+
+			"registered types are: %v", providerType, KeyProviderTypes())
+	}
+	return fn(opts)
+}
+
+// KeyProviderTypes returns the registered key provider types.
+func KeyProviderTypes() []string {
+	keyProvidersLock.RLock()
+	defer keyProvidersLock.RUnlock()
+
+	types := make([]string, 0, len(keyProviders))
+	for providerType := range keyProviders {
+		types = append(types, providerType)
+	}
+	sort.Strings(types)
+	return types
+}
+
+func validateKeyID(keyID string) error {
+	switch {
+	case keyID == "":
+		return errKeyIDEmpty
+	case len(keyID) > maxKeyIDLen:
+		return errKeyIDTooLong
+	case strings.ContainsAny(keyID, `/\`):
+		return errKeyIDInvalid
+	}
+	return nil
+}
+
+type fileKeyProvider struct {
+	sync.Mutex
+
+	dir              string
+	currentKeyIDFile string
+	reloadInterval   time.Duration
+	nowFn            func() time.Time
+	currentKeyID     string
+	reloadedAt       time.Time
+}
+
+// NewFileKeyProvider returns a key provider that reads each key from the
+// file named by its ID in the directory, the files must hold the hex
+// encoding of a 16, 24 or 32 byte key.
+func NewFileKeyProvider(dir, currentKeyID string) (KeyProvider, error) {
+	if err := validateKeyID(currentKeyID); err != nil {
+		return nil, err
+	}
+	p := &fileKeyProvider{dir: dir, currentKeyID: currentKeyID}
+	// Fail fast if the current key is not readable.
+	if _, err := p.Key(currentKeyID); err != nil {
+		return nil, err
+	}
+	return p, nil
+}
+
+// NewReloadingFileKeyProvider returns a file key provider like
+// NewFileKeyProvider whose current key is the key with the ID held by the
+// file at currentKeyIDFile, re-read at most once per reload interval so the
+// current key can be rotated without restarting. An ID that is invalid or
+// whose key is not readable is ignored and the previous current key kept.
+func NewReloadingFileKeyProvider(
+	dir, currentKeyIDFile string,
+	reloadInterval time.Duration,
+) (KeyProvider, error) {
+	p := &fileKeyProvider{
+		dir:              dir,
+		currentKeyIDFile: currentKeyIDFile,
+		reloadInterval:   reloadInterval,
+		nowFn:            time.Now,
+	}
+	// Fail fast if the current key is not readable.
+	currentKeyID, err := p.readCurrentKeyID()
+	if err != nil {
+		return nil, err
+	}
+	p.currentKeyID = currentKeyID
+	p.reloadedAt = p.nowFn()
+	return p, nil
+}
+
+func newFileKeyProviderFromOptions(opts map[string]string) (KeyProvider, error) {
+	dir := opts[fileKeyProviderPathOption]
+	if dir == "" {
+		return nil, fmt.Errorf("file encryption key provider requires option '%s'",
+			fileKeyProviderPathOption)
+	}
+
+	currentKeyIDFile := opts[fileKeyProviderCurrentKeyIDFileOption]
+	if currentKeyIDFile == "" {
+		return NewFileKeyProvider(dir, opts[fileKeyProviderCurrentKeyIDOption])
+	}
+	if opts[fileKeyProviderCurrentKeyIDOption] != "" {
+		return nil, fmt.Errorf("file encryption key provider options '%s' and '%s' "+
+			"are mutually exclusive", fileKeyProviderCurrentKeyIDOption,
+			fileKeyProviderCurrentKeyIDFileOption)
+	}
+
+	reloadInterval := DefaultCurrentKeyIDReloadInterval
+	if value := opts[fileKeyProviderReloadIntervalOption]; value != "" {
+		var err error
+		reloadInterval, err = time.ParseDuration(value)
+		if err != nil {
+			return nil, fmt.Errorf("invalid file encryption key provider option '%s': %v",
+				fileKeyProviderReloadIntervalOption, err)
+		}
+	}
+	return NewReloadingFileKeyProvider(dir, currentKeyIDFile, reloadInterval)
+}
+
+func (p *fileKeyProvider) CurrentKeyID() (string, error) {
+	if p.currentKeyIDFile == "" {
+		return p.currentKeyID, nil
+	}
+
+	p.Lock()
+	defer p.Unlock()
+	if now := p.nowFn(); now.Sub(p.reloadedAt) >= p.reloadInterval {
+		p.reloadedAt = now
+		if currentKeyID, err := p.readCurrentKeyID(); err == nil {
+			p.currentKeyID = currentKeyID
+		}
+	}
+	return p.currentKeyID, nil
+}
+
+// readCurrentKeyID returns the ID held by the current key ID file, if its
+// key is readable.
+func (p *fileKeyProvider) readCurrentKeyID() (string, error) {
+	data, err := ioutil.ReadFile(p.currentKeyIDFile)
+	if err != nil {
+		return "", err
+	}
+	currentKeyID := strings.TrimSpace(string(data))
+	if _, err := p.Key(currentKeyID); err != nil {
+		return "", err
+	}
+	return currentKeyID, nil
+}
+
+func (p *fileKeyProvider) Key(keyID string) ([]byte, error) {
+	if err := validateKeyID(keyID); err != nil {
+		return nil, err
+	}
+	data, err := ioutil.ReadFile(filepath.Join(p.dir, keyID))
+	if err != nil {
+		return nil, err
+	}
+	key, err := hex.DecodeString(strings.TrimSpace(string(data)))
+	if err != nil {
+		return nil, fmt.Errorf("invalid encryption key '%s': %v", keyID, err)
+	}
+	return key, nil
+}
@@ -0,0 +1,111 @@
+// Copyright (c) 2018 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package encryption
+
+import (
+	"errors"
+	"sync"
+)
+
+var (
+	errFramedTooShort = errors.New("framed sealed data too short")
+)
+
+// Keyring caches the ciphers of the keys of a key provider.
+type Keyring struct {
+	sync.RWMutex
+
+	provider KeyProvider
+	ciphers  map[string]*Cipher
+}
+
+// NewKeyring returns a new keyring for the keys of the key provider.
+func NewKeyring(provider KeyProvider) *Keyring {
+	return &Keyring{
+		provider: provider,
+		ciphers:  make(map[string]*Cipher),
+	}
+}
+
+// Current returns the cipher of the key new data is encrypted with.
+func (k *Keyring) Current() (*Cipher, error) {
+	keyID, err := k.provider.CurrentKeyID()
+	if err != nil {
+		return nil, err
+	}
+	return k.Cipher(keyID)
+}
+
+// Cipher returns the cipher of the key with the ID.
+func (k *Keyring) Cipher(keyID string) (*Cipher, error) {
+	k.RLock()
+	c, ok := k.ciphers[keyID]
+	k.RUnlock()
+	if ok {
+		return c, nil
+	}
+
+	if err := validateKeyID(keyID); err != nil {
+		return nil, err
+	}
+	key, err := k.provider.Key(keyID)
+	if err != nil {
+		return nil, err
+	}
+	c, err = NewCipher(keyID, key)
+	if err != nil {
+		return nil, err
+	}
+
+	k.Lock()
+	k.ciphers[keyID] = c
+	k.Unlock()
+	return c, nil
+}
+
+// SealFramed appends the data sealed with the current key to dst, framed
+// with the ID of the key so that it can be opened without knowing the key
+// it was sealed with.
+func (k *Keyring) SealFramed(dst, plaintext []byte) ([]byte, error) {
+	c, err := k.Current()
+	if err != nil {
+		return nil, err
+	}
+	dst = append(dst, byte(len(c.keyID)))
+	dst = append(dst, c.keyID...)
+	return c.Seal(dst, plaintext)
+}
+
+// OpenFramed appends the decrypted data sealed by SealFramed to dst.
+func (k *Keyring) OpenFramed(dst, framed []byte) ([]byte, error) {
+	if len(framed) < 1 {
+		return nil, errFramedTooShort
+	}
+	keyIDLen := int(framed[0])
+	if len(framed) < 1+keyIDLen {
+		return nil, errFramedTooShort
+	}
+	c, err := k.Cipher(string(framed[1 : 1+keyIDLen]))
+	if err != nil {
+		return nil, err
+	}
+	return c.Open(dst, framed[1+keyIDLen:])
+}
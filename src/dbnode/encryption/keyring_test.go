@@ -0,0 +1,156 @@
+// Copyright (c) 2018 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package encryption
+
+import (
+	"encoding/hex"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func writeTestKeys(t *testing.T, keyIDs ...string) string {
+	dir, err := ioutil.TempDir("", "encryption")
+	require.NoError(t, err)
+	for i, keyID := range keyIDs {
+		key := make([]byte, 32)
+		key[0] = byte(i)
+		require.NoError(t, ioutil.WriteFile(filepath.Join(dir, keyID),
+			[]byte(hex.EncodeToString(key)+"\n"), 0600))
+	}
+	return dir
+}
+
+func TestKeyringRotation(t *testing.T) {
+	dir := writeTestKeys(t, "k1", "k2")
+	defer os.RemoveAll(dir)
+
+	provider, err := NewKeyProvider(FileKeyProviderType, map[string]string{
+		"path":         dir,
+		"currentKeyID": "k1",
+	})
+	require.NoError(t, err)
+	framed, err := NewKeyring(provider).SealFramed(nil, []byte("before rotation"))
+	require.NoError(t, err)
+
+	// Data sealed with a previous key can be opened after rotation.
+	provider, err = NewFileKeyProvider(dir, "k2")
+	require.NoError(t, err)
+	keyring := NewKeyring(provider)
+	current, err := keyring.Current()
+	require.NoError(t, err)
+	assert.Equal(t, "k2", current.KeyID())
+
+	opened, err := keyring.OpenFramed(nil, framed)
+	require.NoError(t, err)
+	assert.Equal(t, []byte("before rotation"), opened)
+
+	_, err = keyring.OpenFramed(nil, framed[:2])
+	assert.Equal(t, errFramedTooShort, err)
+}
+
+func TestFileKeyProviderErrors(t *testing.T) {
+	dir := writeTestKeys(t, "k1")
+	defer os.RemoveAll(dir)
+
+	_, err := NewFileKeyProvider(dir, "missing")
+	assert.Error(t, err)
+	_, err = NewFileKeyProvider(dir, "")
+	assert.Equal(t, errKeyIDEmpty, err)
+
+	provider, err := NewFileKeyProvider(dir, "k1")
+	require.NoError(t, err)
+	_, err = provider.Key("../k1")
+	assert.Equal(t, errKeyIDInvalid, err)
+
+	_, err = NewKeyProvider("kms", nil)
+	assert.Error(t, err)
+}
+
+func TestReloadingFileKeyProvider(t *testing.T) {
+	dir := writeTestKeys(t, "k1", "k2")
+	defer os.RemoveAll(dir)
+	currentKeyIDFile := filepath.Join(dir, "current")
+	require.NoError(t, ioutil.WriteFile(currentKeyIDFile, []byte("k1\n"), 0600))
+
+	provider, err := NewKeyProvider(FileKeyProviderType, map[string]string{
+		"path":             dir,
+		"currentKeyIDFile": currentKeyIDFile,
+		"reloadInterval":   "1m",
+	})
+	require.NoError(t, err)
+	now := time.Now()
+	provider.(*fileKeyProvider).nowFn = func() time.Time { return now }
+
+	currentKeyID, err := provider.CurrentKeyID()
+	require.NoError(t, err)
+	assert.Equal(t, "k1", currentKeyID)
+
+	// The rotated ID is read once the reload interval passes.
+	require.NoError(t, ioutil.WriteFile(currentKeyIDFile, []byte("k2\n"), 0600))
+	currentKeyID, err = provider.CurrentKeyID()
+	require.NoError(t, err)
+	assert.Equal(t, "k1", currentKeyID)
+
+	now = now.Add(time.Minute)
+	currentKeyID, err = provider.CurrentKeyID()
+	require.NoError(t, err)
+	assert.Equal(t, "k2", currentKeyID)
+
+	// An ID whose key is not readable keeps the previous current key.
+	require.NoError(t, ioutil.WriteFile(currentKeyIDFile, []byte("missing\n"), 0600))
+	now = now.Add(time.Minute)
+	currentKeyID, err = provider.CurrentKeyID()
+	require.NoError(t, err)
+	assert.Equal(t, "k2", currentKeyID)
+
+	_, err = NewKeyProvider(FileKeyProviderType, map[string]string{
+		"path":             dir,
+		"currentKeyID":     "k1",
+		"currentKeyIDFile": currentKeyIDFile,
+	})
+	assert.Error(t, err)
+}
+
+type staticKeyProvider struct{}
+
+func (staticKeyProvider) CurrentKeyID() (string, error) { return "static", nil }
+func (staticKeyProvider) Key(string) ([]byte, error)    { return make([]byte, 16), nil }
+
+func TestRegisterKeyProvider(t *testing.T) {
+	RegisterKeyProvider("static", func(map[string]string) (KeyProvider, error) {
+		return staticKeyProvider{}, nil
+	})
+	assert.Contains(t, KeyProviderTypes(), "static")
+
+	keyring, err := Configuration{
+		KeyProvider: KeyProviderConfiguration{Type: "static"},
+	}.NewKeyring()
+	require.NoError(t, err)
+	c, err := keyring.Current()
+	require.NoError(t, err)
+	assert.Equal(t, "static", c.KeyID())
+}
@@ -22,16 +22,18 @@
 // THE SOFTWARE.
 
 /*
-	Package namespace is a generated protocol buffer package.
+Package namespace is a generated protocol buffer package.
 
-	It is generated from these files:
-		github.com/m3db/m3/src/dbnode/generated/proto/namespace/namespace.proto
+It is generated from these files:
 
-	It has these top-level messages:
-		RetentionOptions
-		IndexOptions
-		NamespaceOptions
-		Registry
+	github.com/m3db/m3/src/dbnode/generated/proto/namespace/namespace.proto
+
+It has these top-level messages:
+
+	RetentionOptions
+	IndexOptions
+	NamespaceOptions
+	Registry
 */
 package namespace
 
@@ -52,6 +54,30 @@ var _ = math.Inf
 // proto package needs to be updated.
 const _ = proto.GoGoProtoPackageIsVersion2 // please upgrade the proto package
 
+type NamespaceState int32
+
+const (
+	NamespaceState_ACTIVE         NamespaceState = 0
+	NamespaceState_READ_ONLY      NamespaceState = 1
+	NamespaceState_PENDING_DELETE NamespaceState = 2
+)
+
+var NamespaceState_name = map[int32]string{
+	0: "ACTIVE",
+	1: "READ_ONLY",
+	2: "PENDING_DELETE",
+}
+var NamespaceState_value = map[string]int32{
+	"ACTIVE":         0,
+	"READ_ONLY":      1,
+	"PENDING_DELETE": 2,
+}
+
+func (x NamespaceState) String() string {
+	return proto.EnumName(NamespaceState_name, int32(x))
+}
+func (NamespaceState) EnumDescriptor() ([]byte, []int) { return fileDescriptorNamespace, []int{0} }
+
 type RetentionOptions struct {
 	RetentionPeriodNanos                     int64 `protobuf:"varint,1,opt,name=retentionPeriodNanos,proto3" json:"retentionPeriodNanos,omitempty"`
 	BlockSizeNanos                           int64 `protobuf:"varint,2,opt,name=blockSizeNanos,proto3" json:"blockSizeNanos,omitempty"`
@@ -133,14 +159,16 @@ func (m *IndexOptions) GetBlockSizeNanos() int64 {
 }
 
 type NamespaceOptions struct {
-	BootstrapEnabled  bool              `protobuf:"varint,1,opt,name=bootstrapEnabled,proto3" json:"bootstrapEnabled,omitempty"`
-	FlushEnabled      bool              `protobuf:"varint,2,opt,name=flushEnabled,proto3" json:"flushEnabled,omitempty"`
-	WritesToCommitLog bool              `protobuf:"varint,3,opt,name=writesToCommitLog,proto3" json:"writesToCommitLog,omitempty"`
-	CleanupEnabled    bool              `protobuf:"varint,4,opt,name=cleanupEnabled,proto3" json:"cleanupEnabled,omitempty"`
-	RepairEnabled     bool              `protobuf:"varint,5,opt,name=repairEnabled,proto3" json:"repairEnabled,omitempty"`
-	RetentionOptions  *RetentionOptions `protobuf:"bytes,6,opt,name=retentionOptions" json:"retentionOptions,omitempty"`
-	SnapshotEnabled   bool              `protobuf:"varint,7,opt,name=snapshotEnabled,proto3" json:"snapshotEnabled,omitempty"`
-	IndexOptions      *IndexOptions     `protobuf:"bytes,8,opt,name=indexOptions" json:"indexOptions,omitempty"`
+	BootstrapEnabled     bool              `protobuf:"varint,1,opt,name=bootstrapEnabled,proto3" json:"bootstrapEnabled,omitempty"`
+	FlushEnabled         bool              `protobuf:"varint,2,opt,name=flushEnabled,proto3" json:"flushEnabled,omitempty"`
+	WritesToCommitLog    bool              `protobuf:"varint,3,opt,name=writesToCommitLog,proto3" json:"writesToCommitLog,omitempty"`
+	CleanupEnabled       bool              `protobuf:"varint,4,opt,name=cleanupEnabled,proto3" json:"cleanupEnabled,omitempty"`
+	RepairEnabled        bool              `protobuf:"varint,5,opt,name=repairEnabled,proto3" json:"repairEnabled,omitempty"`
+	RetentionOptions     *RetentionOptions `protobuf:"bytes,6,opt,name=retentionOptions" json:"retentionOptions,omitempty"`
+	SnapshotEnabled      bool              `protobuf:"varint,7,opt,name=snapshotEnabled,proto3" json:"snapshotEnabled,omitempty"`
+	IndexOptions         *IndexOptions     `protobuf:"bytes,8,opt,name=indexOptions" json:"indexOptions,omitempty"`
+	State                NamespaceState    `protobuf:"varint,9,opt,name=state,proto3,enum=namespace.NamespaceState" json:"state,omitempty"`
+	DeleteAfterUnixNanos int64             `protobuf:"varint,10,opt,name=deleteAfterUnixNanos,proto3" json:"deleteAfterUnixNanos,omitempty"`
 }
 
 func (m *NamespaceOptions) Reset()                    { *m = NamespaceOptions{} }
@@ -204,6 +232,20 @@ func (m *NamespaceOptions) GetIndexOptions() *IndexOptions {
 	return nil
 }
 
+func (m *NamespaceOptions) GetState() NamespaceState {
+	if m != nil {
+		return m.State
+	}
+	return NamespaceState_ACTIVE
+}
+
+func (m *NamespaceOptions) GetDeleteAfterUnixNanos() int64 {
+	if m != nil {
+		return m.DeleteAfterUnixNanos
+	}
+	return 0
+}
+
 type Registry struct {
 	Namespaces map[string]*NamespaceOptions `protobuf:"bytes,1,rep,name=namespaces" json:"namespaces,omitempty" protobuf_key:"bytes,1,opt,name=key,proto3" protobuf_val:"bytes,2,opt,name=value"`
 }
@@ -225,6 +267,7 @@ func init() {
 	proto.RegisterType((*IndexOptions)(nil), "namespace.IndexOptions")
 	proto.RegisterType((*NamespaceOptions)(nil), "namespace.NamespaceOptions")
 	proto.RegisterType((*Registry)(nil), "namespace.Registry")
+	proto.RegisterEnum("namespace.NamespaceState", NamespaceState_name, NamespaceState_value)
 }
 func (m *RetentionOptions) Marshal() (dAtA []byte, err error) {
 	size := m.Size()
@@ -407,6 +450,16 @@ func (m *NamespaceOptions) MarshalTo(dAtA []byte) (int, error) {
 		}
 		i += n2
 	}
+	if m.State != 0 {
+		dAtA[i] = 0x48
+		i++
+		i = encodeVarintNamespace(dAtA, i, uint64(m.State))
+	}
+	if m.DeleteAfterUnixNanos != 0 {
+		dAtA[i] = 0x50
+		i++
+		i = encodeVarintNamespace(dAtA, i, uint64(m.DeleteAfterUnixNanos))
+	}
 	return i, nil
 }
 
@@ -530,6 +583,12 @@ func (m *NamespaceOptions) Size() (n int) {
 		l = m.IndexOptions.Size()
 		n += 1 + l + sovNamespace(uint64(l))
 	}
+	if m.State != 0 {
+		n += 1 + sovNamespace(uint64(m.State))
+	}
+	if m.DeleteAfterUnixNanos != 0 {
+		n += 1 + sovNamespace(uint64(m.DeleteAfterUnixNanos))
+	}
 	return n
 }
 
@@ -1034,6 +1093,44 @@ func (m *NamespaceOptions) Unmarshal(dAtA []byte) error {
 				return err
 			}
 			iNdEx = postIndex
+		case 9:
+			if wireType != 0 {
+				return fmt.Errorf("proto: wrong wireType = %d for field State", wireType)
+			}
+			m.State = 0
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowNamespace
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				m.State |= (NamespaceState(b) & 0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
+		case 10:
+			if wireType != 0 {
+				return fmt.Errorf("proto: wrong wireType = %d for field DeleteAfterUnixNanos", wireType)
+			}
+			m.DeleteAfterUnixNanos = 0
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowNamespace
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				m.DeleteAfterUnixNanos |= (int64(b) & 0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
 		default:
 			iNdEx = preIndex
 			skippy, err := skipNamespace(dAtA[iNdEx:])
@@ -1338,38 +1435,43 @@ func init() {
 }
 
 var fileDescriptorNamespace = []byte{
-	// 513 bytes of a gzipped FileDescriptorProto
-	0x1f, 0x8b, 0x08, 0x00, 0x00, 0x00, 0x00, 0x00, 0x02, 0xff, 0x8c, 0x94, 0xdf, 0x6e, 0xd3, 0x30,
-	0x14, 0xc6, 0x49, 0xbb, 0x3f, 0xdd, 0x59, 0x61, 0xc1, 0x42, 0xa2, 0x02, 0xa9, 0x9a, 0x0a, 0x42,
-	0x15, 0x42, 0x8d, 0x68, 0x6f, 0x10, 0x5c, 0x8d, 0x51, 0x26, 0x24, 0x54, 0x2a, 0xc3, 0xd5, 0xee,
-	0x9c, 0xe4, 0xb4, 0xb5, 0xd6, 0xd8, 0x91, 0xed, 0xc0, 0xca, 0x43, 0x20, 0xde, 0x83, 0x17, 0xe1,
-	0x82, 0x0b, 0x1e, 0x01, 0x95, 0x17, 0x41, 0x71, 0x48, 0x97, 0xb8, 0xbb, 0xd8, 0x4d, 0xe5, 0x7e,
-	0xe7, 0x67, 0x1f, 0xe7, 0x7c, 0x5f, 0x02, 0x67, 0x73, 0x6e, 0x16, 0x59, 0x38, 0x88, 0x64, 0x12,
-	0x24, 0xa3, 0x38, 0x0c, 0x92, 0x51, 0xa0, 0x55, 0x14, 0xc4, 0xa1, 0x90, 0x31, 0x06, 0x73, 0x14,
-	0xa8, 0x98, 0xc1, 0x38, 0x48, 0x95, 0x34, 0x32, 0x10, 0x2c, 0x41, 0x9d, 0xb2, 0x08, 0xaf, 0x56,
-	0x03, 0x5b, 0x21, 0x07, 0x1b, 0xa1, 0xf7, 0xab, 0x01, 0x3e, 0x45, 0x83, 0xc2, 0x70, 0x29, 0x3e,
-	0xa4, 0xf9, 0xaf, 0x26, 0x43, 0xb8, 0xa7, 0x4a, 0x6d, 0x8a, 0x8a, 0xcb, 0x78, 0xc2, 0x84, 0xd4,
-	0x1d, 0xef, 0xd8, 0xeb, 0x37, 0xe9, 0xb5, 0x35, 0xf2, 0x04, 0xee, 0x84, 0x4b, 0x19, 0x5d, 0x7c,
-	0xe4, 0x5f, 0xb1, 0xa0, 0x1b, 0x96, 0x76, 0x54, 0xf2, 0x0c, 0xee, 0x86, 0xd9, 0x6c, 0x86, 0xea,
-	0x6d, 0x66, 0x32, 0xf5, 0x1f, 0x6d, 0x5a, 0x74, 0xbb, 0x40, 0xfa, 0x70, 0x54, 0x88, 0x53, 0xa6,
-	0x4d, 0xc1, 0xee, 0x58, 0xd6, 0x95, 0x2d, 0x99, 0x77, 0x7a, 0xc3, 0x0c, 0x1b, 0x5f, 0xa6, 0x5c,
-	0xad, 0x3a, 0xbb, 0xc7, 0x5e, 0xbf, 0x45, 0x5d, 0x99, 0x9c, 0x43, 0xdf, 0x91, 0x4e, 0x66, 0x06,
-	0xd5, 0x44, 0x9a, 0x93, 0x28, 0x42, 0xad, 0xab, 0x4f, 0xbc, 0x67, 0x9b, 0xdd, 0x98, 0xef, 0x4d,
-	0xa1, 0xfd, 0x4e, 0xc4, 0x78, 0x59, 0x4e, 0xb2, 0x03, 0xfb, 0x28, 0x58, 0xb8, 0xc4, 0xd8, 0x0e,
-	0xaf, 0x45, 0xcb, 0xbf, 0x37, 0x9d, 0x57, 0xef, 0x5b, 0x13, 0xfc, 0x49, 0x69, 0x57, 0x79, 0xec,
-	0x53, 0xf0, 0x43, 0x29, 0x8d, 0x36, 0x8a, 0xa5, 0xe3, 0xda, 0xf9, 0x5b, 0x3a, 0xe9, 0x41, 0x7b,
-	0xb6, 0xcc, 0xf4, 0xa2, 0xe4, 0x1a, 0x96, 0xab, 0x69, 0xb9, 0x29, 0x5f, 0x14, 0x37, 0xa8, 0x3f,
-	0xc9, 0x53, 0x99, 0x24, 0xdc, 0xbc, 0x97, 0x73, 0x6b, 0x4a, 0x8b, 0x6e, 0x17, 0xf2, 0xab, 0x47,
-	0x4b, 0x64, 0x22, 0xdb, 0xf4, 0xde, 0xb1, 0xa8, 0xa3, 0x92, 0xc7, 0x70, 0x5b, 0x61, 0xca, 0xb8,
-	0x2a, 0xb1, 0xc2, 0x90, 0xba, 0x48, 0xce, 0xc0, 0x57, 0x4e, 0x00, 0xed, 0xd8, 0x0f, 0x87, 0x0f,
-	0x07, 0x57, 0xc1, 0x75, 0x33, 0x4a, 0xb7, 0x36, 0xe5, 0x09, 0xd0, 0x82, 0xa5, 0x7a, 0x21, 0x4d,
-	0xd9, 0x70, 0xbf, 0x48, 0x80, 0x23, 0x93, 0x57, 0xd0, 0xe6, 0x15, 0x97, 0x3a, 0x2d, 0xdb, 0xee,
-	0x7e, 0xa5, 0x5d, 0xd5, 0x44, 0x5a, 0x83, 0x7b, 0x3f, 0x3c, 0x68, 0x51, 0x9c, 0x73, 0x6d, 0xd4,
-	0x8a, 0x9c, 0x02, 0x6c, 0x36, 0xe5, 0xef, 0x47, 0xb3, 0x7f, 0x38, 0x7c, 0x54, 0xbb, 0x76, 0x01,
-	0x0e, 0x36, 0x16, 0xea, 0xb1, 0x30, 0x6a, 0x45, 0x2b, 0xdb, 0x1e, 0x9c, 0xc3, 0x91, 0x53, 0x26,
-	0x3e, 0x34, 0x2f, 0x70, 0x65, 0x3d, 0x3d, 0xa0, 0xf9, 0x92, 0x3c, 0x87, 0xdd, 0xcf, 0x6c, 0x99,
-	0xa1, 0xf5, 0xaf, 0x3e, 0x1b, 0x37, 0x1e, 0xb4, 0x20, 0x5f, 0x36, 0x5e, 0x78, 0xaf, 0xfd, 0x9f,
-	0xeb, 0xae, 0xf7, 0x7b, 0xdd, 0xf5, 0xfe, 0xac, 0xbb, 0xde, 0xf7, 0xbf, 0xdd, 0x5b, 0xe1, 0x9e,
-	0xfd, 0x06, 0x8c, 0xfe, 0x05, 0x00, 0x00, 0xff, 0xff, 0x30, 0x42, 0xc6, 0x09, 0x4e, 0x04, 0x00,
-	0x00,
+	// 597 bytes of a gzipped FileDescriptorProto
+	0x1f, 0x8b, 0x08, 0x00, 0x00, 0x00, 0x00, 0x00, 0x02, 0x03, 0x8d, 0x94, 0xd1, 0x6e, 0xd3, 0x30,
+	0x14, 0x86, 0x97, 0x76, 0xed, 0xda, 0x43, 0xd7, 0x05, 0x0b, 0x89, 0x02, 0xd2, 0x84, 0x0a, 0x42,
+	0xd5, 0x84, 0x1a, 0xd1, 0xdd, 0x20, 0xb8, 0x40, 0xa5, 0x0d, 0x55, 0xa5, 0xaa, 0xab, 0xbc, 0x82,
+	0xc4, 0x6e, 0x26, 0x27, 0x71, 0xdb, 0x68, 0x6d, 0x1c, 0xd9, 0x0e, 0xb4, 0x3c, 0x05, 0xef, 0xc1,
+	0x8b, 0x70, 0xc1, 0x05, 0x8f, 0x80, 0xe0, 0x11, 0x78, 0x01, 0x12, 0x87, 0x74, 0x49, 0xba, 0x8b,
+	0x5d, 0x38, 0x8a, 0xff, 0xf3, 0x39, 0xc7, 0x3e, 0xff, 0x71, 0x60, 0x30, 0x77, 0xe5, 0x22, 0xb0,
+	0xda, 0x36, 0x5b, 0x19, 0xab, 0x53, 0xc7, 0x0a, 0x1f, 0x86, 0xe0, 0xb6, 0xe1, 0x58, 0x1e, 0x73,
+	0xa8, 0x31, 0xa7, 0x1e, 0xe5, 0x44, 0x52, 0xc7, 0xf0, 0x39, 0x93, 0xcc, 0xf0, 0xc8, 0x8a, 0x0a,
+	0x9f, 0xd8, 0xf4, 0xfa, 0xad, 0xad, 0x22, 0xa8, 0xba, 0x15, 0x9a, 0x3f, 0x0a, 0xa0, 0x63, 0x2a,
+	0xa9, 0x27, 0x5d, 0xe6, 0x9d, 0xf9, 0xd1, 0x53, 0xa0, 0x0e, 0xdc, 0xe3, 0x89, 0x36, 0xa1, 0xdc,
+	0x65, 0xce, 0x98, 0x78, 0x4c, 0x34, 0xb4, 0xc7, 0x5a, 0xab, 0x88, 0x6f, 0x8c, 0xa1, 0x67, 0x50,
+	0xb7, 0x96, 0xcc, 0xbe, 0x3a, 0x77, 0xbf, 0xd0, 0x98, 0x2e, 0x28, 0x3a, 0xa7, 0xa2, 0xe7, 0x70,
+	0xd7, 0x0a, 0x66, 0x33, 0xca, 0xdf, 0x05, 0x32, 0xe0, 0xff, 0xd1, 0xa2, 0x42, 0x77, 0x03, 0xa8,
+	0x05, 0x47, 0xb1, 0x38, 0x21, 0x42, 0xc6, 0xec, 0xbe, 0x62, 0xf3, 0xb2, 0x22, 0xa3, 0x4c, 0x7d,
+	0x22, 0x89, 0xb9, 0xf6, 0x5d, 0xbe, 0x69, 0x94, 0x42, 0xb2, 0x82, 0xf3, 0x32, 0xba, 0x80, 0x56,
+	0x4e, 0xea, 0xce, 0x24, 0xe5, 0x63, 0x26, 0xbb, 0xb6, 0x4d, 0x85, 0x48, 0x9f, 0xb8, 0xac, 0x92,
+	0xdd, 0x9a, 0x6f, 0x4e, 0xa0, 0x36, 0xf4, 0x1c, 0xba, 0x4e, 0x2a, 0xd9, 0x80, 0x03, 0xea, 0x11,
+	0x6b, 0x49, 0x1d, 0x55, 0xbc, 0x0a, 0x4e, 0xa6, 0xb7, 0xad, 0x57, 0xf3, 0x6f, 0x11, 0xf4, 0x71,
+	0x62, 0x57, 0xf2, 0xd9, 0x13, 0xd0, 0x2d, 0xc6, 0xa4, 0x90, 0x9c, 0xf8, 0x66, 0xe6, 0xfb, 0x3b,
+	0x3a, 0x6a, 0x42, 0x6d, 0xb6, 0x0c, 0xc4, 0x22, 0xe1, 0x0a, 0x8a, 0xcb, 0x68, 0x91, 0x29, 0x9f,
+	0xb9, 0x2b, 0xa9, 0x98, 0xb2, 0x1e, 0x5b, 0xad, 0x5c, 0x39, 0x62, 0x73, 0x65, 0x4a, 0x05, 0xef,
+	0x06, 0xa2, 0xad, 0xdb, 0x4b, 0x4a, 0xbc, 0x60, 0x9b, 0x7b, 0x5f, 0xa1, 0x39, 0x15, 0x3d, 0x85,
+	0x43, 0x4e, 0x7d, 0xe2, 0xf2, 0x04, 0x8b, 0x0d, 0xc9, 0x8a, 0x68, 0x00, 0x3a, 0xcf, 0x35, 0xa0,
+	0x2a, 0xfb, 0x9d, 0xce, 0xa3, 0xf6, 0x75, 0xe3, 0xe6, 0x7b, 0x14, 0xef, 0x2c, 0x8a, 0x3a, 0x40,
+	0x78, 0xc4, 0x17, 0x0b, 0x26, 0x93, 0x84, 0x07, 0x71, 0x07, 0xe4, 0x64, 0xf4, 0x1a, 0x6a, 0x6e,
+	0xca, 0xa5, 0x46, 0x45, 0xa5, 0xbb, 0x9f, 0x4a, 0x97, 0x36, 0x11, 0x67, 0x60, 0x64, 0x40, 0x49,
+	0xc8, 0xf0, 0x9a, 0x35, 0xaa, 0xe1, 0xaa, 0x7a, 0xe7, 0x41, 0x6a, 0xd5, 0xd6, 0xa7, 0xf3, 0x08,
+	0xc0, 0x31, 0x17, 0xdd, 0x26, 0x87, 0x2e, 0xc3, 0xdd, 0xaa, 0xb6, 0x79, 0xef, 0xb9, 0xeb, 0xd8,
+	0x6f, 0x88, 0x6f, 0xd3, 0x4d, 0xb1, 0xe6, 0x37, 0x0d, 0x2a, 0x98, 0xce, 0xdd, 0xd0, 0xc9, 0x0d,
+	0xea, 0x01, 0x6c, 0x73, 0x44, 0x97, 0xb0, 0x18, 0x6e, 0xf6, 0x49, 0xa6, 0x36, 0x31, 0x78, 0x9d,
+	0x5f, 0x98, 0x5e, 0x38, 0xc7, 0xa9, 0x65, 0x0f, 0x2f, 0xe0, 0x28, 0x17, 0x46, 0x3a, 0x14, 0xaf,
+	0xe8, 0x46, 0x35, 0x4e, 0x15, 0x47, 0xaf, 0xe8, 0x05, 0x94, 0x3e, 0x91, 0x65, 0x40, 0x55, 0x93,
+	0x64, 0x0d, 0xc8, 0xf7, 0x20, 0x8e, 0xc9, 0x57, 0x85, 0x97, 0xda, 0xc9, 0x1b, 0xa8, 0x67, 0x8f,
+	0x8e, 0x00, 0xca, 0xdd, 0xde, 0x74, 0xf8, 0xc1, 0xd4, 0xf7, 0xd0, 0x21, 0x54, 0xb1, 0xd9, 0xed,
+	0x5f, 0x9e, 0x8d, 0x47, 0x1f, 0x75, 0x0d, 0x21, 0xa8, 0x4f, 0xcc, 0x71, 0x7f, 0x38, 0x1e, 0x5c,
+	0xf6, 0xcd, 0x91, 0x39, 0x35, 0xf5, 0xc2, 0x5b, 0xfd, 0xfb, 0xef, 0x63, 0xed, 0x67, 0x38, 0x7e,
+	0x85, 0xe3, 0xeb, 0x9f, 0xe3, 0x3d, 0xab, 0xac, 0xfe, 0x54, 0xa7, 0xff, 0x00, 0x08, 0xc5, 0x1f,
+	0x25, 0xf4, 0x04, 0x00, 0x00,
 }
@@ -32,6 +32,12 @@ enum ErrorType {
 	BAD_REQUEST
 }
 
+enum QueryLimitType {
+	SERIES,
+	DOCS,
+	BYTES
+}
+
 exception Error {
 	1: required ErrorType type = ErrorType.INTERNAL_ERROR
 	2: required string message
@@ -56,6 +62,7 @@ service Node {
 	// TODO(rartoul): Delete this once we delete the V1 code path
 	FetchBlocksMetadataRawResult fetchBlocksMetadataRaw(1: FetchBlocksMetadataRawRequest req) throws (1: Error err)
 	FetchBlocksMetadataRawV2Result fetchBlocksMetadataRawV2(1: FetchBlocksMetadataRawV2Request req) throws (1: Error err)
+	FetchBlocksHashTreeRawResult fetchBlocksHashTreeRaw(1: FetchBlocksHashTreeRawRequest req) throws (1: Error err)
 	void writeBatchRaw(1: WriteBatchRawRequest req) throws (1: WriteBatchRawErrors err)
 	void writeTaggedBatchRaw(1: WriteTaggedBatchRawRequest req) throws (1: WriteBatchRawErrors err)
 	void repair() throws (1: Error err)
@@ -143,11 +150,17 @@ struct FetchTaggedRequest {
 	5: required bool fetchData
 	6: optional i64 limit
 	7: optional TimeType rangeTimeType = TimeType.UNIX_SECONDS
+	8: optional i64 pageSize
+	9: optional binary pageToken
+	10: optional i64 docsLimit
+	11: optional i64 bytesLimit
 }
 
 struct FetchTaggedResult {
 	1: required list<FetchTaggedIDResult> elements
 	2: required bool exhaustive
+	3: optional binary nextPageToken
+	4: optional QueryLimitType exceededLimit
 }
 
 struct FetchTaggedIDResult {
@@ -235,6 +248,8 @@ struct FetchBlocksMetadataRawV2Request {
 	7: optional bool includeSizes
 	8: optional bool includeChecksums
 	9: optional bool includeLastRead
+	10: optional i32 hashTreeDepth
+	11: optional list<i32> hashTreeBuckets
 }
 
 struct FetchBlocksMetadataRawV2Result {
@@ -253,6 +268,23 @@ struct BlockMetadataV2 {
 	8: optional binary encodedTags
 }
 
+struct FetchBlocksHashTreeRawRequest {
+	1: required binary nameSpace
+	2: required i32 shard
+	3: required i64 rangeStart
+	4: required i64 rangeEnd
+	5: required i32 depth
+}
+
+struct FetchBlocksHashTreeRawResult {
+	1: required list<BlockHashTree> elements
+}
+
+struct BlockHashTree {
+	1: required i64 start
+	2: required list<i64> nodes
+}
+
 struct WriteBatchRawRequest {
 	1: required binary nameSpace
 	2: required list<WriteBatchRawRequestElement> elements
@@ -162,6 +162,69 @@ func (p *ErrorType) Value() (driver.Value, error) {
 	return int64(*p), nil
 }
 
+type QueryLimitType int64
+
+const (
+	QueryLimitType_SERIES QueryLimitType = 0
+	QueryLimitType_DOCS   QueryLimitType = 1
+	QueryLimitType_BYTES  QueryLimitType = 2
+)
+
+func (p QueryLimitType) String() string {
+	switch p {
+	case QueryLimitType_SERIES:
+		return "SERIES"
+	case QueryLimitType_DOCS:
+		return "DOCS"
+	case QueryLimitType_BYTES:
+		return "BYTES"
+	}
+	return "<UNSET>"
+}
+
+func QueryLimitTypeFromString(s string) (QueryLimitType, error) {
+	switch s {
+	case "SERIES":
+		return QueryLimitType_SERIES, nil
+	case "DOCS":
+		return QueryLimitType_DOCS, nil
+	case "BYTES":
+		return QueryLimitType_BYTES, nil
+	}
+	return QueryLimitType(0), fmt.Errorf("not a valid QueryLimitType string")
+}
+
+func QueryLimitTypePtr(v QueryLimitType) *QueryLimitType { return &v }
+
+func (p QueryLimitType) MarshalText() ([]byte, error) {
+	return []byte(p.String()), nil
+}
+
+func (p *QueryLimitType) UnmarshalText(text []byte) error {
+	q, err := QueryLimitTypeFromString(string(text))
+	if err != nil {
+		return err
+	}
+	*p = q
+	return nil
+}
+
+func (p *QueryLimitType) Scan(value interface{}) error {
+	v, ok := value.(int64)
+	if !ok {
+		return errors.New("Scan value is not int64")
+	}
+	*p = QueryLimitType(v)
+	return nil
+}
+
+func (p *QueryLimitType) Value() (driver.Value, error) {
+	if p == nil {
+		return nil, nil
+	}
+	return int64(*p), nil
+}
+
 // Attributes:
 //  - Type
 //  - Message
@@ -2499,6 +2562,10 @@ func (p *Segment) String() string {
 //  - FetchData
 //  - Limit
 //  - RangeTimeType
+//  - PageSize
+//  - PageToken
+//  - DocsLimit
+//  - BytesLimit
 type FetchTaggedRequest struct {
 	NameSpace     []byte   `thrift:"nameSpace,1,required" db:"nameSpace" json:"nameSpace"`
 	Query         []byte   `thrift:"query,2,required" db:"query" json:"query"`
@@ -2507,6 +2574,10 @@ type FetchTaggedRequest struct {
 	FetchData     bool     `thrift:"fetchData,5,required" db:"fetchData" json:"fetchData"`
 	Limit         *int64   `thrift:"limit,6" db:"limit" json:"limit,omitempty"`
 	RangeTimeType TimeType `thrift:"rangeTimeType,7" db:"rangeTimeType" json:"rangeTimeType,omitempty"`
+	PageSize      *int64   `thrift:"pageSize,8" db:"pageSize" json:"pageSize,omitempty"`
+	PageToken     []byte   `thrift:"pageToken,9" db:"pageToken" json:"pageToken,omitempty"`
+	DocsLimit     *int64   `thrift:"docsLimit,10" db:"docsLimit" json:"docsLimit,omitempty"`
+	BytesLimit    *int64   `thrift:"bytesLimit,11" db:"bytesLimit" json:"bytesLimit,omitempty"`
 }
 
 func NewFetchTaggedRequest() *FetchTaggedRequest {
@@ -2549,6 +2620,39 @@ var FetchTaggedRequest_RangeTimeType_DEFAULT TimeType = 0
 func (p *FetchTaggedRequest) GetRangeTimeType() TimeType {
 	return p.RangeTimeType
 }
+
+var FetchTaggedRequest_PageSize_DEFAULT int64
+
+func (p *FetchTaggedRequest) GetPageSize() int64 {
+	if !p.IsSetPageSize() {
+		return FetchTaggedRequest_PageSize_DEFAULT
+	}
+	return *p.PageSize
+}
+
+var FetchTaggedRequest_PageToken_DEFAULT []byte
+
+func (p *FetchTaggedRequest) GetPageToken() []byte {
+	return p.PageToken
+}
+
+var FetchTaggedRequest_DocsLimit_DEFAULT int64
+
+func (p *FetchTaggedRequest) GetDocsLimit() int64 {
+	if !p.IsSetDocsLimit() {
+		return FetchTaggedRequest_DocsLimit_DEFAULT
+	}
+	return *p.DocsLimit
+}
+
+var FetchTaggedRequest_BytesLimit_DEFAULT int64
+
+func (p *FetchTaggedRequest) GetBytesLimit() int64 {
+	if !p.IsSetBytesLimit() {
+		return FetchTaggedRequest_BytesLimit_DEFAULT
+	}
+	return *p.BytesLimit
+}
 func (p *FetchTaggedRequest) IsSetLimit() bool {
 	return p.Limit != nil
 }
@@ -2557,6 +2661,22 @@ func (p *FetchTaggedRequest) IsSetRangeTimeType() bool {
 	return p.RangeTimeType != FetchTaggedRequest_RangeTimeType_DEFAULT
 }
 
+func (p *FetchTaggedRequest) IsSetPageSize() bool {
+	return p.PageSize != nil
+}
+
+func (p *FetchTaggedRequest) IsSetPageToken() bool {
+	return p.PageToken != nil
+}
+
+func (p *FetchTaggedRequest) IsSetDocsLimit() bool {
+	return p.DocsLimit != nil
+}
+
+func (p *FetchTaggedRequest) IsSetBytesLimit() bool {
+	return p.BytesLimit != nil
+}
+
 func (p *FetchTaggedRequest) Read(iprot thrift.TProtocol) error {
 	if _, err := iprot.ReadStructBegin(); err != nil {
 		return thrift.PrependError(fmt.Sprintf("%T read error: ", p), err)
@@ -2610,6 +2730,22 @@ func (p *FetchTaggedRequest) Read(iprot thrift.TProtocol) error {
 			if err := p.ReadField7(iprot); err != nil {
 				return err
 			}
+		case 8:
+			if err := p.ReadField8(iprot); err != nil {
+				return err
+			}
+		case 9:
+			if err := p.ReadField9(iprot); err != nil {
+				return err
+			}
+		case 10:
+			if err := p.ReadField10(iprot); err != nil {
+				return err
+			}
+		case 11:
+			if err := p.ReadField11(iprot); err != nil {
+				return err
+			}
 		default:
 			if err := iprot.Skip(fieldTypeId); err != nil {
 				return err
@@ -2704,6 +2840,42 @@ func (p *FetchTaggedRequest) ReadField7(iprot thrift.TProtocol) error {
 	return nil
 }
 
+func (p *FetchTaggedRequest) ReadField8(iprot thrift.TProtocol) error {
+	if v, err := iprot.ReadI64(); err != nil {
+		return thrift.PrependError("error reading field 8: ", err)
+	} else {
+		p.PageSize = &v
+	}
+	return nil
+}
+
+func (p *FetchTaggedRequest) ReadField9(iprot thrift.TProtocol) error {
+	if v, err := iprot.ReadBinary(); err != nil {
+		return thrift.PrependError("error reading field 9: ", err)
+	} else {
+		p.PageToken = v
+	}
+	return nil
+}
+
+func (p *FetchTaggedRequest) ReadField10(iprot thrift.TProtocol) error {
+	if v, err := iprot.ReadI64(); err != nil {
+		return thrift.PrependError("error reading field 10: ", err)
+	} else {
+		p.DocsLimit = &v
+	}
+	return nil
+}
+
+func (p *FetchTaggedRequest) ReadField11(iprot thrift.TProtocol) error {
+	if v, err := iprot.ReadI64(); err != nil {
+		return thrift.PrependError("error reading field 11: ", err)
+	} else {
+		p.BytesLimit = &v
+	}
+	return nil
+}
+
 func (p *FetchTaggedRequest) Write(oprot thrift.TProtocol) error {
 	if err := oprot.WriteStructBegin("FetchTaggedRequest"); err != nil {
 		return thrift.PrependError(fmt.Sprintf("%T write struct begin error: ", p), err)
@@ -2730,6 +2902,18 @@ func (p *FetchTaggedRequest) Write(oprot thrift.TProtocol) error {
 		if err := p.writeField7(oprot); err != nil {
 			return err
 		}
+		if err := p.writeField8(oprot); err != nil {
+			return err
+		}
+		if err := p.writeField9(oprot); err != nil {
+			return err
+		}
+		if err := p.writeField10(oprot); err != nil {
+			return err
+		}
+		if err := p.writeField11(oprot); err != nil {
+			return err
+		}
 	}
 	if err := oprot.WriteFieldStop(); err != nil {
 		return thrift.PrependError("write field stop error: ", err)
@@ -2835,6 +3019,66 @@ func (p *FetchTaggedRequest) writeField7(oprot thrift.TProtocol) (err error) {
 	return err
 }
 
+func (p *FetchTaggedRequest) writeField8(oprot thrift.TProtocol) (err error) {
+	if p.IsSetPageSize() {
+		if err := oprot.WriteFieldBegin("pageSize", thrift.I64, 8); err != nil {
+			return thrift.PrependError(fmt.Sprintf("%T write field begin error 8:pageSize: ", p), err)
+		}
+		if err := oprot.WriteI64(int64(*p.PageSize)); err != nil {
+			return thrift.PrependError(fmt.Sprintf("%T.pageSize (8) field write error: ", p), err)
+		}
+		if err := oprot.WriteFieldEnd(); err != nil {
+			return thrift.PrependError(fmt.Sprintf("%T write field end error 8:pageSize: ", p), err)
+		}
+	}
+	return err
+}
+
+func (p *FetchTaggedRequest) writeField9(oprot thrift.TProtocol) (err error) {
+	if p.IsSetPageToken() {
+		if err := oprot.WriteFieldBegin("pageToken", thrift.STRING, 9); err != nil {
+			return thrift.PrependError(fmt.Sprintf("%T write field begin error 9:pageToken: ", p), err)
+		}
+		if err := oprot.WriteBinary(p.PageToken); err != nil {
+			return thrift.PrependError(fmt.Sprintf("%T.pageToken (9) field write error: ", p), err)
+		}
+		if err := oprot.WriteFieldEnd(); err != nil {
+			return thrift.PrependError(fmt.Sprintf("%T write field end error 9:pageToken: ", p), err)
+		}
+	}
+	return err
+}
+
+func (p *FetchTaggedRequest) writeField10(oprot thrift.TProtocol) (err error) {
+	if p.IsSetDocsLimit() {
+		if err := oprot.WriteFieldBegin("docsLimit", thrift.I64, 10); err != nil {
+			return thrift.PrependError(fmt.Sprintf("%T write field begin error 10:docsLimit: ", p), err)
+		}
+		if err := oprot.WriteI64(int64(*p.DocsLimit)); err != nil {
+			return thrift.PrependError(fmt.Sprintf("%T.docsLimit (10) field write error: ", p), err)
+		}
+		if err := oprot.WriteFieldEnd(); err != nil {
+			return thrift.PrependError(fmt.Sprintf("%T write field end error 10:docsLimit: ", p), err)
+		}
+	}
+	return err
+}
+
+func (p *FetchTaggedRequest) writeField11(oprot thrift.TProtocol) (err error) {
+	if p.IsSetBytesLimit() {
+		if err := oprot.WriteFieldBegin("bytesLimit", thrift.I64, 11); err != nil {
+			return thrift.PrependError(fmt.Sprintf("%T write field begin error 11:bytesLimit: ", p), err)
+		}
+		if err := oprot.WriteI64(int64(*p.BytesLimit)); err != nil {
+			return thrift.PrependError(fmt.Sprintf("%T.bytesLimit (11) field write error: ", p), err)
+		}
+		if err := oprot.WriteFieldEnd(); err != nil {
+			return thrift.PrependError(fmt.Sprintf("%T write field end error 11:bytesLimit: ", p), err)
+		}
+	}
+	return err
+}
+
 func (p *FetchTaggedRequest) String() string {
 	if p == nil {
 		return "<nil>"
@@ -2845,9 +3089,13 @@ func (p *FetchTaggedRequest) String() string {
 // Attributes:
 //  - Elements
 //  - Exhaustive
+//  - NextPageToken
+//  - ExceededLimit
 type FetchTaggedResult_ struct {
-	Elements   []*FetchTaggedIDResult_ `thrift:"elements,1,required" db:"elements" json:"elements"`
-	Exhaustive bool                    `thrift:"exhaustive,2,required" db:"exhaustive" json:"exhaustive"`
+	Elements      []*FetchTaggedIDResult_ `thrift:"elements,1,required" db:"elements" json:"elements"`
+	Exhaustive    bool                    `thrift:"exhaustive,2,required" db:"exhaustive" json:"exhaustive"`
+	NextPageToken []byte                  `thrift:"nextPageToken,3" db:"nextPageToken" json:"nextPageToken,omitempty"`
+	ExceededLimit *QueryLimitType         `thrift:"exceededLimit,4" db:"exceededLimit" json:"exceededLimit,omitempty"`
 }
 
 func NewFetchTaggedResult_() *FetchTaggedResult_ {
@@ -2861,6 +3109,29 @@ func (p *FetchTaggedResult_) GetElements() []*FetchTaggedIDResult_ {
 func (p *FetchTaggedResult_) GetExhaustive() bool {
 	return p.Exhaustive
 }
+
+var FetchTaggedResult__NextPageToken_DEFAULT []byte
+
+func (p *FetchTaggedResult_) GetNextPageToken() []byte {
+	return p.NextPageToken
+}
+
+var FetchTaggedResult__ExceededLimit_DEFAULT QueryLimitType
+
+func (p *FetchTaggedResult_) GetExceededLimit() QueryLimitType {
+	if !p.IsSetExceededLimit() {
+		return FetchTaggedResult__ExceededLimit_DEFAULT
+	}
+	return *p.ExceededLimit
+}
+func (p *FetchTaggedResult_) IsSetNextPageToken() bool {
+	return p.NextPageToken != nil
+}
+
+func (p *FetchTaggedResult_) IsSetExceededLimit() bool {
+	return p.ExceededLimit != nil
+}
+
 func (p *FetchTaggedResult_) Read(iprot thrift.TProtocol) error {
 	if _, err := iprot.ReadStructBegin(); err != nil {
 		return thrift.PrependError(fmt.Sprintf("%T read error: ", p), err)
@@ -2888,6 +3159,14 @@ func (p *FetchTaggedResult_) Read(iprot thrift.TProtocol) error {
 				return err
 			}
 			issetExhaustive = true
+		case 3:
+			if err := p.ReadField3(iprot); err != nil {
+				return err
+			}
+		case 4:
+			if err := p.ReadField4(iprot); err != nil {
+				return err
+			}
 		default:
 			if err := iprot.Skip(fieldTypeId); err != nil {
 				return err
@@ -2938,6 +3217,25 @@ func (p *FetchTaggedResult_) ReadField2(iprot thrift.TProtocol) error {
 	return nil
 }
 
+func (p *FetchTaggedResult_) ReadField3(iprot thrift.TProtocol) error {
+	if v, err := iprot.ReadBinary(); err != nil {
+		return thrift.PrependError("error reading field 3: ", err)
+	} else {
+		p.NextPageToken = v
+	}
+	return nil
+}
+
+func (p *FetchTaggedResult_) ReadField4(iprot thrift.TProtocol) error {
+	if v, err := iprot.ReadI32(); err != nil {
+		return thrift.PrependError("error reading field 4: ", err)
+	} else {
+		temp := QueryLimitType(v)
+		p.ExceededLimit = &temp
+	}
+	return nil
+}
+
 func (p *FetchTaggedResult_) Write(oprot thrift.TProtocol) error {
 	if err := oprot.WriteStructBegin("FetchTaggedResult"); err != nil {
 		return thrift.PrependError(fmt.Sprintf("%T write struct begin error: ", p), err)
@@ -2949,6 +3247,12 @@ func (p *FetchTaggedResult_) Write(oprot thrift.TProtocol) error {
 		if err := p.writeField2(oprot); err != nil {
 			return err
 		}
+		if err := p.writeField3(oprot); err != nil {
+			return err
+		}
+		if err := p.writeField4(oprot); err != nil {
+			return err
+		}
 	}
 	if err := oprot.WriteFieldStop(); err != nil {
 		return thrift.PrependError("write field stop error: ", err)
@@ -2993,6 +3297,36 @@ func (p *FetchTaggedResult_) writeField2(oprot thrift.TProtocol) (err error) {
 	return err
 }
 
+func (p *FetchTaggedResult_) writeField3(oprot thrift.TProtocol) (err error) {
+	if p.IsSetNextPageToken() {
+		if err := oprot.WriteFieldBegin("nextPageToken", thrift.STRING, 3); err != nil {
+			return thrift.PrependError(fmt.Sprintf("%T write field begin error 3:nextPageToken: ", p), err)
+		}
+		if err := oprot.WriteBinary(p.NextPageToken); err != nil {
+			return thrift.PrependError(fmt.Sprintf("%T.nextPageToken (3) field write error: ", p), err)
+		}
+		if err := oprot.WriteFieldEnd(); err != nil {
+			return thrift.PrependError(fmt.Sprintf("%T write field end error 3:nextPageToken: ", p), err)
+		}
+	}
+	return err
+}
+
+func (p *FetchTaggedResult_) writeField4(oprot thrift.TProtocol) (err error) {
+	if p.IsSetExceededLimit() {
+		if err := oprot.WriteFieldBegin("exceededLimit", thrift.I32, 4); err != nil {
+			return thrift.PrependError(fmt.Sprintf("%T write field begin error 4:exceededLimit: ", p), err)
+		}
+		if err := oprot.WriteI32(int32(*p.ExceededLimit)); err != nil {
+			return thrift.PrependError(fmt.Sprintf("%T.exceededLimit (4) field write error: ", p), err)
+		}
+		if err := oprot.WriteFieldEnd(); err != nil {
+			return thrift.PrependError(fmt.Sprintf("%T write field end error 4:exceededLimit: ", p), err)
+		}
+	}
+	return err
+}
+
 func (p *FetchTaggedResult_) String() string {
 	if p == nil {
 		return "<nil>"
@@ -5405,16 +5739,20 @@ func (p *BlockMetadata) String() string {
 //  - IncludeSizes
 //  - IncludeChecksums
 //  - IncludeLastRead
+//  - HashTreeDepth
+//  - HashTreeBuckets
 type FetchBlocksMetadataRawV2Request struct {
-	NameSpace        []byte `thrift:"nameSpace,1,required" db:"nameSpace" json:"nameSpace"`
-	Shard            int32  `thrift:"shard,2,required" db:"shard" json:"shard"`
-	RangeStart       int64  `thrift:"rangeStart,3,required" db:"rangeStart" json:"rangeStart"`
-	RangeEnd         int64  `thrift:"rangeEnd,4,required" db:"rangeEnd" json:"rangeEnd"`
-	Limit            int64  `thrift:"limit,5,required" db:"limit" json:"limit"`
-	PageToken        []byte `thrift:"pageToken,6" db:"pageToken" json:"pageToken,omitempty"`
-	IncludeSizes     *bool  `thrift:"includeSizes,7" db:"includeSizes" json:"includeSizes,omitempty"`
-	IncludeChecksums *bool  `thrift:"includeChecksums,8" db:"includeChecksums" json:"includeChecksums,omitempty"`
-	IncludeLastRead  *bool  `thrift:"includeLastRead,9" db:"includeLastRead" json:"includeLastRead,omitempty"`
+	NameSpace        []byte  `thrift:"nameSpace,1,required" db:"nameSpace" json:"nameSpace"`
+	Shard            int32   `thrift:"shard,2,required" db:"shard" json:"shard"`
+	RangeStart       int64   `thrift:"rangeStart,3,required" db:"rangeStart" json:"rangeStart"`
+	RangeEnd         int64   `thrift:"rangeEnd,4,required" db:"rangeEnd" json:"rangeEnd"`
+	Limit            int64   `thrift:"limit,5,required" db:"limit" json:"limit"`
+	PageToken        []byte  `thrift:"pageToken,6" db:"pageToken" json:"pageToken,omitempty"`
+	IncludeSizes     *bool   `thrift:"includeSizes,7" db:"includeSizes" json:"includeSizes,omitempty"`
+	IncludeChecksums *bool   `thrift:"includeChecksums,8" db:"includeChecksums" json:"includeChecksums,omitempty"`
+	IncludeLastRead  *bool   `thrift:"includeLastRead,9" db:"includeLastRead" json:"includeLastRead,omitempty"`
+	HashTreeDepth    *int32  `thrift:"hashTreeDepth,10" db:"hashTreeDepth" json:"hashTreeDepth,omitempty"`
+	HashTreeBuckets  []int32 `thrift:"hashTreeBuckets,11" db:"hashTreeBuckets" json:"hashTreeBuckets,omitempty"`
 }
 
 func NewFetchBlocksMetadataRawV2Request() *FetchBlocksMetadataRawV2Request {
@@ -5473,6 +5811,21 @@ func (p *FetchBlocksMetadataRawV2Request) GetIncludeLastRead() bool {
 	}
 	return *p.IncludeLastRead
 }
+
+var FetchBlocksMetadataRawV2Request_HashTreeDepth_DEFAULT int32
+
+func (p *FetchBlocksMetadataRawV2Request) GetHashTreeDepth() int32 {
+	if !p.IsSetHashTreeDepth() {
+		return FetchBlocksMetadataRawV2Request_HashTreeDepth_DEFAULT
+	}
+	return *p.HashTreeDepth
+}
+
+var FetchBlocksMetadataRawV2Request_HashTreeBuckets_DEFAULT []int32
+
+func (p *FetchBlocksMetadataRawV2Request) GetHashTreeBuckets() []int32 {
+	return p.HashTreeBuckets
+}
 func (p *FetchBlocksMetadataRawV2Request) IsSetPageToken() bool {
 	return p.PageToken != nil
 }
@@ -5489,6 +5842,14 @@ func (p *FetchBlocksMetadataRawV2Request) IsSetIncludeLastRead() bool {
 	return p.IncludeLastRead != nil
 }
 
+func (p *FetchBlocksMetadataRawV2Request) IsSetHashTreeDepth() bool {
+	return p.HashTreeDepth != nil
+}
+
+func (p *FetchBlocksMetadataRawV2Request) IsSetHashTreeBuckets() bool {
+	return p.HashTreeBuckets != nil
+}
+
 func (p *FetchBlocksMetadataRawV2Request) Read(iprot thrift.TProtocol) error {
 	if _, err := iprot.ReadStructBegin(); err != nil {
 		return thrift.PrependError(fmt.Sprintf("%T read error: ", p), err)
@@ -5550,6 +5911,14 @@ func (p *FetchBlocksMetadataRawV2Request) Read(iprot thrift.TProtocol) error {
 			if err := p.ReadField9(iprot); err != nil {
 				return err
 			}
+		case 10:
+			if err := p.ReadField10(iprot); err != nil {
+				return err
+			}
+		case 11:
+			if err := p.ReadField11(iprot); err != nil {
+				return err
+			}
 		default:
 			if err := iprot.Skip(fieldTypeId); err != nil {
 				return err
@@ -5661,6 +6030,37 @@ func (p *FetchBlocksMetadataRawV2Request) ReadField9(iprot thrift.TProtocol) err
 	return nil
 }
 
+func (p *FetchBlocksMetadataRawV2Request) ReadField10(iprot thrift.TProtocol) error {
+	if v, err := iprot.ReadI32(); err != nil {
+		return thrift.PrependError("error reading field 10: ", err)
+	} else {
+		p.HashTreeDepth = &v
+	}
+	return nil
+}
+
+func (p *FetchBlocksMetadataRawV2Request) ReadField11(iprot thrift.TProtocol) error {
+	_, size, err := iprot.ReadListBegin()
+	if err != nil {
+		return thrift.PrependError("error reading list begin: ", err)
+	}
+	tSlice := make([]int32, 0, size)
+	p.HashTreeBuckets = tSlice
+	for i := 0; i < size; i++ {
+		var _elem23 int32
+		if v, err := iprot.ReadI32(); err != nil {
+			return thrift.PrependError("error reading field 0: ", err)
+		} else {
+			_elem23 = v
+		}
+		p.HashTreeBuckets = append(p.HashTreeBuckets, _elem23)
+	}
+	if err := iprot.ReadListEnd(); err != nil {
+		return thrift.PrependError("error reading list end: ", err)
+	}
+	return nil
+}
+
 func (p *FetchBlocksMetadataRawV2Request) Write(oprot thrift.TProtocol) error {
 	if err := oprot.WriteStructBegin("FetchBlocksMetadataRawV2Request"); err != nil {
 		return thrift.PrependError(fmt.Sprintf("%T write struct begin error: ", p), err)
@@ -5693,6 +6093,12 @@ func (p *FetchBlocksMetadataRawV2Request) Write(oprot thrift.TProtocol) error {
 		if err := p.writeField9(oprot); err != nil {
 			return err
 		}
+		if err := p.writeField10(oprot); err != nil {
+			return err
+		}
+		if err := p.writeField11(oprot); err != nil {
+			return err
+		}
 	}
 	if err := oprot.WriteFieldStop(); err != nil {
 		return thrift.PrependError("write field stop error: ", err)
@@ -5828,7 +6234,45 @@ func (p *FetchBlocksMetadataRawV2Request) writeField9(oprot thrift.TProtocol) (e
 	return err
 }
 
-func (p *FetchBlocksMetadataRawV2Request) String() string {
+func (p *FetchBlocksMetadataRawV2Request) writeField10(oprot thrift.TProtocol) (err error) {
+	if p.IsSetHashTreeDepth() {
+		if err := oprot.WriteFieldBegin("hashTreeDepth", thrift.I32, 10); err != nil {
+			return thrift.PrependError(fmt.Sprintf("%T write field begin error 10:hashTreeDepth: ", p), err)
+		}
+		if err := oprot.WriteI32(int32(*p.HashTreeDepth)); err != nil {
+			return thrift.PrependError(fmt.Sprintf("%T.hashTreeDepth (10) field write error: ", p), err)
+		}
+		if err := oprot.WriteFieldEnd(); err != nil {
+			return thrift.PrependError(fmt.Sprintf("%T write field end error 10:hashTreeDepth: ", p), err)
+		}
+	}
+	return err
+}
+
+func (p *FetchBlocksMetadataRawV2Request) writeField11(oprot thrift.TProtocol) (err error) {
+	if p.IsSetHashTreeBuckets() {
+		if err := oprot.WriteFieldBegin("hashTreeBuckets", thrift.LIST, 11); err != nil {
+			return thrift.PrependError(fmt.Sprintf("%T write field begin error 11:hashTreeBuckets: ", p), err)
+		}
+		if err := oprot.WriteListBegin(thrift.I32, len(p.HashTreeBuckets)); err != nil {
+			return thrift.PrependError("error writing list begin: ", err)
+		}
+		for _, v := range p.HashTreeBuckets {
+			if err := oprot.WriteI32(int32(v)); err != nil {
+				return thrift.PrependError(fmt.Sprintf("%T. (0) field write error: ", p), err)
+			}
+		}
+		if err := oprot.WriteListEnd(); err != nil {
+			return thrift.PrependError("error writing list end: ", err)
+		}
+		if err := oprot.WriteFieldEnd(); err != nil {
+			return thrift.PrependError(fmt.Sprintf("%T write field end error 11:hashTreeBuckets: ", p), err)
+		}
+	}
+	return err
+}
+
+func (p *FetchBlocksMetadataRawV2Request) String() string {
 	if p == nil {
 		return "<nil>"
 	}
@@ -6190,67 +6634,704 @@ func (p *BlockMetadataV2) ReadField2(iprot thrift.TProtocol) error {
 	} else {
 		p.Start = v
 	}
-	return nil
-}
-
-func (p *BlockMetadataV2) ReadField3(iprot thrift.TProtocol) error {
-	p.Err = &Error{
-		Type: 0,
+	return nil
+}
+
+func (p *BlockMetadataV2) ReadField3(iprot thrift.TProtocol) error {
+	p.Err = &Error{
+		Type: 0,
+	}
+	if err := p.Err.Read(iprot); err != nil {
+		return thrift.PrependError(fmt.Sprintf("%T error reading struct: ", p.Err), err)
+	}
+	return nil
+}
+
+func (p *BlockMetadataV2) ReadField4(iprot thrift.TProtocol) error {
+	if v, err := iprot.ReadI64(); err != nil {
+		return thrift.PrependError("error reading field 4: ", err)
+	} else {
+		p.Size = &v
+	}
+	return nil
+}
+
+func (p *BlockMetadataV2) ReadField5(iprot thrift.TProtocol) error {
+	if v, err := iprot.ReadI64(); err != nil {
+		return thrift.PrependError("error reading field 5: ", err)
+	} else {
+		p.Checksum = &v
+	}
+	return nil
+}
+
+func (p *BlockMetadataV2) ReadField6(iprot thrift.TProtocol) error {
+	if v, err := iprot.ReadI64(); err != nil {
+		return thrift.PrependError("error reading field 6: ", err)
+	} else {
+		p.LastRead = &v
+	}
+	return nil
+}
+
+func (p *BlockMetadataV2) ReadField7(iprot thrift.TProtocol) error {
+	if v, err := iprot.ReadI32(); err != nil {
+		return thrift.PrependError("error reading field 7: ", err)
+	} else {
+		temp := TimeType(v)
+		p.LastReadTimeType = temp
+	}
+	return nil
+}
+
+func (p *BlockMetadataV2) ReadField8(iprot thrift.TProtocol) error {
+	if v, err := iprot.ReadBinary(); err != nil {
+		return thrift.PrependError("error reading field 8: ", err)
+	} else {
+		p.EncodedTags = v
+	}
+	return nil
+}
+
+func (p *BlockMetadataV2) Write(oprot thrift.TProtocol) error {
+	if err := oprot.WriteStructBegin("BlockMetadataV2"); err != nil {
+		return thrift.PrependError(fmt.Sprintf("%T write struct begin error: ", p), err)
+	}
+	if p != nil {
+		if err := p.writeField1(oprot); err != nil {
+			return err
+		}
+		if err := p.writeField2(oprot); err != nil {
+			return err
+		}
+		if err := p.writeField3(oprot); err != nil {
+			return err
+		}
+		if err := p.writeField4(oprot); err != nil {
+			return err
+		}
+		if err := p.writeField5(oprot); err != nil {
+			return err
+		}
+		if err := p.writeField6(oprot); err != nil {
+			return err
+		}
+		if err := p.writeField7(oprot); err != nil {
+			return err
+		}
+		if err := p.writeField8(oprot); err != nil {
+			return err
+		}
+	}
+	if err := oprot.WriteFieldStop(); err != nil {
+		return thrift.PrependError("write field stop error: ", err)
+	}
+	if err := oprot.WriteStructEnd(); err != nil {
+		return thrift.PrependError("write struct stop error: ", err)
+	}
+	return nil
+}
+
+func (p *BlockMetadataV2) writeField1(oprot thrift.TProtocol) (err error) {
+	if err := oprot.WriteFieldBegin("id", thrift.STRING, 1); err != nil {
+		return thrift.PrependError(fmt.Sprintf("%T write field begin error 1:id: ", p), err)
+	}
+	if err := oprot.WriteBinary(p.ID); err != nil {
+		return thrift.PrependError(fmt.Sprintf("%T.id (1) field write error: ", p), err)
+	}
+	if err := oprot.WriteFieldEnd(); err != nil {
+		return thrift.PrependError(fmt.Sprintf("%T write field end error 1:id: ", p), err)
+	}
+	return err
+}
+
+func (p *BlockMetadataV2) writeField2(oprot thrift.TProtocol) (err error) {
+	if err := oprot.WriteFieldBegin("start", thrift.I64, 2); err != nil {
+		return thrift.PrependError(fmt.Sprintf("%T write field begin error 2:start: ", p), err)
+	}
+	if err := oprot.WriteI64(int64(p.Start)); err != nil {
+		return thrift.PrependError(fmt.Sprintf("%T.start (2) field write error: ", p), err)
+	}
+	if err := oprot.WriteFieldEnd(); err != nil {
+		return thrift.PrependError(fmt.Sprintf("%T write field end error 2:start: ", p), err)
+	}
+	return err
+}
+
+func (p *BlockMetadataV2) writeField3(oprot thrift.TProtocol) (err error) {
+	if p.IsSetErr() {
+		if err := oprot.WriteFieldBegin("err", thrift.STRUCT, 3); err != nil {
+			return thrift.PrependError(fmt.Sprintf("%T write field begin error 3:err: ", p), err)
+		}
+		if err := p.Err.Write(oprot); err != nil {
+			return thrift.PrependError(fmt.Sprintf("%T error writing struct: ", p.Err), err)
+		}
+		if err := oprot.WriteFieldEnd(); err != nil {
+			return thrift.PrependError(fmt.Sprintf("%T write field end error 3:err: ", p), err)
+		}
+	}
+	return err
+}
+
+func (p *BlockMetadataV2) writeField4(oprot thrift.TProtocol) (err error) {
+	if p.IsSetSize() {
+		if err := oprot.WriteFieldBegin("size", thrift.I64, 4); err != nil {
+			return thrift.PrependError(fmt.Sprintf("%T write field begin error 4:size: ", p), err)
+		}
+		if err := oprot.WriteI64(int64(*p.Size)); err != nil {
+			return thrift.PrependError(fmt.Sprintf("%T.size (4) field write error: ", p), err)
+		}
+		if err := oprot.WriteFieldEnd(); err != nil {
+			return thrift.PrependError(fmt.Sprintf("%T write field end error 4:size: ", p), err)
+		}
+	}
+	return err
+}
+
+func (p *BlockMetadataV2) writeField5(oprot thrift.TProtocol) (err error) {
+	if p.IsSetChecksum() {
+		if err := oprot.WriteFieldBegin("checksum", thrift.I64, 5); err != nil {
+			return thrift.PrependError(fmt.Sprintf("%T write field begin error 5:checksum: ", p), err)
+		}
+		if err := oprot.WriteI64(int64(*p.Checksum)); err != nil {
+			return thrift.PrependError(fmt.Sprintf("%T.checksum (5) field write error: ", p), err)
+		}
+		if err := oprot.WriteFieldEnd(); err != nil {
+			return thrift.PrependError(fmt.Sprintf("%T write field end error 5:checksum: ", p), err)
+		}
+	}
+	return err
+}
+
+func (p *BlockMetadataV2) writeField6(oprot thrift.TProtocol) (err error) {
+	if p.IsSetLastRead() {
+		if err := oprot.WriteFieldBegin("lastRead", thrift.I64, 6); err != nil {
+			return thrift.PrependError(fmt.Sprintf("%T write field begin error 6:lastRead: ", p), err)
+		}
+		if err := oprot.WriteI64(int64(*p.LastRead)); err != nil {
+			return thrift.PrependError(fmt.Sprintf("%T.lastRead (6) field write error: ", p), err)
+		}
+		if err := oprot.WriteFieldEnd(); err != nil {
+			return thrift.PrependError(fmt.Sprintf("%T write field end error 6:lastRead: ", p), err)
+		}
+	}
+	return err
+}
+
+func (p *BlockMetadataV2) writeField7(oprot thrift.TProtocol) (err error) {
+	if p.IsSetLastReadTimeType() {
+		if err := oprot.WriteFieldBegin("lastReadTimeType", thrift.I32, 7); err != nil {
+			return thrift.PrependError(fmt.Sprintf("%T write field begin error 7:lastReadTimeType: ", p), err)
+		}
+		if err := oprot.WriteI32(int32(p.LastReadTimeType)); err != nil {
+			return thrift.PrependError(fmt.Sprintf("%T.lastReadTimeType (7) field write error: ", p), err)
+		}
+		if err := oprot.WriteFieldEnd(); err != nil {
+			return thrift.PrependError(fmt.Sprintf("%T write field end error 7:lastReadTimeType: ", p), err)
+		}
+	}
+	return err
+}
+
+func (p *BlockMetadataV2) writeField8(oprot thrift.TProtocol) (err error) {
+	if p.IsSetEncodedTags() {
+		if err := oprot.WriteFieldBegin("encodedTags", thrift.STRING, 8); err != nil {
+			return thrift.PrependError(fmt.Sprintf("%T write field begin error 8:encodedTags: ", p), err)
+		}
+		if err := oprot.WriteBinary(p.EncodedTags); err != nil {
+			return thrift.PrependError(fmt.Sprintf("%T.encodedTags (8) field write error: ", p), err)
+		}
+		if err := oprot.WriteFieldEnd(); err != nil {
+			return thrift.PrependError(fmt.Sprintf("%T write field end error 8:encodedTags: ", p), err)
+		}
+	}
+	return err
+}
+
+func (p *BlockMetadataV2) String() string {
+	if p == nil {
+		return "<nil>"
+	}
+	return fmt.Sprintf("BlockMetadataV2(%+v)", *p)
+}
+
+// Attributes:
+//  - NameSpace
+//  - Shard
+//  - RangeStart
+//  - RangeEnd
+//  - Depth
+type FetchBlocksHashTreeRawRequest struct {
+	NameSpace  []byte `thrift:"nameSpace,1,required" db:"nameSpace" json:"nameSpace"`
+	Shard      int32  `thrift:"shard,2,required" db:"shard" json:"shard"`
+	RangeStart int64  `thrift:"rangeStart,3,required" db:"rangeStart" json:"rangeStart"`
+	RangeEnd   int64  `thrift:"rangeEnd,4,required" db:"rangeEnd" json:"rangeEnd"`
+	Depth      int32  `thrift:"depth,5,required" db:"depth" json:"depth"`
+}
+
+func NewFetchBlocksHashTreeRawRequest() *FetchBlocksHashTreeRawRequest {
+	return &FetchBlocksHashTreeRawRequest{}
+}
+
+func (p *FetchBlocksHashTreeRawRequest) GetNameSpace() []byte {
+	return p.NameSpace
+}
+
+func (p *FetchBlocksHashTreeRawRequest) GetShard() int32 {
+	return p.Shard
+}
+
+func (p *FetchBlocksHashTreeRawRequest) GetRangeStart() int64 {
+	return p.RangeStart
+}
+
+func (p *FetchBlocksHashTreeRawRequest) GetRangeEnd() int64 {
+	return p.RangeEnd
+}
+
+func (p *FetchBlocksHashTreeRawRequest) GetDepth() int32 {
+	return p.Depth
+}
+func (p *FetchBlocksHashTreeRawRequest) Read(iprot thrift.TProtocol) error {
+	if _, err := iprot.ReadStructBegin(); err != nil {
+		return thrift.PrependError(fmt.Sprintf("%T read error: ", p), err)
+	}
+
+	var issetNameSpace bool = false
+	var issetShard bool = false
+	var issetRangeStart bool = false
+	var issetRangeEnd bool = false
+	var issetDepth bool = false
+
+	for {
+		_, fieldTypeId, fieldId, err := iprot.ReadFieldBegin()
+		if err != nil {
+			return thrift.PrependError(fmt.Sprintf("%T field %d read error: ", p, fieldId), err)
+		}
+		if fieldTypeId == thrift.STOP {
+			break
+		}
+		switch fieldId {
+		case 1:
+			if err := p.ReadField1(iprot); err != nil {
+				return err
+			}
+			issetNameSpace = true
+		case 2:
+			if err := p.ReadField2(iprot); err != nil {
+				return err
+			}
+			issetShard = true
+		case 3:
+			if err := p.ReadField3(iprot); err != nil {
+				return err
+			}
+			issetRangeStart = true
+		case 4:
+			if err := p.ReadField4(iprot); err != nil {
+				return err
+			}
+			issetRangeEnd = true
+		case 5:
+			if err := p.ReadField5(iprot); err != nil {
+				return err
+			}
+			issetDepth = true
+		default:
+			if err := iprot.Skip(fieldTypeId); err != nil {
+				return err
+			}
+		}
+		if err := iprot.ReadFieldEnd(); err != nil {
+			return err
+		}
+	}
+	if err := iprot.ReadStructEnd(); err != nil {
+		return thrift.PrependError(fmt.Sprintf("%T read struct end error: ", p), err)
+	}
+	if !issetNameSpace {
+		return thrift.NewTProtocolExceptionWithType(thrift.INVALID_DATA, fmt.Errorf("Required field NameSpace is not set"))
+	}
+	if !issetShard {
+		return thrift.NewTProtocolExceptionWithType(thrift.INVALID_DATA, fmt.Errorf("Required field Shard is not set"))
+	}
+	if !issetRangeStart {
+		return thrift.NewTProtocolExceptionWithType(thrift.INVALID_DATA, fmt.Errorf("Required field RangeStart is not set"))
+	}
+	if !issetRangeEnd {
+		return thrift.NewTProtocolExceptionWithType(thrift.INVALID_DATA, fmt.Errorf("Required field RangeEnd is not set"))
+	}
+	if !issetDepth {
+		return thrift.NewTProtocolExceptionWithType(thrift.INVALID_DATA, fmt.Errorf("Required field Depth is not set"))
+	}
+	return nil
+}
+
+func (p *FetchBlocksHashTreeRawRequest) ReadField1(iprot thrift.TProtocol) error {
+	if v, err := iprot.ReadBinary(); err != nil {
+		return thrift.PrependError("error reading field 1: ", err)
+	} else {
+		p.NameSpace = v
+	}
+	return nil
+}
+
+func (p *FetchBlocksHashTreeRawRequest) ReadField2(iprot thrift.TProtocol) error {
+	if v, err := iprot.ReadI32(); err != nil {
+		return thrift.PrependError("error reading field 2: ", err)
+	} else {
+		p.Shard = v
+	}
+	return nil
+}
+
+func (p *FetchBlocksHashTreeRawRequest) ReadField3(iprot thrift.TProtocol) error {
+	if v, err := iprot.ReadI64(); err != nil {
+		return thrift.PrependError("error reading field 3: ", err)
+	} else {
+		p.RangeStart = v
+	}
+	return nil
+}
+
+func (p *FetchBlocksHashTreeRawRequest) ReadField4(iprot thrift.TProtocol) error {
+	if v, err := iprot.ReadI64(); err != nil {
+		return thrift.PrependError("error reading field 4: ", err)
+	} else {
+		p.RangeEnd = v
+	}
+	return nil
+}
+
+func (p *FetchBlocksHashTreeRawRequest) ReadField5(iprot thrift.TProtocol) error {
+	if v, err := iprot.ReadI32(); err != nil {
+		return thrift.PrependError("error reading field 5: ", err)
+	} else {
+		p.Depth = v
+	}
+	return nil
+}
+
+func (p *FetchBlocksHashTreeRawRequest) Write(oprot thrift.TProtocol) error {
+	if err := oprot.WriteStructBegin("FetchBlocksHashTreeRawRequest"); err != nil {
+		return thrift.PrependError(fmt.Sprintf("%T write struct begin error: ", p), err)
+	}
+	if p != nil {
+		if err := p.writeField1(oprot); err != nil {
+			return err
+		}
+		if err := p.writeField2(oprot); err != nil {
+			return err
+		}
+		if err := p.writeField3(oprot); err != nil {
+			return err
+		}
+		if err := p.writeField4(oprot); err != nil {
+			return err
+		}
+		if err := p.writeField5(oprot); err != nil {
+			return err
+		}
+	}
+	if err := oprot.WriteFieldStop(); err != nil {
+		return thrift.PrependError("write field stop error: ", err)
+	}
+	if err := oprot.WriteStructEnd(); err != nil {
+		return thrift.PrependError("write struct stop error: ", err)
+	}
+	return nil
+}
+
+func (p *FetchBlocksHashTreeRawRequest) writeField1(oprot thrift.TProtocol) (err error) {
+	if err := oprot.WriteFieldBegin("nameSpace", thrift.STRING, 1); err != nil {
+		return thrift.PrependError(fmt.Sprintf("%T write field begin error 1:nameSpace: ", p), err)
+	}
+	if err := oprot.WriteBinary(p.NameSpace); err != nil {
+		return thrift.PrependError(fmt.Sprintf("%T.nameSpace (1) field write error: ", p), err)
+	}
+	if err := oprot.WriteFieldEnd(); err != nil {
+		return thrift.PrependError(fmt.Sprintf("%T write field end error 1:nameSpace: ", p), err)
+	}
+	return err
+}
+
+func (p *FetchBlocksHashTreeRawRequest) writeField2(oprot thrift.TProtocol) (err error) {
+	if err := oprot.WriteFieldBegin("shard", thrift.I32, 2); err != nil {
+		return thrift.PrependError(fmt.Sprintf("%T write field begin error 2:shard: ", p), err)
+	}
+	if err := oprot.WriteI32(int32(p.Shard)); err != nil {
+		return thrift.PrependError(fmt.Sprintf("%T.shard (2) field write error: ", p), err)
+	}
+	if err := oprot.WriteFieldEnd(); err != nil {
+		return thrift.PrependError(fmt.Sprintf("%T write field end error 2:shard: ", p), err)
+	}
+	return err
+}
+
+func (p *FetchBlocksHashTreeRawRequest) writeField3(oprot thrift.TProtocol) (err error) {
+	if err := oprot.WriteFieldBegin("rangeStart", thrift.I64, 3); err != nil {
+		return thrift.PrependError(fmt.Sprintf("%T write field begin error 3:rangeStart: ", p), err)
+	}
+	if err := oprot.WriteI64(int64(p.RangeStart)); err != nil {
+		return thrift.PrependError(fmt.Sprintf("%T.rangeStart (3) field write error: ", p), err)
+	}
+	if err := oprot.WriteFieldEnd(); err != nil {
+		return thrift.PrependError(fmt.Sprintf("%T write field end error 3:rangeStart: ", p), err)
+	}
+	return err
+}
+
+func (p *FetchBlocksHashTreeRawRequest) writeField4(oprot thrift.TProtocol) (err error) {
+	if err := oprot.WriteFieldBegin("rangeEnd", thrift.I64, 4); err != nil {
+		return thrift.PrependError(fmt.Sprintf("%T write field begin error 4:rangeEnd: ", p), err)
+	}
+	if err := oprot.WriteI64(int64(p.RangeEnd)); err != nil {
+		return thrift.PrependError(fmt.Sprintf("%T.rangeEnd (4) field write error: ", p), err)
+	}
+	if err := oprot.WriteFieldEnd(); err != nil {
+		return thrift.PrependError(fmt.Sprintf("%T write field end error 4:rangeEnd: ", p), err)
+	}
+	return err
+}
+
+func (p *FetchBlocksHashTreeRawRequest) writeField5(oprot thrift.TProtocol) (err error) {
+	if err := oprot.WriteFieldBegin("depth", thrift.I32, 5); err != nil {
+		return thrift.PrependError(fmt.Sprintf("%T write field begin error 5:depth: ", p), err)
+	}
+	if err := oprot.WriteI32(int32(p.Depth)); err != nil {
+		return thrift.PrependError(fmt.Sprintf("%T.depth (5) field write error: ", p), err)
+	}
+	if err := oprot.WriteFieldEnd(); err != nil {
+		return thrift.PrependError(fmt.Sprintf("%T write field end error 5:depth: ", p), err)
+	}
+	return err
+}
+
+func (p *FetchBlocksHashTreeRawRequest) String() string {
+	if p == nil {
+		return "<nil>"
+	}
+	return fmt.Sprintf("FetchBlocksHashTreeRawRequest(%+v)", *p)
+}
+
+// Attributes:
+//  - Elements
+type FetchBlocksHashTreeRawResult_ struct {
+	Elements []*BlockHashTree `thrift:"elements,1,required" db:"elements" json:"elements"`
+}
+
+func NewFetchBlocksHashTreeRawResult_() *FetchBlocksHashTreeRawResult_ {
+	return &FetchBlocksHashTreeRawResult_{}
+}
+
+func (p *FetchBlocksHashTreeRawResult_) GetElements() []*BlockHashTree {
+	return p.Elements
+}
+func (p *FetchBlocksHashTreeRawResult_) Read(iprot thrift.TProtocol) error {
+	if _, err := iprot.ReadStructBegin(); err != nil {
+		return thrift.PrependError(fmt.Sprintf("%T read error: ", p), err)
+	}
+
+	var issetElements bool = false
+
+	for {
+		_, fieldTypeId, fieldId, err := iprot.ReadFieldBegin()
+		if err != nil {
+			return thrift.PrependError(fmt.Sprintf("%T field %d read error: ", p, fieldId), err)
+		}
+		if fieldTypeId == thrift.STOP {
+			break
+		}
+		switch fieldId {
+		case 1:
+			if err := p.ReadField1(iprot); err != nil {
+				return err
+			}
+			issetElements = true
+		default:
+			if err := iprot.Skip(fieldTypeId); err != nil {
+				return err
+			}
+		}
+		if err := iprot.ReadFieldEnd(); err != nil {
+			return err
+		}
+	}
+	if err := iprot.ReadStructEnd(); err != nil {
+		return thrift.PrependError(fmt.Sprintf("%T read struct end error: ", p), err)
+	}
+	if !issetElements {
+		return thrift.NewTProtocolExceptionWithType(thrift.INVALID_DATA, fmt.Errorf("Required field Elements is not set"))
+	}
+	return nil
+}
+
+func (p *FetchBlocksHashTreeRawResult_) ReadField1(iprot thrift.TProtocol) error {
+	_, size, err := iprot.ReadListBegin()
+	if err != nil {
+		return thrift.PrependError("error reading list begin: ", err)
+	}
+	tSlice := make([]*BlockHashTree, 0, size)
+	p.Elements = tSlice
+	for i := 0; i < size; i++ {
+		_elem24 := &BlockHashTree{}
+		if err := _elem24.Read(iprot); err != nil {
+			return thrift.PrependError(fmt.Sprintf("%T error reading struct: ", _elem24), err)
+		}
+		p.Elements = append(p.Elements, _elem24)
+	}
+	if err := iprot.ReadListEnd(); err != nil {
+		return thrift.PrependError("error reading list end: ", err)
+	}
+	return nil
+}
+
+func (p *FetchBlocksHashTreeRawResult_) Write(oprot thrift.TProtocol) error {
+	if err := oprot.WriteStructBegin("FetchBlocksHashTreeRawResult"); err != nil {
+		return thrift.PrependError(fmt.Sprintf("%T write struct begin error: ", p), err)
+	}
+	if p != nil {
+		if err := p.writeField1(oprot); err != nil {
+			return err
+		}
+	}
+	if err := oprot.WriteFieldStop(); err != nil {
+		return thrift.PrependError("write field stop error: ", err)
+	}
+	if err := oprot.WriteStructEnd(); err != nil {
+		return thrift.PrependError("write struct stop error: ", err)
+	}
+	return nil
+}
+
+func (p *FetchBlocksHashTreeRawResult_) writeField1(oprot thrift.TProtocol) (err error) {
+	if err := oprot.WriteFieldBegin("elements", thrift.LIST, 1); err != nil {
+		return thrift.PrependError(fmt.Sprintf("%T write field begin error 1:elements: ", p), err)
+	}
+	if err := oprot.WriteListBegin(thrift.STRUCT, len(p.Elements)); err != nil {
+		return thrift.PrependError("error writing list begin: ", err)
+	}
+	for _, v := range p.Elements {
+		if err := v.Write(oprot); err != nil {
+			return thrift.PrependError(fmt.Sprintf("%T error writing struct: ", v), err)
+		}
+	}
+	if err := oprot.WriteListEnd(); err != nil {
+		return thrift.PrependError("error writing list end: ", err)
+	}
+	if err := oprot.WriteFieldEnd(); err != nil {
+		return thrift.PrependError(fmt.Sprintf("%T write field end error 1:elements: ", p), err)
+	}
+	return err
+}
+
+func (p *FetchBlocksHashTreeRawResult_) String() string {
+	if p == nil {
+		return "<nil>"
+	}
+	return fmt.Sprintf("FetchBlocksHashTreeRawResult_(%+v)", *p)
+}
+
+// Attributes:
+//  - Start
+//  - Nodes
+type BlockHashTree struct {
+	Start int64   `thrift:"start,1,required" db:"start" json:"start"`
+	Nodes []int64 `thrift:"nodes,2,required" db:"nodes" json:"nodes"`
+}
+
+func NewBlockHashTree() *BlockHashTree {
+	return &BlockHashTree{}
+}
+
+func (p *BlockHashTree) GetStart() int64 {
+	return p.Start
+}
+
+func (p *BlockHashTree) GetNodes() []int64 {
+	return p.Nodes
+}
+func (p *BlockHashTree) Read(iprot thrift.TProtocol) error {
+	if _, err := iprot.ReadStructBegin(); err != nil {
+		return thrift.PrependError(fmt.Sprintf("%T read error: ", p), err)
+	}
+
+	var issetStart bool = false
+	var issetNodes bool = false
+
+	for {
+		_, fieldTypeId, fieldId, err := iprot.ReadFieldBegin()
+		if err != nil {
+			return thrift.PrependError(fmt.Sprintf("%T field %d read error: ", p, fieldId), err)
+		}
+		if fieldTypeId == thrift.STOP {
+			break
+		}
+		switch fieldId {
+		case 1:
+			if err := p.ReadField1(iprot); err != nil {
+				return err
+			}
+			issetStart = true
+		case 2:
+			if err := p.ReadField2(iprot); err != nil {
+				return err
+			}
+			issetNodes = true
+		default:
+			if err := iprot.Skip(fieldTypeId); err != nil {
+				return err
+			}
+		}
+		if err := iprot.ReadFieldEnd(); err != nil {
+			return err
+		}
+	}
+	if err := iprot.ReadStructEnd(); err != nil {
+		return thrift.PrependError(fmt.Sprintf("%T read struct end error: ", p), err)
 	}
-	if err := p.Err.Read(iprot); err != nil {
-		return thrift.PrependError(fmt.Sprintf("%T error reading struct: ", p.Err), err)
+	if !issetStart {
+		return thrift.NewTProtocolExceptionWithType(thrift.INVALID_DATA, fmt.Errorf("Required field Start is not set"))
 	}
-	return nil
-}
-
-func (p *BlockMetadataV2) ReadField4(iprot thrift.TProtocol) error {
-	if v, err := iprot.ReadI64(); err != nil {
-		return thrift.PrependError("error reading field 4: ", err)
-	} else {
-		p.Size = &v
+	if !issetNodes {
+		return thrift.NewTProtocolExceptionWithType(thrift.INVALID_DATA, fmt.Errorf("Required field Nodes is not set"))
 	}
 	return nil
 }
 
-func (p *BlockMetadataV2) ReadField5(iprot thrift.TProtocol) error {
+func (p *BlockHashTree) ReadField1(iprot thrift.TProtocol) error {
 	if v, err := iprot.ReadI64(); err != nil {
-		return thrift.PrependError("error reading field 5: ", err)
+		return thrift.PrependError("error reading field 1: ", err)
 	} else {
-		p.Checksum = &v
+		p.Start = v
 	}
 	return nil
 }
 
-func (p *BlockMetadataV2) ReadField6(iprot thrift.TProtocol) error {
-	if v, err := iprot.ReadI64(); err != nil {
-		return thrift.PrependError("error reading field 6: ", err)
-	} else {
-		p.LastRead = &v
+func (p *BlockHashTree) ReadField2(iprot thrift.TProtocol) error {
+	_, size, err := iprot.ReadListBegin()
+	if err != nil {
+		return thrift.PrependError("error reading list begin: ", err)
 	}
-	return nil
-}
-
-func (p *BlockMetadataV2) ReadField7(iprot thrift.TProtocol) error {
-	if v, err := iprot.ReadI32(); err != nil {
-		return thrift.PrependError("error reading field 7: ", err)
-	} else {
-		temp := TimeType(v)
-		p.LastReadTimeType = temp
+	tSlice := make([]int64, 0, size)
+	p.Nodes = tSlice
+	for i := 0; i < size; i++ {
+		var _elem25 int64
+		if v, err := iprot.ReadI64(); err != nil {
+			return thrift.PrependError("error reading field 0: ", err)
+		} else {
+			_elem25 = v
+		}
+		p.Nodes = append(p.Nodes, _elem25)
 	}
-	return nil
-}
-
-func (p *BlockMetadataV2) ReadField8(iprot thrift.TProtocol) error {
-	if v, err := iprot.ReadBinary(); err != nil {
-		return thrift.PrependError("error reading field 8: ", err)
-	} else {
-		p.EncodedTags = v
+	if err := iprot.ReadListEnd(); err != nil {
+		return thrift.PrependError("error reading list end: ", err)
 	}
 	return nil
 }
 
-func (p *BlockMetadataV2) Write(oprot thrift.TProtocol) error {
-	if err := oprot.WriteStructBegin("BlockMetadataV2"); err != nil {
+func (p *BlockHashTree) Write(oprot thrift.TProtocol) error {
+	if err := oprot.WriteStructBegin("BlockHashTree"); err != nil {
 		return thrift.PrependError(fmt.Sprintf("%T write struct begin error: ", p), err)
 	}
 	if p != nil {
@@ -6260,24 +7341,6 @@ func (p *BlockMetadataV2) Write(oprot thrift.TProtocol) error {
 		if err := p.writeField2(oprot); err != nil {
 			return err
 		}
-		if err := p.writeField3(oprot); err != nil {
-			return err
-		}
-		if err := p.writeField4(oprot); err != nil {
-			return err
-		}
-		if err := p.writeField5(oprot); err != nil {
-			return err
-		}
-		if err := p.writeField6(oprot); err != nil {
-			return err
-		}
-		if err := p.writeField7(oprot); err != nil {
-			return err
-		}
-		if err := p.writeField8(oprot); err != nil {
-			return err
-		}
 	}
 	if err := oprot.WriteFieldStop(); err != nil {
 		return thrift.PrependError("write field stop error: ", err)
@@ -6288,127 +7351,45 @@ func (p *BlockMetadataV2) Write(oprot thrift.TProtocol) error {
 	return nil
 }
 
-func (p *BlockMetadataV2) writeField1(oprot thrift.TProtocol) (err error) {
-	if err := oprot.WriteFieldBegin("id", thrift.STRING, 1); err != nil {
-		return thrift.PrependError(fmt.Sprintf("%T write field begin error 1:id: ", p), err)
-	}
-	if err := oprot.WriteBinary(p.ID); err != nil {
-		return thrift.PrependError(fmt.Sprintf("%T.id (1) field write error: ", p), err)
-	}
-	if err := oprot.WriteFieldEnd(); err != nil {
-		return thrift.PrependError(fmt.Sprintf("%T write field end error 1:id: ", p), err)
-	}
-	return err
-}
-
-func (p *BlockMetadataV2) writeField2(oprot thrift.TProtocol) (err error) {
-	if err := oprot.WriteFieldBegin("start", thrift.I64, 2); err != nil {
-		return thrift.PrependError(fmt.Sprintf("%T write field begin error 2:start: ", p), err)
+func (p *BlockHashTree) writeField1(oprot thrift.TProtocol) (err error) {
+	if err := oprot.WriteFieldBegin("start", thrift.I64, 1); err != nil {
+		return thrift.PrependError(fmt.Sprintf("%T write field begin error 1:start: ", p), err)
 	}
 	if err := oprot.WriteI64(int64(p.Start)); err != nil {
-		return thrift.PrependError(fmt.Sprintf("%T.start (2) field write error: ", p), err)
+		return thrift.PrependError(fmt.Sprintf("%T.start (1) field write error: ", p), err)
 	}
 	if err := oprot.WriteFieldEnd(); err != nil {
-		return thrift.PrependError(fmt.Sprintf("%T write field end error 2:start: ", p), err)
-	}
-	return err
-}
-
-func (p *BlockMetadataV2) writeField3(oprot thrift.TProtocol) (err error) {
-	if p.IsSetErr() {
-		if err := oprot.WriteFieldBegin("err", thrift.STRUCT, 3); err != nil {
-			return thrift.PrependError(fmt.Sprintf("%T write field begin error 3:err: ", p), err)
-		}
-		if err := p.Err.Write(oprot); err != nil {
-			return thrift.PrependError(fmt.Sprintf("%T error writing struct: ", p.Err), err)
-		}
-		if err := oprot.WriteFieldEnd(); err != nil {
-			return thrift.PrependError(fmt.Sprintf("%T write field end error 3:err: ", p), err)
-		}
+		return thrift.PrependError(fmt.Sprintf("%T write field end error 1:start: ", p), err)
 	}
 	return err
 }
 
-func (p *BlockMetadataV2) writeField4(oprot thrift.TProtocol) (err error) {
-	if p.IsSetSize() {
-		if err := oprot.WriteFieldBegin("size", thrift.I64, 4); err != nil {
-			return thrift.PrependError(fmt.Sprintf("%T write field begin error 4:size: ", p), err)
-		}
-		if err := oprot.WriteI64(int64(*p.Size)); err != nil {
-			return thrift.PrependError(fmt.Sprintf("%T.size (4) field write error: ", p), err)
-		}
-		if err := oprot.WriteFieldEnd(); err != nil {
-			return thrift.PrependError(fmt.Sprintf("%T write field end error 4:size: ", p), err)
-		}
+func (p *BlockHashTree) writeField2(oprot thrift.TProtocol) (err error) {
+	if err := oprot.WriteFieldBegin("nodes", thrift.LIST, 2); err != nil {
+		return thrift.PrependError(fmt.Sprintf("%T write field begin error 2:nodes: ", p), err)
 	}
-	return err
-}
-
-func (p *BlockMetadataV2) writeField5(oprot thrift.TProtocol) (err error) {
-	if p.IsSetChecksum() {
-		if err := oprot.WriteFieldBegin("checksum", thrift.I64, 5); err != nil {
-			return thrift.PrependError(fmt.Sprintf("%T write field begin error 5:checksum: ", p), err)
-		}
-		if err := oprot.WriteI64(int64(*p.Checksum)); err != nil {
-			return thrift.PrependError(fmt.Sprintf("%T.checksum (5) field write error: ", p), err)
-		}
-		if err := oprot.WriteFieldEnd(); err != nil {
-			return thrift.PrependError(fmt.Sprintf("%T write field end error 5:checksum: ", p), err)
-		}
+	if err := oprot.WriteListBegin(thrift.I64, len(p.Nodes)); err != nil {
+		return thrift.PrependError("error writing list begin: ", err)
 	}
-	return err
-}
-
-func (p *BlockMetadataV2) writeField6(oprot thrift.TProtocol) (err error) {
-	if p.IsSetLastRead() {
-		if err := oprot.WriteFieldBegin("lastRead", thrift.I64, 6); err != nil {
-			return thrift.PrependError(fmt.Sprintf("%T write field begin error 6:lastRead: ", p), err)
-		}
-		if err := oprot.WriteI64(int64(*p.LastRead)); err != nil {
-			return thrift.PrependError(fmt.Sprintf("%T.lastRead (6) field write error: ", p), err)
-		}
-		if err := oprot.WriteFieldEnd(); err != nil {
-			return thrift.PrependError(fmt.Sprintf("%T write field end error 6:lastRead: ", p), err)
+	for _, v := range p.Nodes {
+		if err := oprot.WriteI64(int64(v)); err != nil {
+			return thrift.PrependError(fmt.Sprintf("%T. (0) field write error: ", p), err)
 		}
 	}
-	return err
-}
-
-func (p *BlockMetadataV2) writeField7(oprot thrift.TProtocol) (err error) {
-	if p.IsSetLastReadTimeType() {
-		if err := oprot.WriteFieldBegin("lastReadTimeType", thrift.I32, 7); err != nil {
-			return thrift.PrependError(fmt.Sprintf("%T write field begin error 7:lastReadTimeType: ", p), err)
-		}
-		if err := oprot.WriteI32(int32(p.LastReadTimeType)); err != nil {
-			return thrift.PrependError(fmt.Sprintf("%T.lastReadTimeType (7) field write error: ", p), err)
-		}
-		if err := oprot.WriteFieldEnd(); err != nil {
-			return thrift.PrependError(fmt.Sprintf("%T write field end error 7:lastReadTimeType: ", p), err)
-		}
+	if err := oprot.WriteListEnd(); err != nil {
+		return thrift.PrependError("error writing list end: ", err)
 	}
-	return err
-}
-
-func (p *BlockMetadataV2) writeField8(oprot thrift.TProtocol) (err error) {
-	if p.IsSetEncodedTags() {
-		if err := oprot.WriteFieldBegin("encodedTags", thrift.STRING, 8); err != nil {
-			return thrift.PrependError(fmt.Sprintf("%T write field begin error 8:encodedTags: ", p), err)
-		}
-		if err := oprot.WriteBinary(p.EncodedTags); err != nil {
-			return thrift.PrependError(fmt.Sprintf("%T.encodedTags (8) field write error: ", p), err)
-		}
-		if err := oprot.WriteFieldEnd(); err != nil {
-			return thrift.PrependError(fmt.Sprintf("%T write field end error 8:encodedTags: ", p), err)
-		}
+	if err := oprot.WriteFieldEnd(); err != nil {
+		return thrift.PrependError(fmt.Sprintf("%T write field end error 2:nodes: ", p), err)
 	}
 	return err
 }
 
-func (p *BlockMetadataV2) String() string {
+func (p *BlockHashTree) String() string {
 	if p == nil {
 		return "<nil>"
 	}
-	return fmt.Sprintf("BlockMetadataV2(%+v)", *p)
+	return fmt.Sprintf("BlockHashTree(%+v)", *p)
 }
 
 // Attributes:
@@ -10508,6 +11489,9 @@ type Node interface {
 	FetchBlocksMetadataRawV2(req *FetchBlocksMetadataRawV2Request) (r *FetchBlocksMetadataRawV2Result_, err error)
 	// Parameters:
 	//  - Req
+	FetchBlocksHashTreeRaw(req *FetchBlocksHashTreeRawRequest) (r *FetchBlocksHashTreeRawResult_, err error)
+	// Parameters:
+	//  - Req
 	WriteBatchRaw(req *WriteBatchRawRequest) (err error)
 	// Parameters:
 	//  - Req
@@ -11288,6 +12272,87 @@ func (p *NodeClient) recvFetchBlocksMetadataRawV2() (value *FetchBlocksMetadataR
 	return
 }
 
+// Parameters:
+//  - Req
+func (p *NodeClient) FetchBlocksHashTreeRaw(req *FetchBlocksHashTreeRawRequest) (r *FetchBlocksHashTreeRawResult_, err error) {
+	if err = p.sendFetchBlocksHashTreeRaw(req); err != nil {
+		return
+	}
+	return p.recvFetchBlocksHashTreeRaw()
+}
+
+func (p *NodeClient) sendFetchBlocksHashTreeRaw(req *FetchBlocksHashTreeRawRequest) (err error) {
+	oprot := p.OutputProtocol
+	if oprot == nil {
+		oprot = p.ProtocolFactory.GetProtocol(p.Transport)
+		p.OutputProtocol = oprot
+	}
+	p.SeqId++
+	if err = oprot.WriteMessageBegin("fetchBlocksHashTreeRaw", thrift.CALL, p.SeqId); err != nil {
+		return
+	}
+	args := NodeFetchBlocksHashTreeRawArgs{
+		Req: req,
+	}
+	if err = args.Write(oprot); err != nil {
+		return
+	}
+	if err = oprot.WriteMessageEnd(); err != nil {
+		return
+	}
+	return oprot.Flush()
+}
+
+func (p *NodeClient) recvFetchBlocksHashTreeRaw() (value *FetchBlocksHashTreeRawResult_, err error) {
+	iprot := p.InputProtocol
+	if iprot == nil {
+		iprot = p.ProtocolFactory.GetProtocol(p.Transport)
+		p.InputProtocol = iprot
+	}
+	method, mTypeId, seqId, err := iprot.ReadMessageBegin()
+	if err != nil {
+		return
+	}
+	if method != "fetchBlocksHashTreeRaw" {
+		err = thrift.NewTApplicationException(thrift.WRONG_METHOD_NAME, "fetchBlocksHashTreeRaw failed: wrong method name")
+		return
+	}
+	if p.SeqId != seqId {
+		err = thrift.NewTApplicationException(thrift.BAD_SEQUENCE_ID, "fetchBlocksHashTreeRaw failed: out of sequence response")
+		return
+	}
+	if mTypeId == thrift.EXCEPTION {
+		error180 := thrift.NewTApplicationException(thrift.UNKNOWN_APPLICATION_EXCEPTION, "Unknown Exception")
+		var error181 error
+		error181, err = error180.Read(iprot)
+		if err != nil {
+			return
+		}
+		if err = iprot.ReadMessageEnd(); err != nil {
+			return
+		}
+		err = error181
+		return
+	}
+	if mTypeId != thrift.REPLY {
+		err = thrift.NewTApplicationException(thrift.INVALID_MESSAGE_TYPE_EXCEPTION, "fetchBlocksHashTreeRaw failed: invalid message type")
+		return
+	}
+	result := NodeFetchBlocksHashTreeRawResult{}
+	if err = result.Read(iprot); err != nil {
+		return
+	}
+	if err = iprot.ReadMessageEnd(); err != nil {
+		return
+	}
+	if result.Err != nil {
+		err = result.Err
+		return
+	}
+	value = result.GetSuccess()
+	return
+}
+
 // Parameters:
 //  - Req
 func (p *NodeClient) WriteBatchRaw(req *WriteBatchRawRequest) (err error) {
@@ -12344,6 +13409,7 @@ func NewNodeProcessor(handler Node) *NodeProcessor {
 	self67.processorMap["fetchBlocksRaw"] = &nodeProcessorFetchBlocksRaw{handler: handler}
 	self67.processorMap["fetchBlocksMetadataRaw"] = &nodeProcessorFetchBlocksMetadataRaw{handler: handler}
 	self67.processorMap["fetchBlocksMetadataRawV2"] = &nodeProcessorFetchBlocksMetadataRawV2{handler: handler}
+	self67.processorMap["fetchBlocksHashTreeRaw"] = &nodeProcessorFetchBlocksHashTreeRaw{handler: handler}
 	self67.processorMap["writeBatchRaw"] = &nodeProcessorWriteBatchRaw{handler: handler}
 	self67.processorMap["writeTaggedBatchRaw"] = &nodeProcessorWriteTaggedBatchRaw{handler: handler}
 	self67.processorMap["repair"] = &nodeProcessorRepair{handler: handler}
@@ -12850,6 +13916,59 @@ func (p *nodeProcessorFetchBlocksMetadataRawV2) Process(seqId int32, iprot, opro
 	return true, err
 }
 
+type nodeProcessorFetchBlocksHashTreeRaw struct {
+	handler Node
+}
+
+func (p *nodeProcessorFetchBlocksHashTreeRaw) Process(seqId int32, iprot, oprot thrift.TProtocol) (success bool, err thrift.TException) {
+	args := NodeFetchBlocksHashTreeRawArgs{}
+	if err = args.Read(iprot); err != nil {
+		iprot.ReadMessageEnd()
+		x := thrift.NewTApplicationException(thrift.PROTOCOL_ERROR, err.Error())
+		oprot.WriteMessageBegin("fetchBlocksHashTreeRaw", thrift.EXCEPTION, seqId)
+		x.Write(oprot)
+		oprot.WriteMessageEnd()
+		oprot.Flush()
+		return false, err
+	}
+
+	iprot.ReadMessageEnd()
+	result := NodeFetchBlocksHashTreeRawResult{}
+	var retval *FetchBlocksHashTreeRawResult_
+	var err2 error
+	if retval, err2 = p.handler.FetchBlocksHashTreeRaw(args.Req); err2 != nil {
+		switch v := err2.(type) {
+		case *Error:
+			result.Err = v
+		default:
+			x := thrift.NewTApplicationException(thrift.INTERNAL_ERROR, "Internal error processing fetchBlocksHashTreeRaw: "+err2.Error())
+			oprot.WriteMessageBegin("fetchBlocksHashTreeRaw", thrift.EXCEPTION, seqId)
+			x.Write(oprot)
+			oprot.WriteMessageEnd()
+			oprot.Flush()
+			return true, err2
+		}
+	} else {
+		result.Success = retval
+	}
+	if err2 = oprot.WriteMessageBegin("fetchBlocksHashTreeRaw", thrift.REPLY, seqId); err2 != nil {
+		err = err2
+	}
+	if err2 = result.Write(oprot); err == nil && err2 != nil {
+		err = err2
+	}
+	if err2 = oprot.WriteMessageEnd(); err == nil && err2 != nil {
+		err = err2
+	}
+	if err2 = oprot.Flush(); err == nil && err2 != nil {
+		err = err2
+	}
+	if err != nil {
+		return
+	}
+	return true, err
+}
+
 type nodeProcessorWriteBatchRaw struct {
 	handler Node
 }
@@ -15713,6 +16832,257 @@ func (p *NodeFetchBlocksMetadataRawV2Result) String() string {
 	return fmt.Sprintf("NodeFetchBlocksMetadataRawV2Result(%+v)", *p)
 }
 
+// Attributes:
+//  - Req
+type NodeFetchBlocksHashTreeRawArgs struct {
+	Req *FetchBlocksHashTreeRawRequest `thrift:"req,1" db:"req" json:"req"`
+}
+
+func NewNodeFetchBlocksHashTreeRawArgs() *NodeFetchBlocksHashTreeRawArgs {
+	return &NodeFetchBlocksHashTreeRawArgs{}
+}
+
+var NodeFetchBlocksHashTreeRawArgs_Req_DEFAULT *FetchBlocksHashTreeRawRequest
+
+func (p *NodeFetchBlocksHashTreeRawArgs) GetReq() *FetchBlocksHashTreeRawRequest {
+	if !p.IsSetReq() {
+		return NodeFetchBlocksHashTreeRawArgs_Req_DEFAULT
+	}
+	return p.Req
+}
+func (p *NodeFetchBlocksHashTreeRawArgs) IsSetReq() bool {
+	return p.Req != nil
+}
+
+func (p *NodeFetchBlocksHashTreeRawArgs) Read(iprot thrift.TProtocol) error {
+	if _, err := iprot.ReadStructBegin(); err != nil {
+		return thrift.PrependError(fmt.Sprintf("%T read error: ", p), err)
+	}
+
+	for {
+		_, fieldTypeId, fieldId, err := iprot.ReadFieldBegin()
+		if err != nil {
+			return thrift.PrependError(fmt.Sprintf("%T field %d read error: ", p, fieldId), err)
+		}
+		if fieldTypeId == thrift.STOP {
+			break
+		}
+		switch fieldId {
+		case 1:
+			if err := p.ReadField1(iprot); err != nil {
+				return err
+			}
+		default:
+			if err := iprot.Skip(fieldTypeId); err != nil {
+				return err
+			}
+		}
+		if err := iprot.ReadFieldEnd(); err != nil {
+			return err
+		}
+	}
+	if err := iprot.ReadStructEnd(); err != nil {
+		return thrift.PrependError(fmt.Sprintf("%T read struct end error: ", p), err)
+	}
+	return nil
+}
+
+func (p *NodeFetchBlocksHashTreeRawArgs) ReadField1(iprot thrift.TProtocol) error {
+	p.Req = &FetchBlocksHashTreeRawRequest{}
+	if err := p.Req.Read(iprot); err != nil {
+		return thrift.PrependError(fmt.Sprintf("%T error reading struct: ", p.Req), err)
+	}
+	return nil
+}
+
+func (p *NodeFetchBlocksHashTreeRawArgs) Write(oprot thrift.TProtocol) error {
+	if err := oprot.WriteStructBegin("fetchBlocksHashTreeRaw_args"); err != nil {
+		return thrift.PrependError(fmt.Sprintf("%T write struct begin error: ", p), err)
+	}
+	if p != nil {
+		if err := p.writeField1(oprot); err != nil {
+			return err
+		}
+	}
+	if err := oprot.WriteFieldStop(); err != nil {
+		return thrift.PrependError("write field stop error: ", err)
+	}
+	if err := oprot.WriteStructEnd(); err != nil {
+		return thrift.PrependError("write struct stop error: ", err)
+	}
+	return nil
+}
+
+func (p *NodeFetchBlocksHashTreeRawArgs) writeField1(oprot thrift.TProtocol) (err error) {
+	if err := oprot.WriteFieldBegin("req", thrift.STRUCT, 1); err != nil {
+		return thrift.PrependError(fmt.Sprintf("%T write field begin error 1:req: ", p), err)
+	}
+	if err := p.Req.Write(oprot); err != nil {
+		return thrift.PrependError(fmt.Sprintf("%T error writing struct: ", p.Req), err)
+	}
+	if err := oprot.WriteFieldEnd(); err != nil {
+		return thrift.PrependError(fmt.Sprintf("%T write field end error 1:req: ", p), err)
+	}
+	return err
+}
+
+func (p *NodeFetchBlocksHashTreeRawArgs) String() string {
+	if p == nil {
+		return "<nil>"
+	}
+	return fmt.Sprintf("NodeFetchBlocksHashTreeRawArgs(%+v)", *p)
+}
+
+// Attributes:
+//  - Success
+//  - Err
+type NodeFetchBlocksHashTreeRawResult struct {
+	Success *FetchBlocksHashTreeRawResult_ `thrift:"success,0" db:"success" json:"success,omitempty"`
+	Err     *Error                           `thrift:"err,1" db:"err" json:"err,omitempty"`
+}
+
+func NewNodeFetchBlocksHashTreeRawResult() *NodeFetchBlocksHashTreeRawResult {
+	return &NodeFetchBlocksHashTreeRawResult{}
+}
+
+var NodeFetchBlocksHashTreeRawResult_Success_DEFAULT *FetchBlocksHashTreeRawResult_
+
+func (p *NodeFetchBlocksHashTreeRawResult) GetSuccess() *FetchBlocksHashTreeRawResult_ {
+	if !p.IsSetSuccess() {
+		return NodeFetchBlocksHashTreeRawResult_Success_DEFAULT
+	}
+	return p.Success
+}
+
+var NodeFetchBlocksHashTreeRawResult_Err_DEFAULT *Error
+
+func (p *NodeFetchBlocksHashTreeRawResult) GetErr() *Error {
+	if !p.IsSetErr() {
+		return NodeFetchBlocksHashTreeRawResult_Err_DEFAULT
+	}
+	return p.Err
+}
+func (p *NodeFetchBlocksHashTreeRawResult) IsSetSuccess() bool {
+	return p.Success != nil
+}
+
+func (p *NodeFetchBlocksHashTreeRawResult) IsSetErr() bool {
+	return p.Err != nil
+}
+
+func (p *NodeFetchBlocksHashTreeRawResult) Read(iprot thrift.TProtocol) error {
+	if _, err := iprot.ReadStructBegin(); err != nil {
+		return thrift.PrependError(fmt.Sprintf("%T read error: ", p), err)
+	}
+
+	for {
+		_, fieldTypeId, fieldId, err := iprot.ReadFieldBegin()
+		if err != nil {
+			return thrift.PrependError(fmt.Sprintf("%T field %d read error: ", p, fieldId), err)
+		}
+		if fieldTypeId == thrift.STOP {
+			break
+		}
+		switch fieldId {
+		case 0:
+			if err := p.ReadField0(iprot); err != nil {
+				return err
+			}
+		case 1:
+			if err := p.ReadField1(iprot); err != nil {
+				return err
+			}
+		default:
+			if err := iprot.Skip(fieldTypeId); err != nil {
+				return err
+			}
+		}
+		if err := iprot.ReadFieldEnd(); err != nil {
+			return err
+		}
+	}
+	if err := iprot.ReadStructEnd(); err != nil {
+		return thrift.PrependError(fmt.Sprintf("%T read struct end error: ", p), err)
+	}
+	return nil
+}
+
+func (p *NodeFetchBlocksHashTreeRawResult) ReadField0(iprot thrift.TProtocol) error {
+	p.Success = &FetchBlocksHashTreeRawResult_{}
+	if err := p.Success.Read(iprot); err != nil {
+		return thrift.PrependError(fmt.Sprintf("%T error reading struct: ", p.Success), err)
+	}
+	return nil
+}
+
+func (p *NodeFetchBlocksHashTreeRawResult) ReadField1(iprot thrift.TProtocol) error {
+	p.Err = &Error{
+		Type: 0,
+	}
+	if err := p.Err.Read(iprot); err != nil {
+		return thrift.PrependError(fmt.Sprintf("%T error reading struct: ", p.Err), err)
+	}
+	return nil
+}
+
+func (p *NodeFetchBlocksHashTreeRawResult) Write(oprot thrift.TProtocol) error {
+	if err := oprot.WriteStructBegin("fetchBlocksHashTreeRaw_result"); err != nil {
+		return thrift.PrependError(fmt.Sprintf("%T write struct begin error: ", p), err)
+	}
+	if p != nil {
+		if err := p.writeField0(oprot); err != nil {
+			return err
+		}
+		if err := p.writeField1(oprot); err != nil {
+			return err
+		}
+	}
+	if err := oprot.WriteFieldStop(); err != nil {
+		return thrift.PrependError("write field stop error: ", err)
+	}
+	if err := oprot.WriteStructEnd(); err != nil {
+		return thrift.PrependError("write struct stop error: ", err)
+	}
+	return nil
+}
+
+func (p *NodeFetchBlocksHashTreeRawResult) writeField0(oprot thrift.TProtocol) (err error) {
+	if p.IsSetSuccess() {
+		if err := oprot.WriteFieldBegin("success", thrift.STRUCT, 0); err != nil {
+			return thrift.PrependError(fmt.Sprintf("%T write field begin error 0:success: ", p), err)
+		}
+		if err := p.Success.Write(oprot); err != nil {
+			return thrift.PrependError(fmt.Sprintf("%T error writing struct: ", p.Success), err)
+		}
+		if err := oprot.WriteFieldEnd(); err != nil {
+			return thrift.PrependError(fmt.Sprintf("%T write field end error 0:success: ", p), err)
+		}
+	}
+	return err
+}
+
+func (p *NodeFetchBlocksHashTreeRawResult) writeField1(oprot thrift.TProtocol) (err error) {
+	if p.IsSetErr() {
+		if err := oprot.WriteFieldBegin("err", thrift.STRUCT, 1); err != nil {
+			return thrift.PrependError(fmt.Sprintf("%T write field begin error 1:err: ", p), err)
+		}
+		if err := p.Err.Write(oprot); err != nil {
+			return thrift.PrependError(fmt.Sprintf("%T error writing struct: ", p.Err), err)
+		}
+		if err := oprot.WriteFieldEnd(); err != nil {
+			return thrift.PrependError(fmt.Sprintf("%T write field end error 1:err: ", p), err)
+		}
+	}
+	return err
+}
+
+func (p *NodeFetchBlocksHashTreeRawResult) String() string {
+	if p == nil {
+		return "<nil>"
+	}
+	return fmt.Sprintf("NodeFetchBlocksHashTreeRawResult(%+v)", *p)
+}
+
 // Attributes:
 //  - Req
 type NodeWriteBatchRawArgs struct {
@@ -47,6 +47,7 @@ type TChanCluster interface {
 type TChanNode interface {
 	Fetch(ctx thrift.Context, req *FetchRequest) (*FetchResult_, error)
 	FetchBatchRaw(ctx thrift.Context, req *FetchBatchRawRequest) (*FetchBatchRawResult_, error)
+	FetchBlocksHashTreeRaw(ctx thrift.Context, req *FetchBlocksHashTreeRawRequest) (*FetchBlocksHashTreeRawResult_, error)
 	FetchBlocksMetadataRaw(ctx thrift.Context, req *FetchBlocksMetadataRawRequest) (*FetchBlocksMetadataRawResult_, error)
 	FetchBlocksMetadataRawV2(ctx thrift.Context, req *FetchBlocksMetadataRawV2Request) (*FetchBlocksMetadataRawV2Result_, error)
 	FetchBlocksRaw(ctx thrift.Context, req *FetchBlocksRawRequest) (*FetchBlocksRawResult_, error)
@@ -509,6 +510,24 @@ func (c *tchanNodeClient) FetchBatchRaw(ctx thrift.Context, req *FetchBatchRawRe
 	return resp.GetSuccess(), err
 }
 
+func (c *tchanNodeClient) FetchBlocksHashTreeRaw(ctx thrift.Context, req *FetchBlocksHashTreeRawRequest) (*FetchBlocksHashTreeRawResult_, error) {
+	var resp NodeFetchBlocksHashTreeRawResult
+	args := NodeFetchBlocksHashTreeRawArgs{
+		Req: req,
+	}
+	success, err := c.client.Call(ctx, c.thriftService, "fetchBlocksHashTreeRaw", &args, &resp)
+	if err == nil && !success {
+		switch {
+		case resp.Err != nil:
+			err = resp.Err
+		default:
+			err = fmt.Errorf("received no result or unknown exception for fetchBlocksHashTreeRaw")
+		}
+	}
+
+	return resp.GetSuccess(), err
+}
+
 func (c *tchanNodeClient) FetchBlocksMetadataRaw(ctx thrift.Context, req *FetchBlocksMetadataRawRequest) (*FetchBlocksMetadataRawResult_, error) {
 	var resp NodeFetchBlocksMetadataRawResult
 	args := NodeFetchBlocksMetadataRawArgs{
@@ -877,6 +896,7 @@ func (s *tchanNodeServer) Methods() []string {
 	return []string{
 		"fetch",
 		"fetchBatchRaw",
+		"fetchBlocksHashTreeRaw",
 		"fetchBlocksMetadataRaw",
 		"fetchBlocksMetadataRawV2",
 		"fetchBlocksRaw",
@@ -906,6 +926,8 @@ func (s *tchanNodeServer) Handle(ctx thrift.Context, methodName string, protocol
 		return s.handleFetch(ctx, protocol)
 	case "fetchBatchRaw":
 		return s.handleFetchBatchRaw(ctx, protocol)
+	case "fetchBlocksHashTreeRaw":
+		return s.handleFetchBlocksHashTreeRaw(ctx, protocol)
 	case "fetchBlocksMetadataRaw":
 		return s.handleFetchBlocksMetadataRaw(ctx, protocol)
 	case "fetchBlocksMetadataRawV2":
@@ -1008,6 +1030,34 @@ func (s *tchanNodeServer) handleFetchBatchRaw(ctx thrift.Context, protocol athri
 	return err == nil, &res, nil
 }
 
+func (s *tchanNodeServer) handleFetchBlocksHashTreeRaw(ctx thrift.Context, protocol athrift.TProtocol) (bool, athrift.TStruct, error) {
+	var req NodeFetchBlocksHashTreeRawArgs
+	var res NodeFetchBlocksHashTreeRawResult
+
+	if err := req.Read(protocol); err != nil {
+		return false, nil, err
+	}
+
+	r, err :=
+		s.handler.FetchBlocksHashTreeRaw(ctx, req.Req)
+
+	if err != nil {
+		switch v := err.(type) {
+		case *Error:
+			if v == nil {
+				return false, nil, fmt.Errorf("Handler for err returned non-nil error type *Error but nil value")
+			}
+			res.Err = v
+		default:
+			return false, nil, err
+		}
+	} else {
+		res.Success = r
+	}
+
+	return err == nil, &res, nil
+}
+
 func (s *tchanNodeServer) handleFetchBlocksMetadataRaw(ctx thrift.Context, protocol athrift.TProtocol) (bool, athrift.TStruct, error) {
 	var req NodeFetchBlocksMetadataRawArgs
 	var res NodeFetchBlocksMetadataRawResult
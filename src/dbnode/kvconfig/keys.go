@@ -44,4 +44,9 @@ const (
 	// ClientWriteConsistencyLevel is the KV config key for the runtime
 	// configuration specifying the client write consistency level
 	ClientWriteConsistencyLevel = "m3db.client.write-consistency-level"
+
+	// ClusterRuntimeConfigKey is the KV config key for the runtime
+	// configuration specifying the versioned cluster wide runtime options
+	// and their per node overrides.
+	ClusterRuntimeConfigKey = "m3db.node.cluster-runtime-config"
 )
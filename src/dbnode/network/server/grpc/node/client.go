@@ -0,0 +1,226 @@
+// Copyright (c) 2018 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package node
+
+import (
+	"context"
+	"crypto/tls"
+	"io"
+	"time"
+
+	"github.com/m3db/m3/src/dbnode/generated/proto/nodepb"
+	"github.com/m3db/m3/src/dbnode/storage/index"
+	"github.com/m3db/m3/src/m3ninx/idx"
+	"github.com/m3db/m3x/ident"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials"
+)
+
+// Client is a client of the node gRPC service of a DB node, results are
+// decoded by the node and streamed in batches so that callers such as the
+// coordinator can process them without holding the whole result set.
+type Client interface {
+	// Fetch calls fn with each batch of the datapoints of the series in
+	// the range.
+	Fetch(
+		ctx context.Context,
+		namespace, id ident.ID,
+		start, end time.Time,
+		fn func(dps []*nodepb.Datapoint) error,
+	) error
+
+	// FetchTagged calls fn with each batch of the series matching the query,
+	// with their datapoints if fetchData is set, and returns whether the
+	// results were exhaustive.
+	FetchTagged(
+		ctx context.Context,
+		namespace ident.ID,
+		q index.Query,
+		opts index.QueryOptions,
+		fetchData bool,
+		fn func(series []*nodepb.Series) error,
+	) (bool, error)
+
+	// WriteBatch writes the batch and returns the errors of the writes
+	// that failed, indexed by their position in the batch.
+	WriteBatch(ctx context.Context, writes []*nodepb.WriteRequest) ([]*nodepb.WriteError, error)
+
+	// Close closes the connection to the node.
+	Close() error
+}
+
+type client struct {
+	client     nodepb.NodeClient
+	connection *grpc.ClientConn
+	batchSize  int64
+}
+
+// NewClient creates a new node gRPC client of the node at the address,
+// a batch size of zero uses the default batch size of the node.
+func NewClient(
+	address string,
+	batchSize int,
+	additionalDialOpts ...grpc.DialOption,
+) (Client, error) {
+	return newClient(address, batchSize, grpc.WithInsecure(), additionalDialOpts)
+}
+
+// NewClientWithTLS creates a new node gRPC client that connects to the
+// node at the address with TLS.
+func NewClientWithTLS(
+	address string,
+	batchSize int,
+	tlsConfig *tls.Config,
+	additionalDialOpts ...grpc.DialOption,
+) (Client, error) {
+	creds := grpc.WithTransportCredentials(credentials.NewTLS(tlsConfig))
+	return newClient(address, batchSize, creds, additionalDialOpts)
+}
+
+func newClient(
+	address string,
+	batchSize int,
+	securityDialOpt grpc.DialOption,
+	additionalDialOpts []grpc.DialOption,
+) (Client, error) {
+	dialOptions := append([]grpc.DialOption{securityDialOpt}, additionalDialOpts...)
+	cc, err := grpc.Dial(address, dialOptions...)
+	if err != nil {
+		return nil, err
+	}
+
+	return &client{
+		client:     nodepb.NewNodeClient(cc),
+		connection: cc,
+		batchSize:  int64(batchSize),
+	}, nil
+}
+
+func (c *client) Fetch(
+	ctx context.Context,
+	namespace, id ident.ID,
+	start, end time.Time,
+	fn func(dps []*nodepb.Datapoint) error,
+) error {
+	stream, err := c.client.Fetch(ctx, &nodepb.FetchRequest{
+		NameSpace:       namespace.Bytes(),
+		Id:              id.Bytes(),
+		RangeStartNanos: start.UnixNano(),
+		RangeEndNanos:   end.UnixNano(),
+		ResultType:      nodepb.ResultType_DECODED,
+		BatchSize:       c.batchSize,
+	})
+	if err != nil {
+		return err
+	}
+
+	for {
+		response, err := stream.Recv()
+		if err == io.EOF {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+		if err := fn(response.Datapoints); err != nil {
+			return err
+		}
+	}
+}
+
+func (c *client) FetchTagged(
+	ctx context.Context,
+	namespace ident.ID,
+	q index.Query,
+	opts index.QueryOptions,
+	fetchData bool,
+	fn func(series []*nodepb.Series) error,
+) (bool, error) {
+	query, err := idx.Marshal(q.Query)
+	if err != nil {
+		return false, err
+	}
+
+	stream, err := c.client.FetchTagged(ctx, &nodepb.FetchTaggedRequest{
+		NameSpace:       namespace.Bytes(),
+		Query:           query,
+		RangeStartNanos: opts.StartInclusive.UnixNano(),
+		RangeEndNanos:   opts.EndExclusive.UnixNano(),
+		FetchData:       fetchData,
+		Limit:           int64(opts.Limit),
+		ResultType:      nodepb.ResultType_DECODED,
+		BatchSize:       c.batchSize,
+	})
+	if err != nil {
+		return false, err
+	}
+
+	// NB: every response carries whether the results are exhaustive, the
+	// node always sends at least one response.
+	exhaustive := false
+	for {
+		response, err := stream.Recv()
+		if err == io.EOF {
+			return exhaustive, nil
+		}
+		if err != nil {
+			return false, err
+		}
+		exhaustive = response.Exhaustive
+		if len(response.Series) == 0 {
+			continue
+		}
+		if err := fn(response.Series); err != nil {
+			return false, err
+		}
+	}
+}
+
+func (c *client) WriteBatch(
+	ctx context.Context,
+	writes []*nodepb.WriteRequest,
+) ([]*nodepb.WriteError, error) {
+	stream, err := c.client.WriteBatch(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, write := range writes {
+		if err := stream.Send(write); err != nil {
+			// The cause of a failed send is returned when receiving.
+			if err == io.EOF {
+				break
+			}
+			return nil, err
+		}
+	}
+
+	response, err := stream.CloseAndRecv()
+	if err != nil {
+		return nil, err
+	}
+	return response.Errors, nil
+}
+
+func (c *client) Close() error {
+	return c.connection.Close()
+}
@@ -0,0 +1,164 @@
+// Copyright (c) 2018 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package node
+
+import (
+	"context"
+	"net"
+	"sort"
+	"testing"
+	"time"
+
+	"github.com/m3db/m3/src/dbnode/generated/proto/nodepb"
+	"github.com/m3db/m3/src/dbnode/storage"
+	"github.com/m3db/m3/src/dbnode/storage/index"
+	"github.com/m3db/m3/src/m3ninx/idx"
+	xcontext "github.com/m3db/m3x/context"
+	"github.com/m3db/m3x/ident"
+
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc"
+)
+
+func newTestClient(t *testing.T, ctrl *gomock.Controller) (Client, *storage.MockDatabase, func()) {
+	svc, mockDB := newTestService(ctrl)
+
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	server := grpc.NewServer()
+	nodepb.RegisterNodeServer(server, svc)
+	go server.Serve(listener)
+
+	client, err := NewClient(listener.Addr().String(), 2)
+	require.NoError(t, err)
+	return client, mockDB, func() {
+		client.Close()
+		server.Stop()
+	}
+}
+
+func TestClientFetch(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	client, mockDB, closer := newTestClient(t, ctrl)
+	defer closer()
+
+	start := time.Now().Truncate(time.Hour)
+	end := start.Add(time.Hour)
+	mockDB.EXPECT().
+		ReadEncoded(gomock.Any(), ident.NewIDMatcher("metrics"),
+			ident.NewIDMatcher("foo"), start, end).
+		Return(newTestBlockReaders(t, start, []float64{1, 2, 3}), nil)
+
+	var values []float64
+	err := client.Fetch(context.Background(), ident.StringID("metrics"),
+		ident.StringID("foo"), start, end, func(dps []*nodepb.Datapoint) error {
+			assert.True(t, len(dps) <= 2)
+			for _, dp := range dps {
+				values = append(values, dp.Value)
+			}
+			return nil
+		})
+	require.NoError(t, err)
+	assert.Equal(t, []float64{1, 2, 3}, values)
+}
+
+func TestClientFetchTagged(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	client, mockDB, closer := newTestClient(t, ctrl)
+	defer closer()
+
+	start := time.Now().Truncate(time.Hour)
+	end := start.Add(time.Hour)
+	q := idx.NewTermQuery([]byte("foo"), []byte("bar"))
+
+	results := index.NewResults(index.NewOptions())
+	results.Reset(ident.StringID("metrics"))
+	for _, id := range []string{"a", "b", "c"} {
+		results.Map().Set(ident.StringID(id), ident.NewTags(
+			ident.StringTag("foo", "bar"),
+		))
+	}
+	opts := index.QueryOptions{
+		StartInclusive: start,
+		EndExclusive:   end,
+		Limit:          10,
+	}
+	mockDB.EXPECT().QueryIDs(
+		gomock.Any(),
+		ident.NewIDMatcher("metrics"),
+		index.NewQueryMatcher(index.Query{Query: q}),
+		opts,
+	).Return(index.QueryResults{Results: results, Exhaustive: false}, nil)
+
+	var ids []string
+	exhaustive, err := client.FetchTagged(context.Background(),
+		ident.StringID("metrics"), index.Query{Query: q}, opts, false,
+		func(series []*nodepb.Series) error {
+			for _, s := range series {
+				ids = append(ids, string(s.Id))
+			}
+			return nil
+		})
+	require.NoError(t, err)
+	assert.False(t, exhaustive)
+	sort.Strings(ids)
+	assert.Equal(t, []string{"a", "b", "c"}, ids)
+}
+
+func TestClientWriteBatch(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	client, mockDB, closer := newTestClient(t, ctrl)
+	defer closer()
+
+	mockDB.EXPECT().
+		WriteBatch(gomock.Any(), ident.NewIDMatcher("metrics"), gomock.Any(), gomock.Any()).
+		DoAndReturn(func(_ xcontext.Context, _ ident.ID, writes []storage.BatchWrite,
+			_ storage.BatchWriteErrorFn) error {
+			require.Equal(t, 1, len(writes))
+			assert.Equal(t, "foo", writes[0].ID.String())
+			assert.Equal(t, time.Unix(0, 10), writes[0].Timestamp)
+			return nil
+		})
+
+	errs, err := client.WriteBatch(context.Background(), []*nodepb.WriteRequest{
+		{
+			NameSpace: []byte("metrics"),
+			Id:        []byte("foo"),
+			Datapoint: &nodepb.Datapoint{TimestampNanos: 10, Value: 1},
+		},
+		{
+			NameSpace: []byte("metrics"),
+			Id:        []byte("bar"),
+		},
+	})
+	require.NoError(t, err)
+	require.Equal(t, 1, len(errs))
+	assert.Equal(t, int64(1), errs[0].Index)
+	assert.True(t, errs[0].BadRequest)
+}
@@ -0,0 +1,78 @@
+// Copyright (c) 2018 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package node
+
+import (
+	"net"
+
+	"github.com/m3db/m3/src/dbnode/generated/proto/nodepb"
+	ns "github.com/m3db/m3/src/dbnode/network/server"
+	"github.com/m3db/m3/src/dbnode/storage"
+	"github.com/m3db/m3x/context"
+	"github.com/m3db/m3x/instrument"
+
+	"google.golang.org/grpc"
+)
+
+type server struct {
+	db          storage.Database
+	address     string
+	contextPool context.Pool
+	iopts       instrument.Options
+	serverOpts  []grpc.ServerOption
+}
+
+// NewServer creates a new node gRPC network service, the server options
+// are used to configure the gRPC server such as its TLS credentials
+func NewServer(
+	db storage.Database,
+	address string,
+	contextPool context.Pool,
+	iopts instrument.Options,
+	serverOpts ...grpc.ServerOption,
+) ns.NetworkService {
+	if iopts == nil {
+		iopts = instrument.NewOptions()
+	}
+	return &server{
+		db:          db,
+		address:     address,
+		contextPool: contextPool,
+		iopts:       iopts,
+		serverOpts:  serverOpts,
+	}
+}
+
+func (s *server) ListenAndServe() (ns.Close, error) {
+	listener, err := net.Listen("tcp", s.address)
+	if err != nil {
+		return nil, err
+	}
+
+	server := grpc.NewServer(s.serverOpts...)
+	nodepb.RegisterNodeServer(server, NewService(s.db, s.contextPool, s.iopts))
+
+	go func() {
+		server.Serve(listener)
+	}()
+
+	return server.Stop, nil
+}
@@ -0,0 +1,551 @@
+// Copyright (c) 2018 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package node
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"sort"
+	"time"
+
+	"github.com/m3db/m3/src/dbnode/generated/proto/nodepb"
+	"github.com/m3db/m3/src/dbnode/network/server/tchannelthrift/convert"
+	"github.com/m3db/m3/src/dbnode/storage"
+	"github.com/m3db/m3/src/dbnode/storage/index"
+	"github.com/m3db/m3/src/dbnode/x/xio"
+	"github.com/m3db/m3/src/m3ninx/idx"
+	"github.com/m3db/m3x/context"
+	xerrors "github.com/m3db/m3x/errors"
+	"github.com/m3db/m3x/ident"
+	"github.com/m3db/m3x/instrument"
+	xtime "github.com/m3db/m3x/time"
+
+	"github.com/uber-go/tally"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+)
+
+const (
+	// DefaultBatchSize is the default number of series, segments or
+	// datapoints sent in each streamed response.
+	DefaultBatchSize = 128
+
+	// writeChunkSize is the maximum number of streamed writes passed to the
+	// database in a single batch write.
+	writeChunkSize = 1024
+)
+
+var (
+	errWriteRequiresDatapoint = errors.New("write requires a datapoint")
+
+	// errServerIsOverloaded raised when trying to process a request when the server is overloaded
+	errServerIsOverloaded = errors.New("server is overloaded")
+
+	// errServerIsNotBootstrapped raised when trying to read from a server that is bootstrapping
+	errServerIsNotBootstrapped = errors.New("server is not bootstrapped")
+)
+
+type serviceMetrics struct {
+	fetch                   instrument.MethodMetrics
+	fetchTagged             instrument.MethodMetrics
+	writeBatch              instrument.BatchMethodMetrics
+	overloadRejected        tally.Counter
+	notBootstrappedRejected tally.Counter
+}
+
+func newServiceMetrics(iopts instrument.Options) serviceMetrics {
+	var (
+		scope        = iopts.MetricsScope().SubScope("grpc")
+		samplingRate = iopts.MetricsSamplingRate()
+	)
+	return serviceMetrics{
+		fetch:                   instrument.NewMethodMetrics(scope, "fetch", samplingRate),
+		fetchTagged:             instrument.NewMethodMetrics(scope, "fetchTagged", samplingRate),
+		writeBatch:              instrument.NewBatchMethodMetrics(scope, "writeBatch", samplingRate),
+		overloadRejected:        scope.Counter("overload-rejected"),
+		notBootstrappedRejected: scope.Counter("not-bootstrapped-rejected"),
+	}
+}
+
+type service struct {
+	db          storage.Database
+	contextPool context.Pool
+	nowFn       func() time.Time
+	metrics     serviceMetrics
+}
+
+// NewService creates a new node gRPC service, results are streamed in
+// batches so that memory is bounded on both the node and the client
+// regardless of the size of the result set.
+func NewService(
+	db storage.Database,
+	contextPool context.Pool,
+	iopts instrument.Options,
+) nodepb.NodeServer {
+	return &service{
+		db:          db,
+		contextPool: contextPool,
+		nowFn:       db.Options().ClockOptions().NowFn(),
+		metrics:     newServiceMetrics(iopts),
+	}
+}
+
+func (s *service) Fetch(req *nodepb.FetchRequest, stream nodepb.Node_FetchServer) error {
+	if err := s.checkRead(); err != nil {
+		return err
+	}
+
+	callStart := s.nowFn()
+	ctx := s.contextPool.Get()
+	defer ctx.Close()
+
+	var (
+		nsID      = ident.BytesID(req.NameSpace)
+		tsID      = ident.BytesID(req.Id)
+		start     = time.Unix(0, req.RangeStartNanos)
+		end       = time.Unix(0, req.RangeEndNanos)
+		batchSize = toBatchSize(req.BatchSize)
+	)
+	encoded, err := s.db.ReadEncoded(ctx, nsID, tsID, start, end)
+	if err != nil {
+		s.metrics.fetch.ReportError(s.nowFn().Sub(callStart))
+		return toGRPCError(err)
+	}
+
+	switch req.ResultType {
+	case nodepb.ResultType_DECODED:
+		err = s.streamDatapoints(encoded, batchSize, func(dps []*nodepb.Datapoint) error {
+			return stream.Send(&nodepb.FetchResponse{Datapoints: dps})
+		})
+	default:
+		var segments []*nodepb.Segments
+		segments, err = toSegments(encoded)
+		for err == nil && len(segments) > 0 {
+			n := minInt(batchSize, len(segments))
+			err = stream.Send(&nodepb.FetchResponse{Segments: segments[:n]})
+			segments = segments[n:]
+		}
+	}
+	if err != nil {
+		s.metrics.fetch.ReportError(s.nowFn().Sub(callStart))
+		return toGRPCError(err)
+	}
+
+	s.metrics.fetch.ReportSuccess(s.nowFn().Sub(callStart))
+	return nil
+}
+
+func (s *service) FetchTagged(req *nodepb.FetchTaggedRequest, stream nodepb.Node_FetchTaggedServer) error {
+	if err := s.checkRead(); err != nil {
+		return err
+	}
+
+	callStart := s.nowFn()
+	ctx := s.contextPool.Get()
+	defer ctx.Close()
+
+	q, err := idx.Unmarshal(req.Query)
+	if err != nil {
+		s.metrics.fetchTagged.ReportError(s.nowFn().Sub(callStart))
+		return grpc.Errorf(codes.InvalidArgument, "unable to parse query: %v", err)
+	}
+
+	var (
+		nsID = ident.BytesID(req.NameSpace)
+		opts = index.QueryOptions{
+			StartInclusive: time.Unix(0, req.RangeStartNanos),
+			EndExclusive:   time.Unix(0, req.RangeEndNanos),
+			Limit:          int(req.Limit),
+		}
+		batchSize = toBatchSize(req.BatchSize)
+	)
+	queryResult, err := s.db.QueryIDs(ctx, nsID, index.Query{Query: q}, opts)
+	if err != nil {
+		s.metrics.fetchTagged.ReportError(s.nowFn().Sub(callStart))
+		return toGRPCError(err)
+	}
+
+	var (
+		response = &nodepb.FetchTaggedResponse{Exhaustive: queryResult.Exhaustive}
+		entries  = queryResult.Results.Map().Iter()
+		// NB: data is read with a context per batch so that encoded blocks
+		// are released once each batch has been sent.
+		batchCtx = s.contextPool.Get()
+	)
+	defer func() {
+		batchCtx.Close()
+	}()
+
+	sendBatch := func() error {
+		if err := stream.Send(response); err != nil {
+			return err
+		}
+		response = &nodepb.FetchTaggedResponse{Exhaustive: queryResult.Exhaustive}
+		batchCtx.Close()
+		batchCtx = s.contextPool.Get()
+		return nil
+	}
+
+	for _, entry := range entries {
+		series := &nodepb.Series{
+			Id:   entry.Key().Bytes(),
+			Tags: toTags(entry.Value()),
+		}
+		if req.FetchData {
+			if err := s.readSeries(batchCtx, nsID, entry.Key(), opts, req.ResultType, series); err != nil {
+				s.metrics.fetchTagged.ReportError(s.nowFn().Sub(callStart))
+				return toGRPCError(err)
+			}
+		}
+
+		response.Series = append(response.Series, series)
+		if len(response.Series) < batchSize {
+			continue
+		}
+		if err := sendBatch(); err != nil {
+			s.metrics.fetchTagged.ReportError(s.nowFn().Sub(callStart))
+			return err
+		}
+	}
+
+	// Always send the final response, even if empty, so clients receive
+	// whether the results were exhaustive.
+	if len(response.Series) > 0 || len(entries) == 0 {
+		if err := stream.Send(response); err != nil {
+			s.metrics.fetchTagged.ReportError(s.nowFn().Sub(callStart))
+			return err
+		}
+	}
+
+	s.metrics.fetchTagged.ReportSuccess(s.nowFn().Sub(callStart))
+	return nil
+}
+
+func (s *service) readSeries(
+	ctx context.Context,
+	nsID, tsID ident.ID,
+	opts index.QueryOptions,
+	resultType nodepb.ResultType,
+	series *nodepb.Series,
+) error {
+	encoded, err := s.db.ReadEncoded(ctx, nsID, tsID,
+		opts.StartInclusive, opts.EndExclusive)
+	if err != nil {
+		return err
+	}
+	if resultType == nodepb.ResultType_DECODED {
+		return s.streamDatapoints(encoded, 0, func(dps []*nodepb.Datapoint) error {
+			series.Datapoints = dps
+			return nil
+		})
+	}
+	series.Segments, err = toSegments(encoded)
+	return err
+}
+
+// streamDatapoints decodes the encoded blocks and calls fn with batches of
+// at most batchSize datapoints, a batch size of zero calls fn once.
+func (s *service) streamDatapoints(
+	encoded [][]xio.BlockReader,
+	batchSize int,
+	fn func(dps []*nodepb.Datapoint) error,
+) error {
+	multiIt := s.db.Options().MultiReaderIteratorPool().Get()
+	multiIt.ResetSliceOfSlices(xio.NewReaderSliceOfSlicesFromBlockReadersIterator(encoded))
+	defer multiIt.Close()
+
+	var datapoints []*nodepb.Datapoint
+	for multiIt.Next() {
+		dp, _, annotation := multiIt.Current()
+		datapoints = append(datapoints, &nodepb.Datapoint{
+			TimestampNanos: dp.Timestamp.UnixNano(),
+			Value:          dp.Value,
+			Annotation:     annotation,
+		})
+		if batchSize <= 0 || len(datapoints) < batchSize {
+			continue
+		}
+		if err := fn(datapoints); err != nil {
+			return err
+		}
+		datapoints = nil
+	}
+	if err := multiIt.Err(); err != nil {
+		return err
+	}
+	if len(datapoints) == 0 && batchSize > 0 {
+		return nil
+	}
+	return fn(datapoints)
+}
+
+func (s *service) WriteBatch(stream nodepb.Node_WriteBatchServer) error {
+	if err := s.checkOverloaded(); err != nil {
+		return err
+	}
+
+	callStart := s.nowFn()
+	ctx := s.contextPool.Get()
+	defer ctx.Close()
+
+	var (
+		errs    writeBatchErrors
+		chunk   writeChunk
+		success int
+		index   int64
+	)
+	for ; ; index++ {
+		req, err := stream.Recv()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			s.metrics.writeBatch.ReportError(s.nowFn().Sub(callStart))
+			return err
+		}
+
+		write, err := toBatchWrite(req)
+		if err != nil {
+			errs.add(index, err)
+			continue
+		}
+
+		// NB: writes are passed to the database in chunks of consecutive
+		// writes to the same namespace with or without tags, the same as
+		// the batch writes of the Thrift service.
+		tagged := write.Tags != nil
+		if len(chunk.writes) > 0 && !chunk.accepts(req.NameSpace, tagged) {
+			success += s.writeChunk(ctx, &chunk, &errs)
+		}
+		chunk.namespace, chunk.tagged = req.NameSpace, tagged
+		chunk.writes = append(chunk.writes, write)
+		chunk.indexes = append(chunk.indexes, index)
+	}
+	success += s.writeChunk(ctx, &chunk, &errs)
+
+	s.metrics.writeBatch.ReportSuccess(success)
+	s.metrics.writeBatch.ReportRetryableErrors(errs.retryable)
+	s.metrics.writeBatch.ReportNonRetryableErrors(errs.nonRetryable)
+	s.metrics.writeBatch.ReportLatency(s.nowFn().Sub(callStart))
+
+	sort.Slice(errs.errs, func(i, j int) bool {
+		return errs.errs[i].Index < errs.errs[j].Index
+	})
+	return stream.SendAndClose(&nodepb.WriteBatchResponse{Errors: errs.errs})
+}
+
+// writeChunk is a chunk of consecutive writes of a streamed batch to the
+// same namespace, either all with or all without tags.
+type writeChunk struct {
+	namespace []byte
+	tagged    bool
+	writes    []storage.BatchWrite
+	indexes   []int64
+}
+
+func (c *writeChunk) accepts(namespace []byte, tagged bool) bool {
+	return len(c.writes) < writeChunkSize &&
+		c.tagged == tagged &&
+		bytes.Equal(c.namespace, namespace)
+}
+
+// writeChunk writes the chunk with a single batch write and returns the
+// number of writes that succeeded.
+func (s *service) writeChunk(
+	ctx context.Context,
+	chunk *writeChunk,
+	errs *writeBatchErrors,
+) int {
+	if len(chunk.writes) == 0 {
+		return 0
+	}
+
+	fn := s.db.WriteBatch
+	if chunk.tagged {
+		fn = s.db.WriteTaggedBatch
+	}
+
+	failed := 0
+	err := fn(ctx, ident.BytesID(chunk.namespace), chunk.writes, func(i int, err error) {
+		errs.add(chunk.indexes[i], err)
+		failed++
+	})
+	if err != nil {
+		// The chunk as a whole was rejected, fail each of its writes
+		for _, i := range chunk.indexes {
+			errs.add(i, err)
+		}
+		failed = len(chunk.writes)
+	}
+
+	success := len(chunk.writes) - failed
+	*chunk = writeChunk{}
+	return success
+}
+
+// writeBatchErrors accumulates the errors of the writes of a batch.
+type writeBatchErrors struct {
+	errs         []*nodepb.WriteError
+	retryable    int
+	nonRetryable int
+}
+
+func (e *writeBatchErrors) add(index int64, err error) {
+	badRequest := xerrors.IsInvalidParams(err)
+	if badRequest {
+		e.nonRetryable++
+	} else {
+		e.retryable++
+	}
+	e.errs = append(e.errs, &nodepb.WriteError{
+		Index:      index,
+		Message:    err.Error(),
+		BadRequest: badRequest,
+	})
+}
+
+func toBatchWrite(req *nodepb.WriteRequest) (storage.BatchWrite, error) {
+	dp := req.Datapoint
+	if dp == nil {
+		return storage.BatchWrite{}, xerrors.NewInvalidParamsError(errWriteRequiresDatapoint)
+	}
+
+	unit := xtime.Unit(req.Unit)
+	if unit == xtime.None {
+		unit = xtime.Nanosecond
+	}
+	if !unit.IsValid() {
+		return storage.BatchWrite{}, xerrors.NewInvalidParamsError(
+			fmt.Errorf("invalid unit: %d", req.Unit))
+	}
+
+	write := storage.BatchWrite{
+		ID:         ident.BytesID(req.Id),
+		Timestamp:  time.Unix(0, dp.TimestampNanos),
+		Value:      dp.Value,
+		Unit:       unit,
+		Annotation: dp.Annotation,
+	}
+	if len(req.Tags) == 0 {
+		return write, nil
+	}
+
+	tags := make([]ident.Tag, 0, len(req.Tags))
+	for _, tag := range req.Tags {
+		tags = append(tags, ident.Tag{
+			Name:  ident.BytesID(tag.Name),
+			Value: ident.BytesID(tag.Value),
+		})
+	}
+	write.Tags = ident.NewTagsIterator(ident.NewTags(tags...))
+	return write, nil
+}
+
+// checkOverloaded returns an unavailable error if the server is overloaded,
+// the same as the Thrift service rejects requests.
+func (s *service) checkOverloaded() error {
+	if s.db.IsOverloaded() {
+		s.metrics.overloadRejected.Inc(1)
+		return grpc.Errorf(codes.Unavailable, errServerIsOverloaded.Error())
+	}
+	return nil
+}
+
+// checkRead returns an unavailable error if the server is overloaded or has
+// not bootstrapped yet, since reads of a bootstrapping server are partial.
+func (s *service) checkRead() error {
+	if err := s.checkOverloaded(); err != nil {
+		return err
+	}
+	if !s.db.IsBootstrapped() {
+		s.metrics.notBootstrappedRejected.Inc(1)
+		return grpc.Errorf(codes.Unavailable, errServerIsNotBootstrapped.Error())
+	}
+	return nil
+}
+
+func toBatchSize(requested int64) int {
+	if requested <= 0 {
+		return DefaultBatchSize
+	}
+	return int(requested)
+}
+
+func toTags(tags ident.Tags) []*nodepb.Tag {
+	values := tags.Values()
+	result := make([]*nodepb.Tag, 0, len(values))
+	for _, tag := range values {
+		result = append(result, &nodepb.Tag{
+			Name:  tag.Name.Bytes(),
+			Value: tag.Value.Bytes(),
+		})
+	}
+	return result
+}
+
+func toSegments(encoded [][]xio.BlockReader) ([]*nodepb.Segments, error) {
+	result := make([]*nodepb.Segments, 0, len(encoded))
+	for _, readers := range encoded {
+		converted, err := convert.ToSegments(readers)
+		if err != nil {
+			return nil, err
+		}
+		if converted.Segments == nil {
+			continue
+		}
+		segments := &nodepb.Segments{}
+		if merged := converted.Segments.Merged; merged != nil {
+			segments.Merged = toSegment(merged.Head, merged.Tail,
+				merged.GetStartTime(), merged.GetBlockSize())
+		}
+		for _, unmerged := range converted.Segments.Unmerged {
+			segments.Unmerged = append(segments.Unmerged, toSegment(unmerged.Head,
+				unmerged.Tail, unmerged.GetStartTime(), unmerged.GetBlockSize()))
+		}
+		result = append(result, segments)
+	}
+	return result, nil
+}
+
+func toSegment(head, tail []byte, start, blockSize int64) *nodepb.Segment {
+	return &nodepb.Segment{
+		Head:           head,
+		Tail:           tail,
+		StartNanos:     start,
+		BlockSizeNanos: blockSize,
+	}
+}
+
+func toGRPCError(err error) error {
+	if xerrors.IsInvalidParams(err) {
+		return grpc.Errorf(codes.InvalidArgument, err.Error())
+	}
+	return grpc.Errorf(codes.Internal, err.Error())
+}
+
+func minInt(a, b int) int {
+	if a < b {
+		return a
+	}
+	return b
+}
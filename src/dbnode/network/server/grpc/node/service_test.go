@@ -0,0 +1,364 @@
+// Copyright (c) 2018 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package node
+
+import (
+	"errors"
+	"io"
+	"testing"
+	"time"
+
+	"github.com/m3db/m3/src/dbnode/generated/proto/nodepb"
+	"github.com/m3db/m3/src/dbnode/storage"
+	"github.com/m3db/m3/src/dbnode/storage/index"
+	"github.com/m3db/m3/src/dbnode/ts"
+	"github.com/m3db/m3/src/dbnode/x/xio"
+	"github.com/m3db/m3/src/m3ninx/idx"
+	"github.com/m3db/m3x/context"
+	xerrors "github.com/m3db/m3x/errors"
+	"github.com/m3db/m3x/ident"
+	"github.com/m3db/m3x/instrument"
+	xtime "github.com/m3db/m3x/time"
+
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+)
+
+// Create opts once to avoid recreating a lot of default pools, etc
+var (
+	testStorageOpts = storage.NewOptions()
+)
+
+type testFetchStream struct {
+	grpc.ServerStream
+	responses []*nodepb.FetchResponse
+}
+
+func (s *testFetchStream) Send(r *nodepb.FetchResponse) error {
+	s.responses = append(s.responses, r)
+	return nil
+}
+
+type testFetchTaggedStream struct {
+	grpc.ServerStream
+	responses []*nodepb.FetchTaggedResponse
+}
+
+func (s *testFetchTaggedStream) Send(r *nodepb.FetchTaggedResponse) error {
+	s.responses = append(s.responses, r)
+	return nil
+}
+
+type testWriteBatchStream struct {
+	grpc.ServerStream
+	requests []*nodepb.WriteRequest
+	response *nodepb.WriteBatchResponse
+}
+
+func (s *testWriteBatchStream) Recv() (*nodepb.WriteRequest, error) {
+	if len(s.requests) == 0 {
+		return nil, io.EOF
+	}
+	r := s.requests[0]
+	s.requests = s.requests[1:]
+	return r, nil
+}
+
+func (s *testWriteBatchStream) SendAndClose(r *nodepb.WriteBatchResponse) error {
+	s.response = r
+	return nil
+}
+
+func newTestService(ctrl *gomock.Controller) (*service, *storage.MockDatabase) {
+	svc, mockDB := newTestServiceWithHealth(ctrl, false, true)
+	return svc, mockDB
+}
+
+func newTestServiceWithHealth(
+	ctrl *gomock.Controller,
+	overloaded, bootstrapped bool,
+) (*service, *storage.MockDatabase) {
+	mockDB := storage.NewMockDatabase(ctrl)
+	mockDB.EXPECT().Options().Return(testStorageOpts).AnyTimes()
+	mockDB.EXPECT().IsOverloaded().Return(overloaded).AnyTimes()
+	mockDB.EXPECT().IsBootstrapped().Return(bootstrapped).AnyTimes()
+	svc := NewService(mockDB, testStorageOpts.ContextPool(),
+		instrument.NewOptions()).(*service)
+	return svc, mockDB
+}
+
+func newTestBlockReaders(
+	t *testing.T,
+	start time.Time,
+	values []float64,
+) [][]xio.BlockReader {
+	enc := testStorageOpts.EncoderPool().Get()
+	enc.Reset(start, 0)
+	for i, v := range values {
+		dp := ts.Datapoint{
+			Timestamp: start.Add(time.Duration(i+1) * time.Second),
+			Value:     v,
+		}
+		require.NoError(t, enc.Encode(dp, xtime.Second, nil))
+	}
+	return [][]xio.BlockReader{{
+		xio.BlockReader{SegmentReader: enc.Stream()},
+	}}
+}
+
+func TestServiceFetchDecodedBatches(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	svc, mockDB := newTestService(ctrl)
+
+	start := time.Now().Truncate(time.Hour)
+	end := start.Add(time.Hour)
+	mockDB.EXPECT().
+		ReadEncoded(gomock.Any(), ident.NewIDMatcher("metrics"),
+			ident.NewIDMatcher("foo"), start, end).
+		Return(newTestBlockReaders(t, start, []float64{1, 2, 3}), nil)
+
+	stream := &testFetchStream{}
+	require.NoError(t, svc.Fetch(&nodepb.FetchRequest{
+		NameSpace:       []byte("metrics"),
+		Id:              []byte("foo"),
+		RangeStartNanos: start.UnixNano(),
+		RangeEndNanos:   end.UnixNano(),
+		ResultType:      nodepb.ResultType_DECODED,
+		BatchSize:       2,
+	}, stream))
+
+	require.Equal(t, 2, len(stream.responses))
+	assert.Equal(t, 2, len(stream.responses[0].Datapoints))
+	require.Equal(t, 1, len(stream.responses[1].Datapoints))
+	dp := stream.responses[1].Datapoints[0]
+	assert.Equal(t, start.Add(3*time.Second).UnixNano(), dp.TimestampNanos)
+	assert.Equal(t, 3.0, dp.Value)
+}
+
+func TestServiceFetchRawCompressed(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	svc, mockDB := newTestService(ctrl)
+
+	start := time.Now().Truncate(time.Hour)
+	end := start.Add(time.Hour)
+	mockDB.EXPECT().
+		ReadEncoded(gomock.Any(), ident.NewIDMatcher("metrics"),
+			ident.NewIDMatcher("foo"), start, end).
+		Return(newTestBlockReaders(t, start, []float64{1, 2}), nil)
+
+	stream := &testFetchStream{}
+	require.NoError(t, svc.Fetch(&nodepb.FetchRequest{
+		NameSpace:       []byte("metrics"),
+		Id:              []byte("foo"),
+		RangeStartNanos: start.UnixNano(),
+		RangeEndNanos:   end.UnixNano(),
+	}, stream))
+
+	require.Equal(t, 1, len(stream.responses))
+	require.Equal(t, 1, len(stream.responses[0].Segments))
+	assert.NotNil(t, stream.responses[0].Segments[0].Merged)
+}
+
+func TestServiceFetchTaggedBatches(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	svc, mockDB := newTestService(ctrl)
+
+	start := time.Now().Truncate(time.Hour)
+	end := start.Add(time.Hour)
+
+	q := idx.NewTermQuery([]byte("foo"), []byte("bar"))
+	data, err := idx.Marshal(q)
+	require.NoError(t, err)
+
+	results := index.NewResults(index.NewOptions())
+	results.Reset(ident.StringID("metrics"))
+	for _, id := range []string{"a", "b", "c"} {
+		results.Map().Set(ident.StringID(id), ident.NewTags(
+			ident.StringTag("foo", "bar"),
+		))
+	}
+
+	mockDB.EXPECT().QueryIDs(
+		gomock.Any(),
+		ident.NewIDMatcher("metrics"),
+		index.NewQueryMatcher(index.Query{Query: q}),
+		index.QueryOptions{
+			StartInclusive: start,
+			EndExclusive:   end,
+			Limit:          10,
+		}).Return(index.QueryResults{Results: results, Exhaustive: true}, nil)
+
+	stream := &testFetchTaggedStream{}
+	require.NoError(t, svc.FetchTagged(&nodepb.FetchTaggedRequest{
+		NameSpace:       []byte("metrics"),
+		Query:           data,
+		RangeStartNanos: start.UnixNano(),
+		RangeEndNanos:   end.UnixNano(),
+		Limit:           10,
+		BatchSize:       2,
+	}, stream))
+
+	require.Equal(t, 2, len(stream.responses))
+	assert.Equal(t, 2, len(stream.responses[0].Series))
+	assert.Equal(t, 1, len(stream.responses[1].Series))
+	for _, r := range stream.responses {
+		assert.True(t, r.Exhaustive)
+		for _, s := range r.Series {
+			require.Equal(t, 1, len(s.Tags))
+			assert.Equal(t, "foo", string(s.Tags[0].Name))
+			assert.Equal(t, "bar", string(s.Tags[0].Value))
+		}
+	}
+}
+
+func TestServiceFetchTaggedInvalidQuery(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	svc, _ := newTestService(ctrl)
+
+	err := svc.FetchTagged(&nodepb.FetchTaggedRequest{
+		NameSpace: []byte("metrics"),
+		Query:     []byte("invalid"),
+	}, &testFetchTaggedStream{})
+	require.Error(t, err)
+	assert.Equal(t, codes.InvalidArgument, grpc.Code(err))
+}
+
+func TestServiceWriteBatch(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	svc, mockDB := newTestService(ctrl)
+
+	now := time.Now().Truncate(time.Second)
+	mockDB.EXPECT().
+		WriteBatch(gomock.Any(), ident.NewIDMatcher("metrics"), gomock.Any(), gomock.Any()).
+		DoAndReturn(func(_ context.Context, _ ident.ID, writes []storage.BatchWrite,
+			_ storage.BatchWriteErrorFn) error {
+			require.Equal(t, 2, len(writes))
+			assert.Equal(t, "foo", writes[0].ID.String())
+			assert.Equal(t, now, writes[0].Timestamp)
+			assert.Equal(t, xtime.Nanosecond, writes[0].Unit)
+			assert.Equal(t, "qux", writes[1].ID.String())
+			return nil
+		})
+	mockDB.EXPECT().
+		WriteTaggedBatch(gomock.Any(), ident.NewIDMatcher("metrics"), gomock.Any(), gomock.Any()).
+		DoAndReturn(func(_ context.Context, _ ident.ID, writes []storage.BatchWrite,
+			errFn storage.BatchWriteErrorFn) error {
+			require.Equal(t, 1, len(writes))
+			assert.Equal(t, "bar", writes[0].ID.String())
+			assert.Equal(t, 2.0, writes[0].Value)
+			assert.Equal(t, xtime.Second, writes[0].Unit)
+			require.NotNil(t, writes[0].Tags)
+			errFn(0, errors.New("write failed"))
+			return nil
+		})
+
+	stream := &testWriteBatchStream{
+		requests: []*nodepb.WriteRequest{
+			{
+				NameSpace: []byte("metrics"),
+				Id:        []byte("foo"),
+				Datapoint: &nodepb.Datapoint{TimestampNanos: now.UnixNano(), Value: 1},
+			},
+			{
+				NameSpace: []byte("metrics"),
+				Id:        []byte("qux"),
+				Datapoint: &nodepb.Datapoint{TimestampNanos: now.UnixNano(), Value: 3},
+			},
+			{
+				NameSpace: []byte("metrics"),
+				Id:        []byte("bar"),
+				Tags:      []*nodepb.Tag{{Name: []byte("foo"), Value: []byte("bar")}},
+				Datapoint: &nodepb.Datapoint{TimestampNanos: now.UnixNano(), Value: 2},
+				Unit:      int32(xtime.Second),
+			},
+			{
+				NameSpace: []byte("metrics"),
+				Id:        []byte("baz"),
+			},
+		},
+	}
+	require.NoError(t, svc.WriteBatch(stream))
+
+	require.NotNil(t, stream.response)
+	require.Equal(t, 2, len(stream.response.Errors))
+	assert.Equal(t, int64(2), stream.response.Errors[0].Index)
+	assert.False(t, stream.response.Errors[0].BadRequest)
+	assert.Equal(t, int64(3), stream.response.Errors[1].Index)
+	assert.True(t, stream.response.Errors[1].BadRequest)
+	assert.Equal(t, xerrors.NewInvalidParamsError(errWriteRequiresDatapoint).Error(),
+		stream.response.Errors[1].Message)
+}
+
+func TestServiceRejectsWhenOverloaded(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	svc, _ := newTestServiceWithHealth(ctrl, true, true)
+
+	err := svc.Fetch(&nodepb.FetchRequest{}, &testFetchStream{})
+	assert.Equal(t, codes.Unavailable, grpc.Code(err))
+
+	err = svc.FetchTagged(&nodepb.FetchTaggedRequest{}, &testFetchTaggedStream{})
+	assert.Equal(t, codes.Unavailable, grpc.Code(err))
+
+	stream := &testWriteBatchStream{requests: []*nodepb.WriteRequest{{}}}
+	err = svc.WriteBatch(stream)
+	assert.Equal(t, codes.Unavailable, grpc.Code(err))
+	assert.Nil(t, stream.response)
+}
+
+func TestServiceRejectsReadsWhenNotBootstrapped(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	svc, mockDB := newTestServiceWithHealth(ctrl, false, false)
+
+	err := svc.Fetch(&nodepb.FetchRequest{}, &testFetchStream{})
+	assert.Equal(t, codes.Unavailable, grpc.Code(err))
+
+	err = svc.FetchTagged(&nodepb.FetchTaggedRequest{}, &testFetchTaggedStream{})
+	assert.Equal(t, codes.Unavailable, grpc.Code(err))
+
+	// Writes are accepted while bootstrapping.
+	mockDB.EXPECT().
+		WriteBatch(gomock.Any(), ident.NewIDMatcher("ns"), gomock.Any(), gomock.Any()).
+		Return(nil)
+	stream := &testWriteBatchStream{requests: []*nodepb.WriteRequest{{
+		NameSpace: []byte("ns"),
+		Id:        []byte("foo"),
+		Datapoint: &nodepb.Datapoint{TimestampNanos: 10, Value: 1},
+	}}}
+	require.NoError(t, svc.WriteBatch(stream))
+	assert.Empty(t, stream.response.Errors)
+}
@@ -0,0 +1,720 @@
+// Copyright (c) 2018 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package node
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"time"
+
+	"github.com/m3db/m3/src/dbnode/runtime"
+	"github.com/m3db/m3/src/dbnode/storage"
+	"github.com/m3db/m3/src/dbnode/storage/backup"
+	"github.com/m3db/m3/src/dbnode/storage/index/compaction"
+	"github.com/m3db/m3/src/dbnode/storage/quota"
+	"github.com/m3db/m3/src/dbnode/storage/replication"
+	"github.com/m3db/m3/src/dbnode/storage/stats"
+	"github.com/m3db/m3cluster/shard"
+	xerrors "github.com/m3db/m3x/errors"
+	"github.com/m3db/m3x/ident"
+
+	"github.com/uber/tchannel-go/thrift"
+)
+
+var (
+	errIndexCompactionNotEnabled = xerrors.NewInvalidParamsError(
+		errors.New("index compaction is not enabled"))
+	errBackupNotEnabled = xerrors.NewInvalidParamsError(
+		errors.New("backup is not enabled"))
+	errNamespaceRequired = xerrors.NewInvalidParamsError(
+		errors.New("namespace is required"))
+	errReplicationNotEnabled = xerrors.NewInvalidParamsError(
+		errors.New("replication is not enabled"))
+	errDiskQuotaNotEnabled = xerrors.NewInvalidParamsError(
+		errors.New("disk quotas are not enabled"))
+	errNegativeDiskQuota = xerrors.NewInvalidParamsError(
+		errors.New("disk quota limits must not be negative"))
+	errNegativeThroughputLimit = xerrors.NewInvalidParamsError(
+		errors.New("throughput limit must not be negative"))
+	errDrainNotBootstrapped = xerrors.NewInvalidParamsError(
+		errors.New("cannot drain a node that is not bootstrapped"))
+)
+
+// AdminService is a service exposing administrative operations for a node
+// that have no equivalent in the node RPC service.
+type AdminService struct {
+	db storage.Database
+}
+
+// NewAdminService returns a new admin service.
+func NewAdminService(db storage.Database) *AdminService {
+	return &AdminService{db: db}
+}
+
+// IndexCompactionTriggerRequest is a request to trigger index compactions.
+type IndexCompactionTriggerRequest struct{}
+
+// IndexCompactionTriggerResult is the result of triggering index compactions.
+type IndexCompactionTriggerResult struct {
+	Triggered bool `json:"triggered"`
+}
+
+// IndexCompactionTrigger requests that index segments are compacted during
+// the next index tick, regardless of whether background compactions are enabled.
+func (s *AdminService) IndexCompactionTrigger(
+	ctx thrift.Context,
+	req *IndexCompactionTriggerRequest,
+) (*IndexCompactionTriggerResult, error) {
+	manager, err := s.compactionManager()
+	if err != nil {
+		return nil, err
+	}
+	manager.Trigger()
+	return &IndexCompactionTriggerResult{Triggered: true}, nil
+}
+
+// IndexCompactionStatus returns the status of index compactions.
+func (s *AdminService) IndexCompactionStatus(
+	ctx thrift.Context,
+) (*compaction.Status, error) {
+	manager, err := s.compactionManager()
+	if err != nil {
+		return nil, err
+	}
+	status := manager.Status()
+	return &status, nil
+}
+
+func (s *AdminService) compactionManager() (*compaction.Manager, error) {
+	manager := s.db.Options().IndexOptions().CompactionManager()
+	if manager == nil {
+		return nil, errIndexCompactionNotEnabled
+	}
+	return manager, nil
+}
+
+// FileOpsWindow is a daily window, as durations from midnight UTC such as
+// "2h" or "22h30m", during which flushes, cleanups and compactions may run.
+type FileOpsWindow struct {
+	Start string `json:"start"`
+	End   string `json:"end"`
+}
+
+// FileOpsScheduleResult is the file ops schedule and throughput limit of a node.
+type FileOpsScheduleResult struct {
+	Windows                []FileOpsWindow `json:"windows"`
+	MaxDeferral            string          `json:"maxDeferral"`
+	AllowedNow             bool            `json:"allowedNow"`
+	ThroughputLimitEnabled bool            `json:"throughputLimitEnabled"`
+	ThroughputLimitMbps    float64         `json:"throughputLimitMbps"`
+}
+
+// SetFileOpsScheduleRequest is a request to set the file ops schedule and
+// optionally the disk throughput limit of flushes.
+type SetFileOpsScheduleRequest struct {
+	Windows             []FileOpsWindow `json:"windows"`
+	MaxDeferral         string          `json:"maxDeferral"`
+	ThroughputLimitMbps *float64        `json:"throughputLimitMbps"`
+}
+
+// FileOpsSchedule returns the schedule restricting when flushes, cleanups
+// and index compactions run along with the flush throughput limit.
+func (s *AdminService) FileOpsSchedule(
+	ctx thrift.Context,
+) (*FileOpsScheduleResult, error) {
+	return s.fileOpsScheduleResult(s.db.Options().RuntimeOptionsManager().Get()), nil
+}
+
+// SetFileOpsSchedule sets the schedule restricting when flushes, cleanups
+// and index compactions run, an empty set of windows allows them at any time.
+func (s *AdminService) SetFileOpsSchedule(
+	ctx thrift.Context,
+	req *SetFileOpsScheduleRequest,
+) (*FileOpsScheduleResult, error) {
+	var schedule runtime.FileOpsSchedule
+	for _, w := range req.Windows {
+		start, err := parseFileOpsDuration("start", w.Start)
+		if err != nil {
+			return nil, err
+		}
+		end, err := parseFileOpsDuration("end", w.End)
+		if err != nil {
+			return nil, err
+		}
+		schedule.Windows = append(schedule.Windows,
+			runtime.FileOpsWindow{Start: start, End: end})
+	}
+	if req.MaxDeferral != "" {
+		maxDeferral, err := parseFileOpsDuration("maxDeferral", req.MaxDeferral)
+		if err != nil {
+			return nil, err
+		}
+		schedule.MaxDeferral = maxDeferral
+	}
+
+	runtimeOptsMgr := s.db.Options().RuntimeOptionsManager()
+	runtimeOpts := runtimeOptsMgr.Get().SetFileOpsSchedule(schedule)
+	if limitMbps := req.ThroughputLimitMbps; limitMbps != nil {
+		if *limitMbps < 0 {
+			return nil, errNegativeThroughputLimit
+		}
+		// A zero limit disables throttling of flushes.
+		rateLimitOpts := runtimeOpts.PersistRateLimitOptions().
+			SetLimitEnabled(*limitMbps > 0).
+			SetLimitMbps(*limitMbps)
+		runtimeOpts = runtimeOpts.SetPersistRateLimitOptions(rateLimitOpts)
+	}
+	if err := runtimeOpts.Validate(); err != nil {
+		return nil, xerrors.NewInvalidParamsError(err)
+	}
+	if err := runtimeOptsMgr.Update(runtimeOpts); err != nil {
+		return nil, err
+	}
+
+	return s.fileOpsScheduleResult(runtimeOpts), nil
+}
+
+func (s *AdminService) fileOpsScheduleResult(
+	runtimeOpts runtime.Options,
+) *FileOpsScheduleResult {
+	var (
+		schedule      = runtimeOpts.FileOpsSchedule()
+		rateLimitOpts = runtimeOpts.PersistRateLimitOptions()
+		now           = s.db.Options().ClockOptions().NowFn()()
+		windows       = make([]FileOpsWindow, 0, len(schedule.Windows))
+	)
+	for _, w := range schedule.Windows {
+		windows = append(windows, FileOpsWindow{
+			Start: w.Start.String(),
+			End:   w.End.String(),
+		})
+	}
+	return &FileOpsScheduleResult{
+		Windows:                windows,
+		MaxDeferral:            schedule.MaxDeferralOrDefault().String(),
+		AllowedNow:             schedule.Allows(now),
+		ThroughputLimitEnabled: rateLimitOpts.LimitEnabled(),
+		ThroughputLimitMbps:    rateLimitOpts.LimitMbps(),
+	}
+}
+
+func parseFileOpsDuration(field, value string) (time.Duration, error) {
+	d, err := time.ParseDuration(value)
+	if err != nil {
+		return 0, xerrors.NewInvalidParamsError(
+			fmt.Errorf("invalid %s duration '%s': %v", field, value, err))
+	}
+	return d, nil
+}
+
+// BackupTriggerRequest is a request to trigger a backup.
+type BackupTriggerRequest struct{}
+
+// BackupTriggerResult is the result of triggering a backup.
+type BackupTriggerResult struct {
+	Triggered bool `json:"triggered"`
+}
+
+// BackupTrigger starts a backup of all flushed filesets not yet backed up,
+// a backup is not triggered if one is already in progress.
+func (s *AdminService) BackupTrigger(
+	ctx thrift.Context,
+	req *BackupTriggerRequest,
+) (*BackupTriggerResult, error) {
+	manager, err := s.backupManager()
+	if err != nil {
+		return nil, err
+	}
+	if manager.Status().InProgress {
+		return &BackupTriggerResult{Triggered: false}, nil
+	}
+
+	var (
+		namespaces = storage.BackupNamespaces(s.db.Namespaces())
+		log        = s.db.Options().InstrumentOptions().Logger()
+	)
+	go func() {
+		if _, err := manager.Backup(namespaces); err != nil {
+			log.Errorf("error when running triggered backup: %v", err)
+		}
+	}()
+	return &BackupTriggerResult{Triggered: true}, nil
+}
+
+// BackupStatus returns the status of backups.
+func (s *AdminService) BackupStatus(
+	ctx thrift.Context,
+) (*backup.Status, error) {
+	manager, err := s.backupManager()
+	if err != nil {
+		return nil, err
+	}
+	status := manager.Status()
+	return &status, nil
+}
+
+// BackupManifestRequest is a request for the backup manifest of a block,
+// the shard is ignored for index manifests.
+type BackupManifestRequest struct {
+	Namespace  string `json:"namespace"`
+	Shard      uint32 `json:"shard"`
+	BlockStart int64  `json:"blockStart"`
+	Index      bool   `json:"index"`
+}
+
+// BackupManifestResult is the backup manifest of a block.
+type BackupManifestResult struct {
+	Found    bool             `json:"found"`
+	Manifest *backup.Manifest `json:"manifest,omitempty"`
+}
+
+// BackupManifest returns the backup manifest for a namespace, shard and
+// block start specified in unix seconds, or namespace and block start for
+// the index.
+func (s *AdminService) BackupManifest(
+	ctx thrift.Context,
+	req *BackupManifestRequest,
+) (*BackupManifestResult, error) {
+	manager, err := s.backupManager()
+	if err != nil {
+		return nil, err
+	}
+	if req.Namespace == "" {
+		return nil, errNamespaceRequired
+	}
+
+	var (
+		namespace  = ident.StringID(req.Namespace)
+		blockStart = time.Unix(req.BlockStart, 0)
+		manifest   backup.Manifest
+		found      bool
+	)
+	if req.Index {
+		manifest, found, err = manager.IndexManifest(namespace, blockStart)
+	} else {
+		manifest, found, err = manager.Manifest(namespace, req.Shard, blockStart)
+	}
+	if err != nil {
+		return nil, err
+	}
+	if !found {
+		return &BackupManifestResult{Found: false}, nil
+	}
+	return &BackupManifestResult{Found: true, Manifest: &manifest}, nil
+}
+
+func (s *AdminService) backupManager() (*backup.Manager, error) {
+	manager := s.db.Options().BackupManager()
+	if manager == nil {
+		return nil, errBackupNotEnabled
+	}
+	return manager, nil
+}
+
+// ReplicationStatus returns the status of replication to the standby cluster.
+func (s *AdminService) ReplicationStatus(
+	ctx thrift.Context,
+) (*replication.Status, error) {
+	replicator, err := s.replicator()
+	if err != nil {
+		return nil, err
+	}
+	status := replicator.Status()
+	return &status, nil
+}
+
+// ReplicationNamespaceRequest is a request to enable or disable replication
+// of a namespace.
+type ReplicationNamespaceRequest struct {
+	Namespace string `json:"namespace"`
+	Enabled   bool   `json:"enabled"`
+}
+
+// ReplicationNamespace enables or disables replication of a namespace and
+// returns the resulting replication status.
+func (s *AdminService) ReplicationNamespace(
+	ctx thrift.Context,
+	req *ReplicationNamespaceRequest,
+) (*replication.Status, error) {
+	replicator, err := s.replicator()
+	if err != nil {
+		return nil, err
+	}
+	if req.Namespace == "" {
+		return nil, errNamespaceRequired
+	}
+	replicator.SetNamespaceEnabled(ident.StringID(req.Namespace), req.Enabled)
+	status := replicator.Status()
+	return &status, nil
+}
+
+// ReplicationCatchUpRequest is a request to replicate a block of a shard
+// from its flushed fileset, the block start is specified in unix seconds.
+type ReplicationCatchUpRequest struct {
+	Namespace  string `json:"namespace"`
+	Shard      uint32 `json:"shard"`
+	BlockStart int64  `json:"blockStart"`
+}
+
+// ReplicationCatchUp replicates a block of a shard from its flushed fileset.
+func (s *AdminService) ReplicationCatchUp(
+	ctx thrift.Context,
+	req *ReplicationCatchUpRequest,
+) (*replication.CatchUpResult, error) {
+	replicator, err := s.replicator()
+	if err != nil {
+		return nil, err
+	}
+	if req.Namespace == "" {
+		return nil, errNamespaceRequired
+	}
+	result, err := replicator.CatchUp(ident.StringID(req.Namespace),
+		req.Shard, time.Unix(req.BlockStart, 0))
+	if err != nil {
+		return nil, err
+	}
+	return &result, nil
+}
+
+func (s *AdminService) replicator() (*replication.Replicator, error) {
+	replicator := s.db.Options().Replicator()
+	if replicator == nil {
+		return nil, errReplicationNotEnabled
+	}
+	return replicator, nil
+}
+
+// DiskUsage returns the disk usage of each namespace and shard as of the
+// last measurement, which is taken after each flush.
+func (s *AdminService) DiskUsage(
+	ctx thrift.Context,
+) (*quota.Usage, error) {
+	tracker, err := s.diskQuotaTracker()
+	if err != nil {
+		return nil, err
+	}
+	usage := tracker.Usage()
+	return &usage, nil
+}
+
+// DiskQuotaRequest is a request to set the disk quota of a namespace, a
+// zero limit disables it.
+type DiskQuotaRequest struct {
+	Namespace      string `json:"namespace"`
+	SoftLimitBytes int64  `json:"softLimitBytes"`
+	HardLimitBytes int64  `json:"hardLimitBytes"`
+}
+
+// DiskQuota sets the disk quota of a namespace until the node restarts and
+// returns the resulting disk usage.
+func (s *AdminService) DiskQuota(
+	ctx thrift.Context,
+	req *DiskQuotaRequest,
+) (*quota.Usage, error) {
+	tracker, err := s.diskQuotaTracker()
+	if err != nil {
+		return nil, err
+	}
+	if req.Namespace == "" {
+		return nil, errNamespaceRequired
+	}
+	if req.SoftLimitBytes < 0 || req.HardLimitBytes < 0 {
+		return nil, errNegativeDiskQuota
+	}
+	tracker.SetQuota(ident.StringID(req.Namespace), quota.Quota{
+		SoftLimitBytes: req.SoftLimitBytes,
+		HardLimitBytes: req.HardLimitBytes,
+	})
+	usage := tracker.Usage()
+	return &usage, nil
+}
+
+// NamespaceStats returns the statistics of each namespace held by the node:
+// its active series, in memory blocks and index segments as of its last
+// tick, the bytes and number of its flushed filesets on disk and the hit
+// rate of its block read cache. Namespaces whose filesets could not be
+// measured are reported without disk statistics.
+func (s *AdminService) NamespaceStats(
+	ctx thrift.Context,
+) (*stats.NodeStats, error) {
+	namespaces := s.db.Namespaces()
+	sort.Sort(storage.NamespacesByID(namespaces))
+
+	// Reuse the measurement taken after each flush if disk quotas are
+	// enabled rather than listing the filesets again.
+	var measured map[string]quota.NamespaceUsage
+	if tracker := s.db.Options().DiskQuotaTracker(); tracker != nil {
+		usage := tracker.Usage()
+		measured = make(map[string]quota.NamespaceUsage, len(usage.Namespaces))
+		for _, nsUsage := range usage.Namespaces {
+			measured[nsUsage.Namespace] = nsUsage
+		}
+	}
+
+	var (
+		filePathPrefix  = s.db.Options().CommitLogOptions().FilesystemOptions().FilePathPrefix()
+		quotaNamespaces = storage.QuotaNamespaces(namespaces)
+		result          = &stats.NodeStats{
+			Namespaces: make([]stats.NamespaceStats, 0, len(namespaces)),
+		}
+	)
+	for i, ns := range namespaces {
+		nsStats := ns.Stats()
+
+		nsUsage, ok := measured[nsStats.Namespace]
+		if !ok {
+			var err error
+			nsUsage, err = quota.MeasureNamespace(filePathPrefix, quotaNamespaces[i])
+			if err != nil {
+				result.Errors = append(result.Errors, fmt.Sprintf(
+					"unable to measure namespace %s: %v", nsStats.Namespace, err))
+				result.Namespaces = append(result.Namespaces, nsStats)
+				continue
+			}
+		}
+		nsStats.Disk = &stats.DiskStats{
+			Bytes:         nsUsage.Bytes,
+			DataBytes:     nsUsage.DataBytes,
+			IndexBytes:    nsUsage.IndexBytes,
+			DataFilesets:  nsUsage.DataFilesets,
+			IndexFilesets: nsUsage.IndexFilesets,
+		}
+		result.Namespaces = append(result.Namespaces, nsStats)
+	}
+	return result, nil
+}
+
+func (s *AdminService) diskQuotaTracker() (*quota.Tracker, error) {
+	tracker := s.db.Options().DiskQuotaTracker()
+	if tracker == nil {
+		return nil, errDiskQuotaNotEnabled
+	}
+	return tracker, nil
+}
+
+// DrainTriggerRequest is a request to drain a node.
+type DrainTriggerRequest struct{}
+
+// DrainStatusResult is the drain status of a node.
+type DrainStatusResult struct {
+	State string `json:"state"`
+	// ReadyForTermination is true once all data has been persisted and the
+	// node can be terminated without bootstrapping from peers on replacement.
+	ReadyForTermination bool `json:"readyForTermination"`
+}
+
+// DrainTrigger starts draining the node, which stops it from accepting
+// writes, flushes and snapshots all data and waits for the commit log to
+// be written. A node that is drained does not accept writes until it is
+// restarted, a node that fails to drain resumes accepting writes.
+func (s *AdminService) DrainTrigger(
+	ctx thrift.Context,
+	req *DrainTriggerRequest,
+) (*DrainStatusResult, error) {
+	if !s.db.IsBootstrapped() {
+		return nil, errDrainNotBootstrapped
+	}
+	if state := s.db.DrainState(); state != storage.DrainNotStarted {
+		return &DrainStatusResult{
+			State:               drainStateString(state),
+			ReadyForTermination: state == storage.Drained,
+		}, nil
+	}
+
+	log := s.db.Options().InstrumentOptions().Logger()
+	go func() {
+		if err := s.db.Drain(); err != nil {
+			log.Errorf("error when draining database: %v", err)
+		}
+	}()
+	return &DrainStatusResult{State: drainStateString(storage.Draining)}, nil
+}
+
+// DrainStatus returns the drain status of the node.
+func (s *AdminService) DrainStatus(
+	ctx thrift.Context,
+) (*DrainStatusResult, error) {
+	state := s.db.DrainState()
+	return &DrainStatusResult{
+		State:               drainStateString(state),
+		ReadyForTermination: state == storage.Drained,
+	}, nil
+}
+
+// ShardHealthResult is the health of each shard owned by a node.
+type ShardHealthResult struct {
+	// Ready is true when the node is bootstrapped, not draining and all of
+	// its shards are available, i.e. it is safe to restart another node.
+	Ready                  bool          `json:"ready"`
+	Bootstrapped           bool          `json:"bootstrapped"`
+	DrainState             string        `json:"drainState"`
+	BootstrappedPercent    float64       `json:"bootstrappedPercent"`
+	CommitLogQueueLength   int           `json:"commitLogQueueLength"`
+	CommitLogQueueCapacity int           `json:"commitLogQueueCapacity"`
+	Shards                 []ShardHealth `json:"shards"`
+}
+
+// ShardHealth is the health of a shard.
+type ShardHealth struct {
+	ID                  uint32                 `json:"id"`
+	State               string                 `json:"state"`
+	BootstrappedPercent float64                `json:"bootstrappedPercent"`
+	Namespaces          []NamespaceShardHealth `json:"namespaces"`
+}
+
+// NamespaceShardHealth is the health of a shard of a namespace, times are
+// specified in unix seconds and are zero if not set.
+type NamespaceShardHealth struct {
+	Namespace              string `json:"namespace"`
+	BootstrapState         string `json:"bootstrapState"`
+	LastFlushedBlockStart  int64  `json:"lastFlushedBlockStart"`
+	NumFailedFlushBlocks   int    `json:"numFailedFlushBlocks"`
+	Snapshotting           bool   `json:"snapshotting"`
+	LastSuccessfulSnapshot int64  `json:"lastSuccessfulSnapshot"`
+}
+
+// ShardHealth returns the placement state, bootstrap progress and flush
+// status of each shard owned by the node along with the commit log backlog.
+func (s *AdminService) ShardHealth(
+	ctx thrift.Context,
+) (*ShardHealthResult, error) {
+	var (
+		shards     = s.db.ShardSet().All()
+		namespaces = s.db.Namespaces()
+		byID       = make(map[uint32]int, len(shards))
+		result     = &ShardHealthResult{
+			Ready:                  s.db.IsBootstrapped(),
+			Bootstrapped:           s.db.IsBootstrapped(),
+			DrainState:             drainStateString(s.db.DrainState()),
+			CommitLogQueueLength:   s.db.CommitLogQueueLength(),
+			CommitLogQueueCapacity: s.db.Options().CommitLogOptions().BacklogQueueSize(),
+			Shards:                 make([]ShardHealth, 0, len(shards)),
+		}
+	)
+	if s.db.DrainState() != storage.DrainNotStarted {
+		result.Ready = false
+	}
+	for i, sh := range shards {
+		if sh.State() != shard.Available {
+			result.Ready = false
+		}
+		byID[sh.ID()] = i
+		result.Shards = append(result.Shards, ShardHealth{
+			ID:    sh.ID(),
+			State: shardStateString(sh.State()),
+		})
+	}
+
+	var (
+		total, bootstrapped int
+		shardBootstrapped   = make([]int, len(shards))
+	)
+	for _, n := range namespaces {
+		for _, sh := range n.Shards() {
+			i, ok := byID[sh.ID()]
+			if !ok {
+				// The shard is being closed after being removed from the
+				// node's shard set.
+				continue
+			}
+			var (
+				bootstrapState = sh.BootstrapState()
+				flushStatus    = sh.FlushStatus()
+			)
+			total++
+			if bootstrapState == storage.Bootstrapped {
+				bootstrapped++
+				shardBootstrapped[i]++
+			}
+			result.Shards[i].Namespaces = append(result.Shards[i].Namespaces,
+				NamespaceShardHealth{
+					Namespace:              n.ID().String(),
+					BootstrapState:         bootstrapStateString(bootstrapState),
+					LastFlushedBlockStart:  unixSecondsOrZero(flushStatus.LastFlushedBlockStart),
+					NumFailedFlushBlocks:   flushStatus.NumFailedBlocks,
+					Snapshotting:           flushStatus.Snapshotting,
+					LastSuccessfulSnapshot: unixSecondsOrZero(flushStatus.LastSuccessfulSnapshot),
+				})
+		}
+	}
+
+	for i := range result.Shards {
+		result.Shards[i].BootstrappedPercent = percent(shardBootstrapped[i],
+			len(result.Shards[i].Namespaces))
+	}
+	result.BootstrappedPercent = percent(bootstrapped, total)
+
+	return result, nil
+}
+
+func shardStateString(state shard.State) string {
+	switch state {
+	case shard.Initializing:
+		return "initializing"
+	case shard.Available:
+		return "available"
+	case shard.Leaving:
+		return "leaving"
+	default:
+		return "unknown"
+	}
+}
+
+func bootstrapStateString(state storage.BootstrapState) string {
+	switch state {
+	case storage.BootstrapNotStarted:
+		return "not-started"
+	case storage.Bootstrapping:
+		return "bootstrapping"
+	case storage.Bootstrapped:
+		return "bootstrapped"
+	default:
+		return "unknown"
+	}
+}
+
+func drainStateString(state storage.DrainState) string {
+	switch state {
+	case storage.DrainNotStarted:
+		return "not-started"
+	case storage.Draining:
+		return "draining"
+	case storage.Drained:
+		return "drained"
+	default:
+		return "unknown"
+	}
+}
+
+func unixSecondsOrZero(t time.Time) int64 {
+	if t.IsZero() {
+		return 0
+	}
+	return t.Unix()
+}
+
+func percent(n, total int) float64 {
+	if total == 0 {
+		return 100
+	}
+	return 100 * float64(n) / float64(total)
+}
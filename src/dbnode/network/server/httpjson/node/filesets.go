@@ -0,0 +1,400 @@
+// Copyright (c) 2018 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package node
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"sort"
+	"time"
+
+	"github.com/m3db/m3/src/dbnode/persist"
+	"github.com/m3db/m3/src/dbnode/persist/fs"
+	xerrors "github.com/m3db/m3x/errors"
+	"github.com/m3db/m3x/ident"
+
+	"github.com/uber/tchannel-go/thrift"
+)
+
+const defaultFilesetSeriesDatapointsLimit = 1000
+
+var (
+	errIDRequired = xerrors.NewInvalidParamsError(
+		errors.New("id is required"))
+	errNegativeDatapointsLimit = xerrors.NewInvalidParamsError(
+		errors.New("datapoints limit must not be negative"))
+)
+
+// FilesetsRequest is a request to list the flushed filesets of a shard, the
+// shard is ignored when listing index filesets.
+type FilesetsRequest struct {
+	Namespace string `json:"namespace"`
+	Shard     uint32 `json:"shard"`
+	Index     bool   `json:"index"`
+}
+
+// FilesetsResult is the list of flushed filesets of a shard or of the index
+// ordered by block start and volume.
+type FilesetsResult struct {
+	Filesets []Fileset `json:"filesets"`
+}
+
+// Fileset is a flushed fileset and the files it consists of.
+type Fileset struct {
+	BlockStart int64    `json:"blockStart"`
+	Volume     int      `json:"volume"`
+	Files      []string `json:"files"`
+}
+
+// Filesets lists the flushed data filesets of a namespace and shard, or the
+// index filesets of a namespace, on the node's disk.
+func (s *AdminService) Filesets(
+	ctx thrift.Context,
+	req *FilesetsRequest,
+) (*FilesetsResult, error) {
+	namespace, err := s.filesetNamespace(req.Namespace)
+	if err != nil {
+		return nil, err
+	}
+
+	var (
+		filePathPrefix = s.fsOptions().FilePathPrefix()
+		files          fs.FileSetFilesSlice
+	)
+	if req.Index {
+		files, err = fs.IndexFiles(filePathPrefix, namespace)
+	} else {
+		files, err = fs.DataFiles(filePathPrefix, namespace, req.Shard)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	result := &FilesetsResult{Filesets: make([]Fileset, 0, len(files))}
+	for _, file := range files {
+		result.Filesets = append(result.Filesets, Fileset{
+			BlockStart: file.ID.BlockStart.Unix(),
+			Volume:     file.ID.VolumeIndex,
+			Files:      file.AbsoluteFilepaths,
+		})
+	}
+	sort.Slice(result.Filesets, func(i, j int) bool {
+		if result.Filesets[i].BlockStart != result.Filesets[j].BlockStart {
+			return result.Filesets[i].BlockStart < result.Filesets[j].BlockStart
+		}
+		return result.Filesets[i].Volume < result.Filesets[j].Volume
+	})
+	return result, nil
+}
+
+// FilesetInfoRequest is a request for the info of the flushed data fileset
+// of a block, optionally validating its data against its digests.
+type FilesetInfoRequest struct {
+	Namespace  string `json:"namespace"`
+	Shard      uint32 `json:"shard"`
+	BlockStart int64  `json:"blockStart"`
+	Validate   bool   `json:"validate"`
+}
+
+// FilesetInfoResult is the info of a flushed data fileset.
+type FilesetInfoResult struct {
+	Found           bool                   `json:"found"`
+	BlockStart      int64                  `json:"blockStart"`
+	BlockSize       string                 `json:"blockSize,omitempty"`
+	Volume          int                    `json:"volume"`
+	MajorVersion    int64                  `json:"majorVersion,omitempty"`
+	Series          int64                  `json:"series"`
+	Summaries       int64                  `json:"summaries"`
+	BloomFilter     *FilesetBloomFilter    `json:"bloomFilter,omitempty"`
+	EncryptionKeyID string                 `json:"encryptionKeyID,omitempty"`
+	Digests         *fs.DataFileSetDigests `json:"digests,omitempty"`
+	Validated       bool                   `json:"validated"`
+	ValidationError string                 `json:"validationError,omitempty"`
+}
+
+// FilesetBloomFilter are the parameters of the bloom filter of a fileset.
+type FilesetBloomFilter struct {
+	NumElementsM int64 `json:"numElementsM"`
+	NumHashesK   int64 `json:"numHashesK"`
+}
+
+// FilesetInfo returns the series count, digests and info of the flushed
+// data fileset of a namespace, shard and block start specified in unix
+// seconds. Validating reads the whole fileset and reports a mismatch with
+// its digests as a validation error rather than failing the request.
+func (s *AdminService) FilesetInfo(
+	ctx thrift.Context,
+	req *FilesetInfoRequest,
+) (*FilesetInfoResult, error) {
+	namespace, err := s.filesetNamespace(req.Namespace)
+	if err != nil {
+		return nil, err
+	}
+
+	var (
+		fsOpts     = s.fsOptions()
+		blockStart = time.Unix(req.BlockStart, 0)
+		result     = &FilesetInfoResult{BlockStart: req.BlockStart}
+	)
+	file, ok, err := fs.FileSetAt(fsOpts.FilePathPrefix(), namespace,
+		req.Shard, blockStart)
+	if err != nil {
+		return nil, err
+	}
+	if !ok {
+		return result, nil
+	}
+	result.Found = true
+	result.Volume = file.ID.VolumeIndex
+
+	infoFiles := fs.ReadInfoFiles(fsOpts.FilePathPrefix(), namespace,
+		req.Shard, fsOpts.InfoReaderBufferSize(), fsOpts.DecodingOptions())
+	for _, infoFile := range infoFiles {
+		if err := infoFile.Err.Error(); err != nil {
+			continue
+		}
+		info := infoFile.Info
+		if !time.Unix(0, info.BlockStart).Equal(blockStart) {
+			continue
+		}
+		result.BlockSize = time.Duration(info.BlockSize).String()
+		result.MajorVersion = info.MajorVersion
+		result.Series = info.Entries
+		result.Summaries = info.Summaries.Summaries
+		result.BloomFilter = &FilesetBloomFilter{
+			NumElementsM: info.BloomFilter.NumElementsM,
+			NumHashesK:   info.BloomFilter.NumHashesK,
+		}
+		result.EncryptionKeyID = info.EncryptionKeyID
+		break
+	}
+
+	digests, err := fs.ReadDataFileSetDigests(fsOpts.FilePathPrefix(),
+		namespace, req.Shard, blockStart, fsOpts.InfoReaderBufferSize())
+	if err != nil {
+		return nil, err
+	}
+	result.Digests = &digests
+
+	if req.Validate {
+		if err := s.validateFileset(namespace, req.Shard, blockStart); err != nil {
+			result.ValidationError = err.Error()
+		}
+		result.Validated = true
+	}
+	return result, nil
+}
+
+func (s *AdminService) validateFileset(
+	namespace ident.ID,
+	shard uint32,
+	blockStart time.Time,
+) error {
+	reader, err := s.openFileset(namespace, shard, blockStart)
+	if err != nil {
+		return err
+	}
+	defer reader.Close()
+
+	// The data digest covers the whole data file so every series must be
+	// read before the data can be validated.
+	for {
+		id, tags, data, _, err := reader.Read()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return err
+		}
+		id.Finalize()
+		tags.Close()
+		data.Finalize()
+	}
+	return reader.Validate()
+}
+
+// FilesetSeriesRequest is a request for the raw encoded data of a series in
+// the flushed data fileset of a block.
+type FilesetSeriesRequest struct {
+	Namespace       string `json:"namespace"`
+	Shard           uint32 `json:"shard"`
+	BlockStart      int64  `json:"blockStart"`
+	ID              string `json:"id"`
+	Decode          bool   `json:"decode"`
+	DatapointsLimit int    `json:"datapointsLimit"`
+}
+
+// FilesetSeriesResult is the raw encoded data of a series in a fileset,
+// serialized as base64, along with its checksum and tags.
+type FilesetSeriesResult struct {
+	Found      bool               `json:"found"`
+	ID         string             `json:"id"`
+	Tags       map[string]string  `json:"tags,omitempty"`
+	Checksum   uint32             `json:"checksum"`
+	Data       []byte             `json:"data,omitempty"`
+	Datapoints []FilesetDatapoint `json:"datapoints,omitempty"`
+	Truncated  bool               `json:"truncated,omitempty"`
+	DecodeErr  string             `json:"decodeError,omitempty"`
+}
+
+// FilesetDatapoint is a datapoint decoded from the data of a series.
+type FilesetDatapoint struct {
+	TimestampNanos int64   `json:"timestampNanos"`
+	Value          float64 `json:"value"`
+	Unit           string  `json:"unit"`
+	Annotation     []byte  `json:"annotation,omitempty"`
+}
+
+// FilesetSeries returns the raw encoded data of a series in the flushed data
+// fileset of a namespace, shard and block start specified in unix seconds,
+// optionally decoding up to a limit of its datapoints.
+func (s *AdminService) FilesetSeries(
+	ctx thrift.Context,
+	req *FilesetSeriesRequest,
+) (*FilesetSeriesResult, error) {
+	namespace, err := s.filesetNamespace(req.Namespace)
+	if err != nil {
+		return nil, err
+	}
+	if req.ID == "" {
+		return nil, errIDRequired
+	}
+	if req.DatapointsLimit < 0 {
+		return nil, errNegativeDatapointsLimit
+	}
+
+	reader, err := s.openFileset(namespace, req.Shard, time.Unix(req.BlockStart, 0))
+	if err != nil {
+		return nil, err
+	}
+	defer reader.Close()
+
+	result := &FilesetSeriesResult{ID: req.ID}
+	for {
+		id, tags, data, checksum, err := reader.Read()
+		if err == io.EOF {
+			return result, nil
+		}
+		if err != nil {
+			return nil, err
+		}
+
+		found := id.String() == req.ID
+		if found {
+			result.Found = true
+			result.Checksum = checksum
+			result.Tags = make(map[string]string)
+			for tags.Next() {
+				tag := tags.Current()
+				result.Tags[tag.Name.String()] = tag.Value.String()
+			}
+			if err := tags.Err(); err != nil {
+				result.Tags = nil
+			}
+			data.IncRef()
+			result.Data = append([]byte(nil), data.Bytes()...)
+			data.DecRef()
+		}
+		id.Finalize()
+		tags.Close()
+		data.Finalize()
+
+		if found {
+			break
+		}
+	}
+
+	if req.Decode {
+		s.decodeFilesetSeries(result, req.DatapointsLimit)
+	}
+	return result, nil
+}
+
+func (s *AdminService) decodeFilesetSeries(
+	result *FilesetSeriesResult,
+	limit int,
+) {
+	if limit == 0 {
+		limit = defaultFilesetSeriesDatapointsLimit
+	}
+
+	iter := s.db.Options().ReaderIteratorPool().Get()
+	iter.Reset(bytes.NewReader(result.Data))
+	defer iter.Close()
+
+	for iter.Next() {
+		if len(result.Datapoints) == limit {
+			result.Truncated = true
+			return
+		}
+		dp, unit, annotation := iter.Current()
+		result.Datapoints = append(result.Datapoints, FilesetDatapoint{
+			TimestampNanos: dp.Timestamp.UnixNano(),
+			Value:          dp.Value,
+			Unit:           unit.String(),
+			Annotation:     append([]byte(nil), annotation...),
+		})
+	}
+	if err := iter.Err(); err != nil {
+		result.DecodeErr = err.Error()
+	}
+}
+
+func (s *AdminService) openFileset(
+	namespace ident.ID,
+	shard uint32,
+	blockStart time.Time,
+) (fs.DataFileSetReader, error) {
+	reader, err := fs.NewReader(s.db.Options().BytesPool(), s.fsOptions())
+	if err != nil {
+		return nil, err
+	}
+	err = reader.Open(fs.DataReaderOpenOptions{
+		Identifier: fs.FileSetFileIdentifier{
+			Namespace:  namespace,
+			Shard:      shard,
+			BlockStart: blockStart,
+		},
+		FileSetType: persist.FileSetFlushType,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("unable to open fileset: %v", err)
+	}
+	return reader, nil
+}
+
+func (s *AdminService) filesetNamespace(namespace string) (ident.ID, error) {
+	if namespace == "" {
+		return nil, errNamespaceRequired
+	}
+	id := ident.StringID(namespace)
+	if _, ok := s.db.Namespace(id); !ok {
+		return nil, xerrors.NewInvalidParamsError(
+			fmt.Errorf("unknown namespace: %s", namespace))
+	}
+	return id, nil
+}
+
+func (s *AdminService) fsOptions() fs.Options {
+	return s.db.Options().CommitLogOptions().FilesystemOptions()
+}
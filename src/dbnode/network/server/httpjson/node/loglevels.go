@@ -0,0 +1,150 @@
+// Copyright (c) 2018 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package node
+
+import (
+	"errors"
+	"time"
+
+	"github.com/m3db/m3/src/x/loglevel"
+	xerrors "github.com/m3db/m3x/errors"
+
+	"github.com/uber/tchannel-go/thrift"
+)
+
+const maxDebugTargetDuration = 24 * time.Hour
+
+var (
+	errLogLevelsNotEnabled = xerrors.NewInvalidParamsError(
+		errors.New("runtime log levels are not enabled"))
+	errDebugTargetDurationTooLong = xerrors.NewInvalidParamsError(
+		errors.New("debug target duration must be at most 24h"))
+)
+
+// LogLevelsResult is the log level of each subsystem and the debug targets
+// that have not expired.
+type LogLevelsResult struct {
+	Levels  map[string]string `json:"levels"`
+	Targets []loglevel.Target `json:"targets"`
+}
+
+// LogLevels returns the log level of each subsystem and the debug targets.
+func (s *AdminService) LogLevels(ctx thrift.Context) (*LogLevelsResult, error) {
+	registry, err := s.logLevels()
+	if err != nil {
+		return nil, err
+	}
+	return newLogLevelsResult(registry), nil
+}
+
+// SetLogLevelRequest is a request to set the log level of a subsystem, the
+// level of the subsystem is reset to the configured level if not set.
+type SetLogLevelRequest struct {
+	Subsystem string `json:"subsystem"`
+	Level     string `json:"level"`
+}
+
+// SetLogLevel sets the log level of one of the default, index, bootstrap,
+// flush and query subsystems until it is reset or the node restarts.
+func (s *AdminService) SetLogLevel(
+	ctx thrift.Context,
+	req *SetLogLevelRequest,
+) (*LogLevelsResult, error) {
+	registry, err := s.logLevels()
+	if err != nil {
+		return nil, err
+	}
+
+	if req.Level == "" {
+		err = registry.ResetLevel(req.Subsystem)
+	} else {
+		level, parseErr := loglevel.ParseLevel(req.Level)
+		if parseErr != nil {
+			return nil, xerrors.NewInvalidParamsError(parseErr)
+		}
+		err = registry.SetLevel(req.Subsystem, level)
+	}
+	if err != nil {
+		return nil, xerrors.NewInvalidParamsError(err)
+	}
+	return newLogLevelsResult(registry), nil
+}
+
+// AddDebugTargetRequest is a request to log the debug messages of a shard, a
+// series or a series of a shard for a duration such as "10m".
+type AddDebugTargetRequest struct {
+	Shard    *uint32 `json:"shard"`
+	SeriesID string  `json:"seriesID"`
+	Duration string  `json:"duration"`
+}
+
+// AddDebugTarget logs the debug messages of a shard or series regardless of
+// the log level of their subsystem until the target expires.
+func (s *AdminService) AddDebugTarget(
+	ctx thrift.Context,
+	req *AddDebugTargetRequest,
+) (*LogLevelsResult, error) {
+	registry, err := s.logLevels()
+	if err != nil {
+		return nil, err
+	}
+
+	duration, err := time.ParseDuration(req.Duration)
+	if err != nil {
+		return nil, xerrors.NewInvalidParamsError(err)
+	}
+	if duration > maxDebugTargetDuration {
+		return nil, errDebugTargetDurationTooLong
+	}
+	if _, err := registry.AddTarget(req.Shard, req.SeriesID, duration); err != nil {
+		return nil, xerrors.NewInvalidParamsError(err)
+	}
+	return newLogLevelsResult(registry), nil
+}
+
+// ClearDebugTargets removes all debug targets before they expire.
+func (s *AdminService) ClearDebugTargets(ctx thrift.Context) (*LogLevelsResult, error) {
+	registry, err := s.logLevels()
+	if err != nil {
+		return nil, err
+	}
+	registry.ClearTargets()
+	return newLogLevelsResult(registry), nil
+}
+
+func (s *AdminService) logLevels() (*loglevel.Registry, error) {
+	registry := s.db.Options().LogLevels()
+	if registry == nil {
+		return nil, errLogLevelsNotEnabled
+	}
+	return registry, nil
+}
+
+func newLogLevelsResult(registry *loglevel.Registry) *LogLevelsResult {
+	result := &LogLevelsResult{
+		Levels:  make(map[string]string, len(loglevel.Subsystems)),
+		Targets: registry.Targets(),
+	}
+	for subsystem, level := range registry.Levels() {
+		result.Levels[subsystem] = level.String()
+	}
+	return result
+}
@@ -0,0 +1,47 @@
+// Copyright (c) 2018 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package node
+
+import (
+	"errors"
+
+	"github.com/m3db/m3/src/x/resource"
+	xerrors "github.com/m3db/m3x/errors"
+
+	"github.com/uber/tchannel-go/thrift"
+)
+
+var errResourceUsageNotEnabled = xerrors.NewInvalidParamsError(
+	errors.New("resource usage reporting is not enabled"))
+
+// ResourceUsage returns the memory held by each pool, the open file
+// descriptors by category and the goroutines by subsystem of the node.
+func (s *AdminService) ResourceUsage(ctx thrift.Context) (*resource.Usage, error) {
+	reporter := s.db.Options().ResourceReporter()
+	if reporter == nil {
+		return nil, errResourceUsageNotEnabled
+	}
+	usage, err := reporter.Usage()
+	if err != nil {
+		return nil, err
+	}
+	return &usage, nil
+}
@@ -69,6 +69,9 @@ func (s *server) ListenAndServe() (ns.Close, error) {
 	if err := httpjson.RegisterHandlers(mux, ttnode.NewService(s.db, s.ttopts), s.opts); err != nil {
 		return nil, err
 	}
+	if err := httpjson.RegisterHandlers(mux, NewAdminService(s.db), s.opts); err != nil {
+		return nil, err
+	}
 
 	listener, err := net.Listen("tcp", s.address)
 	if err != nil {
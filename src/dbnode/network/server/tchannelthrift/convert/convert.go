@@ -223,6 +223,16 @@ func FromRPCFetchTaggedRequest(
 	if l := req.Limit; l != nil {
 		opts.Limit = int(*l)
 	}
+	if l := req.PageSize; l != nil {
+		opts.PageSize = int(*l)
+		opts.PageToken = index.PageToken(req.PageToken)
+	}
+	if l := req.DocsLimit; l != nil {
+		opts.DocsLimit = int(*l)
+	}
+	if l := req.BytesLimit; l != nil {
+		opts.BytesLimit = int(*l)
+	}
 
 	q, err := idx.Unmarshal(req.Query)
 	if err != nil {
@@ -273,10 +283,54 @@ func ToRPCFetchTaggedRequest(
 		l := int64(opts.Limit)
 		request.Limit = &l
 	}
+	if opts.PageSize > 0 {
+		l := int64(opts.PageSize)
+		request.PageSize = &l
+		request.PageToken = opts.PageToken
+	}
+	if opts.DocsLimit > 0 {
+		l := int64(opts.DocsLimit)
+		request.DocsLimit = &l
+	}
+	if opts.BytesLimit > 0 {
+		l := int64(opts.BytesLimit)
+		request.BytesLimit = &l
+	}
 
 	return request, nil
 }
 
+// ToRPCQueryLimitType converts the limit that stopped a query early into the
+// rpc limit type, it returns nil if no limit was exceeded.
+func ToRPCQueryLimitType(limit index.QueryLimitType) *rpc.QueryLimitType {
+	switch limit {
+	case index.QueryLimitSeries:
+		return rpc.QueryLimitTypePtr(rpc.QueryLimitType_SERIES)
+	case index.QueryLimitDocs:
+		return rpc.QueryLimitTypePtr(rpc.QueryLimitType_DOCS)
+	case index.QueryLimitBytes:
+		return rpc.QueryLimitTypePtr(rpc.QueryLimitType_BYTES)
+	}
+	return nil
+}
+
+// FromRPCQueryLimitType converts the rpc limit type into the limit that
+// stopped a query early, it returns QueryLimitNone if the limit is not set.
+func FromRPCQueryLimitType(limit *rpc.QueryLimitType) index.QueryLimitType {
+	if limit == nil {
+		return index.QueryLimitNone
+	}
+	switch *limit {
+	case rpc.QueryLimitType_SERIES:
+		return index.QueryLimitSeries
+	case rpc.QueryLimitType_DOCS:
+		return index.QueryLimitDocs
+	case rpc.QueryLimitType_BYTES:
+		return index.QueryLimitBytes
+	}
+	return index.QueryLimitNone
+}
+
 // ToTagsIter returns a tag iterator over the given request.
 func ToTagsIter(r *rpc.WriteTaggedRequest) (ident.TagIterator, error) {
 	if r == nil {
@@ -92,15 +92,28 @@ func TestConvertFetchTaggedRequest(t *testing.T) {
 		StartInclusive: time.Now().Add(-900 * time.Hour),
 		EndExclusive:   time.Now(),
 		Limit:          10,
+		PageSize:       5,
+		PageToken:      index.NewPageToken([]byte("foo")),
+		DocsLimit:      100,
+		BytesLimit:     1024,
 	}
 	fetchData := true
-	var limit int64 = 10
+	var (
+		limit      int64 = 10
+		pageSize   int64 = 5
+		docsLimit  int64 = 100
+		bytesLimit int64 = 1024
+	)
 	requestSkeleton := &rpc.FetchTaggedRequest{
 		NameSpace:  ns.Bytes(),
 		RangeStart: mustToRpcTime(t, opts.StartInclusive),
 		RangeEnd:   mustToRpcTime(t, opts.EndExclusive),
 		FetchData:  fetchData,
 		Limit:      &limit,
+		PageSize:   &pageSize,
+		PageToken:  index.NewPageToken([]byte("foo")),
+		DocsLimit:  &docsLimit,
+		BytesLimit: &bytesLimit,
 	}
 	requireEqual := func(a, b interface{}) {
 		d := cmp.Diff(a, b)
@@ -150,6 +163,20 @@ func TestConvertFetchTaggedRequest(t *testing.T) {
 	}
 }
 
+func TestConvertQueryLimitType(t *testing.T) {
+	assert.Nil(t, convert.ToRPCQueryLimitType(index.QueryLimitNone))
+	assert.Equal(t, index.QueryLimitNone, convert.FromRPCQueryLimitType(nil))
+	for _, limit := range []index.QueryLimitType{
+		index.QueryLimitSeries,
+		index.QueryLimitDocs,
+		index.QueryLimitBytes,
+	} {
+		rpcLimit := convert.ToRPCQueryLimitType(limit)
+		require.NotNil(t, rpcLimit)
+		assert.Equal(t, limit, convert.FromRPCQueryLimitType(rpcLimit))
+	}
+}
+
 type testPools struct {
 	id      ident.Pool
 	wrapper xpool.CheckedBytesWrapperPool
@@ -36,8 +36,10 @@ import (
 	"github.com/m3db/m3/src/dbnode/storage"
 	"github.com/m3db/m3/src/dbnode/storage/block"
 	"github.com/m3db/m3/src/dbnode/storage/index"
+	"github.com/m3db/m3/src/dbnode/storage/repair/hashtree"
 	"github.com/m3db/m3/src/dbnode/x/xio"
 	"github.com/m3db/m3/src/dbnode/x/xpool"
+	"github.com/m3db/m3/src/x/loglevel"
 	"github.com/m3db/m3x/checked"
 	"github.com/m3db/m3x/context"
 	xerrors "github.com/m3db/m3x/errors"
@@ -49,6 +51,8 @@ import (
 	xtime "github.com/m3db/m3x/time"
 
 	apachethrift "github.com/apache/thrift/lib/go/thrift"
+	opentracing "github.com/opentracing/opentracing-go"
+	"github.com/opentracing/opentracing-go/ext"
 	"github.com/uber-go/tally"
 	"github.com/uber/tchannel-go/thrift"
 )
@@ -63,6 +67,12 @@ var (
 const (
 	initSegmentArrayPoolLength  = 4
 	maxSegmentArrayPooledLength = 32
+
+	// fetchBlocksHashTreePageLimit is the number of series metadata fetched
+	// per page when building the hash trees of a shard
+	fetchBlocksHashTreePageLimit = 4096
+
+	writeTaggedBatchRawSpanName = "m3db.node.write-tagged-batch"
 )
 
 var (
@@ -83,12 +93,14 @@ type serviceMetrics struct {
 	writeTagged         instrument.MethodMetrics
 	fetchBlocks         instrument.MethodMetrics
 	fetchBlocksMetadata instrument.MethodMetrics
+	fetchBlocksHashTree instrument.MethodMetrics
 	repair              instrument.MethodMetrics
 	truncate            instrument.MethodMetrics
 	fetchBatchRaw       instrument.BatchMethodMetrics
 	writeBatchRaw       instrument.BatchMethodMetrics
 	writeTaggedBatchRaw instrument.BatchMethodMetrics
 	overloadRejected    tally.Counter
+	queryLimitExceeded  map[index.QueryLimitType]tally.Counter
 }
 
 func newServiceMetrics(scope tally.Scope, samplingRate float64) serviceMetrics {
@@ -99,12 +111,35 @@ func newServiceMetrics(scope tally.Scope, samplingRate float64) serviceMetrics {
 		writeTagged:         instrument.NewMethodMetrics(scope, "writeTagged", samplingRate),
 		fetchBlocks:         instrument.NewMethodMetrics(scope, "fetchBlocks", samplingRate),
 		fetchBlocksMetadata: instrument.NewMethodMetrics(scope, "fetchBlocksMetadata", samplingRate),
+		fetchBlocksHashTree: instrument.NewMethodMetrics(scope, "fetchBlocksHashTree", samplingRate),
 		repair:              instrument.NewMethodMetrics(scope, "repair", samplingRate),
 		truncate:            instrument.NewMethodMetrics(scope, "truncate", samplingRate),
 		fetchBatchRaw:       instrument.NewBatchMethodMetrics(scope, "fetchBatchRaw", samplingRate),
 		writeBatchRaw:       instrument.NewBatchMethodMetrics(scope, "writeBatchRaw", samplingRate),
 		writeTaggedBatchRaw: instrument.NewBatchMethodMetrics(scope, "writeTaggedBatchRaw", samplingRate),
 		overloadRejected:    scope.Counter("overload-rejected"),
+		queryLimitExceeded:  newQueryLimitExceededMetrics(scope),
+	}
+}
+
+func newQueryLimitExceededMetrics(scope tally.Scope) map[index.QueryLimitType]tally.Counter {
+	limits := []index.QueryLimitType{
+		index.QueryLimitSeries,
+		index.QueryLimitDocs,
+		index.QueryLimitBytes,
+	}
+	counters := make(map[index.QueryLimitType]tally.Counter, len(limits))
+	for _, limit := range limits {
+		counters[limit] = scope.Tagged(map[string]string{
+			"limit": limit.String(),
+		}).Counter("query-limit-exceeded")
+	}
+	return counters
+}
+
+func (m serviceMetrics) reportQueryLimitExceeded(limit index.QueryLimitType) {
+	if counter, ok := m.queryLimitExceeded[limit]; ok {
+		counter.Inc(1)
 	}
 }
 
@@ -181,7 +216,7 @@ func NewService(db storage.Database, opts tchannelthrift.Options) rpc.TChanNode
 
 	s := &service{
 		db:      db,
-		logger:  iopts.Logger(),
+		logger:  loglevel.ForSubsystem(iopts.Logger(), loglevel.SubsystemQuery),
 		opts:    opts,
 		nowFn:   db.Options().ClockOptions().NowFn(),
 		metrics: newServiceMetrics(scope, iopts.MetricsSamplingRate()),
@@ -265,6 +300,7 @@ func (s *service) Query(tctx thrift.Context, req *rpc.QueryRequest) (*rpc.QueryR
 	if err != nil {
 		return nil, convert.ToRPCError(err)
 	}
+	s.metrics.reportQueryLimitExceeded(queryResult.ExceededLimit)
 
 	result := &rpc.QueryResult_{
 		Results:    make([]*rpc.QueryResultElement, 0, queryResult.Results.Map().Len()),
@@ -391,11 +427,14 @@ func (s *service) FetchTagged(tctx thrift.Context, req *rpc.FetchTaggedRequest)
 	queryResult, err := s.db.QueryIDs(ctx, ns, query, opts)
 	if err != nil {
 		s.metrics.fetchTagged.ReportError(s.nowFn().Sub(callStart))
-		return nil, tterrors.NewInternalError(err)
+		return nil, convert.ToRPCError(err)
 	}
+	s.metrics.reportQueryLimitExceeded(queryResult.ExceededLimit)
 
 	response := &rpc.FetchTaggedResult_{
-		Exhaustive: queryResult.Exhaustive,
+		Exhaustive:    queryResult.Exhaustive,
+		NextPageToken: queryResult.NextPageToken,
+		ExceededLimit: convert.ToRPCQueryLimitType(queryResult.ExceededLimit),
 	}
 	results := queryResult.Results
 	nsID := results.Namespace()
@@ -717,6 +756,17 @@ func (s *service) FetchBlocksMetadataRawV2(tctx thrift.Context, req *rpc.FetchBl
 		opts.IncludeLastRead = *req.IncludeLastRead
 	}
 
+	// Only return the series in the requested hash tree buckets if set
+	var filter *hashtree.BucketFilter
+	if req.HashTreeDepth != nil {
+		buckets := make([]int, 0, len(req.HashTreeBuckets))
+		for _, b := range req.HashTreeBuckets {
+			buckets = append(buckets, int(b))
+		}
+		bucketFilter := hashtree.NewBucketFilter(int(*req.HashTreeDepth), buckets)
+		filter = &bucketFilter
+	}
+
 	var (
 		nsID  = s.newID(ctx, req.NameSpace)
 		start = time.Unix(0, req.RangeStart)
@@ -730,7 +780,7 @@ func (s *service) FetchBlocksMetadataRawV2(tctx thrift.Context, req *rpc.FetchBl
 
 	ctx.RegisterCloser(fetchedMetadata)
 
-	result, err := s.getFetchBlocksMetadataRawV2Result(ctx, nextPageToken, opts, fetchedMetadata)
+	result, err := s.getFetchBlocksMetadataRawV2Result(ctx, nextPageToken, opts, filter, fetchedMetadata)
 	if err != nil {
 		return nil, convert.ToRPCError(err)
 	}
@@ -743,9 +793,10 @@ func (s *service) getFetchBlocksMetadataRawV2Result(
 	ctx context.Context,
 	nextPageToken storage.PageToken,
 	opts block.FetchBlocksMetadataOptions,
+	filter *hashtree.BucketFilter,
 	results block.FetchBlocksMetadataResults,
 ) (*rpc.FetchBlocksMetadataRawV2Result_, error) {
-	elements, err := s.getBlocksMetadataV2FromResult(ctx, opts, results)
+	elements, err := s.getBlocksMetadataV2FromResult(ctx, opts, filter, results)
 	if err != nil {
 		return nil, err
 	}
@@ -759,10 +810,15 @@ func (s *service) getFetchBlocksMetadataRawV2Result(
 func (s *service) getBlocksMetadataV2FromResult(
 	ctx context.Context,
 	opts block.FetchBlocksMetadataOptions,
+	filter *hashtree.BucketFilter,
 	results block.FetchBlocksMetadataResults,
 ) ([]*rpc.BlockMetadataV2, error) {
 	blocks := s.pools.blockMetadataV2Slice.Get()
 	for _, fetchedMetadata := range results.Results() {
+		if filter != nil && !filter.Contains(fetchedMetadata.ID.Bytes()) {
+			continue
+		}
+
 		fetchedMetadataBlocks := fetchedMetadata.Blocks.Results()
 
 		var (
@@ -823,6 +879,61 @@ func (s *service) getBlocksMetadataV2FromResult(
 	return blocks, nil
 }
 
+func (s *service) FetchBlocksHashTreeRaw(tctx thrift.Context, req *rpc.FetchBlocksHashTreeRawRequest) (*rpc.FetchBlocksHashTreeRawResult_, error) {
+	if s.db.IsOverloaded() {
+		s.metrics.overloadRejected.Inc(1)
+		return nil, tterrors.NewInternalError(errServerIsOverloaded)
+	}
+
+	var err error
+	callStart := s.nowFn()
+	defer func() {
+		s.metrics.fetchBlocksHashTree.ReportSuccessOrError(err, s.nowFn().Sub(callStart))
+	}()
+
+	trees, err := hashtree.NewBlockTrees(int(req.Depth))
+	if err != nil {
+		return nil, tterrors.NewBadRequestError(err)
+	}
+
+	var (
+		ctx       = tchannelthrift.Context(tctx)
+		nsID      = s.newID(ctx, req.NameSpace)
+		start     = time.Unix(0, req.RangeStart)
+		end       = time.Unix(0, req.RangeEnd)
+		pageToken storage.PageToken
+		opts      = block.FetchBlocksMetadataOptions{
+			IncludeSizes:     true,
+			IncludeChecksums: true,
+		}
+	)
+	for {
+		var fetchedMetadata block.FetchBlocksMetadataResults
+		fetchedMetadata, pageToken, err = s.db.FetchBlocksMetadataV2(ctx, nsID,
+			uint32(req.Shard), start, end, fetchBlocksHashTreePageLimit, pageToken, opts)
+		if err != nil {
+			return nil, convert.ToRPCError(err)
+		}
+
+		trees.AddBlocksMetadata(fetchedMetadata)
+		fetchedMetadata.Close()
+
+		if pageToken == nil {
+			break
+		}
+	}
+
+	result := rpc.NewFetchBlocksHashTreeRawResult_()
+	for _, blockStart := range trees.Starts() {
+		tree, _ := trees.Tree(blockStart)
+		result.Elements = append(result.Elements, &rpc.BlockHashTree{
+			Start: blockStart.UnixNano(),
+			Nodes: tree.Nodes(),
+		})
+	}
+	return result, nil
+}
+
 func (s *service) Write(tctx thrift.Context, req *rpc.WriteRequest) error {
 	callStart := s.nowFn()
 	ctx := tchannelthrift.Context(tctx)
@@ -922,60 +1033,54 @@ func (s *service) WriteBatchRaw(tctx thrift.Context, req *rpc.WriteBatchRawReque
 	nsID := s.newPooledID(ctx, req.NameSpace, pooledReq)
 
 	var (
-		errs               []*rpc.WriteBatchRawError
-		success            int
-		retryableErrors    int
-		nonRetryableErrors int
+		errs    writeBatchRawErrors
+		writes  = make([]storage.BatchWrite, 0, len(req.Elements))
+		indexes = make([]int, 0, len(req.Elements))
 	)
 	for i, elem := range req.Elements {
 		unit, unitErr := convert.ToUnit(elem.Datapoint.TimestampTimeType)
 		if unitErr != nil {
-			nonRetryableErrors++
-			errs = append(errs, tterrors.NewBadRequestWriteBatchRawError(i, unitErr))
+			errs.addBadRequest(i, unitErr)
 			continue
 		}
 
 		d, err := unit.Value()
 		if err != nil {
-			nonRetryableErrors++
-			errs = append(errs, tterrors.NewBadRequestWriteBatchRawError(i, err))
+			errs.addBadRequest(i, err)
 			continue
 		}
 
-		seriesID := s.newPooledID(ctx, elem.ID, pooledReq)
-		if err = s.db.Write(
-			ctx, nsID, seriesID,
-			xtime.FromNormalizedTime(elem.Datapoint.Timestamp, d),
-			elem.Datapoint.Value, unit, elem.Datapoint.Annotation,
-		); err != nil && xerrors.IsInvalidParams(err) {
-			nonRetryableErrors++
-			errs = append(errs, tterrors.NewBadRequestWriteBatchRawError(i, err))
-		} else if err != nil {
-			retryableErrors++
-			errs = append(errs, tterrors.NewWriteBatchRawError(i, err))
-		} else {
-			success++
-		}
+		writes = append(writes, storage.BatchWrite{
+			ID:         s.newPooledID(ctx, elem.ID, pooledReq),
+			Timestamp:  xtime.FromNormalizedTime(elem.Datapoint.Timestamp, d),
+			Value:      elem.Datapoint.Value,
+			Unit:       unit,
+			Annotation: elem.Datapoint.Annotation,
+		})
+		indexes = append(indexes, i)
 	}
 
+	success := s.writeBatch(ctx, nsID, writes, indexes, &errs, s.db.WriteBatch)
+
 	s.metrics.writeBatchRaw.ReportSuccess(success)
-	s.metrics.writeBatchRaw.ReportRetryableErrors(retryableErrors)
-	s.metrics.writeBatchRaw.ReportNonRetryableErrors(nonRetryableErrors)
+	s.metrics.writeBatchRaw.ReportRetryableErrors(errs.retryable)
+	s.metrics.writeBatchRaw.ReportNonRetryableErrors(errs.nonRetryable)
 	s.metrics.writeBatchRaw.ReportLatency(s.nowFn().Sub(callStart))
 
-	if len(errs) > 0 {
-		batchErrs := rpc.NewWriteBatchRawErrors()
-		batchErrs.Errors = errs
-		return batchErrs
-	}
-
-	return nil
+	return errs.finalError()
 }
 
 func (s *service) WriteTaggedBatchRaw(tctx thrift.Context, req *rpc.WriteTaggedBatchRawRequest) error {
 	callStart := s.nowFn()
 	ctx := tchannelthrift.Context(tctx)
 
+	// NB: The span continues the trace of the client batch write, if any,
+	// which tchannel propagates in the call context.
+	span, _ := opentracing.StartSpanFromContext(tctx, writeTaggedBatchRawSpanName)
+	span.SetTag("namespace", string(req.NameSpace))
+	span.SetTag("batch.size", len(req.Elements))
+	defer span.Finish()
+
 	// NB(r): Use the pooled request tracking to return thrift alloc'd bytes
 	// to the thrift bytes pool and to return ident.ID wrappers to a pool for
 	// reuse. We also reduce contention on pools by getting one per batch request
@@ -987,61 +1092,120 @@ func (s *service) WriteTaggedBatchRaw(tctx thrift.Context, req *rpc.WriteTaggedB
 	nsID := s.newPooledID(ctx, req.NameSpace, pooledReq)
 
 	var (
-		errs               []*rpc.WriteBatchRawError
-		success            int
-		retryableErrors    int
-		nonRetryableErrors int
+		errs    writeBatchRawErrors
+		writes  = make([]storage.BatchWrite, 0, len(req.Elements))
+		indexes = make([]int, 0, len(req.Elements))
 	)
 	for i, elem := range req.Elements {
 		unit, unitErr := convert.ToUnit(elem.Datapoint.TimestampTimeType)
 		if unitErr != nil {
-			nonRetryableErrors++
-			errs = append(errs, tterrors.NewBadRequestWriteBatchRawError(i, unitErr))
+			errs.addBadRequest(i, unitErr)
 			continue
 		}
 
 		d, err := unit.Value()
 		if err != nil {
-			nonRetryableErrors++
-			errs = append(errs, tterrors.NewBadRequestWriteBatchRawError(i, err))
+			errs.addBadRequest(i, err)
 			continue
 		}
 
 		dec, err := s.newPooledTagsDecoder(ctx, elem.EncodedTags, pooledReq)
 		if err != nil {
-			nonRetryableErrors++
-			errs = append(errs, tterrors.NewBadRequestWriteBatchRawError(i, err))
+			errs.addBadRequest(i, err)
 			continue
 		}
 
-		seriesID := s.newPooledID(ctx, elem.ID, pooledReq)
-		if err = s.db.WriteTagged(
-			ctx, nsID, seriesID, dec,
-			xtime.FromNormalizedTime(elem.Datapoint.Timestamp, d),
-			elem.Datapoint.Value, unit, elem.Datapoint.Annotation,
-		); err != nil && xerrors.IsInvalidParams(err) {
-			nonRetryableErrors++
-			errs = append(errs, tterrors.NewBadRequestWriteBatchRawError(i, err))
-		} else if err != nil {
-			retryableErrors++
-			errs = append(errs, tterrors.NewWriteBatchRawError(i, err))
-		} else {
-			success++
-		}
+		writes = append(writes, storage.BatchWrite{
+			ID:         s.newPooledID(ctx, elem.ID, pooledReq),
+			Tags:       dec,
+			Timestamp:  xtime.FromNormalizedTime(elem.Datapoint.Timestamp, d),
+			Value:      elem.Datapoint.Value,
+			Unit:       unit,
+			Annotation: elem.Datapoint.Annotation,
+		})
+		indexes = append(indexes, i)
 	}
 
+	success := s.writeBatch(ctx, nsID, writes, indexes, &errs, s.db.WriteTaggedBatch)
+
 	s.metrics.writeTaggedBatchRaw.ReportSuccess(success)
-	s.metrics.writeTaggedBatchRaw.ReportRetryableErrors(retryableErrors)
-	s.metrics.writeTaggedBatchRaw.ReportNonRetryableErrors(nonRetryableErrors)
+	s.metrics.writeTaggedBatchRaw.ReportRetryableErrors(errs.retryable)
+	s.metrics.writeTaggedBatchRaw.ReportNonRetryableErrors(errs.nonRetryable)
 	s.metrics.writeTaggedBatchRaw.ReportLatency(s.nowFn().Sub(callStart))
 
-	if len(errs) > 0 {
-		batchErrs := rpc.NewWriteBatchRawErrors()
-		batchErrs.Errors = errs
-		return batchErrs
+	if len(errs.errs) > 0 {
+		ext.Error.Set(span, true)
+		span.SetTag("errors", len(errs.errs))
 	}
 
-	return nil
+	return errs.finalError()
+}
+
+type writeBatchFn func(
+	ctx context.Context,
+	namespace ident.ID,
+	writes []storage.BatchWrite,
+	errFn storage.BatchWriteErrorFn,
+) error
+
+// writeBatch performs the valid writes of a batch request, where indexes maps
+// each write to its element in the request, and returns the number of writes
+// that succeeded.
+func (s *service) writeBatch(
+	ctx context.Context,
+	nsID ident.ID,
+	writes []storage.BatchWrite,
+	indexes []int,
+	errs *writeBatchRawErrors,
+	fn writeBatchFn,
+) int {
+	if len(writes) == 0 {
+		return 0
+	}
+
+	failed := 0
+	err := fn(ctx, nsID, writes, func(index int, err error) {
+		errs.add(indexes[index], err)
+		failed++
+	})
+	if err != nil {
+		// The batch as a whole was rejected, fail each of its writes
+		for _, i := range indexes {
+			errs.add(i, err)
+		}
+		return 0
+	}
+	return len(writes) - failed
+}
+
+// writeBatchRawErrors accumulates the errors of the elements of a batch write.
+type writeBatchRawErrors struct {
+	errs         []*rpc.WriteBatchRawError
+	retryable    int
+	nonRetryable int
+}
+
+func (e *writeBatchRawErrors) add(index int, err error) {
+	if xerrors.IsInvalidParams(err) {
+		e.addBadRequest(index, err)
+		return
+	}
+	e.retryable++
+	e.errs = append(e.errs, tterrors.NewWriteBatchRawError(index, err))
+}
+
+func (e *writeBatchRawErrors) addBadRequest(index int, err error) {
+	e.nonRetryable++
+	e.errs = append(e.errs, tterrors.NewBadRequestWriteBatchRawError(index, err))
+}
+
+func (e *writeBatchRawErrors) finalError() error {
+	if len(e.errs) == 0 {
+		return nil
+	}
+	batchErrs := rpc.NewWriteBatchRawErrors()
+	batchErrs.Errors = e.errs
+	return batchErrs
 }
 
 func (s *service) Repair(tctx thrift.Context) error {
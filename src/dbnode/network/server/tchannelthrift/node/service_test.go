@@ -22,6 +22,7 @@ package node
 
 import (
 	"bytes"
+	"errors"
 	"fmt"
 	"sort"
 	"testing"
@@ -38,10 +39,12 @@ import (
 	"github.com/m3db/m3/src/dbnode/storage/block"
 	"github.com/m3db/m3/src/dbnode/storage/index"
 	"github.com/m3db/m3/src/dbnode/storage/namespace"
+	"github.com/m3db/m3/src/dbnode/storage/repair/hashtree"
 	"github.com/m3db/m3/src/dbnode/ts"
 	"github.com/m3db/m3/src/dbnode/x/xio"
 	"github.com/m3db/m3/src/m3ninx/idx"
 	"github.com/m3db/m3x/checked"
+	"github.com/m3db/m3x/context"
 	"github.com/m3db/m3x/ident"
 	xtime "github.com/m3db/m3x/time"
 
@@ -984,6 +987,158 @@ func TestServiceFetchBlocksMetadataEndpointV2RawIsOverloaded(t *testing.T) {
 	require.Equal(t, tterrors.NewInternalError(errServerIsOverloaded), err)
 }
 
+func TestServiceFetchBlocksMetadataEndpointV2RawHashTreeBuckets(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	// Setup mock db / service / context
+	mockDB := storage.NewMockDatabase(ctrl)
+	mockDB.EXPECT().Options().Return(testStorageOpts).AnyTimes()
+	mockDB.EXPECT().IsOverloaded().Return(false)
+	service := NewService(mockDB, nil).(*service)
+	tctx, _ := tchannelthrift.NewContext(time.Minute)
+	ctx := tchannelthrift.Context(tctx)
+	defer ctx.Close()
+
+	var (
+		start = time.Now().Truncate(time.Hour)
+		end   = start.Add(2 * time.Hour)
+		limit = int64(10)
+		nsID  = "metrics"
+		depth = int32(4)
+		ids   = []string{"foo", "bar", "baz", "qux"}
+	)
+
+	mockResult := block.NewFetchBlocksMetadataResults()
+	for _, id := range ids {
+		blocks := block.NewFetchBlockMetadataResults()
+		blocks.Add(block.FetchBlockMetadataResult{Start: start, Size: 1})
+		mockResult.Add(block.NewFetchBlocksMetadataResult(ident.StringID(id), nil, blocks))
+	}
+
+	mockDB.EXPECT().
+		FetchBlocksMetadataV2(ctx, ident.NewIDMatcher(nsID), uint32(0), start, end,
+			limit, nil, block.FetchBlocksMetadataOptions{}).
+		Return(mockResult, nil, nil)
+
+	// Request only the bucket of the first series
+	bucket := hashtree.Bucket([]byte(ids[0]), int(depth))
+	r, err := service.FetchBlocksMetadataRawV2(tctx, &rpc.FetchBlocksMetadataRawV2Request{
+		NameSpace:       []byte(nsID),
+		Shard:           0,
+		RangeStart:      start.UnixNano(),
+		RangeEnd:        end.UnixNano(),
+		Limit:           limit,
+		HashTreeDepth:   &depth,
+		HashTreeBuckets: []int32{int32(bucket)},
+	})
+	require.NoError(t, err)
+
+	var expected []string
+	for _, id := range ids {
+		if hashtree.Bucket([]byte(id), int(depth)) == bucket {
+			expected = append(expected, id)
+		}
+	}
+	var actual []string
+	for _, elem := range r.Elements {
+		actual = append(actual, string(elem.ID))
+	}
+	require.Equal(t, expected, actual)
+}
+
+func TestServiceFetchBlocksHashTreeRaw(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	// Setup mock db / service / context
+	mockDB := storage.NewMockDatabase(ctrl)
+	mockDB.EXPECT().Options().Return(testStorageOpts).AnyTimes()
+	mockDB.EXPECT().IsOverloaded().Return(false)
+	service := NewService(mockDB, nil).(*service)
+	tctx, _ := tchannelthrift.NewContext(time.Minute)
+	ctx := tchannelthrift.Context(tctx)
+	defer ctx.Close()
+
+	var (
+		start         = time.Now().Truncate(time.Hour)
+		end           = start.Add(4 * time.Hour)
+		nsID          = "metrics"
+		depth         = 3
+		nextPageToken = []byte("page_next")
+		opts          = block.FetchBlocksMetadataOptions{
+			IncludeSizes:     true,
+			IncludeChecksums: true,
+		}
+	)
+
+	// Return the series across two pages
+	expected, err := hashtree.NewBlockTrees(depth)
+	require.NoError(t, err)
+	newPage := func(ids ...string) block.FetchBlocksMetadataResults {
+		result := block.NewFetchBlocksMetadataResults()
+		for i, id := range ids {
+			checksum := uint32(i)
+			blocks := block.NewFetchBlockMetadataResults()
+			blocks.Add(block.FetchBlockMetadataResult{Start: start, Size: 16, Checksum: &checksum})
+			blocks.Add(block.FetchBlockMetadataResult{Start: start.Add(2 * time.Hour), Size: 32})
+			result.Add(block.NewFetchBlocksMetadataResult(ident.StringID(id), nil, blocks))
+		}
+		expected.AddBlocksMetadata(result)
+		return result
+	}
+	firstPage := newPage("foo", "bar")
+	secondPage := newPage("baz")
+
+	gomock.InOrder(
+		mockDB.EXPECT().
+			FetchBlocksMetadataV2(ctx, ident.NewIDMatcher(nsID), uint32(0), start, end,
+				int64(fetchBlocksHashTreePageLimit), nil, opts).
+			Return(firstPage, nextPageToken, nil),
+		mockDB.EXPECT().
+			FetchBlocksMetadataV2(ctx, ident.NewIDMatcher(nsID), uint32(0), start, end,
+				int64(fetchBlocksHashTreePageLimit), storage.PageToken(nextPageToken), opts).
+			Return(secondPage, nil, nil),
+	)
+
+	r, err := service.FetchBlocksHashTreeRaw(tctx, &rpc.FetchBlocksHashTreeRawRequest{
+		NameSpace:  []byte(nsID),
+		Shard:      0,
+		RangeStart: start.UnixNano(),
+		RangeEnd:   end.UnixNano(),
+		Depth:      int32(depth),
+	})
+	require.NoError(t, err)
+
+	require.Equal(t, 2, len(r.Elements))
+	for _, elem := range r.Elements {
+		tree, ok := expected.Tree(time.Unix(0, elem.Start))
+		require.True(t, ok)
+		assert.Equal(t, tree.Nodes(), elem.Nodes)
+	}
+}
+
+func TestServiceFetchBlocksHashTreeRawInvalidDepth(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockDB := storage.NewMockDatabase(ctrl)
+	mockDB.EXPECT().Options().Return(testStorageOpts).AnyTimes()
+	mockDB.EXPECT().IsOverloaded().Return(false)
+	service := NewService(mockDB, nil).(*service)
+	tctx, _ := tchannelthrift.NewContext(time.Minute)
+	ctx := tchannelthrift.Context(tctx)
+	defer ctx.Close()
+
+	_, err := service.FetchBlocksHashTreeRaw(tctx, &rpc.FetchBlocksHashTreeRawRequest{
+		NameSpace: []byte("metrics"),
+		Depth:     hashtree.MaxDepth + 1,
+	})
+	rpcErr, ok := err.(*rpc.Error)
+	require.True(t, ok)
+	require.True(t, tterrors.IsBadRequestError(rpcErr))
+}
+
 func TestServiceFetchTagged(t *testing.T) {
 	ctrl := gomock.NewController(t)
 	defer ctrl.Finish()
@@ -1400,11 +1555,24 @@ func TestServiceWriteBatchRaw(t *testing.T) {
 		{"foo", time.Now().Truncate(time.Second), 12.34},
 		{"bar", time.Now().Truncate(time.Second), 42.42},
 	}
-	for _, w := range values {
-		mockDB.EXPECT().
-			Write(ctx, ident.NewIDMatcher(nsID), ident.NewIDMatcher(w.id), w.t, w.v, xtime.Second, nil).
-			Return(nil)
-	}
+	mockDB.EXPECT().
+		WriteBatch(ctx, ident.NewIDMatcher(nsID), gomock.Any(), gomock.Any()).
+		Do(func(
+			_ context.Context,
+			_ ident.ID,
+			writes []storage.BatchWrite,
+			_ storage.BatchWriteErrorFn,
+		) {
+			require.Equal(t, len(values), len(writes))
+			for i, w := range values {
+				assert.Equal(t, w.id, writes[i].ID.String())
+				assert.True(t, w.t.Equal(writes[i].Timestamp))
+				assert.Equal(t, w.v, writes[i].Value)
+				assert.Equal(t, xtime.Second, writes[i].Unit)
+				assert.Nil(t, writes[i].Tags)
+			}
+		}).
+		Return(nil)
 
 	var elements []*rpc.WriteBatchRawRequestElement
 	for _, w := range values {
@@ -1426,6 +1594,79 @@ func TestServiceWriteBatchRaw(t *testing.T) {
 	require.NoError(t, err)
 }
 
+func TestServiceWriteBatchRawErrors(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockDB := storage.NewMockDatabase(ctrl)
+	mockDB.EXPECT().Options().Return(testStorageOpts).AnyTimes()
+
+	service := NewService(mockDB, nil).(*service)
+
+	tctx, _ := tchannelthrift.NewContext(time.Minute)
+	ctx := tchannelthrift.Context(tctx)
+	defer ctx.Close()
+
+	nsID := "metrics"
+	now := time.Now().Truncate(time.Second)
+
+	mockDB.EXPECT().
+		WriteBatch(ctx, ident.NewIDMatcher(nsID), gomock.Any(), gomock.Any()).
+		Do(func(
+			_ context.Context,
+			_ ident.ID,
+			writes []storage.BatchWrite,
+			errFn storage.BatchWriteErrorFn,
+		) {
+			// The element with an invalid time type is never written
+			require.Equal(t, 2, len(writes))
+			assert.Equal(t, "bar", writes[1].ID.String())
+			errFn(1, errors.New("write failed"))
+		}).
+		Return(nil)
+
+	elements := []*rpc.WriteBatchRawRequestElement{
+		{
+			ID: []byte("foo"),
+			Datapoint: &rpc.Datapoint{
+				Timestamp:         now.Unix(),
+				TimestampTimeType: rpc.TimeType_UNIX_SECONDS,
+				Value:             1,
+			},
+		},
+		{
+			ID: []byte("baz"),
+			Datapoint: &rpc.Datapoint{
+				Timestamp:         now.Unix(),
+				TimestampTimeType: rpc.TimeType(-1),
+				Value:             2,
+			},
+		},
+		{
+			ID: []byte("bar"),
+			Datapoint: &rpc.Datapoint{
+				Timestamp:         now.Unix(),
+				TimestampTimeType: rpc.TimeType_UNIX_SECONDS,
+				Value:             3,
+			},
+		},
+	}
+
+	err := service.WriteBatchRaw(tctx, &rpc.WriteBatchRawRequest{
+		NameSpace: []byte(nsID),
+		Elements:  elements,
+	})
+	require.Error(t, err)
+
+	batchErrs, ok := err.(*rpc.WriteBatchRawErrors)
+	require.True(t, ok)
+	require.Equal(t, 2, len(batchErrs.Errors))
+	assert.Equal(t, int64(1), batchErrs.Errors[0].Index)
+	assert.Equal(t, rpc.ErrorType_BAD_REQUEST, batchErrs.Errors[0].Err.Type)
+	assert.Equal(t, int64(2), batchErrs.Errors[1].Index)
+	assert.Equal(t, rpc.ErrorType_INTERNAL_ERROR, batchErrs.Errors[1].Err.Type)
+}
+
 func TestServiceWriteTaggedBatchRaw(t *testing.T) {
 	ctrl := gomock.NewController(t)
 	defer ctrl.Finish()
@@ -1460,13 +1701,23 @@ func TestServiceWriteTaggedBatchRaw(t *testing.T) {
 		{"foo", "a|b", time.Now().Truncate(time.Second), 12.34},
 		{"bar", "c|dd", time.Now().Truncate(time.Second), 42.42},
 	}
-	for _, w := range values {
-		mockDB.EXPECT().
-			WriteTagged(ctx, ident.NewIDMatcher(nsID), ident.NewIDMatcher(w.id),
-				mockDecoder,
-				w.t, w.v, xtime.Second, nil).
-			Return(nil)
-	}
+	mockDB.EXPECT().
+		WriteTaggedBatch(ctx, ident.NewIDMatcher(nsID), gomock.Any(), gomock.Any()).
+		Do(func(
+			_ context.Context,
+			_ ident.ID,
+			writes []storage.BatchWrite,
+			_ storage.BatchWriteErrorFn,
+		) {
+			require.Equal(t, len(values), len(writes))
+			for i, w := range values {
+				assert.Equal(t, w.id, writes[i].ID.String())
+				assert.True(t, w.t.Equal(writes[i].Timestamp))
+				assert.Equal(t, w.v, writes[i].Value)
+				assert.Equal(t, mockDecoder, writes[i].Tags)
+			}
+		}).
+		Return(nil)
 
 	var elements []*rpc.WriteTaggedBatchRawRequestElement
 	for _, w := range values {
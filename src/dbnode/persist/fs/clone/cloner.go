@@ -23,6 +23,9 @@ package clone
 import (
 	"fmt"
 	"io"
+	"os"
+	"path/filepath"
+	"strings"
 	"time"
 
 	"github.com/m3db/m3/src/dbnode/persist"
@@ -77,6 +80,8 @@ func (c *cloner) Clone(src FileSetID, dest FileSetID, destBlocksize time.Duratio
 			Shard:      dest.Shard,
 			BlockStart: dest.Blockstart,
 		},
+		IndexSummariesPercent:           c.opts.IndexSummariesPercent(),
+		BloomFilterFalsePositivePercent: c.opts.BloomFilterFalsePositivePercent(),
 	}
 	if err := writer.Open(writerOpts); err != nil {
 		return fmt.Errorf("unable to open fileset writer: %v", err)
@@ -114,3 +119,56 @@ func (c *cloner) Clone(src FileSetID, dest FileSetID, destBlocksize time.Duratio
 
 	return nil
 }
+
+func (c *cloner) Rebuild(fileset FileSetID, scratchPathPrefix string, blocksize time.Duration) error {
+	scratch := fileset
+	scratch.PathPrefix = scratchPathPrefix
+	if err := c.Clone(fileset, scratch, blocksize); err != nil {
+		return err
+	}
+
+	var (
+		namespace = ident.StringID(fileset.Namespace)
+		shardDir  = fs.ShardDataDirPath(fileset.PathPrefix, namespace, fileset.Shard)
+	)
+	rebuilt, ok, err := fs.FileSetAt(scratchPathPrefix, namespace, fileset.Shard, fileset.Blockstart)
+	if err != nil {
+		return err
+	}
+	if !ok {
+		return fmt.Errorf("rebuilt fileset not found in: %s", scratchPathPrefix)
+	}
+	existing, ok, err := fs.FileSetAt(fileset.PathPrefix, namespace, fileset.Shard, fileset.Blockstart)
+	if err != nil {
+		return err
+	}
+	if !ok {
+		return fmt.Errorf("fileset not found in: %s", fileset.PathPrefix)
+	}
+
+	// Remove the checkpoint file first and move the rebuilt checkpoint file
+	// last so that the fileset is treated as incomplete, rather than being
+	// read with mismatched files, if the rebuild is interrupted.
+	for _, path := range existing.AbsoluteFilepaths {
+		if isCheckpointFile(path) {
+			if err := os.Remove(path); err != nil {
+				return err
+			}
+		}
+	}
+	var checkpointFile string
+	for _, path := range rebuilt.AbsoluteFilepaths {
+		if isCheckpointFile(path) {
+			checkpointFile = path
+			continue
+		}
+		if err := os.Rename(path, filepath.Join(shardDir, filepath.Base(path))); err != nil {
+			return err
+		}
+	}
+	return os.Rename(checkpointFile, filepath.Join(shardDir, filepath.Base(checkpointFile)))
+}
+
+func isCheckpointFile(path string) bool {
+	return strings.Contains(filepath.Base(path), "checkpoint")
+}
@@ -30,6 +30,7 @@ import (
 	"time"
 
 	"github.com/m3db/m3/src/dbnode/persist/fs"
+	"github.com/m3db/m3/src/dbnode/persist/schema"
 	"github.com/m3db/m3x/checked"
 	"github.com/m3db/m3x/ident"
 
@@ -129,6 +130,62 @@ func TestCloner(t *testing.T) {
 	require.NoError(t, r2.Close())
 }
 
+func TestClonerRebuild(t *testing.T) {
+	dir, err := ioutil.TempDir("", "clone")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+	opts := NewOptions().
+		SetIndexSummariesPercent(0.5).
+		SetBloomFilterFalsePositivePercent(0.0001)
+
+	blockSize := time.Hour
+	data := path.Join(dir, "data")
+	scratch := path.Join(dir, "scratch")
+	require.NoError(t, os.Mkdir(data, opts.DirMode()))
+	require.NoError(t, os.Mkdir(scratch, opts.DirMode()))
+	fileset := FileSetID{
+		PathPrefix: data,
+		Namespace:  "testns",
+		Shard:      123,
+		Blockstart: time.Now().Truncate(blockSize),
+	}
+	testBytes.IncRef()
+	defer testBytes.DecRef()
+	writeTestData(t, blockSize, fileset, opts)
+
+	readInfo := func() schema.IndexInfo {
+		results := fs.ReadInfoFiles(data, ident.StringID(fileset.Namespace),
+			fileset.Shard, opts.BufferSize(), opts.DecodingOptions())
+		require.Equal(t, 1, len(results))
+		require.NoError(t, results[0].Err.Error())
+		return results[0].Info
+	}
+	before := readInfo()
+
+	require.NoError(t, New(opts).Rebuild(fileset, scratch, blockSize))
+
+	after := readInfo()
+	require.Equal(t, int64(numTestSeries/2), after.Summaries.Summaries)
+	require.True(t, after.Summaries.Summaries > before.Summaries.Summaries)
+	require.True(t, after.BloomFilter.NumElementsM > before.BloomFilter.NumElementsM)
+	require.Equal(t, before.Entries, after.Entries)
+
+	// The rebuilt fileset is complete and readable.
+	r, err := fs.NewReader(opts.BytesPool(), fs.NewOptions().
+		SetFilePathPrefix(data).
+		SetDecodingOptions(opts.DecodingOptions()))
+	require.NoError(t, err)
+	require.NoError(t, r.Open(fs.DataReaderOpenOptions{
+		Identifier: fs.FileSetFileIdentifier{
+			Namespace:  ident.StringID(fileset.Namespace),
+			Shard:      fileset.Shard,
+			BlockStart: fileset.Blockstart,
+		},
+	}))
+	require.Equal(t, numTestSeries, r.Entries())
+	require.NoError(t, r.Close())
+}
+
 func writeTestData(t *testing.T, bs time.Duration, src FileSetID, opts Options) {
 	w, err := fs.NewWriter(fs.NewOptions().
 		SetFilePathPrefix(src.PathPrefix).
@@ -39,6 +39,9 @@ type opts struct {
 	bufferSize int
 	fileMode   os.FileMode
 	dirMode    os.FileMode
+
+	summariesPercent                float64
+	bloomFilterFalsePositivePercent float64
 }
 
 // NewOptions returns the new options
@@ -96,3 +99,21 @@ func (o *opts) SetDirMode(d os.FileMode) Options {
 func (o *opts) DirMode() os.FileMode {
 	return o.dirMode
 }
+
+func (o *opts) SetIndexSummariesPercent(value float64) Options {
+	o.summariesPercent = value
+	return o
+}
+
+func (o *opts) IndexSummariesPercent() float64 {
+	return o.summariesPercent
+}
+
+func (o *opts) SetBloomFilterFalsePositivePercent(value float64) Options {
+	o.bloomFilterFalsePositivePercent = value
+	return o
+}
+
+func (o *opts) BloomFilterFalsePositivePercent() float64 {
+	return o.bloomFilterFalsePositivePercent
+}
@@ -41,6 +41,10 @@ type FileSetID struct {
 type FileSetCloner interface {
 	// Clone clones the given fileset
 	Clone(src FileSetID, dest FileSetID, destBlocksize time.Duration) error
+
+	// Rebuild rewrites the given fileset in place, the fileset is cloned to
+	// the scratch path prefix and then moved over the existing fileset
+	Rebuild(fileset FileSetID, scratchPathPrefix string, blocksize time.Duration) error
 }
 
 // Options represents the knobs available while cloning
@@ -74,4 +78,18 @@ type Options interface {
 
 	// DirMode returns the file mode used for dir creation
 	DirMode() os.FileMode
+
+	// SetIndexSummariesPercent sets the percent of index summaries written,
+	// zero uses the filesystem default
+	SetIndexSummariesPercent(value float64) Options
+
+	// IndexSummariesPercent returns the percent of index summaries written
+	IndexSummariesPercent() float64
+
+	// SetBloomFilterFalsePositivePercent sets the bloom filter false positive
+	// rate, zero uses the filesystem default
+	SetBloomFilterFalsePositivePercent(value float64) Options
+
+	// BloomFilterFalsePositivePercent returns the bloom filter false positive rate
+	BloomFilterFalsePositivePercent() float64
 }
@@ -25,6 +25,9 @@ import (
 	"os"
 
 	"github.com/m3db/m3/src/dbnode/digest"
+	"github.com/m3db/m3/src/dbnode/encryption"
+
+	"github.com/golang/snappy"
 )
 
 const (
@@ -41,12 +44,24 @@ type chunkReader struct {
 	buffer    *bufio.Reader
 	remaining int
 	charBuff  []byte
+
+	// decoded holds the unread remainder of the current chunk if it was
+	// compressed or encrypted, otherwise the chunk is read directly from
+	// the buffer.
+	isDecoded   bool
+	decoded     []byte
+	decodedBuff []byte
+
+	// keyring opens encrypted chunks, it is nil if no keyring is configured.
+	keyring     *encryption.Keyring
+	decryptBuff []byte
 }
 
-func newChunkReader(bufferLen int) *chunkReader {
+func newChunkReader(bufferLen int, keyring *encryption.Keyring) *chunkReader {
 	return &chunkReader{
 		buffer:   bufio.NewReaderSize(nil, bufferLen),
 		charBuff: make([]byte, 1),
+		keyring:  keyring,
 	}
 }
 
@@ -54,6 +69,8 @@ func (r *chunkReader) reset(fd *os.File) {
 	r.fd = fd
 	r.buffer.Reset(fd)
 	r.remaining = 0
+	r.isDecoded = false
+	r.decoded = nil
 }
 
 func (r *chunkReader) readHeader() error {
@@ -62,7 +79,8 @@ func (r *chunkReader) readHeader() error {
 		return err
 	}
 
-	size := endianness.Uint32(header[sizeStart:sizeEnd])
+	sizeAndFlags := endianness.Uint32(header[sizeStart:sizeEnd])
+	size := sizeAndFlags & chunkSizeMask
 	checksumSize := digest.
 		Buffer(header[checksumSizeStart:checksumSizeEnd]).
 		ReadDigest()
@@ -90,12 +108,58 @@ func (r *chunkReader) readHeader() error {
 		return errCommitLogReaderChunkSizeChecksumMismatch
 	}
 
-	// Set remaining data to be consumed
-	r.remaining = int(size)
+	var (
+		compressed = sizeAndFlags&chunkSizeSnappyFlag != 0
+		encrypted  = sizeAndFlags&chunkSizeEncryptedFlag != 0
+	)
+	r.isDecoded = compressed || encrypted
+	if !r.isDecoded {
+		// Set remaining data to be consumed
+		r.remaining = int(size)
+		return nil
+	}
+
+	decoded := data
+	if encrypted {
+		if r.keyring == nil {
+			return errCommitLogReaderChunkEncrypted
+		}
+		decrypted, err := r.keyring.OpenFramed(r.decryptBuff[:0], decoded)
+		if err != nil {
+			return err
+		}
+		r.decryptBuff = decrypted
+		decoded = decrypted
+	}
+	if compressed {
+		decompressed, err := snappy.Decode(r.decodedBuff[:cap(r.decodedBuff)], decoded)
+		if err != nil {
+			return err
+		}
+		r.decodedBuff = decompressed
+		decoded = decompressed
+	}
+	if _, err := r.buffer.Discard(int(size)); err != nil {
+		return err
+	}
+	r.decoded = decoded
+	r.remaining = len(decoded)
 
 	return nil
 }
 
+func (r *chunkReader) readChunk(p []byte) (int, error) {
+	if r.isDecoded {
+		n := copy(p, r.decoded)
+		r.decoded = r.decoded[n:]
+		r.remaining -= n
+		return n, nil
+	}
+	n, err := r.buffer.Read(p)
+	r.remaining -= n
+	return n, err
+}
+
 func (r *chunkReader) Read(p []byte) (int, error) {
 	size := len(p)
 	read := 0
@@ -103,8 +167,7 @@ func (r *chunkReader) Read(p []byte) (int, error) {
 	if r.remaining < size {
 		// Copy any remaining
 		if r.remaining > 0 {
-			n, err := r.buffer.Read(p[:r.remaining])
-			r.remaining -= n
+			n, err := r.readChunk(p[:r.remaining])
 			read += n
 			if err != nil {
 				return read, err
@@ -125,8 +188,7 @@ func (r *chunkReader) Read(p []byte) (int, error) {
 		return read, err
 	}
 
-	n, err := r.buffer.Read(p)
-	r.remaining -= n
+	n, err := r.readChunk(p)
 	read += n
 	return read, err
 }
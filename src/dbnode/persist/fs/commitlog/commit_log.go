@@ -23,6 +23,7 @@ package commitlog
 import (
 	"errors"
 	"sync"
+	"sync/atomic"
 	"time"
 
 	"github.com/m3db/m3/src/dbnode/clock"
@@ -76,6 +77,11 @@ type commitLog struct {
 	// circular buffer to avoid central write lock contention
 	writes chan commitLogWrite
 
+	// queued is the number of entries queued, which is bounded by the
+	// backlog queue size regardless of how the entries are batched.
+	queued    int64
+	maxQueued int64
+
 	flushMutex      sync.RWMutex
 	lastFlushAt     time.Time
 	pendingFlushFns []completionFn
@@ -103,6 +109,7 @@ type valueType int
 const (
 	writeValueType valueType = iota
 	flushValueType
+	writeBatchValueType
 )
 
 type commitLogWrite struct {
@@ -112,9 +119,23 @@ type commitLogWrite struct {
 	datapoint    ts.Datapoint
 	unit         xtime.Unit
 	annotation   ts.Annotation
+	batch        []BatchWrite
 	completionFn completionFn
 }
 
+// entries returns the number of entries the write counts as against the
+// backlog queue size.
+func (w commitLogWrite) entries() int64 {
+	switch w.valueType {
+	case flushValueType:
+		return 0
+	case writeBatchValueType:
+		return int64(len(w.batch))
+	default:
+		return 1
+	}
+}
+
 // NewCommitLog creates a new commit log
 func NewCommitLog(opts Options) (CommitLog, error) {
 	if err := opts.Validate(); err != nil {
@@ -130,6 +151,7 @@ func NewCommitLog(opts Options) (CommitLog, error) {
 		log:                  iopts.Logger(),
 		newCommitLogWriterFn: newCommitLogWriter,
 		writes:               make(chan commitLogWrite, opts.BacklogQueueSize()),
+		maxQueued:            int64(opts.BacklogQueueSize()),
 		closeErr:             make(chan error),
 		metrics: commitLogMetrics{
 			queued:      scope.Gauge("writes.queued"),
@@ -188,7 +210,7 @@ func (l *commitLog) flushEvery(interval time.Duration) {
 	var sleepForOverride time.Duration
 
 	for {
-		l.metrics.queued.Update(float64(len(l.writes)))
+		l.metrics.queued.Update(float64(l.QueueLength()))
 
 		sleepFor := interval
 
@@ -228,48 +250,65 @@ func (l *commitLog) write() {
 			l.pendingFlushFns = append(l.pendingFlushFns, write.completionFn)
 		}
 
-		if write.valueType == flushValueType {
+		switch write.valueType {
+		case flushValueType:
 			l.writer.Flush()
-			continue
+		case writeBatchValueType:
+			for i := range write.batch {
+				entry := &write.batch[i]
+				l.writeEntry(entry.Series, entry.Datapoint,
+					entry.Unit, entry.Annotation)
+			}
+		default:
+			l.writeEntry(write.series, write.datapoint,
+				write.unit, write.annotation)
 		}
 
-		if now := l.nowFn(); !now.Before(l.writerExpireAt) {
-			if err := l.openWriter(now); err != nil {
-
-				l.metrics.errors.Inc(1)
-				l.metrics.openErrors.Inc(1)
-				l.log.Errorf("failed to open commit log: %v", err)
+		atomic.AddInt64(&l.queued, -write.entries())
+	}
 
-				if l.commitLogFailFn != nil {
-					l.commitLogFailFn(err)
-				}
+	l.Lock()
+	defer l.Unlock()
 
-				continue
-			}
-		}
+	writer := l.writer
+	l.writer = nil
+	l.closeErr <- writer.Close()
+}
 
-		err := l.writer.Write(write.series,
-			write.datapoint, write.unit, write.annotation)
+func (l *commitLog) writeEntry(
+	series Series,
+	datapoint ts.Datapoint,
+	unit xtime.Unit,
+	annotation ts.Annotation,
+) {
+	if now := l.nowFn(); !now.Before(l.writerExpireAt) {
+		if err := l.openWriter(now); err != nil {
 
-		if err != nil {
 			l.metrics.errors.Inc(1)
-			l.log.Errorf("failed to write to commit log: %v", err)
+			l.metrics.openErrors.Inc(1)
+			l.log.Errorf("failed to open commit log: %v", err)
 
 			if l.commitLogFailFn != nil {
 				l.commitLogFailFn(err)
 			}
 
-			continue
+			return
 		}
-		l.metrics.success.Inc(1)
 	}
 
-	l.Lock()
-	defer l.Unlock()
+	err := l.writer.Write(series, datapoint, unit, annotation)
 
-	writer := l.writer
-	l.writer = nil
-	l.closeErr <- writer.Close()
+	if err != nil {
+		l.metrics.errors.Inc(1)
+		l.log.Errorf("failed to write to commit log: %v", err)
+
+		if l.commitLogFailFn != nil {
+			l.commitLogFailFn(err)
+		}
+
+		return
+	}
+	l.metrics.success.Inc(1)
 }
 
 func (l *commitLog) onFlush(err error) {
@@ -374,14 +413,7 @@ func (l *commitLog) writeWait(
 		completionFn: completion,
 	}
 
-	enqueued := false
-
-	select {
-	case l.writes <- write:
-		enqueued = true
-	default:
-	}
-
+	enqueued := l.enqueue(write)
 	l.RUnlock()
 
 	if !enqueued {
@@ -413,21 +445,89 @@ func (l *commitLog) writeBehind(
 		annotation: annotation,
 	}
 
-	enqueued := false
+	enqueued := l.enqueue(write)
+	l.RUnlock()
 
-	select {
-	case l.writes <- write:
-		enqueued = true
-	default:
+	if !enqueued {
+		return ErrCommitLogQueueFull
+	}
+
+	return nil
+}
+
+func (l *commitLog) WriteBatch(
+	ctx context.Context,
+	writes []BatchWrite,
+) error {
+	if len(writes) == 0 {
+		return nil
 	}
 
+	var (
+		wait   = l.opts.Strategy() == StrategyWriteWait
+		wg     sync.WaitGroup
+		result error
+	)
+
+	write := commitLogWrite{
+		valueType: writeBatchValueType,
+		batch:     writes,
+	}
+
+	if wait {
+		wg.Add(1)
+		write.completionFn = func(err error) {
+			result = err
+			wg.Done()
+		}
+	}
+
+	l.RLock()
+	if l.closed {
+		l.RUnlock()
+		return errCommitLogClosed
+	}
+
+	enqueued := l.enqueue(write)
 	l.RUnlock()
 
 	if !enqueued {
 		return ErrCommitLogQueueFull
 	}
 
-	return nil
+	if wait {
+		wg.Wait()
+	}
+
+	return result
+}
+
+// enqueue queues a write if there is capacity for its entries, a batch
+// larger than the backlog queue size is only queued when the queue is empty.
+// It must be called with the read lock held.
+func (l *commitLog) enqueue(write commitLogWrite) bool {
+	entries := write.entries()
+	for {
+		queued := atomic.LoadInt64(&l.queued)
+		if queued > 0 && queued+entries > l.maxQueued {
+			return false
+		}
+		if atomic.CompareAndSwapInt64(&l.queued, queued, queued+entries) {
+			break
+		}
+	}
+
+	select {
+	case l.writes <- write:
+		return true
+	default:
+		atomic.AddInt64(&l.queued, -entries)
+		return false
+	}
+}
+
+func (l *commitLog) QueueLength() int {
+	return int(atomic.LoadInt64(&l.queued))
 }
 
 func (l *commitLog) Close() error {
@@ -33,6 +33,7 @@ import (
 
 	"github.com/m3db/bitset"
 	"github.com/m3db/m3/src/dbnode/clock"
+	"github.com/m3db/m3/src/dbnode/encryption"
 	"github.com/m3db/m3/src/dbnode/persist/fs"
 	"github.com/m3db/m3/src/dbnode/ts"
 	"github.com/m3db/m3x/context"
@@ -368,6 +369,47 @@ func TestCommitLogWrite(t *testing.T) {
 	assertCommitLogWritesByIterating(t, commitLog, writes)
 }
 
+func TestCommitLogWriteBatch(t *testing.T) {
+	opts, scope := newTestOptions(t, overrides{
+		strategy: StrategyWriteWait,
+	})
+	defer cleanup(t, opts)
+
+	commitLog := newTestCommitLog(t, opts)
+
+	writes := []testWrite{
+		{testSeries(0, "foo.bar", testTags1, 127), time.Now(), 123.456, xtime.Second, []byte{1, 2, 3}, nil},
+		{testSeries(1, "foo.baz", testTags2, 150), time.Now(), 456.789, xtime.Second, nil, nil},
+		{testSeries(0, "foo.bar", testTags1, 127), time.Now().Add(time.Second), 789.123, xtime.Second, nil, nil},
+	}
+
+	batch := make([]BatchWrite, 0, len(writes))
+	for _, write := range writes {
+		batch = append(batch, BatchWrite{
+			Series:     write.series,
+			Datapoint:  ts.Datapoint{Timestamp: write.t, Value: write.v},
+			Unit:       write.u,
+			Annotation: write.a,
+		})
+	}
+
+	ctx := context.NewContext()
+	defer ctx.Close()
+
+	// Returns once the whole batch has been flushed
+	require.NoError(t, commitLog.WriteBatch(ctx, batch))
+
+	success, ok := snapshotCounterValue(scope, "commitlog.writes.success")
+	require.True(t, ok)
+	require.Equal(t, int64(len(writes)), success.Value())
+
+	// Close the commit log and consequently flush
+	require.NoError(t, commitLog.Close())
+
+	// Assert writes occurred by reading the commit log
+	assertCommitLogWritesByIterating(t, commitLog, writes)
+}
+
 func TestReadCommitLogMissingMetadata(t *testing.T) {
 	readConc := 4
 	// Make sure we're not leaking goroutines
@@ -556,6 +598,123 @@ func TestCommitLogWriteBehind(t *testing.T) {
 	assertCommitLogWritesByIterating(t, commitLog, writes)
 }
 
+func TestCommitLogWriteCompressed(t *testing.T) {
+	opts, scope := newTestOptions(t, overrides{
+		strategy: StrategyWriteBehind,
+	})
+	opts = opts.SetCompression(CompressionSnappy)
+	defer cleanup(t, opts)
+
+	commitLog := newTestCommitLog(t, opts)
+
+	// Enough compressible writes to span many chunks
+	var writes []testWrite
+	for i := 0; i < 500; i++ {
+		id := fmt.Sprintf("foo.bar.%d", i)
+		writes = append(writes, testWrite{
+			testSeries(uint64(i), id, testTags1, 127), time.Now(),
+			float64(i), xtime.Millisecond, []byte("annotation annotation annotation"), nil,
+		})
+	}
+
+	writeCommitLogs(t, scope, commitLog, writes)
+
+	// Close the commit log and consequently flush
+	require.NoError(t, commitLog.Close())
+
+	// Assert the chunks were compressed by comparing the size on disk
+	// with the uncompressed flush size
+	files, err := fs.SortedCommitLogFiles(fs.CommitLogsDirPath(
+		opts.FilesystemOptions().FilePathPrefix()))
+	require.NoError(t, err)
+	require.Equal(t, 1, len(files))
+	info, err := os.Stat(files[0])
+	require.NoError(t, err)
+	require.True(t, info.Size() < int64(len(writes)*len("annotation annotation annotation")))
+
+	// Assert writes occurred by reading the commit log
+	assertCommitLogWritesByIterating(t, commitLog, writes)
+}
+
+type testKeyProvider struct{}
+
+func (p testKeyProvider) CurrentKeyID() (string, error) {
+	return "key1", nil
+}
+
+func (p testKeyProvider) Key(keyID string) ([]byte, error) {
+	return []byte("0123456789abcdef0123456789abcdef"), nil
+}
+
+func TestCommitLogWriteEncrypted(t *testing.T) {
+	opts, scope := newTestOptions(t, overrides{
+		strategy: StrategyWriteBehind,
+	})
+	keyring := encryption.NewKeyring(testKeyProvider{})
+	opts = opts.
+		SetFilesystemOptions(opts.FilesystemOptions().SetEncryptionKeyring(keyring)).
+		SetCompression(CompressionSnappy).
+		SetEncryptionEnabled(true)
+	defer cleanup(t, opts)
+
+	commitLog := newTestCommitLog(t, opts)
+
+	var writes []testWrite
+	for i := 0; i < 100; i++ {
+		id := fmt.Sprintf("foo.bar.%d", i)
+		writes = append(writes, testWrite{
+			testSeries(uint64(i), id, testTags1, 127), time.Now(),
+			float64(i), xtime.Millisecond, []byte("annotation"), nil,
+		})
+	}
+
+	writeCommitLogs(t, scope, commitLog, writes)
+
+	// Close the commit log and consequently flush
+	require.NoError(t, commitLog.Close())
+
+	// Assert the series IDs are not readable from the file on disk
+	files, err := fs.SortedCommitLogFiles(fs.CommitLogsDirPath(
+		opts.FilesystemOptions().FilePathPrefix()))
+	require.NoError(t, err)
+	require.Equal(t, 1, len(files))
+	contents, err := ioutil.ReadFile(files[0])
+	require.NoError(t, err)
+	require.False(t, strings.Contains(string(contents), "foo.bar."))
+
+	// Assert writes occurred by reading the commit log
+	assertCommitLogWritesByIterating(t, commitLog, writes)
+}
+
+func TestCommitLogOptionsEncryptionWithoutKeyring(t *testing.T) {
+	opts := NewOptions().SetEncryptionEnabled(true)
+	require.Equal(t, errEncryptionKeyringNotSet, opts.Validate())
+}
+
+func TestChunkWriterFsyncPolicy(t *testing.T) {
+	now := time.Now()
+	w := newChunkWriter(func(error) {}, false)
+	w.nowFn = func() time.Time { return now }
+	w.lastSyncAt = now
+
+	// Zero policy follows the strategy
+	require.False(t, w.shouldSync())
+	w.fsync = true
+	require.True(t, w.shouldSync())
+
+	w.fsync = false
+	w.fsyncPolicy = FsyncPolicy{Interval: time.Second, Bytes: 1024}
+	w.unsyncedBytes = 1023
+	require.False(t, w.shouldSync())
+
+	w.unsyncedBytes = 1024
+	require.True(t, w.shouldSync())
+
+	w.unsyncedBytes = 1
+	now = now.Add(time.Second)
+	require.True(t, w.shouldSync())
+}
+
 func TestCommitLogWriteErrorOnClosed(t *testing.T) {
 	opts, _ := newTestOptions(t, overrides{})
 	defer cleanup(t, opts)
@@ -616,6 +775,53 @@ func TestCommitLogWriteErrorOnFull(t *testing.T) {
 	assertCommitLogWritesByIterating(t, commitLog, writes)
 }
 
+func TestCommitLogWriteBatchQueueCapacity(t *testing.T) {
+	backlogQueueSize := 4
+	opts, _ := newTestOptions(t, overrides{
+		backlogQueueSize: &backlogQueueSize,
+		strategy:         StrategyWriteBehind,
+	})
+	defer cleanup(t, opts)
+
+	// The commit log is not opened so no queued writes are consumed.
+	commitLog, err := NewCommitLog(opts)
+	require.NoError(t, err)
+
+	newBatch := func(size int) []BatchWrite {
+		batch := make([]BatchWrite, 0, size)
+		for i := 0; i < size; i++ {
+			batch = append(batch, BatchWrite{
+				Series:    testSeries(uint64(i), "foo.bar", testTags1, 127),
+				Datapoint: ts.Datapoint{Timestamp: time.Now(), Value: float64(i)},
+				Unit:      xtime.Second,
+			})
+		}
+		return batch
+	}
+
+	ctx := context.NewContext()
+	defer ctx.Close()
+
+	// Batches count one entry per write against the queue capacity.
+	require.NoError(t, commitLog.WriteBatch(ctx, newBatch(3)))
+	require.Equal(t, ErrCommitLogQueueFull, commitLog.WriteBatch(ctx, newBatch(2)))
+	require.Equal(t, 3, commitLog.QueueLength())
+
+	series := testSeries(0, "foo.bar", testTags1, 127)
+	dp := ts.Datapoint{Timestamp: time.Now(), Value: 123.456}
+	require.NoError(t, commitLog.Write(ctx, series, dp, xtime.Second, nil))
+	require.Equal(t, ErrCommitLogQueueFull,
+		commitLog.Write(ctx, series, dp, xtime.Second, nil))
+	require.Equal(t, 4, commitLog.QueueLength())
+
+	// Batches larger than the queue are only accepted by an empty queue.
+	commitLog, err = NewCommitLog(opts)
+	require.NoError(t, err)
+	require.NoError(t, commitLog.WriteBatch(ctx, newBatch(6)))
+	require.Equal(t, ErrCommitLogQueueFull, commitLog.WriteBatch(ctx, newBatch(1)))
+	require.Equal(t, 6, commitLog.QueueLength())
+}
+
 func TestCommitLogExpiresWriter(t *testing.T) {
 	clock := mclock.NewMock()
 	opts, scope := newTestOptions(t, overrides{
@@ -53,7 +53,7 @@ func ReadLogInfo(filePath string, opts Options) (time.Time, time.Duration, int64
 		return time.Time{}, 0, 0, err
 	}
 
-	chunkReader := newChunkReader(opts.FlushSize())
+	chunkReader := newChunkReader(opts.FlushSize(), opts.FilesystemOptions().EncryptionKeyring())
 	chunkReader.reset(fd)
 	size, err := binary.ReadUvarint(chunkReader)
 	if err != nil {
@@ -58,6 +58,9 @@ var (
 	errFlushIntervalNonNegative = errors.New("flush interval must be non-negative")
 	errBlockSizePositive        = errors.New("block size must be a positive duration")
 	errReadConcurrencyPositive  = errors.New("read concurrency must be a positive integer")
+	errFsyncPolicyNonNegative   = errors.New("fsync policy interval and bytes must be non-negative")
+	errCompressionUnknown       = errors.New("unknown compression")
+	errEncryptionKeyringNotSet  = errors.New("commit log encryption enabled without an encryption keyring")
 )
 
 type options struct {
@@ -68,6 +71,9 @@ type options struct {
 	strategy         Strategy
 	flushSize        int
 	flushInterval    time.Duration
+	compression      Compression
+	encrypt          bool
+	fsyncPolicy      FsyncPolicy
 	backlogQueueSize int
 	bytesPool        pool.CheckedBytesPool
 	identPool        ident.Pool
@@ -105,6 +111,17 @@ func (o *options) Validate() error {
 	if o.ReadConcurrency() <= 0 {
 		return errReadConcurrencyPositive
 	}
+	if p := o.FsyncPolicy(); p.Interval < 0 || p.Bytes < 0 {
+		return errFsyncPolicyNonNegative
+	}
+	switch o.Compression() {
+	case CompressionNone, CompressionSnappy:
+	default:
+		return errCompressionUnknown
+	}
+	if o.EncryptionEnabled() && o.FilesystemOptions().EncryptionKeyring() == nil {
+		return errEncryptionKeyringNotSet
+	}
 	return nil
 }
 
@@ -178,6 +195,36 @@ func (o *options) FlushInterval() time.Duration {
 	return o.flushInterval
 }
 
+func (o *options) SetCompression(value Compression) Options {
+	opts := *o
+	opts.compression = value
+	return &opts
+}
+
+func (o *options) Compression() Compression {
+	return o.compression
+}
+
+func (o *options) SetEncryptionEnabled(value bool) Options {
+	opts := *o
+	opts.encrypt = value
+	return &opts
+}
+
+func (o *options) EncryptionEnabled() bool {
+	return o.encrypt
+}
+
+func (o *options) SetFsyncPolicy(value FsyncPolicy) Options {
+	opts := *o
+	opts.fsyncPolicy = value
+	return &opts
+}
+
+func (o *options) FsyncPolicy() FsyncPolicy {
+	return o.fsyncPolicy
+}
+
 func (o *options) SetBacklogQueueSize(value int) Options {
 	opts := *o
 	opts.backlogQueueSize = value
@@ -51,6 +51,7 @@ var (
 	errCommitLogReaderIsNotReusable             = errors.New("commit log reader is not reusable")
 	errCommitLogReaderMultipleReadloops         = errors.New("commit log reader tried to open multiple readLoops, do not call Read() concurrently")
 	errCommitLogReaderMissingMetadata           = errors.New("commit log reader encountered a datapoint without corresponding metadata")
+	errCommitLogReaderChunkEncrypted            = errors.New("commit log reader encountered an encrypted chunk without an encryption keyring")
 )
 
 // ReadAllSeriesPredicate can be passed as the seriesPredicate for callers
@@ -140,7 +141,7 @@ func newCommitLogReader(opts Options, seriesPredicate SeriesFilterPredicate) com
 		opts:              opts,
 		numConc:           int64(numConc),
 		checkedBytesPool:  opts.BytesPool(),
-		chunkReader:       newChunkReader(opts.FlushSize()),
+		chunkReader:       newChunkReader(opts.FlushSize(), opts.FilesystemOptions().EncryptionKeyring()),
 		infoDecoder:       msgpack.NewDecoder(decodingOpts),
 		infoDecoderStream: msgpack.NewDecoderStream(nil),
 		decoderQueues:     decoderQueues,
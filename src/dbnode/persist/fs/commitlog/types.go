@@ -26,6 +26,7 @@ import (
 	"github.com/m3db/m3/src/dbnode/clock"
 	"github.com/m3db/m3/src/dbnode/persist/fs"
 	"github.com/m3db/m3/src/dbnode/ts"
+	"github.com/m3db/m3/src/dbnode/x/xarena"
 	"github.com/m3db/m3x/context"
 	"github.com/m3db/m3x/ident"
 	"github.com/m3db/m3x/instrument"
@@ -48,6 +49,35 @@ const (
 	StrategyWriteBehind
 )
 
+// Compression describes how commit log chunks are compressed
+type Compression int
+
+const (
+	// CompressionNone describes chunks written uncompressed
+	CompressionNone Compression = iota
+
+	// CompressionSnappy describes chunks compressed with snappy, chunks
+	// that do not shrink when compressed are written uncompressed
+	CompressionSnappy
+)
+
+// FsyncPolicy describes when written commit log chunks are fsynced, allowing
+// many chunks to share a single fsync. An fsync occurs once either threshold
+// is reached, the zero value fsyncs after every chunk with StrategyWriteWait
+// and never with StrategyWriteBehind.
+type FsyncPolicy struct {
+	// Interval is the maximum time written chunks wait to be fsynced
+	Interval time.Duration
+
+	// Bytes is the maximum number of written bytes that wait to be fsynced
+	Bytes int64
+}
+
+// IsZero returns whether the policy is unset
+func (p FsyncPolicy) IsZero() bool {
+	return p.Interval == 0 && p.Bytes == 0
+}
+
 // CommitLog provides a synchronized commit log
 type CommitLog interface {
 	// Open the commit log
@@ -62,6 +92,18 @@ type CommitLog interface {
 		annotation ts.Annotation,
 	) error
 
+	// WriteBatch will write a batch of entries in the commit log, each entry
+	// of the batch counts against the backlog queue size and the batch is
+	// acknowledged once all of its entries are written. A batch larger than
+	// the backlog queue size is only accepted when the queue is empty. With
+	// StrategyWriteBehind the writes slice must not be mutated after it is
+	// passed to WriteBatch.
+	WriteBatch(ctx context.Context, writes []BatchWrite) error
+
+	// QueueLength returns the number of entries queued and not yet written
+	// to the commit log.
+	QueueLength() int
+
 	// Close the commit log
 	Close() error
 }
@@ -88,6 +130,14 @@ type IteratorOpts struct {
 	SeriesFilterPredicate SeriesFilterPredicate
 }
 
+// BatchWrite is a single entry of a batch written to the commit log
+type BatchWrite struct {
+	Series     Series
+	Datapoint  ts.Datapoint
+	Unit       xtime.Unit
+	Annotation ts.Annotation
+}
+
 // Series describes a series in the commit log
 type Series struct {
 	// UniqueIndex is the unique index assigned to this series
@@ -102,10 +152,23 @@ type Series struct {
 	// Tags are the series tags
 	Tags ident.Tags // FOLLOWUP(prateek): wire Tags to commit log writer
 
+	// Metadata holds the series tags in an arena in place of Tags when set,
+	// the tags are only materialized when read with SeriesTags
+	Metadata xarena.SeriesMetadata
+
 	// Shard is the shard the series belongs to
 	Shard uint32
 }
 
+// SeriesTags returns the tags of the series, materialized from Metadata if
+// the tags are held in an arena.
+func (s Series) SeriesTags() ident.Tags {
+	if s.Tags.Values() == nil && !s.Metadata.IsZero() {
+		return s.Metadata.Tags()
+	}
+	return s.Tags
+}
+
 // Options represents the options for the commit log
 type Options interface {
 	// Validate validates the Options
@@ -153,6 +216,25 @@ type Options interface {
 	// FlushInterval returns the flush interval
 	FlushInterval() time.Duration
 
+	// SetCompression sets the compression of written chunks
+	SetCompression(value Compression) Options
+
+	// Compression returns the compression of written chunks
+	Compression() Compression
+
+	// SetEncryptionEnabled sets whether written chunks are encrypted with the
+	// current key of the filesystem options encryption keyring
+	SetEncryptionEnabled(value bool) Options
+
+	// EncryptionEnabled returns whether written chunks are encrypted
+	EncryptionEnabled() bool
+
+	// SetFsyncPolicy sets the fsync policy
+	SetFsyncPolicy(value FsyncPolicy) Options
+
+	// FsyncPolicy returns the fsync policy
+	FsyncPolicy() FsyncPolicy
+
 	// SetBacklogQueueSize sets the backlog queue size
 	SetBacklogQueueSize(value int) Options
 
@@ -30,6 +30,7 @@ import (
 	"github.com/m3db/bitset"
 	"github.com/m3db/m3/src/dbnode/clock"
 	"github.com/m3db/m3/src/dbnode/digest"
+	"github.com/m3db/m3/src/dbnode/encryption"
 	"github.com/m3db/m3/src/dbnode/persist/fs"
 	"github.com/m3db/m3/src/dbnode/persist/fs/msgpack"
 	"github.com/m3db/m3/src/dbnode/persist/schema"
@@ -37,6 +38,8 @@ import (
 	"github.com/m3db/m3/src/dbnode/ts"
 	"github.com/m3db/m3x/ident"
 	xtime "github.com/m3db/m3x/time"
+
+	"github.com/golang/snappy"
 )
 
 const (
@@ -51,6 +54,14 @@ const (
 		chunkHeaderChecksumSizeLen +
 		chunkHeaderChecksumDataLen
 
+	// The high bit of the chunk size marks the chunk data as snappy
+	// compressed and the next bit marks it as encrypted, the remaining bits
+	// hold the size of the chunk data as written. Chunks written before
+	// compression and encryption were supported never set them.
+	chunkSizeSnappyFlag    uint32 = 1 << 31
+	chunkSizeEncryptedFlag uint32 = 1 << 30
+	chunkSizeMask                 = chunkSizeEncryptedFlag - 1
+
 	defaultBitSetLength = 65536
 )
 
@@ -105,13 +116,20 @@ func newCommitLogWriter(
 	opts Options,
 ) commitLogWriter {
 	shouldFsync := opts.Strategy() == StrategyWriteWait
+	chunkWriter := newChunkWriter(flushFn, shouldFsync)
+	chunkWriter.compression = opts.Compression()
+	if opts.EncryptionEnabled() {
+		chunkWriter.keyring = opts.FilesystemOptions().EncryptionKeyring()
+	}
+	chunkWriter.fsyncPolicy = opts.FsyncPolicy()
+	chunkWriter.nowFn = opts.ClockOptions().NowFn()
 
 	return &writer{
 		filePathPrefix:     opts.FilesystemOptions().FilePathPrefix(),
 		newFileMode:        opts.FilesystemOptions().NewFileMode(),
 		newDirectoryMode:   opts.FilesystemOptions().NewDirectoryMode(),
 		nowFn:              opts.ClockOptions().NowFn(),
-		chunkWriter:        newChunkWriter(flushFn, shouldFsync),
+		chunkWriter:        chunkWriter,
 		chunkReserveHeader: make([]byte, chunkHeaderLen),
 		buffer:             bufio.NewWriterSize(nil, opts.FlushSize()),
 		sizeBuffer:         make([]byte, binary.MaxVarintLen64),
@@ -152,6 +170,8 @@ func (w *writer) Open(start time.Time, duration time.Duration) error {
 	}
 
 	w.chunkWriter.fd = fd
+	w.chunkWriter.unsyncedBytes = 0
+	w.chunkWriter.lastSyncAt = w.nowFn()
 	w.buffer.Reset(w.chunkWriter)
 	if err := w.write(w.logEncoder.Bytes()); err != nil {
 		w.Close()
@@ -180,7 +200,7 @@ func (w *writer) Write(
 	seen := w.seen.Test(uint(series.UniqueIndex))
 	if !seen {
 		var (
-			tags        = series.Tags
+			tags        = series.SeriesTags()
 			encodedTags []byte
 		)
 
@@ -234,7 +254,15 @@ func (w *writer) Write(
 }
 
 func (w *writer) Flush() error {
-	return w.buffer.Flush()
+	if err := w.buffer.Flush(); err != nil {
+		return err
+	}
+	// Chunks written while writes are stalled would otherwise wait for the
+	// next write to be fsynced by the fsync policy interval.
+	if w.chunkWriter.unsyncedBytes > 0 && w.chunkWriter.shouldSync() {
+		return w.chunkWriter.sync()
+	}
+	return nil
 }
 
 func (w *writer) Close() error {
@@ -245,6 +273,11 @@ func (w *writer) Close() error {
 	if err := w.Flush(); err != nil {
 		return err
 	}
+	if !w.chunkWriter.fsyncPolicy.IsZero() && w.chunkWriter.unsyncedBytes > 0 {
+		if err := w.chunkWriter.sync(); err != nil {
+			return err
+		}
+	}
 	if err := w.chunkWriter.fd.Close(); err != nil {
 		return err
 	}
@@ -278,10 +311,18 @@ func (w *writer) write(data []byte) error {
 }
 
 type chunkWriter struct {
-	fd      *os.File
-	flushFn flushFn
-	buff    []byte
-	fsync   bool
+	fd            *os.File
+	flushFn       flushFn
+	buff          []byte
+	fsync         bool
+	fsyncPolicy   FsyncPolicy
+	compression   Compression
+	compressBuff  []byte
+	keyring       *encryption.Keyring
+	encryptBuff   []byte
+	nowFn         clock.NowFn
+	unsyncedBytes int64
+	lastSyncAt    time.Time
 }
 
 func newChunkWriter(flushFn flushFn, fsync bool) *chunkWriter {
@@ -289,11 +330,35 @@ func newChunkWriter(flushFn flushFn, fsync bool) *chunkWriter {
 		flushFn: flushFn,
 		buff:    make([]byte, chunkHeaderLen),
 		fsync:   fsync,
+		nowFn:   time.Now,
 	}
 }
 
 func (w *chunkWriter) Write(p []byte) (int, error) {
 	size := len(p)
+	data := p
+
+	var flags uint32
+	if w.compression == CompressionSnappy {
+		w.compressBuff = w.compressBuff[:cap(w.compressBuff)]
+		compressed := snappy.Encode(w.compressBuff, p)
+		w.compressBuff = compressed
+		if len(compressed) < size {
+			data = compressed
+			flags |= chunkSizeSnappyFlag
+		}
+	}
+	if w.keyring != nil {
+		// Encrypt after compressing as encrypted data does not compress.
+		encrypted, err := w.keyring.SealFramed(w.encryptBuff[:0], data)
+		if err != nil {
+			w.flushFn(err)
+			return 0, err
+		}
+		w.encryptBuff = encrypted
+		data = encrypted
+		flags |= chunkSizeEncryptedFlag
+	}
 
 	sizeStart, sizeEnd :=
 		0, chunkHeaderSizeLen
@@ -303,11 +368,11 @@ func (w *chunkWriter) Write(p []byte) (int, error) {
 		checksumSizeEnd, checksumSizeEnd+chunkHeaderChecksumDataLen
 
 	// Write size
-	endianness.PutUint32(w.buff[sizeStart:sizeEnd], uint32(size))
+	endianness.PutUint32(w.buff[sizeStart:sizeEnd], uint32(len(data))|flags)
 
 	// Calculate checksums
 	checksumSize := digest.Checksum(w.buff[sizeStart:sizeEnd])
-	checksumData := digest.Checksum(p)
+	checksumData := digest.Checksum(data)
 
 	// Write checksums
 	digest.
@@ -318,7 +383,7 @@ func (w *chunkWriter) Write(p []byte) (int, error) {
 		WriteDigest(checksumData)
 
 	// Combine buffers to reduce to a single syscall
-	w.buff = append(w.buff[:chunkHeaderLen], p...)
+	w.buff = append(w.buff[:chunkHeaderLen], data...)
 
 	// Write contents to file descriptor
 	n, err := w.fd.Write(w.buff)
@@ -326,13 +391,39 @@ func (w *chunkWriter) Write(p []byte) (int, error) {
 		w.flushFn(err)
 		return n, err
 	}
+	w.unsyncedBytes += int64(n)
 
 	// Fsync if required to
-	if w.fsync {
-		err = w.fd.Sync()
+	if w.shouldSync() {
+		err = w.sync()
 	}
 
 	// Fire flush callback
 	w.flushFn(err)
-	return n, err
+	if err != nil {
+		return 0, err
+	}
+	// Report the uncompressed size as written so the buffered writer
+	// does not consider a compressed chunk a short write
+	return size, nil
+}
+
+func (w *chunkWriter) shouldSync() bool {
+	if w.fsyncPolicy.IsZero() {
+		return w.fsync
+	}
+	if w.fsyncPolicy.Bytes > 0 && w.unsyncedBytes >= w.fsyncPolicy.Bytes {
+		return true
+	}
+	return w.fsyncPolicy.Interval > 0 &&
+		w.nowFn().Sub(w.lastSyncAt) >= w.fsyncPolicy.Interval
+}
+
+func (w *chunkWriter) sync() error {
+	if err := w.fd.Sync(); err != nil {
+		return err
+	}
+	w.unsyncedBytes = 0
+	w.lastSyncAt = w.nowFn()
+	return nil
 }
@@ -643,6 +643,31 @@ func IndexSnapshotFiles(filePathPrefix string, namespace ident.ID) (FileSetFiles
 	})
 }
 
+// DataFiles returns a slice of all the names for all the flush data fileset files
+// for a given namespace and shard combination.
+func DataFiles(filePathPrefix string, namespace ident.ID, shard uint32) (FileSetFilesSlice, error) {
+	return filesetFiles(filesetFilesSelector{
+		fileSetType:    persist.FileSetFlushType,
+		contentType:    persist.FileSetDataContentType,
+		filePathPrefix: filePathPrefix,
+		namespace:      namespace,
+		shard:          shard,
+		pattern:        filesetFilePattern,
+	})
+}
+
+// IndexFiles returns a slice of all the names for all the flush index fileset files
+// for a given namespace.
+func IndexFiles(filePathPrefix string, namespace ident.ID) (FileSetFilesSlice, error) {
+	return filesetFiles(filesetFilesSelector{
+		fileSetType:    persist.FileSetFlushType,
+		contentType:    persist.FileSetIndexContentType,
+		filePathPrefix: filePathPrefix,
+		namespace:      namespace,
+		pattern:        filesetFilePattern,
+	})
+}
+
 // FileSetAt returns a FileSetFile for the given namespace/shard/blockStart combination if it exists.
 func FileSetAt(filePathPrefix string, namespace ident.ID, shard uint32, blockStart time.Time) (FileSetFile, bool, error) {
 	matched, err := filesetFiles(filesetFilesSelector{
@@ -989,6 +1014,11 @@ func DataDirPath(prefix string) string {
 	return path.Join(prefix, dataDirName)
 }
 
+// IndexDirPath returns the path to the index directory belonging to a db
+func IndexDirPath(prefix string) string {
+	return path.Join(prefix, indexDirName)
+}
+
 // SnapshotDirPath returns the path to the snapshot directory belong to a db
 func SnapshotDirPath(prefix string) string {
 	return path.Join(prefix, snapshotDirName)
@@ -451,6 +451,22 @@ func TestSnapshotFiles(t *testing.T) {
 	require.False(t, testWriterStart.IsZero())
 }
 
+func TestDataFiles(t *testing.T) {
+	var (
+		shard = uint32(0)
+		dir   = createDataCheckpointFilesDir(t, testNs1ID, shard, 3)
+	)
+	defer os.RemoveAll(dir)
+
+	dataFiles, err := DataFiles(dir, testNs1ID, shard)
+	require.NoError(t, err)
+	require.Equal(t, 3, len(dataFiles))
+	for i, fileset := range dataFiles {
+		require.True(t, time.Unix(0, int64(i)).Equal(fileset.ID.BlockStart))
+		require.True(t, fileset.HasCheckpointFile())
+	}
+}
+
 func TestSnapshotFilesNoFiles(t *testing.T) {
 	// Make empty directory
 	shard := uint32(0)
@@ -22,6 +22,7 @@ package fs
 
 import (
 	"errors"
+	"fmt"
 	"io"
 
 	"github.com/m3db/m3/src/m3ninx/index/segment"
@@ -47,9 +48,27 @@ type ReadIndexSegmentsOptions struct {
 	newPersistentSegmentFn newPersistentSegmentFn
 }
 
-// ReadIndexSegments will read a set of segments.
+// ReadIndexSegments will read a set of segments, each segment returned is a
+// ReloadableIndexSegment so that it can be read again after being closed.
 func ReadIndexSegments(
 	opts ReadIndexSegmentsOptions,
+) ([]segment.Segment, error) {
+	segments, err := readIndexSegments(opts)
+	if err != nil {
+		return nil, err
+	}
+	for i, seg := range segments {
+		segments[i] = &ReloadableIndexSegment{
+			Segment: seg,
+			opts:    opts,
+			index:   i,
+		}
+	}
+	return segments, nil
+}
+
+func readIndexSegments(
+	opts ReadIndexSegmentsOptions,
 ) ([]segment.Segment, error) {
 	readerOpts := opts.ReaderOptions
 	fsOpts := opts.FilesystemOptions
@@ -114,3 +133,37 @@ func ReadIndexSegments(
 	success = true
 	return segments, nil
 }
+
+// ReloadableIndexSegment is a segment read from an index fileset that can be
+// read again from disk, this allows callers to close segments that are not
+// being used and load them back on demand.
+type ReloadableIndexSegment struct {
+	segment.Segment
+
+	opts  ReadIndexSegmentsOptions
+	index int
+}
+
+// Reload reads the segment from its index fileset again, the returned segment
+// is independent of the receiver and must be closed by the caller.
+func (s *ReloadableIndexSegment) Reload() (segment.Segment, error) {
+	segments, err := readIndexSegments(s.opts)
+	if err != nil {
+		return nil, err
+	}
+
+	var result segment.Segment
+	for i, seg := range segments {
+		if i == s.index {
+			result = seg
+			continue
+		}
+		seg.Close()
+	}
+	if result == nil {
+		return nil, fmt.Errorf(
+			"index fileset has %d segments, unable to reload segment %d",
+			len(segments), s.index)
+	}
+	return result, nil
+}
@@ -252,6 +252,14 @@ func (dec *Decoder) decodeIndexInfo() schema.IndexInfo {
 	indexInfo.SnapshotTime = dec.decodeVarint()
 	indexInfo.FileType = persist.FileSetType(dec.decodeVarint())
 
+	if actual < 9 {
+		dec.skip(numFieldsToSkip)
+		return indexInfo
+	}
+
+	encryptionKeyID, _, _ := dec.decodeBytes()
+	indexInfo.EncryptionKeyID = string(encryptionKeyID)
+
 	dec.skip(numFieldsToSkip)
 	return indexInfo
 }
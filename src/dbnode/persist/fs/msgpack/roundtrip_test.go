@@ -43,8 +43,9 @@ var (
 			NumElementsM: 2075674,
 			NumHashesK:   7,
 		},
-		SnapshotTime: time.Now().UnixNano(),
-		FileType:     persist.FileSetSnapshotType,
+		SnapshotTime:    time.Now().UnixNano(),
+		FileType:        persist.FileSetSnapshotType,
+		EncryptionKeyID: "testKeyID",
 	}
 
 	testIndexEntry = schema.IndexEntry{
@@ -112,11 +113,14 @@ func TestIndexInfoRoundTripBackwardsCompatibilityV1(t *testing.T) {
 	// the old file format
 	currSnapshotTime := testIndexInfo.SnapshotTime
 	currFileType := testIndexInfo.FileType
+	currEncryptionKeyID := testIndexInfo.EncryptionKeyID
 	testIndexInfo.SnapshotTime = 0
 	testIndexInfo.FileType = 0
+	testIndexInfo.EncryptionKeyID = ""
 	defer func() {
 		testIndexInfo.SnapshotTime = currSnapshotTime
 		testIndexInfo.FileType = currFileType
+		testIndexInfo.EncryptionKeyID = currEncryptionKeyID
 	}()
 
 	enc.EncodeIndexInfo(testIndexInfo)
@@ -139,6 +143,7 @@ func TestIndexInfoRoundTripForwardsCompatibilityV2(t *testing.T) {
 	// because the old decoder won't read the new fields
 	currSnapshotTime := testIndexInfo.SnapshotTime
 	currFileType := testIndexInfo.FileType
+	currEncryptionKeyID := testIndexInfo.EncryptionKeyID
 
 	enc.EncodeIndexInfo(testIndexInfo)
 
@@ -146,9 +151,11 @@ func TestIndexInfoRoundTripForwardsCompatibilityV2(t *testing.T) {
 	// encoded the data
 	testIndexInfo.SnapshotTime = 0
 	testIndexInfo.FileType = 0
+	testIndexInfo.EncryptionKeyID = ""
 	defer func() {
 		testIndexInfo.SnapshotTime = currSnapshotTime
 		testIndexInfo.FileType = currFileType
+		testIndexInfo.EncryptionKeyID = currEncryptionKeyID
 	}()
 
 	dec.Reset(NewDecoderStream(enc.Bytes()))
@@ -26,6 +26,7 @@ import (
 	"os"
 
 	"github.com/m3db/m3/src/dbnode/clock"
+	"github.com/m3db/m3/src/dbnode/encryption"
 	"github.com/m3db/m3/src/dbnode/persist/fs/msgpack"
 	"github.com/m3db/m3/src/dbnode/runtime"
 	"github.com/m3db/m3/src/dbnode/serialize"
@@ -67,6 +68,8 @@ var (
 
 	errTagEncoderPoolNotSet = errors.New("tag encoder pool is not set")
 	errTagDecoderPoolNotSet = errors.New("tag decoder pool is not set")
+
+	errEncryptionKeyringNotSet = errors.New("encrypted namespaces set without an encryption keyring")
 )
 
 type options struct {
@@ -85,6 +88,9 @@ type options struct {
 	seekReaderBufferSize                 int
 	mmapEnableHugePages                  bool
 	mmapHugePagesThreshold               int64
+	seekReadStrategy                     ReadStrategy
+	encryptionKeyring                    *encryption.Keyring
+	encryptedNamespaces                  []string
 	tagEncoderPool                       serialize.TagEncoderPool
 	tagDecoderPool                       serialize.TagDecoderPool
 	fstOptions                           fst.Options
@@ -116,6 +122,7 @@ func NewOptions() Options {
 		seekReaderBufferSize:                 defaultSeekReaderBufferSize,
 		mmapEnableHugePages:                  defaultMmapEnableHugePages,
 		mmapHugePagesThreshold:               defaultMmapHugePagesThreshold,
+		seekReadStrategy:                     DefaultReadStrategy,
 		tagEncoderPool:                       tagEncoderPool,
 		tagDecoderPool:                       tagDecoderPool,
 		fstOptions:                           fstOptions,
@@ -139,6 +146,9 @@ func (o *options) Validate() error {
 	if o.tagDecoderPool == nil {
 		return errTagDecoderPoolNotSet
 	}
+	if len(o.encryptedNamespaces) > 0 && o.encryptionKeyring == nil {
+		return errEncryptionKeyringNotSet
+	}
 	return nil
 }
 
@@ -292,6 +302,36 @@ func (o *options) MmapHugeTLBThreshold() int64 {
 	return o.mmapHugePagesThreshold
 }
 
+func (o *options) SetSeekReadStrategy(value ReadStrategy) Options {
+	opts := *o
+	opts.seekReadStrategy = value
+	return &opts
+}
+
+func (o *options) SeekReadStrategy() ReadStrategy {
+	return o.seekReadStrategy
+}
+
+func (o *options) SetEncryptionKeyring(value *encryption.Keyring) Options {
+	opts := *o
+	opts.encryptionKeyring = value
+	return &opts
+}
+
+func (o *options) EncryptionKeyring() *encryption.Keyring {
+	return o.encryptionKeyring
+}
+
+func (o *options) SetEncryptedNamespaces(value []string) Options {
+	opts := *o
+	opts.encryptedNamespaces = value
+	return &opts
+}
+
+func (o *options) EncryptedNamespaces() []string {
+	return o.encryptedNamespaces
+}
+
 func (o *options) SetTagEncoderPool(value serialize.TagEncoderPool) Options {
 	opts := *o
 	opts.tagEncoderPool = value
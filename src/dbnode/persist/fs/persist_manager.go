@@ -23,6 +23,7 @@ package fs
 import (
 	"errors"
 	"fmt"
+	"io"
 	"sync"
 	"time"
 
@@ -275,7 +276,6 @@ func (pm *persistManager) PrepareIndex(opts persist.IndexPrepareOptions) (persis
 }
 
 func (pm *persistManager) persistIndex(seg segment.MutableSegment) error {
-	// FOLLOWUP(prateek): need to use-rate limiting runtime options in this code path
 	markError := func(err error) {
 		pm.indexPM.writeErr = err
 	}
@@ -283,19 +283,76 @@ func (pm *persistManager) persistIndex(seg segment.MutableSegment) error {
 		return fmt.Errorf("encountered error: %v, skipping further attempts to persist data", err)
 	}
 
+	pm.RLock()
+	// Rate limit options can change dynamically
+	opts := pm.currRateLimitOpts
+	pm.RUnlock()
+
+	start := pm.nowFn()
+	if pm.start.IsZero() {
+		pm.start = start
+	}
+
 	if err := pm.indexPM.segmentWriter.Reset(seg); err != nil {
 		markError(err)
 		return err
 	}
 
-	if err := pm.indexPM.writer.WriteSegmentFileSet(pm.indexPM.segmentWriter); err != nil {
+	segmentWriter := &countingSegmentFileSetWriter{
+		IndexSegmentFileSetWriter: pm.indexPM.segmentWriter,
+	}
+	if err := pm.indexPM.writer.WriteSegmentFileSet(segmentWriter); err != nil {
 		markError(err)
 		return err
 	}
+	pm.bytesWritten += segmentWriter.bytesWritten
+
+	now := pm.nowFn()
+	pm.worked += now.Sub(start)
+
+	// NB: Segments are written whole so the rate limit is applied after
+	// each segment is written rather than every number of writes.
+	rateLimitMbps := opts.LimitMbps()
+	if opts.LimitEnabled() && rateLimitMbps > 0.0 {
+		target := time.Duration(float64(time.Second) * float64(pm.bytesWritten) / (rateLimitMbps * bytesPerMegabit))
+		if elapsed := now.Sub(pm.start); elapsed < target {
+			pm.sleepFn(target - elapsed)
+			pm.slept += pm.nowFn().Sub(now)
+		}
+	}
 
 	return nil
 }
 
+// countingSegmentFileSetWriter counts the bytes written of the files of an
+// index segment file set.
+type countingSegmentFileSetWriter struct {
+	m3ninxpersist.IndexSegmentFileSetWriter
+
+	bytesWritten int64
+}
+
+func (w *countingSegmentFileSetWriter) WriteFile(
+	fileType m3ninxpersist.IndexSegmentFileType,
+	writer io.Writer,
+) error {
+	return w.IndexSegmentFileSetWriter.WriteFile(fileType, countingWriter{
+		writer:       writer,
+		bytesWritten: &w.bytesWritten,
+	})
+}
+
+type countingWriter struct {
+	writer       io.Writer
+	bytesWritten *int64
+}
+
+func (w countingWriter) Write(p []byte) (int, error) {
+	n, err := w.writer.Write(p)
+	*w.bytesWritten += int64(n)
+	return n, err
+}
+
 func (pm *persistManager) closeIndex() ([]segment.Segment, error) {
 	// ensure StartIndexPersist was called
 	if !pm.indexPM.initialized {
@@ -421,7 +478,8 @@ func (pm *persistManager) PrepareData(opts persist.DataPrepareOptions) (persist.
 		}
 	}
 
-	blockSize := nsMetadata.Options().RetentionOptions().BlockSize()
+	nsOpts := nsMetadata.Options()
+	blockSize := nsOpts.RetentionOptions().BlockSize()
 	dataWriterOpts := DataWriterOpenOptions{
 		BlockSize: blockSize,
 		Snapshot: DataWriterSnapshotOptions{
@@ -434,6 +492,8 @@ func (pm *persistManager) PrepareData(opts persist.DataPrepareOptions) (persist.
 			BlockStart:  blockStart,
 			VolumeIndex: volumeIndex,
 		},
+		IndexSummariesPercent:           nsOpts.IndexSummariesPercent(),
+		BloomFilterFalsePositivePercent: nsOpts.BloomFilterFalsePositivePercent(),
 	}
 	if err := pm.dataPM.writer.Open(dataWriterOpts); err != nil {
 		return prepared, err
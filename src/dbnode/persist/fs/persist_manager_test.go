@@ -23,6 +23,7 @@ package fs
 import (
 	"errors"
 	"io"
+	"io/ioutil"
 	"os"
 	"testing"
 	"time"
@@ -359,7 +360,7 @@ func TestPersistenceManagerPrepareIndexSuccess(t *testing.T) {
 
 	seg := segment.NewMockMutableSegment(ctrl)
 	segWriter.EXPECT().Reset(seg).Return(nil)
-	writer.EXPECT().WriteSegmentFileSet(segWriter).Return(nil)
+	writer.EXPECT().WriteSegmentFileSet(gomock.Any()).Return(nil)
 	require.NoError(t, prepared.Persist(seg))
 
 	reader := NewMockIndexFileSetReader(ctrl)
@@ -389,7 +390,7 @@ func TestPersistenceManagerPrepareIndexSuccess(t *testing.T) {
 	segs, err := prepared.Close()
 	require.NoError(t, err)
 	require.Len(t, segs, 1)
-	require.Equal(t, fsSeg, segs[0])
+	require.Equal(t, fsSeg, segs[0].(*ReloadableIndexSegment).Segment)
 }
 
 func TestPersistenceManagerNoRateLimit(t *testing.T) {
@@ -553,6 +554,81 @@ func TestPersistenceManagerWithRateLimit(t *testing.T) {
 	}
 }
 
+func TestPersistenceManagerIndexWithRateLimit(t *testing.T) {
+	ctrl := gomock.NewController(xtest.Reporter{t})
+	defer ctrl.Finish()
+
+	pm, writer, segWriter, opts := testIndexPersistManager(t, ctrl)
+	defer os.RemoveAll(pm.filePathPrefix)
+
+	var (
+		now        time.Time
+		slept      time.Duration
+		blockStart = time.Unix(1000, 0)
+		data       = make([]byte, bytesPerMegabit)
+	)
+	pm.nowFn = func() time.Time { return now }
+	pm.sleepFn = func(d time.Duration) {
+		slept += d
+		now = now.Add(d)
+	}
+
+	// Enable rate limiting
+	runtimeOpts := opts.RuntimeOptionsManager().Get()
+	opts.RuntimeOptionsManager().Update(
+		runtimeOpts.SetPersistRateLimitOptions(
+			runtimeOpts.PersistRateLimitOptions().
+				SetLimitEnabled(true).
+				SetLimitMbps(2.0)))
+
+	// Wait until enabled
+	for func() bool {
+		pm.Lock()
+		defer pm.Unlock()
+		return !pm.currRateLimitOpts.LimitEnabled()
+	}() {
+		time.Sleep(10 * time.Millisecond)
+	}
+
+	writer.EXPECT().Open(gomock.Any()).Return(nil)
+	writer.EXPECT().WriteSegmentFileSet(gomock.Any()).DoAndReturn(
+		func(w m3ninxpersist.IndexSegmentFileSetWriter) error {
+			return w.WriteFile(m3ninxpersist.DocumentDataIndexSegmentFileType, ioutil.Discard)
+		}).Times(2)
+	segWriter.EXPECT().WriteFile(gomock.Any(), gomock.Any()).DoAndReturn(
+		func(_ m3ninxpersist.IndexSegmentFileType, w io.Writer) error {
+			_, err := w.Write(data)
+			return err
+		}).Times(2)
+
+	flush, err := pm.StartIndexPersist()
+	require.NoError(t, err)
+
+	prepared, err := flush.PrepareIndex(persist.IndexPrepareOptions{
+		NamespaceMetadata: testNs1Metadata(t),
+		BlockStart:        blockStart,
+	})
+	require.NoError(t, err)
+
+	now = time.Now()
+	seg := segment.NewMockMutableSegment(ctrl)
+	segWriter.EXPECT().Reset(seg).Return(nil).Times(2)
+
+	// A megabit written immediately at two megabits per second is throttled
+	// for half a second.
+	require.NoError(t, prepared.Persist(seg))
+	require.Equal(t, time.Second/2, slept)
+
+	// Once the throughput is below the limit it is not throttled further.
+	now = now.Add(2 * time.Second)
+	require.NoError(t, prepared.Persist(seg))
+	require.Equal(t, time.Second/2, slept)
+	require.Equal(t, int64(2*bytesPerMegabit), pm.bytesWritten)
+
+	segWriter.EXPECT().Reset(nil)
+	assert.NoError(t, flush.DoneIndex())
+}
+
 func TestPersistenceManagerNamespaceSwitch(t *testing.T) {
 	ctrl := gomock.NewController(t)
 	defer ctrl.Finish()
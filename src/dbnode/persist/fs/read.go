@@ -30,6 +30,7 @@ import (
 	"time"
 
 	"github.com/m3db/m3/src/dbnode/digest"
+	"github.com/m3db/m3/src/dbnode/encryption"
 	"github.com/m3db/m3/src/dbnode/persist"
 	"github.com/m3db/m3/src/dbnode/persist/fs/msgpack"
 	"github.com/m3db/m3/src/dbnode/persist/schema"
@@ -75,6 +76,10 @@ type reader struct {
 
 	bloomFilterFd *os.File
 
+	// cipher is set when the data of the fileset is encrypted.
+	cipher    *encryption.Cipher
+	sealedBuf []byte
+
 	entries         int
 	bloomFilterInfo schema.IndexBloomFilterInfo
 	entriesRead     int
@@ -306,6 +311,13 @@ func (r *reader) readInfo(size int) error {
 	r.entriesRead = 0
 	r.metadataRead = 0
 	r.bloomFilterInfo = info.BloomFilter
+	r.cipher = nil
+	if info.EncryptionKeyID != "" {
+		r.cipher, err = encryptedDataCipher(r.opts, info.EncryptionKeyID)
+		if err != nil {
+			return err
+		}
+	}
 	return nil
 }
 
@@ -338,20 +350,32 @@ func (r *reader) Read() (ident.ID, ident.TagIterator, checked.Bytes, uint32, err
 	}
 
 	entry := r.indexEntriesByOffsetAsc[r.entriesRead]
+	size, err := dataEntrySize(entry.Size, r.cipher)
+	if err != nil {
+		return nil, nil, nil, 0, err
+	}
 
 	var data checked.Bytes
 	if r.bytesPool != nil {
-		data = r.bytesPool.Get(int(entry.Size))
+		data = r.bytesPool.Get(size)
 		data.IncRef()
 		defer data.DecRef()
-		data.Resize(int(entry.Size))
+		data.Resize(size)
 	} else {
-		data = checked.NewBytes(make([]byte, entry.Size), nil)
+		data = checked.NewBytes(make([]byte, size), nil)
 		data.IncRef()
 		defer data.DecRef()
 	}
 
-	n, err := r.dataReader.Read(data.Bytes())
+	readBuf := data.Bytes()
+	if r.cipher != nil {
+		if cap(r.sealedBuf) < int(entry.Size) {
+			r.sealedBuf = make([]byte, int(entry.Size))
+		}
+		readBuf = r.sealedBuf[:entry.Size]
+	}
+
+	n, err := r.dataReader.Read(readBuf)
 	if err != nil {
 		return nil, nil, nil, 0, err
 	}
@@ -359,6 +383,12 @@ func (r *reader) Read() (ident.ID, ident.TagIterator, checked.Bytes, uint32, err
 		return nil, nil, nil, 0, errReadNotExpectedSize
 	}
 
+	if r.cipher != nil {
+		if _, err := r.cipher.Open(data.Bytes()[:0], readBuf); err != nil {
+			return nil, nil, nil, 0, err
+		}
+	}
+
 	id := r.entryClonedID(entry.ID)
 	tags := r.entryClonedEncodedTagsIter(entry.EncodedTags)
 
@@ -374,7 +404,10 @@ func (r *reader) ReadMetadata() (ident.ID, ident.TagIterator, int, uint32, error
 	entry := r.indexEntriesByOffsetAsc[r.metadataRead]
 	id := r.entryClonedID(entry.ID)
 	tags := r.entryClonedEncodedTagsIter(entry.EncodedTags)
-	length := int(entry.Size)
+	length, err := dataEntrySize(entry.Size, r.cipher)
+	if err != nil {
+		return nil, nil, 0, 0, err
+	}
 	checksum := uint32(entry.Checksum)
 
 	r.metadataRead++
@@ -492,6 +525,7 @@ func (r *reader) Close() error {
 	bytesPool := r.bytesPool
 	tagDecoderPool := r.tagDecoderPool
 	indexEntriesByOffsetAsc := r.indexEntriesByOffsetAsc
+	sealedBuf := r.sealedBuf
 
 	// Reset struct
 	*r = reader{}
@@ -510,10 +544,32 @@ func (r *reader) Close() error {
 	r.bytesPool = bytesPool
 	r.tagDecoderPool = tagDecoderPool
 	r.indexEntriesByOffsetAsc = indexEntriesByOffsetAsc
+	r.sealedBuf = sealedBuf
 
 	return multiErr.FinalError()
 }
 
+// encryptedDataCipher returns the cipher for the key a fileset was encrypted with.
+func encryptedDataCipher(opts Options, keyID string) (*encryption.Cipher, error) {
+	keyring := opts.EncryptionKeyring()
+	if keyring == nil {
+		return nil, errEncryptionKeyringNotSet
+	}
+	return keyring.Cipher(keyID)
+}
+
+// dataEntrySize returns the size of the plaintext data of an index entry
+// given the size of the entry in the data file.
+func dataEntrySize(size int64, cipher *encryption.Cipher) (int, error) {
+	if cipher == nil {
+		return int(size), nil
+	}
+	if size < encryption.Overhead {
+		return 0, errReadNotExpectedSize
+	}
+	return int(size) - encryption.Overhead, nil
+}
+
 // indexEntriesByOffsetAsc implements sort.Sort
 type indexEntriesByOffsetAsc []schema.IndexEntry
 
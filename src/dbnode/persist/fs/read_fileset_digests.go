@@ -21,7 +21,11 @@
 package fs
 
 import (
+	"os"
+	"time"
+
 	"github.com/m3db/m3/src/dbnode/digest"
+	"github.com/m3db/m3x/ident"
 )
 
 // filesetDigests is a container struct for storing a digest for all of the
@@ -61,3 +65,72 @@ func readFileSetDigests(
 
 	return fsDigests, nil
 }
+
+// DataFileSetDigests are the digests of each of the files of a flushed data
+// fileset and the digest of its digests file as recorded by its checkpoint.
+type DataFileSetDigests struct {
+	Info        uint32 `json:"info"`
+	Index       uint32 `json:"index"`
+	Summaries   uint32 `json:"summaries"`
+	BloomFilter uint32 `json:"bloomFilter"`
+	Data        uint32 `json:"data"`
+	Digests     uint32 `json:"digests"`
+}
+
+// ReadDataFileSetDigests reads the digests of the flushed data fileset for
+// a namespace, shard and block start and validates them against the digest
+// recorded in its checkpoint file.
+func ReadDataFileSetDigests(
+	filePathPrefix string,
+	namespace ident.ID,
+	shard uint32,
+	blockStart time.Time,
+	readerBufferSize int,
+) (DataFileSetDigests, error) {
+	var (
+		shardDir           = ShardDataDirPath(filePathPrefix, namespace, shard)
+		checkpointFilepath = filesetPathFromTime(shardDir, blockStart, checkpointFileSuffix)
+		digestFilepath     = filesetPathFromTime(shardDir, blockStart, digestFileSuffix)
+	)
+	exists, err := FileExists(checkpointFilepath)
+	if err != nil {
+		return DataFileSetDigests{}, err
+	}
+	if !exists {
+		return DataFileSetDigests{}, ErrCheckpointFileNotFound
+	}
+
+	checkpointFd, err := os.Open(checkpointFilepath)
+	if err != nil {
+		return DataFileSetDigests{}, err
+	}
+	digestOfDigests, err := digest.NewBuffer().ReadDigestFromFile(checkpointFd)
+	checkpointFd.Close()
+	if err != nil {
+		return DataFileSetDigests{}, err
+	}
+
+	digestFd, err := os.Open(digestFilepath)
+	if err != nil {
+		return DataFileSetDigests{}, err
+	}
+	reader := digest.NewFdWithDigestContentsReader(readerBufferSize)
+	reader.Reset(digestFd)
+	defer reader.Close()
+
+	fsDigests, err := readFileSetDigests(reader)
+	if err != nil {
+		return DataFileSetDigests{}, err
+	}
+	if err := reader.Validate(digestOfDigests); err != nil {
+		return DataFileSetDigests{}, err
+	}
+	return DataFileSetDigests{
+		Info:        fsDigests.infoDigest,
+		Index:       fsDigests.indexDigest,
+		Summaries:   fsDigests.summariesDigest,
+		BloomFilter: fsDigests.bloomFilterDigest,
+		Data:        fsDigests.dataDigest,
+		Digests:     digestOfDigests,
+	}, nil
+}
@@ -0,0 +1,92 @@
+// Copyright (c) 2018 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package fs
+
+import (
+	"errors"
+	"fmt"
+)
+
+var (
+	errReadStrategyUnspecified = errors.New("fileset read strategy unspecified")
+)
+
+// ReadStrategy is the strategy used to read fileset data when seeking
+// to individual series.
+type ReadStrategy uint
+
+const (
+	// ReadStrategyMmap mmaps the data file and hints to the kernel that
+	// access is random, relying on the page cache for recently read data.
+	ReadStrategyMmap ReadStrategy = iota
+	// ReadStrategyBuffered reads series data with positional reads into
+	// pooled buffers, avoiding page cache thrash on nodes where the data
+	// on disk far exceeds the memory available.
+	ReadStrategyBuffered
+
+	// DefaultReadStrategy is the default fileset read strategy.
+	DefaultReadStrategy = ReadStrategyMmap
+)
+
+// ValidReadStrategies returns the valid fileset read strategies.
+func ValidReadStrategies() []ReadStrategy {
+	return []ReadStrategy{ReadStrategyMmap, ReadStrategyBuffered}
+}
+
+func (s ReadStrategy) String() string {
+	switch s {
+	case ReadStrategyMmap:
+		return "mmap"
+	case ReadStrategyBuffered:
+		return "buffered"
+	}
+	return "unknown"
+}
+
+// ParseReadStrategy parses a ReadStrategy from a string.
+func ParseReadStrategy(str string) (ReadStrategy, error) {
+	var r ReadStrategy
+	if str == "" {
+		return r, errReadStrategyUnspecified
+	}
+	for _, valid := range ValidReadStrategies() {
+		if str == valid.String() {
+			r = valid
+			return r, nil
+		}
+	}
+	return r, fmt.Errorf("invalid fileset ReadStrategy '%s' valid types are: %v",
+		str, ValidReadStrategies())
+}
+
+// UnmarshalYAML unmarshals a ReadStrategy into a valid type from string.
+func (s *ReadStrategy) UnmarshalYAML(unmarshal func(interface{}) error) error {
+	var str string
+	if err := unmarshal(&str); err != nil {
+		return err
+	}
+	r, err := ParseReadStrategy(str)
+	if err != nil {
+		return err
+	}
+	*s = r
+	return nil
+}
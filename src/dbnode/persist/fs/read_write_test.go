@@ -31,6 +31,7 @@ import (
 
 	"github.com/m3db/bloom"
 	"github.com/m3db/m3/src/dbnode/digest"
+	"github.com/m3db/m3/src/dbnode/encryption"
 	"github.com/m3db/m3/src/dbnode/persist"
 	"github.com/m3db/m3x/checked"
 	"github.com/m3db/m3x/ident"
@@ -253,6 +254,75 @@ func TestSimpleReadWrite(t *testing.T) {
 	readTestData(t, r, 0, testWriterStart, entries)
 }
 
+type testKeyProvider struct{}
+
+func (p testKeyProvider) CurrentKeyID() (string, error) {
+	return "key1", nil
+}
+
+func (p testKeyProvider) Key(keyID string) ([]byte, error) {
+	return []byte("0123456789abcdef0123456789abcdef"), nil
+}
+
+func TestEncryptedReadWrite(t *testing.T) {
+	dir := createTempDir(t)
+	filePathPrefix := filepath.Join(dir, "")
+	defer os.RemoveAll(dir)
+
+	entries := []testEntry{
+		{"foo", nil, []byte{1, 2, 3}},
+		{"bar", nil, []byte{4, 5, 6}},
+		{"baz", nil, make([]byte, 65536)},
+		{"foo+bar=baz,qux=qaz", map[string]string{
+			"bar": "baz",
+			"qux": "qaz",
+		}, []byte{7, 8, 9}},
+	}
+
+	opts := testDefaultOpts.
+		SetFilePathPrefix(filePathPrefix).
+		SetWriterBufferSize(testWriterBufferSize).
+		SetInfoReaderBufferSize(testReaderBufferSize).
+		SetDataReaderBufferSize(testReaderBufferSize).
+		SetEncryptionKeyring(encryption.NewKeyring(testKeyProvider{})).
+		SetEncryptedNamespaces([]string{testNs1ID.String()})
+
+	w, err := NewWriter(opts)
+	require.NoError(t, err)
+	writeTestData(t, w, 0, testWriterStart, entries, persist.FileSetFlushType)
+
+	// Data is written sealed so is larger than the plaintext
+	dataPath := filesetPathFromTime(
+		ShardDataDirPath(filePathPrefix, testNs1ID, 0), testWriterStart, dataFileSuffix)
+	info, err := os.Stat(dataPath)
+	require.NoError(t, err)
+	require.Equal(t, int64(3+3+65536+3+4*encryption.Overhead), info.Size())
+
+	r, err := NewReader(testBytesPool, opts)
+	require.NoError(t, err)
+	readTestData(t, r, 0, testWriterStart, entries)
+
+	s := newTestSeekerWithOptions(filePathPrefix, opts)
+	require.NoError(t, s.Open(testNs1ID, 0, testWriterStart))
+	data, err := s.SeekByID(ident.StringID("bar"))
+	require.NoError(t, err)
+	data.IncRef()
+	require.Equal(t, []byte{4, 5, 6}, data.Bytes())
+	data.DecRef()
+	require.NoError(t, s.Close())
+
+	// Reading without the keyring fails
+	r = newTestReader(t, filePathPrefix)
+	err = r.Open(DataReaderOpenOptions{
+		Identifier: FileSetFileIdentifier{
+			Namespace:  testNs1ID,
+			Shard:      0,
+			BlockStart: testWriterStart,
+		},
+	})
+	require.Equal(t, errEncryptionKeyringNotSet, err)
+}
+
 func TestDuplicateWrite(t *testing.T) {
 	dir := createTempDir(t)
 	filePathPrefix := filepath.Join(dir, "")
@@ -28,6 +28,7 @@ import (
 	"time"
 
 	"github.com/m3db/m3/src/dbnode/digest"
+	"github.com/m3db/m3/src/dbnode/encryption"
 	"github.com/m3db/m3/src/dbnode/persist/fs/msgpack"
 	"github.com/m3db/m3/src/dbnode/persist/schema"
 	"github.com/m3db/m3/src/x/mmap"
@@ -65,10 +66,18 @@ type seeker struct {
 	entries         int
 	bloomFilterInfo schema.IndexBloomFilterInfo
 	summariesInfo   schema.IndexSummariesInfo
+	// cipher is set when the data of the fileset is encrypted.
+	cipher *encryption.Cipher
 
 	dataMmap  []byte
 	indexMmap []byte
 
+	// dataFd and dataFileSize are only set when using the buffered read
+	// strategy, in which case data is read with positional reads rather
+	// than from an mmap of the data file.
+	dataFd       *os.File
+	dataFileSize int64
+
 	unreadBuf []byte
 
 	decoder      *msgpack.Decoder
@@ -191,7 +200,9 @@ func (s *seeker) Open(namespace ident.ID, shard uint32, blockStart time.Time) er
 		bloomFilterFdWithDigest.Close()
 		summariesFdWithDigest.Close()
 		digestFdWithDigestContents.Close()
-		dataFd.Close()
+		if s.dataFd != dataFd {
+			dataFd.Close()
+		}
 	}()
 
 	infoFdWithDigest.Reset(infoFd)
@@ -199,26 +210,40 @@ func (s *seeker) Open(namespace ident.ID, shard uint32, blockStart time.Time) er
 	summariesFdWithDigest.Reset(summariesFd)
 	digestFdWithDigestContents.Reset(digestFd)
 
-	// Mmap necessary files
+	// Mmap necessary files, seeks into both the index and data files are
+	// random so hint as such to avoid reading ahead into the page cache
 	mmapOptions := mmap.Options{
 		Read: true,
 		HugeTLB: mmap.HugeTLBOptions{
 			Enabled:   s.opts.opts.MmapEnableHugeTLB(),
 			Threshold: s.opts.opts.MmapHugeTLBThreshold(),
 		},
+		Advice: mmap.AdviceRandom,
 	}
-	mmapResult, err := mmap.Files(os.Open, map[string]mmap.FileDesc{
+	mmapFiles := map[string]mmap.FileDesc{
 		filesetPathFromTime(shardDir, blockStart, indexFileSuffix): mmap.FileDesc{
 			File:    &indexFd,
 			Bytes:   &s.indexMmap,
 			Options: mmapOptions,
 		},
-		filesetPathFromTime(shardDir, blockStart, dataFileSuffix): mmap.FileDesc{
+	}
+	switch s.opts.opts.SeekReadStrategy() {
+	case ReadStrategyBuffered:
+		dataStat, err := dataFd.Stat()
+		if err != nil {
+			s.Close()
+			return err
+		}
+		s.dataFd = dataFd
+		s.dataFileSize = dataStat.Size()
+	default:
+		mmapFiles[filesetPathFromTime(shardDir, blockStart, dataFileSuffix)] = mmap.FileDesc{
 			File:    &dataFd,
 			Bytes:   &s.dataMmap,
 			Options: mmapOptions,
-		},
-	})
+		}
+	}
+	mmapResult, err := mmap.Files(os.Open, mmapFiles)
 	if err != nil {
 		s.Close()
 		return err
@@ -321,6 +346,13 @@ func (s *seeker) readInfo(size int, infoDigestReader digest.FdWithDigestReader,
 	s.entries = int(info.Entries)
 	s.bloomFilterInfo = info.BloomFilter
 	s.summariesInfo = info.Summaries
+	s.cipher = nil
+	if info.EncryptionKeyID != "" {
+		s.cipher, err = encryptedDataCipher(s.opts.opts, info.EncryptionKeyID)
+		if err != nil {
+			return err
+		}
+	}
 
 	return nil
 }
@@ -340,37 +372,64 @@ func (s *seeker) SeekByID(id ident.ID) (checked.Bytes, error) {
 // instead of looking it up on its own. Useful in cases where you've already
 // obtained an entry and don't want to waste resources looking it up again.
 func (s *seeker) SeekByIndexEntry(entry IndexEntry) (checked.Bytes, error) {
+	dataLen := int64(len(s.dataMmap))
+	if s.dataFd != nil {
+		dataLen = s.dataFileSize
+	}
+
 	// Should never happen, but prevent panics if somehow we're provided an index entry
 	// with a negative or too large offset
-	if int(entry.Offset) > len(s.dataMmap)-1 {
+	if entry.Offset < 0 || entry.Offset > dataLen-1 {
 		return nil, errInvalidDataFileOffset
 	}
 
-	// We'll treat "data" similar to a reader interface, I.E after every read we'll
-	// reslice it such that the first byte is the next byte we want to read.
-	data := s.dataMmap[entry.Offset:]
-
 	// Should never happen, but prevents panics in the case of malformed data
-	if len(data) < int(entry.Size) {
+	if dataLen-entry.Offset < int64(entry.Size) {
 		return nil, errNotEnoughBytes
 	}
 
+	size, err := dataEntrySize(int64(entry.Size), s.cipher)
+	if err != nil {
+		return nil, err
+	}
+
 	// Obtain an appropriately sized buffer
 	var buffer checked.Bytes
 	if s.bytesPool != nil {
-		buffer = s.bytesPool.Get(int(entry.Size))
+		buffer = s.bytesPool.Get(size)
 		buffer.IncRef()
 		defer buffer.DecRef()
-		buffer.Resize(int(entry.Size))
+		buffer.Resize(size)
 	} else {
-		buffer = checked.NewBytes(make([]byte, entry.Size), nil)
+		buffer = checked.NewBytes(make([]byte, size), nil)
 		buffer.IncRef()
 		defer buffer.DecRef()
 	}
 
-	// Copy the actual data into the underlying buffer
 	underlyingBuf := buffer.Bytes()
-	copy(underlyingBuf, data[:entry.Size])
+	if s.cipher != nil {
+		// Clones are used concurrently so the sealed data is not read into
+		// a buffer shared with the parent.
+		var sealed []byte
+		if s.dataFd != nil {
+			sealed = make([]byte, entry.Size)
+			if _, err := s.dataFd.ReadAt(sealed, entry.Offset); err != nil {
+				return nil, err
+			}
+		} else {
+			sealed = s.dataMmap[entry.Offset : entry.Offset+int64(entry.Size)]
+		}
+		if _, err := s.cipher.Open(underlyingBuf[:0], sealed); err != nil {
+			return nil, err
+		}
+	} else if s.dataFd != nil {
+		// Copy the actual data into the underlying buffer
+		if _, err := s.dataFd.ReadAt(underlyingBuf, entry.Offset); err != nil {
+			return nil, err
+		}
+	} else {
+		copy(underlyingBuf, s.dataMmap[entry.Offset:entry.Offset+int64(entry.Size)])
+	}
 
 	// NB(r): _must_ check the checksum against known checksum as the data
 	// file might not have been verified if we haven't read through the file yet.
@@ -454,6 +513,10 @@ func (s *seeker) Close() error {
 		multiErr = multiErr.Add(mmap.Munmap(s.dataMmap))
 		s.dataMmap = nil
 	}
+	if s.dataFd != nil {
+		multiErr = multiErr.Add(s.dataFd.Close())
+		s.dataFd = nil
+	}
 	return multiErr.FinalError()
 }
 
@@ -470,9 +533,14 @@ func (s *seeker) ConcurrentClone() (ConcurrentDataFileSetSeeker, error) {
 		bytesPool: s.bytesPool,
 		decoder:   msgpack.NewDecoder(s.decodingOpts),
 		opts:      s.opts,
+		cipher:    s.cipher,
 		// Mmaps are read-only so they're concurrency safe
 		dataMmap:  s.dataMmap,
 		indexMmap: s.indexMmap,
+		// Positional reads do not share a file offset so they're
+		// concurrency safe
+		dataFd:       s.dataFd,
+		dataFileSize: s.dataFileSize,
 		// bloomFilter is concurrency safe
 		bloomFilter: s.bloomFilter,
 		indexLookup: indexLookupClone,
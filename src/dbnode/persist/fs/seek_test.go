@@ -36,6 +36,10 @@ import (
 )
 
 func newTestSeeker(filePathPrefix string) DataFileSetSeeker {
+	return newTestSeekerWithOptions(filePathPrefix, testDefaultOpts)
+}
+
+func newTestSeekerWithOptions(filePathPrefix string, opts Options) DataFileSetSeeker {
 	bytesPool := pool.NewCheckedBytesPool([]pool.Bucket{pool.Bucket{
 		Capacity: 1024,
 		Count:    10,
@@ -44,7 +48,7 @@ func newTestSeeker(filePathPrefix string) DataFileSetSeeker {
 	})
 	bytesPool.Init()
 	return NewSeeker(filePathPrefix, testReaderBufferSize, testReaderBufferSize,
-		testReaderBufferSize, bytesPool, false, nil, testDefaultOpts)
+		testReaderBufferSize, bytesPool, false, nil, opts)
 }
 
 func TestSeekEmptyIndex(t *testing.T) {
@@ -401,3 +405,59 @@ func TestCloneSeeker(t *testing.T) {
 	defer data.DecRef()
 	assert.Equal(t, []byte{1, 2, 1}, data.Bytes())
 }
+
+func TestSeekBufferedReadStrategy(t *testing.T) {
+	dir, err := ioutil.TempDir("", "testdb")
+	if err != nil {
+		t.Fatal(err)
+	}
+	filePathPrefix := filepath.Join(dir, "")
+	defer os.RemoveAll(dir)
+
+	w := newTestWriter(t, filePathPrefix)
+	writerOpts := DataWriterOpenOptions{
+		BlockSize: testBlockSize,
+		Identifier: FileSetFileIdentifier{
+			Namespace:  testNs1ID,
+			Shard:      0,
+			BlockStart: testWriterStart,
+		},
+	}
+	err = w.Open(writerOpts)
+	assert.NoError(t, err)
+	assert.NoError(t, w.Write(
+		ident.StringID("foo1"), ident.Tags{},
+		bytesRefd([]byte{1, 2, 1}),
+		digest.Checksum([]byte{1, 2, 1})))
+	assert.NoError(t, w.Write(
+		ident.StringID("foo2"), ident.Tags{},
+		bytesRefd([]byte{1, 2, 2}),
+		digest.Checksum([]byte{1, 2, 2})))
+	assert.NoError(t, w.Close())
+
+	opts := testDefaultOpts.SetSeekReadStrategy(ReadStrategyBuffered)
+	s := newTestSeekerWithOptions(filePathPrefix, opts)
+	err = s.Open(testNs1ID, 0, testWriterStart)
+	require.NoError(t, err)
+	assert.Nil(t, s.(*seeker).dataMmap)
+
+	data, err := s.SeekByID(ident.StringID("foo2"))
+	require.NoError(t, err)
+
+	data.IncRef()
+	defer data.DecRef()
+	assert.Equal(t, []byte{1, 2, 2}, data.Bytes())
+
+	clone, err := s.ConcurrentClone()
+	require.NoError(t, err)
+
+	data, err = clone.SeekByID(ident.StringID("foo1"))
+	require.NoError(t, err)
+
+	data.IncRef()
+	defer data.DecRef()
+	assert.Equal(t, []byte{1, 2, 1}, data.Bytes())
+
+	require.NoError(t, clone.Close())
+	assert.NoError(t, s.Close())
+}
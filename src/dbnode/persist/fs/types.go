@@ -26,6 +26,7 @@ import (
 	"time"
 
 	"github.com/m3db/m3/src/dbnode/clock"
+	"github.com/m3db/m3/src/dbnode/encryption"
 	"github.com/m3db/m3/src/dbnode/persist"
 	"github.com/m3db/m3/src/dbnode/persist/fs/msgpack"
 	"github.com/m3db/m3/src/dbnode/runtime"
@@ -61,6 +62,12 @@ type DataWriterOpenOptions struct {
 	BlockSize          time.Duration
 	// Only used when writing snapshot files
 	Snapshot DataWriterSnapshotOptions
+	// IndexSummariesPercent overrides the percent of index summaries to
+	// write if greater than zero
+	IndexSummariesPercent float64
+	// BloomFilterFalsePositivePercent overrides the percent of false
+	// positive rate to use for the bloom filter if greater than zero
+	BloomFilterFalsePositivePercent float64
 }
 
 // DataWriterSnapshotOptions is the options struct for Open method on the DataFileSetWriter
@@ -409,6 +416,25 @@ type Options interface {
 	// MmapHugeTLBThreshold returns the threshold when to use mmap huge pages for mmap'd files on linux
 	MmapHugeTLBThreshold() int64
 
+	// SetSeekReadStrategy sets the strategy used to read fileset data when seeking
+	SetSeekReadStrategy(value ReadStrategy) Options
+
+	// SeekReadStrategy returns the strategy used to read fileset data when seeking
+	SeekReadStrategy() ReadStrategy
+
+	// SetEncryptionKeyring sets the keyring of the keys fileset data is encrypted with,
+	// encrypted filesets can only be read when it is set
+	SetEncryptionKeyring(value *encryption.Keyring) Options
+
+	// EncryptionKeyring returns the keyring of the keys fileset data is encrypted with
+	EncryptionKeyring() *encryption.Keyring
+
+	// SetEncryptedNamespaces sets the namespaces whose filesets are encrypted when written
+	SetEncryptedNamespaces(value []string) Options
+
+	// EncryptedNamespaces returns the namespaces whose filesets are encrypted when written
+	EncryptedNamespaces() []string
+
 	// SetTagEncoderPool sets the tag encoder pool
 	SetTagEncoderPool(value serialize.TagEncoderPool) Options
 
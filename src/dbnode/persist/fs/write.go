@@ -31,6 +31,7 @@ import (
 
 	"github.com/m3db/bloom"
 	"github.com/m3db/m3/src/dbnode/digest"
+	"github.com/m3db/m3/src/dbnode/encryption"
 	"github.com/m3db/m3/src/dbnode/persist"
 	"github.com/m3db/m3/src/dbnode/persist/fs/msgpack"
 	"github.com/m3db/m3/src/dbnode/persist/schema"
@@ -51,8 +52,10 @@ type writer struct {
 	newFileMode      os.FileMode
 	newDirectoryMode os.FileMode
 
-	summariesPercent                float64
-	bloomFilterFalsePositivePercent float64
+	defaultSummariesPercent                float64
+	defaultBloomFilterFalsePositivePercent float64
+	summariesPercent                       float64
+	bloomFilterFalsePositivePercent        float64
 
 	infoFdWithDigest           digest.FdWithDigestWriter
 	indexFdWithDigest          digest.FdWithDigestWriter
@@ -72,6 +75,14 @@ type writer struct {
 	singleCheckedBytes []checked.Bytes
 	tagEncoderPool     serialize.TagEncoderPool
 	err                error
+
+	// cipher is set when the data of the fileset being written is
+	// encrypted, each data entry is sealed separately.
+	encryptionKeyring   *encryption.Keyring
+	encryptedNamespaces map[string]struct{}
+	cipher              *encryption.Cipher
+	plaintextBuf        []byte
+	sealedBuf           []byte
 }
 
 type indexEntry struct {
@@ -110,22 +121,28 @@ func NewWriter(opts Options) (DataFileSetWriter, error) {
 		return nil, err
 	}
 	bufferSize := opts.WriterBufferSize()
+	encryptedNamespaces := make(map[string]struct{}, len(opts.EncryptedNamespaces()))
+	for _, namespace := range opts.EncryptedNamespaces() {
+		encryptedNamespaces[namespace] = struct{}{}
+	}
 	return &writer{
-		filePathPrefix:                  opts.FilePathPrefix(),
-		newFileMode:                     opts.NewFileMode(),
-		newDirectoryMode:                opts.NewDirectoryMode(),
-		summariesPercent:                opts.IndexSummariesPercent(),
-		bloomFilterFalsePositivePercent: opts.IndexBloomFilterFalsePositivePercent(),
-		infoFdWithDigest:                digest.NewFdWithDigestWriter(bufferSize),
-		indexFdWithDigest:               digest.NewFdWithDigestWriter(bufferSize),
-		summariesFdWithDigest:           digest.NewFdWithDigestWriter(bufferSize),
-		bloomFilterFdWithDigest:         digest.NewFdWithDigestWriter(bufferSize),
-		dataFdWithDigest:                digest.NewFdWithDigestWriter(bufferSize),
-		digestFdWithDigestContents:      digest.NewFdWithDigestContentsWriter(bufferSize),
-		encoder:                         msgpack.NewEncoder(),
-		digestBuf:                       digest.NewBuffer(),
-		singleCheckedBytes:              make([]checked.Bytes, 1),
-		tagEncoderPool:                  opts.TagEncoderPool(),
+		filePathPrefix:                         opts.FilePathPrefix(),
+		newFileMode:                            opts.NewFileMode(),
+		newDirectoryMode:                       opts.NewDirectoryMode(),
+		defaultSummariesPercent:                opts.IndexSummariesPercent(),
+		defaultBloomFilterFalsePositivePercent: opts.IndexBloomFilterFalsePositivePercent(),
+		infoFdWithDigest:                       digest.NewFdWithDigestWriter(bufferSize),
+		indexFdWithDigest:                      digest.NewFdWithDigestWriter(bufferSize),
+		summariesFdWithDigest:                  digest.NewFdWithDigestWriter(bufferSize),
+		bloomFilterFdWithDigest:                digest.NewFdWithDigestWriter(bufferSize),
+		dataFdWithDigest:                       digest.NewFdWithDigestWriter(bufferSize),
+		digestFdWithDigestContents:             digest.NewFdWithDigestContentsWriter(bufferSize),
+		encoder:                                msgpack.NewEncoder(),
+		digestBuf:                              digest.NewBuffer(),
+		singleCheckedBytes:                     make([]checked.Bytes, 1),
+		tagEncoderPool:                         opts.TagEncoderPool(),
+		encryptionKeyring:                      opts.EncryptionKeyring(),
+		encryptedNamespaces:                    encryptedNamespaces,
 	}, nil
 }
 
@@ -143,11 +160,29 @@ func (w *writer) Open(opts DataWriterOpenOptions) error {
 
 	w.blockSize = opts.BlockSize
 	w.start = blockStart
+	w.summariesPercent = w.defaultSummariesPercent
+	if opts.IndexSummariesPercent > 0 {
+		w.summariesPercent = opts.IndexSummariesPercent
+	}
+	w.bloomFilterFalsePositivePercent = w.defaultBloomFilterFalsePositivePercent
+	if opts.BloomFilterFalsePositivePercent > 0 {
+		w.bloomFilterFalsePositivePercent = opts.BloomFilterFalsePositivePercent
+	}
 	w.snapshotTime = opts.Snapshot.SnapshotTime
 	w.currIdx = 0
 	w.currOffset = 0
 	w.err = nil
 
+	w.cipher = nil
+	if _, ok := w.encryptedNamespaces[namespace.String()]; ok {
+		// Encrypt with the key that is current when the fileset is opened,
+		// its ID is written to the info file for readers.
+		w.cipher, err = w.encryptionKeyring.Current()
+		if err != nil {
+			return err
+		}
+	}
+
 	var (
 		shardDir            string
 		infoFilepath        string
@@ -280,13 +315,34 @@ func (w *writer) writeAll(
 		size:           uint32(size),
 		checksum:       checksum,
 	}
-	for _, d := range data {
-		if d == nil {
-			continue
+	if w.cipher != nil {
+		// The checksum remains that of the plaintext so that it can be
+		// compared with the checksums of replicas.
+		w.plaintextBuf = w.plaintextBuf[:0]
+		for _, d := range data {
+			if d == nil {
+				continue
+			}
+			w.plaintextBuf = append(w.plaintextBuf, d.Bytes()...)
 		}
-		if err := w.writeData(d.Bytes()); err != nil {
+		sealed, err := w.cipher.Seal(w.sealedBuf[:0], w.plaintextBuf)
+		if err != nil {
 			return err
 		}
+		w.sealedBuf = sealed
+		entry.size = uint32(len(sealed))
+		if err := w.writeData(sealed); err != nil {
+			return err
+		}
+	} else {
+		for _, d := range data {
+			if d == nil {
+				continue
+			}
+			if err := w.writeData(d.Bytes()); err != nil {
+				return err
+			}
+		}
 	}
 
 	w.indexEntries = append(w.indexEntries, entry)
@@ -529,6 +585,9 @@ func (w *writer) writeInfoFileContents(
 			NumHashesK:   int64(bloomFilter.K()),
 		},
 	}
+	if w.cipher != nil {
+		info.EncryptionKeyID = w.cipher.KeyID()
+	}
 
 	w.encoder.Reset()
 	if err := w.encoder.EncodeIndexInfo(info); err != nil {
@@ -39,6 +39,10 @@ type IndexInfo struct {
 	BloomFilter  IndexBloomFilterInfo
 	SnapshotTime int64
 	FileType     persist.FileSetType
+
+	// EncryptionKeyID is the ID of the key the data file entries are
+	// encrypted with, empty if they are not encrypted.
+	EncryptionKeyID string
 }
 
 // IndexSummariesInfo stores metadata about the summaries
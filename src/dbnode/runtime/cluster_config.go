@@ -0,0 +1,247 @@
+// Copyright (c) 2018 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package runtime
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"time"
+)
+
+const (
+	// MaxClusterConfigHistory is the number of previous versions of the
+	// cluster runtime configuration kept to allow rolling back.
+	MaxClusterConfigHistory = 10
+)
+
+var (
+	errClusterConfigVersionNotFound = errors.New(
+		"cluster runtime config version not found in history")
+	errClusterConfigNegativeValue = errors.New(
+		"cluster runtime config values cannot be negative")
+)
+
+// ClusterConfigValues are the tunable runtime options that can be set
+// cluster wide, options that are not set keep the value the node was
+// configured with.
+type ClusterConfigValues struct {
+	PersistRateLimitEnabled    *bool    `json:"persistRateLimitEnabled,omitempty"`
+	PersistRateLimitMbps       *float64 `json:"persistRateLimitMbps,omitempty"`
+	WriteNewSeriesAsync        *bool    `json:"writeNewSeriesAsync,omitempty"`
+	WriteNewSeriesBackoffNanos *int64   `json:"writeNewSeriesBackoffNanos,omitempty"`
+	TickSeriesBatchSize        *int     `json:"tickSeriesBatchSize,omitempty"`
+	TickPerSeriesSleepNanos    *int64   `json:"tickPerSeriesSleepNanos,omitempty"`
+	TickMinimumIntervalNanos   *int64   `json:"tickMinimumIntervalNanos,omitempty"`
+	MaxWiredBlocks             *uint    `json:"maxWiredBlocks,omitempty"`
+	FlushIndexBlockNumSegments *uint    `json:"flushIndexBlockNumSegments,omitempty"`
+}
+
+// Validate validates the values.
+func (v ClusterConfigValues) Validate() error {
+	for _, value := range []*int64{
+		v.WriteNewSeriesBackoffNanos,
+		v.TickPerSeriesSleepNanos,
+		v.TickMinimumIntervalNanos,
+	} {
+		if value != nil && *value < 0 {
+			return errClusterConfigNegativeValue
+		}
+	}
+	if v.TickSeriesBatchSize != nil && *v.TickSeriesBatchSize < 0 {
+		return errClusterConfigNegativeValue
+	}
+	if v.PersistRateLimitMbps != nil && *v.PersistRateLimitMbps < 0 {
+		return errClusterConfigNegativeValue
+	}
+	return nil
+}
+
+// Merge returns the values with any values set in the override replacing
+// the existing values.
+func (v ClusterConfigValues) Merge(override ClusterConfigValues) ClusterConfigValues {
+	merged := v
+	if override.PersistRateLimitEnabled != nil {
+		merged.PersistRateLimitEnabled = override.PersistRateLimitEnabled
+	}
+	if override.PersistRateLimitMbps != nil {
+		merged.PersistRateLimitMbps = override.PersistRateLimitMbps
+	}
+	if override.WriteNewSeriesAsync != nil {
+		merged.WriteNewSeriesAsync = override.WriteNewSeriesAsync
+	}
+	if override.WriteNewSeriesBackoffNanos != nil {
+		merged.WriteNewSeriesBackoffNanos = override.WriteNewSeriesBackoffNanos
+	}
+	if override.TickSeriesBatchSize != nil {
+		merged.TickSeriesBatchSize = override.TickSeriesBatchSize
+	}
+	if override.TickPerSeriesSleepNanos != nil {
+		merged.TickPerSeriesSleepNanos = override.TickPerSeriesSleepNanos
+	}
+	if override.TickMinimumIntervalNanos != nil {
+		merged.TickMinimumIntervalNanos = override.TickMinimumIntervalNanos
+	}
+	if override.MaxWiredBlocks != nil {
+		merged.MaxWiredBlocks = override.MaxWiredBlocks
+	}
+	if override.FlushIndexBlockNumSegments != nil {
+		merged.FlushIndexBlockNumSegments = override.FlushIndexBlockNumSegments
+	}
+	return merged
+}
+
+// Apply returns the options with the values applied, options that are not
+// set are reset to their value in the defaults so that unsetting a value
+// restores the configured value.
+func (v ClusterConfigValues) Apply(opts, defaults Options) Options {
+	rateLimitOpts := opts.PersistRateLimitOptions().
+		SetLimitEnabled(defaults.PersistRateLimitOptions().LimitEnabled()).
+		SetLimitMbps(defaults.PersistRateLimitOptions().LimitMbps())
+	if v.PersistRateLimitEnabled != nil {
+		rateLimitOpts = rateLimitOpts.SetLimitEnabled(*v.PersistRateLimitEnabled)
+	}
+	if v.PersistRateLimitMbps != nil {
+		rateLimitOpts = rateLimitOpts.SetLimitMbps(*v.PersistRateLimitMbps)
+	}
+
+	opts = opts.
+		SetPersistRateLimitOptions(rateLimitOpts).
+		SetWriteNewSeriesAsync(defaults.WriteNewSeriesAsync()).
+		SetWriteNewSeriesBackoffDuration(defaults.WriteNewSeriesBackoffDuration()).
+		SetTickSeriesBatchSize(defaults.TickSeriesBatchSize()).
+		SetTickPerSeriesSleepDuration(defaults.TickPerSeriesSleepDuration()).
+		SetTickMinimumInterval(defaults.TickMinimumInterval()).
+		SetMaxWiredBlocks(defaults.MaxWiredBlocks()).
+		SetFlushIndexBlockNumSegments(defaults.FlushIndexBlockNumSegments())
+	if v.WriteNewSeriesAsync != nil {
+		opts = opts.SetWriteNewSeriesAsync(*v.WriteNewSeriesAsync)
+	}
+	if v.WriteNewSeriesBackoffNanos != nil {
+		opts = opts.SetWriteNewSeriesBackoffDuration(time.Duration(*v.WriteNewSeriesBackoffNanos))
+	}
+	if v.TickSeriesBatchSize != nil {
+		opts = opts.SetTickSeriesBatchSize(*v.TickSeriesBatchSize)
+	}
+	if v.TickPerSeriesSleepNanos != nil {
+		opts = opts.SetTickPerSeriesSleepDuration(time.Duration(*v.TickPerSeriesSleepNanos))
+	}
+	if v.TickMinimumIntervalNanos != nil {
+		opts = opts.SetTickMinimumInterval(time.Duration(*v.TickMinimumIntervalNanos))
+	}
+	if v.MaxWiredBlocks != nil {
+		opts = opts.SetMaxWiredBlocks(*v.MaxWiredBlocks)
+	}
+	if v.FlushIndexBlockNumSegments != nil {
+		opts = opts.SetFlushIndexBlockNumSegments(*v.FlushIndexBlockNumSegments)
+	}
+	return opts
+}
+
+// ClusterConfig is a version of the cluster runtime configuration.
+type ClusterConfig struct {
+	Version   int                            `json:"version"`
+	Values    ClusterConfigValues            `json:"values"`
+	Overrides map[string]ClusterConfigValues `json:"overrides,omitempty"`
+}
+
+// Validate validates the cluster runtime configuration.
+func (c ClusterConfig) Validate() error {
+	if err := c.Values.Validate(); err != nil {
+		return err
+	}
+	for hostID, override := range c.Overrides {
+		if err := override.Validate(); err != nil {
+			return fmt.Errorf("invalid override for host %s: %v", hostID, err)
+		}
+	}
+	return nil
+}
+
+// HostValues returns the values for a host, with any overrides for the
+// host replacing the cluster wide values.
+func (c ClusterConfig) HostValues(hostID string) ClusterConfigValues {
+	override, ok := c.Overrides[hostID]
+	if !ok {
+		return c.Values
+	}
+	return c.Values.Merge(override)
+}
+
+// ClusterConfigHistory is the current cluster runtime configuration and the
+// previous versions it can be rolled back to, most recent first.
+type ClusterConfigHistory struct {
+	Current  ClusterConfig   `json:"current"`
+	Previous []ClusterConfig `json:"previous,omitempty"`
+}
+
+// ParseClusterConfigHistory parses a cluster runtime configuration history
+// from its JSON representation.
+func ParseClusterConfigHistory(value string) (ClusterConfigHistory, error) {
+	var history ClusterConfigHistory
+	if err := json.Unmarshal([]byte(value), &history); err != nil {
+		return ClusterConfigHistory{}, err
+	}
+	return history, nil
+}
+
+// String returns the JSON representation of the history.
+func (h ClusterConfigHistory) String() string {
+	data, err := json.Marshal(h)
+	if err != nil {
+		return fmt.Sprintf("ClusterConfigHistory{current: %d, previous: %d, err: %v}",
+			h.Current.Version, len(h.Previous), err)
+	}
+	return string(data)
+}
+
+// Update returns the history with the config as the new current version.
+func (h ClusterConfigHistory) Update(cfg ClusterConfig) (ClusterConfigHistory, error) {
+	if err := cfg.Validate(); err != nil {
+		return ClusterConfigHistory{}, err
+	}
+
+	previous := h.Previous
+	if h.Current.Version > 0 {
+		previous = append([]ClusterConfig{h.Current}, previous...)
+	}
+	if len(previous) > MaxClusterConfigHistory {
+		previous = previous[:MaxClusterConfigHistory]
+	}
+
+	cfg.Version = h.Current.Version + 1
+	return ClusterConfigHistory{
+		Current:  cfg,
+		Previous: previous,
+	}, nil
+}
+
+// Rollback returns the history with a previous version of the config as
+// the new current version, the rolled back config is given a new version
+// so that versions always increase.
+func (h ClusterConfigHistory) Rollback(version int) (ClusterConfigHistory, error) {
+	for _, cfg := range h.Previous {
+		if cfg.Version == version {
+			return h.Update(cfg)
+		}
+	}
+	return ClusterConfigHistory{}, errClusterConfigVersionNotFound
+}
@@ -0,0 +1,124 @@
+// Copyright (c) 2018 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package runtime
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestClusterConfigHostValues(t *testing.T) {
+	var (
+		batchSize         = 128
+		overrideBatchSize = 256
+		maxWiredBlocks    = uint(1024)
+	)
+	cfg := ClusterConfig{
+		Values: ClusterConfigValues{
+			TickSeriesBatchSize: &batchSize,
+			MaxWiredBlocks:      &maxWiredBlocks,
+		},
+		Overrides: map[string]ClusterConfigValues{
+			"host1": {TickSeriesBatchSize: &overrideBatchSize},
+		},
+	}
+
+	values := cfg.HostValues("host1")
+	assert.Equal(t, overrideBatchSize, *values.TickSeriesBatchSize)
+	assert.Equal(t, maxWiredBlocks, *values.MaxWiredBlocks)
+
+	values = cfg.HostValues("host2")
+	assert.Equal(t, batchSize, *values.TickSeriesBatchSize)
+	assert.Equal(t, maxWiredBlocks, *values.MaxWiredBlocks)
+}
+
+func TestClusterConfigValuesApply(t *testing.T) {
+	var (
+		defaults     = NewOptions()
+		batchSize    = 128
+		interval     = int64(5 * time.Minute)
+		limitEnabled = true
+	)
+	values := ClusterConfigValues{
+		TickSeriesBatchSize:      &batchSize,
+		TickMinimumIntervalNanos: &interval,
+		PersistRateLimitEnabled:  &limitEnabled,
+	}
+
+	opts := values.Apply(defaults, defaults)
+	require.NoError(t, opts.Validate())
+	assert.Equal(t, batchSize, opts.TickSeriesBatchSize())
+	assert.Equal(t, 5*time.Minute, opts.TickMinimumInterval())
+	assert.True(t, opts.PersistRateLimitOptions().LimitEnabled())
+	assert.Equal(t, defaults.MaxWiredBlocks(), opts.MaxWiredBlocks())
+
+	// Unset values are restored to the defaults
+	opts = ClusterConfigValues{}.Apply(opts, defaults)
+	assert.Equal(t, defaults.TickSeriesBatchSize(), opts.TickSeriesBatchSize())
+	assert.Equal(t, defaults.TickMinimumInterval(), opts.TickMinimumInterval())
+	assert.Equal(t, defaults.PersistRateLimitOptions().LimitEnabled(),
+		opts.PersistRateLimitOptions().LimitEnabled())
+}
+
+func TestClusterConfigValidate(t *testing.T) {
+	negative := -1
+	cfg := ClusterConfig{
+		Overrides: map[string]ClusterConfigValues{
+			"host1": {TickSeriesBatchSize: &negative},
+		},
+	}
+	require.Error(t, cfg.Validate())
+
+	_, err := ClusterConfigHistory{}.Update(cfg)
+	require.Error(t, err)
+}
+
+func TestClusterConfigHistoryUpdateAndRollback(t *testing.T) {
+	var history ClusterConfigHistory
+	for i := 1; i <= MaxClusterConfigHistory+2; i++ {
+		batchSize := i
+		var err error
+		history, err = history.Update(ClusterConfig{
+			Values: ClusterConfigValues{TickSeriesBatchSize: &batchSize},
+		})
+		require.NoError(t, err)
+		assert.Equal(t, i, history.Current.Version)
+	}
+	require.Len(t, history.Previous, MaxClusterConfigHistory)
+	assert.Equal(t, MaxClusterConfigHistory+1, history.Previous[0].Version)
+
+	// Round trip through the stored representation
+	parsed, err := ParseClusterConfigHistory(history.String())
+	require.NoError(t, err)
+	assert.Equal(t, history, parsed)
+
+	rolledBack, err := parsed.Rollback(5)
+	require.NoError(t, err)
+	assert.Equal(t, MaxClusterConfigHistory+3, rolledBack.Current.Version)
+	assert.Equal(t, 5, *rolledBack.Current.Values.TickSeriesBatchSize)
+	assert.Equal(t, history.Current, rolledBack.Previous[0])
+
+	_, err = parsed.Rollback(1)
+	require.Equal(t, errClusterConfigVersionNotFound, err)
+}
@@ -0,0 +1,132 @@
+// Copyright (c) 2018 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package runtime
+
+import (
+	"errors"
+	"fmt"
+	"time"
+)
+
+const (
+	day = 24 * time.Hour
+
+	// DefaultFileOpsMaxDeferral is the default longest file operations may
+	// be deferred waiting for a window.
+	DefaultFileOpsMaxDeferral = 12 * time.Hour
+)
+
+var (
+	errFileOpsMaxDeferralIsNegative = errors.New(
+		"file ops schedule max deferral cannot be negative")
+)
+
+// FileOpsWindow is a daily window of time, as offsets from midnight UTC,
+// during which heavyweight file operations are permitted to run. Windows
+// with an end before their start wrap around midnight.
+type FileOpsWindow struct {
+	Start time.Duration `json:"start"`
+	End   time.Duration `json:"end"`
+}
+
+// Validate validates the window.
+func (w FileOpsWindow) Validate() error {
+	if w.Start < 0 || w.Start >= day || w.End < 0 || w.End >= day {
+		return fmt.Errorf(
+			"file ops window start and end must be within a day: start=%v, end=%v",
+			w.Start, w.End)
+	}
+	if w.Start == w.End {
+		return fmt.Errorf("file ops window must not be empty: start=%v, end=%v",
+			w.Start, w.End)
+	}
+	return nil
+}
+
+// Contains returns whether the time of day of the given time falls within
+// the window.
+func (w FileOpsWindow) Contains(t time.Time) bool {
+	t = t.UTC()
+	offset := t.Sub(time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, time.UTC))
+	if w.Start < w.End {
+		return offset >= w.Start && offset < w.End
+	}
+	return offset >= w.Start || offset < w.End
+}
+
+// FileOpsSchedule restricts when heavyweight file operations such as
+// flushes, cleanups and index compactions are run.
+type FileOpsSchedule struct {
+	// Windows are the daily windows file operations may run in, file
+	// operations may run at any time if no windows are specified.
+	Windows []FileOpsWindow `json:"windows"`
+
+	// MaxDeferral is the longest file operations that must eventually run,
+	// such as flushes, may be deferred waiting for a window before being run
+	// regardless. Zero specifies the default of 12 hours, file operations
+	// are never deferred indefinitely since data that is not flushed is
+	// held in memory.
+	MaxDeferral time.Duration `json:"maxDeferral"`
+}
+
+// Validate validates the schedule.
+func (s FileOpsSchedule) Validate() error {
+	if s.MaxDeferral < 0 {
+		return errFileOpsMaxDeferralIsNegative
+	}
+	for _, w := range s.Windows {
+		if err := w.Validate(); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Allows returns whether file operations are permitted to run at the given time.
+func (s FileOpsSchedule) Allows(t time.Time) bool {
+	if len(s.Windows) == 0 {
+		return true
+	}
+	for _, w := range s.Windows {
+		if w.Contains(t) {
+			return true
+		}
+	}
+	return false
+}
+
+// AllowsDeferred returns whether file operations that last ran at the given
+// time are permitted to run, either because the time is within a window or
+// they have already been deferred for longer than the max deferral.
+func (s FileOpsSchedule) AllowsDeferred(t, lastRun time.Time) bool {
+	if s.Allows(t) {
+		return true
+	}
+	return !lastRun.IsZero() && t.Sub(lastRun) >= s.MaxDeferralOrDefault()
+}
+
+// MaxDeferralOrDefault returns the max deferral or the default if not set.
+func (s FileOpsSchedule) MaxDeferralOrDefault() time.Duration {
+	if s.MaxDeferral <= 0 {
+		return DefaultFileOpsMaxDeferral
+	}
+	return s.MaxDeferral
+}
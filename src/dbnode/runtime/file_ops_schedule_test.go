@@ -0,0 +1,86 @@
+// Copyright (c) 2018 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package runtime
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestFileOpsWindowContains(t *testing.T) {
+	at := func(hour, minute int) time.Time {
+		return time.Date(2018, time.May, 1, hour, minute, 0, 0, time.UTC)
+	}
+
+	w := FileOpsWindow{Start: 2 * time.Hour, End: 6 * time.Hour}
+	require.NoError(t, w.Validate())
+	assert.False(t, w.Contains(at(1, 59)))
+	assert.True(t, w.Contains(at(2, 0)))
+	assert.True(t, w.Contains(at(5, 59)))
+	assert.False(t, w.Contains(at(6, 0)))
+
+	// Windows ending before they start wrap around midnight.
+	wrapped := FileOpsWindow{Start: 22 * time.Hour, End: 2 * time.Hour}
+	require.NoError(t, wrapped.Validate())
+	assert.True(t, wrapped.Contains(at(23, 0)))
+	assert.True(t, wrapped.Contains(at(1, 0)))
+	assert.False(t, wrapped.Contains(at(12, 0)))
+
+	// Times are compared in UTC regardless of location.
+	loc := time.FixedZone("test", -5*60*60)
+	assert.True(t, w.Contains(time.Date(2018, time.April, 30, 22, 0, 0, 0, loc)))
+}
+
+func TestFileOpsWindowValidate(t *testing.T) {
+	assert.Error(t, FileOpsWindow{Start: time.Hour, End: time.Hour}.Validate())
+	assert.Error(t, FileOpsWindow{Start: -time.Hour, End: time.Hour}.Validate())
+	assert.Error(t, FileOpsWindow{Start: time.Hour, End: 24 * time.Hour}.Validate())
+}
+
+func TestFileOpsScheduleAllows(t *testing.T) {
+	now := time.Date(2018, time.May, 1, 12, 0, 0, 0, time.UTC)
+
+	var empty FileOpsSchedule
+	assert.True(t, empty.Allows(now))
+
+	s := FileOpsSchedule{
+		Windows:     []FileOpsWindow{{Start: 2 * time.Hour, End: 6 * time.Hour}},
+		MaxDeferral: 12 * time.Hour,
+	}
+	require.NoError(t, s.Validate())
+	assert.False(t, s.Allows(now))
+	assert.True(t, s.Allows(now.Add(-8*time.Hour)))
+
+	assert.False(t, s.AllowsDeferred(now, time.Time{}))
+	assert.False(t, s.AllowsDeferred(now, now.Add(-time.Hour)))
+	assert.True(t, s.AllowsDeferred(now, now.Add(-12*time.Hour)))
+
+	// A zero max deferral defers for the default rather than indefinitely.
+	s.MaxDeferral = 0
+	assert.False(t, s.AllowsDeferred(now, now.Add(-DefaultFileOpsMaxDeferral+time.Second)))
+	assert.True(t, s.AllowsDeferred(now, now.Add(-DefaultFileOpsMaxDeferral)))
+
+	s.MaxDeferral = -time.Second
+	assert.Error(t, s.Validate())
+}
@@ -76,6 +76,7 @@ type options struct {
 	clientReadConsistencyLevel           topology.ReadConsistencyLevel
 	clientWriteConsistencyLevel          topology.ConsistencyLevel
 	flushIndexBlockNumSegments           uint
+	fileOpsSchedule                      FileOpsSchedule
 }
 
 // NewOptions creates a new set of runtime options with defaults
@@ -118,7 +119,7 @@ func (o *options) Validate() error {
 
 	// tickMinimumInterval can be zero if user desires
 
-	return nil
+	return o.fileOpsSchedule.Validate()
 }
 
 func (o *options) SetPersistRateLimitOptions(value ratelimit.Options) Options {
@@ -240,3 +241,13 @@ func (o *options) SetFlushIndexBlockNumSegments(value uint) Options {
 func (o *options) FlushIndexBlockNumSegments() uint {
 	return o.flushIndexBlockNumSegments
 }
+
+func (o *options) SetFileOpsSchedule(value FileOpsSchedule) Options {
+	opts := *o
+	opts.fileOpsSchedule = value
+	return &opts
+}
+
+func (o *options) FileOpsSchedule() FileOpsSchedule {
+	return o.fileOpsSchedule
+}
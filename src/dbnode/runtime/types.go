@@ -172,6 +172,14 @@ type Options interface {
 	// greater amount of segments that need to be searched independently but
 	// a higher number reduces the memory pressure when flushing an index block.
 	FlushIndexBlockNumSegments() uint
+
+	// SetFileOpsSchedule sets the schedule restricting when heavyweight
+	// file operations such as flushes, cleanups and compactions run.
+	SetFileOpsSchedule(value FileOpsSchedule) Options
+
+	// FileOpsSchedule returns the schedule restricting when heavyweight
+	// file operations such as flushes, cleanups and compactions run.
+	FileOpsSchedule() FileOpsSchedule
 }
 
 // OptionsManager updates and supplies runtime options.
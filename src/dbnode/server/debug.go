@@ -0,0 +1,70 @@
+// Copyright (c) 2018 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package server
+
+import (
+	"crypto/subtle"
+	"math"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/m3db/m3/src/cmd/services/m3dbnode/config"
+)
+
+const bearerPrefix = "Bearer "
+
+// newDebugHandler returns the handler of the debug endpoints which only
+// serves callers with one of the configured tokens and rate limits requests,
+// it returns false if the debug endpoints are not served at all.
+func newDebugHandler(cfg config.DebugConfiguration, next http.Handler) (http.Handler, bool) {
+	if len(cfg.Tokens) == 0 && !cfg.AllowUnauthenticatedOrDefault() {
+		return nil, false
+	}
+	limiter := cfg.NewRateLimiter()
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if len(cfg.Tokens) > 0 && !hasDebugToken(cfg.Tokens, r) {
+			http.Error(w, "unauthorized", http.StatusUnauthorized)
+			return
+		}
+		if ok, retryAfter := limiter.Allow(); !ok {
+			seconds := int(math.Ceil(retryAfter.Seconds()))
+			w.Header().Set("Retry-After", strconv.Itoa(seconds))
+			http.Error(w, "too many requests to debug endpoints", http.StatusTooManyRequests)
+			return
+		}
+		next.ServeHTTP(w, r)
+	}), true
+}
+
+func hasDebugToken(tokens []string, r *http.Request) bool {
+	header := r.Header.Get("Authorization")
+	if !strings.HasPrefix(header, bearerPrefix) {
+		return false
+	}
+	token := []byte(strings.TrimPrefix(header, bearerPrefix))
+	for _, t := range tokens {
+		if subtle.ConstantTimeCompare(token, []byte(t)) == 1 {
+			return true
+		}
+	}
+	return false
+}
@@ -27,6 +27,7 @@ import (
 	"net/http"
 	"os"
 	"os/signal"
+	"path/filepath"
 	"runtime"
 	"runtime/debug"
 	"syscall"
@@ -38,6 +39,7 @@ import (
 	"github.com/m3db/m3/src/dbnode/encoding/m3tsz"
 	"github.com/m3db/m3/src/dbnode/environment"
 	"github.com/m3db/m3/src/dbnode/kvconfig"
+	grpcnode "github.com/m3db/m3/src/dbnode/network/server/grpc/node"
 	hjcluster "github.com/m3db/m3/src/dbnode/network/server/httpjson/cluster"
 	hjnode "github.com/m3db/m3/src/dbnode/network/server/httpjson/node"
 	"github.com/m3db/m3/src/dbnode/network/server/tchannelthrift"
@@ -50,17 +52,27 @@ import (
 	m3dbruntime "github.com/m3db/m3/src/dbnode/runtime"
 	"github.com/m3db/m3/src/dbnode/serialize"
 	"github.com/m3db/m3/src/dbnode/storage"
+	"github.com/m3db/m3/src/dbnode/storage/backup"
 	"github.com/m3db/m3/src/dbnode/storage/block"
 	"github.com/m3db/m3/src/dbnode/storage/cluster"
 	"github.com/m3db/m3/src/dbnode/storage/index"
+	"github.com/m3db/m3/src/dbnode/storage/index/compaction"
 	"github.com/m3db/m3/src/dbnode/storage/namespace"
+	"github.com/m3db/m3/src/dbnode/storage/quota"
 	"github.com/m3db/m3/src/dbnode/storage/repair"
+	"github.com/m3db/m3/src/dbnode/storage/replication"
 	"github.com/m3db/m3/src/dbnode/storage/series"
 	"github.com/m3db/m3/src/dbnode/topology"
 	"github.com/m3db/m3/src/dbnode/ts"
 	"github.com/m3db/m3/src/dbnode/x/tchannel"
+	"github.com/m3db/m3/src/dbnode/x/xarena"
 	"github.com/m3db/m3/src/dbnode/x/xio"
+	"github.com/m3db/m3/src/x/loglevel"
 	"github.com/m3db/m3/src/x/mmap"
+	"github.com/m3db/m3/src/x/profiling"
+	"github.com/m3db/m3/src/x/resource"
+	"github.com/m3db/m3/src/x/tracing"
+	"github.com/m3db/m3/src/x/xtls"
 	clusterclient "github.com/m3db/m3cluster/client"
 	"github.com/m3db/m3cluster/client/etcd"
 	"github.com/m3db/m3cluster/generated/proto/commonpb"
@@ -76,7 +88,10 @@ import (
 
 	"github.com/coreos/etcd/embed"
 	"github.com/coreos/pkg/capnslog"
+	opentracing "github.com/opentracing/opentracing-go"
 	"github.com/uber-go/tally"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials"
 )
 
 const (
@@ -84,6 +99,10 @@ const (
 	serverGracefulCloseTimeout        = 10 * time.Second
 	defaultNamespaceResolutionTimeout = time.Minute
 	defaultTopologyResolutionTimeout  = time.Minute
+
+	// postingsListCachePatternsFileName is the file in the index directory
+	// the hottest patterns of the postings list cache are recorded to.
+	postingsListCachePatternsFileName = "postings_list_cache_patterns.json"
 )
 
 // RunOptions provides options for running the server
@@ -129,11 +148,30 @@ func Run(runOpts RunOptions) {
 		cfg = runOpts.Config
 	}
 
-	logger, err := cfg.Logging.BuildLogger()
+	// The logger logs at the debug level and messages are filtered by the
+	// level of their subsystem, which can be changed at runtime.
+	logLevel := xlog.LevelInfo
+	if cfg.Logging.Level != "" {
+		level, err := loglevel.ParseLevel(cfg.Logging.Level)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "unable to create logger: %v", err)
+			os.Exit(1)
+		}
+		logLevel = level
+	}
+	logCfg := cfg.Logging
+	logCfg.Level = xlog.LevelDebug.String()
+	baseLogger, err := logCfg.BuildLogger()
 	if err != nil {
 		fmt.Fprintf(os.Stderr, "unable to create logger: %v", err)
 		os.Exit(1)
 	}
+	logLevels := loglevel.NewRegistry(baseLogger, logLevel)
+	logger := logLevels.Logger(loglevel.SubsystemDefault)
+
+	if err := cfg.ValidateGRPCTLS(); err != nil {
+		logger.Fatalf("invalid gRPC TLS configuration: %v", err)
+	}
 
 	debug.SetGCPercent(cfg.GCPercentage)
 
@@ -197,7 +235,10 @@ func Run(runOpts RunOptions) {
 		SetLogger(logger).
 		SetMetricsScope(scope).
 		SetMetricsSamplingRate(cfg.Metrics.SampleRate())
-	opts = opts.SetInstrumentOptions(iopts)
+	opts = opts.
+		SetInstrumentOptions(iopts).
+		SetLogLevels(logLevels).
+		SetResourceReporter(newResourceReporter(cfg.Filesystem.FilePathPrefix))
 
 	if cfg.Index.MaxQueryIDsConcurrency != 0 {
 		queryIDsWorkerPool := xsync.NewWorkerPool(cfg.Index.MaxQueryIDsConcurrency)
@@ -213,6 +254,30 @@ func Run(runOpts RunOptions) {
 	}
 	defer buildReporter.Stop()
 
+	if tracingCfg := cfg.Tracing; tracingCfg != nil {
+		// NB: The tracer must be installed globally before the tchannel
+		// servers are started, which continue the traces of incoming calls
+		// using the global tracer.
+		tracer, err := tracingCfg.NewTracer("m3dbnode", iopts)
+		if err != nil {
+			logger.Fatalf("unable to set up tracing: %v", err)
+		}
+		opentracing.SetGlobalTracer(tracer)
+		defer tracer.Close()
+
+		logger.Infof("exporting write traces to %s", tracingCfg.Endpoint)
+	}
+
+	if profilingCfg := cfg.Profiling; profilingCfg != nil {
+		profiler, err := profilingCfg.NewProfiler("m3dbnode", iopts)
+		if err != nil {
+			logger.Fatalf("unable to set up profiling: %v", err)
+		}
+		defer profiler.Close()
+
+		logger.Infof("uploading profiles to %s", profilingCfg.Endpoint)
+	}
+
 	runtimeOpts := m3dbruntime.NewOptions().
 		SetPersistRateLimitOptions(ratelimit.NewOptions().
 			SetLimitEnabled(true).
@@ -230,8 +295,58 @@ func Run(runOpts RunOptions) {
 	if cfg.WriteNewSeriesAsync {
 		insertMode = index.InsertAsync
 	}
-	opts = opts.SetIndexOptions(
-		indexOpts.SetInsertMode(insertMode))
+	indexOpts = indexOpts.SetInsertMode(insertMode)
+	var postingsListCacheWarm *postingsListCacheWarmer
+	if cacheCfg := cfg.Index.PostingsListCache; cacheCfg != nil {
+		postingsListCache, err := index.NewPostingsListCache(cacheCfg.Size,
+			index.PostingsListCacheOptions{
+				InstrumentOptions: iopts.SetMetricsScope(
+					scope.SubScope("dbindex")),
+			})
+		if err != nil {
+			logger.Fatalf("could not construct postings list cache: %v", err)
+		}
+		indexOpts = indexOpts.SetPostingsListCache(postingsListCache)
+		if warmCfg := cacheCfg.Warm; warmCfg != nil {
+			postingsListCacheWarm = &postingsListCacheWarmer{
+				cache: postingsListCache,
+				path: filepath.Join(fs.IndexDirPath(cfg.Filesystem.FilePathPrefix),
+					postingsListCachePatternsFileName),
+				size:   warmCfg.Size,
+				logger: logger,
+			}
+			if postingsListCacheWarm.size == 0 {
+				postingsListCacheWarm.size = cacheCfg.Size
+			}
+		}
+	}
+	if cacheCfg := cfg.Index.LazySegmentCache; cacheCfg != nil {
+		lazySegmentCache, err := index.NewLazySegmentCache(cacheCfg.Size,
+			index.LazySegmentCacheOptions{
+				InstrumentOptions: iopts.SetMetricsScope(
+					scope.SubScope("dbindex")),
+			})
+		if err != nil {
+			logger.Fatalf("could not construct lazy segment cache: %v", err)
+		}
+		indexOpts = indexOpts.SetLazySegmentCache(lazySegmentCache)
+	}
+	if compactionCfg := cfg.Index.Compaction; compactionCfg != nil {
+		compactionMgr, err := compaction.NewManager(compactionCfg.NewManagerOptions(
+			iopts.SetMetricsScope(scope.SubScope("dbindex"))))
+		if err != nil {
+			logger.Fatalf("could not construct index compaction manager: %v", err)
+		}
+		indexOpts = indexOpts.SetCompactionManager(compactionMgr)
+	}
+	if limitsCfg := cfg.Index.QueryLimits; limitsCfg != nil {
+		indexOpts = indexOpts.SetQueryLimits(index.QueryLimits{
+			MaxSeries: limitsCfg.MaxSeries,
+			MaxDocs:   limitsCfg.MaxDocs,
+			MaxBytes:  limitsCfg.MaxBytes,
+		})
+	}
+	opts = opts.SetIndexOptions(indexOpts)
 
 	if tick := cfg.Tick; tick != nil {
 		runtimeOpts = runtimeOpts.
@@ -240,6 +355,16 @@ func Run(runOpts RunOptions) {
 			SetTickMinimumInterval(tick.MinimumInterval)
 	}
 
+	if scheduleCfg := cfg.FileOpsSchedule; scheduleCfg != nil {
+		runtimeOpts = runtimeOpts.SetFileOpsSchedule(scheduleCfg.Schedule())
+		if limitMbps := scheduleCfg.ThroughputLimitMbps; limitMbps > 0 {
+			runtimeOpts = runtimeOpts.SetPersistRateLimitOptions(
+				runtimeOpts.PersistRateLimitOptions().
+					SetLimitEnabled(true).
+					SetLimitMbps(limitMbps))
+		}
+	}
+
 	runtimeOptsMgr := m3dbruntime.NewOptionsManager()
 	if err := runtimeOptsMgr.Update(runtimeOpts); err != nil {
 		logger.Fatalf("could not set initial runtime options: %v", err)
@@ -299,6 +424,18 @@ func Run(runOpts RunOptions) {
 		SetTagEncoderPool(tagEncoderPool).
 		SetTagDecoderPool(tagDecoderPool)
 
+	var encryptCommitLog bool
+	if encryptionCfg := cfg.Encryption; encryptionCfg != nil {
+		keyring, err := encryptionCfg.NewKeyring()
+		if err != nil {
+			logger.Fatalf("could not create encryption keyring: %v", err)
+		}
+		fsopts = fsopts.
+			SetEncryptionKeyring(keyring).
+			SetEncryptedNamespaces(encryptionCfg.Namespaces)
+		encryptCommitLog = encryptionCfg.CommitLog
+	}
+
 	var commitLogQueueSize int
 	specified := cfg.CommitLog.Queue.Size
 	switch cfg.CommitLog.Queue.CalculationType {
@@ -311,12 +448,29 @@ func Run(runOpts RunOptions) {
 			cfg.CommitLog.Queue.CalculationType)
 	}
 
+	var commitLogCompression commitlog.Compression
+	switch cfg.CommitLog.Compression {
+	case "", config.CommitLogCompressionNone:
+		commitLogCompression = commitlog.CompressionNone
+	case config.CommitLogCompressionSnappy:
+		commitLogCompression = commitlog.CompressionSnappy
+	default:
+		logger.Fatalf("unknown commit log compression: %v",
+			cfg.CommitLog.Compression)
+	}
+
 	opts = opts.SetCommitLogOptions(opts.CommitLogOptions().
 		SetInstrumentOptions(opts.InstrumentOptions()).
 		SetFilesystemOptions(fsopts).
 		SetStrategy(commitlog.StrategyWriteBehind).
 		SetFlushSize(cfg.CommitLog.FlushMaxBytes).
 		SetFlushInterval(cfg.CommitLog.FlushEvery).
+		SetCompression(commitLogCompression).
+		SetEncryptionEnabled(encryptCommitLog).
+		SetFsyncPolicy(commitlog.FsyncPolicy{
+			Interval: cfg.CommitLog.FsyncEvery,
+			Bytes:    cfg.CommitLog.FsyncEveryBytes,
+		}).
 		SetBacklogQueueSize(commitLogQueueSize).
 		SetBlockSize(cfg.CommitLog.BlockSize))
 
@@ -344,10 +498,22 @@ func Run(runOpts RunOptions) {
 		}
 		blockRetrieverMgr := block.NewDatabaseBlockRetrieverManager(
 			func(md namespace.Metadata) (block.DatabaseBlockRetriever, error) {
-				retriever := fs.NewBlockRetriever(retrieverOpts, fsopts)
+				nsFsOpts := fsopts
+				if seekReadCfg := cfg.Filesystem.SeekRead; seekReadCfg != nil {
+					strategy := seekReadCfg.NamespaceStrategy(md.ID().String())
+					nsFsOpts = nsFsOpts.SetSeekReadStrategy(strategy)
+				}
+				retriever := fs.NewBlockRetriever(retrieverOpts, nsFsOpts)
 				if err := retriever.Open(md); err != nil {
 					return nil, err
 				}
+				if blockReadCfg := cfg.Cache.BlockRead; blockReadCfg != nil {
+					maxBytes := blockReadCfg.NamespaceMaxBytes(md.ID().String())
+					if maxBytes > 0 {
+						return block.NewCachingDatabaseBlockRetriever(md, retriever,
+							maxBytes, iopts), nil
+					}
+				}
 				return retriever, nil
 			})
 		opts = opts.SetDatabaseBlockRetrieverManager(blockRetrieverMgr)
@@ -360,6 +526,42 @@ func Run(runOpts RunOptions) {
 	}
 	opts = opts.SetPersistManager(pm)
 
+	if backupCfg := cfg.Backup; backupCfg != nil {
+		store, err := backupCfg.NewObjectStore()
+		if err != nil {
+			logger.Fatalf("could not create backup object store: %v", err)
+		}
+		keyPrefix := backupCfg.KeyPrefix
+		if keyPrefix == "" {
+			keyPrefix = hostID
+		}
+		backupMgr, err := backup.NewManager(backup.ManagerOptions{
+			Store:              store,
+			FilePathPrefix:     cfg.Filesystem.FilePathPrefix,
+			KeyPrefix:          keyPrefix,
+			RestoreKeyPrefixes: backupCfg.RestoreKeyPrefixes,
+			InstrumentOptions:  iopts,
+			NowFn:              opts.ClockOptions().NowFn(),
+		})
+		if err != nil {
+			logger.Fatalf("could not create backup manager: %v", err)
+		}
+		opts = opts.SetBackupManager(backupMgr)
+	}
+
+	if quotaCfg := cfg.DiskQuota; quotaCfg != nil {
+		tracker, err := quota.NewTracker(quota.TrackerOptions{
+			FilePathPrefix:    cfg.Filesystem.FilePathPrefix,
+			Quotas:            quotaCfg.Quotas(),
+			InstrumentOptions: iopts,
+			NowFn:             opts.ClockOptions().NowFn(),
+		})
+		if err != nil {
+			logger.Fatalf("could not create disk quota tracker: %v", err)
+		}
+		opts = opts.SetDiskQuotaTracker(tracker)
+	}
+
 	var (
 		envCfg environment.ConfigureResults
 	)
@@ -435,6 +637,7 @@ func Run(runOpts RunOptions) {
 	clientAdminOpts := m3dbClient.Options().(client.AdminOptions)
 	kvWatchClientConsistencyLevels(envCfg.KVStore, logger,
 		clientAdminOpts, runtimeOptsMgr)
+	kvWatchClusterRuntimeConfig(envCfg.KVStore, logger, hostID, runtimeOptsMgr)
 
 	// Set bootstrap options
 	bs, err := cfg.Bootstrap.New(opts, m3dbClient)
@@ -468,16 +671,21 @@ func Run(runOpts RunOptions) {
 			scope.SubScope("host-block-metadata-slice-pool")),
 		policy.HostBlockMetadataSlicePool.Capacity)
 
+	repairOpts := opts.RepairOptions().
+		SetAdminClient(m3dbClient).
+		SetRepairInterval(cfg.Repair.Interval).
+		SetRepairTimeOffset(cfg.Repair.Offset).
+		SetRepairTimeJitter(cfg.Repair.Jitter).
+		SetRepairThrottle(cfg.Repair.Throttle).
+		SetRepairCheckInterval(cfg.Repair.CheckInterval).
+		SetHostBlockMetadataSlicePool(hostBlockMetadataSlicePool)
+	if cfg.Repair.HashTreeDepth != nil {
+		repairOpts = repairOpts.SetRepairHashTreeDepth(*cfg.Repair.HashTreeDepth)
+	}
+
 	opts = opts.
 		SetRepairEnabled(cfg.Repair.Enabled).
-		SetRepairOptions(opts.RepairOptions().
-			SetAdminClient(m3dbClient).
-			SetRepairInterval(cfg.Repair.Interval).
-			SetRepairTimeOffset(cfg.Repair.Offset).
-			SetRepairTimeJitter(cfg.Repair.Jitter).
-			SetRepairThrottle(cfg.Repair.Throttle).
-			SetRepairCheckInterval(cfg.Repair.CheckInterval).
-			SetHostBlockMetadataSlicePool(hostBlockMetadataSlicePool))
+		SetRepairOptions(repairOpts)
 
 	// Set tchannelthrift options
 	blockMetadataPool := tchannelthrift.NewBlockMetadataPool(
@@ -500,6 +708,37 @@ func Run(runOpts RunOptions) {
 		SetTagEncoderPool(tagEncoderPool).
 		SetTagDecoderPool(tagDecoderPool)
 
+	var replicator *replication.Replicator
+	if replicationCfg := cfg.Replication; replicationCfg != nil {
+		replicationClient, err := replicationCfg.Client.NewClient(
+			client.ConfigurationParameters{
+				InstrumentOptions: iopts.
+					SetMetricsScope(iopts.MetricsScope().SubScope("replication-client")),
+			})
+		if err != nil {
+			logger.Fatalf("could not create replication client: %v", err)
+		}
+		namespaces := make([]ident.ID, 0, len(replicationCfg.Namespaces))
+		for _, ns := range replicationCfg.Namespaces {
+			namespaces = append(namespaces, ident.StringID(ns))
+		}
+		replicator, err = replication.NewReplicator(replication.Options{
+			Client:             replicationClient,
+			Namespaces:         namespaces,
+			QueueSize:          replicationCfg.QueueSize,
+			Concurrency:        replicationCfg.Concurrency,
+			FilesystemOptions:  fsopts,
+			BytesPool:          opts.BytesPool(),
+			ReaderIteratorPool: opts.ReaderIteratorPool(),
+			InstrumentOptions:  iopts,
+			NowFn:              opts.ClockOptions().NowFn(),
+		})
+		if err != nil {
+			logger.Fatalf("could not create replicator: %v", err)
+		}
+		opts = opts.SetReplicator(replicator)
+	}
+
 	db, err := cluster.NewDatabase(hostID, envCfg.TopologyInitializer, opts)
 	if err != nil {
 		logger.Fatalf("could not construct database: %v", err)
@@ -547,12 +786,44 @@ func Run(runOpts RunOptions) {
 	defer httpjsonClusterClose()
 	logger.Infof("cluster httpjson: listening on %v", cfg.HTTPClusterListenAddress)
 
-	if cfg.DebugListenAddress != "" {
-		go func() {
-			if err := http.ListenAndServe(cfg.DebugListenAddress, nil); err != nil {
-				logger.Errorf("debug server could not listen on %s: %v", cfg.DebugListenAddress, err)
+	if cfg.GRPCListenAddress != "" {
+		var grpcOpts []grpc.ServerOption
+		if cfg.GRPCTLS != nil {
+			grpcTLS, err := cfg.GRPCTLS.NewReloader(iopts.SetMetricsScope(
+				iopts.MetricsScope().SubScope("grpc")))
+			if err != nil {
+				logger.Fatalf("could not load grpc tls certificates: %v", err)
 			}
-		}()
+			defer grpcTLS.Close()
+			grpcOpts = append(grpcOpts,
+				grpc.Creds(credentials.NewTLS(grpcTLS.ServerTLSConfig())))
+			logger.Info("node grpc: tls enabled")
+		}
+
+		grpcNodeClose, err := grpcnode.NewServer(db, cfg.GRPCListenAddress,
+			contextPool, iopts, grpcOpts...).ListenAndServe()
+		if err != nil {
+			logger.Fatalf("could not open grpc interface on %s: %v",
+				cfg.GRPCListenAddress, err)
+		}
+		defer grpcNodeClose()
+		logger.Infof("node grpc: listening on %v", cfg.GRPCListenAddress)
+	}
+
+	if cfg.DebugListenAddress != "" {
+		var debugCfg config.DebugConfiguration
+		if cfg.Debug != nil {
+			debugCfg = *cfg.Debug
+		}
+		if debugHandler, ok := newDebugHandler(debugCfg, http.DefaultServeMux); ok {
+			go func() {
+				if err := http.ListenAndServe(cfg.DebugListenAddress, debugHandler); err != nil {
+					logger.Errorf("debug server could not listen on %s: %v", cfg.DebugListenAddress, err)
+				}
+			}()
+		} else {
+			logger.Info("debug endpoints disabled without tokens by debug.allowUnauthenticated")
+		}
 	}
 
 	go func() {
@@ -569,6 +840,10 @@ func Run(runOpts RunOptions) {
 		}
 		logger.Infof("bootstrapped")
 
+		if postingsListCacheWarm != nil {
+			postingsListCacheWarm.warm()
+		}
+
 		// Only set the write new series limit after bootstrapping
 		kvWatchNewSeriesLimitPerShard(envCfg.KVStore, logger, topo,
 			runtimeOptsMgr, cfg.WriteNewSeriesLimitPerSecond)
@@ -591,6 +866,10 @@ func Run(runOpts RunOptions) {
 
 	logger.Warnf("interrupt: %v", interruptErr)
 
+	if postingsListCacheWarm != nil {
+		postingsListCacheWarm.record()
+	}
+
 	// Attempt graceful server close
 	closedCh := make(chan struct{})
 	go func() {
@@ -598,6 +877,11 @@ func Run(runOpts RunOptions) {
 		if err != nil {
 			logger.Errorf("close database error: %v", err)
 		}
+		if replicator != nil {
+			if err := replicator.Close(); err != nil {
+				logger.Errorf("close replicator error: %v", err)
+			}
+		}
 		closedCh <- struct{}{}
 	}()
 
@@ -746,6 +1030,34 @@ func kvWatchClientConsistencyLevels(
 		})
 }
 
+func kvWatchClusterRuntimeConfig(
+	store kv.Store,
+	logger xlog.Logger,
+	hostID string,
+	runtimeOptsMgr m3dbruntime.OptionsManager,
+) {
+	// Options not set in the cluster runtime config keep the values
+	// the node was configured with.
+	defaults := runtimeOptsMgr.Get()
+	apply := func(values m3dbruntime.ClusterConfigValues) error {
+		runtimeOpts := values.Apply(runtimeOptsMgr.Get(), defaults)
+		return runtimeOptsMgr.Update(runtimeOpts)
+	}
+
+	kvWatchStringValue(store, logger,
+		kvconfig.ClusterRuntimeConfigKey,
+		func(value string) error {
+			history, err := m3dbruntime.ParseClusterConfigHistory(value)
+			if err != nil {
+				return err
+			}
+			return apply(history.Current.HostValues(hostID))
+		},
+		func() error {
+			return apply(m3dbruntime.ClusterConfigValues{})
+		})
+}
+
 func kvWatchStringValue(
 	store kv.Store,
 	logger xlog.Logger,
@@ -1030,6 +1342,17 @@ func withEncodingAndPoolingOptions(
 	opts = opts.
 		SetSeriesOptions(seriesOpts).
 		SetDatabaseSeriesPool(seriesPool)
+	if arenaPolicy := policy.SeriesMetadataArena; arenaPolicy != nil {
+		arenaOpts := xarena.NewOptions().
+			SetInstrumentOptions(iopts.SetMetricsScope(
+				scope.SubScope("series-metadata-arena")))
+		if arenaPolicy.SlabSize > 0 {
+			arenaOpts = arenaOpts.SetSlabSize(arenaPolicy.SlabSize)
+		}
+		logger.Infof("series metadata arenas enabled with slab size=%d",
+			arenaOpts.SlabSize())
+		opts = opts.SetSeriesMetadataArenas(xarena.NewBytesArenas(arenaOpts))
+	}
 	opts = opts.SetCommitLogOptions(opts.CommitLogOptions().
 		SetBytesPool(bytesPool).
 		SetIdentifierPool(identifierPool))
@@ -1045,9 +1368,86 @@ func withEncodingAndPoolingOptions(
 		SetResultsPool(resultsPool)
 	resultsPool.Init(func() index.Results { return index.NewResults(indexOpts) })
 
+	if reporter := opts.ResourceReporter(); reporter != nil {
+		registerResourcePools(reporter, policy, opts.SeriesMetadataArenas())
+	}
+
 	return opts.SetIndexOptions(indexOpts)
 }
 
+// newResourceReporter returns a resource reporter that counts the open
+// files of the data directories and the goroutines of the node subsystems.
+func newResourceReporter(filePathPrefix string) *resource.Reporter {
+	reporter := resource.NewReporter()
+	reporter.RegisterDirectory("data", fs.DataDirPath(filePathPrefix))
+	reporter.RegisterDirectory("index", fs.IndexDirPath(filePathPrefix))
+	reporter.RegisterDirectory("snapshots", fs.SnapshotDirPath(filePathPrefix))
+	reporter.RegisterDirectory("commitlogs", fs.CommitLogsDirPath(filePathPrefix))
+
+	const pkg = "github.com/m3db/m3/src/"
+	reporter.RegisterSubsystem("commitlog", pkg+"dbnode/persist/fs/commitlog")
+	reporter.RegisterSubsystem("persist", pkg+"dbnode/persist")
+	reporter.RegisterSubsystem("bootstrap", pkg+"dbnode/storage/bootstrap")
+	reporter.RegisterSubsystem("repair", pkg+"dbnode/storage/repair")
+	reporter.RegisterSubsystem("index", pkg+"dbnode/storage/index", pkg+"m3ninx")
+	reporter.RegisterSubsystem("storage", pkg+"dbnode/storage")
+	reporter.RegisterSubsystem("client", pkg+"dbnode/client")
+	reporter.RegisterSubsystem("network", pkg+"dbnode/network",
+		"github.com/uber/tchannel-go", "net/http")
+	reporter.RegisterSubsystem("cluster", pkg+"cluster", "github.com/coreos/etcd")
+	return reporter
+}
+
+// registerResourcePools registers the pools with the resource reporter. Pools
+// do not track how many of their objects are in use so their usage is
+// estimated from their configured size, the arena usage is measured.
+func registerResourcePools(
+	reporter *resource.Reporter,
+	policy config.PoolingPolicy,
+	arenas xarena.BytesArenas,
+) {
+	for _, bucket := range policy.BytesPool.Buckets {
+		usage := resource.PoolUsage{
+			Objects:   int64(bucket.Size),
+			Bytes:     int64(bucket.Size) * int64(bucket.Capacity),
+			Estimated: true,
+		}
+		reporter.RegisterPool(fmt.Sprintf("bytes-pool-%d", bucket.Capacity),
+			func() resource.PoolUsage { return usage })
+	}
+
+	objectPools := []struct {
+		name   string
+		policy config.PoolPolicy
+	}{
+		{name: "block-pool", policy: policy.BlockPool},
+		{name: "encoder-pool", policy: policy.EncoderPool},
+		{name: "iterator-pool", policy: policy.IteratorPool},
+		{name: "multi-iterator-pool", policy: policy.IteratorPool},
+		{name: "segment-reader-pool", policy: policy.SegmentReaderPool},
+		{name: "series-pool", policy: policy.SeriesPool},
+		{name: "identifier-pool", policy: policy.IdentifierPool},
+		{name: "index-results-pool", policy: policy.IndexResultsPool},
+	}
+	for _, p := range objectPools {
+		usage := resource.PoolUsage{
+			Objects:   int64(poolOptions(p.policy, tally.NoopScope).Size()),
+			Estimated: true,
+		}
+		reporter.RegisterPool(p.name, func() resource.PoolUsage { return usage })
+	}
+
+	if arenas != nil {
+		reporter.RegisterPool("series-metadata-arena", func() resource.PoolUsage {
+			usage := arenas.Usage()
+			return resource.PoolUsage{
+				Objects: int64(usage.LiveSlabs),
+				Bytes:   int64(usage.LiveSlabs) * int64(usage.SlabSize),
+			}
+		})
+	}
+}
+
 func poolOptions(
 	policy config.PoolPolicy,
 	scope tally.Scope,
@@ -1143,3 +1543,41 @@ func hostSupportsHugeTLB() (bool, error) {
 	// The warning was probably caused by something else, proceed using HugeTLB
 	return true, nil
 }
+
+// postingsListCacheWarmer records the hottest patterns of the postings list
+// cache at shutdown and warms the cache with them once bootstrapped, so that
+// queries after a restart do not see a cold cache.
+type postingsListCacheWarmer struct {
+	cache  *index.PostingsListCache
+	path   string
+	size   int
+	logger xlog.Logger
+}
+
+func (w *postingsListCacheWarmer) warm() {
+	patterns, err := index.ReadPostingsListCachePatterns(w.path)
+	if err != nil {
+		w.logger.Errorf("could not read postings list cache patterns: %v", err)
+		return
+	}
+	if len(patterns) == 0 {
+		return
+	}
+
+	start := time.Now()
+	resolved, err := w.cache.Warm(patterns)
+	if err != nil {
+		w.logger.Errorf("could not warm postings list cache: %v", err)
+	}
+	w.logger.Infof("warmed postings list cache with %d patterns, resolved %d postings lists in %v",
+		len(patterns), resolved, time.Since(start))
+}
+
+func (w *postingsListCacheWarmer) record() {
+	patterns := w.cache.Hottest(w.size)
+	if err := index.WritePostingsListCachePatterns(w.path, patterns); err != nil {
+		w.logger.Errorf("could not record postings list cache patterns: %v", err)
+		return
+	}
+	w.logger.Infof("recorded %d postings list cache patterns", len(patterns))
+}
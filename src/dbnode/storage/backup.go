@@ -0,0 +1,115 @@
+// Copyright (c) 2018 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package storage
+
+import (
+	"sync"
+	"time"
+
+	"github.com/m3db/m3/src/dbnode/clock"
+	"github.com/m3db/m3/src/dbnode/storage/backup"
+	xlog "github.com/m3db/m3x/log"
+)
+
+const (
+	backupInitialBackoff = time.Minute
+	backupMaxBackoff     = time.Hour
+)
+
+// backupWorker runs backups separately from flushes so that slow uploads to
+// the object store never delay flushing, after a failed backup it backs off
+// exponentially so that an unavailable object store is not retried on every
+// file system run.
+type backupWorker struct {
+	sync.Mutex
+
+	manager *backup.Manager
+	log     xlog.Logger
+	nowFn   clock.NowFn
+
+	running  bool
+	failures int
+	nextRun  time.Time
+}
+
+func newBackupWorker(
+	manager *backup.Manager,
+	log xlog.Logger,
+	nowFn clock.NowFn,
+) *backupWorker {
+	return &backupWorker{
+		manager: manager,
+		log:     log,
+		nowFn:   nowFn,
+	}
+}
+
+// Run starts a backup of the namespaces unless a backup is in progress or
+// the worker is backing off after a failure, it returns whether it started.
+func (w *backupWorker) Run(namespaces []backup.Namespace, runType runType) bool {
+	w.Lock()
+	if w.running || w.nowFn().Before(w.nextRun) {
+		w.Unlock()
+		return false
+	}
+	w.running = true
+	w.Unlock()
+
+	backupFn := func() {
+		_, err := w.manager.Backup(namespaces)
+
+		w.Lock()
+		w.running = false
+		if err == nil {
+			w.failures = 0
+			w.nextRun = time.Time{}
+		} else {
+			w.failures++
+			w.nextRun = w.nowFn().Add(backupBackoff(w.failures))
+		}
+		nextRun := w.nextRun
+		w.Unlock()
+
+		if err != nil {
+			w.log.Errorf("error when backing up data, next backup at %v: %v", nextRun, err)
+		}
+	}
+
+	if runType == syncRun {
+		backupFn()
+	} else {
+		go backupFn()
+	}
+	return true
+}
+
+// backupBackoff returns the backoff after the given number of consecutive
+// failed backups, doubling from the initial backoff up to the max backoff.
+func backupBackoff(failures int) time.Duration {
+	backoff := backupInitialBackoff
+	for i := 1; i < failures && backoff < backupMaxBackoff; i++ {
+		backoff *= 2
+	}
+	if backoff > backupMaxBackoff {
+		return backupMaxBackoff
+	}
+	return backoff
+}
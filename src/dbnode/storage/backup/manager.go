@@ -0,0 +1,478 @@
+// Copyright (c) 2018 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package backup
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"hash/adler32"
+	"io"
+	"os"
+	"path"
+	"path/filepath"
+	"sync"
+	"time"
+
+	"github.com/m3db/m3/src/dbnode/persist/fs"
+	xerrors "github.com/m3db/m3x/errors"
+	"github.com/m3db/m3x/ident"
+	"github.com/m3db/m3x/instrument"
+
+	"github.com/uber-go/tally"
+)
+
+const (
+	manifestsKeyPrefix = "manifests"
+	manifestKeySuffix  = ".json"
+)
+
+var (
+	errStoreNotSet          = errors.New("backup object store is not set")
+	errFilePathPrefixNotSet = errors.New("backup file path prefix is not set")
+	errBackupInProgress     = errors.New("backup already in progress")
+)
+
+// ManagerOptions are the options for a backup Manager.
+type ManagerOptions struct {
+	// Store is the object store backups are written to.
+	Store ObjectStore
+	// FilePathPrefix is the file path prefix of the filesets to back up.
+	FilePathPrefix string
+	// KeyPrefix is prepended to all object keys, typically the host ID so
+	// that replicas backing up to the same bucket do not overwrite each other.
+	KeyPrefix string
+	// RestoreKeyPrefixes are the key prefixes searched in order for backups
+	// when restoring, defaults to the key prefix. Setting this to the key
+	// prefixes of other hosts allows restoring shards they backed up, such
+	// as when rebuilding a cluster from backups.
+	RestoreKeyPrefixes []string
+	// InstrumentOptions are the instrument options.
+	InstrumentOptions instrument.Options
+	// NowFn is the function used to determine the current time.
+	NowFn func() time.Time
+}
+
+// Manager uploads flushed data and index fileset volumes to an object store,
+// skipping any files already recorded unchanged in the manifest of their
+// block so that each run only uploads what has been flushed since the last.
+type Manager struct {
+	sync.Mutex
+
+	opts      ManagerOptions
+	nowFn     func() time.Time
+	status    Status
+	manifests map[string]Manifest
+	metrics   managerMetrics
+}
+
+// NewManager returns a new backup manager.
+func NewManager(opts ManagerOptions) (*Manager, error) {
+	if opts.Store == nil {
+		return nil, errStoreNotSet
+	}
+	if opts.FilePathPrefix == "" {
+		return nil, errFilePathPrefixNotSet
+	}
+	if opts.InstrumentOptions == nil {
+		opts.InstrumentOptions = instrument.NewOptions()
+	}
+	if len(opts.RestoreKeyPrefixes) == 0 {
+		opts.RestoreKeyPrefixes = []string{opts.KeyPrefix}
+	}
+	nowFn := opts.NowFn
+	if nowFn == nil {
+		nowFn = time.Now
+	}
+	return &Manager{
+		opts:      opts,
+		nowFn:     nowFn,
+		manifests: make(map[string]Manifest),
+		metrics: newManagerMetrics(opts.InstrumentOptions.MetricsScope().
+			SubScope("backup")),
+	}, nil
+}
+
+// Status returns the current backup status.
+func (m *Manager) Status() Status {
+	m.Lock()
+	status := m.status
+	m.Unlock()
+	return status
+}
+
+// Backup uploads all flushed filesets of the given namespaces that have not
+// yet been backed up, errors for individual blocks do not stop the run.
+func (m *Manager) Backup(namespaces []Namespace) (Result, error) {
+	m.Lock()
+	if m.status.InProgress {
+		m.Unlock()
+		return Result{}, errBackupInProgress
+	}
+	start := m.nowFn()
+	m.status.InProgress = true
+	m.status.LastStartedAt = start
+	m.Unlock()
+
+	var (
+		result   Result
+		multiErr = xerrors.NewMultiError()
+		live     = make(map[string]struct{})
+	)
+	for _, ns := range namespaces {
+		for _, shard := range ns.Shards {
+			if err := m.backupData(ns.ID, shard, live, &result); err != nil {
+				multiErr = multiErr.Add(err)
+			}
+		}
+		if ns.IndexEnabled {
+			if err := m.backupIndex(ns.ID, live, &result); err != nil {
+				multiErr = multiErr.Add(err)
+			}
+		}
+	}
+	m.evictManifests(live)
+
+	var (
+		err = multiErr.FinalError()
+		end = m.nowFn()
+	)
+	m.Lock()
+	m.status.InProgress = false
+	m.status.LastCompletedAt = end
+	m.status.LastDuration = end.Sub(start)
+	m.status.LastResult = result
+	m.status.LastError = ""
+	if err != nil {
+		m.status.Failed++
+		m.status.LastError = err.Error()
+		m.metrics.failed.Inc(1)
+	} else {
+		m.status.Completed++
+		m.metrics.completed.Inc(1)
+	}
+	m.Unlock()
+	m.metrics.duration.Record(end.Sub(start))
+
+	return result, err
+}
+
+// Manifest returns the data manifest of a namespace, shard and block.
+func (m *Manager) Manifest(
+	namespace ident.ID,
+	shard uint32,
+	blockStart time.Time,
+) (Manifest, bool, error) {
+	return m.manifest(dataManifestKey(m.opts.KeyPrefix, namespace, shard, blockStart))
+}
+
+// IndexManifest returns the index manifest of a namespace and block.
+func (m *Manager) IndexManifest(
+	namespace ident.ID,
+	blockStart time.Time,
+) (Manifest, bool, error) {
+	return m.manifest(indexManifestKey(m.opts.KeyPrefix, namespace, blockStart))
+}
+
+func (m *Manager) backupData(
+	namespace ident.ID,
+	shard uint32,
+	live map[string]struct{},
+	result *Result,
+) error {
+	filesets, err := fs.DataFiles(m.opts.FilePathPrefix, namespace, shard)
+	if err != nil {
+		return err
+	}
+
+	multiErr := xerrors.NewMultiError()
+	for _, fileset := range filesets {
+		if !fileset.HasCheckpointFile() {
+			// Flush of this block has not completed.
+			continue
+		}
+		blockStart := fileset.ID.BlockStart
+		current := Manifest{
+			Namespace:   namespace.String(),
+			ContentType: DataContentType,
+			Shard:       shard,
+			BlockStart:  blockStart,
+		}
+		manifestKey := dataManifestKey(m.opts.KeyPrefix, namespace, shard, blockStart)
+		live[manifestKey] = struct{}{}
+		err := m.backupBlock(manifestKey,
+			dataKeyPrefix(m.opts.KeyPrefix, namespace, shard), current,
+			fs.FileSetFilesSlice{fileset}, result)
+		if err != nil {
+			multiErr = multiErr.Add(fmt.Errorf(
+				"failed to back up namespace %s shard %d block %s: %v",
+				namespace.String(), shard, blockStart.String(), err))
+		}
+	}
+	return multiErr.FinalError()
+}
+
+func (m *Manager) backupIndex(
+	namespace ident.ID,
+	live map[string]struct{},
+	result *Result,
+) error {
+	filesets, err := fs.IndexFiles(m.opts.FilePathPrefix, namespace)
+	if err != nil {
+		return err
+	}
+
+	// Index filesets may have multiple volumes per block, all complete
+	// volumes of a block are recorded in a single manifest.
+	var (
+		multiErr = xerrors.NewMultiError()
+		byBlock  = make(map[int64]fs.FileSetFilesSlice)
+		blocks   []time.Time
+	)
+	for _, fileset := range filesets {
+		if !fileset.HasCheckpointFile() {
+			continue
+		}
+		blockStart := fileset.ID.BlockStart
+		if _, ok := byBlock[blockStart.UnixNano()]; !ok {
+			blocks = append(blocks, blockStart)
+		}
+		byBlock[blockStart.UnixNano()] = append(byBlock[blockStart.UnixNano()], fileset)
+	}
+
+	for _, blockStart := range blocks {
+		current := Manifest{
+			Namespace:   namespace.String(),
+			ContentType: IndexContentType,
+			BlockStart:  blockStart,
+		}
+		manifestKey := indexManifestKey(m.opts.KeyPrefix, namespace, blockStart)
+		live[manifestKey] = struct{}{}
+		err := m.backupBlock(manifestKey,
+			indexKeyPrefix(m.opts.KeyPrefix, namespace), current,
+			byBlock[blockStart.UnixNano()], result)
+		if err != nil {
+			multiErr = multiErr.Add(fmt.Errorf(
+				"failed to back up index of namespace %s block %s: %v",
+				namespace.String(), blockStart.String(), err))
+		}
+	}
+	return multiErr.FinalError()
+}
+
+// backupBlock uploads the files of the block's filesets that differ from
+// those recorded in its existing manifest, then writes the new manifest.
+// The manifest is written last so that it only ever references files that
+// have been uploaded in full.
+func (m *Manager) backupBlock(
+	manifestKey string,
+	keyPrefix string,
+	current Manifest,
+	filesets fs.FileSetFilesSlice,
+	result *Result,
+) error {
+	previous, _, err := m.manifest(manifestKey)
+	if err != nil {
+		return err
+	}
+	previousFiles := make(map[string]ManifestFile, len(previous.Files))
+	for _, f := range previous.Files {
+		previousFiles[f.Name] = f
+	}
+
+	changed := len(previous.Files) == 0
+	for _, fileset := range filesets {
+		for _, filePath := range fileset.AbsoluteFilepaths {
+			info, err := os.Stat(filePath)
+			if err != nil {
+				return err
+			}
+
+			name := filepath.Base(filePath)
+			prev, ok := previousFiles[name]
+			if ok && prev.Size == info.Size() && prev.ModTime.Equal(info.ModTime()) {
+				current.Files = append(current.Files, prev)
+				result.FilesSkipped++
+				m.metrics.filesSkipped.Inc(1)
+				continue
+			}
+
+			file, err := m.upload(path.Join(keyPrefix, name), filePath, info)
+			if err != nil {
+				return err
+			}
+			file.VolumeIndex = fileset.ID.VolumeIndex
+			current.Files = append(current.Files, file)
+			changed = true
+			result.FilesUploaded++
+			result.BytesUploaded += file.Size
+			m.metrics.filesUploaded.Inc(1)
+			m.metrics.bytesUploaded.Inc(file.Size)
+		}
+	}
+	if !changed && len(current.Files) == len(previous.Files) {
+		return nil
+	}
+
+	current.UpdatedAt = m.nowFn()
+	data, err := json.Marshal(current)
+	if err != nil {
+		return err
+	}
+	if err := m.opts.Store.Put(manifestKey, bytes.NewReader(data), int64(len(data))); err != nil {
+		return err
+	}
+
+	m.Lock()
+	m.manifests[manifestKey] = current
+	m.Unlock()
+	result.ManifestsWritten++
+	m.metrics.manifestsWritten.Inc(1)
+	return nil
+}
+
+func (m *Manager) upload(
+	key string,
+	filePath string,
+	info os.FileInfo,
+) (ManifestFile, error) {
+	fd, err := os.Open(filePath)
+	if err != nil {
+		return ManifestFile{}, err
+	}
+	defer fd.Close()
+
+	checksum := adler32.New()
+	r := io.TeeReader(fd, checksum)
+	if err := m.opts.Store.Put(key, r, info.Size()); err != nil {
+		return ManifestFile{}, err
+	}
+
+	return ManifestFile{
+		Name:     filepath.Base(filePath),
+		Key:      key,
+		Size:     info.Size(),
+		ModTime:  info.ModTime(),
+		Checksum: checksum.Sum32(),
+	}, nil
+}
+
+// evictManifests evicts the cached manifests of blocks that no longer have
+// filesets on disk, such as those expired by retention or of shards that are
+// no longer owned, so that the cache does not grow with every block backed
+// up. The manifests themselves are kept in the object store for restores.
+func (m *Manager) evictManifests(live map[string]struct{}) {
+	m.Lock()
+	for key := range m.manifests {
+		if _, ok := live[key]; !ok {
+			delete(m.manifests, key)
+		}
+	}
+	m.Unlock()
+}
+
+// manifest returns the manifest stored at the key, preferring the copy
+// cached from a previous read or write to avoid a round trip per block.
+func (m *Manager) manifest(key string) (Manifest, bool, error) {
+	m.Lock()
+	manifest, ok := m.manifests[key]
+	m.Unlock()
+	if ok {
+		return manifest, true, nil
+	}
+
+	r, err := m.opts.Store.Get(key)
+	if err == ErrObjectNotFound {
+		return Manifest{}, false, nil
+	}
+	if err != nil {
+		return Manifest{}, false, err
+	}
+	defer r.Close()
+
+	if err := json.NewDecoder(r).Decode(&manifest); err != nil {
+		return Manifest{}, false, fmt.Errorf(
+			"failed to decode backup manifest %s: %v", key, err)
+	}
+
+	m.Lock()
+	m.manifests[key] = manifest
+	m.Unlock()
+	return manifest, true, nil
+}
+
+func dataKeyPrefix(keyPrefix string, namespace ident.ID, shard uint32) string {
+	return path.Join(keyPrefix, namespace.String(),
+		string(DataContentType), fmt.Sprintf("%d", shard))
+}
+
+func indexKeyPrefix(keyPrefix string, namespace ident.ID) string {
+	return path.Join(keyPrefix, namespace.String(),
+		string(IndexContentType))
+}
+
+func dataManifestKey(
+	keyPrefix string,
+	namespace ident.ID,
+	shard uint32,
+	blockStart time.Time,
+) string {
+	return path.Join(keyPrefix, manifestsKeyPrefix, namespace.String(),
+		string(DataContentType), fmt.Sprintf("%d", shard),
+		fmt.Sprintf("%d%s", blockStart.UnixNano(), manifestKeySuffix))
+}
+
+func indexManifestKey(
+	keyPrefix string,
+	namespace ident.ID,
+	blockStart time.Time,
+) string {
+	return path.Join(keyPrefix, manifestsKeyPrefix, namespace.String(),
+		string(IndexContentType),
+		fmt.Sprintf("%d%s", blockStart.UnixNano(), manifestKeySuffix))
+}
+
+type managerMetrics struct {
+	completed        tally.Counter
+	failed           tally.Counter
+	filesUploaded    tally.Counter
+	filesSkipped     tally.Counter
+	bytesUploaded    tally.Counter
+	manifestsWritten tally.Counter
+	duration         tally.Timer
+	filesRestored    tally.Counter
+	bytesRestored    tally.Counter
+}
+
+func newManagerMetrics(scope tally.Scope) managerMetrics {
+	return managerMetrics{
+		completed:        scope.Counter("completed"),
+		failed:           scope.Counter("failed"),
+		filesUploaded:    scope.Counter("files-uploaded"),
+		filesSkipped:     scope.Counter("files-skipped"),
+		bytesUploaded:    scope.Counter("bytes-uploaded"),
+		manifestsWritten: scope.Counter("manifests-written"),
+		duration:         scope.Timer("duration"),
+		filesRestored:    scope.Counter("files-restored"),
+		bytesRestored:    scope.Counter("bytes-restored"),
+	}
+}
@@ -0,0 +1,212 @@
+// Copyright (c) 2018 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package backup
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/m3db/m3/src/dbnode/persist/fs"
+	"github.com/m3db/m3x/ident"
+
+	"github.com/stretchr/testify/require"
+)
+
+var (
+	testNamespace  = ident.StringID("testns")
+	testBlockStart = time.Unix(0, 0).Add(2 * time.Hour)
+)
+
+func writeTestDataFileSet(
+	t *testing.T,
+	filePathPrefix string,
+	shard uint32,
+	blockStart time.Time,
+	data string,
+) {
+	dir := fs.ShardDataDirPath(filePathPrefix, testNamespace, shard)
+	require.NoError(t, os.MkdirAll(dir, 0755))
+	for _, suffix := range []string{"info", "data", "checkpoint"} {
+		filePath := filepath.Join(dir,
+			fmt.Sprintf("fileset-%d-%s.db", blockStart.UnixNano(), suffix))
+		require.NoError(t, ioutil.WriteFile(filePath, []byte(data+suffix), 0644))
+	}
+}
+
+func writeTestIndexFileSet(
+	t *testing.T,
+	filePathPrefix string,
+	blockStart time.Time,
+	volume int,
+) {
+	dir := fs.NamespaceIndexDataDirPath(filePathPrefix, testNamespace)
+	require.NoError(t, os.MkdirAll(dir, 0755))
+	for _, suffix := range []string{"info", "segment-0-docs", "checkpoint"} {
+		filePath := filepath.Join(dir,
+			fmt.Sprintf("fileset-%d-%d-%s.db", blockStart.UnixNano(), volume, suffix))
+		require.NoError(t, ioutil.WriteFile(filePath, []byte(suffix), 0644))
+	}
+}
+
+func newTestManager(t *testing.T, filePathPrefix, storeDir string) *Manager {
+	mgr, err := NewManager(ManagerOptions{
+		Store:          NewDirectoryObjectStore(storeDir),
+		FilePathPrefix: filePathPrefix,
+		KeyPrefix:      "host0",
+	})
+	require.NoError(t, err)
+	return mgr
+}
+
+func TestManagerBackupIncremental(t *testing.T) {
+	filePathPrefix, err := ioutil.TempDir("", "backup-filesets")
+	require.NoError(t, err)
+	defer os.RemoveAll(filePathPrefix)
+	storeDir, err := ioutil.TempDir("", "backup-store")
+	require.NoError(t, err)
+	defer os.RemoveAll(storeDir)
+
+	writeTestDataFileSet(t, filePathPrefix, 0, testBlockStart, "a")
+	writeTestDataFileSet(t, filePathPrefix, 1, testBlockStart, "b")
+	writeTestIndexFileSet(t, filePathPrefix, testBlockStart, 0)
+
+	namespaces := []Namespace{
+		{ID: testNamespace, Shards: []uint32{0, 1}, IndexEnabled: true},
+	}
+	mgr := newTestManager(t, filePathPrefix, storeDir)
+	result, err := mgr.Backup(namespaces)
+	require.NoError(t, err)
+	require.Equal(t, Result{
+		FilesUploaded:    9,
+		BytesUploaded:    int64(2*len("ainfoadataacheckpoint") + len("infosegment-0-docscheckpoint")),
+		ManifestsWritten: 3,
+	}, result)
+
+	manifest, ok, err := mgr.Manifest(testNamespace, 1, testBlockStart)
+	require.NoError(t, err)
+	require.True(t, ok)
+	require.Equal(t, DataContentType, manifest.ContentType)
+	require.Equal(t, uint32(1), manifest.Shard)
+	require.Equal(t, 3, len(manifest.Files))
+	for _, f := range manifest.Files {
+		contents, err := ioutil.ReadFile(filepath.Join(storeDir, filepath.FromSlash(f.Key)))
+		require.NoError(t, err)
+		require.Equal(t, f.Size, int64(len(contents)))
+	}
+
+	indexManifest, ok, err := mgr.IndexManifest(testNamespace, testBlockStart)
+	require.NoError(t, err)
+	require.True(t, ok)
+	require.Equal(t, IndexContentType, indexManifest.ContentType)
+	require.Equal(t, 3, len(indexManifest.Files))
+
+	// Nothing has changed so a second run uploads nothing.
+	result, err = mgr.Backup(namespaces)
+	require.NoError(t, err)
+	require.Equal(t, Result{FilesSkipped: 9}, result)
+
+	// A new index volume and a new data block are uploaded by a fresh
+	// manager that has to read the existing manifests from the store.
+	writeTestIndexFileSet(t, filePathPrefix, testBlockStart, 1)
+	writeTestDataFileSet(t, filePathPrefix, 0, testBlockStart.Add(2*time.Hour), "c")
+
+	mgr = newTestManager(t, filePathPrefix, storeDir)
+	result, err = mgr.Backup(namespaces)
+	require.NoError(t, err)
+	require.Equal(t, int64(6), result.FilesUploaded)
+	require.Equal(t, int64(9), result.FilesSkipped)
+	require.Equal(t, int64(2), result.ManifestsWritten)
+
+	indexManifest, ok, err = mgr.IndexManifest(testNamespace, testBlockStart)
+	require.NoError(t, err)
+	require.True(t, ok)
+	require.Equal(t, 6, len(indexManifest.Files))
+
+	status := mgr.Status()
+	require.False(t, status.InProgress)
+	require.Equal(t, int64(1), status.Completed)
+	require.Equal(t, result, status.LastResult)
+}
+
+func TestManagerBackupSkipsIncompleteFileSets(t *testing.T) {
+	filePathPrefix, err := ioutil.TempDir("", "backup-filesets")
+	require.NoError(t, err)
+	defer os.RemoveAll(filePathPrefix)
+	storeDir, err := ioutil.TempDir("", "backup-store")
+	require.NoError(t, err)
+	defer os.RemoveAll(storeDir)
+
+	writeTestDataFileSet(t, filePathPrefix, 0, testBlockStart, "a")
+	checkpoint := filepath.Join(fs.ShardDataDirPath(filePathPrefix, testNamespace, 0),
+		fmt.Sprintf("fileset-%d-checkpoint.db", testBlockStart.UnixNano()))
+	require.NoError(t, os.Remove(checkpoint))
+
+	mgr := newTestManager(t, filePathPrefix, storeDir)
+	result, err := mgr.Backup([]Namespace{{ID: testNamespace, Shards: []uint32{0}}})
+	require.NoError(t, err)
+	require.Equal(t, Result{}, result)
+
+	_, ok, err := mgr.Manifest(testNamespace, 0, testBlockStart)
+	require.NoError(t, err)
+	require.False(t, ok)
+}
+
+func TestManagerBackupEvictsExpiredManifests(t *testing.T) {
+	filePathPrefix, err := ioutil.TempDir("", "backup-filesets")
+	require.NoError(t, err)
+	defer os.RemoveAll(filePathPrefix)
+	storeDir, err := ioutil.TempDir("", "backup-store")
+	require.NoError(t, err)
+	defer os.RemoveAll(storeDir)
+
+	nextBlockStart := testBlockStart.Add(2 * time.Hour)
+	writeTestDataFileSet(t, filePathPrefix, 0, testBlockStart, "a")
+	writeTestDataFileSet(t, filePathPrefix, 0, nextBlockStart, "b")
+
+	namespaces := []Namespace{{ID: testNamespace, Shards: []uint32{0}}}
+	mgr := newTestManager(t, filePathPrefix, storeDir)
+	_, err = mgr.Backup(namespaces)
+	require.NoError(t, err)
+	require.Equal(t, 2, len(mgr.manifests))
+
+	// Retention removes the filesets of the first block.
+	dir := fs.ShardDataDirPath(filePathPrefix, testNamespace, 0)
+	for _, suffix := range []string{"info", "data", "checkpoint"} {
+		require.NoError(t, os.Remove(filepath.Join(dir,
+			fmt.Sprintf("fileset-%d-%s.db", testBlockStart.UnixNano(), suffix))))
+	}
+
+	result, err := mgr.Backup(namespaces)
+	require.NoError(t, err)
+	require.Equal(t, Result{FilesSkipped: 3}, result)
+	require.Equal(t, 1, len(mgr.manifests))
+	_, ok := mgr.manifests[dataManifestKey("host0", testNamespace, 0, nextBlockStart)]
+	require.True(t, ok)
+
+	// The manifest of the expired block is still in the store for restores.
+	_, ok, err = mgr.Manifest(testNamespace, 0, testBlockStart)
+	require.NoError(t, err)
+	require.True(t, ok)
+}
@@ -0,0 +1,181 @@
+// Copyright (c) 2018 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package backup
+
+import (
+	"fmt"
+	"hash/adler32"
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/m3db/m3/src/dbnode/persist/fs"
+	"github.com/m3db/m3x/ident"
+)
+
+const (
+	restoreDirPerm      = 0755
+	checkpointFileToken = "checkpoint"
+)
+
+// HasData returns whether any of the restore key prefixes has a backup of
+// the data fileset of a namespace, shard and block.
+func (m *Manager) HasData(
+	namespace ident.ID,
+	shard uint32,
+	blockStart time.Time,
+) (bool, error) {
+	_, ok, err := m.findManifest(func(keyPrefix string) string {
+		return dataManifestKey(keyPrefix, namespace, shard, blockStart)
+	})
+	return ok, err
+}
+
+// RestoreData downloads the backed up data fileset of a namespace, shard
+// and block into the local filesystem unless it already exists locally,
+// returning false if there is no backup of the block.
+func (m *Manager) RestoreData(
+	namespace ident.ID,
+	shard uint32,
+	blockStart time.Time,
+) (bool, error) {
+	exists, err := fs.DataFileSetExistsAt(m.opts.FilePathPrefix,
+		namespace, shard, blockStart)
+	if err != nil {
+		return false, err
+	}
+	if exists {
+		return true, nil
+	}
+
+	manifest, ok, err := m.findManifest(func(keyPrefix string) string {
+		return dataManifestKey(keyPrefix, namespace, shard, blockStart)
+	})
+	if err != nil || !ok {
+		return false, err
+	}
+	dir := fs.ShardDataDirPath(m.opts.FilePathPrefix, namespace, shard)
+	return true, m.restoreFiles(dir, manifest)
+}
+
+// RestoreIndex downloads the backed up index fileset volumes of a namespace
+// and block into the local filesystem unless any already exist locally,
+// returning false if there is no backup of the block.
+func (m *Manager) RestoreIndex(
+	namespace ident.ID,
+	blockStart time.Time,
+) (bool, error) {
+	existing, err := fs.IndexFileSetsAt(m.opts.FilePathPrefix, namespace, blockStart)
+	if err != nil {
+		return false, err
+	}
+	if len(existing) > 0 {
+		return true, nil
+	}
+
+	manifest, ok, err := m.findManifest(func(keyPrefix string) string {
+		return indexManifestKey(keyPrefix, namespace, blockStart)
+	})
+	if err != nil || !ok {
+		return false, err
+	}
+	dir := fs.NamespaceIndexDataDirPath(m.opts.FilePathPrefix, namespace)
+	return true, m.restoreFiles(dir, manifest)
+}
+
+func (m *Manager) findManifest(
+	keyFn func(keyPrefix string) string,
+) (Manifest, bool, error) {
+	for _, keyPrefix := range m.opts.RestoreKeyPrefixes {
+		manifest, ok, err := m.manifest(keyFn(keyPrefix))
+		if err != nil {
+			return Manifest{}, false, err
+		}
+		if ok {
+			return manifest, true, nil
+		}
+	}
+	return Manifest{}, false, nil
+}
+
+// restoreFiles downloads the files of a manifest into a directory, writing
+// checkpoint files last so that a partially restored fileset is never
+// considered complete.
+func (m *Manager) restoreFiles(dir string, manifest Manifest) error {
+	if err := os.MkdirAll(dir, restoreDirPerm); err != nil {
+		return err
+	}
+
+	files := append([]ManifestFile(nil), manifest.Files...)
+	sort.SliceStable(files, func(i, j int) bool {
+		iCheckpoint := strings.Contains(files[i].Name, checkpointFileToken)
+		jCheckpoint := strings.Contains(files[j].Name, checkpointFileToken)
+		return !iCheckpoint && jCheckpoint
+	})
+	for _, file := range files {
+		if err := m.restoreFile(dir, file); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (m *Manager) restoreFile(dir string, file ManifestFile) error {
+	r, err := m.opts.Store.Get(file.Key)
+	if err == ErrObjectNotFound {
+		return fmt.Errorf("backup object %s referenced by manifest is missing", file.Key)
+	}
+	if err != nil {
+		return err
+	}
+	defer r.Close()
+
+	tmp, err := ioutil.TempFile(dir, file.Name)
+	if err != nil {
+		return err
+	}
+	checksum := adler32.New()
+	n, err := io.Copy(io.MultiWriter(tmp, checksum), r)
+	if err == nil && n != file.Size {
+		err = fmt.Errorf("restored %d bytes of %s, expected %d", n, file.Key, file.Size)
+	}
+	if err == nil && checksum.Sum32() != file.Checksum {
+		err = fmt.Errorf("checksum mismatch restoring %s", file.Key)
+	}
+	if closeErr := tmp.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		os.Remove(tmp.Name())
+		return err
+	}
+	if err := os.Rename(tmp.Name(), filepath.Join(dir, file.Name)); err != nil {
+		return err
+	}
+
+	m.metrics.filesRestored.Inc(1)
+	m.metrics.bytesRestored.Inc(file.Size)
+	return nil
+}
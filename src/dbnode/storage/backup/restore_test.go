@@ -0,0 +1,139 @@
+// Copyright (c) 2018 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package backup
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/m3db/m3/src/dbnode/persist/fs"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestManagerRestoreFromOtherHost(t *testing.T) {
+	filePathPrefix, err := ioutil.TempDir("", "backup-filesets")
+	require.NoError(t, err)
+	defer os.RemoveAll(filePathPrefix)
+	restorePathPrefix, err := ioutil.TempDir("", "backup-restore")
+	require.NoError(t, err)
+	defer os.RemoveAll(restorePathPrefix)
+	storeDir, err := ioutil.TempDir("", "backup-store")
+	require.NoError(t, err)
+	defer os.RemoveAll(storeDir)
+
+	writeTestDataFileSet(t, filePathPrefix, 3, testBlockStart, "a")
+	writeTestIndexFileSet(t, filePathPrefix, testBlockStart, 0)
+	_, err = newTestManager(t, filePathPrefix, storeDir).Backup([]Namespace{
+		{ID: testNamespace, Shards: []uint32{3}, IndexEnabled: true},
+	})
+	require.NoError(t, err)
+
+	// Restore onto a replacement host that searches the backups of the
+	// host it replaces after its own.
+	mgr, err := NewManager(ManagerOptions{
+		Store:              NewDirectoryObjectStore(storeDir),
+		FilePathPrefix:     restorePathPrefix,
+		KeyPrefix:          "host1",
+		RestoreKeyPrefixes: []string{"host1", "host0"},
+	})
+	require.NoError(t, err)
+
+	ok, err := mgr.HasData(testNamespace, 3, testBlockStart)
+	require.NoError(t, err)
+	require.True(t, ok)
+
+	ok, err = mgr.RestoreData(testNamespace, 3, testBlockStart)
+	require.NoError(t, err)
+	require.True(t, ok)
+
+	ok, err = fs.DataFileSetExistsAt(restorePathPrefix, testNamespace, 3, testBlockStart)
+	require.NoError(t, err)
+	require.True(t, ok)
+
+	srcDir := fs.ShardDataDirPath(filePathPrefix, testNamespace, 3)
+	dstDir := fs.ShardDataDirPath(restorePathPrefix, testNamespace, 3)
+	srcFiles, err := ioutil.ReadDir(srcDir)
+	require.NoError(t, err)
+	for _, f := range srcFiles {
+		expected, err := ioutil.ReadFile(filepath.Join(srcDir, f.Name()))
+		require.NoError(t, err)
+		actual, err := ioutil.ReadFile(filepath.Join(dstDir, f.Name()))
+		require.NoError(t, err)
+		require.Equal(t, expected, actual)
+	}
+
+	ok, err = mgr.RestoreIndex(testNamespace, testBlockStart)
+	require.NoError(t, err)
+	require.True(t, ok)
+
+	indexFileSets, err := fs.IndexFileSetsAt(restorePathPrefix, testNamespace, testBlockStart)
+	require.NoError(t, err)
+	require.Equal(t, 1, len(indexFileSets))
+
+	// Blocks that were never backed up cannot be restored.
+	ok, err = mgr.RestoreData(testNamespace, 3, testBlockStart.Add(2*time.Hour))
+	require.NoError(t, err)
+	require.False(t, ok)
+
+	ok, err = mgr.RestoreData(testNamespace, 4, testBlockStart)
+	require.NoError(t, err)
+	require.False(t, ok)
+}
+
+func TestManagerRestoreChecksumMismatch(t *testing.T) {
+	filePathPrefix, err := ioutil.TempDir("", "backup-filesets")
+	require.NoError(t, err)
+	defer os.RemoveAll(filePathPrefix)
+	restorePathPrefix, err := ioutil.TempDir("", "backup-restore")
+	require.NoError(t, err)
+	defer os.RemoveAll(restorePathPrefix)
+	storeDir, err := ioutil.TempDir("", "backup-store")
+	require.NoError(t, err)
+	defer os.RemoveAll(storeDir)
+
+	writeTestDataFileSet(t, filePathPrefix, 0, testBlockStart, "a")
+	mgr := newTestManager(t, filePathPrefix, storeDir)
+	_, err = mgr.Backup([]Namespace{{ID: testNamespace, Shards: []uint32{0}}})
+	require.NoError(t, err)
+
+	manifest, ok, err := mgr.Manifest(testNamespace, 0, testBlockStart)
+	require.NoError(t, err)
+	require.True(t, ok)
+
+	// Corrupt a backed up file while keeping its size.
+	corrupt := filepath.Join(storeDir, filepath.FromSlash(manifest.Files[0].Key))
+	data, err := ioutil.ReadFile(corrupt)
+	require.NoError(t, err)
+	data[0]++
+	require.NoError(t, ioutil.WriteFile(corrupt, data, 0644))
+
+	restoreMgr := newTestManager(t, restorePathPrefix, storeDir)
+	_, err = restoreMgr.RestoreData(testNamespace, 0, testBlockStart)
+	require.Error(t, err)
+
+	ok, err = fs.DataFileSetExistsAt(restorePathPrefix, testNamespace, 0, testBlockStart)
+	require.NoError(t, err)
+	require.False(t, ok)
+}
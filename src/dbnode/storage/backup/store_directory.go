@@ -0,0 +1,84 @@
+// Copyright (c) 2018 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package backup
+
+import (
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+)
+
+const (
+	directoryStoreDirPerm = 0755
+)
+
+type directoryObjectStore struct {
+	dir string
+}
+
+// NewDirectoryObjectStore returns an object store that writes objects as
+// files beneath a directory, such as a mounted network volume.
+func NewDirectoryObjectStore(dir string) ObjectStore {
+	return &directoryObjectStore{dir: dir}
+}
+
+func (s *directoryObjectStore) Put(key string, r io.Reader, size int64) error {
+	filePath := s.path(key)
+	if err := os.MkdirAll(filepath.Dir(filePath), directoryStoreDirPerm); err != nil {
+		return err
+	}
+
+	// Write to a temporary file and rename so partially written objects are
+	// never visible under their key.
+	tmp, err := ioutil.TempFile(filepath.Dir(filePath), filepath.Base(filePath))
+	if err != nil {
+		return err
+	}
+	n, err := io.Copy(tmp, r)
+	if err == nil && n != size {
+		err = fmt.Errorf("wrote %d bytes for object %s, expected %d", n, key, size)
+	}
+	if closeErr := tmp.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		os.Remove(tmp.Name())
+		return err
+	}
+	return os.Rename(tmp.Name(), filePath)
+}
+
+func (s *directoryObjectStore) Get(key string) (io.ReadCloser, error) {
+	fd, err := os.Open(s.path(key))
+	if os.IsNotExist(err) {
+		return nil, ErrObjectNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+	return fd, nil
+}
+
+func (s *directoryObjectStore) path(key string) string {
+	return filepath.Join(s.dir, filepath.FromSlash(key))
+}
@@ -0,0 +1,305 @@
+// Copyright (c) 2018 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package backup
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"sort"
+	"strings"
+	"time"
+)
+
+const (
+	s3Service         = "s3"
+	s3Algorithm       = "AWS4-HMAC-SHA256"
+	s3DateFormat      = "20060102"
+	s3TimeFormat      = "20060102T150405Z"
+	s3UnsignedPayload = "UNSIGNED-PAYLOAD"
+	s3ErrorBodyLimit  = 1024
+)
+
+var (
+	errS3BucketNotSet      = errors.New("s3 bucket is not set")
+	errS3RegionNotSet      = errors.New("s3 region is not set")
+	errS3CredentialsNotSet = errors.New("s3 access key ID and secret access key must be set")
+)
+
+// S3Options are the options for an S3 compatible object store.
+type S3Options struct {
+	// Endpoint is the base URL of the service, defaults to the AWS S3
+	// endpoint of the region. Google Cloud Storage may be used by setting
+	// this to https://storage.googleapis.com with HMAC interoperability keys.
+	Endpoint string
+	// Region is the region of the bucket, "auto" for Google Cloud Storage.
+	Region string
+	// Bucket is the bucket objects are written to.
+	Bucket string
+	// AccessKeyID is the access key ID used to sign requests.
+	AccessKeyID string
+	// SecretAccessKey is the secret access key used to sign requests.
+	SecretAccessKey string
+	// HTTPClient is the client requests are made with, defaults to
+	// http.DefaultClient.
+	HTTPClient *http.Client
+	// NowFn is the function used to determine the signing time.
+	NowFn func() time.Time
+}
+
+type s3ObjectStore struct {
+	opts     S3Options
+	endpoint *url.URL
+	client   *http.Client
+	nowFn    func() time.Time
+}
+
+// NewS3ObjectStore returns an object store that writes objects to an S3
+// compatible bucket using path style requests signed with AWS signature V4.
+func NewS3ObjectStore(opts S3Options) (ObjectStore, error) {
+	if opts.Bucket == "" {
+		return nil, errS3BucketNotSet
+	}
+	if opts.Region == "" {
+		return nil, errS3RegionNotSet
+	}
+	if opts.AccessKeyID == "" || opts.SecretAccessKey == "" {
+		return nil, errS3CredentialsNotSet
+	}
+	endpoint := opts.Endpoint
+	if endpoint == "" {
+		endpoint = fmt.Sprintf("https://s3.%s.amazonaws.com", opts.Region)
+	}
+	u, err := url.Parse(endpoint)
+	if err != nil {
+		return nil, fmt.Errorf("invalid s3 endpoint %s: %v", endpoint, err)
+	}
+	client := opts.HTTPClient
+	if client == nil {
+		client = http.DefaultClient
+	}
+	nowFn := opts.NowFn
+	if nowFn == nil {
+		nowFn = time.Now
+	}
+	return &s3ObjectStore{
+		opts:     opts,
+		endpoint: u,
+		client:   client,
+		nowFn:    nowFn,
+	}, nil
+}
+
+func (s *s3ObjectStore) Put(key string, r io.Reader, size int64) error {
+	req, err := s.newRequest(http.MethodPut, key, r)
+	if err != nil {
+		return err
+	}
+	req.ContentLength = size
+
+	resp, err := s.do(req)
+	if err != nil {
+		return err
+	}
+	resp.Body.Close()
+	return nil
+}
+
+func (s *s3ObjectStore) Get(key string) (io.ReadCloser, error) {
+	req, err := s.newRequest(http.MethodGet, key, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	resp, err := s.do(req)
+	if err != nil {
+		return nil, err
+	}
+	return resp.Body, nil
+}
+
+func (s *s3ObjectStore) newRequest(
+	method string,
+	key string,
+	body io.Reader,
+) (*http.Request, error) {
+	u := *s.endpoint
+	u.Path = strings.TrimSuffix(u.Path, "/") + "/" + s.opts.Bucket + "/" + key
+	u.RawPath = s3EscapePath(u.Path)
+
+	var rc io.ReadCloser
+	if body != nil {
+		rc = ioutil.NopCloser(body)
+	}
+	req, err := http.NewRequest(method, u.String(), rc)
+	if err != nil {
+		return nil, err
+	}
+	if body == nil {
+		req.Body = nil
+	}
+	req.Header.Set("x-amz-content-sha256", s3UnsignedPayload)
+	s3SignV4(req, s.opts.AccessKeyID, s.opts.SecretAccessKey,
+		s.opts.Region, s.nowFn())
+	return req, nil
+}
+
+func (s *s3ObjectStore) do(req *http.Request) (*http.Response, error) {
+	resp, err := s.client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	if resp.StatusCode >= 200 && resp.StatusCode < 300 {
+		return resp, nil
+	}
+
+	defer resp.Body.Close()
+	if resp.StatusCode == http.StatusNotFound {
+		return nil, ErrObjectNotFound
+	}
+	body, _ := ioutil.ReadAll(io.LimitReader(resp.Body, s3ErrorBodyLimit))
+	return nil, fmt.Errorf("s3 %s %s failed with status %d: %s",
+		req.Method, req.URL.Path, resp.StatusCode, strings.TrimSpace(string(body)))
+}
+
+// s3SignV4 signs the request with AWS signature V4, signing the host and
+// all headers already set on the request. The payload hash must be set as
+// the x-amz-content-sha256 header before signing.
+func s3SignV4(
+	req *http.Request,
+	accessKeyID string,
+	secretAccessKey string,
+	region string,
+	t time.Time,
+) {
+	t = t.UTC()
+	req.Header.Set("x-amz-date", t.Format(s3TimeFormat))
+
+	host := req.Host
+	if host == "" {
+		host = req.URL.Host
+	}
+	headers := map[string]string{"host": host}
+	for name, values := range req.Header {
+		headers[strings.ToLower(name)] = strings.TrimSpace(strings.Join(values, ","))
+	}
+	names := make([]string, 0, len(headers))
+	for name := range headers {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	var canonicalHeaders strings.Builder
+	for _, name := range names {
+		canonicalHeaders.WriteString(name)
+		canonicalHeaders.WriteString(":")
+		canonicalHeaders.WriteString(headers[name])
+		canonicalHeaders.WriteString("\n")
+	}
+	signedHeaders := strings.Join(names, ";")
+
+	canonicalRequest := strings.Join([]string{
+		req.Method,
+		req.URL.EscapedPath(),
+		s3CanonicalQuery(req.URL.Query()),
+		canonicalHeaders.String(),
+		signedHeaders,
+		headers["x-amz-content-sha256"],
+	}, "\n")
+
+	scope := strings.Join([]string{
+		t.Format(s3DateFormat), region, s3Service, "aws4_request",
+	}, "/")
+	canonicalRequestHash := sha256.Sum256([]byte(canonicalRequest))
+	stringToSign := strings.Join([]string{
+		s3Algorithm,
+		t.Format(s3TimeFormat),
+		scope,
+		hex.EncodeToString(canonicalRequestHash[:]),
+	}, "\n")
+
+	key := s3SigningKey(secretAccessKey, t.Format(s3DateFormat), region, s3Service)
+	signature := hex.EncodeToString(s3HMAC(key, stringToSign))
+
+	req.Header.Set("Authorization", fmt.Sprintf(
+		"%s Credential=%s/%s, SignedHeaders=%s, Signature=%s",
+		s3Algorithm, accessKeyID, scope, signedHeaders, signature))
+}
+
+func s3SigningKey(secretAccessKey, date, region, service string) []byte {
+	key := s3HMAC([]byte("AWS4"+secretAccessKey), date)
+	key = s3HMAC(key, region)
+	key = s3HMAC(key, service)
+	return s3HMAC(key, "aws4_request")
+}
+
+func s3HMAC(key []byte, data string) []byte {
+	h := hmac.New(sha256.New, key)
+	h.Write([]byte(data))
+	return h.Sum(nil)
+}
+
+func s3CanonicalQuery(values url.Values) string {
+	keys := make([]string, 0, len(values))
+	for k := range values {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+
+	var parts []string
+	for _, k := range keys {
+		vs := append([]string(nil), values[k]...)
+		sort.Strings(vs)
+		for _, v := range vs {
+			parts = append(parts, s3Escape(k, true)+"="+s3Escape(v, true))
+		}
+	}
+	return strings.Join(parts, "&")
+}
+
+// s3EscapePath URI encodes a path as required by signature V4, escaping
+// everything other than unreserved characters and slashes.
+func s3EscapePath(p string) string {
+	return s3Escape(p, false)
+}
+
+func s3Escape(s string, escapeSlash bool) string {
+	var b strings.Builder
+	for i := 0; i < len(s); i++ {
+		c := s[i]
+		switch {
+		case 'A' <= c && c <= 'Z', 'a' <= c && c <= 'z', '0' <= c && c <= '9',
+			c == '-', c == '_', c == '.', c == '~':
+			b.WriteByte(c)
+		case c == '/' && !escapeSlash:
+			b.WriteByte(c)
+		default:
+			fmt.Fprintf(&b, "%%%02X", c)
+		}
+	}
+	return b.String()
+}
@@ -0,0 +1,106 @@
+// Copyright (c) 2018 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+// Package backup ships flushed fileset volumes and index segments to object
+// storage incrementally, recording a manifest per block.
+package backup
+
+import (
+	"errors"
+	"io"
+	"time"
+
+	"github.com/m3db/m3x/ident"
+)
+
+var (
+	// ErrObjectNotFound is returned by an ObjectStore when an object does not exist.
+	ErrObjectNotFound = errors.New("object not found")
+)
+
+// ObjectStore is a store of objects addressed by key, such as an S3 or GCS bucket.
+type ObjectStore interface {
+	// Put writes size bytes read from r as the object with the given key,
+	// replacing any existing object.
+	Put(key string, r io.Reader, size int64) error
+
+	// Get returns the contents of the object with the given key or
+	// ErrObjectNotFound if it does not exist.
+	Get(key string) (io.ReadCloser, error)
+}
+
+// Namespace is a namespace and the shards of it owned by the node to back up.
+type Namespace struct {
+	ID           ident.ID
+	Shards       []uint32
+	IndexEnabled bool
+}
+
+// ContentType is the type of content a manifest describes.
+type ContentType string
+
+const (
+	// DataContentType is the content type of data fileset volumes.
+	DataContentType ContentType = "data"
+	// IndexContentType is the content type of index fileset volumes.
+	IndexContentType ContentType = "index"
+)
+
+// Manifest describes the files backed up for a block, data manifests are
+// per namespace, shard and block whereas index manifests are per namespace
+// and block.
+type Manifest struct {
+	Namespace   string         `json:"namespace"`
+	ContentType ContentType    `json:"contentType"`
+	Shard       uint32         `json:"shard"`
+	BlockStart  time.Time      `json:"blockStart"`
+	UpdatedAt   time.Time      `json:"updatedAt"`
+	Files       []ManifestFile `json:"files"`
+}
+
+// ManifestFile is a single file recorded in a manifest.
+type ManifestFile struct {
+	Name        string    `json:"name"`
+	Key         string    `json:"key"`
+	VolumeIndex int       `json:"volumeIndex"`
+	Size        int64     `json:"size"`
+	ModTime     time.Time `json:"modTime"`
+	Checksum    uint32    `json:"checksum"`
+}
+
+// Result is the outcome of a single backup run.
+type Result struct {
+	FilesUploaded    int64 `json:"filesUploaded"`
+	FilesSkipped     int64 `json:"filesSkipped"`
+	BytesUploaded    int64 `json:"bytesUploaded"`
+	ManifestsWritten int64 `json:"manifestsWritten"`
+}
+
+// Status is a point in time summary of backup activity.
+type Status struct {
+	InProgress      bool          `json:"inProgress"`
+	Completed       int64         `json:"completed"`
+	Failed          int64         `json:"failed"`
+	LastStartedAt   time.Time     `json:"lastStartedAt"`
+	LastCompletedAt time.Time     `json:"lastCompletedAt"`
+	LastDuration    time.Duration `json:"lastDuration"`
+	LastError       string        `json:"lastError,omitempty"`
+	LastResult      Result        `json:"lastResult"`
+}
@@ -0,0 +1,112 @@
+// Copyright (c) 2018 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package storage
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/m3db/m3/src/dbnode/persist/fs"
+	"github.com/m3db/m3/src/dbnode/storage/backup"
+	"github.com/m3db/m3x/ident"
+	xlog "github.com/m3db/m3x/log"
+
+	"github.com/stretchr/testify/require"
+)
+
+var errTestStoreUnavailable = errors.New("store unavailable")
+
+type testUnavailableObjectStore struct{}
+
+func (s testUnavailableObjectStore) Put(string, io.Reader, int64) error {
+	return errTestStoreUnavailable
+}
+
+func (s testUnavailableObjectStore) Get(string) (io.ReadCloser, error) {
+	return nil, errTestStoreUnavailable
+}
+
+func TestBackupWorkerBacksOffAfterFailure(t *testing.T) {
+	filePathPrefix, err := ioutil.TempDir("", "backup-worker")
+	require.NoError(t, err)
+	defer os.RemoveAll(filePathPrefix)
+
+	now := time.Now()
+	nowFn := func() time.Time { return now }
+	mgr, err := backup.NewManager(backup.ManagerOptions{
+		Store:          testUnavailableObjectStore{},
+		FilePathPrefix: filePathPrefix,
+		NowFn:          nowFn,
+	})
+	require.NoError(t, err)
+
+	// Uploading the flushed fileset of the namespace fails.
+	namespace := ident.StringID("testns")
+	dir := fs.ShardDataDirPath(filePathPrefix, namespace, 0)
+	require.NoError(t, os.MkdirAll(dir, 0755))
+	for _, suffix := range []string{"info", "data", "checkpoint"} {
+		filePath := filepath.Join(dir, fmt.Sprintf("fileset-0-%s.db", suffix))
+		require.NoError(t, ioutil.WriteFile(filePath, []byte(suffix), 0644))
+	}
+	namespaces := []backup.Namespace{{ID: namespace, Shards: []uint32{0}}}
+	w := newBackupWorker(mgr, xlog.NullLogger, nowFn)
+	require.True(t, w.Run(namespaces, syncRun))
+	require.Equal(t, int64(1), mgr.Status().Failed)
+
+	// Backups are not retried until the backoff has passed.
+	require.False(t, w.Run(namespaces, syncRun))
+	now = now.Add(backupInitialBackoff)
+	require.True(t, w.Run(namespaces, syncRun))
+	require.Equal(t, int64(2), mgr.Status().Failed)
+
+	now = now.Add(backupInitialBackoff)
+	require.False(t, w.Run(namespaces, syncRun))
+	now = now.Add(backupInitialBackoff)
+	require.True(t, w.Run(namespaces, syncRun))
+
+	// A successful backup resets the backoff.
+	storeDir, err := ioutil.TempDir("", "backup-worker-store")
+	require.NoError(t, err)
+	defer os.RemoveAll(storeDir)
+	w.manager, err = backup.NewManager(backup.ManagerOptions{
+		Store:          backup.NewDirectoryObjectStore(storeDir),
+		FilePathPrefix: filePathPrefix,
+		NowFn:          nowFn,
+	})
+	require.NoError(t, err)
+	now = now.Add(4 * backupInitialBackoff)
+	require.True(t, w.Run(namespaces, syncRun))
+	require.Equal(t, int64(1), w.manager.Status().Completed)
+	require.True(t, w.Run(namespaces, syncRun))
+}
+
+func TestBackupBackoff(t *testing.T) {
+	require.Equal(t, backupInitialBackoff, backupBackoff(1))
+	require.Equal(t, 2*backupInitialBackoff, backupBackoff(2))
+	require.Equal(t, 4*backupInitialBackoff, backupBackoff(3))
+	require.Equal(t, backupMaxBackoff, backupBackoff(100))
+}
@@ -0,0 +1,278 @@
+// Copyright (c) 2018 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package block
+
+import (
+	"container/list"
+	"sync"
+	"time"
+
+	"github.com/m3db/m3/src/dbnode/storage/namespace"
+	"github.com/m3db/m3/src/dbnode/storage/stats"
+	"github.com/m3db/m3/src/dbnode/ts"
+	"github.com/m3db/m3/src/dbnode/x/xio"
+	"github.com/m3db/m3x/checked"
+	"github.com/m3db/m3x/context"
+	"github.com/m3db/m3x/ident"
+	"github.com/m3db/m3x/instrument"
+
+	"github.com/uber-go/tally"
+)
+
+type readCacheKey struct {
+	shard      uint32
+	id         string
+	blockStart int64
+}
+
+type readCacheEntry struct {
+	key  readCacheKey
+	data []byte
+	tags []ident.Tag
+}
+
+func (e *readCacheEntry) size() int64 {
+	size := len(e.key.id) + len(e.data)
+	for _, tag := range e.tags {
+		size += len(tag.Name.Bytes()) + len(tag.Value.Bytes())
+	}
+	return int64(size)
+}
+
+type readCacheMetrics struct {
+	hits      tally.Counter
+	misses    tally.Counter
+	evictions tally.Counter
+	bytes     tally.Gauge
+	entries   tally.Gauge
+}
+
+func newReadCacheMetrics(scope tally.Scope) readCacheMetrics {
+	return readCacheMetrics{
+		hits:      scope.Counter("hits"),
+		misses:    scope.Counter("misses"),
+		evictions: scope.Counter("evictions"),
+		bytes:     scope.Gauge("bytes"),
+		entries:   scope.Gauge("entries"),
+	}
+}
+
+// ReadCacheStatsReporter is implemented by block retrievers that cache the
+// blocks they read.
+type ReadCacheStatsReporter interface {
+	// ReadCacheStats returns the lookups and size of the cache.
+	ReadCacheStats() stats.CacheStats
+}
+
+type cachingBlockRetriever struct {
+	sync.Mutex
+
+	DatabaseBlockRetriever
+	blockSize time.Duration
+	maxBytes  int64
+	bytes     int64
+	lru       *list.List
+	entries   map[readCacheKey]*list.Element
+	hits      int64
+	misses    int64
+	metrics   readCacheMetrics
+}
+
+// NewCachingDatabaseBlockRetriever returns a block retriever that keeps
+// recently read sealed blocks in an in memory LRU cache keyed by shard,
+// series ID and block start, bounded by maxBytes of block data. Blocks
+// served from the cache skip reading from disk entirely.
+func NewCachingDatabaseBlockRetriever(
+	md namespace.Metadata,
+	retriever DatabaseBlockRetriever,
+	maxBytes int64,
+	iopts instrument.Options,
+) DatabaseBlockRetriever {
+	scope := iopts.MetricsScope().
+		SubScope("block-read-cache").
+		Tagged(map[string]string{"namespace": md.ID().String()})
+	return &cachingBlockRetriever{
+		DatabaseBlockRetriever: retriever,
+		blockSize:              md.Options().RetentionOptions().BlockSize(),
+		maxBytes:               maxBytes,
+		lru:                    list.New(),
+		entries:                make(map[readCacheKey]*list.Element),
+		metrics:                newReadCacheMetrics(scope),
+	}
+}
+
+func (r *cachingBlockRetriever) Stream(
+	ctx context.Context,
+	shard uint32,
+	id ident.ID,
+	blockStart time.Time,
+	onRetrieve OnRetrieveBlock,
+) (xio.BlockReader, error) {
+	key := readCacheKey{
+		shard:      shard,
+		id:         id.String(),
+		blockStart: blockStart.UnixNano(),
+	}
+	if entry, ok := r.get(key); ok {
+		r.metrics.hits.Inc(1)
+		// NB: The cached data is never mutated so it is safe to share
+		// between readers without copying.
+		data := checked.NewBytes(entry.data, nil)
+		segment := ts.NewSegment(data, nil, ts.FinalizeNone)
+		if onRetrieve != nil {
+			r.onRetrieveHit(id, blockStart, entry, onRetrieve)
+		}
+		return xio.BlockReader{
+			SegmentReader: xio.NewSegmentReader(segment),
+			Start:         blockStart,
+			BlockSize:     r.blockSize,
+		}, nil
+	}
+
+	r.metrics.misses.Inc(1)
+	cacheOnRetrieve := &readCacheOnRetrieve{
+		retriever:  r,
+		key:        key,
+		onRetrieve: onRetrieve,
+	}
+	return r.DatabaseBlockRetriever.Stream(ctx, shard, id,
+		blockStart, cacheOnRetrieve)
+}
+
+// onRetrieveHit calls the on retrieve callback of a block served from the
+// cache with a copy of the block, as the underlying retriever does for the
+// blocks it reads.
+func (r *cachingBlockRetriever) onRetrieveHit(
+	id ident.ID,
+	blockStart time.Time,
+	entry *readCacheEntry,
+	onRetrieve OnRetrieveBlock,
+) {
+	var (
+		idCopy  = ident.StringID(id.String())
+		tags    = ident.NewTagsIterator(ident.NewTags(entry.tags...))
+		data    = checked.NewBytes(append([]byte(nil), entry.data...), nil)
+		segment = ts.NewSegment(data, nil, ts.FinalizeNone)
+	)
+	// NB: The callback is called asynchronously, as by the underlying
+	// retriever, since callers may hold the lock of the series it updates.
+	go onRetrieve.OnRetrieveBlock(idCopy, tags, blockStart, segment)
+}
+
+func (r *cachingBlockRetriever) get(key readCacheKey) (*readCacheEntry, bool) {
+	r.Lock()
+	defer r.Unlock()
+
+	elem, ok := r.entries[key]
+	if !ok {
+		r.misses++
+		return nil, false
+	}
+	r.hits++
+	r.lru.MoveToFront(elem)
+	return elem.Value.(*readCacheEntry), true
+}
+
+func (r *cachingBlockRetriever) ReadCacheStats() stats.CacheStats {
+	r.Lock()
+	defer r.Unlock()
+
+	return stats.CacheStats{
+		Hits:     r.hits,
+		Misses:   r.misses,
+		HitRate:  stats.HitRate(r.hits, r.misses),
+		Entries:  int64(len(r.entries)),
+		Bytes:    r.bytes,
+		MaxBytes: r.maxBytes,
+	}
+}
+
+func (r *cachingBlockRetriever) put(entry *readCacheEntry) {
+	if entry.size() > r.maxBytes {
+		return
+	}
+
+	r.Lock()
+	defer r.Unlock()
+
+	if elem, ok := r.entries[entry.key]; ok {
+		r.removeWithLock(elem)
+	}
+	r.entries[entry.key] = r.lru.PushFront(entry)
+	r.bytes += entry.size()
+
+	for r.bytes > r.maxBytes {
+		r.removeWithLock(r.lru.Back())
+		r.metrics.evictions.Inc(1)
+	}
+
+	r.metrics.bytes.Update(float64(r.bytes))
+	r.metrics.entries.Update(float64(len(r.entries)))
+}
+
+func (r *cachingBlockRetriever) removeWithLock(elem *list.Element) {
+	entry := r.lru.Remove(elem).(*readCacheEntry)
+	delete(r.entries, entry.key)
+	r.bytes -= entry.size()
+}
+
+type readCacheOnRetrieve struct {
+	retriever  *cachingBlockRetriever
+	key        readCacheKey
+	onRetrieve OnRetrieveBlock
+}
+
+func (c *readCacheOnRetrieve) OnRetrieveBlock(
+	id ident.ID,
+	tags ident.TagIterator,
+	startTime time.Time,
+	segment ts.Segment,
+) {
+	entry := &readCacheEntry{key: c.key}
+	if segment.Head != nil {
+		entry.data = append(entry.data, segment.Head.Bytes()...)
+	}
+	if segment.Tail != nil {
+		entry.data = append(entry.data, segment.Tail.Bytes()...)
+	}
+	if tags != nil {
+		// Copy the tags so that hits can pass them to the callback too.
+		iter := tags.Duplicate()
+		for iter.Next() {
+			tag := iter.Current()
+			entry.tags = append(entry.tags, ident.StringTag(
+				tag.Name.String(), tag.Value.String()))
+		}
+		iter.Close()
+	}
+	c.retriever.put(entry)
+
+	if c.onRetrieve != nil {
+		c.onRetrieve.OnRetrieveBlock(id, tags, startTime, segment)
+		return
+	}
+
+	// Nothing else takes ownership of the retrieved copy.
+	segment.Finalize()
+	if tags != nil {
+		tags.Close()
+	}
+}
@@ -0,0 +1,233 @@
+// Copyright (c) 2018 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package block
+
+import (
+	"io/ioutil"
+	"testing"
+	"time"
+
+	"github.com/m3db/m3/src/dbnode/storage/namespace"
+	"github.com/m3db/m3/src/dbnode/storage/stats"
+	"github.com/m3db/m3/src/dbnode/ts"
+	"github.com/m3db/m3/src/dbnode/x/xio"
+	"github.com/m3db/m3x/checked"
+	"github.com/m3db/m3x/context"
+	"github.com/m3db/m3x/ident"
+	"github.com/m3db/m3x/instrument"
+
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func newTestCachingRetriever(
+	t *testing.T,
+	ctrl *gomock.Controller,
+	maxBytes int64,
+) (*cachingBlockRetriever, *MockDatabaseBlockRetriever) {
+	md, err := namespace.NewMetadata(ident.StringID("testns"), namespace.NewOptions())
+	require.NoError(t, err)
+
+	mockRetriever := NewMockDatabaseBlockRetriever(ctrl)
+	r := NewCachingDatabaseBlockRetriever(md, mockRetriever, maxBytes,
+		instrument.NewOptions())
+	return r.(*cachingBlockRetriever), mockRetriever
+}
+
+// expectStreamRetrieve expects a stream from the underlying retriever and
+// synchronously invokes the on retrieve callback with the given data.
+func expectStreamRetrieve(
+	mockRetriever *MockDatabaseBlockRetriever,
+	id string,
+	start time.Time,
+	data []byte,
+) {
+	mockRetriever.EXPECT().
+		Stream(gomock.Any(), uint32(0), ident.NewIDMatcher(id), start, gomock.Any()).
+		DoAndReturn(func(
+			_ context.Context,
+			_ uint32,
+			id ident.ID,
+			start time.Time,
+			onRetrieve OnRetrieveBlock,
+		) (xio.BlockReader, error) {
+			segment := ts.NewSegment(checked.NewBytes(data, nil), nil, ts.FinalizeNone)
+			onRetrieve.OnRetrieveBlock(id, nil, start, segment)
+			return xio.BlockReader{
+				SegmentReader: xio.NewSegmentReader(segment),
+				Start:         start,
+			}, nil
+		})
+}
+
+func TestCachingBlockRetrieverServesFromCache(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	r, mockRetriever := newTestCachingRetriever(t, ctrl, 1024)
+
+	ctx := context.NewContext()
+	defer ctx.Close()
+
+	start := time.Now().Truncate(time.Hour)
+	expectStreamRetrieve(mockRetriever, "foo", start, []byte("data"))
+
+	onRetrieve := NewMockOnRetrieveBlock(ctrl)
+	onRetrieve.EXPECT().OnRetrieveBlock(ident.NewIDMatcher("foo"), nil, start, gomock.Any())
+
+	_, err := r.Stream(ctx, 0, ident.StringID("foo"), start, onRetrieve)
+	require.NoError(t, err)
+
+	// Second read is served from the cache without hitting the retriever.
+	hit := make(chan struct{})
+	onRetrieve.EXPECT().
+		OnRetrieveBlock(ident.NewIDMatcher("foo"), gomock.Any(), start, gomock.Any()).
+		Do(func(ident.ID, ident.TagIterator, time.Time, ts.Segment) { close(hit) })
+	reader, err := r.Stream(ctx, 0, ident.StringID("foo"), start, onRetrieve)
+	require.NoError(t, err)
+	assert.Equal(t, start, reader.Start)
+	assert.Equal(t, r.blockSize, reader.BlockSize)
+
+	data, err := ioutil.ReadAll(reader)
+	require.NoError(t, err)
+	assert.Equal(t, []byte("data"), data)
+	<-hit
+
+	assert.Equal(t, stats.CacheStats{
+		Hits:     1,
+		Misses:   1,
+		HitRate:  0.5,
+		Entries:  1,
+		Bytes:    int64(len("foo") + len("data")),
+		MaxBytes: 1024,
+	}, r.ReadCacheStats())
+}
+
+func TestCachingBlockRetrieverEvictsLeastRecentlyUsed(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	// Each entry is 3 bytes of ID plus 4 bytes of data.
+	r, mockRetriever := newTestCachingRetriever(t, ctrl, 14)
+
+	ctx := context.NewContext()
+	defer ctx.Close()
+
+	start := time.Now().Truncate(time.Hour)
+	for _, id := range []string{"foo", "bar"} {
+		expectStreamRetrieve(mockRetriever, id, start, []byte("data"))
+		_, err := r.Stream(ctx, 0, ident.StringID(id), start, nil)
+		require.NoError(t, err)
+	}
+	assert.Equal(t, int64(14), r.bytes)
+
+	// Touch foo so that bar is the least recently used.
+	_, err := r.Stream(ctx, 0, ident.StringID("foo"), start, nil)
+	require.NoError(t, err)
+
+	expectStreamRetrieve(mockRetriever, "baz", start, []byte("data"))
+	_, err = r.Stream(ctx, 0, ident.StringID("baz"), start, nil)
+	require.NoError(t, err)
+
+	assert.Equal(t, int64(14), r.bytes)
+	assert.Equal(t, 2, len(r.entries))
+	_, ok := r.entries[readCacheKey{id: "bar", blockStart: start.UnixNano()}]
+	assert.False(t, ok)
+
+	// Reading bar again must go back to the underlying retriever.
+	expectStreamRetrieve(mockRetriever, "bar", start, []byte("data"))
+	_, err = r.Stream(ctx, 0, ident.StringID("bar"), start, nil)
+	require.NoError(t, err)
+}
+
+func TestCachingBlockRetrieverSkipsEntriesOverBudget(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	r, mockRetriever := newTestCachingRetriever(t, ctrl, 4)
+
+	ctx := context.NewContext()
+	defer ctx.Close()
+
+	start := time.Now().Truncate(time.Hour)
+	expectStreamRetrieve(mockRetriever, "foo", start, []byte("data"))
+	_, err := r.Stream(ctx, 0, ident.StringID("foo"), start, nil)
+	require.NoError(t, err)
+
+	assert.Equal(t, int64(0), r.bytes)
+	assert.Equal(t, 0, len(r.entries))
+}
+
+func TestCachingBlockRetrieverCallsOnRetrieveOnHits(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	r, mockRetriever := newTestCachingRetriever(t, ctrl, 1024)
+
+	ctx := context.NewContext()
+	defer ctx.Close()
+
+	start := time.Now().Truncate(time.Hour)
+	mockRetriever.EXPECT().
+		Stream(gomock.Any(), uint32(0), ident.NewIDMatcher("foo"), start, gomock.Any()).
+		DoAndReturn(func(
+			_ context.Context,
+			_ uint32,
+			id ident.ID,
+			start time.Time,
+			onRetrieve OnRetrieveBlock,
+		) (xio.BlockReader, error) {
+			segment := ts.NewSegment(checked.NewBytes([]byte("data"), nil), nil, ts.FinalizeNone)
+			tags := ident.NewTagsIterator(ident.NewTags(ident.StringTag("city", "nyc")))
+			onRetrieve.OnRetrieveBlock(id, tags, start, segment)
+			return xio.BlockReader{SegmentReader: xio.NewSegmentReader(segment), Start: start}, nil
+		})
+	_, err := r.Stream(ctx, 0, ident.StringID("foo"), start, nil)
+	require.NoError(t, err)
+
+	type retrieved struct {
+		id   string
+		tags map[string]string
+		data []byte
+	}
+	retrievedCh := make(chan retrieved, 1)
+	onRetrieve := NewMockOnRetrieveBlock(ctrl)
+	onRetrieve.EXPECT().
+		OnRetrieveBlock(gomock.Any(), gomock.Any(), start, gomock.Any()).
+		Do(func(id ident.ID, tags ident.TagIterator, _ time.Time, segment ts.Segment) {
+			result := retrieved{id: id.String(), tags: make(map[string]string)}
+			for tags.Next() {
+				tag := tags.Current()
+				result.tags[tag.Name.String()] = tag.Value.String()
+			}
+			result.data = segment.Head.Bytes()
+			retrievedCh <- result
+		})
+
+	_, err = r.Stream(ctx, 0, ident.StringID("foo"), start, onRetrieve)
+	require.NoError(t, err)
+	assert.Equal(t, retrieved{
+		id:   "foo",
+		tags: map[string]string{"city": "nyc"},
+		data: []byte("data"),
+	}, <-retrievedCh)
+}
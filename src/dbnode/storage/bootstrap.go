@@ -26,6 +26,7 @@ import (
 
 	"github.com/m3db/m3/src/dbnode/clock"
 	"github.com/m3db/m3/src/dbnode/storage/bootstrap"
+	"github.com/m3db/m3/src/x/loglevel"
 	xerrors "github.com/m3db/m3x/errors"
 	xlog "github.com/m3db/m3x/log"
 
@@ -79,7 +80,7 @@ func newBootstrapManager(
 		database:        database,
 		mediator:        mediator,
 		opts:            opts,
-		log:             opts.InstrumentOptions().Logger(),
+		log:             loglevel.ForSubsystem(opts.InstrumentOptions().Logger(), loglevel.SubsystemBootstrap),
 		nowFn:           opts.ClockOptions().NowFn(),
 		processProvider: opts.BootstrapProcessProvider(),
 		status:          scope.Gauge("bootstrapped"),
@@ -82,6 +82,15 @@ type fileSystemSourceMetrics struct {
 	persistedIndexBlocksWrite tally.Counter
 }
 
+// NewFileSystemSource returns a source that reads from on-disk files, for
+// use by bootstrappers that populate the filesystem before reading from it.
+func NewFileSystemSource(opts Options) (bootstrap.Source, error) {
+	if err := opts.Validate(); err != nil {
+		return nil, err
+	}
+	return newFileSystemSource(opts), nil
+}
+
 func newFileSystemSource(opts Options) bootstrap.Source {
 	iopts := opts.InstrumentOptions()
 	scope := iopts.MetricsScope().SubScope("fs-bootstrapper")
@@ -22,6 +22,7 @@ package peers
 
 import (
 	"fmt"
+	"sort"
 	"sync"
 	"time"
 
@@ -56,6 +57,45 @@ type persistenceFlush struct {
 	timeRange         xtime.Range
 }
 
+// blockFetch is a single block of a shard to fetch from peers.
+type blockFetch struct {
+	shard     uint32
+	timeRange xtime.Range
+	// requested is the range the block was requested for, used to
+	// mark the block unfulfilled without exceeding the requested range
+	requested xtime.Range
+}
+
+// newBlockFetches returns the blocks to fetch for all shards ordered with
+// the most recent blocks first so that the node can begin serving queries
+// for recent data as early as possible.
+func newBlockFetches(
+	shardsTimeRanges result.ShardTimeRanges,
+	blockSize time.Duration,
+) []blockFetch {
+	var fetches []blockFetch
+	for shard, ranges := range shardsTimeRanges {
+		it := ranges.Iter()
+		for it.Next() {
+			currRange := it.Value()
+			for blockStart := currRange.Start; blockStart.Before(currRange.End); blockStart = blockStart.Add(blockSize) {
+				fetches = append(fetches, blockFetch{
+					shard:     shard,
+					timeRange: xtime.Range{Start: blockStart, End: blockStart.Add(blockSize)},
+					requested: currRange,
+				})
+			}
+		}
+	}
+	sort.Slice(fetches, func(i, j int) bool {
+		if !fetches[i].timeRange.Start.Equal(fetches[j].timeRange.Start) {
+			return fetches[i].timeRange.Start.After(fetches[j].timeRange.Start)
+		}
+		return fetches[i].shard < fetches[j].shard
+	})
+	return fetches
+}
+
 func newPeersSource(opts Options) (bootstrap.Source, error) {
 	return &peersSource{
 		opts:  opts,
@@ -176,16 +216,18 @@ func (s *peersSource) ReadData(
 			opts, persistenceWorkerDoneCh, persistenceQueue, persistFlush, result, &resultLock)
 	}
 
+	// Blocks are fetched across all shards most recent first, the worker pool
+	// blocks when full so blocks are started in the order they are submitted
 	workers := xsync.NewWorkerPool(concurrency)
 	workers.Init()
-	for shard, ranges := range shardsTimeRanges {
-		shard, ranges := shard, ranges
+	for _, fetch := range newBlockFetches(shardsTimeRanges, blockSize) {
+		fetch := fetch
 		wg.Add(1)
 		workers.Go(func() {
 			defer wg.Done()
-			s.fetchBootstrapBlocksFromPeers(shard, ranges, nsMetadata, session,
+			s.fetchBootstrapBlockFromPeers(fetch, nsMetadata, session,
 				resultOpts, result, &resultLock, shouldPersist, persistenceQueue,
-				shardRetrieverMgr, blockSize)
+				shardRetrieverMgr)
 		})
 	}
 
@@ -246,15 +288,14 @@ func (s *peersSource) startPersistenceQueueWorkerLoop(
 	close(doneCh)
 }
 
-// fetchBootstrapBlocksFromPeers loops through all the provided ranges for a given shard and
-// fetches all the bootstrap blocks from the appropriate peers.
+// fetchBootstrapBlockFromPeers fetches a single bootstrap block for a shard
+// from the appropriate peers.
 // 		Persistence enabled case: Immediately add the results to the bootstrap result
 // 		Persistence disabled case: Don't add the results yet, but push a flush into the
 // 						  persistenceQueue. The persistenceQueue worker will eventually
 // 						  add the results once its performed the flush.
-func (s *peersSource) fetchBootstrapBlocksFromPeers(
-	shard uint32,
-	ranges xtime.Ranges,
+func (s *peersSource) fetchBootstrapBlockFromPeers(
+	fetch blockFetch,
 	nsMetadata namespace.Metadata,
 	session client.AdminSession,
 	bopts result.Options,
@@ -263,45 +304,41 @@ func (s *peersSource) fetchBootstrapBlocksFromPeers(
 	shouldPersist bool,
 	persistenceQueue chan persistenceFlush,
 	shardRetrieverMgr block.DatabaseShardBlockRetrieverManager,
-	blockSize time.Duration,
 ) {
-	it := ranges.Iter()
-	for it.Next() {
-		currRange := it.Value()
-
-		for blockStart := currRange.Start; blockStart.Before(currRange.End); blockStart = blockStart.Add(blockSize) {
-			version := s.opts.FetchBlocksMetadataEndpointVersion()
-			blockEnd := blockStart.Add(blockSize)
-			shardResult, err := session.FetchBootstrapBlocksFromPeers(
-				nsMetadata, shard, blockStart, blockEnd, bopts, version)
-
-			s.logFetchBootstrapBlocksFromPeersOutcome(shard, shardResult, err)
+	var (
+		shard   = fetch.shard
+		version = s.opts.FetchBlocksMetadataEndpointVersion()
+	)
+	shardResult, err := session.FetchBootstrapBlocksFromPeers(nsMetadata,
+		shard, fetch.timeRange.Start, fetch.timeRange.End, bopts, version)
 
-			if err != nil {
-				// Do not add result at all to the bootstrap result
-				lock.Lock()
-				bootstrapResult.Add(shard, nil, xtime.NewRanges(currRange))
-				lock.Unlock()
-				continue
-			}
+	s.logFetchBootstrapBlocksFromPeersOutcome(shard, shardResult, err)
 
-			if shouldPersist {
-				persistenceQueue <- persistenceFlush{
-					nsMetadata:        nsMetadata,
-					shard:             shard,
-					shardRetrieverMgr: shardRetrieverMgr,
-					shardResult:       shardResult,
-					timeRange:         xtime.Range{Start: blockStart, End: blockEnd},
-				}
-				continue
-			}
+	if err != nil {
+		// Do not add result at all to the bootstrap result, only the
+		// block that failed is unfulfilled
+		unfulfilled, _ := fetch.timeRange.Intersect(fetch.requested)
+		lock.Lock()
+		bootstrapResult.Add(shard, nil, xtime.NewRanges(unfulfilled))
+		lock.Unlock()
+		return
+	}
 
-			// If not waiting to flush, add straight away to bootstrap result
-			lock.Lock()
-			bootstrapResult.Add(shard, shardResult, xtime.Ranges{})
-			lock.Unlock()
+	if shouldPersist {
+		persistenceQueue <- persistenceFlush{
+			nsMetadata:        nsMetadata,
+			shard:             shard,
+			shardRetrieverMgr: shardRetrieverMgr,
+			shardResult:       shardResult,
+			timeRange:         fetch.timeRange,
 		}
+		return
 	}
+
+	// If not waiting to flush, add straight away to bootstrap result
+	lock.Lock()
+	bootstrapResult.Add(shard, shardResult, xtime.Ranges{})
+	lock.Unlock()
 }
 
 func (s *peersSource) logFetchBootstrapBlocksFromPeersOutcome(
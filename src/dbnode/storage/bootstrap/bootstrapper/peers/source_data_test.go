@@ -764,3 +764,75 @@ func assertBlockChecksum(t *testing.T, expectedChecksum uint32, block block.Data
 	require.NoError(t, err)
 	require.Equal(t, expectedChecksum, checksum)
 }
+
+func TestPeersSourceBlockFetchesMostRecentFirst(t *testing.T) {
+	var (
+		blockSize = 2 * time.Hour
+		start     = time.Now().Truncate(blockSize)
+		target    = result.ShardTimeRanges{
+			0: xtime.NewRanges(xtime.Range{Start: start, End: start.Add(2 * blockSize)}),
+			1: xtime.NewRanges(xtime.Range{Start: start.Add(blockSize), End: start.Add(3 * blockSize)}),
+		}
+	)
+
+	fetches := newBlockFetches(target, blockSize)
+
+	type shardStart struct {
+		shard uint32
+		start time.Time
+	}
+	var actual []shardStart
+	for _, fetch := range fetches {
+		require.Equal(t, blockSize, fetch.timeRange.End.Sub(fetch.timeRange.Start))
+		actual = append(actual, shardStart{shard: fetch.shard, start: fetch.timeRange.Start})
+	}
+	assert.Equal(t, []shardStart{
+		{shard: 1, start: start.Add(2 * blockSize)},
+		{shard: 0, start: start.Add(blockSize)},
+		{shard: 1, start: start.Add(blockSize)},
+		{shard: 0, start: start},
+	}, actual)
+}
+
+func TestPeersSourceMarksOnlyFailedBlockUnfulfilled(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	opts := testDefaultOpts
+	nsMetadata := testNamespaceMetadata(t)
+	ropts := nsMetadata.Options().RetentionOptions()
+
+	start := time.Now().Add(-ropts.RetentionPeriod()).Truncate(ropts.BlockSize())
+	mid := start.Add(ropts.BlockSize())
+	end := mid.Add(ropts.BlockSize())
+
+	mockAdminSession := client.NewMockAdminSession(ctrl)
+	mockAdminSession.EXPECT().
+		FetchBootstrapBlocksFromPeers(namespace.NewMetadataMatcher(nsMetadata),
+			uint32(0), start, mid, gomock.Any(), client.FetchBlocksMetadataEndpointV1).
+		Return(nil, fmt.Errorf("an error"))
+	mockAdminSession.EXPECT().
+		FetchBootstrapBlocksFromPeers(namespace.NewMetadataMatcher(nsMetadata),
+			uint32(0), mid, end, gomock.Any(), client.FetchBlocksMetadataEndpointV1).
+		Return(result.NewShardResult(0, opts.ResultOptions()), nil)
+
+	mockAdminClient := client.NewMockAdminClient(ctrl)
+	mockAdminClient.EXPECT().DefaultAdminSession().Return(mockAdminSession, nil)
+
+	opts = opts.SetAdminClient(mockAdminClient)
+
+	src, err := newPeersSource(opts)
+	require.NoError(t, err)
+
+	target := result.ShardTimeRanges{
+		0: xtime.NewRanges(xtime.Range{Start: start, End: end}),
+	}
+
+	r, err := src.ReadData(nsMetadata, target, testDefaultRunOpts)
+	require.NoError(t, err)
+
+	require.Equal(t, 1, r.Unfulfilled()[0].Len())
+	rangeIter := r.Unfulfilled()[0].Iter()
+	require.True(t, rangeIter.Next())
+	require.Equal(t, xtime.Range{Start: start, End: mid}, rangeIter.Value())
+}
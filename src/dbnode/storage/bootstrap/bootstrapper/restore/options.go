@@ -0,0 +1,71 @@
+// Copyright (c) 2018 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package restore
+
+import (
+	"errors"
+
+	"github.com/m3db/m3/src/dbnode/storage/backup"
+	bfs "github.com/m3db/m3/src/dbnode/storage/bootstrap/bootstrapper/fs"
+)
+
+var (
+	errBackupManagerNotSet = errors.New("backup manager not set")
+)
+
+type options struct {
+	fsOpts    bfs.Options
+	backupMgr *backup.Manager
+}
+
+// NewOptions creates a new Options.
+func NewOptions() Options {
+	return &options{
+		fsOpts: bfs.NewOptions(),
+	}
+}
+
+func (o *options) Validate() error {
+	if o.backupMgr == nil {
+		return errBackupManagerNotSet
+	}
+	return o.fsOpts.Validate()
+}
+
+func (o *options) SetFilesystemBootstrapperOptions(value bfs.Options) Options {
+	opts := *o
+	opts.fsOpts = value
+	return &opts
+}
+
+func (o *options) FilesystemBootstrapperOptions() bfs.Options {
+	return o.fsOpts
+}
+
+func (o *options) SetBackupManager(value *backup.Manager) Options {
+	opts := *o
+	opts.backupMgr = value
+	return &opts
+}
+
+func (o *options) BackupManager() *backup.Manager {
+	return o.backupMgr
+}
@@ -0,0 +1,87 @@
+// Copyright (c) 2018 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package restore
+
+import (
+	"fmt"
+
+	"github.com/m3db/m3/src/dbnode/storage/bootstrap"
+	"github.com/m3db/m3/src/dbnode/storage/bootstrap/bootstrapper"
+	bfs "github.com/m3db/m3/src/dbnode/storage/bootstrap/bootstrapper/fs"
+)
+
+const (
+	// RestoreBootstrapperName is the name of the restore bootstrapper.
+	RestoreBootstrapperName = "restore"
+)
+
+type restoreBootstrapperProvider struct {
+	opts Options
+	next bootstrap.BootstrapperProvider
+}
+
+// NewRestoreBootstrapperProvider creates a new bootstrapper to bootstrap
+// from filesets restored from backups in object storage.
+func NewRestoreBootstrapperProvider(
+	opts Options,
+	next bootstrap.BootstrapperProvider,
+) (bootstrap.BootstrapperProvider, error) {
+	if err := opts.Validate(); err != nil {
+		return nil, fmt.Errorf("unable to validate restore options: %v", err)
+	}
+	return restoreBootstrapperProvider{
+		opts: opts,
+		next: next,
+	}, nil
+}
+
+func (p restoreBootstrapperProvider) Provide() (bootstrap.Bootstrapper, error) {
+	fsSource, err := bfs.NewFileSystemSource(p.opts.FilesystemBootstrapperOptions())
+	if err != nil {
+		return nil, err
+	}
+
+	var (
+		src  = newRestoreSource(p.opts, fsSource)
+		b    = &restoreBootstrapper{}
+		next bootstrap.Bootstrapper
+	)
+	if p.next != nil {
+		next, err = p.next.Provide()
+		if err != nil {
+			return nil, err
+		}
+	}
+	return bootstrapper.NewBaseBootstrapper(b.String(), src,
+		p.opts.FilesystemBootstrapperOptions().ResultOptions(), next)
+}
+
+func (p restoreBootstrapperProvider) String() string {
+	return RestoreBootstrapperName
+}
+
+type restoreBootstrapper struct {
+	bootstrap.Bootstrapper
+}
+
+func (*restoreBootstrapper) String() string {
+	return RestoreBootstrapperName
+}
@@ -0,0 +1,221 @@
+// Copyright (c) 2018 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package restore
+
+import (
+	"time"
+
+	"github.com/m3db/m3/src/dbnode/storage/backup"
+	"github.com/m3db/m3/src/dbnode/storage/bootstrap"
+	"github.com/m3db/m3/src/dbnode/storage/bootstrap/result"
+	"github.com/m3db/m3/src/dbnode/storage/namespace"
+	xlog "github.com/m3db/m3x/log"
+	xtime "github.com/m3db/m3x/time"
+
+	"github.com/uber-go/tally"
+)
+
+// restoreSource bootstraps shards by downloading the backed up filesets of
+// any blocks that are missing locally and then reading them with the
+// filesystem source. Restored filesets are indistinguishable from flushed
+// ones so once restored they are also used by subsequent bootstraps.
+type restoreSource struct {
+	opts     Options
+	manager  *backup.Manager
+	fsSource bootstrap.Source
+	log      xlog.Logger
+	metrics  restoreSourceMetrics
+}
+
+type restoreSourceMetrics struct {
+	dataBlocksRestored  tally.Counter
+	indexBlocksRestored tally.Counter
+	restoreErrors       tally.Counter
+}
+
+func newRestoreSource(opts Options, fsSource bootstrap.Source) bootstrap.Source {
+	iopts := opts.FilesystemBootstrapperOptions().InstrumentOptions()
+	scope := iopts.MetricsScope().SubScope("restore-bootstrapper")
+	return &restoreSource{
+		opts:     opts,
+		manager:  opts.BackupManager(),
+		fsSource: fsSource,
+		log:      iopts.Logger(),
+		metrics: restoreSourceMetrics{
+			dataBlocksRestored:  scope.Counter("data-blocks-restored"),
+			indexBlocksRestored: scope.Counter("index-blocks-restored"),
+			restoreErrors:       scope.Counter("restore-errors"),
+		},
+	}
+}
+
+func (s *restoreSource) Can(strategy bootstrap.Strategy) bool {
+	return s.fsSource.Can(strategy)
+}
+
+func (s *restoreSource) AvailableData(
+	md namespace.Metadata,
+	shardsTimeRanges result.ShardTimeRanges,
+	runOpts bootstrap.RunOptions,
+) result.ShardTimeRanges {
+	return s.availability(md, shardsTimeRanges)
+}
+
+func (s *restoreSource) ReadData(
+	md namespace.Metadata,
+	shardsTimeRanges result.ShardTimeRanges,
+	runOpts bootstrap.RunOptions,
+) (result.DataBootstrapResult, error) {
+	s.restoreData(md, shardsTimeRanges)
+	return s.fsSource.ReadData(md, shardsTimeRanges, runOpts)
+}
+
+func (s *restoreSource) AvailableIndex(
+	md namespace.Metadata,
+	shardsTimeRanges result.ShardTimeRanges,
+	runOpts bootstrap.RunOptions,
+) result.ShardTimeRanges {
+	return s.availability(md, shardsTimeRanges)
+}
+
+func (s *restoreSource) ReadIndex(
+	md namespace.Metadata,
+	shardsTimeRanges result.ShardTimeRanges,
+	runOpts bootstrap.RunOptions,
+) (result.IndexBootstrapResult, error) {
+	// The filesystem source builds index blocks from data filesets when no
+	// index fileset exists so restore both, data filesets restored while
+	// bootstrapping data already exist locally and are not downloaded again.
+	s.restoreIndex(md, shardsTimeRanges)
+	s.restoreData(md, shardsTimeRanges)
+	return s.fsSource.ReadIndex(md, shardsTimeRanges, runOpts)
+}
+
+func (s *restoreSource) availability(
+	md namespace.Metadata,
+	shardsTimeRanges result.ShardTimeRanges,
+) result.ShardTimeRanges {
+	var (
+		blockSize = md.Options().RetentionOptions().BlockSize()
+		available = make(result.ShardTimeRanges)
+	)
+	for shard, ranges := range shardsTimeRanges {
+		var tr xtime.Ranges
+		forEachBlockStart(ranges, blockSize, func(blockStart time.Time) {
+			ok, err := s.manager.HasData(md.ID(), shard, blockStart)
+			if err != nil {
+				s.logRestoreError(md, shard, blockStart, err)
+				return
+			}
+			if ok {
+				tr = tr.AddRange(xtime.Range{
+					Start: blockStart,
+					End:   blockStart.Add(blockSize),
+				})
+			}
+		})
+		available[shard] = tr
+	}
+	return available
+}
+
+func (s *restoreSource) restoreData(
+	md namespace.Metadata,
+	shardsTimeRanges result.ShardTimeRanges,
+) {
+	blockSize := md.Options().RetentionOptions().BlockSize()
+	for shard, ranges := range shardsTimeRanges {
+		forEachBlockStart(ranges, blockSize, func(blockStart time.Time) {
+			// Failures are not fatal, blocks that could not be restored are
+			// left unfulfilled for the next bootstrapper.
+			ok, err := s.manager.RestoreData(md.ID(), shard, blockStart)
+			if err != nil {
+				s.logRestoreError(md, shard, blockStart, err)
+				return
+			}
+			if ok {
+				s.metrics.dataBlocksRestored.Inc(1)
+			}
+		})
+	}
+}
+
+func (s *restoreSource) restoreIndex(
+	md namespace.Metadata,
+	shardsTimeRanges result.ShardTimeRanges,
+) {
+	// Index blocks span all shards so restore each index block overlapping
+	// any of the requested ranges once.
+	var (
+		blockSize   = md.Options().IndexOptions().BlockSize()
+		blockStarts = make(map[xtime.UnixNano]struct{})
+	)
+	for _, ranges := range shardsTimeRanges {
+		forEachBlockStart(ranges, blockSize, func(blockStart time.Time) {
+			blockStarts[xtime.ToUnixNano(blockStart)] = struct{}{}
+		})
+	}
+	for blockStartNanos := range blockStarts {
+		blockStart := blockStartNanos.ToTime()
+		ok, err := s.manager.RestoreIndex(md.ID(), blockStart)
+		if err != nil {
+			s.log.WithFields(
+				xlog.NewField("namespace", md.ID().String()),
+				xlog.NewField("blockStart", blockStart.String()),
+				xlog.NewField("error", err.Error()),
+			).Error("unable to restore index block from backup")
+			s.metrics.restoreErrors.Inc(1)
+			continue
+		}
+		if ok {
+			s.metrics.indexBlocksRestored.Inc(1)
+		}
+	}
+}
+
+func (s *restoreSource) logRestoreError(
+	md namespace.Metadata,
+	shard uint32,
+	blockStart time.Time,
+	err error,
+) {
+	s.log.WithFields(
+		xlog.NewField("namespace", md.ID().String()),
+		xlog.NewField("shard", shard),
+		xlog.NewField("blockStart", blockStart.String()),
+		xlog.NewField("error", err.Error()),
+	).Error("unable to restore block from backup")
+	s.metrics.restoreErrors.Inc(1)
+}
+
+func forEachBlockStart(
+	ranges xtime.Ranges,
+	blockSize time.Duration,
+	fn func(blockStart time.Time),
+) {
+	it := ranges.Iter()
+	for it.Next() {
+		currRange := it.Value()
+		for blockStart := currRange.Start.Truncate(blockSize); blockStart.Before(currRange.End); blockStart = blockStart.Add(blockSize) {
+			fn(blockStart)
+		}
+	}
+}
@@ -0,0 +1,174 @@
+// Copyright (c) 2018 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package restore
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/m3db/m3/src/dbnode/persist/fs"
+	"github.com/m3db/m3/src/dbnode/retention"
+	"github.com/m3db/m3/src/dbnode/storage/backup"
+	"github.com/m3db/m3/src/dbnode/storage/bootstrap"
+	"github.com/m3db/m3/src/dbnode/storage/bootstrap/result"
+	"github.com/m3db/m3/src/dbnode/storage/namespace"
+	"github.com/m3db/m3x/ident"
+	xtime "github.com/m3db/m3x/time"
+
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/require"
+)
+
+var (
+	testNamespaceID = ident.StringID("testns")
+	testBlockSize   = 2 * time.Hour
+	testBlockStart  = time.Now().Truncate(testBlockSize).Add(-4 * testBlockSize)
+)
+
+func testNamespaceMetadata(t *testing.T) namespace.Metadata {
+	md, err := namespace.NewMetadata(testNamespaceID, namespace.NewOptions().
+		SetRetentionOptions(retention.NewOptions().SetBlockSize(testBlockSize)))
+	require.NoError(t, err)
+	return md
+}
+
+// newTestBackupManager backs up a data fileset for the block of the shard
+// and returns a manager restoring from that backup into a new directory.
+func newTestBackupManager(
+	t *testing.T,
+	shard uint32,
+	blockStart time.Time,
+) (*backup.Manager, string, func()) {
+	var (
+		dirs    []string
+		cleanup = func() {
+			for _, dir := range dirs {
+				os.RemoveAll(dir)
+			}
+		}
+	)
+	for i := 0; i < 3; i++ {
+		dir, err := ioutil.TempDir("", "restore-source")
+		require.NoError(t, err)
+		dirs = append(dirs, dir)
+	}
+	filePathPrefix, restorePathPrefix, storeDir := dirs[0], dirs[1], dirs[2]
+
+	shardDir := fs.ShardDataDirPath(filePathPrefix, testNamespaceID, shard)
+	require.NoError(t, os.MkdirAll(shardDir, 0755))
+	for _, suffix := range []string{"info", "data", "checkpoint"} {
+		filePath := filepath.Join(shardDir,
+			fmt.Sprintf("fileset-%d-%s.db", blockStart.UnixNano(), suffix))
+		require.NoError(t, ioutil.WriteFile(filePath, []byte(suffix), 0644))
+	}
+
+	store := backup.NewDirectoryObjectStore(storeDir)
+	backupMgr, err := backup.NewManager(backup.ManagerOptions{
+		Store:          store,
+		FilePathPrefix: filePathPrefix,
+	})
+	require.NoError(t, err)
+	_, err = backupMgr.Backup([]backup.Namespace{
+		{ID: testNamespaceID, Shards: []uint32{shard}},
+	})
+	require.NoError(t, err)
+
+	restoreMgr, err := backup.NewManager(backup.ManagerOptions{
+		Store:          store,
+		FilePathPrefix: restorePathPrefix,
+	})
+	require.NoError(t, err)
+	return restoreMgr, restorePathPrefix, cleanup
+}
+
+func TestRestoreSourceAvailableData(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mgr, _, cleanup := newTestBackupManager(t, 0, testBlockStart)
+	defer cleanup()
+
+	src := newRestoreSource(NewOptions().SetBackupManager(mgr),
+		bootstrap.NewMockSource(ctrl))
+
+	end := testBlockStart.Add(3 * testBlockSize)
+	available := src.AvailableData(testNamespaceMetadata(t), result.ShardTimeRanges{
+		0: xtime.Ranges{}.AddRange(xtime.Range{Start: testBlockStart, End: end}),
+		1: xtime.Ranges{}.AddRange(xtime.Range{Start: testBlockStart, End: end}),
+	}, bootstrap.NewRunOptions())
+
+	require.True(t, available.Equal(result.ShardTimeRanges{
+		0: xtime.Ranges{}.AddRange(xtime.Range{
+			Start: testBlockStart,
+			End:   testBlockStart.Add(testBlockSize),
+		}),
+		1: xtime.Ranges{},
+	}))
+}
+
+func TestRestoreSourceReadDataRestoresBeforeReading(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mgr, restorePathPrefix, cleanup := newTestBackupManager(t, 0, testBlockStart)
+	defer cleanup()
+
+	var (
+		md       = testNamespaceMetadata(t)
+		runOpts  = bootstrap.NewRunOptions()
+		fsSource = bootstrap.NewMockSource(ctrl)
+		src      = newRestoreSource(NewOptions().SetBackupManager(mgr), fsSource)
+		ranges   = result.ShardTimeRanges{
+			0: xtime.Ranges{}.AddRange(xtime.Range{
+				Start: testBlockStart,
+				End:   testBlockStart.Add(testBlockSize),
+			}),
+		}
+		expected = result.NewDataBootstrapResult()
+	)
+
+	fsSource.EXPECT().ReadData(md, ranges, runOpts).DoAndReturn(
+		func(
+			_ namespace.Metadata,
+			_ result.ShardTimeRanges,
+			_ bootstrap.RunOptions,
+		) (result.DataBootstrapResult, error) {
+			// The fileset must be restored before the filesystem source reads.
+			exists, err := fs.DataFileSetExistsAt(restorePathPrefix,
+				testNamespaceID, 0, testBlockStart)
+			require.NoError(t, err)
+			require.True(t, exists)
+			return expected, nil
+		})
+
+	res, err := src.ReadData(md, ranges, runOpts)
+	require.NoError(t, err)
+	require.Equal(t, expected, res)
+}
+
+func TestNewRestoreBootstrapperProviderRequiresBackupManager(t *testing.T) {
+	_, err := NewRestoreBootstrapperProvider(NewOptions(), nil)
+	require.Error(t, err)
+}
@@ -0,0 +1,46 @@
+// Copyright (c) 2018 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package restore
+
+import (
+	"github.com/m3db/m3/src/dbnode/storage/backup"
+	bfs "github.com/m3db/m3/src/dbnode/storage/bootstrap/bootstrapper/fs"
+)
+
+// Options represents the options for bootstrapping from backups.
+type Options interface {
+	// Validate validates the options are correct
+	Validate() error
+
+	// SetFilesystemBootstrapperOptions sets the options of the filesystem
+	// bootstrapper used to read filesets once restored.
+	SetFilesystemBootstrapperOptions(value bfs.Options) Options
+
+	// FilesystemBootstrapperOptions returns the options of the filesystem
+	// bootstrapper used to read filesets once restored.
+	FilesystemBootstrapperOptions() bfs.Options
+
+	// SetBackupManager sets the backup manager filesets are restored with.
+	SetBackupManager(value *backup.Manager) Options
+
+	// BackupManager returns the backup manager filesets are restored with.
+	BackupManager() *backup.Manager
+}
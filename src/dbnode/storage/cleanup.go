@@ -203,6 +203,14 @@ func (m *cleanupManager) cleanupExpiredDataFiles(t time.Time) error {
 			continue
 		}
 		earliestToRetain := retention.FlushTimeStart(n.Options().RetentionOptions(), t)
+		if tracker := m.opts.DiskQuotaTracker(); tracker != nil {
+			// Expire the oldest flushed blocks early while the namespace
+			// exceeds its soft disk quota.
+			expireBefore, ok := tracker.ExpireBefore(n.ID())
+			if ok && expireBefore.After(earliestToRetain) {
+				earliestToRetain = expireBefore
+			}
+		}
 		shards := n.GetOwnedShards()
 		multiErr = multiErr.Add(m.cleanupExpiredNamespaceDataFiles(earliestToRetain, shards))
 	}
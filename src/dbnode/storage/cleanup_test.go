@@ -23,13 +23,18 @@ package storage
 import (
 	"errors"
 	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
 	"strings"
 	"testing"
 	"time"
 
+	"github.com/m3db/m3/src/dbnode/persist/fs"
 	"github.com/m3db/m3/src/dbnode/persist/fs/commitlog"
 	"github.com/m3db/m3/src/dbnode/retention"
 	"github.com/m3db/m3/src/dbnode/storage/namespace"
+	"github.com/m3db/m3/src/dbnode/storage/quota"
 	"github.com/m3db/m3x/ident"
 	xtest "github.com/m3db/m3x/test"
 
@@ -121,6 +126,61 @@ func TestCleanupManagerNamespaceCleanup(t *testing.T) {
 	require.NoError(t, mgr.Cleanup(ts))
 }
 
+func TestCleanupManagerExpiresDataFilesOverSoftDiskQuota(t *testing.T) {
+	ctrl := gomock.NewController(xtest.Reporter{t})
+	defer ctrl.Finish()
+
+	filePathPrefix, err := ioutil.TempDir("", "cleanup-disk-quota")
+	require.NoError(t, err)
+	defer os.RemoveAll(filePathPrefix)
+
+	var (
+		nsID  = ident.StringID("ns")
+		ts    = timeFor(36000)
+		rOpts = retention.NewOptions().
+			SetRetentionPeriod(21600 * time.Second).
+			SetBlockSize(3600 * time.Second)
+		nsOpts = namespace.NewOptions().
+			SetRetentionOptions(rOpts).
+			SetCleanupEnabled(true)
+	)
+
+	// Five blocks of 10 bytes each from the earliest block retained
+	dir := fs.ShardDataDirPath(filePathPrefix, nsID, 0)
+	require.NoError(t, os.MkdirAll(dir, 0755))
+	for i := 0; i < 5; i++ {
+		blockStart := timeFor(14400).Add(time.Duration(i) * rOpts.BlockSize())
+		filePath := filepath.Join(dir,
+			fmt.Sprintf("fileset-%d-data.db", blockStart.UnixNano()))
+		require.NoError(t, ioutil.WriteFile(filePath, make([]byte, 10), 0644))
+	}
+
+	tracker, err := quota.NewTracker(quota.TrackerOptions{
+		FilePathPrefix: filePathPrefix,
+		Quotas: map[string]quota.Quota{
+			nsID.String(): quota.Quota{SoftLimitBytes: 25},
+		},
+	})
+	require.NoError(t, err)
+	require.NoError(t, tracker.Measure([]quota.Namespace{
+		{ID: nsID, Shards: []uint32{0}},
+	}))
+
+	shard := NewMockdatabaseShard(ctrl)
+	ns := NewMockdatabaseNamespace(ctrl)
+	ns.EXPECT().ID().Return(nsID).AnyTimes()
+	ns.EXPECT().Options().Return(nsOpts).AnyTimes()
+	ns.EXPECT().GetOwnedShards().Return([]databaseShard{shard}).AnyTimes()
+
+	db := newMockdatabase(ctrl, ns)
+	mgr := newCleanupManager(db, tally.NoopScope).(*cleanupManager)
+	mgr.opts = mgr.opts.SetDiskQuotaTracker(tracker)
+
+	// The three oldest blocks are expired to free the 25 bytes over quota
+	shard.EXPECT().CleanupExpiredFileSets(timeFor(25200)).Return(nil)
+	require.NoError(t, mgr.cleanupExpiredDataFiles(ts))
+}
+
 // Test NS doesn't cleanup when flag is present
 func TestCleanupManagerDoesntNeedCleanup(t *testing.T) {
 	ctrl := gomock.NewController(t)
@@ -57,6 +57,19 @@ var (
 
 	// errDatabaseIsClosed raised when trying to perform an action that requires an open database
 	errDatabaseIsClosed = errors.New("database is closed")
+
+	// errDatabaseAlreadyDraining raised when trying to drain a database that is already draining
+	errDatabaseAlreadyDraining = errors.New("database is already draining")
+
+	// errDatabaseNotBootstrapped raised when trying to drain a database that is not bootstrapped
+	errDatabaseNotBootstrapped = errors.New("database is not bootstrapped")
+
+	// ErrDatabaseDraining raised when trying to write to a database that is draining
+	ErrDatabaseDraining = errors.New("database is draining")
+)
+
+const (
+	commitLogDrainCheckInterval = 100 * time.Millisecond
 )
 
 type databaseState int
@@ -82,8 +95,9 @@ type db struct {
 	namespaces *databaseNamespacesMap
 	commitLog  commitlog.CommitLog
 
-	state    databaseState
-	mediator databaseMediator
+	state      databaseState
+	drainState DrainState
+	mediator   databaseMediator
 
 	created    uint64
 	bootstraps int
@@ -101,11 +115,15 @@ type databaseMetrics struct {
 	unknownNamespaceRead                tally.Counter
 	unknownNamespaceWrite               tally.Counter
 	unknownNamespaceWriteTagged         tally.Counter
+	unknownNamespaceWriteBatch          tally.Counter
+	unknownNamespaceWriteTaggedBatch    tally.Counter
 	unknownNamespaceFetchBlocks         tally.Counter
 	unknownNamespaceFetchBlocksMetadata tally.Counter
+	unknownNamespaceIterateShardBlock   tally.Counter
 	unknownNamespaceQueryIDs            tally.Counter
 	errQueryIDsIndexDisabled            tally.Counter
 	errWriteTaggedIndexDisabled         tally.Counter
+	drainingWrite                       tally.Counter
 }
 
 func newDatabaseMetrics(scope tally.Scope) databaseMetrics {
@@ -115,11 +133,15 @@ func newDatabaseMetrics(scope tally.Scope) databaseMetrics {
 		unknownNamespaceRead:                unknownNamespaceScope.Counter("read"),
 		unknownNamespaceWrite:               unknownNamespaceScope.Counter("write"),
 		unknownNamespaceWriteTagged:         unknownNamespaceScope.Counter("write-tagged"),
+		unknownNamespaceWriteBatch:          unknownNamespaceScope.Counter("write-batch"),
+		unknownNamespaceWriteTaggedBatch:    unknownNamespaceScope.Counter("write-tagged-batch"),
 		unknownNamespaceFetchBlocks:         unknownNamespaceScope.Counter("fetch-blocks"),
 		unknownNamespaceFetchBlocksMetadata: unknownNamespaceScope.Counter("fetch-blocks-metadata"),
+		unknownNamespaceIterateShardBlock:   unknownNamespaceScope.Counter("iterate-shard-block"),
 		unknownNamespaceQueryIDs:            unknownNamespaceScope.Counter("query-ids"),
 		errQueryIDsIndexDisabled:            indexDisabledScope.Counter("err-query-ids"),
 		errWriteTaggedIndexDisabled:         indexDisabledScope.Counter("err-write-tagged"),
+		drainingWrite:                       scope.SubScope("draining").Counter("write"),
 	}
 }
 
@@ -199,7 +221,7 @@ func (d *db) UpdateOwnedNamespaces(newNamespaces namespace.Map) error {
 	d.Lock()
 	defer d.Unlock()
 
-	removes, adds, updates := d.namespaceDeltaWithLock(newNamespaces)
+	removes, adds, updates, stateUpdates := d.namespaceDeltaWithLock(newNamespaces)
 	if err := d.logNamespaceUpdate(removes, adds, updates); err != nil {
 		enrichedErr := fmt.Errorf("unable to log namespace updates: %v", err)
 		d.log.Errorf("%v", enrichedErr)
@@ -213,6 +235,22 @@ func (d *db) UpdateOwnedNamespaces(newNamespaces namespace.Map) error {
 		return err
 	}
 
+	// apply namespace state changes, unlike other updates these take
+	// effect without a restart
+	for _, md := range stateUpdates {
+		ns, ok := d.namespaces.Get(md.ID())
+		if !ok {
+			continue
+		}
+		opts := md.Options()
+		d.log.WithFields(
+			xlog.NewField("namespace", md.ID().String()),
+			xlog.NewField("state", opts.State().String()),
+			xlog.NewField("deleteAfter", opts.DeleteAfter().String()),
+		).Infof("updating namespace state")
+		ns.SetState(opts.State(), opts.DeleteAfter())
+	}
+
 	// log that updates and removals are skipped
 	if len(removes) > 0 || len(updates) > 0 {
 		d.log.Warnf("skipping namespace removals and updates, restart process if you want changes to take effect.")
@@ -226,12 +264,16 @@ func (d *db) UpdateOwnedNamespaces(newNamespaces namespace.Map) error {
 	return nil
 }
 
-func (d *db) namespaceDeltaWithLock(newNamespaces namespace.Map) ([]ident.ID, []namespace.Metadata, []namespace.Metadata) {
+func (d *db) namespaceDeltaWithLock(
+	newNamespaces namespace.Map,
+) ([]ident.ID, []namespace.Metadata, []namespace.Metadata, []namespace.Metadata) {
 	var (
-		existing = d.namespaces
-		removes  []ident.ID
-		adds     []namespace.Metadata
-		updates  []namespace.Metadata
+		existing     = d.namespaces
+		now          = d.nowFn()
+		removes      []ident.ID
+		adds         []namespace.Metadata
+		updates      []namespace.Metadata
+		stateUpdates []namespace.Metadata
 	)
 
 	// check if existing namespaces exist in newNamespaces
@@ -245,8 +287,20 @@ func (d *db) namespaceDeltaWithLock(newNamespaces namespace.Map) ([]ident.ID, []
 			continue
 		}
 
+		// state changes are applied separately from other option changes
+		var (
+			newOpts            = newMd.Options()
+			state, deleteAfter = ns.State()
+		)
+		if newOpts.State() != state || !newOpts.DeleteAfter().Equal(deleteAfter) {
+			stateUpdates = append(stateUpdates, newMd)
+		}
+
 		// if namespace exists in newNamespaces, check if options are the same
-		optionsSame := newMd.Options().Equal(ns.Options())
+		optionsSame := newOpts.
+			SetState(ns.Options().State()).
+			SetDeleteAfter(ns.Options().DeleteAfter()).
+			Equal(ns.Options())
 
 		// if options are the same, we don't need to do anything
 		if optionsSame {
@@ -258,14 +312,15 @@ func (d *db) namespaceDeltaWithLock(newNamespaces namespace.Map) ([]ident.ID, []
 	}
 
 	// check for any namespaces that need to be added
+	// namespaces that are due to be deleted are not added back once removed
 	for _, ns := range newNamespaces.Metadatas() {
 		_, exists := d.namespaces.Get(ns.ID())
-		if !exists {
+		if !exists && !namespace.ShouldDelete(ns.Options(), now) {
 			adds = append(adds, ns)
 		}
 	}
 
-	return removes, adds, updates
+	return removes, adds, updates, stateUpdates
 }
 
 func (d *db) logNamespaceUpdate(removes []ident.ID, adds, updates []namespace.Metadata) error {
@@ -315,6 +370,32 @@ func (d *db) addNamespacesWithLock(namespaces []namespace.Metadata) error {
 	return nil
 }
 
+func (d *db) RemoveDeletedNamespaces(now time.Time) error {
+	d.Lock()
+	var deleted []databaseNamespace
+	for _, entry := range d.namespaces.Iter() {
+		ns := entry.Value()
+		state, deleteAfter := ns.State()
+		if state != namespace.StatePendingDelete || now.Before(deleteAfter) {
+			continue
+		}
+		d.namespaces.Delete(ns.ID())
+		deleted = append(deleted, ns)
+	}
+	d.Unlock()
+
+	// The data of the namespaces is deleted by the cleanup of files of
+	// namespaces that the database no longer owns.
+	var multiErr xerrors.MultiError
+	for _, ns := range deleted {
+		d.log.WithFields(
+			xlog.NewField("namespace", ns.ID().String()),
+		).Infof("deleting namespace pending deletion")
+		multiErr = multiErr.Add(ns.Close())
+	}
+	return multiErr.FinalError()
+}
+
 func (d *db) newDatabaseNamespaceWithLock(
 	md namespace.Metadata,
 ) (databaseNamespace, error) {
@@ -481,6 +562,11 @@ func (d *db) Write(
 	unit xtime.Unit,
 	annotation []byte,
 ) error {
+	if d.isDraining() {
+		d.metrics.drainingWrite.Inc(1)
+		return ErrDatabaseDraining
+	}
+
 	n, err := d.namespaceFor(namespace)
 	if err != nil {
 		d.metrics.unknownNamespaceWrite.Inc(1)
@@ -504,6 +590,11 @@ func (d *db) WriteTagged(
 	unit xtime.Unit,
 	annotation []byte,
 ) error {
+	if d.isDraining() {
+		d.metrics.drainingWrite.Inc(1)
+		return ErrDatabaseDraining
+	}
+
 	n, err := d.namespaceFor(namespace)
 	if err != nil {
 		d.metrics.unknownNamespaceWriteTagged.Inc(1)
@@ -517,6 +608,59 @@ func (d *db) WriteTagged(
 	return err
 }
 
+func (d *db) WriteBatch(
+	ctx context.Context,
+	namespace ident.ID,
+	writes []BatchWrite,
+	errFn BatchWriteErrorFn,
+) error {
+	if d.isDraining() {
+		d.metrics.drainingWrite.Inc(1)
+		return ErrDatabaseDraining
+	}
+
+	n, err := d.namespaceFor(namespace)
+	if err != nil {
+		d.metrics.unknownNamespaceWriteBatch.Inc(1)
+		return err
+	}
+
+	return n.WriteBatch(ctx, writes, d.recordingBatchWriteErrorFn(errFn))
+}
+
+func (d *db) WriteTaggedBatch(
+	ctx context.Context,
+	namespace ident.ID,
+	writes []BatchWrite,
+	errFn BatchWriteErrorFn,
+) error {
+	if d.isDraining() {
+		d.metrics.drainingWrite.Inc(1)
+		return ErrDatabaseDraining
+	}
+
+	n, err := d.namespaceFor(namespace)
+	if err != nil {
+		d.metrics.unknownNamespaceWriteTaggedBatch.Inc(1)
+		return err
+	}
+
+	return n.WriteTaggedBatch(ctx, writes, d.recordingBatchWriteErrorFn(errFn))
+}
+
+// recordingBatchWriteErrorFn records writes of a batch rejected by a full
+// commit log queue as errors before passing them on to errFn.
+func (d *db) recordingBatchWriteErrorFn(
+	errFn BatchWriteErrorFn,
+) BatchWriteErrorFn {
+	return func(index int, err error) {
+		if err == commitlog.ErrCommitLogQueueFull {
+			d.errors.Record(1)
+		}
+		errFn(index, err)
+	}
+}
+
 func (d *db) QueryIDs(
 	ctx context.Context,
 	namespace ident.ID,
@@ -573,6 +717,21 @@ func (d *db) FetchBlocks(
 	return n.FetchBlocks(ctx, shardID, id, starts)
 }
 
+func (d *db) IterateShardBlock(
+	ctx context.Context,
+	namespace ident.ID,
+	shardID uint32,
+	blockStart time.Time,
+) (ShardBlockIterator, error) {
+	n, err := d.namespaceFor(namespace)
+	if err != nil {
+		d.metrics.unknownNamespaceIterateShardBlock.Inc(1)
+		return nil, xerrors.NewInvalidParamsError(err)
+	}
+
+	return n.IterateShardBlock(ctx, shardID, blockStart)
+}
+
 func (d *db) FetchBlocksMetadata(
 	ctx context.Context,
 	namespace ident.ID,
@@ -653,6 +812,61 @@ func (d *db) BootstrapState() DatabaseBootstrapState {
 	}
 }
 
+func (d *db) CommitLogQueueLength() int {
+	return d.commitLog.QueueLength()
+}
+
+func (d *db) Drain() error {
+	if !d.IsBootstrapped() {
+		return errDatabaseNotBootstrapped
+	}
+
+	d.Lock()
+	if d.drainState != DrainNotStarted {
+		d.Unlock()
+		return errDatabaseAlreadyDraining
+	}
+	d.drainState = Draining
+	d.Unlock()
+
+	d.log.Info("draining database")
+
+	// Wait for any in progress file operations to complete and prevent new
+	// ones from starting, then force a tick which flushes and snapshots.
+	d.mediator.DisableFileOps()
+	if err := d.mediator.Tick(syncRun, force); err != nil {
+		// Resume accepting writes so the drain can be retried.
+		d.mediator.EnableFileOps()
+		d.Lock()
+		d.drainState = DrainNotStarted
+		d.Unlock()
+		return err
+	}
+
+	// No new writes are accepted so the commit log queue only shrinks.
+	for d.commitLog.QueueLength() > 0 {
+		time.Sleep(commitLogDrainCheckInterval)
+	}
+
+	d.Lock()
+	d.drainState = Drained
+	d.Unlock()
+
+	d.log.Info("drained database")
+	return nil
+}
+
+func (d *db) DrainState() DrainState {
+	d.RLock()
+	state := d.drainState
+	d.RUnlock()
+	return state
+}
+
+func (d *db) isDraining() bool {
+	return d.DrainState() != DrainNotStarted
+}
+
 func (d *db) namespaceFor(namespace ident.ID) (databaseNamespace, error) {
 	d.RLock()
 	n, exists := d.namespaces.Get(namespace)
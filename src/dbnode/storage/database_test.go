@@ -667,6 +667,72 @@ func TestDatabaseUpdateNamespace(t *testing.T) {
 	require.Equal(t, defaultTestNs2Opts, ns2.Options())
 }
 
+func TestDatabaseUpdateNamespaceState(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	d, mapCh, _ := newTestDatabase(t, ctrl, Bootstrapped)
+	require.NoError(t, d.Open())
+	defer func() {
+		close(mapCh)
+		require.NoError(t, d.Close())
+		leaktest.CheckTimeout(t, time.Second)()
+	}()
+
+	// construct new namespace Map with ns1 marked read only
+	md1, err := namespace.NewMetadata(defaultTestNs1ID,
+		defaultTestNs1Opts.SetState(namespace.StateReadOnly))
+	require.NoError(t, err)
+	md2, err := namespace.NewMetadata(defaultTestNs2ID, defaultTestNs2Opts)
+	require.NoError(t, err)
+	nsMap, err := namespace.NewMap([]namespace.Metadata{md1, md2})
+	require.NoError(t, err)
+
+	// update the database watch with new Map
+	mapCh <- nsMap
+
+	// ensure the state is applied without the other options changing
+	ns1, ok := d.Namespace(defaultTestNs1ID)
+	require.True(t, ok)
+	require.True(t, xclock.WaitUntil(func() bool {
+		state, _ := ns1.(databaseNamespace).State()
+		return state == namespace.StateReadOnly
+	}, 2*time.Second))
+	require.Equal(t, defaultTestNs1Opts, ns1.Options())
+
+	ns2, ok := d.Namespace(defaultTestNs2ID)
+	require.True(t, ok)
+	state, _ := ns2.(databaseNamespace).State()
+	require.Equal(t, namespace.StateActive, state)
+}
+
+func TestDatabaseRemoveDeletedNamespaces(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	d, mapCh, _ := newTestDatabase(t, ctrl, Bootstrapped)
+	defer func() {
+		close(mapCh)
+	}()
+
+	now := time.Now()
+	active := dbAddNewMockNamespace(ctrl, d, "testns1")
+	active.EXPECT().State().Return(namespace.StateActive, time.Time{})
+	pending := dbAddNewMockNamespace(ctrl, d, "testns2")
+	pending.EXPECT().State().Return(namespace.StatePendingDelete, now.Add(time.Minute))
+	expired := dbAddNewMockNamespace(ctrl, d, "testns3")
+	expired.EXPECT().State().Return(namespace.StatePendingDelete, now)
+	expired.EXPECT().Close().Return(nil)
+
+	require.NoError(t, d.RemoveDeletedNamespaces(now))
+
+	result := d.Namespaces()
+	require.Equal(t, 2, len(result))
+	sort.Sort(NamespacesByID(result))
+	assert.Equal(t, "testns1", result[0].ID().String())
+	assert.Equal(t, "testns2", result[1].ID().String())
+}
+
 func TestDatabaseNamespaceIndexFunctions(t *testing.T) {
 	ctrl := gomock.NewController(t)
 	defer ctrl.Finish()
@@ -680,6 +746,7 @@ func TestDatabaseNamespaceIndexFunctions(t *testing.T) {
 	ns.EXPECT().GetOwnedShards().Return([]databaseShard{}).AnyTimes()
 	ns.EXPECT().Tick(gomock.Any(), gomock.Any()).Return(nil).AnyTimes()
 	ns.EXPECT().BootstrapState().Return(ShardBootstrapStates{}).AnyTimes()
+	ns.EXPECT().State().Return(namespace.StateActive, time.Time{}).AnyTimes()
 	require.NoError(t, d.Open())
 
 	ctx := context.NewContext()
@@ -744,3 +811,55 @@ func TestDatabaseBootstrapState(t *testing.T) {
 		},
 	}, dbBootstrapState)
 }
+
+func TestDatabaseDrain(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	d, mapCh, _ := newTestDatabase(t, ctrl, Bootstrapped)
+	defer func() {
+		close(mapCh)
+	}()
+
+	mediator := NewMockdatabaseMediator(ctrl)
+	mediator.EXPECT().IsBootstrapped().Return(true).AnyTimes()
+	gomock.InOrder(
+		mediator.EXPECT().DisableFileOps(),
+		mediator.EXPECT().Tick(syncRun, force).Return(nil),
+	)
+	d.mediator = mediator
+
+	require.Equal(t, DrainNotStarted, d.DrainState())
+	require.NoError(t, d.Drain())
+	require.Equal(t, Drained, d.DrainState())
+	require.Equal(t, errDatabaseAlreadyDraining, d.Drain())
+
+	ctx := context.NewContext()
+	defer ctx.Close()
+
+	err := d.Write(ctx, ident.StringID("testns"), ident.StringID("foo"),
+		time.Now(), 1.0, xtime.Second, nil)
+	require.Equal(t, ErrDatabaseDraining, err)
+}
+
+func TestDatabaseDrainTickError(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	d, mapCh, _ := newTestDatabase(t, ctrl, Bootstrapped)
+	defer func() {
+		close(mapCh)
+	}()
+
+	mediator := NewMockdatabaseMediator(ctrl)
+	mediator.EXPECT().IsBootstrapped().Return(true).AnyTimes()
+	gomock.InOrder(
+		mediator.EXPECT().DisableFileOps(),
+		mediator.EXPECT().Tick(syncRun, force).Return(errTickCancelled),
+		mediator.EXPECT().EnableFileOps(),
+	)
+	d.mediator = mediator
+
+	require.Equal(t, errTickCancelled, d.Drain())
+	require.Equal(t, DrainNotStarted, d.DrainState())
+}
@@ -24,7 +24,12 @@ import (
 	"sync"
 	"time"
 
+	"github.com/m3db/m3/src/dbnode/storage/backup"
+	"github.com/m3db/m3/src/dbnode/storage/quota"
+	"github.com/m3db/m3/src/x/loglevel"
 	xlog "github.com/m3db/m3x/log"
+
+	"github.com/uber-go/tally"
 )
 
 type fileOpStatus int
@@ -65,6 +70,9 @@ type fileSystemManager struct {
 	opts     Options
 	status   fileOpStatus
 	enabled  bool
+	lastRun  time.Time
+	deferred tally.Counter
+	backups  *backupWorker
 }
 
 func newFileSystemManager(
@@ -75,15 +83,23 @@ func newFileSystemManager(
 	scope := instrumentOpts.MetricsScope().SubScope("fs")
 	fm := newFlushManager(database, scope)
 	cm := newCleanupManager(database, scope)
+	log := loglevel.ForSubsystem(instrumentOpts.Logger(), loglevel.SubsystemFlush)
+
+	var backups *backupWorker
+	if mgr := opts.BackupManager(); mgr != nil {
+		backups = newBackupWorker(mgr, log, opts.ClockOptions().NowFn())
+	}
 
 	return &fileSystemManager{
 		databaseFlushManager:   fm,
 		databaseCleanupManager: cm,
-		log:      instrumentOpts.Logger(),
+		log:      log,
 		database: database,
 		opts:     opts,
 		status:   fileOpNotStarted,
 		enabled:  true,
+		deferred: scope.Counter("file-ops-deferred"),
+		backups:  backups,
 	}
 }
 
@@ -121,7 +137,13 @@ func (m *fileSystemManager) Run(
 		m.Unlock()
 		return false
 	}
+	if forceType == noForce && !m.scheduleAllowsRunWithLock(t) {
+		m.Unlock()
+		m.deferred.Inc(1)
+		return false
+	}
 	m.status = fileOpInProgress
+	m.lastRun = t
 	m.Unlock()
 
 	// NB(xichen): perform data cleanup and flushing sequentially to minimize the impact of disk seeks.
@@ -132,6 +154,22 @@ func (m *fileSystemManager) Run(
 		if err := m.Flush(t, dbBootstrapStates); err != nil {
 			m.log.Errorf("error when flushing data for time %v: %v", t, err)
 		}
+		if tracker := m.opts.DiskQuotaTracker(); tracker != nil {
+			namespaces := QuotaNamespaces(m.database.Namespaces())
+			if err := tracker.Measure(namespaces); err != nil {
+				m.log.Errorf("error when measuring disk usage for time %v: %v", t, err)
+			}
+		}
+		if m.backups != nil {
+			// NB: backups run in their own goroutine, unless this is a sync
+			// run, so that uploads do not hold up the next flush.
+			m.backups.Run(BackupNamespaces(m.database.Namespaces()), runType)
+		}
+		if replicator := m.opts.Replicator(); replicator != nil {
+			if _, err := replicator.CatchUpPending(); err != nil {
+				m.log.Errorf("error when catching up replication for time %v: %v", t, err)
+			}
+		}
 		m.Lock()
 		m.status = fileOpNotStarted
 		m.Unlock()
@@ -153,3 +191,55 @@ func (m *fileSystemManager) Report() {
 func (m *fileSystemManager) shouldRunWithLock() bool {
 	return m.enabled && m.status != fileOpInProgress && m.database.IsBootstrapped()
 }
+
+// scheduleAllowsRunWithLock returns whether the file ops schedule permits
+// running at the given time, file ops that have never run are never deferred.
+func (m *fileSystemManager) scheduleAllowsRunWithLock(t time.Time) bool {
+	if m.lastRun.IsZero() {
+		return true
+	}
+	schedule := m.opts.RuntimeOptionsManager().Get().FileOpsSchedule()
+	return schedule.AllowsDeferred(t, m.lastRun)
+}
+
+// BackupNamespaces returns the owned shards of the namespaces with flushes
+// enabled, as backups are taken of flushed filesets.
+func BackupNamespaces(namespaces []Namespace) []backup.Namespace {
+	result := make([]backup.Namespace, 0, len(namespaces))
+	for _, ns := range namespaces {
+		nsOpts := ns.Options()
+		if !nsOpts.FlushEnabled() {
+			continue
+		}
+		shards := ns.Shards()
+		shardIDs := make([]uint32, 0, len(shards))
+		for _, shard := range shards {
+			shardIDs = append(shardIDs, shard.ID())
+		}
+		result = append(result, backup.Namespace{
+			ID:           ns.ID(),
+			Shards:       shardIDs,
+			IndexEnabled: nsOpts.IndexOptions().Enabled(),
+		})
+	}
+	return result
+}
+
+// QuotaNamespaces returns the owned shards of the namespaces to measure the
+// disk usage of.
+func QuotaNamespaces(namespaces []Namespace) []quota.Namespace {
+	result := make([]quota.Namespace, 0, len(namespaces))
+	for _, ns := range namespaces {
+		shards := ns.Shards()
+		shardIDs := make([]uint32, 0, len(shards))
+		for _, shard := range shards {
+			shardIDs = append(shardIDs, shard.ID())
+		}
+		result = append(result, quota.Namespace{
+			ID:           ns.ID(),
+			Shards:       shardIDs,
+			IndexEnabled: ns.Options().IndexOptions().Enabled(),
+		})
+	}
+	return result
+}
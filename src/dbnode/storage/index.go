@@ -21,6 +21,7 @@
 package storage
 
 import (
+	"bytes"
 	"errors"
 	"fmt"
 	"sort"
@@ -43,6 +44,7 @@ import (
 	"github.com/m3db/m3/src/m3ninx/index/segment"
 	"github.com/m3db/m3/src/m3ninx/index/segment/mem"
 	"github.com/m3db/m3/src/m3ninx/postings"
+	"github.com/m3db/m3/src/x/loglevel"
 	xclose "github.com/m3db/m3x/close"
 	"github.com/m3db/m3x/context"
 	xerrors "github.com/m3db/m3x/errors"
@@ -122,6 +124,7 @@ type nsIndexRuntimeOptions struct {
 	insertMode            index.InsertMode
 	maxQueryLimit         int64
 	flushBlockNumSegments uint
+	fileOpsSchedule       runtime.FileOpsSchedule
 }
 
 type newBlockFn func(time.Time, namespace.Metadata, index.Options) (index.Block, error)
@@ -226,7 +229,7 @@ func newNamespaceIndexWithOptions(
 
 		newBlockFn: newBlockFn,
 		opts:       newIndexOpts.opts,
-		logger:     indexOpts.InstrumentOptions().Logger(),
+		logger:     loglevel.ForSubsystem(indexOpts.InstrumentOptions().Logger(), loglevel.SubsystemIndex),
 		nsMetadata: nsMD,
 
 		metrics: newNamespaceIndexMetrics(instrumentOpts),
@@ -256,6 +259,7 @@ func newNamespaceIndexWithOptions(
 func (i *nsIndex) SetRuntimeOptions(value runtime.Options) {
 	i.state.Lock()
 	i.state.runtimeOpts.flushBlockNumSegments = value.FlushIndexBlockNumSegments()
+	i.state.runtimeOpts.fileOpsSchedule = value.FileOpsSchedule()
 	i.state.Unlock()
 }
 
@@ -452,8 +456,40 @@ func (i *nsIndex) Bootstrap(
 }
 
 func (i *nsIndex) Tick(c context.Cancellable, tickStart time.Time) (namespaceIndexTickResult, error) {
+	result, compactable, err := i.tickBlocks(c, tickStart)
+	if len(compactable) == 0 {
+		return result, err
+	}
+
+	// Compactions are performed without holding the index lock since they
+	// can take a considerable amount of time, blocks guard their own segments.
+	var (
+		manager  = i.opts.IndexOptions().CompactionManager()
+		multiErr = xerrors.NewMultiError().Add(err)
+	)
+	for _, block := range compactable {
+		if c.IsCancelled() {
+			multiErr = multiErr.Add(errDbIndexTerminatingTickCancellation)
+			break
+		}
+		compactResult, err := block.CompactSegments(manager)
+		multiErr = multiErr.Add(err)
+		result.NumSegmentsCompacted += compactResult.NumSegmentsCompacted
+	}
+
+	return result, multiErr.FinalError()
+}
+
+// tickBlocks ticks, seals and evicts blocks and returns the sealed blocks
+// that should have their segments compacted.
+func (i *nsIndex) tickBlocks(
+	c context.Cancellable,
+	tickStart time.Time,
+) (namespaceIndexTickResult, []index.Block, error) {
 	var (
 		result                     = namespaceIndexTickResult{}
+		compactable                []index.Block
+		manager                    = i.opts.IndexOptions().CompactionManager()
 		earliestBlockStartToRetain = retention.FlushTimeStartForRetentionPeriod(i.retentionPeriod, i.blockSize, tickStart)
 		lastSealableBlockStart     = retention.FlushTimeEndForBlockSize(i.blockSize, tickStart.Add(-i.bufferPast))
 	)
@@ -464,13 +500,18 @@ func (i *nsIndex) Tick(c context.Cancellable, tickStart time.Time) (namespaceInd
 		i.state.Unlock()
 	}()
 
+	// Background compactions are only run within the file ops schedule,
+	// explicitly triggered compactions run regardless.
+	shouldCompact := manager != nil && manager.ShouldCompact(
+		i.state.runtimeOpts.fileOpsSchedule.Allows(tickStart))
+
 	result.NumBlocks = int64(len(i.state.blocksByTime))
 
 	var multiErr xerrors.MultiError
 	for blockStart, block := range i.state.blocksByTime {
 		if c.IsCancelled() {
 			multiErr = multiErr.Add(errDbIndexTerminatingTickCancellation)
-			return result, multiErr.FinalError()
+			return result, nil, multiErr.FinalError()
 		}
 
 		// drop any blocks past the retention period
@@ -487,15 +528,20 @@ func (i *nsIndex) Tick(c context.Cancellable, tickStart time.Time) (namespaceInd
 		multiErr = multiErr.Add(tickErr)
 		result.NumSegments += blockTickResult.NumSegments
 		result.NumTotalDocs += blockTickResult.NumDocs
+		result.NumSegmentsUnpinned += blockTickResult.NumSegmentsUnpinned
 
 		// seal any blocks that are sealable
 		if !blockStart.ToTime().After(lastSealableBlockStart) && !block.IsSealed() {
 			multiErr = multiErr.Add(block.Seal())
 			result.NumBlocksSealed++
 		}
+
+		if shouldCompact && block.IsSealed() {
+			compactable = append(compactable, block)
+		}
 	}
 
-	return result, multiErr.FinalError()
+	return result, compactable, multiErr.FinalError()
 }
 
 func (i *nsIndex) Flush(
@@ -728,14 +774,34 @@ func (i *nsIndex) Query(
 			opts.Limit, i.state.runtimeOpts.maxQueryLimit) // FOLLOWUP(prateek): log query too once it's serializable.
 		opts.Limit = int(i.state.runtimeOpts.maxQueryLimit)
 	}
+	opts = opts.ApplyLimits(i.opts.IndexOptions().QueryLimits())
+
+	// paginated queries must match every series to determine the lowest
+	// sorting IDs for the page, the series limit bounds the page size instead.
+	var pageAfterID []byte
+	if opts.PageSize > 0 {
+		afterID, err := opts.PageToken.LastID()
+		if err != nil {
+			return index.QueryResults{}, err
+		}
+		if opts.Limit > 0 && opts.Limit < opts.PageSize {
+			opts.PageSize = opts.Limit
+		}
+		opts.Limit = 0
+		pageAfterID = afterID
+	}
 
 	var (
-		exhaustive = true
-		results    = i.opts.IndexOptions().ResultsPool().Get()
-		err        error
+		exhaustive    = true
+		exceededLimit = index.QueryLimitNone
+		results       = i.opts.IndexOptions().ResultsPool().Get()
+		err           error
 	)
 	results.Reset(i.nsMetadata.ID())
 	ctx.RegisterFinalizer(results)
+	if opts.PageSize > 0 {
+		results.SetPage(pageAfterID, opts.PageSize)
+	}
 
 	// Chunk the query request into bounds based on applicable blocks and
 	// execute the requests to each of them; and merge results.
@@ -757,7 +823,7 @@ func (i *nsIndex) Query(
 		}
 
 		// terminate early if we know we don't need any more results
-		if opts.Limit > 0 && results.Size() >= opts.Limit {
+		if opts.ExceededLimit(results) != index.QueryLimitNone {
 			exhaustive = false
 			break
 		}
@@ -783,12 +849,35 @@ func (i *nsIndex) Query(
 	// FOLLOWUP(prateek): do the above operation with controllable parallelism to optimize
 	// for latency at the cost of higher mem-usage.
 
+	// NB: a page stopped by a limit still returns a token so that clients
+	// can resume after the last ID returned rather than rescan the same IDs.
+	var nextPageToken index.PageToken
+	if !exhaustive {
+		exceededLimit = opts.ExceededLimit(results)
+	}
+	if opts.PageSize > 0 && (!exhaustive || results.PageTruncated()) && results.Size() > 0 {
+		nextPageToken = index.NewPageToken(lastResultID(results))
+	}
+
 	return index.QueryResults{
-		Exhaustive: exhaustive,
-		Results:    results,
+		Exhaustive:    exhaustive,
+		ExceededLimit: exceededLimit,
+		NextPageToken: nextPageToken,
+		Results:       results,
 	}, nil
 }
 
+// lastResultID returns the highest sorting ID held by the results.
+func lastResultID(results index.Results) []byte {
+	var last []byte
+	for _, entry := range results.Map().Iter() {
+		if id := entry.Key().Bytes(); last == nil || bytes.Compare(id, last) > 0 {
+			last = id
+		}
+	}
+	return last
+}
+
 // ensureBlockPresentWithRLock guarantees an index.Block exists for the specified
 // blockStart, allocating one if it does not. It returns the desired block, or
 // error if it's unable to do so.
@@ -27,6 +27,8 @@ import (
 	"time"
 
 	"github.com/m3db/m3/src/dbnode/storage/bootstrap/result"
+	"github.com/m3db/m3/src/dbnode/storage/index/compaction"
+	"github.com/m3db/m3/src/dbnode/storage/index/segments"
 	"github.com/m3db/m3/src/dbnode/storage/namespace"
 	m3ninxindex "github.com/m3db/m3/src/m3ninx/index"
 	"github.com/m3db/m3/src/m3ninx/index/segment"
@@ -60,7 +62,7 @@ const (
 	blockStateSealed
 )
 
-type newExecutorFn func() (search.Executor, error)
+type newExecutorFn func(queryRange xtime.Range) (search.Executor, error)
 
 type block struct {
 	sync.RWMutex
@@ -68,6 +70,10 @@ type block struct {
 	activeSegment       segment.MutableSegment
 	shardRangesSegments []blockShardRangesSegments
 
+	// activeMinTime is the earliest timestamp of the entries written to the
+	// active segment, it is zero if none have been written.
+	activeMinTime time.Time
+
 	newExecutorFn newExecutorFn
 	startTime     time.Time
 	endTime       time.Time
@@ -82,6 +88,10 @@ type block struct {
 type blockShardRangesSegments struct {
 	shardTimeRanges result.ShardTimeRanges
 	segments        []segment.Segment
+
+	// timeRange spans the shard time ranges, the segments only index series
+	// with data within it.
+	timeRange xtime.Range
 }
 
 // NewBlock returns a new Block, representing a complete reverse index for the
@@ -147,6 +157,12 @@ func (b *block) WriteBatch(inserts *WriteBatch) (WriteBatchResult, error) {
 		}, err
 	}
 
+	for _, entry := range inserts.PendingEntries() {
+		if b.activeMinTime.IsZero() || entry.Timestamp.Before(b.activeMinTime) {
+			b.activeMinTime = entry.Timestamp
+		}
+	}
+
 	err := b.activeSegment.InsertBatch(m3ninxindex.Batch{
 		Docs:                inserts.PendingDocs(),
 		AllowPartialUpdates: true,
@@ -180,13 +196,69 @@ func (b *block) WriteBatch(inserts *WriteBatch) (WriteBatchResult, error) {
 	}, partialErr
 }
 
-func (b *block) executorWithRLock() (search.Executor, error) {
-	var expectedReaders int
-	if b.activeSegment != nil {
+// queryRange returns the range of the block selected by the query options,
+// which is the whole block if the options are not time bounded.
+func (b *block) queryRange(opts QueryOptions) xtime.Range {
+	blockRange := xtime.Range{Start: b.startTime, End: b.endTime}
+	if opts.StartInclusive.IsZero() || opts.EndExclusive.IsZero() {
+		return blockRange
+	}
+	queryRange, ok := blockRange.Intersect(xtime.Range{
+		Start: opts.StartInclusive,
+		End:   opts.EndExclusive,
+	})
+	if !ok {
+		return xtime.Range{}
+	}
+	return queryRange
+}
+
+// activeSegmentRangeWithRLock returns the range the series indexed by the
+// active segment may have data within. A series is only indexed by its first
+// write to the block, writes that are out of order by up to the buffer past
+// and future may be earlier.
+func (b *block) activeSegmentRangeWithRLock() xtime.Range {
+	activeRange := xtime.Range{Start: b.startTime, End: b.endTime}
+	if b.activeMinTime.IsZero() {
+		return activeRange
+	}
+	retentionOpts := b.nsMD.Options().RetentionOptions()
+	start := b.activeMinTime.Add(-retentionOpts.BufferPast() - retentionOpts.BufferFuture())
+	if start.After(activeRange.Start) {
+		activeRange.Start = start
+	}
+	return activeRange
+}
+
+// overlapsWithRLock returns whether any segment may index series with data
+// within the query range.
+func (b *block) overlapsWithRLock(queryRange xtime.Range) bool {
+	if queryRange.IsEmpty() {
+		return false
+	}
+	if b.activeSegment != nil && b.activeSegmentRangeWithRLock().Overlaps(queryRange) {
+		return true
+	}
+	for _, group := range b.shardRangesSegments {
+		if len(group.segments) > 0 && group.timeRange.Overlaps(queryRange) {
+			return true
+		}
+	}
+	return false
+}
+
+func (b *block) executorWithRLock(queryRange xtime.Range) (search.Executor, error) {
+	var (
+		includeActive   = b.activeSegment != nil && b.activeSegmentRangeWithRLock().Overlaps(queryRange)
+		expectedReaders int
+	)
+	if includeActive {
 		expectedReaders++
 	}
 	for _, group := range b.shardRangesSegments {
-		expectedReaders += len(group.segments)
+		if group.timeRange.Overlaps(queryRange) {
+			expectedReaders += len(group.segments)
+		}
 	}
 
 	var (
@@ -204,7 +276,7 @@ func (b *block) executorWithRLock() (search.Executor, error) {
 	}()
 
 	// start with the segment that's being actively written to (if we have one)
-	if b.activeSegment != nil {
+	if includeActive {
 		reader, err := b.activeSegment.Reader()
 		if err != nil {
 			return nil, err
@@ -212,8 +284,12 @@ func (b *block) executorWithRLock() (search.Executor, error) {
 		readers = append(readers, reader)
 	}
 
-	// loop over the segments associated to shard time ranges
+	// loop over the segments associated to shard time ranges, skipping those
+	// with no data in the query range
 	for _, group := range b.shardRangesSegments {
+		if !group.timeRange.Overlaps(queryRange) {
+			continue
+		}
 		for _, seg := range group.segments {
 			reader, err := seg.Reader()
 			if err != nil {
@@ -238,12 +314,17 @@ func (b *block) Query(
 		return false, errUnableToQueryBlockClosed
 	}
 
-	exec, err := b.newExecutorFn()
+	// skip the block up front if none of its segments have data in range
+	queryRange := b.queryRange(opts)
+	if !b.overlapsWithRLock(queryRange) {
+		return true, nil
+	}
+
+	exec, err := b.newExecutorFn(queryRange)
 	if err != nil {
 		return false, err
 	}
 
-	// FOLLOWUP(prateek): push down QueryOptions to restrict results
 	// TODO(jeromefroe): Use the idx query directly once we implement an index in m3ninx
 	// and don't need to use the segments anymore.
 	iter, err := exec.Execute(query.Query.SearchQuery())
@@ -252,10 +333,7 @@ func (b *block) Query(
 		return false, err
 	}
 
-	var (
-		size       = results.Size()
-		brokeEarly = false
-	)
+	brokeEarly := false
 	execCloser := safeCloser{closable: exec}
 	iterCloser := safeCloser{closable: iter}
 
@@ -265,12 +343,12 @@ func (b *block) Query(
 	}()
 
 	for iter.Next() {
-		if opts.Limit > 0 && size >= opts.Limit {
+		if opts.ExceededLimit(results) != QueryLimitNone {
 			brokeEarly = true
 			break
 		}
 		d := iter.Current()
-		_, size, err = results.Add(d)
+		_, _, err = results.Add(d)
 		if err != nil {
 			return false, err
 		}
@@ -336,7 +414,8 @@ func (b *block) AddResults(
 
 	entry := blockShardRangesSegments{
 		shardTimeRanges: results.Fulfilled(),
-		segments:        results.Segments(),
+		segments:        b.withLazySegments(results.Segments()),
+		timeRange:       xtime.Range{Start: min, End: max},
 	}
 
 	// First see if this block can cover all our current blocks covering shard
@@ -369,6 +448,70 @@ func (b *block) AddResults(
 	return multiErr.FinalError()
 }
 
+// withPostingsListCache wraps any immutable segments with a read through
+// segment backed by the postings list cache, if one is configured.
+func (b *block) withPostingsListCache(segments []segment.Segment) []segment.Segment {
+	cache := b.opts.PostingsListCache()
+	if cache == nil {
+		return segments
+	}
+	wrapped := make([]segment.Segment, 0, len(segments))
+	for _, seg := range segments {
+		if _, ok := seg.(segment.MutableSegment); ok {
+			// Mutable segments can change underneath the cache, never cache them.
+			wrapped = append(wrapped, seg)
+			continue
+		}
+		wrapped = append(wrapped, NewReadThroughSegment(seg, cache))
+	}
+	return wrapped
+}
+
+// withLazySegments wraps any persisted segments with a lazy segment if a lazy
+// segment cache is configured and the namespace does not keep all its index
+// blocks resident, segments of blocks that are already outside of the
+// resident window are unpinned immediately. All other immutable segments are
+// wrapped with the postings list cache.
+func (b *block) withLazySegments(segments []segment.Segment) []segment.Segment {
+	cache := b.opts.LazySegmentCache()
+	if cache == nil || b.nsMD.Options().IndexOptions().ResidentBlocks() <= 0 {
+		return b.withPostingsListCache(segments)
+	}
+
+	var (
+		resident = b.isResidentAt(b.opts.ClockOptions().NowFn()())
+		wrapFn   = func(seg segment.Segment) segment.Segment {
+			return b.withPostingsListCache([]segment.Segment{seg})[0]
+		}
+		wrapped = make([]segment.Segment, 0, len(segments))
+	)
+	for _, seg := range segments {
+		reloadable, ok := seg.(ReloadableSegment)
+		if !ok {
+			wrapped = append(wrapped, wrapFn(seg))
+			continue
+		}
+		lazy := newLazySegment(reloadable, wrapFn, cache)
+		if !resident {
+			lazy.Unpin()
+		}
+		wrapped = append(wrapped, lazy)
+	}
+	return wrapped
+}
+
+// isResidentAt returns whether the block is one of the most recent index
+// blocks of its namespace at the given time.
+func (b *block) isResidentAt(t time.Time) bool {
+	residentBlocks := b.nsMD.Options().IndexOptions().ResidentBlocks()
+	if residentBlocks <= 0 {
+		return true
+	}
+	oldestResident := t.Truncate(b.blockSize).
+		Add(-time.Duration(residentBlocks-1) * b.blockSize)
+	return !b.startTime.Before(oldestResident)
+}
+
 func (b *block) Tick(c context.Cancellable, tickStart time.Time) (BlockTickResult, error) {
 	b.RLock()
 	defer b.RUnlock()
@@ -377,6 +520,19 @@ func (b *block) Tick(c context.Cancellable, tickStart time.Time) (BlockTickResul
 		return result, errUnableToTickBlockClosed
 	}
 
+	// once the block ages out of the resident window its persisted segments
+	// are handed over to the lazy segment cache.
+	if !b.isResidentAt(tickStart) {
+		for _, group := range b.shardRangesSegments {
+			for _, seg := range group.segments {
+				if lazy, ok := seg.(*lazySegment); ok && lazy.Pinned() {
+					lazy.Unpin()
+					result.NumSegmentsUnpinned++
+				}
+			}
+		}
+	}
+
 	// active segment, can be nil incase we've evicted it already.
 	if b.activeSegment != nil {
 		result.NumSegments++
@@ -456,6 +612,149 @@ func (b *block) NeedsMutableSegmentsEvicted() bool {
 	return anyMutableSegmentNeedsEviction
 }
 
+type blockCompaction struct {
+	group     int
+	task      compaction.Task
+	compacted segment.Segment
+	err       error
+}
+
+func (b *block) CompactSegments(
+	manager *compaction.Manager,
+) (CompactSegmentsResult, error) {
+	var result CompactSegmentsResult
+
+	// Plan the compactions while holding the read lock, the compactions
+	// themselves are performed without holding the lock so that queries
+	// are not blocked for their duration.
+	b.RLock()
+	if b.state != blockStateSealed {
+		b.RUnlock()
+		return result, nil
+	}
+	var compactions []*blockCompaction
+	for idx, group := range b.shardRangesSegments {
+		candidates := make([]compaction.Segment, 0, len(group.segments))
+		for _, seg := range group.segments {
+			if _, ok := seg.(segment.MutableSegment); ok {
+				// Mutable segments are converted and evicted by index flushes.
+				continue
+			}
+			if lazy, ok := seg.(*lazySegment); ok && !lazy.Pinned() {
+				// Compacting segments that are not resident would load them
+				// and keep the compacted result resident.
+				continue
+			}
+			candidates = append(candidates, compaction.Segment{
+				Size:    seg.Size(),
+				Type:    segments.FSTType,
+				Segment: seg,
+			})
+		}
+		if len(candidates) < 2 {
+			continue
+		}
+		plan, err := compaction.NewPlan(candidates, manager.PlannerOptions())
+		if err != nil {
+			b.RUnlock()
+			return result, err
+		}
+		for _, task := range plan.Tasks {
+			if len(task.Segments) < 2 {
+				continue
+			}
+			compactions = append(compactions, &blockCompaction{
+				group: idx,
+				task:  task,
+			})
+		}
+	}
+	b.RUnlock()
+
+	if len(compactions) == 0 {
+		return result, nil
+	}
+
+	var wg sync.WaitGroup
+	for _, c := range compactions {
+		c := c
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			c.compacted, c.err = manager.Compact(c.task)
+		}()
+	}
+	wg.Wait()
+
+	b.Lock()
+	defer b.Unlock()
+
+	var multiErr xerrors.MultiError
+	for _, c := range compactions {
+		if c.err != nil {
+			multiErr = multiErr.Add(c.err)
+			continue
+		}
+		var (
+			replaced bool
+			err      error
+		)
+		if b.state == blockStateSealed {
+			replaced, err = b.replaceSegmentsWithLock(c)
+		}
+		if !replaced {
+			// The segments were replaced or closed while compacting, for
+			// instance by a bootstrap or flush, discard the result.
+			multiErr = multiErr.Add(c.compacted.Close())
+			continue
+		}
+		// NB: the compacted segment is in use even if closing the segments
+		// it replaced failed.
+		multiErr = multiErr.Add(err)
+		result.NumTasks++
+		result.NumSegmentsCompacted += int64(len(c.task.Segments))
+	}
+
+	return result, multiErr.FinalError()
+}
+
+// replaceSegmentsWithLock replaces the compacted segments of the group with
+// the result of the compaction and closes them, it returns false if the
+// segments are no longer all in the group.
+func (b *block) replaceSegmentsWithLock(c *blockCompaction) (bool, error) {
+	if c.group >= len(b.shardRangesSegments) {
+		return false, nil
+	}
+
+	var (
+		group    = b.shardRangesSegments[c.group]
+		replaced = make(map[segment.Segment]struct{}, len(c.task.Segments))
+	)
+	for _, seg := range c.task.Segments {
+		replaced[seg.Segment] = struct{}{}
+	}
+
+	retained := make([]segment.Segment, 0, len(group.segments))
+	for _, seg := range group.segments {
+		if _, ok := replaced[seg]; ok {
+			continue
+		}
+		retained = append(retained, seg)
+	}
+	if len(retained)+len(replaced) != len(group.segments) {
+		return false, nil
+	}
+
+	var multiErr xerrors.MultiError
+	for seg := range replaced {
+		multiErr = multiErr.Add(seg.Close())
+	}
+	retained = append(retained, b.withPostingsListCache(
+		[]segment.Segment{c.compacted})...)
+	b.shardRangesSegments[c.group].segments = retained
+	return true, multiErr.FinalError()
+}
+
 func (b *block) EvictMutableSegments() (EvictMutableSegmentResults, error) {
 	var results EvictMutableSegmentResults
 	b.Lock()
@@ -28,6 +28,7 @@ import (
 
 	"github.com/m3db/m3/src/dbnode/retention"
 	"github.com/m3db/m3/src/dbnode/storage/bootstrap/result"
+	"github.com/m3db/m3/src/dbnode/storage/index/compaction"
 	"github.com/m3db/m3/src/dbnode/storage/namespace"
 	"github.com/m3db/m3/src/m3ninx/doc"
 	"github.com/m3db/m3/src/m3ninx/idx"
@@ -442,7 +443,7 @@ func TestBlockQueryExecutorError(t *testing.T) {
 	b, ok := blk.(*block)
 	require.True(t, ok)
 
-	b.newExecutorFn = func() (search.Executor, error) {
+	b.newExecutorFn = func(xtime.Range) (search.Executor, error) {
 		b.RLock() // ensures we call newExecutorFn with RLock, or this would deadlock
 		defer b.RUnlock()
 		return nil, fmt.Errorf("random-err")
@@ -491,7 +492,10 @@ func TestBlockQueryAddResultsSegmentsError(t *testing.T) {
 
 	b.activeSegment = seg1
 	b.shardRangesSegments = []blockShardRangesSegments{
-		blockShardRangesSegments{segments: []segment.Segment{seg2, seg3}}}
+		blockShardRangesSegments{
+			segments:  []segment.Segment{seg2, seg3},
+			timeRange: xtime.Range{Start: start, End: start.Add(time.Hour)},
+		}}
 
 	r1 := index.NewMockReader(ctrl)
 	seg1.EXPECT().Reader().Return(r1, nil)
@@ -508,6 +512,57 @@ func TestBlockQueryAddResultsSegmentsError(t *testing.T) {
 	require.Equal(t, randErr, err)
 }
 
+func TestBlockQuerySkipsSegmentsOutsideQueryRange(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	testMD := newTestNSMetadata(t)
+	start := time.Now().Truncate(time.Hour)
+	blk, err := NewBlock(start, testMD, testOpts)
+	require.NoError(t, err)
+
+	b, ok := blk.(*block)
+	require.True(t, ok)
+
+	// the active segment was first written to after the buffer past and
+	// future of the query range, the second group only has data after it
+	active := segment.NewMockMutableSegment(ctrl)
+	seg1 := segment.NewMockSegment(ctrl)
+	seg2 := segment.NewMockSegment(ctrl)
+	b.activeSegment = active
+	b.activeMinTime = start.Add(45 * time.Minute)
+	b.shardRangesSegments = []blockShardRangesSegments{
+		{
+			segments:  []segment.Segment{seg1},
+			timeRange: xtime.Range{Start: start, End: start.Add(20 * time.Minute)},
+		},
+		{
+			segments:  []segment.Segment{seg2},
+			timeRange: xtime.Range{Start: start.Add(40 * time.Minute), End: start.Add(time.Hour)},
+		},
+	}
+
+	// only the first group is read for a query of its range
+	r1 := index.NewMockReader(ctrl)
+	seg1.EXPECT().Reader().Return(r1, nil)
+	r1.EXPECT().Close().Return(nil)
+	opts := QueryOptions{
+		StartInclusive: start.Add(5 * time.Minute),
+		EndExclusive:   start.Add(10 * time.Minute),
+	}
+	exec, err := b.executorWithRLock(b.queryRange(opts))
+	require.NoError(t, err)
+	require.NoError(t, exec.Close())
+
+	// a query of a range no segment has data in skips the block
+	exhaustive, err := b.Query(Query{}, QueryOptions{
+		StartInclusive: start.Add(25 * time.Minute),
+		EndExclusive:   start.Add(30 * time.Minute),
+	}, NewResults(testOpts))
+	require.NoError(t, err)
+	require.True(t, exhaustive)
+}
+
 func TestBlockMockQueryExecutorExecError(t *testing.T) {
 	ctrl := gomock.NewController(t)
 	defer ctrl.Finish()
@@ -522,7 +577,7 @@ func TestBlockMockQueryExecutorExecError(t *testing.T) {
 
 	// dIter:= doc.NewMockIterator(ctrl)
 	exec := search.NewMockExecutor(ctrl)
-	b.newExecutorFn = func() (search.Executor, error) {
+	b.newExecutorFn = func(xtime.Range) (search.Executor, error) {
 		return exec, nil
 	}
 	gomock.InOrder(
@@ -546,7 +601,7 @@ func TestBlockMockQueryExecutorExecIterErr(t *testing.T) {
 	require.True(t, ok)
 
 	exec := search.NewMockExecutor(ctrl)
-	b.newExecutorFn = func() (search.Executor, error) {
+	b.newExecutorFn = func(xtime.Range) (search.Executor, error) {
 		return exec, nil
 	}
 
@@ -577,7 +632,7 @@ func TestBlockMockQueryExecutorExecLimit(t *testing.T) {
 	require.True(t, ok)
 
 	exec := search.NewMockExecutor(ctrl)
-	b.newExecutorFn = func() (search.Executor, error) {
+	b.newExecutorFn = func(xtime.Range) (search.Executor, error) {
 		return exec, nil
 	}
 
@@ -604,6 +659,45 @@ func TestBlockMockQueryExecutorExecLimit(t *testing.T) {
 		ident.NewTagsIterator(t1)))
 }
 
+func TestBlockMockQueryExecutorExecDocsLimit(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	testMD := newTestNSMetadata(t)
+	start := time.Now().Truncate(time.Hour)
+	blk, err := NewBlock(start, testMD, testOpts)
+	require.NoError(t, err)
+
+	b, ok := blk.(*block)
+	require.True(t, ok)
+
+	exec := search.NewMockExecutor(ctrl)
+	b.newExecutorFn = func(xtime.Range) (search.Executor, error) {
+		return exec, nil
+	}
+
+	dIter := doc.NewMockIterator(ctrl)
+	gomock.InOrder(
+		exec.EXPECT().Execute(gomock.Any()).Return(dIter, nil),
+		dIter.EXPECT().Next().Return(true),
+		dIter.EXPECT().Current().Return(testDoc1()),
+		dIter.EXPECT().Next().Return(true),
+		dIter.EXPECT().Current().Return(testDoc1()),
+		dIter.EXPECT().Next().Return(true),
+		dIter.EXPECT().Err().Return(nil),
+		dIter.EXPECT().Close().Return(nil),
+		exec.EXPECT().Close().Return(nil),
+	)
+	opts := QueryOptions{DocsLimit: 2}
+	results := NewResults(testOpts)
+	exhaustive, err := b.Query(Query{}, opts, results)
+	require.NoError(t, err)
+	require.False(t, exhaustive)
+	require.Equal(t, 1, results.Size())
+	require.Equal(t, 2, results.TotalDocsCount())
+	require.Equal(t, QueryLimitDocs, opts.ExceededLimit(results))
+}
+
 func TestBlockMockQueryExecutorExecIterCloseErr(t *testing.T) {
 	ctrl := gomock.NewController(t)
 	defer ctrl.Finish()
@@ -617,7 +711,7 @@ func TestBlockMockQueryExecutorExecIterCloseErr(t *testing.T) {
 	require.True(t, ok)
 
 	exec := search.NewMockExecutor(ctrl)
-	b.newExecutorFn = func() (search.Executor, error) {
+	b.newExecutorFn = func(xtime.Range) (search.Executor, error) {
 		return exec, nil
 	}
 
@@ -647,7 +741,7 @@ func TestBlockMockQueryExecutorExecIterExecCloseErr(t *testing.T) {
 	require.True(t, ok)
 
 	exec := search.NewMockExecutor(ctrl)
-	b.newExecutorFn = func() (search.Executor, error) {
+	b.newExecutorFn = func(xtime.Range) (search.Executor, error) {
 		return exec, nil
 	}
 
@@ -677,7 +771,7 @@ func TestBlockMockQueryLimit(t *testing.T) {
 	require.True(t, ok)
 
 	exec := search.NewMockExecutor(ctrl)
-	b.newExecutorFn = func() (search.Executor, error) {
+	b.newExecutorFn = func(xtime.Range) (search.Executor, error) {
 		return exec, nil
 	}
 
@@ -718,7 +812,7 @@ func TestBlockMockQueryLimitExhaustive(t *testing.T) {
 	require.True(t, ok)
 
 	exec := search.NewMockExecutor(ctrl)
-	b.newExecutorFn = func() (search.Executor, error) {
+	b.newExecutorFn = func(xtime.Range) (search.Executor, error) {
 		return exec, nil
 	}
 
@@ -760,7 +854,7 @@ func TestBlockMockQueryMergeResultsMapLimit(t *testing.T) {
 	require.NoError(t, b.Seal())
 
 	exec := search.NewMockExecutor(ctrl)
-	b.newExecutorFn = func() (search.Executor, error) {
+	b.newExecutorFn = func(xtime.Range) (search.Executor, error) {
 		return exec, nil
 	}
 
@@ -802,7 +896,7 @@ func TestBlockMockQueryMergeResultsDupeID(t *testing.T) {
 	require.True(t, ok)
 
 	exec := search.NewMockExecutor(ctrl)
-	b.newExecutorFn = func() (search.Executor, error) {
+	b.newExecutorFn = func(xtime.Range) (search.Executor, error) {
 		return exec, nil
 	}
 
@@ -899,6 +993,53 @@ func TestBlockAddResultsAfterSealWorks(t *testing.T) {
 	require.Equal(t, seg1, b.shardRangesSegments[0].segments[0])
 }
 
+func TestBlockTickUnpinsNonResidentSegments(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	testMD := newTestNSMetadata(t)
+	md, err := namespace.NewMetadata(testMD.ID(), testMD.Options().SetIndexOptions(
+		testMD.Options().IndexOptions().SetResidentBlocks(1)))
+	require.NoError(t, err)
+
+	cache, err := NewLazySegmentCache(4, LazySegmentCacheOptions{})
+	require.NoError(t, err)
+
+	start := time.Now().Truncate(time.Hour)
+	blk, err := NewBlock(start, md, testOpts.SetLazySegmentCache(cache))
+	require.NoError(t, err)
+
+	b, ok := blk.(*block)
+	require.True(t, ok)
+
+	seg := segment.NewMockSegment(ctrl)
+	seg.EXPECT().Size().Return(int64(10))
+	require.NoError(t, b.AddResults(
+		result.NewIndexBlock(start, []segment.Segment{&testReloadableSegment{Segment: seg}},
+			result.NewShardTimeRanges(start, start.Add(time.Hour), 1, 2, 3))))
+	require.Equal(t, 1, len(b.shardRangesSegments))
+	lazy, ok := b.shardRangesSegments[0].segments[0].(*lazySegment)
+	require.True(t, ok)
+	require.True(t, lazy.Pinned())
+
+	// While the block is the most recent one its segments stay pinned.
+	tickResult, err := b.Tick(nil, start.Add(time.Minute))
+	require.NoError(t, err)
+	require.Equal(t, int64(0), tickResult.NumSegmentsUnpinned)
+	require.True(t, lazy.Pinned())
+
+	tickResult, err = b.Tick(nil, start.Add(time.Hour+time.Minute))
+	require.NoError(t, err)
+	require.Equal(t, int64(1), tickResult.NumSegmentsUnpinned)
+	require.Equal(t, int64(10), tickResult.NumDocs)
+	require.False(t, lazy.Pinned())
+	require.Equal(t, 1, cache.lru.Len())
+
+	seg.EXPECT().Close().Return(nil)
+	require.NoError(t, b.Close())
+	require.Equal(t, 0, cache.lru.Len())
+}
+
 func TestBlockTickSingleSegment(t *testing.T) {
 	ctrl := gomock.NewController(t)
 	defer ctrl.Finish()
@@ -1511,3 +1652,54 @@ func testDoc2() doc.Document {
 		},
 	}
 }
+
+func newTestFSTSegment(t *testing.T, ids ...string) segment.Segment {
+	memSeg, err := mem.NewSegment(0, mem.NewOptions())
+	require.NoError(t, err)
+	for _, id := range ids {
+		_, err := memSeg.Insert(doc.Document{
+			ID:     []byte(id),
+			Fields: []doc.Field{{Name: []byte("name"), Value: []byte(id)}},
+		})
+		require.NoError(t, err)
+	}
+	fstSeg, _, err := compaction.NewCompactor(compaction.CompactorOptions{}).
+		Compact([]segment.Segment{memSeg})
+	require.NoError(t, err)
+	return fstSeg
+}
+
+func TestBlockCompactSegments(t *testing.T) {
+	testMD := newTestNSMetadata(t)
+	start := time.Now().Truncate(time.Hour)
+	blk, err := NewBlock(start, testMD, testOpts)
+	require.NoError(t, err)
+
+	b, ok := blk.(*block)
+	require.True(t, ok)
+
+	manager, err := compaction.NewManager(compaction.ManagerOptions{})
+	require.NoError(t, err)
+
+	// Unsealed blocks are not compacted.
+	res, err := b.CompactSegments(manager)
+	require.NoError(t, err)
+	require.Equal(t, CompactSegmentsResult{}, res)
+
+	require.NoError(t, b.Seal())
+	require.NoError(t, b.AddResults(
+		result.NewIndexBlock(start, []segment.Segment{
+			newTestFSTSegment(t, "foo", "bar"),
+			newTestFSTSegment(t, "baz"),
+		}, result.NewShardTimeRanges(start, start.Add(time.Hour), 1, 2, 3))))
+
+	res, err = b.CompactSegments(manager)
+	require.NoError(t, err)
+	require.Equal(t, int64(1), res.NumTasks)
+	require.Equal(t, int64(2), res.NumSegmentsCompacted)
+
+	require.Equal(t, 1, len(b.shardRangesSegments))
+	require.Equal(t, 1, len(b.shardRangesSegments[0].segments))
+	require.Equal(t, int64(3), b.shardRangesSegments[0].segments[0].Size())
+	require.Equal(t, int64(1), manager.Status().Completed)
+}
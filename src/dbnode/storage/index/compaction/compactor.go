@@ -0,0 +1,246 @@
+// Copyright (c) 2018 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package compaction
+
+import (
+	"bytes"
+	"errors"
+	"sync"
+	"time"
+
+	"github.com/m3db/m3/src/m3ninx/doc"
+	"github.com/m3db/m3/src/m3ninx/index"
+	"github.com/m3db/m3/src/m3ninx/index/segment"
+	"github.com/m3db/m3/src/m3ninx/index/segment/fst"
+	"github.com/m3db/m3/src/m3ninx/index/segment/mem"
+	"github.com/m3db/m3/src/m3ninx/postings"
+	"github.com/m3db/m3/src/m3ninx/x"
+)
+
+const (
+	// compactorThrottleBatchSize is the number of documents inserted into the
+	// compacted segment between checks of the throttle.
+	compactorThrottleBatchSize = 1024
+)
+
+var (
+	errCompactorNoSegments = errors.New("no segments to compact")
+)
+
+// CompactorOptions are the options used by a Compactor.
+type CompactorOptions struct {
+	// MemSegmentOptions are the options used for the intermediate segment
+	// documents are merged into before being converted to a FST segment.
+	MemSegmentOptions mem.Options
+	// FSTSegmentOptions are the options used for the resulting FST segment.
+	FSTSegmentOptions fst.Options
+	// MaxDocsPerSecond throttles how quickly documents are merged, zero
+	// disables throttling.
+	MaxDocsPerSecond int
+	// NowFn returns the current time.
+	NowFn func() time.Time
+	// SleepFn sleeps for the given duration.
+	SleepFn func(time.Duration)
+}
+
+// Compactor merges a set of segments into a single FST segment.
+type Compactor struct {
+	sync.Mutex
+
+	opts   CompactorOptions
+	writer fst.Writer
+	docs   []doc.Document
+}
+
+// NewCompactor returns a new compactor.
+func NewCompactor(opts CompactorOptions) *Compactor {
+	if opts.MemSegmentOptions == nil {
+		opts.MemSegmentOptions = mem.NewOptions()
+	}
+	if opts.FSTSegmentOptions == nil {
+		opts.FSTSegmentOptions = fst.NewOptions()
+	}
+	if opts.NowFn == nil {
+		opts.NowFn = time.Now
+	}
+	if opts.SleepFn == nil {
+		opts.SleepFn = time.Sleep
+	}
+	return &Compactor{
+		opts:   opts,
+		writer: fst.NewWriter(),
+		docs:   make([]doc.Document, 0, compactorThrottleBatchSize),
+	}
+}
+
+// Compact merges the documents of all the provided segments into a single
+// FST segment. The provided segments are not closed, it is the caller's
+// responsibility to close them once the compacted segment has replaced them.
+func (c *Compactor) Compact(segs []segment.Segment) (segment.Segment, int64, error) {
+	if len(segs) == 0 {
+		return nil, 0, errCompactorNoSegments
+	}
+
+	c.Lock()
+	defer c.Unlock()
+
+	merged, err := mem.NewSegment(postings.ID(0), c.opts.MemSegmentOptions)
+	if err != nil {
+		return nil, 0, err
+	}
+	defer merged.Close()
+
+	var (
+		numDocs    int64
+		batchStart = c.opts.NowFn()
+	)
+	flush := func() error {
+		if len(c.docs) == 0 {
+			return nil
+		}
+		err := merged.InsertBatch(index.Batch{
+			Docs:                c.docs,
+			AllowPartialUpdates: true,
+		})
+		if err != nil {
+			if _, ok := err.(*index.BatchPartialError); !ok {
+				return err
+			}
+			// Duplicate IDs across segments are expected, the first
+			// document inserted is retained.
+		}
+		numDocs += int64(len(c.docs))
+		for i := range c.docs {
+			c.docs[i] = doc.Document{}
+		}
+		c.docs = c.docs[:0]
+		c.throttle(batchStart, compactorThrottleBatchSize)
+		batchStart = c.opts.NowFn()
+		return nil
+	}
+
+	for _, seg := range segs {
+		if err := c.appendDocs(seg, flush); err != nil {
+			return nil, 0, err
+		}
+	}
+	if err := flush(); err != nil {
+		return nil, 0, err
+	}
+
+	compacted, err := c.toFST(merged)
+	if err != nil {
+		return nil, 0, err
+	}
+	return compacted, numDocs, nil
+}
+
+func (c *Compactor) appendDocs(seg segment.Segment, flush func() error) error {
+	reader, err := seg.Reader()
+	if err != nil {
+		return err
+	}
+	readerCloser := x.NewSafeCloser(reader)
+	defer readerCloser.Close()
+
+	iter, err := reader.AllDocs()
+	if err != nil {
+		return err
+	}
+	iterCloser := x.NewSafeCloser(iter)
+	defer iterCloser.Close()
+
+	for iter.Next() {
+		c.docs = append(c.docs, iter.Current())
+		if len(c.docs) < compactorThrottleBatchSize {
+			continue
+		}
+		if err := flush(); err != nil {
+			return err
+		}
+	}
+	if err := iter.Err(); err != nil {
+		return err
+	}
+	// NB: flush before closing the reader as the documents may reference
+	// bytes owned by the reader.
+	if err := flush(); err != nil {
+		return err
+	}
+	if err := iterCloser.Close(); err != nil {
+		return err
+	}
+	return readerCloser.Close()
+}
+
+func (c *Compactor) throttle(batchStart time.Time, batchSize int) {
+	if c.opts.MaxDocsPerSecond <= 0 {
+		return
+	}
+	expected := time.Duration(batchSize) * time.Second /
+		time.Duration(c.opts.MaxDocsPerSecond)
+	if took := c.opts.NowFn().Sub(batchStart); took < expected {
+		c.opts.SleepFn(expected - took)
+	}
+}
+
+func (c *Compactor) toFST(merged segment.MutableSegment) (segment.Segment, error) {
+	if _, err := merged.Seal(); err != nil {
+		return nil, err
+	}
+	if err := c.writer.Reset(merged); err != nil {
+		return nil, err
+	}
+
+	var (
+		docsDataBuffer  bytes.Buffer
+		docsIndexBuffer bytes.Buffer
+		postingsBuffer  bytes.Buffer
+		fstTermsBuffer  bytes.Buffer
+		fstFieldsBuffer bytes.Buffer
+	)
+	if err := c.writer.WriteDocumentsData(&docsDataBuffer); err != nil {
+		return nil, err
+	}
+	if err := c.writer.WriteDocumentsIndex(&docsIndexBuffer); err != nil {
+		return nil, err
+	}
+	if err := c.writer.WritePostingsOffsets(&postingsBuffer); err != nil {
+		return nil, err
+	}
+	if err := c.writer.WriteFSTTerms(&fstTermsBuffer); err != nil {
+		return nil, err
+	}
+	if err := c.writer.WriteFSTFields(&fstFieldsBuffer); err != nil {
+		return nil, err
+	}
+
+	return fst.NewSegment(fst.SegmentData{
+		MajorVersion:  c.writer.MajorVersion(),
+		MinorVersion:  c.writer.MinorVersion(),
+		Metadata:      c.writer.Metadata(),
+		DocsData:      docsDataBuffer.Bytes(),
+		DocsIdxData:   docsIndexBuffer.Bytes(),
+		PostingsData:  postingsBuffer.Bytes(),
+		FSTTermsData:  fstTermsBuffer.Bytes(),
+		FSTFieldsData: fstFieldsBuffer.Bytes(),
+	}, c.opts.FSTSegmentOptions)
+}
@@ -0,0 +1,230 @@
+// Copyright (c) 2018 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package compaction
+
+import (
+	"errors"
+	"sync"
+	"time"
+
+	"github.com/m3db/m3/src/m3ninx/index/segment"
+	"github.com/m3db/m3x/instrument"
+
+	"github.com/uber-go/tally"
+)
+
+const (
+	defaultManagerConcurrency = 1
+)
+
+var (
+	errManagerConcurrencyNotPositive = errors.New("compaction concurrency must be positive")
+)
+
+// ManagerOptions are the options for a compaction Manager.
+type ManagerOptions struct {
+	// Concurrency is the maximum number of compaction tasks that may run at
+	// once across all index blocks.
+	Concurrency int
+	// BackgroundEnabled enables compactions to run as part of the regular
+	// index tick, if disabled compactions only run when triggered.
+	BackgroundEnabled bool
+	// PlannerOptions are the options used to plan compactions.
+	PlannerOptions PlannerOptions
+	// CompactorOptions are the options used to execute compactions.
+	CompactorOptions CompactorOptions
+	// InstrumentOptions are the instrument options.
+	InstrumentOptions instrument.Options
+}
+
+// Status is a point in time summary of compaction activity.
+type Status struct {
+	BackgroundEnabled bool          `json:"backgroundEnabled"`
+	Triggered         bool          `json:"triggered"`
+	Running           int           `json:"running"`
+	Concurrency       int           `json:"concurrency"`
+	Completed         int64         `json:"completed"`
+	Failed            int64         `json:"failed"`
+	SegmentsCompacted int64         `json:"segmentsCompacted"`
+	DocsCompacted     int64         `json:"docsCompacted"`
+	LastCompactedAt   time.Time     `json:"lastCompactedAt"`
+	LastDuration      time.Duration `json:"lastDuration"`
+	LastError         string        `json:"lastError,omitempty"`
+	Levels            []Level       `json:"levels"`
+}
+
+// Manager bounds the concurrency of index segment compactions, holds the
+// planning configuration and records compaction activity so that it can be
+// inspected and triggered by operators.
+type Manager struct {
+	sync.Mutex
+
+	opts        ManagerOptions
+	nowFn       func() time.Time
+	workers     chan *Compactor
+	triggered   bool
+	running     int
+	status      Status
+	metrics     managerMetrics
+	plannerOpts PlannerOptions
+}
+
+// NewManager returns a new compaction manager.
+func NewManager(opts ManagerOptions) (*Manager, error) {
+	if opts.Concurrency == 0 {
+		opts.Concurrency = defaultManagerConcurrency
+	}
+	if opts.Concurrency < 0 {
+		return nil, errManagerConcurrencyNotPositive
+	}
+	if len(opts.PlannerOptions.Levels) == 0 {
+		opts.PlannerOptions = DefaultOptions
+	}
+	if err := opts.PlannerOptions.Validate(); err != nil {
+		return nil, err
+	}
+	if opts.InstrumentOptions == nil {
+		opts.InstrumentOptions = instrument.NewOptions()
+	}
+	nowFn := opts.CompactorOptions.NowFn
+	if nowFn == nil {
+		nowFn = time.Now
+	}
+
+	workers := make(chan *Compactor, opts.Concurrency)
+	for i := 0; i < opts.Concurrency; i++ {
+		workers <- NewCompactor(opts.CompactorOptions)
+	}
+
+	return &Manager{
+		opts:        opts,
+		nowFn:       nowFn,
+		workers:     workers,
+		plannerOpts: opts.PlannerOptions,
+		metrics: newManagerMetrics(opts.InstrumentOptions.MetricsScope().
+			SubScope("index-compaction")),
+	}, nil
+}
+
+// PlannerOptions returns the options to plan compactions with.
+func (m *Manager) PlannerOptions() PlannerOptions {
+	return m.plannerOpts
+}
+
+// Concurrency returns the maximum number of concurrent compactions.
+func (m *Manager) Concurrency() int {
+	return m.opts.Concurrency
+}
+
+// Trigger requests that compactions run at the next opportunity regardless
+// of whether background compactions are enabled.
+func (m *Manager) Trigger() {
+	m.Lock()
+	m.triggered = true
+	m.Unlock()
+}
+
+// ShouldCompact returns whether compactions should run now, it consumes any
+// pending trigger. Background compactions only run if backgroundAllowed is
+// true, triggered compactions run regardless.
+func (m *Manager) ShouldCompact(backgroundAllowed bool) bool {
+	m.Lock()
+	defer m.Unlock()
+	if m.triggered {
+		m.triggered = false
+		return true
+	}
+	return m.opts.BackgroundEnabled && backgroundAllowed
+}
+
+// Compact runs a single compaction task, blocking until a compaction worker
+// is available.
+func (m *Manager) Compact(task Task) (segment.Segment, error) {
+	compactor := <-m.workers
+	defer func() {
+		m.workers <- compactor
+	}()
+
+	segs := make([]segment.Segment, 0, len(task.Segments))
+	for _, seg := range task.Segments {
+		segs = append(segs, seg.Segment)
+	}
+
+	m.Lock()
+	m.running++
+	m.Unlock()
+
+	start := m.nowFn()
+	compacted, numDocs, err := compactor.Compact(segs)
+	took := m.nowFn().Sub(start)
+
+	m.Lock()
+	defer m.Unlock()
+	m.running--
+	if err != nil {
+		m.status.Failed++
+		m.status.LastError = err.Error()
+		m.metrics.failed.Inc(1)
+		return nil, err
+	}
+	m.status.Completed++
+	m.status.SegmentsCompacted += int64(len(segs))
+	m.status.DocsCompacted += numDocs
+	m.status.LastCompactedAt = start
+	m.status.LastDuration = took
+	m.status.LastError = ""
+	m.metrics.completed.Inc(1)
+	m.metrics.segments.Inc(int64(len(segs)))
+	m.metrics.docs.Inc(numDocs)
+	m.metrics.latency.Record(took)
+	return compacted, nil
+}
+
+// Status returns the current compaction status.
+func (m *Manager) Status() Status {
+	m.Lock()
+	defer m.Unlock()
+	status := m.status
+	status.BackgroundEnabled = m.opts.BackgroundEnabled
+	status.Triggered = m.triggered
+	status.Running = m.running
+	status.Concurrency = m.opts.Concurrency
+	status.Levels = append([]Level(nil), m.plannerOpts.Levels...)
+	return status
+}
+
+type managerMetrics struct {
+	completed tally.Counter
+	failed    tally.Counter
+	segments  tally.Counter
+	docs      tally.Counter
+	latency   tally.Timer
+}
+
+func newManagerMetrics(scope tally.Scope) managerMetrics {
+	return managerMetrics{
+		completed: scope.Counter("completed"),
+		failed:    scope.Counter("failed"),
+		segments:  scope.Counter("segments"),
+		docs:      scope.Counter("docs"),
+		latency:   scope.Timer("latency"),
+	}
+}
@@ -0,0 +1,142 @@
+// Copyright (c) 2018 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package compaction
+
+import (
+	"testing"
+	"time"
+
+	"github.com/m3db/m3/src/m3ninx/doc"
+	"github.com/m3db/m3/src/m3ninx/index/segment"
+	"github.com/m3db/m3/src/m3ninx/index/segment/mem"
+	"github.com/m3db/m3/src/m3ninx/postings"
+
+	"github.com/stretchr/testify/require"
+)
+
+func newTestMemSegment(t *testing.T, ids ...string) segment.Segment {
+	seg, err := mem.NewSegment(postings.ID(0), mem.NewOptions())
+	require.NoError(t, err)
+	for _, id := range ids {
+		_, err := seg.Insert(doc.Document{
+			ID: []byte(id),
+			Fields: []doc.Field{
+				{Name: []byte("name"), Value: []byte(id)},
+			},
+		})
+		require.NoError(t, err)
+	}
+	return seg
+}
+
+func TestCompactorCompactMergesSegments(t *testing.T) {
+	compactor := NewCompactor(CompactorOptions{})
+
+	var (
+		s1 = newTestMemSegment(t, "foo", "bar")
+		s2 = newTestMemSegment(t, "bar", "baz")
+	)
+	compacted, numDocs, err := compactor.Compact([]segment.Segment{s1, s2})
+	require.NoError(t, err)
+	require.Equal(t, int64(4), numDocs)
+	require.Equal(t, int64(3), compacted.Size())
+
+	for _, id := range []string{"foo", "bar", "baz"} {
+		ok, err := compacted.ContainsID([]byte(id))
+		require.NoError(t, err)
+		require.True(t, ok)
+	}
+	require.NoError(t, compacted.Close())
+}
+
+func TestCompactorCompactNoSegments(t *testing.T) {
+	compactor := NewCompactor(CompactorOptions{})
+	_, _, err := compactor.Compact(nil)
+	require.Equal(t, errCompactorNoSegments, err)
+}
+
+func TestCompactorThrottle(t *testing.T) {
+	var (
+		now   = time.Now()
+		slept time.Duration
+	)
+	compactor := NewCompactor(CompactorOptions{
+		MaxDocsPerSecond: compactorThrottleBatchSize,
+		NowFn:            func() time.Time { return now },
+		SleepFn:          func(d time.Duration) { slept += d },
+	})
+
+	compactor.throttle(now.Add(-100*time.Millisecond), compactorThrottleBatchSize)
+	require.Equal(t, 900*time.Millisecond, slept)
+}
+
+func TestManagerTriggerAndShouldCompact(t *testing.T) {
+	manager, err := NewManager(ManagerOptions{})
+	require.NoError(t, err)
+	require.Equal(t, 1, manager.Concurrency())
+	require.Equal(t, DefaultOptions.Levels, manager.PlannerOptions().Levels)
+
+	require.False(t, manager.ShouldCompact(true))
+	manager.Trigger()
+	require.True(t, manager.Status().Triggered)
+	require.True(t, manager.ShouldCompact(true))
+	require.False(t, manager.ShouldCompact(true))
+
+	// Triggered compactions run even when background compactions are not allowed.
+	manager.Trigger()
+	require.True(t, manager.ShouldCompact(false))
+
+	manager, err = NewManager(ManagerOptions{BackgroundEnabled: true})
+	require.NoError(t, err)
+	require.True(t, manager.ShouldCompact(true))
+	require.False(t, manager.ShouldCompact(false))
+}
+
+func TestManagerInvalidConcurrency(t *testing.T) {
+	_, err := NewManager(ManagerOptions{Concurrency: -1})
+	require.Equal(t, errManagerConcurrencyNotPositive, err)
+}
+
+func TestManagerCompactRecordsStatus(t *testing.T) {
+	manager, err := NewManager(ManagerOptions{Concurrency: 2})
+	require.NoError(t, err)
+
+	compacted, err := manager.Compact(Task{
+		Segments: []Segment{
+			{Segment: newTestMemSegment(t, "foo")},
+			{Segment: newTestMemSegment(t, "bar")},
+		},
+	})
+	require.NoError(t, err)
+	require.Equal(t, int64(2), compacted.Size())
+
+	_, err = manager.Compact(Task{})
+	require.Error(t, err)
+
+	status := manager.Status()
+	require.Equal(t, 2, status.Concurrency)
+	require.Equal(t, int64(1), status.Completed)
+	require.Equal(t, int64(1), status.Failed)
+	require.Equal(t, int64(2), status.SegmentsCompacted)
+	require.Equal(t, int64(2), status.DocsCompacted)
+	require.Equal(t, errCompactorNoSegments.Error(), status.LastError)
+	require.Equal(t, 0, status.Running)
+}
@@ -0,0 +1,377 @@
+// Copyright (c) 2018 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package index
+
+import (
+	"container/list"
+	"errors"
+	"sync"
+
+	"github.com/m3db/m3/src/m3ninx/index"
+	"github.com/m3db/m3/src/m3ninx/index/segment"
+	"github.com/m3db/m3x/instrument"
+
+	"github.com/uber-go/tally"
+)
+
+var (
+	errLazySegmentCacheSizeNotPositive = errors.New("lazy segment cache size must be positive")
+	errLazySegmentClosed               = errors.New("lazy segment has been closed")
+	errLazySegmentReaderClosed         = errors.New("lazy segment reader has been closed")
+)
+
+// ReloadableSegment is an immutable segment that was read from disk and can
+// be read again from disk after it has been closed.
+type ReloadableSegment interface {
+	segment.Segment
+
+	// Reload reads the segment from disk again, the returned segment is
+	// independent of the receiver and must be closed by the caller.
+	Reload() (segment.Segment, error)
+}
+
+// LazySegmentCacheOptions is the set of options used by the LazySegmentCache.
+type LazySegmentCacheOptions struct {
+	InstrumentOptions instrument.Options
+}
+
+// LazySegmentCache is an LRU over the persisted segments of index blocks that
+// are not memory-resident. Such segments are loaded from disk when they are
+// queried and at most size of them are kept loaded at any one time, the least
+// recently used segments are closed to release their mapped files. Segments
+// that are evicted while being read are closed once their last reader is
+// closed.
+type LazySegmentCache struct {
+	sync.Mutex
+
+	size    int
+	lru     *list.List
+	entries map[*lazySegment]*list.Element
+
+	metrics lazySegmentCacheMetrics
+}
+
+// NewLazySegmentCache creates a new lazy segment cache keeping at most size
+// segments loaded.
+func NewLazySegmentCache(
+	size int,
+	opts LazySegmentCacheOptions,
+) (*LazySegmentCache, error) {
+	if size <= 0 {
+		return nil, errLazySegmentCacheSizeNotPositive
+	}
+	iopts := opts.InstrumentOptions
+	if iopts == nil {
+		iopts = instrument.NewOptions()
+	}
+	return &LazySegmentCache{
+		size:    size,
+		lru:     list.New(),
+		entries: make(map[*lazySegment]*list.Element, size),
+		metrics: newLazySegmentCacheMetrics(
+			iopts.MetricsScope().SubScope("lazy-segment-cache")),
+	}, nil
+}
+
+// touch marks the segment as the most recently used, evicting the least
+// recently used segments if the cache is over capacity.
+func (c *LazySegmentCache) touch(seg *lazySegment) {
+	c.Lock()
+	if elem, ok := c.entries[seg]; ok {
+		c.lru.MoveToFront(elem)
+		c.Unlock()
+		return
+	}
+
+	c.entries[seg] = c.lru.PushFront(seg)
+	var evicted []*lazySegment
+	for c.lru.Len() > c.size {
+		elem := c.lru.Back()
+		victim := elem.Value.(*lazySegment)
+		c.lru.Remove(elem)
+		delete(c.entries, victim)
+		evicted = append(evicted, victim)
+	}
+	c.metrics.loadedSegments.Update(float64(c.lru.Len()))
+	c.Unlock()
+
+	// NB: unload outside of the cache lock, segments are never accessed
+	// with their own lock held while acquiring the cache lock but unloading
+	// can close the segment which may be expensive.
+	for _, victim := range evicted {
+		victim.unload()
+		c.metrics.evictions.Inc(1)
+	}
+}
+
+func (c *LazySegmentCache) remove(seg *lazySegment) {
+	c.Lock()
+	if elem, ok := c.entries[seg]; ok {
+		c.lru.Remove(elem)
+		delete(c.entries, seg)
+	}
+	c.metrics.loadedSegments.Update(float64(c.lru.Len()))
+	c.Unlock()
+}
+
+type lazySegmentCacheMetrics struct {
+	loads          tally.Counter
+	loadErrors     tally.Counter
+	evictions      tally.Counter
+	loadedSegments tally.Gauge
+}
+
+func newLazySegmentCacheMetrics(scope tally.Scope) lazySegmentCacheMetrics {
+	return lazySegmentCacheMetrics{
+		loads:          scope.Counter("loads"),
+		loadErrors:     scope.Counter("load-errors"),
+		evictions:      scope.Counter("evictions"),
+		loadedSegments: scope.Gauge("loaded-segments"),
+	}
+}
+
+type lazySegmentWrapFn func(seg segment.Segment) segment.Segment
+
+// lazySegment is a persisted segment that is loaded on demand. While pinned
+// the segment stays loaded, once unpinned it is tracked by the lazy segment
+// cache and can be closed and loaded back from disk at any time.
+type lazySegment struct {
+	sync.Mutex
+
+	reloadable ReloadableSegment
+	wrapFn     lazySegmentWrapFn
+	cache      *LazySegmentCache
+	size       int64
+
+	current *lazySegmentRef
+	pinned  bool
+	closed  bool
+}
+
+// lazySegmentRef is a reference counted loaded segment, the segment is
+// closed once it has been unloaded and all its readers are closed.
+type lazySegmentRef struct {
+	seg  segment.Segment
+	refs int
+}
+
+// newLazySegment returns a pinned lazy segment that takes ownership of the
+// reloadable segment which is already loaded.
+func newLazySegment(
+	reloadable ReloadableSegment,
+	wrapFn lazySegmentWrapFn,
+	cache *LazySegmentCache,
+) *lazySegment {
+	return &lazySegment{
+		reloadable: reloadable,
+		wrapFn:     wrapFn,
+		cache:      cache,
+		size:       reloadable.Size(),
+		current:    &lazySegmentRef{seg: wrapFn(reloadable)},
+		pinned:     true,
+	}
+}
+
+// Pinned returns whether the segment is kept loaded regardless of use.
+func (s *lazySegment) Pinned() bool {
+	s.Lock()
+	defer s.Unlock()
+	return s.pinned
+}
+
+// Unpin hands the segment over to the lazy segment cache.
+func (s *lazySegment) Unpin() {
+	s.Lock()
+	if !s.pinned || s.closed {
+		s.Unlock()
+		return
+	}
+	s.pinned = false
+	loaded := s.current != nil
+	s.Unlock()
+
+	if loaded {
+		s.cache.touch(s)
+	}
+}
+
+func (s *lazySegment) acquire() (*lazySegmentRef, error) {
+	s.Lock()
+	if s.closed {
+		s.Unlock()
+		return nil, errLazySegmentClosed
+	}
+	if s.current == nil {
+		seg, err := s.reloadable.Reload()
+		if err != nil {
+			s.Unlock()
+			s.cache.metrics.loadErrors.Inc(1)
+			return nil, err
+		}
+		s.current = &lazySegmentRef{seg: s.wrapFn(seg)}
+		s.cache.metrics.loads.Inc(1)
+	}
+	ref := s.current
+	ref.refs++
+	pinned := s.pinned
+	s.Unlock()
+
+	if !pinned {
+		s.cache.touch(s)
+	}
+	return ref, nil
+}
+
+func (s *lazySegment) release(ref *lazySegmentRef) error {
+	s.Lock()
+	defer s.Unlock()
+	ref.refs--
+	if ref.refs == 0 && ref != s.current {
+		return ref.seg.Close()
+	}
+	return nil
+}
+
+func (s *lazySegment) unload() {
+	s.Lock()
+	defer s.Unlock()
+	s.unloadWithLock()
+}
+
+func (s *lazySegment) unloadWithLock() error {
+	ref := s.current
+	s.current = nil
+	if ref != nil && ref.refs == 0 {
+		return ref.seg.Close()
+	}
+	return nil
+}
+
+func (s *lazySegment) Size() int64 {
+	s.Lock()
+	defer s.Unlock()
+	if s.closed {
+		return 0
+	}
+	return s.size
+}
+
+func (s *lazySegment) ContainsID(docID []byte) (bool, error) {
+	ref, err := s.acquire()
+	if err != nil {
+		return false, err
+	}
+	defer s.release(ref)
+	return ref.seg.ContainsID(docID)
+}
+
+func (s *lazySegment) Reader() (index.Reader, error) {
+	ref, err := s.acquire()
+	if err != nil {
+		return nil, err
+	}
+	reader, err := ref.seg.Reader()
+	if err != nil {
+		s.release(ref)
+		return nil, err
+	}
+	return &lazySegmentReader{Reader: reader, lazy: s, ref: ref}, nil
+}
+
+func (s *lazySegment) Fields() (segment.FieldsIterator, error) {
+	ref, err := s.acquire()
+	if err != nil {
+		return nil, err
+	}
+	iter, err := ref.seg.Fields()
+	if err != nil {
+		s.release(ref)
+		return nil, err
+	}
+	return &lazySegmentIterator{OrderedBytesIterator: iter, lazy: s, ref: ref}, nil
+}
+
+func (s *lazySegment) Terms(field []byte) (segment.TermsIterator, error) {
+	ref, err := s.acquire()
+	if err != nil {
+		return nil, err
+	}
+	iter, err := ref.seg.Terms(field)
+	if err != nil {
+		s.release(ref)
+		return nil, err
+	}
+	return &lazySegmentIterator{OrderedBytesIterator: iter, lazy: s, ref: ref}, nil
+}
+
+func (s *lazySegment) Close() error {
+	s.Lock()
+	if s.closed {
+		s.Unlock()
+		return errLazySegmentClosed
+	}
+	s.closed = true
+	err := s.unloadWithLock()
+	s.Unlock()
+
+	s.cache.remove(s)
+	return err
+}
+
+type lazySegmentReader struct {
+	index.Reader
+
+	lazy   *lazySegment
+	ref    *lazySegmentRef
+	closed bool
+}
+
+func (r *lazySegmentReader) Close() error {
+	if r.closed {
+		return errLazySegmentReaderClosed
+	}
+	r.closed = true
+	err := r.Reader.Close()
+	if releaseErr := r.lazy.release(r.ref); err == nil {
+		err = releaseErr
+	}
+	return err
+}
+
+type lazySegmentIterator struct {
+	segment.OrderedBytesIterator
+
+	lazy   *lazySegment
+	ref    *lazySegmentRef
+	closed bool
+}
+
+func (i *lazySegmentIterator) Close() error {
+	if i.closed {
+		return nil
+	}
+	i.closed = true
+	err := i.OrderedBytesIterator.Close()
+	if releaseErr := i.lazy.release(i.ref); err == nil {
+		err = releaseErr
+	}
+	return err
+}
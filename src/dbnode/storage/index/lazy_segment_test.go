@@ -0,0 +1,132 @@
+// Copyright (c) 2018 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package index
+
+import (
+	"testing"
+
+	m3ninxindex "github.com/m3db/m3/src/m3ninx/index"
+	"github.com/m3db/m3/src/m3ninx/index/segment"
+
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/require"
+)
+
+type testReloadableSegment struct {
+	segment.Segment
+
+	reloadFn func() (segment.Segment, error)
+}
+
+func (s *testReloadableSegment) Reload() (segment.Segment, error) {
+	return s.reloadFn()
+}
+
+func newTestLazySegment(
+	ctrl *gomock.Controller,
+	cache *LazySegmentCache,
+) (*lazySegment, *segment.MockSegment, *int) {
+	loaded := segment.NewMockSegment(ctrl)
+	loaded.EXPECT().Size().Return(int64(10))
+
+	reloads := 0
+	reloadable := &testReloadableSegment{Segment: loaded}
+	reloadable.reloadFn = func() (segment.Segment, error) {
+		reloads++
+		return loaded, nil
+	}
+
+	noWrap := func(seg segment.Segment) segment.Segment { return seg }
+	return newLazySegment(reloadable, noWrap, cache), loaded, &reloads
+}
+
+func TestLazySegmentPinnedIsNotEvicted(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	cache, err := NewLazySegmentCache(1, LazySegmentCacheOptions{})
+	require.NoError(t, err)
+
+	pinned, pinnedSeg, pinnedReloads := newTestLazySegment(ctrl, cache)
+	unpinned, unpinnedSeg, _ := newTestLazySegment(ctrl, cache)
+	unpinned.Unpin()
+	require.True(t, pinned.Pinned())
+	require.False(t, unpinned.Pinned())
+	require.Equal(t, int64(10), pinned.Size())
+
+	pinnedSeg.EXPECT().ContainsID([]byte("foo")).Return(true, nil)
+	ok, err := pinned.ContainsID([]byte("foo"))
+	require.NoError(t, err)
+	require.True(t, ok)
+	require.Equal(t, 0, *pinnedReloads)
+
+	pinnedSeg.EXPECT().Close().Return(nil)
+	unpinnedSeg.EXPECT().Close().Return(nil)
+	require.NoError(t, pinned.Close())
+	require.NoError(t, unpinned.Close())
+	require.Equal(t, int64(0), pinned.Size())
+}
+
+func TestLazySegmentEvictedAfterReadersClosed(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	cache, err := NewLazySegmentCache(1, LazySegmentCacheOptions{})
+	require.NoError(t, err)
+
+	first, firstSeg, firstReloads := newTestLazySegment(ctrl, cache)
+	second, secondSeg, secondReloads := newTestLazySegment(ctrl, cache)
+	first.Unpin()
+
+	firstReader := m3ninxindex.NewMockReader(ctrl)
+	firstSeg.EXPECT().Reader().Return(firstReader, nil)
+	reader, err := first.Reader()
+	require.NoError(t, err)
+
+	// Unpinning the second segment evicts the first, which must stay open
+	// until its reader is closed.
+	second.Unpin()
+	require.Equal(t, 1, cache.lru.Len())
+
+	gomock.InOrder(
+		firstReader.EXPECT().Close().Return(nil),
+		firstSeg.EXPECT().Close().Return(nil),
+	)
+	require.NoError(t, reader.Close())
+	require.Error(t, reader.Close())
+
+	// Reading the first segment again loads it from disk and evicts the second.
+	secondSeg.EXPECT().Close().Return(nil)
+	firstSeg.EXPECT().ContainsID([]byte("foo")).Return(false, nil)
+	ok, err := first.ContainsID([]byte("foo"))
+	require.NoError(t, err)
+	require.False(t, ok)
+	require.Equal(t, 1, *firstReloads)
+	require.Equal(t, 0, *secondReloads)
+
+	firstSeg.EXPECT().Close().Return(nil)
+	require.NoError(t, first.Close())
+	require.NoError(t, second.Close())
+	require.Equal(t, 0, cache.lru.Len())
+
+	_, err = first.Reader()
+	require.Equal(t, errLazySegmentClosed, err)
+}
@@ -24,6 +24,7 @@ import (
 	"errors"
 
 	"github.com/m3db/m3/src/dbnode/clock"
+	"github.com/m3db/m3/src/dbnode/storage/index/compaction"
 	"github.com/m3db/m3/src/m3ninx/index/segment/mem"
 	"github.com/m3db/m3x/ident"
 	"github.com/m3db/m3x/instrument"
@@ -50,6 +51,10 @@ type opts struct {
 	idPool         ident.Pool
 	bytesPool      pool.CheckedBytesPool
 	resultsPool    ResultsPool
+	postingsCache  *PostingsListCache
+	lazyCache      *LazySegmentCache
+	compactionMgr  *compaction.Manager
+	queryLimits    QueryLimits
 }
 
 var undefinedUUIDFn = func() ([]byte, error) { return nil, errIDGenerationDisabled }
@@ -159,3 +164,43 @@ func (o *opts) SetResultsPool(value ResultsPool) Options {
 func (o *opts) ResultsPool() ResultsPool {
 	return o.resultsPool
 }
+
+func (o *opts) SetPostingsListCache(value *PostingsListCache) Options {
+	opts := *o
+	opts.postingsCache = value
+	return &opts
+}
+
+func (o *opts) PostingsListCache() *PostingsListCache {
+	return o.postingsCache
+}
+
+func (o *opts) SetLazySegmentCache(value *LazySegmentCache) Options {
+	opts := *o
+	opts.lazyCache = value
+	return &opts
+}
+
+func (o *opts) LazySegmentCache() *LazySegmentCache {
+	return o.lazyCache
+}
+
+func (o *opts) SetCompactionManager(value *compaction.Manager) Options {
+	opts := *o
+	opts.compactionMgr = value
+	return &opts
+}
+
+func (o *opts) CompactionManager() *compaction.Manager {
+	return o.compactionMgr
+}
+
+func (o *opts) SetQueryLimits(value QueryLimits) Options {
+	opts := *o
+	opts.queryLimits = value
+	return &opts
+}
+
+func (o *opts) QueryLimits() QueryLimits {
+	return o.queryLimits
+}
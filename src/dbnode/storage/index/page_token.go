@@ -0,0 +1,77 @@
+// Copyright (c) 2018 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package index
+
+import (
+	"bytes"
+	"errors"
+
+	xerrors "github.com/m3db/m3x/errors"
+)
+
+const (
+	pageTokenVersion byte = 1
+)
+
+var (
+	errPageTokenInvalid = xerrors.NewInvalidParamsError(errors.New("invalid page token"))
+)
+
+// PageToken is an opaque token used to resume a paginated query from where
+// the previous page ended. Pages are ordered by series ID so a token remains
+// valid as the index changes between requests.
+type PageToken []byte
+
+// NewPageToken returns a new page token that resumes a query after the
+// given series ID.
+func NewPageToken(lastID []byte) PageToken {
+	token := make(PageToken, 0, 1+len(lastID))
+	token = append(token, pageTokenVersion)
+	return append(token, lastID...)
+}
+
+// LastID returns the series ID the page token resumes after, a nil ID is
+// returned for an empty token which refers to the first page.
+func (t PageToken) LastID() ([]byte, error) {
+	if len(t) == 0 {
+		return nil, nil
+	}
+	if t[0] != pageTokenVersion || len(t) == 1 {
+		return nil, errPageTokenInvalid
+	}
+	return t[1:], nil
+}
+
+// pageIDs is a max heap of the IDs retained for a page.
+type pageIDs [][]byte
+
+func (h pageIDs) Len() int            { return len(h) }
+func (h pageIDs) Less(i, j int) bool  { return bytes.Compare(h[i], h[j]) > 0 }
+func (h pageIDs) Swap(i, j int)       { h[i], h[j] = h[j], h[i] }
+func (h *pageIDs) Push(x interface{}) { *h = append(*h, x.([]byte)) }
+func (h *pageIDs) Pop() interface{} {
+	old := *h
+	n := len(old)
+	x := old[n-1]
+	old[n-1] = nil
+	*h = old[:n-1]
+	return x
+}
@@ -0,0 +1,388 @@
+// Copyright (c) 2018 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package index
+
+import (
+	"container/list"
+	"errors"
+	"sync"
+
+	"github.com/m3db/m3/src/m3ninx/postings"
+	"github.com/m3db/m3x/instrument"
+
+	"github.com/cespare/xxhash"
+	"github.com/pborman/uuid"
+	"github.com/uber-go/tally"
+)
+
+var (
+	errPostingsListCacheSizeNotPositive = errors.New("postings list cache size must be positive")
+)
+
+// PatternType is the type of pattern a cached postings list was resolved for.
+type PatternType int
+
+const (
+	// PatternTypeRegexp indicates the pattern is a regular expression.
+	PatternTypeRegexp PatternType = iota
+	// PatternTypeTerm indicates the pattern is an exact term.
+	PatternTypeTerm
+)
+
+// PostingsListCacheOptions is the set of options used by the PostingsListCache.
+type PostingsListCacheOptions struct {
+	InstrumentOptions instrument.Options
+}
+
+// PostingsListCache caches the postings lists resolved for matchers against
+// immutable segments. Admission to the cache is governed by a TinyLFU policy:
+// once full, a new entry is only admitted if it has been requested more
+// frequently than the least recently used entry it would evict. This keeps
+// hot dashboard queries resident while protecting the cache from being
+// flushed by one-off queries.
+type PostingsListCache struct {
+	sync.Mutex
+
+	size     int
+	lru      *list.List
+	entries  map[postingsListCacheKey]*list.Element
+	segments map[string]map[postingsListCacheKey]struct{}
+	sketch   *frequencySketch
+
+	// live are the segments wrapped with the cache that are not closed.
+	live map[*ReadThroughSegment]struct{}
+
+	metrics postingsListCacheMetrics
+}
+
+type postingsListCacheKey struct {
+	segmentUUID string
+	field       string
+	pattern     string
+	patternType PatternType
+}
+
+type postingsListCacheEntry struct {
+	key      postingsListCacheKey
+	postings postings.List
+}
+
+// NewPostingsListCache creates a new postings list cache holding at most
+// size postings lists.
+func NewPostingsListCache(
+	size int,
+	opts PostingsListCacheOptions,
+) (*PostingsListCache, error) {
+	if size <= 0 {
+		return nil, errPostingsListCacheSizeNotPositive
+	}
+	iopts := opts.InstrumentOptions
+	if iopts == nil {
+		iopts = instrument.NewOptions()
+	}
+	return &PostingsListCache{
+		size:     size,
+		lru:      list.New(),
+		entries:  make(map[postingsListCacheKey]*list.Element, size),
+		segments: make(map[string]map[postingsListCacheKey]struct{}),
+		sketch:   newFrequencySketch(size),
+		live:     make(map[*ReadThroughSegment]struct{}),
+		metrics: newPostingsListCacheMetrics(
+			iopts.MetricsScope().SubScope("postings-list-cache")),
+	}, nil
+}
+
+// GetRegexp returns the cached postings list for a regexp, if any.
+func (q *PostingsListCache) GetRegexp(
+	segmentUUID uuid.UUID,
+	field string,
+	pattern string,
+) (postings.List, bool) {
+	return q.get(segmentUUID, field, pattern, PatternTypeRegexp)
+}
+
+// GetTerm returns the cached postings list for a term, if any.
+func (q *PostingsListCache) GetTerm(
+	segmentUUID uuid.UUID,
+	field string,
+	pattern string,
+) (postings.List, bool) {
+	return q.get(segmentUUID, field, pattern, PatternTypeTerm)
+}
+
+// PutRegexp offers the postings list for a regexp to the cache.
+func (q *PostingsListCache) PutRegexp(
+	segmentUUID uuid.UUID,
+	field string,
+	pattern string,
+	pl postings.List,
+) {
+	q.put(segmentUUID, field, pattern, PatternTypeRegexp, pl)
+}
+
+// PutTerm offers the postings list for a term to the cache.
+func (q *PostingsListCache) PutTerm(
+	segmentUUID uuid.UUID,
+	field string,
+	pattern string,
+	pl postings.List,
+) {
+	q.put(segmentUUID, field, pattern, PatternTypeTerm, pl)
+}
+
+// PurgeSegment removes all postings lists cached for the given segment, it
+// must be called when a segment is rotated out or closed.
+func (q *PostingsListCache) PurgeSegment(segmentUUID uuid.UUID) {
+	q.Lock()
+	defer q.Unlock()
+
+	keys, ok := q.segments[segmentUUID.String()]
+	if !ok {
+		return
+	}
+	for key := range keys {
+		if elem, ok := q.entries[key]; ok {
+			q.lru.Remove(elem)
+			delete(q.entries, key)
+			q.metrics.purges.Inc(1)
+		}
+	}
+	delete(q.segments, segmentUUID.String())
+}
+
+// Len returns the number of postings lists currently cached.
+func (q *PostingsListCache) Len() int {
+	q.Lock()
+	defer q.Unlock()
+	return q.lru.Len()
+}
+
+func (q *PostingsListCache) get(
+	segmentUUID uuid.UUID,
+	field string,
+	pattern string,
+	patternType PatternType,
+) (postings.List, bool) {
+	key := newPostingsListCacheKey(segmentUUID, field, pattern, patternType)
+
+	q.Lock()
+	defer q.Unlock()
+
+	q.sketch.increment(key.hash())
+	elem, ok := q.entries[key]
+	if !ok {
+		q.metrics.misses(patternType).Inc(1)
+		return nil, false
+	}
+	q.lru.MoveToFront(elem)
+	q.metrics.hits(patternType).Inc(1)
+	return elem.Value.(*postingsListCacheEntry).postings, true
+}
+
+func (q *PostingsListCache) put(
+	segmentUUID uuid.UUID,
+	field string,
+	pattern string,
+	patternType PatternType,
+	pl postings.List,
+) {
+	key := newPostingsListCacheKey(segmentUUID, field, pattern, patternType)
+
+	q.Lock()
+	defer q.Unlock()
+
+	if elem, ok := q.entries[key]; ok {
+		elem.Value.(*postingsListCacheEntry).postings = pl
+		q.lru.MoveToFront(elem)
+		return
+	}
+
+	if q.lru.Len() >= q.size {
+		victim := q.lru.Back()
+		victimKey := victim.Value.(*postingsListCacheEntry).key
+		if q.sketch.estimate(key.hash()) <= q.sketch.estimate(victimKey.hash()) {
+			// Candidate is not accessed more frequently than the entry it would
+			// replace, reject it to keep the hot set resident.
+			q.metrics.rejections.Inc(1)
+			return
+		}
+		q.removeWithLock(victim)
+		q.metrics.evictions.Inc(1)
+	}
+
+	elem := q.lru.PushFront(&postingsListCacheEntry{key: key, postings: pl})
+	q.entries[key] = elem
+	segmentKeys, ok := q.segments[key.segmentUUID]
+	if !ok {
+		segmentKeys = make(map[postingsListCacheKey]struct{})
+		q.segments[key.segmentUUID] = segmentKeys
+	}
+	segmentKeys[key] = struct{}{}
+	q.metrics.admissions.Inc(1)
+}
+
+func (q *PostingsListCache) removeWithLock(elem *list.Element) {
+	key := elem.Value.(*postingsListCacheEntry).key
+	q.lru.Remove(elem)
+	delete(q.entries, key)
+	if segmentKeys, ok := q.segments[key.segmentUUID]; ok {
+		delete(segmentKeys, key)
+		if len(segmentKeys) == 0 {
+			delete(q.segments, key.segmentUUID)
+		}
+	}
+}
+
+func newPostingsListCacheKey(
+	segmentUUID uuid.UUID,
+	field string,
+	pattern string,
+	patternType PatternType,
+) postingsListCacheKey {
+	return postingsListCacheKey{
+		segmentUUID: segmentUUID.String(),
+		field:       field,
+		pattern:     pattern,
+		patternType: patternType,
+	}
+}
+
+func (k postingsListCacheKey) hash() uint64 {
+	d := xxhash.New()
+	d.Write([]byte(k.segmentUUID))
+	d.Write([]byte(k.field))
+	d.Write([]byte(k.pattern))
+	d.Write([]byte{byte(k.patternType)})
+	return d.Sum64()
+}
+
+type postingsListCacheMetrics struct {
+	regexpHits   tally.Counter
+	regexpMisses tally.Counter
+	termHits     tally.Counter
+	termMisses   tally.Counter
+	admissions   tally.Counter
+	rejections   tally.Counter
+	evictions    tally.Counter
+	purges       tally.Counter
+}
+
+func newPostingsListCacheMetrics(scope tally.Scope) postingsListCacheMetrics {
+	regexpScope := scope.Tagged(map[string]string{"query_type": "regexp"})
+	termScope := scope.Tagged(map[string]string{"query_type": "term"})
+	return postingsListCacheMetrics{
+		regexpHits:   regexpScope.Counter("hits"),
+		regexpMisses: regexpScope.Counter("misses"),
+		termHits:     termScope.Counter("hits"),
+		termMisses:   termScope.Counter("misses"),
+		admissions:   scope.Counter("admissions"),
+		rejections:   scope.Counter("rejections"),
+		evictions:    scope.Counter("evictions"),
+		purges:       scope.Counter("purges"),
+	}
+}
+
+func (m postingsListCacheMetrics) hits(t PatternType) tally.Counter {
+	if t == PatternTypeTerm {
+		return m.termHits
+	}
+	return m.regexpHits
+}
+
+func (m postingsListCacheMetrics) misses(t PatternType) tally.Counter {
+	if t == PatternTypeTerm {
+		return m.termMisses
+	}
+	return m.regexpMisses
+}
+
+const (
+	frequencySketchDepth = 4
+	// frequencySketchMaxCount is the saturation point of each counter.
+	frequencySketchMaxCount = 15
+	// frequencySketchSampleFactor controls how many increments occur (as a
+	// multiple of the cache size) before all counters are halved, aging out
+	// historic popularity.
+	frequencySketchSampleFactor = 10
+	// frequencySketchMinWidth keeps collisions rare for small caches.
+	frequencySketchMinWidth = 1024
+)
+
+// frequencySketch is a count-min sketch with periodic aging used to estimate
+// the access frequency of keys for TinyLFU admission.
+type frequencySketch struct {
+	counters   [frequencySketchDepth][]uint8
+	mask       uint64
+	additions  int
+	sampleSize int
+}
+
+func newFrequencySketch(size int) *frequencySketch {
+	width := frequencySketchMinWidth
+	for width < size {
+		width <<= 1
+	}
+	s := &frequencySketch{
+		mask:       uint64(width - 1),
+		sampleSize: frequencySketchSampleFactor * size,
+	}
+	for i := range s.counters {
+		s.counters[i] = make([]uint8, width)
+	}
+	return s
+}
+
+func (s *frequencySketch) index(h uint64, row int) uint64 {
+	// Double hashing to derive an independent index for each row.
+	return (h + uint64(row)*((h>>32)|1)) & s.mask
+}
+
+func (s *frequencySketch) increment(h uint64) {
+	for row := range s.counters {
+		idx := s.index(h, row)
+		if s.counters[row][idx] < frequencySketchMaxCount {
+			s.counters[row][idx]++
+		}
+	}
+	s.additions++
+	if s.additions >= s.sampleSize {
+		s.reset()
+	}
+}
+
+func (s *frequencySketch) estimate(h uint64) uint8 {
+	min := uint8(frequencySketchMaxCount)
+	for row := range s.counters {
+		if v := s.counters[row][s.index(h, row)]; v < min {
+			min = v
+		}
+	}
+	return min
+}
+
+func (s *frequencySketch) reset() {
+	for row := range s.counters {
+		for i := range s.counters[row] {
+			s.counters[row][i] >>= 1
+		}
+	}
+	s.additions /= 2
+}
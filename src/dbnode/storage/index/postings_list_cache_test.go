@@ -0,0 +1,125 @@
+// Copyright (c) 2018 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package index
+
+import (
+	"testing"
+
+	"github.com/m3db/m3/src/m3ninx/postings"
+	"github.com/m3db/m3/src/m3ninx/postings/roaring"
+
+	"github.com/pborman/uuid"
+	"github.com/stretchr/testify/require"
+)
+
+func newTestPostingsList(ids ...postings.ID) postings.List {
+	pl := roaring.NewPostingsList()
+	for _, id := range ids {
+		pl.Insert(id)
+	}
+	return pl
+}
+
+func TestPostingsListCacheGetPut(t *testing.T) {
+	cache, err := NewPostingsListCache(4, PostingsListCacheOptions{})
+	require.NoError(t, err)
+
+	segUUID := uuid.NewRandom()
+	_, ok := cache.GetRegexp(segUUID, "foo", "ba.*")
+	require.False(t, ok)
+
+	pl := newTestPostingsList(1, 2, 3)
+	cache.PutRegexp(segUUID, "foo", "ba.*", pl)
+
+	cached, ok := cache.GetRegexp(segUUID, "foo", "ba.*")
+	require.True(t, ok)
+	require.True(t, pl.Equal(cached))
+
+	// Same pattern as a term is a distinct entry.
+	_, ok = cache.GetTerm(segUUID, "foo", "ba.*")
+	require.False(t, ok)
+
+	// Same pattern against another segment is a distinct entry.
+	_, ok = cache.GetRegexp(uuid.NewRandom(), "foo", "ba.*")
+	require.False(t, ok)
+}
+
+func TestPostingsListCachePurgeSegment(t *testing.T) {
+	cache, err := NewPostingsListCache(8, PostingsListCacheOptions{})
+	require.NoError(t, err)
+
+	var (
+		purged   = uuid.NewRandom()
+		retained = uuid.NewRandom()
+	)
+	cache.PutRegexp(purged, "foo", "a.*", newTestPostingsList(1))
+	cache.PutTerm(purged, "foo", "bar", newTestPostingsList(2))
+	cache.PutRegexp(retained, "foo", "a.*", newTestPostingsList(3))
+	require.Equal(t, 3, cache.Len())
+
+	cache.PurgeSegment(purged)
+	require.Equal(t, 1, cache.Len())
+
+	_, ok := cache.GetRegexp(purged, "foo", "a.*")
+	require.False(t, ok)
+	_, ok = cache.GetRegexp(retained, "foo", "a.*")
+	require.True(t, ok)
+}
+
+func TestPostingsListCacheAdmissionPolicy(t *testing.T) {
+	cache, err := NewPostingsListCache(2, PostingsListCacheOptions{})
+	require.NoError(t, err)
+
+	segUUID := uuid.NewRandom()
+
+	// Make two hot entries that fill the cache.
+	for i := 0; i < 3; i++ {
+		cache.GetRegexp(segUUID, "foo", "hot-1")
+		cache.GetRegexp(segUUID, "foo", "hot-2")
+	}
+	cache.PutRegexp(segUUID, "foo", "hot-1", newTestPostingsList(1))
+	cache.PutRegexp(segUUID, "foo", "hot-2", newTestPostingsList(2))
+	require.Equal(t, 2, cache.Len())
+
+	// A one-off query should not evict the hot entries.
+	cache.GetRegexp(segUUID, "foo", "cold")
+	cache.PutRegexp(segUUID, "foo", "cold", newTestPostingsList(3))
+	_, ok := cache.GetRegexp(segUUID, "foo", "cold")
+	require.False(t, ok)
+	_, ok = cache.GetRegexp(segUUID, "foo", "hot-1")
+	require.True(t, ok)
+	_, ok = cache.GetRegexp(segUUID, "foo", "hot-2")
+	require.True(t, ok)
+
+	// Once the new entry becomes hotter than the LRU victim it is admitted.
+	for i := 0; i < 10; i++ {
+		cache.GetRegexp(segUUID, "foo", "warming")
+	}
+	cache.PutRegexp(segUUID, "foo", "warming", newTestPostingsList(4))
+	_, ok = cache.GetRegexp(segUUID, "foo", "warming")
+	require.True(t, ok)
+	require.Equal(t, 2, cache.Len())
+}
+
+func TestPostingsListCacheInvalidSize(t *testing.T) {
+	_, err := NewPostingsListCache(0, PostingsListCacheOptions{})
+	require.Error(t, err)
+}
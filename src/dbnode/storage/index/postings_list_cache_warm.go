@@ -0,0 +1,189 @@
+// Copyright (c) 2018 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package index
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sort"
+
+	m3ninxindex "github.com/m3db/m3/src/m3ninx/index"
+	xerrors "github.com/m3db/m3x/errors"
+)
+
+// PostingsListCachePattern is a matcher whose postings lists are cached,
+// independent of the segments they were resolved against so that it can be
+// resolved again against the segments loaded after a restart.
+type PostingsListCachePattern struct {
+	Field       string      `json:"field"`
+	Pattern     string      `json:"pattern"`
+	PatternType PatternType `json:"patternType"`
+}
+
+// Hottest returns at most n of the patterns with postings lists in the
+// cache, the most frequently requested first.
+func (q *PostingsListCache) Hottest(n int) []PostingsListCachePattern {
+	q.Lock()
+	defer q.Unlock()
+
+	var (
+		patterns  []PostingsListCachePattern
+		estimates = make(map[PostingsListCachePattern]uint8)
+	)
+	// NB: iterate from the most recently used entry so that patterns
+	// requested as frequently are ranked by recency.
+	for elem := q.lru.Front(); elem != nil; elem = elem.Next() {
+		key := elem.Value.(*postingsListCacheEntry).key
+		pattern := PostingsListCachePattern{
+			Field:       key.field,
+			Pattern:     key.pattern,
+			PatternType: key.patternType,
+		}
+		estimate := q.sketch.estimate(key.hash())
+		current, ok := estimates[pattern]
+		if !ok {
+			patterns = append(patterns, pattern)
+		}
+		if !ok || estimate > current {
+			estimates[pattern] = estimate
+		}
+	}
+
+	sort.SliceStable(patterns, func(i, j int) bool {
+		return estimates[patterns[i]] > estimates[patterns[j]]
+	})
+	if len(patterns) > n {
+		patterns = patterns[:n]
+	}
+	return patterns
+}
+
+// Warm resolves the patterns against every segment currently wrapped with
+// the cache and returns the number of postings lists resolved. Besides
+// populating the cache this faults in the pages of the FSTs the patterns
+// are matched against, so that the first queries after a restart do not
+// pay for either.
+func (q *PostingsListCache) Warm(patterns []PostingsListCachePattern) (int, error) {
+	q.Lock()
+	segments := make([]*ReadThroughSegment, 0, len(q.live))
+	for seg := range q.live {
+		segments = append(segments, seg)
+	}
+	q.Unlock()
+
+	var (
+		resolved int
+		multiErr xerrors.MultiError
+	)
+	for _, seg := range segments {
+		reader, err := seg.Reader()
+		if err == errCantGetReaderFromClosedSegment {
+			// Rotated out since the segments were listed.
+			continue
+		}
+		if err != nil {
+			multiErr = multiErr.Add(err)
+			continue
+		}
+		for _, pattern := range patterns {
+			if err := warmPattern(reader, pattern); err != nil {
+				multiErr = multiErr.Add(err)
+				continue
+			}
+			resolved++
+		}
+		if err := reader.Close(); err != nil {
+			multiErr = multiErr.Add(err)
+		}
+	}
+	return resolved, multiErr.FinalError()
+}
+
+func warmPattern(reader m3ninxindex.Reader, pattern PostingsListCachePattern) error {
+	field := []byte(pattern.Field)
+	if pattern.PatternType == PatternTypeTerm {
+		_, err := reader.MatchTerm(field, []byte(pattern.Pattern))
+		return err
+	}
+
+	// NB: the cached pattern is the anchored form of the regexp it was
+	// compiled from, compiling it again yields the same anchored form.
+	compiled, err := m3ninxindex.CompileRegex([]byte(pattern.Pattern))
+	if err != nil {
+		return err
+	}
+	_, err = reader.MatchRegexp(field, compiled)
+	return err
+}
+
+func (q *PostingsListCache) addSegment(seg *ReadThroughSegment) {
+	q.Lock()
+	q.live[seg] = struct{}{}
+	q.Unlock()
+}
+
+func (q *PostingsListCache) removeSegment(seg *ReadThroughSegment) {
+	q.Lock()
+	delete(q.live, seg)
+	q.Unlock()
+}
+
+// WritePostingsListCachePatterns writes the patterns to a file, replacing
+// any patterns previously written to it.
+func WritePostingsListCachePatterns(
+	path string,
+	patterns []PostingsListCachePattern,
+) error {
+	data, err := json.Marshal(patterns)
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return err
+	}
+
+	// Write to a temporary file first so that a crash while writing does
+	// not leave a truncated file behind.
+	tmpPath := path + ".tmp"
+	if err := ioutil.WriteFile(tmpPath, data, 0644); err != nil {
+		return err
+	}
+	return os.Rename(tmpPath, path)
+}
+
+// ReadPostingsListCachePatterns reads the patterns written to a file, no
+// patterns are returned if the file does not exist.
+func ReadPostingsListCachePatterns(path string) ([]PostingsListCachePattern, error) {
+	data, err := ioutil.ReadFile(path)
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	var patterns []PostingsListCachePattern
+	if err := json.Unmarshal(data, &patterns); err != nil {
+		return nil, err
+	}
+	return patterns, nil
+}
@@ -0,0 +1,122 @@
+// Copyright (c) 2018 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package index
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	m3ninxindex "github.com/m3db/m3/src/m3ninx/index"
+	"github.com/m3db/m3/src/m3ninx/index/segment"
+
+	"github.com/golang/mock/gomock"
+	"github.com/pborman/uuid"
+	"github.com/stretchr/testify/require"
+)
+
+func TestPostingsListCacheHottest(t *testing.T) {
+	cache, err := NewPostingsListCache(8, PostingsListCacheOptions{})
+	require.NoError(t, err)
+
+	var (
+		seg1 = uuid.NewRandom()
+		seg2 = uuid.NewRandom()
+	)
+	for i := 0; i < 3; i++ {
+		cache.GetRegexp(seg1, "foo", "hot.*")
+	}
+	cache.GetTerm(seg1, "foo", "warm")
+	cache.PutRegexp(seg1, "foo", "hot.*", newTestPostingsList(1))
+	cache.PutRegexp(seg2, "foo", "hot.*", newTestPostingsList(2))
+	cache.PutTerm(seg1, "foo", "warm", newTestPostingsList(3))
+	cache.PutTerm(seg1, "bar", "cold", newTestPostingsList(4))
+
+	// Patterns cached for several segments are returned once.
+	require.Equal(t, []PostingsListCachePattern{
+		{Field: "foo", Pattern: "hot.*", PatternType: PatternTypeRegexp},
+		{Field: "foo", Pattern: "warm", PatternType: PatternTypeTerm},
+	}, cache.Hottest(2))
+	require.Len(t, cache.Hottest(10), 3)
+}
+
+func TestPostingsListCacheWarm(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	cache, err := NewPostingsListCache(16, PostingsListCacheOptions{})
+	require.NoError(t, err)
+
+	compiled, err := m3ninxindex.CompileRegex([]byte("ba.*"))
+	require.NoError(t, err)
+	patterns := []PostingsListCachePattern{
+		{Field: "foo", Pattern: compiled.Simple.String(), PatternType: PatternTypeRegexp},
+		{Field: "foo", Pattern: "bar", PatternType: PatternTypeTerm},
+	}
+
+	seg := segment.NewMockSegment(ctrl)
+	reader := m3ninxindex.NewMockReader(ctrl)
+	seg.EXPECT().Reader().Return(reader, nil).Times(2)
+	reader.EXPECT().MatchRegexp([]byte("foo"), gomock.Any()).
+		Return(newTestPostingsList(1), nil)
+	reader.EXPECT().MatchTerm([]byte("foo"), []byte("bar")).
+		Return(newTestPostingsList(2), nil)
+	reader.EXPECT().Close().Return(nil)
+	readThrough := NewReadThroughSegment(seg, cache)
+
+	// Closed segments are not warmed.
+	closed := NewReadThroughSegment(segment.NewMockSegment(ctrl), cache)
+	closed.segment.(*segment.MockSegment).EXPECT().Close().Return(nil)
+	require.NoError(t, closed.Close())
+
+	resolved, err := cache.Warm(patterns)
+	require.NoError(t, err)
+	require.Equal(t, 2, resolved)
+	require.Equal(t, 2, cache.Len())
+
+	// The warmed postings lists are served from the cache.
+	r, err := readThrough.Reader()
+	require.NoError(t, err)
+	pl, err := r.MatchRegexp([]byte("foo"), compiled)
+	require.NoError(t, err)
+	require.True(t, newTestPostingsList(1).Equal(pl))
+}
+
+func TestPostingsListCachePatternsReadWrite(t *testing.T) {
+	dir, err := ioutil.TempDir("", "postings-list-cache")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	path := filepath.Join(dir, "index", "postings_list_cache_patterns.json")
+	patterns, err := ReadPostingsListCachePatterns(path)
+	require.NoError(t, err)
+	require.Nil(t, patterns)
+
+	expected := []PostingsListCachePattern{
+		{Field: "foo", Pattern: `(?-s:\Aba.*\z)`, PatternType: PatternTypeRegexp},
+		{Field: "foo", Pattern: "bar", PatternType: PatternTypeTerm},
+	}
+	require.NoError(t, WritePostingsListCachePatterns(path, expected))
+	patterns, err = ReadPostingsListCachePatterns(path)
+	require.NoError(t, err)
+	require.Equal(t, expected, patterns)
+}
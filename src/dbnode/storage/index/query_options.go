@@ -0,0 +1,67 @@
+// Copyright (c) 2018 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package index
+
+// String returns the name of the query limit type.
+func (t QueryLimitType) String() string {
+	switch t {
+	case QueryLimitNone:
+		return "none"
+	case QueryLimitSeries:
+		return "series"
+	case QueryLimitDocs:
+		return "docs"
+	case QueryLimitBytes:
+		return "bytes"
+	}
+	return "unknown"
+}
+
+// ApplyLimits returns the query options with each limit bounded by the
+// maximum limits provided.
+func (o QueryOptions) ApplyLimits(limits QueryLimits) QueryOptions {
+	o.Limit = boundLimit(o.Limit, limits.MaxSeries)
+	o.DocsLimit = boundLimit(o.DocsLimit, limits.MaxDocs)
+	o.BytesLimit = boundLimit(o.BytesLimit, limits.MaxBytes)
+	return o
+}
+
+// ExceededLimit returns the first limit that the results have reached, or
+// QueryLimitNone if the results are within all limits.
+func (o QueryOptions) ExceededLimit(results Results) QueryLimitType {
+	if o.Limit > 0 && results.Size() >= o.Limit {
+		return QueryLimitSeries
+	}
+	if o.DocsLimit > 0 && results.TotalDocsCount() >= o.DocsLimit {
+		return QueryLimitDocs
+	}
+	if o.BytesLimit > 0 && results.TotalBytes() >= o.BytesLimit {
+		return QueryLimitBytes
+	}
+	return QueryLimitNone
+}
+
+func boundLimit(requested, max int) int {
+	if max > 0 && (requested <= 0 || requested > max) {
+		return max
+	}
+	return requested
+}
@@ -0,0 +1,55 @@
+// Copyright (c) 2018 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package index
+
+import (
+	"testing"
+
+	"github.com/m3db/m3/src/m3ninx/doc"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestQueryOptionsApplyLimits(t *testing.T) {
+	limits := QueryLimits{MaxSeries: 10, MaxDocs: 100}
+
+	opts := QueryOptions{}.ApplyLimits(limits)
+	require.Equal(t, 10, opts.Limit)
+	require.Equal(t, 100, opts.DocsLimit)
+	require.Equal(t, 0, opts.BytesLimit)
+
+	opts = QueryOptions{Limit: 5, DocsLimit: 1000, BytesLimit: 64}.ApplyLimits(limits)
+	require.Equal(t, 5, opts.Limit)
+	require.Equal(t, 100, opts.DocsLimit)
+	require.Equal(t, 64, opts.BytesLimit)
+}
+
+func TestQueryOptionsExceededLimit(t *testing.T) {
+	results := NewResults(testOpts)
+	_, _, err := results.Add(doc.Document{ID: []byte("abcd")})
+	require.NoError(t, err)
+
+	require.Equal(t, QueryLimitNone, QueryOptions{}.ExceededLimit(results))
+	require.Equal(t, QueryLimitNone, QueryOptions{Limit: 2}.ExceededLimit(results))
+	require.Equal(t, QueryLimitSeries, QueryOptions{Limit: 1}.ExceededLimit(results))
+	require.Equal(t, QueryLimitDocs, QueryOptions{DocsLimit: 1}.ExceededLimit(results))
+	require.Equal(t, QueryLimitBytes, QueryOptions{BytesLimit: 4}.ExceededLimit(results))
+}
@@ -0,0 +1,220 @@
+// Copyright (c) 2018 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package index
+
+import (
+	"errors"
+	"sync"
+
+	"github.com/m3db/m3/src/m3ninx/doc"
+	"github.com/m3db/m3/src/m3ninx/index"
+	"github.com/m3db/m3/src/m3ninx/index/segment"
+	"github.com/m3db/m3/src/m3ninx/postings"
+
+	"github.com/pborman/uuid"
+)
+
+var (
+	errCantGetReaderFromClosedSegment = errors.New("cant get reader from closed segment")
+	errCantCloseClosedSegment         = errors.New("cant close closed segment")
+)
+
+// ReadThroughSegment wraps a segment with a postings list cache so that
+// matchers that are resolved repeatedly against the segment are served from
+// the cache. The cached entries are purged when the segment is closed, i.e.
+// when it is rotated out of an index block.
+//
+// NB: only immutable segments may be wrapped since cached postings lists are
+// never invalidated by writes.
+type ReadThroughSegment struct {
+	sync.RWMutex
+
+	segment segment.Segment
+
+	uuid              uuid.UUID
+	postingsListCache *PostingsListCache
+
+	closed bool
+}
+
+// NewReadThroughSegment creates a new read through segment.
+func NewReadThroughSegment(
+	seg segment.Segment,
+	cache *PostingsListCache,
+) *ReadThroughSegment {
+	r := &ReadThroughSegment{
+		segment:           seg,
+		uuid:              uuid.NewRandom(),
+		postingsListCache: cache,
+	}
+	if cache != nil {
+		cache.addSegment(r)
+	}
+	return r
+}
+
+// Reader returns a read through reader for the read through segment.
+func (r *ReadThroughSegment) Reader() (index.Reader, error) {
+	r.RLock()
+	defer r.RUnlock()
+	if r.closed {
+		return nil, errCantGetReaderFromClosedSegment
+	}
+
+	reader, err := r.segment.Reader()
+	if err != nil {
+		return nil, err
+	}
+	return newReadThroughSegmentReader(
+		reader, r.uuid, r.postingsListCache), nil
+}
+
+// Close purges all entries in the cache associated with this segment,
+// and then closes the underlying segment.
+func (r *ReadThroughSegment) Close() error {
+	r.Lock()
+	defer r.Unlock()
+	if r.closed {
+		return errCantCloseClosedSegment
+	}
+
+	r.closed = true
+	if r.postingsListCache != nil {
+		r.postingsListCache.removeSegment(r)
+		r.postingsListCache.PurgeSegment(r.uuid)
+	}
+	return r.segment.Close()
+}
+
+// Size returns the number of documents within the segment.
+func (r *ReadThroughSegment) Size() int64 {
+	return r.segment.Size()
+}
+
+// ContainsID returns a bool indicating if the segment contains the provided ID.
+func (r *ReadThroughSegment) ContainsID(id []byte) (bool, error) {
+	return r.segment.ContainsID(id)
+}
+
+// Fields returns an iterator over the list of known fields.
+func (r *ReadThroughSegment) Fields() (segment.FieldsIterator, error) {
+	return r.segment.Fields()
+}
+
+// Terms returns an iterator over the known terms values for the given field.
+func (r *ReadThroughSegment) Terms(field []byte) (segment.TermsIterator, error) {
+	return r.segment.Terms(field)
+}
+
+type readThroughSegmentReader struct {
+	// reader is explicitly not embedded at the top level
+	// of the struct to force new methods added to index.Reader
+	// to be explicitly supported by the read through cache.
+	reader            index.Reader
+	uuid              uuid.UUID
+	postingsListCache *PostingsListCache
+}
+
+func newReadThroughSegmentReader(
+	reader index.Reader,
+	uuid uuid.UUID,
+	cache *PostingsListCache,
+) index.Reader {
+	return &readThroughSegmentReader{
+		reader:            reader,
+		uuid:              uuid,
+		postingsListCache: cache,
+	}
+}
+
+// MatchRegexp returns a cached posting list or queries the underlying
+// segment if their is a cache miss.
+func (s *readThroughSegmentReader) MatchRegexp(
+	field []byte,
+	c index.CompiledRegex,
+) (postings.List, error) {
+	if s.postingsListCache == nil || c.Simple == nil {
+		return s.reader.MatchRegexp(field, c)
+	}
+
+	// NB: allocating strings for the cache key is cheap relative to the regexp evaluation.
+	fieldStr := string(field)
+	patternStr := c.Simple.String()
+	pl, ok := s.postingsListCache.GetRegexp(s.uuid, fieldStr, patternStr)
+	if ok {
+		return pl, nil
+	}
+
+	pl, err := s.reader.MatchRegexp(field, c)
+	if err == nil {
+		s.postingsListCache.PutRegexp(s.uuid, fieldStr, patternStr, pl)
+	}
+	return pl, err
+}
+
+// MatchTerm returns a cached posting list or queries the underlying
+// segment if their is a cache miss.
+func (s *readThroughSegmentReader) MatchTerm(
+	field []byte,
+	term []byte,
+) (postings.List, error) {
+	if s.postingsListCache == nil {
+		return s.reader.MatchTerm(field, term)
+	}
+
+	fieldStr := string(field)
+	patternStr := string(term)
+	pl, ok := s.postingsListCache.GetTerm(s.uuid, fieldStr, patternStr)
+	if ok {
+		return pl, nil
+	}
+
+	pl, err := s.reader.MatchTerm(field, term)
+	if err == nil {
+		s.postingsListCache.PutTerm(s.uuid, fieldStr, patternStr, pl)
+	}
+	return pl, err
+}
+
+// MatchAll is a pass through call, since there's no postings list to cache.
+func (s *readThroughSegmentReader) MatchAll() (postings.MutableList, error) {
+	return s.reader.MatchAll()
+}
+
+// AllDocs is a pass through call, since there's no postings list to cache.
+func (s *readThroughSegmentReader) AllDocs() (index.IDDocIterator, error) {
+	return s.reader.AllDocs()
+}
+
+// Doc is a pass through call, since there's no postings list to cache.
+func (s *readThroughSegmentReader) Doc(id postings.ID) (doc.Document, error) {
+	return s.reader.Doc(id)
+}
+
+// Docs is a pass through call, since there's no postings list to cache.
+func (s *readThroughSegmentReader) Docs(pl postings.List) (doc.Iterator, error) {
+	return s.reader.Docs(pl)
+}
+
+// Close is a pass through call.
+func (s *readThroughSegmentReader) Close() error {
+	return s.reader.Close()
+}
@@ -0,0 +1,126 @@
+// Copyright (c) 2018 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package index
+
+import (
+	"testing"
+
+	m3ninxindex "github.com/m3db/m3/src/m3ninx/index"
+	"github.com/m3db/m3/src/m3ninx/index/segment"
+
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/require"
+)
+
+func TestReadThroughSegmentMatchRegexp(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	seg := segment.NewMockSegment(ctrl)
+	reader := m3ninxindex.NewMockReader(ctrl)
+	seg.EXPECT().Reader().Return(reader, nil).Times(2)
+
+	cache, err := NewPostingsListCache(16, PostingsListCacheOptions{})
+	require.NoError(t, err)
+
+	var (
+		field = []byte("some-field")
+		pl    = newTestPostingsList(1, 2)
+	)
+	compiled, err := m3ninxindex.CompileRegex([]byte("some-.*"))
+	require.NoError(t, err)
+
+	// Only the first call should reach the underlying reader.
+	reader.EXPECT().MatchRegexp(field, gomock.Any()).Return(pl, nil).Times(1)
+
+	readThrough := NewReadThroughSegment(seg, cache)
+	for i := 0; i < 2; i++ {
+		r, err := readThrough.Reader()
+		require.NoError(t, err)
+
+		actual, err := r.MatchRegexp(field, compiled)
+		require.NoError(t, err)
+		require.True(t, pl.Equal(actual))
+	}
+	require.Equal(t, 1, cache.Len())
+}
+
+func TestReadThroughSegmentMatchTerm(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	seg := segment.NewMockSegment(ctrl)
+	reader := m3ninxindex.NewMockReader(ctrl)
+	seg.EXPECT().Reader().Return(reader, nil).Times(2)
+
+	cache, err := NewPostingsListCache(16, PostingsListCacheOptions{})
+	require.NoError(t, err)
+
+	var (
+		field = []byte("some-field")
+		term  = []byte("some-term")
+		pl    = newTestPostingsList(3)
+	)
+	reader.EXPECT().MatchTerm(field, term).Return(pl, nil).Times(1)
+
+	readThrough := NewReadThroughSegment(seg, cache)
+	for i := 0; i < 2; i++ {
+		r, err := readThrough.Reader()
+		require.NoError(t, err)
+
+		actual, err := r.MatchTerm(field, term)
+		require.NoError(t, err)
+		require.True(t, pl.Equal(actual))
+	}
+}
+
+func TestReadThroughSegmentClosePurgesCache(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	seg := segment.NewMockSegment(ctrl)
+	reader := m3ninxindex.NewMockReader(ctrl)
+	seg.EXPECT().Reader().Return(reader, nil)
+	seg.EXPECT().Close().Return(nil)
+
+	cache, err := NewPostingsListCache(16, PostingsListCacheOptions{})
+	require.NoError(t, err)
+
+	var (
+		field = []byte("some-field")
+		term  = []byte("some-term")
+	)
+	reader.EXPECT().MatchTerm(field, term).Return(newTestPostingsList(3), nil)
+
+	readThrough := NewReadThroughSegment(seg, cache)
+	r, err := readThrough.Reader()
+	require.NoError(t, err)
+	_, err = r.MatchTerm(field, term)
+	require.NoError(t, err)
+	require.Equal(t, 1, cache.Len())
+
+	require.NoError(t, readThrough.Close())
+	require.Equal(t, 0, cache.Len())
+
+	_, err = readThrough.Reader()
+	require.Error(t, err)
+	require.Error(t, readThrough.Close())
+}
@@ -21,6 +21,8 @@
 package index
 
 import (
+	"bytes"
+	"container/heap"
 	"errors"
 
 	"github.com/m3db/m3/src/m3ninx/doc"
@@ -35,8 +37,15 @@ var (
 type results struct {
 	nsID       ident.ID
 	size       int
+	totalDocs  int
+	totalBytes int
 	resultsMap *ResultsMap
 
+	pageAfterID   []byte
+	pageSize      int
+	pageIDs       pageIDs
+	pageTruncated bool
+
 	idPool    ident.Pool
 	bytesPool pool.CheckedBytesPool
 
@@ -63,6 +72,23 @@ func (r *results) Add(d doc.Document) (added bool, size int, err error) {
 	// before we're sure we need it.
 	tsID := ident.BytesID(d.ID)
 
+	// check if it sorts outside of the page being collected, documents of
+	// previous pages are not charged to the docs limit of the page.
+	if r.pageSize > 0 {
+		if r.pageAfterID != nil && bytes.Compare(d.ID, r.pageAfterID) <= 0 {
+			return added, r.size, nil
+		}
+	}
+
+	r.totalDocs++
+
+	if r.pageSize > 0 {
+		if r.size >= r.pageSize && bytes.Compare(d.ID, r.pageIDs[0]) > 0 {
+			r.pageTruncated = true
+			return added, r.size, nil
+		}
+	}
+
 	// check if it already exists in the map.
 	if r.resultsMap.Contains(tsID) {
 		return added, r.size, nil
@@ -76,11 +102,39 @@ func (r *results) Add(d doc.Document) (added bool, size int, err error) {
 	// the tsID's bytes.
 	r.resultsMap.Set(tsID, tags)
 	r.size++
+	r.totalBytes += len(d.ID)
+	for _, f := range d.Fields {
+		r.totalBytes += len(f.Name) + len(f.Value)
+	}
+
+	if r.pageSize > 0 {
+		heap.Push(&r.pageIDs, append([]byte(nil), d.ID...))
+		if r.size > r.pageSize {
+			r.evictLastPageID()
+		}
+	}
 
 	added = true
 	return added, r.size, nil
 }
 
+// evictLastPageID removes the highest sorting ID from the results so that
+// the page only retains the lowest sorting IDs, its bytes are no longer
+// charged to the bytes limit as they are not returned.
+func (r *results) evictLastPageID() {
+	evictID := ident.BytesID(heap.Pop(&r.pageIDs).([]byte))
+	if tags, ok := r.resultsMap.Get(evictID); ok {
+		r.totalBytes -= len(evictID)
+		for _, tag := range tags.Values() {
+			r.totalBytes -= len(tag.Name.Bytes()) + len(tag.Value.Bytes())
+		}
+		tags.Finalize()
+	}
+	r.resultsMap.Delete(evictID)
+	r.size--
+	r.pageTruncated = true
+}
+
 func (r *results) tags(fields doc.Fields) ident.Tags {
 	tags := r.idPool.Tags()
 	for _, f := range fields {
@@ -115,6 +169,26 @@ func (r *results) Size() int {
 	return r.size
 }
 
+func (r *results) TotalDocsCount() int {
+	return r.totalDocs
+}
+
+func (r *results) TotalBytes() int {
+	return r.totalBytes
+}
+
+func (r *results) SetPage(afterID []byte, size int) {
+	r.pageAfterID = nil
+	if afterID != nil {
+		r.pageAfterID = append([]byte(nil), afterID...)
+	}
+	r.pageSize = size
+}
+
+func (r *results) PageTruncated() bool {
+	return r.pageTruncated
+}
+
 func (r *results) Reset(nsID ident.ID) {
 	// finalize existing held nsID
 	if r.nsID != nil {
@@ -135,6 +209,17 @@ func (r *results) Reset(nsID ident.ID) {
 	// reset all keys in the map next
 	r.resultsMap.Reset()
 	r.size = 0
+	r.totalDocs = 0
+	r.totalBytes = 0
+
+	// reset any pagination state.
+	for i := range r.pageIDs {
+		r.pageIDs[i] = nil
+	}
+	r.pageIDs = r.pageIDs[:0]
+	r.pageAfterID = nil
+	r.pageSize = 0
+	r.pageTruncated = false
 
 	// NB: could do keys+value in one step but I'm trying to avoid
 	// using an internal method of a code-gen'd type.
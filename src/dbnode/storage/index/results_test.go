@@ -143,3 +143,81 @@ func TestResultsResetNamespaceClones(t *testing.T) {
 	nsID.Finalize()
 	require.Equal(t, "something", res.Namespace().String())
 }
+
+func TestResultsTotalDocsAndBytes(t *testing.T) {
+	res := NewResults(testOpts)
+	d := doc.Document{
+		ID: []byte("abc"),
+		Fields: doc.Fields{
+			doc.Field{Name: []byte("foo"), Value: []byte("bar")},
+		},
+	}
+	_, _, err := res.Add(d)
+	require.NoError(t, err)
+	_, _, err = res.Add(d)
+	require.NoError(t, err)
+
+	require.Equal(t, 1, res.Size())
+	require.Equal(t, 2, res.TotalDocsCount())
+	require.Equal(t, 9, res.TotalBytes())
+
+	res.Reset(nil)
+	require.Equal(t, 0, res.TotalDocsCount())
+	require.Equal(t, 0, res.TotalBytes())
+}
+
+func TestResultsPageRetainsLowestIDs(t *testing.T) {
+	res := NewResults(testOpts)
+	res.SetPage([]byte("b"), 2)
+
+	for _, id := range []string{"e", "a", "d", "b", "c", "f"} {
+		_, _, err := res.Add(doc.Document{ID: []byte(id)})
+		require.NoError(t, err)
+	}
+
+	require.Equal(t, 2, res.Size())
+	require.True(t, res.PageTruncated())
+	for _, id := range []string{"c", "d"} {
+		_, ok := res.Map().Get(ident.StringID(id))
+		require.True(t, ok)
+	}
+
+	res.Reset(nil)
+	require.False(t, res.PageTruncated())
+	_, size, err := res.Add(doc.Document{ID: []byte("a")})
+	require.NoError(t, err)
+	require.Equal(t, 1, size)
+}
+
+func TestResultsPageDoesNotChargePreviousPages(t *testing.T) {
+	res := NewResults(testOpts)
+	res.SetPage([]byte("b"), 2)
+
+	for _, id := range []string{"a", "b", "c"} {
+		_, _, err := res.Add(doc.Document{ID: []byte(id)})
+		require.NoError(t, err)
+	}
+
+	require.Equal(t, 1, res.Size())
+	require.Equal(t, 1, res.TotalDocsCount())
+	require.Equal(t, 1, res.TotalBytes())
+}
+
+func TestResultsPageEvictionReleasesBytes(t *testing.T) {
+	res := NewResults(testOpts)
+	res.SetPage(nil, 1)
+
+	for _, id := range []string{"b", "a"} {
+		_, _, err := res.Add(doc.Document{
+			ID: []byte(id),
+			Fields: doc.Fields{
+				doc.Field{Name: []byte("foo"), Value: []byte("bar")},
+			},
+		})
+		require.NoError(t, err)
+	}
+
+	require.Equal(t, 1, res.Size())
+	require.Equal(t, 2, res.TotalDocsCount())
+	require.Equal(t, 7, res.TotalBytes())
+}
@@ -27,6 +27,7 @@ import (
 
 	"github.com/m3db/m3/src/dbnode/clock"
 	"github.com/m3db/m3/src/dbnode/storage/bootstrap/result"
+	"github.com/m3db/m3/src/dbnode/storage/index/compaction"
 	"github.com/m3db/m3/src/m3ninx/doc"
 	"github.com/m3db/m3/src/m3ninx/idx"
 	"github.com/m3db/m3/src/m3ninx/index/segment/mem"
@@ -61,13 +62,77 @@ type Query struct {
 type QueryOptions struct {
 	StartInclusive time.Time
 	EndExclusive   time.Time
-	Limit          int
+	// Limit is the maximum number of series to return.
+	Limit int
+	// DocsLimit is the maximum number of documents to match, including
+	// documents for series already matched in another block. Documents of
+	// previous pages of a paginated query are not counted.
+	DocsLimit int
+	// BytesLimit is the maximum number of ID and tag bytes to return.
+	BytesLimit int
+	// PageSize enables pagination when positive, at most PageSize series are
+	// returned ordered by ID. Clients fetch every page of a query from the
+	// hosts, bounding the series of each request to PageSize.
+	PageSize int
+	// PageToken resumes a paginated query, it is empty for the first page.
+	PageToken PageToken
+	// Shards restricts the fetches of a client to the hosts owning the
+	// shards when set, for queries that can only match series of the shards.
+	// It is not sent to the hosts, which query all of their shards.
+	Shards []uint32
+	// OnReplicaDivergence is called by clients with each block of a series
+	// whose checksum differs between the replicas that returned it when set,
+	// clients wait for the responses of all replicas to compare them.
+	// It is not sent to the hosts.
+	OnReplicaDivergence func(ReplicaDivergence)
+	// OnLimitExceeded is called by clients with the limit that caused the
+	// results of a query to not be exhaustive, if set.
+	OnLimitExceeded func(QueryLimitType)
+}
+
+// ReplicaDivergence is a block of a series whose checksum differs between
+// replicas, replicas with the same datapoints for the block encode it the
+// same and so have the same checksum.
+type ReplicaDivergence struct {
+	ID         []byte
+	BlockStart time.Time
+	// Checksums are the checksums of the block by host ID.
+	Checksums map[string]uint32
 }
 
 // QueryResults is the collection of results for a query.
 type QueryResults struct {
 	Results    Results
 	Exhaustive bool
+	// ExceededLimit is the limit that caused the query to stop early, if
+	// the results are exhaustive it is always QueryLimitNone.
+	ExceededLimit QueryLimitType
+	// NextPageToken resumes a paginated query with the next page, it is
+	// empty once there are no more pages. It is also set when a docs or
+	// bytes limit stopped the page, resuming after the last ID returned.
+	NextPageToken PageToken
+}
+
+// QueryLimitType describes a limit applied to a query.
+type QueryLimitType uint
+
+const (
+	// QueryLimitNone refers to no limit.
+	QueryLimitNone QueryLimitType = iota
+	// QueryLimitSeries refers to the series limit.
+	QueryLimitSeries
+	// QueryLimitDocs refers to the docs limit.
+	QueryLimitDocs
+	// QueryLimitBytes refers to the bytes limit.
+	QueryLimitBytes
+)
+
+// QueryLimits are the maximum limits a node applies to every query, a zero
+// value for any limit means it is unlimited.
+type QueryLimits struct {
+	MaxSeries int
+	MaxDocs   int
+	MaxBytes  int
 }
 
 // Results is a collection of results for a query.
@@ -88,6 +153,22 @@ type Results interface {
 	// Size returns the number of IDs tracked.
 	Size() int
 
+	// TotalDocsCount returns the number of documents added, including
+	// documents for IDs that were already tracked.
+	TotalDocsCount() int
+
+	// TotalBytes returns the number of ID and tag bytes of the results, IDs
+	// evicted from a page are not counted.
+	TotalBytes() int
+
+	// SetPage restricts the results to at most size IDs which sort after
+	// the provided ID, retaining the lowest sorting IDs when more are added.
+	SetPage(afterID []byte, size int)
+
+	// PageTruncated returns whether IDs were excluded from the results because
+	// they sort after the current page.
+	PageTruncated() bool
+
 	// Add converts the provided document to a metric and adds it to the results.
 	// This method makes a copy of the bytes backing the document, so the original
 	// may be modified after this function returns without affecting the results map.
@@ -164,6 +245,10 @@ type Block interface {
 	// soon as it can be to reduce memory footprint.
 	NeedsMutableSegmentsEvicted() bool
 
+	// CompactSegments compacts the immutable segments of a sealed block as
+	// planned by the provided compaction manager.
+	CompactSegments(manager *compaction.Manager) (CompactSegmentsResult, error)
+
 	// EvictMutableSegments closes any mutable segments, this is only applicable
 	// valid to be called once the block and hence mutable segments are sealed.
 	// It is expected that results have been added to the block that covers any
@@ -186,6 +271,18 @@ func (e *EvictMutableSegmentResults) Add(o EvictMutableSegmentResults) {
 	e.NumMutableSegments += o.NumMutableSegments
 }
 
+// CompactSegmentsResult returns statistics about the CompactSegments execution.
+type CompactSegmentsResult struct {
+	NumTasks             int64
+	NumSegmentsCompacted int64
+}
+
+// Add adds the provided results to the receiver.
+func (r *CompactSegmentsResult) Add(o CompactSegmentsResult) {
+	r.NumTasks += o.NumTasks
+	r.NumSegmentsCompacted += o.NumSegmentsCompacted
+}
+
 // WriteBatchResult returns statistics about the WriteBatch execution.
 type WriteBatchResult struct {
 	NumSuccess int64
@@ -194,8 +291,9 @@ type WriteBatchResult struct {
 
 // BlockTickResult returns statistics about tick.
 type BlockTickResult struct {
-	NumSegments int64
-	NumDocs     int64
+	NumSegments         int64
+	NumDocs             int64
+	NumSegmentsUnpinned int64
 }
 
 // WriteBatch is a batch type that allows for building of a slice of documents
@@ -552,4 +650,32 @@ type Options interface {
 
 	// ResultsPool returns the results pool.
 	ResultsPool() ResultsPool
+
+	// SetPostingsListCache sets the postings list cache, if nil then
+	// postings lists resolved against immutable segments are not cached.
+	SetPostingsListCache(value *PostingsListCache) Options
+
+	// PostingsListCache returns the postings list cache.
+	PostingsListCache() *PostingsListCache
+
+	// SetLazySegmentCache sets the lazy segment cache, if nil then the
+	// persisted segments of all index blocks are kept memory-resident
+	// regardless of the resident blocks of their namespace.
+	SetLazySegmentCache(value *LazySegmentCache) Options
+
+	// LazySegmentCache returns the lazy segment cache.
+	LazySegmentCache() *LazySegmentCache
+
+	// SetCompactionManager sets the compaction manager, if nil then the
+	// immutable segments of index blocks are never compacted.
+	SetCompactionManager(value *compaction.Manager) Options
+
+	// CompactionManager returns the compaction manager.
+	CompactionManager() *compaction.Manager
+
+	// SetQueryLimits sets the maximum limits applied to every query.
+	SetQueryLimits(value QueryLimits) Options
+
+	// QueryLimits returns the maximum limits applied to every query.
+	QueryLimits() QueryLimits
 }
@@ -510,3 +510,59 @@ func TestNamespaceIndexBlockQuery(t *testing.T) {
 	_, err = idx.Query(ctx, q, qOpts)
 	require.NoError(t, err)
 }
+
+func TestNamespaceIndexBlockQueryPageStoppedByLimit(t *testing.T) {
+	ctrl := gomock.NewController(xtest.Reporter{t})
+	defer ctrl.Finish()
+
+	retention := 2 * time.Hour
+	blockSize := time.Hour
+	now := time.Now().Truncate(blockSize).Add(10 * time.Minute)
+	t0 := now.Truncate(blockSize)
+	opts := testDatabaseOptions()
+	opts = opts.SetClockOptions(opts.ClockOptions().SetNowFn(func() time.Time {
+		return now
+	}))
+
+	b0 := index.NewMockBlock(ctrl)
+	b0.EXPECT().StartTime().Return(t0).AnyTimes()
+	b0.EXPECT().EndTime().Return(t0.Add(blockSize)).AnyTimes()
+	newBlockFn := func(ts time.Time, md namespace.Metadata, io index.Options) (index.Block, error) {
+		if ts.Equal(t0) {
+			return b0, nil
+		}
+		panic("should never get here")
+	}
+	md := testNamespaceMetadata(blockSize, retention)
+	idx, err := newNamespaceIndexWithNewBlockFn(md, newBlockFn, opts)
+	require.NoError(t, err)
+
+	// The block stops once the docs after the page token reach the limit,
+	// the page resumes after the last ID returned.
+	ctx := context.NewContext()
+	q := index.Query{}
+	qOpts := index.QueryOptions{
+		StartInclusive: t0,
+		EndExclusive:   now.Add(time.Minute),
+		DocsLimit:      2,
+		PageSize:       10,
+		PageToken:      index.NewPageToken([]byte("a")),
+	}
+	b0.EXPECT().Query(q, gomock.Any(), gomock.Any()).DoAndReturn(
+		func(_ index.Query, opts index.QueryOptions, results index.Results) (bool, error) {
+			for _, id := range []string{"a", "c", "b"} {
+				_, _, err := results.Add(doc.Document{ID: []byte(id)})
+				require.NoError(t, err)
+				if opts.ExceededLimit(results) != index.QueryLimitNone {
+					return false, nil
+				}
+			}
+			return true, nil
+		})
+	res, err := idx.Query(ctx, q, qOpts)
+	require.NoError(t, err)
+	require.False(t, res.Exhaustive)
+	require.Equal(t, index.QueryLimitDocs, res.ExceededLimit)
+	require.Equal(t, 2, res.Results.TotalDocsCount())
+	require.Equal(t, index.NewPageToken([]byte("c")), res.NextPageToken)
+}
@@ -157,6 +157,13 @@ func (m *mediator) Tick(runType runType, forceType forceType) error {
 	tickStart := m.nowFn()
 	dbBootstrapStateAtTickStart := m.database.BootstrapState()
 
+	// Remove namespaces whose deletion grace period has passed before the
+	// tick so that the cleanup that follows removes their files.
+	if err := m.database.RemoveDeletedNamespaces(tickStart); err != nil {
+		log := m.opts.InstrumentOptions().Logger()
+		log.Errorf("error removing deleted namespaces: %v", err)
+	}
+
 	if err := m.databaseTickManager.Tick(forceType, tickStart); err != nil {
 		return err
 	}
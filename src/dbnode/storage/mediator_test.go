@@ -44,6 +44,7 @@ func TestDatabaseMediatorOpenClose(t *testing.T) {
 	db.EXPECT().Options().Return(opts).AnyTimes()
 	db.EXPECT().GetOwnedNamespaces().Return(nil, nil).AnyTimes()
 	db.EXPECT().BootstrapState().Return(DatabaseBootstrapState{}).AnyTimes()
+	db.EXPECT().RemoveDeletedNamespaces(gomock.Any()).Return(nil).AnyTimes()
 	m, err := newMediator(db, opts)
 	require.NoError(t, err)
 
@@ -38,7 +38,10 @@ import (
 	"github.com/m3db/m3/src/dbnode/storage/bootstrap/result"
 	"github.com/m3db/m3/src/dbnode/storage/index"
 	"github.com/m3db/m3/src/dbnode/storage/namespace"
+	"github.com/m3db/m3/src/dbnode/storage/quota"
+	"github.com/m3db/m3/src/dbnode/storage/replication"
 	"github.com/m3db/m3/src/dbnode/storage/series"
+	"github.com/m3db/m3/src/dbnode/storage/stats"
 	"github.com/m3db/m3/src/dbnode/ts"
 	"github.com/m3db/m3/src/dbnode/x/xio"
 	"github.com/m3db/m3x/context"
@@ -55,6 +58,7 @@ import (
 var (
 	errNamespaceAlreadyClosed    = errors.New("namespace already closed")
 	errNamespaceIndexingDisabled = errors.New("namespace indexing is disabled")
+	errNamespaceNotWritable      = errors.New("namespace is not writable")
 )
 
 type commitLogWriter interface {
@@ -65,6 +69,11 @@ type commitLogWriter interface {
 		unit xtime.Unit,
 		annotation ts.Annotation,
 	) error
+
+	WriteBatch(
+		ctx context.Context,
+		writes []commitlog.BatchWrite,
+	) error
 }
 
 type commitLogWriterFn func(
@@ -85,6 +94,20 @@ func (fn commitLogWriterFn) Write(
 	return fn(ctx, series, datapoint, unit, annotation)
 }
 
+func (fn commitLogWriterFn) WriteBatch(
+	ctx context.Context,
+	writes []commitlog.BatchWrite,
+) error {
+	for i := range writes {
+		write := &writes[i]
+		if err := fn(ctx, write.Series, write.Datapoint,
+			write.Unit, write.Annotation); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
 var commitLogWriteNoOp = commitLogWriter(commitLogWriterFn(func(
 	ctx context.Context,
 	series commitlog.Series,
@@ -95,6 +118,55 @@ var commitLogWriteNoOp = commitLogWriter(commitLogWriterFn(func(
 	return nil
 }))
 
+type replicatingCommitLogWriter struct {
+	writer     commitLogWriter
+	replicator *replication.Replicator
+	blockSize  time.Duration
+}
+
+// newReplicatingCommitLogWriter returns a commit log writer that enqueues
+// writes for replication once they have been written to the commit log.
+func newReplicatingCommitLogWriter(
+	writer commitLogWriter,
+	replicator *replication.Replicator,
+	blockSize time.Duration,
+) commitLogWriter {
+	return &replicatingCommitLogWriter{
+		writer:     writer,
+		replicator: replicator,
+		blockSize:  blockSize,
+	}
+}
+
+func (w *replicatingCommitLogWriter) Write(
+	ctx context.Context,
+	series commitlog.Series,
+	datapoint ts.Datapoint,
+	unit xtime.Unit,
+	annotation ts.Annotation,
+) error {
+	if err := w.writer.Write(ctx, series, datapoint, unit, annotation); err != nil {
+		return err
+	}
+	w.replicator.Replicate(series, w.blockSize, datapoint, unit, annotation)
+	return nil
+}
+
+func (w *replicatingCommitLogWriter) WriteBatch(
+	ctx context.Context,
+	writes []commitlog.BatchWrite,
+) error {
+	if err := w.writer.WriteBatch(ctx, writes); err != nil {
+		return err
+	}
+	for i := range writes {
+		write := &writes[i]
+		w.replicator.Replicate(write.Series, w.blockSize, write.Datapoint,
+			write.Unit, write.Annotation)
+	}
+	return nil
+}
+
 type dbNamespace struct {
 	sync.RWMutex
 
@@ -112,14 +184,19 @@ type dbNamespace struct {
 	snapshotFilesFn    snapshotFilesFn
 	log                xlog.Logger
 	bootstrapState     BootstrapState
+	state              namespace.State
+	deleteAfter        time.Time
 
 	// Contains an entry to all shards for fast shard lookup, an
 	// entry will be nil when this shard does not belong to current database
 	shards []databaseShard
 
-	increasingIndex increasingIndex
-	commitLogWriter commitLogWriter
-	reverseIndex    namespaceIndex
+	increasingIndex  increasingIndex
+	commitLogWriter  commitLogWriter
+	reverseIndex     namespaceIndex
+	diskQuotaTracker *quota.Tracker
+
+	writeBatchWorkers xsync.WorkerPool
 
 	tickWorkers            xsync.WorkerPool
 	tickWorkersConcurrency int
@@ -130,8 +207,11 @@ type dbNamespace struct {
 
 type databaseNamespaceStatsLastTick struct {
 	sync.RWMutex
+	tickedAt     time.Time
 	activeSeries int64
 	activeBlocks int64
+	openBlocks   int64
+	wiredBlocks  int64
 	index        databaseNamespaceIndexStatsLastTick
 }
 
@@ -148,6 +228,8 @@ type databaseNamespaceMetrics struct {
 	snapshot            instrument.MethodMetrics
 	write               instrument.MethodMetrics
 	writeTagged         instrument.MethodMetrics
+	writeBatch          instrument.MethodMetrics
+	writeTaggedBatch    instrument.MethodMetrics
 	read                instrument.MethodMetrics
 	fetchBlocks         instrument.MethodMetrics
 	fetchBlocksMetadata instrument.MethodMetrics
@@ -155,6 +237,7 @@ type databaseNamespaceMetrics struct {
 	unfulfilled         tally.Counter
 	bootstrapStart      tally.Counter
 	bootstrapEnd        tally.Counter
+	writesRejected      tally.Counter
 	shards              databaseNamespaceShardMetrics
 	tick                databaseNamespaceTickMetrics
 	status              databaseNamespaceStatusMetrics
@@ -182,11 +265,13 @@ type databaseNamespaceTickMetrics struct {
 }
 
 type databaseNamespaceIndexTickMetrics struct {
-	numBlocks        tally.Gauge
-	numDocs          tally.Gauge
-	numSegments      tally.Gauge
-	numBlocksSealed  tally.Counter
-	numBlocksEvicted tally.Counter
+	numBlocks            tally.Gauge
+	numDocs              tally.Gauge
+	numSegments          tally.Gauge
+	numBlocksSealed      tally.Counter
+	numBlocksEvicted     tally.Counter
+	numSegmentsCompacted tally.Counter
+	numSegmentsUnpinned  tally.Counter
 }
 
 // databaseNamespaceStatusMetrics are metrics emitted at a fixed interval
@@ -218,6 +303,8 @@ func newDatabaseNamespaceMetrics(scope tally.Scope, samplingRate float64) databa
 		snapshot:            instrument.NewMethodMetrics(scope, "snapshot", samplingRate),
 		write:               instrument.NewMethodMetrics(scope, "write", samplingRate),
 		writeTagged:         instrument.NewMethodMetrics(scope, "write-tagged", samplingRate),
+		writeBatch:          instrument.NewMethodMetrics(scope, "write-batch", samplingRate),
+		writeTaggedBatch:    instrument.NewMethodMetrics(scope, "write-tagged-batch", samplingRate),
 		read:                instrument.NewMethodMetrics(scope, "read", samplingRate),
 		fetchBlocks:         instrument.NewMethodMetrics(scope, "fetchBlocks", samplingRate),
 		fetchBlocksMetadata: instrument.NewMethodMetrics(scope, "fetchBlocksMetadata", samplingRate),
@@ -225,6 +312,7 @@ func newDatabaseNamespaceMetrics(scope tally.Scope, samplingRate float64) databa
 		unfulfilled:         scope.Counter("bootstrap.unfulfilled"),
 		bootstrapStart:      scope.Counter("bootstrap.start"),
 		bootstrapEnd:        scope.Counter("bootstrap.end"),
+		writesRejected:      scope.Counter("writes-rejected-not-writable"),
 		shards: databaseNamespaceShardMetrics{
 			add:         shardsScope.Counter("add"),
 			close:       shardsScope.Counter("close"),
@@ -243,11 +331,13 @@ func newDatabaseNamespaceMetrics(scope tally.Scope, samplingRate float64) databa
 			mergedOutOfOrderBlocks: tickScope.Counter("merged-out-of-order-blocks"),
 			errors:                 tickScope.Counter("errors"),
 			index: databaseNamespaceIndexTickMetrics{
-				numDocs:          indexTickScope.Gauge("num-docs"),
-				numBlocks:        indexTickScope.Gauge("num-blocks"),
-				numSegments:      indexTickScope.Gauge("num-segments"),
-				numBlocksSealed:  indexTickScope.Counter("num-blocks-sealed"),
-				numBlocksEvicted: indexTickScope.Counter("num-blocks-evicted"),
+				numDocs:              indexTickScope.Gauge("num-docs"),
+				numBlocks:            indexTickScope.Gauge("num-blocks"),
+				numSegments:          indexTickScope.Gauge("num-segments"),
+				numBlocksSealed:      indexTickScope.Counter("num-blocks-sealed"),
+				numBlocksEvicted:     indexTickScope.Counter("num-blocks-evicted"),
+				numSegmentsCompacted: indexTickScope.Counter("num-segments-compacted"),
+				numSegmentsUnpinned:  indexTickScope.Counter("num-segments-unpinned"),
 			},
 		},
 		status: databaseNamespaceStatusMetrics{
@@ -277,6 +367,10 @@ func newDatabaseNamespace(
 	if !nopts.WritesToCommitLog() {
 		commitLogWriter = commitLogWriteNoOp
 	}
+	if replicator := opts.Replicator(); replicator != nil {
+		commitLogWriter = newReplicatingCommitLogWriter(commitLogWriter,
+			replicator, nopts.RetentionOptions().BlockSize())
+	}
 
 	iops := opts.InstrumentOptions()
 	logger := iops.Logger().WithFields(xlog.NewField("namespace", id.String()))
@@ -320,6 +414,8 @@ func newDatabaseNamespace(
 		opts:                   opts,
 		metadata:               metadata,
 		nopts:                  nopts,
+		state:                  nopts.State(),
+		deleteAfter:            nopts.DeleteAfter(),
 		seriesOpts:             seriesOpts,
 		nowFn:                  opts.ClockOptions().NowFn(),
 		snapshotFilesFn:        fs.SnapshotFiles,
@@ -327,6 +423,8 @@ func newDatabaseNamespace(
 		increasingIndex:        increasingIndex,
 		commitLogWriter:        commitLogWriter,
 		reverseIndex:           index,
+		diskQuotaTracker:       opts.DiskQuotaTracker(),
+		writeBatchWorkers:      opts.WriteBatchWorkerPool(),
 		tickWorkers:            tickWorkers,
 		tickWorkersConcurrency: tickWorkersConcurrency,
 		metrics:                newDatabaseNamespaceMetrics(scope, iops.MetricsSamplingRate()),
@@ -366,6 +464,20 @@ func (n *dbNamespace) ID() ident.ID {
 	return n.id
 }
 
+func (n *dbNamespace) SetState(state namespace.State, deleteAfter time.Time) {
+	n.Lock()
+	n.state = state
+	n.deleteAfter = deleteAfter
+	n.Unlock()
+}
+
+func (n *dbNamespace) State() (namespace.State, time.Time) {
+	n.RLock()
+	state, deleteAfter := n.state, n.deleteAfter
+	n.RUnlock()
+	return state, deleteAfter
+}
+
 func (n *dbNamespace) NumSeries() int64 {
 	var count int64
 	for _, shard := range n.GetOwnedShards() {
@@ -374,6 +486,35 @@ func (n *dbNamespace) NumSeries() int64 {
 	return count
 }
 
+func (n *dbNamespace) Stats() stats.NamespaceStats {
+	n.statsLastTick.RLock()
+	result := stats.NamespaceStats{
+		Namespace:    n.ID().String(),
+		TickedAt:     n.statsLastTick.tickedAt,
+		ActiveSeries: n.statsLastTick.activeSeries,
+		Blocks: stats.BlockStats{
+			Active: n.statsLastTick.activeBlocks,
+			Open:   n.statsLastTick.openBlocks,
+			Wired:  n.statsLastTick.wiredBlocks,
+		},
+	}
+	if n.reverseIndex != nil {
+		result.Index = &stats.IndexStats{
+			NumBlocks:   n.statsLastTick.index.numBlocks,
+			NumSegments: n.statsLastTick.index.numSegments,
+			NumDocs:     n.statsLastTick.index.numDocs,
+		}
+	}
+	n.statsLastTick.RUnlock()
+
+	result.NumShards = len(n.GetOwnedShards())
+	if reporter, ok := n.blockRetriever.(block.ReadCacheStatsReporter); ok {
+		cacheStats := reporter.ReadCacheStats()
+		result.Cache = &cacheStats
+	}
+	return result
+}
+
 func (n *dbNamespace) Shards() []Shard {
 	n.RLock()
 	shards := n.shardSet.AllIDs()
@@ -514,8 +655,11 @@ func (n *dbNamespace) Tick(c context.Cancellable, tickStart time.Time) error {
 	}
 
 	n.statsLastTick.Lock()
+	n.statsLastTick.tickedAt = tickStart
 	n.statsLastTick.activeSeries = int64(r.activeSeries)
 	n.statsLastTick.activeBlocks = int64(r.activeBlocks)
+	n.statsLastTick.openBlocks = int64(r.openBlocks)
+	n.statsLastTick.wiredBlocks = int64(r.wiredBlocks)
 	n.statsLastTick.index = databaseNamespaceIndexStatsLastTick{
 		numDocs:     indexTickResults.NumTotalDocs,
 		numBlocks:   indexTickResults.NumBlocks,
@@ -538,6 +682,8 @@ func (n *dbNamespace) Tick(c context.Cancellable, tickStart time.Time) error {
 	n.metrics.tick.index.numSegments.Update(float64(indexTickResults.NumSegments))
 	n.metrics.tick.index.numBlocksEvicted.Inc(indexTickResults.NumBlocksEvicted)
 	n.metrics.tick.index.numBlocksSealed.Inc(indexTickResults.NumBlocksSealed)
+	n.metrics.tick.index.numSegmentsCompacted.Inc(indexTickResults.NumSegmentsCompacted)
+	n.metrics.tick.index.numSegmentsUnpinned.Inc(indexTickResults.NumSegmentsUnpinned)
 	n.metrics.tick.errors.Inc(int64(r.errors))
 
 	return nil
@@ -552,6 +698,10 @@ func (n *dbNamespace) Write(
 	annotation []byte,
 ) error {
 	callStart := n.nowFn()
+	if err := n.checkWritable(); err != nil {
+		n.metrics.write.ReportError(n.nowFn().Sub(callStart))
+		return err
+	}
 	shard, err := n.shardFor(id)
 	if err != nil {
 		n.metrics.write.ReportError(n.nowFn().Sub(callStart))
@@ -576,6 +726,10 @@ func (n *dbNamespace) WriteTagged(
 		n.metrics.writeTagged.ReportError(n.nowFn().Sub(callStart))
 		return errNamespaceIndexingDisabled
 	}
+	if err := n.checkWritable(); err != nil {
+		n.metrics.writeTagged.ReportError(n.nowFn().Sub(callStart))
+		return err
+	}
 	shard, err := n.shardFor(id)
 	if err != nil {
 		n.metrics.writeTagged.ReportError(n.nowFn().Sub(callStart))
@@ -586,6 +740,155 @@ func (n *dbNamespace) WriteTagged(
 	return err
 }
 
+func (n *dbNamespace) WriteBatch(
+	ctx context.Context,
+	writes []BatchWrite,
+	errFn BatchWriteErrorFn,
+) error {
+	callStart := n.nowFn()
+	if err := n.checkWritable(); err != nil {
+		n.metrics.writeBatch.ReportError(n.nowFn().Sub(callStart))
+		return err
+	}
+	n.writeBatch(ctx, writes, false, errFn)
+	n.metrics.writeBatch.ReportSuccess(n.nowFn().Sub(callStart))
+	return nil
+}
+
+func (n *dbNamespace) WriteTaggedBatch(
+	ctx context.Context,
+	writes []BatchWrite,
+	errFn BatchWriteErrorFn,
+) error {
+	callStart := n.nowFn()
+	if n.reverseIndex == nil { // only happens if indexing is enabled.
+		n.metrics.writeTaggedBatch.ReportError(n.nowFn().Sub(callStart))
+		return errNamespaceIndexingDisabled
+	}
+	if err := n.checkWritable(); err != nil {
+		n.metrics.writeTaggedBatch.ReportError(n.nowFn().Sub(callStart))
+		return err
+	}
+	n.writeBatch(ctx, writes, true, errFn)
+	n.metrics.writeTaggedBatch.ReportSuccess(n.nowFn().Sub(callStart))
+	return nil
+}
+
+// namespaceShardBatch is the partition of a batch of writes that belongs to
+// a single shard.
+type namespaceShardBatch struct {
+	shard           databaseShard
+	indexes         []int
+	commitLogWrites []commitlog.BatchWrite
+}
+
+// writeBatch partitions the writes by shard and runs the pipeline of each
+// shard concurrently, then appends the writes of all shards to the commit
+// log at once. Writes that fail are passed to errFn in order of their index.
+func (n *dbNamespace) writeBatch(
+	ctx context.Context,
+	writes []BatchWrite,
+	shouldReverseIndex bool,
+	errFn BatchWriteErrorFn,
+) {
+	if len(writes) == 0 {
+		return
+	}
+
+	var (
+		errs      = make([]error, len(writes))
+		batches   []namespaceShardBatch
+		batchesBy = make(map[uint32]int)
+	)
+	n.RLock()
+	for i := range writes {
+		shardID := n.shardSet.Lookup(writes[i].ID)
+		if idx, ok := batchesBy[shardID]; ok {
+			batches[idx].indexes = append(batches[idx].indexes, i)
+			continue
+		}
+		shard, err := n.shardAtWithRLock(shardID)
+		if err != nil {
+			errs[i] = err
+			continue
+		}
+		batchesBy[shardID] = len(batches)
+		batches = append(batches, namespaceShardBatch{
+			shard:   shard,
+			indexes: []int{i},
+		})
+	}
+	n.RUnlock()
+
+	// NB: Each shard only sets the errors at the indexes of its own writes
+	// so the pipelines can share the errors slice.
+	if len(batches) == 1 {
+		batch := &batches[0]
+		batch.commitLogWrites = batch.shard.WriteBatch(ctx, writes,
+			batch.indexes, shouldReverseIndex, errs, nil)
+	} else {
+		var wg sync.WaitGroup
+		for i := range batches {
+			batch := &batches[i]
+			wg.Add(1)
+			n.writeBatchWorkers.Go(func() {
+				batch.commitLogWrites = batch.shard.WriteBatch(ctx, writes,
+					batch.indexes, shouldReverseIndex, errs, nil)
+				wg.Done()
+			})
+		}
+		wg.Wait()
+	}
+
+	numCommitLogWrites := 0
+	for i := range batches {
+		numCommitLogWrites += len(batches[i].commitLogWrites)
+	}
+	if numCommitLogWrites > 0 {
+		commitLogWrites := make([]commitlog.BatchWrite, 0, numCommitLogWrites)
+		for i := range batches {
+			commitLogWrites = append(commitLogWrites, batches[i].commitLogWrites...)
+		}
+		if err := n.commitLogWriter.WriteBatch(ctx, commitLogWrites); err != nil {
+			for i := range batches {
+				for _, idx := range batches[i].indexes {
+					if errs[idx] == nil {
+						errs[idx] = err
+					}
+				}
+			}
+		}
+	}
+
+	for i, err := range errs {
+		if err != nil {
+			errFn(i, err)
+		}
+	}
+}
+
+// checkWritable returns an error if writes to the namespace are rejected
+// because of its lifecycle state or its disk quota.
+func (n *dbNamespace) checkWritable() error {
+	n.RLock()
+	state := n.state
+	n.RUnlock()
+	if !state.Writable() {
+		n.metrics.writesRejected.Inc(1)
+		return errNamespaceNotWritable
+	}
+	return n.checkDiskQuota()
+}
+
+// checkDiskQuota returns an error if writes to the namespace are rejected
+// because it exceeds its hard disk quota.
+func (n *dbNamespace) checkDiskQuota() error {
+	if n.diskQuotaTracker == nil {
+		return nil
+	}
+	return n.diskQuotaTracker.CheckWrite(n.id)
+}
+
 func (n *dbNamespace) QueryIDs(
 	ctx context.Context,
 	query index.Query,
@@ -635,6 +938,18 @@ func (n *dbNamespace) FetchBlocks(
 	return res, err
 }
 
+func (n *dbNamespace) IterateShardBlock(
+	ctx context.Context,
+	shardID uint32,
+	blockStart time.Time,
+) (ShardBlockIterator, error) {
+	shard, err := n.readableShardAt(shardID)
+	if err != nil {
+		return nil, err
+	}
+	return shard.IterateBlock(ctx, blockStart)
+}
+
 func (n *dbNamespace) FetchBlocksMetadata(
 	ctx context.Context,
 	shardID uint32,
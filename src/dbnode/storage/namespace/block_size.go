@@ -0,0 +1,64 @@
+// Copyright (c) 2018 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package namespace
+
+import "time"
+
+type recommendedBlockSize struct {
+	forRetentionLessThanOrEqual time.Duration
+	blockSize                   time.Duration
+}
+
+var recommendedBlockSizesByRetentionAsc = []recommendedBlockSize{
+	{
+		forRetentionLessThanOrEqual: 12 * time.Hour,
+		blockSize:                   30 * time.Minute,
+	},
+	{
+		forRetentionLessThanOrEqual: 24 * time.Hour,
+		blockSize:                   time.Hour,
+	},
+	{
+		forRetentionLessThanOrEqual: 7 * 24 * time.Hour,
+		blockSize:                   2 * time.Hour,
+	},
+	{
+		forRetentionLessThanOrEqual: 30 * 24 * time.Hour,
+		blockSize:                   12 * time.Hour,
+	},
+	{
+		forRetentionLessThanOrEqual: 365 * 24 * time.Hour,
+		blockSize:                   24 * time.Hour,
+	},
+}
+
+// RecommendedBlockSize returns the recommended block size for a namespace
+// with the given retention period.
+func RecommendedBlockSize(retentionPeriod time.Duration) time.Duration {
+	for _, elem := range recommendedBlockSizesByRetentionAsc {
+		if retentionPeriod <= elem.forRetentionLessThanOrEqual {
+			return elem.blockSize
+		}
+	}
+	// Use the maximum block size for retention periods longer than
+	// any of the recommendations
+	return recommendedBlockSizesByRetentionAsc[len(recommendedBlockSizesByRetentionAsc)-1].blockSize
+}
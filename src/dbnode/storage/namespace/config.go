@@ -56,6 +56,14 @@ type MetadataConfiguration struct {
 	RepairEnabled     *bool                   `yaml:"repairEnabled"`
 	Retention         retention.Configuration `yaml:"retention" validate:"nonzero"`
 	Index             IndexConfiguration      `yaml:"index"`
+
+	// IndexSummariesPercent is the percent of series written to the summaries
+	// file of filesets, if not set the filesystem default is used
+	IndexSummariesPercent *float64 `yaml:"indexSummariesPercent"`
+
+	// BloomFilterFalsePositivePercent is the false positive rate of the
+	// bloom filter of filesets, if not set the filesystem default is used
+	BloomFilterFalsePositivePercent *float64 `yaml:"bloomFilterFalsePositivePercent"`
 }
 
 // Metadata returns a Metadata corresponding to the receiver struct
@@ -80,6 +88,12 @@ func (mc *MetadataConfiguration) Metadata() (Metadata, error) {
 	if v := mc.RepairEnabled; v != nil {
 		opts = opts.SetRepairEnabled(*v)
 	}
+	if v := mc.IndexSummariesPercent; v != nil {
+		opts = opts.SetIndexSummariesPercent(*v)
+	}
+	if v := mc.BloomFilterFalsePositivePercent; v != nil {
+		opts = opts.SetBloomFilterFalsePositivePercent(*v)
+	}
 	return NewMetadata(ident.StringID(mc.ID), opts)
 }
 
@@ -87,11 +101,16 @@ func (mc *MetadataConfiguration) Metadata() (Metadata, error) {
 type IndexConfiguration struct {
 	Enabled   bool          `yaml:"enabled" validate:"nonzero"`
 	BlockSize time.Duration `yaml:"blockSize" validate:"nonzero"`
+
+	// ResidentBlocks is the number of most recent index blocks kept
+	// memory-resident, zero keeps all blocks resident.
+	ResidentBlocks int `yaml:"residentBlocks" validate:"min=0"`
 }
 
 // Options returns the IndexOptions corresponding to the receiver struct.
 func (ic *IndexConfiguration) Options() IndexOptions {
 	return NewIndexOptions().
 		SetEnabled(ic.Enabled).
-		SetBlockSize(ic.BlockSize)
+		SetBlockSize(ic.BlockSize).
+		SetResidentBlocks(ic.ResidentBlocks)
 }
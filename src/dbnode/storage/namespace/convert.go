@@ -105,7 +105,11 @@ func ToMetadata(
 		SetWritesToCommitLog(opts.WritesToCommitLog).
 		SetSnapshotEnabled(opts.SnapshotEnabled).
 		SetRetentionOptions(ropts).
-		SetIndexOptions(iopts)
+		SetIndexOptions(iopts).
+		SetState(State(opts.State))
+	if opts.DeleteAfterUnixNanos != 0 {
+		mopts = mopts.SetDeleteAfter(time.Unix(0, opts.DeleteAfterUnixNanos))
+	}
 
 	return NewMetadata(ident.StringID(id), mopts)
 }
@@ -141,6 +145,11 @@ func OptionsToProto(opts Options) *nsproto.NamespaceOptions {
 	ropts := opts.RetentionOptions()
 	iopts := opts.IndexOptions()
 
+	var deleteAfterUnixNanos int64
+	if deleteAfter := opts.DeleteAfter(); !deleteAfter.IsZero() {
+		deleteAfterUnixNanos = deleteAfter.UnixNano()
+	}
+
 	return &nsproto.NamespaceOptions{
 		BootstrapEnabled:  opts.BootstrapEnabled(),
 		FlushEnabled:      opts.FlushEnabled(),
@@ -160,5 +169,7 @@ func OptionsToProto(opts Options) *nsproto.NamespaceOptions {
 			Enabled:        iopts.Enabled(),
 			BlockSizeNanos: iopts.BlockSize().Nanoseconds(),
 		},
+		State:                nsproto.NamespaceState(opts.State()),
+		DeleteAfterUnixNanos: deleteAfterUnixNanos,
 	}
 }
@@ -217,6 +217,31 @@ func TestFromProtoSnapshotEnabled(t *testing.T) {
 	assert.Equal(t, !namespace.NewOptions().SnapshotEnabled(), md.Options().SnapshotEnabled())
 }
 
+func TestStateRoundTrip(t *testing.T) {
+	deleteAfter := time.Unix(0, toNanos(60))
+	md, err := namespace.NewMetadata(
+		ident.StringID("ns1"),
+		namespace.NewOptions().
+			SetState(namespace.StatePendingDelete).
+			SetDeleteAfter(deleteAfter),
+	)
+	require.NoError(t, err)
+	nsMap, err := namespace.NewMap([]namespace.Metadata{md})
+	require.NoError(t, err)
+
+	reg := namespace.ToProto(nsMap)
+	require.Len(t, reg.Namespaces, 1)
+	assert.Equal(t, nsproto.NamespaceState_PENDING_DELETE, reg.Namespaces["ns1"].State)
+	assert.Equal(t, toNanos(60), reg.Namespaces["ns1"].DeleteAfterUnixNanos)
+
+	nsMap, err = namespace.FromProto(*reg)
+	require.NoError(t, err)
+	roundTripped, err := nsMap.Get(ident.StringID("ns1"))
+	require.NoError(t, err)
+	assert.Equal(t, namespace.StatePendingDelete, roundTripped.Options().State())
+	assert.True(t, deleteAfter.Equal(roundTripped.Options().DeleteAfter()))
+}
+
 func assertEqualMetadata(t *testing.T, name string, expected nsproto.NamespaceOptions, observed namespace.Metadata) {
 	require.Equal(t, name, observed.ID().String())
 	opts := observed.Options()
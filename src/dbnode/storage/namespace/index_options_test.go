@@ -33,6 +33,8 @@ func TestIndexOptionsEqual(t *testing.T) {
 	require.False(t, opts.SetEnabled(true).Equal(opts.SetEnabled(false)))
 	require.False(t, opts.SetBlockSize(time.Hour).Equal(
 		opts.SetBlockSize(time.Hour*2)))
+	require.False(t, opts.SetResidentBlocks(1).Equal(
+		opts.SetResidentBlocks(2)))
 }
 
 func TestIndexOptionsEnabled(t *testing.T) {
@@ -45,3 +47,9 @@ func TestIndexOptionsBlockSize(t *testing.T) {
 	opts := NewIndexOptions()
 	require.Equal(t, time.Hour, opts.SetBlockSize(time.Hour).BlockSize())
 }
+
+func TestIndexOptionsResidentBlocks(t *testing.T) {
+	opts := NewIndexOptions()
+	require.Equal(t, 0, opts.ResidentBlocks())
+	require.Equal(t, 3, opts.SetResidentBlocks(3).ResidentBlocks())
+}
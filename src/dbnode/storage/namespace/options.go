@@ -22,6 +22,7 @@ package namespace
 
 import (
 	"errors"
+	"time"
 
 	"github.com/m3db/m3/src/dbnode/retention"
 )
@@ -50,17 +51,24 @@ var (
 	errIndexBlockSizePositive                       = errors.New("index block size must positive")
 	errIndexBlockSizeTooLarge                       = errors.New("index block size needs to be <= namespace retention period")
 	errIndexBlockSizeMustBeAMultipleOfDataBlockSize = errors.New("index block size must be a multiple of data block size")
+	errIndexSummariesPercentInvalid                 = errors.New("index summaries percent must be between 0 and 1")
+	errBloomFilterFalsePositivePercentInvalid       = errors.New("bloom filter false positive percent must be between 0 and 1")
+	errDeleteAfterNotSet                            = errors.New("namespace pending deletion must set delete after time")
 )
 
 type options struct {
-	bootstrapEnabled  bool
-	flushEnabled      bool
-	snapshotEnabled   bool
-	writesToCommitLog bool
-	cleanupEnabled    bool
-	repairEnabled     bool
-	retentionOpts     retention.Options
-	indexOpts         IndexOptions
+	bootstrapEnabled                bool
+	flushEnabled                    bool
+	snapshotEnabled                 bool
+	writesToCommitLog               bool
+	cleanupEnabled                  bool
+	repairEnabled                   bool
+	retentionOpts                   retention.Options
+	indexOpts                       IndexOptions
+	indexSummariesPercent           float64
+	bloomFilterFalsePositivePercent float64
+	state                           State
+	deleteAfter                     time.Time
 }
 
 // NewOptions creates a new namespace options
@@ -81,6 +89,15 @@ func (o *options) Validate() error {
 	if err := o.retentionOpts.Validate(); err != nil {
 		return err
 	}
+	if o.indexSummariesPercent < 0 || o.indexSummariesPercent > 1 {
+		return errIndexSummariesPercentInvalid
+	}
+	if o.bloomFilterFalsePositivePercent < 0 || o.bloomFilterFalsePositivePercent > 1 {
+		return errBloomFilterFalsePositivePercentInvalid
+	}
+	if o.state == StatePendingDelete && o.deleteAfter.IsZero() {
+		return errDeleteAfterNotSet
+	}
 	if !o.indexOpts.Enabled() {
 		return nil
 	}
@@ -109,7 +126,11 @@ func (o *options) Equal(value Options) bool {
 		o.cleanupEnabled == value.CleanupEnabled() &&
 		o.repairEnabled == value.RepairEnabled() &&
 		o.retentionOpts.Equal(value.RetentionOptions()) &&
-		o.indexOpts.Equal(value.IndexOptions())
+		o.indexOpts.Equal(value.IndexOptions()) &&
+		o.indexSummariesPercent == value.IndexSummariesPercent() &&
+		o.bloomFilterFalsePositivePercent == value.BloomFilterFalsePositivePercent() &&
+		o.state == value.State() &&
+		o.deleteAfter.Equal(value.DeleteAfter())
 }
 
 func (o *options) SetBootstrapEnabled(value bool) Options {
@@ -191,3 +212,43 @@ func (o *options) SetIndexOptions(value IndexOptions) Options {
 func (o *options) IndexOptions() IndexOptions {
 	return o.indexOpts
 }
+
+func (o *options) SetIndexSummariesPercent(value float64) Options {
+	opts := *o
+	opts.indexSummariesPercent = value
+	return &opts
+}
+
+func (o *options) IndexSummariesPercent() float64 {
+	return o.indexSummariesPercent
+}
+
+func (o *options) SetBloomFilterFalsePositivePercent(value float64) Options {
+	opts := *o
+	opts.bloomFilterFalsePositivePercent = value
+	return &opts
+}
+
+func (o *options) BloomFilterFalsePositivePercent() float64 {
+	return o.bloomFilterFalsePositivePercent
+}
+
+func (o *options) SetState(value State) Options {
+	opts := *o
+	opts.state = value
+	return &opts
+}
+
+func (o *options) State() State {
+	return o.state
+}
+
+func (o *options) SetDeleteAfter(value time.Time) Options {
+	opts := *o
+	opts.deleteAfter = value
+	return &opts
+}
+
+func (o *options) DeleteAfter() time.Time {
+	return o.deleteAfter
+}
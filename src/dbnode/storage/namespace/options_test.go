@@ -51,6 +51,16 @@ func TestOptionsEqualsIndexOpts(t *testing.T) {
 	require.False(t, o2.Equal(o1))
 }
 
+func TestOptionsEqualsFileSetTuning(t *testing.T) {
+	o1 := NewOptions()
+	o2 := o1.SetIndexSummariesPercent(0.1)
+	o3 := o1.SetBloomFilterFalsePositivePercent(0.001)
+	require.False(t, o1.Equal(o2))
+	require.False(t, o1.Equal(o3))
+	require.False(t, o2.Equal(o3))
+	require.True(t, o2.Equal(o1.SetIndexSummariesPercent(0.1)))
+}
+
 func TestOptionsEqualsRetention(t *testing.T) {
 	ctrl := gomock.NewController(t)
 	defer ctrl.Finish()
@@ -164,3 +174,50 @@ func TestOptionsValidateNoIndexing(t *testing.T) {
 	rOpts.EXPECT().Validate().Return(nil)
 	require.NoError(t, o1.Validate())
 }
+
+func TestOptionsValidateFileSetTuning(t *testing.T) {
+	opts := NewOptions()
+	require.NoError(t, opts.SetIndexSummariesPercent(0.5).
+		SetBloomFilterFalsePositivePercent(0.01).Validate())
+	require.Equal(t, errIndexSummariesPercentInvalid,
+		opts.SetIndexSummariesPercent(1.5).Validate())
+	require.Equal(t, errBloomFilterFalsePositivePercentInvalid,
+		opts.SetBloomFilterFalsePositivePercent(-0.1).Validate())
+}
+
+func TestOptionsValidateState(t *testing.T) {
+	opts := NewOptions()
+	require.NoError(t, opts.SetState(StateReadOnly).Validate())
+	require.Equal(t, errDeleteAfterNotSet,
+		opts.SetState(StatePendingDelete).Validate())
+	require.NoError(t, opts.SetState(StatePendingDelete).
+		SetDeleteAfter(time.Now()).Validate())
+}
+
+func TestOptionsEqualsState(t *testing.T) {
+	opts := NewOptions()
+	deleteAfter := time.Unix(1000, 0)
+	pendingDelete := opts.SetState(StatePendingDelete).SetDeleteAfter(deleteAfter)
+	require.False(t, opts.Equal(opts.SetState(StateReadOnly)))
+	require.False(t, opts.Equal(pendingDelete))
+	require.True(t, pendingDelete.Equal(opts.SetState(StatePendingDelete).
+		SetDeleteAfter(deleteAfter)))
+}
+
+func TestShouldDelete(t *testing.T) {
+	now := time.Unix(1000, 0)
+	opts := NewOptions().SetState(StatePendingDelete)
+	require.True(t, ShouldDelete(opts.SetDeleteAfter(now), now))
+	require.False(t, ShouldDelete(opts.SetDeleteAfter(now.Add(time.Second)), now))
+	require.False(t, ShouldDelete(NewOptions().SetDeleteAfter(now), now))
+}
+
+func TestParseState(t *testing.T) {
+	for _, state := range validStates {
+		parsed, err := ParseState(state.String())
+		require.NoError(t, err)
+		require.Equal(t, state, parsed)
+	}
+	_, err := ParseState("deleted")
+	require.Error(t, err)
+}
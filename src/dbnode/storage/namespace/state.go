@@ -0,0 +1,82 @@
+// Copyright (c) 2018 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package namespace
+
+import (
+	"fmt"
+	"time"
+)
+
+// State is the lifecycle state of a namespace.
+type State int
+
+const (
+	// StateActive is the state of a namespace that accepts reads and writes.
+	StateActive State = iota
+
+	// StateReadOnly is the state of a namespace that accepts reads but
+	// rejects writes.
+	StateReadOnly
+
+	// StatePendingDelete is the state of a namespace that rejects writes and
+	// is deleted by the database nodes once its delete after time passes.
+	StatePendingDelete
+)
+
+var validStates = []State{
+	StateActive,
+	StateReadOnly,
+	StatePendingDelete,
+}
+
+func (s State) String() string {
+	switch s {
+	case StateActive:
+		return "active"
+	case StateReadOnly:
+		return "read_only"
+	case StatePendingDelete:
+		return "pending_delete"
+	}
+	return "unknown"
+}
+
+// ParseState parses a namespace state from its string representation.
+func ParseState(str string) (State, error) {
+	for _, state := range validStates {
+		if str == state.String() {
+			return state, nil
+		}
+	}
+	return 0, fmt.Errorf("invalid namespace state '%s' valid states are: %v",
+		str, validStates)
+}
+
+// Writable returns whether a namespace in the state accepts writes.
+func (s State) Writable() bool {
+	return s == StateActive
+}
+
+// ShouldDelete returns whether a namespace with the options should be deleted
+// at the given time.
+func ShouldDelete(opts Options, now time.Time) bool {
+	return opts.State() == StatePendingDelete && !now.Before(opts.DeleteAfter())
+}
@@ -84,6 +84,36 @@ type Options interface {
 
 	// IndexOptions returns the IndexOptions.
 	IndexOptions() IndexOptions
+
+	// SetIndexSummariesPercent sets the percent of series written to the
+	// summaries file of filesets, zero uses the filesystem default.
+	SetIndexSummariesPercent(value float64) Options
+
+	// IndexSummariesPercent returns the percent of series written to the
+	// summaries file of filesets.
+	IndexSummariesPercent() float64
+
+	// SetBloomFilterFalsePositivePercent sets the false positive rate of the
+	// bloom filter of filesets, zero uses the filesystem default.
+	SetBloomFilterFalsePositivePercent(value float64) Options
+
+	// BloomFilterFalsePositivePercent returns the false positive rate of the
+	// bloom filter of filesets.
+	BloomFilterFalsePositivePercent() float64
+
+	// SetState sets the lifecycle state of the namespace.
+	SetState(value State) Options
+
+	// State returns the lifecycle state of the namespace.
+	State() State
+
+	// SetDeleteAfter sets the time after which a namespace pending deletion
+	// is deleted.
+	SetDeleteAfter(value time.Time) Options
+
+	// DeleteAfter returns the time after which a namespace pending deletion
+	// is deleted.
+	DeleteAfter() time.Time
 }
 
 // IndexOptions controls the indexing options for a namespace.
@@ -102,6 +132,15 @@ type IndexOptions interface {
 
 	// BlockSize returns the block size.
 	BlockSize() time.Duration
+
+	// SetResidentBlocks sets the number of most recent index blocks whose
+	// persisted segments are kept memory-resident, the segments of older
+	// blocks are loaded on demand from disk. Zero keeps all blocks resident.
+	SetResidentBlocks(value int) IndexOptions
+
+	// ResidentBlocks returns the number of most recent index blocks whose
+	// persisted segments are kept memory-resident.
+	ResidentBlocks() int
 }
 
 // Metadata represents namespace metadata information
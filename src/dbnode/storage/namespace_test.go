@@ -23,11 +23,16 @@ package storage
 import (
 	"errors"
 	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sort"
 	"sync"
 	"testing"
 	"time"
 
 	"github.com/m3db/m3/src/dbnode/persist/fs"
+	"github.com/m3db/m3/src/dbnode/persist/fs/commitlog"
 	"github.com/m3db/m3/src/dbnode/retention"
 	"github.com/m3db/m3/src/dbnode/runtime"
 	"github.com/m3db/m3/src/dbnode/sharding"
@@ -36,7 +41,9 @@ import (
 	"github.com/m3db/m3/src/dbnode/storage/bootstrap/result"
 	"github.com/m3db/m3/src/dbnode/storage/index"
 	"github.com/m3db/m3/src/dbnode/storage/namespace"
+	"github.com/m3db/m3/src/dbnode/storage/quota"
 	"github.com/m3db/m3/src/dbnode/storage/repair"
+	"github.com/m3db/m3/src/dbnode/storage/stats"
 	"github.com/m3db/m3/src/dbnode/x/metrics"
 	"github.com/m3db/m3cluster/shard"
 	"github.com/m3db/m3x/context"
@@ -169,6 +176,164 @@ func TestNamespaceWriteShardOwned(t *testing.T) {
 	require.NoError(t, ns.Write(ctx, id, ts, val, unit, ant))
 }
 
+type testBatchCommitLogWriter struct {
+	commitLogWriter
+
+	batches [][]commitlog.BatchWrite
+}
+
+func (w *testBatchCommitLogWriter) WriteBatch(
+	ctx context.Context,
+	writes []commitlog.BatchWrite,
+) error {
+	w.batches = append(w.batches, writes)
+	return nil
+}
+
+func TestNamespaceWriteBatchPartitionsByShard(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	ctx := context.NewContext()
+	defer ctx.Close()
+
+	metadata, err := namespace.NewMetadata(defaultTestNs1ID, defaultTestNs1Opts)
+	require.NoError(t, err)
+	hashFn := func(id ident.ID) uint32 {
+		if id.String() == "bar" {
+			return testShardIDs[1].ID()
+		}
+		return testShardIDs[0].ID()
+	}
+	shardSet, err := sharding.NewShardSet(testShardIDs, hashFn)
+	require.NoError(t, err)
+	dopts := testDatabaseOptions().SetRuntimeOptionsManager(runtime.NewOptionsManager())
+	defer dopts.RuntimeOptionsManager().Close()
+	dbNs, err := newDatabaseNamespace(metadata, shardSet, nil, nil, nil, dopts)
+	require.NoError(t, err)
+	ns := dbNs.(*dbNamespace)
+
+	commitLog := &testBatchCommitLogWriter{}
+	ns.commitLogWriter = commitLog
+
+	now := time.Now()
+	writes := []BatchWrite{
+		{ID: ident.StringID("foo"), Timestamp: now, Value: 1, Unit: xtime.Second},
+		{ID: ident.StringID("bar"), Timestamp: now, Value: 2, Unit: xtime.Second},
+		{ID: ident.StringID("baz"), Timestamp: now, Value: 3, Unit: xtime.Second},
+	}
+
+	shardWriteBatch := func(
+		expectedIndexes []int,
+		failIndex int,
+	) func(context.Context, []BatchWrite, []int, bool, []error, []commitlog.BatchWrite) []commitlog.BatchWrite {
+		return func(
+			_ context.Context,
+			writes []BatchWrite,
+			indexes []int,
+			_ bool,
+			errs []error,
+			commitLogWrites []commitlog.BatchWrite,
+		) []commitlog.BatchWrite {
+			assert.Equal(t, expectedIndexes, indexes)
+			for _, idx := range indexes {
+				if idx == failIndex {
+					errs[idx] = errors.New("write failed")
+					continue
+				}
+				commitLogWrites = append(commitLogWrites, commitlog.BatchWrite{
+					Series: commitlog.Series{ID: writes[idx].ID},
+				})
+			}
+			return commitLogWrites
+		}
+	}
+
+	shard0 := NewMockdatabaseShard(ctrl)
+	shard0.EXPECT().
+		WriteBatch(ctx, gomock.Any(), gomock.Any(), false, gomock.Any(), gomock.Any()).
+		DoAndReturn(shardWriteBatch([]int{0, 2}, 2))
+	shard1 := NewMockdatabaseShard(ctrl)
+	shard1.EXPECT().
+		WriteBatch(ctx, gomock.Any(), gomock.Any(), false, gomock.Any(), gomock.Any()).
+		DoAndReturn(shardWriteBatch([]int{1}, -1))
+	ns.shards[testShardIDs[0].ID()] = shard0
+	ns.shards[testShardIDs[1].ID()] = shard1
+
+	var failed []int
+	require.NoError(t, ns.WriteBatch(ctx, writes, func(index int, err error) {
+		failed = append(failed, index)
+	}))
+	require.Equal(t, []int{2}, failed)
+
+	// The successful writes of both shards are appended to the commit log once
+	require.Equal(t, 1, len(commitLog.batches))
+	var ids []string
+	for _, write := range commitLog.batches[0] {
+		ids = append(ids, write.Series.ID.String())
+	}
+	sort.Strings(ids)
+	require.Equal(t, []string{"bar", "foo"}, ids)
+}
+
+func TestNamespaceWriteHardDiskQuotaExceeded(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	ctx := context.NewContext()
+	defer ctx.Close()
+
+	filePathPrefix, err := ioutil.TempDir("", "namespace-disk-quota")
+	require.NoError(t, err)
+	defer os.RemoveAll(filePathPrefix)
+
+	dir := fs.ShardDataDirPath(filePathPrefix, defaultTestNs1ID, 0)
+	require.NoError(t, os.MkdirAll(dir, 0755))
+	require.NoError(t, ioutil.WriteFile(filepath.Join(dir,
+		fmt.Sprintf("fileset-%d-data.db", time.Unix(0, 0).UnixNano())),
+		make([]byte, 10), 0644))
+
+	tracker, err := quota.NewTracker(quota.TrackerOptions{
+		FilePathPrefix: filePathPrefix,
+		Quotas: map[string]quota.Quota{
+			defaultTestNs1ID.String(): quota.Quota{HardLimitBytes: 5},
+		},
+	})
+	require.NoError(t, err)
+	require.NoError(t, tracker.Measure([]quota.Namespace{
+		{ID: defaultTestNs1ID, Shards: []uint32{0}},
+	}))
+
+	ns, closer := newTestNamespace(t)
+	defer closer()
+	ns.diskQuotaTracker = tracker
+	ns.shards[testShardIDs[0].ID()] = NewMockdatabaseShard(ctrl)
+
+	err = ns.Write(ctx, ident.StringID("foo"), time.Now(), 0.0, xtime.Second, nil)
+	require.Equal(t, quota.ErrHardLimitExceeded, err)
+}
+
+func TestNamespaceWriteNotWritable(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	ctx := context.NewContext()
+	defer ctx.Close()
+
+	ns, closer := newTestNamespace(t)
+	defer closer()
+	ns.shards[testShardIDs[0].ID()] = NewMockdatabaseShard(ctrl)
+
+	for _, state := range []namespace.State{
+		namespace.StateReadOnly,
+		namespace.StatePendingDelete,
+	} {
+		ns.SetState(state, time.Now())
+		err := ns.Write(ctx, ident.StringID("foo"), time.Now(), 0.0, xtime.Second, nil)
+		require.Equal(t, errNamespaceNotWritable, err)
+	}
+}
+
 func TestNamespaceReadEncodedShardNotOwned(t *testing.T) {
 	ctx := context.NewContext()
 	defer ctx.Close()
@@ -1140,6 +1305,48 @@ func TestNamespaceTicksIndex(t *testing.T) {
 	require.NoError(t, err)
 }
 
+func TestNamespaceStats(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	idx := NewMocknamespaceIndex(ctrl)
+	ns, closer := newTestNamespaceWithIndex(t, idx)
+	defer closer()
+
+	// Stats are zero until the namespace is ticked.
+	result := ns.Stats()
+	assert.Equal(t, defaultTestNs1ID.String(), result.Namespace)
+	assert.True(t, result.TickedAt.IsZero())
+	assert.Equal(t, len(testShardIDs), result.NumShards)
+	assert.Equal(t, &stats.IndexStats{}, result.Index)
+	assert.Nil(t, result.Cache)
+
+	for i := range testShardIDs {
+		shard := NewMockdatabaseShard(ctrl)
+		shard.EXPECT().Tick(context.NewNoOpCanncellable(), gomock.Any()).Return(tickResult{
+			activeSeries: 10,
+			activeBlocks: 4,
+			openBlocks:   2,
+			wiredBlocks:  1,
+		}, nil)
+		ns.shards[testShardIDs[i].ID()] = shard
+	}
+	idx.EXPECT().Tick(context.NewNoOpCanncellable(), gomock.Any()).Return(namespaceIndexTickResult{
+		NumBlocks:    2,
+		NumSegments:  5,
+		NumTotalDocs: 20,
+	}, nil)
+
+	tickStart := time.Now()
+	require.NoError(t, ns.Tick(context.NewNoOpCanncellable(), tickStart))
+
+	result = ns.Stats()
+	assert.Equal(t, tickStart, result.TickedAt)
+	assert.Equal(t, int64(20), result.ActiveSeries)
+	assert.Equal(t, stats.BlockStats{Active: 8, Open: 4, Wired: 2}, result.Blocks)
+	assert.Equal(t, &stats.IndexStats{NumBlocks: 2, NumSegments: 5, NumDocs: 20}, result.Index)
+}
+
 func TestNamespaceIndexDisabledQuery(t *testing.T) {
 	ns, closer := newTestNamespace(t)
 	defer closer()
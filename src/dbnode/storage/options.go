@@ -35,14 +35,20 @@ import (
 	"github.com/m3db/m3/src/dbnode/persist/fs/commitlog"
 	"github.com/m3db/m3/src/dbnode/retention"
 	m3dbruntime "github.com/m3db/m3/src/dbnode/runtime"
+	"github.com/m3db/m3/src/dbnode/storage/backup"
 	"github.com/m3db/m3/src/dbnode/storage/block"
 	"github.com/m3db/m3/src/dbnode/storage/bootstrap"
 	"github.com/m3db/m3/src/dbnode/storage/index"
 	"github.com/m3db/m3/src/dbnode/storage/namespace"
+	"github.com/m3db/m3/src/dbnode/storage/quota"
 	"github.com/m3db/m3/src/dbnode/storage/repair"
+	"github.com/m3db/m3/src/dbnode/storage/replication"
 	"github.com/m3db/m3/src/dbnode/storage/series"
+	"github.com/m3db/m3/src/dbnode/x/xarena"
 	"github.com/m3db/m3/src/dbnode/x/xcounter"
 	"github.com/m3db/m3/src/dbnode/x/xio"
+	"github.com/m3db/m3/src/x/loglevel"
+	"github.com/m3db/m3/src/x/resource"
 	"github.com/m3db/m3x/context"
 	"github.com/m3db/m3x/ident"
 	"github.com/m3db/m3x/instrument"
@@ -142,6 +148,13 @@ type options struct {
 	fetchBlockMetadataResultsPool  block.FetchBlockMetadataResultsPool
 	fetchBlocksMetadataResultsPool block.FetchBlocksMetadataResultsPool
 	queryIDsWorkerPool             xsync.WorkerPool
+	writeBatchWorkerPool           xsync.WorkerPool
+	seriesMetadataArenas           xarena.BytesArenas
+	backupManager                  *backup.Manager
+	replicator                     *replication.Replicator
+	diskQuotaTracker               *quota.Tracker
+	logLevels                      *loglevel.Registry
+	resourceReporter               *resource.Reporter
 }
 
 // NewOptions creates a new set of storage options with defaults
@@ -160,6 +173,10 @@ func newOptions(poolOpts pool.ObjectPoolOptions) Options {
 	queryIDsWorkerPool := xsync.NewWorkerPool(int(math.Ceil(float64(runtime.NumCPU()) / 2)))
 	queryIDsWorkerPool.Init()
 
+	// Default to using all of the available cores for batched writes
+	writeBatchWorkerPool := xsync.NewWorkerPool(runtime.NumCPU())
+	writeBatchWorkerPool.Init()
+
 	o := &options{
 		clockOpts:                clock.NewOptions(),
 		instrumentOpts:           instrument.NewOptions(),
@@ -195,6 +212,7 @@ func newOptions(poolOpts pool.ObjectPoolOptions) Options {
 		fetchBlockMetadataResultsPool:  block.NewFetchBlockMetadataResultsPool(poolOpts, 0),
 		fetchBlocksMetadataResultsPool: block.NewFetchBlocksMetadataResultsPool(poolOpts, 0),
 		queryIDsWorkerPool:             queryIDsWorkerPool,
+		writeBatchWorkerPool:           writeBatchWorkerPool,
 	}
 	return o.SetEncodingM3TSZPooled()
 }
@@ -622,3 +640,73 @@ func (o *options) SetQueryIDsWorkerPool(value xsync.WorkerPool) Options {
 func (o *options) QueryIDsWorkerPool() xsync.WorkerPool {
 	return o.queryIDsWorkerPool
 }
+
+func (o *options) SetWriteBatchWorkerPool(value xsync.WorkerPool) Options {
+	opts := *o
+	opts.writeBatchWorkerPool = value
+	return &opts
+}
+
+func (o *options) WriteBatchWorkerPool() xsync.WorkerPool {
+	return o.writeBatchWorkerPool
+}
+
+func (o *options) SetSeriesMetadataArenas(value xarena.BytesArenas) Options {
+	opts := *o
+	opts.seriesMetadataArenas = value
+	return &opts
+}
+
+func (o *options) SeriesMetadataArenas() xarena.BytesArenas {
+	return o.seriesMetadataArenas
+}
+
+func (o *options) SetBackupManager(value *backup.Manager) Options {
+	opts := *o
+	opts.backupManager = value
+	return &opts
+}
+
+func (o *options) BackupManager() *backup.Manager {
+	return o.backupManager
+}
+
+func (o *options) SetReplicator(value *replication.Replicator) Options {
+	opts := *o
+	opts.replicator = value
+	return &opts
+}
+
+func (o *options) Replicator() *replication.Replicator {
+	return o.replicator
+}
+
+func (o *options) SetDiskQuotaTracker(value *quota.Tracker) Options {
+	opts := *o
+	opts.diskQuotaTracker = value
+	return &opts
+}
+
+func (o *options) DiskQuotaTracker() *quota.Tracker {
+	return o.diskQuotaTracker
+}
+
+func (o *options) SetLogLevels(value *loglevel.Registry) Options {
+	opts := *o
+	opts.logLevels = value
+	return &opts
+}
+
+func (o *options) LogLevels() *loglevel.Registry {
+	return o.logLevels
+}
+
+func (o *options) SetResourceReporter(value *resource.Reporter) Options {
+	opts := *o
+	opts.resourceReporter = value
+	return &opts
+}
+
+func (o *options) ResourceReporter() *resource.Reporter {
+	return o.resourceReporter
+}
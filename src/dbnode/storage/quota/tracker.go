@@ -0,0 +1,338 @@
+// Copyright (c) 2018 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package quota
+
+import (
+	"errors"
+	"os"
+	"sort"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/m3db/m3/src/dbnode/persist/fs"
+	xerrors "github.com/m3db/m3x/errors"
+	"github.com/m3db/m3x/ident"
+	"github.com/m3db/m3x/instrument"
+
+	"github.com/uber-go/tally"
+)
+
+var (
+	errFilePathPrefixNotSet = errors.New("quota file path prefix is not set")
+)
+
+// TrackerOptions are the options for a quota Tracker.
+type TrackerOptions struct {
+	// FilePathPrefix is the file path prefix of the filesets to measure.
+	FilePathPrefix string
+	// Quotas are the quotas by namespace, namespaces without a quota are
+	// measured but never limited.
+	Quotas map[string]Quota
+	// InstrumentOptions are the instrument options.
+	InstrumentOptions instrument.Options
+	// NowFn is the function used to determine the current time.
+	NowFn func() time.Time
+}
+
+type trackerMetrics struct {
+	measureErrors  tally.Counter
+	measureLatency tally.Timer
+	writesRejected tally.Counter
+}
+
+func newTrackerMetrics(scope tally.Scope) trackerMetrics {
+	return trackerMetrics{
+		measureErrors:  scope.Counter("measure-errors"),
+		measureLatency: scope.Timer("measure-latency"),
+		writesRejected: scope.Counter("writes-rejected"),
+	}
+}
+
+type blockUsage struct {
+	blockStart time.Time
+	bytes      int64
+}
+
+type namespaceState struct {
+	usage NamespaceUsage
+	// blocks is the bytes of data filesets by block, sorted by block start.
+	blocks []blockUsage
+}
+
+// Tracker measures the bytes on disk of flushed data and index filesets per
+// namespace and shard and evaluates them against the namespace quotas. Usage
+// is measured after each flush, so a namespace stays limited from the
+// measurement that found it over its quota until one finds it back under.
+type Tracker struct {
+	sync.RWMutex
+
+	opts       TrackerOptions
+	nowFn      func() time.Time
+	scope      tally.Scope
+	metrics    trackerMetrics
+	quotas     map[string]Quota
+	namespaces map[string]*namespaceState
+	measuredAt time.Time
+}
+
+// NewTracker returns a new disk quota tracker.
+func NewTracker(opts TrackerOptions) (*Tracker, error) {
+	if opts.FilePathPrefix == "" {
+		return nil, errFilePathPrefixNotSet
+	}
+	if opts.InstrumentOptions == nil {
+		opts.InstrumentOptions = instrument.NewOptions()
+	}
+	nowFn := opts.NowFn
+	if nowFn == nil {
+		nowFn = time.Now
+	}
+	quotas := make(map[string]Quota, len(opts.Quotas))
+	for namespace, quota := range opts.Quotas {
+		quotas[namespace] = quota
+	}
+	scope := opts.InstrumentOptions.MetricsScope().SubScope("disk-quota")
+	return &Tracker{
+		opts:       opts,
+		nowFn:      nowFn,
+		scope:      scope,
+		metrics:    newTrackerMetrics(scope),
+		quotas:     quotas,
+		namespaces: make(map[string]*namespaceState),
+	}, nil
+}
+
+// Measure measures the disk usage of the given namespaces, replacing the
+// previous measurement. Errors measuring a shard or the index of a namespace
+// are returned once all namespaces have been measured.
+func (t *Tracker) Measure(namespaces []Namespace) error {
+	start := t.nowFn()
+	multiErr := xerrors.NewMultiError()
+	states := make(map[string]*namespaceState, len(namespaces))
+	for _, ns := range namespaces {
+		state, err := measureNamespace(t.opts.FilePathPrefix, ns)
+		if err != nil {
+			t.metrics.measureErrors.Inc(1)
+			multiErr = multiErr.Add(err)
+		}
+		states[state.usage.Namespace] = state
+	}
+
+	t.Lock()
+	for namespace, state := range states {
+		t.evaluateWithLock(state, t.quotas[namespace])
+	}
+	t.namespaces = states
+	t.measuredAt = start
+	t.Unlock()
+
+	t.metrics.measureLatency.Record(t.nowFn().Sub(start))
+	return multiErr.FinalError()
+}
+
+// MeasureNamespace measures the bytes on disk of the flushed data and index
+// filesets of a namespace without evaluating it against a quota.
+func MeasureNamespace(filePathPrefix string, ns Namespace) (NamespaceUsage, error) {
+	state, err := measureNamespace(filePathPrefix, ns)
+	return state.usage, err
+}
+
+func measureNamespace(prefix string, ns Namespace) (*namespaceState, error) {
+	var (
+		multiErr = xerrors.NewMultiError()
+		blocks   = make(map[int64]int64)
+		state    = &namespaceState{
+			usage: NamespaceUsage{
+				Namespace: ns.ID.String(),
+				Shards:    make([]ShardUsage, 0, len(ns.Shards)),
+			},
+		}
+	)
+	for _, shard := range ns.Shards {
+		filesets, err := fs.DataFiles(prefix, ns.ID, shard)
+		if err != nil {
+			multiErr = multiErr.Add(err)
+			continue
+		}
+		shardUsage := ShardUsage{Shard: shard}
+		state.usage.DataFilesets += len(filesets)
+		for _, fileset := range filesets {
+			bytes, err := filesSize(fileset.AbsoluteFilepaths)
+			if err != nil {
+				multiErr = multiErr.Add(err)
+			}
+			shardUsage.Bytes += bytes
+			blocks[fileset.ID.BlockStart.UnixNano()] += bytes
+		}
+		state.usage.Shards = append(state.usage.Shards, shardUsage)
+		state.usage.DataBytes += shardUsage.Bytes
+	}
+
+	if ns.IndexEnabled {
+		filesets, err := fs.IndexFiles(prefix, ns.ID)
+		if err != nil {
+			multiErr = multiErr.Add(err)
+		}
+		state.usage.IndexFilesets = len(filesets)
+		for _, fileset := range filesets {
+			bytes, err := filesSize(fileset.AbsoluteFilepaths)
+			if err != nil {
+				multiErr = multiErr.Add(err)
+			}
+			state.usage.IndexBytes += bytes
+		}
+	}
+
+	state.usage.Bytes = state.usage.DataBytes + state.usage.IndexBytes
+	state.blocks = make([]blockUsage, 0, len(blocks))
+	for blockStart, bytes := range blocks {
+		state.blocks = append(state.blocks, blockUsage{
+			blockStart: time.Unix(0, blockStart),
+			bytes:      bytes,
+		})
+	}
+	sort.Slice(state.blocks, func(i, j int) bool {
+		return state.blocks[i].blockStart.Before(state.blocks[j].blockStart)
+	})
+	return state, multiErr.FinalError()
+}
+
+func filesSize(filePaths []string) (int64, error) {
+	var size int64
+	for _, filePath := range filePaths {
+		info, err := os.Stat(filePath)
+		if os.IsNotExist(err) {
+			// Removed by a cleanup since the fileset was listed
+			continue
+		}
+		if err != nil {
+			return size, err
+		}
+		size += info.Size()
+	}
+	return size, nil
+}
+
+func (t *Tracker) evaluateWithLock(state *namespaceState, quota Quota) {
+	usage := &state.usage
+	usage.Quota = quota
+	usage.SoftLimitExceeded = quota.SoftLimitBytes > 0 &&
+		usage.Bytes > quota.SoftLimitBytes
+	usage.HardLimitExceeded = quota.HardLimitBytes > 0 &&
+		usage.Bytes > quota.HardLimitBytes
+	usage.ExpireBefore = time.Time{}
+	if usage.SoftLimitExceeded {
+		usage.ExpireBefore = expireBefore(state.blocks,
+			usage.Bytes-quota.SoftLimitBytes)
+	}
+
+	scope := t.scope.Tagged(map[string]string{"namespace": usage.Namespace})
+	scope.Gauge("bytes").Update(float64(usage.Bytes))
+	scope.Gauge("data-bytes").Update(float64(usage.DataBytes))
+	scope.Gauge("index-bytes").Update(float64(usage.IndexBytes))
+	scope.Gauge("soft-limit-exceeded").Update(boolGaugeValue(usage.SoftLimitExceeded))
+	scope.Gauge("hard-limit-exceeded").Update(boolGaugeValue(usage.HardLimitExceeded))
+	for _, shard := range usage.Shards {
+		scope.Tagged(map[string]string{
+			"shard": strconv.Itoa(int(shard.Shard)),
+		}).Gauge("shard-bytes").Update(float64(shard.Bytes))
+	}
+}
+
+// expireBefore returns the block start before which data filesets must be
+// expired to free at least excess bytes, the newest block is never expired
+// so the namespace always retains its most recently flushed data.
+func expireBefore(blocks []blockUsage, excess int64) time.Time {
+	var cutoff time.Time
+	for i := 0; i < len(blocks)-1 && excess > 0; i++ {
+		excess -= blocks[i].bytes
+		cutoff = blocks[i+1].blockStart
+	}
+	return cutoff
+}
+
+func boolGaugeValue(value bool) float64 {
+	if value {
+		return 1
+	}
+	return 0
+}
+
+// SetQuota sets the quota of a namespace, re-evaluating the last
+// measurement of the namespace against it. A zero quota removes any limits.
+func (t *Tracker) SetQuota(namespace ident.ID, quota Quota) {
+	name := namespace.String()
+	t.Lock()
+	t.quotas[name] = quota
+	if state, ok := t.namespaces[name]; ok {
+		t.evaluateWithLock(state, quota)
+	}
+	t.Unlock()
+}
+
+// CheckWrite returns ErrHardLimitExceeded if the namespace exceeded its hard
+// limit at the last measurement.
+func (t *Tracker) CheckWrite(namespace ident.ID) error {
+	t.RLock()
+	state, ok := t.namespaces[string(namespace.Bytes())]
+	exceeded := ok && state.usage.HardLimitExceeded
+	t.RUnlock()
+	if exceeded {
+		t.metrics.writesRejected.Inc(1)
+		return ErrHardLimitExceeded
+	}
+	return nil
+}
+
+// ExpireBefore returns the block start before which flushed data filesets of
+// the namespace should be expired to bring it back under its soft limit,
+// returns false if the namespace is within its soft limit.
+func (t *Tracker) ExpireBefore(namespace ident.ID) (time.Time, bool) {
+	t.RLock()
+	state, ok := t.namespaces[string(namespace.Bytes())]
+	var cutoff time.Time
+	if ok {
+		cutoff = state.usage.ExpireBefore
+	}
+	t.RUnlock()
+	return cutoff, !cutoff.IsZero()
+}
+
+// Usage returns the last measured disk usage, sorted by namespace.
+func (t *Tracker) Usage() Usage {
+	t.RLock()
+	usage := Usage{
+		MeasuredAt: t.measuredAt,
+		Namespaces: make([]NamespaceUsage, 0, len(t.namespaces)),
+	}
+	for _, state := range t.namespaces {
+		nsUsage := state.usage
+		nsUsage.Shards = append([]ShardUsage(nil), state.usage.Shards...)
+		usage.Namespaces = append(usage.Namespaces, nsUsage)
+	}
+	t.RUnlock()
+
+	sort.Slice(usage.Namespaces, func(i, j int) bool {
+		return usage.Namespaces[i].Namespace < usage.Namespaces[j].Namespace
+	})
+	return usage
+}
@@ -0,0 +1,186 @@
+// Copyright (c) 2018 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package quota
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/m3db/m3/src/dbnode/persist/fs"
+	"github.com/m3db/m3x/ident"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+var (
+	testNamespace  = ident.StringID("testns")
+	testBlockStart = time.Unix(0, 0).Add(2 * time.Hour)
+	testBlockSize  = 2 * time.Hour
+)
+
+func writeTestDataFileSet(
+	t *testing.T,
+	filePathPrefix string,
+	shard uint32,
+	blockStart time.Time,
+	size int,
+) {
+	dir := fs.ShardDataDirPath(filePathPrefix, testNamespace, shard)
+	require.NoError(t, os.MkdirAll(dir, 0755))
+	filePath := filepath.Join(dir,
+		fmt.Sprintf("fileset-%d-data.db", blockStart.UnixNano()))
+	require.NoError(t, ioutil.WriteFile(filePath, make([]byte, size), 0644))
+}
+
+func writeTestIndexFileSet(
+	t *testing.T,
+	filePathPrefix string,
+	blockStart time.Time,
+	size int,
+) {
+	dir := fs.NamespaceIndexDataDirPath(filePathPrefix, testNamespace)
+	require.NoError(t, os.MkdirAll(dir, 0755))
+	filePath := filepath.Join(dir,
+		fmt.Sprintf("fileset-%d-0-segment-0-docs.db", blockStart.UnixNano()))
+	require.NoError(t, ioutil.WriteFile(filePath, make([]byte, size), 0644))
+}
+
+func newTestTracker(t *testing.T, quota Quota) (*Tracker, string) {
+	filePathPrefix, err := ioutil.TempDir("", "quota-filesets")
+	require.NoError(t, err)
+
+	tracker, err := NewTracker(TrackerOptions{
+		FilePathPrefix: filePathPrefix,
+		Quotas:         map[string]Quota{testNamespace.String(): quota},
+	})
+	require.NoError(t, err)
+	return tracker, filePathPrefix
+}
+
+func TestTrackerMeasure(t *testing.T) {
+	tracker, filePathPrefix := newTestTracker(t, Quota{})
+	defer os.RemoveAll(filePathPrefix)
+
+	writeTestDataFileSet(t, filePathPrefix, 0, testBlockStart, 100)
+	writeTestDataFileSet(t, filePathPrefix, 0, testBlockStart.Add(testBlockSize), 50)
+	writeTestDataFileSet(t, filePathPrefix, 1, testBlockStart, 30)
+	writeTestIndexFileSet(t, filePathPrefix, testBlockStart, 20)
+
+	require.NoError(t, tracker.Measure([]Namespace{
+		{ID: testNamespace, Shards: []uint32{0, 1, 2}, IndexEnabled: true},
+	}))
+
+	usage := tracker.Usage()
+	require.Equal(t, 1, len(usage.Namespaces))
+	nsUsage := usage.Namespaces[0]
+	assert.Equal(t, testNamespace.String(), nsUsage.Namespace)
+	assert.Equal(t, int64(200), nsUsage.Bytes)
+	assert.Equal(t, int64(180), nsUsage.DataBytes)
+	assert.Equal(t, int64(20), nsUsage.IndexBytes)
+	assert.Equal(t, 3, nsUsage.DataFilesets)
+	assert.Equal(t, 1, nsUsage.IndexFilesets)
+	assert.Equal(t, []ShardUsage{
+		{Shard: 0, Bytes: 150},
+		{Shard: 1, Bytes: 30},
+		{Shard: 2, Bytes: 0},
+	}, nsUsage.Shards)
+	assert.False(t, nsUsage.SoftLimitExceeded)
+	assert.False(t, nsUsage.HardLimitExceeded)
+
+	_, ok := tracker.ExpireBefore(testNamespace)
+	assert.False(t, ok)
+	assert.NoError(t, tracker.CheckWrite(testNamespace))
+}
+
+func TestMeasureNamespace(t *testing.T) {
+	filePathPrefix, err := ioutil.TempDir("", "quota-filesets")
+	require.NoError(t, err)
+	defer os.RemoveAll(filePathPrefix)
+
+	writeTestDataFileSet(t, filePathPrefix, 0, testBlockStart, 100)
+	writeTestDataFileSet(t, filePathPrefix, 1, testBlockStart, 30)
+	writeTestIndexFileSet(t, filePathPrefix, testBlockStart, 20)
+
+	// The index is not measured if it is not enabled.
+	usage, err := MeasureNamespace(filePathPrefix, Namespace{
+		ID:     testNamespace,
+		Shards: []uint32{0, 1},
+	})
+	require.NoError(t, err)
+	assert.Equal(t, testNamespace.String(), usage.Namespace)
+	assert.Equal(t, int64(130), usage.Bytes)
+	assert.Equal(t, 2, usage.DataFilesets)
+	assert.Equal(t, 0, usage.IndexFilesets)
+	assert.Equal(t, Quota{}, usage.Quota)
+}
+
+func TestTrackerSoftLimitExpiresOldestBlocks(t *testing.T) {
+	tracker, filePathPrefix := newTestTracker(t, Quota{SoftLimitBytes: 120})
+	defer os.RemoveAll(filePathPrefix)
+
+	for i := 0; i < 3; i++ {
+		blockStart := testBlockStart.Add(time.Duration(i) * testBlockSize)
+		writeTestDataFileSet(t, filePathPrefix, 0, blockStart, 50)
+		writeTestDataFileSet(t, filePathPrefix, 1, blockStart, 50)
+	}
+
+	namespaces := []Namespace{{ID: testNamespace, Shards: []uint32{0, 1}}}
+	require.NoError(t, tracker.Measure(namespaces))
+
+	// Expiring the oldest block only leaves 200 bytes, above the soft
+	// limit, so the two oldest blocks are expired
+	expireBefore, ok := tracker.ExpireBefore(testNamespace)
+	require.True(t, ok)
+	assert.True(t, expireBefore.Equal(testBlockStart.Add(2*testBlockSize)))
+	assert.NoError(t, tracker.CheckWrite(testNamespace))
+
+	// The newest block is never expired
+	tracker.SetQuota(testNamespace, Quota{SoftLimitBytes: 1})
+	expireBefore, ok = tracker.ExpireBefore(testNamespace)
+	require.True(t, ok)
+	assert.True(t, expireBefore.Equal(testBlockStart.Add(2*testBlockSize)))
+
+	tracker.SetQuota(testNamespace, Quota{})
+	_, ok = tracker.ExpireBefore(testNamespace)
+	assert.False(t, ok)
+}
+
+func TestTrackerHardLimitRejectsWrites(t *testing.T) {
+	tracker, filePathPrefix := newTestTracker(t, Quota{HardLimitBytes: 100})
+	defer os.RemoveAll(filePathPrefix)
+
+	writeTestDataFileSet(t, filePathPrefix, 0, testBlockStart, 150)
+
+	namespaces := []Namespace{{ID: testNamespace, Shards: []uint32{0}}}
+	require.NoError(t, tracker.Measure(namespaces))
+	assert.Equal(t, ErrHardLimitExceeded, tracker.CheckWrite(testNamespace))
+	assert.NoError(t, tracker.CheckWrite(ident.StringID("otherns")))
+
+	// Writes are allowed again once usage is measured under the limit
+	require.NoError(t, os.RemoveAll(fs.DataDirPath(filePathPrefix)))
+	require.NoError(t, tracker.Measure(namespaces))
+	assert.NoError(t, tracker.CheckWrite(testNamespace))
+}
@@ -0,0 +1,83 @@
+// Copyright (c) 2018 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+// Package quota tracks the bytes on disk of each namespace and shard and
+// enforces soft and hard disk quotas per namespace.
+package quota
+
+import (
+	"errors"
+	"time"
+
+	"github.com/m3db/m3x/ident"
+)
+
+var (
+	// ErrHardLimitExceeded is returned when writing to a namespace whose
+	// disk usage exceeds its hard quota.
+	ErrHardLimitExceeded = errors.New("namespace disk quota exceeded")
+)
+
+// Quota is the disk quota of a namespace, a zero limit is disabled.
+type Quota struct {
+	// SoftLimitBytes is the disk usage above which the oldest flushed blocks
+	// of the namespace are expired before the end of their retention.
+	SoftLimitBytes int64 `json:"softLimitBytes"`
+	// HardLimitBytes is the disk usage above which writes to the namespace
+	// are rejected.
+	HardLimitBytes int64 `json:"hardLimitBytes"`
+}
+
+// Namespace is a namespace and the shards of it owned by the node to measure.
+type Namespace struct {
+	ID           ident.ID
+	Shards       []uint32
+	IndexEnabled bool
+}
+
+// ShardUsage is the bytes on disk of the flushed data filesets of a shard.
+type ShardUsage struct {
+	Shard uint32 `json:"shard"`
+	Bytes int64  `json:"bytes"`
+}
+
+// NamespaceUsage is the bytes on disk of a namespace and its quota.
+type NamespaceUsage struct {
+	Namespace         string       `json:"namespace"`
+	Bytes             int64        `json:"bytes"`
+	DataBytes         int64        `json:"dataBytes"`
+	IndexBytes        int64        `json:"indexBytes"`
+	DataFilesets      int          `json:"dataFilesets"`
+	IndexFilesets     int          `json:"indexFilesets"`
+	Shards            []ShardUsage `json:"shards"`
+	Quota             Quota        `json:"quota"`
+	SoftLimitExceeded bool         `json:"softLimitExceeded"`
+	HardLimitExceeded bool         `json:"hardLimitExceeded"`
+	// ExpireBefore is the block start before which flushed data filesets
+	// are expired early to bring the namespace back under its soft limit,
+	// zero if none are.
+	ExpireBefore time.Time `json:"expireBefore"`
+}
+
+// Usage is the disk usage of all measured namespaces.
+type Usage struct {
+	MeasuredAt time.Time        `json:"measuredAt"`
+	Namespaces []NamespaceUsage `json:"namespaces"`
+}
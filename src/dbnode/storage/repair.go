@@ -35,6 +35,7 @@ import (
 	"github.com/m3db/m3/src/dbnode/storage/block"
 	"github.com/m3db/m3/src/dbnode/storage/bootstrap/result"
 	"github.com/m3db/m3/src/dbnode/storage/repair"
+	"github.com/m3db/m3/src/dbnode/storage/repair/hashtree"
 	"github.com/m3db/m3x/context"
 	xerrors "github.com/m3db/m3x/errors"
 	"github.com/m3db/m3x/ident"
@@ -114,30 +115,115 @@ func (r shardRepairer) Repair(
 	}
 	ctx.RegisterCloser(localMetadata)
 
+	// If hash trees are enabled only the series in the buckets that differ
+	// between the local host and its peers are compared
+	buckets := r.divergentBuckets(session, namespace, shard, start, end, localMetadata)
+
 	localIter := block.NewFilteredBlocksMetadataIter(localMetadata)
+	if buckets != nil {
+		localIter = newBucketFilteredBlocksMetadataIter(localIter, *buckets)
+	}
 	err = metadata.AddLocalMetadata(origin, localIter)
 	if err != nil {
 		return repair.MetadataComparisonResult{}, err
 	}
 
 	// Add peer metadata
-	level := r.rpopts.RepairConsistencyLevel()
-	peerIter, err := session.FetchBlocksMetadataFromPeers(namespace, shard.ID(), start, end,
-		level, result.NewOptions(), client.FetchBlocksMetadataEndpointV2)
+	var (
+		level    = r.rpopts.RepairConsistencyLevel()
+		peerIter client.PeerBlockMetadataIter
+	)
+	if buckets == nil {
+		peerIter, err = session.FetchBlocksMetadataFromPeers(namespace, shard.ID(), start, end,
+			level, result.NewOptions(), client.FetchBlocksMetadataEndpointV2)
+	} else if buckets.Len() > 0 {
+		peerIter, err = session.FetchBlocksMetadataFromPeersInBuckets(namespace, shard.ID(),
+			start, end, level, result.NewOptions(), *buckets)
+	}
 	if err != nil {
 		return repair.MetadataComparisonResult{}, err
 	}
-	if err := metadata.AddPeerMetadata(peerIter); err != nil {
-		return repair.MetadataComparisonResult{}, err
+	if peerIter != nil {
+		if err := metadata.AddPeerMetadata(peerIter); err != nil {
+			return repair.MetadataComparisonResult{}, err
+		}
 	}
 
 	metadataRes := metadata.Compare()
+	if buckets != nil {
+		// Only the divergent series were compared, report the totals of the
+		// local host instead
+		metadataRes.NumSeries, metadataRes.NumBlocks = blocksMetadataTotals(localMetadata)
+	}
 
 	r.recordFn(namespace, shard, metadataRes)
 
 	return metadataRes, nil
 }
 
+// divergentBuckets returns the hash tree buckets whose contents differ
+// between the local host and any of its peers, it returns nil if hash
+// trees are disabled or could not be compared with every peer in which
+// case the metadata of all series should be compared.
+func (r shardRepairer) divergentBuckets(
+	session client.AdminSession,
+	namespace ident.ID,
+	shard databaseShard,
+	start, end time.Time,
+	localMetadata block.FetchBlocksMetadataResults,
+) *hashtree.BucketFilter {
+	depth := r.rpopts.RepairHashTreeDepth()
+	if depth <= 0 {
+		return nil
+	}
+
+	logger := r.logger.WithFields(
+		xlog.NewField("namespace", namespace.String()),
+		xlog.NewField("shard", shard.ID()),
+	)
+	fallback := func(err error) *hashtree.BucketFilter {
+		r.scope.Counter("hash-tree-fallback").Inc(1)
+		logger.WithFields(xlog.NewField("error", err.Error())).
+			Warn("could not compare hash trees, comparing metadata of all series")
+		return nil
+	}
+
+	localTrees, err := hashtree.NewBlockTrees(depth)
+	if err != nil {
+		return fallback(err)
+	}
+	localTrees.AddBlocksMetadata(localMetadata)
+
+	peerTrees, err := session.FetchBlocksHashTreesFromPeers(namespace, shard.ID(),
+		start, end, depth)
+	if err != nil {
+		return fallback(err)
+	}
+
+	divergent := make(map[int]struct{})
+	for _, peer := range peerTrees {
+		if peer.Err != nil {
+			return fallback(fmt.Errorf("peer %s: %v", peer.Host.ID(), peer.Err))
+		}
+		buckets, err := hashtree.DiffBuckets(localTrees, peer.Trees)
+		if err != nil {
+			return fallback(err)
+		}
+		for _, bucket := range buckets {
+			divergent[bucket] = struct{}{}
+		}
+	}
+
+	buckets := make([]int, 0, len(divergent))
+	for bucket := range divergent {
+		buckets = append(buckets, bucket)
+	}
+	r.scope.Counter("hash-tree-divergent-buckets").Inc(int64(len(buckets)))
+
+	filter := hashtree.NewBucketFilter(depth, buckets)
+	return &filter
+}
+
 func (r shardRepairer) recordDifferences(
 	namespace ident.ID,
 	shard databaseShard,
@@ -166,6 +252,50 @@ func (r shardRepairer) recordDifferences(
 	checksumDiffScope.Counter("blocks").Inc(diffRes.ChecksumDifferences.NumBlocks())
 }
 
+// bucketFilteredBlocksMetadataIter only returns the blocks metadata of
+// series that belong to a set of hash tree buckets.
+type bucketFilteredBlocksMetadataIter struct {
+	block.FilteredBlocksMetadataIter
+	buckets hashtree.BucketFilter
+}
+
+func newBucketFilteredBlocksMetadataIter(
+	iter block.FilteredBlocksMetadataIter,
+	buckets hashtree.BucketFilter,
+) block.FilteredBlocksMetadataIter {
+	return bucketFilteredBlocksMetadataIter{
+		FilteredBlocksMetadataIter: iter,
+		buckets:                    buckets,
+	}
+}
+
+func (it bucketFilteredBlocksMetadataIter) Next() bool {
+	for it.FilteredBlocksMetadataIter.Next() {
+		id, _ := it.Current()
+		if it.buckets.Contains(id.Bytes()) {
+			return true
+		}
+	}
+	return false
+}
+
+func blocksMetadataTotals(results block.FetchBlocksMetadataResults) (int64, int64) {
+	var numSeries, numBlocks int64
+	for _, result := range results.Results() {
+		var seriesBlocks int64
+		for _, blockResult := range result.Blocks.Results() {
+			if blockResult.Err == nil {
+				seriesBlocks++
+			}
+		}
+		if seriesBlocks > 0 {
+			numSeries++
+			numBlocks += seriesBlocks
+		}
+	}
+	return numSeries, numBlocks
+}
+
 type repairFn func() error
 
 type sleepFn func(d time.Duration)
@@ -0,0 +1,153 @@
+// Copyright (c) 2018 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package hashtree
+
+import (
+	"sort"
+	"time"
+
+	"github.com/m3db/m3/src/dbnode/storage/block"
+	xtime "github.com/m3db/m3x/time"
+)
+
+// BlockTrees is a set of hash trees of the same depth keyed by block start.
+type BlockTrees struct {
+	depth int
+	trees map[xtime.UnixNano]*Tree
+}
+
+// NewBlockTrees returns a new empty set of hash trees of the given depth.
+func NewBlockTrees(depth int) (*BlockTrees, error) {
+	if err := validateDepth(depth); err != nil {
+		return nil, err
+	}
+	return &BlockTrees{
+		depth: depth,
+		trees: make(map[xtime.UnixNano]*Tree),
+	}, nil
+}
+
+// Depth returns the depth of the trees.
+func (b *BlockTrees) Depth() int {
+	return b.depth
+}
+
+// AddBlocksMetadata adds the size and checksum of each block in the
+// results to the tree of the block start, blocks that failed to return
+// metadata are skipped.
+func (b *BlockTrees) AddBlocksMetadata(results block.FetchBlocksMetadataResults) {
+	for _, result := range results.Results() {
+		id := result.ID.Bytes()
+		for _, blockResult := range result.Blocks.Results() {
+			if blockResult.Err != nil {
+				continue
+			}
+			var checksum uint32
+			if blockResult.Checksum != nil {
+				checksum = *blockResult.Checksum
+			}
+			b.GetOrAdd(blockResult.Start).Add(id, blockResult.Size, checksum)
+		}
+	}
+}
+
+// GetOrAdd returns the tree for a block start, creating an empty one if
+// it doesn't exist.
+func (b *BlockTrees) GetOrAdd(start time.Time) *Tree {
+	key := xtime.ToUnixNano(start)
+	tree, ok := b.trees[key]
+	if !ok {
+		// Depth has already been validated.
+		tree, _ = NewTree(b.depth)
+		b.trees[key] = tree
+	}
+	return tree
+}
+
+// Set sets the tree for a block start.
+func (b *BlockTrees) Set(start time.Time, tree *Tree) error {
+	if tree.depth != b.depth {
+		return errDepthMismatch
+	}
+	b.trees[xtime.ToUnixNano(start)] = tree
+	return nil
+}
+
+// Tree returns the tree for a block start if it exists.
+func (b *BlockTrees) Tree(start time.Time) (*Tree, bool) {
+	tree, ok := b.trees[xtime.ToUnixNano(start)]
+	return tree, ok
+}
+
+// Starts returns the block starts of the trees in ascending order.
+func (b *BlockTrees) Starts() []time.Time {
+	starts := make([]time.Time, 0, len(b.trees))
+	for key := range b.trees {
+		starts = append(starts, key.ToTime())
+	}
+	sort.Slice(starts, func(i, j int) bool {
+		return starts[i].Before(starts[j])
+	})
+	return starts
+}
+
+// DiffBuckets returns the union of the buckets whose contents differ
+// between the trees of the two sets, a block start with a tree in only
+// one of the sets is compared against an empty tree.
+func DiffBuckets(a, b *BlockTrees) ([]int, error) {
+	if a.depth != b.depth {
+		return nil, errDepthMismatch
+	}
+	empty, _ := NewTree(a.depth)
+	starts := make(map[xtime.UnixNano]struct{}, len(a.trees))
+	for key := range a.trees {
+		starts[key] = struct{}{}
+	}
+	for key := range b.trees {
+		starts[key] = struct{}{}
+	}
+
+	union := make(map[int]struct{})
+	for key := range starts {
+		treeA, ok := a.trees[key]
+		if !ok {
+			treeA = empty
+		}
+		treeB, ok := b.trees[key]
+		if !ok {
+			treeB = empty
+		}
+		buckets, err := Diff(treeA, treeB)
+		if err != nil {
+			return nil, err
+		}
+		for _, bucket := range buckets {
+			union[bucket] = struct{}{}
+		}
+	}
+
+	buckets := make([]int, 0, len(union))
+	for bucket := range union {
+		buckets = append(buckets, bucket)
+	}
+	sort.Ints(buckets)
+	return buckets, nil
+}
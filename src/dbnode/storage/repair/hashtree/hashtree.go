@@ -0,0 +1,251 @@
+// Copyright (c) 2018 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+// Package hashtree provides hierarchical hash trees summarizing the series
+// metadata of a single block, used to find the divergent ranges of series
+// between replicas without exchanging the metadata of every series.
+package hashtree
+
+import (
+	"encoding/binary"
+	"errors"
+	"fmt"
+
+	"github.com/spaolacci/murmur3"
+)
+
+const (
+	// MaxDepth is the maximum depth of a hash tree, a tree of max depth
+	// has 2^MaxDepth leaf buckets.
+	MaxDepth = 16
+)
+
+var (
+	errDepthMismatch = errors.New("hash trees have different depths")
+)
+
+// Tree is a complete binary hash tree over the series of a single block.
+// Series are assigned to leaf buckets by the hash of their ID, each leaf
+// summarizes the size and checksum of the series in its bucket and each
+// internal node is the hash of its two children.
+type Tree struct {
+	depth  int
+	nodes  []uint64
+	leaves int
+	dirty  bool
+}
+
+// NewTree returns a new empty hash tree of the given depth.
+func NewTree(depth int) (*Tree, error) {
+	if err := validateDepth(depth); err != nil {
+		return nil, err
+	}
+	return &Tree{
+		depth:  depth,
+		nodes:  make([]uint64, numNodes(depth)),
+		leaves: numBuckets(depth),
+		dirty:  true,
+	}, nil
+}
+
+// NewTreeFromNodes returns a hash tree of the given depth from the level
+// ordered nodes of a tree previously returned by Nodes.
+func NewTreeFromNodes(depth int, nodes []int64) (*Tree, error) {
+	if err := validateDepth(depth); err != nil {
+		return nil, err
+	}
+	if len(nodes) != numNodes(depth) {
+		return nil, fmt.Errorf("hash tree of depth %d expects %d nodes, got %d",
+			depth, numNodes(depth), len(nodes))
+	}
+	t := &Tree{
+		depth:  depth,
+		nodes:  make([]uint64, len(nodes)),
+		leaves: numBuckets(depth),
+	}
+	for i, v := range nodes {
+		t.nodes[i] = uint64(v)
+	}
+	return t, nil
+}
+
+// Depth returns the depth of the tree.
+func (t *Tree) Depth() int {
+	return t.depth
+}
+
+// NumBuckets returns the number of leaf buckets of the tree.
+func (t *Tree) NumBuckets() int {
+	return t.leaves
+}
+
+// Add adds the metadata of a series to the tree, the order in which
+// series are added does not affect the resulting tree.
+func (t *Tree) Add(id []byte, size int64, checksum uint32) {
+	h := murmur3.Sum64(id)
+	leaf := t.leafIndex(bucket(h, t.depth))
+	t.nodes[leaf] += mix(h ^ mix(uint64(size)<<32|uint64(checksum)))
+	t.dirty = true
+}
+
+// Root returns the root hash of the tree.
+func (t *Tree) Root() int64 {
+	t.build()
+	return int64(t.nodes[0])
+}
+
+// Nodes returns the level ordered nodes of the tree, suitable for sending
+// over the wire and rebuilding the tree with NewTreeFromNodes.
+func (t *Tree) Nodes() []int64 {
+	t.build()
+	nodes := make([]int64, len(t.nodes))
+	for i, v := range t.nodes {
+		nodes[i] = int64(v)
+	}
+	return nodes
+}
+
+// Diff returns the leaf buckets whose contents differ between two trees,
+// only descending into subtrees whose hashes differ.
+func Diff(a, b *Tree) ([]int, error) {
+	if a.depth != b.depth {
+		return nil, errDepthMismatch
+	}
+	a.build()
+	b.build()
+
+	var (
+		firstLeaf = len(a.nodes) - a.leaves
+		buckets   []int
+		stack     = []int{0}
+	)
+	for len(stack) > 0 {
+		idx := stack[len(stack)-1]
+		stack = stack[:len(stack)-1]
+		if a.nodes[idx] == b.nodes[idx] {
+			continue
+		}
+		if idx >= firstLeaf {
+			buckets = append(buckets, idx-firstLeaf)
+			continue
+		}
+		// Push right first so buckets are returned in ascending order.
+		stack = append(stack, 2*idx+2, 2*idx+1)
+	}
+	return buckets, nil
+}
+
+// Bucket returns the leaf bucket a series ID belongs to in a tree of the
+// given depth.
+func Bucket(id []byte, depth int) int {
+	return bucket(murmur3.Sum64(id), depth)
+}
+
+// BucketFilter matches series IDs that belong to a set of buckets.
+type BucketFilter struct {
+	depth   int
+	buckets map[int]struct{}
+}
+
+// NewBucketFilter returns a filter matching series IDs that belong to any
+// of the given buckets of a tree of the given depth.
+func NewBucketFilter(depth int, buckets []int) BucketFilter {
+	set := make(map[int]struct{}, len(buckets))
+	for _, b := range buckets {
+		set[b] = struct{}{}
+	}
+	return BucketFilter{depth: depth, buckets: set}
+}
+
+// Depth returns the depth of the tree the buckets belong to.
+func (f BucketFilter) Depth() int {
+	return f.depth
+}
+
+// Buckets returns the buckets matched by the filter.
+func (f BucketFilter) Buckets() []int {
+	buckets := make([]int, 0, len(f.buckets))
+	for b := range f.buckets {
+		buckets = append(buckets, b)
+	}
+	return buckets
+}
+
+// Len returns the number of buckets matched by the filter.
+func (f BucketFilter) Len() int {
+	return len(f.buckets)
+}
+
+// Contains returns whether the series ID belongs to one of the buckets.
+func (f BucketFilter) Contains(id []byte) bool {
+	_, ok := f.buckets[Bucket(id, f.depth)]
+	return ok
+}
+
+func (t *Tree) build() {
+	if !t.dirty {
+		return
+	}
+	var buf [16]byte
+	for i := len(t.nodes) - t.leaves - 1; i >= 0; i-- {
+		binary.LittleEndian.PutUint64(buf[:8], t.nodes[2*i+1])
+		binary.LittleEndian.PutUint64(buf[8:], t.nodes[2*i+2])
+		t.nodes[i] = murmur3.Sum64(buf[:])
+	}
+	t.dirty = false
+}
+
+func (t *Tree) leafIndex(bucket int) int {
+	return len(t.nodes) - t.leaves + bucket
+}
+
+func bucket(h uint64, depth int) int {
+	if depth == 0 {
+		return 0
+	}
+	return int(h >> uint(64-depth))
+}
+
+// mix is the splitmix64 finalizer, used to spread the bits of the series
+// metadata before summing them into a leaf.
+func mix(v uint64) uint64 {
+	v ^= v >> 30
+	v *= 0xbf58476d1ce4e5b9
+	v ^= v >> 27
+	v *= 0x94d049bb133111eb
+	v ^= v >> 31
+	return v
+}
+
+func numBuckets(depth int) int {
+	return 1 << uint(depth)
+}
+
+func numNodes(depth int) int {
+	return 2*numBuckets(depth) - 1
+}
+
+func validateDepth(depth int) error {
+	if depth < 0 || depth > MaxDepth {
+		return fmt.Errorf("hash tree depth must be between 0 and %d, got %d",
+			MaxDepth, depth)
+	}
+	return nil
+}
@@ -0,0 +1,131 @@
+// Copyright (c) 2018 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package hashtree
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type testSeries struct {
+	id       string
+	size     int64
+	checksum uint32
+}
+
+func testSeriesRange(n int) []testSeries {
+	series := make([]testSeries, 0, n)
+	for i := 0; i < n; i++ {
+		series = append(series, testSeries{
+			id:       fmt.Sprintf("series.%d", i),
+			size:     int64(i + 1),
+			checksum: uint32(i * 7),
+		})
+	}
+	return series
+}
+
+func newTestTree(t *testing.T, depth int, series []testSeries) *Tree {
+	tree, err := NewTree(depth)
+	require.NoError(t, err)
+	for _, s := range series {
+		tree.Add([]byte(s.id), s.size, s.checksum)
+	}
+	return tree
+}
+
+func TestTreeOrderIndependent(t *testing.T) {
+	series := testSeriesRange(100)
+	reversed := make([]testSeries, len(series))
+	for i := range series {
+		reversed[len(series)-1-i] = series[i]
+	}
+
+	a := newTestTree(t, 4, series)
+	b := newTestTree(t, 4, reversed)
+	assert.Equal(t, a.Root(), b.Root())
+	assert.Equal(t, a.Nodes(), b.Nodes())
+
+	buckets, err := Diff(a, b)
+	require.NoError(t, err)
+	assert.Empty(t, buckets)
+}
+
+func TestTreeDiffFindsDivergentBuckets(t *testing.T) {
+	depth := 6
+	series := testSeriesRange(1000)
+	local := newTestTree(t, depth, series)
+
+	// Change the checksum of one series and drop another.
+	modified := append([]testSeries(nil), series...)
+	modified[10].checksum++
+	modified = append(modified[:20], modified[21:]...)
+	peer := newTestTree(t, depth, modified)
+	require.NotEqual(t, local.Root(), peer.Root())
+
+	buckets, err := Diff(local, peer)
+	require.NoError(t, err)
+
+	expected := []int{
+		Bucket([]byte(series[10].id), depth),
+		Bucket([]byte(series[20].id), depth),
+	}
+	if expected[0] == expected[1] {
+		expected = expected[:1]
+	} else if expected[0] > expected[1] {
+		expected[0], expected[1] = expected[1], expected[0]
+	}
+	assert.Equal(t, expected, buckets)
+
+	filter := NewBucketFilter(depth, buckets)
+	assert.True(t, filter.Contains([]byte(series[10].id)))
+	assert.True(t, filter.Contains([]byte(series[20].id)))
+}
+
+func TestTreeFromNodesRoundTrip(t *testing.T) {
+	tree := newTestTree(t, 3, testSeriesRange(20))
+
+	decoded, err := NewTreeFromNodes(3, tree.Nodes())
+	require.NoError(t, err)
+	assert.Equal(t, tree.Root(), decoded.Root())
+
+	buckets, err := Diff(tree, decoded)
+	require.NoError(t, err)
+	assert.Empty(t, buckets)
+
+	_, err = NewTreeFromNodes(4, tree.Nodes())
+	require.Error(t, err)
+}
+
+func TestTreeInvalidDepth(t *testing.T) {
+	_, err := NewTree(-1)
+	require.Error(t, err)
+	_, err = NewTree(MaxDepth + 1)
+	require.Error(t, err)
+
+	a := newTestTree(t, 2, nil)
+	b := newTestTree(t, 3, nil)
+	_, err = Diff(a, b)
+	require.Equal(t, errDepthMismatch, err)
+}
@@ -25,6 +25,7 @@ import (
 	"time"
 
 	"github.com/m3db/m3/src/dbnode/client"
+	"github.com/m3db/m3/src/dbnode/storage/repair/hashtree"
 	"github.com/m3db/m3/src/dbnode/topology"
 )
 
@@ -37,6 +38,7 @@ const (
 	defaultRepairThrottle         = 90 * time.Second
 	defaultRepairMaxRetries       = 3
 	defaultRepairShardConcurrency = 1
+	defaultRepairHashTreeDepth    = 10
 )
 
 var (
@@ -49,6 +51,7 @@ var (
 	errRepairCheckIntervalTooBig    = errors.New("repair check interval too big in repair options")
 	errInvalidRepairThrottle        = errors.New("invalid repair throttle in repair options")
 	errInvalidRepairMaxRetries      = errors.New("invalid repair max retries in repair options")
+	errInvalidRepairHashTreeDepth   = errors.New("invalid repair hash tree depth in repair options")
 	errNoHostBlockMetadataSlicePool = errors.New("no host block metadata pool in repair options")
 )
 
@@ -62,6 +65,7 @@ type options struct {
 	repairCheckInterval        time.Duration
 	repairThrottle             time.Duration
 	repairMaxRetries           int
+	repairHashTreeDepth        int
 	hostBlockMetadataSlicePool HostBlockMetadataSlicePool
 }
 
@@ -76,6 +80,7 @@ func NewOptions() Options {
 		repairCheckInterval:        defaultRepairCheckInterval,
 		repairThrottle:             defaultRepairThrottle,
 		repairMaxRetries:           defaultRepairMaxRetries,
+		repairHashTreeDepth:        defaultRepairHashTreeDepth,
 		hostBlockMetadataSlicePool: NewHostBlockMetadataSlicePool(nil, 0),
 	}
 }
@@ -170,6 +175,16 @@ func (o *options) RepairMaxRetries() int {
 	return o.repairMaxRetries
 }
 
+func (o *options) SetRepairHashTreeDepth(value int) Options {
+	opts := *o
+	opts.repairHashTreeDepth = value
+	return &opts
+}
+
+func (o *options) RepairHashTreeDepth() int {
+	return o.repairHashTreeDepth
+}
+
 func (o *options) SetHostBlockMetadataSlicePool(value HostBlockMetadataSlicePool) Options {
 	opts := *o
 	opts.hostBlockMetadataSlicePool = value
@@ -208,6 +223,9 @@ func (o *options) Validate() error {
 	if o.repairMaxRetries < 0 {
 		return errInvalidRepairMaxRetries
 	}
+	if o.repairHashTreeDepth < 0 || o.repairHashTreeDepth > hashtree.MaxDepth {
+		return errInvalidRepairHashTreeDepth
+	}
 	if o.hostBlockMetadataSlicePool == nil {
 		return errNoHostBlockMetadataSlicePool
 	}
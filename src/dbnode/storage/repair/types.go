@@ -206,6 +206,15 @@ type Options interface {
 	// MaxRepairRetries returns the max number of retries for a block start
 	RepairMaxRetries() int
 
+	// SetRepairHashTreeDepth sets the depth of the hash trees used to find
+	// the series that differ between replicas, zero disables hash trees and
+	// compares the metadata of every series
+	SetRepairHashTreeDepth(value int) Options
+
+	// RepairHashTreeDepth returns the depth of the hash trees used to find
+	// the series that differ between replicas
+	RepairHashTreeDepth() int
+
 	// SetHostBlockMetadataSlicePool sets the hostBlockMetadataSlice pool
 	SetHostBlockMetadataSlicePool(value HostBlockMetadataSlicePool) Options
 
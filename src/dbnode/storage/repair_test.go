@@ -22,6 +22,7 @@ package storage
 
 import (
 	"errors"
+	"fmt"
 	"sync"
 	"testing"
 	"time"
@@ -31,6 +32,7 @@ import (
 	"github.com/m3db/m3/src/dbnode/storage/block"
 	"github.com/m3db/m3/src/dbnode/storage/namespace"
 	"github.com/m3db/m3/src/dbnode/storage/repair"
+	"github.com/m3db/m3/src/dbnode/storage/repair/hashtree"
 	"github.com/m3db/m3/src/dbnode/topology"
 	"github.com/m3db/m3x/context"
 	"github.com/m3db/m3x/ident"
@@ -219,7 +221,9 @@ func TestDatabaseShardRepairerRepair(t *testing.T) {
 	mockClient := client.NewMockAdminClient(ctrl)
 	mockClient.EXPECT().DefaultAdminSession().Return(session, nil)
 
-	rpOpts := testRepairOptions(ctrl).SetAdminClient(mockClient)
+	rpOpts := testRepairOptions(ctrl).
+		SetAdminClient(mockClient).
+		SetRepairHashTreeDepth(0)
 
 	now := time.Now()
 	nowFn := func() time.Time { return now }
@@ -332,6 +336,175 @@ func TestDatabaseShardRepairerRepair(t *testing.T) {
 	require.Equal(t, expected, block.Metadata())
 }
 
+func TestDatabaseShardRepairerRepairHashTrees(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	var (
+		depth    = 4
+		now      = time.Now()
+		peerHost = topology.NewHost("1", "addr1")
+		fooID    = ident.StringID("foo")
+		barID    = ident.StringID("bar")
+	)
+	// Make sure the series fall into different buckets
+	for i := 0; hashtree.Bucket(barID.Bytes(), depth) == hashtree.Bucket(fooID.Bytes(), depth); i++ {
+		barID = ident.StringID(fmt.Sprintf("bar.%d", i))
+	}
+
+	tests := []struct {
+		name            string
+		peerFooSize     int64
+		expectBuckets   []int
+		expectSizeDiffs int
+	}{
+		{name: "identical", peerFooSize: 2},
+		{
+			name:            "divergent",
+			peerFooSize:     3,
+			expectBuckets:   []int{hashtree.Bucket(fooID.Bytes(), depth)},
+			expectSizeDiffs: 1,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			session := client.NewMockAdminSession(ctrl)
+			session.EXPECT().Origin().Return(topology.NewHost("0", "addr0"))
+			session.EXPECT().Replicas().Return(2)
+
+			mockClient := client.NewMockAdminClient(ctrl)
+			mockClient.EXPECT().DefaultAdminSession().Return(session, nil)
+
+			rpOpts := testRepairOptions(ctrl).
+				SetAdminClient(mockClient).
+				SetRepairHashTreeDepth(depth)
+			opts := testDatabaseOptions().
+				SetInstrumentOptions(testDatabaseOptions().InstrumentOptions().
+					SetMetricsScope(tally.NoopScope))
+
+			var (
+				namespace = ident.StringID("testNamespace")
+				start     = now
+				end       = now.Add(defaultTestRetentionOpts.BlockSize())
+				blockTime = now.Add(30 * time.Minute)
+				checksum  = uint32(4)
+				shardID   = uint32(0)
+			)
+
+			// Both series have one block locally
+			localMetadata := block.NewFetchBlocksMetadataResults()
+			for _, id := range []ident.ID{fooID, barID} {
+				results := block.NewFetchBlockMetadataResults()
+				results.Add(block.NewFetchBlockMetadataResult(blockTime,
+					2, &checksum, time.Time{}, nil))
+				localMetadata.Add(block.NewFetchBlocksMetadataResult(id, nil, results))
+			}
+
+			shard := NewMockdatabaseShard(ctrl)
+			shard.EXPECT().
+				FetchBlocksMetadata(gomock.Any(), start, end, gomock.Any(), int64(0), gomock.Any()).
+				Return(localMetadata, nil, nil)
+			shard.EXPECT().ID().Return(shardID).AnyTimes()
+
+			// The peer only differs in the size of foo's block if divergent
+			peerTrees, err := hashtree.NewBlockTrees(depth)
+			require.NoError(t, err)
+			peerTrees.GetOrAdd(blockTime).Add(fooID.Bytes(), tt.peerFooSize, checksum)
+			peerTrees.GetOrAdd(blockTime).Add(barID.Bytes(), 2, checksum)
+			session.EXPECT().
+				FetchBlocksHashTreesFromPeers(namespace, shardID, start, end, depth).
+				Return([]client.PeerBlocksHashTrees{{Host: peerHost, Trees: peerTrees}}, nil)
+
+			if len(tt.expectBuckets) > 0 {
+				peerIter := client.NewMockPeerBlockMetadataIter(ctrl)
+				gomock.InOrder(
+					peerIter.EXPECT().Next().Return(true),
+					peerIter.EXPECT().Current().Return(peerHost, block.NewMetadata(fooID,
+						ident.Tags{}, blockTime, tt.peerFooSize, &checksum, time.Time{})),
+					peerIter.EXPECT().Next().Return(false),
+					peerIter.EXPECT().Err().Return(nil),
+				)
+				session.EXPECT().
+					FetchBlocksMetadataFromPeersInBuckets(namespace, shardID, start, end,
+						rpOpts.RepairConsistencyLevel(), gomock.Any(),
+						hashtree.NewBucketFilter(depth, tt.expectBuckets)).
+					Return(peerIter, nil)
+			}
+
+			var resDiff repair.MetadataComparisonResult
+			repairer := newShardRepairer(opts, rpOpts).(shardRepairer)
+			repairer.recordFn = func(_ ident.ID, _ databaseShard, diffRes repair.MetadataComparisonResult) {
+				resDiff = diffRes
+			}
+
+			ctx := context.NewContext()
+			_, err = repairer.Repair(ctx, namespace, xtime.Range{Start: start, End: end}, shard)
+			require.NoError(t, err)
+
+			require.Equal(t, int64(2), resDiff.NumSeries)
+			require.Equal(t, int64(2), resDiff.NumBlocks)
+			require.Equal(t, 0, resDiff.ChecksumDifferences.Series().Len())
+			require.Equal(t, tt.expectSizeDiffs, resDiff.SizeDifferences.Series().Len())
+			if tt.expectSizeDiffs > 0 {
+				_, exists := resDiff.SizeDifferences.Series().Get(fooID)
+				require.True(t, exists)
+			}
+		})
+	}
+}
+
+func TestDatabaseShardRepairerRepairHashTreesFallback(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	session := client.NewMockAdminSession(ctrl)
+	session.EXPECT().Origin().Return(topology.NewHost("0", "addr0"))
+	session.EXPECT().Replicas().Return(2)
+
+	mockClient := client.NewMockAdminClient(ctrl)
+	mockClient.EXPECT().DefaultAdminSession().Return(session, nil)
+
+	rpOpts := testRepairOptions(ctrl).SetAdminClient(mockClient)
+	opts := testDatabaseOptions()
+
+	var (
+		namespace = ident.StringID("testNamespace")
+		now       = time.Now()
+		start     = now
+		end       = now.Add(defaultTestRetentionOpts.BlockSize())
+		shardID   = uint32(0)
+		peerHost  = topology.NewHost("1", "addr1")
+	)
+
+	shard := NewMockdatabaseShard(ctrl)
+	shard.EXPECT().
+		FetchBlocksMetadata(gomock.Any(), start, end, gomock.Any(), int64(0), gomock.Any()).
+		Return(block.NewFetchBlocksMetadataResults(), nil, nil)
+	shard.EXPECT().ID().Return(shardID).AnyTimes()
+
+	// A peer failing to return its hash trees falls back to comparing
+	// the metadata of all series
+	session.EXPECT().
+		FetchBlocksHashTreesFromPeers(namespace, shardID, start, end, rpOpts.RepairHashTreeDepth()).
+		Return([]client.PeerBlocksHashTrees{{Host: peerHost, Err: errors.New("unavailable")}}, nil)
+
+	peerIter := client.NewMockPeerBlockMetadataIter(ctrl)
+	peerIter.EXPECT().Next().Return(false)
+	peerIter.EXPECT().Err().Return(nil)
+	session.EXPECT().
+		FetchBlocksMetadataFromPeers(namespace, shardID, start, end,
+			rpOpts.RepairConsistencyLevel(), gomock.Any(), client.FetchBlocksMetadataEndpointV2).
+		Return(peerIter, nil)
+
+	repairer := newShardRepairer(opts, rpOpts).(shardRepairer)
+	repairer.recordFn = func(ident.ID, databaseShard, repair.MetadataComparisonResult) {}
+
+	ctx := context.NewContext()
+	_, err := repairer.Repair(ctx, namespace, xtime.Range{Start: start, End: end}, shard)
+	require.NoError(t, err)
+}
+
 func TestRepairerRepairTimes(t *testing.T) {
 	ctrl := gomock.NewController(t)
 	defer ctrl.Finish()
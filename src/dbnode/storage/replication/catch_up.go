@@ -0,0 +1,214 @@
+// Copyright (c) 2018 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package replication
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"time"
+
+	"github.com/m3db/m3/src/dbnode/persist"
+	"github.com/m3db/m3/src/dbnode/persist/fs"
+	xerrors "github.com/m3db/m3x/errors"
+	"github.com/m3db/m3x/ident"
+)
+
+var (
+	errNamespaceNotEnabled = errors.New("replication is not enabled for namespace")
+	errFileSetNotFound     = errors.New("no complete fileset found for block")
+)
+
+// CatchUp replicates all datapoints of a block of a shard from its flushed
+// fileset, writes already replicated are simply written again.
+func (r *Replicator) CatchUp(
+	namespace ident.ID,
+	shard uint32,
+	blockStart time.Time,
+) (CatchUpResult, error) {
+	r.RLock()
+	enabled := r.enabled[namespace.String()]
+	r.RUnlock()
+	if !enabled {
+		return CatchUpResult{}, errNamespaceNotEnabled
+	}
+
+	// Serialize catch ups as they read entire filesets
+	r.catchUpLock.Lock()
+	defer r.catchUpLock.Unlock()
+
+	result, err := r.catchUp(namespace, shard, blockStart)
+	if err != nil {
+		r.metrics.catchUpErrors.Inc(1)
+		return result, err
+	}
+
+	r.Lock()
+	delete(r.pending, catchUpKey{
+		namespace:  namespace.String(),
+		shard:      shard,
+		blockStart: blockStart.UnixNano(),
+	})
+	r.Unlock()
+
+	return result, nil
+}
+
+// CatchUpPending catches up all pending blocks that have been flushed, blocks
+// not yet flushed remain pending until a later call.
+func (r *Replicator) CatchUpPending() (CatchUpResult, error) {
+	r.RLock()
+	pending := r.pendingCatchUpsWithRLock()
+	r.RUnlock()
+
+	var (
+		result         CatchUpResult
+		filePathPrefix = r.opts.FilesystemOptions.FilePathPrefix()
+		multiErr       = xerrors.NewMultiError()
+	)
+	for _, c := range pending {
+		namespace := ident.StringID(c.Namespace)
+		exists, err := fs.DataFileSetExistsAt(filePathPrefix, namespace,
+			c.Shard, c.BlockStart)
+		if err != nil {
+			multiErr = multiErr.Add(err)
+			continue
+		}
+		if !exists {
+			continue
+		}
+
+		blockResult, err := r.CatchUp(namespace, c.Shard, c.BlockStart)
+		result.Series += blockResult.Series
+		result.Datapoints += blockResult.Datapoints
+		result.Failed += blockResult.Failed
+		if err != nil && err != errNamespaceNotEnabled {
+			multiErr = multiErr.Add(fmt.Errorf(
+				"failed to catch up namespace %s shard %d block %v: %v",
+				c.Namespace, c.Shard, c.BlockStart, err))
+		}
+	}
+	return result, multiErr.FinalError()
+}
+
+func (r *Replicator) catchUp(
+	namespace ident.ID,
+	shard uint32,
+	blockStart time.Time,
+) (CatchUpResult, error) {
+	var result CatchUpResult
+
+	fsOpts := r.opts.FilesystemOptions
+	files, err := fs.DataFiles(fsOpts.FilePathPrefix(), namespace, shard)
+	if err != nil {
+		return result, err
+	}
+	latest, ok := files.LatestVolumeForBlock(blockStart)
+	if !ok || !latest.HasCheckpointFile() {
+		return result, errFileSetNotFound
+	}
+
+	session, err := r.defaultSession()
+	if err != nil {
+		return result, err
+	}
+
+	reader, err := fs.NewReader(r.opts.BytesPool, fsOpts)
+	if err != nil {
+		return result, err
+	}
+	err = reader.Open(fs.DataReaderOpenOptions{
+		Identifier:  latest.ID,
+		FileSetType: persist.FileSetFlushType,
+	})
+	if err != nil {
+		return result, err
+	}
+	defer reader.Close()
+
+	iter := r.opts.ReaderIteratorPool.Get()
+	defer iter.Close()
+
+	var lastErr error
+	for {
+		id, tagsIter, data, _, err := reader.Read()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return result, err
+		}
+
+		tags, err := tagsFromIter(tagsIter)
+		tagsIter.Close()
+		if err != nil {
+			id.Finalize()
+			data.Finalize()
+			return result, err
+		}
+
+		data.IncRef()
+		iter.Reset(bytes.NewReader(data.Bytes()))
+		for iter.Next() {
+			dp, unit, annotation := iter.Current()
+			err := session.WriteTagged(namespace, id, ident.NewTagsIterator(tags),
+				dp.Timestamp, dp.Value, unit, annotation)
+			if err != nil {
+				result.Failed++
+				lastErr = err
+				continue
+			}
+			result.Datapoints++
+		}
+		iterErr := iter.Err()
+		data.DecRef()
+
+		id.Finalize()
+		data.Finalize()
+		if iterErr != nil {
+			return result, iterErr
+		}
+		result.Series++
+	}
+
+	r.metrics.caughtUp.Inc(result.Datapoints)
+	if result.Failed > 0 {
+		return result, fmt.Errorf("failed to replicate %d datapoints: %v",
+			result.Failed, lastErr)
+	}
+	return result, nil
+}
+
+func tagsFromIter(iter ident.TagIterator) (ident.Tags, error) {
+	var tags []ident.Tag
+	for iter.Next() {
+		tag := iter.Current()
+		tags = append(tags, ident.Tag{
+			Name:  ident.BytesID(append([]byte(nil), tag.Name.Bytes()...)),
+			Value: ident.BytesID(append([]byte(nil), tag.Value.Bytes()...)),
+		})
+	}
+	if err := iter.Err(); err != nil {
+		return ident.Tags{}, err
+	}
+	return ident.NewTags(tags...), nil
+}
@@ -0,0 +1,438 @@
+// Copyright (c) 2018 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package replication
+
+import (
+	"errors"
+	"sort"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/m3db/m3/src/dbnode/client"
+	"github.com/m3db/m3/src/dbnode/encoding"
+	"github.com/m3db/m3/src/dbnode/persist/fs"
+	"github.com/m3db/m3/src/dbnode/persist/fs/commitlog"
+	"github.com/m3db/m3/src/dbnode/ts"
+	"github.com/m3db/m3x/ident"
+	"github.com/m3db/m3x/instrument"
+	"github.com/m3db/m3x/pool"
+	xtime "github.com/m3db/m3x/time"
+
+	"github.com/uber-go/tally"
+)
+
+const (
+	defaultQueueSize   = 65536
+	defaultConcurrency = 16
+	reportInterval     = time.Second
+)
+
+var (
+	errClientNotSet             = errors.New("replication client is not set")
+	errFilesystemOptionsNotSet  = errors.New("replication filesystem options are not set")
+	errBytesPoolNotSet          = errors.New("replication bytes pool is not set")
+	errReaderIteratorPoolNotSet = errors.New("replication reader iterator pool is not set")
+)
+
+// Options are the options for a Replicator.
+type Options struct {
+	// Client is the client of the standby cluster writes are replicated to.
+	Client client.Client
+	// Namespaces are the namespaces replicated when the replicator is
+	// created, namespaces can be enabled and disabled at runtime.
+	Namespaces []ident.ID
+	// QueueSize is the number of writes that can be queued for replication,
+	// writes are dropped and their blocks scheduled to be caught up from
+	// filesets once the queue is full.
+	QueueSize int
+	// Concurrency is the number of concurrent writes to the standby cluster.
+	Concurrency int
+	// FilesystemOptions are the options used to read filesets to catch up.
+	FilesystemOptions fs.Options
+	// BytesPool is the bytes pool used to read filesets to catch up.
+	BytesPool pool.CheckedBytesPool
+	// ReaderIteratorPool is the pool used to decode filesets to catch up.
+	ReaderIteratorPool encoding.ReaderIteratorPool
+	// InstrumentOptions are the instrument options.
+	InstrumentOptions instrument.Options
+	// NowFn is the function used to determine the current time.
+	NowFn func() time.Time
+}
+
+type replicatedWrite struct {
+	namespace  string
+	id         ident.ID
+	tags       ident.Tags
+	shard      uint32
+	blockStart time.Time
+	datapoint  ts.Datapoint
+	unit       xtime.Unit
+	annotation ts.Annotation
+	enqueuedAt time.Time
+}
+
+type catchUpKey struct {
+	namespace  string
+	shard      uint32
+	blockStart int64
+}
+
+// Replicator asynchronously replicates writes to a standby cluster, typically
+// in another region. Writes that cannot be replicated, either because the
+// queue is full or the standby cluster returned an error, have their block
+// recorded so that it can be caught up from its fileset once flushed.
+type Replicator struct {
+	sync.RWMutex
+
+	opts    Options
+	nowFn   func() time.Time
+	enabled map[string]bool
+	pending map[catchUpKey]struct{}
+	writes  chan replicatedWrite
+	closed  bool
+	wg      sync.WaitGroup
+	doneCh  chan struct{}
+
+	sessionLock sync.Mutex
+	session     client.Session
+
+	statusLock       sync.Mutex
+	lastReplicatedAt time.Time
+	lag              time.Duration
+
+	replicated int64
+	failed     int64
+	dropped    int64
+
+	catchUpLock sync.Mutex
+
+	metrics replicatorMetrics
+}
+
+type replicatorMetrics struct {
+	queued          tally.Gauge
+	pendingCatchUps tally.Gauge
+	lag             tally.Timer
+	success         tally.Counter
+	errors          tally.Counter
+	dropped         tally.Counter
+	caughtUp        tally.Counter
+	catchUpErrors   tally.Counter
+}
+
+func newReplicatorMetrics(scope tally.Scope) replicatorMetrics {
+	return replicatorMetrics{
+		queued:          scope.Gauge("queued"),
+		pendingCatchUps: scope.Gauge("pending-catch-ups"),
+		lag:             scope.Timer("lag"),
+		success:         scope.Counter("writes.success"),
+		errors:          scope.Counter("writes.errors"),
+		dropped:         scope.Counter("writes.dropped"),
+		caughtUp:        scope.Counter("catch-up.datapoints"),
+		catchUpErrors:   scope.Counter("catch-up.errors"),
+	}
+}
+
+// NewReplicator returns a new replicator, Close must be called to stop
+// its background replication.
+func NewReplicator(opts Options) (*Replicator, error) {
+	if opts.Client == nil {
+		return nil, errClientNotSet
+	}
+	if opts.FilesystemOptions == nil {
+		return nil, errFilesystemOptionsNotSet
+	}
+	if opts.BytesPool == nil {
+		return nil, errBytesPoolNotSet
+	}
+	if opts.ReaderIteratorPool == nil {
+		return nil, errReaderIteratorPoolNotSet
+	}
+	if opts.InstrumentOptions == nil {
+		opts.InstrumentOptions = instrument.NewOptions()
+	}
+	if opts.QueueSize <= 0 {
+		opts.QueueSize = defaultQueueSize
+	}
+	if opts.Concurrency <= 0 {
+		opts.Concurrency = defaultConcurrency
+	}
+	nowFn := opts.NowFn
+	if nowFn == nil {
+		nowFn = time.Now
+	}
+
+	r := &Replicator{
+		opts:    opts,
+		nowFn:   nowFn,
+		enabled: make(map[string]bool, len(opts.Namespaces)),
+		pending: make(map[catchUpKey]struct{}),
+		writes:  make(chan replicatedWrite, opts.QueueSize),
+		doneCh:  make(chan struct{}),
+		metrics: newReplicatorMetrics(opts.InstrumentOptions.MetricsScope().
+			SubScope("replication")),
+	}
+	for _, ns := range opts.Namespaces {
+		r.enabled[ns.String()] = true
+	}
+
+	r.wg.Add(opts.Concurrency)
+	for i := 0; i < opts.Concurrency; i++ {
+		go r.replicateLoop()
+	}
+	go r.reportLoop()
+
+	return r, nil
+}
+
+// Replicate enqueues a write to be replicated if its namespace is enabled,
+// it never blocks. The series, tags and annotation are copied so the caller
+// retains ownership of them.
+func (r *Replicator) Replicate(
+	series commitlog.Series,
+	blockSize time.Duration,
+	datapoint ts.Datapoint,
+	unit xtime.Unit,
+	annotation ts.Annotation,
+) {
+	namespace := series.Namespace.String()
+
+	r.RLock()
+	if r.closed || !r.enabled[namespace] {
+		r.RUnlock()
+		return
+	}
+
+	write := replicatedWrite{
+		namespace:  namespace,
+		id:         ident.BytesID(append([]byte(nil), series.ID.Bytes()...)),
+		tags:       copyTags(series.SeriesTags()),
+		shard:      series.Shard,
+		blockStart: datapoint.Timestamp.Truncate(blockSize),
+		datapoint:  datapoint,
+		unit:       unit,
+		annotation: append(ts.Annotation(nil), annotation...),
+		enqueuedAt: r.nowFn(),
+	}
+
+	enqueued := false
+	select {
+	case r.writes <- write:
+		enqueued = true
+	default:
+	}
+	r.RUnlock()
+
+	if !enqueued {
+		atomic.AddInt64(&r.dropped, 1)
+		r.metrics.dropped.Inc(1)
+		r.addPendingCatchUp(write.namespace, write.shard, write.blockStart)
+	}
+}
+
+// SetNamespaceEnabled enables or disables replication of a namespace,
+// disabling a namespace discards its pending catch ups.
+func (r *Replicator) SetNamespaceEnabled(namespace ident.ID, enabled bool) {
+	ns := namespace.String()
+
+	r.Lock()
+	defer r.Unlock()
+
+	if enabled {
+		r.enabled[ns] = true
+		return
+	}
+	delete(r.enabled, ns)
+	for key := range r.pending {
+		if key.namespace == ns {
+			delete(r.pending, key)
+		}
+	}
+}
+
+// Status returns the current replication status.
+func (r *Replicator) Status() Status {
+	r.RLock()
+	namespaces := make([]NamespaceStatus, 0, len(r.enabled))
+	for ns := range r.enabled {
+		namespaces = append(namespaces, NamespaceStatus{Namespace: ns, Enabled: true})
+	}
+	pending := r.pendingCatchUpsWithRLock()
+	r.RUnlock()
+
+	sort.Slice(namespaces, func(i, j int) bool {
+		return namespaces[i].Namespace < namespaces[j].Namespace
+	})
+
+	r.statusLock.Lock()
+	lastReplicatedAt, lag := r.lastReplicatedAt, r.lag
+	r.statusLock.Unlock()
+
+	return Status{
+		Namespaces:       namespaces,
+		Queued:           len(r.writes),
+		Replicated:       atomic.LoadInt64(&r.replicated),
+		Failed:           atomic.LoadInt64(&r.failed),
+		Dropped:          atomic.LoadInt64(&r.dropped),
+		LastReplicatedAt: lastReplicatedAt,
+		Lag:              lag,
+		PendingCatchUps:  pending,
+	}
+}
+
+// Close stops replication once all queued writes have been replicated.
+func (r *Replicator) Close() error {
+	r.Lock()
+	if r.closed {
+		r.Unlock()
+		return nil
+	}
+	r.closed = true
+	close(r.writes)
+	r.Unlock()
+
+	r.wg.Wait()
+	close(r.doneCh)
+	return nil
+}
+
+func (r *Replicator) replicateLoop() {
+	defer r.wg.Done()
+	for write := range r.writes {
+		r.replicate(write)
+	}
+}
+
+func (r *Replicator) replicate(write replicatedWrite) {
+	session, err := r.defaultSession()
+	if err == nil {
+		err = session.WriteTagged(ident.StringID(write.namespace), write.id,
+			ident.NewTagsIterator(write.tags), write.datapoint.Timestamp,
+			write.datapoint.Value, write.unit, write.annotation)
+	}
+	if err != nil {
+		atomic.AddInt64(&r.failed, 1)
+		r.metrics.errors.Inc(1)
+		r.addPendingCatchUp(write.namespace, write.shard, write.blockStart)
+		return
+	}
+
+	now := r.nowFn()
+	lag := now.Sub(write.enqueuedAt)
+	atomic.AddInt64(&r.replicated, 1)
+	r.metrics.success.Inc(1)
+	r.metrics.lag.Record(lag)
+
+	r.statusLock.Lock()
+	r.lastReplicatedAt = now
+	r.lag = lag
+	r.statusLock.Unlock()
+}
+
+// defaultSession lazily creates the session so that the standby cluster
+// being unavailable does not prevent the node from starting.
+func (r *Replicator) defaultSession() (client.Session, error) {
+	r.sessionLock.Lock()
+	defer r.sessionLock.Unlock()
+
+	if r.session != nil {
+		return r.session, nil
+	}
+	session, err := r.opts.Client.DefaultSession()
+	if err != nil {
+		return nil, err
+	}
+	r.session = session
+	return session, nil
+}
+
+func (r *Replicator) reportLoop() {
+	ticker := time.NewTicker(reportInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ticker.C:
+			r.RLock()
+			pending := len(r.pending)
+			r.RUnlock()
+			r.metrics.queued.Update(float64(len(r.writes)))
+			r.metrics.pendingCatchUps.Update(float64(pending))
+		case <-r.doneCh:
+			return
+		}
+	}
+}
+
+func (r *Replicator) addPendingCatchUp(
+	namespace string,
+	shard uint32,
+	blockStart time.Time,
+) {
+	key := catchUpKey{
+		namespace:  namespace,
+		shard:      shard,
+		blockStart: blockStart.UnixNano(),
+	}
+
+	r.Lock()
+	if r.enabled[namespace] {
+		r.pending[key] = struct{}{}
+	}
+	r.Unlock()
+}
+
+func (r *Replicator) pendingCatchUpsWithRLock() []CatchUp {
+	result := make([]CatchUp, 0, len(r.pending))
+	for key := range r.pending {
+		result = append(result, CatchUp{
+			Namespace:  key.namespace,
+			Shard:      key.shard,
+			BlockStart: time.Unix(0, key.blockStart),
+		})
+	}
+	sort.Slice(result, func(i, j int) bool {
+		if result[i].Namespace != result[j].Namespace {
+			return result[i].Namespace < result[j].Namespace
+		}
+		if result[i].Shard != result[j].Shard {
+			return result[i].Shard < result[j].Shard
+		}
+		return result[i].BlockStart.Before(result[j].BlockStart)
+	})
+	return result
+}
+
+func copyTags(tags ident.Tags) ident.Tags {
+	values := tags.Values()
+	if len(values) == 0 {
+		return ident.Tags{}
+	}
+	copied := make([]ident.Tag, 0, len(values))
+	for _, tag := range values {
+		copied = append(copied, ident.Tag{
+			Name:  ident.BytesID(append([]byte(nil), tag.Name.Bytes()...)),
+			Value: ident.BytesID(append([]byte(nil), tag.Value.Bytes()...)),
+		})
+	}
+	return ident.NewTags(copied...)
+}
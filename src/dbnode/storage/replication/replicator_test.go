@@ -0,0 +1,231 @@
+// Copyright (c) 2018 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package replication
+
+import (
+	"errors"
+	"io"
+	"io/ioutil"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/m3db/m3/src/dbnode/client"
+	"github.com/m3db/m3/src/dbnode/digest"
+	"github.com/m3db/m3/src/dbnode/encoding"
+	"github.com/m3db/m3/src/dbnode/encoding/m3tsz"
+	"github.com/m3db/m3/src/dbnode/persist"
+	"github.com/m3db/m3/src/dbnode/persist/fs"
+	"github.com/m3db/m3/src/dbnode/persist/fs/commitlog"
+	"github.com/m3db/m3/src/dbnode/ts"
+	"github.com/m3db/m3x/checked"
+	"github.com/m3db/m3x/ident"
+	"github.com/m3db/m3x/pool"
+	xtime "github.com/m3db/m3x/time"
+
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/require"
+)
+
+var (
+	testNamespace = ident.StringID("testns")
+	testBlockSize = 2 * time.Hour
+)
+
+func newTestReplicator(
+	t *testing.T,
+	ctrl *gomock.Controller,
+	filePathPrefix string,
+	queueSize int,
+) (*Replicator, *client.MockSession) {
+	session := client.NewMockSession(ctrl)
+	c := client.NewMockClient(ctrl)
+	c.EXPECT().DefaultSession().Return(session, nil).AnyTimes()
+
+	bytesPool := pool.NewCheckedBytesPool(nil, nil, func(s []pool.Bucket) pool.BytesPool {
+		return pool.NewBytesPool(s, nil)
+	})
+	bytesPool.Init()
+
+	encodingOpts := encoding.NewOptions()
+	iterPool := encoding.NewReaderIteratorPool(nil)
+	iterPool.Init(func(r io.Reader) encoding.ReaderIterator {
+		return m3tsz.NewReaderIterator(r, m3tsz.DefaultIntOptimizationEnabled, encodingOpts)
+	})
+
+	r, err := NewReplicator(Options{
+		Client:             c,
+		Namespaces:         []ident.ID{testNamespace},
+		QueueSize:          queueSize,
+		Concurrency:        1,
+		FilesystemOptions:  fs.NewOptions().SetFilePathPrefix(filePathPrefix),
+		BytesPool:          bytesPool,
+		ReaderIteratorPool: iterPool,
+	})
+	require.NoError(t, err)
+	return r, session
+}
+
+func testSeries(id string, shard uint32) commitlog.Series {
+	return commitlog.Series{
+		Namespace: testNamespace,
+		ID:        ident.StringID(id),
+		Tags:      ident.NewTags(ident.StringTag("host", id)),
+		Shard:     shard,
+	}
+}
+
+func TestReplicatorReplicatesEnabledNamespaces(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	r, session := newTestReplicator(t, ctrl, "", 16)
+
+	now := time.Now()
+	session.EXPECT().
+		WriteTagged(ident.NewIDMatcher("testns"), ident.NewIDMatcher("foo"),
+			gomock.Any(), now, 42.0, xtime.Second, gomock.Any()).
+		Return(nil)
+
+	r.Replicate(testSeries("foo", 0), testBlockSize,
+		ts.Datapoint{Timestamp: now, Value: 42}, xtime.Second, nil)
+
+	// Writes to namespaces not enabled are not replicated
+	other := testSeries("bar", 0)
+	other.Namespace = ident.StringID("other")
+	r.Replicate(other, testBlockSize,
+		ts.Datapoint{Timestamp: now, Value: 1}, xtime.Second, nil)
+
+	require.NoError(t, r.Close())
+
+	status := r.Status()
+	require.Equal(t, int64(1), status.Replicated)
+	require.Equal(t, int64(0), status.Failed)
+	require.Equal(t, []NamespaceStatus{{Namespace: "testns", Enabled: true}},
+		status.Namespaces)
+	require.Empty(t, status.PendingCatchUps)
+}
+
+func TestReplicatorFailedWritesPendCatchUp(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	r, session := newTestReplicator(t, ctrl, "", 16)
+
+	now := time.Now()
+	session.EXPECT().
+		WriteTagged(gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any(),
+			gomock.Any(), gomock.Any(), gomock.Any()).
+		Return(errors.New("unavailable"))
+
+	r.Replicate(testSeries("foo", 3), testBlockSize,
+		ts.Datapoint{Timestamp: now, Value: 42}, xtime.Second, nil)
+	require.NoError(t, r.Close())
+
+	status := r.Status()
+	require.Equal(t, int64(1), status.Failed)
+	require.Equal(t, []CatchUp{{
+		Namespace:  "testns",
+		Shard:      3,
+		BlockStart: time.Unix(0, now.Truncate(testBlockSize).UnixNano()),
+	}}, status.PendingCatchUps)
+
+	// Disabling the namespace discards its pending catch ups
+	r.SetNamespaceEnabled(testNamespace, false)
+	status = r.Status()
+	require.Empty(t, status.Namespaces)
+	require.Empty(t, status.PendingCatchUps)
+}
+
+func TestReplicatorCatchUpPendingFromFileSet(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	filePathPrefix, err := ioutil.TempDir("", "replication")
+	require.NoError(t, err)
+	defer os.RemoveAll(filePathPrefix)
+
+	r, session := newTestReplicator(t, ctrl, filePathPrefix, 16)
+	defer r.Close()
+
+	var (
+		blockStart = time.Now().Truncate(testBlockSize)
+		shard      = uint32(1)
+		datapoints = []ts.Datapoint{
+			{Timestamp: blockStart.Add(time.Minute), Value: 1},
+			{Timestamp: blockStart.Add(2 * time.Minute), Value: 2},
+		}
+	)
+	r.addPendingCatchUp(testNamespace.String(), shard, blockStart)
+
+	// Nothing is caught up before the block is flushed
+	result, err := r.CatchUpPending()
+	require.NoError(t, err)
+	require.Equal(t, CatchUpResult{}, result)
+	require.Len(t, r.Status().PendingCatchUps, 1)
+
+	encoder := m3tsz.NewEncoder(blockStart, nil,
+		m3tsz.DefaultIntOptimizationEnabled, encoding.NewOptions())
+	for _, dp := range datapoints {
+		require.NoError(t, encoder.Encode(dp, xtime.Second, nil))
+	}
+	seg := encoder.Discard()
+	data := append(append([]byte(nil), seg.Head.Bytes()...), seg.Tail.Bytes()...)
+
+	writer, err := fs.NewWriter(fs.NewOptions().SetFilePathPrefix(filePathPrefix))
+	require.NoError(t, err)
+	require.NoError(t, writer.Open(fs.DataWriterOpenOptions{
+		FileSetType: persist.FileSetFlushType,
+		Identifier: fs.FileSetFileIdentifier{
+			Namespace:  testNamespace,
+			Shard:      shard,
+			BlockStart: blockStart,
+		},
+		BlockSize: testBlockSize,
+	}))
+	series := testSeries("foo", shard)
+	require.NoError(t, writer.Write(series.ID, series.Tags,
+		checked.NewBytes(data, nil), digest.Checksum(data)))
+	require.NoError(t, writer.Close())
+
+	for _, dp := range datapoints {
+		session.EXPECT().
+			WriteTagged(ident.NewIDMatcher("testns"), ident.NewIDMatcher("foo"),
+				gomock.Any(), dp.Timestamp, dp.Value, xtime.Second, gomock.Any()).
+			Return(nil)
+	}
+
+	result, err = r.CatchUpPending()
+	require.NoError(t, err)
+	require.Equal(t, CatchUpResult{Series: 1, Datapoints: 2}, result)
+	require.Empty(t, r.Status().PendingCatchUps)
+}
+
+func TestReplicatorCatchUpNamespaceNotEnabled(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	r, _ := newTestReplicator(t, ctrl, "", 16)
+	defer r.Close()
+
+	_, err := r.CatchUp(ident.StringID("other"), 0, time.Now())
+	require.Equal(t, errNamespaceNotEnabled, err)
+}
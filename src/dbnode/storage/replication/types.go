@@ -0,0 +1,58 @@
+// Copyright (c) 2018 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package replication
+
+import (
+	"time"
+)
+
+// Status is the status of replication to the standby cluster.
+type Status struct {
+	Namespaces       []NamespaceStatus `json:"namespaces"`
+	Queued           int               `json:"queued"`
+	Replicated       int64             `json:"replicated"`
+	Failed           int64             `json:"failed"`
+	Dropped          int64             `json:"dropped"`
+	LastReplicatedAt time.Time         `json:"lastReplicatedAt"`
+	Lag              time.Duration     `json:"lag"`
+	PendingCatchUps  []CatchUp         `json:"pendingCatchUps"`
+}
+
+// NamespaceStatus is the replication status of a namespace.
+type NamespaceStatus struct {
+	Namespace string `json:"namespace"`
+	Enabled   bool   `json:"enabled"`
+}
+
+// CatchUp identifies a block of a shard that must be replicated from its
+// flushed fileset, as writes to it were dropped or failed to replicate.
+type CatchUp struct {
+	Namespace  string    `json:"namespace"`
+	Shard      uint32    `json:"shard"`
+	BlockStart time.Time `json:"blockStart"`
+}
+
+// CatchUpResult is the result of replicating a block from its fileset.
+type CatchUpResult struct {
+	Series     int64 `json:"series"`
+	Datapoints int64 `json:"datapoints"`
+	Failed     int64 `json:"failed"`
+}
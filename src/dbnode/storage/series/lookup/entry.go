@@ -26,6 +26,7 @@ import (
 
 	"github.com/m3db/m3/src/dbnode/storage/index"
 	"github.com/m3db/m3/src/dbnode/storage/series"
+	"github.com/m3db/m3/src/dbnode/x/xarena"
 	xtime "github.com/m3db/m3x/time"
 )
 
@@ -57,6 +58,12 @@ func NewEntry(series series.DatabaseSeries, index uint64) *Entry {
 	return entry
 }
 
+// ReleaseMetadata releases the arena held ID and tags of the series of the
+// Entry, once the Entry is no longer in use and before the series is closed.
+func (entry *Entry) ReleaseMetadata() {
+	entry.Series.SetMetadata(xarena.SeriesMetadata{}).Release()
+}
+
 // ReaderWriterCount returns the current ref count on the Entry.
 func (entry *Entry) ReaderWriterCount() int32 {
 	return atomic.LoadInt32(&entry.curReadWriters)
@@ -30,6 +30,7 @@ import (
 	"github.com/m3db/m3/src/dbnode/persist"
 	"github.com/m3db/m3/src/dbnode/storage/block"
 	"github.com/m3db/m3/src/dbnode/ts"
+	"github.com/m3db/m3/src/dbnode/x/xarena"
 	"github.com/m3db/m3/src/dbnode/x/xio"
 	"github.com/m3db/m3x/context"
 	xerrors "github.com/m3db/m3x/errors"
@@ -65,6 +66,9 @@ type dbSeries struct {
 	// calling series.Reset()).
 	id   ident.ID
 	tags ident.Tags
+	// metadata holds the ID and tags in place of tags when set, the tags
+	// are only materialized when read.
+	metadata xarena.SeriesMetadata
 
 	buffer                      databaseBuffer
 	blocks                      block.DatabaseSeriesBlocks
@@ -114,11 +118,34 @@ func (s *dbSeries) ID() ident.ID {
 
 func (s *dbSeries) Tags() ident.Tags {
 	s.RLock()
-	tags := s.tags
+	tags, metadata := s.tags, s.metadata
 	s.RUnlock()
+	if !metadata.IsZero() {
+		return metadata.Tags()
+	}
 	return tags
 }
 
+func (s *dbSeries) Metadata() xarena.SeriesMetadata {
+	s.RLock()
+	metadata := s.metadata
+	s.RUnlock()
+	return metadata
+}
+
+func (s *dbSeries) SetMetadata(metadata xarena.SeriesMetadata) xarena.SeriesMetadata {
+	s.Lock()
+	prev := s.metadata
+	if !metadata.IsZero() {
+		// NB: The ID is boxed once here rather than on every call to ID.
+		s.id = metadata.ID()
+	}
+	s.tags = ident.Tags{}
+	s.metadata = metadata
+	s.Unlock()
+	return prev
+}
+
 func (s *dbSeries) Tick() (TickResult, error) {
 	var r TickResult
 
@@ -723,6 +750,7 @@ func (s *dbSeries) Close() {
 	// See Reset() for why these aren't finalized
 	s.id = nil
 	s.tags = ident.Tags{}
+	s.metadata = xarena.SeriesMetadata{}
 
 	switch s.opts.CachePolicy() {
 	case CacheLRU:
@@ -777,6 +805,7 @@ func (s *dbSeries) Reset(
 	// a long period of time.
 	s.id = id
 	s.tags = tags
+	s.metadata = xarena.SeriesMetadata{}
 
 	s.blocks.Reset()
 	s.buffer.Reset(opts)
@@ -28,6 +28,7 @@ import (
 	"github.com/m3db/m3/src/dbnode/persist"
 	"github.com/m3db/m3/src/dbnode/retention"
 	"github.com/m3db/m3/src/dbnode/storage/block"
+	"github.com/m3db/m3/src/dbnode/x/xarena"
 	"github.com/m3db/m3/src/dbnode/x/xio"
 	"github.com/m3db/m3x/context"
 	"github.com/m3db/m3x/ident"
@@ -48,6 +49,15 @@ type DatabaseSeries interface {
 	// Tags return the tags of the series
 	Tags() ident.Tags
 
+	// Metadata returns the ID and tags of the series held in an arena, the
+	// zero value if they are not held in an arena.
+	Metadata() xarena.SeriesMetadata
+
+	// SetMetadata sets the ID and tags of the series to the metadata held in
+	// an arena, returning the metadata it replaces for the caller to release.
+	// Setting the zero value keeps the ID of the series and drops its tags.
+	SetMetadata(metadata xarena.SeriesMetadata) xarena.SeriesMetadata
+
 	// Tick executes any updates to ensure buffer drains, blocks are flushed, etc
 	Tick() (TickResult, error)
 
@@ -46,8 +46,10 @@ import (
 	"github.com/m3db/m3/src/dbnode/storage/series"
 	"github.com/m3db/m3/src/dbnode/storage/series/lookup"
 	"github.com/m3db/m3/src/dbnode/ts"
+	"github.com/m3db/m3/src/dbnode/x/xarena"
 	"github.com/m3db/m3/src/dbnode/x/xio"
 	"github.com/m3db/m3/src/m3ninx/doc"
+	"github.com/m3db/m3/src/x/loglevel"
 	xclose "github.com/m3db/m3x/close"
 	"github.com/m3db/m3x/context"
 	xerrors "github.com/m3db/m3x/errors"
@@ -146,6 +148,7 @@ type dbShard struct {
 	namespaceReaderMgr       databaseNamespaceReaderManager
 	increasingIndex          increasingIndex
 	seriesPool               series.DatabaseSeriesPool
+	seriesMetadataArena      xarena.BytesArena
 	commitLogWriter          commitLogWriter
 	reverseIndex             namespaceIndex
 	insertQueue              *dbShardInsertQueue
@@ -253,29 +256,30 @@ func newDatabaseShard(
 		SubScope("dbshard")
 
 	s := &dbShard{
-		opts:               opts,
-		seriesOpts:         seriesOpts,
-		nowFn:              opts.ClockOptions().NowFn(),
-		state:              dbShardStateOpen,
-		namespace:          namespaceMetadata,
-		shard:              shard,
-		namespaceReaderMgr: namespaceReaderMgr,
-		increasingIndex:    increasingIndex,
-		seriesPool:         opts.DatabaseSeriesPool(),
-		commitLogWriter:    commitLogWriter,
-		reverseIndex:       reverseIndex,
-		lookup:             newShardMap(shardMapOptions{}),
-		list:               list.New(),
-		filesetBeforeFn:    fs.DataFileSetsBefore,
-		deleteFilesFn:      fs.DeleteFiles,
-		snapshotFilesFn:    fs.SnapshotFiles,
-		sleepFn:            time.Sleep,
-		identifierPool:     opts.IdentifierPool(),
-		contextPool:        opts.ContextPool(),
-		flushState:         newShardFlushState(),
-		tickWg:             &sync.WaitGroup{},
-		logger:             opts.InstrumentOptions().Logger(),
-		metrics:            newDatabaseShardMetrics(scope),
+		opts:                opts,
+		seriesOpts:          seriesOpts,
+		nowFn:               opts.ClockOptions().NowFn(),
+		state:               dbShardStateOpen,
+		namespace:           namespaceMetadata,
+		shard:               shard,
+		namespaceReaderMgr:  namespaceReaderMgr,
+		increasingIndex:     increasingIndex,
+		seriesPool:          opts.DatabaseSeriesPool(),
+		seriesMetadataArena: newShardSeriesMetadataArena(opts, shard),
+		commitLogWriter:     commitLogWriter,
+		reverseIndex:        reverseIndex,
+		lookup:              newShardMap(shardMapOptions{}),
+		list:                list.New(),
+		filesetBeforeFn:     fs.DataFileSetsBefore,
+		deleteFilesFn:       fs.DeleteFiles,
+		snapshotFilesFn:     fs.SnapshotFiles,
+		sleepFn:             time.Sleep,
+		identifierPool:      opts.IdentifierPool(),
+		contextPool:         opts.ContextPool(),
+		flushState:          newShardFlushState(),
+		tickWg:              &sync.WaitGroup{},
+		logger:              opts.InstrumentOptions().Logger(),
+		metrics:             newDatabaseShardMetrics(scope),
 	}
 	s.insertQueue = newDatabaseShardInsertQueue(s.insertSeriesBatch,
 		s.nowFn, scope)
@@ -618,6 +622,7 @@ func (s *dbShard) tickAndExpire(
 		i                             int
 		slept                         time.Duration
 		expired                       []*lookup.Entry
+		compactable                   []*lookup.Entry
 	)
 	s.RLock()
 	tickSleepBatch := s.currRuntimeOptions.tickSleepSeriesBatchSize
@@ -630,6 +635,10 @@ func (s *dbShard) tickAndExpire(
 			expired[i] = nil
 		}
 		expired = expired[:0]
+		for i := range compactable {
+			compactable[i] = nil
+		}
+		compactable = compactable[:0]
 		for _, entry := range currEntries {
 			if i > 0 && i%tickSleepBatch == 0 {
 				// NB(xichen): if the tick is cancelled, we bail out immediately.
@@ -669,6 +678,10 @@ func (s *dbShard) tickAndExpire(
 				if err != nil {
 					r.errors++
 				}
+				if s.seriesMetadataArena != nil &&
+					entry.Series.Metadata().Compactable() {
+					compactable = append(compactable, entry)
+				}
 			}
 			r.activeBlocks += result.ActiveBlocks
 			r.openBlocks += result.OpenBlocks
@@ -689,6 +702,10 @@ func (s *dbShard) tickAndExpire(
 			}
 			expired = expired[:0]
 		}
+		// Move the metadata of series off mostly released arena slabs.
+		if len(compactable) > 0 {
+			s.compactSeriesMetadata(compactable)
+		}
 		// Continue
 		return true
 	})
@@ -738,6 +755,9 @@ func (s *dbShard) purgeExpiredSeries(expiredEntries []*lookup.Entry) {
 		// NB(xichen): if we get here, we are guaranteed that there can be
 		// no more reads/writes to this series while the lock is held, so it's
 		// safe to remove it.
+		if s.seriesMetadataArena != nil {
+			entry.ReleaseMetadata()
+		}
 		series.Close()
 		s.list.Remove(elem)
 		s.lookup.Delete(id)
@@ -745,6 +765,37 @@ func (s *dbShard) purgeExpiredSeries(expiredEntries []*lookup.Entry) {
 	s.Unlock()
 }
 
+// compactSeriesMetadata copies the arena held metadata of the series of the
+// entries to new regions and releases their previous regions, so that a few
+// long lived series do not keep mostly released slabs from being reclaimed.
+// References to the previous regions held elsewhere, such as by the index
+// documents of the series, remain valid and keep the slabs alive until they
+// are dropped.
+func (s *dbShard) compactSeriesMetadata(entries []*lookup.Entry) {
+	s.Lock()
+	for _, entry := range entries {
+		id := entry.Series.ID()
+		elem, exists := s.lookup.Get(id)
+		if !exists || elem.Value.(*lookup.Entry) != entry {
+			continue
+		}
+		metadata := entry.Series.Metadata()
+		if metadata.IsZero() {
+			continue
+		}
+		compacted := metadata.Copy(s.seriesMetadataArena)
+		entry.Series.SetMetadata(compacted).Release()
+		// Key the lookup by the compacted ID so that it does not reference
+		// the previous region.
+		s.lookup.Delete(id)
+		s.lookup.SetUnsafe(compacted.ID(), elem, shardMapSetUnsafeOptions{
+			NoCopyKey:     true,
+			NoFinalizeKey: true,
+		})
+	}
+	s.Unlock()
+}
+
 func (s *dbShard) WriteTagged(
 	ctx context.Context,
 	id ident.ID,
@@ -786,6 +837,82 @@ func (s *dbShard) writeAndIndex(
 		return err
 	}
 
+	series, err := s.writeAndIndexEntry(ctx, entry, opts, id, tags,
+		timestamp, value, unit, annotation, shouldReverseIndex)
+	if err != nil {
+		return err
+	}
+
+	// Write commit log
+	datapoint := ts.Datapoint{
+		Timestamp: timestamp,
+		Value:     value,
+	}
+
+	return s.commitLogWriter.Write(ctx, series, datapoint,
+		unit, annotation)
+}
+
+// WriteBatch performs the writes of a batch at the given indexes as a single
+// pipeline, looking up all of the series already in the shard with a single
+// acquisition of the shard lock. The commit log writes are left to the caller
+// so that a whole batch can be appended to the commit log at once.
+func (s *dbShard) WriteBatch(
+	ctx context.Context,
+	writes []BatchWrite,
+	indexes []int,
+	shouldReverseIndex bool,
+	errs []error,
+	commitLogWrites []commitlog.BatchWrite,
+) []commitlog.BatchWrite {
+	entries, opts := s.tryRetrieveWritableSeriesBatch(writes, indexes, errs)
+	for i, idx := range indexes {
+		if errs[idx] != nil {
+			continue
+		}
+
+		write := &writes[idx]
+		tags := write.Tags
+		if tags == nil {
+			tags = ident.EmptyTagIterator
+		}
+
+		series, err := s.writeAndIndexEntry(ctx, entries[i], opts, write.ID,
+			tags, write.Timestamp, write.Value, write.Unit, write.Annotation,
+			shouldReverseIndex)
+		if err != nil {
+			errs[idx] = err
+			continue
+		}
+
+		commitLogWrites = append(commitLogWrites, commitlog.BatchWrite{
+			Series: series,
+			Datapoint: ts.Datapoint{
+				Timestamp: write.Timestamp,
+				Value:     write.Value,
+			},
+			Unit:       write.Unit,
+			Annotation: write.Annotation,
+		})
+	}
+	return commitLogWrites
+}
+
+// writeAndIndexEntry writes to the series of the entry, or inserts the series
+// if there is no entry, and returns the series to write to the commit log.
+// The reader writer count of the entry taken when retrieving it is released.
+func (s *dbShard) writeAndIndexEntry(
+	ctx context.Context,
+	entry *lookup.Entry,
+	opts writableSeriesOptions,
+	id ident.ID,
+	tags ident.TagIterator,
+	timestamp time.Time,
+	value float64,
+	unit xtime.Unit,
+	annotation []byte,
+	shouldReverseIndex bool,
+) (commitlog.Series, error) {
 	writable := entry != nil
 
 	// If no entry and we are not writing new series asynchronously
@@ -799,7 +926,7 @@ func (s *dbShard) writeAndIndex(
 			},
 		})
 		if err != nil {
-			return err
+			return commitlog.Series{}, err
 		}
 
 		// Wait for the insert to be batched together and inserted
@@ -808,7 +935,7 @@ func (s *dbShard) writeAndIndex(
 		// Retrieve the inserted entry
 		entry, err = s.writableSeries(id, tags)
 		if err != nil {
-			return err
+			return commitlog.Series{}, err
 		}
 		writable = true
 
@@ -819,11 +946,12 @@ func (s *dbShard) writeAndIndex(
 	var (
 		commitLogSeriesID          ident.ID
 		commitLogSeriesTags        ident.Tags
+		commitLogSeriesMetadata    xarena.SeriesMetadata
 		commitLogSeriesUniqueIndex uint64
 	)
 	if writable {
 		// Perform write
-		err = entry.Series.Write(ctx, timestamp, value, unit, annotation)
+		err := entry.Series.Write(ctx, timestamp, value, unit, annotation)
 		// Load series metadata before decrementing the writer count
 		// to ensure this metadata is snapshotted at a consistent state
 		// NB(r): We explicitly do not place the series ID back into a
@@ -831,7 +959,7 @@ func (s *dbShard) writeAndIndex(
 		// as the commit log need to use the reference without the
 		// overhead of ownership tracking. This makes taking a ref here safe.
 		commitLogSeriesID = entry.Series.ID()
-		commitLogSeriesTags = entry.Series.Tags()
+		commitLogSeriesTags, commitLogSeriesMetadata = s.seriesTagsOrMetadata(entry)
 		commitLogSeriesUniqueIndex = entry.Index
 		if err == nil && shouldReverseIndex {
 			if entry.NeedsIndexUpdate(s.reverseIndex.BlockStartForWriteTime(timestamp)) {
@@ -842,7 +970,7 @@ func (s *dbShard) writeAndIndex(
 		// release the reference we got on entry from `writableSeries`
 		entry.DecrementReaderWriterCount()
 		if err != nil {
-			return err
+			return commitlog.Series{}, err
 		}
 	} else {
 		// This is an asynchronous insert and write
@@ -861,7 +989,7 @@ func (s *dbShard) writeAndIndex(
 			},
 		})
 		if err != nil {
-			return err
+			return commitlog.Series{}, err
 		}
 		// NB(r): Make sure to use the copied ID which will eventually
 		// be set to the newly series inserted ID.
@@ -870,25 +998,28 @@ func (s *dbShard) writeAndIndex(
 		// (i.e. registering a dependency on the context) is too expensive.
 		commitLogSeriesID = result.copiedID
 		commitLogSeriesTags = result.copiedTags
+		commitLogSeriesMetadata = result.copiedMetadata
 		commitLogSeriesUniqueIndex = result.entry.Index
 	}
 
-	// Write commit log
-	series := commitlog.Series{
+	if loglevel.DebugTargeted(s.logger, s.shard, id.Bytes()) {
+		s.logger.WithFields(
+			xlog.NewField(loglevel.ShardField, s.shard),
+			xlog.NewField(loglevel.SeriesIDField, id.String()),
+			xlog.NewField("timestamp", timestamp),
+			xlog.NewField("value", value),
+			xlog.NewField("async", !writable),
+		).Debug("wrote datapoint")
+	}
+
+	return commitlog.Series{
 		UniqueIndex: commitLogSeriesUniqueIndex,
 		Namespace:   s.namespace.ID(),
 		ID:          commitLogSeriesID,
 		Tags:        commitLogSeriesTags,
+		Metadata:    commitLogSeriesMetadata,
 		Shard:       s.shard,
-	}
-
-	datapoint := ts.Datapoint{
-		Timestamp: timestamp,
-		Value:     value,
-	}
-
-	return s.commitLogWriter.Write(ctx, series, datapoint,
-		unit, annotation)
+	}, nil
 }
 
 func (s *dbShard) ReadEncoded(
@@ -896,6 +1027,15 @@ func (s *dbShard) ReadEncoded(
 	id ident.ID,
 	start, end time.Time,
 ) ([][]xio.BlockReader, error) {
+	if loglevel.DebugTargeted(s.logger, s.shard, id.Bytes()) {
+		s.logger.WithFields(
+			xlog.NewField(loglevel.ShardField, s.shard),
+			xlog.NewField(loglevel.SeriesIDField, id.String()),
+			xlog.NewField("start", start),
+			xlog.NewField("end", end),
+		).Debug("reading series")
+	}
+
 	s.RLock()
 	entry, _, err := s.lookupEntryWithLock(id)
 	if entry != nil {
@@ -990,6 +1130,32 @@ func (s *dbShard) tryRetrieveWritableSeries(id ident.ID) (
 	return nil, opts, nil
 }
 
+// tryRetrieveWritableSeriesBatch retrieves the entries of the series of the
+// writes at the given indexes with a single acquisition of the shard lock, the
+// entry of a write is nil if its series does not exist yet.
+func (s *dbShard) tryRetrieveWritableSeriesBatch(
+	writes []BatchWrite,
+	indexes []int,
+	errs []error,
+) ([]*lookup.Entry, writableSeriesOptions) {
+	entries := make([]*lookup.Entry, len(indexes))
+	s.RLock()
+	opts := writableSeriesOptions{
+		writeNewSeriesAsync: s.currRuntimeOptions.writeNewSeriesAsync,
+	}
+	for i, idx := range indexes {
+		entry, _, err := s.lookupEntryWithLock(writes[idx].ID)
+		if err == nil {
+			entry.IncrementReaderWriterCount()
+			entries[i] = entry
+		} else if err != errShardEntryNotFound {
+			errs[idx] = err
+		}
+	}
+	s.RUnlock()
+	return entries, opts
+}
+
 func (s *dbShard) newShardEntry(
 	id ident.ID,
 	tagsArgOpts tagsArgOptions,
@@ -999,23 +1165,15 @@ func (s *dbShard) newShardEntry(
 	// finalized.
 	// Since series are purged so infrequently the overhead of not releasing
 	// back an ID to a pool is amortized over a long period of time.
+	// When the shard has a series metadata arena the ID and tags are instead
+	// copied into a single region of the arena, which is released along with
+	// the entry when the series is purged.
 	var (
 		seriesID   ident.BytesID
 		seriesTags ident.Tags
+		metadata   xarena.SeriesMetadata
 		err        error
 	)
-	if id.IsNoFinalize() {
-		// If the ID is already marked as NoFinalize, meaning it won't be returned
-		// to any pools, then we can directly take reference to it.
-		// We make sure to use ident.BytesID for this ID to avoid inc/decref when
-		// accessing the ID since it's not pooled and therefore the safety is not
-		// required.
-		seriesID = ident.BytesID(id.Bytes())
-	} else {
-		seriesID = ident.BytesID(append([]byte(nil), id.Bytes()...))
-		seriesID.NoFinalize()
-	}
-
 	switch tagsArgOpts.arg {
 	case tagsIterArg:
 		// NB(r): Take a duplicate so that we don't double close the tag iterator
@@ -1026,19 +1184,39 @@ func (s *dbShard) newShardEntry(
 		if tagsIter.CurrentIndex() != 0 {
 			return nil, errNewShardEntryTagsIterNotAtIndexZero
 		}
-		seriesTags, err = convert.TagsFromTagsIter(
-			seriesID, tagsIter, s.identifierPool)
+		if s.seriesMetadataArena != nil {
+			metadata, err = xarena.NewSeriesMetadata(s.seriesMetadataArena,
+				id.Bytes(), tagsIter)
+			seriesID, seriesTags = metadata.ID(), metadata.Tags()
+		} else {
+			seriesID = newSeriesID(id)
+			seriesTags, err = convert.TagsFromTagsIter(
+				seriesID, tagsIter, s.identifierPool)
+		}
 		tagsIter.Close()
 		if err != nil {
 			return nil, err
 		}
 
 		if err := convert.ValidateMetric(seriesID, seriesTags); err != nil {
+			metadata.Release()
 			return nil, err
 		}
 
 	case tagsArg:
-		seriesTags = tagsArgOpts.tags
+		if s.seriesMetadataArena != nil {
+			tagsIter := ident.NewTagsIterator(tagsArgOpts.tags)
+			metadata, err = xarena.NewSeriesMetadata(s.seriesMetadataArena,
+				id.Bytes(), tagsIter)
+			tagsIter.Close()
+			if err != nil {
+				return nil, err
+			}
+			seriesID = metadata.ID()
+		} else {
+			seriesID = newSeriesID(id)
+			seriesTags = tagsArgOpts.tags
+		}
 
 	default:
 		return nil, errNewShardEntryTagsTypeInvalid
@@ -1048,14 +1226,57 @@ func (s *dbShard) newShardEntry(
 	series := s.seriesPool.Get()
 	series.Reset(seriesID, seriesTags, s.seriesBlockRetriever,
 		s.seriesOnRetrieveBlock, s, s.seriesOpts)
+	if !metadata.IsZero() {
+		// The tags are held by the metadata rather than the series.
+		series.SetMetadata(metadata)
+	}
 	uniqueIndex := s.increasingIndex.nextIndex()
 	return lookup.NewEntry(series, uniqueIndex), nil
 }
 
+// newShardSeriesMetadataArena returns the series metadata arena of the shard,
+// nil if series metadata is not allocated from arenas.
+func newShardSeriesMetadataArena(opts Options, shard uint32) xarena.BytesArena {
+	arenas := opts.SeriesMetadataArenas()
+	if arenas == nil {
+		return nil
+	}
+	return arenas.Arena(shard)
+}
+
+// seriesTagsOrMetadata returns the tags of the series of the entry, or its
+// arena held metadata in place of its tags so that the tags are not
+// materialized on every write.
+func (s *dbShard) seriesTagsOrMetadata(
+	entry *lookup.Entry,
+) (ident.Tags, xarena.SeriesMetadata) {
+	if s.seriesMetadataArena != nil {
+		if metadata := entry.Series.Metadata(); !metadata.IsZero() {
+			return ident.Tags{}, metadata
+		}
+	}
+	return entry.Series.Tags(), xarena.SeriesMetadata{}
+}
+
+func newSeriesID(id ident.ID) ident.BytesID {
+	if id.IsNoFinalize() {
+		// If the ID is already marked as NoFinalize, meaning it won't be returned
+		// to any pools, then we can directly take reference to it.
+		// We make sure to use ident.BytesID for this ID to avoid inc/decref when
+		// accessing the ID since it's not pooled and therefore the safety is not
+		// required.
+		return ident.BytesID(id.Bytes())
+	}
+	seriesID := ident.BytesID(append([]byte(nil), id.Bytes()...))
+	seriesID.NoFinalize()
+	return seriesID
+}
+
 type insertAsyncResult struct {
-	wg         *sync.WaitGroup
-	copiedID   ident.ID
-	copiedTags ident.Tags
+	wg             *sync.WaitGroup
+	copiedID       ident.ID
+	copiedTags     ident.Tags
+	copiedMetadata xarena.SeriesMetadata
 	// entry is not guaranteed to be the final entry
 	// inserted into the shard map in case there is already
 	// an existing entry waiting in the insert queue
@@ -1119,12 +1340,14 @@ func (s *dbShard) insertSeriesAsyncBatched(
 		entry: entry,
 		opts:  opts,
 	})
+	copiedTags, copiedMetadata := s.seriesTagsOrMetadata(entry)
 	return insertAsyncResult{
 		wg: wg,
 		// Make sure to return the copied ID from the new series
-		copiedID:   entry.Series.ID(),
-		copiedTags: entry.Series.Tags(),
-		entry:      entry,
+		copiedID:       entry.Series.ID(),
+		copiedTags:     copiedTags,
+		copiedMetadata: copiedMetadata,
+		entry:          entry,
 	}, err
 }
 
@@ -1180,6 +1403,9 @@ func (s *dbShard) insertSeriesSync(
 	if s.newSeriesBootstrapped {
 		_, err := entry.Series.Bootstrap(nil)
 		if err != nil {
+			if s.seriesMetadataArena != nil {
+				entry.ReleaseMetadata()
+			}
 			entry = nil // Don't increment the writer count for this series
 			return nil, err
 		}
@@ -1234,7 +1460,11 @@ func (s *dbShard) insertSeriesBatch(inserts []dbShardInsert) error {
 		// for the same ID.
 		entry, _, err := s.lookupEntryWithLock(inserts[i].entry.Series.ID())
 		if entry != nil {
-			// Already exists so update the entry we're pointed at for this insert
+			// Already exists so update the entry we're pointed at for this insert,
+			// the entry created for the insert is never inserted
+			if entry != inserts[i].entry && s.seriesMetadataArena != nil {
+				inserts[i].entry.ReleaseMetadata()
+			}
 			inserts[i].entry = entry
 		}
 
@@ -1955,6 +2185,25 @@ func (s *dbShard) FlushState(blockStart time.Time) fileOpState {
 	return state
 }
 
+func (s *dbShard) FlushStatus() ShardFlushStatus {
+	var status ShardFlushStatus
+	s.flushState.RLock()
+	for blockStart, state := range s.flushState.statesByTime {
+		switch state.Status {
+		case fileOpSuccess:
+			if t := blockStart.ToTime(); t.After(status.LastFlushedBlockStart) {
+				status.LastFlushedBlockStart = t
+			}
+		case fileOpFailed:
+			status.NumFailedBlocks++
+		}
+	}
+	s.flushState.RUnlock()
+
+	status.Snapshotting, status.LastSuccessfulSnapshot = s.SnapshotState()
+	return status
+}
+
 func (s *dbShard) markFlushStateSuccessOrError(blockStart time.Time, err error) error {
 	// Track flush state for block state
 	if err == nil {
@@ -0,0 +1,310 @@
+// Copyright (c) 2018 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package storage
+
+import (
+	"bytes"
+	"container/heap"
+	"fmt"
+	"io"
+	"time"
+
+	"github.com/m3db/m3/src/dbnode/encoding"
+	"github.com/m3db/m3/src/dbnode/storage/index/convert"
+	"github.com/m3db/m3/src/dbnode/storage/series/lookup"
+	"github.com/m3db/m3/src/dbnode/ts"
+	"github.com/m3db/m3/src/dbnode/x/xio"
+	"github.com/m3db/m3x/checked"
+	"github.com/m3db/m3x/context"
+	"github.com/m3db/m3x/ident"
+	xtime "github.com/m3db/m3x/time"
+)
+
+// shardBlockSeries is a single series of a shard block being iterated.
+type shardBlockSeries struct {
+	id   ident.ID
+	tags ident.Tags
+	iter encoding.Iterator
+	// data is the series data read from a fileset, if any, released once
+	// the series has been iterated.
+	data checked.Bytes
+	at   time.Time
+}
+
+func (s *shardBlockSeries) close() {
+	s.iter.Close()
+	s.tags.Finalize()
+	s.id.Finalize()
+	if s.data != nil {
+		s.data.DecRef()
+		s.data.Finalize()
+		s.data = nil
+	}
+}
+
+// shardBlockSeriesHeap is a min heap of series ordered by the timestamp of
+// their current datapoint and then by series ID.
+type shardBlockSeriesHeap []*shardBlockSeries
+
+func (h shardBlockSeriesHeap) Len() int {
+	return len(h)
+}
+
+func (h shardBlockSeriesHeap) Less(i, j int) bool {
+	if !h[i].at.Equal(h[j].at) {
+		return h[i].at.Before(h[j].at)
+	}
+	return bytes.Compare(h[i].id.Bytes(), h[j].id.Bytes()) < 0
+}
+
+func (h shardBlockSeriesHeap) Swap(i, j int) {
+	h[i], h[j] = h[j], h[i]
+}
+
+func (h *shardBlockSeriesHeap) Push(x interface{}) {
+	*h = append(*h, x.(*shardBlockSeries))
+}
+
+func (h *shardBlockSeriesHeap) Pop() interface{} {
+	old := *h
+	n := len(old)
+	x := old[n-1]
+	old[n-1] = nil
+	*h = old[:n-1]
+	return x
+}
+
+type shardBlockIterator struct {
+	pending []*shardBlockSeries
+	heap    shardBlockSeriesHeap
+	current *shardBlockSeries
+	err     error
+	closed  bool
+}
+
+// newShardBlockIterator returns an iterator that merges the datapoints of
+// the given series in time order, the iterator takes ownership of the series.
+func newShardBlockIterator(series []*shardBlockSeries) ShardBlockIterator {
+	return &shardBlockIterator{pending: series}
+}
+
+func (it *shardBlockIterator) Next() bool {
+	if it.err != nil || it.closed {
+		return false
+	}
+
+	if it.pending != nil {
+		// Lazily move each series to its first datapoint
+		it.heap = make(shardBlockSeriesHeap, 0, len(it.pending))
+		for i, series := range it.pending {
+			it.pending[i] = nil
+			if it.advance(series) {
+				it.heap = append(it.heap, series)
+			}
+		}
+		it.pending = nil
+		heap.Init(&it.heap)
+	} else if it.current != nil {
+		// Move the series of the previous datapoint along
+		if it.advance(it.current) {
+			heap.Fix(&it.heap, 0)
+		} else {
+			heap.Pop(&it.heap)
+		}
+	}
+	it.current = nil
+
+	if it.err != nil || len(it.heap) == 0 {
+		return false
+	}
+	it.current = it.heap[0]
+	return true
+}
+
+// advance moves the series to its next datapoint, closing the series if it
+// has no more datapoints.
+func (it *shardBlockIterator) advance(series *shardBlockSeries) bool {
+	if series.iter.Next() {
+		dp, _, _ := series.iter.Current()
+		series.at = dp.Timestamp
+		return true
+	}
+	if err := series.iter.Err(); err != nil && it.err == nil {
+		it.err = err
+	}
+	series.close()
+	return false
+}
+
+func (it *shardBlockIterator) Current() (
+	ident.ID,
+	ident.Tags,
+	ts.Datapoint,
+	xtime.Unit,
+	ts.Annotation,
+) {
+	dp, unit, annotation := it.current.iter.Current()
+	return it.current.id, it.current.tags, dp, unit, annotation
+}
+
+func (it *shardBlockIterator) Err() error {
+	return it.err
+}
+
+func (it *shardBlockIterator) Close() {
+	if it.closed {
+		return
+	}
+	it.closed = true
+	for _, series := range it.pending {
+		series.close()
+	}
+	for _, series := range it.heap {
+		series.close()
+	}
+	it.pending = nil
+	it.heap = nil
+	it.current = nil
+}
+
+// closeShardBlockSeries closes series collected for a shard block iterator
+// that could not be created.
+func closeShardBlockSeries(series []*shardBlockSeries) {
+	for _, s := range series {
+		s.close()
+	}
+}
+
+// IterateBlock returns an iterator over the datapoints of all series of the
+// shard within a block in time order. Flushed blocks are read from their
+// fileset and other blocks from the series held in memory, the iterator
+// must be closed before the context is closed.
+func (s *dbShard) IterateBlock(
+	ctx context.Context,
+	blockStart time.Time,
+) (ShardBlockIterator, error) {
+	blockSize := s.namespace.Options().RetentionOptions().BlockSize()
+	blockStart = blockStart.Truncate(blockSize)
+	if s.FlushState(blockStart).Status == fileOpSuccess {
+		return s.iterateFlushedBlock(blockStart)
+	}
+	return s.iterateBufferedBlock(ctx, blockStart, blockSize)
+}
+
+func (s *dbShard) iterateFlushedBlock(
+	blockStart time.Time,
+) (ShardBlockIterator, error) {
+	reader, err := s.namespaceReaderMgr.get(s.shard, blockStart, readerPosition{})
+	if err != nil {
+		return nil, err
+	}
+
+	var series []*shardBlockSeries
+	for {
+		id, tagsIter, data, _, err := reader.Read()
+		if err == io.EOF {
+			break
+		}
+		if err == nil {
+			var tags ident.Tags
+			tags, err = convert.TagsFromTagsIter(id, tagsIter, s.identifierPool)
+			tagsIter.Close()
+			if err != nil {
+				id.Finalize()
+				data.Finalize()
+			} else {
+				data.IncRef()
+				iter := s.opts.ReaderIteratorPool().Get()
+				iter.Reset(bytes.NewReader(data.Bytes()))
+				series = append(series, &shardBlockSeries{
+					id:   id,
+					tags: tags,
+					iter: iter,
+					data: data,
+				})
+				continue
+			}
+		}
+
+		// Best effort to close the reader on a read error
+		closeShardBlockSeries(series)
+		if err := reader.Close(); err != nil {
+			s.logger.Errorf("could not close reader on unexpected err: %v", err)
+		}
+		s.namespaceReaderMgr.put(reader)
+		return nil, fmt.Errorf("could not read data for block %v: %v",
+			blockStart, err)
+	}
+
+	if err := reader.Close(); err != nil {
+		closeShardBlockSeries(series)
+		return nil, fmt.Errorf("could not close reader for block %v: %v",
+			blockStart, err)
+	}
+	s.namespaceReaderMgr.put(reader)
+	return newShardBlockIterator(series), nil
+}
+
+func (s *dbShard) iterateBufferedBlock(
+	ctx context.Context,
+	blockStart time.Time,
+	blockSize time.Duration,
+) (ShardBlockIterator, error) {
+	var (
+		series   []*shardBlockSeries
+		blockEnd = blockStart.Add(blockSize)
+		readErr  error
+	)
+	err := s.forEachShardEntry(func(entry *lookup.Entry) bool {
+		readers, err := entry.Series.ReadEncoded(ctx, blockStart, blockEnd)
+		if err != nil {
+			readErr = err
+			return false
+		}
+		if len(readers) == 0 {
+			return true
+		}
+
+		iter := s.opts.MultiReaderIteratorPool().Get()
+		iter.ResetSliceOfSlices(
+			xio.NewReaderSliceOfSlicesFromBlockReadersIterator(readers))
+
+		// Copy the ID and tags as the series may be expired while iterating
+		tags := s.identifierPool.Tags()
+		for _, tag := range entry.Series.Tags().Values() {
+			tags.Append(s.identifierPool.CloneTag(tag))
+		}
+		series = append(series, &shardBlockSeries{
+			id:   s.identifierPool.Clone(entry.Series.ID()),
+			tags: tags,
+			iter: iter,
+		})
+		return true
+	})
+	if err == nil {
+		err = readErr
+	}
+	if err != nil {
+		closeShardBlockSeries(series)
+		return nil, err
+	}
+	return newShardBlockIterator(series), nil
+}
@@ -0,0 +1,112 @@
+// Copyright (c) 2018 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package storage
+
+import (
+	"testing"
+	"time"
+
+	"github.com/m3db/m3x/ident"
+	xtime "github.com/m3db/m3x/time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestShardIterateBlockBufferedTimeOrdered(t *testing.T) {
+	opts := testDatabaseOptions()
+	blockSize := defaultTestRetentionOpts.BlockSize()
+	blockStart := time.Now().Truncate(blockSize)
+	now := blockStart.Add(10 * time.Second)
+	opts = opts.SetClockOptions(opts.ClockOptions().SetNowFn(func() time.Time {
+		return now
+	}))
+	shard := testDatabaseShard(t, opts)
+	defer shard.Close()
+
+	ctx := opts.ContextPool().Get()
+	defer ctx.Close()
+
+	writes := []struct {
+		id    string
+		at    time.Duration
+		value float64
+	}{
+		{"foo", 1 * time.Second, 1},
+		{"bar", 2 * time.Second, 2},
+		{"foo", 3 * time.Second, 3},
+		{"baz", 3 * time.Second, 4},
+		{"bar", 5 * time.Second, 5},
+		{"baz", 4 * time.Second, 6},
+	}
+	for _, w := range writes {
+		err := shard.Write(ctx, ident.StringID(w.id), blockStart.Add(w.at),
+			w.value, xtime.Second, nil)
+		require.NoError(t, err)
+	}
+
+	type result struct {
+		id    string
+		at    time.Duration
+		value float64
+	}
+	expected := []result{
+		{"foo", 1 * time.Second, 1},
+		{"bar", 2 * time.Second, 2},
+		{"baz", 3 * time.Second, 4},
+		{"foo", 3 * time.Second, 3},
+		{"baz", 4 * time.Second, 6},
+		{"bar", 5 * time.Second, 5},
+	}
+
+	iter, err := shard.IterateBlock(ctx, now)
+	require.NoError(t, err)
+
+	var actual []result
+	for iter.Next() {
+		id, _, dp, unit, _ := iter.Current()
+		assert.Equal(t, xtime.Second, unit)
+		actual = append(actual, result{
+			id:    id.String(),
+			at:    dp.Timestamp.Sub(blockStart),
+			value: dp.Value,
+		})
+	}
+	require.NoError(t, iter.Err())
+	iter.Close()
+
+	assert.Equal(t, expected, actual)
+}
+
+func TestShardIterateBlockEmpty(t *testing.T) {
+	opts := testDatabaseOptions()
+	shard := testDatabaseShard(t, opts)
+	defer shard.Close()
+
+	ctx := opts.ContextPool().Get()
+	defer ctx.Close()
+
+	iter, err := shard.IterateBlock(ctx, opts.ClockOptions().NowFn()())
+	require.NoError(t, err)
+	require.False(t, iter.Next())
+	require.NoError(t, iter.Err())
+	iter.Close()
+}
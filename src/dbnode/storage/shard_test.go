@@ -41,10 +41,12 @@ import (
 	"github.com/m3db/m3/src/dbnode/storage/series/lookup"
 	"github.com/m3db/m3/src/dbnode/ts"
 	xmetrics "github.com/m3db/m3/src/dbnode/x/metrics"
+	"github.com/m3db/m3/src/dbnode/x/xarena"
 	"github.com/m3db/m3/src/dbnode/x/xio"
 	"github.com/m3db/m3x/checked"
 	"github.com/m3db/m3x/context"
 	"github.com/m3db/m3x/ident"
+	"github.com/m3db/m3x/instrument"
 	xtest "github.com/m3db/m3x/test"
 	xtime "github.com/m3db/m3x/time"
 
@@ -138,6 +140,27 @@ func TestShardFlushStateNotStarted(t *testing.T) {
 	}
 }
 
+func TestShardFlushStatus(t *testing.T) {
+	opts := testDatabaseOptions()
+	s := testDatabaseShard(t, opts)
+	defer s.Close()
+
+	assert.Equal(t, ShardFlushStatus{}, s.FlushStatus())
+
+	var (
+		blockSize = defaultTestRetentionOpts.BlockSize()
+		start     = time.Now().Truncate(blockSize)
+	)
+	s.markFlushStateSuccess(start.Add(-2 * blockSize))
+	s.markFlushStateSuccess(start.Add(-blockSize))
+	s.markFlushStateFail(start)
+
+	status := s.FlushStatus()
+	assert.True(t, start.Add(-blockSize).Equal(status.LastFlushedBlockStart))
+	assert.Equal(t, 1, status.NumFailedBlocks)
+	assert.False(t, status.Snapshotting)
+}
+
 func TestShardBootstrapWithError(t *testing.T) {
 	ctrl := gomock.NewController(t)
 	defer ctrl.Finish()
@@ -471,6 +494,123 @@ func TestShardTick(t *testing.T) {
 	require.True(t, ok)
 }
 
+func TestShardWriteBatch(t *testing.T) {
+	opts := testDatabaseOptions()
+	shard := testDatabaseShard(t, opts)
+	defer shard.Close()
+
+	ctx := context.NewContext()
+	defer ctx.Close()
+
+	nowFn := opts.ClockOptions().NowFn()
+	shard.Write(ctx, ident.StringID("foo"), nowFn(), 1.0, xtime.Second, nil)
+
+	writes := []BatchWrite{
+		{ID: ident.StringID("foo"), Timestamp: nowFn(), Value: 2.0, Unit: xtime.Second},
+		{ID: ident.StringID("other"), Timestamp: nowFn(), Value: 3.0, Unit: xtime.Second},
+		{ID: ident.StringID("bar"), Timestamp: nowFn(), Value: 4.0, Unit: xtime.Second},
+	}
+	errs := make([]error, len(writes))
+
+	// Only the writes at the given indexes belong to the shard
+	commitLogWrites := shard.WriteBatch(ctx, writes, []int{0, 2}, false, errs, nil)
+	for _, err := range errs {
+		require.NoError(t, err)
+	}
+
+	require.Equal(t, 2, len(commitLogWrites))
+	assert.Equal(t, "foo", commitLogWrites[0].Series.ID.String())
+	assert.Equal(t, 2.0, commitLogWrites[0].Datapoint.Value)
+	assert.Equal(t, "bar", commitLogWrites[1].Series.ID.String())
+	assert.Equal(t, 4.0, commitLogWrites[1].Datapoint.Value)
+	for _, write := range commitLogWrites {
+		assert.Equal(t, shard.ID(), write.Series.Shard)
+		assert.True(t, defaultTestNs1ID.Equal(write.Series.Namespace))
+	}
+
+	shard.RLock()
+	_, _, err := shard.lookupEntryWithLock(ident.StringID("bar"))
+	require.NoError(t, err)
+	_, _, err = shard.lookupEntryWithLock(ident.StringID("other"))
+	require.Equal(t, errShardEntryNotFound, err)
+	shard.RUnlock()
+}
+
+func TestShardSeriesMetadataArena(t *testing.T) {
+	scope := tally.NewTestScope("", nil)
+	arenas := xarena.NewBytesArenas(xarena.NewOptions().
+		SetInstrumentOptions(instrument.NewOptions().SetMetricsScope(scope)))
+	opts := testDatabaseOptions().SetSeriesMetadataArenas(arenas)
+	shard := testDatabaseShard(t, opts)
+	defer shard.Close()
+
+	id := ident.StringID("cpu.host=a")
+	tags := ident.NewTags(
+		ident.StringTag("__name__", "cpu"),
+		ident.StringTag("host", "a"),
+		ident.StringTag("dc", "east"))
+	tagsIter := ident.NewTagsIterator(tags)
+	defer tagsIter.Close()
+
+	entry, err := shard.insertSeriesSync(id, newTagsIterArg(tagsIter), insertSync)
+	require.NoError(t, err)
+	require.Equal(t, "cpu.host=a", entry.Series.ID().String())
+
+	var actual []string
+	for _, tag := range entry.Series.Tags().Values() {
+		actual = append(actual, tag.Name.String()+"="+tag.Value.String())
+	}
+	require.Equal(t, []string{"__name__=cpu", "host=a", "dc=east"}, actual)
+
+	// Only the bytes of the ID, the tags not contained in it and the offsets
+	// of the tags are allocated, from the arena of the shard
+	liveBytes := func() float64 {
+		key := fmt.Sprintf("live-bytes+shard=%d", shard.ID())
+		return scope.Snapshot().Gauges()[key].Value()
+	}
+	expected := len("cpu.host=a") + len("__name__") + len("dc") + len("east") +
+		3*4*4
+	require.Equal(t, float64(expected), liveBytes())
+
+	entry.ReleaseMetadata()
+	require.Equal(t, float64(0), liveBytes())
+}
+
+func TestShardCompactSeriesMetadata(t *testing.T) {
+	arenas := xarena.NewBytesArenas(xarena.NewOptions().SetSlabSize(1024))
+	opts := testDatabaseOptions().SetSeriesMetadataArenas(arenas)
+	shard := testDatabaseShard(t, opts)
+	defer shard.Close()
+
+	tags := ident.NewTags(ident.StringTag("__name__", "cpu"))
+	entry, err := shard.insertSeriesSync(ident.StringID("cpu"),
+		newTagsArg(tags), insertSync)
+	require.NoError(t, err)
+
+	// Fill the slab of the series then release the other regions of it, so
+	// the series is the only one left on a sealed slab.
+	arena := arenas.Arena(shard.ID())
+	var regions []xarena.Region
+	for i := 0; i < 8; i++ {
+		regions = append(regions, arena.Allocate(128))
+	}
+	for _, region := range regions {
+		region.Release()
+	}
+	require.True(t, entry.Series.Metadata().Compactable())
+
+	shard.compactSeriesMetadata([]*lookup.Entry{entry})
+	require.False(t, entry.Series.Metadata().Compactable())
+	assert.Equal(t, "cpu", entry.Series.ID().String())
+	assert.True(t, tags.Equal(entry.Series.Tags()))
+
+	shard.RLock()
+	found, _, err := shard.lookupEntryWithLock(ident.StringID("cpu"))
+	shard.RUnlock()
+	require.NoError(t, err)
+	assert.True(t, found == entry)
+}
+
 func TestShardWriteAsync(t *testing.T) {
 	testReporter := xmetrics.NewTestStatsReporter(xmetrics.NewTestStatsReporterOptions())
 	scope, closer := tally.NewRootScope(tally.ScopeOptions{
@@ -0,0 +1,114 @@
+// Copyright (c) 2018 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package stats
+
+import (
+	"sort"
+)
+
+// ClusterStats are the statistics of the namespaces of a cluster.
+type ClusterStats struct {
+	Namespaces []ClusterNamespaceStats `json:"namespaces"`
+}
+
+// ClusterNamespaceStats are the statistics of a namespace summed across the
+// nodes that reported it, including all replicas. The tick time is that of
+// the least recently ticked node, zero if any node has not ticked it yet.
+type ClusterNamespaceStats struct {
+	NamespaceStats
+	NumNodes int `json:"numNodes"`
+}
+
+// Aggregate sums the statistics of each namespace across nodes, the index,
+// disk and cache statistics are set if any node reported them.
+func Aggregate(nodes []NodeStats) ClusterStats {
+	byName := make(map[string]*ClusterNamespaceStats)
+	for _, node := range nodes {
+		for _, ns := range node.Namespaces {
+			agg, ok := byName[ns.Namespace]
+			if !ok {
+				agg = &ClusterNamespaceStats{
+					NamespaceStats: NamespaceStats{
+						Namespace: ns.Namespace,
+						TickedAt:  ns.TickedAt,
+					},
+				}
+				byName[ns.Namespace] = agg
+			}
+			agg.add(ns)
+		}
+	}
+
+	result := ClusterStats{
+		Namespaces: make([]ClusterNamespaceStats, 0, len(byName)),
+	}
+	for _, agg := range byName {
+		if agg.Cache != nil {
+			agg.Cache.HitRate = HitRate(agg.Cache.Hits, agg.Cache.Misses)
+		}
+		result.Namespaces = append(result.Namespaces, *agg)
+	}
+	sort.Slice(result.Namespaces, func(i, j int) bool {
+		return result.Namespaces[i].Namespace < result.Namespaces[j].Namespace
+	})
+	return result
+}
+
+func (s *ClusterNamespaceStats) add(ns NamespaceStats) {
+	s.NumNodes++
+	if ns.TickedAt.IsZero() || ns.TickedAt.Before(s.TickedAt) {
+		s.TickedAt = ns.TickedAt
+	}
+	s.NumShards += ns.NumShards
+	s.ActiveSeries += ns.ActiveSeries
+	s.Blocks.Active += ns.Blocks.Active
+	s.Blocks.Open += ns.Blocks.Open
+	s.Blocks.Wired += ns.Blocks.Wired
+
+	if ns.Index != nil {
+		if s.Index == nil {
+			s.Index = &IndexStats{}
+		}
+		s.Index.NumBlocks += ns.Index.NumBlocks
+		s.Index.NumSegments += ns.Index.NumSegments
+		s.Index.NumDocs += ns.Index.NumDocs
+	}
+	if ns.Disk != nil {
+		if s.Disk == nil {
+			s.Disk = &DiskStats{}
+		}
+		s.Disk.Bytes += ns.Disk.Bytes
+		s.Disk.DataBytes += ns.Disk.DataBytes
+		s.Disk.IndexBytes += ns.Disk.IndexBytes
+		s.Disk.DataFilesets += ns.Disk.DataFilesets
+		s.Disk.IndexFilesets += ns.Disk.IndexFilesets
+	}
+	if ns.Cache != nil {
+		if s.Cache == nil {
+			s.Cache = &CacheStats{}
+		}
+		s.Cache.Hits += ns.Cache.Hits
+		s.Cache.Misses += ns.Cache.Misses
+		s.Cache.Entries += ns.Cache.Entries
+		s.Cache.Bytes += ns.Cache.Bytes
+		s.Cache.MaxBytes += ns.Cache.MaxBytes
+	}
+}
@@ -0,0 +1,105 @@
+// Copyright (c) 2018 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package stats
+
+import (
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestAggregate(t *testing.T) {
+	var (
+		earlier = time.Date(2018, 6, 1, 12, 0, 0, 0, time.UTC)
+		later   = earlier.Add(time.Minute)
+	)
+	nodes := []NodeStats{
+		{
+			Namespaces: []NamespaceStats{
+				{
+					Namespace:    "metrics",
+					TickedAt:     later,
+					NumShards:    2,
+					ActiveSeries: 100,
+					Blocks:       BlockStats{Active: 10, Open: 2, Wired: 4},
+					Index:        &IndexStats{NumBlocks: 1, NumSegments: 3, NumDocs: 100},
+					Disk:         &DiskStats{Bytes: 30, DataBytes: 20, IndexBytes: 10, DataFilesets: 4, IndexFilesets: 1},
+					Cache:        &CacheStats{Hits: 3, Misses: 1, HitRate: 0.75, Entries: 2, Bytes: 64, MaxBytes: 128},
+				},
+				{Namespace: "aggregated", TickedAt: later, NumShards: 2, ActiveSeries: 5},
+			},
+		},
+		{
+			Namespaces: []NamespaceStats{
+				{Namespace: "aggregated", NumShards: 2},
+				{
+					Namespace:    "metrics",
+					TickedAt:     earlier,
+					NumShards:    2,
+					ActiveSeries: 50,
+					Blocks:       BlockStats{Active: 5, Open: 1, Wired: 2},
+					Index:        &IndexStats{NumBlocks: 1, NumSegments: 2, NumDocs: 50},
+					Disk:         &DiskStats{Bytes: 15, DataBytes: 10, IndexBytes: 5, DataFilesets: 2, IndexFilesets: 1},
+					Cache:        &CacheStats{Hits: 1, Misses: 3, HitRate: 0.25, Entries: 1, Bytes: 32, MaxBytes: 128},
+				},
+			},
+		},
+	}
+
+	result := Aggregate(nodes)
+	require.Equal(t, 2, len(result.Namespaces))
+
+	aggregated := result.Namespaces[0]
+	assert.Equal(t, "aggregated", aggregated.Namespace)
+	assert.Equal(t, 2, aggregated.NumNodes)
+	assert.True(t, aggregated.TickedAt.IsZero())
+	assert.Equal(t, int64(5), aggregated.ActiveSeries)
+	assert.Nil(t, aggregated.Index)
+	assert.Nil(t, aggregated.Disk)
+	assert.Nil(t, aggregated.Cache)
+
+	metrics := result.Namespaces[1]
+	assert.Equal(t, "metrics", metrics.Namespace)
+	assert.Equal(t, 2, metrics.NumNodes)
+	assert.Equal(t, earlier, metrics.TickedAt)
+	assert.Equal(t, 4, metrics.NumShards)
+	assert.Equal(t, int64(150), metrics.ActiveSeries)
+	assert.Equal(t, BlockStats{Active: 15, Open: 3, Wired: 6}, metrics.Blocks)
+	assert.Equal(t, &IndexStats{NumBlocks: 2, NumSegments: 5, NumDocs: 150}, metrics.Index)
+	assert.Equal(t, &DiskStats{Bytes: 45, DataBytes: 30, IndexBytes: 15, DataFilesets: 6, IndexFilesets: 2}, metrics.Disk)
+	assert.Equal(t, &CacheStats{Hits: 4, Misses: 4, HitRate: 0.5, Entries: 3, Bytes: 96, MaxBytes: 256}, metrics.Cache)
+
+	// The namespace stats are inlined in the JSON encoding.
+	data, err := json.Marshal(metrics)
+	require.NoError(t, err)
+	var decoded map[string]interface{}
+	require.NoError(t, json.Unmarshal(data, &decoded))
+	assert.Equal(t, "metrics", decoded["namespace"])
+	assert.Equal(t, float64(2), decoded["numNodes"])
+}
+
+func TestHitRate(t *testing.T) {
+	assert.Equal(t, float64(0), HitRate(0, 0))
+	assert.Equal(t, 0.75, HitRate(3, 1))
+}
@@ -0,0 +1,93 @@
+// Copyright (c) 2018 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+// Package stats defines the statistics a node reports for each namespace it
+// holds and their aggregation into a cluster-wide view.
+package stats
+
+import (
+	"time"
+)
+
+// NodeStats are the statistics of the namespaces held by a node.
+type NodeStats struct {
+	Namespaces []NamespaceStats `json:"namespaces"`
+	Errors     []string         `json:"errors,omitempty"`
+}
+
+// NamespaceStats are the statistics of a namespace. The series, block and
+// index statistics are as of the last tick of the namespace.
+type NamespaceStats struct {
+	Namespace string `json:"namespace"`
+	// TickedAt is the time of the last tick, zero if the namespace has not
+	// been ticked yet.
+	TickedAt     time.Time  `json:"tickedAt"`
+	NumShards    int        `json:"numShards"`
+	ActiveSeries int64      `json:"activeSeries"`
+	Blocks       BlockStats `json:"blocks"`
+	// Index is nil if the namespace is not indexed.
+	Index *IndexStats `json:"index,omitempty"`
+	// Disk is nil if the filesets of the namespace could not be measured.
+	Disk *DiskStats `json:"disk,omitempty"`
+	// Cache is nil if the namespace has no block read cache.
+	Cache *CacheStats `json:"cache,omitempty"`
+}
+
+// BlockStats are the number of in memory series blocks.
+type BlockStats struct {
+	Active int64 `json:"active"`
+	Open   int64 `json:"open"`
+	Wired  int64 `json:"wired"`
+}
+
+// IndexStats are the number of index blocks, segments and documents.
+type IndexStats struct {
+	NumBlocks   int64 `json:"numBlocks"`
+	NumSegments int64 `json:"numSegments"`
+	NumDocs     int64 `json:"numDocs"`
+}
+
+// DiskStats are the bytes and number of flushed filesets on disk.
+type DiskStats struct {
+	Bytes         int64 `json:"bytes"`
+	DataBytes     int64 `json:"dataBytes"`
+	IndexBytes    int64 `json:"indexBytes"`
+	DataFilesets  int   `json:"dataFilesets"`
+	IndexFilesets int   `json:"indexFilesets"`
+}
+
+// CacheStats are the lookups and size of a block read cache.
+type CacheStats struct {
+	Hits     int64   `json:"hits"`
+	Misses   int64   `json:"misses"`
+	HitRate  float64 `json:"hitRate"`
+	Entries  int64   `json:"entries"`
+	Bytes    int64   `json:"bytes"`
+	MaxBytes int64   `json:"maxBytes"`
+}
+
+// HitRate returns the ratio of lookups that were hits, zero if there were
+// no lookups.
+func HitRate(hits, misses int64) float64 {
+	if hits+misses == 0 {
+		return 0
+	}
+	return float64(hits) / float64(hits+misses)
+}
@@ -31,15 +31,23 @@ import (
 	"github.com/m3db/m3/src/dbnode/persist/fs/commitlog"
 	"github.com/m3db/m3/src/dbnode/runtime"
 	"github.com/m3db/m3/src/dbnode/sharding"
+	"github.com/m3db/m3/src/dbnode/storage/backup"
 	"github.com/m3db/m3/src/dbnode/storage/block"
 	"github.com/m3db/m3/src/dbnode/storage/bootstrap"
 	"github.com/m3db/m3/src/dbnode/storage/bootstrap/result"
 	"github.com/m3db/m3/src/dbnode/storage/index"
 	"github.com/m3db/m3/src/dbnode/storage/namespace"
+	"github.com/m3db/m3/src/dbnode/storage/quota"
 	"github.com/m3db/m3/src/dbnode/storage/repair"
+	"github.com/m3db/m3/src/dbnode/storage/replication"
 	"github.com/m3db/m3/src/dbnode/storage/series"
+	"github.com/m3db/m3/src/dbnode/storage/stats"
+	"github.com/m3db/m3/src/dbnode/ts"
+	"github.com/m3db/m3/src/dbnode/x/xarena"
 	"github.com/m3db/m3/src/dbnode/x/xcounter"
 	"github.com/m3db/m3/src/dbnode/x/xio"
+	"github.com/m3db/m3/src/x/loglevel"
+	"github.com/m3db/m3/src/x/resource"
 	"github.com/m3db/m3x/context"
 	"github.com/m3db/m3x/ident"
 	"github.com/m3db/m3x/instrument"
@@ -51,6 +59,20 @@ import (
 // PageToken is an opaque paging token.
 type PageToken []byte
 
+// BatchWrite is a single write of a batch of writes to a namespace.
+type BatchWrite struct {
+	ID         ident.ID
+	Tags       ident.TagIterator
+	Timestamp  time.Time
+	Value      float64
+	Unit       xtime.Unit
+	Annotation []byte
+}
+
+// BatchWriteErrorFn is called with the index and error of each write of a
+// batch that fails, in order of index and before the batch write returns.
+type BatchWriteErrorFn func(index int, err error)
+
 // Database is a time series database
 type Database interface {
 	// Options returns the database options
@@ -103,6 +125,26 @@ type Database interface {
 		annotation []byte,
 	) error
 
+	// WriteBatch writes a batch of values to the database, the writes are
+	// partitioned by shard and the batch is appended to the commit log once.
+	// Errors of individual writes are passed to errFn with their index.
+	WriteBatch(
+		ctx context.Context,
+		namespace ident.ID,
+		writes []BatchWrite,
+		errFn BatchWriteErrorFn,
+	) error
+
+	// WriteTaggedBatch writes a batch of tagged values to the database, the
+	// writes are partitioned by shard and the batch is appended to the commit
+	// log once. Errors of individual writes are passed to errFn with their index.
+	WriteTaggedBatch(
+		ctx context.Context,
+		namespace ident.ID,
+		writes []BatchWrite,
+		errFn BatchWriteErrorFn,
+	) error
+
 	// QueryIDs resolves the given query into known IDs.
 	QueryIDs(
 		ctx context.Context,
@@ -128,6 +170,16 @@ type Database interface {
 		starts []time.Time,
 	) ([]block.FetchBlockResult, error)
 
+	// IterateShardBlock returns an iterator over the datapoints of all series
+	// of a shard within a block in time order, the iterator must be closed
+	// before the context is closed.
+	IterateShardBlock(
+		ctx context.Context,
+		namespace ident.ID,
+		shard uint32,
+		blockStart time.Time,
+	) (ShardBlockIterator, error)
+
 	// FetchBlocksMetadata retrieves blocks metadata for a given shard, returns the
 	// fetched block metadata results, the next page token, and any error encountered.
 	// If we have fetched all the block metadata, we return nil as the next page token.
@@ -171,6 +223,39 @@ type Database interface {
 
 	// BootstrapState captures and returns a snapshot of the databases' bootstrap state.
 	BootstrapState() DatabaseBootstrapState
+
+	// CommitLogQueueLength returns the number of writes queued and not yet
+	// written to the commit log.
+	CommitLogQueueLength() int
+
+	// Drain stops accepting writes, waits for any in progress file operations
+	// to complete and then flushes and snapshots all data and waits for the
+	// commit log queue to empty so that the node can be terminated without
+	// needing to be bootstrapped from its peers. Background file operations
+	// remain disabled once the database is drained.
+	Drain() error
+
+	// DrainState returns the drain state of the database.
+	DrainState() DrainState
+}
+
+// ShardBlockIterator iterates the datapoints of all series of a shard within
+// a block in time order, datapoints with equal timestamps are ordered by
+// series ID.
+type ShardBlockIterator interface {
+	// Next moves to the next datapoint, returning false when there are no
+	// more datapoints or an error occurred.
+	Next() bool
+
+	// Current returns the series and value of the current datapoint, the ID
+	// and tags remain valid until the iterator is closed.
+	Current() (ident.ID, ident.Tags, ts.Datapoint, xtime.Unit, ts.Annotation)
+
+	// Err returns any error encountered while iterating.
+	Err() error
+
+	// Close closes the iterator.
+	Close()
 }
 
 // database is the internal database interface
@@ -182,6 +267,10 @@ type database interface {
 
 	// UpdateOwnedNamespaces updates the namespaces this database owns.
 	UpdateOwnedNamespaces(namespaces namespace.Map) error
+
+	// RemoveDeletedNamespaces closes and removes the namespaces pending
+	// deletion whose delete after time has passed.
+	RemoveDeletedNamespaces(now time.Time) error
 }
 
 // Namespace is a time series database namespace
@@ -195,6 +284,10 @@ type Namespace interface {
 	// NumSeries returns the number of series in the namespace
 	NumSeries() int64
 
+	// Stats returns the statistics of the namespace as of its last tick,
+	// along with those of its block read cache if it has one.
+	Stats() stats.NamespaceStats
+
 	// Shards returns the shard description
 	Shards() []Shard
 }
@@ -214,6 +307,14 @@ type databaseNamespace interface {
 	// Close will release the namespace resources and close the namespace
 	Close() error
 
+	// SetState sets the lifecycle state of the namespace and the time after
+	// which it is deleted if it is pending deletion
+	SetState(state namespace.State, deleteAfter time.Time)
+
+	// State returns the lifecycle state of the namespace and the time after
+	// which it is deleted if it is pending deletion
+	State() (namespace.State, time.Time)
+
 	// AssignShardSet sets the shard set assignment and returns immediately
 	AssignShardSet(shardSet sharding.ShardSet)
 
@@ -247,6 +348,20 @@ type databaseNamespace interface {
 		annotation []byte,
 	) error
 
+	// WriteBatch writes a batch of values to the namespace
+	WriteBatch(
+		ctx context.Context,
+		writes []BatchWrite,
+		errFn BatchWriteErrorFn,
+	) error
+
+	// WriteTaggedBatch writes a batch of tagged values to the namespace
+	WriteTaggedBatch(
+		ctx context.Context,
+		writes []BatchWrite,
+		errFn BatchWriteErrorFn,
+	) error
+
 	// QueryIDs resolves the given query into known IDs.
 	QueryIDs(
 		ctx context.Context,
@@ -269,6 +384,14 @@ type databaseNamespace interface {
 		starts []time.Time,
 	) ([]block.FetchBlockResult, error)
 
+	// IterateShardBlock returns an iterator over the datapoints of all
+	// series of a shard within a block in time order.
+	IterateShardBlock(
+		ctx context.Context,
+		shardID uint32,
+		blockStart time.Time,
+	) (ShardBlockIterator, error)
+
 	// FetchBlocksMetadata retrieves the blocks metadata.
 	FetchBlocksMetadata(
 		ctx context.Context,
@@ -345,6 +468,9 @@ type Shard interface {
 
 	// BootstrapState returns the shards' bootstrap state.
 	BootstrapState() BootstrapState
+
+	// FlushStatus returns a summary of the shards' flushes and snapshots.
+	FlushStatus() ShardFlushStatus
 }
 
 type databaseShard interface {
@@ -381,6 +507,19 @@ type databaseShard interface {
 		annotation []byte,
 	) error
 
+	// WriteBatch writes the writes of a batch at the given indexes to series
+	// of the shard without writing them to the commit log. The error of each
+	// failed write is set in errs at its index and the commit log writes of
+	// the successful writes are appended to commitLogWrites and returned.
+	WriteBatch(
+		ctx context.Context,
+		writes []BatchWrite,
+		indexes []int,
+		shouldReverseIndex bool,
+		errs []error,
+		commitLogWrites []commitlog.BatchWrite,
+	) []commitlog.BatchWrite
+
 	ReadEncoded(
 		ctx context.Context,
 		id ident.ID,
@@ -394,6 +533,13 @@ type databaseShard interface {
 		starts []time.Time,
 	) ([]block.FetchBlockResult, error)
 
+	// IterateBlock returns an iterator over the datapoints of all series
+	// within a block in time order.
+	IterateBlock(
+		ctx context.Context,
+		blockStart time.Time,
+	) (ShardBlockIterator, error)
+
 	// FetchBlocksMetadata retrieves the blocks metadata.
 	FetchBlocksMetadata(
 		ctx context.Context,
@@ -493,11 +639,13 @@ type namespaceIndex interface {
 // namespaceIndexTickResult are details about the work performed by the namespaceIndex
 // during a Tick().
 type namespaceIndexTickResult struct {
-	NumBlocks        int64
-	NumBlocksSealed  int64
-	NumBlocksEvicted int64
-	NumSegments      int64
-	NumTotalDocs     int64
+	NumBlocks            int64
+	NumBlocksSealed      int64
+	NumBlocksEvicted     int64
+	NumSegments          int64
+	NumSegmentsCompacted int64
+	NumSegmentsUnpinned  int64
+	NumTotalDocs         int64
 }
 
 // namespaceIndexInsertQueue is a queue used in-front of the indexing component
@@ -843,11 +991,65 @@ type Options interface {
 	// FetchBlocksMetadataResultsPool returns the fetchBlocksMetadataResultsPool.
 	FetchBlocksMetadataResultsPool() block.FetchBlocksMetadataResultsPool
 
+	// SetWriteBatchWorkerPool sets the worker pool that runs the per shard
+	// pipelines of batched writes.
+	SetWriteBatchWorkerPool(value xsync.WorkerPool) Options
+
+	// WriteBatchWorkerPool returns the worker pool that runs the per shard
+	// pipelines of batched writes.
+	WriteBatchWorkerPool() xsync.WorkerPool
+
+	// SetSeriesMetadataArenas sets the arenas that the IDs and tags of new
+	// series of each shard are allocated from, when nil they are allocated
+	// individually.
+	SetSeriesMetadataArenas(value xarena.BytesArenas) Options
+
+	// SeriesMetadataArenas returns the arenas that the IDs and tags of new
+	// series of each shard are allocated from.
+	SeriesMetadataArenas() xarena.BytesArenas
+
 	// SetQueryIDsWorkerPool sets the QueryIDs worker pool.
 	SetQueryIDsWorkerPool(value xsync.WorkerPool) Options
 
 	// QueryIDsWorkerPool returns the QueryIDs worker pool.
 	QueryIDsWorkerPool() xsync.WorkerPool
+
+	// SetBackupManager sets the backup manager, if nil then flushed
+	// filesets are not backed up.
+	SetBackupManager(value *backup.Manager) Options
+
+	// BackupManager returns the backup manager.
+	BackupManager() *backup.Manager
+
+	// SetReplicator sets the replicator, if nil then writes are not
+	// replicated to a standby cluster.
+	SetReplicator(value *replication.Replicator) Options
+
+	// Replicator returns the replicator.
+	Replicator() *replication.Replicator
+
+	// SetDiskQuotaTracker sets the disk quota tracker, if nil then disk
+	// usage is not tracked and namespaces have no disk quotas.
+	SetDiskQuotaTracker(value *quota.Tracker) Options
+
+	// DiskQuotaTracker returns the disk quota tracker.
+	DiskQuotaTracker() *quota.Tracker
+
+	// SetLogLevels sets the registry of the subsystem log levels and debug
+	// targets, if nil then log levels cannot be changed at runtime.
+	SetLogLevels(value *loglevel.Registry) Options
+
+	// LogLevels returns the registry of the subsystem log levels and debug
+	// targets.
+	LogLevels() *loglevel.Registry
+
+	// SetResourceReporter sets the reporter of the memory, file descriptors
+	// and goroutines used by the node, if nil then resource usage is not
+	// reported.
+	SetResourceReporter(value *resource.Reporter) Options
+
+	// ResourceReporter returns the resource usage reporter.
+	ResourceReporter() *resource.Reporter
 }
 
 // DatabaseBootstrapState stores a snapshot of the bootstrap state for all shards across all
@@ -875,3 +1077,29 @@ const (
 	// Bootstrapped indicates a bootstrap process has completed.
 	Bootstrapped
 )
+
+// DrainState is an enum representing the possible drain states for a database.
+type DrainState int
+
+const (
+	// DrainNotStarted indicates the database is not draining and accepts writes.
+	DrainNotStarted DrainState = iota
+	// Draining indicates the database is rejecting writes and persisting data.
+	Draining
+	// Drained indicates the database has persisted all data and is ready to
+	// be terminated.
+	Drained
+)
+
+// ShardFlushStatus is a summary of the flushes and snapshots of a shard.
+type ShardFlushStatus struct {
+	// LastFlushedBlockStart is the start of the latest block that was
+	// successfully flushed, zero if no block has been flushed.
+	LastFlushedBlockStart time.Time
+	// NumFailedBlocks is the number of blocks whose last flush failed.
+	NumFailedBlocks int
+	// Snapshotting is whether the shard is currently snapshotting.
+	Snapshotting bool
+	// LastSuccessfulSnapshot is the time of the last successful snapshot.
+	LastSuccessfulSnapshot time.Time
+}
@@ -0,0 +1,276 @@
+// Copyright (c) 2018 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package xarena
+
+import (
+	"strconv"
+	"sync"
+
+	"github.com/uber-go/tally"
+)
+
+const (
+	// maxSlabFraction is the fraction of a slab above which regions are
+	// allocated on their own rather than from a slab.
+	maxSlabFraction = 8
+
+	// compactSlabFraction is the fraction of a sealed slab below which the
+	// live bytes of the slab are worth moving to another slab, so that a
+	// few long lived regions do not keep the whole slab from being reclaimed.
+	compactSlabFraction = 4
+)
+
+// BytesArena allocates long lived byte slices from large slabs. Packing many
+// small slices into a single pointer free allocation means the garbage
+// collector tracks one object per slab rather than one per slice.
+//
+// Slabs are never recycled, since callers may hand out references to the
+// bytes of a region that outlive it. Releasing regions accounts for the bytes
+// and slabs still in use, once every region of a slab is released the arena
+// drops its reference to it and it is reclaimed by the garbage collector
+// when no longer referenced elsewhere. Since a single live region keeps its
+// whole slab alive, owners compact long lived regions by copying the regions
+// reported as Compactable to new regions and releasing them.
+type BytesArena interface {
+	// Allocate returns a region of size bytes.
+	Allocate(size int) Region
+
+	// Usage returns the memory held by the arena.
+	Usage() Usage
+}
+
+// Usage is the memory held by a BytesArena.
+type Usage struct {
+	// LiveBytes are the bytes of the regions not yet released.
+	LiveBytes int
+
+	// LiveSlabs are the slabs with regions not yet released, including the
+	// slab regions are currently allocated from.
+	LiveSlabs int
+
+	// SlabSize is the size of each slab.
+	SlabSize int
+}
+
+// Region is a range of bytes allocated from a BytesArena.
+type Region struct {
+	// Bytes are the bytes of the region.
+	Bytes []byte
+
+	arena *bytesArena
+	slab  *slab
+}
+
+// Release releases the region back to its arena, a region must be released
+// at most once. Releasing the zero value is a no-op.
+func (r Region) Release() {
+	if r.arena == nil {
+		return
+	}
+	r.arena.release(r)
+}
+
+// Compactable returns whether the region is on a slab that is no longer
+// allocated from and is mostly released, in which case copying the region
+// to a new region and releasing it lets the slab be reclaimed.
+func (r Region) Compactable() bool {
+	if r.arena == nil {
+		return false
+	}
+	return r.arena.compactable(r)
+}
+
+type slab struct {
+	live      int
+	liveBytes int
+	sealed    bool
+}
+
+type bytesArenaMetrics struct {
+	liveBytes      tally.Gauge
+	liveSlabs      tally.Gauge
+	slabsAllocated tally.Counter
+	oversized      tally.Counter
+}
+
+func newBytesArenaMetrics(scope tally.Scope) bytesArenaMetrics {
+	return bytesArenaMetrics{
+		liveBytes:      scope.Gauge("live-bytes"),
+		liveSlabs:      scope.Gauge("live-slabs"),
+		slabsAllocated: scope.Counter("slabs-allocated"),
+		oversized:      scope.Counter("oversized-allocations"),
+	}
+}
+
+type bytesArena struct {
+	sync.Mutex
+
+	slabSize  int
+	current   *slab
+	remaining []byte
+	liveBytes int
+	liveSlabs int
+	metrics   bytesArenaMetrics
+}
+
+// NewBytesArena returns a new bytes arena.
+func NewBytesArena(opts Options) BytesArena {
+	scope := opts.InstrumentOptions().MetricsScope()
+	return &bytesArena{
+		slabSize: opts.SlabSize(),
+		metrics:  newBytesArenaMetrics(scope),
+	}
+}
+
+func (a *bytesArena) Allocate(size int) Region {
+	if size > a.slabSize/maxSlabFraction {
+		a.metrics.oversized.Inc(1)
+		return Region{Bytes: make([]byte, size)}
+	}
+
+	a.Lock()
+	if len(a.remaining) < size {
+		a.newSlabWithLock()
+	}
+	// Cap the region so appends to it cannot overwrite the next region
+	bytes := a.remaining[:size:size]
+	a.remaining = a.remaining[size:]
+	current := a.current
+	current.live++
+	current.liveBytes += size
+	a.liveBytes += size
+	a.updateGaugesWithLock()
+	a.Unlock()
+
+	return Region{Bytes: bytes, arena: a, slab: current}
+}
+
+func (a *bytesArena) Usage() Usage {
+	a.Lock()
+	usage := Usage{
+		LiveBytes: a.liveBytes,
+		LiveSlabs: a.liveSlabs,
+		SlabSize:  a.slabSize,
+	}
+	a.Unlock()
+	return usage
+}
+
+func (a *bytesArena) newSlabWithLock() {
+	if prev := a.current; prev != nil {
+		prev.sealed = true
+		if prev.live == 0 {
+			a.liveSlabs--
+		}
+	}
+	a.current = &slab{}
+	a.remaining = make([]byte, a.slabSize)
+	a.liveSlabs++
+	a.metrics.slabsAllocated.Inc(1)
+}
+
+func (a *bytesArena) release(r Region) {
+	a.Lock()
+	r.slab.live--
+	r.slab.liveBytes -= len(r.Bytes)
+	a.liveBytes -= len(r.Bytes)
+	if r.slab.live == 0 && r.slab.sealed {
+		a.liveSlabs--
+	}
+	a.updateGaugesWithLock()
+	a.Unlock()
+}
+
+func (a *bytesArena) compactable(r Region) bool {
+	a.Lock()
+	compactable := r.slab.sealed &&
+		r.slab.liveBytes < a.slabSize/compactSlabFraction
+	a.Unlock()
+	return compactable
+}
+
+func (a *bytesArena) updateGaugesWithLock() {
+	a.metrics.liveBytes.Update(float64(a.liveBytes))
+	a.metrics.liveSlabs.Update(float64(a.liveSlabs))
+}
+
+// BytesArenas are the bytes arenas of each shard, so that allocations for
+// different shards do not contend on a single arena.
+type BytesArenas interface {
+	// Arena returns the arena of the shard.
+	Arena(shard uint32) BytesArena
+
+	// Usage returns the memory held by the arenas of all shards.
+	Usage() Usage
+}
+
+type bytesArenas struct {
+	sync.Mutex
+
+	opts   Options
+	arenas map[uint32]*bytesArena
+}
+
+// NewBytesArenas returns new bytes arenas, the arena of each shard reports
+// its metrics tagged with the shard.
+func NewBytesArenas(opts Options) BytesArenas {
+	return &bytesArenas{
+		opts:   opts,
+		arenas: make(map[uint32]*bytesArena),
+	}
+}
+
+func (a *bytesArenas) Arena(shard uint32) BytesArena {
+	a.Lock()
+	defer a.Unlock()
+
+	// NB: The arena of a shard is kept when the shard is closed, as the
+	// regions of its series may still be released, and reused if the shard
+	// is assigned again.
+	arena, ok := a.arenas[shard]
+	if !ok {
+		iopts := a.opts.InstrumentOptions()
+		scope := iopts.MetricsScope().Tagged(map[string]string{
+			"shard": strconv.Itoa(int(shard)),
+		})
+		arena = NewBytesArena(a.opts.SetInstrumentOptions(
+			iopts.SetMetricsScope(scope))).(*bytesArena)
+		a.arenas[shard] = arena
+	}
+	return arena
+}
+
+func (a *bytesArenas) Usage() Usage {
+	a.Lock()
+	arenas := make([]*bytesArena, 0, len(a.arenas))
+	for _, arena := range a.arenas {
+		arenas = append(arenas, arena)
+	}
+	a.Unlock()
+
+	usage := Usage{SlabSize: a.opts.SlabSize()}
+	for _, arena := range arenas {
+		arenaUsage := arena.Usage()
+		usage.LiveBytes += arenaUsage.LiveBytes
+		usage.LiveSlabs += arenaUsage.LiveSlabs
+	}
+	return usage
+}
@@ -0,0 +1,151 @@
+// Copyright (c) 2018 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package xarena
+
+import (
+	"testing"
+
+	"github.com/m3db/m3x/instrument"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/uber-go/tally"
+)
+
+func newTestBytesArena(slabSize int) (*bytesArena, tally.TestScope) {
+	scope := tally.NewTestScope("", nil)
+	opts := NewOptions().
+		SetSlabSize(slabSize).
+		SetInstrumentOptions(instrument.NewOptions().SetMetricsScope(scope))
+	return NewBytesArena(opts).(*bytesArena), scope
+}
+
+func TestBytesArenaAllocatesFromSlabs(t *testing.T) {
+	arena, scope := newTestBytesArena(64)
+
+	first := arena.Allocate(8)
+	second := arena.Allocate(8)
+	copy(first.Bytes, "abcdefgh")
+	copy(second.Bytes, "ijklmnop")
+
+	require.Equal(t, 8, len(first.Bytes))
+	require.Equal(t, 8, cap(first.Bytes))
+	assert.Equal(t, "abcdefgh", string(first.Bytes))
+	assert.Equal(t, "ijklmnop", string(second.Bytes))
+	assert.True(t, first.slab == second.slab)
+
+	// Appending to a region must not overwrite the region after it
+	_ = append(first.Bytes, 'z')
+	assert.Equal(t, "ijklmnop", string(second.Bytes))
+
+	counters := scope.Snapshot().Counters()
+	require.Equal(t, int64(1), counters["slabs-allocated+"].Value())
+	assert.Equal(t, Usage{LiveBytes: 16, LiveSlabs: 1, SlabSize: 64}, arena.Usage())
+}
+
+func TestBytesArenaOversizedAllocation(t *testing.T) {
+	arena, scope := newTestBytesArena(64)
+
+	region := arena.Allocate(9)
+	require.Equal(t, 9, len(region.Bytes))
+	assert.Nil(t, region.slab)
+	assert.Equal(t, 0, arena.liveBytes)
+
+	// Releasing untracked regions is a no-op
+	region.Release()
+	Region{}.Release()
+
+	counters := scope.Snapshot().Counters()
+	require.Equal(t, int64(1), counters["oversized-allocations+"].Value())
+}
+
+func TestBytesArenaReleaseDropsSealedSlabs(t *testing.T) {
+	arena, _ := newTestBytesArena(16)
+
+	// Fill the first slab then allocate from a second one
+	var regions []Region
+	for i := 0; i < 9; i++ {
+		regions = append(regions, arena.Allocate(2))
+	}
+	require.Equal(t, 2, arena.liveSlabs)
+	require.True(t, regions[0].slab == regions[7].slab)
+	require.True(t, regions[0].slab != regions[8].slab)
+
+	for _, region := range regions[:7] {
+		region.Release()
+	}
+	assert.Equal(t, 2, arena.liveSlabs)
+	regions[7].Release()
+	assert.Equal(t, 1, arena.liveSlabs)
+
+	// The current slab stays live while it is still being allocated from
+	regions[8].Release()
+	assert.Equal(t, 1, arena.liveSlabs)
+	assert.Equal(t, 0, arena.liveBytes)
+
+	// Bytes of released regions remain valid as slabs are never recycled
+	copy(regions[0].Bytes, "ok")
+	assert.Equal(t, "ok", string(regions[0].Bytes))
+}
+
+func TestBytesArenaCompactable(t *testing.T) {
+	arena, _ := newTestBytesArena(16)
+
+	var regions []Region
+	for i := 0; i < 9; i++ {
+		regions = append(regions, arena.Allocate(2))
+	}
+
+	// Regions of the slab still being allocated from are not compactable
+	assert.False(t, regions[8].Compactable())
+	assert.False(t, regions[0].Compactable())
+
+	// A sealed slab with a quarter of its bytes live is not compactable,
+	// with less than a quarter it is
+	for _, region := range regions[:6] {
+		region.Release()
+	}
+	assert.False(t, regions[6].Compactable())
+	regions[6].Release()
+	assert.True(t, regions[7].Compactable())
+
+	assert.False(t, Region{}.Compactable())
+}
+
+func TestBytesArenasPerShard(t *testing.T) {
+	scope := tally.NewTestScope("", nil)
+	arenas := NewBytesArenas(NewOptions().
+		SetSlabSize(64).
+		SetInstrumentOptions(instrument.NewOptions().SetMetricsScope(scope)))
+
+	first := arenas.Arena(1)
+	require.True(t, first == arenas.Arena(1))
+	second := arenas.Arena(2)
+	require.True(t, first != second)
+
+	first.Allocate(8)
+	second.Allocate(4)
+	assert.Equal(t, Usage{LiveBytes: 12, LiveSlabs: 2, SlabSize: 64}, arenas.Usage())
+
+	gauges := scope.Snapshot().Gauges()
+	assert.Equal(t, float64(8), gauges["live-bytes+shard=1"].Value())
+	assert.Equal(t, float64(4), gauges["live-bytes+shard=2"].Value())
+}
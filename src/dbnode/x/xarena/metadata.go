@@ -0,0 +1,190 @@
+// Copyright (c) 2018 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package xarena
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"math"
+
+	"github.com/m3db/m3x/ident"
+)
+
+// tagRefLen is the size of the offsets of the name and value of a tag, the
+// start and end offset of each into the region.
+const tagRefLen = 4 * 4
+
+var (
+	errSeriesMetadataTooLarge = errors.New("series metadata too large for arena")
+)
+
+// SeriesMetadata is the ID and tags of a series held in a single region of a
+// BytesArena. The tags are stored in the region as offsets of their names and
+// values rather than as ident.Tags, so that the garbage collector tracks no
+// object per tag, and are only materialized as ident.Tags when read.
+//
+// The region holds the ID, followed by the names and values of the tags not
+// contained in the ID, followed by the offsets of the name and value of each
+// tag, tags contained in the ID reference the bytes of the ID.
+type SeriesMetadata struct {
+	region  Region
+	idLen   uint32
+	numTags uint32
+}
+
+// NewSeriesMetadata copies the ID and the tags of the iterator to a region
+// of the arena, the iterator is consumed.
+func NewSeriesMetadata(
+	arena BytesArena,
+	id []byte,
+	tags ident.TagIterator,
+) (SeriesMetadata, error) {
+	// Size the region with a pass over a duplicate of the tags
+	var (
+		size    = len(id)
+		numTags = 0
+	)
+	sizeIter := tags.Duplicate()
+	for sizeIter.Next() {
+		tag := sizeIter.Current()
+		if name := tag.Name.Bytes(); bytes.Index(id, name) == -1 {
+			size += len(name)
+		}
+		if value := tag.Value.Bytes(); bytes.Index(id, value) == -1 {
+			size += len(value)
+		}
+		numTags++
+	}
+	err := sizeIter.Err()
+	sizeIter.Close()
+	if err != nil {
+		return SeriesMetadata{}, err
+	}
+	size += numTags * tagRefLen
+	if uint64(size) > math.MaxUint32 {
+		return SeriesMetadata{}, errSeriesMetadataTooLarge
+	}
+
+	var (
+		region  = arena.Allocate(size)
+		idLen   = copy(region.Bytes, id)
+		offset  = idLen
+		refs    = region.Bytes[size-numTags*tagRefLen:]
+		literal = func(b []byte) (uint32, uint32) {
+			if idx := bytes.Index(region.Bytes[:idLen], b); idx != -1 {
+				return uint32(idx), uint32(idx + len(b))
+			}
+			start := offset
+			offset += copy(region.Bytes[offset:], b)
+			return uint32(start), uint32(offset)
+		}
+	)
+	for i := 0; tags.Next() && i < numTags; i++ {
+		tag := tags.Current()
+		nameStart, nameEnd := literal(tag.Name.Bytes())
+		valueStart, valueEnd := literal(tag.Value.Bytes())
+		ref := refs[i*tagRefLen:]
+		binary.LittleEndian.PutUint32(ref[0:], nameStart)
+		binary.LittleEndian.PutUint32(ref[4:], nameEnd)
+		binary.LittleEndian.PutUint32(ref[8:], valueStart)
+		binary.LittleEndian.PutUint32(ref[12:], valueEnd)
+	}
+	if err := tags.Err(); err != nil {
+		region.Release()
+		return SeriesMetadata{}, err
+	}
+
+	return SeriesMetadata{
+		region:  region,
+		idLen:   uint32(idLen),
+		numTags: uint32(numTags),
+	}, nil
+}
+
+// IsZero returns whether the metadata is the zero value.
+func (m SeriesMetadata) IsZero() bool {
+	return m.region.Bytes == nil
+}
+
+// ID returns the ID of the series, it references the region.
+func (m SeriesMetadata) ID() ident.BytesID {
+	return ident.BytesID(m.region.Bytes[:m.idLen:m.idLen])
+}
+
+// NumTags returns the number of tags of the series.
+func (m SeriesMetadata) NumTags() int {
+	return int(m.numTags)
+}
+
+// Tag returns the name and value of the tag at the index, they reference
+// the region.
+func (m SeriesMetadata) Tag(i int) (name []byte, value []byte) {
+	var (
+		b   = m.region.Bytes
+		ref = b[len(b)-int(m.numTags-uint32(i))*tagRefLen:]
+	)
+	nameStart := binary.LittleEndian.Uint32(ref[0:])
+	nameEnd := binary.LittleEndian.Uint32(ref[4:])
+	valueStart := binary.LittleEndian.Uint32(ref[8:])
+	valueEnd := binary.LittleEndian.Uint32(ref[12:])
+	return b[nameStart:nameEnd:nameEnd], b[valueStart:valueEnd:valueEnd]
+}
+
+// Tags returns the tags of the series, the tags are allocated on each call
+// and reference the region.
+func (m SeriesMetadata) Tags() ident.Tags {
+	if m.numTags == 0 {
+		return ident.Tags{}
+	}
+	tags := make([]ident.Tag, 0, m.numTags)
+	for i := 0; i < int(m.numTags); i++ {
+		name, value := m.Tag(i)
+		tags = append(tags, ident.Tag{
+			Name:  ident.BytesID(name),
+			Value: ident.BytesID(value),
+		})
+	}
+	return ident.NewTags(tags...)
+}
+
+// Compactable returns whether the region of the metadata should be moved to
+// let the slab it is on be reclaimed, see Region.Compactable.
+func (m SeriesMetadata) Compactable() bool {
+	return m.region.Compactable()
+}
+
+// Copy returns a copy of the metadata in a new region of the arena.
+func (m SeriesMetadata) Copy(arena BytesArena) SeriesMetadata {
+	region := arena.Allocate(len(m.region.Bytes))
+	copy(region.Bytes, m.region.Bytes)
+	return SeriesMetadata{
+		region:  region,
+		idLen:   m.idLen,
+		numTags: m.numTags,
+	}
+}
+
+// Release releases the region of the metadata, the metadata must be
+// released at most once. Releasing the zero value is a no-op.
+func (m SeriesMetadata) Release() {
+	m.region.Release()
+}
@@ -0,0 +1,69 @@
+// Copyright (c) 2018 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package xarena
+
+import (
+	"testing"
+
+	"github.com/m3db/m3x/ident"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSeriesMetadata(t *testing.T) {
+	arena, _ := newTestBytesArena(1024)
+
+	tags := ident.NewTags(
+		ident.StringTag("__name__", "cpu"),
+		ident.StringTag("host", "a"),
+		ident.StringTag("dc", "east"))
+	tagsIter := ident.NewTagsIterator(tags)
+	defer tagsIter.Close()
+
+	metadata, err := NewSeriesMetadata(arena, []byte("cpu.host=a"), tagsIter)
+	require.NoError(t, err)
+	require.False(t, metadata.IsZero())
+	assert.Equal(t, "cpu.host=a", metadata.ID().String())
+	require.Equal(t, 3, metadata.NumTags())
+
+	name, value := metadata.Tag(2)
+	assert.Equal(t, "dc", string(name))
+	assert.Equal(t, "east", string(value))
+	assert.True(t, tags.Equal(metadata.Tags()))
+
+	// Only the bytes of the ID, the tags not contained in it and the offsets
+	// of the tags are allocated
+	expected := len("cpu.host=a") + len("__name__") + len("dc") + len("east") +
+		3*tagRefLen
+	require.Equal(t, expected, arena.Usage().LiveBytes)
+
+	copied := metadata.Copy(arena)
+	metadata.Release()
+	assert.Equal(t, "cpu.host=a", copied.ID().String())
+	assert.True(t, tags.Equal(copied.Tags()))
+	require.Equal(t, expected, arena.Usage().LiveBytes)
+
+	copied.Release()
+	assert.Equal(t, 0, arena.Usage().LiveBytes)
+	assert.True(t, SeriesMetadata{}.IsZero())
+	SeriesMetadata{}.Release()
+}
@@ -0,0 +1,62 @@
+// Copyright (c) 2018 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package xarena
+
+import (
+	"github.com/m3db/m3x/instrument"
+)
+
+const (
+	defaultSlabSize = 1 << 20
+)
+
+// Options controls the parameters of bytes arenas
+type Options struct {
+	slabSize       int
+	instrumentOpts instrument.Options
+}
+
+// NewOptions creates new options
+func NewOptions() Options {
+	return Options{
+		slabSize:       defaultSlabSize,
+		instrumentOpts: instrument.NewOptions(),
+	}
+}
+
+// SlabSize returns the size of the slabs regions are allocated from,
+// regions larger than an eighth of a slab are allocated on their own
+func (o Options) SlabSize() int { return o.slabSize }
+
+// InstrumentOptions returns the instrument options
+func (o Options) InstrumentOptions() instrument.Options { return o.instrumentOpts }
+
+// SetSlabSize sets the size of the slabs regions are allocated from
+func (o Options) SetSlabSize(value int) Options {
+	o.slabSize = value
+	return o
+}
+
+// SetInstrumentOptions sets the instrument options
+func (o Options) SetInstrumentOptions(value instrument.Options) Options {
+	o.instrumentOpts = value
+	return o
+}
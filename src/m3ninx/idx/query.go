@@ -63,6 +63,26 @@ func MustCreateRegexpQuery(field, regexp []byte) Query {
 	}
 }
 
+// NewCaseInsensitiveTermQuery returns a new query for finding documents which match
+// a term exactly, ignoring case.
+func NewCaseInsensitiveTermQuery(field, term []byte) (Query, error) {
+	re, err := CaseInsensitiveRegexp(term)
+	if err != nil {
+		return Query{}, err
+	}
+	return NewRegexpQuery(field, re)
+}
+
+// NewFuzzyQuery returns a new query for finding documents which match a term within
+// the given number of edits (insertions, deletions or substitutions).
+func NewFuzzyQuery(field, term []byte, maxEdits int) (Query, error) {
+	re, err := FuzzyRegexp(term, maxEdits)
+	if err != nil {
+		return Query{}, err
+	}
+	return NewRegexpQuery(field, re)
+}
+
 // NewNegationQuery returns a new query for finding documents which don't match a given query.
 func NewNegationQuery(q Query) Query {
 	return Query{
@@ -0,0 +1,184 @@
+// Copyright (c) 2018 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package idx
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"math"
+	"unicode"
+	"unicode/utf8"
+)
+
+const (
+	// MaxFuzzyEdits is the maximum edit distance supported by fuzzy queries.
+	MaxFuzzyEdits = 2
+)
+
+// maxFuzzyTermRunes bounds the length of the term of a fuzzy query for each
+// edit distance, the expression a term expands into grows with its length to
+// the power of the edit distance plus one so this keeps the compiled FST
+// automaton within reasonable limits.
+var maxFuzzyTermRunes = [MaxFuzzyEdits + 1]int{
+	0: math.MaxInt32,
+	1: 128,
+	2: 32,
+}
+
+var (
+	errFuzzyEditsOutOfRange = fmt.Errorf("fuzzy edits must be between 0 and %d", MaxFuzzyEdits)
+	errTermInvalidUTF8      = errors.New("term is not valid utf8")
+)
+
+// MaxFuzzyTermLength returns the max length in runes of the term of a fuzzy
+// query with the given edit distance, 128 runes for one edit and 32 runes for
+// two edits.
+func MaxFuzzyTermLength(maxEdits int) int {
+	if maxEdits < 0 || maxEdits > MaxFuzzyEdits {
+		return 0
+	}
+	return maxFuzzyTermRunes[maxEdits]
+}
+
+// CaseInsensitiveRegexp returns a regular expression which matches the
+// provided term exactly, ignoring case. Case folding is expanded into explicit
+// character classes rather than using the (?i) flag so the expression is
+// evaluated identically by both the map-backed and FST-backed segments.
+func CaseInsensitiveRegexp(term []byte) ([]byte, error) {
+	if !utf8.Valid(term) {
+		return nil, errTermInvalidUTF8
+	}
+	var buf bytes.Buffer
+	for _, r := range string(term) {
+		writeCaseInsensitiveRune(&buf, r)
+	}
+	return buf.Bytes(), nil
+}
+
+// FuzzyRegexp returns a regular expression which matches all terms within
+// maxEdits insertions, deletions or substitutions of the provided term, the
+// term must be at most MaxFuzzyTermLength(maxEdits) runes long.
+func FuzzyRegexp(term []byte, maxEdits int) ([]byte, error) {
+	if maxEdits < 0 || maxEdits > MaxFuzzyEdits {
+		return nil, errFuzzyEditsOutOfRange
+	}
+	if !utf8.Valid(term) {
+		return nil, errTermInvalidUTF8
+	}
+
+	runes := []rune(string(term))
+	if max := MaxFuzzyTermLength(maxEdits); len(runes) > max {
+		return nil, fmt.Errorf("fuzzy term of %d runes exceeds the max of %d "+
+			"runes at edit distance %d", len(runes), max, maxEdits)
+	}
+
+	b := fuzzyRegexpBuilder{
+		term: runes,
+		memo: make(map[fuzzyState]string),
+	}
+	return []byte(b.regexp(fuzzyState{edits: maxEdits})), nil
+}
+
+// fuzzyState is a position in the term of a fuzzy query and the number of
+// edits left to match the remainder of the term with.
+type fuzzyState struct {
+	pos   int
+	edits int
+}
+
+type fuzzyRegexpBuilder struct {
+	term []rune
+	memo map[fuzzyState]string
+}
+
+// regexp returns the expression which matches all terms within the edits of
+// the state of the remainder of the term, which is the union of matching the
+// next rune, substituting or deleting it, and inserting any rune before it.
+func (b *fuzzyRegexpBuilder) regexp(s fuzzyState) string {
+	if re, ok := b.memo[s]; ok {
+		return re
+	}
+
+	var buf bytes.Buffer
+	switch {
+	case s.edits == 0:
+		for _, r := range b.term[s.pos:] {
+			buf.WriteString(quoteRune(r))
+		}
+	case s.pos == len(b.term):
+		fmt.Fprintf(&buf, ".{0,%d}", s.edits)
+	default:
+		var (
+			next     = fuzzyState{pos: s.pos + 1, edits: s.edits}
+			edited   = fuzzyState{pos: s.pos + 1, edits: s.edits - 1}
+			inserted = fuzzyState{pos: s.pos, edits: s.edits - 1}
+		)
+		buf.WriteString("(?:")
+		buf.WriteString(quoteRune(b.term[s.pos]))
+		buf.WriteString(b.regexp(next))
+		buf.WriteString("|.?")
+		buf.WriteString(b.regexp(edited))
+		buf.WriteString("|.")
+		buf.WriteString(b.regexp(inserted))
+		buf.WriteByte(')')
+	}
+
+	re := buf.String()
+	b.memo[s] = re
+	return re
+}
+
+func writeCaseInsensitiveRune(buf *bytes.Buffer, r rune) {
+	folded := []rune{r}
+	for f := unicode.SimpleFold(r); f != r; f = unicode.SimpleFold(f) {
+		folded = append(folded, f)
+	}
+	if len(folded) == 1 {
+		buf.WriteString(quoteRune(r))
+		return
+	}
+	buf.WriteByte('[')
+	for _, f := range folded {
+		buf.WriteString(quoteClassRune(f))
+	}
+	buf.WriteByte(']')
+}
+
+func quoteRune(r rune) string {
+	s := string(r)
+	if len(s) == 1 && isSpecialRegexpByte(s[0]) {
+		return `\` + s
+	}
+	return s
+}
+
+func quoteClassRune(r rune) string {
+	switch r {
+	case '\\', ']', '[', '^', '-':
+		return `\` + string(r)
+	}
+	return string(r)
+}
+
+func isSpecialRegexpByte(b byte) bool {
+	return bytes.IndexByte([]byte(`\.+*?()|[]{}^$`), b) >= 0
+}
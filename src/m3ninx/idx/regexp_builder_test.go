@@ -0,0 +1,133 @@
+// Copyright (c) 2018 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package idx
+
+import (
+	"regexp"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestCaseInsensitiveRegexp(t *testing.T) {
+	re, err := CaseInsensitiveRegexp([]byte("Foo.Bar"))
+	require.NoError(t, err)
+
+	compiled := regexp.MustCompile("^(?:" + string(re) + ")$")
+	for _, s := range []string{"foo.bar", "FOO.BAR", "fOo.bAr"} {
+		require.True(t, compiled.MatchString(s), s)
+	}
+	for _, s := range []string{"fooxbar", "foo.ba", "foo.barr"} {
+		require.False(t, compiled.MatchString(s), s)
+	}
+}
+
+func TestFuzzyRegexp(t *testing.T) {
+	tests := []struct {
+		name     string
+		maxEdits int
+		matches  []string
+		misses   []string
+	}{
+		{
+			name:     "zero edits",
+			maxEdits: 0,
+			matches:  []string{"apple"},
+			misses:   []string{"appl", "apples", "ample"},
+		},
+		{
+			name:     "one edit",
+			maxEdits: 1,
+			matches:  []string{"apple", "appl", "apples", "ample", "xapple"},
+			misses:   []string{"app", "amplex", "banana"},
+		},
+		{
+			name:     "two edits",
+			maxEdits: 2,
+			matches:  []string{"apple", "app", "amplex", "aple", "applesx"},
+			misses:   []string{"ap", "ampxex", "banana"},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			re, err := FuzzyRegexp([]byte("apple"), test.maxEdits)
+			require.NoError(t, err)
+
+			compiled := regexp.MustCompile("^(?:" + string(re) + ")$")
+			for _, s := range test.matches {
+				require.True(t, compiled.MatchString(s), s)
+			}
+			for _, s := range test.misses {
+				require.False(t, compiled.MatchString(s), s)
+			}
+		})
+	}
+}
+
+func TestFuzzyRegexpEscapesTerm(t *testing.T) {
+	re, err := FuzzyRegexp([]byte("a.b"), 1)
+	require.NoError(t, err)
+
+	compiled := regexp.MustCompile("^(?:" + string(re) + ")$")
+	require.True(t, compiled.MatchString("a.bc"))
+	require.False(t, compiled.MatchString("axbcd"))
+}
+
+func TestFuzzyRegexpTermLength(t *testing.T) {
+	term := strings.Repeat("a", MaxFuzzyTermLength(2))
+	re, err := FuzzyRegexp([]byte(term), 2)
+	require.NoError(t, err)
+
+	compiled := regexp.MustCompile("^(?:" + string(re) + ")$")
+	require.True(t, compiled.MatchString("xx"+term[2:]))
+	require.True(t, compiled.MatchString(term[:10]+"x"+term[11:]+"x"))
+	require.False(t, compiled.MatchString("xxx"+term[3:]))
+
+	_, err = FuzzyRegexp([]byte(term+"a"), 2)
+	require.Error(t, err)
+}
+
+func TestFuzzyRegexpInvalidEdits(t *testing.T) {
+	_, err := FuzzyRegexp([]byte("apple"), MaxFuzzyEdits+1)
+	require.Error(t, err)
+
+	_, err = FuzzyRegexp([]byte("apple"), -1)
+	require.Error(t, err)
+}
+
+func TestFuzzyQuery(t *testing.T) {
+	q, err := NewFuzzyQuery([]byte("fruit"), []byte("apple"), 1)
+	require.NoError(t, err)
+
+	data, err := Marshal(q)
+	require.NoError(t, err)
+
+	cpy, err := Unmarshal(data)
+	require.NoError(t, err)
+	require.True(t, q.Equal(cpy))
+}
+
+func TestCaseInsensitiveTermQuery(t *testing.T) {
+	_, err := NewCaseInsensitiveTermQuery([]byte("fruit"), []byte("Apple"))
+	require.NoError(t, err)
+}
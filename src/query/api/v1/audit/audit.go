@@ -0,0 +1,236 @@
+// Copyright (c) 2018 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+// Package audit records the mutating admin calls to the coordinator HTTP
+// APIs, with their caller, parameters and result, to an append-only audit
+// log and optionally an external sink.
+package audit
+
+import (
+	"bytes"
+	"io/ioutil"
+	"net/http"
+	"time"
+
+	"github.com/m3db/m3/src/query/util/logging"
+
+	"github.com/uber-go/tally"
+	"go.uber.org/zap"
+)
+
+const (
+	// DefaultMaxBodyBytes is the default max number of bytes of request and
+	// error response bodies recorded with an event.
+	DefaultMaxBodyBytes = 64 * 1024
+)
+
+// Event is the record of a single mutating admin call.
+type Event struct {
+	Time       time.Time           `json:"time"`
+	Caller     string              `json:"caller,omitempty"`
+	RemoteAddr string              `json:"remoteAddr"`
+	Method     string              `json:"method"`
+	Path       string              `json:"path"`
+	Params     map[string][]string `json:"params,omitempty"`
+	Body       string              `json:"body,omitempty"`
+	Status     int                 `json:"status"`
+	Error      string              `json:"error,omitempty"`
+	Duration   time.Duration       `json:"duration"`
+}
+
+// Sink receives audit events.
+type Sink interface {
+	// Write records an event.
+	Write(event Event) error
+
+	// Close closes the sink.
+	Close() error
+}
+
+// CallerFn returns the identity of the caller of a request, empty if the
+// caller is unknown.
+type CallerFn func(r *http.Request) string
+
+type auditMetrics struct {
+	events      tally.Counter
+	writeErrors tally.Counter
+}
+
+func newAuditMetrics(scope tally.Scope) auditMetrics {
+	return auditMetrics{
+		events:      scope.Counter("events"),
+		writeErrors: scope.Counter("write-errors"),
+	}
+}
+
+// Auditor is the middleware that records calls to audit sinks.
+type Auditor struct {
+	sinks        []Sink
+	callerFn     CallerFn
+	maxBodyBytes int
+	nowFn        func() time.Time
+	metrics      auditMetrics
+}
+
+// NewAuditor returns a new auditor that records calls to all the sinks,
+// the caller function may be nil if callers are not authenticated.
+func NewAuditor(
+	sinks []Sink,
+	callerFn CallerFn,
+	maxBodyBytes int,
+	scope tally.Scope,
+) *Auditor {
+	if callerFn == nil {
+		callerFn = func(*http.Request) string { return "" }
+	}
+	if maxBodyBytes <= 0 {
+		maxBodyBytes = DefaultMaxBodyBytes
+	}
+	return &Auditor{
+		sinks:        sinks,
+		callerFn:     callerFn,
+		maxBodyBytes: maxBodyBytes,
+		nowFn:        time.Now,
+		metrics:      newAuditMetrics(scope.SubScope("audit")),
+	}
+}
+
+// Wrap returns a handler that records the calls to the handler that are
+// not reads, i.e. that are not GET, HEAD or OPTIONS requests.
+func (a *Auditor) Wrap(next http.Handler) http.Handler {
+	return a.wrap(next, false)
+}
+
+// WrapAll returns a handler that records all calls to the handler,
+// including reads, for handlers whose reads are sensitive.
+func (a *Auditor) WrapAll(next http.Handler) http.Handler {
+	return a.wrap(next, true)
+}
+
+func (a *Auditor) wrap(next http.Handler, reads bool) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.Method {
+		case http.MethodGet, http.MethodHead, http.MethodOptions:
+			if !reads {
+				next.ServeHTTP(w, r)
+				return
+			}
+		}
+
+		start := a.nowFn()
+		event := Event{
+			Time:       start,
+			Caller:     a.callerFn(r),
+			RemoteAddr: r.RemoteAddr,
+			Method:     r.Method,
+			Path:       r.URL.Path,
+		}
+		if params := r.URL.Query(); len(params) > 0 {
+			event.Params = params
+		}
+
+		// Read the body so it can be recorded, the handler reads it from
+		// the buffered copy.
+		if r.Body != nil {
+			body, err := ioutil.ReadAll(r.Body)
+			r.Body.Close()
+			if err != nil {
+				event.Error = err.Error()
+			}
+			r.Body = ioutil.NopCloser(bytes.NewReader(body))
+			event.Body = a.truncate(body)
+		}
+
+		recorder := &responseRecorder{
+			ResponseWriter: w,
+			status:         http.StatusOK,
+			maxBodyBytes:   a.maxBodyBytes,
+		}
+		next.ServeHTTP(recorder, r)
+
+		event.Status = recorder.status
+		if recorder.status >= http.StatusBadRequest && event.Error == "" {
+			event.Error = a.truncate(recorder.body.Bytes())
+		}
+		event.Duration = a.nowFn().Sub(start)
+		a.Record(r, event)
+	})
+}
+
+// Record writes an event to all sinks.
+func (a *Auditor) Record(r *http.Request, event Event) {
+	a.metrics.events.Inc(1)
+	for _, sink := range a.sinks {
+		if err := sink.Write(event); err != nil {
+			a.metrics.writeErrors.Inc(1)
+			logging.WithContext(r.Context()).Error("unable to write audit event",
+				zap.String("path", event.Path), zap.Error(err))
+		}
+	}
+}
+
+func (a *Auditor) truncate(b []byte) string {
+	if len(b) > a.maxBodyBytes {
+		b = b[:a.maxBodyBytes]
+	}
+	return string(b)
+}
+
+// Close closes all sinks.
+func (a *Auditor) Close() error {
+	var lastErr error
+	for _, sink := range a.sinks {
+		if err := sink.Close(); err != nil {
+			lastErr = err
+		}
+	}
+	return lastErr
+}
+
+// responseRecorder records the status of a response and the start of its
+// body if it is an error.
+type responseRecorder struct {
+	http.ResponseWriter
+
+	status       int
+	wroteHeader  bool
+	body         bytes.Buffer
+	maxBodyBytes int
+}
+
+func (r *responseRecorder) WriteHeader(status int) {
+	if !r.wroteHeader {
+		r.status = status
+		r.wroteHeader = true
+	}
+	r.ResponseWriter.WriteHeader(status)
+}
+
+func (r *responseRecorder) Write(b []byte) (int, error) {
+	r.wroteHeader = true
+	remaining := r.maxBodyBytes - r.body.Len()
+	if r.status >= http.StatusBadRequest && remaining > 0 {
+		if len(b) < remaining {
+			remaining = len(b)
+		}
+		r.body.Write(b[:remaining])
+	}
+	return r.ResponseWriter.Write(b)
+}
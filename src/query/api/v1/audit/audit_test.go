@@ -0,0 +1,177 @@
+// Copyright (c) 2018 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package audit
+
+import (
+	"bufio"
+	"encoding/json"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/m3db/m3/src/query/util/logging"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/uber-go/tally"
+)
+
+type memorySink struct {
+	sync.Mutex
+	events []Event
+}
+
+func (s *memorySink) Write(event Event) error {
+	s.Lock()
+	defer s.Unlock()
+	s.events = append(s.events, event)
+	return nil
+}
+
+func (s *memorySink) Close() error {
+	return nil
+}
+
+func TestAuditorRecordsMutatingCalls(t *testing.T) {
+	logging.InitWithCores(nil)
+
+	sink := &memorySink{}
+	caller := func(r *http.Request) string { return r.Header.Get("Caller") }
+	auditor := NewAuditor([]Sink{sink}, caller, 0, tally.NoopScope)
+
+	var received string
+	h := auditor.Wrap(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, err := ioutil.ReadAll(r.Body)
+		require.NoError(t, err)
+		received = string(body)
+		if r.Method == http.MethodDelete {
+			w.WriteHeader(http.StatusNotFound)
+			w.Write([]byte(`{"error":"namespace not found"}`))
+		}
+	}))
+
+	req := httptest.NewRequest(http.MethodGet, "/api/v1/namespace", nil)
+	h.ServeHTTP(httptest.NewRecorder(), req)
+	assert.Empty(t, sink.events)
+
+	req = httptest.NewRequest(http.MethodPost, "/api/v1/placement?dryRun=true",
+		strings.NewReader(`{"num_shards":64}`))
+	req.Header.Set("Caller", "alice")
+	w := httptest.NewRecorder()
+	h.ServeHTTP(w, req)
+	assert.Equal(t, http.StatusOK, w.Code)
+	// The handler reads the body that was recorded.
+	assert.Equal(t, `{"num_shards":64}`, received)
+
+	req = httptest.NewRequest(http.MethodDelete, "/api/v1/namespace/metrics", nil)
+	h.ServeHTTP(httptest.NewRecorder(), req)
+
+	require.Len(t, sink.events, 2)
+	event := sink.events[0]
+	assert.Equal(t, "alice", event.Caller)
+	assert.Equal(t, http.MethodPost, event.Method)
+	assert.Equal(t, "/api/v1/placement", event.Path)
+	assert.Equal(t, map[string][]string{"dryRun": {"true"}}, event.Params)
+	assert.Equal(t, `{"num_shards":64}`, event.Body)
+	assert.Equal(t, http.StatusOK, event.Status)
+	assert.Empty(t, event.Error)
+
+	event = sink.events[1]
+	assert.Empty(t, event.Caller)
+	assert.Equal(t, "/api/v1/namespace/metrics", event.Path)
+	assert.Equal(t, http.StatusNotFound, event.Status)
+	assert.Equal(t, `{"error":"namespace not found"}`, event.Error)
+}
+
+func TestAuditorTruncatesBodies(t *testing.T) {
+	sink := &memorySink{}
+	auditor := NewAuditor([]Sink{sink}, nil, 4, tally.NoopScope)
+	h := auditor.Wrap(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusBadRequest)
+		w.Write([]byte("invalid"))
+	}))
+
+	req := httptest.NewRequest(http.MethodPost, "/api/v1/database/create",
+		strings.NewReader("abcdefgh"))
+	w := httptest.NewRecorder()
+	h.ServeHTTP(w, req)
+	// The response itself is not truncated.
+	assert.Equal(t, "invalid", w.Body.String())
+
+	require.Len(t, sink.events, 1)
+	assert.Equal(t, "abcd", sink.events[0].Body)
+	assert.Equal(t, "inva", sink.events[0].Error)
+}
+
+func TestAuditorWrapAllRecordsReads(t *testing.T) {
+	sink := &memorySink{}
+	auditor := NewAuditor([]Sink{sink}, nil, 0, tally.NoopScope)
+	h := auditor.WrapAll(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte("profile"))
+	}))
+
+	req := httptest.NewRequest(http.MethodGet, "/debug/pprof/heap", nil)
+	w := httptest.NewRecorder()
+	h.ServeHTTP(w, req)
+	assert.Equal(t, "profile", w.Body.String())
+
+	require.Len(t, sink.events, 1)
+	assert.Equal(t, http.MethodGet, sink.events[0].Method)
+	assert.Equal(t, "/debug/pprof/heap", sink.events[0].Path)
+	assert.Equal(t, http.StatusOK, sink.events[0].Status)
+}
+
+func TestFileSinkAppends(t *testing.T) {
+	dir, err := ioutil.TempDir("", "audit")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	path := filepath.Join(dir, "audit.log")
+	for i, caller := range []string{"alice", "bob"} {
+		// Reopening the sink appends to the existing log.
+		sink, err := NewFileSink(path)
+		require.NoError(t, err)
+		require.NoError(t, sink.Write(Event{Caller: caller, Status: 200 + i}))
+		require.NoError(t, sink.Close())
+	}
+
+	f, err := os.Open(path)
+	require.NoError(t, err)
+	defer f.Close()
+
+	var events []Event
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		var event Event
+		require.NoError(t, json.Unmarshal(scanner.Bytes(), &event))
+		events = append(events, event)
+	}
+	require.NoError(t, scanner.Err())
+	require.Len(t, events, 2)
+	assert.Equal(t, "alice", events[0].Caller)
+	assert.Equal(t, "bob", events[1].Caller)
+	assert.Equal(t, 201, events[1].Status)
+}
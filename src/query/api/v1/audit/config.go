@@ -0,0 +1,91 @@
+// Copyright (c) 2018 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package audit
+
+import (
+	"context"
+	"time"
+
+	"github.com/m3db/m3/src/query/util/logging"
+
+	"github.com/uber-go/tally"
+)
+
+// Configuration is the audit log configuration of the coordinator HTTP
+// APIs.
+type Configuration struct {
+	// Path is the file events are appended to, events are written to the
+	// coordinator log if not set.
+	Path string `yaml:"path"`
+
+	// MaxBodyBytes is the max number of bytes of request and error
+	// response bodies recorded with an event, defaults to 64KiB.
+	MaxBodyBytes int `yaml:"maxBodyBytes"`
+
+	// Sink is the external endpoint events are also shipped to, if set.
+	Sink *HTTPSinkConfiguration `yaml:"sink"`
+}
+
+// HTTPSinkConfiguration is the configuration of an external endpoint that
+// events are posted to.
+type HTTPSinkConfiguration struct {
+	// URL is the endpoint each event is posted to as JSON.
+	URL string `yaml:"url" validate:"nonzero"`
+
+	// Headers are sent with every request, e.g. for authentication.
+	Headers map[string]string `yaml:"headers"`
+
+	// Timeout is the timeout of each request, defaults to 5s.
+	Timeout time.Duration `yaml:"timeout"`
+
+	// QueueSize is the number of events queued to be sent before events
+	// are dropped, defaults to 1024.
+	QueueSize int `yaml:"queueSize"`
+}
+
+// NewAuditor returns the auditor of the configuration.
+func (c Configuration) NewAuditor(
+	callerFn CallerFn,
+	scope tally.Scope,
+) (*Auditor, error) {
+	var sinks []Sink
+	if c.Path != "" {
+		sink, err := NewFileSink(c.Path)
+		if err != nil {
+			return nil, err
+		}
+		sinks = append(sinks, sink)
+	} else {
+		sinks = append(sinks, NewLogSink(logging.WithContext(context.Background())))
+	}
+
+	if c.Sink != nil {
+		sinks = append(sinks, NewHTTPSink(HTTPSinkOptions{
+			URL:       c.Sink.URL,
+			Headers:   c.Sink.Headers,
+			Timeout:   c.Sink.Timeout,
+			QueueSize: c.Sink.QueueSize,
+			Scope:     scope.SubScope("audit-sink"),
+		}))
+	}
+
+	return NewAuditor(sinks, callerFn, c.MaxBodyBytes, scope), nil
+}
@@ -0,0 +1,65 @@
+// Copyright (c) 2018 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package audit
+
+import (
+	"encoding/json"
+	"os"
+	"sync"
+)
+
+const (
+	fileMode = 0600
+)
+
+type fileSink struct {
+	sync.Mutex
+
+	file *os.File
+	enc  *json.Encoder
+}
+
+// NewFileSink returns a sink that appends events as JSON lines to the file
+// at the path, creating it if it does not exist. Every event is synced to
+// disk before the call is considered recorded.
+func NewFileSink(path string) (Sink, error) {
+	file, err := os.OpenFile(path, os.O_WRONLY|os.O_APPEND|os.O_CREATE, fileMode)
+	if err != nil {
+		return nil, err
+	}
+	return &fileSink{file: file, enc: json.NewEncoder(file)}, nil
+}
+
+func (s *fileSink) Write(event Event) error {
+	s.Lock()
+	defer s.Unlock()
+
+	if err := s.enc.Encode(event); err != nil {
+		return err
+	}
+	return s.file.Sync()
+}
+
+func (s *fileSink) Close() error {
+	s.Lock()
+	defer s.Unlock()
+	return s.file.Close()
+}
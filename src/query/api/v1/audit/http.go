@@ -0,0 +1,172 @@
+// Copyright (c) 2018 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package audit
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/m3db/m3/src/query/util/logging"
+
+	"github.com/uber-go/tally"
+	"go.uber.org/zap"
+)
+
+const (
+	// DefaultHTTPSinkTimeout is the default timeout of requests to an
+	// external sink.
+	DefaultHTTPSinkTimeout = 5 * time.Second
+
+	// DefaultHTTPSinkQueueSize is the default number of events queued to be
+	// sent to an external sink.
+	DefaultHTTPSinkQueueSize = 1024
+)
+
+var (
+	errHTTPSinkQueueFull = errors.New("audit sink queue full, event dropped")
+)
+
+// HTTPSinkOptions are the options of a sink that ships events to an
+// external endpoint.
+type HTTPSinkOptions struct {
+	// URL is the endpoint each event is posted to as JSON.
+	URL string
+
+	// Headers are sent with every request, e.g. for authentication.
+	Headers map[string]string
+
+	Timeout   time.Duration
+	QueueSize int
+	Scope     tally.Scope
+}
+
+type httpSinkMetrics struct {
+	sent    tally.Counter
+	errors  tally.Counter
+	dropped tally.Counter
+}
+
+func newHTTPSinkMetrics(scope tally.Scope) httpSinkMetrics {
+	return httpSinkMetrics{
+		sent:    scope.Counter("sent"),
+		errors:  scope.Counter("send-errors"),
+		dropped: scope.Counter("dropped"),
+	}
+}
+
+type httpSink struct {
+	opts    HTTPSinkOptions
+	client  *http.Client
+	queue   chan Event
+	metrics httpSinkMetrics
+	wg      sync.WaitGroup
+}
+
+// NewHTTPSink returns a sink that posts events to an external endpoint in
+// the background, so that calls are not delayed by the endpoint. Events
+// are dropped while the queue is full.
+func NewHTTPSink(opts HTTPSinkOptions) Sink {
+	if opts.Timeout <= 0 {
+		opts.Timeout = DefaultHTTPSinkTimeout
+	}
+	if opts.QueueSize <= 0 {
+		opts.QueueSize = DefaultHTTPSinkQueueSize
+	}
+	if opts.Scope == nil {
+		opts.Scope = tally.NoopScope
+	}
+
+	s := &httpSink{
+		opts:    opts,
+		client:  &http.Client{Timeout: opts.Timeout},
+		queue:   make(chan Event, opts.QueueSize),
+		metrics: newHTTPSinkMetrics(opts.Scope),
+	}
+	s.wg.Add(1)
+	go s.run()
+	return s
+}
+
+func (s *httpSink) Write(event Event) error {
+	select {
+	case s.queue <- event:
+		return nil
+	default:
+		s.metrics.dropped.Inc(1)
+		return errHTTPSinkQueueFull
+	}
+}
+
+func (s *httpSink) run() {
+	defer s.wg.Done()
+
+	logger := logging.WithContext(context.Background())
+	for event := range s.queue {
+		if err := s.send(event); err != nil {
+			s.metrics.errors.Inc(1)
+			logger.Error("unable to send audit event",
+				zap.String("url", s.opts.URL), zap.Error(err))
+			continue
+		}
+		s.metrics.sent.Inc(1)
+	}
+}
+
+func (s *httpSink) send(event Event) error {
+	body, err := json.Marshal(event)
+	if err != nil {
+		return err
+	}
+
+	req, err := http.NewRequest(http.MethodPost, s.opts.URL, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	for name, value := range s.opts.Headers {
+		req.Header.Set(name, value)
+	}
+
+	resp, err := s.client.Do(req)
+	if err != nil {
+		return err
+	}
+	resp.Body.Close()
+
+	if resp.StatusCode/100 != 2 {
+		return fmt.Errorf("audit sink returned status %d", resp.StatusCode)
+	}
+	return nil
+}
+
+// Close sends the queued events and stops the sink, events must not be
+// written after it is closed.
+func (s *httpSink) Close() error {
+	close(s.queue)
+	s.wg.Wait()
+	return nil
+}
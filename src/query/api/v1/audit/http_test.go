@@ -0,0 +1,86 @@
+// Copyright (c) 2018 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package audit
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+
+	"github.com/m3db/m3/src/query/util/logging"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestHTTPSinkShipsEvents(t *testing.T) {
+	logging.InitWithCores(nil)
+
+	var (
+		lock   sync.Mutex
+		events []Event
+	)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "secret", r.Header.Get("X-Api-Key"))
+		var event Event
+		require.NoError(t, json.NewDecoder(r.Body).Decode(&event))
+		lock.Lock()
+		events = append(events, event)
+		lock.Unlock()
+	}))
+	defer server.Close()
+
+	sink := NewHTTPSink(HTTPSinkOptions{
+		URL:     server.URL,
+		Headers: map[string]string{"X-Api-Key": "secret"},
+	})
+	require.NoError(t, sink.Write(Event{Caller: "alice", Path: "/api/v1/placement"}))
+	require.NoError(t, sink.Write(Event{Caller: "bob", Path: "/api/v1/namespace"}))
+
+	// Closing the sink sends the queued events.
+	require.NoError(t, sink.Close())
+	require.Len(t, events, 2)
+	assert.Equal(t, "alice", events[0].Caller)
+	assert.Equal(t, "/api/v1/namespace", events[1].Path)
+}
+
+func TestHTTPSinkDropsWhenFull(t *testing.T) {
+	block := make(chan struct{})
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		<-block
+	}))
+	defer server.Close()
+
+	sink := NewHTTPSink(HTTPSinkOptions{URL: server.URL, QueueSize: 1})
+
+	// One event is being sent and one is queued, so eventually a write is
+	// dropped.
+	var err error
+	for i := 0; i < 3 && err == nil; i++ {
+		err = sink.Write(Event{})
+	}
+	assert.Equal(t, errHTTPSinkQueueFull, err)
+
+	close(block)
+	require.NoError(t, sink.Close())
+}
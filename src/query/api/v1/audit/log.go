@@ -0,0 +1,53 @@
+// Copyright (c) 2018 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package audit
+
+import (
+	"go.uber.org/zap"
+)
+
+type logSink struct {
+	logger *zap.Logger
+}
+
+// NewLogSink returns a sink that writes events as structured log entries.
+func NewLogSink(logger *zap.Logger) Sink {
+	return &logSink{logger: logger}
+}
+
+func (s *logSink) Write(event Event) error {
+	s.logger.Info("audit",
+		zap.Time("time", event.Time),
+		zap.String("caller", event.Caller),
+		zap.String("remoteAddr", event.RemoteAddr),
+		zap.String("method", event.Method),
+		zap.String("path", event.Path),
+		zap.Any("params", event.Params),
+		zap.String("body", event.Body),
+		zap.Int("status", event.Status),
+		zap.String("error", event.Error),
+		zap.Duration("duration", event.Duration))
+	return nil
+}
+
+func (s *logSink) Close() error {
+	return nil
+}
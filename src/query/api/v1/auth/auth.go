@@ -0,0 +1,136 @@
+// Copyright (c) 2018 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+// Package auth authenticates the bearer tokens of requests to the
+// coordinator HTTP APIs and authorizes them by the role each route
+// requires.
+package auth
+
+import (
+	"errors"
+	"net/http"
+	"strings"
+
+	"github.com/m3db/m3/src/query/api/v1/handler"
+	"github.com/m3db/m3/src/query/storage/access"
+	"github.com/m3db/m3/src/query/util/logging"
+
+	"github.com/uber-go/tally"
+	"go.uber.org/zap"
+)
+
+const (
+	authorizationHeader = "Authorization"
+	bearerPrefix        = "Bearer "
+)
+
+var (
+	errMissingToken = errors.New("missing bearer token")
+	errForbidden    = errors.New("token is not granted the role required")
+)
+
+type authMetrics struct {
+	authorized      tally.Counter
+	unauthenticated tally.Counter
+	forbidden       tally.Counter
+}
+
+func newAuthMetrics(scope tally.Scope) authMetrics {
+	return authMetrics{
+		authorized:      scope.Counter("authorized"),
+		unauthenticated: scope.Counter("unauthenticated"),
+		forbidden:       scope.Counter("forbidden"),
+	}
+}
+
+// Auth is the middleware that authenticates requests with the bearer
+// token of their Authorization header and authorizes them by role.
+type Auth struct {
+	authenticators []Authenticator
+	metrics        authMetrics
+}
+
+// NewAuth returns a new auth middleware, a token is authenticated by the
+// first authenticator that accepts it.
+func NewAuth(authenticators []Authenticator, scope tally.Scope) *Auth {
+	return &Auth{
+		authenticators: authenticators,
+		metrics:        newAuthMetrics(scope.SubScope("auth")),
+	}
+}
+
+// Authenticate returns the identity of the bearer token of a request.
+func (a *Auth) Authenticate(r *http.Request) (Identity, error) {
+	header := r.Header.Get(authorizationHeader)
+	if !strings.HasPrefix(header, bearerPrefix) {
+		return Identity{}, errMissingToken
+	}
+	token := strings.TrimSpace(strings.TrimPrefix(header, bearerPrefix))
+	if token == "" {
+		return Identity{}, errMissingToken
+	}
+
+	var lastErr error
+	for _, authenticator := range a.authenticators {
+		identity, err := authenticator.Authenticate(token)
+		if err == nil {
+			return identity, nil
+		}
+		lastErr = err
+	}
+	return Identity{}, lastErr
+}
+
+// Require returns a handler that only serves requests with a token that is
+// granted the role.
+func (a *Auth) Require(role Role, next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		logger := logging.WithContext(r.Context())
+
+		identity, err := a.Authenticate(r)
+		if err != nil {
+			a.metrics.unauthenticated.Inc(1)
+			logger.Warn("unauthenticated request",
+				zap.String("path", r.URL.Path), zap.Error(err))
+			w.Header().Set("WWW-Authenticate", `Bearer realm="m3coordinator"`)
+			handler.Error(w, err, http.StatusUnauthorized)
+			return
+		}
+
+		if !identity.HasRole(role) {
+			a.metrics.forbidden.Inc(1)
+			logger.Warn("forbidden request",
+				zap.String("path", r.URL.Path),
+				zap.String("identity", identity.Name),
+				zap.Stringer("role", role))
+			handler.Error(w, errForbidden, http.StatusForbidden)
+			return
+		}
+
+		a.metrics.authorized.Inc(1)
+		// The identity scopes the namespaces and labels the request may access.
+		roles := make([]string, 0, len(identity.Roles))
+		for _, role := range identity.Roles {
+			roles = append(roles, role.String())
+		}
+		next.ServeHTTP(w, r.WithContext(
+			access.NewContext(r.Context(), identity.Name, roles)))
+	})
+}
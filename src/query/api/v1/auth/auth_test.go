@@ -0,0 +1,132 @@
+// Copyright (c) 2018 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package auth
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/m3db/m3/src/query/storage/access"
+	"github.com/m3db/m3/src/query/util/logging"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/uber-go/tally"
+)
+
+func newTestAuth(t *testing.T) (*Auth, tally.TestScope) {
+	scope := tally.NewTestScope("", nil)
+	a, err := Configuration{
+		Tokens: []TokenConfiguration{
+			{Name: "reader", Token: "read-token", Roles: []Role{RoleRead}},
+			{Name: "writer", Token: "write-token", Roles: []Role{RoleRead, RoleWrite}},
+			{Name: "admin", Token: "admin-token", Roles: []Role{RoleAdmin}},
+		},
+	}.NewAuth(scope)
+	require.NoError(t, err)
+	return a, scope
+}
+
+func TestAuthRequire(t *testing.T) {
+	logging.InitWithCores(nil)
+	a, scope := newTestAuth(t)
+
+	ok := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	})
+
+	tests := []struct {
+		header string
+		role   Role
+		code   int
+	}{
+		{header: "", role: RoleRead, code: http.StatusUnauthorized},
+		{header: "Basic read-token", role: RoleRead, code: http.StatusUnauthorized},
+		{header: "Bearer unknown", role: RoleRead, code: http.StatusUnauthorized},
+		{header: "Bearer read-token", role: RoleRead, code: http.StatusOK},
+		{header: "Bearer read-token", role: RoleWrite, code: http.StatusForbidden},
+		{header: "Bearer write-token", role: RoleWrite, code: http.StatusOK},
+		{header: "Bearer write-token", role: RoleAdmin, code: http.StatusForbidden},
+		{header: "Bearer admin-token", role: RoleRead, code: http.StatusOK},
+		{header: "Bearer admin-token", role: RoleAdmin, code: http.StatusOK},
+	}
+	for _, test := range tests {
+		req := httptest.NewRequest(http.MethodGet, "/api/v1/test", nil)
+		if test.header != "" {
+			req.Header.Set(authorizationHeader, test.header)
+		}
+		w := httptest.NewRecorder()
+		a.Require(test.role, ok).ServeHTTP(w, req)
+		assert.Equal(t, test.code, w.Code, "%s requiring %s", test.header, test.role)
+		if test.code == http.StatusUnauthorized {
+			assert.NotEmpty(t, w.Header().Get("WWW-Authenticate"))
+		}
+	}
+
+	counters := scope.Snapshot().Counters()
+	assert.Equal(t, int64(4), counters["auth.authorized+"].Value())
+	assert.Equal(t, int64(3), counters["auth.unauthenticated+"].Value())
+	assert.Equal(t, int64(2), counters["auth.forbidden+"].Value())
+}
+
+func TestAuthRequireSetsIdentity(t *testing.T) {
+	logging.InitWithCores(nil)
+	a, _ := newTestAuth(t)
+
+	var principal access.Principal
+	next := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		principal = access.PrincipalFromContext(r.Context())
+	})
+
+	req := httptest.NewRequest(http.MethodGet, "/api/v1/test", nil)
+	req.Header.Set(authorizationHeader, "Bearer write-token")
+	a.Require(RoleWrite, next).ServeHTTP(httptest.NewRecorder(), req)
+	assert.Equal(t, "writer", principal.Identity)
+	assert.Equal(t, []string{"read", "write"}, principal.Roles)
+}
+
+func TestConfigurationNewAuthInvalid(t *testing.T) {
+	scope := tally.NoopScope
+	_, err := Configuration{}.NewAuth(scope)
+	assert.Equal(t, errNoAuthenticators, err)
+
+	_, err = Configuration{
+		Tokens: []TokenConfiguration{{Name: "empty", Roles: []Role{RoleRead}}},
+	}.NewAuth(scope)
+	assert.Equal(t, errEmptyToken, err)
+
+	_, err = Configuration{OIDC: &OIDCConfiguration{IssuerURL: "https://issuer"}}.NewAuth(scope)
+	assert.Equal(t, errNoOIDCClientID, err)
+}
+
+func TestParseRole(t *testing.T) {
+	for _, valid := range ValidRoles() {
+		parsed, err := ParseRole(valid.String())
+		require.NoError(t, err)
+		assert.Equal(t, valid, parsed)
+	}
+
+	_, err := ParseRole("")
+	assert.Error(t, err)
+	_, err = ParseRole("superuser")
+	assert.Error(t, err)
+}
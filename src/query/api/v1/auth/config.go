@@ -0,0 +1,103 @@
+// Copyright (c) 2018 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package auth
+
+import (
+	"errors"
+	"time"
+
+	"github.com/uber-go/tally"
+)
+
+var (
+	errNoAuthenticators = errors.New("auth enabled with no tokens or oidc configured")
+	errEmptyToken       = errors.New("auth token must not be empty")
+	errNoOIDCIssuer     = errors.New("oidc issuerURL must be set")
+	errNoOIDCClientID   = errors.New("oidc clientID must be set")
+)
+
+// Configuration is the authentication and authorization configuration of
+// the coordinator HTTP APIs.
+type Configuration struct {
+	// Tokens are the static bearer tokens accepted.
+	Tokens []TokenConfiguration `yaml:"tokens"`
+
+	// OIDC is the configuration of the OIDC provider whose ID tokens are
+	// accepted as bearer tokens.
+	OIDC *OIDCConfiguration `yaml:"oidc"`
+}
+
+// TokenConfiguration is a static bearer token.
+type TokenConfiguration struct {
+	// Name identifies the token in logs and metrics.
+	Name string `yaml:"name" validate:"nonzero"`
+
+	// Token is the secret bearer token.
+	Token string `yaml:"token" validate:"nonzero"`
+
+	// Roles are the roles granted to requests with the token.
+	Roles []Role `yaml:"roles" validate:"nonzero"`
+}
+
+// OIDCConfiguration is the configuration of an OIDC provider.
+type OIDCConfiguration struct {
+	// IssuerURL is the URL of the provider, its signing keys are discovered
+	// from the provider configuration at
+	// <issuerURL>/.well-known/openid-configuration.
+	IssuerURL string `yaml:"issuerURL" validate:"nonzero"`
+
+	// ClientID is the audience that tokens must be issued for.
+	ClientID string `yaml:"clientID" validate:"nonzero"`
+
+	// RolesClaim is the claim listing the roles granted to the subject of
+	// a token, defaults to "roles".
+	RolesClaim string `yaml:"rolesClaim"`
+
+	// Timeout is the timeout of requests to the provider.
+	Timeout time.Duration `yaml:"timeout"`
+}
+
+// NewAuth returns the authentication and authorization middleware of the
+// configuration.
+func (c Configuration) NewAuth(scope tally.Scope) (*Auth, error) {
+	var authenticators []Authenticator
+	if len(c.Tokens) > 0 {
+		for _, t := range c.Tokens {
+			if t.Token == "" {
+				return nil, errEmptyToken
+			}
+		}
+		authenticators = append(authenticators, NewStaticAuthenticator(c.Tokens))
+	}
+	if c.OIDC != nil {
+		if c.OIDC.IssuerURL == "" {
+			return nil, errNoOIDCIssuer
+		}
+		if c.OIDC.ClientID == "" {
+			return nil, errNoOIDCClientID
+		}
+		authenticators = append(authenticators, NewOIDCAuthenticator(*c.OIDC))
+	}
+	if len(authenticators) == 0 {
+		return nil, errNoAuthenticators
+	}
+	return NewAuth(authenticators, scope), nil
+}
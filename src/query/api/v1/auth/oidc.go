@@ -0,0 +1,336 @@
+// Copyright (c) 2018 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package auth
+
+import (
+	"crypto"
+	"crypto/rsa"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math/big"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	oidcDiscoveryPath       = "/.well-known/openid-configuration"
+	defaultOIDCRolesClaim   = "roles"
+	defaultOIDCTimeout      = 10 * time.Second
+	oidcMinKeyRefreshPeriod = time.Minute
+	// oidcClockSkew is the leeway allowed when checking token expiry.
+	oidcClockSkew = time.Minute
+)
+
+var (
+	errMalformedJWT       = errors.New("malformed jwt")
+	errUnsupportedJWTAlg  = errors.New("unsupported jwt signing algorithm, only RS256 is supported")
+	errUnknownJWTKey      = errors.New("jwt signed with unknown key")
+	errInvalidJWTIssuer   = errors.New("jwt issuer does not match")
+	errInvalidJWTAudience = errors.New("jwt audience does not match")
+	errExpiredJWT         = errors.New("jwt expired")
+	errJWTNotYetValid     = errors.New("jwt not yet valid")
+)
+
+type jwtHeader struct {
+	Alg string `json:"alg"`
+	Kid string `json:"kid"`
+}
+
+type jwk struct {
+	Kty string `json:"kty"`
+	Kid string `json:"kid"`
+	Use string `json:"use"`
+	N   string `json:"n"`
+	E   string `json:"e"`
+}
+
+type oidcAuthenticator struct {
+	sync.RWMutex
+
+	cfg        OIDCConfiguration
+	httpClient *http.Client
+	nowFn      func() time.Time
+
+	keys          map[string]*rsa.PublicKey
+	lastRefreshAt time.Time
+	refresh       *oidcKeysRefresh
+}
+
+// oidcKeysRefresh is an in-flight fetch of the signing keys of the issuer,
+// done is closed once keys or err are set.
+type oidcKeysRefresh struct {
+	done chan struct{}
+	keys map[string]*rsa.PublicKey
+	err  error
+}
+
+// NewOIDCAuthenticator returns an authenticator of OIDC ID tokens signed
+// by the issuer of the configuration, the signing keys are fetched from
+// the issuer as tokens signed with unknown keys are seen.
+func NewOIDCAuthenticator(cfg OIDCConfiguration) Authenticator {
+	timeout := cfg.Timeout
+	if timeout <= 0 {
+		timeout = defaultOIDCTimeout
+	}
+	if cfg.RolesClaim == "" {
+		cfg.RolesClaim = defaultOIDCRolesClaim
+	}
+	cfg.IssuerURL = strings.TrimSuffix(cfg.IssuerURL, "/")
+	return &oidcAuthenticator{
+		cfg:        cfg,
+		httpClient: &http.Client{Timeout: timeout},
+		nowFn:      time.Now,
+		keys:       make(map[string]*rsa.PublicKey),
+	}
+}
+
+func (a *oidcAuthenticator) Authenticate(token string) (Identity, error) {
+	parts := strings.Split(token, ".")
+	if len(parts) != 3 {
+		return Identity{}, errMalformedJWT
+	}
+
+	var header jwtHeader
+	if err := decodeJWTSegment(parts[0], &header); err != nil {
+		return Identity{}, err
+	}
+	if header.Alg != "RS256" {
+		return Identity{}, errUnsupportedJWTAlg
+	}
+
+	key, err := a.key(header.Kid)
+	if err != nil {
+		return Identity{}, err
+	}
+
+	signature, err := base64.RawURLEncoding.DecodeString(parts[2])
+	if err != nil {
+		return Identity{}, errMalformedJWT
+	}
+	digest := sha256.Sum256([]byte(parts[0] + "." + parts[1]))
+	if err := rsa.VerifyPKCS1v15(key, crypto.SHA256, digest[:], signature); err != nil {
+		return Identity{}, fmt.Errorf("invalid jwt signature: %v", err)
+	}
+
+	var claims map[string]interface{}
+	if err := decodeJWTSegment(parts[1], &claims); err != nil {
+		return Identity{}, err
+	}
+	return a.identity(claims)
+}
+
+// identity validates the claims of a token and returns its identity.
+func (a *oidcAuthenticator) identity(claims map[string]interface{}) (Identity, error) {
+	if iss, _ := claims["iss"].(string); strings.TrimSuffix(iss, "/") != a.cfg.IssuerURL {
+		return Identity{}, errInvalidJWTIssuer
+	}
+	if !containsString(stringsClaim(claims["aud"]), a.cfg.ClientID) {
+		return Identity{}, errInvalidJWTAudience
+	}
+
+	now := a.nowFn()
+	exp, ok := claims["exp"].(float64)
+	if !ok || now.After(time.Unix(int64(exp), 0).Add(oidcClockSkew)) {
+		return Identity{}, errExpiredJWT
+	}
+	if nbf, ok := claims["nbf"].(float64); ok &&
+		now.Add(oidcClockSkew).Before(time.Unix(int64(nbf), 0)) {
+		return Identity{}, errJWTNotYetValid
+	}
+
+	identity := Identity{}
+	identity.Name, _ = claims["sub"].(string)
+	for _, value := range stringsClaim(claims[a.cfg.RolesClaim]) {
+		// Roles the coordinator does not know of are ignored since the
+		// claim may be shared with other services.
+		if role, err := ParseRole(value); err == nil {
+			identity.Roles = append(identity.Roles, role)
+		}
+	}
+	return identity, nil
+}
+
+// key returns the signing key with an ID, refreshing the keys of the
+// issuer if the key is unknown and they were not refreshed recently. Callers
+// that need a refresh while one is in flight wait for its result rather than
+// starting another, and a failed refresh does not delay the next one.
+func (a *oidcAuthenticator) key(kid string) (*rsa.PublicKey, error) {
+	a.RLock()
+	key, ok := a.keys[kid]
+	a.RUnlock()
+	if ok {
+		return key, nil
+	}
+
+	a.Lock()
+	if key, ok := a.keys[kid]; ok {
+		a.Unlock()
+		return key, nil
+	}
+
+	if refresh := a.refresh; refresh != nil {
+		a.Unlock()
+		<-refresh.done
+		return refresh.key(kid)
+	}
+
+	now := a.nowFn()
+	if !a.lastRefreshAt.IsZero() && now.Sub(a.lastRefreshAt) < oidcMinKeyRefreshPeriod {
+		a.Unlock()
+		return nil, errUnknownJWTKey
+	}
+
+	// NB: the fetch happens outside the lock so that tokens signed with
+	// known keys are not blocked on it.
+	refresh := &oidcKeysRefresh{done: make(chan struct{})}
+	a.refresh = refresh
+	a.Unlock()
+
+	keys, err := a.fetchKeys()
+	if err != nil {
+		err = fmt.Errorf("unable to fetch oidc signing keys: %v", err)
+	}
+
+	a.Lock()
+	if err == nil {
+		a.keys = keys
+		a.lastRefreshAt = now
+	}
+	a.refresh = nil
+	a.Unlock()
+
+	refresh.keys, refresh.err = keys, err
+	close(refresh.done)
+	return refresh.key(kid)
+}
+
+func (r *oidcKeysRefresh) key(kid string) (*rsa.PublicKey, error) {
+	if r.err != nil {
+		return nil, r.err
+	}
+	if key, ok := r.keys[kid]; ok {
+		return key, nil
+	}
+	return nil, errUnknownJWTKey
+}
+
+func (a *oidcAuthenticator) fetchKeys() (map[string]*rsa.PublicKey, error) {
+	var discovery struct {
+		JWKSURI string `json:"jwks_uri"`
+	}
+	if err := a.getJSON(a.cfg.IssuerURL+oidcDiscoveryPath, &discovery); err != nil {
+		return nil, err
+	}
+	if discovery.JWKSURI == "" {
+		return nil, errors.New("oidc discovery document has no jwks_uri")
+	}
+
+	var jwks struct {
+		Keys []jwk `json:"keys"`
+	}
+	if err := a.getJSON(discovery.JWKSURI, &jwks); err != nil {
+		return nil, err
+	}
+
+	keys := make(map[string]*rsa.PublicKey, len(jwks.Keys))
+	for _, k := range jwks.Keys {
+		if k.Kty != "RSA" || (k.Use != "" && k.Use != "sig") {
+			continue
+		}
+		key, err := k.rsaPublicKey()
+		if err != nil {
+			return nil, err
+		}
+		keys[k.Kid] = key
+	}
+	return keys, nil
+}
+
+func (a *oidcAuthenticator) getJSON(url string, result interface{}) error {
+	resp, err := a.httpClient.Get(url)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("GET %s: %d", url, resp.StatusCode)
+	}
+	return json.NewDecoder(resp.Body).Decode(result)
+}
+
+func (k jwk) rsaPublicKey() (*rsa.PublicKey, error) {
+	n, err := base64.RawURLEncoding.DecodeString(k.N)
+	if err != nil {
+		return nil, fmt.Errorf("invalid modulus of jwk %s: %v", k.Kid, err)
+	}
+	e, err := base64.RawURLEncoding.DecodeString(k.E)
+	if err != nil {
+		return nil, fmt.Errorf("invalid exponent of jwk %s: %v", k.Kid, err)
+	}
+	return &rsa.PublicKey{
+		N: new(big.Int).SetBytes(n),
+		E: int(new(big.Int).SetBytes(e).Int64()),
+	}, nil
+}
+
+func decodeJWTSegment(segment string, result interface{}) error {
+	data, err := base64.RawURLEncoding.DecodeString(segment)
+	if err != nil {
+		return errMalformedJWT
+	}
+	if err := json.Unmarshal(data, result); err != nil {
+		return errMalformedJWT
+	}
+	return nil
+}
+
+// stringsClaim returns the values of a claim that is either a string or a
+// list of strings.
+func stringsClaim(claim interface{}) []string {
+	switch v := claim.(type) {
+	case string:
+		return []string{v}
+	case []interface{}:
+		values := make([]string, 0, len(v))
+		for _, elem := range v {
+			if s, ok := elem.(string); ok {
+				values = append(values, s)
+			}
+		}
+		return values
+	}
+	return nil
+}
+
+func containsString(values []string, value string) bool {
+	for _, v := range values {
+		if v == value {
+			return true
+		}
+	}
+	return false
+}
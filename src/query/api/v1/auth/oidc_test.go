@@ -0,0 +1,253 @@
+// Copyright (c) 2018 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package auth
+
+import (
+	"crypto"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type testIssuer struct {
+	*httptest.Server
+	key       *rsa.PrivateKey
+	kid       string
+	jwksCalls int
+
+	// keysFailures is the number of key requests to fail, and keysGate
+	// blocks key requests until closed if set.
+	keysFailures int
+	keysGate     chan struct{}
+	keysFetching chan struct{}
+}
+
+func newTestIssuer(t *testing.T) *testIssuer {
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	require.NoError(t, err)
+
+	issuer := &testIssuer{key: key, kid: "key1"}
+	mux := http.NewServeMux()
+	mux.HandleFunc(oidcDiscoveryPath, func(w http.ResponseWriter, r *http.Request) {
+		json.NewEncoder(w).Encode(map[string]string{
+			"issuer":   issuer.URL,
+			"jwks_uri": issuer.URL + "/keys",
+		})
+	})
+	mux.HandleFunc("/keys", func(w http.ResponseWriter, r *http.Request) {
+		issuer.jwksCalls++
+		if issuer.keysGate != nil {
+			close(issuer.keysFetching)
+			<-issuer.keysGate
+		}
+		if issuer.keysFailures > 0 {
+			issuer.keysFailures--
+			w.WriteHeader(http.StatusInternalServerError)
+			return
+		}
+		json.NewEncoder(w).Encode(map[string][]jwk{
+			"keys": {{
+				Kty: "RSA",
+				Kid: issuer.kid,
+				Use: "sig",
+				N:   base64.RawURLEncoding.EncodeToString(key.PublicKey.N.Bytes()),
+				E:   base64.RawURLEncoding.EncodeToString(big.NewInt(int64(key.PublicKey.E)).Bytes()),
+			}},
+		})
+	})
+	issuer.Server = httptest.NewServer(mux)
+	return issuer
+}
+
+func (i *testIssuer) token(t *testing.T, kid string, claims map[string]interface{}) string {
+	header, err := json.Marshal(jwtHeader{Alg: "RS256", Kid: kid})
+	require.NoError(t, err)
+	payload, err := json.Marshal(claims)
+	require.NoError(t, err)
+
+	signed := base64.RawURLEncoding.EncodeToString(header) + "." +
+		base64.RawURLEncoding.EncodeToString(payload)
+	digest := sha256.Sum256([]byte(signed))
+	signature, err := rsa.SignPKCS1v15(rand.Reader, i.key, crypto.SHA256, digest[:])
+	require.NoError(t, err)
+	return signed + "." + base64.RawURLEncoding.EncodeToString(signature)
+}
+
+func (i *testIssuer) claims() map[string]interface{} {
+	return map[string]interface{}{
+		"iss":   i.URL,
+		"aud":   []string{"m3coordinator"},
+		"sub":   "alice",
+		"exp":   time.Now().Add(time.Hour).Unix(),
+		"roles": []string{"read", "write", "unrelated"},
+	}
+}
+
+func TestOIDCAuthenticator(t *testing.T) {
+	issuer := newTestIssuer(t)
+	defer issuer.Close()
+
+	a := NewOIDCAuthenticator(OIDCConfiguration{
+		IssuerURL: issuer.URL,
+		ClientID:  "m3coordinator",
+	})
+
+	identity, err := a.Authenticate(issuer.token(t, issuer.kid, issuer.claims()))
+	require.NoError(t, err)
+	assert.Equal(t, "alice", identity.Name)
+	assert.Equal(t, []Role{RoleRead, RoleWrite}, identity.Roles)
+
+	// Keys are cached.
+	_, err = a.Authenticate(issuer.token(t, issuer.kid, issuer.claims()))
+	require.NoError(t, err)
+	assert.Equal(t, 1, issuer.jwksCalls)
+
+	// Unknown keys are not refetched more than once per refresh period.
+	_, err = a.Authenticate(issuer.token(t, "key2", issuer.claims()))
+	assert.Equal(t, errUnknownJWTKey, err)
+	assert.Equal(t, 1, issuer.jwksCalls)
+}
+
+func TestOIDCAuthenticatorWaitsForInFlightRefresh(t *testing.T) {
+	issuer := newTestIssuer(t)
+	defer issuer.Close()
+	issuer.keysGate = make(chan struct{})
+	issuer.keysFetching = make(chan struct{})
+
+	a := NewOIDCAuthenticator(OIDCConfiguration{
+		IssuerURL: issuer.URL,
+		ClientID:  "m3coordinator",
+	})
+
+	var (
+		token = issuer.token(t, issuer.kid, issuer.claims())
+		errs  = make(chan error, 5)
+	)
+	authenticate := func() {
+		_, err := a.Authenticate(token)
+		errs <- err
+	}
+	go authenticate()
+	<-issuer.keysFetching
+
+	// Callers with the same unknown key wait for the refresh in flight
+	// rather than failing or fetching the keys again.
+	for i := 0; i < 4; i++ {
+		go authenticate()
+	}
+	time.Sleep(50 * time.Millisecond)
+	close(issuer.keysGate)
+
+	for i := 0; i < 5; i++ {
+		require.NoError(t, <-errs)
+	}
+	assert.Equal(t, 1, issuer.jwksCalls)
+}
+
+func TestOIDCAuthenticatorRetriesFailedRefresh(t *testing.T) {
+	issuer := newTestIssuer(t)
+	defer issuer.Close()
+	issuer.keysFailures = 1
+
+	a := NewOIDCAuthenticator(OIDCConfiguration{
+		IssuerURL: issuer.URL,
+		ClientID:  "m3coordinator",
+	})
+
+	token := issuer.token(t, issuer.kid, issuer.claims())
+	_, err := a.Authenticate(token)
+	require.Error(t, err)
+
+	// A failed refresh does not delay the next one.
+	_, err = a.Authenticate(token)
+	require.NoError(t, err)
+	assert.Equal(t, 2, issuer.jwksCalls)
+}
+
+func TestOIDCAuthenticatorInvalidTokens(t *testing.T) {
+	issuer := newTestIssuer(t)
+	defer issuer.Close()
+
+	a := NewOIDCAuthenticator(OIDCConfiguration{
+		IssuerURL: issuer.URL,
+		ClientID:  "m3coordinator",
+	})
+
+	tests := []struct {
+		name   string
+		update func(claims map[string]interface{})
+		err    error
+	}{
+		{
+			name:   "wrong issuer",
+			update: func(claims map[string]interface{}) { claims["iss"] = "https://other" },
+			err:    errInvalidJWTIssuer,
+		},
+		{
+			name:   "wrong audience",
+			update: func(claims map[string]interface{}) { claims["aud"] = "other" },
+			err:    errInvalidJWTAudience,
+		},
+		{
+			name: "expired",
+			update: func(claims map[string]interface{}) {
+				claims["exp"] = time.Now().Add(-time.Hour).Unix()
+			},
+			err: errExpiredJWT,
+		},
+		{
+			name: "not yet valid",
+			update: func(claims map[string]interface{}) {
+				claims["nbf"] = time.Now().Add(time.Hour).Unix()
+			},
+			err: errJWTNotYetValid,
+		},
+	}
+	for _, test := range tests {
+		claims := issuer.claims()
+		test.update(claims)
+		_, err := a.Authenticate(issuer.token(t, issuer.kid, claims))
+		assert.Equal(t, test.err, err, test.name)
+	}
+
+	_, err := a.Authenticate("not-a-jwt")
+	assert.Equal(t, errMalformedJWT, err)
+
+	// Tampered payloads fail signature verification.
+	var (
+		token    = strings.Split(issuer.token(t, issuer.kid, issuer.claims()), ".")
+		other    = strings.Split(issuer.token(t, issuer.kid, map[string]interface{}{"sub": "mallory"}), ".")
+		tampered = strings.Join([]string{token[0], other[1], token[2]}, ".")
+	)
+	_, err = a.Authenticate(tampered)
+	assert.Error(t, err)
+}
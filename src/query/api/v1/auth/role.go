@@ -0,0 +1,108 @@
+// Copyright (c) 2018 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package auth
+
+import (
+	"errors"
+	"fmt"
+)
+
+var (
+	errRoleUnspecified = errors.New("auth role unspecified")
+)
+
+// Role is the role required by a route, granted to the identities
+// authenticated by a token.
+type Role uint
+
+const (
+	// RoleRead allows querying and reading metrics.
+	RoleRead Role = iota
+	// RoleWrite allows writing metrics.
+	RoleWrite
+	// RoleAdmin allows managing the cluster, it grants all other roles.
+	RoleAdmin
+)
+
+// ValidRoles returns the valid roles.
+func ValidRoles() []Role {
+	return []Role{RoleRead, RoleWrite, RoleAdmin}
+}
+
+func (r Role) String() string {
+	switch r {
+	case RoleRead:
+		return "read"
+	case RoleWrite:
+		return "write"
+	case RoleAdmin:
+		return "admin"
+	}
+	return "unknown"
+}
+
+// ParseRole parses a Role from a string.
+func ParseRole(str string) (Role, error) {
+	var r Role
+	if str == "" {
+		return r, errRoleUnspecified
+	}
+	for _, valid := range ValidRoles() {
+		if str == valid.String() {
+			r = valid
+			return r, nil
+		}
+	}
+	return r, fmt.Errorf("invalid auth Role '%s' valid roles are: %v",
+		str, ValidRoles())
+}
+
+// UnmarshalYAML unmarshals a Role into a valid type from string.
+func (r *Role) UnmarshalYAML(unmarshal func(interface{}) error) error {
+	var str string
+	if err := unmarshal(&str); err != nil {
+		return err
+	}
+	parsed, err := ParseRole(str)
+	if err != nil {
+		return err
+	}
+	*r = parsed
+	return nil
+}
+
+// Identity is an authenticated caller.
+type Identity struct {
+	// Name is the name of the token or the subject of the OIDC token.
+	Name  string
+	Roles []Role
+}
+
+// HasRole returns whether the identity is granted a role, the admin role
+// grants all roles.
+func (i Identity) HasRole(role Role) bool {
+	for _, r := range i.Roles {
+		if r == role || r == RoleAdmin {
+			return true
+		}
+	}
+	return false
+}
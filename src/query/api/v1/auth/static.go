@@ -0,0 +1,78 @@
+// Copyright (c) 2018 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package auth
+
+import (
+	"crypto/subtle"
+	"errors"
+)
+
+var (
+	errInvalidToken = errors.New("invalid token")
+)
+
+// Authenticator authenticates the bearer token of a request.
+type Authenticator interface {
+	// Authenticate returns the identity of a bearer token, or an error if
+	// the token is not valid.
+	Authenticate(token string) (Identity, error)
+}
+
+type staticToken struct {
+	token    []byte
+	identity Identity
+}
+
+type staticAuthenticator struct {
+	tokens []staticToken
+}
+
+// NewStaticAuthenticator returns an authenticator of static bearer tokens.
+func NewStaticAuthenticator(tokens []TokenConfiguration) Authenticator {
+	a := &staticAuthenticator{tokens: make([]staticToken, 0, len(tokens))}
+	for _, t := range tokens {
+		a.tokens = append(a.tokens, staticToken{
+			token:    []byte(t.Token),
+			identity: Identity{Name: t.Name, Roles: t.Roles},
+		})
+	}
+	return a
+}
+
+func (a *staticAuthenticator) Authenticate(token string) (Identity, error) {
+	// Compare against all tokens in constant time so the time taken does
+	// not reveal which tokens exist.
+	var (
+		value    = []byte(token)
+		identity Identity
+		found    bool
+	)
+	for _, t := range a.tokens {
+		if subtle.ConstantTimeCompare(t.token, value) == 1 {
+			identity = t.identity
+			found = true
+		}
+	}
+	if !found {
+		return Identity{}, errInvalidToken
+	}
+	return identity, nil
+}
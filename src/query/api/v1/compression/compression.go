@@ -0,0 +1,271 @@
+// Copyright (c) 2018 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+// Package compression negotiates the encoding of HTTP responses from the
+// Accept-Encoding header of requests and compresses them with pooled
+// encoders.
+package compression
+
+import (
+	"bytes"
+	"compress/gzip"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/golang/snappy"
+)
+
+// Encoding is a content encoding of a response.
+type Encoding string
+
+const (
+	// Identity is the encoding of uncompressed responses.
+	Identity Encoding = "identity"
+
+	// Gzip is the gzip encoding.
+	Gzip Encoding = "gzip"
+
+	// Snappy is the snappy block encoding, as used by Prometheus remote
+	// read and write.
+	Snappy Encoding = "snappy"
+
+	acceptEncodingHeader  = "Accept-Encoding"
+	contentEncodingHeader = "Content-Encoding"
+)
+
+// DefaultEncodings are the encodings responses are compressed with, in the
+// order they are preferred when a request accepts several of them equally.
+var DefaultEncodings = []Encoding{Snappy, Gzip}
+
+// Negotiate returns the encoding of the supported encodings accepted by the
+// Accept-Encoding header with the highest quality, ties are broken by the
+// order of the supported encodings. Identity is returned if the header
+// accepts none of them.
+func Negotiate(acceptEncoding string, supported ...Encoding) Encoding {
+	var (
+		qualities = make(map[Encoding]float64, len(supported))
+		wildcard  = -1.0
+	)
+	for _, part := range strings.Split(acceptEncoding, ",") {
+		name, quality := parseAcceptEncoding(part)
+		if name == "*" {
+			wildcard = quality
+			continue
+		}
+		if name != "" {
+			qualities[Encoding(name)] = quality
+		}
+	}
+
+	var (
+		best        = Identity
+		bestQuality = 0.0
+	)
+	for _, encoding := range supported {
+		quality, ok := qualities[encoding]
+		if !ok {
+			quality = wildcard
+		}
+		if quality > bestQuality {
+			best, bestQuality = encoding, quality
+		}
+	}
+	return best
+}
+
+// parseAcceptEncoding returns the encoding of an element of an
+// Accept-Encoding header and its quality, which is zero if invalid.
+func parseAcceptEncoding(part string) (string, float64) {
+	var (
+		params  = strings.Split(part, ";")
+		name    = strings.ToLower(strings.TrimSpace(params[0]))
+		quality = 1.0
+	)
+	for _, param := range params[1:] {
+		param = strings.TrimSpace(param)
+		if !strings.HasPrefix(param, "q=") {
+			continue
+		}
+		q, err := strconv.ParseFloat(strings.TrimPrefix(param, "q="), 64)
+		if err != nil || q < 0 || q > 1 {
+			return name, 0
+		}
+		quality = q
+	}
+	return name, quality
+}
+
+// Encode returns the data compressed with the encoding.
+func Encode(encoding Encoding, data []byte) ([]byte, error) {
+	switch encoding {
+	case Identity:
+		return data, nil
+	case Snappy:
+		return snappy.Encode(nil, data), nil
+	case Gzip:
+		var buf bytes.Buffer
+		enc := newEncoder(encoding, &buf)
+		if _, err := enc.Write(data); err != nil {
+			return nil, err
+		}
+		if err := enc.Close(); err != nil {
+			return nil, err
+		}
+		putEncoder(encoding, enc)
+		return buf.Bytes(), nil
+	}
+	return nil, fmt.Errorf("unknown encoding: %s", encoding)
+}
+
+// Wrap returns a handler that compresses the responses of the handler with
+// the encoding negotiated from the encodings, unless the handler sets its
+// own Content-Encoding.
+func Wrap(next http.Handler, encodings ...Encoding) http.Handler {
+	if len(encodings) == 0 {
+		encodings = DefaultEncodings
+	}
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Add("Vary", acceptEncodingHeader)
+		encoding := Negotiate(r.Header.Get(acceptEncodingHeader), encodings...)
+		if encoding == Identity {
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		cw := &compressWriter{ResponseWriter: w, encoding: encoding}
+		defer cw.close()
+		next.ServeHTTP(cw, r)
+	})
+}
+
+// compressWriter compresses the body of a response once its header is
+// written, unless the header sets the content encoding already.
+type compressWriter struct {
+	http.ResponseWriter
+
+	encoding Encoding
+	encoder  encoder
+	started  bool
+}
+
+func (w *compressWriter) start() {
+	w.started = true
+	header := w.Header()
+	if header.Get(contentEncodingHeader) != "" {
+		return
+	}
+	header.Set(contentEncodingHeader, string(w.encoding))
+	header.Del("Content-Length")
+	w.encoder = newEncoder(w.encoding, w.ResponseWriter)
+}
+
+func (w *compressWriter) WriteHeader(status int) {
+	if !w.started {
+		w.start()
+	}
+	w.ResponseWriter.WriteHeader(status)
+}
+
+func (w *compressWriter) Write(b []byte) (int, error) {
+	if !w.started {
+		w.start()
+	}
+	if w.encoder == nil {
+		return w.ResponseWriter.Write(b)
+	}
+	return w.encoder.Write(b)
+}
+
+func (w *compressWriter) close() error {
+	if w.encoder == nil {
+		return nil
+	}
+	err := w.encoder.Close()
+	putEncoder(w.encoding, w.encoder)
+	w.encoder = nil
+	return err
+}
+
+// encoder is a pooled streaming encoder.
+type encoder interface {
+	io.WriteCloser
+
+	// Reset discards the state of the encoder and makes it write to w.
+	Reset(w io.Writer)
+}
+
+var (
+	gzipEncoderPool = sync.Pool{New: func() interface{} {
+		return gzip.NewWriter(nil)
+	}}
+	snappyEncoderPool = sync.Pool{New: func() interface{} {
+		return &snappyEncoder{}
+	}}
+)
+
+func newEncoder(encoding Encoding, w io.Writer) encoder {
+	var enc encoder
+	switch encoding {
+	case Gzip:
+		enc = gzipEncoderPool.Get().(*gzip.Writer)
+	case Snappy:
+		enc = snappyEncoderPool.Get().(*snappyEncoder)
+	}
+	enc.Reset(w)
+	return enc
+}
+
+func putEncoder(encoding Encoding, enc encoder) {
+	enc.Reset(nil)
+	switch encoding {
+	case Gzip:
+		gzipEncoderPool.Put(enc)
+	case Snappy:
+		snappyEncoderPool.Put(enc)
+	}
+}
+
+// snappyEncoder buffers the data written to it and writes it as a single
+// snappy block when closed, since clients of snappy encoded responses such
+// as Prometheus expect the block format rather than the framed format.
+type snappyEncoder struct {
+	w   io.Writer
+	buf bytes.Buffer
+	dst []byte
+}
+
+func (e *snappyEncoder) Write(b []byte) (int, error) {
+	return e.buf.Write(b)
+}
+
+func (e *snappyEncoder) Close() error {
+	e.dst = snappy.Encode(e.dst[:cap(e.dst)], e.buf.Bytes())
+	_, err := e.w.Write(e.dst)
+	return err
+}
+
+func (e *snappyEncoder) Reset(w io.Writer) {
+	e.w = w
+	e.buf.Reset()
+}
@@ -0,0 +1,130 @@
+// Copyright (c) 2018 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package compression
+
+import (
+	"bytes"
+	"compress/gzip"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/golang/snappy"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestNegotiate(t *testing.T) {
+	for _, test := range []struct {
+		acceptEncoding string
+		expected       Encoding
+	}{
+		{acceptEncoding: "", expected: Identity},
+		{acceptEncoding: "br, deflate", expected: Identity},
+		{acceptEncoding: "gzip", expected: Gzip},
+		{acceptEncoding: "gzip, snappy", expected: Snappy},
+		{acceptEncoding: "gzip, snappy, zstd", expected: Snappy},
+		{acceptEncoding: "snappy;q=0.5, gzip;q=0.8", expected: Gzip},
+		{acceptEncoding: "SNAPPY ; q=1.0, gzip", expected: Snappy},
+		{acceptEncoding: "*", expected: Snappy},
+		{acceptEncoding: "*, snappy;q=0", expected: Gzip},
+		{acceptEncoding: "gzip;q=invalid", expected: Identity},
+	} {
+		assert.Equal(t, test.expected, Negotiate(test.acceptEncoding, DefaultEncodings...),
+			test.acceptEncoding)
+	}
+}
+
+func decode(t *testing.T, encoding Encoding, data []byte) []byte {
+	switch encoding {
+	case Gzip:
+		r, err := gzip.NewReader(bytes.NewReader(data))
+		require.NoError(t, err)
+		decoded, err := ioutil.ReadAll(r)
+		require.NoError(t, err)
+		return decoded
+	case Snappy:
+		decoded, err := snappy.Decode(nil, data)
+		require.NoError(t, err)
+		return decoded
+	}
+	return data
+}
+
+func TestWrapCompressesResponses(t *testing.T) {
+	body := strings.Repeat(`{"status":"success","data":[1,2,3]}`, 100)
+	h := Wrap(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		w.Write([]byte(body[:len(body)/2]))
+		w.Write([]byte(body[len(body)/2:]))
+	}))
+
+	// the pooled encoders are reused across requests
+	for i := 0; i < 2; i++ {
+		for _, encoding := range []Encoding{Identity, Gzip, Snappy} {
+			req := httptest.NewRequest(http.MethodGet, "/", nil)
+			req.Header.Set(acceptEncodingHeader, string(encoding))
+			recorder := httptest.NewRecorder()
+			h.ServeHTTP(recorder, req)
+
+			require.Equal(t, http.StatusOK, recorder.Code)
+			assert.Equal(t, acceptEncodingHeader, recorder.Header().Get("Vary"))
+			if encoding == Identity {
+				assert.Equal(t, "", recorder.Header().Get(contentEncodingHeader))
+				assert.Equal(t, body, recorder.Body.String())
+				continue
+			}
+			assert.Equal(t, string(encoding), recorder.Header().Get(contentEncodingHeader))
+			assert.True(t, recorder.Body.Len() < len(body), encoding)
+			assert.Equal(t, body, string(decode(t, encoding, recorder.Body.Bytes())), encoding)
+		}
+	}
+}
+
+func TestWrapKeepsEncodedResponses(t *testing.T) {
+	data := snappy.Encode(nil, []byte("already encoded"))
+	h := Wrap(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set(contentEncodingHeader, string(Snappy))
+		w.Write(data)
+	}))
+
+	req := httptest.NewRequest(http.MethodGet, "/", nil)
+	req.Header.Set(acceptEncodingHeader, "gzip")
+	recorder := httptest.NewRecorder()
+	h.ServeHTTP(recorder, req)
+
+	assert.Equal(t, string(Snappy), recorder.Header().Get(contentEncodingHeader))
+	assert.Equal(t, data, recorder.Body.Bytes())
+}
+
+func TestEncode(t *testing.T) {
+	data := []byte(strings.Repeat("series", 100))
+	for _, encoding := range []Encoding{Identity, Gzip, Snappy} {
+		encoded, err := Encode(encoding, data)
+		require.NoError(t, err)
+		assert.Equal(t, data, decode(t, encoding, encoded), encoding)
+	}
+
+	_, err := Encode("br", data)
+	assert.Error(t, err)
+}
@@ -0,0 +1,136 @@
+// Copyright (c) 2018 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package clusterconfig
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/m3db/m3/src/dbnode/runtime"
+	"github.com/m3db/m3/src/query/util/logging"
+	"github.com/m3db/m3cluster/client"
+	"github.com/m3db/m3cluster/kv/mem"
+
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func setupClusterConfigTest(t *testing.T) (*client.MockClient, *gomock.Controller) {
+	logging.InitWithCores(nil)
+
+	ctrl := gomock.NewController(t)
+	mockClient := client.NewMockClient(ctrl)
+	mockClient.EXPECT().KV().Return(mem.NewStore(), nil).AnyTimes()
+
+	return mockClient, ctrl
+}
+
+func serve(
+	t *testing.T,
+	h http.Handler,
+	method, url, body string,
+) (int, runtime.ClusterConfigHistory) {
+	w := httptest.NewRecorder()
+	req := httptest.NewRequest(method, url, strings.NewReader(body))
+	h.ServeHTTP(w, req)
+
+	resp := w.Result()
+	var history runtime.ClusterConfigHistory
+	if resp.StatusCode == http.StatusOK {
+		require.NoError(t, json.NewDecoder(resp.Body).Decode(&history))
+	}
+	return resp.StatusCode, history
+}
+
+func TestClusterConfigSetGetAndRollback(t *testing.T) {
+	mockClient, ctrl := setupClusterConfigTest(t)
+	defer ctrl.Finish()
+
+	var (
+		getHandler      = NewGetHandler(mockClient)
+		setHandler      = NewSetHandler(mockClient)
+		rollbackHandler = NewRollbackHandler(mockClient)
+	)
+
+	code, history := serve(t, getHandler, GetHTTPMethod, GetURL, "")
+	require.Equal(t, http.StatusOK, code)
+	assert.Equal(t, 0, history.Current.Version)
+
+	code, history = serve(t, setHandler, SetHTTPMethod, SetURL, `{
+		"values": {"tickSeriesBatchSize": 128, "tickMinimumIntervalDuration": "5m"},
+		"overrides": {"host1": {"maxWiredBlocks": 1024}}
+	}`)
+	require.Equal(t, http.StatusOK, code)
+	assert.Equal(t, 1, history.Current.Version)
+	assert.Equal(t, 128, *history.Current.Values.TickSeriesBatchSize)
+	assert.Equal(t, int64(5*time.Minute), *history.Current.Values.TickMinimumIntervalNanos)
+	assert.Equal(t, uint(1024), *history.Current.Overrides["host1"].MaxWiredBlocks)
+
+	code, history = serve(t, setHandler, SetHTTPMethod, SetURL,
+		`{"version": 1, "values": {"tickSeriesBatchSize": 256}}`)
+	require.Equal(t, http.StatusOK, code)
+	assert.Equal(t, 2, history.Current.Version)
+
+	// Stale versions are rejected
+	code, _ = serve(t, setHandler, SetHTTPMethod, SetURL,
+		`{"version": 1, "values": {"tickSeriesBatchSize": 512}}`)
+	assert.Equal(t, http.StatusConflict, code)
+
+	code, history = serve(t, rollbackHandler, RollbackHTTPMethod, RollbackURL, `{"version": 1}`)
+	require.Equal(t, http.StatusOK, code)
+	assert.Equal(t, 3, history.Current.Version)
+	assert.Equal(t, 128, *history.Current.Values.TickSeriesBatchSize)
+
+	code, history = serve(t, getHandler, GetHTTPMethod, GetURL, "")
+	require.Equal(t, http.StatusOK, code)
+	assert.Equal(t, 3, history.Current.Version)
+	require.Len(t, history.Previous, 2)
+	assert.Equal(t, 2, history.Previous[0].Version)
+}
+
+func TestClusterConfigInvalidRequests(t *testing.T) {
+	mockClient, ctrl := setupClusterConfigTest(t)
+	defer ctrl.Finish()
+
+	setHandler := NewSetHandler(mockClient)
+	for _, body := range []string{
+		`{"values": {"unknown": 1}}`,
+		`{"values": {"tickSeriesBatchSize": -1}}`,
+		`{"values": {"tickMinimumIntervalDuration": "invalid"}}`,
+	} {
+		code, _ := serve(t, setHandler, SetHTTPMethod, SetURL, body)
+		assert.Equal(t, http.StatusBadRequest, code, body)
+	}
+
+	rollbackHandler := NewRollbackHandler(mockClient)
+	for _, body := range []string{
+		`{}`,
+		`{"version": 3}`,
+	} {
+		code, _ := serve(t, rollbackHandler, RollbackHTTPMethod, RollbackURL, body)
+		assert.Equal(t, http.StatusBadRequest, code, body)
+	}
+}
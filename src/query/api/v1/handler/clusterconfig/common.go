@@ -0,0 +1,99 @@
+// Copyright (c) 2018 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package clusterconfig
+
+import (
+	"fmt"
+
+	"github.com/m3db/m3/src/dbnode/kvconfig"
+	"github.com/m3db/m3/src/dbnode/runtime"
+	"github.com/m3db/m3/src/query/util/logging"
+	clusterclient "github.com/m3db/m3cluster/client"
+	"github.com/m3db/m3cluster/generated/proto/commonpb"
+	"github.com/m3db/m3cluster/kv"
+
+	"github.com/gorilla/mux"
+)
+
+// Handler represents a generic handler for cluster config endpoints.
+type Handler struct {
+	// This is used by other cluster config Handlers
+	// nolint: structcheck
+	client clusterclient.Client
+}
+
+// History returns the current cluster runtime config history in the given
+// store and its KV version.
+func History(store kv.Store) (runtime.ClusterConfigHistory, int, error) {
+	value, err := store.Get(kvconfig.ClusterRuntimeConfigKey)
+	if err == kv.ErrNotFound {
+		// No config set is the same as an empty history
+		return runtime.ClusterConfigHistory{}, 0, nil
+	}
+	if err != nil {
+		return runtime.ClusterConfigHistory{}, -1, err
+	}
+
+	var protoValue commonpb.StringProto
+	if err := value.Unmarshal(&protoValue); err != nil {
+		return runtime.ClusterConfigHistory{}, -1, err
+	}
+
+	history, err := runtime.ParseClusterConfigHistory(protoValue.Value)
+	if err != nil {
+		return runtime.ClusterConfigHistory{}, -1, err
+	}
+
+	return history, value.Version(), nil
+}
+
+// update sets the history in the given store if it is still at the KV
+// version it was read at.
+func update(store kv.Store, history runtime.ClusterConfigHistory, version int) error {
+	var (
+		protoValue = &commonpb.StringProto{Value: history.String()}
+		err        error
+	)
+	if version == 0 {
+		_, err = store.SetIfNotExists(kvconfig.ClusterRuntimeConfigKey, protoValue)
+	} else {
+		_, err = store.CheckAndSet(kvconfig.ClusterRuntimeConfigKey, version, protoValue)
+	}
+
+	switch err {
+	case nil:
+		return nil
+	case kv.ErrAlreadyExists, kv.ErrVersionMismatch:
+		// Updated concurrently since it was read
+		return errVersionMismatch
+	default:
+		return fmt.Errorf("failed to set cluster config: %v", err)
+	}
+}
+
+// RegisterRoutes registers the cluster config routes
+func RegisterRoutes(r *mux.Router, client clusterclient.Client) {
+	logged := logging.WithResponseTimeLogging
+
+	r.HandleFunc(GetURL, logged(NewGetHandler(client)).ServeHTTP).Methods(GetHTTPMethod)
+	r.HandleFunc(SetURL, logged(NewSetHandler(client)).ServeHTTP).Methods(SetHTTPMethod)
+	r.HandleFunc(RollbackURL, logged(NewRollbackHandler(client)).ServeHTTP).Methods(RollbackHTTPMethod)
+}
@@ -0,0 +1,120 @@
+// Copyright (c) 2018 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package clusterconfig
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+
+	"github.com/m3db/m3/src/dbnode/runtime"
+	"github.com/m3db/m3/src/query/api/v1/handler"
+	"github.com/m3db/m3/src/query/util/logging"
+	clusterclient "github.com/m3db/m3cluster/client"
+
+	"go.uber.org/zap"
+)
+
+const (
+	// RollbackURL is the url for the cluster config rollback handler.
+	RollbackURL = handler.RoutePrefixV1 + "/runtime/config/rollback"
+
+	// RollbackHTTPMethod is the HTTP method used with this resource.
+	RollbackHTTPMethod = http.MethodPost
+)
+
+var (
+	errRollbackVersionNotSet = errors.New("must specify version to roll back to")
+)
+
+// RollbackRequest is the request to roll back the cluster config.
+type RollbackRequest struct {
+	// Version is the previous version to roll back to.
+	Version int `json:"version"`
+}
+
+// RollbackHandler is the handler for cluster config rollbacks.
+type RollbackHandler Handler
+
+// NewRollbackHandler returns a new instance of RollbackHandler.
+func NewRollbackHandler(client clusterclient.Client) *RollbackHandler {
+	return &RollbackHandler{client: client}
+}
+
+func (h *RollbackHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	logger := logging.WithContext(ctx)
+
+	req, rErr := h.parseRequest(r)
+	if rErr != nil {
+		logger.Error("unable to parse request", zap.Any("error", rErr))
+		handler.Error(w, rErr.Inner(), rErr.Code())
+		return
+	}
+
+	history, err := h.Rollback(req.Version)
+	if err != nil {
+		logger.Error("unable to roll back cluster config", zap.Any("error", err))
+		handler.Error(w, err, errorCode(err))
+		return
+	}
+
+	handler.WriteJSONResponse(w, history, logger)
+}
+
+func (h *RollbackHandler) parseRequest(r *http.Request) (RollbackRequest, *handler.ParseError) {
+	defer r.Body.Close()
+
+	var req RollbackRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		return RollbackRequest{}, handler.NewParseError(err, http.StatusBadRequest)
+	}
+	if req.Version <= 0 {
+		return RollbackRequest{}, handler.NewParseError(errRollbackVersionNotSet, http.StatusBadRequest)
+	}
+
+	return req, nil
+}
+
+// Rollback sets a previous version of the cluster config as the current
+// version.
+func (h *RollbackHandler) Rollback(version int) (runtime.ClusterConfigHistory, error) {
+	store, err := h.client.KV()
+	if err != nil {
+		return runtime.ClusterConfigHistory{}, err
+	}
+
+	history, kvVersion, err := History(store)
+	if err != nil {
+		return runtime.ClusterConfigHistory{}, err
+	}
+
+	history, err = history.Rollback(version)
+	if err != nil {
+		return runtime.ClusterConfigHistory{}, newBadRequestError(err)
+	}
+
+	if err := update(store, history, kvVersion); err != nil {
+		return runtime.ClusterConfigHistory{}, err
+	}
+
+	return history, nil
+}
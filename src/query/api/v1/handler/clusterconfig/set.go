@@ -0,0 +1,148 @@
+// Copyright (c) 2018 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package clusterconfig
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"net/http"
+
+	"github.com/m3db/m3/src/dbnode/runtime"
+	"github.com/m3db/m3/src/query/api/v1/handler"
+	"github.com/m3db/m3/src/query/util/logging"
+	clusterclient "github.com/m3db/m3cluster/client"
+
+	"go.uber.org/zap"
+)
+
+const (
+	// SetURL is the url for the cluster config set handler.
+	SetURL = handler.RoutePrefixV1 + "/runtime/config"
+
+	// SetHTTPMethod is the HTTP method used with this resource.
+	SetHTTPMethod = http.MethodPost
+)
+
+var (
+	errVersionMismatch = errors.New("cluster config version does not match current version")
+)
+
+// SetHandler is the handler for cluster config updates.
+type SetHandler Handler
+
+// NewSetHandler returns a new instance of SetHandler.
+func NewSetHandler(client clusterclient.Client) *SetHandler {
+	return &SetHandler{client: client}
+}
+
+func (h *SetHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	logger := logging.WithContext(ctx)
+
+	cfg, rErr := h.parseRequest(r)
+	if rErr != nil {
+		logger.Error("unable to parse request", zap.Any("error", rErr))
+		handler.Error(w, rErr.Inner(), rErr.Code())
+		return
+	}
+
+	history, err := h.Set(cfg)
+	if err != nil {
+		logger.Error("unable to set cluster config", zap.Any("error", err))
+		handler.Error(w, err, errorCode(err))
+		return
+	}
+
+	handler.WriteJSONResponse(w, history, logger)
+}
+
+func (h *SetHandler) parseRequest(r *http.Request) (runtime.ClusterConfig, *handler.ParseError) {
+	defer r.Body.Close()
+
+	// Allow durations to be specified as strings, e.g. "tickMinimumIntervalDuration": "1m"
+	rBody, err := handler.DurationToNanosBytes(r.Body)
+	if err != nil {
+		return runtime.ClusterConfig{}, handler.NewParseError(err, http.StatusBadRequest)
+	}
+
+	var cfg runtime.ClusterConfig
+	d := json.NewDecoder(bytes.NewReader(rBody))
+	d.DisallowUnknownFields()
+	if err := d.Decode(&cfg); err != nil {
+		return runtime.ClusterConfig{}, handler.NewParseError(err, http.StatusBadRequest)
+	}
+
+	return cfg, nil
+}
+
+// Set sets a new version of the cluster config. If the config specifies a
+// version it must match the current version to guard against concurrent
+// updates.
+func (h *SetHandler) Set(cfg runtime.ClusterConfig) (runtime.ClusterConfigHistory, error) {
+	store, err := h.client.KV()
+	if err != nil {
+		return runtime.ClusterConfigHistory{}, err
+	}
+
+	history, version, err := History(store)
+	if err != nil {
+		return runtime.ClusterConfigHistory{}, err
+	}
+
+	if cfg.Version != 0 && cfg.Version != history.Current.Version {
+		return runtime.ClusterConfigHistory{}, errVersionMismatch
+	}
+
+	history, err = history.Update(cfg)
+	if err != nil {
+		return runtime.ClusterConfigHistory{}, newBadRequestError(err)
+	}
+
+	if err := update(store, history, version); err != nil {
+		return runtime.ClusterConfigHistory{}, err
+	}
+
+	return history, nil
+}
+
+type badRequestError struct {
+	err error
+}
+
+func newBadRequestError(err error) error {
+	return badRequestError{err: err}
+}
+
+func (e badRequestError) Error() string {
+	return e.err.Error()
+}
+
+func errorCode(err error) int {
+	switch err.(type) {
+	case badRequestError:
+		return http.StatusBadRequest
+	}
+	if err == errVersionMismatch {
+		return http.StatusConflict
+	}
+	return http.StatusInternalServerError
+}
@@ -0,0 +1,170 @@
+// Copyright (c) 2018 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package database
+
+import (
+	"errors"
+	"net/http"
+
+	dbconfig "github.com/m3db/m3/src/cmd/services/m3dbnode/config"
+	"github.com/m3db/m3/src/cmd/services/m3query/config"
+	"github.com/m3db/m3/src/query/api/v1/handler/namespace"
+	"github.com/m3db/m3/src/query/api/v1/handler/placement"
+	"github.com/m3db/m3/src/query/generated/proto/admin"
+	"github.com/m3db/m3/src/query/storage/local"
+	clusterclient "github.com/m3db/m3cluster/client"
+	"github.com/m3db/m3cluster/kv"
+)
+
+var (
+	errMissingDatabaseInitConfig = errors.New("missing database init config")
+	errMissingDatabaseInitHosts  = errors.New("missing hosts for cluster database init")
+)
+
+// AutoInitializer initializes the database on first start so that a
+// database can be stood up without any calls to the database create API.
+type AutoInitializer struct {
+	client               clusterclient.Client
+	clusters             local.ClustersStaticConfiguration
+	dbType               dbType
+	placementInitReq     *admin.PlacementInitRequest
+	placementInitHandler *placement.InitHandler
+	namespaceAddHandler  *namespace.AddHandler
+}
+
+// NewAutoInitializer returns a new auto initializer for the database init
+// configuration, returning an error if the configuration is invalid.
+func NewAutoInitializer(
+	client clusterclient.Client,
+	cfg config.Configuration,
+	embeddedDbCfg *dbconfig.DBConfiguration,
+) (*AutoInitializer, error) {
+	initCfg := cfg.DatabaseInit
+	if initCfg == nil {
+		return nil, errMissingDatabaseInitConfig
+	}
+
+	createReq := &admin.DatabaseCreateRequest{
+		Type:              initCfg.Type,
+		ReplicationFactor: int32(initCfg.ReplicationFactor),
+	}
+	for _, host := range initCfg.Hosts {
+		createReq.Hosts = append(createReq.Hosts, &admin.Host{
+			Id:             host.ID,
+			Address:        host.Address,
+			Port:           host.Port,
+			IsolationGroup: host.IsolationGroup,
+			Zone:           host.Zone,
+			Weight:         host.Weight,
+		})
+	}
+	if dbType(createReq.Type) == dbTypeCluster && len(createReq.Hosts) == 0 {
+		return nil, errMissingDatabaseInitHosts
+	}
+
+	placementInitReq, err := defaultedPlacementInitRequest(createReq, embeddedDbCfg)
+	if err != nil {
+		return nil, err
+	}
+
+	return &AutoInitializer{
+		client:               client,
+		clusters:             cfg.Clusters,
+		dbType:               dbType(createReq.Type),
+		placementInitReq:     placementInitReq,
+		placementInitHandler: placement.NewInitHandler(client, cfg),
+		namespaceAddHandler:  namespace.NewAddHandler(client),
+	}, nil
+}
+
+// Init creates each namespace of the configured clusters that does not exist
+// and initializes the placement if there is none. It is safe to call on every
+// start and to retry until it succeeds.
+func (i *AutoInitializer) Init() error {
+	if err := i.initNamespaces(); err != nil {
+		return err
+	}
+	return i.initPlacement()
+}
+
+func (i *AutoInitializer) initNamespaces() error {
+	store, err := i.client.KV()
+	if err != nil {
+		return err
+	}
+
+	metadatas, _, err := namespace.Metadata(store)
+	if err != nil {
+		return err
+	}
+
+	existing := make(map[string]struct{}, len(metadatas))
+	for _, md := range metadatas {
+		existing[md.ID().String()] = struct{}{}
+	}
+
+	for _, cluster := range i.clusters {
+		for _, ns := range cluster.Namespaces {
+			if _, ok := existing[ns.Namespace]; ok {
+				continue
+			}
+
+			addReq, err := defaultedNamespaceAddRequest(&admin.DatabaseCreateRequest{
+				NamespaceName: ns.Namespace,
+				Type:          string(i.dbType),
+				RetentionTime: ns.Retention.String(),
+			})
+			if err != nil {
+				return err
+			}
+
+			if _, err := i.namespaceAddHandler.Add(addReq); err != nil {
+				return err
+			}
+			existing[ns.Namespace] = struct{}{}
+		}
+	}
+
+	return nil
+}
+
+func (i *AutoInitializer) initPlacement() error {
+	// Use the default placement service since there is no request to
+	// override it with cluster headers.
+	headers := make(http.Header)
+	service, err := placement.Service(i.client, headers)
+	if err != nil {
+		return err
+	}
+
+	_, _, err = service.Placement()
+	switch err {
+	case nil:
+		// Already initialized
+		return nil
+	case kv.ErrNotFound:
+	default:
+		return err
+	}
+
+	_, err = i.placementInitHandler.Init(&http.Request{Header: headers}, i.placementInitReq)
+	return err
+}
@@ -0,0 +1,111 @@
+// Copyright (c) 2018 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package database
+
+import (
+	"testing"
+	"time"
+
+	"github.com/m3db/m3/src/cmd/services/m3query/config"
+	"github.com/m3db/m3/src/query/api/v1/handler/namespace"
+	"github.com/m3db/m3/src/query/storage/local"
+	"github.com/m3db/m3cluster/generated/proto/placementpb"
+	"github.com/m3db/m3cluster/kv"
+	"github.com/m3db/m3cluster/placement"
+
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestNewAutoInitializerInvalidConfig(t *testing.T) {
+	mockClient, _, _ := SetupDatabaseTest(t)
+
+	_, err := NewAutoInitializer(mockClient, config.Configuration{}, testDBCfg)
+	assert.Equal(t, errMissingDatabaseInitConfig, err)
+
+	_, err = NewAutoInitializer(mockClient, config.Configuration{
+		DatabaseInit: &config.DatabaseInitConfiguration{Type: "cluster"},
+	}, testDBCfg)
+	assert.Equal(t, errMissingDatabaseInitHosts, err)
+
+	_, err = NewAutoInitializer(mockClient, config.Configuration{
+		DatabaseInit: &config.DatabaseInitConfiguration{Type: "unknown"},
+	}, testDBCfg)
+	assert.Equal(t, errInvalidDBType, err)
+}
+
+func TestAutoInitializerInitLocal(t *testing.T) {
+	mockClient, mockKV, mockPlacementService := SetupDatabaseTest(t)
+
+	cfg := config.Configuration{
+		DatabaseInit: &config.DatabaseInitConfiguration{Type: "local"},
+		Clusters: local.ClustersStaticConfiguration{
+			{
+				Namespaces: []local.ClusterStaticNamespaceConfiguration{
+					{Namespace: "default", Retention: 48 * time.Hour},
+					{Namespace: "metrics_10s_720h", Retention: 720 * time.Hour, Resolution: 10 * time.Second},
+				},
+			},
+		},
+	}
+	initializer, err := NewAutoInitializer(mockClient, cfg, testDBCfg)
+	require.NoError(t, err)
+
+	// Both namespaces are added and the placement is initialized.
+	mockKV.EXPECT().Get(namespace.M3DBNodeNamespacesKey).Return(nil, kv.ErrNotFound).Times(3)
+	mockKV.EXPECT().CheckAndSet(namespace.M3DBNodeNamespacesKey, gomock.Any(), gomock.Not(nil)).Return(1, nil).Times(2)
+
+	newPlacement, err := placement.NewPlacementFromProto(&placementpb.Placement{
+		Instances: map[string]*placementpb.Instance{
+			"m3db_local": &placementpb.Instance{
+				Id:             "m3db_local",
+				IsolationGroup: "local",
+				Zone:           "embedded",
+				Weight:         1,
+				Endpoint:       "127.0.0.1:9000",
+				Hostname:       "localhost",
+				Port:           9000,
+			},
+		},
+	})
+	require.NoError(t, err)
+	mockPlacementService.EXPECT().Placement().Return(nil, 0, kv.ErrNotFound)
+	mockPlacementService.EXPECT().BuildInitialPlacement(gomock.Any(), 64, 1).Return(newPlacement, nil)
+
+	require.NoError(t, initializer.Init())
+}
+
+func TestAutoInitializerInitAlreadyInitialized(t *testing.T) {
+	mockClient, mockKV, mockPlacementService := SetupDatabaseTest(t)
+
+	cfg := config.Configuration{
+		DatabaseInit: &config.DatabaseInitConfiguration{Type: "local"},
+	}
+	initializer, err := NewAutoInitializer(mockClient, cfg, testDBCfg)
+	require.NoError(t, err)
+
+	// The placement exists so it is not initialized again.
+	mockKV.EXPECT().Get(namespace.M3DBNodeNamespacesKey).Return(nil, kv.ErrNotFound)
+	mockPlacementService.EXPECT().Placement().Return(placement.NewPlacement(), 1, nil)
+
+	require.NoError(t, initializer.Init())
+}
@@ -72,34 +72,6 @@ const (
 	maxRecommendCalculateBlockSize = 24 * time.Hour
 )
 
-type recommendedBlockSize struct {
-	forRetentionLessThanOrEqual time.Duration
-	blockSize                   time.Duration
-}
-
-var recommendedBlockSizesByRetentionAsc = []recommendedBlockSize{
-	{
-		forRetentionLessThanOrEqual: 12 * time.Hour,
-		blockSize:                   30 * time.Minute,
-	},
-	{
-		forRetentionLessThanOrEqual: 24 * time.Hour,
-		blockSize:                   time.Hour,
-	},
-	{
-		forRetentionLessThanOrEqual: 7 * 24 * time.Hour,
-		blockSize:                   2 * time.Hour,
-	},
-	{
-		forRetentionLessThanOrEqual: 30 * 24 * time.Hour,
-		blockSize:                   12 * time.Hour,
-	},
-	{
-		forRetentionLessThanOrEqual: 365 * 24 * time.Hour,
-		blockSize:                   24 * time.Hour,
-	},
-}
-
 var (
 	errMissingRequiredField    = errors.New("missing required field")
 	errInvalidDBType           = errors.New("invalid database type")
@@ -268,17 +240,7 @@ func defaultedNamespaceAddRequest(r *admin.DatabaseCreateRequest) (*admin.Namesp
 			}
 
 		default:
-			// Use the maximum block size if we don't find a way to
-			// recommended one based on request parameters
-			max := recommendedBlockSizesByRetentionAsc[len(recommendedBlockSizesByRetentionAsc)-1]
-			blockSize = max.blockSize
-			for _, elem := range recommendedBlockSizesByRetentionAsc {
-				if retentionPeriod <= elem.forRetentionLessThanOrEqual {
-					blockSize = elem.blockSize
-					break
-				}
-			}
-
+			blockSize = dbnamespace.RecommendedBlockSize(retentionPeriod)
 		}
 
 		retentionOpts = retentionOpts.SetBlockSize(blockSize)
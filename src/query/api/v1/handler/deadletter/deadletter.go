@@ -0,0 +1,118 @@
+// Copyright (c) 2018 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+// Package deadletter provides the endpoints to inspect the dead letter
+// queue of failed writes and replay them.
+package deadletter
+
+import (
+	"net/http"
+
+	"github.com/m3db/m3/src/query/api/v1/handler"
+	"github.com/m3db/m3/src/query/storage/deadletter"
+	"github.com/m3db/m3/src/query/util/logging"
+
+	"github.com/gorilla/mux"
+	"go.uber.org/zap"
+)
+
+const (
+	// GetURL is the url for the dead letter queue get handler.
+	GetURL = handler.RoutePrefixV1 + "/deadletter"
+
+	// GetHTTPMethod is the HTTP method used with the get resource.
+	GetHTTPMethod = http.MethodGet
+
+	// ReplayURL is the url for the dead letter queue replay handler.
+	ReplayURL = handler.RoutePrefixV1 + "/deadletter/replay"
+
+	// ReplayHTTPMethod is the HTTP method used with the replay resource.
+	ReplayHTTPMethod = http.MethodPost
+)
+
+// GetResponse is the response of the dead letter queue get handler.
+type GetResponse struct {
+	Files []deadletter.FileInfo `json:"files"`
+	Size  int64                 `json:"size"`
+}
+
+// GetHandler is the handler for the spill files of the dead letter queue.
+type GetHandler struct {
+	replayer *deadletter.Replayer
+}
+
+// NewGetHandler returns a new instance of GetHandler.
+func NewGetHandler(replayer *deadletter.Replayer) *GetHandler {
+	return &GetHandler{replayer: replayer}
+}
+
+func (h *GetHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	logger := logging.WithContext(r.Context())
+
+	files, err := h.replayer.Files()
+	if err != nil {
+		logger.Error("unable to list dead letter files", zap.Any("error", err))
+		handler.Error(w, err, http.StatusInternalServerError)
+		return
+	}
+
+	resp := GetResponse{Files: files}
+	if resp.Files == nil {
+		resp.Files = []deadletter.FileInfo{}
+	}
+	for _, f := range files {
+		resp.Size += f.Size
+	}
+	handler.WriteJSONResponse(w, resp, logger)
+}
+
+// ReplayHandler is the handler that replays the dead letter queue.
+type ReplayHandler struct {
+	replayer *deadletter.Replayer
+}
+
+// NewReplayHandler returns a new instance of ReplayHandler.
+func NewReplayHandler(replayer *deadletter.Replayer) *ReplayHandler {
+	return &ReplayHandler{replayer: replayer}
+}
+
+func (h *ReplayHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	logger := logging.WithContext(r.Context())
+
+	// Writes that fail to replay are spilled again rather than failing the
+	// replay, the result counts them so that the replay can be retried.
+	result, err := h.replayer.Replay(r.Context())
+	if err != nil {
+		logger.Error("unable to replay dead letter queue", zap.Any("error", err),
+			zap.Int("replayed", result.Replayed))
+		handler.Error(w, err, http.StatusInternalServerError)
+		return
+	}
+
+	handler.WriteJSONResponse(w, result, logger)
+}
+
+// RegisterRoutes registers the dead letter queue routes.
+func RegisterRoutes(r *mux.Router, replayer *deadletter.Replayer) {
+	logged := logging.WithResponseTimeLogging
+
+	r.HandleFunc(GetURL, logged(NewGetHandler(replayer)).ServeHTTP).Methods(GetHTTPMethod)
+	r.HandleFunc(ReplayURL, logged(NewReplayHandler(replayer)).ServeHTTP).Methods(ReplayHTTPMethod)
+}
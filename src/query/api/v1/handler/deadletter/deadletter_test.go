@@ -0,0 +1,79 @@
+// Copyright (c) 2018 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package deadletter
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/m3db/m3/src/query/models"
+	"github.com/m3db/m3/src/query/storage"
+	"github.com/m3db/m3/src/query/storage/deadletter"
+	"github.com/m3db/m3/src/query/storage/mock"
+	"github.com/m3db/m3/src/query/ts"
+	"github.com/m3db/m3/src/query/util/logging"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestDeadLetterGetAndReplay(t *testing.T) {
+	logging.InitWithCores(nil)
+
+	dir, err := ioutil.TempDir("", "deadletter")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	queue, err := deadletter.NewQueue(deadletter.Options{Dir: dir})
+	require.NoError(t, err)
+	defer queue.Close()
+
+	require.NoError(t, queue.Spill(&storage.WriteQuery{
+		Tags:       models.Tags{{Name: models.MetricName, Value: "foo"}},
+		Datapoints: ts.Datapoints{{Timestamp: time.Unix(1500000000, 0), Value: 1}},
+	}))
+
+	store := mock.NewMockStorage()
+	replayer := deadletter.NewReplayer(queue, store)
+
+	w := httptest.NewRecorder()
+	NewGetHandler(replayer).ServeHTTP(w, httptest.NewRequest(GetHTTPMethod, GetURL, nil))
+	require.Equal(t, http.StatusOK, w.Code)
+
+	var getResp GetResponse
+	require.NoError(t, json.NewDecoder(w.Body).Decode(&getResp))
+	require.Len(t, getResp.Files, 1)
+	assert.True(t, getResp.Size > 0)
+
+	w = httptest.NewRecorder()
+	NewReplayHandler(replayer).ServeHTTP(w, httptest.NewRequest(ReplayHTTPMethod, ReplayURL, nil))
+	require.Equal(t, http.StatusOK, w.Code)
+
+	var replayResp deadletter.ReplayResult
+	require.NoError(t, json.NewDecoder(w.Body).Decode(&replayResp))
+	assert.Equal(t, deadletter.ReplayResult{Files: 1, Replayed: 1}, replayResp)
+	assert.Len(t, store.Writes(), 1)
+}
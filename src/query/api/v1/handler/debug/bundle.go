@@ -0,0 +1,223 @@
+// Copyright (c) 2018 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package debug
+
+import (
+	"archive/zip"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"runtime"
+	"runtime/pprof"
+	"strings"
+	"time"
+
+	"github.com/m3db/m3/src/query/util/logging"
+
+	"go.uber.org/zap"
+	yaml "gopkg.in/yaml.v2"
+)
+
+const (
+	redacted = "<redacted>"
+)
+
+var (
+	// secretKeys are the lowercase config keys whose values are redacted in
+	// bundles, and secretKeySubstrings the substrings of lowercase keys
+	// whose scalar values are redacted, e.g. the token of each of the
+	// tokens but not their names.
+	secretKeys = map[string]struct{}{
+		"key":     struct{}{},
+		"keys":    struct{}{},
+		"headers": struct{}{},
+	}
+	secretKeySubstrings = []string{
+		"secret",
+		"password",
+		"token",
+		"credential",
+	}
+)
+
+// RuntimeInfo is the runtime state captured in a debug bundle.
+type RuntimeInfo struct {
+	Time         time.Time        `json:"time"`
+	GoVersion    string           `json:"goVersion"`
+	NumCPU       int              `json:"numCPU"`
+	GOMAXPROCS   int              `json:"gomaxprocs"`
+	NumGoroutine int              `json:"numGoroutine"`
+	MemStats     runtime.MemStats `json:"memStats"`
+}
+
+// BundleHandler serves a zip archive of the goroutine stacks, heap profile,
+// runtime state and redacted config of the service.
+type BundleHandler struct {
+	serviceCfg interface{}
+	nowFn      func() time.Time
+}
+
+// NewBundleHandler returns a new instance of BundleHandler.
+func NewBundleHandler(serviceCfg interface{}, nowFn func() time.Time) *BundleHandler {
+	return &BundleHandler{
+		serviceCfg: serviceCfg,
+		nowFn:      nowFn,
+	}
+}
+
+func (h *BundleHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	logger := logging.WithContext(r.Context())
+
+	now := h.nowFn()
+	name := fmt.Sprintf("debug-%s.zip", now.UTC().Format("20060102T150405Z"))
+	w.Header().Set("Content-Type", "application/zip")
+	w.Header().Set("Content-Disposition", fmt.Sprintf("attachment; filename=%q", name))
+
+	// Once the archive is written to the response the status can no longer
+	// change, so failures are only logged.
+	zw := zip.NewWriter(w)
+	for _, file := range []struct {
+		name    string
+		writeFn func(out io.Writer) error
+	}{
+		{"goroutines.txt", func(out io.Writer) error {
+			return pprof.Lookup("goroutine").WriteTo(out, 2)
+		}},
+		{"heap.pb.gz", func(out io.Writer) error {
+			return pprof.Lookup("heap").WriteTo(out, 0)
+		}},
+		{"runtime.json", func(out io.Writer) error {
+			return json.NewEncoder(out).Encode(newRuntimeInfo(now))
+		}},
+		{"config.yaml", func(out io.Writer) error {
+			return writeRedactedConfig(out, h.serviceCfg)
+		}},
+	} {
+		fw, err := zw.CreateHeader(&zip.FileHeader{
+			Name:     file.name,
+			Method:   zip.Deflate,
+			Modified: now,
+		})
+		if err != nil {
+			logger.Error("unable to create debug bundle file",
+				zap.String("file", file.name), zap.Error(err))
+			return
+		}
+		if err := file.writeFn(fw); err != nil {
+			logger.Error("unable to write debug bundle file",
+				zap.String("file", file.name), zap.Error(err))
+		}
+	}
+	if err := zw.Close(); err != nil {
+		logger.Error("unable to write debug bundle", zap.Error(err))
+	}
+}
+
+func newRuntimeInfo(now time.Time) RuntimeInfo {
+	info := RuntimeInfo{
+		Time:         now,
+		GoVersion:    runtime.Version(),
+		NumCPU:       runtime.NumCPU(),
+		GOMAXPROCS:   runtime.GOMAXPROCS(0),
+		NumGoroutine: runtime.NumGoroutine(),
+	}
+	runtime.ReadMemStats(&info.MemStats)
+	return info
+}
+
+func writeRedactedConfig(w io.Writer, cfg interface{}) (err error) {
+	if cfg == nil {
+		return nil
+	}
+
+	// The YAML encoder panics on types it cannot marshal.
+	defer func() {
+		if r := recover(); r != nil {
+			err = fmt.Errorf("unable to marshal config: %v", r)
+		}
+	}()
+
+	// Round trip the config through YAML to redact it by key regardless of
+	// its type.
+	data, err := yaml.Marshal(cfg)
+	if err != nil {
+		return err
+	}
+	var value interface{}
+	if err := yaml.Unmarshal(data, &value); err != nil {
+		return err
+	}
+	data, err = yaml.Marshal(redact(value))
+	if err != nil {
+		return err
+	}
+	_, err = w.Write(data)
+	return err
+}
+
+// redact replaces the values of all the secret keys in a decoded YAML value.
+func redact(value interface{}) interface{} {
+	switch v := value.(type) {
+	case map[interface{}]interface{}:
+		for key, elem := range v {
+			name := strings.ToLower(fmt.Sprint(key))
+			if _, ok := secretKeys[name]; ok {
+				v[key] = redacted
+				continue
+			}
+			if isSecretKeySubstring(name) {
+				v[key] = redactScalars(elem)
+				continue
+			}
+			v[key] = redact(elem)
+		}
+	case []interface{}:
+		for i, elem := range v {
+			v[i] = redact(elem)
+		}
+	}
+	return value
+}
+
+// redactScalars replaces a secret value, or each of the values of a list of
+// secrets, while the keys of maps are redacted by name.
+func redactScalars(value interface{}) interface{} {
+	switch v := value.(type) {
+	case map[interface{}]interface{}:
+		return redact(v)
+	case []interface{}:
+		for i, elem := range v {
+			v[i] = redactScalars(elem)
+		}
+		return v
+	}
+	return redacted
+}
+
+func isSecretKeySubstring(name string) bool {
+	for _, s := range secretKeySubstrings {
+		if strings.Contains(name, s) {
+			return true
+		}
+	}
+	return false
+}
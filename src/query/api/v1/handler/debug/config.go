@@ -0,0 +1,75 @@
+// Copyright (c) 2018 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package debug
+
+import (
+	"time"
+
+	"github.com/m3db/m3/src/x/ratelimit"
+)
+
+const (
+	// DefaultRequestsPerMinute is the default rate of requests to the debug
+	// endpoints.
+	DefaultRequestsPerMinute = 6
+
+	// DefaultBurst is the default number of requests to the debug endpoints
+	// served at once before the rate applies.
+	DefaultBurst = 2
+)
+
+// Configuration is the configuration of the debug endpoints.
+type Configuration struct {
+	// AllowUnauthenticated serves the debug endpoints when auth is not
+	// configured, defaults to true. They are only served to callers with
+	// the admin role when auth is configured.
+	AllowUnauthenticated *bool `yaml:"allowUnauthenticated"`
+
+	// RequestsPerMinute is the rate of requests to all the debug endpoints
+	// combined, defaults to 6.
+	RequestsPerMinute int `yaml:"requestsPerMinute"`
+
+	// Burst is the number of requests served at once before the rate
+	// applies, defaults to 2.
+	Burst int `yaml:"burst"`
+}
+
+// AllowUnauthenticatedOrDefault returns whether the debug endpoints are
+// served when auth is not configured.
+func (c Configuration) AllowUnauthenticatedOrDefault() bool {
+	if c.AllowUnauthenticated == nil {
+		return true
+	}
+	return *c.AllowUnauthenticated
+}
+
+// NewRateLimiter returns the rate limiter of the debug endpoints.
+func (c Configuration) NewRateLimiter() *ratelimit.TokenBucket {
+	perMinute := c.RequestsPerMinute
+	if perMinute <= 0 {
+		perMinute = DefaultRequestsPerMinute
+	}
+	burst := c.Burst
+	if burst <= 0 {
+		burst = DefaultBurst
+	}
+	return ratelimit.NewTokenBucket(time.Minute/time.Duration(perMinute), burst)
+}
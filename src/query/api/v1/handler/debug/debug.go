@@ -0,0 +1,130 @@
+// Copyright (c) 2018 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+// Package debug provides the profiling endpoints of the coordinator and a
+// debug bundle endpoint that captures its state in a single call.
+package debug
+
+import (
+	"errors"
+	"math"
+	"net/http"
+	"net/http/pprof"
+	"strconv"
+	"time"
+
+	"github.com/m3db/m3/src/query/api/v1/handler"
+	"github.com/m3db/m3/src/query/util/logging"
+	"github.com/m3db/m3/src/x/ratelimit"
+
+	"github.com/gorilla/mux"
+	"github.com/uber-go/tally"
+	"go.uber.org/zap"
+)
+
+const (
+	// URLPrefix is the prefix of the urls of all the debug endpoints.
+	URLPrefix = "/debug/"
+
+	// PprofURLPrefix is the url prefix of the named pprof profiles, e.g.
+	// heap and goroutine.
+	PprofURLPrefix = URLPrefix + "pprof/"
+
+	// ProfileURL is the url of the CPU profile handler.
+	ProfileURL = PprofURLPrefix + "profile"
+
+	// TraceURL is the url of the execution trace handler.
+	TraceURL = PprofURLPrefix + "trace"
+
+	// SymbolURL is the url of the symbol lookup handler.
+	SymbolURL = PprofURLPrefix + "symbol"
+
+	// BundleURL is the url of the debug bundle handler.
+	BundleURL = URLPrefix + "bundle"
+
+	// HTTPMethod is the HTTP method used with the debug resources.
+	HTTPMethod = http.MethodGet
+)
+
+var (
+	errRateLimited = errors.New("debug endpoints rate limit exceeded")
+)
+
+type debugMetrics struct {
+	served      tally.Counter
+	rateLimited tally.Counter
+}
+
+func newDebugMetrics(scope tally.Scope) debugMetrics {
+	return debugMetrics{
+		served:      scope.Counter("served"),
+		rateLimited: scope.Counter("rate-limited"),
+	}
+}
+
+// limited wraps a handler to limit the rate of requests to it and log each
+// request served.
+func limited(
+	limiter *ratelimit.TokenBucket,
+	metrics debugMetrics,
+	next http.Handler,
+) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		logger := logging.WithContext(r.Context())
+		if ok, retryAfter := limiter.Allow(); !ok {
+			metrics.rateLimited.Inc(1)
+			seconds := int(math.Ceil(retryAfter.Seconds()))
+			w.Header().Set("Retry-After", strconv.Itoa(seconds))
+			handler.Error(w, errRateLimited, http.StatusTooManyRequests)
+			return
+		}
+
+		metrics.served.Inc(1)
+		logger.Info("serving debug request",
+			zap.String("path", r.URL.Path),
+			zap.String("remoteAddr", r.RemoteAddr))
+		next.ServeHTTP(w, r)
+	})
+}
+
+// RegisterRoutes registers the debug routes, the config is captured in
+// debug bundles with its secrets redacted. All debug routes share one rate
+// limit since each of them is expensive to serve.
+func RegisterRoutes(
+	r *mux.Router,
+	cfg Configuration,
+	serviceCfg interface{},
+	scope tally.Scope,
+) {
+	var (
+		limiter = cfg.NewRateLimiter()
+		metrics = newDebugMetrics(scope.SubScope("debug"))
+		wrap    = func(h http.Handler) http.Handler {
+			return limited(limiter, metrics, h)
+		}
+		bundle = NewBundleHandler(serviceCfg, time.Now)
+	)
+
+	r.Handle(ProfileURL, wrap(http.HandlerFunc(pprof.Profile))).Methods(HTTPMethod)
+	r.Handle(TraceURL, wrap(http.HandlerFunc(pprof.Trace))).Methods(HTTPMethod)
+	r.Handle(SymbolURL, wrap(http.HandlerFunc(pprof.Symbol))).Methods(HTTPMethod)
+	r.Handle(BundleURL, wrap(bundle)).Methods(HTTPMethod)
+	r.PathPrefix(PprofURLPrefix).Handler(wrap(http.HandlerFunc(pprof.Index))).Methods(HTTPMethod)
+}
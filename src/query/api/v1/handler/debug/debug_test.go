@@ -0,0 +1,120 @@
+// Copyright (c) 2018 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package debug
+
+import (
+	"archive/zip"
+	"bytes"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/m3db/m3/src/query/util/logging"
+
+	"github.com/gorilla/mux"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/uber-go/tally"
+)
+
+type testTokenConfig struct {
+	Name  string `yaml:"name"`
+	Token string `yaml:"token"`
+}
+
+type testServiceConfig struct {
+	ListenAddress string            `yaml:"listenAddress"`
+	Tokens        []testTokenConfig `yaml:"tokens"`
+	Headers       map[string]string `yaml:"headers"`
+	SigningSecret string            `yaml:"signingSecret"`
+	Secrets       []string          `yaml:"secrets"`
+}
+
+func TestRoutesRateLimited(t *testing.T) {
+	logging.InitWithCores(nil)
+
+	r := mux.NewRouter()
+	RegisterRoutes(r, Configuration{RequestsPerMinute: 1, Burst: 1}, nil,
+		tally.NoopScope)
+
+	serve := func(url string) *httptest.ResponseRecorder {
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, httptest.NewRequest(HTTPMethod, url, nil))
+		return w
+	}
+
+	w := serve(PprofURLPrefix + "goroutine?debug=1")
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Contains(t, w.Body.String(), "goroutine profile")
+
+	// All debug routes share the limit.
+	w = serve(BundleURL)
+	assert.Equal(t, http.StatusTooManyRequests, w.Code)
+	assert.Equal(t, "60", w.Header().Get("Retry-After"))
+}
+
+func TestBundleHandler(t *testing.T) {
+	logging.InitWithCores(nil)
+
+	now := time.Date(2018, 6, 1, 12, 0, 0, 0, time.UTC)
+	cfg := testServiceConfig{
+		ListenAddress: "0.0.0.0:7201",
+		Tokens:        []testTokenConfig{{Name: "operator", Token: "s3cr3t-token"}},
+		Headers:       map[string]string{"Authorization": "Bearer s3cr3t-header"},
+		SigningSecret: "s3cr3t-signing",
+		Secrets:       []string{"s3cr3t-1", "s3cr3t-2"},
+	}
+	h := NewBundleHandler(cfg, func() time.Time { return now })
+
+	w := httptest.NewRecorder()
+	h.ServeHTTP(w, httptest.NewRequest(HTTPMethod, BundleURL, nil))
+	require.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, "application/zip", w.Header().Get("Content-Type"))
+	assert.Contains(t, w.Header().Get("Content-Disposition"),
+		"debug-20180601T120000Z.zip")
+
+	body := w.Body.Bytes()
+	zr, err := zip.NewReader(bytes.NewReader(body), int64(len(body)))
+	require.NoError(t, err)
+
+	files := make(map[string]string, len(zr.File))
+	for _, f := range zr.File {
+		rc, err := f.Open()
+		require.NoError(t, err)
+		data, err := ioutil.ReadAll(rc)
+		require.NoError(t, err)
+		rc.Close()
+		files[f.Name] = string(data)
+	}
+
+	require.Len(t, files, 4)
+	assert.Contains(t, files["goroutines.txt"], "goroutine")
+	assert.NotEmpty(t, files["heap.pb.gz"])
+	assert.Contains(t, files["runtime.json"], `"goVersion"`)
+
+	config := files["config.yaml"]
+	assert.Contains(t, config, "listenAddress: 0.0.0.0:7201")
+	assert.Contains(t, config, "name: operator")
+	assert.False(t, strings.Contains(config, "s3cr3t"), config)
+}
@@ -0,0 +1,147 @@
+// Copyright (c) 2018 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package handler
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/m3db/m3/src/dbnode/topology"
+	"github.com/m3db/m3/src/query/models"
+	"github.com/m3db/m3/src/query/util/querystats"
+)
+
+const (
+	// readConsistencyParam, localOnlyParam, verifyReplicasParam,
+	// docsLimitParam and bytesLimitParam set the fetch controls of clients
+	// unable to set the headers
+	readConsistencyParam = "read-consistency"
+	localOnlyParam       = "local-only"
+	verifyReplicasParam  = "verify-replicas"
+	docsLimitParam       = "docs-limit"
+	bytesLimitParam      = "bytes-limit"
+
+	// quorumReadConsistency is accepted as an alias of majority.
+	quorumReadConsistency = "quorum"
+)
+
+// ParseFetchControls parses the read consistency and fanout controls of the
+// fetches of a request from its headers, or from its params if the headers
+// are not set.
+func ParseFetchControls(r *http.Request) (models.FetchControls, *ParseError) {
+	var controls models.FetchControls
+	if str := headerOrParam(r, ReadConsistencyHeader, readConsistencyParam); str != "" {
+		level, err := parseReadConsistencyLevel(str)
+		if err != nil {
+			return controls, NewParseError(err, http.StatusBadRequest)
+		}
+		controls.ReadConsistencyLevel = &level
+	}
+
+	if str := headerOrParam(r, LocalOnlyHeader, localOnlyParam); str != "" {
+		localOnly, err := strconv.ParseBool(str)
+		if err != nil {
+			return controls, NewParseError(
+				fmt.Errorf("invalid local only value: %s", str), http.StatusBadRequest)
+		}
+		controls.LocalOnly = localOnly
+	}
+
+	if str := headerOrParam(r, VerifyReplicasHeader, verifyReplicasParam); str != "" {
+		verifyReplicas, err := strconv.ParseBool(str)
+		if err != nil {
+			return controls, NewParseError(
+				fmt.Errorf("invalid verify replicas value: %s", str), http.StatusBadRequest)
+		}
+		controls.VerifyReplicas = verifyReplicas
+	}
+
+	if str := headerOrParam(r, DocsLimitHeader, docsLimitParam); str != "" {
+		limit, err := parseLimit(str)
+		if err != nil {
+			return controls, NewParseError(
+				fmt.Errorf("invalid docs limit: %s", str), http.StatusBadRequest)
+		}
+		controls.DocsLimit = limit
+	}
+
+	if str := headerOrParam(r, BytesLimitHeader, bytesLimitParam); str != "" {
+		limit, err := parseLimit(str)
+		if err != nil {
+			return controls, NewParseError(
+				fmt.Errorf("invalid bytes limit: %s", str), http.StatusBadRequest)
+		}
+		controls.BytesLimit = limit
+	}
+
+	return controls, nil
+}
+
+// AddWarningsHeader sets the warnings header to the warnings recorded in the
+// statistics of the query of a context, if any.
+func AddWarningsHeader(ctx context.Context, w http.ResponseWriter) {
+	stats, ok := querystats.FromContext(ctx)
+	if !ok {
+		return
+	}
+	if warnings := stats.Warnings(); len(warnings) > 0 {
+		w.Header().Set(WarningsHeader, strings.Join(warnings, "; "))
+	}
+}
+
+func headerOrParam(r *http.Request, header, param string) string {
+	if value := r.Header.Get(header); value != "" {
+		return value
+	}
+	return r.URL.Query().Get(param)
+}
+
+func parseLimit(str string) (int, error) {
+	limit, err := strconv.Atoi(str)
+	if err != nil {
+		return 0, err
+	}
+	if limit < 0 {
+		return 0, fmt.Errorf("negative limit: %d", limit)
+	}
+	return limit, nil
+}
+
+func parseReadConsistencyLevel(str string) (topology.ReadConsistencyLevel, error) {
+	str = strings.ToLower(str)
+	if str == quorumReadConsistency {
+		return topology.ReadConsistencyLevelMajority, nil
+	}
+
+	valid := make([]string, 0, len(topology.ValidReadConsistencyLevels()))
+	for _, level := range topology.ValidReadConsistencyLevels() {
+		if level.String() == str {
+			return level, nil
+		}
+		valid = append(valid, level.String())
+	}
+
+	return 0, fmt.Errorf("invalid read consistency level: %s, must be one of %s or %s",
+		str, strings.Join(valid, ", "), quorumReadConsistency)
+}
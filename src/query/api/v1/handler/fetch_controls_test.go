@@ -0,0 +1,86 @@
+// Copyright (c) 2018 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package handler
+
+import (
+	"net/http"
+	"testing"
+
+	"github.com/m3db/m3/src/dbnode/topology"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseFetchControls(t *testing.T) {
+	req, _ := http.NewRequest("GET", "/api/v1/query_range?local-only=true", nil)
+	req.Header.Set(ReadConsistencyHeader, "quorum")
+	controls, err := ParseFetchControls(req)
+	require.Nil(t, err)
+	require.NotNil(t, controls.ReadConsistencyLevel)
+	assert.Equal(t, topology.ReadConsistencyLevelMajority, *controls.ReadConsistencyLevel)
+	assert.True(t, controls.LocalOnly)
+
+	// Headers take precedence over params.
+	req.Header.Set(LocalOnlyHeader, "false")
+	req.Header.Set(ReadConsistencyHeader, "one")
+	controls, err = ParseFetchControls(req)
+	require.Nil(t, err)
+	assert.Equal(t, topology.ReadConsistencyLevelOne, *controls.ReadConsistencyLevel)
+	assert.False(t, controls.LocalOnly)
+
+	req, _ = http.NewRequest("GET", "/api/v1/query_range", nil)
+	controls, err = ParseFetchControls(req)
+	require.Nil(t, err)
+	assert.Nil(t, controls.ReadConsistencyLevel)
+	assert.False(t, controls.LocalOnly)
+
+	assert.False(t, controls.VerifyReplicas)
+
+	req.Header.Set(VerifyReplicasHeader, "true")
+	controls, err = ParseFetchControls(req)
+	require.Nil(t, err)
+	assert.True(t, controls.VerifyReplicas)
+
+	req.Header.Set(VerifyReplicasHeader, "maybe")
+	_, err = ParseFetchControls(req)
+	require.NotNil(t, err)
+	assert.Equal(t, http.StatusBadRequest, err.Code())
+
+	req.Header.Del(VerifyReplicasHeader)
+	req, _ = http.NewRequest("GET", "/api/v1/query_range?docs-limit=100", nil)
+	req.Header.Set(BytesLimitHeader, "4096")
+	controls, err = ParseFetchControls(req)
+	require.Nil(t, err)
+	assert.Equal(t, 100, controls.DocsLimit)
+	assert.Equal(t, 4096, controls.BytesLimit)
+
+	req.Header.Set(DocsLimitHeader, "-1")
+	_, err = ParseFetchControls(req)
+	require.NotNil(t, err)
+	assert.Equal(t, http.StatusBadRequest, err.Code())
+
+	req.Header.Del(DocsLimitHeader)
+	req.Header.Set(ReadConsistencyHeader, "some")
+	_, err = ParseFetchControls(req)
+	require.NotNil(t, err)
+	assert.Equal(t, http.StatusBadRequest, err.Code())
+}
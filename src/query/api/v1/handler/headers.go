@@ -32,4 +32,24 @@ const (
 
 	// DeprecatedHeader is the M3 deprecated header
 	DeprecatedHeader = "M3-Deprecated"
+
+	// ReadConsistencyHeader is the M3 header to set the read consistency
+	// level of the fetches of a query
+	ReadConsistencyHeader = "M3-Read-Consistency"
+
+	// LocalOnlyHeader is the M3 header to fetch from the local zone only
+	// rather than also fanning out to remote zones
+	LocalOnlyHeader = "M3-Local-Only"
+
+	// VerifyReplicasHeader is the M3 header to compare the blocks fetched
+	// from each replica and warn of those that differ
+	VerifyReplicasHeader = "M3-Verify-Replicas"
+
+	// DocsLimitHeader is the M3 header to set the maximum number of index
+	// documents the fetches of a query may match
+	DocsLimitHeader = "M3-Limit-Max-Docs"
+
+	// BytesLimitHeader is the M3 header to set the maximum number of ID
+	// and tag bytes the fetches of a query may return
+	BytesLimitHeader = "M3-Limit-Max-Bytes"
 )
@@ -29,6 +29,8 @@ import (
 	"github.com/m3db/m3/src/query/api/v1/handler"
 	"github.com/m3db/m3/src/query/models"
 	"github.com/m3db/m3/src/query/storage"
+	"github.com/m3db/m3/src/query/storage/access"
+	"github.com/m3db/m3/src/query/storage/tenant"
 	"github.com/m3db/m3/src/query/ts"
 	"github.com/m3db/m3/src/query/util"
 	"github.com/m3db/m3/src/query/util/logging"
@@ -79,6 +81,14 @@ func (h *WriteJSONHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
 	}
 
 	if err := h.store.Write(r.Context(), writeQuery); err != nil {
+		if tenant.IsQuotaExceeded(err) {
+			handler.Error(w, err, http.StatusTooManyRequests)
+			return
+		}
+		if access.IsAccessDenied(err) {
+			handler.Error(w, err, http.StatusForbidden)
+			return
+		}
 		logging.WithContext(r.Context()).Error("Write error", zap.Any("err", err))
 		handler.Error(w, err, http.StatusInternalServerError)
 	}
@@ -60,7 +60,7 @@ func TestJSONWrite(t *testing.T) {
 
 	ctrl := gomock.NewController(t)
 	storage, session := local.NewStorageAndSession(t, ctrl)
-	session.EXPECT().WriteTagged(gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any()).AnyTimes()
+	session.EXPECT().WriteTaggedTraced(gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any()).AnyTimes()
 
 	jsonWrite := &WriteJSONHandler{store: storage}
 
@@ -0,0 +1,248 @@
+// Copyright (c) 2018 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package handler
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/m3db/m3/src/query/models"
+	"github.com/m3db/m3/src/query/storage"
+	"github.com/m3db/m3/src/query/util/logging"
+	"github.com/m3db/m3/src/query/util/querystats"
+
+	"go.uber.org/zap"
+)
+
+const (
+	// LabelValuesURL is the url to look up the values of several labels,
+	// such as the label_values() variables of a dashboard, in one request.
+	LabelValuesURL = "/api/v1/label_values"
+
+	// LabelValuesHTTPMethod is the HTTP method used with this resource.
+	LabelValuesHTTPMethod = http.MethodPost
+
+	// maxLabelValuesQueries is the max number of lookups of a request.
+	maxLabelValuesQueries = 100
+
+	defaultLabelValuesRange = time.Hour
+)
+
+var (
+	errNoLabelValuesQueries      = errors.New("no label values queries")
+	errLabelValuesQueryNoLabel   = errors.New("label values query has no label")
+	errTooManyLabelValuesQueries = fmt.Errorf(
+		"more than %d label values queries", maxLabelValuesQueries)
+)
+
+// LabelValuesRequest is a request for the values of several labels of the
+// series matched by the matchers of each lookup between start and end. End
+// defaults to now and start to an hour before end.
+type LabelValuesRequest struct {
+	Start   time.Time          `json:"start"`
+	End     time.Time          `json:"end"`
+	Queries []LabelValuesQuery `json:"queries"`
+}
+
+// LabelValuesQuery is a lookup of the values of a label of the series
+// matched by the matchers, all series with the label if there are none.
+type LabelValuesQuery struct {
+	Label    string          `json:"label"`
+	Matchers models.Matchers `json:"matchers"`
+}
+
+// LabelValuesResponse is the values of each lookup of a request, in the
+// order of the lookups.
+type LabelValuesResponse struct {
+	Results []LabelValuesResult `json:"results"`
+}
+
+// LabelValuesResult is the sorted values of the label of a lookup.
+type LabelValuesResult struct {
+	Label  string   `json:"label"`
+	Values []string `json:"values"`
+}
+
+// LabelValuesHandler looks up the values of several labels in one request,
+// the series of lookups with the same matchers are only fetched once.
+type LabelValuesHandler struct {
+	store storage.Storage
+	nowFn func() time.Time
+}
+
+// NewLabelValuesHandler returns a new label values handler.
+func NewLabelValuesHandler(store storage.Storage) http.Handler {
+	return &LabelValuesHandler{store: store, nowFn: time.Now}
+}
+
+func (h *LabelValuesHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	logger := logging.WithContext(r.Context())
+
+	req, rErr := h.parseRequest(r)
+	if rErr != nil {
+		logger.Error("unable to parse request", zap.Any("error", rErr))
+		Error(w, rErr.Inner(), rErr.Code())
+		return
+	}
+	fetchControls, rErr := ParseFetchControls(r)
+	if rErr != nil {
+		Error(w, rErr.Inner(), rErr.Code())
+		return
+	}
+
+	opts := parseLimitFetchOptions(r)
+	opts.FetchControls = fetchControls
+	resp, err := h.labelValues(r.Context(), req, opts)
+	if err != nil {
+		logger.Error("unable to fetch label values", zap.Any("error", err))
+		Error(w, err, http.StatusBadRequest)
+		return
+	}
+
+	WriteJSONResponse(w, resp, logger)
+}
+
+func (h *LabelValuesHandler) parseRequest(r *http.Request) (*LabelValuesRequest, *ParseError) {
+	defer r.Body.Close()
+
+	var req LabelValuesRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		return nil, NewParseError(err, http.StatusBadRequest)
+	}
+	switch {
+	case len(req.Queries) == 0:
+		return nil, NewParseError(errNoLabelValuesQueries, http.StatusBadRequest)
+	case len(req.Queries) > maxLabelValuesQueries:
+		return nil, NewParseError(errTooManyLabelValuesQueries, http.StatusBadRequest)
+	}
+	for _, query := range req.Queries {
+		if query.Label == "" {
+			return nil, NewParseError(errLabelValuesQueryNoLabel, http.StatusBadRequest)
+		}
+	}
+
+	if req.End.IsZero() {
+		req.End = h.nowFn()
+	}
+	if req.Start.IsZero() {
+		req.Start = req.End.Add(-defaultLabelValuesRange)
+	}
+	if req.Start.After(req.End) {
+		return nil, NewParseError(fmt.Errorf("start %v is after end %v",
+			req.Start, req.End), http.StatusBadRequest)
+	}
+	return &req, nil
+}
+
+// labelValues fetches the series of each distinct set of matchers once and
+// collects the values of every label looked up with them.
+func (h *LabelValuesHandler) labelValues(
+	ctx context.Context,
+	req *LabelValuesRequest,
+	opts *storage.FetchOptions,
+) (*LabelValuesResponse, error) {
+	type lookup struct {
+		matchers models.Matchers
+		labels   map[string]map[string]struct{}
+	}
+
+	var (
+		lookups = make(map[string]*lookup)
+		keys    = make([]string, 0, len(req.Queries))
+	)
+	for _, query := range req.Queries {
+		matchers := query.Matchers
+		if len(matchers) == 0 {
+			matchers = models.Matchers{{
+				Type:  models.MatchRegexp,
+				Name:  query.Label,
+				Value: ".+",
+			}}
+		}
+
+		key := matchersKey(matchers)
+		keys = append(keys, key)
+		l, ok := lookups[key]
+		if !ok {
+			l = &lookup{
+				matchers: matchers,
+				labels:   make(map[string]map[string]struct{}),
+			}
+			lookups[key] = l
+		}
+		l.labels[query.Label] = make(map[string]struct{})
+	}
+
+	querystats.SetQuery(ctx, fmt.Sprintf("label_values(%d lookups)", len(lookups)))
+	for _, l := range lookups {
+		results, err := h.store.FetchTags(ctx, &storage.FetchQuery{
+			TagMatchers: l.matchers,
+			Start:       req.Start,
+			End:         req.End,
+		}, opts)
+		if err != nil {
+			return nil, err
+		}
+
+		querystats.AddSeries(ctx, len(results.Metrics))
+		for _, metric := range results.Metrics {
+			for _, tag := range metric.Tags {
+				if values, ok := l.labels[tag.Name]; ok {
+					values[tag.Value] = struct{}{}
+				}
+			}
+		}
+	}
+
+	resp := &LabelValuesResponse{
+		Results: make([]LabelValuesResult, 0, len(req.Queries)),
+	}
+	for i, query := range req.Queries {
+		set := lookups[keys[i]].labels[query.Label]
+		values := make([]string, 0, len(set))
+		for value := range set {
+			values = append(values, value)
+		}
+		sort.Strings(values)
+		resp.Results = append(resp.Results, LabelValuesResult{
+			Label:  query.Label,
+			Values: values,
+		})
+	}
+	return resp, nil
+}
+
+// matchersKey returns a key of the matchers that is the same regardless of
+// their order.
+func matchersKey(matchers models.Matchers) string {
+	strs := make([]string, 0, len(matchers))
+	for _, matcher := range matchers {
+		strs = append(strs, matcher.String())
+	}
+	sort.Strings(strs)
+	return strings.Join(strs, ",")
+}
@@ -0,0 +1,107 @@
+// Copyright (c) 2018 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package handler
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/m3db/m3/src/query/models"
+	"github.com/m3db/m3/src/query/storage"
+	"github.com/m3db/m3/src/query/storage/mock"
+	"github.com/m3db/m3/src/query/util/logging"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type countingStorage struct {
+	storage.Storage
+	fetches []*storage.FetchQuery
+}
+
+func (s *countingStorage) FetchTags(
+	ctx context.Context,
+	query *storage.FetchQuery,
+	options *storage.FetchOptions,
+) (*storage.SearchResults, error) {
+	s.fetches = append(s.fetches, query)
+	return s.Storage.FetchTags(ctx, query, options)
+}
+
+func TestLabelValuesSharesMatchers(t *testing.T) {
+	logging.InitWithCores(nil)
+
+	mockStorage := mock.NewMockStorage()
+	mockStorage.SetFetchTagsResult(&storage.SearchResults{
+		Metrics: models.Metrics{
+			{Tags: models.Tags{{Name: "dc", Value: "east"}, {Name: "host", Value: "b"}}},
+			{Tags: models.Tags{{Name: "dc", Value: "west"}, {Name: "host", Value: "a"}}},
+			{Tags: models.Tags{{Name: "dc", Value: "east"}, {Name: "host", Value: "a"}}},
+		},
+	}, nil)
+	store := &countingStorage{Storage: mockStorage}
+
+	body := `{"queries": [
+		{"label": "host", "matchers": [{"type": 0, "name": "job", "value": "api"}, {"type": 0, "name": "dc", "value": "east"}]},
+		{"label": "dc", "matchers": [{"type": 0, "name": "dc", "value": "east"}, {"type": 0, "name": "job", "value": "api"}]},
+		{"label": "job"}
+	]}`
+	req := httptest.NewRequest(LabelValuesHTTPMethod, LabelValuesURL, strings.NewReader(body))
+	recorder := httptest.NewRecorder()
+	NewLabelValuesHandler(store).ServeHTTP(recorder, req)
+	require.Equal(t, http.StatusOK, recorder.Code, recorder.Body.String())
+
+	var resp LabelValuesResponse
+	require.NoError(t, json.Unmarshal(recorder.Body.Bytes(), &resp))
+	assert.Equal(t, []LabelValuesResult{
+		{Label: "host", Values: []string{"a", "b"}},
+		{Label: "dc", Values: []string{"east", "west"}},
+		{Label: "job", Values: []string{}},
+	}, resp.Results)
+
+	// the first two lookups share their matchers and are fetched once
+	require.Len(t, store.fetches, 2)
+	for _, fetch := range store.fetches {
+		assert.Equal(t, fetch.End.Add(-defaultLabelValuesRange), fetch.Start)
+	}
+}
+
+func TestLabelValuesInvalidRequests(t *testing.T) {
+	logging.InitWithCores(nil)
+
+	h := NewLabelValuesHandler(mock.NewMockStorage())
+	for _, body := range []string{
+		`{`,
+		`{"queries": []}`,
+		`{"queries": [{"matchers": []}]}`,
+		`{"start": "2018-01-02T00:00:00Z", "end": "2018-01-01T00:00:00Z", "queries": [{"label": "a"}]}`,
+	} {
+		req := httptest.NewRequest(LabelValuesHTTPMethod, LabelValuesURL, strings.NewReader(body))
+		recorder := httptest.NewRecorder()
+		h.ServeHTTP(recorder, req)
+		assert.Equal(t, http.StatusBadRequest, recorder.Code, body)
+	}
+}
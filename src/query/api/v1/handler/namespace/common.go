@@ -78,4 +78,5 @@ func RegisterRoutes(r *mux.Router, client clusterclient.Client) {
 	r.HandleFunc(GetURL, logged(NewGetHandler(client)).ServeHTTP).Methods(GetHTTPMethod)
 	r.HandleFunc(AddURL, logged(NewAddHandler(client)).ServeHTTP).Methods(AddHTTPMethod)
 	r.HandleFunc(DeleteURL, logged(NewDeleteHandler(client)).ServeHTTP).Methods(DeleteHTTPMethod)
+	r.HandleFunc(StateURL, logged(NewStateHandler(client)).ServeHTTP).Methods(StateHTTPMethod)
 }
@@ -0,0 +1,193 @@
+// Copyright (c) 2018 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package namespace
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"time"
+
+	nsproto "github.com/m3db/m3/src/dbnode/generated/proto/namespace"
+	"github.com/m3db/m3/src/dbnode/storage/namespace"
+	"github.com/m3db/m3/src/query/api/v1/handler"
+	"github.com/m3db/m3/src/query/generated/proto/admin"
+	"github.com/m3db/m3/src/query/util/logging"
+	clusterclient "github.com/m3db/m3cluster/client"
+
+	"go.uber.org/zap"
+)
+
+const (
+	// StateURL is the url for the namespace state handler.
+	StateURL = handler.RoutePrefixV1 + "/namespace/state"
+
+	// StateHTTPMethod is the HTTP method used with this resource.
+	StateHTTPMethod = http.MethodPost
+
+	// defaultDeleteGracePeriod is the grace period used when a namespace is
+	// marked pending deletion without specifying one.
+	defaultDeleteGracePeriod = 24 * time.Hour
+)
+
+var (
+	errStateEmptyName          = errors.New("must specify namespace name")
+	errNegativeGracePeriod     = errors.New("grace period must not be negative")
+	errGracePeriodNotAvailable = errors.New("grace period is only valid when pending deletion")
+)
+
+// StateRequest is the request to change the lifecycle state of a namespace.
+type StateRequest struct {
+	// Name is the name of the namespace.
+	Name string `json:"name"`
+	// State is the new state, one of "active", "read_only" or
+	// "pending_delete".
+	State string `json:"state"`
+	// GracePeriod is how long to wait before deleting the namespace when
+	// its state is "pending_delete", e.g. "48h".
+	GracePeriod string `json:"gracePeriod"`
+}
+
+// StateHandler is the handler for namespace state changes.
+type StateHandler struct {
+	client clusterclient.Client
+	nowFn  func() time.Time
+}
+
+// NewStateHandler returns a new instance of StateHandler.
+func NewStateHandler(client clusterclient.Client) *StateHandler {
+	return &StateHandler{
+		client: client,
+		nowFn:  time.Now,
+	}
+}
+
+func (h *StateHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	logger := logging.WithContext(ctx)
+
+	req, rErr := h.parseRequest(r)
+	if rErr != nil {
+		logger.Error("unable to parse request", zap.Any("error", rErr))
+		handler.Error(w, rErr.Inner(), rErr.Code())
+		return
+	}
+
+	nsRegistry, err := h.SetState(req)
+	if err != nil {
+		logger.Error("unable to set namespace state", zap.Any("error", err))
+		if err == errNamespaceNotFound {
+			handler.Error(w, err, http.StatusNotFound)
+		} else {
+			handler.Error(w, err, http.StatusBadRequest)
+		}
+		return
+	}
+
+	resp := &admin.NamespaceGetResponse{
+		Registry: &nsRegistry,
+	}
+
+	handler.WriteProtoMsgJSONResponse(w, resp, logger)
+}
+
+func (h *StateHandler) parseRequest(r *http.Request) (StateRequest, *handler.ParseError) {
+	defer r.Body.Close()
+
+	var req StateRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		return StateRequest{}, handler.NewParseError(err, http.StatusBadRequest)
+	}
+	if req.Name == "" {
+		return StateRequest{}, handler.NewParseError(errStateEmptyName, http.StatusBadRequest)
+	}
+
+	return req, nil
+}
+
+// SetState sets the lifecycle state of a namespace.
+func (h *StateHandler) SetState(req StateRequest) (nsproto.Registry, error) {
+	var emptyReg = nsproto.Registry{}
+
+	state, err := namespace.ParseState(req.State)
+	if err != nil {
+		return emptyReg, err
+	}
+
+	var deleteAfter time.Time
+	if state == namespace.StatePendingDelete {
+		gracePeriod := defaultDeleteGracePeriod
+		if req.GracePeriod != "" {
+			gracePeriod, err = time.ParseDuration(req.GracePeriod)
+			if err != nil {
+				return emptyReg, fmt.Errorf("unable to parse grace period: %v", err)
+			}
+		}
+		if gracePeriod < 0 {
+			return emptyReg, errNegativeGracePeriod
+		}
+		deleteAfter = h.nowFn().Add(gracePeriod)
+	} else if req.GracePeriod != "" {
+		return emptyReg, errGracePeriodNotAvailable
+	}
+
+	store, err := h.client.KV()
+	if err != nil {
+		return emptyReg, err
+	}
+
+	metadatas, version, err := Metadata(store)
+	if err != nil {
+		return emptyReg, err
+	}
+
+	mdIdx := -1
+	for idx, md := range metadatas {
+		if md.ID().String() == req.Name {
+			mdIdx = idx
+			break
+		}
+	}
+
+	if mdIdx == -1 {
+		return emptyReg, errNamespaceNotFound
+	}
+
+	md := metadatas[mdIdx]
+	opts := md.Options().SetState(state).SetDeleteAfter(deleteAfter)
+	if metadatas[mdIdx], err = namespace.NewMetadata(md.ID(), opts); err != nil {
+		return emptyReg, fmt.Errorf("unable to get metadata: %v", err)
+	}
+
+	nsMap, err := namespace.NewMap(metadatas)
+	if err != nil {
+		return emptyReg, err
+	}
+
+	protoRegistry := namespace.ToProto(nsMap)
+	_, err = store.CheckAndSet(M3DBNodeNamespacesKey, version, protoRegistry)
+	if err != nil {
+		return emptyReg, fmt.Errorf("failed to set namespace state: %v", err)
+	}
+
+	return *protoRegistry, nil
+}
@@ -0,0 +1,134 @@
+// Copyright (c) 2018 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package namespace
+
+import (
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	nsproto "github.com/m3db/m3/src/dbnode/generated/proto/namespace"
+	"github.com/m3db/m3cluster/kv"
+
+	"github.com/golang/mock/gomock"
+	"github.com/golang/protobuf/proto"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func testStateRegistry() nsproto.Registry {
+	return nsproto.Registry{
+		Namespaces: map[string]*nsproto.NamespaceOptions{
+			"testNamespace": &nsproto.NamespaceOptions{
+				BootstrapEnabled:  true,
+				FlushEnabled:      true,
+				WritesToCommitLog: true,
+				CleanupEnabled:    false,
+				RepairEnabled:     false,
+				RetentionOptions: &nsproto.RetentionOptions{
+					RetentionPeriodNanos:                     172800000000000,
+					BlockSizeNanos:                           7200000000000,
+					BufferFutureNanos:                        600000000000,
+					BufferPastNanos:                          600000000000,
+					BlockDataExpiry:                          true,
+					BlockDataExpiryAfterNotAccessPeriodNanos: 3600000000000,
+				},
+			},
+		},
+	}
+}
+
+func TestNamespaceStateHandlerPendingDelete(t *testing.T) {
+	mockClient, mockKV, ctrl := SetupNamespaceTest(t)
+	stateHandler := NewStateHandler(mockClient)
+	now := time.Unix(1000, 0)
+	stateHandler.nowFn = func() time.Time { return now }
+
+	w := httptest.NewRecorder()
+
+	jsonInput := `{"name": "testNamespace", "state": "pending_delete", "gracePeriod": "1h"}`
+	req := httptest.NewRequest("POST", "/namespace/state", strings.NewReader(jsonInput))
+	require.NotNil(t, req)
+
+	mockValue := kv.NewMockValue(ctrl)
+	mockValue.EXPECT().Unmarshal(gomock.Any()).Return(nil).SetArg(0, testStateRegistry())
+	mockValue.EXPECT().Version().Return(2)
+
+	var updated *nsproto.Registry
+	mockKV.EXPECT().Get(M3DBNodeNamespacesKey).Return(mockValue, nil)
+	mockKV.EXPECT().CheckAndSet(M3DBNodeNamespacesKey, 2, gomock.Any()).
+		Do(func(_ string, _ int, value proto.Message) {
+			updated = value.(*nsproto.Registry)
+		}).
+		Return(3, nil)
+	stateHandler.ServeHTTP(w, req)
+
+	resp := w.Result()
+	_, err := ioutil.ReadAll(resp.Body)
+	require.NoError(t, err)
+	assert.Equal(t, http.StatusOK, resp.StatusCode)
+
+	require.NotNil(t, updated)
+	opts := updated.Namespaces["testNamespace"]
+	require.NotNil(t, opts)
+	assert.Equal(t, nsproto.NamespaceState_PENDING_DELETE, opts.State)
+	assert.Equal(t, now.Add(time.Hour).UnixNano(), opts.DeleteAfterUnixNanos)
+	assert.True(t, opts.FlushEnabled)
+}
+
+func TestNamespaceStateHandlerNotFound(t *testing.T) {
+	mockClient, mockKV, _ := SetupNamespaceTest(t)
+	stateHandler := NewStateHandler(mockClient)
+
+	w := httptest.NewRecorder()
+
+	jsonInput := `{"name": "nope", "state": "read_only"}`
+	req := httptest.NewRequest("POST", "/namespace/state", strings.NewReader(jsonInput))
+	require.NotNil(t, req)
+
+	mockKV.EXPECT().Get(M3DBNodeNamespacesKey).Return(nil, kv.ErrNotFound)
+	stateHandler.ServeHTTP(w, req)
+
+	resp := w.Result()
+	body, _ := ioutil.ReadAll(resp.Body)
+	assert.Equal(t, http.StatusNotFound, resp.StatusCode)
+	assert.Equal(t, "{\"error\":\"unable to find a namespace with specified name\"}\n", string(body))
+}
+
+func TestNamespaceStateHandlerInvalidRequest(t *testing.T) {
+	mockClient, _, _ := SetupNamespaceTest(t)
+	stateHandler := NewStateHandler(mockClient)
+
+	for _, input := range []string{
+		`{"state": "read_only"}`,
+		`{"name": "testNamespace", "state": "unknown"}`,
+		`{"name": "testNamespace", "state": "read_only", "gracePeriod": "1h"}`,
+		`{"name": "testNamespace", "state": "pending_delete", "gracePeriod": "-1h"}`,
+	} {
+		w := httptest.NewRecorder()
+		req := httptest.NewRequest("POST", "/namespace/state", strings.NewReader(input))
+		stateHandler.ServeHTTP(w, req)
+		assert.Equal(t, http.StatusBadRequest, w.Result().StatusCode, input)
+	}
+}
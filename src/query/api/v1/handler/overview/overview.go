@@ -0,0 +1,364 @@
+// Copyright (c) 2018 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package overview
+
+import (
+	"encoding/json"
+	"fmt"
+	"net"
+	"net/http"
+	"sort"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/m3db/m3/src/dbnode/storage/quota"
+	"github.com/m3db/m3/src/query/api/v1/handler"
+	"github.com/m3db/m3/src/query/api/v1/handler/namespace"
+	"github.com/m3db/m3/src/query/api/v1/handler/placement"
+	"github.com/m3db/m3/src/query/util/logging"
+	clusterclient "github.com/m3db/m3cluster/client"
+	"github.com/m3db/m3cluster/kv"
+	clusterplacement "github.com/m3db/m3cluster/placement"
+	"github.com/m3db/m3cluster/shard"
+
+	"github.com/gorilla/mux"
+	"go.uber.org/zap"
+)
+
+const (
+	// URL is the url for the cluster overview handler.
+	URL = handler.RoutePrefixV1 + "/cluster/overview"
+
+	// HTTPMethod is the HTTP method used with this resource.
+	HTTPMethod = http.MethodGet
+
+	// AggregatorServiceName is the service name of the aggregator placement.
+	AggregatorServiceName = "m3aggregator"
+
+	includeUsageParam = "includeUsage"
+	usagePortParam    = "usagePort"
+
+	// defaultUsagePort is the default port of the node HTTP JSON API
+	// which serves the disk usage of each node.
+	defaultUsagePort    = 9002
+	defaultUsageTimeout = 5 * time.Second
+	diskUsagePath       = "/diskusage"
+)
+
+// Response is the cluster overview.
+type Response struct {
+	Placement           *PlacementOverview  `json:"placement,omitempty"`
+	AggregatorPlacement *PlacementOverview  `json:"aggregatorPlacement,omitempty"`
+	Namespaces          []NamespaceOverview `json:"namespaces"`
+	Errors              []string            `json:"errors,omitempty"`
+}
+
+// PlacementOverview is the overview of a placement.
+type PlacementOverview struct {
+	Version    int                `json:"version"`
+	Replicas   int                `json:"replicas"`
+	NumShards  int                `json:"numShards"`
+	IsSharded  bool               `json:"isSharded"`
+	IsMirrored bool               `json:"isMirrored"`
+	Instances  []InstanceOverview `json:"instances"`
+}
+
+// InstanceOverview is the overview of an instance in a placement.
+type InstanceOverview struct {
+	ID             string              `json:"id"`
+	Endpoint       string              `json:"endpoint"`
+	Hostname       string              `json:"hostname"`
+	IsolationGroup string              `json:"isolationGroup"`
+	Zone           string              `json:"zone"`
+	Weight         uint32              `json:"weight"`
+	Shards         ShardStatesOverview `json:"shards"`
+	UsageBytes     int64               `json:"usageBytes,omitempty"`
+	UsageError     string              `json:"usageError,omitempty"`
+}
+
+// ShardStatesOverview is the number of shards of an instance in each state.
+type ShardStatesOverview struct {
+	Initializing int `json:"initializing"`
+	Available    int `json:"available"`
+	Leaving      int `json:"leaving"`
+}
+
+// NamespaceOverview is the overview of a namespace.
+type NamespaceOverview struct {
+	Name         string     `json:"name"`
+	Retention    string     `json:"retention"`
+	BlockSize    string     `json:"blockSize"`
+	BufferPast   string     `json:"bufferPast"`
+	BufferFuture string     `json:"bufferFuture"`
+	IndexEnabled bool       `json:"indexEnabled"`
+	State        string     `json:"state"`
+	DeleteAfter  *time.Time `json:"deleteAfter,omitempty"`
+	// UsageBytes is the bytes on disk of the namespace summed across all
+	// nodes, including all replicas.
+	UsageBytes int64 `json:"usageBytes,omitempty"`
+}
+
+// Handler is the handler for the cluster overview.
+type Handler struct {
+	client     clusterclient.Client
+	httpClient *http.Client
+}
+
+// NewHandler returns a new instance of Handler.
+func NewHandler(client clusterclient.Client) *Handler {
+	return &Handler{
+		client:     client,
+		httpClient: &http.Client{Timeout: defaultUsageTimeout},
+	}
+}
+
+func (h *Handler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	logger := logging.WithContext(ctx)
+
+	includeUsage, usagePort, err := parseUsageParams(r)
+	if err != nil {
+		handler.Error(w, err, http.StatusBadRequest)
+		return
+	}
+
+	resp, err := h.Overview(r.Header, includeUsage, usagePort)
+	if err != nil {
+		logger.Error("unable to get cluster overview", zap.Any("error", err))
+		handler.Error(w, err, http.StatusInternalServerError)
+		return
+	}
+
+	handler.WriteJSONResponse(w, resp, logger)
+}
+
+func parseUsageParams(r *http.Request) (bool, int, error) {
+	var (
+		query        = r.URL.Query()
+		includeUsage = false
+		usagePort    = defaultUsagePort
+		err          error
+	)
+	if v := query.Get(includeUsageParam); v != "" {
+		includeUsage, err = strconv.ParseBool(v)
+		if err != nil {
+			return false, 0, fmt.Errorf("invalid %s: %v", includeUsageParam, err)
+		}
+	}
+	if v := query.Get(usagePortParam); v != "" {
+		usagePort, err = strconv.Atoi(v)
+		if err != nil || usagePort <= 0 {
+			return false, 0, fmt.Errorf("invalid %s: %s", usagePortParam, v)
+		}
+	}
+	return includeUsage, usagePort, nil
+}
+
+// Overview returns the cluster overview. Parts of the cluster that cannot
+// be resolved are reported as errors in the response rather than failing
+// the whole overview.
+func (h *Handler) Overview(
+	headers http.Header,
+	includeUsage bool,
+	usagePort int,
+) (*Response, error) {
+	resp := &Response{Namespaces: []NamespaceOverview{}}
+
+	store, err := h.client.KV()
+	if err != nil {
+		return nil, err
+	}
+
+	metadatas, _, err := namespace.Metadata(store)
+	if err != nil {
+		resp.Errors = append(resp.Errors, fmt.Sprintf("unable to get namespaces: %v", err))
+	}
+	for _, md := range metadatas {
+		var (
+			opts  = md.Options()
+			ropts = opts.RetentionOptions()
+			ns    = NamespaceOverview{
+				Name:         md.ID().String(),
+				Retention:    ropts.RetentionPeriod().String(),
+				BlockSize:    ropts.BlockSize().String(),
+				BufferPast:   ropts.BufferPast().String(),
+				BufferFuture: ropts.BufferFuture().String(),
+				IndexEnabled: opts.IndexOptions().Enabled(),
+				State:        opts.State().String(),
+			}
+		)
+		if deleteAfter := opts.DeleteAfter(); !deleteAfter.IsZero() {
+			ns.DeleteAfter = &deleteAfter
+		}
+		resp.Namespaces = append(resp.Namespaces, ns)
+	}
+	sort.Slice(resp.Namespaces, func(i, j int) bool {
+		return resp.Namespaces[i].Name < resp.Namespaces[j].Name
+	})
+
+	resp.Placement, err = h.placementOverview(headers)
+	if err != nil {
+		resp.Errors = append(resp.Errors, fmt.Sprintf("unable to get placement: %v", err))
+	}
+
+	aggHeaders := make(http.Header, len(headers))
+	for k, v := range headers {
+		aggHeaders[k] = v
+	}
+	aggHeaders.Set(placement.HeaderClusterServiceName, AggregatorServiceName)
+	resp.AggregatorPlacement, err = h.placementOverview(aggHeaders)
+	if err != nil {
+		resp.Errors = append(resp.Errors, fmt.Sprintf("unable to get aggregator placement: %v", err))
+	}
+
+	if includeUsage && resp.Placement != nil {
+		h.addUsage(resp, usagePort)
+	}
+
+	return resp, nil
+}
+
+// placementOverview returns the overview of the placement for the service
+// in the headers, or nil if the service has no placement.
+func (h *Handler) placementOverview(headers http.Header) (*PlacementOverview, error) {
+	service, err := placement.Service(h.client, headers)
+	if err != nil {
+		return nil, err
+	}
+
+	p, version, err := service.Placement()
+	if err == kv.ErrNotFound {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	return newPlacementOverview(p, version), nil
+}
+
+func newPlacementOverview(p clusterplacement.Placement, version int) *PlacementOverview {
+	overview := &PlacementOverview{
+		Version:    version,
+		Replicas:   p.ReplicaFactor(),
+		NumShards:  p.NumShards(),
+		IsSharded:  p.IsSharded(),
+		IsMirrored: p.IsMirrored(),
+		Instances:  make([]InstanceOverview, 0, p.NumInstances()),
+	}
+	for _, instance := range p.Instances() {
+		shards := instance.Shards()
+		overview.Instances = append(overview.Instances, InstanceOverview{
+			ID:             instance.ID(),
+			Endpoint:       instance.Endpoint(),
+			Hostname:       instance.Hostname(),
+			IsolationGroup: instance.IsolationGroup(),
+			Zone:           instance.Zone(),
+			Weight:         instance.Weight(),
+			Shards: ShardStatesOverview{
+				Initializing: shards.NumShardsForState(shard.Initializing),
+				Available:    shards.NumShardsForState(shard.Available),
+				Leaving:      shards.NumShardsForState(shard.Leaving),
+			},
+		})
+	}
+	sort.Slice(overview.Instances, func(i, j int) bool {
+		return overview.Instances[i].ID < overview.Instances[j].ID
+	})
+	return overview
+}
+
+// addUsage fetches the disk usage of each node concurrently and adds it to
+// the instances and namespaces of the overview.
+func (h *Handler) addUsage(resp *Response, usagePort int) {
+	var (
+		instances = resp.Placement.Instances
+		usages    = make([]*quota.Usage, len(instances))
+		wg        sync.WaitGroup
+	)
+	for i := range instances {
+		i := i
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			usage, err := h.fetchUsage(instances[i].Endpoint, usagePort)
+			if err != nil {
+				instances[i].UsageError = err.Error()
+				return
+			}
+			usages[i] = usage
+		}()
+	}
+	wg.Wait()
+
+	namespaceBytes := make(map[string]int64)
+	for i, usage := range usages {
+		if usage == nil {
+			continue
+		}
+		for _, ns := range usage.Namespaces {
+			instances[i].UsageBytes += ns.Bytes
+			namespaceBytes[ns.Namespace] += ns.Bytes
+		}
+	}
+	for i := range resp.Namespaces {
+		resp.Namespaces[i].UsageBytes = namespaceBytes[resp.Namespaces[i].Name]
+	}
+}
+
+func (h *Handler) fetchUsage(endpoint string, port int) (*quota.Usage, error) {
+	var usage quota.Usage
+	if err := h.fetchNode(endpoint, port, diskUsagePath, &usage); err != nil {
+		return nil, err
+	}
+	return &usage, nil
+}
+
+// fetchNode decodes the JSON response of a path of the node HTTP JSON API of
+// the node with the given placement endpoint.
+func (h *Handler) fetchNode(endpoint string, port int, path string, result interface{}) error {
+	host, _, err := net.SplitHostPort(endpoint)
+	if err != nil {
+		return err
+	}
+
+	url := fmt.Sprintf("http://%s%s", net.JoinHostPort(host, strconv.Itoa(port)), path)
+	httpResp, err := h.httpClient.Get(url)
+	if err != nil {
+		return err
+	}
+	defer httpResp.Body.Close()
+
+	if httpResp.StatusCode != http.StatusOK {
+		return fmt.Errorf("unexpected status code fetching %s: %d", path, httpResp.StatusCode)
+	}
+
+	return json.NewDecoder(httpResp.Body).Decode(result)
+}
+
+// RegisterRoutes registers the cluster overview routes
+func RegisterRoutes(r *mux.Router, client clusterclient.Client) {
+	logged := logging.WithResponseTimeLogging
+
+	r.HandleFunc(URL, logged(NewHandler(client)).ServeHTTP).Methods(HTTPMethod)
+	r.HandleFunc(StatsURL, logged(NewStatsHandler(client)).ServeHTTP).Methods(StatsHTTPMethod)
+}
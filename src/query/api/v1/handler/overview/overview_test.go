@@ -0,0 +1,203 @@
+// Copyright (c) 2018 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package overview
+
+import (
+	"encoding/json"
+	"fmt"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"testing"
+	"time"
+
+	nsproto "github.com/m3db/m3/src/dbnode/generated/proto/namespace"
+	"github.com/m3db/m3/src/dbnode/kvconfig"
+	"github.com/m3db/m3/src/dbnode/storage/quota"
+	"github.com/m3db/m3/src/query/util/logging"
+	"github.com/m3db/m3cluster/client"
+	"github.com/m3db/m3cluster/generated/proto/placementpb"
+	"github.com/m3db/m3cluster/kv"
+	"github.com/m3db/m3cluster/kv/mem"
+	"github.com/m3db/m3cluster/placement"
+	"github.com/m3db/m3cluster/services"
+
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type serviceNameMatcher string
+
+func (m serviceNameMatcher) Matches(x interface{}) bool {
+	sid, ok := x.(services.ServiceID)
+	return ok && sid.Name() == string(m)
+}
+
+func (m serviceNameMatcher) String() string {
+	return fmt.Sprintf("service ID with name %s", string(m))
+}
+
+func setupOverviewTest(
+	t *testing.T,
+	ctrl *gomock.Controller,
+	endpoint string,
+) *client.MockClient {
+	logging.InitWithCores(nil)
+
+	store := mem.NewStore()
+	_, err := store.Set(kvconfig.NamespacesKey, &nsproto.Registry{
+		Namespaces: map[string]*nsproto.NamespaceOptions{
+			"metrics": &nsproto.NamespaceOptions{
+				RetentionOptions: &nsproto.RetentionOptions{
+					RetentionPeriodNanos: int64(48 * time.Hour),
+					BlockSizeNanos:       int64(2 * time.Hour),
+					BufferFutureNanos:    int64(10 * time.Minute),
+					BufferPastNanos:      int64(10 * time.Minute),
+				},
+				IndexOptions: &nsproto.IndexOptions{
+					Enabled:        true,
+					BlockSizeNanos: int64(2 * time.Hour),
+				},
+			},
+		},
+	})
+	require.NoError(t, err)
+
+	p, err := placement.NewPlacementFromProto(&placementpb.Placement{
+		Instances: map[string]*placementpb.Instance{
+			"host1": &placementpb.Instance{
+				Id:             "host1",
+				IsolationGroup: "rack1",
+				Zone:           "embedded",
+				Weight:         1,
+				Endpoint:       endpoint,
+				Hostname:       "host1",
+				Shards: []*placementpb.Shard{
+					{Id: 0, State: placementpb.ShardState_AVAILABLE},
+					{Id: 1, State: placementpb.ShardState_INITIALIZING},
+				},
+			},
+		},
+		ReplicaFactor: 1,
+		NumShards:     2,
+		IsSharded:     true,
+	})
+	require.NoError(t, err)
+
+	dbPlacementService := placement.NewMockService(ctrl)
+	dbPlacementService.EXPECT().Placement().Return(p, 3, nil)
+	aggPlacementService := placement.NewMockService(ctrl)
+	aggPlacementService.EXPECT().Placement().Return(nil, 0, kv.ErrNotFound)
+
+	mockServices := services.NewMockServices(ctrl)
+	mockServices.EXPECT().PlacementService(serviceNameMatcher("m3db"), gomock.Any()).
+		Return(dbPlacementService, nil)
+	mockServices.EXPECT().PlacementService(serviceNameMatcher(AggregatorServiceName), gomock.Any()).
+		Return(aggPlacementService, nil)
+
+	mockClient := client.NewMockClient(ctrl)
+	mockClient.EXPECT().KV().Return(store, nil).AnyTimes()
+	mockClient.EXPECT().Services(gomock.Any()).Return(mockServices, nil).AnyTimes()
+	return mockClient
+}
+
+func TestOverviewHandler(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockClient := setupOverviewTest(t, ctrl, "127.0.0.1:9000")
+	w := httptest.NewRecorder()
+	req := httptest.NewRequest(HTTPMethod, URL, nil)
+	NewHandler(mockClient).ServeHTTP(w, req)
+	require.Equal(t, http.StatusOK, w.Code)
+
+	var resp Response
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &resp))
+	assert.Empty(t, resp.Errors)
+	assert.Nil(t, resp.AggregatorPlacement)
+
+	require.NotNil(t, resp.Placement)
+	assert.Equal(t, 3, resp.Placement.Version)
+	assert.Equal(t, 2, resp.Placement.NumShards)
+	require.Len(t, resp.Placement.Instances, 1)
+	instance := resp.Placement.Instances[0]
+	assert.Equal(t, "host1", instance.ID)
+	assert.Equal(t, ShardStatesOverview{Initializing: 1, Available: 1}, instance.Shards)
+	assert.Equal(t, int64(0), instance.UsageBytes)
+
+	require.Len(t, resp.Namespaces, 1)
+	ns := resp.Namespaces[0]
+	assert.Equal(t, "metrics", ns.Name)
+	assert.Equal(t, "48h0m0s", ns.Retention)
+	assert.Equal(t, "2h0m0s", ns.BlockSize)
+	assert.True(t, ns.IndexEnabled)
+	assert.Equal(t, "active", ns.State)
+}
+
+func TestOverviewHandlerWithUsage(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	usageServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		require.Equal(t, diskUsagePath, r.URL.Path)
+		json.NewEncoder(w).Encode(quota.Usage{
+			Namespaces: []quota.NamespaceUsage{
+				{Namespace: "metrics", Bytes: 1024},
+			},
+		})
+	}))
+	defer usageServer.Close()
+
+	host, port, err := net.SplitHostPort(usageServer.Listener.Addr().String())
+	require.NoError(t, err)
+	usagePort, err := strconv.Atoi(port)
+	require.NoError(t, err)
+
+	mockClient := setupOverviewTest(t, ctrl, net.JoinHostPort(host, "9000"))
+	w := httptest.NewRecorder()
+	req := httptest.NewRequest(HTTPMethod,
+		fmt.Sprintf("%s?includeUsage=true&usagePort=%d", URL, usagePort), nil)
+	NewHandler(mockClient).ServeHTTP(w, req)
+	require.Equal(t, http.StatusOK, w.Code)
+
+	var resp Response
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &resp))
+	require.NotNil(t, resp.Placement)
+	require.Len(t, resp.Placement.Instances, 1)
+	assert.Equal(t, int64(1024), resp.Placement.Instances[0].UsageBytes)
+	assert.Empty(t, resp.Placement.Instances[0].UsageError)
+	require.Len(t, resp.Namespaces, 1)
+	assert.Equal(t, int64(1024), resp.Namespaces[0].UsageBytes)
+}
+
+func TestOverviewHandlerInvalidParams(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	h := NewHandler(client.NewMockClient(ctrl))
+	for _, query := range []string{"?includeUsage=maybe", "?usagePort=-1"} {
+		w := httptest.NewRecorder()
+		h.ServeHTTP(w, httptest.NewRequest(HTTPMethod, URL+query, nil))
+		assert.Equal(t, http.StatusBadRequest, w.Code, query)
+	}
+}
@@ -0,0 +1,181 @@
+// Copyright (c) 2018 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package overview
+
+import (
+	"fmt"
+	"net/http"
+	"strconv"
+	"sync"
+
+	"github.com/m3db/m3/src/dbnode/storage/stats"
+	"github.com/m3db/m3/src/query/api/v1/handler"
+	"github.com/m3db/m3/src/query/util/logging"
+	clusterclient "github.com/m3db/m3cluster/client"
+
+	"go.uber.org/zap"
+)
+
+const (
+	// StatsURL is the url for the cluster namespace stats handler.
+	StatsURL = handler.RoutePrefixV1 + "/cluster/stats"
+
+	// StatsHTTPMethod is the HTTP method used with this resource.
+	StatsHTTPMethod = http.MethodGet
+
+	includeNodesParam = "includeNodes"
+	nodePortParam     = "nodePort"
+
+	namespaceStatsPath = "/namespacestats"
+)
+
+// StatsResponse is the cluster-wide view of the namespace stats reported by
+// each node in the placement.
+type StatsResponse struct {
+	Namespaces []stats.ClusterNamespaceStats `json:"namespaces"`
+	Nodes      []NodeStats                   `json:"nodes"`
+	Errors     []string                      `json:"errors,omitempty"`
+}
+
+// NodeStats are the namespace stats reported by a node, the namespaces are
+// only included if requested.
+type NodeStats struct {
+	ID         string                 `json:"id"`
+	Endpoint   string                 `json:"endpoint"`
+	Namespaces []stats.NamespaceStats `json:"namespaces,omitempty"`
+	Errors     []string               `json:"errors,omitempty"`
+}
+
+// StatsHandler is the handler for the cluster namespace stats.
+type StatsHandler struct {
+	overview *Handler
+}
+
+// NewStatsHandler returns a new instance of StatsHandler.
+func NewStatsHandler(client clusterclient.Client) *StatsHandler {
+	return &StatsHandler{overview: NewHandler(client)}
+}
+
+func (h *StatsHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	logger := logging.WithContext(r.Context())
+
+	includeNodes, nodePort, err := parseStatsParams(r)
+	if err != nil {
+		handler.Error(w, err, http.StatusBadRequest)
+		return
+	}
+
+	resp, err := h.Stats(r.Header, includeNodes, nodePort)
+	if err != nil {
+		logger.Error("unable to get cluster stats", zap.Any("error", err))
+		handler.Error(w, err, http.StatusInternalServerError)
+		return
+	}
+
+	handler.WriteJSONResponse(w, resp, logger)
+}
+
+func parseStatsParams(r *http.Request) (bool, int, error) {
+	var (
+		query        = r.URL.Query()
+		includeNodes = false
+		nodePort     = defaultUsagePort
+		err          error
+	)
+	if v := query.Get(includeNodesParam); v != "" {
+		includeNodes, err = strconv.ParseBool(v)
+		if err != nil {
+			return false, 0, fmt.Errorf("invalid %s: %v", includeNodesParam, err)
+		}
+	}
+	if v := query.Get(nodePortParam); v != "" {
+		nodePort, err = strconv.Atoi(v)
+		if err != nil || nodePort <= 0 {
+			return false, 0, fmt.Errorf("invalid %s: %s", nodePortParam, v)
+		}
+	}
+	return includeNodes, nodePort, nil
+}
+
+// Stats fetches the namespace stats of each node in the placement
+// concurrently and aggregates them. Nodes that cannot be reached are
+// reported as errors and left out of the aggregation.
+func (h *StatsHandler) Stats(
+	headers http.Header,
+	includeNodes bool,
+	nodePort int,
+) (*StatsResponse, error) {
+	placement, err := h.overview.placementOverview(headers)
+	if err != nil {
+		return nil, err
+	}
+
+	resp := &StatsResponse{
+		Namespaces: []stats.ClusterNamespaceStats{},
+		Nodes:      []NodeStats{},
+	}
+	if placement == nil {
+		resp.Errors = append(resp.Errors, "no placement")
+		return resp, nil
+	}
+
+	var (
+		instances = placement.Instances
+		nodes     = make([]*stats.NodeStats, len(instances))
+		wg        sync.WaitGroup
+	)
+	resp.Nodes = make([]NodeStats, len(instances))
+	for i := range instances {
+		i := i
+		resp.Nodes[i] = NodeStats{
+			ID:       instances[i].ID,
+			Endpoint: instances[i].Endpoint,
+		}
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			var nodeStats stats.NodeStats
+			err := h.overview.fetchNode(instances[i].Endpoint, nodePort,
+				namespaceStatsPath, &nodeStats)
+			if err != nil {
+				resp.Nodes[i].Errors = []string{err.Error()}
+				return
+			}
+			nodes[i] = &nodeStats
+		}()
+	}
+	wg.Wait()
+
+	reported := make([]stats.NodeStats, 0, len(nodes))
+	for i, nodeStats := range nodes {
+		if nodeStats == nil {
+			continue
+		}
+		reported = append(reported, *nodeStats)
+		resp.Nodes[i].Errors = nodeStats.Errors
+		if includeNodes {
+			resp.Nodes[i].Namespaces = nodeStats.Namespaces
+		}
+	}
+	resp.Namespaces = stats.Aggregate(reported).Namespaces
+
+	return resp, nil
+}
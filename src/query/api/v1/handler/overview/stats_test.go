@@ -0,0 +1,153 @@
+// Copyright (c) 2018 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package overview
+
+import (
+	"encoding/json"
+	"fmt"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"testing"
+
+	"github.com/m3db/m3/src/dbnode/storage/stats"
+	"github.com/m3db/m3/src/query/util/logging"
+	"github.com/m3db/m3cluster/client"
+	"github.com/m3db/m3cluster/generated/proto/placementpb"
+	"github.com/m3db/m3cluster/placement"
+	"github.com/m3db/m3cluster/services"
+
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func setupStatsTest(
+	t *testing.T,
+	ctrl *gomock.Controller,
+	endpoints map[string]string,
+) *client.MockClient {
+	logging.InitWithCores(nil)
+
+	instances := make(map[string]*placementpb.Instance, len(endpoints))
+	for id, endpoint := range endpoints {
+		instances[id] = &placementpb.Instance{
+			Id:       id,
+			Endpoint: endpoint,
+			Hostname: id,
+			Weight:   1,
+			Shards: []*placementpb.Shard{
+				{Id: 0, State: placementpb.ShardState_AVAILABLE},
+			},
+		}
+	}
+	p, err := placement.NewPlacementFromProto(&placementpb.Placement{
+		Instances:     instances,
+		ReplicaFactor: len(endpoints),
+		NumShards:     1,
+		IsSharded:     true,
+	})
+	require.NoError(t, err)
+
+	dbPlacementService := placement.NewMockService(ctrl)
+	dbPlacementService.EXPECT().Placement().Return(p, 1, nil)
+
+	mockServices := services.NewMockServices(ctrl)
+	mockServices.EXPECT().PlacementService(serviceNameMatcher("m3db"), gomock.Any()).
+		Return(dbPlacementService, nil)
+
+	mockClient := client.NewMockClient(ctrl)
+	mockClient.EXPECT().Services(gomock.Any()).Return(mockServices, nil).AnyTimes()
+	return mockClient
+}
+
+func TestStatsHandler(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	statsServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		require.Equal(t, namespaceStatsPath, r.URL.Path)
+		json.NewEncoder(w).Encode(stats.NodeStats{
+			Namespaces: []stats.NamespaceStats{
+				{
+					Namespace:    "metrics",
+					NumShards:    1,
+					ActiveSeries: 100,
+					Disk:         &stats.DiskStats{Bytes: 1024, DataFilesets: 2},
+					Cache:        &stats.CacheStats{Hits: 3, Misses: 1},
+				},
+			},
+		})
+	}))
+	defer statsServer.Close()
+
+	host, port, err := net.SplitHostPort(statsServer.Listener.Addr().String())
+	require.NoError(t, err)
+	nodePort, err := strconv.Atoi(port)
+	require.NoError(t, err)
+
+	mockClient := setupStatsTest(t, ctrl, map[string]string{
+		"host1": net.JoinHostPort(host, "9000"),
+		// The stats of a node with an invalid endpoint cannot be fetched.
+		"host2": "host2",
+	})
+	w := httptest.NewRecorder()
+	req := httptest.NewRequest(StatsHTTPMethod,
+		fmt.Sprintf("%s?includeNodes=true&nodePort=%d", StatsURL, nodePort), nil)
+	NewStatsHandler(mockClient).ServeHTTP(w, req)
+	require.Equal(t, http.StatusOK, w.Code)
+
+	var resp StatsResponse
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &resp))
+	assert.Empty(t, resp.Errors)
+
+	require.Len(t, resp.Namespaces, 1)
+	ns := resp.Namespaces[0]
+	assert.Equal(t, "metrics", ns.Namespace)
+	assert.Equal(t, 1, ns.NumNodes)
+	assert.Equal(t, int64(100), ns.ActiveSeries)
+	assert.Equal(t, &stats.DiskStats{Bytes: 1024, DataFilesets: 2}, ns.Disk)
+	assert.Equal(t, &stats.CacheStats{Hits: 3, Misses: 1, HitRate: 0.75}, ns.Cache)
+
+	require.Len(t, resp.Nodes, 2)
+	byID := make(map[string]NodeStats)
+	for _, node := range resp.Nodes {
+		byID[node.ID] = node
+	}
+	assert.Empty(t, byID["host1"].Errors)
+	require.Len(t, byID["host1"].Namespaces, 1)
+	assert.Equal(t, int64(100), byID["host1"].Namespaces[0].ActiveSeries)
+	assert.Len(t, byID["host2"].Errors, 1)
+	assert.Empty(t, byID["host2"].Namespaces)
+}
+
+func TestStatsHandlerInvalidParams(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	h := NewStatsHandler(client.NewMockClient(ctrl))
+	for _, query := range []string{"?includeNodes=maybe", "?nodePort=0"} {
+		w := httptest.NewRecorder()
+		h.ServeHTTP(w, httptest.NewRequest(StatsHTTPMethod, StatsURL+query, nil))
+		assert.Equal(t, http.StatusBadRequest, w.Code, query)
+	}
+}
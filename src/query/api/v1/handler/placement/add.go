@@ -60,6 +60,24 @@ func (h *AddHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	opts, rErr := parseDryRunOptions(r)
+	if rErr != nil {
+		handler.Error(w, rErr.Inner(), rErr.Code())
+		return
+	}
+
+	if opts.enabled {
+		plan, err := h.Plan(r, req, opts)
+		if err != nil {
+			logger.Error("unable to plan placement add", zap.Any("error", err))
+			handler.Error(w, err, http.StatusInternalServerError)
+			return
+		}
+
+		handler.WriteJSONResponse(w, plan, logger)
+		return
+	}
+
 	placement, err := h.Add(r, req)
 	if err != nil {
 		logger.Error("unable to add placement", zap.Any("error", err))
@@ -101,6 +119,14 @@ func (h *AddHandler) Add(
 		return nil, err
 	}
 
+	if domains := newFailureDomains(h.cfg); domains.enabled() {
+		return changeWithFailureDomains(h.client, httpReq.Header, domains,
+			func(service placement.Service) (placement.Placement, error) {
+				newPlacement, _, err := service.AddInstances(instances)
+				return newPlacement, err
+			})
+	}
+
 	service, err := Service(h.client, httpReq.Header)
 	if err != nil {
 		return nil, err
@@ -113,3 +139,21 @@ func (h *AddHandler) Add(
 
 	return newPlacement, nil
 }
+
+// Plan returns the plan of adding instances to a placement without adding them.
+func (h *AddHandler) Plan(
+	httpReq *http.Request,
+	req *admin.PlacementAddRequest,
+	opts dryRunOptions,
+) (ChangePlan, error) {
+	instances, err := ConvertInstancesProto(req.Instances)
+	if err != nil {
+		return ChangePlan{}, err
+	}
+
+	return planChange(h.client, httpReq.Header, newFailureDomains(h.cfg), opts,
+		func(service placement.Service) (placement.Placement, error) {
+			newPlacement, _, err := service.AddInstances(instances)
+			return newPlacement, err
+		})
+}
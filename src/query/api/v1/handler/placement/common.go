@@ -60,6 +60,16 @@ type Handler struct {
 
 // Service gets a placement service from m3cluster client
 func Service(clusterClient clusterclient.Client, headers http.Header) (placement.Service, error) {
+	return newService(clusterClient, headers, false)
+}
+
+// newService gets a placement service that does not persist placement
+// changes when dry run is set.
+func newService(
+	clusterClient clusterclient.Client,
+	headers http.Header,
+	dryRun bool,
+) (placement.Service, error) {
 	cs, err := clusterClient.Services(services.NewOverrideOptions())
 	if err != nil {
 		return nil, err
@@ -85,7 +95,9 @@ func Service(clusterClient clusterclient.Client, headers http.Header) (placement
 		SetEnvironment(serviceEnvironment).
 		SetZone(serviceZone)
 
-	ps, err := cs.PlacementService(sid, placement.NewOptions().SetValidZone(serviceZone))
+	ps, err := cs.PlacementService(sid, placement.NewOptions().
+		SetValidZone(serviceZone).
+		SetDryrun(dryRun))
 	if err != nil {
 		return nil, err
 	}
@@ -129,4 +141,6 @@ func RegisterRoutes(r *mux.Router, client clusterclient.Client, cfg config.Confi
 	r.HandleFunc(DeleteAllURL, logged(NewDeleteAllHandler(client, cfg)).ServeHTTP).Methods(DeleteAllHTTPMethod)
 	r.HandleFunc(AddURL, logged(NewAddHandler(client, cfg)).ServeHTTP).Methods(AddHTTPMethod)
 	r.HandleFunc(DeleteURL, logged(NewDeleteHandler(client, cfg)).ServeHTTP).Methods(DeleteHTTPMethod)
+	r.HandleFunc(RebalanceURL, logged(NewRebalanceHandler(client, cfg)).ServeHTTP).Methods(RebalanceHTTPMethod)
+	r.HandleFunc(RebalanceGetURL, logged(NewRebalanceGetHandler(client, cfg)).ServeHTTP).Methods(RebalanceGetHTTPMethod)
 }
@@ -30,6 +30,7 @@ import (
 	"github.com/m3db/m3/src/query/generated/proto/admin"
 	"github.com/m3db/m3/src/query/util/logging"
 	clusterclient "github.com/m3db/m3cluster/client"
+	"github.com/m3db/m3cluster/placement"
 
 	"github.com/gorilla/mux"
 	"go.uber.org/zap"
@@ -67,13 +68,28 @@ func (h *DeleteHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	service, err := Service(h.client, r.Header)
-	if err != nil {
-		handler.Error(w, err, http.StatusInternalServerError)
+	opts, rErr := parseDryRunOptions(r)
+	if rErr != nil {
+		handler.Error(w, rErr.Inner(), rErr.Code())
 		return
 	}
 
-	placement, err := service.RemoveInstances([]string{id})
+	if opts.enabled {
+		plan, err := planChange(h.client, r.Header, newFailureDomains(h.cfg), opts,
+			func(service placement.Service) (placement.Placement, error) {
+				return service.RemoveInstances([]string{id})
+			})
+		if err != nil {
+			logger.Error("unable to plan placement delete", zap.Any("error", err))
+			handler.Error(w, err, http.StatusNotFound)
+			return
+		}
+
+		handler.WriteJSONResponse(w, plan, logger)
+		return
+	}
+
+	placement, err := h.remove(r, id)
 	if err != nil {
 		logger.Error("unable to delete placement", zap.Any("error", err))
 		handler.Error(w, err, http.StatusNotFound)
@@ -93,3 +109,19 @@ func (h *DeleteHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
 
 	handler.WriteProtoMsgJSONResponse(w, resp, logger)
 }
+
+func (h *DeleteHandler) remove(r *http.Request, id string) (placement.Placement, error) {
+	if domains := newFailureDomains(h.cfg); domains.enabled() {
+		return changeWithFailureDomains(h.client, r.Header, domains,
+			func(service placement.Service) (placement.Placement, error) {
+				return service.RemoveInstances([]string{id})
+			})
+	}
+
+	service, err := Service(h.client, r.Header)
+	if err != nil {
+		return nil, err
+	}
+
+	return service.RemoveInstances([]string{id})
+}
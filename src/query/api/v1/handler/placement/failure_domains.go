@@ -0,0 +1,237 @@
+// Copyright (c) 2018 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package placement
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"sort"
+	"strings"
+
+	"github.com/m3db/m3/src/cmd/services/m3query/config"
+	clusterclient "github.com/m3db/m3cluster/client"
+	"github.com/m3db/m3cluster/kv"
+	"github.com/m3db/m3cluster/placement"
+	"github.com/m3db/m3cluster/shard"
+)
+
+const defaultFailureDomainSeparator = "/"
+
+var errFailureDomainLevelNoName = errors.New("failure domain level name must be set")
+
+// failureDomains is a failure domain hierarchy encoded in instance isolation
+// groups, such as "region/zone/rack", with the max replicas of a shard that
+// a single domain of each level may hold.
+type failureDomains struct {
+	separator string
+	levels    []config.FailureDomainLevelConfiguration
+}
+
+func newFailureDomains(cfg config.Configuration) failureDomains {
+	cm := cfg.ClusterManagement
+	if cm == nil || cm.FailureDomains == nil {
+		return failureDomains{}
+	}
+
+	separator := cm.FailureDomains.Separator
+	if separator == "" {
+		separator = defaultFailureDomainSeparator
+	}
+
+	return failureDomains{
+		separator: separator,
+		levels:    cm.FailureDomains.Levels,
+	}
+}
+
+// validateLevels returns an error if the levels are not uniquely named or
+// an inner level allows more replicas in a domain than the levels containing
+// it, since the domains of the inner level could never hold them.
+func (d failureDomains) validateLevels() error {
+	var (
+		names       = make(map[string]struct{}, len(d.levels))
+		maxReplicas int
+		maxLevel    string
+	)
+	for _, level := range d.levels {
+		if level.Name == "" {
+			return errFailureDomainLevelNoName
+		}
+		if _, ok := names[level.Name]; ok {
+			return fmt.Errorf("duplicate failure domain level: %s", level.Name)
+		}
+		names[level.Name] = struct{}{}
+
+		if level.MaxReplicas < 0 {
+			return fmt.Errorf("failure domain level %s max replicas must not be negative",
+				level.Name)
+		}
+		if level.MaxReplicas == 0 {
+			continue
+		}
+		if maxReplicas > 0 && level.MaxReplicas > maxReplicas {
+			return fmt.Errorf("failure domain level %s max replicas %d exceeds "+
+				"max replicas %d of containing level %s",
+				level.Name, level.MaxReplicas, maxReplicas, maxLevel)
+		}
+		maxReplicas, maxLevel = level.MaxReplicas, level.Name
+	}
+	return nil
+}
+
+// enabled returns whether any level limits the replicas of a domain.
+func (d failureDomains) enabled() bool {
+	for _, level := range d.levels {
+		if level.MaxReplicas > 0 {
+			return true
+		}
+	}
+	return false
+}
+
+// domain returns the domain of the level that an isolation group belongs to,
+// an isolation group with fewer levels than the hierarchy is its own domain
+// for the levels it is missing.
+func (d failureDomains) domain(isolationGroup string, level int) string {
+	parts := strings.Split(isolationGroup, d.separator)
+	if level+1 < len(parts) {
+		parts = parts[:level+1]
+	}
+	return strings.Join(parts, d.separator)
+}
+
+// check returns an error if the replicas of a shard, given as the number of
+// replicas in each isolation group, exceed the max replicas of a domain.
+func (d failureDomains) check(shardID uint32, replicas map[string]int) error {
+	for i, level := range d.levels {
+		if level.MaxReplicas <= 0 {
+			continue
+		}
+
+		counts := make(map[string]int, len(replicas))
+		for group, n := range replicas {
+			domain := d.domain(group, i)
+			counts[domain] += n
+			if counts[domain] > level.MaxReplicas {
+				return fmt.Errorf("shard %d has more than %d replicas in %s %s",
+					shardID, level.MaxReplicas, level.Name, domain)
+			}
+		}
+	}
+	return nil
+}
+
+// validate returns an error if any shard of the placement has more replicas
+// in a domain than its level allows. Leaving shards are not counted since
+// they are removed once the shards replacing them are available.
+func (d failureDomains) validate(p placement.Placement) error {
+	if !d.enabled() {
+		return nil
+	}
+
+	replicas := make(map[uint32]map[string]int)
+	for _, instance := range p.Instances() {
+		for _, s := range instance.Shards().All() {
+			if s.State() == shard.Leaving {
+				continue
+			}
+			if replicas[s.ID()] == nil {
+				replicas[s.ID()] = make(map[string]int)
+			}
+			replicas[s.ID()][instance.IsolationGroup()]++
+		}
+	}
+
+	shardIDs := make([]uint32, 0, len(replicas))
+	for id := range replicas {
+		shardIDs = append(shardIDs, id)
+	}
+	sort.Slice(shardIDs, func(i, j int) bool {
+		return shardIDs[i] < shardIDs[j]
+	})
+
+	for _, id := range shardIDs {
+		if err := d.check(id, replicas[id]); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// ValidateFailureDomains validates the failure domains configuration.
+func ValidateFailureDomains(cfg config.Configuration) error {
+	return newFailureDomains(cfg).validateLevels()
+}
+
+// CheckPlacementFailureDomains returns an error if the current placement,
+// if any, does not satisfy the failure domain constraints. Constraints are
+// otherwise only checked by placement changes.
+func CheckPlacementFailureDomains(client clusterclient.Client, cfg config.Configuration) error {
+	domains := newFailureDomains(cfg)
+	if !domains.enabled() {
+		return nil
+	}
+
+	service, err := Service(client, http.Header{})
+	if err != nil {
+		return err
+	}
+
+	p, _, err := service.Placement()
+	if err == kv.ErrNotFound {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+
+	if err := domains.validate(p); err != nil {
+		return fmt.Errorf("current placement violates failure domains: %v", err)
+	}
+	return nil
+}
+
+// changeWithFailureDomains makes a placement change with a dry run placement
+// service and only persists the resulting placement if it satisfies the
+// failure domain constraints.
+func changeWithFailureDomains(
+	client clusterclient.Client,
+	headers http.Header,
+	domains failureDomains,
+	change func(service placement.Service) (placement.Placement, error),
+) (placement.Placement, error) {
+	_, version, updated, err := dryRunChange(client, headers, domains, change)
+	if err != nil {
+		return nil, err
+	}
+
+	service, err := Service(client, headers)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := service.CheckAndSet(updated, version); err != nil {
+		return nil, err
+	}
+
+	return updated, nil
+}
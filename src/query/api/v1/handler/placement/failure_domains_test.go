@@ -0,0 +1,177 @@
+// Copyright (c) 2018 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package placement
+
+import (
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/m3db/m3/src/cmd/services/m3query/config"
+	"github.com/m3db/m3cluster/kv"
+	"github.com/m3db/m3cluster/placement"
+
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func newTestFailureDomainsConfig() config.Configuration {
+	return config.Configuration{
+		ClusterManagement: &config.ClusterManagementConfiguration{
+			FailureDomains: &config.FailureDomainsConfiguration{
+				Levels: []config.FailureDomainLevelConfiguration{
+					{Name: "region"},
+					{Name: "zone", MaxReplicas: 1},
+					{Name: "rack"},
+				},
+			},
+		},
+	}
+}
+
+func TestFailureDomainsDomain(t *testing.T) {
+	domains := newFailureDomains(newTestFailureDomainsConfig())
+	require.True(t, domains.enabled())
+
+	assert.Equal(t, "r1", domains.domain("r1/z1/a", 0))
+	assert.Equal(t, "r1/z1", domains.domain("r1/z1/a", 1))
+	assert.Equal(t, "r1/z1/a", domains.domain("r1/z1/a", 2))
+	assert.Equal(t, "r1", domains.domain("r1", 1))
+
+	assert.False(t, newFailureDomains(config.Configuration{}).enabled())
+}
+
+func TestFailureDomainsValidate(t *testing.T) {
+	domains := newFailureDomains(newTestFailureDomainsConfig())
+
+	p := newTestRebalancePlacement(4, "r1/z1/a", "r1/z2/a", "r1/z3/a")
+	assert.NoError(t, domains.validate(p))
+
+	p = newTestRebalancePlacement(4, "r1/z1/a", "r1/z1/b", "r1/z2/a")
+	assert.EqualError(t, domains.validate(p),
+		"shard 0 has more than 1 replicas in zone r1/z1")
+
+	assert.NoError(t, failureDomains{}.validate(p))
+}
+
+func TestFailureDomainsValidateLevels(t *testing.T) {
+	assert.NoError(t, ValidateFailureDomains(newTestFailureDomainsConfig()))
+
+	invalid := [][]config.FailureDomainLevelConfiguration{
+		{{Name: ""}},
+		{{Name: "zone"}, {Name: "zone"}},
+		{{Name: "zone", MaxReplicas: -1}},
+		{{Name: "zone", MaxReplicas: 1}, {Name: "rack", MaxReplicas: 2}},
+	}
+	for _, levels := range invalid {
+		domains := failureDomains{levels: levels}
+		assert.Error(t, domains.validateLevels())
+	}
+}
+
+func TestCheckPlacementFailureDomains(t *testing.T) {
+	mockClient, mockPlacementService := SetupPlacementTest(t)
+	cfg := newTestFailureDomainsConfig()
+
+	mockPlacementService.EXPECT().Placement().Return(nil, 0, kv.ErrNotFound)
+	assert.NoError(t, CheckPlacementFailureDomains(mockClient, cfg))
+
+	valid := newTestRebalancePlacement(2, "r1/z1/a", "r1/z2/a")
+	mockPlacementService.EXPECT().Placement().Return(valid, 1, nil)
+	assert.NoError(t, CheckPlacementFailureDomains(mockClient, cfg))
+
+	invalid := newTestRebalancePlacement(2, "r1/z1/a", "r1/z1/b")
+	mockPlacementService.EXPECT().Placement().Return(invalid, 1, nil)
+	assert.EqualError(t, CheckPlacementFailureDomains(mockClient, cfg),
+		"current placement violates failure domains: "+
+			"shard 0 has more than 1 replicas in zone r1/z1")
+}
+
+func TestPlanRebalanceRespectsFailureDomains(t *testing.T) {
+	domains := newFailureDomains(newTestFailureDomainsConfig())
+
+	p := newTestRebalancePlacement(6, "r1/z1/a", "r1/z2/a", "r1/z3/a")
+	p, err := addEmptyInstances(p, []placement.Instance{newTestRebalanceInstance("r1/z1/b")})
+	require.NoError(t, err)
+
+	// Without constraints the new instance takes shards from every zone.
+	sources := make(map[string]struct{})
+	for _, move := range planRebalance(p, failureDomains{}) {
+		sources[move.from] = struct{}{}
+	}
+	assert.Len(t, sources, 3)
+
+	// With at most one replica per zone it may only take shards from the
+	// instance in its own zone.
+	moves := planRebalance(p, domains)
+	require.NotEmpty(t, moves)
+	for _, move := range moves {
+		assert.Equal(t, "host-r1/z1/a", move.from)
+		assert.Equal(t, "host-r1/z1/b", move.to)
+	}
+
+	updated, changed := rebalanceStep(p, len(moves), domains)
+	require.True(t, changed)
+	assert.NoError(t, domains.validate(updated))
+}
+
+func TestPlacementAddHandlerFailureDomains(t *testing.T) {
+	mockClient, mockPlacementService := SetupPlacementTest(t)
+	handler := NewAddHandler(mockClient, newTestFailureDomainsConfig())
+
+	current := newTestRebalancePlacement(2, "r1/z1/a", "r1/z2/a")
+	reqBody := "{\"instances\":[{\"id\": \"host1\",\"isolation_group\": \"r1/z1/b\",\"zone\": \"test\",\"weight\": 1,\"endpoint\": \"http://host1:1234\",\"hostname\": \"host1\",\"port\": 1234}]}"
+
+	// Test a placement that violates the constraints is not persisted.
+	w := httptest.NewRecorder()
+	req := httptest.NewRequest("POST", "/placement", strings.NewReader(reqBody))
+	require.NotNil(t, req)
+
+	mockPlacementService.EXPECT().Placement().Return(current, 3, nil)
+	mockPlacementService.EXPECT().AddInstances(gomock.Not(nil)).
+		Return(newTestRebalancePlacement(2, "r1/z1/a", "r1/z1/b"), nil, nil)
+	handler.ServeHTTP(w, req)
+
+	resp := w.Result()
+	body, _ := ioutil.ReadAll(resp.Body)
+	assert.Equal(t, http.StatusInternalServerError, resp.StatusCode)
+	assert.Equal(t, "{\"error\":\"shard 0 has more than 1 replicas in zone r1/z1\"}\n", string(body))
+
+	// Test a placement that satisfies the constraints is persisted.
+	w = httptest.NewRecorder()
+	req = httptest.NewRequest("POST", "/placement", strings.NewReader(reqBody))
+	require.NotNil(t, req)
+
+	updated := newTestRebalancePlacement(2, "r1/z1/a", "r1/z2/a")
+	updated, err := addEmptyInstances(updated, []placement.Instance{newTestRebalanceInstance("r1/z1/b")})
+	require.NoError(t, err)
+
+	mockPlacementService.EXPECT().Placement().Return(current, 3, nil)
+	mockPlacementService.EXPECT().AddInstances(gomock.Not(nil)).Return(updated, nil, nil)
+	mockPlacementService.EXPECT().CheckAndSet(updated, 3).Return(nil)
+	handler.ServeHTTP(w, req)
+
+	resp = w.Result()
+	assert.Equal(t, http.StatusOK, resp.StatusCode)
+}
@@ -101,16 +101,42 @@ func (h *InitHandler) Init(
 		return nil, err
 	}
 
-	service, err := Service(h.client, httpReq.Header)
+	domains := newFailureDomains(h.cfg)
+	if !domains.enabled() {
+		service, err := Service(h.client, httpReq.Header)
+		if err != nil {
+			return nil, err
+		}
+
+		return service.BuildInitialPlacement(instances,
+			int(req.NumShards), int(req.ReplicationFactor))
+	}
+
+	// Build the placement without persisting it so that it is only
+	// persisted if it satisfies the failure domain constraints.
+	dryRun, err := newService(h.client, httpReq.Header, true)
 	if err != nil {
 		return nil, err
 	}
 
-	placement, err := service.BuildInitialPlacement(instances,
+	placement, err := dryRun.BuildInitialPlacement(instances,
 		int(req.NumShards), int(req.ReplicationFactor))
 	if err != nil {
 		return nil, err
 	}
 
+	if err := domains.validate(placement); err != nil {
+		return nil, err
+	}
+
+	service, err := Service(h.client, httpReq.Header)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := service.SetIfNotExist(placement); err != nil {
+		return nil, err
+	}
+
 	return placement, nil
 }
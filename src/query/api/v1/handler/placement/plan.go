@@ -0,0 +1,214 @@
+// Copyright (c) 2018 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package placement
+
+import (
+	"fmt"
+	"net/http"
+	"sort"
+	"strconv"
+
+	"github.com/m3db/m3/src/query/api/v1/handler"
+	clusterclient "github.com/m3db/m3cluster/client"
+	"github.com/m3db/m3cluster/placement"
+	"github.com/m3db/m3cluster/shard"
+)
+
+const (
+	// dryRunParam is the URL param that makes a placement change return the
+	// plan of the change instead of making it.
+	dryRunParam = "dryRun"
+
+	// shardBytesParam is the URL param with the estimated size in bytes of
+	// a shard replica, used to estimate the bytes a change would stream.
+	shardBytesParam = "shardBytes"
+)
+
+// ChangePlan is the shard movement that a placement change would make.
+type ChangePlan struct {
+	ShardsMoved    int             `json:"shardsMoved"`
+	EstimatedBytes int64           `json:"estimatedBytes"`
+	Instances      []InstanceDelta `json:"instances"`
+}
+
+// InstanceDelta is the change to the shards owned by an instance, leaving
+// shards are not counted as owned since they are removed once the shards
+// replacing them are available.
+type InstanceDelta struct {
+	ID            string   `json:"id"`
+	ShardsBefore  int      `json:"shardsBefore"`
+	ShardsAfter   int      `json:"shardsAfter"`
+	ShardsAdded   []uint32 `json:"shardsAdded"`
+	ShardsRemoved []uint32 `json:"shardsRemoved"`
+}
+
+// dryRunOptions are the options for planning a placement change.
+type dryRunOptions struct {
+	enabled    bool
+	shardBytes int64
+}
+
+func parseDryRunOptions(r *http.Request) (dryRunOptions, *handler.ParseError) {
+	var (
+		opts  dryRunOptions
+		query = r.URL.Query()
+	)
+	if v := query.Get(dryRunParam); v != "" {
+		enabled, err := strconv.ParseBool(v)
+		if err != nil {
+			return opts, handler.NewParseError(
+				fmt.Errorf("invalid %s param: %v", dryRunParam, err),
+				http.StatusBadRequest)
+		}
+		opts.enabled = enabled
+	}
+
+	if v := query.Get(shardBytesParam); v != "" {
+		shardBytes, err := strconv.ParseInt(v, 10, 64)
+		if err != nil || shardBytes < 0 {
+			return opts, handler.NewParseError(
+				fmt.Errorf("invalid %s param: %s", shardBytesParam, v),
+				http.StatusBadRequest)
+		}
+		opts.shardBytes = shardBytes
+	}
+
+	return opts, nil
+}
+
+// newChangePlan returns the shard movement between two placements, a shard
+// replica counts as moved if an instance owns it after the change but not
+// before it.
+func newChangePlan(before, after placement.Placement, shardBytes int64) ChangePlan {
+	var (
+		owned = make(map[string][2]map[uint32]struct{})
+		plan  ChangePlan
+	)
+	for i, p := range []placement.Placement{before, after} {
+		for _, instance := range p.Instances() {
+			sets := owned[instance.ID()]
+			sets[i] = ownedShards(instance)
+			owned[instance.ID()] = sets
+		}
+	}
+
+	ids := make([]string, 0, len(owned))
+	for id := range owned {
+		ids = append(ids, id)
+	}
+	sort.Strings(ids)
+
+	for _, id := range ids {
+		sets := owned[id]
+		delta := InstanceDelta{
+			ID:            id,
+			ShardsBefore:  len(sets[0]),
+			ShardsAfter:   len(sets[1]),
+			ShardsAdded:   shardDifference(sets[1], sets[0]),
+			ShardsRemoved: shardDifference(sets[0], sets[1]),
+		}
+		if len(delta.ShardsAdded) == 0 && len(delta.ShardsRemoved) == 0 {
+			continue
+		}
+		plan.ShardsMoved += len(delta.ShardsAdded)
+		plan.Instances = append(plan.Instances, delta)
+	}
+	plan.EstimatedBytes = int64(plan.ShardsMoved) * shardBytes
+
+	return plan
+}
+
+func ownedShards(instance placement.Instance) map[uint32]struct{} {
+	owned := make(map[uint32]struct{})
+	for _, s := range instance.Shards().All() {
+		if s.State() != shard.Leaving {
+			owned[s.ID()] = struct{}{}
+		}
+	}
+	return owned
+}
+
+// shardDifference returns the sorted shards of a that are not in b.
+func shardDifference(a, b map[uint32]struct{}) []uint32 {
+	diff := make([]uint32, 0)
+	for id := range a {
+		if _, ok := b[id]; !ok {
+			diff = append(diff, id)
+		}
+	}
+	sort.Slice(diff, func(i, j int) bool {
+		return diff[i] < diff[j]
+	})
+	return diff
+}
+
+// dryRunChange makes a placement change with a dry run placement service,
+// returning the current placement and its version along with the placement
+// the change results in. The resulting placement must satisfy the failure
+// domain constraints.
+func dryRunChange(
+	client clusterclient.Client,
+	headers http.Header,
+	domains failureDomains,
+	change func(service placement.Service) (placement.Placement, error),
+) (placement.Placement, int, placement.Placement, error) {
+	service, err := Service(client, headers)
+	if err != nil {
+		return nil, 0, nil, err
+	}
+
+	current, version, err := service.Placement()
+	if err != nil {
+		return nil, 0, nil, err
+	}
+
+	dryRun, err := newService(client, headers, true)
+	if err != nil {
+		return nil, 0, nil, err
+	}
+
+	updated, err := change(dryRun)
+	if err != nil {
+		return nil, 0, nil, err
+	}
+
+	if err := domains.validate(updated); err != nil {
+		return nil, 0, nil, err
+	}
+
+	return current, version, updated, nil
+}
+
+// planChange returns the plan of a placement change without making it.
+func planChange(
+	client clusterclient.Client,
+	headers http.Header,
+	domains failureDomains,
+	opts dryRunOptions,
+	change func(service placement.Service) (placement.Placement, error),
+) (ChangePlan, error) {
+	current, _, updated, err := dryRunChange(client, headers, domains, change)
+	if err != nil {
+		return ChangePlan{}, err
+	}
+
+	return newChangePlan(current, updated, opts.shardBytes), nil
+}
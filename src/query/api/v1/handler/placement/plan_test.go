@@ -0,0 +1,106 @@
+// Copyright (c) 2018 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package placement
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/m3db/m3/src/cmd/services/m3query/config"
+	"github.com/m3db/m3cluster/placement"
+
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestNewChangePlan(t *testing.T) {
+	before := newTestRebalancePlacement(6, "a", "b", "c")
+	after, err := addEmptyInstances(before.Clone(), []placement.Instance{newTestRebalanceInstance("d")})
+	require.NoError(t, err)
+	after, changed := rebalanceStep(after, 3, failureDomains{})
+	require.True(t, changed)
+
+	plan := newChangePlan(before, after, 100)
+	assert.Equal(t, 3, plan.ShardsMoved)
+	assert.Equal(t, int64(300), plan.EstimatedBytes)
+
+	var added, removed int
+	for _, delta := range plan.Instances {
+		added += len(delta.ShardsAdded)
+		removed += len(delta.ShardsRemoved)
+		assert.Equal(t, delta.ShardsBefore+len(delta.ShardsAdded)-len(delta.ShardsRemoved),
+			delta.ShardsAfter)
+	}
+	assert.Equal(t, 3, added)
+	assert.Equal(t, 3, removed)
+
+	last := plan.Instances[len(plan.Instances)-1]
+	assert.Equal(t, "host-d", last.ID)
+	assert.Equal(t, 0, last.ShardsBefore)
+	assert.Equal(t, 3, last.ShardsAfter)
+
+	assert.Empty(t, newChangePlan(before, before, 100).Instances)
+}
+
+func TestPlacementAddHandlerDryRun(t *testing.T) {
+	mockClient, mockPlacementService := SetupPlacementTest(t)
+	handler := NewAddHandler(mockClient, config.Configuration{})
+
+	current := newTestRebalancePlacement(2, "a", "b")
+	updated, err := addEmptyInstances(current.Clone(), []placement.Instance{newTestRebalanceInstance("c")})
+	require.NoError(t, err)
+	updated, _ = rebalanceStep(updated, 1, failureDomains{})
+
+	// Test the plan is returned without persisting the placement.
+	w := httptest.NewRecorder()
+	req := httptest.NewRequest("POST", "/placement?dryRun=true&shardBytes=10",
+		strings.NewReader("{\"instances\":[{\"id\": \"host-c\",\"isolation_group\": \"c\",\"zone\": \"embedded\",\"weight\": 1,\"endpoint\": \"host-c:9000\",\"hostname\": \"host-c\",\"port\": 9000}]}"))
+	require.NotNil(t, req)
+
+	mockPlacementService.EXPECT().Placement().Return(current, 1, nil)
+	mockPlacementService.EXPECT().AddInstances(gomock.Not(nil)).Return(updated, nil, nil)
+	handler.ServeHTTP(w, req)
+
+	resp := w.Result()
+	body, _ := ioutil.ReadAll(resp.Body)
+	require.Equal(t, http.StatusOK, resp.StatusCode)
+
+	var plan ChangePlan
+	require.NoError(t, json.Unmarshal(body, &plan))
+	assert.Equal(t, 1, plan.ShardsMoved)
+	assert.Equal(t, int64(10), plan.EstimatedBytes)
+	require.Len(t, plan.Instances, 2)
+	assert.Equal(t, "host-c", plan.Instances[1].ID)
+
+	// Test an invalid dry run param.
+	w = httptest.NewRecorder()
+	req = httptest.NewRequest("POST", "/placement?dryRun=maybe",
+		strings.NewReader("{\"instances\":[]}"))
+	require.NotNil(t, req)
+
+	handler.ServeHTTP(w, req)
+	assert.Equal(t, http.StatusBadRequest, w.Result().StatusCode)
+}
@@ -0,0 +1,189 @@
+// Copyright (c) 2018 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package placement
+
+import (
+	"fmt"
+	"net/http"
+
+	"github.com/m3db/m3/src/cmd/services/m3query/config"
+	"github.com/m3db/m3/src/query/api/v1/handler"
+	"github.com/m3db/m3/src/query/generated/proto/admin"
+	"github.com/m3db/m3/src/query/util/logging"
+	clusterclient "github.com/m3db/m3cluster/client"
+	"github.com/m3db/m3cluster/placement"
+	"github.com/m3db/m3cluster/shard"
+
+	"github.com/gogo/protobuf/jsonpb"
+	"go.uber.org/zap"
+)
+
+const (
+	// RebalanceURL is the url for the placement rebalance handler (with the POST method).
+	RebalanceURL = handler.RoutePrefixV1 + "/placement/rebalance"
+
+	// RebalanceHTTPMethod is the HTTP method used with this resource.
+	RebalanceHTTPMethod = http.MethodPost
+
+	// defaultRebalanceParallelism is the default max number of shards
+	// initializing at once when rebalancing a placement.
+	defaultRebalanceParallelism = 1
+)
+
+// RebalanceHandler is the handler for placement rebalances. Each request adds
+// any new instances to the placement without shards and then starts moving
+// shards towards a minimal movement balanced placement, keeping no more than
+// the parallelism budget of shards initializing at once. Requests are
+// repeated as shards are marked available until no moves are pending.
+type RebalanceHandler Handler
+
+// NewRebalanceHandler returns a new instance of RebalanceHandler.
+func NewRebalanceHandler(client clusterclient.Client, cfg config.Configuration) *RebalanceHandler {
+	return &RebalanceHandler{client: client, cfg: cfg}
+}
+
+func (h *RebalanceHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	logger := logging.WithContext(ctx)
+
+	req, rErr := h.parseRequest(r)
+	if rErr != nil {
+		handler.Error(w, rErr.Inner(), rErr.Code())
+		return
+	}
+
+	placement, version, err := h.Rebalance(r, req)
+	if err != nil {
+		logger.Error("unable to rebalance placement", zap.Any("error", err))
+		handler.Error(w, err, http.StatusInternalServerError)
+		return
+	}
+
+	resp, err := newRebalanceResponse(placement, version, newFailureDomains(h.cfg))
+	if err != nil {
+		logger.Error("unable to get placement protobuf", zap.Any("error", err))
+		handler.Error(w, err, http.StatusInternalServerError)
+		return
+	}
+
+	handler.WriteProtoMsgJSONResponse(w, resp, logger)
+}
+
+func (h *RebalanceHandler) parseRequest(r *http.Request) (*admin.PlacementRebalanceRequest, *handler.ParseError) {
+	defer r.Body.Close()
+	rebalanceReq := new(admin.PlacementRebalanceRequest)
+	if err := jsonpb.Unmarshal(r.Body, rebalanceReq); err != nil {
+		return nil, handler.NewParseError(err, http.StatusBadRequest)
+	}
+
+	return rebalanceReq, nil
+}
+
+// Rebalance adds any new instances to the placement and starts the next
+// shard moves of the rebalance, returning the placement and its version.
+func (h *RebalanceHandler) Rebalance(
+	httpReq *http.Request,
+	req *admin.PlacementRebalanceRequest,
+) (placement.Placement, int, error) {
+	instances, err := ConvertInstancesProto(req.Instances)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	service, err := Service(h.client, httpReq.Header)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	current, version, err := service.Placement()
+	if err != nil {
+		return nil, 0, err
+	}
+
+	updated, err := addEmptyInstances(current, instances)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	parallelism := int(req.Parallelism)
+	if parallelism <= 0 {
+		parallelism = h.defaultParallelism()
+	}
+
+	updated, changed := rebalanceStep(updated, parallelism, newFailureDomains(h.cfg))
+	if !changed && len(instances) == 0 {
+		return current, version, nil
+	}
+
+	if err := service.CheckAndSet(updated, version); err != nil {
+		return nil, 0, err
+	}
+
+	return updated, version + 1, nil
+}
+
+func (h *RebalanceHandler) defaultParallelism() int {
+	if cm := h.cfg.ClusterManagement; cm != nil && cm.RebalanceParallelism > 0 {
+		return cm.RebalanceParallelism
+	}
+	return defaultRebalanceParallelism
+}
+
+// addEmptyInstances adds the instances to the placement without any shards,
+// the rebalance assigns them shards gradually.
+func addEmptyInstances(
+	p placement.Placement,
+	instances []placement.Instance,
+) (placement.Placement, error) {
+	if len(instances) == 0 {
+		return p, nil
+	}
+
+	p = p.Clone()
+	all := p.Instances()
+	for _, instance := range instances {
+		if _, ok := p.Instance(instance.ID()); ok {
+			return nil, fmt.Errorf("instance %s already exists in placement", instance.ID())
+		}
+		all = append(all, instance.SetShards(shard.NewShards(nil)))
+	}
+	return p.SetInstances(all), nil
+}
+
+func newRebalanceResponse(
+	p placement.Placement,
+	version int,
+	domains failureDomains,
+) (*admin.PlacementRebalanceResponse, error) {
+	placementProto, err := p.Proto()
+	if err != nil {
+		return nil, err
+	}
+
+	progress := newRebalanceProgress(p, domains)
+	return &admin.PlacementRebalanceResponse{
+		Placement:          placementProto,
+		Version:            int32(version),
+		PendingMoves:       int32(progress.pendingMoves),
+		InitializingShards: int32(progress.initializingShards),
+		LeavingShards:      int32(progress.leavingShards),
+	}, nil
+}
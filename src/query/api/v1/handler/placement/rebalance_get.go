@@ -0,0 +1,74 @@
+// Copyright (c) 2018 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package placement
+
+import (
+	"net/http"
+
+	"github.com/m3db/m3/src/cmd/services/m3query/config"
+	"github.com/m3db/m3/src/query/api/v1/handler"
+	"github.com/m3db/m3/src/query/util/logging"
+	clusterclient "github.com/m3db/m3cluster/client"
+
+	"go.uber.org/zap"
+)
+
+const (
+	// RebalanceGetURL is the url for the placement rebalance progress handler (with the GET method).
+	RebalanceGetURL = handler.RoutePrefixV1 + "/placement/rebalance"
+
+	// RebalanceGetHTTPMethod is the HTTP method used with this resource.
+	RebalanceGetHTTPMethod = http.MethodGet
+)
+
+// RebalanceGetHandler is the handler for placement rebalance progress.
+type RebalanceGetHandler Handler
+
+// NewRebalanceGetHandler returns a new instance of RebalanceGetHandler.
+func NewRebalanceGetHandler(client clusterclient.Client, cfg config.Configuration) *RebalanceGetHandler {
+	return &RebalanceGetHandler{client: client, cfg: cfg}
+}
+
+func (h *RebalanceGetHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	logger := logging.WithContext(ctx)
+
+	service, err := Service(h.client, r.Header)
+	if err != nil {
+		handler.Error(w, err, http.StatusInternalServerError)
+		return
+	}
+
+	placement, version, err := service.Placement()
+	if err != nil {
+		handler.Error(w, err, http.StatusNotFound)
+		return
+	}
+
+	resp, err := newRebalanceResponse(placement, version, newFailureDomains(h.cfg))
+	if err != nil {
+		logger.Error("unable to get placement protobuf", zap.Any("error", err))
+		handler.Error(w, err, http.StatusInternalServerError)
+		return
+	}
+
+	handler.WriteProtoMsgJSONResponse(w, resp, logger)
+}
@@ -0,0 +1,286 @@
+// Copyright (c) 2018 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package placement
+
+import (
+	"sort"
+
+	"github.com/m3db/m3cluster/placement"
+	"github.com/m3db/m3cluster/shard"
+)
+
+// shardMove is a single shard replica moving from one instance to another.
+type shardMove struct {
+	shard uint32
+	from  string
+	to    string
+}
+
+// rebalanceProgress describes how far a placement is from being balanced.
+type rebalanceProgress struct {
+	pendingMoves       int
+	initializingShards int
+	leavingShards      int
+}
+
+// planRebalance computes the shard moves required to balance the placement
+// by instance weight while moving as few shards as possible: only the
+// surplus of instances above their target load is moved and it is only moved
+// to instances below their target load. Leaving shards do not count towards
+// an instance's load and only available shards are moved, and shards are not
+// moved into a failure domain that already has its max replicas of the shard.
+func planRebalance(p placement.Placement, domains failureDomains) []shardMove {
+	instances := append([]placement.Instance(nil), p.Instances()...)
+	sort.Slice(instances, func(i, j int) bool {
+		return instances[i].ID() < instances[j].ID()
+	})
+
+	var (
+		isolations = make(map[string]string, len(instances))
+		owned      = make(map[string]map[uint32]shard.State, len(instances))
+		groups     = make(map[uint32]map[string]int)
+		loads      = make(map[string]int, len(instances))
+		totalLoad  int
+		moves      []shardMove
+	)
+	for _, instance := range instances {
+		id := instance.ID()
+		isolations[id] = instance.IsolationGroup()
+		owned[id] = make(map[uint32]shard.State)
+		for _, s := range instance.Shards().All() {
+			if s.State() == shard.Leaving {
+				continue
+			}
+			owned[id][s.ID()] = s.State()
+			if groups[s.ID()] == nil {
+				groups[s.ID()] = make(map[string]int)
+			}
+			groups[s.ID()][instance.IsolationGroup()]++
+			loads[id]++
+			totalLoad++
+		}
+	}
+
+	targets := rebalanceTargets(instances, loads, totalLoad)
+
+	// canMove returns whether a shard can move between the instances without
+	// placing two replicas of the shard in the same isolation group or more
+	// replicas in a failure domain than its level allows.
+	canMove := func(shardID uint32, from, to string) bool {
+		if state, ok := owned[from][shardID]; !ok || state != shard.Available {
+			return false
+		}
+		if _, ok := owned[to][shardID]; ok {
+			return false
+		}
+		replicas := groups[shardID][isolations[to]]
+		if isolations[from] == isolations[to] {
+			replicas--
+		}
+		if replicas != 0 {
+			return false
+		}
+		if !domains.enabled() {
+			return true
+		}
+
+		moved := make(map[string]int, len(groups[shardID])+1)
+		for group, n := range groups[shardID] {
+			moved[group] = n
+		}
+		moved[isolations[from]]--
+		moved[isolations[to]]++
+		return domains.check(shardID, moved) == nil
+	}
+
+	for _, receiver := range instances {
+		to := receiver.ID()
+		for loads[to] < targets[to] {
+			move, ok := nextShardMove(instances, owned, loads, targets, to, canMove)
+			if !ok {
+				break
+			}
+
+			delete(owned[move.from], move.shard)
+			owned[to][move.shard] = shard.Initializing
+			groups[move.shard][isolations[move.from]]--
+			groups[move.shard][isolations[to]]++
+			loads[move.from]--
+			loads[to]++
+			moves = append(moves, move)
+		}
+	}
+
+	return moves
+}
+
+// nextShardMove finds a shard to move to the receiving instance from the
+// instance with the largest surplus that has a shard that can be moved.
+func nextShardMove(
+	instances []placement.Instance,
+	owned map[string]map[uint32]shard.State,
+	loads map[string]int,
+	targets map[string]int,
+	to string,
+	canMove func(shardID uint32, from, to string) bool,
+) (shardMove, bool) {
+	donors := make([]string, 0, len(instances))
+	for _, instance := range instances {
+		if id := instance.ID(); loads[id] > targets[id] {
+			donors = append(donors, id)
+		}
+	}
+	sort.SliceStable(donors, func(i, j int) bool {
+		return loads[donors[i]]-targets[donors[i]] > loads[donors[j]]-targets[donors[j]]
+	})
+
+	for _, from := range donors {
+		shardIDs := make([]uint32, 0, len(owned[from]))
+		for shardID := range owned[from] {
+			shardIDs = append(shardIDs, shardID)
+		}
+		sort.Slice(shardIDs, func(i, j int) bool {
+			return shardIDs[i] < shardIDs[j]
+		})
+		for _, shardID := range shardIDs {
+			if canMove(shardID, from, to) {
+				return shardMove{shard: shardID, from: from, to: to}, true
+			}
+		}
+	}
+	return shardMove{}, false
+}
+
+// rebalanceTargets returns the number of shards each instance should own
+// proportional to its weight, remainders are given to the instances with the
+// largest fractional share, preferring those that already own more shards.
+func rebalanceTargets(
+	instances []placement.Instance,
+	loads map[string]int,
+	totalLoad int,
+) map[string]int {
+	var totalWeight uint32
+	for _, instance := range instances {
+		totalWeight += instance.Weight()
+	}
+
+	type remainder struct {
+		id       string
+		fraction float64
+	}
+	var (
+		targets    = make(map[string]int, len(instances))
+		remainders = make([]remainder, 0, len(instances))
+		assigned   int
+	)
+	for _, instance := range instances {
+		weight, total := float64(instance.Weight()), float64(totalWeight)
+		if totalWeight == 0 {
+			weight, total = 1, float64(len(instances))
+		}
+		exact := float64(totalLoad) * weight / total
+		target := int(exact)
+		targets[instance.ID()] = target
+		assigned += target
+		remainders = append(remainders, remainder{
+			id:       instance.ID(),
+			fraction: exact - float64(target),
+		})
+	}
+
+	sort.SliceStable(remainders, func(i, j int) bool {
+		if remainders[i].fraction != remainders[j].fraction {
+			return remainders[i].fraction > remainders[j].fraction
+		}
+		return loads[remainders[i].id] > loads[remainders[j].id]
+	})
+	for i := 0; assigned < totalLoad && i < len(remainders); i++ {
+		targets[remainders[i].id]++
+		assigned++
+	}
+
+	return targets
+}
+
+// applyShardMoves returns a copy of the placement with each moving shard
+// marked as leaving its current instance and initializing on its new
+// instance with the current instance as its source.
+func applyShardMoves(p placement.Placement, moves []shardMove) placement.Placement {
+	p = p.Clone()
+	for _, move := range moves {
+		from, ok := p.Instance(move.from)
+		if !ok {
+			continue
+		}
+		to, ok := p.Instance(move.to)
+		if !ok {
+			continue
+		}
+		s, ok := from.Shards().Shard(move.shard)
+		if !ok {
+			continue
+		}
+		from.Shards().Add(s.SetState(shard.Leaving))
+		to.Shards().Add(shard.NewShard(move.shard).
+			SetState(shard.Initializing).
+			SetSourceID(move.from))
+	}
+	return p
+}
+
+// rebalanceStep starts as many of the pending moves of the rebalance plan as
+// the parallelism allows given the shards already initializing, returning
+// the resulting placement and whether it was changed.
+func rebalanceStep(
+	p placement.Placement,
+	parallelism int,
+	domains failureDomains,
+) (placement.Placement, bool) {
+	budget := parallelism - numShardsForState(p, shard.Initializing)
+	if budget <= 0 {
+		return p, false
+	}
+
+	moves := planRebalance(p, domains)
+	if len(moves) == 0 {
+		return p, false
+	}
+	if len(moves) > budget {
+		moves = moves[:budget]
+	}
+	return applyShardMoves(p, moves), true
+}
+
+func newRebalanceProgress(p placement.Placement, domains failureDomains) rebalanceProgress {
+	return rebalanceProgress{
+		pendingMoves:       len(planRebalance(p, domains)),
+		initializingShards: numShardsForState(p, shard.Initializing),
+		leavingShards:      numShardsForState(p, shard.Leaving),
+	}
+}
+
+func numShardsForState(p placement.Placement, state shard.State) int {
+	var n int
+	for _, instance := range p.Instances() {
+		n += instance.Shards().NumShardsForState(state)
+	}
+	return n
+}
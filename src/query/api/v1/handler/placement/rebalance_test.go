@@ -0,0 +1,203 @@
+// Copyright (c) 2018 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package placement
+
+import (
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/m3db/m3/src/cmd/services/m3query/config"
+	"github.com/m3db/m3cluster/placement"
+	"github.com/m3db/m3cluster/shard"
+
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func newTestRebalancePlacement(numShards int, groups ...string) placement.Placement {
+	shardIDs := make([]uint32, 0, numShards)
+	for i := 0; i < numShards; i++ {
+		shardIDs = append(shardIDs, uint32(i))
+	}
+
+	instances := make([]placement.Instance, 0, len(groups))
+	for _, group := range groups {
+		shards := shard.NewShards(nil)
+		for _, id := range shardIDs {
+			shards.Add(shard.NewShard(id).SetState(shard.Available))
+		}
+		instances = append(instances, newTestRebalanceInstance(group).SetShards(shards))
+	}
+
+	return placement.NewPlacement().
+		SetInstances(instances).
+		SetShards(shardIDs).
+		SetReplicaFactor(len(groups)).
+		SetIsSharded(true)
+}
+
+func newTestRebalanceInstance(group string) placement.Instance {
+	return placement.NewInstance().
+		SetID("host-" + group).
+		SetIsolationGroup(group).
+		SetZone("embedded").
+		SetWeight(1).
+		SetEndpoint("host-" + group + ":9000").
+		SetShards(shard.NewShards(nil))
+}
+
+func TestPlanRebalanceMovesOnlySurplus(t *testing.T) {
+	p := newTestRebalancePlacement(6, "a", "b", "c")
+	p, err := addEmptyInstances(p, []placement.Instance{newTestRebalanceInstance("d")})
+	require.NoError(t, err)
+
+	moves := planRebalance(p, failureDomains{})
+
+	// 18 replicas across 4 instances, the new instance needs 4 shards and the
+	// existing instances keep at least 4 each
+	require.Len(t, moves, 4)
+	var (
+		movedShards = make(map[uint32]struct{})
+		movedFrom   = make(map[string]int)
+	)
+	for _, move := range moves {
+		assert.Equal(t, "host-d", move.to)
+		movedShards[move.shard] = struct{}{}
+		movedFrom[move.from]++
+	}
+	assert.Len(t, movedShards, 4)
+	assert.Equal(t, map[string]int{"host-a": 1, "host-b": 1, "host-c": 2}, movedFrom)
+}
+
+func TestPlanRebalanceRespectsIsolationGroups(t *testing.T) {
+	p := newTestRebalancePlacement(6, "a", "b", "c")
+	instance := newTestRebalanceInstance("d").SetID("host-a2").SetIsolationGroup("a")
+	p, err := addEmptyInstances(p, []placement.Instance{instance})
+	require.NoError(t, err)
+
+	// Every shard already has a replica in isolation group a so shards can
+	// only move from the other instance in the same isolation group
+	moves := planRebalance(p, failureDomains{})
+	require.NotEmpty(t, moves)
+	for _, move := range moves {
+		assert.Equal(t, "host-a", move.from)
+		assert.Equal(t, "host-a2", move.to)
+	}
+}
+
+func TestPlanRebalanceBalanced(t *testing.T) {
+	assert.Empty(t, planRebalance(newTestRebalancePlacement(6, "a", "b", "c"), failureDomains{}))
+}
+
+func TestRebalanceStepRespectsParallelism(t *testing.T) {
+	p := newTestRebalancePlacement(6, "a", "b", "c")
+	p, err := addEmptyInstances(p, []placement.Instance{newTestRebalanceInstance("d")})
+	require.NoError(t, err)
+
+	p, changed := rebalanceStep(p, 2, failureDomains{})
+	require.True(t, changed)
+	assert.Equal(t, rebalanceProgress{
+		pendingMoves:       2,
+		initializingShards: 2,
+		leavingShards:      2,
+	}, newRebalanceProgress(p, failureDomains{}))
+
+	instance, ok := p.Instance("host-d")
+	require.True(t, ok)
+	for _, s := range instance.Shards().All() {
+		assert.Equal(t, shard.Initializing, s.State())
+		source, ok := p.Instance(s.SourceID())
+		require.True(t, ok)
+		leaving, ok := source.Shards().Shard(s.ID())
+		require.True(t, ok)
+		assert.Equal(t, shard.Leaving, leaving.State())
+	}
+
+	// No budget left until the initializing shards are available
+	_, changed = rebalanceStep(p, 2, failureDomains{})
+	assert.False(t, changed)
+
+	for _, s := range instance.Shards().All() {
+		source, _ := p.Instance(s.SourceID())
+		source.Shards().Remove(s.ID())
+		instance.Shards().Add(s.SetState(shard.Available).SetSourceID(""))
+	}
+
+	p, changed = rebalanceStep(p, 2, failureDomains{})
+	require.True(t, changed)
+	assert.Equal(t, rebalanceProgress{
+		pendingMoves:       0,
+		initializingShards: 2,
+		leavingShards:      2,
+	}, newRebalanceProgress(p, failureDomains{}))
+}
+
+func TestAddEmptyInstancesExisting(t *testing.T) {
+	p := newTestRebalancePlacement(6, "a", "b", "c")
+	_, err := addEmptyInstances(p, []placement.Instance{newTestRebalanceInstance("a")})
+	require.Error(t, err)
+}
+
+func TestPlacementRebalanceHandler(t *testing.T) {
+	mockClient, mockPlacementService := SetupPlacementTest(t)
+	handler := NewRebalanceHandler(mockClient, config.Configuration{})
+
+	w := httptest.NewRecorder()
+	req := httptest.NewRequest("POST", "/placement/rebalance", strings.NewReader("{\"parallelism\":1,\"instances\":[{\"id\":\"host-d\",\"isolation_group\":\"d\",\"zone\":\"embedded\",\"weight\":1,\"endpoint\":\"host-d:9000\",\"hostname\":\"host-d\",\"port\":9000}]}"))
+	require.NotNil(t, req)
+
+	mockPlacementService.EXPECT().Placement().Return(newTestRebalancePlacement(6, "a", "b", "c"), 3, nil)
+	mockPlacementService.EXPECT().CheckAndSet(gomock.Any(), 3).Do(func(p placement.Placement, _ int) {
+		instance, ok := p.Instance("host-d")
+		require.True(t, ok)
+		assert.Equal(t, 1, instance.Shards().NumShardsForState(shard.Initializing))
+	}).Return(nil)
+	handler.ServeHTTP(w, req)
+
+	resp := w.Result()
+	body, _ := ioutil.ReadAll(resp.Body)
+	assert.Equal(t, http.StatusOK, resp.StatusCode)
+	assert.Contains(t, string(body), "\"version\":4,\"pendingMoves\":3,\"initializingShards\":1,\"leavingShards\":1")
+}
+
+func TestPlacementRebalanceGetHandler(t *testing.T) {
+	mockClient, mockPlacementService := SetupPlacementTest(t)
+	handler := NewRebalanceGetHandler(mockClient, config.Configuration{})
+
+	w := httptest.NewRecorder()
+	req := httptest.NewRequest("GET", "/placement/rebalance", nil)
+	require.NotNil(t, req)
+
+	p, err := addEmptyInstances(newTestRebalancePlacement(6, "a", "b", "c"),
+		[]placement.Instance{newTestRebalanceInstance("d")})
+	require.NoError(t, err)
+	mockPlacementService.EXPECT().Placement().Return(p, 2, nil)
+	handler.ServeHTTP(w, req)
+
+	resp := w.Result()
+	body, _ := ioutil.ReadAll(resp.Body)
+	assert.Equal(t, http.StatusOK, resp.StatusCode)
+	assert.Contains(t, string(body), "\"version\":2,\"pendingMoves\":4")
+}
@@ -47,6 +47,16 @@ const (
 	stepParam         = "step"
 	debugParam        = "debug"
 	endExclusiveParam = "end-exclusive"
+	// lookbackParam sets the lookback of all metric types, and
+	// counterLookbackParam overrides it for counters
+	lookbackParam        = "lookback"
+	counterLookbackParam = "counter-lookback"
+	dedupeSeriesParam    = "dedupe-series"
+	nanModeParam         = "nan-mode"
+	// maxDatapointsParam sets the max number of datapoints returned per
+	// series, consolidated as set by consolidationParam
+	maxDatapointsParam = "max-datapoints"
+	consolidationParam = "consolidation"
 
 	formatErrStr = "error parsing param: %s, error: %v"
 )
@@ -137,9 +147,80 @@ func parseParams(r *http.Request) (models.RequestParams, *handler.ParseError) {
 		params.IncludeEnd = !excludeEnd
 	}
 
+	// Fail on duplicate series if unable to parse the flag
+	if dedupeVal := r.FormValue(dedupeSeriesParam); dedupeVal != "" {
+		dedupe, err := strconv.ParseBool(dedupeVal)
+		if err != nil {
+			logging.WithContext(r.Context()).Warn("unable to parse dedupe series flag", zap.Any("error", err))
+		}
+
+		params.DedupeSeries = dedupe
+	}
+
+	if nanModeVal := r.FormValue(nanModeParam); nanModeVal != "" {
+		nanMode, err := models.ParseNaNMode(nanModeVal)
+		if err != nil {
+			return params, handler.NewParseError(fmt.Errorf(formatErrStr, nanModeParam, err), http.StatusBadRequest)
+		}
+		params.NaNMode = nanMode
+	}
+
+	if maxDatapointsVal := r.FormValue(maxDatapointsParam); maxDatapointsVal != "" {
+		maxDatapoints, err := strconv.Atoi(maxDatapointsVal)
+		if err != nil || maxDatapoints <= 0 {
+			err = fmt.Errorf("max datapoints must be a positive integer: %s", maxDatapointsVal)
+			return params, handler.NewParseError(fmt.Errorf(formatErrStr, maxDatapointsParam, err), http.StatusBadRequest)
+		}
+		params.MaxDatapoints = maxDatapoints
+	}
+
+	if consolidationVal := r.FormValue(consolidationParam); consolidationVal != "" {
+		consolidation, err := models.ParseConsolidationType(consolidationVal)
+		if err != nil {
+			return params, handler.NewParseError(fmt.Errorf(formatErrStr, consolidationParam, err), http.StatusBadRequest)
+		}
+		params.Consolidation = consolidation
+	}
+
 	return params, nil
 }
 
+// parseLookback parses the lookback params of the request, using the defaults
+// for those not set
+func parseLookback(r *http.Request, defaults models.LookbackOptions) (models.LookbackOptions, *handler.ParseError) {
+	lookback := defaults
+	if r.FormValue(lookbackParam) != "" {
+		d, err := parseLookbackDuration(r, lookbackParam)
+		if err != nil {
+			return lookback, err
+		}
+		lookback.Gauge = d
+		lookback.Counter = d
+	}
+
+	if r.FormValue(counterLookbackParam) != "" {
+		d, err := parseLookbackDuration(r, counterLookbackParam)
+		if err != nil {
+			return lookback, err
+		}
+		lookback.Counter = d
+	}
+
+	return lookback, nil
+}
+
+func parseLookbackDuration(r *http.Request, key string) (time.Duration, *handler.ParseError) {
+	d, err := parseDuration(r, key)
+	if err == nil && d < 0 {
+		err = fmt.Errorf("lookback cannot be negative: %v", d)
+	}
+	if err != nil {
+		return 0, handler.NewParseError(fmt.Errorf(formatErrStr, key, err), http.StatusBadRequest)
+	}
+
+	return d, nil
+}
+
 func parseQuery(r *http.Request) (string, error) {
 	queries, ok := r.URL.Query()[queryParam]
 	if !ok || len(queries) == 0 || queries[0] == "" {
@@ -81,6 +81,70 @@ func TestInvalidTarget(t *testing.T) {
 	require.Equal(t, err.Code(), http.StatusBadRequest)
 }
 
+func TestParseLookback(t *testing.T) {
+	defaults := models.LookbackOptions{Gauge: time.Minute, Counter: time.Minute}
+
+	req, _ := http.NewRequest("GET", PromReadURL, nil)
+	req.URL.RawQuery = defaultParams().Encode()
+	lookback, err := parseLookback(req, defaults)
+	require.Nil(t, err)
+	assert.Equal(t, defaults, lookback)
+
+	vals := defaultParams()
+	vals.Add(lookbackParam, "2m")
+	vals.Add(counterLookbackParam, "10m")
+	req.URL.RawQuery = vals.Encode()
+	lookback, err = parseLookback(req, defaults)
+	require.Nil(t, err)
+	assert.Equal(t, 2*time.Minute, lookback.Gauge)
+	assert.Equal(t, 10*time.Minute, lookback.Counter)
+
+	vals = defaultParams()
+	vals.Add(counterLookbackParam, "-1m")
+	req.URL.RawQuery = vals.Encode()
+	_, err = parseLookback(req, defaults)
+	require.NotNil(t, err)
+	assert.Equal(t, http.StatusBadRequest, err.Code())
+}
+
+func TestParseNaNMode(t *testing.T) {
+	vals := defaultParams()
+	vals.Add(nanModeParam, "propagate")
+	req, _ := http.NewRequest("GET", PromReadURL, nil)
+	req.URL.RawQuery = vals.Encode()
+	params, err := parseParams(req)
+	require.Nil(t, err)
+	assert.Equal(t, models.NaNPropagate, params.NaNMode)
+
+	vals = defaultParams()
+	vals.Add(nanModeParam, "ignore")
+	req.URL.RawQuery = vals.Encode()
+	_, err = parseParams(req)
+	require.NotNil(t, err)
+	assert.Equal(t, http.StatusBadRequest, err.Code())
+}
+
+func TestParseMaxDatapoints(t *testing.T) {
+	vals := defaultParams()
+	vals.Add(maxDatapointsParam, "500")
+	vals.Add(consolidationParam, "max")
+	req, _ := http.NewRequest("GET", PromReadURL, nil)
+	req.URL.RawQuery = vals.Encode()
+	params, err := parseParams(req)
+	require.Nil(t, err)
+	assert.Equal(t, 500, params.MaxDatapoints)
+	assert.Equal(t, models.ConsolidationMax, params.Consolidation)
+
+	for _, invalid := range []string{"0", "-1", "many"} {
+		vals = defaultParams()
+		vals.Add(maxDatapointsParam, invalid)
+		req.URL.RawQuery = vals.Encode()
+		_, err = parseParams(req)
+		require.NotNil(t, err, invalid)
+		assert.Equal(t, http.StatusBadRequest, err.Code())
+	}
+}
+
 func TestParseDuration(t *testing.T) {
 	r, err := http.NewRequest(http.MethodGet, "/foo?step=10s", nil)
 	require.NoError(t, err)
@@ -34,6 +34,7 @@ import (
 	"github.com/m3db/m3/src/query/parser/promql"
 	"github.com/m3db/m3/src/query/ts"
 	"github.com/m3db/m3/src/query/util/logging"
+	"github.com/m3db/m3/src/query/util/querystats"
 
 	"go.uber.org/zap"
 )
@@ -56,7 +57,8 @@ var (
 
 // PromReadHandler represents a handler for prometheus read endpoint.
 type PromReadHandler struct {
-	engine *executor.Engine
+	engine   *executor.Engine
+	lookback models.LookbackOptions
 }
 
 // ReadResponse is the response that gets returned to the user
@@ -69,9 +71,10 @@ type blockWithMeta struct {
 	meta  block.Metadata
 }
 
-// NewPromReadHandler returns a new instance of handler.
-func NewPromReadHandler(engine *executor.Engine) http.Handler {
-	return &PromReadHandler{engine: engine}
+// NewPromReadHandler returns a new instance of handler, the lookback is the
+// default of queries that do not set the lookback params.
+func NewPromReadHandler(engine *executor.Engine, lookback models.LookbackOptions) http.Handler {
+	return &PromReadHandler{engine: engine, lookback: lookback}
 }
 
 func (h *PromReadHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
@@ -84,9 +87,22 @@ func (h *PromReadHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	params.Lookback, rErr = parseLookback(r, h.lookback)
+	if rErr != nil {
+		handler.Error(w, rErr.Inner(), rErr.Code())
+		return
+	}
+
+	params.FetchControls, rErr = handler.ParseFetchControls(r)
+	if rErr != nil {
+		handler.Error(w, rErr.Inner(), rErr.Code())
+		return
+	}
+
 	if params.Debug {
 		logger.Info("Request params", zap.Any("params", params))
 	}
+	querystats.SetQuery(ctx, params.Query)
 
 	result, err := h.read(ctx, w, params)
 	if err != nil {
@@ -95,9 +111,22 @@ func (h *PromReadHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	querystats.AddSeries(ctx, len(result))
+
+	if params.MaxDatapoints > 0 {
+		result, err = ts.SeriesList(result).Consolidate(params.Start,
+			params.MaxDatapoints, params.Consolidation)
+		if err != nil {
+			logger.Error("unable to consolidate results", zap.Error(err))
+			handler.Error(w, err, http.StatusInternalServerError)
+			return
+		}
+	}
+
 	// TODO: Support multiple result types
 	w.Header().Set("Content-Type", "application/json")
 	w.Header().Set("Access-Control-Allow-Origin", "*")
+	handler.AddWarningsHeader(ctx, w)
 	renderResultsJSON(w, result, params)
 }
 
@@ -30,8 +30,12 @@ import (
 	"github.com/m3db/m3/src/query/api/v1/handler/prometheus"
 	"github.com/m3db/m3/src/query/executor"
 	"github.com/m3db/m3/src/query/generated/proto/prompb"
+	"github.com/m3db/m3/src/query/models"
 	"github.com/m3db/m3/src/query/storage"
+	"github.com/m3db/m3/src/query/storage/access"
+	"github.com/m3db/m3/src/query/storage/tenant"
 	"github.com/m3db/m3/src/query/util/logging"
+	"github.com/m3db/m3/src/query/util/querystats"
 
 	"github.com/golang/protobuf/proto"
 	"github.com/golang/snappy"
@@ -93,7 +97,24 @@ func (h *PromReadHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	result, err := h.read(ctx, w, req, timeout)
+	fetchControls, rErr := handler.ParseFetchControls(r)
+	if rErr != nil {
+		h.promReadMetrics.fetchErrorsClient.Inc(1)
+		handler.Error(w, rErr.Inner(), rErr.Code())
+		return
+	}
+
+	result, err := h.read(ctx, w, req, timeout, fetchControls)
+	if err != nil && tenant.IsQuotaExceeded(err) {
+		h.promReadMetrics.fetchErrorsClient.Inc(1)
+		handler.Error(w, err, http.StatusTooManyRequests)
+		return
+	}
+	if err != nil && access.IsAccessDenied(err) {
+		h.promReadMetrics.fetchErrorsClient.Inc(1)
+		handler.Error(w, err, http.StatusForbidden)
+		return
+	}
 	if err != nil {
 		h.promReadMetrics.fetchErrorsServer.Inc(1)
 		logger.Error("unable to fetch data", zap.Any("error", err))
@@ -101,6 +122,10 @@ func (h *PromReadHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	for _, res := range result {
+		querystats.AddSeries(ctx, len(res.Timeseries))
+	}
+
 	resp := &prompb.ReadResponse{
 		Results: result,
 	}
@@ -141,7 +166,13 @@ func (h *PromReadHandler) parseRequest(r *http.Request) (*prompb.ReadRequest, *h
 	return &req, nil
 }
 
-func (h *PromReadHandler) read(reqCtx context.Context, w http.ResponseWriter, r *prompb.ReadRequest, timeout time.Duration) ([]*prompb.QueryResult, error) {
+func (h *PromReadHandler) read(
+	reqCtx context.Context,
+	w http.ResponseWriter,
+	r *prompb.ReadRequest,
+	timeout time.Duration,
+	fetchControls models.FetchControls,
+) ([]*prompb.QueryResult, error) {
 	// TODO: Handle multi query use case
 	if len(r.Queries) != 1 {
 		return nil, fmt.Errorf("prometheus read endpoint currently only supports one query at a time")
@@ -154,11 +185,12 @@ func (h *PromReadHandler) read(reqCtx context.Context, w http.ResponseWriter, r
 	if err != nil {
 		return nil, err
 	}
+	querystats.SetQuery(reqCtx, query.TagMatchers.String())
 
 	// Results is closed by execute
 	results := make(chan *storage.QueryResult)
 
-	opts := &executor.EngineOptions{}
+	opts := &executor.EngineOptions{FetchControls: fetchControls}
 	// Detect clients closing connections
 	abortCh, closingCh := handler.CloseWatcher(ctx, w)
 	opts.AbortCh = abortCh
@@ -31,6 +31,7 @@ import (
 
 	"github.com/m3db/m3/src/dbnode/x/metrics"
 	"github.com/m3db/m3/src/query/executor"
+	"github.com/m3db/m3/src/query/models"
 	"github.com/m3db/m3/src/query/storage"
 	"github.com/m3db/m3/src/query/test"
 	"github.com/m3db/m3/src/query/test/local"
@@ -89,7 +90,7 @@ func TestPromReadStorageWithFetchError(t *testing.T) {
 	session.EXPECT().FetchTagged(gomock.Any(), gomock.Any(), gomock.Any()).Return(nil, true, fmt.Errorf("unable to get data"))
 	promRead := &PromReadHandler{engine: executor.NewEngine(storage), promReadMetrics: promReadTestMetrics}
 	req := test.GeneratePromReadRequest()
-	_, err := promRead.read(context.TODO(), httptest.NewRecorder(), req, time.Hour)
+	_, err := promRead.read(context.TODO(), httptest.NewRecorder(), req, time.Hour, models.FetchControls{})
 	require.NotNil(t, err, "unable to read from storage")
 }
 
@@ -25,6 +25,7 @@ import (
 	"errors"
 	"net/http"
 	"sync"
+	"sync/atomic"
 
 	"github.com/m3db/m3/src/cmd/services/m3coordinator/downsample"
 	"github.com/m3db/m3/src/query/api/v1/handler"
@@ -32,9 +33,10 @@ import (
 	"github.com/m3db/m3/src/query/generated/proto/prompb"
 	"github.com/m3db/m3/src/query/storage"
 	"github.com/m3db/m3/src/query/util/logging"
-	xerrors "github.com/m3db/m3x/errors"
 
 	"github.com/golang/protobuf/proto"
+	opentracing "github.com/opentracing/opentracing-go"
+	"github.com/opentracing/opentracing-go/ext"
 	"github.com/uber-go/tally"
 	"go.uber.org/zap"
 )
@@ -45,6 +47,10 @@ const (
 
 	// PromWriteHTTPMethod is the HTTP method used with this resource.
 	PromWriteHTTPMethod = http.MethodPost
+
+	writeSpanName           = "remote-write"
+	writeStorageSpanName    = "remote-write.storage"
+	writeDownsampleSpanName = "remote-write.downsample"
 )
 
 var (
@@ -55,6 +61,7 @@ var (
 type PromWriteHandler struct {
 	store            storage.Storage
 	downsampler      downsample.Downsampler
+	timeWindow       *WriteTimeWindow
 	promWriteMetrics promWriteMetrics
 }
 
@@ -63,6 +70,18 @@ func NewPromWriteHandler(
 	store storage.Storage,
 	downsampler downsample.Downsampler,
 	scope tally.Scope,
+) (http.Handler, error) {
+	return NewPromWriteHandlerWithTimeWindow(store, downsampler, nil, scope)
+}
+
+// NewPromWriteHandlerWithTimeWindow returns a new instance of handler that
+// rejects or clamps samples outside of the write time window, samples are
+// only checked for bad timestamps if the window is nil.
+func NewPromWriteHandlerWithTimeWindow(
+	store storage.Storage,
+	downsampler downsample.Downsampler,
+	timeWindow *WriteTimeWindow,
+	scope tally.Scope,
 ) (http.Handler, error) {
 	if store == nil && downsampler == nil {
 		return nil, errNoStorageOrDownsampler
@@ -70,35 +89,50 @@ func NewPromWriteHandler(
 	return &PromWriteHandler{
 		store:            store,
 		downsampler:      downsampler,
+		timeWindow:       timeWindow,
 		promWriteMetrics: newPromWriteMetrics(scope),
 	}, nil
 }
 
 type promWriteMetrics struct {
-	writeSuccess      tally.Counter
-	writeErrorsServer tally.Counter
-	writeErrorsClient tally.Counter
+	writeSuccess   tally.Counter
+	clampedSamples tally.Counter
+	writeErrors    writeErrorMetrics
 }
 
 func newPromWriteMetrics(scope tally.Scope) promWriteMetrics {
 	return promWriteMetrics{
-		writeSuccess:      scope.Counter("write.success"),
-		writeErrorsServer: scope.Tagged(map[string]string{"code": "5XX"}).Counter("write.errors"),
-		writeErrorsClient: scope.Tagged(map[string]string{"code": "4XX"}).Counter("write.errors"),
+		writeSuccess:   scope.Counter("write.success"),
+		clampedSamples: scope.Counter("write.clamped-samples"),
+		writeErrors:    newWriteErrorMetrics(scope),
 	}
 }
 
 func (h *PromWriteHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
 	req, rErr := h.parseRequest(r)
 	if rErr != nil {
-		h.promWriteMetrics.writeErrorsClient.Inc(1)
+		h.promWriteMetrics.writeErrors.request(rErr.Code(), WriteErrorCauseInvalidRequest)
 		handler.Error(w, rErr.Inner(), rErr.Code())
 		return
 	}
-	if err := h.write(r.Context(), req); err != nil {
-		h.promWriteMetrics.writeErrorsServer.Inc(1)
-		logging.WithContext(r.Context()).Error("Write error", zap.Any("err", err))
-		handler.Error(w, err, http.StatusInternalServerError)
+
+	span := startWriteSpan(r)
+	span.SetTag("series", len(req.Timeseries))
+	defer span.Finish()
+
+	ctx := opentracing.ContextWithSpan(r.Context(), span)
+	validator := newSampleValidator(h.timeWindow, r)
+	if errs := h.write(ctx, req, validator); !errs.empty() {
+		ext.Error.Set(span, true)
+		resp := errs.response()
+		code := writeErrorStatusCode(resp.Causes)
+		h.promWriteMetrics.writeErrors.request(code, primaryWriteErrorCause(resp.Causes))
+		h.promWriteMetrics.writeErrors.samples(resp.Causes)
+		if code >= http.StatusInternalServerError {
+			logging.WithContext(r.Context()).Error("Write error",
+				zap.String("err", resp.Error), zap.Any("causes", resp.Causes))
+		}
+		writeErrorResponse(w, resp, code)
 		return
 	}
 
@@ -119,17 +153,39 @@ func (h *PromWriteHandler) parseRequest(r *http.Request) (*prompb.WriteRequest,
 	return &req, nil
 }
 
-func (h *PromWriteHandler) write(ctx context.Context, r *prompb.WriteRequest) error {
+// write writes the series of a request to storage and the downsampler and
+// returns the errors of the series and samples that failed to be written.
+func (h *PromWriteHandler) write(
+	ctx context.Context,
+	r *prompb.WriteRequest,
+	validator sampleValidator,
+) *writeErrors {
 	var (
-		wg            sync.WaitGroup
-		writeUnaggErr error
-		writeAggErr   error
+		wg      sync.WaitGroup
+		errs    = newWriteErrors()
+		clamped int
 	)
+
+	// Samples with bad timestamps or outside of the write time window are
+	// rejected upfront and the remaining samples of their series still
+	// written.
+	samples := make([][]*prompb.Sample, len(r.Timeseries))
+	for i, t := range r.Timeseries {
+		var n int
+		samples[i], n = validSamples(i, t, validator, errs)
+		clamped += n
+	}
+	if clamped > 0 {
+		h.promWriteMetrics.clampedSamples.Inc(int64(clamped))
+	}
+
 	if h.downsampler != nil {
 		// If writing downsampled aggregations, write them async
 		wg.Add(1)
 		go func() {
-			writeAggErr = h.writeAggregated(ctx, r)
+			span, ctx := opentracing.StartSpanFromContext(ctx, writeDownsampleSpanName)
+			numErrs := h.writeAggregated(ctx, r, validator, errs)
+			finishSpan(span, numErrs)
 			wg.Done()
 		}()
 	}
@@ -137,7 +193,9 @@ func (h *PromWriteHandler) write(ctx context.Context, r *prompb.WriteRequest) er
 	if h.store != nil {
 		// Write the unaggregated points out, don't spawn goroutine
 		// so we reduce number of goroutines just a fraction
-		writeUnaggErr = h.writeUnaggregated(ctx, r)
+		span, ctx := opentracing.StartSpanFromContext(ctx, writeStorageSpanName)
+		numErrs := h.writeUnaggregated(ctx, r, samples, errs)
+		finishSpan(span, numErrs)
 	}
 
 	if h.downsampler != nil {
@@ -146,23 +204,67 @@ func (h *PromWriteHandler) write(ctx context.Context, r *prompb.WriteRequest) er
 		wg.Wait()
 	}
 
-	var multiErr xerrors.MultiError
-	multiErr = multiErr.Add(writeUnaggErr)
-	multiErr = multiErr.Add(writeAggErr)
-	return multiErr.FinalError()
+	return errs
 }
 
+// validSamples returns the samples of a series to write and the number of
+// them that were clamped, and records an error for each rejected sample.
+func validSamples(
+	series int,
+	t *prompb.TimeSeries,
+	validator sampleValidator,
+	errs *writeErrors,
+) ([]*prompb.Sample, int) {
+	var (
+		valid   = t.Samples
+		copied  bool
+		clamped int
+	)
+	for i, sample := range t.Samples {
+		validated, err := validator.validate(sample)
+		if validated == sample {
+			if copied {
+				valid = append(valid, sample)
+			}
+			continue
+		}
+		if !copied {
+			// Copy on the first rejected or clamped sample so the request
+			// is not modified.
+			valid = append(make([]*prompb.Sample, 0, len(t.Samples)), t.Samples[:i]...)
+			copied = true
+		}
+		if err != nil {
+			errs.addSample(series, i, err)
+			continue
+		}
+		valid = append(valid, validated)
+		clamped++
+	}
+	return valid, clamped
+}
+
+// writeUnaggregated writes the valid samples of each series to storage and
+// returns the number of series that failed. A series that fails is reported
+// with all its samples failed, even if some of them were written, since
+// storage does not report the error of each datapoint.
 func (h *PromWriteHandler) writeUnaggregated(
 	ctx context.Context,
 	r *prompb.WriteRequest,
-) error {
+	samples [][]*prompb.Sample,
+	errs *writeErrors,
+) int {
 	var (
-		wg       sync.WaitGroup
-		errLock  sync.Mutex
-		multiErr xerrors.MultiError
+		wg      sync.WaitGroup
+		numErrs int32
 	)
-	for _, t := range r.Timeseries {
-		t := t // Capture for goroutine
+	for i, t := range r.Timeseries {
+		if len(samples[i]) == 0 {
+			continue
+		}
+
+		i := i // Capture for goroutine
+		t := &prompb.TimeSeries{Labels: t.Labels, Samples: samples[i]}
 
 		// TODO(r): Consider adding a worker pool to limit write
 		// request concurrency, instead of using the batch size
@@ -175,9 +277,8 @@ func (h *PromWriteHandler) writeUnaggregated(
 			}
 
 			if err := h.store.Write(ctx, write); err != nil {
-				errLock.Lock()
-				multiErr = multiErr.Add(err)
-				errLock.Unlock()
+				atomic.AddInt32(&numErrs, 1)
+				errs.addSeries(i, len(t.Samples), err)
 			}
 
 			wg.Done()
@@ -186,18 +287,27 @@ func (h *PromWriteHandler) writeUnaggregated(
 
 	wg.Wait()
 
-	return multiErr.FinalError()
+	return int(numErrs)
 }
 
+// writeAggregated writes the samples with valid timestamps of each series to
+// the downsampler and returns the number of series and samples that failed.
 func (h *PromWriteHandler) writeAggregated(
 	_ context.Context,
 	r *prompb.WriteRequest,
-) error {
+	validator sampleValidator,
+	errs *writeErrors,
+) int {
 	var (
 		metricsAppender = h.downsampler.NewMetricsAppender()
-		multiErr        xerrors.MultiError
+		numErrs         int
 	)
-	for _, ts := range r.Timeseries {
+	for i, ts := range r.Timeseries {
+		numSamples := numValidSamples(ts, validator)
+		if numSamples == 0 {
+			continue
+		}
+
 		metricsAppender.Reset()
 		for _, label := range ts.Labels {
 			metricsAppender.AddTag(label.Name, label.Value)
@@ -205,19 +315,55 @@ func (h *PromWriteHandler) writeAggregated(
 
 		samplesAppender, err := metricsAppender.SamplesAppender()
 		if err != nil {
-			multiErr = multiErr.Add(err)
+			numErrs++
+			errs.addSeries(i, numSamples, err)
 			continue
 		}
 
-		for _, elem := range ts.Samples {
+		for j, elem := range ts.Samples {
+			if _, err := validator.validate(elem); err != nil {
+				// Already reported when validating the request.
+				continue
+			}
 			err := samplesAppender.AppendGaugeSample(elem.Value)
 			if err != nil {
-				multiErr = multiErr.Add(err)
+				numErrs++
+				errs.addSample(i, j, err)
 			}
 		}
 	}
 
 	metricsAppender.Finalize()
 
-	return multiErr.FinalError()
+	return numErrs
+}
+
+func numValidSamples(t *prompb.TimeSeries, validator sampleValidator) int {
+	n := 0
+	for _, sample := range t.Samples {
+		if _, err := validator.validate(sample); err == nil {
+			n++
+		}
+	}
+	return n
+}
+
+// startWriteSpan starts the server span for a write request, continuing
+// the trace propagated by the caller in the request headers if any.
+func startWriteSpan(r *http.Request) opentracing.Span {
+	tracer := opentracing.GlobalTracer()
+	opts := []opentracing.StartSpanOption{ext.SpanKindRPCServer}
+	parent, err := tracer.Extract(opentracing.HTTPHeaders,
+		opentracing.HTTPHeadersCarrier(r.Header))
+	if err == nil {
+		opts = append(opts, opentracing.ChildOf(parent))
+	}
+	return tracer.StartSpan(writeSpanName, opts...)
+}
+
+func finishSpan(span opentracing.Span, numErrs int) {
+	if numErrs > 0 {
+		ext.Error.Set(span, true)
+	}
+	span.Finish()
 }
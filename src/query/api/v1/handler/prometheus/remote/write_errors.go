@@ -0,0 +1,358 @@
+// Copyright (c) 2018 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package remote
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+	"sync"
+
+	m3dberrors "github.com/m3db/m3/src/dbnode/storage/errors"
+	"github.com/m3db/m3/src/dbnode/storage/quota"
+	"github.com/m3db/m3/src/query/storage"
+	"github.com/m3db/m3/src/query/storage/access"
+	"github.com/m3db/m3/src/query/storage/tenant"
+
+	"github.com/uber-go/tally"
+)
+
+// WriteErrorCause is the cause of a failed write.
+type WriteErrorCause string
+
+const (
+	// WriteErrorCauseInvalidRequest is a request that could not be parsed.
+	WriteErrorCauseInvalidRequest WriteErrorCause = "invalid_request"
+
+	// WriteErrorCauseBadTimestamp is a sample with a missing or negative
+	// timestamp.
+	WriteErrorCauseBadTimestamp WriteErrorCause = "bad_timestamp"
+
+	// WriteErrorCauseTooFarInPast is a sample older than the buffer past of
+	// its namespace.
+	WriteErrorCauseTooFarInPast WriteErrorCause = "too_far_in_past"
+
+	// WriteErrorCauseTooFarInFuture is a sample newer than the buffer future
+	// of its namespace.
+	WriteErrorCauseTooFarInFuture WriteErrorCause = "too_far_in_future"
+
+	// WriteErrorCauseLimitExceeded is a write rejected by a tenant or
+	// namespace disk quota.
+	WriteErrorCauseLimitExceeded WriteErrorCause = "limit_exceeded"
+
+	// WriteErrorCauseAccessDenied is a write rejected by a namespace access
+	// policy.
+	WriteErrorCauseAccessDenied WriteErrorCause = "access_denied"
+
+	// WriteErrorCauseShardUnavailable is a write that could not be
+	// acknowledged by enough replicas of its shard.
+	WriteErrorCauseShardUnavailable WriteErrorCause = "shard_unavailable"
+
+	// WriteErrorCauseEncoding is a write that failed to encode its tags or
+	// datapoints.
+	WriteErrorCauseEncoding WriteErrorCause = "encoding_error"
+
+	// WriteErrorCauseUnknown is any other write failure.
+	WriteErrorCauseUnknown WriteErrorCause = "unknown"
+
+	// maxWriteErrorDetails is the max number of per-sample errors returned
+	// in the body of a failed write request.
+	maxWriteErrorDetails = 100
+)
+
+var (
+	writeErrorCauses = []WriteErrorCause{
+		WriteErrorCauseInvalidRequest,
+		WriteErrorCauseBadTimestamp,
+		WriteErrorCauseTooFarInPast,
+		WriteErrorCauseTooFarInFuture,
+		WriteErrorCauseLimitExceeded,
+		WriteErrorCauseAccessDenied,
+		WriteErrorCauseShardUnavailable,
+		WriteErrorCauseEncoding,
+		WriteErrorCauseUnknown,
+	}
+
+	errBadTimestamp = errors.New("sample timestamp is missing or negative")
+
+	// NB: errors returned by dbnodes are received by the coordinator as
+	// their message only, so causes are matched by message rather than by
+	// error value.
+	shardUnavailableMessages = []string{
+		"not responsible for shard",
+		"failed to meet consistency level",
+		"session has no host queue for host",
+	}
+	encodingMessages = []string{
+		"encoder is closed",
+		"encoder has no encoded datapoints",
+		"time encoding scheme",
+		"unable to include tags",
+		"no metric name tag found",
+	}
+)
+
+// ClassifyWriteError returns the cause of a failed write.
+func ClassifyWriteError(err error) WriteErrorCause {
+	if err == nil {
+		return ""
+	}
+
+	if windowErr, ok := err.(*WriteTimeWindowError); ok {
+		if windowErr.Timestamp.Before(windowErr.Start) {
+			return WriteErrorCauseTooFarInPast
+		}
+		return WriteErrorCauseTooFarInFuture
+	}
+
+	msg := err.Error()
+	switch {
+	case strings.Contains(msg, m3dberrors.ErrTooPast.Error()):
+		return WriteErrorCauseTooFarInPast
+	case strings.Contains(msg, m3dberrors.ErrTooFuture.Error()):
+		return WriteErrorCauseTooFarInFuture
+	case strings.Contains(msg, errBadTimestamp.Error()):
+		return WriteErrorCauseBadTimestamp
+	case tenant.IsQuotaExceeded(err),
+		strings.Contains(msg, quota.ErrHardLimitExceeded.Error()):
+		return WriteErrorCauseLimitExceeded
+	case access.IsAccessDenied(err):
+		return WriteErrorCauseAccessDenied
+	case containsAny(msg, encodingMessages):
+		return WriteErrorCauseEncoding
+	case containsAny(msg, shardUnavailableMessages):
+		return WriteErrorCauseShardUnavailable
+	}
+	return WriteErrorCauseUnknown
+}
+
+func containsAny(msg string, substrs []string) bool {
+	for _, substr := range substrs {
+		if strings.Contains(msg, substr) {
+			return true
+		}
+	}
+	return false
+}
+
+// writeErrorStatusCode returns the status code of a write that failed with
+// errors of the given causes. Client errors are only returned if no cause is
+// a server error, so that clients retry writes that may succeed when retried.
+func writeErrorStatusCode(causes map[WriteErrorCause]int) int {
+	has := func(cause WriteErrorCause) bool {
+		return causes[cause] > 0
+	}
+	switch {
+	case has(WriteErrorCauseUnknown), has(WriteErrorCauseEncoding):
+		return http.StatusInternalServerError
+	case has(WriteErrorCauseShardUnavailable):
+		return http.StatusServiceUnavailable
+	case has(WriteErrorCauseLimitExceeded):
+		return http.StatusTooManyRequests
+	case has(WriteErrorCauseAccessDenied):
+		return http.StatusForbidden
+	}
+	return http.StatusBadRequest
+}
+
+func statusCodeClass(code int) string {
+	if code >= http.StatusInternalServerError {
+		return "5XX"
+	}
+	return "4XX"
+}
+
+// WriteErrorDetail is the error of a single sample of a failed write, or of
+// all samples of a series if the sample is not set.
+type WriteErrorDetail struct {
+	Series int             `json:"series"`
+	Sample *int            `json:"sample,omitempty"`
+	Cause  WriteErrorCause `json:"cause"`
+	Error  string          `json:"error"`
+
+	// Timestamp and Window are the timestamp of a sample rejected for being
+	// outside of the write time window and the window, in milliseconds.
+	Timestamp *int64           `json:"timestamp,omitempty"`
+	Window    *WriteTimeBounds `json:"window,omitempty"`
+}
+
+// WriteTimeBounds are the bounds of the write time window in milliseconds,
+// both inclusive.
+type WriteTimeBounds struct {
+	Start int64 `json:"start"`
+	End   int64 `json:"end"`
+}
+
+// WriteErrorResponse is the body of a failed write request.
+type WriteErrorResponse struct {
+	Error string `json:"error"`
+
+	// Causes are the number of failed samples of each cause.
+	Causes map[WriteErrorCause]int `json:"causes"`
+
+	// Details are the errors of the failed samples, truncated to at most
+	// maxWriteErrorDetails entries.
+	Details []WriteErrorDetail `json:"details"`
+
+	// Truncated is true if there were more errors than details returned.
+	Truncated bool `json:"truncated,omitempty"`
+}
+
+// writeErrors collects the errors of the series and samples of a write.
+type writeErrors struct {
+	sync.Mutex
+	causes  map[WriteErrorCause]int
+	details []WriteErrorDetail
+	first   error
+	total   int
+}
+
+func newWriteErrors() *writeErrors {
+	return &writeErrors{causes: make(map[WriteErrorCause]int)}
+}
+
+// addSeries records the failure of numSamples samples of a series.
+func (e *writeErrors) addSeries(series, numSamples int, err error) {
+	e.add(series, nil, numSamples, err)
+}
+
+// addSample records the failure of a single sample of a series.
+func (e *writeErrors) addSample(series, sample int, err error) {
+	e.add(series, &sample, 1, err)
+}
+
+func (e *writeErrors) add(series int, sample *int, numSamples int, err error) {
+	cause := ClassifyWriteError(err)
+	e.Lock()
+	if e.first == nil {
+		e.first = err
+	}
+	e.total++
+	e.causes[cause] += numSamples
+	if len(e.details) < maxWriteErrorDetails {
+		detail := WriteErrorDetail{
+			Series: series,
+			Sample: sample,
+			Cause:  cause,
+			Error:  err.Error(),
+		}
+		if windowErr, ok := err.(*WriteTimeWindowError); ok {
+			timestamp := storage.TimeToTimestamp(windowErr.Timestamp)
+			detail.Timestamp = &timestamp
+			detail.Window = &WriteTimeBounds{
+				Start: storage.TimeToTimestamp(windowErr.Start),
+				End:   storage.TimeToTimestamp(windowErr.End),
+			}
+		}
+		e.details = append(e.details, detail)
+	}
+	e.Unlock()
+}
+
+func (e *writeErrors) empty() bool {
+	e.Lock()
+	empty := e.total == 0
+	e.Unlock()
+	return empty
+}
+
+func (e *writeErrors) finalError() error {
+	e.Lock()
+	defer e.Unlock()
+	switch e.total {
+	case 0:
+		return nil
+	case 1:
+		return e.first
+	}
+	return fmt.Errorf("%d write errors, first error: %v", e.total, e.first)
+}
+
+func (e *writeErrors) response() WriteErrorResponse {
+	err := e.finalError()
+	e.Lock()
+	defer e.Unlock()
+	return WriteErrorResponse{
+		Error:     err.Error(),
+		Causes:    e.causes,
+		Details:   e.details,
+		Truncated: e.total > len(e.details),
+	}
+}
+
+func writeErrorResponse(w http.ResponseWriter, resp WriteErrorResponse, code int) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(code)
+	json.NewEncoder(w).Encode(resp)
+}
+
+// writeErrorMetrics are the counts of failed write requests and samples by
+// cause.
+type writeErrorMetrics struct {
+	scope         tally.Scope
+	failedSamples map[WriteErrorCause]tally.Counter
+}
+
+func newWriteErrorMetrics(scope tally.Scope) writeErrorMetrics {
+	m := writeErrorMetrics{
+		scope:         scope,
+		failedSamples: make(map[WriteErrorCause]tally.Counter, len(writeErrorCauses)),
+	}
+	for _, cause := range writeErrorCauses {
+		m.failedSamples[cause] = scope.Tagged(map[string]string{
+			"cause": string(cause),
+		}).Counter("write.failed-samples")
+	}
+	return m
+}
+
+// request records a failed write request with the status code it failed with
+// and the cause of most of its failed samples.
+func (m writeErrorMetrics) request(code int, cause WriteErrorCause) {
+	m.scope.Tagged(map[string]string{
+		"code":  statusCodeClass(code),
+		"cause": string(cause),
+	}).Counter("write.errors").Inc(1)
+}
+
+func (m writeErrorMetrics) samples(causes map[WriteErrorCause]int) {
+	for cause, n := range causes {
+		if counter, ok := m.failedSamples[cause]; ok {
+			counter.Inc(int64(n))
+		}
+	}
+}
+
+// primaryWriteErrorCause returns the cause with the most failed samples.
+func primaryWriteErrorCause(causes map[WriteErrorCause]int) WriteErrorCause {
+	var (
+		primary WriteErrorCause = WriteErrorCauseUnknown
+		max                     = -1
+	)
+	for _, cause := range writeErrorCauses {
+		if n, ok := causes[cause]; ok && n > max {
+			primary, max = cause, n
+		}
+	}
+	return primary
+}
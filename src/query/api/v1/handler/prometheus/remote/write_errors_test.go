@@ -0,0 +1,136 @@
+// Copyright (c) 2018 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package remote
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	m3dberrors "github.com/m3db/m3/src/dbnode/storage/errors"
+	"github.com/m3db/m3/src/query/api/v1/handler/prometheus/remote/test/remote"
+	"github.com/m3db/m3/src/query/storage/access"
+	"github.com/m3db/m3/src/query/test/local"
+	"github.com/m3db/m3/src/query/util/logging"
+
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/uber-go/tally"
+)
+
+func TestClassifyWriteError(t *testing.T) {
+	tests := []struct {
+		err      error
+		expected WriteErrorCause
+	}{
+		{nil, ""},
+		{m3dberrors.ErrTooPast, WriteErrorCauseTooFarInPast},
+		{errors.New("datapoint is too far in the future"), WriteErrorCauseTooFarInFuture},
+		{errBadTimestamp, WriteErrorCauseBadTimestamp},
+		{errors.New("tenant quota exceeded: max active series reached"), WriteErrorCauseLimitExceeded},
+		{errors.New("namespace disk quota exceeded"), WriteErrorCauseLimitExceeded},
+		{fmt.Errorf("%v: metrics", access.ErrAccessDenied), WriteErrorCauseAccessDenied},
+		{errors.New("not responsible for shard 12"), WriteErrorCauseShardUnavailable},
+		{errors.New("failed to meet consistency level majority with 0/3 success"), WriteErrorCauseShardUnavailable},
+		{errors.New("encoder is closed"), WriteErrorCauseEncoding},
+		{errors.New("boom"), WriteErrorCauseUnknown},
+	}
+	for _, test := range tests {
+		assert.Equal(t, test.expected, ClassifyWriteError(test.err), fmt.Sprint(test.err))
+	}
+}
+
+func TestWriteErrorStatusCode(t *testing.T) {
+	tests := []struct {
+		causes   map[WriteErrorCause]int
+		expected int
+	}{
+		{map[WriteErrorCause]int{WriteErrorCauseTooFarInPast: 2}, http.StatusBadRequest},
+		{map[WriteErrorCause]int{WriteErrorCauseAccessDenied: 1, WriteErrorCauseBadTimestamp: 1}, http.StatusForbidden},
+		{map[WriteErrorCause]int{WriteErrorCauseLimitExceeded: 1, WriteErrorCauseAccessDenied: 1}, http.StatusTooManyRequests},
+		{map[WriteErrorCause]int{WriteErrorCauseShardUnavailable: 1, WriteErrorCauseLimitExceeded: 1}, http.StatusServiceUnavailable},
+		{map[WriteErrorCause]int{WriteErrorCauseUnknown: 1, WriteErrorCauseShardUnavailable: 1}, http.StatusInternalServerError},
+	}
+	for _, test := range tests {
+		assert.Equal(t, test.expected, writeErrorStatusCode(test.causes))
+	}
+}
+
+func TestPromWriteErrorDetails(t *testing.T) {
+	logging.InitWithCores(nil)
+
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	storage, session := local.NewStorageAndSession(t, ctrl)
+	session.EXPECT().
+		WriteTaggedTraced(gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any(),
+			gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any()).
+		Return(m3dberrors.ErrTooPast).AnyTimes()
+
+	scope := tally.NewTestScope("", nil)
+	promWrite := &PromWriteHandler{
+		store:            storage,
+		promWriteMetrics: newPromWriteMetrics(scope),
+	}
+
+	promReq := remote.GeneratePromWriteRequest()
+	promReq.Timeseries[0].Samples[1].Timestamp = 0
+	promReqBody := remote.GeneratePromWriteRequestBody(t, promReq)
+	req := httptest.NewRequest(PromWriteHTTPMethod, PromWriteURL, promReqBody)
+
+	recorder := httptest.NewRecorder()
+	promWrite.ServeHTTP(recorder, req)
+	require.Equal(t, http.StatusBadRequest, recorder.Code)
+
+	var resp WriteErrorResponse
+	require.NoError(t, json.Unmarshal(recorder.Body.Bytes(), &resp))
+	assert.Equal(t, map[WriteErrorCause]int{
+		WriteErrorCauseBadTimestamp: 1,
+		WriteErrorCauseTooFarInPast: 3,
+	}, resp.Causes)
+	require.Len(t, resp.Details, 3)
+
+	details := make(map[WriteErrorCause][]WriteErrorDetail)
+	for _, detail := range resp.Details {
+		details[detail.Cause] = append(details[detail.Cause], detail)
+	}
+	require.Len(t, details[WriteErrorCauseBadTimestamp], 1)
+	badTimestamp := details[WriteErrorCauseBadTimestamp][0]
+	assert.Equal(t, 0, badTimestamp.Series)
+	require.NotNil(t, badTimestamp.Sample)
+	assert.Equal(t, 1, *badTimestamp.Sample)
+	for _, detail := range details[WriteErrorCauseTooFarInPast] {
+		assert.Nil(t, detail.Sample)
+	}
+
+	snapshot := scope.Snapshot().Counters()
+	failed := snapshot["write.failed-samples+cause=too_far_in_past"]
+	require.NotNil(t, failed)
+	assert.Equal(t, int64(3), failed.Value())
+	requests := snapshot["write.errors+cause=too_far_in_past,code=4XX"]
+	require.NotNil(t, requests)
+	assert.Equal(t, int64(1), requests.Value())
+}
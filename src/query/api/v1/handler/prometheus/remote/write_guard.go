@@ -0,0 +1,306 @@
+// Copyright (c) 2018 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package remote
+
+import (
+	"bytes"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"net"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/m3db/m3/src/query/api/v1/handler"
+	"github.com/m3db/m3/src/query/util/logging"
+
+	"github.com/uber-go/tally"
+	"go.uber.org/zap"
+)
+
+const (
+	// DefaultSignatureHeader is the default header with the HMAC signature
+	// of a write request.
+	DefaultSignatureHeader = "X-M3-Signature"
+
+	// DefaultSignatureTimestampHeader is the default header with the unix
+	// time in seconds at which a write request was signed.
+	DefaultSignatureTimestampHeader = "X-M3-Signature-Timestamp"
+
+	// DefaultSignatureMaxAge is the default max age of a signature.
+	DefaultSignatureMaxAge = 5 * time.Minute
+
+	// DefaultSignedMaxBodySize is the default max size in bytes of the body
+	// of a signed request.
+	DefaultSignedMaxBodySize = 32 << 20
+
+	signaturePrefix = "sha256="
+)
+
+var (
+	errSourceNotAllowed     = errors.New("source address is not allowed to write")
+	errSignatureMissing     = errors.New("request signature missing")
+	errSignatureInvalid     = errors.New("request signature invalid")
+	errSignatureExpired     = errors.New("request signature timestamp outside of max age")
+	errSigningNoSecrets     = errors.New("request signing requires at least one secret")
+	errUnknownSourceAddress = errors.New("unable to determine source address")
+)
+
+// WriteGuardConfiguration is the configuration that hardens the remote write
+// endpoint, for instance when it is exposed to the internet.
+type WriteGuardConfiguration struct {
+	// AllowedCIDRs are the source address ranges allowed to write, all
+	// sources are allowed if empty.
+	AllowedCIDRs []string `yaml:"allowedCIDRs"`
+
+	// ClientIPHeader is the header with the source address of requests set
+	// by a trusted proxy, such as X-Forwarded-For, the last address of the
+	// header is used. The remote address of the connection is used if not set.
+	ClientIPHeader string `yaml:"clientIPHeader"`
+
+	// Signing requires requests to be signed with a shared secret if set.
+	Signing *SigningConfiguration `yaml:"signing"`
+}
+
+// SigningConfiguration is the configuration of HMAC request signing. The
+// signature of a request is the hex encoded HMAC-SHA256 of its timestamp
+// header, a period and its body, prefixed with "sha256=".
+type SigningConfiguration struct {
+	// Secrets are the shared secrets signatures are accepted from, more
+	// than one can be set while rotating secrets.
+	Secrets []string `yaml:"secrets"`
+
+	// Header is the header with the signature, defaults to X-M3-Signature.
+	Header string `yaml:"header"`
+
+	// TimestampHeader is the header with the unix time in seconds the
+	// request was signed at, defaults to X-M3-Signature-Timestamp.
+	TimestampHeader string `yaml:"timestampHeader"`
+
+	// MaxAge is the max difference between the signing time of a request
+	// and now, which bounds the window a captured request can be replayed
+	// in, defaults to 5m.
+	MaxAge time.Duration `yaml:"maxAge"`
+
+	// MaxBodySize is the max size in bytes of the body of a request, which
+	// is read into memory to verify its signature, defaults to 32MiB.
+	MaxBodySize int64 `yaml:"maxBodySize"`
+}
+
+type writeGuardMetrics struct {
+	rejectedSource    tally.Counter
+	rejectedSignature tally.Counter
+}
+
+func newWriteGuardMetrics(scope tally.Scope) writeGuardMetrics {
+	return writeGuardMetrics{
+		rejectedSource:    scope.Counter("rejected-source"),
+		rejectedSignature: scope.Counter("rejected-signature"),
+	}
+}
+
+// WriteGuard only lets through write requests from allowed source addresses
+// and, if signing is configured, with a valid signature.
+type WriteGuard struct {
+	allowed         []*net.IPNet
+	clientIPHeader  string
+	secrets         [][]byte
+	header          string
+	timestampHeader string
+	maxAge          time.Duration
+	maxBodySize     int64
+	nowFn           func() time.Time
+	metrics         writeGuardMetrics
+}
+
+// NewWriteGuard returns the configured write guard.
+func (c WriteGuardConfiguration) NewWriteGuard(scope tally.Scope) (*WriteGuard, error) {
+	g := &WriteGuard{
+		clientIPHeader: c.ClientIPHeader,
+		nowFn:          time.Now,
+		metrics:        newWriteGuardMetrics(scope.SubScope("write-guard")),
+	}
+	for _, cidr := range c.AllowedCIDRs {
+		_, ipNet, err := net.ParseCIDR(cidr)
+		if err != nil {
+			return nil, fmt.Errorf("invalid allowed CIDR %s: %v", cidr, err)
+		}
+		g.allowed = append(g.allowed, ipNet)
+	}
+
+	if signing := c.Signing; signing != nil {
+		if len(signing.Secrets) == 0 {
+			return nil, errSigningNoSecrets
+		}
+		for _, secret := range signing.Secrets {
+			g.secrets = append(g.secrets, []byte(secret))
+		}
+		g.header = signing.Header
+		if g.header == "" {
+			g.header = DefaultSignatureHeader
+		}
+		g.timestampHeader = signing.TimestampHeader
+		if g.timestampHeader == "" {
+			g.timestampHeader = DefaultSignatureTimestampHeader
+		}
+		g.maxAge = signing.MaxAge
+		if g.maxAge <= 0 {
+			g.maxAge = DefaultSignatureMaxAge
+		}
+		g.maxBodySize = signing.MaxBodySize
+		if g.maxBodySize <= 0 {
+			g.maxBodySize = DefaultSignedMaxBodySize
+		}
+	}
+	return g, nil
+}
+
+// Wrap returns a handler that rejects the requests the guard does not let
+// through and serves the rest with the next handler.
+func (g *WriteGuard) Wrap(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		logger := logging.WithContext(r.Context())
+
+		if err := g.checkSource(r); err != nil {
+			g.metrics.rejectedSource.Inc(1)
+			logger.Warn("rejected write from source",
+				zap.String("remoteAddr", r.RemoteAddr), zap.Error(err))
+			handler.Error(w, errSourceNotAllowed, http.StatusForbidden)
+			return
+		}
+
+		if len(g.secrets) > 0 {
+			// Requests without a valid signature header are rejected before
+			// their body is read, which is bounded since it is read before
+			// the signature is verified.
+			signature, timestamp, err := g.signatureHeaders(r)
+			if err != nil {
+				g.metrics.rejectedSignature.Inc(1)
+				logger.Warn("rejected write with invalid signature",
+					zap.String("remoteAddr", r.RemoteAddr), zap.Error(err))
+				handler.Error(w, err, http.StatusUnauthorized)
+				return
+			}
+
+			body, err := ioutil.ReadAll(http.MaxBytesReader(w, r.Body, g.maxBodySize))
+			r.Body.Close()
+			if err != nil {
+				handler.Error(w, err, http.StatusRequestEntityTooLarge)
+				return
+			}
+			if err := g.checkSignature(signature, timestamp, body); err != nil {
+				g.metrics.rejectedSignature.Inc(1)
+				logger.Warn("rejected write with invalid signature",
+					zap.String("remoteAddr", r.RemoteAddr), zap.Error(err))
+				handler.Error(w, err, http.StatusUnauthorized)
+				return
+			}
+			r.Body = ioutil.NopCloser(bytes.NewReader(body))
+		}
+
+		next.ServeHTTP(w, r)
+	})
+}
+
+func (g *WriteGuard) checkSource(r *http.Request) error {
+	if len(g.allowed) == 0 {
+		return nil
+	}
+	ip, err := g.sourceIP(r)
+	if err != nil {
+		return err
+	}
+	for _, ipNet := range g.allowed {
+		if ipNet.Contains(ip) {
+			return nil
+		}
+	}
+	return errSourceNotAllowed
+}
+
+func (g *WriteGuard) sourceIP(r *http.Request) (net.IP, error) {
+	addr := r.RemoteAddr
+	if g.clientIPHeader != "" {
+		// The last address is the one added by the trusted proxy, any
+		// earlier addresses may have been set by the client.
+		values := strings.Split(r.Header.Get(g.clientIPHeader), ",")
+		addr = strings.TrimSpace(values[len(values)-1])
+	} else if host, _, err := net.SplitHostPort(addr); err == nil {
+		addr = host
+	}
+	ip := net.ParseIP(addr)
+	if ip == nil {
+		return nil, errUnknownSourceAddress
+	}
+	return ip, nil
+}
+
+// signatureHeaders returns the signature and timestamp of a request if they
+// are well formed and the timestamp is within the max age.
+func (g *WriteGuard) signatureHeaders(r *http.Request) ([]byte, string, error) {
+	header := r.Header.Get(g.header)
+	timestamp := r.Header.Get(g.timestampHeader)
+	if header == "" || timestamp == "" {
+		return nil, "", errSignatureMissing
+	}
+	if !strings.HasPrefix(header, signaturePrefix) {
+		return nil, "", errSignatureInvalid
+	}
+	signature, err := hex.DecodeString(strings.TrimPrefix(header, signaturePrefix))
+	if err != nil {
+		return nil, "", errSignatureInvalid
+	}
+
+	seconds, err := strconv.ParseInt(timestamp, 10, 64)
+	if err != nil {
+		return nil, "", errSignatureInvalid
+	}
+	age := g.nowFn().Sub(time.Unix(seconds, 0))
+	if age > g.maxAge || age < -g.maxAge {
+		return nil, "", errSignatureExpired
+	}
+	return signature, timestamp, nil
+}
+
+func (g *WriteGuard) checkSignature(signature []byte, timestamp string, body []byte) error {
+	for _, secret := range g.secrets {
+		if hmac.Equal(signature, Sign(secret, timestamp, body)) {
+			return nil
+		}
+	}
+	return errSignatureInvalid
+}
+
+// Sign returns the HMAC-SHA256 signature of a request body signed at the
+// given unix timestamp, the signature header is "sha256=" followed by the
+// hex encoded signature.
+func Sign(secret []byte, timestamp string, body []byte) []byte {
+	mac := hmac.New(sha256.New, secret)
+	mac.Write([]byte(timestamp))
+	mac.Write([]byte("."))
+	mac.Write(body)
+	return mac.Sum(nil)
+}
@@ -0,0 +1,182 @@
+// Copyright (c) 2018 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package remote
+
+import (
+	"bytes"
+	"encoding/hex"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/m3db/m3/src/query/util/logging"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/uber-go/tally"
+)
+
+func newTestGuardedHandler(
+	t *testing.T,
+	cfg WriteGuardConfiguration,
+) (http.Handler, *WriteGuard, *[]byte) {
+	logging.InitWithCores(nil)
+	guard, err := cfg.NewWriteGuard(tally.NoopScope)
+	require.NoError(t, err)
+
+	var served []byte
+	next := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, err := ioutil.ReadAll(r.Body)
+		require.NoError(t, err)
+		served = body
+	})
+	return guard.Wrap(next), guard, &served
+}
+
+func TestWriteGuardAllowedCIDRs(t *testing.T) {
+	h, _, _ := newTestGuardedHandler(t, WriteGuardConfiguration{
+		AllowedCIDRs: []string{"10.0.0.0/8", "::1/128"},
+	})
+
+	tests := []struct {
+		remoteAddr string
+		code       int
+	}{
+		{"10.1.2.3:1234", http.StatusOK},
+		{"[::1]:1234", http.StatusOK},
+		{"192.168.0.1:1234", http.StatusForbidden},
+		{"invalid", http.StatusForbidden},
+	}
+	for _, test := range tests {
+		req := httptest.NewRequest(PromWriteHTTPMethod, PromWriteURL, nil)
+		req.RemoteAddr = test.remoteAddr
+		w := httptest.NewRecorder()
+		h.ServeHTTP(w, req)
+		assert.Equal(t, test.code, w.Code, test.remoteAddr)
+	}
+}
+
+func TestWriteGuardClientIPHeader(t *testing.T) {
+	h, _, _ := newTestGuardedHandler(t, WriteGuardConfiguration{
+		AllowedCIDRs:   []string{"10.0.0.0/8"},
+		ClientIPHeader: "X-Forwarded-For",
+	})
+
+	// Only the last address, added by the trusted proxy, is used
+	req := httptest.NewRequest(PromWriteHTTPMethod, PromWriteURL, nil)
+	req.Header.Set("X-Forwarded-For", "10.0.0.1, 192.168.0.1")
+	w := httptest.NewRecorder()
+	h.ServeHTTP(w, req)
+	assert.Equal(t, http.StatusForbidden, w.Code)
+
+	req = httptest.NewRequest(PromWriteHTTPMethod, PromWriteURL, nil)
+	req.Header.Set("X-Forwarded-For", "192.168.0.1, 10.0.0.1")
+	w = httptest.NewRecorder()
+	h.ServeHTTP(w, req)
+	assert.Equal(t, http.StatusOK, w.Code)
+}
+
+func TestWriteGuardSigning(t *testing.T) {
+	h, guard, served := newTestGuardedHandler(t, WriteGuardConfiguration{
+		Signing: &SigningConfiguration{
+			Secrets: []string{"old-secret", "new-secret"},
+		},
+	})
+	now := time.Now()
+	guard.nowFn = func() time.Time { return now }
+
+	body := []byte("compressed write request")
+	signed := func(secret string, at time.Time) *http.Request {
+		timestamp := strconv.FormatInt(at.Unix(), 10)
+		req := httptest.NewRequest(PromWriteHTTPMethod, PromWriteURL,
+			bytes.NewReader(body))
+		req.Header.Set(DefaultSignatureTimestampHeader, timestamp)
+		req.Header.Set(DefaultSignatureHeader, signaturePrefix+
+			hex.EncodeToString(Sign([]byte(secret), timestamp, body)))
+		return req
+	}
+
+	tests := []struct {
+		name string
+		req  *http.Request
+		code int
+	}{
+		{"new secret", signed("new-secret", now), http.StatusOK},
+		{"old secret", signed("old-secret", now.Add(-time.Minute)), http.StatusOK},
+		{"unknown secret", signed("other", now), http.StatusUnauthorized},
+		{"expired", signed("new-secret", now.Add(-time.Hour)), http.StatusUnauthorized},
+		{"unsigned", httptest.NewRequest(PromWriteHTTPMethod, PromWriteURL,
+			bytes.NewReader(body)), http.StatusUnauthorized},
+	}
+	for _, test := range tests {
+		*served = nil
+		w := httptest.NewRecorder()
+		h.ServeHTTP(w, test.req)
+		assert.Equal(t, test.code, w.Code, test.name)
+		if test.code == http.StatusOK {
+			assert.Equal(t, body, *served, test.name)
+		}
+	}
+
+	// A tampered body does not match the signature
+	req := signed("new-secret", now)
+	req.Body = ioutil.NopCloser(bytes.NewReader([]byte("tampered")))
+	w := httptest.NewRecorder()
+	h.ServeHTTP(w, req)
+	assert.Equal(t, http.StatusUnauthorized, w.Code)
+}
+
+func TestWriteGuardSigningMaxBodySize(t *testing.T) {
+	h, guard, served := newTestGuardedHandler(t, WriteGuardConfiguration{
+		Signing: &SigningConfiguration{
+			Secrets:     []string{"secret"},
+			MaxBodySize: 8,
+		},
+	})
+	now := time.Now()
+	guard.nowFn = func() time.Time { return now }
+
+	body := []byte("larger than the max body size")
+	timestamp := strconv.FormatInt(now.Unix(), 10)
+	req := httptest.NewRequest(PromWriteHTTPMethod, PromWriteURL, bytes.NewReader(body))
+	req.Header.Set(DefaultSignatureTimestampHeader, timestamp)
+	req.Header.Set(DefaultSignatureHeader, signaturePrefix+
+		hex.EncodeToString(Sign([]byte("secret"), timestamp, body)))
+	w := httptest.NewRecorder()
+	h.ServeHTTP(w, req)
+	assert.Equal(t, http.StatusRequestEntityTooLarge, w.Code)
+	assert.Nil(t, *served)
+}
+
+func TestWriteGuardConfigurationInvalid(t *testing.T) {
+	_, err := WriteGuardConfiguration{
+		AllowedCIDRs: []string{"10.0.0.1"},
+	}.NewWriteGuard(tally.NoopScope)
+	assert.Error(t, err)
+
+	_, err = WriteGuardConfiguration{
+		Signing: &SigningConfiguration{},
+	}.NewWriteGuard(tally.NoopScope)
+	assert.Equal(t, errSigningNoSecrets, err)
+}
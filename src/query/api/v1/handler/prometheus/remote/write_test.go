@@ -59,7 +59,7 @@ func TestPromWrite(t *testing.T) {
 
 	ctrl := gomock.NewController(t)
 	storage, session := local.NewStorageAndSession(t, ctrl)
-	session.EXPECT().WriteTagged(gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any()).AnyTimes()
+	session.EXPECT().WriteTaggedTraced(gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any()).AnyTimes()
 
 	promWrite := &PromWriteHandler{store: storage}
 
@@ -70,8 +70,8 @@ func TestPromWrite(t *testing.T) {
 	r, err := promWrite.parseRequest(req)
 	require.Nil(t, err, "unable to parse request")
 
-	writeErr := promWrite.write(context.TODO(), r)
-	require.NoError(t, writeErr)
+	errs := promWrite.write(context.TODO(), r)
+	require.True(t, errs.empty())
 }
 
 func TestWriteErrorMetricCount(t *testing.T) {
@@ -79,7 +79,7 @@ func TestWriteErrorMetricCount(t *testing.T) {
 
 	ctrl := gomock.NewController(t)
 	storage, session := local.NewStorageAndSession(t, ctrl)
-	session.EXPECT().WriteTagged(gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any()).AnyTimes()
+	session.EXPECT().WriteTaggedTraced(gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any()).AnyTimes()
 
 	reporter := xmetrics.NewTestStatsReporter(xmetrics.NewTestStatsReporterOptions())
 	scope, closer := tally.NewRootScope(tally.ScopeOptions{Reporter: reporter}, time.Millisecond)
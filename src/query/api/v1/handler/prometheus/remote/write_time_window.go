@@ -0,0 +1,199 @@
+// Copyright (c) 2018 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package remote
+
+import (
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/m3db/m3/src/query/generated/proto/prompb"
+	"github.com/m3db/m3/src/query/storage"
+)
+
+// OutOfWindowAction is the action taken on samples with timestamps outside
+// of the write time window.
+type OutOfWindowAction string
+
+const (
+	// OutOfWindowReject rejects samples outside of the window with a
+	// WriteTimeWindowError.
+	OutOfWindowReject OutOfWindowAction = "reject"
+
+	// OutOfWindowClamp accepts samples outside of the window with their
+	// timestamp set to the nearest bound of the window.
+	OutOfWindowClamp OutOfWindowAction = "clamp"
+
+	// DefaultSourceHeader is the default header with the ingest source of a
+	// write request.
+	DefaultSourceHeader = "M3-Source"
+)
+
+// WriteTimeWindowConfiguration is the configuration of the window of time
+// around now that sample timestamps must fall within to be written. The
+// window should be at most the buffer past and buffer future of the
+// namespaces written to, so that samples are rejected or clamped by the
+// coordinator before being rejected by the database.
+type WriteTimeWindowConfiguration struct {
+	// BufferPast is how far before now samples are accepted.
+	BufferPast time.Duration `yaml:"bufferPast" validate:"nonzero"`
+
+	// BufferFuture is how far after now samples are accepted.
+	BufferFuture time.Duration `yaml:"bufferFuture" validate:"nonzero"`
+
+	// Action is the action taken on samples outside of the window, either
+	// reject or clamp, defaults to reject.
+	Action OutOfWindowAction `yaml:"action"`
+
+	// SourceHeader is the header with the ingest source of a write request,
+	// defaults to M3-Source.
+	SourceHeader string `yaml:"sourceHeader"`
+
+	// Sources override the action taken on the samples of ingest sources.
+	Sources []WriteTimeWindowSourceConfiguration `yaml:"sources"`
+}
+
+// WriteTimeWindowSourceConfiguration is the action taken on samples outside
+// of the write time window for an ingest source.
+type WriteTimeWindowSourceConfiguration struct {
+	// Source is the ingest source as set in the source header.
+	Source string `yaml:"source" validate:"nonzero"`
+
+	// Action is the action taken on the samples of the source outside of
+	// the window, either reject or clamp.
+	Action OutOfWindowAction `yaml:"action" validate:"nonzero"`
+}
+
+// NewWriteTimeWindow returns the configured write time window.
+func (c WriteTimeWindowConfiguration) NewWriteTimeWindow() (*WriteTimeWindow, error) {
+	w := &WriteTimeWindow{
+		bufferPast:   c.BufferPast,
+		bufferFuture: c.BufferFuture,
+		action:       OutOfWindowReject,
+		sourceHeader: c.SourceHeader,
+		sources:      make(map[string]OutOfWindowAction, len(c.Sources)),
+		nowFn:        time.Now,
+	}
+	if w.sourceHeader == "" {
+		w.sourceHeader = DefaultSourceHeader
+	}
+	if c.Action != "" {
+		if err := validateOutOfWindowAction(c.Action); err != nil {
+			return nil, err
+		}
+		w.action = c.Action
+	}
+	for _, source := range c.Sources {
+		if err := validateOutOfWindowAction(source.Action); err != nil {
+			return nil, fmt.Errorf("source %s: %v", source.Source, err)
+		}
+		w.sources[source.Source] = source.Action
+	}
+	return w, nil
+}
+
+func validateOutOfWindowAction(action OutOfWindowAction) error {
+	switch action {
+	case OutOfWindowReject, OutOfWindowClamp:
+		return nil
+	}
+	return fmt.Errorf("invalid out of window action %s, must be %s or %s",
+		action, OutOfWindowReject, OutOfWindowClamp)
+}
+
+// WriteTimeWindow rejects or clamps samples with timestamps outside of a
+// window of time around now.
+type WriteTimeWindow struct {
+	bufferPast   time.Duration
+	bufferFuture time.Duration
+	action       OutOfWindowAction
+	sourceHeader string
+	sources      map[string]OutOfWindowAction
+	nowFn        func() time.Time
+}
+
+// WriteTimeWindowError is the error of a sample with a timestamp outside of
+// the write time window.
+type WriteTimeWindowError struct {
+	Timestamp time.Time
+	Start     time.Time
+	End       time.Time
+}
+
+func (e *WriteTimeWindowError) Error() string {
+	return fmt.Sprintf("sample timestamp %s outside of write time window [%s, %s]",
+		e.Timestamp.Format(time.RFC3339Nano), e.Start.Format(time.RFC3339Nano),
+		e.End.Format(time.RFC3339Nano))
+}
+
+// sampleValidator validates the timestamps of the samples of a write
+// request, the window is fixed when the request is received so that all
+// samples of the request are validated against the same window.
+type sampleValidator struct {
+	window bool
+	start  int64
+	end    int64
+	clamp  bool
+}
+
+func newSampleValidator(w *WriteTimeWindow, r *http.Request) sampleValidator {
+	if w == nil {
+		return sampleValidator{}
+	}
+	action, ok := w.sources[r.Header.Get(w.sourceHeader)]
+	if !ok {
+		action = w.action
+	}
+	now := w.nowFn()
+	return sampleValidator{
+		window: true,
+		start:  storage.TimeToTimestamp(now.Add(-w.bufferPast)),
+		end:    storage.TimeToTimestamp(now.Add(w.bufferFuture)),
+		clamp:  action == OutOfWindowClamp,
+	}
+}
+
+// validate returns the sample to write, which is a copy of the sample with
+// its timestamp clamped to the window if it is outside of the window and
+// clamping, or the error of the sample if it cannot be written.
+func (v sampleValidator) validate(sample *prompb.Sample) (*prompb.Sample, error) {
+	if sample.Timestamp <= 0 {
+		return nil, errBadTimestamp
+	}
+	if !v.window || (sample.Timestamp >= v.start && sample.Timestamp <= v.end) {
+		return sample, nil
+	}
+	if !v.clamp {
+		return nil, &WriteTimeWindowError{
+			Timestamp: storage.TimestampToTime(sample.Timestamp),
+			Start:     storage.TimestampToTime(v.start),
+			End:       storage.TimestampToTime(v.end),
+		}
+	}
+
+	clamped := *sample
+	if clamped.Timestamp < v.start {
+		clamped.Timestamp = v.start
+	} else {
+		clamped.Timestamp = v.end
+	}
+	return &clamped, nil
+}
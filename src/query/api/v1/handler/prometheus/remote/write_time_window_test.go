@@ -0,0 +1,167 @@
+// Copyright (c) 2018 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package remote
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/m3db/m3/src/query/api/v1/handler/prometheus/remote/test/remote"
+	"github.com/m3db/m3/src/query/generated/proto/prompb"
+	"github.com/m3db/m3/src/query/storage"
+	"github.com/m3db/m3/src/query/test/local"
+	"github.com/m3db/m3/src/query/util/logging"
+
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/uber-go/tally"
+)
+
+func newTestWriteTimeWindow(t *testing.T, now time.Time) *WriteTimeWindow {
+	w, err := WriteTimeWindowConfiguration{
+		BufferPast:   10 * time.Minute,
+		BufferFuture: time.Minute,
+		Sources: []WriteTimeWindowSourceConfiguration{
+			{Source: "debug", Action: OutOfWindowClamp},
+		},
+	}.NewWriteTimeWindow()
+	require.NoError(t, err)
+	w.nowFn = func() time.Time { return now }
+	return w
+}
+
+func TestWriteTimeWindowConfigurationInvalidAction(t *testing.T) {
+	_, err := WriteTimeWindowConfiguration{
+		BufferPast:   time.Minute,
+		BufferFuture: time.Minute,
+		Action:       "drop",
+	}.NewWriteTimeWindow()
+	require.Error(t, err)
+
+	_, err = WriteTimeWindowConfiguration{
+		BufferPast:   time.Minute,
+		BufferFuture: time.Minute,
+		Sources: []WriteTimeWindowSourceConfiguration{
+			{Source: "debug", Action: "drop"},
+		},
+	}.NewWriteTimeWindow()
+	require.Error(t, err)
+}
+
+func TestSampleValidator(t *testing.T) {
+	var (
+		now    = time.Unix(1500000000, 0)
+		window = newTestWriteTimeWindow(t, now)
+		start  = storage.TimeToTimestamp(now.Add(-10 * time.Minute))
+		end    = storage.TimeToTimestamp(now.Add(time.Minute))
+		inside = &prompb.Sample{Timestamp: storage.TimeToTimestamp(now)}
+		past   = &prompb.Sample{Timestamp: start - 1}
+		future = &prompb.Sample{Timestamp: end + 1}
+	)
+
+	req := httptest.NewRequest(PromWriteHTTPMethod, PromWriteURL, nil)
+	validator := newSampleValidator(window, req)
+
+	sample, err := validator.validate(inside)
+	require.NoError(t, err)
+	assert.True(t, sample == inside)
+
+	_, err = validator.validate(&prompb.Sample{})
+	assert.Equal(t, errBadTimestamp, err)
+
+	_, err = validator.validate(past)
+	require.Error(t, err)
+	windowErr, ok := err.(*WriteTimeWindowError)
+	require.True(t, ok)
+	assert.Equal(t, storage.TimestampToTime(start), windowErr.Start)
+	assert.Equal(t, storage.TimestampToTime(end), windowErr.End)
+	assert.Equal(t, WriteErrorCauseTooFarInPast, ClassifyWriteError(err))
+
+	_, err = validator.validate(future)
+	assert.Equal(t, WriteErrorCauseTooFarInFuture, ClassifyWriteError(err))
+
+	// Samples of sources that clamp are accepted at the bounds of the window
+	// without modifying the request.
+	req.Header.Set(DefaultSourceHeader, "debug")
+	validator = newSampleValidator(window, req)
+	sample, err = validator.validate(past)
+	require.NoError(t, err)
+	assert.Equal(t, start, sample.Timestamp)
+	assert.Equal(t, start-1, past.Timestamp)
+	sample, err = validator.validate(future)
+	require.NoError(t, err)
+	assert.Equal(t, end, sample.Timestamp)
+
+	// Without a window only bad timestamps are rejected.
+	validator = newSampleValidator(nil, req)
+	sample, err = validator.validate(past)
+	require.NoError(t, err)
+	assert.True(t, sample == past)
+}
+
+func TestPromWriteTimeWindowErrorDetails(t *testing.T) {
+	logging.InitWithCores(nil)
+
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	now := time.Now()
+	store, session := local.NewStorageAndSession(t, ctrl)
+	session.EXPECT().
+		WriteTaggedTraced(gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any(),
+			gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any()).
+		Return(nil).Times(3)
+
+	handler, err := NewPromWriteHandlerWithTimeWindow(store, nil,
+		newTestWriteTimeWindow(t, now), tally.NoopScope)
+	require.NoError(t, err)
+
+	promReq := remote.GeneratePromWriteRequest()
+	tooPast := now.Add(-time.Hour).UnixNano() / int64(time.Millisecond)
+	promReq.Timeseries[1].Samples[0].Timestamp = tooPast
+	promReqBody := remote.GeneratePromWriteRequestBody(t, promReq)
+	req := httptest.NewRequest(PromWriteHTTPMethod, PromWriteURL, promReqBody)
+
+	recorder := httptest.NewRecorder()
+	handler.ServeHTTP(recorder, req)
+	require.Equal(t, http.StatusBadRequest, recorder.Code)
+
+	var resp WriteErrorResponse
+	require.NoError(t, json.Unmarshal(recorder.Body.Bytes(), &resp))
+	assert.Equal(t, map[WriteErrorCause]int{WriteErrorCauseTooFarInPast: 1}, resp.Causes)
+	require.Len(t, resp.Details, 1)
+
+	detail := resp.Details[0]
+	assert.Equal(t, 1, detail.Series)
+	require.NotNil(t, detail.Sample)
+	assert.Equal(t, 0, *detail.Sample)
+	require.NotNil(t, detail.Timestamp)
+	assert.Equal(t, tooPast, *detail.Timestamp)
+	require.NotNil(t, detail.Window)
+	assert.Equal(t, &WriteTimeBounds{
+		Start: now.Add(-10*time.Minute).UnixNano() / int64(time.Millisecond),
+		End:   now.Add(time.Minute).UnixNano() / int64(time.Millisecond),
+	}, detail.Window)
+}
@@ -0,0 +1,96 @@
+// Copyright (c) 2018 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package querylog
+
+import (
+	"errors"
+	"time"
+
+	"github.com/m3db/m3/src/query/storage"
+
+	"github.com/uber-go/tally"
+)
+
+var (
+	errNoSinks           = errors.New("query log requires a path or a namespace")
+	errInvalidSampleRate = errors.New("query log sample rate must be between 0 and 1")
+)
+
+// Configuration is the configuration of the query log.
+type Configuration struct {
+	// SampleRate is the fraction of queries that are logged, defaults to
+	// 1%. Queries that fail or hit a limit are always logged.
+	SampleRate *float64 `yaml:"sampleRate"`
+
+	// Path is the file entries are appended to as JSON lines, if set.
+	Path string `yaml:"path"`
+
+	// Namespace is the aggregated namespace entries are written to as
+	// series, if set.
+	Namespace *NamespaceConfiguration `yaml:"namespace"`
+
+	// QueueSize is the number of entries queued to be written before
+	// entries are dropped, defaults to 4096.
+	QueueSize int `yaml:"queueSize"`
+}
+
+// NamespaceConfiguration selects the aggregated namespace query log entries
+// are written to by its retention and resolution.
+type NamespaceConfiguration struct {
+	Retention  time.Duration `yaml:"retention" validate:"nonzero"`
+	Resolution time.Duration `yaml:"resolution" validate:"nonzero"`
+}
+
+// NewLogger returns the query log of the configuration, entries written to
+// a namespace are written to the storage.
+func (c Configuration) NewLogger(
+	store storage.Storage,
+	scope tally.Scope,
+) (*Logger, error) {
+	sampleRate := DefaultSampleRate
+	if c.SampleRate != nil {
+		sampleRate = *c.SampleRate
+	}
+	if sampleRate < 0 || sampleRate > 1 {
+		return nil, errInvalidSampleRate
+	}
+
+	var sinks []Sink
+	if c.Path != "" {
+		sink, err := NewFileSink(c.Path)
+		if err != nil {
+			return nil, err
+		}
+		sinks = append(sinks, sink)
+	}
+	if c.Namespace != nil {
+		sinks = append(sinks, NewNamespaceSink(store, storage.Attributes{
+			MetricsType: storage.AggregatedMetricsType,
+			Retention:   c.Namespace.Retention,
+			Resolution:  c.Namespace.Resolution,
+		}))
+	}
+	if len(sinks) == 0 {
+		return nil, errNoSinks
+	}
+
+	return NewLogger(sinks, sampleRate, c.QueueSize, scope), nil
+}
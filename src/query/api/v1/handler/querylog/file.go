@@ -0,0 +1,73 @@
+// Copyright (c) 2018 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package querylog
+
+import (
+	"bufio"
+	"encoding/json"
+	"os"
+	"sync"
+)
+
+const (
+	fileMode = 0600
+)
+
+type fileSink struct {
+	sync.Mutex
+
+	file   *os.File
+	writer *bufio.Writer
+	enc    *json.Encoder
+}
+
+// NewFileSink returns a sink that appends entries as JSON lines to the file
+// at the path, creating it if it does not exist. Unlike the audit log the
+// file is not synced after every entry, a crash may lose the last entries.
+func NewFileSink(path string) (Sink, error) {
+	file, err := os.OpenFile(path, os.O_WRONLY|os.O_APPEND|os.O_CREATE, fileMode)
+	if err != nil {
+		return nil, err
+	}
+	writer := bufio.NewWriter(file)
+	return &fileSink{file: file, writer: writer, enc: json.NewEncoder(writer)}, nil
+}
+
+func (s *fileSink) Write(entry Entry) error {
+	s.Lock()
+	defer s.Unlock()
+
+	if err := s.enc.Encode(entry); err != nil {
+		return err
+	}
+	return s.writer.Flush()
+}
+
+func (s *fileSink) Close() error {
+	s.Lock()
+	defer s.Unlock()
+
+	if err := s.writer.Flush(); err != nil {
+		s.file.Close()
+		return err
+	}
+	return s.file.Close()
+}
@@ -0,0 +1,119 @@
+// Copyright (c) 2018 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package querylog
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"unicode"
+
+	"github.com/cespare/xxhash"
+)
+
+const (
+	numberPlaceholder = "?"
+	stringPlaceholder = `"?"`
+)
+
+// Normalize returns the shape of a PromQL query or selector, with its
+// string and number literals replaced by placeholders and its whitespace
+// collapsed, so that queries which differ only in label values, thresholds
+// or formatting normalize to the same text. Metric and label names, functions
+// and durations are kept as they determine the cost of a query.
+func Normalize(query string) string {
+	var (
+		b     strings.Builder
+		space bool
+	)
+	for i := 0; i < len(query); {
+		c := query[i]
+		switch {
+		case c == '"' || c == '\'' || c == '`':
+			end := i + 1
+			for end < len(query) && query[end] != c {
+				if query[end] == '\\' && c != '`' {
+					end++
+				}
+				end++
+			}
+			writeToken(&b, &space, stringPlaceholder)
+			i = end + 1
+		case isDigit(c) || (c == '.' && i+1 < len(query) && isDigit(query[i+1])):
+			end := i + 1
+			for end < len(query) && (isIdentChar(query[end]) || query[end] == '.' ||
+				((query[end] == '+' || query[end] == '-') &&
+					(query[end-1] == 'e' || query[end-1] == 'E'))) {
+				end++
+			}
+			token := query[i:end]
+			if _, err := strconv.ParseFloat(token, 64); err == nil {
+				// Durations such as 5m are not numbers and are kept.
+				token = numberPlaceholder
+			}
+			writeToken(&b, &space, token)
+			i = end
+		case isIdentChar(c):
+			end := i + 1
+			for end < len(query) && isIdentChar(query[end]) {
+				end++
+			}
+			writeToken(&b, &space, query[i:end])
+			i = end
+		case unicode.IsSpace(rune(c)):
+			space = true
+			i++
+		default:
+			// Whitespace around operators and punctuation is not significant.
+			space = false
+			b.WriteByte(c)
+			i++
+		}
+	}
+	return b.String()
+}
+
+// writeToken writes a token, separating it from a previous token by a single
+// space if they were separated by whitespace in the query.
+func writeToken(b *strings.Builder, space *bool, token string) {
+	if *space && b.Len() > 0 {
+		last := b.String()[b.Len()-1]
+		if isIdentChar(last) || last == '?' || last == '"' {
+			b.WriteByte(' ')
+		}
+	}
+	*space = false
+	b.WriteString(token)
+}
+
+// Fingerprint returns the fingerprint of a normalized query.
+func Fingerprint(normalized string) string {
+	return fmt.Sprintf("%016x", xxhash.Sum64String(normalized))
+}
+
+func isDigit(c byte) bool {
+	return c >= '0' && c <= '9'
+}
+
+func isIdentChar(c byte) bool {
+	return c == '_' || c == ':' || isDigit(c) ||
+		(c >= 'a' && c <= 'z') || (c >= 'A' && c <= 'Z')
+}
@@ -0,0 +1,57 @@
+// Copyright (c) 2018 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package querylog
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestNormalize(t *testing.T) {
+	for _, test := range []struct {
+		query      string
+		normalized string
+	}{
+		{query: `up`, normalized: `up`},
+		{
+			query:      `sum by (job) (rate(http_requests{job="api", code=~"5.."}[5m]))`,
+			normalized: `sum by(job)(rate(http_requests{job="?",code=~"?"}[5m]))`,
+		},
+		{
+			query:      `http_requests{job='api'} > 1.5e3 and on() vector(0)`,
+			normalized: `http_requests{job="?"}>? and on()vector(?)`,
+		},
+		{query: `up offset 1h`, normalized: `up offset 1h`},
+		{query: `{job="a \" b"}`, normalized: `{job="?"}`},
+	} {
+		assert.Equal(t, test.normalized, Normalize(test.query), test.query)
+	}
+}
+
+func TestFingerprintGroupsQueriesByShape(t *testing.T) {
+	a := Fingerprint(Normalize(`http_requests{job="api"} > 10`))
+	b := Fingerprint(Normalize(`http_requests{job="web"}   >   20`))
+	c := Fingerprint(Normalize(`http_errors{job="api"} > 10`))
+	assert.Equal(t, a, b)
+	assert.NotEqual(t, a, c)
+	assert.Len(t, a, 16)
+}
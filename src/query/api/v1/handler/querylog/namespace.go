@@ -0,0 +1,98 @@
+// Copyright (c) 2018 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package querylog
+
+import (
+	"context"
+	"strconv"
+	"strings"
+
+	"github.com/m3db/m3/src/query/models"
+	"github.com/m3db/m3/src/query/storage"
+	"github.com/m3db/m3/src/query/ts"
+	xerrors "github.com/m3db/m3x/errors"
+	xtime "github.com/m3db/m3x/time"
+)
+
+const (
+	// LatencyMetric is the name of the series of query latencies in seconds.
+	LatencyMetric = "coordinator_query_log_latency_seconds"
+
+	// BytesMetric is the name of the series of query response bytes.
+	BytesMetric = "coordinator_query_log_bytes"
+
+	// SeriesMetric is the name of the series of query result series.
+	SeriesMetric = "coordinator_query_log_series"
+)
+
+type namespaceSink struct {
+	store      storage.Storage
+	attributes storage.Attributes
+}
+
+// NewNamespaceSink returns a sink that writes the latency, bytes and series
+// of each entry as datapoints of series tagged by the handler, status,
+// tenant, fingerprint and limits hit of the query, to the namespace of the
+// storage with the attributes. The storage must not be scoped to tenants
+// as the entries of all tenants are written to the same namespace.
+func NewNamespaceSink(store storage.Storage, attributes storage.Attributes) Sink {
+	return &namespaceSink{store: store, attributes: attributes}
+}
+
+func (s *namespaceSink) Write(entry Entry) error {
+	tags := models.Tags{
+		{Name: "handler", Value: entry.Handler},
+		{Name: "status", Value: strconv.Itoa(entry.Status)},
+		{Name: "fingerprint", Value: entry.Fingerprint},
+	}
+	if entry.Tenant != "" {
+		tags = append(tags, models.Tag{Name: "tenant", Value: entry.Tenant})
+	}
+	if len(entry.LimitsHit) > 0 {
+		tags = append(tags, models.Tag{
+			Name:  "limits_hit",
+			Value: strings.Join(entry.LimitsHit, ","),
+		})
+	}
+
+	var multiErr xerrors.MultiError
+	for _, metric := range []struct {
+		name  string
+		value float64
+	}{
+		{name: LatencyMetric, value: entry.LatencySeconds},
+		{name: BytesMetric, value: float64(entry.Bytes)},
+		{name: SeriesMetric, value: float64(entry.Series)},
+	} {
+		metricTags := append(models.Tags{{Name: models.MetricName, Value: metric.name}}, tags...)
+		multiErr = multiErr.Add(s.store.Write(context.Background(), &storage.WriteQuery{
+			Tags:       models.Normalize(metricTags),
+			Datapoints: ts.Datapoints{{Timestamp: entry.Time, Value: metric.value}},
+			Unit:       xtime.Millisecond,
+			Attributes: s.attributes,
+		}))
+	}
+	return multiErr.FinalError()
+}
+
+func (s *namespaceSink) Close() error {
+	return nil
+}
@@ -0,0 +1,257 @@
+// Copyright (c) 2018 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+// Package querylog logs a sample of the queries to the coordinator, with
+// their normalized text and fingerprint, tenant, latency, result sizes and
+// the limits they hit, as JSON lines or as series written to a dedicated
+// namespace so that the query load of a cluster can be analyzed with the
+// cluster itself.
+package querylog
+
+import (
+	"context"
+	"math/rand"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/m3db/m3/src/query/api/v1/handler/querymetrics"
+	"github.com/m3db/m3/src/query/storage/tenant"
+	"github.com/m3db/m3/src/query/util/logging"
+	"github.com/m3db/m3/src/query/util/querystats"
+
+	"github.com/uber-go/tally"
+	"go.uber.org/zap"
+)
+
+const (
+	// DefaultSampleRate is the default fraction of queries that are logged.
+	DefaultSampleRate = 0.01
+
+	// DefaultQueueSize is the default number of entries queued to be
+	// written to the sinks.
+	DefaultQueueSize = 4096
+)
+
+// Entry is the log entry of a single query.
+type Entry struct {
+	Time           time.Time `json:"time"`
+	Handler        string    `json:"handler"`
+	Tenant         string    `json:"tenant,omitempty"`
+	Query          string    `json:"query"`
+	Fingerprint    string    `json:"fingerprint"`
+	Status         int       `json:"status"`
+	LatencySeconds float64   `json:"latencySeconds"`
+	Bytes          int64     `json:"bytes"`
+	Series         int64     `json:"series"`
+	LimitsHit      []string  `json:"limitsHit,omitempty"`
+	TraceID        string    `json:"traceID,omitempty"`
+
+	// Sampled is true if the query was logged by sampling rather than
+	// because it failed or hit a limit, sampled entries each stand for
+	// 1/SampleRate queries.
+	Sampled    bool    `json:"sampled"`
+	SampleRate float64 `json:"sampleRate"`
+}
+
+// Sink receives query log entries.
+type Sink interface {
+	// Write records an entry.
+	Write(entry Entry) error
+
+	// Close closes the sink.
+	Close() error
+}
+
+type loggerMetrics struct {
+	logged      tally.Counter
+	dropped     tally.Counter
+	writeErrors tally.Counter
+}
+
+func newLoggerMetrics(scope tally.Scope) loggerMetrics {
+	return loggerMetrics{
+		logged:      scope.Counter("logged"),
+		dropped:     scope.Counter("dropped"),
+		writeErrors: scope.Counter("write-errors"),
+	}
+}
+
+// Logger is the middleware that logs queries.
+type Logger struct {
+	sinks        []Sink
+	sampleRate   float64
+	traceHeaders []string
+	queue        chan Entry
+	metrics      loggerMetrics
+	wg           sync.WaitGroup
+	randFn       func() float64
+	nowFn        func() time.Time
+}
+
+// NewLogger returns a logger that logs the sample rate fraction of queries,
+// along with every query that fails or hits a limit, to the sinks. Entries
+// are written in the background so that queries are not delayed by the
+// sinks and are dropped while the queue is full.
+func NewLogger(
+	sinks []Sink,
+	sampleRate float64,
+	queueSize int,
+	scope tally.Scope,
+) *Logger {
+	if queueSize <= 0 {
+		queueSize = DefaultQueueSize
+	}
+	if scope == nil {
+		scope = tally.NoopScope
+	}
+
+	l := &Logger{
+		sinks:        sinks,
+		sampleRate:   sampleRate,
+		traceHeaders: querymetrics.DefaultTraceHeaders,
+		queue:        make(chan Entry, queueSize),
+		metrics:      newLoggerMetrics(scope),
+		randFn:       rand.Float64,
+		nowFn:        time.Now,
+	}
+	l.wg.Add(1)
+	go l.run()
+	return l
+}
+
+// Wrap returns a handler that logs the queries served by the handler under
+// its name. The tenant of a query is read from the request context, where
+// it is added by the tenant middleware wrapping the route.
+func (l *Logger) Wrap(name string, next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		// Share the statistics of the query with the per query metrics if
+		// they are already recorded.
+		stats, ok := querystats.FromContext(r.Context())
+		if !ok {
+			stats = &querystats.Stats{}
+			r = r.WithContext(querystats.NewContext(r.Context(), stats))
+		}
+
+		start := l.nowFn()
+		recorder := &responseRecorder{ResponseWriter: w, status: http.StatusOK}
+		next.ServeHTTP(recorder, r)
+
+		var (
+			limitsHit = stats.LimitsHit()
+			failed    = recorder.status/100 == 5
+			sampled   = l.sampleRate > 0 && l.randFn() < l.sampleRate
+		)
+		if !sampled && !failed && len(limitsHit) == 0 {
+			return
+		}
+
+		query := Normalize(stats.Query())
+		entry := Entry{
+			Time:           start,
+			Handler:        name,
+			Query:          query,
+			Fingerprint:    Fingerprint(query),
+			Status:         recorder.status,
+			LatencySeconds: l.nowFn().Sub(start).Seconds(),
+			Bytes:          recorder.bytes,
+			Series:         stats.Series(),
+			LimitsHit:      limitsHit,
+			TraceID:        querymetrics.TraceID(r, l.traceHeaders),
+			Sampled:        sampled,
+			SampleRate:     l.sampleRate,
+		}
+		entry.Tenant, _ = tenant.FromContext(r.Context())
+		l.Log(entry)
+	})
+}
+
+// Log queues an entry to be written to the sinks, dropping it if the queue
+// is full.
+func (l *Logger) Log(entry Entry) {
+	select {
+	case l.queue <- entry:
+	default:
+		l.metrics.dropped.Inc(1)
+	}
+}
+
+func (l *Logger) run() {
+	defer l.wg.Done()
+
+	logger := logging.WithContext(context.Background())
+	for entry := range l.queue {
+		for _, sink := range l.sinks {
+			if err := sink.Write(entry); err != nil {
+				l.metrics.writeErrors.Inc(1)
+				logger.Error("unable to write query log entry", zap.Error(err))
+			}
+		}
+		l.metrics.logged.Inc(1)
+	}
+}
+
+// Close writes the queued entries and closes the sinks, queries must not
+// be served after it is closed.
+func (l *Logger) Close() error {
+	close(l.queue)
+	l.wg.Wait()
+
+	var lastErr error
+	for _, sink := range l.sinks {
+		if err := sink.Close(); err != nil {
+			lastErr = err
+		}
+	}
+	return lastErr
+}
+
+// responseRecorder records the status and number of bytes of a response.
+type responseRecorder struct {
+	http.ResponseWriter
+
+	status      int
+	wroteHeader bool
+	bytes       int64
+}
+
+func (r *responseRecorder) WriteHeader(status int) {
+	if !r.wroteHeader {
+		r.status = status
+		r.wroteHeader = true
+	}
+	r.ResponseWriter.WriteHeader(status)
+}
+
+func (r *responseRecorder) Write(b []byte) (int, error) {
+	r.wroteHeader = true
+	n, err := r.ResponseWriter.Write(b)
+	r.bytes += int64(n)
+	return n, err
+}
+
+// CloseNotify lets handlers detect clients closing connections through the
+// recorder.
+func (r *responseRecorder) CloseNotify() <-chan bool {
+	if notifier, ok := r.ResponseWriter.(http.CloseNotifier); ok {
+		return notifier.CloseNotify()
+	}
+	return make(chan bool)
+}
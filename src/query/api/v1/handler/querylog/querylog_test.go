@@ -0,0 +1,219 @@
+// Copyright (c) 2018 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package querylog
+
+import (
+	"bufio"
+	"encoding/json"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/m3db/m3/src/query/models"
+	"github.com/m3db/m3/src/query/storage"
+	"github.com/m3db/m3/src/query/storage/mock"
+	"github.com/m3db/m3/src/query/storage/tenant"
+	"github.com/m3db/m3/src/query/util/logging"
+	"github.com/m3db/m3/src/query/util/querystats"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type recordingSink struct {
+	sync.Mutex
+	entries []Entry
+}
+
+func (s *recordingSink) Write(entry Entry) error {
+	s.Lock()
+	s.entries = append(s.entries, entry)
+	s.Unlock()
+	return nil
+}
+
+func (s *recordingSink) Close() error {
+	return nil
+}
+
+func TestLoggerLogsSampledFailedAndLimitedQueries(t *testing.T) {
+	logging.InitWithCores(nil)
+
+	sink := &recordingSink{}
+	logger := NewLogger([]Sink{sink}, 0.5, 0, nil)
+
+	var sample float64
+	logger.randFn = func() float64 { return sample }
+	now := time.Unix(1500000000, 0)
+	logger.nowFn = func() time.Time {
+		now = now.Add(time.Second)
+		return now
+	}
+
+	handler := logger.Wrap("prom-native-read", http.HandlerFunc(
+		func(w http.ResponseWriter, r *http.Request) {
+			querystats.SetQuery(r.Context(), r.URL.Query().Get("query"))
+			querystats.AddSeries(r.Context(), 3)
+			switch r.URL.Query().Get("outcome") {
+			case "error":
+				w.WriteHeader(http.StatusInternalServerError)
+			case "limited":
+				querystats.AddLimitHit(r.Context(), "series-limit")
+			}
+			w.Write([]byte("result"))
+		}))
+	serve := func(query, outcome string) {
+		req := httptest.NewRequest(http.MethodGet, "/api/v1/query_range", nil)
+		params := req.URL.Query()
+		params.Set("query", query)
+		params.Set("outcome", outcome)
+		req.URL.RawQuery = params.Encode()
+		req = req.WithContext(tenant.NewContext(req.Context(), "acme"))
+		handler.ServeHTTP(httptest.NewRecorder(), req)
+	}
+
+	// Sampled in, sampled out, then failed and limited queries that are
+	// logged even though they are sampled out.
+	sample = 0.1
+	serve(`rate(http_requests{job="api"}[5m]) > 10`, "")
+	sample = 0.9
+	serve(`up`, "")
+	serve(`up`, "error")
+	serve(`up`, "limited")
+	require.NoError(t, logger.Close())
+
+	require.Len(t, sink.entries, 3)
+	sampled := sink.entries[0]
+	assert.Equal(t, "prom-native-read", sampled.Handler)
+	assert.Equal(t, "acme", sampled.Tenant)
+	assert.Equal(t, `rate(http_requests{job="?"}[5m])>?`, sampled.Query)
+	assert.Equal(t, Fingerprint(sampled.Query), sampled.Fingerprint)
+	assert.Equal(t, http.StatusOK, sampled.Status)
+	assert.Equal(t, 1.0, sampled.LatencySeconds)
+	assert.Equal(t, int64(len("result")), sampled.Bytes)
+	assert.Equal(t, int64(3), sampled.Series)
+	assert.True(t, sampled.Sampled)
+	assert.Equal(t, 0.5, sampled.SampleRate)
+
+	assert.Equal(t, http.StatusInternalServerError, sink.entries[1].Status)
+	assert.False(t, sink.entries[1].Sampled)
+	assert.Equal(t, []string{"series-limit"}, sink.entries[2].LimitsHit)
+	assert.False(t, sink.entries[2].Sampled)
+}
+
+func TestLoggerSharesQueryStats(t *testing.T) {
+	sink := &recordingSink{}
+	logger := NewLogger([]Sink{sink}, 1, 0, nil)
+
+	stats := &querystats.Stats{}
+	handler := logger.Wrap("search", http.HandlerFunc(
+		func(w http.ResponseWriter, r *http.Request) {
+			querystats.AddSeries(r.Context(), 2)
+		}))
+	req := httptest.NewRequest(http.MethodPost, "/search", nil)
+	handler.ServeHTTP(httptest.NewRecorder(),
+		req.WithContext(querystats.NewContext(req.Context(), stats)))
+	require.NoError(t, logger.Close())
+
+	assert.Equal(t, int64(2), stats.Series())
+	require.Len(t, sink.entries, 1)
+	assert.Equal(t, int64(2), sink.entries[0].Series)
+}
+
+func TestFileSinkAppendsJSONLines(t *testing.T) {
+	dir, err := ioutil.TempDir("", "querylog")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	path := filepath.Join(dir, "queries.log")
+	sink, err := NewFileSink(path)
+	require.NoError(t, err)
+	require.NoError(t, sink.Write(Entry{Handler: "search", Tenant: "acme"}))
+	require.NoError(t, sink.Write(Entry{Handler: "prom-remote-read"}))
+	require.NoError(t, sink.Close())
+
+	file, err := os.Open(path)
+	require.NoError(t, err)
+	defer file.Close()
+
+	var entries []Entry
+	scanner := bufio.NewScanner(file)
+	for scanner.Scan() {
+		var entry Entry
+		require.NoError(t, json.Unmarshal(scanner.Bytes(), &entry))
+		entries = append(entries, entry)
+	}
+	require.NoError(t, scanner.Err())
+	require.Len(t, entries, 2)
+	assert.Equal(t, "acme", entries[0].Tenant)
+	assert.Equal(t, "prom-remote-read", entries[1].Handler)
+}
+
+func TestNamespaceSinkWritesSeries(t *testing.T) {
+	store := mock.NewMockStorage()
+	attrs := storage.Attributes{
+		MetricsType: storage.AggregatedMetricsType,
+		Retention:   30 * 24 * time.Hour,
+		Resolution:  time.Minute,
+	}
+	sink := NewNamespaceSink(store, attrs)
+
+	now := time.Now()
+	require.NoError(t, sink.Write(Entry{
+		Time:           now,
+		Handler:        "prom-native-read",
+		Tenant:         "acme",
+		Fingerprint:    "0123456789abcdef",
+		Status:         http.StatusOK,
+		LatencySeconds: 0.25,
+		Bytes:          1024,
+		Series:         3,
+		LimitsHit:      []string{"series-limit"},
+	}))
+
+	writes := store.Writes()
+	require.Len(t, writes, 3)
+	values := make(map[string]float64, len(writes))
+	for _, write := range writes {
+		assert.Equal(t, attrs, write.Attributes)
+		require.Len(t, write.Datapoints, 1)
+		assert.True(t, now.Equal(write.Datapoints[0].Timestamp))
+
+		tags := make(map[string]string, len(write.Tags))
+		for _, tag := range write.Tags {
+			tags[tag.Name] = tag.Value
+		}
+		assert.Equal(t, "acme", tags["tenant"])
+		assert.Equal(t, "0123456789abcdef", tags["fingerprint"])
+		assert.Equal(t, "series-limit", tags["limits_hit"])
+		values[tags[models.MetricName]] = write.Datapoints[0].Value
+	}
+	assert.Equal(t, map[string]float64{
+		LatencyMetric: 0.25,
+		BytesMetric:   1024,
+		SeriesMetric:  3,
+	}, values)
+}
@@ -0,0 +1,58 @@
+// Copyright (c) 2018 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package querymetrics
+
+import (
+	"time"
+
+	"github.com/uber-go/tally"
+)
+
+var (
+	// DefaultTraceHeaders are the headers the trace ID of a query is read
+	// from by default, in order: W3C trace context, Jaeger and Zipkin B3.
+	DefaultTraceHeaders = []string{
+		"traceparent",
+		"uber-trace-id",
+		"X-B3-TraceId",
+	}
+)
+
+// Configuration is the configuration of the per query metrics.
+type Configuration struct {
+	// TraceHeaders are the headers the trace ID of a query is read from, in
+	// order, to attach to the metrics of the query as an exemplar. Defaults
+	// to the W3C trace context, Jaeger and Zipkin B3 headers.
+	TraceHeaders []string `yaml:"traceHeaders"`
+
+	// SlowQueryThreshold is the latency above which queries are logged with
+	// their trace ID, slow queries are not logged if not set.
+	SlowQueryThreshold time.Duration `yaml:"slowQueryThreshold"`
+}
+
+// NewMetrics returns the per query metrics of the configuration.
+func (c Configuration) NewMetrics(scope tally.Scope) *Metrics {
+	traceHeaders := c.TraceHeaders
+	if len(traceHeaders) == 0 {
+		traceHeaders = DefaultTraceHeaders
+	}
+	return NewMetrics(traceHeaders, c.SlowQueryThreshold, scope)
+}
@@ -0,0 +1,140 @@
+// Copyright (c) 2018 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package querymetrics
+
+import (
+	"math"
+	"sort"
+	"strconv"
+	"sync"
+	"time"
+)
+
+// Exemplar is a sample of a histogram with the trace of the query that
+// observed it.
+type Exemplar struct {
+	TraceID   string    `json:"traceID"`
+	Value     float64   `json:"value"`
+	Timestamp time.Time `json:"timestamp"`
+}
+
+// BucketExemplar is the latest exemplar of a histogram bucket, identified
+// by its upper bound as with Prometheus histograms.
+type BucketExemplar struct {
+	UpperBound string `json:"le"`
+	Exemplar
+}
+
+// HistogramExemplars are the latest exemplars of each bucket of a
+// histogram, latencies are in seconds.
+type HistogramExemplars struct {
+	Metric  string           `json:"metric"`
+	Handler string           `json:"handler"`
+	Status  string           `json:"status"`
+	Buckets []BucketExemplar `json:"buckets"`
+}
+
+type histogramKey struct {
+	metric  string
+	handler string
+	status  string
+}
+
+// exemplarStore keeps the latest exemplar of each bucket of each histogram,
+// so its size is bounded by the number of histograms.
+type exemplarStore struct {
+	sync.RWMutex
+
+	bounds    map[string][]float64
+	exemplars map[histogramKey][]*Exemplar
+}
+
+func newExemplarStore(bounds map[string][]float64) *exemplarStore {
+	return &exemplarStore{
+		bounds:    bounds,
+		exemplars: make(map[histogramKey][]*Exemplar),
+	}
+}
+
+func (s *exemplarStore) observe(key histogramKey, exemplar Exemplar) {
+	bounds := s.bounds[key.metric]
+	// The bucket past the last bound is the +Inf bucket.
+	idx := sort.SearchFloat64s(bounds, exemplar.Value)
+
+	s.Lock()
+	buckets, ok := s.exemplars[key]
+	if !ok {
+		buckets = make([]*Exemplar, len(bounds)+1)
+		s.exemplars[key] = buckets
+	}
+	buckets[idx] = &exemplar
+	s.Unlock()
+}
+
+// snapshot returns the exemplars of the histograms matching the metric and
+// handler, either of which matches all histograms if empty.
+func (s *exemplarStore) snapshot(metric, handler string) []HistogramExemplars {
+	s.RLock()
+	defer s.RUnlock()
+
+	result := make([]HistogramExemplars, 0, len(s.exemplars))
+	for key, buckets := range s.exemplars {
+		if metric != "" && key.metric != metric {
+			continue
+		}
+		if handler != "" && key.handler != handler {
+			continue
+		}
+
+		bounds := s.bounds[key.metric]
+		h := HistogramExemplars{
+			Metric:  key.metric,
+			Handler: key.handler,
+			Status:  key.status,
+		}
+		for i, exemplar := range buckets {
+			if exemplar == nil {
+				continue
+			}
+			upperBound := math.Inf(1)
+			if i < len(bounds) {
+				upperBound = bounds[i]
+			}
+			h.Buckets = append(h.Buckets, BucketExemplar{
+				UpperBound: strconv.FormatFloat(upperBound, 'g', -1, 64),
+				Exemplar:   *exemplar,
+			})
+		}
+		result = append(result, h)
+	}
+
+	sort.Slice(result, func(i, j int) bool {
+		a, b := result[i], result[j]
+		if a.Metric != b.Metric {
+			return a.Metric < b.Metric
+		}
+		if a.Handler != b.Handler {
+			return a.Handler < b.Handler
+		}
+		return a.Status < b.Status
+	})
+	return result
+}
@@ -0,0 +1,219 @@
+// Copyright (c) 2018 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+// Package querymetrics records the latency, response bytes and series of
+// each query to the coordinator as histograms tagged by handler and status,
+// attaching the trace ID of queries as exemplars of the histogram buckets so
+// that slow queries can be traced from their metrics.
+package querymetrics
+
+import (
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/m3db/m3/src/query/api/v1/handler"
+	"github.com/m3db/m3/src/query/util/logging"
+	"github.com/m3db/m3/src/query/util/querystats"
+
+	"github.com/uber-go/tally"
+	"go.uber.org/zap"
+)
+
+const (
+	// ExemplarsURL is the url for the query exemplars handler.
+	ExemplarsURL = handler.RoutePrefixV1 + "/query/exemplars"
+
+	// ExemplarsHTTPMethod is the HTTP method used with this resource.
+	ExemplarsHTTPMethod = http.MethodGet
+
+	// LatencyMetric is the name of the query latency histogram.
+	LatencyMetric = "query-latency"
+
+	// BytesMetric is the name of the query response bytes histogram.
+	BytesMetric = "query-bytes"
+
+	// SeriesMetric is the name of the query series histogram.
+	SeriesMetric = "query-series"
+
+	// ReplicaDivergencesMetric is the name of the counter of the blocks
+	// fetched by queries that differ between replicas.
+	ReplicaDivergencesMetric = "query-replica-divergences"
+
+	metricParam  = "metric"
+	handlerParam = "handler"
+)
+
+var (
+	latencyBuckets = tally.MustMakeExponentialDurationBuckets(time.Millisecond, 2, 16)
+	bytesBuckets   = tally.MustMakeExponentialValueBuckets(1024, 4, 12)
+	seriesBuckets  = tally.MustMakeExponentialValueBuckets(1, 4, 12)
+)
+
+// Metrics is the middleware that records the metrics of queries.
+type Metrics struct {
+	scope              tally.Scope
+	traceHeaders       []string
+	slowQueryThreshold time.Duration
+	exemplars          *exemplarStore
+	nowFn              func() time.Time
+}
+
+// NewMetrics returns a new instance of Metrics, the trace ID of each query
+// is read from the first of the trace headers that is set and queries
+// slower than the threshold are logged unless it is zero.
+func NewMetrics(
+	traceHeaders []string,
+	slowQueryThreshold time.Duration,
+	scope tally.Scope,
+) *Metrics {
+	latencyBounds := make([]float64, 0, len(latencyBuckets))
+	for _, b := range latencyBuckets {
+		latencyBounds = append(latencyBounds, b.Seconds())
+	}
+	return &Metrics{
+		scope:              scope,
+		traceHeaders:       traceHeaders,
+		slowQueryThreshold: slowQueryThreshold,
+		exemplars: newExemplarStore(map[string][]float64{
+			LatencyMetric: latencyBounds,
+			BytesMetric:   []float64(bytesBuckets),
+			SeriesMetric:  []float64(seriesBuckets),
+		}),
+		nowFn: time.Now,
+	}
+}
+
+// Wrap returns a handler that records the metrics of the queries served by
+// the handler under its name.
+func (m *Metrics) Wrap(name string, next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		start := m.nowFn()
+		stats := &querystats.Stats{}
+		recorder := &responseRecorder{ResponseWriter: w, status: http.StatusOK}
+		next.ServeHTTP(recorder, r.WithContext(querystats.NewContext(r.Context(), stats)))
+
+		var (
+			latency = m.nowFn().Sub(start)
+			status  = strconv.Itoa(recorder.status)
+			series  = stats.Series()
+			traceID = TraceID(r, m.traceHeaders)
+			scope   = m.scope.Tagged(map[string]string{
+				"handler": name,
+				"status":  status,
+			})
+		)
+		scope.Histogram(LatencyMetric, latencyBuckets).RecordDuration(latency)
+		scope.Histogram(BytesMetric, bytesBuckets).RecordValue(float64(recorder.bytes))
+		scope.Histogram(SeriesMetric, seriesBuckets).RecordValue(float64(series))
+		if divergences := stats.ReplicaDivergences(); divergences > 0 {
+			scope.Counter(ReplicaDivergencesMetric).Inc(divergences)
+		}
+
+		if traceID != "" {
+			for metric, value := range map[string]float64{
+				LatencyMetric: latency.Seconds(),
+				BytesMetric:   float64(recorder.bytes),
+				SeriesMetric:  float64(series),
+			} {
+				m.exemplars.observe(histogramKey{
+					metric:  metric,
+					handler: name,
+					status:  status,
+				}, Exemplar{
+					TraceID:   traceID,
+					Value:     value,
+					Timestamp: start,
+				})
+			}
+		}
+
+		if m.slowQueryThreshold > 0 && latency >= m.slowQueryThreshold {
+			logging.WithContext(r.Context()).Info("slow query",
+				zap.String("handler", name),
+				zap.Int("status", recorder.status),
+				zap.Duration("latency", latency),
+				zap.Int64("bytes", recorder.bytes),
+				zap.Int64("series", series),
+				zap.String("traceID", traceID),
+				zap.String("url", r.URL.RequestURI()))
+		}
+	})
+}
+
+// Exemplars returns the latest exemplars of the histograms matching the
+// metric and handler, either of which matches all histograms if empty.
+func (m *Metrics) Exemplars(metric, handler string) []HistogramExemplars {
+	return m.exemplars.snapshot(metric, handler)
+}
+
+// ExemplarsHandler is the handler for the query exemplars.
+type ExemplarsHandler struct {
+	metrics *Metrics
+}
+
+// NewExemplarsHandler returns a new instance of ExemplarsHandler.
+func NewExemplarsHandler(metrics *Metrics) *ExemplarsHandler {
+	return &ExemplarsHandler{metrics: metrics}
+}
+
+func (h *ExemplarsHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	logger := logging.WithContext(r.Context())
+
+	params := r.URL.Query()
+	handler.WriteJSONResponse(w, struct {
+		Histograms []HistogramExemplars `json:"histograms"`
+	}{
+		Histograms: h.metrics.Exemplars(params.Get(metricParam), params.Get(handlerParam)),
+	}, logger)
+}
+
+// responseRecorder records the status and number of bytes of a response.
+type responseRecorder struct {
+	http.ResponseWriter
+
+	status      int
+	wroteHeader bool
+	bytes       int64
+}
+
+func (r *responseRecorder) WriteHeader(status int) {
+	if !r.wroteHeader {
+		r.status = status
+		r.wroteHeader = true
+	}
+	r.ResponseWriter.WriteHeader(status)
+}
+
+func (r *responseRecorder) Write(b []byte) (int, error) {
+	r.wroteHeader = true
+	n, err := r.ResponseWriter.Write(b)
+	r.bytes += int64(n)
+	return n, err
+}
+
+// CloseNotify lets handlers detect clients closing connections through the
+// recorder.
+func (r *responseRecorder) CloseNotify() <-chan bool {
+	if notifier, ok := r.ResponseWriter.(http.CloseNotifier); ok {
+		return notifier.CloseNotify()
+	}
+	return make(chan bool)
+}
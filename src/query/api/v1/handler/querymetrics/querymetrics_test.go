@@ -0,0 +1,142 @@
+// Copyright (c) 2018 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package querymetrics
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/m3db/m3/src/query/util/logging"
+	"github.com/m3db/m3/src/query/util/querystats"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/uber-go/tally"
+)
+
+func TestTraceID(t *testing.T) {
+	tests := []struct {
+		name    string
+		header  string
+		value   string
+		traceID string
+	}{
+		{"w3c", "traceparent", "00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01", "4bf92f3577b34da6a3ce929d0e0e4736"},
+		{"invalid w3c", "traceparent", "4bf92f3577b34da6", ""},
+		{"jaeger", "uber-trace-id", "5f1a2b3c4d5e6f70:1a2b3c4d5e6f7081:0:1", "5f1a2b3c4d5e6f70"},
+		{"b3", "X-B3-TraceId", "80f198ee56343ba864fe8b2a57d3eff7", "80f198ee56343ba864fe8b2a57d3eff7"},
+		{"unset", "X-Other", "abc", ""},
+	}
+	for _, test := range tests {
+		req := httptest.NewRequest(http.MethodGet, "/", nil)
+		req.Header.Set(test.header, test.value)
+		assert.Equal(t, test.traceID, TraceID(req, DefaultTraceHeaders), test.name)
+	}
+}
+
+func TestMetricsWrap(t *testing.T) {
+	logging.InitWithCores(nil)
+
+	scope := tally.NewTestScope("", nil)
+	metrics := Configuration{}.NewMetrics(scope)
+	now := time.Now()
+	metrics.nowFn = func() time.Time {
+		now = now.Add(300 * time.Millisecond)
+		return now
+	}
+
+	h := metrics.Wrap("prom-native-read", http.HandlerFunc(
+		func(w http.ResponseWriter, r *http.Request) {
+			querystats.AddSeries(r.Context(), 10)
+			w.Write(make([]byte, 2000))
+		}))
+
+	req := httptest.NewRequest(http.MethodGet, "/api/v1/prom/native/read", nil)
+	req.Header.Set("uber-trace-id", "5f1a2b3c4d5e6f70:1a2b3c4d5e6f7081:0:1")
+	h.ServeHTTP(httptest.NewRecorder(), req)
+
+	histograms := scope.Snapshot().Histograms()
+	found := make(map[string]bool)
+	for _, histogram := range histograms {
+		assert.Equal(t, "prom-native-read", histogram.Tags()["handler"])
+		assert.Equal(t, "200", histogram.Tags()["status"])
+		found[histogram.Name()] = true
+	}
+	assert.Equal(t, map[string]bool{
+		LatencyMetric: true,
+		BytesMetric:   true,
+		SeriesMetric:  true,
+	}, found)
+
+	exemplars := metrics.Exemplars("", "prom-native-read")
+	require.Len(t, exemplars, 3)
+
+	expected := map[string]BucketExemplar{
+		BytesMetric:   {UpperBound: "4096", Exemplar: Exemplar{Value: 2000}},
+		LatencyMetric: {UpperBound: "0.512", Exemplar: Exemplar{Value: 0.3}},
+		SeriesMetric:  {UpperBound: "16", Exemplar: Exemplar{Value: 10}},
+	}
+	for _, h := range exemplars {
+		require.Len(t, h.Buckets, 1, h.Metric)
+		bucket := h.Buckets[0]
+		assert.Equal(t, expected[h.Metric].UpperBound, bucket.UpperBound, h.Metric)
+		assert.InDelta(t, expected[h.Metric].Value, bucket.Value, 0.001, h.Metric)
+		assert.Equal(t, "5f1a2b3c4d5e6f70", bucket.TraceID)
+	}
+
+	// Queries without a trace ID leave the exemplars as they were.
+	h.ServeHTTP(httptest.NewRecorder(),
+		httptest.NewRequest(http.MethodGet, "/api/v1/prom/native/read", nil))
+	assert.Equal(t, exemplars, metrics.Exemplars("", ""))
+}
+
+func TestExemplarsHandler(t *testing.T) {
+	logging.InitWithCores(nil)
+
+	metrics := Configuration{}.NewMetrics(tally.NoopScope)
+	h := metrics.Wrap("search", http.HandlerFunc(
+		func(w http.ResponseWriter, r *http.Request) {
+			w.WriteHeader(http.StatusBadRequest)
+		}))
+	req := httptest.NewRequest(http.MethodPost, "/api/v1/search", nil)
+	req.Header.Set("X-B3-TraceId", "80f198ee56343ba864fe8b2a57d3eff7")
+	h.ServeHTTP(httptest.NewRecorder(), req)
+
+	w := httptest.NewRecorder()
+	NewExemplarsHandler(metrics).ServeHTTP(w,
+		httptest.NewRequest(ExemplarsHTTPMethod, ExemplarsURL+"?metric="+SeriesMetric, nil))
+	require.Equal(t, http.StatusOK, w.Code)
+
+	var resp struct {
+		Histograms []HistogramExemplars `json:"histograms"`
+	}
+	require.NoError(t, json.NewDecoder(w.Body).Decode(&resp))
+	require.Len(t, resp.Histograms, 1)
+	assert.Equal(t, SeriesMetric, resp.Histograms[0].Metric)
+	assert.Equal(t, "search", resp.Histograms[0].Handler)
+	assert.Equal(t, "400", resp.Histograms[0].Status)
+	require.Len(t, resp.Histograms[0].Buckets, 1)
+	assert.Equal(t, "1", resp.Histograms[0].Buckets[0].UpperBound)
+	assert.Equal(t, "80f198ee56343ba864fe8b2a57d3eff7", resp.Histograms[0].Buckets[0].TraceID)
+}
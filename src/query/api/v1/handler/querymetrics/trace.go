@@ -0,0 +1,64 @@
+// Copyright (c) 2018 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package querymetrics
+
+import (
+	"net/http"
+	"strings"
+)
+
+const (
+	w3cTraceHeader    = "traceparent"
+	jaegerTraceHeader = "uber-trace-id"
+)
+
+// TraceID returns the trace ID of a request from the first of the headers
+// that is set, empty if none are set. The W3C trace context and Jaeger
+// headers are parsed, the values of other headers are the trace ID.
+func TraceID(r *http.Request, headers []string) string {
+	for _, header := range headers {
+		value := strings.TrimSpace(r.Header.Get(header))
+		if value == "" {
+			continue
+		}
+
+		switch strings.ToLower(header) {
+		case w3cTraceHeader:
+			// version-traceid-parentid-flags
+			parts := strings.Split(value, "-")
+			if len(parts) < 4 {
+				continue
+			}
+			value = parts[1]
+		case jaegerTraceHeader:
+			// traceid:spanid:parentid:flags
+			parts := strings.Split(value, ":")
+			if len(parts) < 4 {
+				continue
+			}
+			value = parts[0]
+		}
+		if value != "" {
+			return value
+		}
+	}
+	return ""
+}
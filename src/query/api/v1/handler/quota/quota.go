@@ -0,0 +1,154 @@
+// Copyright (c) 2018 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+// Package quota provides the endpoints to inspect the consumption of
+// tenants and set their quota limits.
+package quota
+
+import (
+	"encoding/json"
+	"net/http"
+
+	"github.com/m3db/m3/src/query/api/v1/handler"
+	"github.com/m3db/m3/src/query/storage/tenant"
+	"github.com/m3db/m3/src/query/util/logging"
+	clusterclient "github.com/m3db/m3cluster/client"
+	"github.com/m3db/m3cluster/generated/proto/commonpb"
+
+	"github.com/gorilla/mux"
+	"go.uber.org/zap"
+)
+
+const (
+	// GetURL is the url for the tenant quotas get handler.
+	GetURL = handler.RoutePrefixV1 + "/tenant/quotas"
+
+	// GetHTTPMethod is the HTTP method used with the get resource.
+	GetHTTPMethod = http.MethodGet
+
+	// SetURL is the url for the tenant quotas set handler.
+	SetURL = handler.RoutePrefixV1 + "/tenant/quotas"
+
+	// SetHTTPMethod is the HTTP method used with the set resource.
+	SetHTTPMethod = http.MethodPost
+
+	tenantParam = "tenant"
+)
+
+// GetResponse is the response of the tenant quotas get handler.
+type GetResponse struct {
+	Limits tenant.QuotaLimits      `json:"limits"`
+	Usage  map[string]tenant.Usage `json:"usage"`
+}
+
+// GetHandler is the handler for tenant consumption and quota limits.
+type GetHandler struct {
+	quotas tenant.Quotas
+}
+
+// NewGetHandler returns a new instance of GetHandler.
+func NewGetHandler(quotas tenant.Quotas) *GetHandler {
+	return &GetHandler{quotas: quotas}
+}
+
+func (h *GetHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	logger := logging.WithContext(r.Context())
+
+	resp := GetResponse{
+		Limits: h.quotas.Limits(),
+		Usage:  h.quotas.Usage(),
+	}
+
+	// Optionally only return the limits and consumption of one tenant.
+	if id := r.URL.Query().Get(tenantParam); id != "" {
+		usage := make(map[string]tenant.Usage, 1)
+		if u, ok := resp.Usage[id]; ok {
+			usage[id] = u
+		}
+		resp.Usage = usage
+		resp.Limits = tenant.QuotaLimits{
+			Default: resp.Limits.Default,
+			Tenants: map[string]tenant.Limits{id: resp.Limits.For(id)},
+		}
+	}
+
+	handler.WriteJSONResponse(w, resp, logger)
+}
+
+// SetHandler is the handler for tenant quota limit updates.
+type SetHandler struct {
+	client clusterclient.Client
+}
+
+// NewSetHandler returns a new instance of SetHandler.
+func NewSetHandler(client clusterclient.Client) *SetHandler {
+	return &SetHandler{client: client}
+}
+
+func (h *SetHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	logger := logging.WithContext(r.Context())
+
+	limits, rErr := h.parseRequest(r)
+	if rErr != nil {
+		logger.Error("unable to parse request", zap.Any("error", rErr))
+		handler.Error(w, rErr.Inner(), rErr.Code())
+		return
+	}
+
+	store, err := h.client.KV()
+	if err != nil {
+		logger.Error("unable to get kv store", zap.Any("error", err))
+		handler.Error(w, err, http.StatusInternalServerError)
+		return
+	}
+
+	protoValue := &commonpb.StringProto{Value: limits.String()}
+	if _, err := store.Set(tenant.QuotasKey, protoValue); err != nil {
+		logger.Error("unable to set tenant quotas", zap.Any("error", err))
+		handler.Error(w, err, http.StatusInternalServerError)
+		return
+	}
+
+	handler.WriteJSONResponse(w, limits, logger)
+}
+
+func (h *SetHandler) parseRequest(r *http.Request) (tenant.QuotaLimits, *handler.ParseError) {
+	defer r.Body.Close()
+
+	var limits tenant.QuotaLimits
+	d := json.NewDecoder(r.Body)
+	d.DisallowUnknownFields()
+	if err := d.Decode(&limits); err != nil {
+		return tenant.QuotaLimits{}, handler.NewParseError(err, http.StatusBadRequest)
+	}
+
+	return limits, nil
+}
+
+// RegisterRoutes registers the tenant quotas routes, limits can only be set
+// with a cluster management client.
+func RegisterRoutes(r *mux.Router, client clusterclient.Client, quotas tenant.Quotas) {
+	logged := logging.WithResponseTimeLogging
+
+	r.HandleFunc(GetURL, logged(NewGetHandler(quotas)).ServeHTTP).Methods(GetHTTPMethod)
+	if client != nil {
+		r.HandleFunc(SetURL, logged(NewSetHandler(client)).ServeHTTP).Methods(SetHTTPMethod)
+	}
+}
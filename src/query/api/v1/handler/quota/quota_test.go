@@ -0,0 +1,93 @@
+// Copyright (c) 2018 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package quota
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/m3db/m3/src/query/storage/tenant"
+	"github.com/m3db/m3/src/query/util/logging"
+	"github.com/m3db/m3cluster/client"
+	"github.com/m3db/m3cluster/kv/mem"
+
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestQuotasSetAndGet(t *testing.T) {
+	logging.InitWithCores(nil)
+
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	store := mem.NewStore()
+	mockClient := client.NewMockClient(ctrl)
+	mockClient.EXPECT().KV().Return(store, nil).AnyTimes()
+
+	quotas, err := tenant.NewQuotas(tenant.QuotaOptions{Store: store})
+	require.NoError(t, err)
+	defer quotas.Close()
+
+	w := httptest.NewRecorder()
+	req := httptest.NewRequest(SetHTTPMethod, SetURL, strings.NewReader(
+		`{"default": {"maxActiveSeries": 10}, "tenants": {"acme": {"maxQueryBytes": 100}}}`))
+	NewSetHandler(mockClient).ServeHTTP(w, req)
+	require.Equal(t, http.StatusOK, w.Code)
+
+	for i := 0; i < 100 && quotas.Limits().Default.MaxActiveSeries != 10; i++ {
+		time.Sleep(10 * time.Millisecond)
+	}
+	require.NoError(t, quotas.AllowWrite("acme", "requests", 1))
+	require.NoError(t, quotas.AllowWrite("other", "requests", 1))
+
+	w = httptest.NewRecorder()
+	req = httptest.NewRequest(GetHTTPMethod, GetURL+"?tenant=acme", nil)
+	NewGetHandler(quotas).ServeHTTP(w, req)
+	require.Equal(t, http.StatusOK, w.Code)
+
+	var resp GetResponse
+	require.NoError(t, json.NewDecoder(w.Body).Decode(&resp))
+	assert.Equal(t, tenant.QuotaLimits{
+		Default: tenant.Limits{MaxActiveSeries: 10},
+		Tenants: map[string]tenant.Limits{"acme": {MaxQueryBytes: 100}},
+	}, resp.Limits)
+	require.Len(t, resp.Usage, 1)
+	assert.Equal(t, int64(1), resp.Usage["acme"].ActiveSeries)
+}
+
+func TestQuotasSetInvalid(t *testing.T) {
+	logging.InitWithCores(nil)
+
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	w := httptest.NewRecorder()
+	req := httptest.NewRequest(SetHTTPMethod, SetURL,
+		strings.NewReader(`{"default": {"maxSeries": 10}}`))
+	NewSetHandler(client.NewMockClient(ctrl)).ServeHTTP(w, req)
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+}
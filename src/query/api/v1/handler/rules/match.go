@@ -0,0 +1,125 @@
+// Copyright (c) 2018 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package rules
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"time"
+
+	"github.com/m3db/m3/src/cmd/services/m3coordinator/downsample"
+	"github.com/m3db/m3/src/query/api/v1/handler"
+	"github.com/m3db/m3/src/query/util"
+	"github.com/m3db/m3/src/query/util/logging"
+
+	"go.uber.org/zap"
+)
+
+const (
+	// MatchURL is the url for the rules match handler.
+	MatchURL = handler.RoutePrefixV1 + "/rules/match"
+
+	// MatchHTTPMethod is the HTTP method used with this resource.
+	MatchHTTPMethod = http.MethodPost
+)
+
+var (
+	errDownsamplingNotEnabled = errors.New("downsampling is not enabled, " +
+		"no aggregated namespaces are configured")
+	errNoTags = errors.New("no tags specified")
+)
+
+// MatchRequest is a request to match a series against the downsampling rules.
+type MatchRequest struct {
+	Tags map[string]string `json:"tags"`
+	// Timestamp is the time to match the rules active at, defaults to now.
+	Timestamp string `json:"timestamp"`
+}
+
+// MatchHandler is a handler that returns the aggregations and rollups that
+// the samples of a series are downsampled with, to debug why metrics do or
+// do not appear in aggregated namespaces.
+type MatchHandler struct {
+	downsampler downsample.Downsampler
+	nowFn       func() time.Time
+}
+
+// NewMatchHandler returns a new instance of the rules match handler, the
+// downsampler is nil if downsampling is not enabled.
+func NewMatchHandler(downsampler downsample.Downsampler) http.Handler {
+	return &MatchHandler{
+		downsampler: downsampler,
+		nowFn:       time.Now,
+	}
+}
+
+func (h *MatchHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	logger := logging.WithContext(r.Context())
+
+	if h.downsampler == nil {
+		handler.Error(w, errDownsamplingNotEnabled, http.StatusNotFound)
+		return
+	}
+
+	req, rErr := h.parseRequest(r)
+	if rErr != nil {
+		logger.Error("unable to parse request", zap.Any("error", rErr))
+		handler.Error(w, rErr.Inner(), rErr.Code())
+		return
+	}
+
+	at := h.nowFn()
+	if req.Timestamp != "" {
+		var err error
+		at, err = util.ParseTimeString(req.Timestamp)
+		if err != nil {
+			handler.Error(w, err, http.StatusBadRequest)
+			return
+		}
+	}
+
+	result, err := h.downsampler.Match(req.Tags, at)
+	if err != nil {
+		logger.Error("unable to match rules", zap.Any("error", err))
+		handler.Error(w, err, http.StatusInternalServerError)
+		return
+	}
+
+	handler.WriteJSONResponse(w, result, logger)
+}
+
+func (h *MatchHandler) parseRequest(r *http.Request) (*MatchRequest, *handler.ParseError) {
+	if r.Body == nil {
+		return nil, handler.NewParseError(errNoTags, http.StatusBadRequest)
+	}
+	defer r.Body.Close()
+
+	var req MatchRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		return nil, handler.NewParseError(err, http.StatusBadRequest)
+	}
+	if len(req.Tags) == 0 {
+		return nil, handler.NewParseError(errNoTags, http.StatusBadRequest)
+	}
+
+	return &req, nil
+}
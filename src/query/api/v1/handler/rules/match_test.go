@@ -0,0 +1,108 @@
+// Copyright (c) 2018 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package rules
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/m3db/m3/src/cmd/services/m3coordinator/downsample"
+	"github.com/m3db/m3/src/query/util/logging"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type testDownsampler struct {
+	tags map[string]string
+	at   time.Time
+}
+
+func (d *testDownsampler) NewMetricsAppender() downsample.MetricsAppender {
+	return nil
+}
+
+func (d *testDownsampler) Match(
+	tags map[string]string,
+	at time.Time,
+) (downsample.MatchResult, error) {
+	d.tags, d.at = tags, at
+	return downsample.MatchResult{
+		Mappings: []downsample.MatchedPipeline{
+			{
+				Aggregations:    []string{"Max"},
+				StoragePolicies: []string{"1m:30d"},
+			},
+		},
+	}, nil
+}
+
+func TestMatchHandler(t *testing.T) {
+	logging.InitWithCores(nil)
+
+	downsampler := &testDownsampler{}
+	h := NewMatchHandler(downsampler)
+
+	body := `{"tags": {"__name__": "foo", "app": "test"}, "timestamp": "1534952005"}`
+	req := httptest.NewRequest(MatchHTTPMethod, MatchURL, strings.NewReader(body))
+	w := httptest.NewRecorder()
+	h.ServeHTTP(w, req)
+
+	require.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, map[string]string{"__name__": "foo", "app": "test"}, downsampler.tags)
+	assert.True(t, time.Unix(1534952005, 0).Equal(downsampler.at))
+
+	var result downsample.MatchResult
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &result))
+	assert.Equal(t, []downsample.MatchedPipeline{
+		{
+			Aggregations:    []string{"Max"},
+			StoragePolicies: []string{"1m:30d"},
+		},
+	}, result.Mappings)
+}
+
+func TestMatchHandlerNoTags(t *testing.T) {
+	logging.InitWithCores(nil)
+
+	h := NewMatchHandler(&testDownsampler{})
+	req := httptest.NewRequest(MatchHTTPMethod, MatchURL, strings.NewReader(`{}`))
+	w := httptest.NewRecorder()
+	h.ServeHTTP(w, req)
+
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+}
+
+func TestMatchHandlerDownsamplingNotEnabled(t *testing.T) {
+	logging.InitWithCores(nil)
+
+	h := NewMatchHandler(nil)
+	req := httptest.NewRequest(MatchHTTPMethod, MatchURL,
+		strings.NewReader(`{"tags": {"__name__": "foo"}}`))
+	w := httptest.NewRecorder()
+	h.ServeHTTP(w, req)
+
+	assert.Equal(t, http.StatusNotFound, w.Code)
+}
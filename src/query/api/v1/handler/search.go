@@ -29,6 +29,7 @@ import (
 
 	"github.com/m3db/m3/src/query/storage"
 	"github.com/m3db/m3/src/query/util/logging"
+	"github.com/m3db/m3/src/query/util/querystats"
 
 	"go.uber.org/zap"
 )
@@ -63,6 +64,13 @@ func (h *SearchHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 	opts := h.parseURLParams(r)
+	fetchControls, rErr := ParseFetchControls(r)
+	if rErr != nil {
+		Error(w, rErr.Inner(), rErr.Code())
+		return
+	}
+	opts.FetchControls = fetchControls
+	querystats.SetQuery(r.Context(), query.TagMatchers.String())
 
 	results, err := h.search(r.Context(), query, opts)
 	if err != nil {
@@ -71,6 +79,7 @@ func (h *SearchHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	querystats.AddSeries(r.Context(), len(results.Metrics))
 	WriteJSONResponse(w, results, logger)
 }
 
@@ -90,6 +99,12 @@ func (h *SearchHandler) parseBody(r *http.Request) (*storage.FetchQuery, *ParseE
 }
 
 func (h *SearchHandler) parseURLParams(r *http.Request) *storage.FetchOptions {
+	return parseLimitFetchOptions(r)
+}
+
+// parseLimitFetchOptions returns the fetch options with the limit of the
+// request, the default limit if not set or invalid.
+func parseLimitFetchOptions(r *http.Request) *storage.FetchOptions {
 	var (
 		limit int
 		err   error
@@ -0,0 +1,233 @@
+// Copyright (c) 2018 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package topology
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	nsproto "github.com/m3db/m3/src/dbnode/generated/proto/namespace"
+	"github.com/m3db/m3/src/dbnode/kvconfig"
+	"github.com/m3db/m3/src/query/util/logging"
+	"github.com/m3db/m3cluster/kv"
+	"github.com/m3db/m3cluster/services"
+	"github.com/m3db/m3metrics/generated/proto/rulepb"
+
+	"go.uber.org/zap"
+)
+
+const (
+	// PlacementEventType is the type of events for placement changes.
+	PlacementEventType = "placement"
+	// NamespaceEventType is the type of events for namespace changes.
+	NamespaceEventType = "namespace"
+	// RulesEventType is the type of events for downsampling rules changes.
+	RulesEventType = "rules"
+
+	subscriberBufferSize = 64
+)
+
+// Event is a topology change event.
+type Event struct {
+	Type      string      `json:"type"`
+	Key       string      `json:"key"`
+	Version   int         `json:"version"`
+	Deleted   bool        `json:"deleted,omitempty"`
+	Timestamp time.Time   `json:"timestamp"`
+	Data      interface{} `json:"data,omitempty"`
+}
+
+// PlacementEventData is the data of a placement change event.
+type PlacementEventData struct {
+	Instances []string `json:"instances"`
+	NumShards int      `json:"numShards"`
+	Replicas  int      `json:"replicas"`
+}
+
+// NamespaceEventData is the data of a namespace change event.
+type NamespaceEventData struct {
+	Namespaces []string `json:"namespaces"`
+}
+
+// broadcaster watches for topology changes and sends the change events
+// to all subscribers.
+type broadcaster struct {
+	sync.Mutex
+	subscribers map[chan Event]struct{}
+	nowFn       func() time.Time
+}
+
+func newBroadcaster() *broadcaster {
+	return &broadcaster{
+		subscribers: make(map[chan Event]struct{}),
+		nowFn:       time.Now,
+	}
+}
+
+// subscribe returns a channel receiving events and a function to stop
+// receiving events.
+func (b *broadcaster) subscribe() (<-chan Event, func()) {
+	ch := make(chan Event, subscriberBufferSize)
+	b.Lock()
+	b.subscribers[ch] = struct{}{}
+	b.Unlock()
+
+	return ch, func() {
+		b.Lock()
+		delete(b.subscribers, ch)
+		b.Unlock()
+	}
+}
+
+func (b *broadcaster) publish(event Event) {
+	event.Timestamp = b.nowFn()
+
+	b.Lock()
+	defer b.Unlock()
+	for ch := range b.subscribers {
+		select {
+		case ch <- event:
+		default:
+			// Drop events for subscribers that are not keeping up rather
+			// than blocking the watches for all other subscribers.
+			logging.WithContext(context.Background()).Warn("dropping topology event for slow subscriber",
+				zap.String("type", event.Type), zap.String("key", event.Key))
+		}
+	}
+}
+
+// watchKV publishes an event of the given type whenever the key changes.
+// The data function decodes the event data from the new value.
+func (b *broadcaster) watchKV(
+	store kv.Store,
+	key string,
+	eventType string,
+	dataFn func(kv.Value) (interface{}, error),
+) error {
+	watch, err := store.Watch(key)
+	if err != nil {
+		return err
+	}
+
+	go func() {
+		logger := logging.WithContext(context.Background())
+		for range watch.C() {
+			value := watch.Get()
+			if value == nil {
+				b.publish(Event{Type: eventType, Key: key, Deleted: true})
+				continue
+			}
+
+			event := Event{Type: eventType, Key: key, Version: value.Version()}
+			if dataFn != nil {
+				data, err := dataFn(value)
+				if err != nil {
+					logger.Warn("unable to decode topology event data",
+						zap.String("key", key), zap.Any("error", err))
+				}
+				event.Data = data
+			}
+			b.publish(event)
+		}
+	}()
+	return nil
+}
+
+func (b *broadcaster) watchNamespaces(store kv.Store) error {
+	return b.watchKV(store, kvconfig.NamespacesKey, NamespaceEventType,
+		func(value kv.Value) (interface{}, error) {
+			var registry nsproto.Registry
+			if err := value.Unmarshal(&registry); err != nil {
+				return nil, err
+			}
+			data := NamespaceEventData{}
+			for name := range registry.Namespaces {
+				data.Namespaces = append(data.Namespaces, name)
+			}
+			return data, nil
+		})
+}
+
+// watchRules watches the rules namespaces key and the rule set of each
+// rules namespace as it is added.
+func (b *broadcaster) watchRules(
+	store kv.Store,
+	namespacesKey string,
+	ruleSetKeyFn func(namespace []byte) string,
+) error {
+	var (
+		watchedLock sync.Mutex
+		watched     = make(map[string]struct{})
+	)
+	return b.watchKV(store, namespacesKey, RulesEventType,
+		func(value kv.Value) (interface{}, error) {
+			var namespaces rulepb.Namespaces
+			if err := value.Unmarshal(&namespaces); err != nil {
+				return nil, err
+			}
+
+			watchedLock.Lock()
+			defer watchedLock.Unlock()
+			for _, namespace := range namespaces.Namespaces {
+				key := ruleSetKeyFn([]byte(namespace.Name))
+				if _, ok := watched[key]; ok {
+					continue
+				}
+				if err := b.watchKV(store, key, RulesEventType, nil); err != nil {
+					return nil, err
+				}
+				watched[key] = struct{}{}
+			}
+			return nil, nil
+		})
+}
+
+func (b *broadcaster) watchPlacement(svcs services.Services, sid services.ServiceID) error {
+	watch, err := svcs.Watch(sid, services.NewQueryOptions())
+	if err != nil {
+		return err
+	}
+
+	key := sid.String()
+	go func() {
+		for range watch.C() {
+			service := watch.Get()
+			if service == nil {
+				b.publish(Event{Type: PlacementEventType, Key: key, Deleted: true})
+				continue
+			}
+
+			data := PlacementEventData{}
+			for _, instance := range service.Instances() {
+				data.Instances = append(data.Instances, instance.InstanceID())
+			}
+			if sharding := service.Sharding(); sharding != nil {
+				data.NumShards = sharding.NumShards()
+			}
+			if replication := service.Replication(); replication != nil {
+				data.Replicas = replication.Replicas()
+			}
+			b.publish(Event{Type: PlacementEventType, Key: key, Data: data})
+		}
+	}()
+	return nil
+}
@@ -0,0 +1,195 @@
+// Copyright (c) 2018 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package topology
+
+import (
+	"bufio"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	nsproto "github.com/m3db/m3/src/dbnode/generated/proto/namespace"
+	"github.com/m3db/m3/src/dbnode/kvconfig"
+	"github.com/m3db/m3/src/query/util/logging"
+	"github.com/m3db/m3cluster/client"
+	"github.com/m3db/m3cluster/kv/mem"
+	"github.com/m3db/m3cluster/services"
+	"github.com/m3db/m3metrics/generated/proto/rulepb"
+	"github.com/m3db/m3metrics/matcher"
+
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestBroadcasterNamespaceAndRulesEvents(t *testing.T) {
+	logging.InitWithCores(nil)
+
+	var (
+		store       = mem.NewStore()
+		b           = newBroadcaster()
+		matcherOpts = matcher.NewOptions()
+	)
+	events, unsubscribe := b.subscribe()
+	defer unsubscribe()
+
+	require.NoError(t, b.watchNamespaces(store))
+	require.NoError(t, b.watchRules(store, matcherOpts.NamespacesKey(),
+		matcherOpts.RuleSetKeyFn()))
+
+	_, err := store.Set(kvconfig.NamespacesKey, &nsproto.Registry{
+		Namespaces: map[string]*nsproto.NamespaceOptions{
+			"metrics": &nsproto.NamespaceOptions{},
+		},
+	})
+	require.NoError(t, err)
+
+	event := nextEvent(t, events)
+	assert.Equal(t, NamespaceEventType, event.Type)
+	assert.Equal(t, kvconfig.NamespacesKey, event.Key)
+	assert.Equal(t, 1, event.Version)
+	assert.Equal(t, NamespaceEventData{Namespaces: []string{"metrics"}}, event.Data)
+
+	_, err = store.Set(matcherOpts.NamespacesKey(), &rulepb.Namespaces{
+		Namespaces: []*rulepb.Namespace{&rulepb.Namespace{Name: "default"}},
+	})
+	require.NoError(t, err)
+
+	event = nextEvent(t, events)
+	assert.Equal(t, RulesEventType, event.Type)
+	assert.Equal(t, matcherOpts.NamespacesKey(), event.Key)
+
+	// Rule set changes of the rules namespace are also published
+	ruleSetKey := matcherOpts.RuleSetKeyFn()([]byte("default"))
+	_, err = store.Set(ruleSetKey, &rulepb.RuleSet{Namespace: "default"})
+	require.NoError(t, err)
+
+	event = nextEvent(t, events)
+	assert.Equal(t, RulesEventType, event.Type)
+	assert.Equal(t, ruleSetKey, event.Key)
+
+	_, err = store.Delete(kvconfig.NamespacesKey)
+	require.NoError(t, err)
+
+	event = nextEvent(t, events)
+	assert.Equal(t, NamespaceEventType, event.Type)
+	assert.True(t, event.Deleted)
+}
+
+func TestEventsHandlerStream(t *testing.T) {
+	logging.InitWithCores(nil)
+
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	placementCh := make(chan struct{}, 1)
+	mockWatch := services.NewMockWatch(ctrl)
+	mockWatch.EXPECT().C().Return(placementCh).AnyTimes()
+
+	mockServices := services.NewMockServices(ctrl)
+	mockServices.EXPECT().Watch(gomock.Any(), gomock.Any()).Return(mockWatch, nil)
+
+	store := mem.NewStore()
+	mockClient := client.NewMockClient(ctrl)
+	mockClient.EXPECT().KV().Return(store, nil).AnyTimes()
+	mockClient.EXPECT().Services(gomock.Any()).Return(mockServices, nil)
+
+	server := httptest.NewServer(NewEventsHandler(mockClient))
+	defer server.Close()
+
+	resp, err := http.Get(server.URL + "?types=namespace")
+	require.NoError(t, err)
+	defer resp.Body.Close()
+	require.Equal(t, http.StatusOK, resp.StatusCode)
+	assert.Equal(t, "text/event-stream", resp.Header.Get("Content-Type"))
+
+	_, err = store.Set(kvconfig.NamespacesKey, &nsproto.Registry{})
+	require.NoError(t, err)
+
+	reader := bufio.NewReader(resp.Body)
+	line, err := reader.ReadString('\n')
+	require.NoError(t, err)
+	assert.Equal(t, "event: namespace\n", line)
+
+	line, err = reader.ReadString('\n')
+	require.NoError(t, err)
+	require.True(t, strings.HasPrefix(line, "data: "))
+
+	var event Event
+	require.NoError(t, json.Unmarshal([]byte(strings.TrimPrefix(line, "data: ")), &event))
+	assert.Equal(t, NamespaceEventType, event.Type)
+	assert.Equal(t, 1, event.Version)
+}
+
+func TestEventsHandlerRetriesStartAfterError(t *testing.T) {
+	logging.InitWithCores(nil)
+
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockWatch := services.NewMockWatch(ctrl)
+	mockWatch.EXPECT().C().Return(make(chan struct{})).AnyTimes()
+
+	mockServices := services.NewMockServices(ctrl)
+	mockServices.EXPECT().Watch(gomock.Any(), gomock.Any()).Return(mockWatch, nil)
+
+	store := mem.NewStore()
+	mockClient := client.NewMockClient(ctrl)
+	mockClient.EXPECT().KV().Return(store, nil).Times(2)
+	gomock.InOrder(
+		mockClient.EXPECT().Services(gomock.Any()).Return(nil, errors.New("unavailable")),
+		mockClient.EXPECT().Services(gomock.Any()).Return(mockServices, nil),
+	)
+
+	h := NewEventsHandler(mockClient)
+	require.Error(t, h.start())
+
+	// The watches started by the first attempt are not started again.
+	require.NoError(t, h.start())
+	require.NoError(t, h.start())
+}
+
+func TestEventsHandlerInvalidTypes(t *testing.T) {
+	logging.InitWithCores(nil)
+
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	h := NewEventsHandler(client.NewMockClient(ctrl))
+	w := httptest.NewRecorder()
+	req := httptest.NewRequest(EventsHTTPMethod, EventsURL+"?types=unknown", nil)
+	h.ServeHTTP(w, req)
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+}
+
+func nextEvent(t *testing.T, events <-chan Event) Event {
+	select {
+	case event := <-events:
+		return event
+	case <-time.After(5 * time.Second):
+		require.FailNow(t, "timed out waiting for event")
+	}
+	return Event{}
+}
@@ -0,0 +1,208 @@
+// Copyright (c) 2018 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package topology
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+	"sync"
+
+	"github.com/m3db/m3/src/query/api/v1/handler"
+	"github.com/m3db/m3/src/query/api/v1/handler/placement"
+	"github.com/m3db/m3/src/query/util/logging"
+	clusterclient "github.com/m3db/m3cluster/client"
+	"github.com/m3db/m3cluster/services"
+	"github.com/m3db/m3metrics/matcher"
+
+	"github.com/gorilla/mux"
+	"go.uber.org/zap"
+)
+
+const (
+	// EventsURL is the url for the topology events stream handler.
+	EventsURL = handler.RoutePrefixV1 + "/topology/events"
+
+	// EventsHTTPMethod is the HTTP method used with this resource.
+	EventsHTTPMethod = http.MethodGet
+
+	typesParam = "types"
+)
+
+var (
+	errStreamingNotSupported = errors.New("streaming is not supported by the connection")
+)
+
+// EventsHandler streams topology change events as server sent events.
+type EventsHandler struct {
+	sync.Mutex
+
+	client      clusterclient.Client
+	broadcaster *broadcaster
+
+	namespacesWatched bool
+	rulesWatched      bool
+	placementWatched  bool
+}
+
+// NewEventsHandler returns a new instance of EventsHandler.
+func NewEventsHandler(client clusterclient.Client) *EventsHandler {
+	return &EventsHandler{
+		client:      client,
+		broadcaster: newBroadcaster(),
+	}
+}
+
+// start begins watching for topology changes, watches are only started
+// once the first subscriber connects. If a watch fails to start the next
+// subscriber retries starting it, watches already started are kept.
+func (h *EventsHandler) start() error {
+	h.Lock()
+	defer h.Unlock()
+
+	if h.namespacesWatched && h.rulesWatched && h.placementWatched {
+		return nil
+	}
+
+	store, err := h.client.KV()
+	if err != nil {
+		return err
+	}
+
+	if !h.namespacesWatched {
+		if err := h.broadcaster.watchNamespaces(store); err != nil {
+			return err
+		}
+		h.namespacesWatched = true
+	}
+
+	if !h.rulesWatched {
+		matcherOpts := matcher.NewOptions()
+		if err := h.broadcaster.watchRules(store, matcherOpts.NamespacesKey(),
+			matcherOpts.RuleSetKeyFn()); err != nil {
+			return err
+		}
+		h.rulesWatched = true
+	}
+
+	if !h.placementWatched {
+		svcs, err := h.client.Services(services.NewOverrideOptions())
+		if err != nil {
+			return err
+		}
+
+		sid := services.NewServiceID().
+			SetName(placement.DefaultServiceName).
+			SetEnvironment(placement.DefaultServiceEnvironment).
+			SetZone(placement.DefaultServiceZone)
+		if err := h.broadcaster.watchPlacement(svcs, sid); err != nil {
+			return err
+		}
+		h.placementWatched = true
+	}
+	return nil
+}
+
+func (h *EventsHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	logger := logging.WithContext(ctx)
+
+	flusher, ok := w.(http.Flusher)
+	if !ok {
+		handler.Error(w, errStreamingNotSupported, http.StatusInternalServerError)
+		return
+	}
+
+	types, err := parseTypes(r)
+	if err != nil {
+		handler.Error(w, err, http.StatusBadRequest)
+		return
+	}
+
+	if err := h.start(); err != nil {
+		logger.Error("unable to watch topology", zap.Any("error", err))
+		handler.Error(w, err, http.StatusInternalServerError)
+		return
+	}
+
+	events, unsubscribe := h.broadcaster.subscribe()
+	defer unsubscribe()
+
+	w.Header().Set("Content-Type", "text/event-stream")
+	w.Header().Set("Cache-Control", "no-cache")
+	w.Header().Set("Connection", "keep-alive")
+	w.WriteHeader(http.StatusOK)
+	flusher.Flush()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case event := <-events:
+			if _, ok := types[event.Type]; !ok {
+				continue
+			}
+			data, err := json.Marshal(event)
+			if err != nil {
+				logger.Error("unable to marshal topology event", zap.Any("error", err))
+				continue
+			}
+			if _, err := fmt.Fprintf(w, "event: %s\ndata: %s\n\n", event.Type, data); err != nil {
+				return
+			}
+			flusher.Flush()
+		}
+	}
+}
+
+// parseTypes returns the event types to stream, all event types are
+// streamed unless specified as a comma separated list.
+func parseTypes(r *http.Request) (map[string]struct{}, error) {
+	types := map[string]struct{}{
+		PlacementEventType: struct{}{},
+		NamespaceEventType: struct{}{},
+		RulesEventType:     struct{}{},
+	}
+
+	value := strings.TrimSpace(r.URL.Query().Get(typesParam))
+	if value == "" {
+		return types, nil
+	}
+
+	selected := make(map[string]struct{})
+	for _, t := range strings.Split(value, ",") {
+		t = strings.TrimSpace(t)
+		if _, ok := types[t]; !ok {
+			return nil, fmt.Errorf("invalid event type: %s", t)
+		}
+		selected[t] = struct{}{}
+	}
+	return selected, nil
+}
+
+// RegisterRoutes registers the topology routes
+func RegisterRoutes(r *mux.Router, client clusterclient.Client) {
+	// NB: The stream is long lived so it is not wrapped with the response
+	// time logging used by other handlers.
+	r.HandleFunc(EventsURL, NewEventsHandler(client).ServeHTTP).Methods(EventsHTTPMethod)
+}
@@ -21,24 +21,40 @@
 package httpd
 
 import (
+	"context"
 	"encoding/json"
 	"net/http"
-	"net/http/pprof"
+	"strings"
 	"time"
 
 	"github.com/m3db/m3/src/cmd/services/m3coordinator/downsample"
 	dbconfig "github.com/m3db/m3/src/cmd/services/m3dbnode/config"
 	"github.com/m3db/m3/src/cmd/services/m3query/config"
+	"github.com/m3db/m3/src/query/api/v1/audit"
+	"github.com/m3db/m3/src/query/api/v1/auth"
+	"github.com/m3db/m3/src/query/api/v1/compression"
 	"github.com/m3db/m3/src/query/api/v1/handler"
+	"github.com/m3db/m3/src/query/api/v1/handler/clusterconfig"
 	"github.com/m3db/m3/src/query/api/v1/handler/database"
+	deadletterhandler "github.com/m3db/m3/src/query/api/v1/handler/deadletter"
+	"github.com/m3db/m3/src/query/api/v1/handler/debug"
 	m3json "github.com/m3db/m3/src/query/api/v1/handler/json"
 	"github.com/m3db/m3/src/query/api/v1/handler/namespace"
 	"github.com/m3db/m3/src/query/api/v1/handler/openapi"
+	"github.com/m3db/m3/src/query/api/v1/handler/overview"
 	"github.com/m3db/m3/src/query/api/v1/handler/placement"
 	"github.com/m3db/m3/src/query/api/v1/handler/prometheus/native"
 	"github.com/m3db/m3/src/query/api/v1/handler/prometheus/remote"
+	"github.com/m3db/m3/src/query/api/v1/handler/querylog"
+	"github.com/m3db/m3/src/query/api/v1/handler/querymetrics"
+	"github.com/m3db/m3/src/query/api/v1/handler/quota"
+	"github.com/m3db/m3/src/query/api/v1/handler/rules"
+	"github.com/m3db/m3/src/query/api/v1/handler/topology"
 	"github.com/m3db/m3/src/query/executor"
+	"github.com/m3db/m3/src/query/models"
 	"github.com/m3db/m3/src/query/storage"
+	"github.com/m3db/m3/src/query/storage/deadletter"
+	"github.com/m3db/m3/src/query/storage/tenant"
 	"github.com/m3db/m3/src/query/util/logging"
 	clusterclient "github.com/m3db/m3cluster/client"
 
@@ -48,12 +64,43 @@ import (
 
 const (
 	healthURL = "/health"
-	pprofURL  = "/debug/pprof/profile"
 	routesURL = "/routes"
 )
 
 var (
 	remoteSource = map[string]string{"source": "remote"}
+
+	// publicRoutes are served without authentication when auth is enabled.
+	publicRoutes = map[string]struct{}{
+		healthURL: struct{}{},
+	}
+
+	// readRoutes and writeRoutes require the read and write roles when auth
+	// is enabled, all other routes require the admin role.
+	readRoutes = map[string]struct{}{
+		remote.PromReadURL:      struct{}{},
+		native.PromReadURL:      struct{}{},
+		handler.SearchURL:       struct{}{},
+		handler.LabelValuesURL:  struct{}{},
+		rules.MatchURL:          struct{}{},
+		openapi.URL:             struct{}{},
+		openapi.StaticURLPrefix: struct{}{},
+	}
+	writeRoutes = map[string]struct{}{
+		remote.PromWriteURL: struct{}{},
+		m3json.WriteJSONURL: struct{}{},
+	}
+
+	// tenantRoutes read or write series and require a tenant when
+	// multi-tenancy is enabled.
+	tenantRoutes = map[string]struct{}{
+		remote.PromReadURL:     struct{}{},
+		remote.PromWriteURL:    struct{}{},
+		native.PromReadURL:     struct{}{},
+		handler.SearchURL:      struct{}{},
+		handler.LabelValuesURL: struct{}{},
+		m3json.WriteJSONURL:    struct{}{},
+	}
 )
 
 // Handler represents an HTTP handler.
@@ -63,18 +110,26 @@ type Handler struct {
 	downsampler   downsample.Downsampler
 	engine        *executor.Engine
 	clusterClient clusterclient.Client
+	quotas        tenant.Quotas
+	queryLog      *querylog.Logger
+	deadLetter    *deadletter.Replayer
 	config        config.Configuration
 	embeddedDbCfg *dbconfig.DBConfiguration
 	scope         tally.Scope
 	createdAt     time.Time
 }
 
-// NewHandler returns a new instance of handler with routes.
+// NewHandler returns a new instance of handler with routes, queries are
+// not logged if the query log is nil and the dead letter queue routes are
+// not registered if the dead letter replayer is nil.
 func NewHandler(
 	storage storage.Storage,
 	downsampler downsample.Downsampler,
 	engine *executor.Engine,
 	clusterClient clusterclient.Client,
+	quotas tenant.Quotas,
+	queryLog *querylog.Logger,
+	deadLetter *deadletter.Replayer,
 	cfg config.Configuration,
 	embeddedDbCfg *dbconfig.DBConfiguration,
 	scope tally.Scope,
@@ -86,6 +141,9 @@ func NewHandler(
 		downsampler:   downsampler,
 		engine:        engine,
 		clusterClient: clusterClient,
+		quotas:        quotas,
+		queryLog:      queryLog,
+		deadLetter:    deadLetter,
 		config:        cfg,
 		embeddedDbCfg: embeddedDbCfg,
 		scope:         scope,
@@ -103,32 +161,211 @@ func (h *Handler) RegisterRoutes() error {
 
 	// Prometheus remote read/write endpoints
 	promRemoteReadHandler := remote.NewPromReadHandler(h.engine, h.scope.Tagged(remoteSource))
-	promRemoteWriteHandler, err := remote.NewPromWriteHandler(h.storage, nil, h.scope.Tagged(remoteSource))
+	var (
+		writeTimeWindow *remote.WriteTimeWindow
+		err             error
+	)
+	if h.config.WriteTimeWindow != nil {
+		writeTimeWindow, err = h.config.WriteTimeWindow.NewWriteTimeWindow()
+		if err != nil {
+			return err
+		}
+	}
+	promRemoteWriteHandler, err := remote.NewPromWriteHandlerWithTimeWindow(h.storage, nil,
+		writeTimeWindow, h.scope.Tagged(remoteSource))
 	if err != nil {
 		return err
 	}
+	if h.config.RemoteWrite != nil {
+		guard, err := h.config.RemoteWrite.NewWriteGuard(h.scope.Tagged(remoteSource))
+		if err != nil {
+			return err
+		}
+		promRemoteWriteHandler = guard.Wrap(promRemoteWriteHandler)
+	}
+
+	// Per query metrics of the read endpoints
+	var queryMetricsCfg querymetrics.Configuration
+	if h.config.QueryMetrics != nil {
+		queryMetricsCfg = *h.config.QueryMetrics
+	}
+	queryMetrics := queryMetricsCfg.NewMetrics(h.scope)
+	measured := queryMetrics.Wrap
+	if h.queryLog != nil {
+		// Queries are logged within the metrics middleware so that both
+		// share the statistics of each query.
+		measured = func(name string, next http.Handler) http.Handler {
+			return queryMetrics.Wrap(name, h.queryLog.Wrap(name, next))
+		}
+	}
+
+	// Query responses are compressed with the encoding negotiated from the
+	// Accept-Encoding header of each request
+	compressed := func(next http.Handler) http.Handler {
+		return compression.Wrap(next, compression.DefaultEncodings...)
+	}
+
+	// Default lookback of native queries by metric type
+	var lookbackCfg models.LookbackConfiguration
+	if h.config.Lookback != nil {
+		lookbackCfg = *h.config.Lookback
+	}
+	promReadHandler := native.NewPromReadHandler(h.engine, lookbackCfg.Options())
 
-	h.Router.HandleFunc(remote.PromReadURL, logged(promRemoteReadHandler).ServeHTTP).Methods(remote.PromReadHTTPMethod)
+	h.Router.HandleFunc(remote.PromReadURL, logged(measured("prom-remote-read", promRemoteReadHandler)).ServeHTTP).Methods(remote.PromReadHTTPMethod)
 	h.Router.HandleFunc(remote.PromWriteURL, logged(promRemoteWriteHandler).ServeHTTP).Methods(remote.PromWriteHTTPMethod)
-	h.Router.HandleFunc(native.PromReadURL, logged(native.NewPromReadHandler(h.engine)).ServeHTTP).Methods(native.PromReadHTTPMethod)
+	h.Router.HandleFunc(native.PromReadURL, logged(measured("prom-native-read", compressed(promReadHandler))).ServeHTTP).Methods(native.PromReadHTTPMethod)
+	h.Router.HandleFunc(querymetrics.ExemplarsURL, logged(querymetrics.NewExemplarsHandler(queryMetrics)).ServeHTTP).Methods(querymetrics.ExemplarsHTTPMethod)
 
 	// Native M3 search and write endpoints
-	h.Router.HandleFunc(handler.SearchURL, logged(handler.NewSearchHandler(h.storage)).ServeHTTP).Methods(handler.SearchHTTPMethod)
+	h.Router.HandleFunc(handler.SearchURL, logged(measured("search", compressed(handler.NewSearchHandler(h.storage)))).ServeHTTP).Methods(handler.SearchHTTPMethod)
+	h.Router.HandleFunc(handler.LabelValuesURL, logged(measured("label-values", compressed(handler.NewLabelValuesHandler(h.storage)))).ServeHTTP).Methods(handler.LabelValuesHTTPMethod)
 	h.Router.HandleFunc(m3json.WriteJSONURL, logged(m3json.NewWriteJSONHandler(h.storage)).ServeHTTP).Methods(m3json.JSONWriteHTTPMethod)
 
+	// Downsampling rules debug endpoint
+	h.Router.HandleFunc(rules.MatchURL, logged(rules.NewMatchHandler(h.downsampler)).ServeHTTP).Methods(rules.MatchHTTPMethod)
+
 	if h.clusterClient != nil {
 		placement.RegisterRoutes(h.Router, h.clusterClient, h.config)
 		namespace.RegisterRoutes(h.Router, h.clusterClient)
 		database.RegisterRoutes(h.Router, h.clusterClient, h.config, h.embeddedDbCfg)
+		clusterconfig.RegisterRoutes(h.Router, h.clusterClient)
+		topology.RegisterRoutes(h.Router, h.clusterClient)
+		overview.RegisterRoutes(h.Router, h.clusterClient)
+	}
+
+	if h.quotas != nil {
+		quota.RegisterRoutes(h.Router, h.clusterClient, h.quotas)
+	}
+
+	if h.deadLetter != nil {
+		deadletterhandler.RegisterRoutes(h.Router, h.deadLetter)
 	}
 
 	h.registerHealthEndpoints()
-	h.registerProfileEndpoints()
+	h.registerDebugEndpoints()
 	h.registerRoutesEndpoint()
 
+	if h.config.Tenancy != nil {
+		if err := h.requireTenant(*h.config.Tenancy); err != nil {
+			return err
+		}
+	}
+
+	var a *auth.Auth
+	if h.config.Auth != nil {
+		a, err = h.config.Auth.NewAuth(h.scope)
+		if err != nil {
+			return err
+		}
+		if err := h.requireRoles(a); err != nil {
+			return err
+		}
+	}
+
+	if h.config.Audit != nil {
+		var callerFn audit.CallerFn
+		if a != nil {
+			callerFn = func(r *http.Request) string {
+				identity, err := a.Authenticate(r)
+				if err != nil {
+					return ""
+				}
+				return identity.Name
+			}
+		}
+		auditor, err := h.config.Audit.NewAuditor(callerFn, h.scope)
+		if err != nil {
+			return err
+		}
+		return h.auditAdmin(auditor)
+	}
+
 	return nil
 }
 
+// requireTenant wraps the handler of each route that reads or writes series
+// to reject requests without a tenant and add the tenant to the request
+// context, which the tenant storage scopes the request to.
+func (h *Handler) requireTenant(cfg tenant.Configuration) error {
+	rejected := h.scope.SubScope("tenant").Counter("requests-rejected")
+	return h.Router.Walk(
+		func(route *mux.Route, router *mux.Router, ancestors []*mux.Route) error {
+			tmpl, err := route.GetPathTemplate()
+			if err != nil {
+				return err
+			}
+			if _, ok := tenantRoutes[tmpl]; !ok {
+				return nil
+			}
+
+			next := route.GetHandler()
+			route.Handler(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				id, err := tenant.FromRequest(r, cfg)
+				if err != nil {
+					rejected.Inc(1)
+					handler.Error(w, err, http.StatusUnauthorized)
+					return
+				}
+				next.ServeHTTP(w, r.WithContext(tenant.NewContext(r.Context(), id)))
+			}))
+			return nil
+		})
+}
+
+// requireRoles wraps the handler of each route to require the role of the
+// route, the routes must all be registered first.
+func (h *Handler) requireRoles(a *auth.Auth) error {
+	return h.Router.Walk(
+		func(route *mux.Route, router *mux.Router, ancestors []*mux.Route) error {
+			tmpl, err := route.GetPathTemplate()
+			if err != nil {
+				return err
+			}
+			if _, ok := publicRoutes[tmpl]; ok {
+				return nil
+			}
+
+			role := auth.RoleAdmin
+			if _, ok := readRoutes[tmpl]; ok {
+				role = auth.RoleRead
+			} else if _, ok := writeRoutes[tmpl]; ok {
+				role = auth.RoleWrite
+			}
+			route.Handler(a.Require(role, route.GetHandler()))
+			return nil
+		})
+}
+
+// auditAdmin wraps the handler of each admin route to record its mutating
+// calls, and all calls of the debug routes, including those rejected by
+// auth, the routes must all be registered first.
+func (h *Handler) auditAdmin(auditor *audit.Auditor) error {
+	return h.Router.Walk(
+		func(route *mux.Route, router *mux.Router, ancestors []*mux.Route) error {
+			tmpl, err := route.GetPathTemplate()
+			if err != nil {
+				return err
+			}
+			if _, ok := publicRoutes[tmpl]; ok {
+				return nil
+			}
+			if _, ok := readRoutes[tmpl]; ok {
+				return nil
+			}
+			if _, ok := writeRoutes[tmpl]; ok {
+				return nil
+			}
+			if strings.HasPrefix(tmpl, debug.URLPrefix) {
+				// Debug reads expose the internals of the service.
+				route.Handler(auditor.WrapAll(route.GetHandler()))
+				return nil
+			}
+			route.Handler(auditor.Wrap(route.GetHandler()))
+			return nil
+		})
+}
+
 // Endpoints useful for profiling the service
 func (h *Handler) registerHealthEndpoints() {
 	h.Router.HandleFunc(healthURL, func(w http.ResponseWriter, r *http.Request) {
@@ -140,9 +377,19 @@ func (h *Handler) registerHealthEndpoints() {
 	}).Methods(http.MethodGet)
 }
 
-// Endpoints useful for profiling the service
-func (h *Handler) registerProfileEndpoints() {
-	h.Router.HandleFunc(pprofURL, pprof.Profile)
+// Endpoints useful for profiling and debugging the service, these are served
+// without auth unless disallowed since they expose its internals.
+func (h *Handler) registerDebugEndpoints() {
+	var cfg debug.Configuration
+	if h.config.Debug != nil {
+		cfg = *h.config.Debug
+	}
+	if h.config.Auth == nil && !cfg.AllowUnauthenticatedOrDefault() {
+		logging.WithContext(context.Background()).Info(
+			"debug endpoints disabled without auth by debug.allowUnauthenticated")
+		return
+	}
+	debug.RegisterRoutes(h.Router, cfg, h.config, h.scope)
 }
 
 // Endpoints useful for viewing routes directory
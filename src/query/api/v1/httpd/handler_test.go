@@ -22,18 +22,28 @@ package httpd
 
 import (
 	"encoding/json"
+	"io/ioutil"
 	"net/http"
 	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
 	"testing"
 	"time"
 
 	"github.com/m3db/m3/src/cmd/services/m3query/config"
+	"github.com/m3db/m3/src/query/api/v1/audit"
+	"github.com/m3db/m3/src/query/api/v1/auth"
+	"github.com/m3db/m3/src/query/api/v1/handler/debug"
 	m3json "github.com/m3db/m3/src/query/api/v1/handler/json"
 	"github.com/m3db/m3/src/query/api/v1/handler/prometheus/native"
 	"github.com/m3db/m3/src/query/api/v1/handler/prometheus/remote"
+	"github.com/m3db/m3/src/query/api/v1/handler/quota"
 	"github.com/m3db/m3/src/query/executor"
+	"github.com/m3db/m3/src/query/storage/tenant"
 	"github.com/m3db/m3/src/query/test/local"
 	"github.com/m3db/m3/src/query/util/logging"
+	"github.com/m3db/m3cluster/client"
 
 	"github.com/golang/mock/gomock"
 	"github.com/stretchr/testify/assert"
@@ -49,7 +59,7 @@ func TestPromRemoteReadGet(t *testing.T) {
 	ctrl := gomock.NewController(t)
 	storage, _ := local.NewStorageAndSession(t, ctrl)
 
-	h, err := NewHandler(storage, nil, executor.NewEngine(storage), nil,
+	h, err := NewHandler(storage, nil, executor.NewEngine(storage), nil, nil, nil, nil,
 		config.Configuration{}, nil, tally.NewTestScope("", nil))
 	require.NoError(t, err, "unable to setup handler")
 	err = h.RegisterRoutes()
@@ -66,7 +76,7 @@ func TestPromRemoteReadPost(t *testing.T) {
 	ctrl := gomock.NewController(t)
 	storage, _ := local.NewStorageAndSession(t, ctrl)
 
-	h, err := NewHandler(storage, nil, executor.NewEngine(storage), nil,
+	h, err := NewHandler(storage, nil, executor.NewEngine(storage), nil, nil, nil, nil,
 		config.Configuration{}, nil, tally.NewTestScope("", nil))
 	require.NoError(t, err, "unable to setup handler")
 	err = h.RegisterRoutes()
@@ -83,7 +93,7 @@ func TestPromNativeReadGet(t *testing.T) {
 	ctrl := gomock.NewController(t)
 	storage, _ := local.NewStorageAndSession(t, ctrl)
 
-	h, err := NewHandler(storage, nil, executor.NewEngine(storage), nil,
+	h, err := NewHandler(storage, nil, executor.NewEngine(storage), nil, nil, nil, nil,
 		config.Configuration{}, nil, tally.NewTestScope("", nil))
 	require.NoError(t, err, "unable to setup handler")
 	h.RegisterRoutes()
@@ -99,7 +109,7 @@ func TestPromNativeReadPost(t *testing.T) {
 	ctrl := gomock.NewController(t)
 	storage, _ := local.NewStorageAndSession(t, ctrl)
 
-	h, err := NewHandler(storage, nil, executor.NewEngine(storage), nil,
+	h, err := NewHandler(storage, nil, executor.NewEngine(storage), nil, nil, nil, nil,
 		config.Configuration{}, nil, tally.NewTestScope("", nil))
 	require.NoError(t, err, "unable to setup handler")
 	h.RegisterRoutes()
@@ -115,7 +125,7 @@ func TestJSONWritePost(t *testing.T) {
 	ctrl := gomock.NewController(t)
 	storage, _ := local.NewStorageAndSession(t, ctrl)
 
-	h, err := NewHandler(storage, nil, executor.NewEngine(storage), nil,
+	h, err := NewHandler(storage, nil, executor.NewEngine(storage), nil, nil, nil, nil,
 		config.Configuration{}, nil, tally.NewTestScope("", nil))
 	require.NoError(t, err, "unable to setup handler")
 	h.RegisterRoutes()
@@ -131,7 +141,7 @@ func TestRoutesGet(t *testing.T) {
 	ctrl := gomock.NewController(t)
 	storage, _ := local.NewStorageAndSession(t, ctrl)
 
-	h, err := NewHandler(storage, nil, executor.NewEngine(storage), nil,
+	h, err := NewHandler(storage, nil, executor.NewEngine(storage), nil, nil, nil, nil,
 		config.Configuration{}, nil, tally.NewTestScope("", nil))
 	require.NoError(t, err, "unable to setup handler")
 	h.RegisterRoutes()
@@ -164,7 +174,7 @@ func TestHealthGet(t *testing.T) {
 	ctrl := gomock.NewController(t)
 	storage, _ := local.NewStorageAndSession(t, ctrl)
 
-	h, err := NewHandler(storage, nil, executor.NewEngine(storage), nil,
+	h, err := NewHandler(storage, nil, executor.NewEngine(storage), nil, nil, nil, nil,
 		config.Configuration{}, nil, tally.NewTestScope("", nil))
 	require.NoError(t, err, "unable to setup handler")
 	h.RegisterRoutes()
@@ -185,3 +195,190 @@ func TestHealthGet(t *testing.T) {
 
 	assert.True(t, result > 0)
 }
+
+func TestAuthRequiredRoles(t *testing.T) {
+	logging.InitWithCores(nil)
+
+	ctrl := gomock.NewController(t)
+	storage, _ := local.NewStorageAndSession(t, ctrl)
+
+	cfg := config.Configuration{
+		Auth: &auth.Configuration{
+			Tokens: []auth.TokenConfiguration{
+				{Name: "reader", Token: "read-token", Roles: []auth.Role{auth.RoleRead}},
+			},
+		},
+	}
+	h, err := NewHandler(storage, nil, executor.NewEngine(storage), nil, nil, nil, nil,
+		cfg, nil, tally.NewTestScope("", nil))
+	require.NoError(t, err, "unable to setup handler")
+	require.NoError(t, h.RegisterRoutes(), "unable to register routes")
+
+	serve := func(method, url, token string) int {
+		req := httptest.NewRequest(method, url, nil)
+		if token != "" {
+			req.Header.Set("Authorization", "Bearer "+token)
+		}
+		res := httptest.NewRecorder()
+		h.Router.ServeHTTP(res, req)
+		return res.Code
+	}
+
+	// Health checks do not require a token.
+	assert.Equal(t, http.StatusOK, serve(http.MethodGet, healthURL, ""))
+
+	// Reads require the read role.
+	assert.Equal(t, http.StatusUnauthorized, serve(http.MethodGet, native.PromReadURL, ""))
+	code := serve(http.MethodGet, native.PromReadURL, "read-token")
+	assert.NotEqual(t, http.StatusUnauthorized, code)
+	assert.NotEqual(t, http.StatusForbidden, code)
+
+	// Writes and admin routes require the write and admin roles.
+	assert.Equal(t, http.StatusForbidden, serve(http.MethodPost, m3json.WriteJSONURL, "read-token"))
+	assert.Equal(t, http.StatusForbidden, serve(http.MethodGet, routesURL, "read-token"))
+}
+
+func TestTenantRequired(t *testing.T) {
+	logging.InitWithCores(nil)
+
+	ctrl := gomock.NewController(t)
+	storage, _ := local.NewStorageAndSession(t, ctrl)
+
+	cfg := config.Configuration{Tenancy: &tenant.Configuration{}}
+	h, err := NewHandler(storage, nil, executor.NewEngine(storage), nil, nil, nil, nil,
+		cfg, nil, tally.NewTestScope("", nil))
+	require.NoError(t, err, "unable to setup handler")
+	require.NoError(t, h.RegisterRoutes(), "unable to register routes")
+
+	// Routes that read or write series require a tenant.
+	req := httptest.NewRequest(http.MethodPost, m3json.WriteJSONURL, nil)
+	res := httptest.NewRecorder()
+	h.Router.ServeHTTP(res, req)
+	assert.Equal(t, http.StatusUnauthorized, res.Code)
+
+	req = httptest.NewRequest(http.MethodPost, m3json.WriteJSONURL, nil)
+	req.Header.Set(tenant.DefaultHeader, "acme")
+	res = httptest.NewRecorder()
+	h.Router.ServeHTTP(res, req)
+	assert.NotEqual(t, http.StatusUnauthorized, res.Code)
+
+	// Other routes do not.
+	req = httptest.NewRequest(http.MethodGet, healthURL, nil)
+	res = httptest.NewRecorder()
+	h.Router.ServeHTTP(res, req)
+	assert.Equal(t, http.StatusOK, res.Code)
+}
+
+func TestAuditAdminCalls(t *testing.T) {
+	logging.InitWithCores(nil)
+
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+	storage, _ := local.NewStorageAndSession(t, ctrl)
+
+	dir, err := ioutil.TempDir("", "audit")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	quotas, err := tenant.NewQuotas(tenant.QuotaOptions{})
+	require.NoError(t, err)
+	defer quotas.Close()
+
+	path := filepath.Join(dir, "audit.log")
+	cfg := config.Configuration{
+		Auth: &auth.Configuration{
+			Tokens: []auth.TokenConfiguration{
+				{Name: "reader", Token: "read-token", Roles: []auth.Role{auth.RoleRead}},
+				{Name: "operator", Token: "admin-token", Roles: []auth.Role{auth.RoleAdmin}},
+			},
+		},
+		Audit: &audit.Configuration{Path: path},
+	}
+	h, err := NewHandler(storage, nil, executor.NewEngine(storage),
+		client.NewMockClient(ctrl), quotas, nil, nil, cfg, nil, tally.NewTestScope("", nil))
+	require.NoError(t, err, "unable to setup handler")
+	require.NoError(t, h.RegisterRoutes(), "unable to register routes")
+
+	serve := func(method, url, token string) int {
+		req := httptest.NewRequest(method, url, strings.NewReader("{"))
+		req.Header.Set("Authorization", "Bearer "+token)
+		res := httptest.NewRecorder()
+		h.Router.ServeHTTP(res, req)
+		return res.Code
+	}
+
+	// Reads, writes and admin reads are not audited, mutating admin calls
+	// are whether or not they are allowed.
+	serve(http.MethodPost, m3json.WriteJSONURL, "admin-token")
+	serve(http.MethodGet, quota.GetURL, "admin-token")
+	assert.Equal(t, http.StatusForbidden, serve(http.MethodPost, quota.SetURL, "read-token"))
+	assert.Equal(t, http.StatusBadRequest, serve(http.MethodPost, quota.SetURL, "admin-token"))
+
+	data, err := ioutil.ReadFile(path)
+	require.NoError(t, err)
+	lines := strings.Split(strings.TrimSpace(string(data)), "\n")
+	require.Len(t, lines, 2)
+
+	var events [2]audit.Event
+	for i, line := range lines {
+		require.NoError(t, json.Unmarshal([]byte(line), &events[i]))
+		assert.Equal(t, quota.SetURL, events[i].Path)
+		assert.Equal(t, "{", events[i].Body)
+	}
+	assert.Equal(t, "reader", events[0].Caller)
+	assert.Equal(t, http.StatusForbidden, events[0].Status)
+	assert.Equal(t, "operator", events[1].Caller)
+	assert.Equal(t, http.StatusBadRequest, events[1].Status)
+}
+
+func TestDebugRoutes(t *testing.T) {
+	logging.InitWithCores(nil)
+
+	ctrl := gomock.NewController(t)
+	storage, _ := local.NewStorageAndSession(t, ctrl)
+
+	serve := func(h *Handler, token string) int {
+		req := httptest.NewRequest(debug.HTTPMethod, debug.BundleURL, nil)
+		if token != "" {
+			req.Header.Set("Authorization", "Bearer "+token)
+		}
+		res := httptest.NewRecorder()
+		h.Router.ServeHTTP(res, req)
+		return res.Code
+	}
+
+	// Debug routes are served without auth unless disallowed.
+	h, err := NewHandler(storage, nil, executor.NewEngine(storage), nil, nil, nil, nil,
+		config.Configuration{}, nil, tally.NewTestScope("", nil))
+	require.NoError(t, err, "unable to setup handler")
+	require.NoError(t, h.RegisterRoutes(), "unable to register routes")
+	assert.Equal(t, http.StatusOK, serve(h, ""))
+
+	disallowed := false
+	h, err = NewHandler(storage, nil, executor.NewEngine(storage), nil, nil, nil, nil,
+		config.Configuration{Debug: &debug.Configuration{AllowUnauthenticated: &disallowed}},
+		nil, tally.NewTestScope("", nil))
+	require.NoError(t, err, "unable to setup handler")
+	require.NoError(t, h.RegisterRoutes(), "unable to register routes")
+	assert.Equal(t, http.StatusNotFound, serve(h, ""))
+
+	// Otherwise they require the admin role and are rate limited.
+	cfg := config.Configuration{
+		Auth: &auth.Configuration{
+			Tokens: []auth.TokenConfiguration{
+				{Name: "reader", Token: "read-token", Roles: []auth.Role{auth.RoleRead}},
+				{Name: "operator", Token: "admin-token", Roles: []auth.Role{auth.RoleAdmin}},
+			},
+		},
+		Debug: &debug.Configuration{RequestsPerMinute: 1, Burst: 1},
+	}
+	h, err = NewHandler(storage, nil, executor.NewEngine(storage), nil, nil, nil, nil,
+		cfg, nil, tally.NewTestScope("", nil))
+	require.NoError(t, err, "unable to setup handler")
+	require.NoError(t, h.RegisterRoutes(), "unable to register routes")
+
+	assert.Equal(t, http.StatusUnauthorized, serve(h, ""))
+	assert.Equal(t, http.StatusForbidden, serve(h, "read-token"))
+	assert.Equal(t, http.StatusOK, serve(h, "admin-token"))
+	assert.Equal(t, http.StatusTooManyRequests, serve(h, "admin-token"))
+}
@@ -0,0 +1,313 @@
+// Copyright (c) 2018 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+// Package canary writes heartbeat series to each cluster namespace and
+// queries them back to measure the end-to-end latency from a write being
+// accepted to it being queryable.
+package canary
+
+import (
+	"sync"
+	"time"
+
+	"github.com/m3db/m3/src/dbnode/storage/index"
+	"github.com/m3db/m3/src/m3ninx/idx"
+	"github.com/m3db/m3/src/query/models"
+	"github.com/m3db/m3/src/query/storage"
+	"github.com/m3db/m3/src/query/storage/local"
+	"github.com/m3db/m3x/ident"
+	xtime "github.com/m3db/m3x/time"
+
+	"github.com/uber-go/tally"
+	"go.uber.org/zap"
+)
+
+const (
+	// DefaultMetricName is the default metric name of the heartbeat series.
+	DefaultMetricName = "coordinator_canary_heartbeat"
+
+	// InstanceTag is the tag of heartbeat series with the instance that
+	// wrote them.
+	InstanceTag = "instance"
+
+	defaultInterval      = 10 * time.Second
+	defaultPollInterval  = time.Second
+	defaultTimeout       = 2 * time.Minute
+	defaultLatencyTarget = 30 * time.Second
+)
+
+// Options is the set of options for the canary.
+type Options struct {
+	// Interval is the interval heartbeats are written at.
+	Interval time.Duration
+
+	// PollInterval is the interval pending heartbeats are queried at, which
+	// is the precision of the measured latency.
+	PollInterval time.Duration
+
+	// Timeout is the time after which a heartbeat that is not queryable is
+	// counted as missing.
+	Timeout time.Duration
+
+	// LatencyTarget is the latency objective, heartbeats that are not
+	// queryable within it are counted as violating the objective.
+	LatencyTarget time.Duration
+
+	// MetricName is the metric name of the heartbeat series.
+	MetricName string
+
+	// Instance is the value of the instance tag of the heartbeat series,
+	// which keeps the heartbeats of each coordinator apart.
+	Instance string
+
+	// Scope is the metrics scope.
+	Scope tally.Scope
+
+	// Logger is the logger.
+	Logger *zap.Logger
+}
+
+// Canary writes a heartbeat to each cluster namespace at an interval and
+// polls for it until it is queryable or times out.
+type Canary struct {
+	namespaces []*namespaceCanary
+	opts       Options
+	closeCh    chan struct{}
+	wg         sync.WaitGroup
+}
+
+// New returns a canary of the cluster namespaces, it must be started to
+// write heartbeats.
+func New(namespaces local.ClusterNamespaces, opts Options) *Canary {
+	if opts.Interval <= 0 {
+		opts.Interval = defaultInterval
+	}
+	if opts.PollInterval <= 0 {
+		opts.PollInterval = defaultPollInterval
+	}
+	if opts.Timeout <= 0 {
+		opts.Timeout = defaultTimeout
+	}
+	if opts.LatencyTarget <= 0 {
+		opts.LatencyTarget = defaultLatencyTarget
+	}
+	if opts.MetricName == "" {
+		opts.MetricName = DefaultMetricName
+	}
+	if opts.Scope == nil {
+		opts.Scope = tally.NoopScope
+	}
+	if opts.Logger == nil {
+		opts.Logger = zap.NewNop()
+	}
+
+	c := &Canary{opts: opts, closeCh: make(chan struct{})}
+	for _, namespace := range namespaces {
+		c.namespaces = append(c.namespaces, newNamespaceCanary(namespace, opts))
+	}
+	return c
+}
+
+// Start starts writing and polling for heartbeats in the background.
+func (c *Canary) Start() {
+	for _, n := range c.namespaces {
+		n := n
+		c.wg.Add(1)
+		go func() {
+			c.run(n)
+			c.wg.Done()
+		}()
+	}
+}
+
+func (c *Canary) run(n *namespaceCanary) {
+	writeTicker := time.NewTicker(c.opts.Interval)
+	defer writeTicker.Stop()
+	pollTicker := time.NewTicker(c.opts.PollInterval)
+	defer pollTicker.Stop()
+
+	for {
+		select {
+		case <-c.closeCh:
+			return
+		case now := <-writeTicker.C:
+			n.write(now)
+		case now := <-pollTicker.C:
+			n.poll(now)
+		}
+	}
+}
+
+// Close stops writing heartbeats, heartbeats pending at close are neither
+// counted as queryable nor as missing.
+func (c *Canary) Close() error {
+	close(c.closeCh)
+	c.wg.Wait()
+	return nil
+}
+
+type namespaceCanary struct {
+	namespace local.ClusterNamespace
+	id        ident.ID
+	tags      models.Tags
+	query     index.Query
+	opts      Options
+
+	// pending are the timestamps of the heartbeats written and not yet
+	// queryable, oldest first. Only accessed by the run loop.
+	pending []time.Time
+
+	metrics namespaceCanaryMetrics
+}
+
+func newNamespaceCanary(
+	namespace local.ClusterNamespace,
+	opts Options,
+) *namespaceCanary {
+	tags := models.Normalize(models.Tags{
+		{Name: models.MetricName, Value: opts.MetricName},
+		{Name: InstanceTag, Value: opts.Instance},
+	})
+	terms := make([]idx.Query, 0, len(tags))
+	for _, tag := range tags {
+		terms = append(terms, idx.NewTermQuery([]byte(tag.Name), []byte(tag.Value)))
+	}
+	scope := opts.Scope.Tagged(map[string]string{
+		"namespace": namespace.NamespaceID().String(),
+	})
+	return &namespaceCanary{
+		namespace: namespace,
+		id:        ident.StringID(tags.ID()),
+		tags:      tags,
+		query:     index.Query{Query: idx.NewConjunctionQuery(terms...)},
+		opts:      opts,
+		metrics:   newNamespaceCanaryMetrics(scope),
+	}
+}
+
+// write writes a heartbeat with the current time as its timestamp and
+// value, the value is the unix time in seconds so that the age of the
+// latest heartbeat can be queried as well.
+func (n *namespaceCanary) write(now time.Time) {
+	timestamp := now.Truncate(time.Millisecond)
+	err := n.namespace.Session().WriteTagged(n.namespace.NamespaceID(), n.id,
+		storage.TagsToIdentTagIterator(n.tags), timestamp,
+		float64(timestamp.UnixNano())/float64(time.Second), xtime.Millisecond, nil)
+	n.metrics.writeLatency.Record(time.Since(now))
+	if err != nil {
+		n.metrics.writeErrors.Inc(1)
+		n.opts.Logger.Warn("unable to write canary heartbeat",
+			zap.String("namespace", n.namespace.NamespaceID().String()),
+			zap.Error(err))
+		return
+	}
+
+	n.metrics.written.Inc(1)
+	n.pending = append(n.pending, timestamp)
+	n.metrics.pending.Update(float64(len(n.pending)))
+}
+
+// poll queries the pending heartbeats and records the latency of those
+// that are queryable, and counts those pending for longer than the timeout
+// as missing.
+func (n *namespaceCanary) poll(now time.Time) {
+	if len(n.pending) == 0 {
+		return
+	}
+
+	found, err := n.fetch(n.pending[0], now)
+	if err != nil {
+		n.metrics.fetchErrors.Inc(1)
+		n.opts.Logger.Warn("unable to fetch canary heartbeats",
+			zap.String("namespace", n.namespace.NamespaceID().String()),
+			zap.Error(err))
+	}
+
+	pending := n.pending[:0]
+	for _, timestamp := range n.pending {
+		latency := now.Sub(timestamp)
+		switch {
+		case found[timestamp.UnixNano()]:
+			n.metrics.queryable.Inc(1)
+			n.metrics.latency.Record(latency)
+			if latency > n.opts.LatencyTarget {
+				n.metrics.targetViolations.Inc(1)
+			}
+		case latency > n.opts.Timeout:
+			n.metrics.missing.Inc(1)
+			n.metrics.targetViolations.Inc(1)
+		default:
+			pending = append(pending, timestamp)
+		}
+	}
+	n.pending = pending
+	n.metrics.pending.Update(float64(len(n.pending)))
+}
+
+// fetch returns the timestamps of the heartbeats between start and now.
+func (n *namespaceCanary) fetch(start, now time.Time) (map[int64]bool, error) {
+	iters, _, err := n.namespace.Session().FetchTagged(n.namespace.NamespaceID(),
+		n.query, index.QueryOptions{
+			StartInclusive: start,
+			EndExclusive:   now.Add(time.Millisecond),
+		})
+	if err != nil {
+		return nil, err
+	}
+	defer iters.Close()
+
+	found := make(map[int64]bool)
+	for _, iter := range iters.Iters() {
+		for iter.Next() {
+			dp, _, _ := iter.Current()
+			found[dp.Timestamp.UnixNano()] = true
+		}
+		if err := iter.Err(); err != nil {
+			return found, err
+		}
+	}
+	return found, nil
+}
+
+type namespaceCanaryMetrics struct {
+	written          tally.Counter
+	queryable        tally.Counter
+	missing          tally.Counter
+	targetViolations tally.Counter
+	writeErrors      tally.Counter
+	fetchErrors      tally.Counter
+	pending          tally.Gauge
+	latency          tally.Timer
+	writeLatency     tally.Timer
+}
+
+func newNamespaceCanaryMetrics(scope tally.Scope) namespaceCanaryMetrics {
+	return namespaceCanaryMetrics{
+		written:          scope.Counter("heartbeats-written"),
+		queryable:        scope.Counter("heartbeats-queryable"),
+		missing:          scope.Counter("heartbeats-missing"),
+		targetViolations: scope.Counter("latency-target-violations"),
+		writeErrors:      scope.Counter("write-errors"),
+		fetchErrors:      scope.Counter("fetch-errors"),
+		pending:          scope.Gauge("heartbeats-pending"),
+		latency:          scope.Timer("ingest-latency"),
+		writeLatency:     scope.Timer("write-latency"),
+	}
+}
@@ -0,0 +1,166 @@
+// Copyright (c) 2018 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package canary
+
+import (
+	"testing"
+	"time"
+
+	"github.com/m3db/m3/src/dbnode/client"
+	"github.com/m3db/m3/src/dbnode/encoding"
+	"github.com/m3db/m3/src/dbnode/ts"
+	"github.com/m3db/m3/src/query/storage/local"
+	"github.com/m3db/m3x/ident"
+	xtime "github.com/m3db/m3x/time"
+
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/uber-go/tally"
+)
+
+const testNamespace = "metrics"
+
+func newTestCanary(
+	t *testing.T,
+	ctrl *gomock.Controller,
+) (*Canary, *client.MockSession, tally.TestScope) {
+	session := client.NewMockSession(ctrl)
+	clusters, err := local.NewClusters(local.UnaggregatedClusterNamespaceDefinition{
+		NamespaceID: ident.StringID(testNamespace),
+		Session:     session,
+		Retention:   24 * time.Hour,
+	})
+	require.NoError(t, err)
+
+	scope := tally.NewTestScope("", nil)
+	c := New(clusters.ClusterNamespaces(), Options{
+		Timeout:       time.Minute,
+		LatencyTarget: 10 * time.Second,
+		Instance:      "coordinator-0",
+		Scope:         scope,
+	})
+	require.Len(t, c.namespaces, 1)
+	return c, session, scope
+}
+
+func newTestIters(ctrl *gomock.Controller, timestamps ...time.Time) encoding.SeriesIterators {
+	iter := encoding.NewMockSeriesIterator(ctrl)
+	for _, timestamp := range timestamps {
+		iter.EXPECT().Next().Return(true)
+		iter.EXPECT().Current().Return(ts.Datapoint{Timestamp: timestamp}, xtime.Millisecond, nil)
+	}
+	iter.EXPECT().Next().Return(false)
+	iter.EXPECT().Err().Return(nil)
+
+	iters := encoding.NewMockSeriesIterators(ctrl)
+	iters.EXPECT().Iters().Return([]encoding.SeriesIterator{iter})
+	iters.EXPECT().Close()
+	return iters
+}
+
+func counterValue(scope tally.TestScope, name string) int64 {
+	counter, ok := scope.Snapshot().Counters()[name+"+namespace="+testNamespace]
+	if !ok {
+		return 0
+	}
+	return counter.Value()
+}
+
+func TestCanaryHeartbeatQueryable(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	c, session, scope := newTestCanary(t, ctrl)
+	n := c.namespaces[0]
+	assert.Equal(t, "coordinator_canary_heartbeat", n.tags[0].Value)
+
+	now := time.Unix(1500000000, 0)
+	session.EXPECT().
+		WriteTagged(ident.NewIDMatcher(testNamespace), gomock.Any(), gomock.Any(),
+			now, float64(now.Unix()), xtime.Millisecond, gomock.Any()).
+		Return(nil)
+	n.write(now)
+	assert.Equal(t, []time.Time{now}, n.pending)
+
+	// Not queryable yet.
+	session.EXPECT().
+		FetchTagged(ident.NewIDMatcher(testNamespace), gomock.Any(), gomock.Any()).
+		Return(newTestIters(ctrl), true, nil)
+	n.poll(now.Add(time.Second))
+	assert.Len(t, n.pending, 1)
+
+	session.EXPECT().
+		FetchTagged(ident.NewIDMatcher(testNamespace), gomock.Any(), gomock.Any()).
+		Return(newTestIters(ctrl, now), true, nil)
+	n.poll(now.Add(2 * time.Second))
+	assert.Len(t, n.pending, 0)
+
+	assert.Equal(t, int64(1), counterValue(scope, "heartbeats-written"))
+	assert.Equal(t, int64(1), counterValue(scope, "heartbeats-queryable"))
+	assert.Equal(t, int64(0), counterValue(scope, "latency-target-violations"))
+
+	timer, ok := scope.Snapshot().Timers()["ingest-latency+namespace="+testNamespace]
+	require.True(t, ok)
+	assert.Equal(t, []time.Duration{2 * time.Second}, timer.Values())
+}
+
+func TestCanaryHeartbeatMissing(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	c, session, scope := newTestCanary(t, ctrl)
+	n := c.namespaces[0]
+
+	now := time.Unix(1500000000, 0)
+	session.EXPECT().
+		WriteTagged(gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any(),
+			gomock.Any(), gomock.Any(), gomock.Any()).
+		Return(nil).Times(2)
+	n.write(now)
+	n.write(now.Add(30 * time.Second))
+
+	session.EXPECT().
+		FetchTagged(gomock.Any(), gomock.Any(), gomock.Any()).
+		Return(newTestIters(ctrl), true, nil)
+	n.poll(now.Add(time.Minute + time.Second))
+
+	assert.Equal(t, []time.Time{now.Add(30 * time.Second)}, n.pending)
+	assert.Equal(t, int64(1), counterValue(scope, "heartbeats-missing"))
+	assert.Equal(t, int64(1), counterValue(scope, "latency-target-violations"))
+}
+
+func TestCanaryHeartbeatWriteError(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	c, session, scope := newTestCanary(t, ctrl)
+	n := c.namespaces[0]
+
+	session.EXPECT().
+		WriteTagged(gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any(),
+			gomock.Any(), gomock.Any(), gomock.Any()).
+		Return(assert.AnError)
+	n.write(time.Now())
+
+	assert.Len(t, n.pending, 0)
+	assert.Equal(t, int64(1), counterValue(scope, "write-errors"))
+}
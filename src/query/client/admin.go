@@ -0,0 +1,157 @@
+// Copyright (c) 2018 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package client
+
+import (
+	"bytes"
+	"context"
+	"io"
+	"net/http"
+	"net/url"
+
+	"github.com/m3db/m3/src/query/generated/proto/admin"
+
+	"github.com/gogo/protobuf/jsonpb"
+	"github.com/gogo/protobuf/proto"
+)
+
+const (
+	namespacePath = routePrefixV1 + "/namespace"
+	placementPath = routePrefixV1 + "/placement"
+)
+
+// Namespaces returns the namespaces of the cluster.
+func (c *Client) Namespaces(ctx context.Context) (*admin.NamespaceGetResponse, error) {
+	var resp admin.NamespaceGetResponse
+	if err := c.do(ctx, request{
+		method: http.MethodGet,
+		path:   namespacePath,
+	}, decodeProtoJSON(&resp)); err != nil {
+		return nil, err
+	}
+	return &resp, nil
+}
+
+// AddNamespace adds a namespace and returns the namespaces of the cluster.
+func (c *Client) AddNamespace(
+	ctx context.Context,
+	req *admin.NamespaceAddRequest,
+) (*admin.NamespaceGetResponse, error) {
+	body, err := protoJSONBody(req)
+	if err != nil {
+		return nil, err
+	}
+
+	var resp admin.NamespaceGetResponse
+	if err := c.do(ctx, request{
+		method:      http.MethodPost,
+		path:        namespacePath,
+		contentType: "application/json",
+		body:        body,
+	}, decodeProtoJSON(&resp)); err != nil {
+		return nil, err
+	}
+	return &resp, nil
+}
+
+// DeleteNamespace deletes a namespace, IsNotFound returns true for the
+// error returned if the namespace does not exist.
+func (c *Client) DeleteNamespace(ctx context.Context, id string) error {
+	return c.do(ctx, request{
+		method: http.MethodDelete,
+		path:   namespacePath + "/" + url.PathEscape(id),
+	}, nil)
+}
+
+// Placement returns the placement of the cluster.
+func (c *Client) Placement(ctx context.Context) (*admin.PlacementGetResponse, error) {
+	var resp admin.PlacementGetResponse
+	if err := c.do(ctx, request{
+		method: http.MethodGet,
+		path:   placementPath,
+	}, decodeProtoJSON(&resp)); err != nil {
+		return nil, err
+	}
+	return &resp, nil
+}
+
+// InitPlacement initializes the placement of the cluster.
+func (c *Client) InitPlacement(
+	ctx context.Context,
+	req *admin.PlacementInitRequest,
+) (*admin.PlacementGetResponse, error) {
+	return c.placementRequest(ctx, http.MethodPost, placementPath+"/init", req)
+}
+
+// AddPlacementInstances adds instances to the placement of the cluster.
+func (c *Client) AddPlacementInstances(
+	ctx context.Context,
+	req *admin.PlacementAddRequest,
+) (*admin.PlacementGetResponse, error) {
+	return c.placementRequest(ctx, http.MethodPost, placementPath, req)
+}
+
+// RemovePlacementInstance removes an instance from the placement of the
+// cluster.
+func (c *Client) RemovePlacementInstance(
+	ctx context.Context,
+	id string,
+) (*admin.PlacementGetResponse, error) {
+	return c.placementRequest(ctx, http.MethodDelete,
+		placementPath+"/"+url.PathEscape(id), nil)
+}
+
+func (c *Client) placementRequest(
+	ctx context.Context,
+	method, path string,
+	req proto.Message,
+) (*admin.PlacementGetResponse, error) {
+	r := request{method: method, path: path}
+	if req != nil {
+		body, err := protoJSONBody(req)
+		if err != nil {
+			return nil, err
+		}
+		r.contentType = "application/json"
+		r.body = body
+	}
+
+	var resp admin.PlacementGetResponse
+	if err := c.do(ctx, r, decodeProtoJSON(&resp)); err != nil {
+		return nil, err
+	}
+	return &resp, nil
+}
+
+func protoJSONBody(msg proto.Message) (func() io.Reader, error) {
+	var buf bytes.Buffer
+	if err := (&jsonpb.Marshaler{}).Marshal(&buf, msg); err != nil {
+		return nil, err
+	}
+	data := buf.Bytes()
+	return func() io.Reader { return bytes.NewReader(data) }, nil
+}
+
+func decodeProtoJSON(msg proto.Message) func(io.Reader) error {
+	return func(r io.Reader) error {
+		return jsonpb.Unmarshal(r, msg)
+	}
+}
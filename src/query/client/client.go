@@ -0,0 +1,240 @@
+// Copyright (c) 2018 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+// Package client is a Go client of the coordinator HTTP APIs, it writes and
+// queries series and administers the namespaces and placement of the cluster
+// with typed requests and responses.
+package client
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	xerrors "github.com/m3db/m3x/errors"
+	xretry "github.com/m3db/m3x/retry"
+)
+
+const (
+	routePrefixV1 = "/api/v1"
+
+	defaultTimeout = 30 * time.Second
+)
+
+var (
+	errNoURL = errors.New("no coordinator URL set")
+)
+
+// Options is the set of options for the client.
+type Options struct {
+	// URL is the base URL of the coordinator such as "http://localhost:7201".
+	URL string
+
+	// HTTPClient is the HTTP client of the requests, a client with a 30s
+	// timeout is used if not set.
+	HTTPClient *http.Client
+
+	// Retrier retries requests that fail with a network error or a server
+	// error, requests are not retried if not set.
+	Retrier xretry.Retrier
+
+	// Headers are set on every request, such as the Cluster-Environment-Name
+	// and Cluster-Zone-Name headers of the placement APIs.
+	Headers http.Header
+}
+
+// Client is a client of the coordinator HTTP APIs, it is safe for
+// concurrent use.
+type Client struct {
+	baseURL    *url.URL
+	httpClient *http.Client
+	retrier    xretry.Retrier
+	headers    http.Header
+}
+
+// New returns a new client.
+func New(opts Options) (*Client, error) {
+	if opts.URL == "" {
+		return nil, errNoURL
+	}
+	baseURL, err := url.Parse(strings.TrimSuffix(opts.URL, "/"))
+	if err != nil {
+		return nil, err
+	}
+	httpClient := opts.HTTPClient
+	if httpClient == nil {
+		httpClient = &http.Client{Timeout: defaultTimeout}
+	}
+	return &Client{
+		baseURL:    baseURL,
+		httpClient: httpClient,
+		retrier:    opts.Retrier,
+		headers:    opts.Headers,
+	}, nil
+}
+
+// Error is a request the coordinator responded to with an error status code.
+type Error struct {
+	StatusCode int
+	Message    string
+}
+
+func (e *Error) Error() string {
+	return fmt.Sprintf("coordinator responded with status %d: %s", e.StatusCode, e.Message)
+}
+
+// IsNotFound returns whether the error is a request the coordinator
+// responded to with a not found status code.
+func IsNotFound(err error) bool {
+	respErr, ok := err.(*Error)
+	return ok && respErr.StatusCode == http.StatusNotFound
+}
+
+// request is a request to the coordinator, the body is a function so that
+// it can be read again if the request is retried.
+type request struct {
+	method      string
+	path        string
+	query       url.Values
+	contentType string
+	headers     map[string]string
+	body        func() io.Reader
+}
+
+// do performs the request with retries and decodes the response with
+// decodeFn if the request succeeds.
+func (c *Client) do(
+	ctx context.Context,
+	req request,
+	decodeFn func(io.Reader) error,
+) error {
+	attempt := func() error {
+		if err := ctx.Err(); err != nil {
+			return xerrors.NewNonRetryableError(err)
+		}
+		return c.attempt(ctx, req, decodeFn)
+	}
+	if c.retrier == nil {
+		return unwrapNonRetryable(attempt())
+	}
+	return unwrapNonRetryable(c.retrier.Attempt(attempt))
+}
+
+func (c *Client) attempt(
+	ctx context.Context,
+	req request,
+	decodeFn func(io.Reader) error,
+) error {
+	u := *c.baseURL
+	u.Path += req.path
+	u.RawQuery = req.query.Encode()
+
+	var body io.Reader
+	if req.body != nil {
+		body = req.body()
+	}
+	httpReq, err := http.NewRequest(req.method, u.String(), body)
+	if err != nil {
+		return xerrors.NewNonRetryableError(err)
+	}
+	httpReq = httpReq.WithContext(ctx)
+	for key, values := range c.headers {
+		for _, value := range values {
+			httpReq.Header.Add(key, value)
+		}
+	}
+	for key, value := range req.headers {
+		httpReq.Header.Set(key, value)
+	}
+	if req.contentType != "" {
+		httpReq.Header.Set("Content-Type", req.contentType)
+	}
+
+	resp, err := c.httpClient.Do(httpReq)
+	if err != nil {
+		// Network errors are retried.
+		return err
+	}
+	defer func() {
+		io.Copy(ioutil.Discard, resp.Body)
+		resp.Body.Close()
+	}()
+
+	if resp.StatusCode >= http.StatusBadRequest {
+		respErr := newError(resp)
+		if resp.StatusCode < http.StatusInternalServerError &&
+			resp.StatusCode != http.StatusTooManyRequests {
+			return xerrors.NewNonRetryableError(respErr)
+		}
+		return respErr
+	}
+
+	if decodeFn == nil {
+		return nil
+	}
+	if err := decodeFn(resp.Body); err != nil {
+		return xerrors.NewNonRetryableError(
+			fmt.Errorf("unable to decode response: %v", err))
+	}
+	return nil
+}
+
+// newError returns the error of a response with an error status code, the
+// message is the error of the JSON body if any and the body otherwise.
+func newError(resp *http.Response) *Error {
+	body, _ := ioutil.ReadAll(resp.Body)
+	var errBody struct {
+		Error string `json:"error"`
+	}
+	message := strings.TrimSpace(string(body))
+	if err := json.Unmarshal(body, &errBody); err == nil && errBody.Error != "" {
+		message = errBody.Error
+	}
+	return &Error{StatusCode: resp.StatusCode, Message: message}
+}
+
+func unwrapNonRetryable(err error) error {
+	if inner := xerrors.GetInnerNonRetryableError(err); inner != nil {
+		return inner
+	}
+	return err
+}
+
+func jsonBody(v interface{}) (func() io.Reader, error) {
+	data, err := json.Marshal(v)
+	if err != nil {
+		return nil, err
+	}
+	return func() io.Reader { return bytes.NewReader(data) }, nil
+}
+
+func decodeJSON(v interface{}) func(io.Reader) error {
+	return func(r io.Reader) error {
+		return json.NewDecoder(r).Decode(v)
+	}
+}
@@ -0,0 +1,162 @@
+// Copyright (c) 2018 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package client
+
+import (
+	"context"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/m3db/m3/src/query/generated/proto/prompb"
+	"github.com/m3db/m3/src/query/models"
+	xretry "github.com/m3db/m3x/retry"
+
+	"github.com/gogo/protobuf/proto"
+	"github.com/golang/snappy"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func newTestClient(t *testing.T, handler http.HandlerFunc, retries int) (*Client, func()) {
+	server := httptest.NewServer(handler)
+	opts := Options{URL: server.URL}
+	if retries > 0 {
+		opts.Retrier = xretry.NewRetrier(xretry.NewOptions().
+			SetMaxRetries(retries).
+			SetInitialBackoff(time.Millisecond))
+	}
+	c, err := New(opts)
+	require.NoError(t, err)
+	return c, server.Close
+}
+
+func TestClientWrite(t *testing.T) {
+	now := time.Unix(1500000000, 0)
+	c, closer := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, promWritePath, r.URL.Path)
+		assert.Equal(t, "snappy", r.Header.Get("Content-Encoding"))
+
+		compressed, err := ioutil.ReadAll(r.Body)
+		require.NoError(t, err)
+		data, err := snappy.Decode(nil, compressed)
+		require.NoError(t, err)
+		var req prompb.WriteRequest
+		require.NoError(t, proto.Unmarshal(data, &req))
+
+		require.Len(t, req.Timeseries, 1)
+		assert.Equal(t, []*prompb.Label{
+			{Name: "__name__", Value: "foo"},
+			{Name: "bar", Value: "baz"},
+		}, req.Timeseries[0].Labels)
+		assert.Equal(t, []*prompb.Sample{
+			{Timestamp: now.UnixNano() / int64(time.Millisecond), Value: 42},
+		}, req.Timeseries[0].Samples)
+	}, 0)
+	defer closer()
+
+	require.NoError(t, c.Write(context.Background(), []Series{{
+		Labels:  map[string]string{"bar": "baz", "__name__": "foo"},
+		Samples: []Sample{{Timestamp: now, Value: 42}},
+	}}))
+}
+
+func TestClientQueryRange(t *testing.T) {
+	start := time.Unix(1500000000, 0)
+	c, closer := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, queryRangePath, r.URL.Path)
+		assert.Equal(t, "up", r.FormValue("query"))
+		assert.Equal(t, "1500000000", r.FormValue("start"))
+		assert.Equal(t, "10s", r.FormValue("step"))
+		fmt.Fprint(w, `{"status":"success","data":{"resultType":"matrix","result":[`+
+			`{"metric":{"__name__":"up"},"values":[[1500000000,"1"],[1500000010,"NaN"]]}]}}`)
+	}, 0)
+	defer closer()
+
+	result, err := c.QueryRange(context.Background(), QueryRangeRequest{
+		Query: "up",
+		Start: start,
+		End:   start.Add(time.Minute),
+		Step:  10 * time.Second,
+	})
+	require.NoError(t, err)
+	require.Len(t, result.Series, 1)
+	assert.Equal(t, map[string]string{"__name__": "up"}, result.Series[0].Metric)
+	require.Len(t, result.Series[0].Samples, 2)
+	assert.Equal(t, Sample{Timestamp: start, Value: 1}, result.Series[0].Samples[0])
+	assert.True(t, start.Add(10*time.Second).Equal(result.Series[0].Samples[1].Timestamp))
+}
+
+func TestClientLabelValues(t *testing.T) {
+	c, closer := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, searchPath, r.URL.Path)
+		assert.Equal(t, "10", r.FormValue("limit"))
+		fmt.Fprint(w, `{"Metrics":[`+
+			`{"ID":"a","Tags":[{"Name":"host","Value":"b"}]},`+
+			`{"ID":"b","Tags":[{"Name":"host","Value":"a"}]},`+
+			`{"ID":"c","Tags":[{"Name":"host","Value":"b"}]}]}`)
+	}, 0)
+	defer closer()
+
+	values, err := c.LabelValues(context.Background(), "host", SearchRequest{
+		Matchers: models.Matchers{{Type: models.MatchEqual, Name: "__name__", Value: "up"}},
+		Limit:    10,
+	})
+	require.NoError(t, err)
+	assert.Equal(t, []string{"a", "b"}, values)
+}
+
+func TestClientRetries(t *testing.T) {
+	var calls int
+	c, closer := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		calls++
+		if calls < 3 {
+			w.WriteHeader(http.StatusServiceUnavailable)
+			fmt.Fprint(w, `{"error":"unavailable"}`)
+			return
+		}
+		fmt.Fprint(w, `{"registry":{"namespaces":{}}}`)
+	}, 2)
+	defer closer()
+
+	_, err := c.Namespaces(context.Background())
+	require.NoError(t, err)
+	assert.Equal(t, 3, calls)
+}
+
+func TestClientDoesNotRetryClientErrors(t *testing.T) {
+	var calls int
+	c, closer := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		calls++
+		w.WriteHeader(http.StatusNotFound)
+		fmt.Fprint(w, `{"error":"namespace not found"}`)
+	}, 2)
+	defer closer()
+
+	err := c.DeleteNamespace(context.Background(), "foo")
+	require.Error(t, err)
+	assert.True(t, IsNotFound(err))
+	assert.Equal(t, "namespace not found", err.(*Error).Message)
+	assert.Equal(t, 1, calls)
+}
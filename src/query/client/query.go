@@ -0,0 +1,223 @@
+// Copyright (c) 2018 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package client
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/url"
+	"sort"
+	"strconv"
+	"time"
+
+	"github.com/m3db/m3/src/query/models"
+)
+
+const (
+	queryRangePath = routePrefixV1 + "/query_range"
+	searchPath     = "/search"
+)
+
+// QueryRangeRequest is a PromQL range query.
+type QueryRangeRequest struct {
+	Query string
+	Start time.Time
+	End   time.Time
+	Step  time.Duration
+
+	// Timeout is the timeout of the query on the coordinator, the default
+	// timeout of the coordinator is used if not set.
+	Timeout time.Duration
+}
+
+// QueryRangeResult is the result of a range query.
+type QueryRangeResult struct {
+	Series []QuerySeries
+}
+
+// QuerySeries is a series of the result of a range query.
+type QuerySeries struct {
+	Metric  map[string]string
+	Samples []Sample
+}
+
+type queryRangeResponse struct {
+	Status string `json:"status"`
+	Data   struct {
+		Result []struct {
+			Metric map[string]string `json:"metric"`
+			Values [][2]interface{}  `json:"values"`
+		} `json:"result"`
+	} `json:"data"`
+}
+
+// QueryRange evaluates a PromQL range query.
+func (c *Client) QueryRange(
+	ctx context.Context,
+	req QueryRangeRequest,
+) (QueryRangeResult, error) {
+	query := url.Values{}
+	query.Set("query", req.Query)
+	query.Set("start", formatTime(req.Start))
+	query.Set("end", formatTime(req.End))
+	query.Set("step", req.Step.String())
+
+	headers := make(map[string]string)
+	if req.Timeout > 0 {
+		headers["timeout"] = req.Timeout.String()
+	}
+
+	var resp queryRangeResponse
+	if err := c.do(ctx, request{
+		method:  http.MethodGet,
+		path:    queryRangePath,
+		query:   query,
+		headers: headers,
+	}, decodeJSON(&resp)); err != nil {
+		return QueryRangeResult{}, err
+	}
+
+	result := QueryRangeResult{
+		Series: make([]QuerySeries, 0, len(resp.Data.Result)),
+	}
+	for _, r := range resp.Data.Result {
+		series := QuerySeries{
+			Metric:  r.Metric,
+			Samples: make([]Sample, 0, len(r.Values)),
+		}
+		for _, v := range r.Values {
+			sample, err := parseSample(v)
+			if err != nil {
+				return QueryRangeResult{}, err
+			}
+			series.Samples = append(series.Samples, sample)
+		}
+		result.Series = append(result.Series, series)
+	}
+	return result, nil
+}
+
+// parseSample parses a sample of the form [<unix seconds>, "<value>"].
+func parseSample(v [2]interface{}) (Sample, error) {
+	seconds, ok := v[0].(float64)
+	if !ok {
+		return Sample{}, fmt.Errorf("invalid sample timestamp: %v", v[0])
+	}
+	str, ok := v[1].(string)
+	if !ok {
+		return Sample{}, fmt.Errorf("invalid sample value: %v", v[1])
+	}
+	value, err := strconv.ParseFloat(str, 64)
+	if err != nil {
+		return Sample{}, fmt.Errorf("invalid sample value: %v", err)
+	}
+	return Sample{
+		Timestamp: time.Unix(0, int64(seconds*float64(time.Second))),
+		Value:     value,
+	}, nil
+}
+
+func formatTime(t time.Time) string {
+	return strconv.FormatFloat(float64(t.UnixNano())/float64(time.Second), 'f', -1, 64)
+}
+
+// SearchRequest is a request for the series whose labels match all of the
+// matchers.
+type SearchRequest struct {
+	Matchers models.Matchers
+	Start    time.Time
+	End      time.Time
+
+	// Limit is the max number of series returned, the default limit of the
+	// coordinator is used if not set.
+	Limit int
+}
+
+type searchBody struct {
+	Matchers models.Matchers `json:"matchers"`
+	Start    time.Time       `json:"start"`
+	End      time.Time       `json:"end"`
+}
+
+type searchResponse struct {
+	Metrics models.Metrics
+}
+
+// Search returns the IDs and labels of the series that match the request.
+func (c *Client) Search(ctx context.Context, req SearchRequest) (models.Metrics, error) {
+	body, err := jsonBody(searchBody{
+		Matchers: req.Matchers,
+		Start:    req.Start,
+		End:      req.End,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	query := url.Values{}
+	if req.Limit > 0 {
+		query.Set("limit", strconv.Itoa(req.Limit))
+	}
+
+	var resp searchResponse
+	if err := c.do(ctx, request{
+		method:      http.MethodPost,
+		path:        searchPath,
+		query:       query,
+		contentType: "application/json",
+		body:        body,
+	}, decodeJSON(&resp)); err != nil {
+		return nil, err
+	}
+	return resp.Metrics, nil
+}
+
+// LabelValues returns the distinct values of a label of the series that
+// match the request.
+func (c *Client) LabelValues(
+	ctx context.Context,
+	name string,
+	req SearchRequest,
+) ([]string, error) {
+	metrics, err := c.Search(ctx, req)
+	if err != nil {
+		return nil, err
+	}
+
+	var (
+		seen   = make(map[string]struct{})
+		values []string
+	)
+	for _, metric := range metrics {
+		for _, tag := range metric.Tags {
+			if tag.Name != name {
+				continue
+			}
+			if _, ok := seen[tag.Value]; !ok {
+				seen[tag.Value] = struct{}{}
+				values = append(values, tag.Value)
+			}
+		}
+	}
+	sort.Strings(values)
+	return values, nil
+}
@@ -0,0 +1,92 @@
+// Copyright (c) 2018 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package client
+
+import (
+	"bytes"
+	"context"
+	"io"
+	"net/http"
+	"sort"
+	"time"
+
+	"github.com/m3db/m3/src/query/generated/proto/prompb"
+
+	"github.com/gogo/protobuf/proto"
+	"github.com/golang/snappy"
+)
+
+const promWritePath = routePrefixV1 + "/prom/remote/write"
+
+// Series is a series written by Write.
+type Series struct {
+	// Labels are the labels of the series including its name label.
+	Labels map[string]string
+
+	Samples []Sample
+}
+
+// Sample is a sample of a series.
+type Sample struct {
+	Timestamp time.Time
+	Value     float64
+}
+
+// Write writes the series with a Prometheus remote write request, the
+// request is retried as a whole if it fails with a server error.
+func (c *Client) Write(ctx context.Context, series []Series) error {
+	req := &prompb.WriteRequest{
+		Timeseries: make([]*prompb.TimeSeries, 0, len(series)),
+	}
+	for _, s := range series {
+		ts := &prompb.TimeSeries{
+			Labels:  make([]*prompb.Label, 0, len(s.Labels)),
+			Samples: make([]*prompb.Sample, 0, len(s.Samples)),
+		}
+		for name, value := range s.Labels {
+			ts.Labels = append(ts.Labels, &prompb.Label{Name: name, Value: value})
+		}
+		sort.Slice(ts.Labels, func(i, j int) bool {
+			return ts.Labels[i].Name < ts.Labels[j].Name
+		})
+		for _, sample := range s.Samples {
+			ts.Samples = append(ts.Samples, &prompb.Sample{
+				Timestamp: sample.Timestamp.UnixNano() / int64(time.Millisecond),
+				Value:     sample.Value,
+			})
+		}
+		req.Timeseries = append(req.Timeseries, ts)
+	}
+
+	data, err := proto.Marshal(req)
+	if err != nil {
+		return err
+	}
+	compressed := snappy.Encode(nil, data)
+
+	return c.do(ctx, request{
+		method:      http.MethodPost,
+		path:        promWritePath,
+		contentType: "application/x-protobuf",
+		headers:     map[string]string{"Content-Encoding": "snappy"},
+		body:        func() io.Reader { return bytes.NewReader(compressed) },
+	}, nil)
+}
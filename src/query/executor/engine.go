@@ -44,6 +44,8 @@ type Engine struct {
 type EngineOptions struct {
 	// AbortCh is a channel that signals when results are no longer desired by the caller.
 	AbortCh <-chan bool
+	// FetchControls are the consistency and fanout controls of fetches.
+	FetchControls models.FetchControls
 }
 
 // Query is the result after execution
@@ -84,7 +86,8 @@ func (e *Engine) Execute(ctx context.Context, query *storage.FetchQuery, opts *E
 	defer e.tracker.DetachQuery(task.qid)
 
 	result, err := e.store.Fetch(ctx, query, &storage.FetchOptions{
-		KillChan: task.closing,
+		KillChan:      task.closing,
+		FetchControls: opts.FetchControls,
 	})
 	if err != nil {
 		results <- &storage.QueryResult{Err: err}
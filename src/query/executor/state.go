@@ -118,8 +118,12 @@ func GenerateExecutionState(
 	}
 
 	options := transform.Options{
-		TimeSpec: pplan.TimeSpec,
-		Debug:    pplan.Debug,
+		TimeSpec:      pplan.TimeSpec,
+		Debug:         pplan.Debug,
+		Lookback:      pplan.Lookback,
+		DedupeSeries:  pplan.DedupeSeries,
+		NaNMode:       pplan.NaNMode,
+		FetchControls: pplan.FetchControls,
 	}
 	controller, err := state.createNode(step, options)
 	if err != nil {
@@ -32,6 +32,16 @@ import (
 type Options struct {
 	TimeSpec TimeSpec
 	Debug    bool
+	// Lookback is the duration the last value of each series is carried
+	// forward, by metric type
+	Lookback models.LookbackOptions
+	// DedupeSeries keeps the first of the series with the same label set
+	// produced by a function rather than failing the query
+	DedupeSeries bool
+	// NaNMode is how sum, avg, min and max aggregations handle NaN values
+	NaNMode models.NaNMode
+	// FetchControls are the consistency and fanout controls of fetches
+	FetchControls models.FetchControls
 }
 
 // OpNode represents the execution node
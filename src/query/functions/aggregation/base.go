@@ -22,10 +22,12 @@ package aggregation
 
 import (
 	"fmt"
+	"math"
 
 	"github.com/m3db/m3/src/query/block"
 	"github.com/m3db/m3/src/query/executor/transform"
 	"github.com/m3db/m3/src/query/functions/utils"
+	"github.com/m3db/m3/src/query/models"
 	"github.com/m3db/m3/src/query/parser"
 )
 
@@ -41,6 +43,15 @@ var aggregationFunctions = map[string]aggregationFn{
 	CountType:             countFn,
 }
 
+// nanModeTypes are the aggregations that handle NaN values as set by the
+// NaN mode of the query, others always skip them.
+var nanModeTypes = map[string]bool{
+	SumType:     true,
+	MinType:     true,
+	MaxType:     true,
+	AverageType: true,
+}
+
 // NodeParams contains additional parameters required for aggregation ops
 type NodeParams struct {
 	// MatchingTags is the set of tags by which the aggregation groups output series
@@ -88,10 +99,16 @@ func (o baseOp) String() string {
 }
 
 // Node creates an execution node
-func (o baseOp) Node(controller *transform.Controller, _ transform.Options) transform.OpNode {
+func (o baseOp) Node(controller *transform.Controller, opts transform.Options) transform.OpNode {
+	nanMode := models.NaNSkip
+	if nanModeTypes[o.opType] {
+		nanMode = opts.NaNMode
+	}
+
 	return &baseNode{
 		op:         o,
 		controller: controller,
+		nanMode:    nanMode,
 	}
 }
 
@@ -106,6 +123,7 @@ func newBaseOp(params NodeParams, opType string, aggFn aggregationFn) baseOp {
 type baseNode struct {
 	op         baseOp
 	controller *transform.Controller
+	nanMode    models.NaNMode
 }
 
 // Process the block
@@ -135,7 +153,10 @@ func (n *baseNode) Process(ID parser.NodeID, b block.Block) error {
 		return err
 	}
 
-	aggregatedValues := make([]float64, len(buckets))
+	var (
+		aggregatedValues = make([]float64, len(buckets))
+		zeroedValues     []float64
+	)
 	for index := 0; stepIter.Next(); index++ {
 		step, err := stepIter.Current()
 		if err != nil {
@@ -143,7 +164,17 @@ func (n *baseNode) Process(ID parser.NodeID, b block.Block) error {
 		}
 
 		values := step.Values()
+		if n.nanMode == models.NaNAsZero {
+			zeroedValues = zeroNaNs(zeroedValues, values)
+			values = zeroedValues
+		}
+
 		for i, bucket := range buckets {
+			if n.nanMode == models.NaNPropagate && anyNaN(values, bucket) {
+				aggregatedValues[i] = math.NaN()
+				continue
+			}
+
 			aggregatedValues[i] = n.op.aggFn(values, bucket)
 		}
 
@@ -154,3 +185,26 @@ func (n *baseNode) Process(ID parser.NodeID, b block.Block) error {
 	defer nextBlock.Close()
 	return n.controller.Process(nextBlock)
 }
+
+// zeroNaNs copies the values into the buffer with NaN values replaced by
+// zero, leaving the values of the step untouched.
+func zeroNaNs(buf, values []float64) []float64 {
+	buf = append(buf[:0], values...)
+	for i, v := range buf {
+		if math.IsNaN(v) {
+			buf[i] = 0
+		}
+	}
+
+	return buf
+}
+
+func anyNaN(values []float64, bucket []int) bool {
+	for _, idx := range bucket {
+		if math.IsNaN(values[idx]) {
+			return true
+		}
+	}
+
+	return false
+}
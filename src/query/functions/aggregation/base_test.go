@@ -178,3 +178,42 @@ func TestFunctionFilteringWithoutD(t *testing.T) {
 	assert.Equal(t, bounds, sink.Meta.Bounds)
 	assert.Equal(t, expectedMetaTags, sink.Meta.Tags)
 }
+
+func TestNaNModes(t *testing.T) {
+	tests := []struct {
+		mode     models.NaNMode
+		expected []float64
+	}{
+		{models.NaNSkip, []float64{5, 13, 13, 17, 21}},
+		{models.NaNPropagate, []float64{math.NaN(), math.NaN(), 13, 17, 21}},
+		{models.NaNAsZero, []float64{10.0 / 3, 26.0 / 3, 13, 17, 21}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.mode.String(), func(t *testing.T) {
+			op, err := NewAggregationOp(AverageType, NodeParams{
+				MatchingTags: []string{"a"}, Without: false,
+			})
+			require.NoError(t, err)
+
+			bl := test.NewBlockFromValuesWithSeriesMeta(bounds, seriesMetas, v)
+			c, sink := executor.NewControllerWithSink(parser.NodeID(1))
+			node := op.(baseOp).Node(c, transform.Options{NaNMode: tt.mode})
+			require.NoError(t, node.Process(parser.NodeID(0), bl))
+			require.Len(t, sink.Values, 3)
+			test.EqualsWithNansWithDelta(t, tt.expected, sink.Values[0], 0.0001)
+			test.EqualsWithNansWithDelta(t, []float64{350, 450, 550, 650, 750}, sink.Values[2], 0.0001)
+		})
+	}
+
+	// NB: Other aggregations always skip NaN values.
+	op, err := NewAggregationOp(CountType, NodeParams{
+		MatchingTags: []string{"a"}, Without: false,
+	})
+	require.NoError(t, err)
+	bl := test.NewBlockFromValuesWithSeriesMeta(bounds, seriesMetas, v)
+	c, sink := executor.NewControllerWithSink(parser.NodeID(1))
+	node := op.(baseOp).Node(c, transform.Options{NaNMode: models.NaNPropagate})
+	require.NoError(t, node.Process(parser.NodeID(0), bl))
+	test.EqualsWithNansWithDelta(t, []float64{2, 2, 3, 3, 3}, sink.Values[0], 0.0001)
+}
@@ -47,7 +47,11 @@ func makeAndBlock(
 		return nil, err
 	}
 
-	intersection := andIntersect(matching, lIter.SeriesMeta(), rIter.SeriesMeta())
+	intersection, err := andIntersect(matching, lIter.SeriesMeta(), rIter.SeriesMeta())
+	if err != nil {
+		return nil, err
+	}
+
 	for index := 0; lIter.Next() && rIter.Next(); index++ {
 		lStep, err := lIter.Current()
 		if err != nil {
@@ -76,28 +80,11 @@ func makeAndBlock(
 	return builder.Build(), nil
 }
 
-// intersect returns the slice of rhs indices if there is a match with
+// andIntersect returns the slice of rhs indices if there is a match with
 // a corresponding lhs index. If no match is found, it returns -1
 func andIntersect(
 	matching *VectorMatching,
 	lhs, rhs []block.SeriesMeta,
-) []int {
-	idFunction := HashFunc(matching.On, matching.MatchingLabels...)
-	// The set of signatures for the right-hand side.
-	rightSigs := make(map[uint64]int, len(rhs))
-	for idx, meta := range rhs {
-		rightSigs[idFunction(meta.Tags)] = idx
-	}
-
-	matches := make([]int, len(lhs))
-	for i, ls := range lhs {
-		// If there's a matching entry in the right-hand side Vector, add the sample.
-		if idx, ok := rightSigs[idFunction(ls.Tags)]; ok {
-			matches[i] = idx
-		} else {
-			matches[i] = -1
-		}
-	}
-
-	return matches
+) ([]int, error) {
+	return matchSignatures(matching, lhs, rhs, defaultJoinOptions)
 }
@@ -27,7 +27,7 @@ import (
 )
 
 type binaryFunc func(x, y float64) float64
-type singleScalarFunc func(x float64) float64
+type singleScalarFunc func(step int, x float64) float64
 
 // processes two logical blocks, performing a logical operation on them
 func processBinary(
@@ -43,30 +43,25 @@ func processBinary(
 	}
 
 	if params.LIsScalar {
-		scalarL, ok := lhs.(*block.Scalar)
-		if !ok {
-			return nil, errLeftScalar
+		lVals, err := scalarValues(lhs, errLeftScalar)
+		if err != nil {
+			return nil, err
 		}
 
-		lVal := scalarL.Value()
-
 		// rhs is a series; use rhs metadata and series meta
 		if !params.RIsScalar {
 			return processSingleBlock(
 				rhs,
 				controller,
-				func(x float64) float64 {
-					return fn(lVal, x)
+				func(step int, x float64) float64 {
+					return fn(lVals[step], x)
 				},
 			)
 		}
 
-		// if both lhs and rhs are scalars, can create a new block
-		// by extracting values from lhs and rhs instead of doing
-		// by-value comparisons
-		scalarR, ok := rhs.(*block.Scalar)
-		if !ok {
-			return nil, errRightScalar
+		rVals, err := scalarValues(rhs, errRightScalar)
+		if err != nil {
+			return nil, err
 		}
 
 		// NB(arnikola): this is a sanity check, as scalar comparisons
@@ -75,25 +70,39 @@ func processBinary(
 			return nil, errNoModifierForComparison
 		}
 
-		return block.NewScalar(
-			fn(lVal, scalarR.Value()),
-			lIter.Meta().Bounds,
-		), nil
+		// if both lhs and rhs are constant scalars, can create a new block
+		// by extracting values from lhs and rhs instead of doing
+		// by-value comparisons
+		scalarL, lConstant := lhs.(*block.Scalar)
+		scalarR, rConstant := rhs.(*block.Scalar)
+		if lConstant && rConstant {
+			return block.NewScalar(
+				fn(scalarL.Value(), scalarR.Value()),
+				lIter.Meta().Bounds,
+			), nil
+		}
+
+		return processSingleBlock(
+			lhs,
+			controller,
+			func(step int, x float64) float64 {
+				return fn(x, rVals[step])
+			},
+		)
 	}
 
 	if params.RIsScalar {
-		scalarR, ok := rhs.(*block.Scalar)
-		if !ok {
-			return nil, errRightScalar
+		rVals, err := scalarValues(rhs, errRightScalar)
+		if err != nil {
+			return nil, err
 		}
 
-		rVal := scalarR.Value()
 		// lhs is a series; use lhs metadata and series meta
 		return processSingleBlock(
 			lhs,
 			controller,
-			func(x float64) float64 {
-				return fn(x, rVal)
+			func(step int, x float64) float64 {
+				return fn(x, rVals[step])
 			},
 		)
 	}
@@ -114,6 +123,39 @@ func processBinary(
 	return processBothSeries(lIter, rIter, controller, params.VectorMatching, fn)
 }
 
+// scalarValues returns the value of a scalar block at each step, which is
+// either a constant scalar or a block with a single series such as the
+// result of the scalar function.
+func scalarValues(b block.Block, errNotScalar error) ([]float64, error) {
+	it, err := b.StepIter()
+	if err != nil {
+		return nil, err
+	}
+
+	values := make([]float64, it.StepCount())
+	if scalar, ok := b.(*block.Scalar); ok {
+		for i := range values {
+			values[i] = scalar.Value()
+		}
+		return values, nil
+	}
+
+	if len(it.SeriesMeta()) != 1 {
+		return nil, errNotScalar
+	}
+
+	for index := 0; it.Next(); index++ {
+		step, err := it.Current()
+		if err != nil {
+			return nil, err
+		}
+
+		values[index] = step.Values()[0]
+	}
+
+	return values, nil
+}
+
 func processSingleBlock(
 	block block.Block,
 	controller *transform.Controller,
@@ -141,7 +183,7 @@ func processSingleBlock(
 
 		values := step.Values()
 		for _, value := range values {
-			builder.AppendValue(index, fn(value))
+			builder.AppendValue(index, fn(index, value))
 		}
 	}
 
@@ -163,7 +205,10 @@ func processBothSeries(
 	lSeriesMeta := utils.FlattenMetadata(lMeta, lIter.SeriesMeta())
 	rSeriesMeta := utils.FlattenMetadata(rMeta, rIter.SeriesMeta())
 
-	takeLeft, correspondingRight, lSeriesMeta := intersect(matching, lSeriesMeta, rSeriesMeta)
+	takeLeft, correspondingRight, lSeriesMeta, err := intersect(matching, lSeriesMeta, rSeriesMeta)
+	if err != nil {
+		return nil, err
+	}
 
 	lMeta.Tags, lSeriesMeta = utils.DedupeMetadata(lSeriesMeta)
 
@@ -208,27 +253,24 @@ func processBothSeries(
 func intersect(
 	matching *VectorMatching,
 	lhs, rhs []block.SeriesMeta,
-) ([]int, []int, []block.SeriesMeta) {
-	idFunction := HashFunc(matching.On, matching.MatchingLabels...)
-	// The set of signatures for the right-hand side.
-	rightSigs := make(map[uint64]int, len(rhs))
-	for idx, meta := range rhs {
-		rightSigs[idFunction(meta.Tags)] = idx
+) ([]int, []int, []block.SeriesMeta, error) {
+	matches, err := matchSignatures(matching, lhs, rhs, defaultJoinOptions)
+	if err != nil {
+		return nil, nil, nil, err
 	}
 
 	takeLeft := make([]int, 0, initIndexSliceLength)
 	correspondingRight := make([]int, 0, initIndexSliceLength)
 	leftMetas := make([]block.SeriesMeta, 0, initIndexSliceLength)
 
-	for lIdx, ls := range lhs {
+	for lIdx, rIdx := range matches {
 		// If there's a matching entry in the left-hand side Vector, add the sample.
-		id := idFunction(ls.Tags)
-		if rIdx, ok := rightSigs[id]; ok {
+		if rIdx >= 0 {
 			takeLeft = append(takeLeft, lIdx)
 			correspondingRight = append(correspondingRight, rIdx)
-			leftMetas = append(leftMetas, ls)
+			leftMetas = append(leftMetas, lhs[lIdx])
 		}
 	}
 
-	return takeLeft, correspondingRight, leftMetas
+	return takeLeft, correspondingRight, leftMetas, nil
 }
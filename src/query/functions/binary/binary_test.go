@@ -139,6 +139,38 @@ func TestScalars(t *testing.T) {
 	}
 }
 
+func TestTimeVaryingScalar(t *testing.T) {
+	values, bounds := test.GenerateValuesAndBounds(nil, nil)
+	op, err := NewOp(
+		GreaterType,
+		NodeParams{
+			LNode:     parser.NodeID(0),
+			RNode:     parser.NodeID(1),
+			RIsScalar: true,
+		},
+	)
+	require.NoError(t, err)
+
+	c, sink := executor.NewControllerWithSink(parser.NodeID(2))
+	node := op.(baseOp).Node(c, transform.Options{})
+
+	// NB: The rhs is a single series without tags, such as the result of
+	// the scalar function.
+	scalar := test.NewBlockFromValuesWithSeriesMeta(bounds,
+		[]block.SeriesMeta{{Tags: models.EmptyTags()}},
+		[][]float64{{1, 1, 8, 8, 8}})
+	err = node.Process(parser.NodeID(1), scalar)
+	require.NoError(t, err)
+	err = node.Process(parser.NodeID(0), test.NewBlockFromValues(bounds, values))
+	require.NoError(t, err)
+
+	expected := [][]float64{
+		{math.NaN(), math.NaN(), math.NaN(), math.NaN(), math.NaN()},
+		{5, 6, math.NaN(), math.NaN(), 9},
+	}
+	test.EqualsWithNans(t, expected, sink.Values)
+}
+
 func TestScalarsReturnBoolFalse(t *testing.T) {
 	_, bounds := test.GenerateValuesAndBounds(nil, nil)
 
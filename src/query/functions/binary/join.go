@@ -0,0 +1,438 @@
+// Copyright (c) 2018 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package binary
+
+import (
+	"bufio"
+	"container/heap"
+	"encoding/binary"
+	"io"
+	"io/ioutil"
+	"os"
+	"sort"
+
+	"github.com/m3db/m3/src/query/block"
+	"github.com/m3db/m3/src/query/models"
+)
+
+const (
+	// defaultSortMergeThreshold is the number of series both sides of a binary
+	// operation must exceed for the series to be matched by a sort-merge join
+	// of their signatures rather than a hash map of one side.
+	defaultSortMergeThreshold = 100000
+
+	// defaultSpillThreshold is the number of series of a side above which its
+	// sorted signatures are spilled to disk in runs of this size.
+	defaultSpillThreshold = 2000000
+
+	spillFilePrefix = "m3query-join-"
+	signatureSize   = 16
+)
+
+// joinOptions are the thresholds of the join strategies used to match the
+// series of two sides of a binary operation.
+type joinOptions struct {
+	sortMergeThreshold int
+	spillThreshold     int
+	spillDir           string
+}
+
+var defaultJoinOptions = joinOptions{
+	sortMergeThreshold: defaultSortMergeThreshold,
+	spillThreshold:     defaultSpillThreshold,
+}
+
+// matchSignatures returns the index of the rhs series matching each lhs
+// series, or -1 if there is no match. If several rhs series share a
+// signature the last of them is matched.
+func matchSignatures(
+	matching *VectorMatching,
+	lhs, rhs []block.SeriesMeta,
+	opts joinOptions,
+) ([]int, error) {
+	idFunction := HashFunc(matching.On, matching.MatchingLabels...)
+	if len(lhs) <= opts.sortMergeThreshold || len(rhs) <= opts.sortMergeThreshold {
+		return hashJoin(idFunction, lhs, rhs), nil
+	}
+
+	return sortMergeJoin(idFunction, lhs, rhs, opts)
+}
+
+// hashJoin matches series with a hash map of the rhs signatures.
+func hashJoin(
+	idFunction func(models.Tags) uint64,
+	lhs, rhs []block.SeriesMeta,
+) []int {
+	// The set of signatures for the right-hand side.
+	rightSigs := make(map[uint64]int, len(rhs))
+	for idx, meta := range rhs {
+		rightSigs[idFunction(meta.Tags)] = idx
+	}
+
+	matches := make([]int, len(lhs))
+	for i, ls := range lhs {
+		// If there's a matching entry in the right-hand side Vector, add the sample.
+		if idx, ok := rightSigs[idFunction(ls.Tags)]; ok {
+			matches[i] = idx
+		} else {
+			matches[i] = -1
+		}
+	}
+
+	return matches
+}
+
+// sortMergeJoin matches series by sorting the signatures of both sides and
+// merging them, which only holds the signatures of each side in memory, or
+// none of them if they are spilled to disk.
+func sortMergeJoin(
+	idFunction func(models.Tags) uint64,
+	lhs, rhs []block.SeriesMeta,
+	opts joinOptions,
+) ([]int, error) {
+	lIter, err := sortedSignatures(idFunction, lhs, opts)
+	if err != nil {
+		return nil, err
+	}
+	defer lIter.Close()
+
+	rSorted, err := sortedSignatures(idFunction, rhs, opts)
+	if err != nil {
+		return nil, err
+	}
+	defer rSorted.Close()
+	rIter := &lastSignatureIter{iter: rSorted}
+
+	matches := make([]int, len(lhs))
+	for i := range matches {
+		matches[i] = -1
+	}
+
+	lOk, rOk := lIter.Next(), rIter.Next()
+	for lOk && rOk {
+		l, r := lIter.Current(), rIter.Current()
+		switch {
+		case l.sig < r.sig:
+			lOk = lIter.Next()
+		case l.sig > r.sig:
+			rOk = rIter.Next()
+		default:
+			// NB: Several lhs series may match the same rhs series, so only
+			// the lhs is advanced.
+			matches[l.idx] = r.idx
+			lOk = lIter.Next()
+		}
+	}
+
+	if err := lIter.Err(); err != nil {
+		return nil, err
+	}
+	if err := rIter.Err(); err != nil {
+		return nil, err
+	}
+
+	return matches, nil
+}
+
+type signature struct {
+	sig uint64
+	idx int
+}
+
+type signatures []signature
+
+func (s signatures) Len() int      { return len(s) }
+func (s signatures) Swap(i, j int) { s[i], s[j] = s[j], s[i] }
+func (s signatures) Less(i, j int) bool {
+	if s[i].sig != s[j].sig {
+		return s[i].sig < s[j].sig
+	}
+	return s[i].idx < s[j].idx
+}
+
+// signatureIter iterates over signatures ordered by signature then index.
+type signatureIter interface {
+	Next() bool
+	Current() signature
+	Err() error
+	Close() error
+}
+
+func sortedSignatures(
+	idFunction func(models.Tags) uint64,
+	metas []block.SeriesMeta,
+	opts joinOptions,
+) (signatureIter, error) {
+	if len(metas) <= opts.spillThreshold {
+		return newSliceSignatureIter(signaturesOf(idFunction, metas, 0)), nil
+	}
+
+	return spillSignatures(idFunction, metas, opts)
+}
+
+func signaturesOf(
+	idFunction func(models.Tags) uint64,
+	metas []block.SeriesMeta,
+	offset int,
+) signatures {
+	sigs := make(signatures, len(metas))
+	for i, meta := range metas {
+		sigs[i] = signature{sig: idFunction(meta.Tags), idx: offset + i}
+	}
+
+	sort.Sort(sigs)
+	return sigs
+}
+
+type sliceSignatureIter struct {
+	sigs signatures
+	idx  int
+}
+
+func newSliceSignatureIter(sigs signatures) *sliceSignatureIter {
+	return &sliceSignatureIter{sigs: sigs, idx: -1}
+}
+
+func (it *sliceSignatureIter) Next() bool {
+	it.idx++
+	return it.idx < len(it.sigs)
+}
+
+func (it *sliceSignatureIter) Current() signature { return it.sigs[it.idx] }
+func (it *sliceSignatureIter) Err() error         { return nil }
+func (it *sliceSignatureIter) Close() error       { return nil }
+
+// lastSignatureIter only returns the last signature of those that are equal.
+type lastSignatureIter struct {
+	iter    signatureIter
+	curr    signature
+	next    signature
+	hasNext bool
+	started bool
+}
+
+func (it *lastSignatureIter) Next() bool {
+	if !it.started {
+		it.started = true
+		if it.hasNext = it.iter.Next(); it.hasNext {
+			it.next = it.iter.Current()
+		}
+	}
+
+	if !it.hasNext {
+		return false
+	}
+
+	it.curr = it.next
+	for {
+		if it.hasNext = it.iter.Next(); !it.hasNext {
+			return true
+		}
+
+		it.next = it.iter.Current()
+		if it.next.sig != it.curr.sig {
+			return true
+		}
+		it.curr = it.next
+	}
+}
+
+func (it *lastSignatureIter) Current() signature { return it.curr }
+func (it *lastSignatureIter) Err() error         { return it.iter.Err() }
+func (it *lastSignatureIter) Close() error       { return it.iter.Close() }
+
+// spillSignatures writes the signatures to disk in sorted runs of at most
+// spillThreshold signatures and returns an iterator merging the runs.
+func spillSignatures(
+	idFunction func(models.Tags) uint64,
+	metas []block.SeriesMeta,
+	opts joinOptions,
+) (signatureIter, error) {
+	merged := &mergedSignatureIter{}
+	for start := 0; start < len(metas); start += opts.spillThreshold {
+		end := start + opts.spillThreshold
+		if end > len(metas) {
+			end = len(metas)
+		}
+
+		run, err := writeSignatureRun(signaturesOf(idFunction, metas[start:end], start), opts.spillDir)
+		if err != nil {
+			merged.Close()
+			return nil, err
+		}
+
+		merged.runs = append(merged.runs, run)
+	}
+
+	return merged, nil
+}
+
+func writeSignatureRun(sigs signatures, dir string) (*signatureRun, error) {
+	file, err := ioutil.TempFile(dir, spillFilePrefix)
+	if err != nil {
+		return nil, err
+	}
+
+	run := &signatureRun{file: file}
+	writer := bufio.NewWriter(file)
+	var buf [signatureSize]byte
+	for _, s := range sigs {
+		binary.LittleEndian.PutUint64(buf[:8], s.sig)
+		binary.LittleEndian.PutUint64(buf[8:], uint64(s.idx))
+		if _, err := writer.Write(buf[:]); err != nil {
+			run.Close()
+			return nil, err
+		}
+	}
+
+	if err := writer.Flush(); err != nil {
+		run.Close()
+		return nil, err
+	}
+
+	if _, err := file.Seek(0, io.SeekStart); err != nil {
+		run.Close()
+		return nil, err
+	}
+
+	run.reader = bufio.NewReader(file)
+	return run, nil
+}
+
+// signatureRun reads a sorted run of signatures from a spill file, which is
+// removed once closed.
+type signatureRun struct {
+	file   *os.File
+	reader *bufio.Reader
+	curr   signature
+	err    error
+}
+
+func (r *signatureRun) next() bool {
+	var buf [signatureSize]byte
+	if _, err := io.ReadFull(r.reader, buf[:]); err != nil {
+		if err != io.EOF {
+			r.err = err
+		}
+		return false
+	}
+
+	r.curr = signature{
+		sig: binary.LittleEndian.Uint64(buf[:8]),
+		idx: int(binary.LittleEndian.Uint64(buf[8:])),
+	}
+	return true
+}
+
+func (r *signatureRun) Close() error {
+	err := r.file.Close()
+	if removeErr := os.Remove(r.file.Name()); err == nil {
+		err = removeErr
+	}
+	return err
+}
+
+// mergedSignatureIter merges sorted runs of signatures.
+type mergedSignatureIter struct {
+	runs    []*signatureRun
+	heap    signatureRunHeap
+	curr    signature
+	started bool
+	err     error
+}
+
+func (it *mergedSignatureIter) Next() bool {
+	if it.err != nil {
+		return false
+	}
+
+	if !it.started {
+		it.started = true
+		for _, run := range it.runs {
+			if it.advance(run) {
+				it.heap = append(it.heap, run)
+			}
+		}
+		heap.Init(&it.heap)
+	} else if len(it.heap) > 0 {
+		// Advance the run of the current signature.
+		if it.advance(it.heap[0]) {
+			heap.Fix(&it.heap, 0)
+		} else {
+			heap.Pop(&it.heap)
+		}
+	}
+
+	if it.err != nil || len(it.heap) == 0 {
+		return false
+	}
+
+	it.curr = it.heap[0].curr
+	return true
+}
+
+func (it *mergedSignatureIter) advance(run *signatureRun) bool {
+	if run.next() {
+		return true
+	}
+
+	if run.err != nil && it.err == nil {
+		it.err = run.err
+	}
+	return false
+}
+
+func (it *mergedSignatureIter) Current() signature { return it.curr }
+func (it *mergedSignatureIter) Err() error         { return it.err }
+
+func (it *mergedSignatureIter) Close() error {
+	var firstErr error
+	for _, run := range it.runs {
+		if err := run.Close(); err != nil && firstErr == nil {
+			firstErr = err
+		}
+	}
+	it.runs = nil
+	return firstErr
+}
+
+type signatureRunHeap []*signatureRun
+
+func (h signatureRunHeap) Len() int { return len(h) }
+func (h signatureRunHeap) Less(i, j int) bool {
+	if h[i].curr.sig != h[j].curr.sig {
+		return h[i].curr.sig < h[j].curr.sig
+	}
+	return h[i].curr.idx < h[j].curr.idx
+}
+func (h signatureRunHeap) Swap(i, j int) { h[i], h[j] = h[j], h[i] }
+
+func (h *signatureRunHeap) Push(x interface{}) {
+	*h = append(*h, x.(*signatureRun))
+}
+
+func (h *signatureRunHeap) Pop() interface{} {
+	old := *h
+	n := len(old)
+	run := old[n-1]
+	*h = old[:n-1]
+	return run
+}
@@ -0,0 +1,74 @@
+// Copyright (c) 2018 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package binary
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"testing"
+
+	"github.com/m3db/m3/src/query/block"
+	"github.com/m3db/m3/src/query/models"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func joinTestMetas(n, step int) []block.SeriesMeta {
+	metas := make([]block.SeriesMeta, n)
+	for i := range metas {
+		// NB: Series are repeated every 7 values so that signatures are
+		// shared by several series of a side.
+		metas[i].Tags = models.Tags{
+			{Name: "a", Value: fmt.Sprint((i * step) % 7)},
+			{Name: "b", Value: fmt.Sprint(i)},
+		}
+	}
+	return metas
+}
+
+func TestSortMergeJoinMatchesHashJoin(t *testing.T) {
+	dir, err := ioutil.TempDir("", "join")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	var (
+		matching = &VectorMatching{On: true, MatchingLabels: []string{"a"}}
+		lhs      = joinTestMetas(50, 1)
+		rhs      = joinTestMetas(30, 3)
+	)
+	expected, err := matchSignatures(matching, lhs, rhs, defaultJoinOptions)
+	require.NoError(t, err)
+
+	for _, opts := range []joinOptions{
+		{sortMergeThreshold: 10, spillThreshold: 100},
+		{sortMergeThreshold: 10, spillThreshold: 4, spillDir: dir},
+	} {
+		actual, err := matchSignatures(matching, lhs, rhs, opts)
+		require.NoError(t, err)
+		assert.Equal(t, expected, actual, "options: %+v", opts)
+	}
+
+	files, err := ioutil.ReadDir(dir)
+	require.NoError(t, err)
+	assert.Len(t, files, 0, "spill files removed")
+}
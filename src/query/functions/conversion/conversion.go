@@ -0,0 +1,142 @@
+// Copyright (c) 2018 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+// Package conversion converts between instant vectors and scalars.
+package conversion
+
+import (
+	"fmt"
+	"math"
+
+	"github.com/m3db/m3/src/query/block"
+	"github.com/m3db/m3/src/query/executor/transform"
+	"github.com/m3db/m3/src/query/models"
+	"github.com/m3db/m3/src/query/parser"
+)
+
+const (
+	// ScalarType returns the value of a single element vector as a scalar at
+	// each step, or NaN if the vector does not have exactly one element.
+	ScalarType = "scalar"
+
+	// VectorType returns a scalar as a vector without labels.
+	VectorType = "vector"
+)
+
+// NewConversionOp creates a new conversion operation
+func NewConversionOp(opType string) (transform.Params, error) {
+	switch opType {
+	case ScalarType, VectorType:
+		return conversionOp{opType: opType}, nil
+	}
+
+	return nil, fmt.Errorf("unknown conversion type: %s", opType)
+}
+
+// conversionOp stores required properties for conversion ops
+type conversionOp struct {
+	opType string
+}
+
+// OpType for the operator
+func (o conversionOp) OpType() string {
+	return o.opType
+}
+
+// String representation
+func (o conversionOp) String() string {
+	return fmt.Sprintf("type: %s", o.OpType())
+}
+
+// Node creates an execution node
+func (o conversionOp) Node(controller *transform.Controller, _ transform.Options) transform.OpNode {
+	return &conversionNode{
+		op:         o,
+		controller: controller,
+	}
+}
+
+type conversionNode struct {
+	op         conversionOp
+	controller *transform.Controller
+}
+
+// Process converts the block into a block with a single series without
+// labels. Both the scalar and vector results are a single series, since
+// scalars that vary over time cannot be represented by a scalar block.
+func (n *conversionNode) Process(ID parser.NodeID, b block.Block) error {
+	stepIter, err := b.StepIter()
+	if err != nil {
+		return err
+	}
+
+	meta := stepIter.Meta()
+	meta.Tags = models.EmptyTags()
+	seriesMetas := []block.SeriesMeta{{Tags: models.EmptyTags()}}
+	builder, err := n.controller.BlockBuilder(meta, seriesMetas)
+	if err != nil {
+		return err
+	}
+
+	if err := builder.AddCols(stepIter.StepCount()); err != nil {
+		return err
+	}
+
+	for index := 0; stepIter.Next(); index++ {
+		step, err := stepIter.Current()
+		if err != nil {
+			return err
+		}
+
+		builder.AppendValue(index, n.convert(step.Values()))
+	}
+
+	nextBlock := builder.Build()
+	defer nextBlock.Close()
+	return n.controller.Process(nextBlock)
+}
+
+func (n *conversionNode) convert(values []float64) float64 {
+	if n.op.opType == VectorType {
+		// NB: The argument of vector is a scalar, which is a single value.
+		if len(values) == 0 {
+			return math.NaN()
+		}
+		return values[0]
+	}
+
+	// NB: Series without a value at a step are not elements of the vector
+	// at that step.
+	var (
+		value = math.NaN()
+		count int
+	)
+	for _, v := range values {
+		if math.IsNaN(v) {
+			continue
+		}
+		value = v
+		count++
+	}
+	if count != 1 {
+		return math.NaN()
+	}
+	return value
+}
@@ -0,0 +1,72 @@
+// Copyright (c) 2018 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package conversion
+
+import (
+	"math"
+	"testing"
+
+	"github.com/m3db/m3/src/query/block"
+	"github.com/m3db/m3/src/query/executor/transform"
+	"github.com/m3db/m3/src/query/parser"
+	"github.com/m3db/m3/src/query/test"
+	"github.com/m3db/m3/src/query/test/executor"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+var nan = math.NaN()
+
+func TestScalar(t *testing.T) {
+	v := [][]float64{
+		{0, nan, 2, nan, 4},
+		{5, nan, nan, 8, 9},
+	}
+	values, bounds := test.GenerateValuesAndBounds(v, nil)
+	op, err := NewConversionOp(ScalarType)
+	require.NoError(t, err)
+
+	c, sink := executor.NewControllerWithSink(parser.NodeID(1))
+	node := op.Node(c, transform.Options{})
+	err = node.Process(parser.NodeID(0), test.NewBlockFromValues(bounds, values))
+	require.NoError(t, err)
+	test.EqualsWithNans(t, [][]float64{{nan, nan, 2, 8, nan}}, sink.Values)
+	require.Len(t, sink.Metas, 1)
+	assert.Len(t, sink.Metas[0].Tags, 0)
+}
+
+func TestVector(t *testing.T) {
+	_, bounds := test.GenerateValuesAndBounds(nil, nil)
+	op, err := NewConversionOp(VectorType)
+	require.NoError(t, err)
+
+	c, sink := executor.NewControllerWithSink(parser.NodeID(1))
+	node := op.Node(c, transform.Options{})
+	err = node.Process(parser.NodeID(0), block.NewScalar(3, bounds))
+	require.NoError(t, err)
+	assert.Equal(t, [][]float64{{3, 3, 3, 3, 3}}, sink.Values)
+}
+
+func TestUnknownConversion(t *testing.T) {
+	_, err := NewConversionOp("matrix")
+	assert.Error(t, err)
+}
@@ -53,6 +53,8 @@ type FetchNode struct {
 	controller *transform.Controller
 	storage    storage.Storage
 	timespec   transform.TimeSpec
+	lookback   models.LookbackOptions
+	controls   models.FetchControls
 	debug      bool
 }
 
@@ -76,7 +78,15 @@ func (o FetchOp) String() string {
 
 // Node creates an execution node
 func (o FetchOp) Node(controller *transform.Controller, storage storage.Storage, options transform.Options) parser.Source {
-	return &FetchNode{op: o, controller: controller, storage: storage, timespec: options.TimeSpec, debug: options.Debug}
+	return &FetchNode{
+		op:         o,
+		controller: controller,
+		storage:    storage,
+		timespec:   options.TimeSpec,
+		lookback:   options.Lookback,
+		controls:   options.FetchControls,
+		debug:      options.Debug,
+	}
 }
 
 // Execute runs the fetch node operation
@@ -90,7 +100,10 @@ func (n *FetchNode) Execute(ctx context.Context) error {
 		End:         endTime,
 		TagMatchers: n.op.Matchers,
 		Interval:    timeSpec.Step,
-	}, &storage.FetchOptions{})
+		Lookback:    n.lookback,
+	}, &storage.FetchOptions{
+		FetchControls: n.controls,
+	})
 	if err != nil {
 		return err
 	}
@@ -0,0 +1,121 @@
+// Copyright (c) 2018 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package linear
+
+import (
+	"fmt"
+
+	"github.com/m3db/m3/src/query/executor/transform"
+)
+
+// ConvertUnitType scales each value in the timeseries from the unit given by
+// the first argument to the unit given by the second, e.g.
+// convert_unit(bytes, "B", "MiB"). Both units must measure the same quantity.
+const ConvertUnitType = "convert_unit"
+
+type unitQuantity string
+
+const (
+	quantityData     unitQuantity = "data"
+	quantityDuration unitQuantity = "duration"
+)
+
+type unit struct {
+	quantity unitQuantity
+	// factor is the size of the unit in the base unit of its quantity.
+	factor float64
+}
+
+var units = map[string]unit{
+	"B":   {quantityData, 1},
+	"kB":  {quantityData, 1e3},
+	"MB":  {quantityData, 1e6},
+	"GB":  {quantityData, 1e9},
+	"TB":  {quantityData, 1e12},
+	"PB":  {quantityData, 1e15},
+	"KiB": {quantityData, 1 << 10},
+	"MiB": {quantityData, 1 << 20},
+	"GiB": {quantityData, 1 << 30},
+	"TiB": {quantityData, 1 << 40},
+	"PiB": {quantityData, 1 << 50},
+
+	"ns":  {quantityDuration, 1e-9},
+	"us":  {quantityDuration, 1e-6},
+	"µs":  {quantityDuration, 1e-6},
+	"ms":  {quantityDuration, 1e-3},
+	"s":   {quantityDuration, 1},
+	"min": {quantityDuration, 60},
+	"h":   {quantityDuration, 60 * 60},
+	"d":   {quantityDuration, 24 * 60 * 60},
+	"w":   {quantityDuration, 7 * 24 * 60 * 60},
+}
+
+// NewConvertUnitOp creates a new unit conversion op, the args are the unit
+// to convert from and the unit to convert to
+func NewConvertUnitOp(args []interface{}) (BaseOp, error) {
+	if len(args) != 2 {
+		return emptyOp, fmt.Errorf("invalid number of args for %s: %d", ConvertUnitType, len(args))
+	}
+
+	var names [2]string
+	for i, arg := range args {
+		name, ok := arg.(string)
+		if !ok {
+			return emptyOp, fmt.Errorf("unable to cast to unit argument: %v", arg)
+		}
+		names[i] = name
+	}
+
+	from, ok := units[names[0]]
+	if !ok {
+		return emptyOp, fmt.Errorf("unknown unit: %s", names[0])
+	}
+
+	to, ok := units[names[1]]
+	if !ok {
+		return emptyOp, fmt.Errorf("unknown unit: %s", names[1])
+	}
+
+	if from.quantity != to.quantity {
+		return emptyOp, fmt.Errorf("unable to convert %s unit %s to %s unit %s",
+			from.quantity, names[0], to.quantity, names[1])
+	}
+
+	scale := from.factor / to.factor
+	return BaseOp{
+		operatorType: ConvertUnitType,
+		processorFn: func(op BaseOp, controller *transform.Controller) Processor {
+			return &convertUnitNode{scale: scale}
+		},
+	}, nil
+}
+
+type convertUnitNode struct {
+	scale float64
+}
+
+func (c *convertUnitNode) Process(values []float64) []float64 {
+	for i := range values {
+		values[i] *= c.scale
+	}
+
+	return values
+}
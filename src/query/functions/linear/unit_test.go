@@ -0,0 +1,76 @@
+// Copyright (c) 2018 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package linear
+
+import (
+	"testing"
+
+	"github.com/m3db/m3/src/query/executor/transform"
+	"github.com/m3db/m3/src/query/parser"
+	"github.com/m3db/m3/src/query/test"
+	"github.com/m3db/m3/src/query/test/executor"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestConvertUnit(t *testing.T) {
+	v := [][]float64{
+		{0, nan, 1 << 20, 3 << 20, 1 << 19},
+		{nan, 1 << 30, 1 << 10, 0, 1 << 21},
+	}
+	values, bounds := test.GenerateValuesAndBounds(v, nil)
+
+	block := test.NewBlockFromValues(bounds, values)
+	c, sink := executor.NewControllerWithSink(parser.NodeID(1))
+	op, err := NewConvertUnitOp([]interface{}{"B", "MiB"})
+	require.NoError(t, err)
+	node := op.Node(c, transform.Options{})
+	err = node.Process(parser.NodeID(0), block)
+	require.NoError(t, err)
+
+	expected := [][]float64{
+		{0, nan, 1, 3, 0.5},
+		{nan, 1024, 1.0 / 1024, 0, 2},
+	}
+	assert.Len(t, sink.Values, 2)
+	test.EqualsWithNans(t, expected, sink.Values)
+}
+
+func TestConvertUnitDuration(t *testing.T) {
+	op, err := NewConvertUnitOp([]interface{}{"ms", "min"})
+	require.NoError(t, err)
+	processor := op.processorFn(op, nil)
+	test.EqualsWithNansWithDelta(t, []float64{1, 0.5},
+		processor.Process([]float64{60000, 30000}), 0.0001)
+}
+
+func TestConvertUnitInvalidArgs(t *testing.T) {
+	for _, args := range [][]interface{}{
+		{"B"},
+		{"B", "parsecs"},
+		{"B", "ms"},
+		{"B", 1.0},
+	} {
+		_, err := NewConvertUnitOp(args)
+		assert.Error(t, err, "%v", args)
+	}
+}
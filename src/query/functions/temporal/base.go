@@ -29,6 +29,7 @@ import (
 
 	"github.com/m3db/m3/src/query/block"
 	"github.com/m3db/m3/src/query/executor/transform"
+	"github.com/m3db/m3/src/query/functions/utils"
 	"github.com/m3db/m3/src/query/models"
 	"github.com/m3db/m3/src/query/parser"
 	"github.com/m3db/m3/src/query/util/logging"
@@ -44,6 +45,9 @@ type baseOp struct {
 	duration     time.Duration
 	processorFn  MakeProcessor
 	aggFunc      aggFunc
+	// extendByLookback extends the window of each series by the lookback of
+	// its metric type, for functions that only need the latest samples.
+	extendByLookback bool
 }
 
 // skipping lint check for a single operator type since we will be adding more
@@ -128,7 +132,7 @@ func (c *baseNode) Process(ID parser.NodeID, b block.Block) error {
 	c.cache.init(bounds)
 	blockDuration := bounds.Duration
 	// Figure out the maximum blocks needed for the temporal function
-	maxBlocks := int(math.Ceil(float64(c.op.duration) / float64(blockDuration)))
+	maxBlocks := int(math.Ceil(float64(c.maxWindow()) / float64(blockDuration)))
 
 	// Figure out the leftmost block
 	leftRangeStart := bounds.Previous(maxBlocks)
@@ -185,6 +189,23 @@ func (c *baseNode) Process(ID parser.NodeID, b block.Block) error {
 	return c.processCompletedBlocks(processRequests, maxBlocks)
 }
 
+// maxWindow returns the longest window of any series.
+func (c *baseNode) maxWindow() time.Duration {
+	if !c.op.extendByLookback {
+		return c.op.duration
+	}
+	return c.op.duration + c.transformOpts.Lookback.Max()
+}
+
+// lookbackSteps returns the number of steps the window of a series is
+// extended by.
+func (c *baseNode) lookbackSteps(meta block.SeriesMeta, stepSize time.Duration) int {
+	if !c.op.extendByLookback {
+		return 0
+	}
+	return int(c.transformOpts.Lookback.Lookback(meta.Tags) / stepSize)
+}
+
 // processCurrent processes the current block. For the current block, figure out whether we have enough previous blocks which can help process it
 func (c *baseNode) processCurrent(bounds models.Bounds, leftRangeStart models.Bounds) ([]block.Block, bool, error) {
 	numBlocks := bounds.Blocks(leftRangeStart.Start)
@@ -251,6 +272,27 @@ func (c *baseNode) processSingleRequest(request processRequest) error {
 		resultSeriesMeta[i].Name = tags.ID()
 	}
 
+	// NB: Dropping the name of series can make their label sets collide.
+	keep, err := utils.DedupeSeries(c.op.operatorType, seriesMeta,
+		resultSeriesMeta, c.transformOpts.DedupeSeries)
+	if err != nil {
+		return err
+	}
+
+	keepSeries := make([]bool, len(resultSeriesMeta))
+	if keep == nil {
+		for i := range keepSeries {
+			keepSeries[i] = true
+		}
+	} else {
+		keptMetas := make([]block.SeriesMeta, 0, len(keep))
+		for _, idx := range keep {
+			keepSeries[idx] = true
+			keptMetas = append(keptMetas, resultSeriesMeta[idx])
+		}
+		resultSeriesMeta = keptMetas
+	}
+
 	builder, err := c.controller.BlockBuilder(seriesIter.Meta(), resultSeriesMeta)
 	if err != nil {
 		return err
@@ -261,11 +303,14 @@ func (c *baseNode) processSingleRequest(request processRequest) error {
 	}
 
 	aggDuration := c.op.duration
-	steps := int((aggDuration + bounds.Duration) / bounds.StepSize)
+	steps := int((c.maxWindow() + bounds.Duration) / bounds.StepSize)
 	values := make([]float64, 0, steps)
 	desiredLength := int(aggDuration / bounds.StepSize)
-	for seriesIter.Next() {
+	for idx := 0; seriesIter.Next(); idx++ {
 		values = values[:0]
+		// NB: The window is only extended by the lookback once it covers the
+		// duration, so that the first steps are not dropped.
+		windowLength := desiredLength + c.lookbackSteps(seriesMeta[idx], bounds.StepSize)
 		for i, iter := range depIters {
 			if !iter.Next() {
 				return fmt.Errorf("incorrect number of series for block: %d", i)
@@ -284,14 +329,20 @@ func (c *baseNode) processSingleRequest(request processRequest) error {
 			return err
 		}
 
+		if !keepSeries[idx] {
+			continue
+		}
+
 		for i := 0; i < series.Len(); i++ {
 			val := series.ValueAtStep(i)
 			values = append(values, val)
 			newVal := math.NaN()
 			// Remove the older values from slice as newer values are pushed in.
 			// TODO: Consider using a rotating slice since this is inefficient
+			if windowLength < len(values) {
+				values = values[len(values)-windowLength:]
+			}
 			if desiredLength <= len(values) {
-				values = values[len(values)-desiredLength:]
 				newVal = c.processor.Process(values)
 			}
 
@@ -0,0 +1,188 @@
+// Copyright (c) 2018 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package temporal
+
+import (
+	"fmt"
+	"math"
+	"time"
+
+	"github.com/m3db/m3/src/query/executor/transform"
+)
+
+const (
+	// HoltWintersPredictType predicts the value of each step from the seasonal
+	// decomposition of the values in the range before it, with seasons of the
+	// given duration in seconds.
+	HoltWintersPredictType = "holt_winters_predict"
+
+	// HoltWintersUpperType is the upper bound of the confidence band around
+	// the predicted value.
+	HoltWintersUpperType = "holt_winters_upper"
+
+	// HoltWintersLowerType is the lower bound of the confidence band around
+	// the predicted value.
+	HoltWintersLowerType = "holt_winters_lower"
+
+	// AnomalyScoreType is the deviation of the value of each step from the
+	// predicted value, in units of the expected deviation of the step within
+	// the season. Scores beyond the confidence band scale are anomalies.
+	AnomalyScoreType = "anomaly_score"
+
+	// Smoothing factors of the level, trend and seasonal components, and of
+	// the deviation which uses the seasonal factor.
+	holtWintersLevelFactor    = 0.5
+	holtWintersTrendFactor    = 0.1
+	holtWintersSeasonalFactor = 0.3
+
+	// holtWintersBandScale is the number of expected deviations the bounds of
+	// the confidence band are from the predicted value.
+	holtWintersBandScale = 3
+)
+
+// NewHoltWintersOp creates a new base temporal transform for seasonal
+// Holt-Winters functions, the args are the range and the season in seconds.
+func NewHoltWintersOp(args []interface{}, optype string) (transform.Params, error) {
+	switch optype {
+	case HoltWintersPredictType, HoltWintersUpperType, HoltWintersLowerType, AnomalyScoreType:
+	default:
+		return nil, fmt.Errorf("unknown holt winters type: %s", optype)
+	}
+
+	if len(args) != 2 {
+		return emptyOp, fmt.Errorf("invalid number of args for %s: %d", optype, len(args))
+	}
+
+	seconds, ok := args[1].(float64)
+	if !ok || seconds <= 0 {
+		return emptyOp, fmt.Errorf("season must be a positive number of seconds for %s: %v", optype, args[1])
+	}
+
+	season := time.Duration(seconds * float64(time.Second))
+	return newBaseOp(args[:1], optype, func(
+		op baseOp,
+		controller *transform.Controller,
+		opts transform.Options,
+	) Processor {
+		node := &holtWintersNode{op: op}
+		if step := opts.TimeSpec.Step; step > 0 {
+			node.seasonLength = int(season / step)
+		}
+		return node
+	}, nil)
+}
+
+type holtWintersNode struct {
+	op           baseOp
+	seasonLength int
+}
+
+func (h *holtWintersNode) Process(values []float64) float64 {
+	if len(values) == 0 {
+		return math.NaN()
+	}
+
+	// NB: The last value is the one being predicted, the others are used to
+	// fit the model.
+	last := values[len(values)-1]
+	predicted, deviation, ok := holtWinters(values[:len(values)-1], h.seasonLength)
+	if !ok {
+		return math.NaN()
+	}
+
+	switch h.op.operatorType {
+	case HoltWintersUpperType:
+		return predicted + holtWintersBandScale*deviation
+	case HoltWintersLowerType:
+		return predicted - holtWintersBandScale*deviation
+	case AnomalyScoreType:
+		if math.IsNaN(last) || deviation == 0 {
+			return math.NaN()
+		}
+		return (last - predicted) / deviation
+	default:
+		return predicted
+	}
+}
+
+// holtWinters fits an additive Holt-Winters model with Brutlag deviations
+// to the values and returns the predicted value and expected deviation of
+// the next step. The model needs at least two seasons of values, the first
+// of which must have values.
+func holtWinters(values []float64, seasonLength int) (float64, float64, bool) {
+	n := len(values)
+	if seasonLength < 1 || n < 2*seasonLength {
+		return 0, 0, false
+	}
+
+	firstMean, ok := nanMean(values[:seasonLength])
+	if !ok {
+		return 0, 0, false
+	}
+	secondMean, ok := nanMean(values[seasonLength : 2*seasonLength])
+	if !ok {
+		secondMean = firstMean
+	}
+
+	var (
+		level     = firstMean
+		trend     = (secondMean - firstMean) / float64(seasonLength)
+		seasonal  = make([]float64, seasonLength)
+		deviation = make([]float64, seasonLength)
+	)
+	for i, v := range values[:seasonLength] {
+		if !math.IsNaN(v) {
+			seasonal[i] = v - firstMean
+		}
+	}
+
+	for t := seasonLength; t < n; t++ {
+		idx := t % seasonLength
+		predicted := level + trend + seasonal[idx]
+		y := values[t]
+		if math.IsNaN(y) {
+			// NB: Missing values do not change the model other than by
+			// advancing it.
+			y = predicted
+		}
+
+		prevLevel := level
+		level = holtWintersLevelFactor*(y-seasonal[idx]) +
+			(1-holtWintersLevelFactor)*(level+trend)
+		trend = holtWintersTrendFactor*(level-prevLevel) +
+			(1-holtWintersTrendFactor)*trend
+		seasonal[idx] = holtWintersSeasonalFactor*(y-level) +
+			(1-holtWintersSeasonalFactor)*seasonal[idx]
+		deviation[idx] = holtWintersSeasonalFactor*math.Abs(y-predicted) +
+			(1-holtWintersSeasonalFactor)*deviation[idx]
+	}
+
+	idx := n % seasonLength
+	return level + trend + seasonal[idx], deviation[idx], true
+}
+
+func nanMean(values []float64) (float64, bool) {
+	sum, count := sumAndCount(values)
+	if count == 0 {
+		return 0, false
+	}
+	return sum / count, true
+}
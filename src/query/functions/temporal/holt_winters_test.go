@@ -0,0 +1,94 @@
+// Copyright (c) 2018 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package temporal
+
+import (
+	"math"
+	"testing"
+	"time"
+
+	"github.com/m3db/m3/src/query/executor/transform"
+	"github.com/m3db/m3/src/query/parser"
+	"github.com/m3db/m3/src/query/test/executor"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func newTestHoltWintersNode(t *testing.T, optype string, season float64) *holtWintersNode {
+	op, err := NewHoltWintersOp([]interface{}{time.Hour, season}, optype)
+	require.NoError(t, err)
+
+	c, _ := executor.NewControllerWithSink(parser.NodeID(1))
+	node := op.Node(c, transform.Options{
+		TimeSpec: transform.TimeSpec{Step: time.Minute},
+	})
+	return node.(*baseNode).processor.(*holtWintersNode)
+}
+
+func TestHoltWintersPeriodic(t *testing.T) {
+	values := []float64{1, 2, 3, 1, 2, 3, 1, 2, 3, 1}
+	for _, optype := range []string{
+		HoltWintersPredictType,
+		HoltWintersUpperType,
+		HoltWintersLowerType,
+	} {
+		node := newTestHoltWintersNode(t, optype, 180)
+		assert.Equal(t, 3, node.seasonLength)
+		assert.InDelta(t, 1, node.Process(values), 0.0001, optype)
+	}
+
+	node := newTestHoltWintersNode(t, AnomalyScoreType, 180)
+	assert.True(t, math.IsNaN(node.Process(values)), "no expected deviation")
+}
+
+func TestHoltWintersAnomalyScore(t *testing.T) {
+	values := []float64{1, 2, 3, 1.2, 2, 2.8, 1, 2.1, 3, 0.9, 2, 3.1, 1}
+	score := newTestHoltWintersNode(t, AnomalyScoreType, 180).Process(values)
+	assert.True(t, math.Abs(score) < holtWintersBandScale, "expected value")
+
+	values[len(values)-1] = 10
+	score = newTestHoltWintersNode(t, AnomalyScoreType, 180).Process(values)
+	assert.True(t, score > holtWintersBandScale, "anomalous value")
+
+	upper := newTestHoltWintersNode(t, HoltWintersUpperType, 180).Process(values)
+	lower := newTestHoltWintersNode(t, HoltWintersLowerType, 180).Process(values)
+	assert.True(t, lower < 1 && 1 < upper)
+	assert.True(t, upper < 10)
+}
+
+func TestHoltWintersNotEnoughValues(t *testing.T) {
+	node := newTestHoltWintersNode(t, HoltWintersPredictType, 180)
+	assert.True(t, math.IsNaN(node.Process([]float64{1, 2, 3, 1, 2, 3})))
+	assert.True(t, math.IsNaN(node.Process(nil)))
+
+	nans := []float64{math.NaN(), math.NaN(), math.NaN(), 1, 2, 3, 1}
+	assert.True(t, math.IsNaN(node.Process(nans)))
+}
+
+func TestHoltWintersInvalidArgs(t *testing.T) {
+	_, err := NewHoltWintersOp([]interface{}{time.Hour, 180.0}, "unknown")
+	assert.Error(t, err)
+	_, err = NewHoltWintersOp([]interface{}{time.Hour}, AnomalyScoreType)
+	assert.Error(t, err)
+	_, err = NewHoltWintersOp([]interface{}{time.Hour, -1.0}, AnomalyScoreType)
+	assert.Error(t, err)
+}
@@ -41,7 +41,15 @@ const (
 // NewRateOp creates a new base temporal transform for rate functions
 func NewRateOp(args []interface{}, optype string) (transform.Params, error) {
 	if optype == IRateType || optype == IDeltaType {
-		return newBaseOp(args, optype, newRateNode, nil)
+		op, err := newBaseOp(args, optype, newRateNode, nil)
+		if err != nil {
+			return nil, err
+		}
+
+		// NB: Rates only depend on the last two samples, so a sample before
+		// the range within the lookback is used if the range has only one.
+		op.extendByLookback = true
+		return op, nil
 	}
 
 	return nil, fmt.Errorf("unknown rate type: %s", optype)
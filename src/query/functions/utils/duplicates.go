@@ -0,0 +1,140 @@
+// Copyright (c) 2018 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package utils
+
+import (
+	"bytes"
+	"fmt"
+	"strings"
+
+	"github.com/m3db/m3/src/query/block"
+	"github.com/m3db/m3/src/query/models"
+)
+
+// maxDuplicateExamples is the max number of colliding label sets named by a
+// duplicate series error.
+const maxDuplicateExamples = 5
+
+// DuplicateSeries is a label set shared by several result series and the IDs
+// of the series they originate from.
+type DuplicateSeries struct {
+	Tags      models.Tags
+	SeriesIDs []string
+}
+
+// DuplicateSeriesError is returned when a function produces several series
+// with the same label set.
+type DuplicateSeriesError struct {
+	// Function is the function that produced the series.
+	Function string
+	// Duplicates are the first colliding label sets.
+	Duplicates []DuplicateSeries
+	// Total is the number of colliding label sets.
+	Total int
+}
+
+func (e *DuplicateSeriesError) Error() string {
+	var buf bytes.Buffer
+	fmt.Fprintf(&buf, "%s produced %d duplicate label sets", e.Function, e.Total)
+	for _, d := range e.Duplicates {
+		fmt.Fprintf(&buf, "; {%s} from series %s", tagsString(d.Tags),
+			strings.Join(d.SeriesIDs, ", "))
+	}
+	if more := e.Total - len(e.Duplicates); more > 0 {
+		fmt.Fprintf(&buf, "; and %d more", more)
+	}
+	buf.WriteString(", enable series deduplication to keep the first series of each label set")
+	return buf.String()
+}
+
+func tagsString(tags models.Tags) string {
+	pairs := make([]string, 0, len(tags))
+	for _, t := range tags {
+		pairs = append(pairs, fmt.Sprintf("%s=%q", t.Name, t.Value))
+	}
+	return strings.Join(pairs, ", ")
+}
+
+// DedupeSeries checks the series produced by a function for duplicate label
+// sets, where each result series originates from the source series at the
+// same index. It returns the indices of the series to keep, which is nil if
+// all of them are kept. If there are duplicates an error is returned unless
+// keepFirst is set, in which case only the first series of each label set is
+// kept.
+func DedupeSeries(
+	function string,
+	sourceMetas, resultMetas []block.SeriesMeta,
+	keepFirst bool,
+) ([]int, error) {
+	var (
+		first      = make(map[string]int, len(resultMetas))
+		collisions map[int][]int
+		order      []int
+	)
+	for i, meta := range resultMetas {
+		id := meta.Tags.ID()
+		firstIdx, ok := first[id]
+		if !ok {
+			first[id] = i
+			continue
+		}
+
+		if collisions == nil {
+			collisions = make(map[int][]int)
+		}
+		if _, ok := collisions[firstIdx]; !ok {
+			order = append(order, firstIdx)
+		}
+		collisions[firstIdx] = append(collisions[firstIdx], i)
+	}
+
+	if len(collisions) == 0 {
+		return nil, nil
+	}
+
+	if keepFirst {
+		keep := make([]int, 0, len(first))
+		for i, meta := range resultMetas {
+			if first[meta.Tags.ID()] == i {
+				keep = append(keep, i)
+			}
+		}
+		return keep, nil
+	}
+
+	err := &DuplicateSeriesError{Function: function, Total: len(order)}
+	for _, firstIdx := range order {
+		if len(err.Duplicates) == maxDuplicateExamples {
+			break
+		}
+
+		indices := append([]int{firstIdx}, collisions[firstIdx]...)
+		ids := make([]string, 0, len(indices))
+		for _, idx := range indices {
+			ids = append(ids, sourceMetas[idx].Name)
+		}
+		err.Duplicates = append(err.Duplicates, DuplicateSeries{
+			Tags:      resultMetas[firstIdx].Tags,
+			SeriesIDs: ids,
+		})
+	}
+	return nil, err
+}
@@ -0,0 +1,62 @@
+// Copyright (c) 2018 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package utils
+
+import (
+	"testing"
+
+	"github.com/m3db/m3/src/query/block"
+	"github.com/m3db/m3/src/query/models"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestDedupeSeries(t *testing.T) {
+	source := []block.SeriesMeta{
+		{Name: "__name__=a,job=x,"},
+		{Name: "__name__=b,job=x,"},
+		{Name: "__name__=a,job=y,"},
+	}
+	result := []block.SeriesMeta{
+		{Tags: models.Tags{{Name: "job", Value: "x"}}},
+		{Tags: models.Tags{{Name: "job", Value: "x"}}},
+		{Tags: models.Tags{{Name: "job", Value: "y"}}},
+	}
+
+	keep, err := DedupeSeries("irate", source, result[2:], false)
+	require.NoError(t, err)
+	assert.Nil(t, keep)
+
+	_, err = DedupeSeries("irate", source, result, false)
+	require.Error(t, err)
+	dupErr, ok := err.(*DuplicateSeriesError)
+	require.True(t, ok)
+	assert.Equal(t, 1, dupErr.Total)
+	require.Len(t, dupErr.Duplicates, 1)
+	assert.Equal(t, []string{"__name__=a,job=x,", "__name__=b,job=x,"},
+		dupErr.Duplicates[0].SeriesIDs)
+	assert.Contains(t, err.Error(), `{job="x"}`)
+
+	keep, err = DedupeSeries("irate", source, result, true)
+	require.NoError(t, err)
+	assert.Equal(t, []int{0, 2}, keep)
+}
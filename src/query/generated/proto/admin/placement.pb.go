@@ -107,10 +107,88 @@ func (m *PlacementAddRequest) GetInstances() []*placementpb.Instance {
 	return nil
 }
 
+type PlacementRebalanceRequest struct {
+	Instances   []*placementpb.Instance `protobuf:"bytes,1,rep,name=instances" json:"instances,omitempty"`
+	Parallelism int32                   `protobuf:"varint,2,opt,name=parallelism,proto3" json:"parallelism,omitempty"`
+}
+
+func (m *PlacementRebalanceRequest) Reset()         { *m = PlacementRebalanceRequest{} }
+func (m *PlacementRebalanceRequest) String() string { return proto.CompactTextString(m) }
+func (*PlacementRebalanceRequest) ProtoMessage()    {}
+func (*PlacementRebalanceRequest) Descriptor() ([]byte, []int) {
+	return fileDescriptorPlacement, []int{3}
+}
+
+func (m *PlacementRebalanceRequest) GetInstances() []*placementpb.Instance {
+	if m != nil {
+		return m.Instances
+	}
+	return nil
+}
+
+func (m *PlacementRebalanceRequest) GetParallelism() int32 {
+	if m != nil {
+		return m.Parallelism
+	}
+	return 0
+}
+
+type PlacementRebalanceResponse struct {
+	Placement          *placementpb.Placement `protobuf:"bytes,1,opt,name=placement" json:"placement,omitempty"`
+	Version            int32                  `protobuf:"varint,2,opt,name=version,proto3" json:"version,omitempty"`
+	PendingMoves       int32                  `protobuf:"varint,3,opt,name=pending_moves,json=pendingMoves,proto3" json:"pending_moves,omitempty"`
+	InitializingShards int32                  `protobuf:"varint,4,opt,name=initializing_shards,json=initializingShards,proto3" json:"initializing_shards,omitempty"`
+	LeavingShards      int32                  `protobuf:"varint,5,opt,name=leaving_shards,json=leavingShards,proto3" json:"leaving_shards,omitempty"`
+}
+
+func (m *PlacementRebalanceResponse) Reset()         { *m = PlacementRebalanceResponse{} }
+func (m *PlacementRebalanceResponse) String() string { return proto.CompactTextString(m) }
+func (*PlacementRebalanceResponse) ProtoMessage()    {}
+func (*PlacementRebalanceResponse) Descriptor() ([]byte, []int) {
+	return fileDescriptorPlacement, []int{4}
+}
+
+func (m *PlacementRebalanceResponse) GetPlacement() *placementpb.Placement {
+	if m != nil {
+		return m.Placement
+	}
+	return nil
+}
+
+func (m *PlacementRebalanceResponse) GetVersion() int32 {
+	if m != nil {
+		return m.Version
+	}
+	return 0
+}
+
+func (m *PlacementRebalanceResponse) GetPendingMoves() int32 {
+	if m != nil {
+		return m.PendingMoves
+	}
+	return 0
+}
+
+func (m *PlacementRebalanceResponse) GetInitializingShards() int32 {
+	if m != nil {
+		return m.InitializingShards
+	}
+	return 0
+}
+
+func (m *PlacementRebalanceResponse) GetLeavingShards() int32 {
+	if m != nil {
+		return m.LeavingShards
+	}
+	return 0
+}
+
 func init() {
 	proto.RegisterType((*PlacementInitRequest)(nil), "admin.PlacementInitRequest")
 	proto.RegisterType((*PlacementGetResponse)(nil), "admin.PlacementGetResponse")
 	proto.RegisterType((*PlacementAddRequest)(nil), "admin.PlacementAddRequest")
+	proto.RegisterType((*PlacementRebalanceRequest)(nil), "admin.PlacementRebalanceRequest")
+	proto.RegisterType((*PlacementRebalanceResponse)(nil), "admin.PlacementRebalanceResponse")
 }
 func (m *PlacementInitRequest) Marshal() (dAtA []byte, err error) {
 	size := m.Size()
@@ -215,6 +293,89 @@ func (m *PlacementAddRequest) MarshalTo(dAtA []byte) (int, error) {
 	return i, nil
 }
 
+func (m *PlacementRebalanceRequest) Marshal() (dAtA []byte, err error) {
+	size := m.Size()
+	dAtA = make([]byte, size)
+	n, err := m.MarshalTo(dAtA)
+	if err != nil {
+		return nil, err
+	}
+	return dAtA[:n], nil
+}
+
+func (m *PlacementRebalanceRequest) MarshalTo(dAtA []byte) (int, error) {
+	var i int
+	_ = i
+	var l int
+	_ = l
+	if len(m.Instances) > 0 {
+		for _, msg := range m.Instances {
+			dAtA[i] = 0xa
+			i++
+			i = encodeVarintPlacement(dAtA, i, uint64(msg.Size()))
+			n, err := msg.MarshalTo(dAtA[i:])
+			if err != nil {
+				return 0, err
+			}
+			i += n
+		}
+	}
+	if m.Parallelism != 0 {
+		dAtA[i] = 0x10
+		i++
+		i = encodeVarintPlacement(dAtA, i, uint64(m.Parallelism))
+	}
+	return i, nil
+}
+
+func (m *PlacementRebalanceResponse) Marshal() (dAtA []byte, err error) {
+	size := m.Size()
+	dAtA = make([]byte, size)
+	n, err := m.MarshalTo(dAtA)
+	if err != nil {
+		return nil, err
+	}
+	return dAtA[:n], nil
+}
+
+func (m *PlacementRebalanceResponse) MarshalTo(dAtA []byte) (int, error) {
+	var i int
+	_ = i
+	var l int
+	_ = l
+	if m.Placement != nil {
+		dAtA[i] = 0xa
+		i++
+		i = encodeVarintPlacement(dAtA, i, uint64(m.Placement.Size()))
+		n2, err := m.Placement.MarshalTo(dAtA[i:])
+		if err != nil {
+			return 0, err
+		}
+		i += n2
+	}
+	if m.Version != 0 {
+		dAtA[i] = 0x10
+		i++
+		i = encodeVarintPlacement(dAtA, i, uint64(m.Version))
+	}
+	if m.PendingMoves != 0 {
+		dAtA[i] = 0x18
+		i++
+		i = encodeVarintPlacement(dAtA, i, uint64(m.PendingMoves))
+	}
+	if m.InitializingShards != 0 {
+		dAtA[i] = 0x20
+		i++
+		i = encodeVarintPlacement(dAtA, i, uint64(m.InitializingShards))
+	}
+	if m.LeavingShards != 0 {
+		dAtA[i] = 0x28
+		i++
+		i = encodeVarintPlacement(dAtA, i, uint64(m.LeavingShards))
+	}
+	return i, nil
+}
+
 func encodeVarintPlacement(dAtA []byte, offset int, v uint64) int {
 	for v >= 1<<7 {
 		dAtA[offset] = uint8(v&0x7f | 0x80)
@@ -267,6 +428,43 @@ func (m *PlacementAddRequest) Size() (n int) {
 	return n
 }
 
+func (m *PlacementRebalanceRequest) Size() (n int) {
+	var l int
+	_ = l
+	if len(m.Instances) > 0 {
+		for _, e := range m.Instances {
+			l = e.Size()
+			n += 1 + l + sovPlacement(uint64(l))
+		}
+	}
+	if m.Parallelism != 0 {
+		n += 1 + sovPlacement(uint64(m.Parallelism))
+	}
+	return n
+}
+
+func (m *PlacementRebalanceResponse) Size() (n int) {
+	var l int
+	_ = l
+	if m.Placement != nil {
+		l = m.Placement.Size()
+		n += 1 + l + sovPlacement(uint64(l))
+	}
+	if m.Version != 0 {
+		n += 1 + sovPlacement(uint64(m.Version))
+	}
+	if m.PendingMoves != 0 {
+		n += 1 + sovPlacement(uint64(m.PendingMoves))
+	}
+	if m.InitializingShards != 0 {
+		n += 1 + sovPlacement(uint64(m.InitializingShards))
+	}
+	if m.LeavingShards != 0 {
+		n += 1 + sovPlacement(uint64(m.LeavingShards))
+	}
+	return n
+}
+
 func sovPlacement(x uint64) (n int) {
 	for {
 		n++
@@ -582,6 +780,265 @@ func (m *PlacementAddRequest) Unmarshal(dAtA []byte) error {
 	}
 	return nil
 }
+func (m *PlacementRebalanceRequest) Unmarshal(dAtA []byte) error {
+	l := len(dAtA)
+	iNdEx := 0
+	for iNdEx < l {
+		preIndex := iNdEx
+		var wire uint64
+		for shift := uint(0); ; shift += 7 {
+			if shift >= 64 {
+				return ErrIntOverflowPlacement
+			}
+			if iNdEx >= l {
+				return io.ErrUnexpectedEOF
+			}
+			b := dAtA[iNdEx]
+			iNdEx++
+			wire |= (uint64(b) & 0x7F) << shift
+			if b < 0x80 {
+				break
+			}
+		}
+		fieldNum := int32(wire >> 3)
+		wireType := int(wire & 0x7)
+		if wireType == 4 {
+			return fmt.Errorf("proto: PlacementRebalanceRequest: wiretype end group for non-group")
+		}
+		if fieldNum <= 0 {
+			return fmt.Errorf("proto: PlacementRebalanceRequest: illegal tag %d (wire type %d)", fieldNum, wire)
+		}
+		switch fieldNum {
+		case 1:
+			if wireType != 2 {
+				return fmt.Errorf("proto: wrong wireType = %d for field Instances", wireType)
+			}
+			var msglen int
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowPlacement
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				msglen |= (int(b) & 0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
+			if msglen < 0 {
+				return ErrInvalidLengthPlacement
+			}
+			postIndex := iNdEx + msglen
+			if postIndex > l {
+				return io.ErrUnexpectedEOF
+			}
+			m.Instances = append(m.Instances, &placementpb.Instance{})
+			if err := m.Instances[len(m.Instances)-1].Unmarshal(dAtA[iNdEx:postIndex]); err != nil {
+				return err
+			}
+			iNdEx = postIndex
+		case 2:
+			if wireType != 0 {
+				return fmt.Errorf("proto: wrong wireType = %d for field Parallelism", wireType)
+			}
+			m.Parallelism = 0
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowPlacement
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				m.Parallelism |= (int32(b) & 0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
+		default:
+			iNdEx = preIndex
+			skippy, err := skipPlacement(dAtA[iNdEx:])
+			if err != nil {
+				return err
+			}
+			if skippy < 0 {
+				return ErrInvalidLengthPlacement
+			}
+			if (iNdEx + skippy) > l {
+				return io.ErrUnexpectedEOF
+			}
+			iNdEx += skippy
+		}
+	}
+
+	if iNdEx > l {
+		return io.ErrUnexpectedEOF
+	}
+	return nil
+}
+func (m *PlacementRebalanceResponse) Unmarshal(dAtA []byte) error {
+	l := len(dAtA)
+	iNdEx := 0
+	for iNdEx < l {
+		preIndex := iNdEx
+		var wire uint64
+		for shift := uint(0); ; shift += 7 {
+			if shift >= 64 {
+				return ErrIntOverflowPlacement
+			}
+			if iNdEx >= l {
+				return io.ErrUnexpectedEOF
+			}
+			b := dAtA[iNdEx]
+			iNdEx++
+			wire |= (uint64(b) & 0x7F) << shift
+			if b < 0x80 {
+				break
+			}
+		}
+		fieldNum := int32(wire >> 3)
+		wireType := int(wire & 0x7)
+		if wireType == 4 {
+			return fmt.Errorf("proto: PlacementRebalanceResponse: wiretype end group for non-group")
+		}
+		if fieldNum <= 0 {
+			return fmt.Errorf("proto: PlacementRebalanceResponse: illegal tag %d (wire type %d)", fieldNum, wire)
+		}
+		switch fieldNum {
+		case 1:
+			if wireType != 2 {
+				return fmt.Errorf("proto: wrong wireType = %d for field Placement", wireType)
+			}
+			var msglen int
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowPlacement
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				msglen |= (int(b) & 0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
+			if msglen < 0 {
+				return ErrInvalidLengthPlacement
+			}
+			postIndex := iNdEx + msglen
+			if postIndex > l {
+				return io.ErrUnexpectedEOF
+			}
+			if m.Placement == nil {
+				m.Placement = &placementpb.Placement{}
+			}
+			if err := m.Placement.Unmarshal(dAtA[iNdEx:postIndex]); err != nil {
+				return err
+			}
+			iNdEx = postIndex
+		case 2:
+			if wireType != 0 {
+				return fmt.Errorf("proto: wrong wireType = %d for field Version", wireType)
+			}
+			m.Version = 0
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowPlacement
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				m.Version |= (int32(b) & 0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
+		case 3:
+			if wireType != 0 {
+				return fmt.Errorf("proto: wrong wireType = %d for field PendingMoves", wireType)
+			}
+			m.PendingMoves = 0
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowPlacement
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				m.PendingMoves |= (int32(b) & 0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
+		case 4:
+			if wireType != 0 {
+				return fmt.Errorf("proto: wrong wireType = %d for field InitializingShards", wireType)
+			}
+			m.InitializingShards = 0
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowPlacement
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				m.InitializingShards |= (int32(b) & 0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
+		case 5:
+			if wireType != 0 {
+				return fmt.Errorf("proto: wrong wireType = %d for field LeavingShards", wireType)
+			}
+			m.LeavingShards = 0
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowPlacement
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				m.LeavingShards |= (int32(b) & 0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
+		default:
+			iNdEx = preIndex
+			skippy, err := skipPlacement(dAtA[iNdEx:])
+			if err != nil {
+				return err
+			}
+			if skippy < 0 {
+				return ErrInvalidLengthPlacement
+			}
+			if (iNdEx + skippy) > l {
+				return io.ErrUnexpectedEOF
+			}
+			iNdEx += skippy
+		}
+	}
+
+	if iNdEx > l {
+		return io.ErrUnexpectedEOF
+	}
+	return nil
+}
 func skipPlacement(dAtA []byte) (n int, err error) {
 	l := len(dAtA)
 	iNdEx := 0
@@ -692,24 +1149,30 @@ func init() {
 }
 
 var fileDescriptorPlacement = []byte{
-	// 300 bytes of a gzipped FileDescriptorProto
-	0x1f, 0x8b, 0x08, 0x00, 0x00, 0x00, 0x00, 0x00, 0x02, 0xff, 0xa4, 0x90, 0xcd, 0x4a, 0x3b, 0x31,
-	0x14, 0xc5, 0xff, 0xf9, 0x97, 0x2a, 0x4d, 0x37, 0x1a, 0x3f, 0x18, 0x04, 0x87, 0xd2, 0x55, 0x37,
-	0x4e, 0xa0, 0xe3, 0x0b, 0x58, 0x50, 0xa9, 0x2b, 0x19, 0x1f, 0xa0, 0x64, 0x92, 0xdb, 0x36, 0xd0,
-	0x24, 0xd3, 0x7c, 0x08, 0xbe, 0x85, 0x5b, 0xdf, 0xc8, 0xa5, 0x8f, 0x20, 0xe3, 0x8b, 0x08, 0xa1,
-	0x9d, 0x8e, 0x76, 0xe9, 0xf2, 0x9e, 0x73, 0xee, 0x8f, 0xc3, 0xc1, 0x93, 0x85, 0xf4, 0xcb, 0x50,
-	0x66, 0xdc, 0x28, 0xaa, 0x72, 0x51, 0x52, 0x95, 0x53, 0x67, 0x39, 0x5d, 0x07, 0xb0, 0x2f, 0x74,
-	0x01, 0x1a, 0x2c, 0xf3, 0x20, 0x68, 0x65, 0x8d, 0x37, 0x94, 0x09, 0x25, 0x35, 0xad, 0x56, 0x8c,
-	0x83, 0x02, 0xed, 0xb3, 0xa8, 0x92, 0x6e, 0x94, 0x2f, 0x6e, 0xf7, 0x51, 0x7c, 0x15, 0x9c, 0x07,
-	0xbb, 0xc7, 0x69, 0x08, 0x55, 0xf9, 0x9b, 0x36, 0x7c, 0x43, 0xf8, 0xf4, 0x71, 0xab, 0x4d, 0xb5,
-	0xf4, 0x05, 0xac, 0x03, 0x38, 0x4f, 0x72, 0xdc, 0x93, 0xda, 0x79, 0xa6, 0x39, 0xb8, 0x04, 0x0d,
-	0x3a, 0xa3, 0xfe, 0xf8, 0x2c, 0x6b, 0x91, 0xb2, 0xe9, 0xc6, 0x2d, 0x76, 0x39, 0x72, 0x89, 0xb1,
-	0x0e, 0x6a, 0xe6, 0x96, 0xcc, 0x0a, 0x97, 0xfc, 0x1f, 0xa0, 0x51, 0xb7, 0xe8, 0xe9, 0xa0, 0x9e,
-	0xa2, 0x40, 0xae, 0x30, 0xb1, 0x50, 0xad, 0x24, 0x67, 0x5e, 0x1a, 0x3d, 0x9b, 0x33, 0xee, 0x8d,
-	0x4d, 0x3a, 0x31, 0x76, 0xdc, 0x72, 0xee, 0xa2, 0x31, 0x9c, 0xb7, 0xaa, 0xdd, 0x83, 0x2f, 0xc0,
-	0x55, 0x46, 0x3b, 0x20, 0xd7, 0xb8, 0xd7, 0x14, 0x49, 0xd0, 0x00, 0x8d, 0xfa, 0xe3, 0xf3, 0x1f,
-	0xd5, 0x9a, 0xaf, 0x62, 0x17, 0x24, 0x09, 0x3e, 0x7c, 0x06, 0xeb, 0xa4, 0xd1, 0x9b, 0x62, 0xdb,
-	0x73, 0xf8, 0x80, 0x4f, 0x9a, 0x8f, 0x1b, 0x21, 0xfe, 0xb2, 0xc0, 0xe4, 0xe8, 0xbd, 0x4e, 0xd1,
-	0x47, 0x9d, 0xa2, 0xcf, 0x3a, 0x45, 0xaf, 0x5f, 0xe9, 0xbf, 0xf2, 0x20, 0x0e, 0x9d, 0x7f, 0x07,
-	0x00, 0x00, 0xff, 0xff, 0x8b, 0x10, 0xf7, 0x35, 0xfc, 0x01, 0x00, 0x00,
+	// 389 bytes of a gzipped FileDescriptorProto
+	0x1f, 0x8b, 0x08, 0x00, 0x00, 0x00, 0x00, 0x00, 0x02, 0x03, 0xb5, 0x92, 0xd1, 0x4a, 0xc3, 0x30,
+	0x14, 0x86, 0xad, 0x73, 0xca, 0x32, 0x27, 0x9a, 0xa9, 0xd4, 0x81, 0x63, 0x54, 0x04, 0x6f, 0x6c,
+	0xc1, 0xf9, 0x02, 0x0e, 0x54, 0x26, 0x08, 0x32, 0x1f, 0x60, 0xa4, 0xed, 0xd9, 0x16, 0x48, 0xd3,
+	0x9a, 0xa4, 0x03, 0x7d, 0x0a, 0x6f, 0x7d, 0x23, 0x2f, 0x7d, 0x04, 0xd1, 0x2b, 0xdf, 0xc2, 0x2c,
+	0xeb, 0xba, 0xea, 0xbc, 0x1b, 0x5e, 0xa4, 0x90, 0xff, 0x7c, 0xf9, 0xf3, 0xf7, 0xe4, 0xa0, 0xce,
+	0x90, 0xaa, 0x51, 0xea, 0xbb, 0x41, 0x1c, 0x79, 0x51, 0x3b, 0xf4, 0xf5, 0xc7, 0x93, 0x22, 0xf0,
+	0x1e, 0x52, 0x10, 0x8f, 0xde, 0x10, 0x38, 0x08, 0xa2, 0x20, 0xf4, 0x12, 0x11, 0xab, 0xd8, 0x23,
+	0x61, 0x44, 0xb9, 0x97, 0x30, 0x12, 0x40, 0x04, 0x5c, 0xb9, 0x46, 0xc5, 0x65, 0x23, 0x37, 0x2e,
+	0x17, 0xad, 0x02, 0x96, 0x4a, 0x05, 0x62, 0xc1, 0x27, 0x77, 0x48, 0xfc, 0xdf, 0x6e, 0xce, 0x8b,
+	0x85, 0x76, 0xef, 0x66, 0x5a, 0x97, 0x53, 0xd5, 0x03, 0x1d, 0x46, 0x2a, 0xdc, 0x46, 0x15, 0xca,
+	0xa5, 0x22, 0x3c, 0x00, 0x69, 0x5b, 0xad, 0xd2, 0x49, 0xf5, 0x6c, 0xcf, 0x2d, 0x38, 0xb9, 0xdd,
+	0xac, 0xda, 0x9b, 0x73, 0xf8, 0x10, 0x21, 0x9e, 0x46, 0x7d, 0x39, 0x22, 0x22, 0x94, 0xf6, 0x6a,
+	0xcb, 0x3a, 0x29, 0xf7, 0x2a, 0x5a, 0xb9, 0x37, 0x02, 0x3e, 0x45, 0x58, 0x40, 0xc2, 0x68, 0x40,
+	0x14, 0x8d, 0x79, 0x7f, 0x40, 0x02, 0x15, 0x0b, 0xbb, 0x64, 0xb0, 0x9d, 0x42, 0xe5, 0xca, 0x14,
+	0x9c, 0x41, 0x21, 0xda, 0x35, 0xe8, 0x64, 0x32, 0x89, 0xb9, 0x04, 0x7c, 0x8e, 0x2a, 0x79, 0x10,
+	0x1d, 0xcd, 0xd2, 0xd1, 0xf6, 0x7f, 0x44, 0xcb, 0x4f, 0xf5, 0xe6, 0x20, 0xb6, 0xd1, 0xc6, 0x18,
+	0x84, 0xd4, 0xf6, 0x59, 0xb0, 0xd9, 0xd6, 0xb9, 0x41, 0xf5, 0xfc, 0xc4, 0x45, 0x18, 0x2e, 0xd3,
+	0x01, 0x47, 0xa0, 0x83, 0xf9, 0xed, 0xe0, 0x13, 0x66, 0x80, 0x65, 0x7a, 0xda, 0x42, 0xd5, 0x84,
+	0x08, 0xc2, 0x18, 0x30, 0x2a, 0xa3, 0x2c, 0x7b, 0x51, 0x72, 0xbe, 0x2c, 0xd4, 0xf8, 0xeb, 0xd2,
+	0xff, 0x69, 0x17, 0x3e, 0x42, 0xb5, 0x04, 0x78, 0x48, 0xf9, 0xb0, 0x1f, 0xc5, 0x63, 0xfd, 0x27,
+	0xd3, 0x07, 0xdc, 0xcc, 0xc4, 0xdb, 0x89, 0x86, 0x3d, 0x54, 0xa7, 0x7a, 0x9a, 0x28, 0x61, 0xf4,
+	0x69, 0x42, 0x66, 0x23, 0xb1, 0x66, 0x50, 0x5c, 0x2c, 0x65, 0xb3, 0x71, 0x8c, 0xb6, 0x18, 0x90,
+	0x71, 0x81, 0x2d, 0x1b, 0xb6, 0x96, 0xa9, 0x53, 0xac, 0xb3, 0xfd, 0xfa, 0xd1, 0xb4, 0xde, 0xf4,
+	0x7a, 0xd7, 0xeb, 0xf9, 0xb3, 0xb9, 0xe2, 0xaf, 0x9b, 0x41, 0x6e, 0x7f, 0x03, 0x51, 0x4d, 0x8d,
+	0x89, 0x5c, 0x03, 0x00, 0x00,
 }
@@ -22,29 +22,31 @@
 // THE SOFTWARE.
 
 /*
-	Package rpcpb is a generated protocol buffer package.
-
-	It is generated from these files:
-		github.com/m3db/m3/src/query/generated/proto/rpcpb/query.proto
-
-	It has these top-level messages:
-		WriteMessage
-		WriteQuery
-		WriteOptions
-		Datapoint
-		Datapoints
-		Error
-		FetchMessage
-		FetchQuery
-		FetchOptions
-		Matcher
-		FetchResult
-		Segment
-		Segments
-		CompressedValuesReplica
-		CompressedDatapoints
-		Tag
-		Series
+Package rpcpb is a generated protocol buffer package.
+
+It is generated from these files:
+
+	github.com/m3db/m3/src/query/generated/proto/rpcpb/query.proto
+
+It has these top-level messages:
+
+	WriteMessage
+	WriteQuery
+	WriteOptions
+	Datapoint
+	Datapoints
+	Error
+	FetchMessage
+	FetchQuery
+	FetchOptions
+	Matcher
+	FetchResult
+	Segment
+	Segments
+	CompressedValuesReplica
+	CompressedDatapoints
+	Tag
+	Series
 */
 package rpcpb
 
@@ -70,6 +72,39 @@ var _ = math.Inf
 // proto package needs to be updated.
 const _ = proto.GoGoProtoPackageIsVersion2 // please upgrade the proto package
 
+type ReadConsistencyLevel int32
+
+const (
+	ReadConsistencyLevel_NOT_SET           ReadConsistencyLevel = 0
+	ReadConsistencyLevel_NONE              ReadConsistencyLevel = 1
+	ReadConsistencyLevel_ONE               ReadConsistencyLevel = 2
+	ReadConsistencyLevel_UNSTRICT_MAJORITY ReadConsistencyLevel = 3
+	ReadConsistencyLevel_MAJORITY          ReadConsistencyLevel = 4
+	ReadConsistencyLevel_ALL               ReadConsistencyLevel = 5
+)
+
+var ReadConsistencyLevel_name = map[int32]string{
+	0: "NOT_SET",
+	1: "NONE",
+	2: "ONE",
+	3: "UNSTRICT_MAJORITY",
+	4: "MAJORITY",
+	5: "ALL",
+}
+var ReadConsistencyLevel_value = map[string]int32{
+	"NOT_SET":           0,
+	"NONE":              1,
+	"ONE":               2,
+	"UNSTRICT_MAJORITY": 3,
+	"MAJORITY":          4,
+	"ALL":               5,
+}
+
+func (x ReadConsistencyLevel) String() string {
+	return proto.EnumName(ReadConsistencyLevel_name, int32(x))
+}
+func (ReadConsistencyLevel) EnumDescriptor() ([]byte, []int) { return fileDescriptorQuery, []int{0} }
+
 type WriteMessage struct {
 	Query   *WriteQuery   `protobuf:"bytes,1,opt,name=query" json:"query,omitempty"`
 	Options *WriteOptions `protobuf:"bytes,2,opt,name=options" json:"options,omitempty"`
@@ -271,7 +306,10 @@ func (m *FetchQuery) GetTagMatchers() []*Matcher {
 }
 
 type FetchOptions struct {
-	Id string `protobuf:"bytes,1,opt,name=id,proto3" json:"id,omitempty"`
+	Id                   string               `protobuf:"bytes,1,opt,name=id,proto3" json:"id,omitempty"`
+	ReadConsistencyLevel ReadConsistencyLevel `protobuf:"varint,2,opt,name=readConsistencyLevel,proto3,enum=rpcpb.ReadConsistencyLevel" json:"readConsistencyLevel,omitempty"`
+	DocsLimit            int64                `protobuf:"varint,3,opt,name=docsLimit,proto3" json:"docsLimit,omitempty"`
+	BytesLimit           int64                `protobuf:"varint,4,opt,name=bytesLimit,proto3" json:"bytesLimit,omitempty"`
 }
 
 func (m *FetchOptions) Reset()                    { *m = FetchOptions{} }
@@ -286,10 +324,35 @@ func (m *FetchOptions) GetId() string {
 	return ""
 }
 
+func (m *FetchOptions) GetReadConsistencyLevel() ReadConsistencyLevel {
+	if m != nil {
+		return m.ReadConsistencyLevel
+	}
+	return ReadConsistencyLevel_NOT_SET
+}
+
+func (m *FetchOptions) GetDocsLimit() int64 {
+	if m != nil {
+		return m.DocsLimit
+	}
+	return 0
+}
+
+func (m *FetchOptions) GetBytesLimit() int64 {
+	if m != nil {
+		return m.BytesLimit
+	}
+	return 0
+}
+
 type Matcher struct {
-	Name  string `protobuf:"bytes,1,opt,name=name,proto3" json:"name,omitempty"`
-	Value string `protobuf:"bytes,2,opt,name=value,proto3" json:"value,omitempty"`
-	Type  int64  `protobuf:"varint,3,opt,name=type,proto3" json:"type,omitempty"`
+	Name     string `protobuf:"bytes,1,opt,name=name,proto3" json:"name,omitempty"`
+	Value    string `protobuf:"bytes,2,opt,name=value,proto3" json:"value,omitempty"`
+	Type     int64  `protobuf:"varint,3,opt,name=type,proto3" json:"type,omitempty"`
+	MaxEdits int64  `protobuf:"varint,4,opt,name=maxEdits,proto3" json:"maxEdits,omitempty"`
+	// hasMaxEdits is set if maxEdits is set, so zero edits can be told
+	// apart from zones that do not send the edit distance.
+	HasMaxEdits bool `protobuf:"varint,5,opt,name=hasMaxEdits,proto3" json:"hasMaxEdits,omitempty"`
 }
 
 func (m *Matcher) Reset()                    { *m = Matcher{} }
@@ -318,6 +381,20 @@ func (m *Matcher) GetType() int64 {
 	return 0
 }
 
+func (m *Matcher) GetMaxEdits() int64 {
+	if m != nil {
+		return m.MaxEdits
+	}
+	return 0
+}
+
+func (m *Matcher) GetHasMaxEdits() bool {
+	if m != nil {
+		return m.HasMaxEdits
+	}
+	return false
+}
+
 type FetchResult struct {
 	Series []*Series `protobuf:"bytes,1,rep,name=series" json:"series,omitempty"`
 }
@@ -544,6 +621,7 @@ func init() {
 	proto.RegisterType((*CompressedDatapoints)(nil), "rpcpb.CompressedDatapoints")
 	proto.RegisterType((*Tag)(nil), "rpcpb.Tag")
 	proto.RegisterType((*Series)(nil), "rpcpb.Series")
+	proto.RegisterEnum("rpcpb.ReadConsistencyLevel", ReadConsistencyLevel_name, ReadConsistencyLevel_value)
 }
 
 // Reference imports to suppress errors if they are not otherwise used.
@@ -1024,6 +1102,21 @@ func (m *FetchOptions) MarshalTo(dAtA []byte) (int, error) {
 		i = encodeVarintQuery(dAtA, i, uint64(len(m.Id)))
 		i += copy(dAtA[i:], m.Id)
 	}
+	if m.ReadConsistencyLevel != 0 {
+		dAtA[i] = 0x10
+		i++
+		i = encodeVarintQuery(dAtA, i, uint64(m.ReadConsistencyLevel))
+	}
+	if m.DocsLimit != 0 {
+		dAtA[i] = 0x18
+		i++
+		i = encodeVarintQuery(dAtA, i, uint64(m.DocsLimit))
+	}
+	if m.BytesLimit != 0 {
+		dAtA[i] = 0x20
+		i++
+		i = encodeVarintQuery(dAtA, i, uint64(m.BytesLimit))
+	}
 	return i, nil
 }
 
@@ -1059,6 +1152,21 @@ func (m *Matcher) MarshalTo(dAtA []byte) (int, error) {
 		i++
 		i = encodeVarintQuery(dAtA, i, uint64(m.Type))
 	}
+	if m.MaxEdits != 0 {
+		dAtA[i] = 0x20
+		i++
+		i = encodeVarintQuery(dAtA, i, uint64(m.MaxEdits))
+	}
+	if m.HasMaxEdits {
+		dAtA[i] = 0x28
+		i++
+		if m.HasMaxEdits {
+			dAtA[i] = 1
+		} else {
+			dAtA[i] = 0
+		}
+		i++
+	}
 	return i, nil
 }
 
@@ -1476,6 +1584,15 @@ func (m *FetchOptions) Size() (n int) {
 	if l > 0 {
 		n += 1 + l + sovQuery(uint64(l))
 	}
+	if m.ReadConsistencyLevel != 0 {
+		n += 1 + sovQuery(uint64(m.ReadConsistencyLevel))
+	}
+	if m.DocsLimit != 0 {
+		n += 1 + sovQuery(uint64(m.DocsLimit))
+	}
+	if m.BytesLimit != 0 {
+		n += 1 + sovQuery(uint64(m.BytesLimit))
+	}
 	return n
 }
 
@@ -1493,6 +1610,12 @@ func (m *Matcher) Size() (n int) {
 	if m.Type != 0 {
 		n += 1 + sovQuery(uint64(m.Type))
 	}
+	if m.MaxEdits != 0 {
+		n += 1 + sovQuery(uint64(m.MaxEdits))
+	}
+	if m.HasMaxEdits {
+		n += 2
+	}
 	return n
 }
 
@@ -2630,6 +2753,63 @@ func (m *FetchOptions) Unmarshal(dAtA []byte) error {
 			}
 			m.Id = string(dAtA[iNdEx:postIndex])
 			iNdEx = postIndex
+		case 2:
+			if wireType != 0 {
+				return fmt.Errorf("proto: wrong wireType = %d for field ReadConsistencyLevel", wireType)
+			}
+			m.ReadConsistencyLevel = 0
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowQuery
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				m.ReadConsistencyLevel |= (ReadConsistencyLevel(b) & 0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
+		case 3:
+			if wireType != 0 {
+				return fmt.Errorf("proto: wrong wireType = %d for field DocsLimit", wireType)
+			}
+			m.DocsLimit = 0
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowQuery
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				m.DocsLimit |= (int64(b) & 0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
+		case 4:
+			if wireType != 0 {
+				return fmt.Errorf("proto: wrong wireType = %d for field BytesLimit", wireType)
+			}
+			m.BytesLimit = 0
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowQuery
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				m.BytesLimit |= (int64(b) & 0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
 		default:
 			iNdEx = preIndex
 			skippy, err := skipQuery(dAtA[iNdEx:])
@@ -2757,6 +2937,45 @@ func (m *Matcher) Unmarshal(dAtA []byte) error {
 					break
 				}
 			}
+		case 4:
+			if wireType != 0 {
+				return fmt.Errorf("proto: wrong wireType = %d for field MaxEdits", wireType)
+			}
+			m.MaxEdits = 0
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowQuery
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				m.MaxEdits |= (int64(b) & 0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
+		case 5:
+			if wireType != 0 {
+				return fmt.Errorf("proto: wrong wireType = %d for field HasMaxEdits", wireType)
+			}
+			var v int
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowQuery
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				v |= (int(b) & 0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
+			m.HasMaxEdits = bool(v != 0)
 		default:
 			iNdEx = preIndex
 			skippy, err := skipQuery(dAtA[iNdEx:])
@@ -3785,56 +4004,65 @@ func init() {
 }
 
 var fileDescriptorQuery = []byte{
-	// 801 bytes of a gzipped FileDescriptorProto
-	0x1f, 0x8b, 0x08, 0x00, 0x00, 0x00, 0x00, 0x00, 0x02, 0xff, 0x8c, 0x55, 0xdb, 0x6e, 0x1c, 0x45,
-	0x10, 0x65, 0x76, 0x3d, 0xeb, 0xdd, 0xf2, 0xe2, 0x38, 0x4d, 0x24, 0x46, 0x21, 0x8c, 0xa2, 0x91,
-	0x08, 0xe6, 0xb6, 0x63, 0xad, 0x91, 0x40, 0x41, 0x02, 0x09, 0x92, 0xf0, 0x14, 0x21, 0xda, 0x2b,
-	0x78, 0x43, 0xea, 0x9d, 0x29, 0xcf, 0x8e, 0xb2, 0x73, 0xa1, 0xbb, 0x17, 0x61, 0xbe, 0x82, 0x4f,
-	0xe0, 0x73, 0x78, 0xcc, 0x07, 0xf0, 0x80, 0xcc, 0x8f, 0xa0, 0xae, 0xee, 0xb9, 0xad, 0x8d, 0xf0,
-	0x5b, 0x75, 0xd5, 0x99, 0xba, 0x9c, 0x3e, 0x5d, 0x03, 0x5f, 0x66, 0xb9, 0xde, 0xec, 0xd6, 0x8b,
-	0xa4, 0x2a, 0xe2, 0xe2, 0x3c, 0x5d, 0xc7, 0xc5, 0x79, 0xac, 0x64, 0x12, 0xff, 0xbc, 0x43, 0x79,
-	0x15, 0x67, 0x58, 0xa2, 0x14, 0x1a, 0xd3, 0xb8, 0x96, 0x95, 0xae, 0x62, 0x59, 0x27, 0xf5, 0xda,
-	0xc6, 0x16, 0xe4, 0x61, 0x3e, 0xb9, 0xa2, 0x4b, 0x98, 0xff, 0x28, 0x73, 0x8d, 0x2f, 0x51, 0x29,
-	0x91, 0x21, 0x7b, 0x1f, 0x7c, 0x42, 0x05, 0xde, 0x63, 0xef, 0xf4, 0x68, 0x79, 0x7f, 0x41, 0xb0,
-	0x05, 0x61, 0xbe, 0x37, 0x01, 0x6e, 0xe3, 0xec, 0x13, 0x38, 0xac, 0x6a, 0x9d, 0x57, 0xa5, 0x0a,
-	0x46, 0x04, 0x7d, 0xab, 0x0f, 0xfd, 0xce, 0x86, 0x78, 0x83, 0x89, 0xfe, 0xf2, 0x00, 0xba, 0x24,
-	0x8c, 0xc1, 0xc1, 0xae, 0xcc, 0x35, 0x55, 0xf1, 0x39, 0xd9, 0x2c, 0x04, 0x10, 0x65, 0x59, 0x69,
-	0x61, 0xbe, 0xa0, 0xa4, 0x73, 0xde, 0xf3, 0xb0, 0x33, 0x80, 0x54, 0x68, 0x51, 0x57, 0x79, 0xa9,
-	0x55, 0x30, 0x7e, 0x3c, 0x3e, 0x3d, 0x5a, 0x9e, 0xb8, 0xa2, 0xcf, 0x9a, 0x00, 0xef, 0x61, 0x58,
-	0x0c, 0x07, 0x5a, 0x64, 0x2a, 0x38, 0x20, 0xec, 0x3b, 0x37, 0x66, 0x59, 0xac, 0x44, 0xa6, 0x9e,
-	0x97, 0x5a, 0x5e, 0x71, 0x02, 0x3e, 0xfc, 0x0c, 0x66, 0xad, 0x8b, 0x9d, 0xc0, 0xf8, 0x15, 0x5a,
-	0x22, 0x66, 0xdc, 0x98, 0xec, 0x01, 0xf8, 0xbf, 0x88, 0xed, 0x0e, 0xa9, 0xb9, 0x19, 0xb7, 0x87,
-	0xa7, 0xa3, 0xcf, 0xbd, 0x28, 0x74, 0x34, 0xba, 0xb9, 0xd9, 0x31, 0x8c, 0xf2, 0xd4, 0x7d, 0x3a,
-	0xca, 0xd3, 0xe8, 0x2b, 0x98, 0xb5, 0x2d, 0xb2, 0x47, 0x30, 0xd3, 0x79, 0x81, 0x4a, 0x8b, 0xa2,
-	0x26, 0xcc, 0x98, 0x77, 0x8e, 0x61, 0x11, 0xcf, 0x15, 0x89, 0x36, 0x00, 0xcf, 0xba, 0xc1, 0x86,
-	0x54, 0x78, 0x77, 0xa0, 0xe2, 0x14, 0xee, 0x5d, 0xe6, 0xbf, 0x62, 0xca, 0x51, 0x55, 0xdb, 0x5d,
-	0xcb, 0xf0, 0x94, 0xef, 0xbb, 0xa3, 0x77, 0xc1, 0x7f, 0x2e, 0x65, 0x25, 0x4d, 0x23, 0x68, 0x0c,
-	0x37, 0x86, 0x3d, 0x18, 0xc1, 0xbc, 0x40, 0x9d, 0x6c, 0xfe, 0x47, 0x30, 0x84, 0xb9, 0x9b, 0x60,
-	0x08, 0x7a, 0x43, 0x30, 0x97, 0x00, 0x5d, 0x0e, 0xd3, 0x8b, 0xd2, 0x42, 0x6a, 0x47, 0x97, 0x3d,
-	0x98, 0x1b, 0xc2, 0x32, 0xa5, 0x74, 0x63, 0x6e, 0x4c, 0x76, 0x06, 0x47, 0x5a, 0x64, 0x2f, 0x85,
-	0x4e, 0x36, 0x28, 0x1b, 0x91, 0x1c, 0xbb, 0x42, 0xce, 0xcd, 0xfb, 0x10, 0x73, 0x73, 0xfd, 0x06,
-	0x6e, 0xdc, 0xdc, 0xb7, 0x70, 0xe8, 0xb0, 0x46, 0xb4, 0xa5, 0x28, 0xd0, 0x05, 0xc9, 0xbe, 0x5d,
-	0x12, 0x06, 0xa9, 0xaf, 0x6a, 0x0c, 0xc6, 0xd4, 0x19, 0xd9, 0xd1, 0xa7, 0x70, 0x44, 0x85, 0x38,
-	0xaa, 0xdd, 0x56, 0xb3, 0xf7, 0x60, 0xa2, 0x50, 0xe6, 0xd8, 0x5c, 0xdf, 0x9b, 0xae, 0xc9, 0x0b,
-	0x72, 0x72, 0x17, 0x8c, 0x0a, 0x38, 0xbc, 0xc0, 0xac, 0xc0, 0x52, 0x9b, 0xa4, 0x1b, 0x14, 0xb6,
-	0xb7, 0x39, 0x27, 0x9b, 0x0a, 0x89, 0x7c, 0xeb, 0x5e, 0x0b, 0xd9, 0x46, 0x5e, 0x44, 0xcf, 0x2a,
-	0x2f, 0x9a, 0x0e, 0x3a, 0x87, 0x89, 0xae, 0xb7, 0x55, 0xf2, 0xea, 0x22, 0xff, 0x0d, 0x83, 0x03,
-	0x1b, 0x6d, 0x1d, 0xd1, 0x4f, 0x30, 0x75, 0xe5, 0x14, 0x7b, 0x02, 0x93, 0x02, 0x65, 0x86, 0xa9,
-	0xbb, 0xda, 0xe3, 0xb6, 0x43, 0x02, 0x70, 0x17, 0x65, 0x1f, 0xc2, 0x74, 0x57, 0x3a, 0xe4, 0x68,
-	0x40, 0x78, 0x83, 0x6c, 0xe3, 0xd1, 0x0b, 0x78, 0xfb, 0x9b, 0xaa, 0xa8, 0x25, 0x2a, 0x85, 0xe9,
-	0x0f, 0x86, 0x2b, 0xc5, 0xb1, 0xde, 0xe6, 0x89, 0x60, 0x1f, 0xc1, 0x54, 0xb9, 0xd2, 0x8e, 0x92,
-	0x7b, 0xc3, 0x34, 0x8a, 0xb7, 0x80, 0xe8, 0xb5, 0x07, 0x0f, 0xba, 0x44, 0xbd, 0x97, 0xf1, 0x08,
-	0x66, 0xe6, 0x5e, 0x54, 0x2d, 0x12, 0x74, 0x4c, 0x75, 0x8e, 0x21, 0x35, 0xa3, 0x7d, 0x6a, 0x02,
-	0x38, 0xc4, 0x32, 0xed, 0xd1, 0xd6, 0x1c, 0xd9, 0x13, 0x38, 0x4e, 0xda, 0x6a, 0x2b, 0xbb, 0x52,
-	0x4c, 0xea, 0x3d, 0x2f, 0x7b, 0x0a, 0x53, 0x69, 0xc7, 0x51, 0x81, 0x4f, 0x33, 0x84, 0x6e, 0x86,
-	0xff, 0x98, 0x9a, 0xb7, 0xf8, 0x28, 0x86, 0xf1, 0x4a, 0x64, 0x03, 0x91, 0xcd, 0x6f, 0x13, 0xd9,
-	0xbc, 0x59, 0x09, 0x7f, 0x78, 0x30, 0xb1, 0x6a, 0xe9, 0x89, 0x76, 0x6e, 0x44, 0xcb, 0x3e, 0x80,
-	0x09, 0x61, 0x9a, 0xa7, 0x76, 0x7f, 0x7f, 0x37, 0x28, 0xee, 0x00, 0x2c, 0x74, 0x3b, 0xd2, 0x3e,
-	0x15, 0x70, 0xc0, 0x95, 0xc8, 0xec, 0x4a, 0x64, 0x5f, 0x00, 0x74, 0x43, 0xd2, 0xd8, 0xdd, 0x26,
-	0xbd, 0xed, 0x06, 0x78, 0x0f, 0xbe, 0xcc, 0xc1, 0xb7, 0xef, 0x77, 0x09, 0x3e, 0x89, 0x9f, 0x0d,
-	0x1e, 0xbd, 0xdb, 0x21, 0x0f, 0x59, 0xdf, 0x69, 0xdf, 0xc7, 0x99, 0xc7, 0x3e, 0x06, 0x9f, 0x76,
-	0x2a, 0x1b, 0xfc, 0x59, 0x9a, 0x6f, 0xe6, 0xce, 0x49, 0xbb, 0xea, 0xd4, 0xfb, 0xfa, 0xe4, 0xcf,
-	0xeb, 0xd0, 0x7b, 0x7d, 0x1d, 0x7a, 0x7f, 0x5f, 0x87, 0xde, 0xef, 0xff, 0x84, 0x6f, 0xac, 0x27,
-	0xf4, 0xa3, 0x3b, 0xff, 0x37, 0x00, 0x00, 0xff, 0xff, 0x25, 0xa1, 0x04, 0x1f, 0x2a, 0x07, 0x00,
-	0x00,
+	// 952 bytes of a gzipped FileDescriptorProto
+	0x1f, 0x8b, 0x08, 0x00, 0x00, 0x00, 0x00, 0x00, 0x02, 0xff, 0x8c, 0x56, 0xdb, 0x8e, 0x1b, 0x45,
+	0x13, 0xce, 0x78, 0x3c, 0x3e, 0x94, 0xe7, 0x77, 0xbc, 0xfd, 0x2f, 0xc2, 0x5a, 0x82, 0xb5, 0x1a,
+	0x89, 0xb0, 0x04, 0xb0, 0x57, 0xbb, 0x48, 0xa0, 0x20, 0x81, 0xc2, 0xc6, 0x91, 0x82, 0x76, 0xd7,
+	0xa2, 0x3d, 0x80, 0xb8, 0x21, 0x6a, 0xcf, 0xd4, 0x8e, 0x47, 0xf1, 0x1c, 0x98, 0x6e, 0x47, 0x31,
+	0xf7, 0xdc, 0xf3, 0x08, 0xbc, 0x00, 0xef, 0xc1, 0x65, 0x1e, 0x80, 0x0b, 0xb4, 0xbc, 0x08, 0xea,
+	0x9e, 0x9e, 0x83, 0x1d, 0x23, 0x72, 0x57, 0xfd, 0xd5, 0x37, 0x5d, 0x55, 0x5f, 0x57, 0x95, 0x0d,
+	0x5f, 0x04, 0xa1, 0x58, 0xae, 0x17, 0x63, 0x2f, 0x89, 0x26, 0xd1, 0xb9, 0xbf, 0x98, 0x44, 0xe7,
+	0x13, 0x9e, 0x79, 0x93, 0x9f, 0xd6, 0x98, 0x6d, 0x26, 0x01, 0xc6, 0x98, 0x31, 0x81, 0xfe, 0x24,
+	0xcd, 0x12, 0x91, 0x4c, 0xb2, 0xd4, 0x4b, 0x17, 0xb9, 0x6f, 0xac, 0x10, 0x62, 0x29, 0xc8, 0xb9,
+	0x01, 0xfb, 0xfb, 0x2c, 0x14, 0x78, 0x85, 0x9c, 0xb3, 0x00, 0xc9, 0xfb, 0x60, 0x29, 0xd6, 0xd0,
+	0x38, 0x36, 0x4e, 0x7a, 0x67, 0x07, 0x63, 0x45, 0x1b, 0x2b, 0xce, 0x37, 0xd2, 0x41, 0x73, 0x3f,
+	0xf9, 0x18, 0xda, 0x49, 0x2a, 0xc2, 0x24, 0xe6, 0xc3, 0x86, 0xa2, 0xfe, 0xbf, 0x4e, 0x9d, 0xe5,
+	0x2e, 0x5a, 0x70, 0x9c, 0x3f, 0x0d, 0x80, 0xea, 0x12, 0x42, 0xa0, 0xb9, 0x8e, 0x43, 0xa1, 0xa2,
+	0x58, 0x54, 0xd9, 0x64, 0x04, 0xc0, 0xe2, 0x38, 0x11, 0x4c, 0x7e, 0xa1, 0x2e, 0xb5, 0x69, 0x0d,
+	0x21, 0xa7, 0x00, 0x3e, 0x13, 0x2c, 0x4d, 0xc2, 0x58, 0xf0, 0xa1, 0x79, 0x6c, 0x9e, 0xf4, 0xce,
+	0x06, 0x3a, 0xe8, 0xe3, 0xc2, 0x41, 0x6b, 0x1c, 0x32, 0x81, 0xa6, 0x60, 0x01, 0x1f, 0x36, 0x15,
+	0xf7, 0x9d, 0xd7, 0x6a, 0x19, 0xbb, 0x2c, 0xe0, 0xd3, 0x58, 0x64, 0x1b, 0xaa, 0x88, 0x47, 0x9f,
+	0x42, 0xb7, 0x84, 0xc8, 0x00, 0xcc, 0xe7, 0x98, 0x0b, 0xd1, 0xa5, 0xd2, 0x24, 0x87, 0x60, 0xbd,
+	0x60, 0xab, 0x35, 0xaa, 0xe4, 0xba, 0x34, 0x3f, 0x3c, 0x6c, 0x7c, 0x66, 0x38, 0x23, 0xb0, 0xeb,
+	0x75, 0x93, 0x3e, 0x34, 0x42, 0x5f, 0x7f, 0xda, 0x08, 0x7d, 0xe7, 0x4b, 0xe8, 0x96, 0x29, 0x92,
+	0x7b, 0xd0, 0x15, 0x61, 0x84, 0x5c, 0xb0, 0x28, 0x55, 0x1c, 0x93, 0x56, 0xc0, 0x76, 0x10, 0x43,
+	0x07, 0x71, 0x96, 0x00, 0x8f, 0xab, 0xc2, 0xb6, 0xa5, 0x30, 0xde, 0x40, 0x8a, 0x13, 0xb8, 0x7b,
+	0x13, 0xbe, 0x44, 0x9f, 0x22, 0x4f, 0x56, 0xeb, 0x52, 0xe1, 0x0e, 0xdd, 0x85, 0x9d, 0x77, 0xc1,
+	0x9a, 0x66, 0x59, 0x92, 0xc9, 0x44, 0x50, 0x1a, 0xba, 0x8c, 0xfc, 0x20, 0x1b, 0xe6, 0x09, 0x0a,
+	0x6f, 0xf9, 0x1f, 0x0d, 0xa3, 0x38, 0x6f, 0xd6, 0x30, 0x8a, 0xfa, 0x5a, 0xc3, 0xdc, 0x00, 0x54,
+	0x77, 0xc8, 0x5c, 0xb8, 0x60, 0x99, 0xd0, 0x72, 0xe5, 0x07, 0xf9, 0x42, 0x18, 0xfb, 0xea, 0x3a,
+	0x93, 0x4a, 0x93, 0x9c, 0x42, 0x4f, 0xb0, 0xe0, 0x8a, 0x09, 0x6f, 0x89, 0x59, 0xd1, 0x24, 0x7d,
+	0x1d, 0x48, 0xc3, 0xb4, 0x4e, 0x71, 0x7e, 0x37, 0xc0, 0xae, 0x67, 0xb0, 0xfb, 0x74, 0x64, 0x06,
+	0x87, 0x19, 0x32, 0xff, 0x22, 0x89, 0x79, 0xc8, 0x05, 0xc6, 0xde, 0xe6, 0x12, 0x5f, 0xe0, 0x4a,
+	0x45, 0xed, 0x97, 0x4d, 0x45, 0xf7, 0x50, 0xe8, 0xde, 0x0f, 0xe5, 0xf3, 0xfb, 0x89, 0xc7, 0x2f,
+	0xc3, 0x28, 0x14, 0x43, 0x33, 0x7f, 0xfe, 0x12, 0x90, 0x53, 0xb0, 0xd8, 0x08, 0xd4, 0xee, 0xa6,
+	0x72, 0xd7, 0x10, 0xe7, 0x17, 0x03, 0xda, 0x3a, 0x79, 0x39, 0x45, 0x31, 0x8b, 0x50, 0x27, 0xab,
+	0xec, 0xfd, 0x3d, 0x2a, 0x99, 0x62, 0x93, 0xa2, 0x0e, 0xa7, 0x6c, 0x72, 0x04, 0x9d, 0x88, 0xbd,
+	0x9c, 0xfa, 0xa1, 0xe0, 0x3a, 0x4e, 0x79, 0x26, 0xc7, 0xd0, 0x5b, 0x32, 0x7e, 0x55, 0xb8, 0x2d,
+	0xd5, 0x2a, 0x75, 0xc8, 0xf9, 0x04, 0x7a, 0x4a, 0x36, 0x8a, 0x7c, 0xbd, 0x12, 0xe4, 0x3d, 0x68,
+	0x71, 0xcc, 0x42, 0x2c, 0xba, 0xf1, 0x7f, 0x5a, 0x97, 0xb9, 0x02, 0xa9, 0x76, 0x3a, 0x11, 0xb4,
+	0xe7, 0x18, 0x44, 0x18, 0x0b, 0x99, 0xd2, 0x12, 0x59, 0xae, 0xb4, 0x4d, 0x95, 0xad, 0xd2, 0x64,
+	0xe1, 0x4a, 0x0f, 0xbf, 0xb2, 0xa5, 0x5c, 0xea, 0xb5, 0xdd, 0x30, 0x2a, 0xf2, 0xaf, 0x00, 0xe9,
+	0x5d, 0xac, 0x12, 0xef, 0xf9, 0x3c, 0xfc, 0x19, 0x75, 0x15, 0x15, 0xe0, 0xfc, 0x08, 0x1d, 0x1d,
+	0x8e, 0x93, 0xfb, 0xd0, 0x8a, 0x30, 0x0b, 0xd0, 0xd7, 0x9d, 0xda, 0x2f, 0x33, 0x54, 0x04, 0xaa,
+	0xbd, 0xe4, 0x01, 0x74, 0xd6, 0xb1, 0x66, 0x36, 0x8e, 0xcd, 0x3d, 0xcc, 0xd2, 0xef, 0x3c, 0x81,
+	0xb7, 0x2f, 0x92, 0x28, 0xcd, 0x90, 0x73, 0xf4, 0xbf, 0x93, 0x4a, 0x73, 0x8a, 0xe9, 0x2a, 0xf4,
+	0x18, 0xf9, 0x10, 0x3a, 0x5c, 0x87, 0xd6, 0x92, 0xdc, 0xdd, 0xbe, 0x86, 0xd3, 0x92, 0xe0, 0xbc,
+	0x32, 0xe0, 0xb0, 0xba, 0xa8, 0x36, 0xe8, 0xf7, 0xa0, 0x2b, 0x5f, 0x95, 0xa7, 0xcc, 0x43, 0xad,
+	0x54, 0x05, 0x6c, 0x4b, 0xd3, 0xd8, 0x95, 0x66, 0x08, 0x6d, 0x8c, 0xfd, 0x9a, 0x6c, 0xc5, 0x91,
+	0xdc, 0x87, 0xbe, 0x57, 0x46, 0x73, 0xf3, 0x0d, 0x29, 0xaf, 0xde, 0x41, 0xc9, 0x43, 0xe8, 0x64,
+	0x79, 0x39, 0xb2, 0x05, 0x64, 0x0d, 0x23, 0x5d, 0xc3, 0xbf, 0x54, 0x4d, 0x4b, 0xbe, 0x33, 0x01,
+	0xd3, 0x65, 0xc1, 0x56, 0x8b, 0xda, 0xfb, 0x5a, 0xd4, 0x2e, 0x36, 0xdc, 0x6f, 0x06, 0xb4, 0xf2,
+	0x6e, 0xa9, 0x8d, 0xa0, 0xad, 0x46, 0xf0, 0x03, 0x68, 0x29, 0x4e, 0xb1, 0x39, 0x0e, 0x76, 0x57,
+	0x1d, 0xa7, 0x9a, 0x40, 0x46, 0x7a, 0xe5, 0xe7, 0x93, 0x0f, 0x9a, 0xe8, 0xb2, 0x20, 0xdf, 0xf0,
+	0xe4, 0x73, 0x80, 0xaa, 0x48, 0x55, 0x76, 0xf5, 0xc3, 0xb0, 0xef, 0x05, 0x68, 0x8d, 0xfe, 0xc0,
+	0x87, 0xc3, 0x7d, 0x73, 0x4e, 0x7a, 0xd0, 0xbe, 0x9e, 0xb9, 0xcf, 0xe6, 0x53, 0x77, 0x70, 0x87,
+	0x74, 0xa0, 0x79, 0x3d, 0xbb, 0x9e, 0x0e, 0x0c, 0xd2, 0x06, 0x53, 0x1a, 0x0d, 0xf2, 0x16, 0x1c,
+	0x7c, 0x7b, 0x3d, 0x77, 0xe9, 0xd3, 0x0b, 0xf7, 0xd9, 0xd5, 0xa3, 0xaf, 0x67, 0xf4, 0xa9, 0xfb,
+	0xc3, 0xc0, 0x24, 0x36, 0x74, 0xca, 0x53, 0x53, 0xb2, 0x1f, 0x5d, 0x5e, 0x0e, 0xac, 0xb3, 0x10,
+	0xac, 0x7c, 0xe9, 0x9d, 0x81, 0xa5, 0x46, 0x8c, 0x6c, 0x6d, 0x4a, 0xbd, 0x78, 0x8f, 0x48, 0x1d,
+	0xcc, 0xa7, 0xf0, 0xd4, 0x20, 0x1f, 0x81, 0xa5, 0x7e, 0x88, 0xc8, 0xd6, 0xcf, 0x71, 0xf1, 0x8d,
+	0xad, 0x41, 0xb5, 0xe0, 0x4f, 0x8c, 0xaf, 0x06, 0x7f, 0xdc, 0x8e, 0x8c, 0x57, 0xb7, 0x23, 0xe3,
+	0xaf, 0xdb, 0x91, 0xf1, 0xeb, 0xdf, 0xa3, 0x3b, 0x8b, 0x96, 0xfa, 0x77, 0x70, 0xfe, 0xcf, 0x00,
+	0xae, 0xdd, 0x78, 0xca, 0x5f, 0x08, 0x00, 0x00,
 }
@@ -0,0 +1,68 @@
+// Copyright (c) 2018 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package models
+
+import (
+	"fmt"
+)
+
+// ConsolidationType is how consecutive values of a series are consolidated
+// into a single value when downsampling results.
+type ConsolidationType int
+
+const (
+	// ConsolidationAvg is the average of the values.
+	ConsolidationAvg ConsolidationType = iota
+
+	// ConsolidationMin is the minimum of the values.
+	ConsolidationMin
+
+	// ConsolidationMax is the maximum of the values.
+	ConsolidationMax
+
+	// ConsolidationLast is the last of the values.
+	ConsolidationLast
+)
+
+var consolidationTypeNames = map[ConsolidationType]string{
+	ConsolidationAvg:  "avg",
+	ConsolidationMin:  "min",
+	ConsolidationMax:  "max",
+	ConsolidationLast: "last",
+}
+
+func (t ConsolidationType) String() string {
+	if name, ok := consolidationTypeNames[t]; ok {
+		return name
+	}
+	return "unknown"
+}
+
+// ParseConsolidationType parses a consolidation type from its name, one of
+// avg, min, max or last.
+func ParseConsolidationType(str string) (ConsolidationType, error) {
+	for t, name := range consolidationTypeNames {
+		if name == str {
+			return t, nil
+		}
+	}
+	return ConsolidationAvg, fmt.Errorf("unknown consolidation type: %s, must be one of avg, min, max or last", str)
+}
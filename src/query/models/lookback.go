@@ -0,0 +1,124 @@
+// Copyright (c) 2018 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package models
+
+import (
+	"strings"
+	"time"
+)
+
+// MetricType is the type of a metric, which determines how long its last
+// value is carried forward.
+type MetricType int
+
+const (
+	// MetricTypeGauge is a metric whose value can go up and down.
+	MetricTypeGauge MetricType = iota
+
+	// MetricTypeCounter is a monotonically increasing metric, which tolerates
+	// a longer lookback since rates only depend on its increase.
+	MetricTypeCounter
+)
+
+// DefaultCounterSuffixes are the metric name suffixes of counters following
+// the Prometheus naming conventions.
+var DefaultCounterSuffixes = []string{"_total", "_count", "_sum", "_bucket"}
+
+func (t MetricType) String() string {
+	if t == MetricTypeCounter {
+		return "counter"
+	}
+	return "gauge"
+}
+
+// LookbackOptions are the durations the last value of a series is carried
+// forward to later steps, by metric type. A zero duration does not carry
+// values forward by age and keeps the alignment of ts.RawPointsToFixedStep.
+type LookbackOptions struct {
+	// Gauge is the lookback of gauges.
+	Gauge time.Duration
+
+	// Counter is the lookback of counters.
+	Counter time.Duration
+
+	// CounterSuffixes are the metric name suffixes of counters, defaults to
+	// DefaultCounterSuffixes if not set.
+	CounterSuffixes []string
+}
+
+// Enabled returns true if a lookback is set for either metric type.
+func (o LookbackOptions) Enabled() bool {
+	return o.Gauge > 0 || o.Counter > 0
+}
+
+// Max returns the longest lookback of any metric type.
+func (o LookbackOptions) Max() time.Duration {
+	if o.Counter > o.Gauge {
+		return o.Counter
+	}
+	return o.Gauge
+}
+
+// MetricType returns the type of the metric with the given name.
+func (o LookbackOptions) MetricType(name string) MetricType {
+	suffixes := o.CounterSuffixes
+	if suffixes == nil {
+		suffixes = DefaultCounterSuffixes
+	}
+	for _, suffix := range suffixes {
+		if strings.HasSuffix(name, suffix) {
+			return MetricTypeCounter
+		}
+	}
+	return MetricTypeGauge
+}
+
+// Lookback returns the lookback of the series with the given tags.
+func (o LookbackOptions) Lookback(tags Tags) time.Duration {
+	name, _ := tags.Get(MetricName)
+	if o.MetricType(name) == MetricTypeCounter {
+		return o.Counter
+	}
+	return o.Gauge
+}
+
+// LookbackConfiguration is the configuration of the default lookback of
+// queries, which can be overridden by query parameters.
+type LookbackConfiguration struct {
+	// Gauge is the lookback of gauges.
+	Gauge time.Duration `yaml:"gauge" validate:"min=0"`
+
+	// Counter is the lookback of counters.
+	Counter time.Duration `yaml:"counter" validate:"min=0"`
+
+	// CounterSuffixes are the metric name suffixes of counters, defaults to
+	// "_total", "_count", "_sum" and "_bucket" if not set.
+	CounterSuffixes []string `yaml:"counterSuffixes"`
+}
+
+// Options returns the lookback options of the configuration.
+func (c LookbackConfiguration) Options() LookbackOptions {
+	return LookbackOptions{
+		Gauge:           c.Gauge,
+		Counter:         c.Counter,
+		CounterSuffixes: c.CounterSuffixes,
+	}
+}
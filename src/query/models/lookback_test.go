@@ -0,0 +1,46 @@
+// Copyright (c) 2018 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package models
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestLookbackByMetricType(t *testing.T) {
+	opts := LookbackOptions{Gauge: time.Minute, Counter: 5 * time.Minute}
+	assert.True(t, opts.Enabled())
+	assert.Equal(t, 5*time.Minute, opts.Max())
+
+	counter := Tags{{Name: MetricName, Value: "http_requests_total"}}
+	gauge := Tags{{Name: MetricName, Value: "memory_bytes"}}
+	assert.Equal(t, 5*time.Minute, opts.Lookback(counter))
+	assert.Equal(t, time.Minute, opts.Lookback(gauge))
+	assert.Equal(t, time.Minute, opts.Lookback(Tags{}))
+
+	opts.CounterSuffixes = []string{"_bytes"}
+	assert.Equal(t, MetricTypeGauge, opts.MetricType("http_requests_total"))
+	assert.Equal(t, MetricTypeCounter, opts.MetricType("memory_bytes"))
+
+	assert.False(t, LookbackOptions{}.Enabled())
+}
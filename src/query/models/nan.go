@@ -0,0 +1,64 @@
+// Copyright (c) 2018 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package models
+
+import (
+	"fmt"
+)
+
+// NaNMode is how aggregations handle NaN values of their input series.
+type NaNMode int
+
+const (
+	// NaNSkip ignores NaN values, the result is NaN only if all values are
+	// NaN.
+	NaNSkip NaNMode = iota
+
+	// NaNPropagate makes the result NaN if any value is NaN.
+	NaNPropagate
+
+	// NaNAsZero treats NaN values as zero.
+	NaNAsZero
+)
+
+var nanModeNames = map[NaNMode]string{
+	NaNSkip:      "skip",
+	NaNPropagate: "propagate",
+	NaNAsZero:    "zero",
+}
+
+func (m NaNMode) String() string {
+	if name, ok := nanModeNames[m]; ok {
+		return name
+	}
+	return "unknown"
+}
+
+// ParseNaNMode parses a NaN mode from its name, one of skip, propagate or
+// zero.
+func ParseNaNMode(str string) (NaNMode, error) {
+	for mode, name := range nanModeNames {
+		if name == str {
+			return mode, nil
+		}
+	}
+	return NaNSkip, fmt.Errorf("unknown NaN mode: %s, must be one of skip, propagate or zero", str)
+}
@@ -22,8 +22,34 @@ package models
 
 import (
 	"time"
+
+	"github.com/m3db/m3/src/dbnode/topology"
 )
 
+// FetchControls are the per request controls of the consistency and fanout
+// of fetches.
+type FetchControls struct {
+	// ReadConsistencyLevel overrides the read consistency level of fetches
+	// from local namespaces, and of those forwarded to remote zones, if set.
+	ReadConsistencyLevel *topology.ReadConsistencyLevel
+
+	// LocalOnly fetches from the local zone only rather than also fanning
+	// out to remote zones.
+	LocalOnly bool
+
+	// VerifyReplicas compares the checksums of the blocks of the series
+	// fetched from local namespaces between replicas and reports those that
+	// differ as warnings of the query.
+	VerifyReplicas bool
+
+	// DocsLimit and BytesLimit bound the documents matched and the ID and
+	// tag bytes returned by the index query of each fetch from a local
+	// namespace if positive, each is further bounded by the limits of the
+	// nodes. Results are not exhaustive when either is exceeded.
+	DocsLimit  int
+	BytesLimit int
+}
+
 // RequestParams represents the params from the request
 type RequestParams struct {
 	Start time.Time
@@ -35,6 +61,21 @@ type RequestParams struct {
 	Query      string
 	Debug      bool
 	IncludeEnd bool
+	// Lookback is the duration the last value of each series is carried
+	// forward, by metric type
+	Lookback LookbackOptions
+	// DedupeSeries keeps the first of the series with the same label set
+	// produced by a function rather than failing the query
+	DedupeSeries bool
+	// NaNMode is how sum, avg, min and max aggregations handle NaN values
+	NaNMode NaNMode
+	// MaxDatapoints is the max number of datapoints returned per series, the
+	// values of series with more datapoints are consolidated
+	MaxDatapoints int
+	// Consolidation is how values are consolidated to at most MaxDatapoints
+	Consolidation ConsolidationType
+	// FetchControls are the consistency and fanout controls of fetches
+	FetchControls FetchControls
 }
 
 // ExclusiveEnd returns the end exclusive
@@ -25,6 +25,9 @@ import (
 	"hash/fnv"
 	"regexp"
 	"sort"
+	"strings"
+
+	"github.com/m3db/m3/src/m3ninx/idx"
 )
 
 const (
@@ -68,14 +71,22 @@ const (
 	MatchNotEqual
 	MatchRegexp
 	MatchNotRegexp
+	MatchEqualCaseInsensitive
+	MatchFuzzy
 )
 
+// DefaultFuzzyEdits is the edit distance used by fuzzy matchers unless
+// otherwise specified.
+const DefaultFuzzyEdits = 1
+
 func (m MatchType) String() string {
 	typeToStr := map[MatchType]string{
-		MatchEqual:     "=",
-		MatchNotEqual:  "!=",
-		MatchRegexp:    "=~",
-		MatchNotRegexp: "!~",
+		MatchEqual:                "=",
+		MatchNotEqual:             "!=",
+		MatchRegexp:               "=~",
+		MatchNotRegexp:            "!~",
+		MatchEqualCaseInsensitive: "=*",
+		MatchFuzzy:                "~=",
 	}
 	if str, ok := typeToStr[m]; ok {
 		return str
@@ -88,12 +99,17 @@ type Matcher struct {
 	Type  MatchType `json:"type"`
 	Name  string    `json:"name"`
 	Value string    `json:"value"`
+	// MaxEdits is the edit distance allowed by MatchFuzzy matchers.
+	MaxEdits int `json:"maxEdits,omitempty"`
 
 	re *regexp.Regexp
 }
 
 // NewMatcher returns a matcher object.
 func NewMatcher(t MatchType, n, v string) (*Matcher, error) {
+	if t == MatchFuzzy {
+		return NewFuzzyMatcher(n, v, DefaultFuzzyEdits)
+	}
 	m := &Matcher{
 		Type:  t,
 		Name:  n,
@@ -109,6 +125,27 @@ func NewMatcher(t MatchType, n, v string) (*Matcher, error) {
 	return m, nil
 }
 
+// NewFuzzyMatcher returns a matcher which matches values within maxEdits
+// insertions, deletions or substitutions of the given value, which must be at
+// most idx.MaxFuzzyTermLength(maxEdits) runes long.
+func NewFuzzyMatcher(n, v string, maxEdits int) (*Matcher, error) {
+	pattern, err := idx.FuzzyRegexp([]byte(v), maxEdits)
+	if err != nil {
+		return nil, err
+	}
+	re, err := regexp.Compile("^(?:" + string(pattern) + ")$")
+	if err != nil {
+		return nil, err
+	}
+	return &Matcher{
+		Type:     MatchFuzzy,
+		Name:     n,
+		Value:    v,
+		MaxEdits: maxEdits,
+		re:       re,
+	}, nil
+}
+
 func (m *Matcher) String() string {
 	return fmt.Sprintf("%s%s%q", m.Name, m.Type, m.Value)
 }
@@ -124,13 +161,71 @@ func (m *Matcher) Matches(s string) bool {
 		return m.re.MatchString(s)
 	case MatchNotRegexp:
 		return !m.re.MatchString(s)
+	case MatchEqualCaseInsensitive:
+		return strings.EqualFold(s, m.Value)
+	case MatchFuzzy:
+		if m.re == nil {
+			// Fuzzy matchers decoded from JSON or built as struct literals
+			// have no compiled regexp.
+			return withinEdits(s, m.Value, m.MaxEdits)
+		}
+		return m.re.MatchString(s)
 	}
 	panic("labels.Matcher.Matches: invalid match type")
 }
 
+// withinEdits returns whether the Levenshtein distance between the runes of
+// the two strings is at most maxEdits.
+func withinEdits(a, b string, maxEdits int) bool {
+	var (
+		ar, br = []rune(a), []rune(b)
+		prev   = make([]int, len(br)+1)
+		curr   = make([]int, len(br)+1)
+	)
+	for j := range prev {
+		prev[j] = j
+	}
+	for i := 1; i <= len(ar); i++ {
+		curr[0] = i
+		for j := 1; j <= len(br); j++ {
+			cost := 1
+			if ar[i-1] == br[j-1] {
+				cost = 0
+			}
+			curr[j] = minInt(prev[j]+1, minInt(curr[j-1]+1, prev[j-1]+cost))
+		}
+		prev, curr = curr, prev
+	}
+	return prev[len(br)] <= maxEdits
+}
+
+func minInt(a, b int) int {
+	if a < b {
+		return a
+	}
+	return b
+}
+
 // Matchers is of matchers
 type Matchers []*Matcher
 
+// String returns the matchers as a PromQL selector, with the metric name
+// outside of the braces if it is matched exactly.
+func (m Matchers) String() string {
+	var (
+		name     string
+		matchers = make([]string, 0, len(m))
+	)
+	for _, matcher := range m {
+		if name == "" && matcher.Name == MetricName && matcher.Type == MatchEqual {
+			name = matcher.Value
+			continue
+		}
+		matchers = append(matchers, matcher.String())
+	}
+	return name + "{" + strings.Join(matchers, ",") + "}"
+}
+
 // ToTags converts Matchers to Tags
 // NB (braskin): this only works for exact matches
 func (m Matchers) ToTags() (Tags, error) {
@@ -92,6 +92,26 @@ func TestMatcher(t *testing.T) {
 			value:   "foo-bar",
 			match:   false,
 		},
+		{
+			matcher: mustNewMatcher(t, MatchEqualCaseInsensitive, "bar"),
+			value:   "BaR",
+			match:   true,
+		},
+		{
+			matcher: mustNewMatcher(t, MatchEqualCaseInsensitive, "bar"),
+			value:   "foo-bar",
+			match:   false,
+		},
+		{
+			matcher: mustNewMatcher(t, MatchFuzzy, "bar"),
+			value:   "baz",
+			match:   true,
+		},
+		{
+			matcher: mustNewMatcher(t, MatchFuzzy, "bar"),
+			value:   "foo-bar",
+			match:   false,
+		},
 	}
 
 	for _, test := range tests {
@@ -103,6 +123,36 @@ func TestMatcher(t *testing.T) {
 
 func TestMatchType(t *testing.T) {
 	require.Equal(t, MatchEqual.String(), "=")
+	require.Equal(t, MatchEqualCaseInsensitive.String(), "=*")
+	require.Equal(t, MatchFuzzy.String(), "~=")
+}
+
+func TestMatchersString(t *testing.T) {
+	name, err := NewMatcher(MatchEqual, MetricName, "up")
+	require.NoError(t, err)
+	job, err := NewMatcher(MatchRegexp, "job", "api.*")
+	require.NoError(t, err)
+
+	assert.Equal(t, `up{job=~"api.*"}`, Matchers{job, name}.String())
+	assert.Equal(t, `{job=~"api.*"}`, Matchers{job}.String())
+}
+
+func TestFuzzyMatcherEdits(t *testing.T) {
+	m, err := NewFuzzyMatcher("foo", "bar", 2)
+	require.NoError(t, err)
+	require.True(t, m.Matches("bz"))
+	require.False(t, m.Matches("xyz"))
+
+	_, err = NewFuzzyMatcher("foo", "bar", 5)
+	require.Error(t, err)
+}
+
+func TestFuzzyMatcherWithoutRegexp(t *testing.T) {
+	m := &Matcher{Type: MatchFuzzy, Name: "foo", Value: "bar", MaxEdits: 1}
+	assert.True(t, m.Matches("bar"))
+	assert.True(t, m.Matches("baz"))
+	assert.True(t, m.Matches("ba"))
+	assert.False(t, m.Matches("bzz"))
 }
 
 func createTags(withName bool) Tags {
@@ -0,0 +1,129 @@
+// Copyright (c) 2018 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package promql
+
+import (
+	"bytes"
+	"unicode"
+
+	"github.com/m3db/m3/src/query/functions/linear"
+	"github.com/m3db/m3/src/query/functions/temporal"
+)
+
+// Substitute functions are the Prometheus functions that calls of functions
+// only implemented by m3query are parsed as, each takes the same args and
+// returns the same type as the functions substituted by it.
+const (
+	// predict_linear takes a range vector and a scalar.
+	rangeScalarSubstitute = "predict_linear"
+	// label_join takes an instant vector and at least two strings.
+	vectorStringsSubstitute = "label_join"
+)
+
+// m3Functions are the functions only implemented by m3query, and the
+// substitute function of each.
+var m3Functions = map[string]string{
+	temporal.HoltWintersPredictType: rangeScalarSubstitute,
+	temporal.HoltWintersUpperType:   rangeScalarSubstitute,
+	temporal.HoltWintersLowerType:   rangeScalarSubstitute,
+	temporal.AnomalyScoreType:       rangeScalarSubstitute,
+	linear.ConvertUnitType:          vectorStringsSubstitute,
+}
+
+func isSubstitute(name string) bool {
+	return name == rangeScalarSubstitute || name == vectorStringsSubstitute
+}
+
+// substituteFunctions rewrites calls of functions only implemented by m3query
+// into calls of their substitute function, since the Prometheus parser rejects
+// calls of functions it does not know. It returns the rewritten query and the
+// name of the function of each call of a substitute function in the order
+// they appear in the query, which is the order calls are walked in.
+func substituteFunctions(q string) (string, []string) {
+	var (
+		buf   bytes.Buffer
+		calls []string
+		runes = []rune(q)
+	)
+	for i := 0; i < len(runes); {
+		r := runes[i]
+		switch {
+		case r == '"' || r == '\'' || r == '`':
+			end := skipString(runes, i)
+			buf.WriteString(string(runes[i:end]))
+			i = end
+
+		case r == '_' || unicode.IsLetter(r):
+			end := i
+			for end < len(runes) && isIdentRune(runes[end]) {
+				end++
+			}
+
+			ident := string(runes[i:end])
+			if isCall(runes, end) {
+				if substitute, ok := m3Functions[ident]; ok {
+					calls = append(calls, ident)
+					ident = substitute
+				} else if isSubstitute(ident) {
+					calls = append(calls, ident)
+				}
+			}
+
+			buf.WriteString(ident)
+			i = end
+
+		default:
+			buf.WriteRune(r)
+			i++
+		}
+	}
+
+	return buf.String(), calls
+}
+
+func isIdentRune(r rune) bool {
+	return r == '_' || r == ':' || unicode.IsLetter(r) || unicode.IsDigit(r)
+}
+
+// isCall returns true if the identifier ending at the index is followed by
+// an opening parenthesis.
+func isCall(runes []rune, end int) bool {
+	for i := end; i < len(runes); i++ {
+		if !unicode.IsSpace(runes[i]) {
+			return runes[i] == '('
+		}
+	}
+	return false
+}
+
+// skipString returns the index after the string starting at the index.
+func skipString(runes []rune, start int) int {
+	quote := runes[start]
+	for i := start + 1; i < len(runes); i++ {
+		switch {
+		case runes[i] == '\\' && quote != '`':
+			i++
+		case runes[i] == quote:
+			return i + 1
+		}
+	}
+	return len(runes)
+}
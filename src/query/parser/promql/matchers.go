@@ -0,0 +1,104 @@
+// Copyright (c) 2018 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package promql
+
+import (
+	"bytes"
+	"strings"
+
+	"github.com/m3db/m3/src/query/models"
+)
+
+// Markers prefixed to the values of the equality matchers that matchers only
+// implemented by m3query are parsed as, they are runes of the Unicode private
+// use area so they do not clash with label values.
+const (
+	caseInsensitiveMarker = '\uE000'
+	fuzzyMarker           = '\uE001'
+)
+
+// substituteMatchers rewrites the case-insensitive (=*) and fuzzy (~=)
+// matchers only implemented by m3query into equality matchers with a marker
+// of their match type prefixed to their value, since the Prometheus parser
+// rejects match operators it does not know.
+func substituteMatchers(q string) string {
+	var (
+		buf    bytes.Buffer
+		runes  = []rune(q)
+		depth  int
+		marker rune
+	)
+	for i := 0; i < len(runes); {
+		r := runes[i]
+		switch {
+		case r == '"' || r == '\'' || r == '`':
+			end := skipString(runes, i)
+			buf.WriteRune(r)
+			if marker != 0 {
+				buf.WriteRune(marker)
+				marker = 0
+			}
+			buf.WriteString(string(runes[i+1 : end]))
+			i = end
+
+		case r == '{':
+			depth++
+			buf.WriteRune(r)
+			i++
+
+		case r == '}':
+			depth--
+			buf.WriteRune(r)
+			i++
+
+		case depth > 0 && i+1 < len(runes) && r == '=' && runes[i+1] == '*':
+			marker = caseInsensitiveMarker
+			buf.WriteRune('=')
+			i += 2
+
+		case depth > 0 && i+1 < len(runes) && r == '~' && runes[i+1] == '=' &&
+			(i == 0 || (runes[i-1] != '=' && runes[i-1] != '!')):
+			marker = fuzzyMarker
+			buf.WriteRune('=')
+			i += 2
+
+		default:
+			buf.WriteRune(r)
+			i++
+		}
+	}
+
+	return buf.String()
+}
+
+// substitutedMatchType returns the match type and value of an equality
+// matcher rewritten by substituteMatchers, or false if it was not rewritten.
+func substitutedMatchType(value string) (models.MatchType, string, bool) {
+	switch {
+	case strings.HasPrefix(value, string(caseInsensitiveMarker)):
+		return models.MatchEqualCaseInsensitive,
+			strings.TrimPrefix(value, string(caseInsensitiveMarker)), true
+	case strings.HasPrefix(value, string(fuzzyMarker)):
+		return models.MatchFuzzy,
+			strings.TrimPrefix(value, string(fuzzyMarker)), true
+	}
+	return 0, "", false
+}
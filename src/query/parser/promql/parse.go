@@ -24,29 +24,33 @@ import (
 	"fmt"
 
 	"github.com/m3db/m3/src/query/errors"
+	"github.com/m3db/m3/src/query/functions/conversion"
 	"github.com/m3db/m3/src/query/parser"
 
 	pql "github.com/prometheus/prometheus/promql"
 )
 
 type promParser struct {
-	expr pql.Expr
+	expr  pql.Expr
+	calls []string
 }
 
 // Parse takes a promQL string and converts parses it into a DAG
 func Parse(q string) (parser.Parser, error) {
-	expr, err := pql.ParseExpr(q)
+	substituted, calls := substituteFunctions(substituteMatchers(q))
+	expr, err := pql.ParseExpr(substituted)
 	if err != nil {
 		return nil, err
 	}
 
 	return &promParser{
-		expr: expr,
+		expr:  expr,
+		calls: calls,
 	}, nil
 }
 
 func (p *promParser) DAG() (parser.Nodes, parser.Edges, error) {
-	state := &parseState{}
+	state := &parseState{calls: p.calls}
 	err := state.walk(p.expr)
 	if err != nil {
 		return nil, nil, err
@@ -62,6 +66,21 @@ func (p *promParser) String() string {
 type parseState struct {
 	edges      parser.Edges
 	transforms parser.Nodes
+	// calls are the names of the functions of the remaining calls of
+	// substitute functions.
+	calls []string
+}
+
+// functionName returns the name of the function called, which differs from
+// the name parsed for calls of functions only implemented by m3query.
+func (p *parseState) functionName(n *pql.Call) string {
+	if !isSubstitute(n.Func.Name) || len(p.calls) == 0 {
+		return n.Func.Name
+	}
+
+	name := p.calls[0]
+	p.calls = p.calls[1:]
+	return name
 }
 
 func (p *parseState) lastTransformID() parser.NodeID {
@@ -121,15 +140,23 @@ func (p *parseState) walk(node pql.Node) error {
 		return nil
 
 	case *pql.Call:
+		name := p.functionName(n)
 		expressions := n.Args
 		argValues := make([]interface{}, 0, len(expressions))
 		for _, expr := range expressions {
 			switch e := expr.(type) {
 			case *pql.NumberLiteral:
-				argValues = append(argValues, e.Val)
-				continue
+				// NB: The argument of vector is the scalar to convert rather
+				// than a parameter, so it is evaluated as a node.
+				if name != conversion.VectorType {
+					argValues = append(argValues, e.Val)
+					continue
+				}
 			case *pql.MatrixSelector:
 				argValues = append(argValues, e.Range)
+			case *pql.StringLiteral:
+				argValues = append(argValues, e.Val)
+				continue
 			}
 
 			err := p.walk(expr)
@@ -138,7 +165,7 @@ func (p *parseState) walk(node pql.Node) error {
 			}
 		}
 
-		op, err := NewFunctionExpr(n.Func.Name, argValues)
+		op, err := NewFunctionExpr(name, argValues)
 		if err != nil {
 			return err
 		}
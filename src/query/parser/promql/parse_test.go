@@ -26,8 +26,10 @@ import (
 	"github.com/m3db/m3/src/query/functions"
 	"github.com/m3db/m3/src/query/functions/aggregation"
 	"github.com/m3db/m3/src/query/functions/binary"
+	"github.com/m3db/m3/src/query/functions/conversion"
 	"github.com/m3db/m3/src/query/functions/linear"
 	"github.com/m3db/m3/src/query/functions/temporal"
+	"github.com/m3db/m3/src/query/models"
 	"github.com/m3db/m3/src/query/parser"
 
 	"github.com/stretchr/testify/assert"
@@ -278,3 +280,96 @@ func TestFailedTemporalParse(t *testing.T) {
 	_, err := Parse(q)
 	require.Error(t, err)
 }
+
+func TestConversionParses(t *testing.T) {
+	p, err := Parse("up > scalar(threshold)")
+	require.NoError(t, err)
+	transforms, edges, err := p.DAG()
+	require.NoError(t, err)
+	require.Len(t, transforms, 4)
+	assert.Equal(t, functions.FetchType, transforms[0].Op.OpType())
+	assert.Equal(t, functions.FetchType, transforms[1].Op.OpType())
+	assert.Equal(t, conversion.ScalarType, transforms[2].Op.OpType())
+	assert.Equal(t, binary.GreaterType, transforms[3].Op.OpType())
+	require.Len(t, edges, 3)
+	assert.Equal(t, parser.NodeID("1"), edges[0].ParentID)
+	assert.Equal(t, parser.NodeID("2"), edges[0].ChildID)
+
+	p, err = Parse("vector(1)")
+	require.NoError(t, err)
+	transforms, edges, err = p.DAG()
+	require.NoError(t, err)
+	require.Len(t, transforms, 2)
+	assert.Equal(t, functions.ScalarType, transforms[0].Op.OpType())
+	assert.Equal(t, conversion.VectorType, transforms[1].Op.OpType())
+	require.Len(t, edges, 1)
+	assert.Equal(t, parser.NodeID("0"), edges[0].ParentID)
+	assert.Equal(t, parser.NodeID("1"), edges[0].ChildID)
+}
+
+func TestHoltWintersParses(t *testing.T) {
+	p, err := Parse("anomaly_score(foo[7d], 86400) > 3 or holt_winters_upper(bar[1d], 3600)")
+	require.NoError(t, err)
+	transforms, _, err := p.DAG()
+	require.NoError(t, err)
+	require.Len(t, transforms, 7)
+	assert.Equal(t, functions.FetchType, transforms[0].Op.OpType())
+	assert.Equal(t, temporal.AnomalyScoreType, transforms[1].Op.OpType())
+	assert.Equal(t, functions.FetchType, transforms[4].Op.OpType())
+	assert.Equal(t, temporal.HoltWintersUpperType, transforms[5].Op.OpType())
+	assert.Equal(t, binary.OrType, transforms[6].Op.OpType())
+}
+
+func TestSubstituteFunctions(t *testing.T) {
+	q, calls := substituteFunctions(
+		`anomaly_score(foo{a="anomaly_score(x)"}[7d], 86400) + predict_linear(bar[1h], 60) + anomaly_score`)
+	assert.Equal(t,
+		`predict_linear(foo{a="anomaly_score(x)"}[7d], 86400) + predict_linear(bar[1h], 60) + anomaly_score`, q)
+	assert.Equal(t, []string{temporal.AnomalyScoreType, "predict_linear"}, calls)
+}
+
+func TestConvertUnitParses(t *testing.T) {
+	p, err := Parse(`convert_unit(heap_bytes, "B", "MiB") > 512`)
+	require.NoError(t, err)
+	transforms, edges, err := p.DAG()
+	require.NoError(t, err)
+	require.Len(t, transforms, 4)
+	assert.Equal(t, functions.FetchType, transforms[0].Op.OpType())
+	assert.Equal(t, linear.ConvertUnitType, transforms[1].Op.OpType())
+	assert.Equal(t, functions.ScalarType, transforms[2].Op.OpType())
+	assert.Equal(t, binary.GreaterType, transforms[3].Op.OpType())
+	require.Len(t, edges, 3)
+	assert.Equal(t, parser.NodeID("0"), edges[0].ParentID)
+	assert.Equal(t, parser.NodeID("1"), edges[0].ChildID)
+
+	_, err = Parse(`convert_unit(heap_bytes, "B")`)
+	require.Error(t, err)
+}
+
+func TestSubstituteMatchers(t *testing.T) {
+	assert.Equal(t, "foo{a=\"\uE000Bar\",b=\"\uE001baz\",c=~\"x\",d!~'=*'} == 1",
+		substituteMatchers(`foo{a=*"Bar",b~="baz",c=~"x",d!~'=*'} == 1`))
+}
+
+func TestFuzzyAndCaseInsensitiveMatchersParse(t *testing.T) {
+	p, err := Parse(`foo{a=*"Bar", b~="baz", c="qux"}`)
+	require.NoError(t, err)
+	transforms, _, err := p.DAG()
+	require.NoError(t, err)
+	require.Len(t, transforms, 1)
+
+	fetch, ok := transforms[0].Op.(functions.FetchOp)
+	require.True(t, ok)
+	byName := make(map[string]*models.Matcher, len(fetch.Matchers))
+	for _, m := range fetch.Matchers {
+		byName[m.Name] = m
+	}
+
+	assert.Equal(t, models.MatchEqualCaseInsensitive, byName["a"].Type)
+	assert.Equal(t, "Bar", byName["a"].Value)
+	assert.True(t, byName["a"].Matches("bar"))
+	assert.Equal(t, models.MatchFuzzy, byName["b"].Type)
+	assert.Equal(t, "baz", byName["b"].Value)
+	assert.True(t, byName["b"].Matches("bar"))
+	assert.Equal(t, models.MatchEqual, byName["c"].Type)
+}
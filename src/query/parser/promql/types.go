@@ -27,6 +27,7 @@ import (
 	"github.com/m3db/m3/src/query/functions"
 	"github.com/m3db/m3/src/query/functions/aggregation"
 	"github.com/m3db/m3/src/query/functions/binary"
+	"github.com/m3db/m3/src/query/functions/conversion"
 	"github.com/m3db/m3/src/query/functions/linear"
 	"github.com/m3db/m3/src/query/functions/temporal"
 	"github.com/m3db/m3/src/query/models"
@@ -163,6 +164,9 @@ func NewFunctionExpr(name string, argValues []interface{}) (parser.Params, error
 	case linear.RoundType:
 		return linear.NewRoundOp(argValues)
 
+	case linear.ConvertUnitType:
+		return linear.NewConvertUnitOp(argValues)
+
 	case linear.DayOfMonthType, linear.DayOfWeekType, linear.DaysInMonthType, linear.HourType,
 		linear.MinuteType, linear.MonthType, linear.YearType:
 		return linear.NewDateOp(name)
@@ -178,6 +182,13 @@ func NewFunctionExpr(name string, argValues []interface{}) (parser.Params, error
 	case temporal.ResetsType, temporal.ChangesType:
 		return temporal.NewFunctionOp(argValues, name)
 
+	case temporal.HoltWintersPredictType, temporal.HoltWintersUpperType,
+		temporal.HoltWintersLowerType, temporal.AnomalyScoreType:
+		return temporal.NewHoltWintersOp(argValues, name)
+
+	case conversion.ScalarType, conversion.VectorType:
+		return conversion.NewConversionOp(name)
+
 	default:
 		// TODO: handle other types
 		return nil, fmt.Errorf("function not supported: %s", name)
@@ -232,7 +243,14 @@ func labelMatchersToModelMatcher(lMatchers []*labels.Matcher) (models.Matchers,
 			return nil, err
 		}
 
-		match, err := models.NewMatcher(modelType, m.Name, m.Value)
+		value := m.Value
+		if m.Type == labels.MatchEqual {
+			if substitutedType, substitutedValue, ok := substitutedMatchType(value); ok {
+				modelType, value = substitutedType, substitutedValue
+			}
+		}
+
+		match, err := models.NewMatcher(modelType, m.Name, value)
 		if err != nil {
 			return nil, err
 		}
@@ -32,11 +32,15 @@ import (
 
 // PhysicalPlan represents the physical plan
 type PhysicalPlan struct {
-	steps      map[parser.NodeID]LogicalStep
-	pipeline   []parser.NodeID // Ordered list of steps to be performed
-	ResultStep ResultOp
-	TimeSpec   transform.TimeSpec
-	Debug      bool
+	steps         map[parser.NodeID]LogicalStep
+	pipeline      []parser.NodeID // Ordered list of steps to be performed
+	ResultStep    ResultOp
+	TimeSpec      transform.TimeSpec
+	Debug         bool
+	Lookback      models.LookbackOptions
+	DedupeSeries  bool
+	NaNMode       models.NaNMode
+	FetchControls models.FetchControls
 }
 
 // ResultOp is resonsible for delivering results to the clients
@@ -59,7 +63,11 @@ func NewPhysicalPlan(lp LogicalPlan, storage storage.Storage, params models.Requ
 			Now:   params.Now,
 			Step:  params.Step,
 		},
-		Debug: params.Debug,
+		Debug:         params.Debug,
+		Lookback:      params.Lookback,
+		DedupeSeries:  params.DedupeSeries,
+		NaNMode:       params.NaNMode,
+		FetchControls: params.FetchControls,
 	}
 
 	pl, err := p.createResultNode()
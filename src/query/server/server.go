@@ -22,8 +22,10 @@ package server
 
 import (
 	"context"
+	"crypto/tls"
 	"fmt"
 	"math/rand"
+	"net"
 	"net/http"
 	"os"
 	"os/signal"
@@ -34,18 +36,34 @@ import (
 	dbconfig "github.com/m3db/m3/src/cmd/services/m3dbnode/config"
 	"github.com/m3db/m3/src/cmd/services/m3query/config"
 	"github.com/m3db/m3/src/dbnode/client"
+	"github.com/m3db/m3/src/dbnode/network/server/grpc/node"
 	"github.com/m3db/m3/src/dbnode/serialize"
+	"github.com/m3db/m3/src/query/api/v1/handler/database"
+	"github.com/m3db/m3/src/query/api/v1/handler/placement"
+	promremote "github.com/m3db/m3/src/query/api/v1/handler/prometheus/remote"
+	"github.com/m3db/m3/src/query/api/v1/handler/querylog"
 	"github.com/m3db/m3/src/query/api/v1/httpd"
+	"github.com/m3db/m3/src/query/canary"
 	m3dbcluster "github.com/m3db/m3/src/query/cluster/m3db"
 	"github.com/m3db/m3/src/query/executor"
 	"github.com/m3db/m3/src/query/policy/filter"
 	"github.com/m3db/m3/src/query/storage"
+	"github.com/m3db/m3/src/query/storage/access"
+	"github.com/m3db/m3/src/query/storage/dbnode"
+	"github.com/m3db/m3/src/query/storage/deadletter"
 	"github.com/m3db/m3/src/query/storage/fanout"
 	"github.com/m3db/m3/src/query/storage/local"
+	"github.com/m3db/m3/src/query/storage/mirror"
 	"github.com/m3db/m3/src/query/storage/remote"
+	"github.com/m3db/m3/src/query/storage/sampling"
+	"github.com/m3db/m3/src/query/storage/shadow"
+	"github.com/m3db/m3/src/query/storage/tenant"
 	"github.com/m3db/m3/src/query/stores/m3db"
 	tsdbRemote "github.com/m3db/m3/src/query/tsdb/remote"
 	"github.com/m3db/m3/src/query/util/logging"
+	"github.com/m3db/m3/src/x/profiling"
+	"github.com/m3db/m3/src/x/tracing"
+	"github.com/m3db/m3/src/x/xtls"
 	clusterclient "github.com/m3db/m3cluster/client"
 	etcdclient "github.com/m3db/m3cluster/client/etcd"
 	"github.com/m3db/m3metrics/aggregation"
@@ -58,15 +76,19 @@ import (
 	xsync "github.com/m3db/m3x/sync"
 	xtime "github.com/m3db/m3x/time"
 
+	opentracing "github.com/opentracing/opentracing-go"
 	"github.com/pkg/errors"
 	"github.com/uber-go/tally"
 	"go.uber.org/zap"
 	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials"
 )
 
 const (
 	defaultWorkerPoolCount = 4096
 	defaultWorkerPoolSize  = 20
+
+	databaseInitRetryInterval = 5 * time.Second
 )
 
 var (
@@ -134,6 +156,45 @@ func Run(runOpts RunOptions) {
 		}
 	}()
 
+	rpcTLS, err := newRPCTLSReloader(cfg.RPC, scope)
+	if err != nil {
+		logger.Fatal("unable to load rpc tls certificates", zap.Error(err))
+	}
+	if rpcTLS != nil {
+		defer rpcTLS.Close()
+	}
+
+	if tracingCfg := cfg.Tracing; tracingCfg != nil {
+		// NB: The tracer is installed globally before the storage is set up
+		// so that the m3db client and tchannel propagate write traces.
+		tracer, err := tracingCfg.NewTracer("m3coordinator",
+			instrument.NewOptions().
+				SetZapLogger(logger).
+				SetMetricsScope(scope))
+		if err != nil {
+			logger.Fatal("unable to set up tracing", zap.Error(err))
+		}
+		opentracing.SetGlobalTracer(tracer)
+		defer tracer.Close()
+
+		logger.Info("exporting write traces",
+			zap.String("endpoint", tracingCfg.Endpoint))
+	}
+
+	if profilingCfg := cfg.Profiling; profilingCfg != nil {
+		profiler, err := profilingCfg.NewProfiler("m3coordinator",
+			instrument.NewOptions().
+				SetZapLogger(logger).
+				SetMetricsScope(scope))
+		if err != nil {
+			logger.Fatal("unable to set up profiling", zap.Error(err))
+		}
+		defer profiler.Close()
+
+		logger.Info("uploading profiles",
+			zap.String("endpoint", profilingCfg.Endpoint))
+	}
+
 	var (
 		backendStorage storage.Storage
 		clusterClient  clusterclient.Client
@@ -144,7 +205,7 @@ func Run(runOpts RunOptions) {
 	// For grpc backend, we need to setup only the grpc client and a storage accompanying that client.
 	// For m3db backend, we need to make connections to the m3db cluster which generates a session and use the storage with the session.
 	if cfg.Backend == config.GRPCStorageType {
-		backendStorage, enabled, err = remoteClient(cfg)
+		backendStorage, enabled, err = remoteClient(cfg, rpcTLS)
 		if err != nil {
 			logger.Fatal("unable to setup grpc backend", zap.Error(err))
 		}
@@ -153,19 +214,136 @@ func Run(runOpts RunOptions) {
 		}
 
 		logger.Info("setup grpc backend")
+	} else if cfg.Backend == config.NodeGRPCStorageType {
+		var cleanup cleanupFn
+		backendStorage, cleanup, err = newNodeGRPCStorage(cfg.NodeGRPC, scope)
+		if err != nil {
+			logger.Fatal("unable to setup node grpc backend", zap.Error(err))
+		}
+		defer cleanup()
+
+		logger.Info("setup node grpc backend")
 	} else {
 		var cleanup cleanupFn
-		backendStorage, clusterClient, downsampler, cleanup, err = newM3DBStorage(runOpts, cfg, logger, scope)
+		backendStorage, clusterClient, downsampler, cleanup, err = newM3DBStorage(runOpts, cfg, logger, scope, rpcTLS)
 		if err != nil {
 			logger.Fatal("unable to setup m3db backend", zap.Error(err))
 		}
 		defer cleanup()
 	}
 
+	// Entries of all tenants are written to the same query log namespace,
+	// so the query log writes to the storage before it is scoped to tenants.
+	var queryLog *querylog.Logger
+	if queryLogCfg := cfg.QueryLog; queryLogCfg != nil {
+		queryLog, err = queryLogCfg.NewLogger(backendStorage,
+			scope.SubScope("query-log"))
+		if err != nil {
+			logger.Fatal("unable to set up query log", zap.Error(err))
+		}
+		defer queryLog.Close()
+
+		logger.Info("logging queries",
+			zap.String("path", queryLogCfg.Path),
+			zap.Bool("namespace", queryLogCfg.Namespace != nil))
+	}
+
+	// Failed writes are spilled before they are scoped to tenants so that
+	// they are replayed with their tenant tag and without a tenant.
+	var deadLetter *deadletter.Replayer
+	if deadLetterCfg := cfg.DeadLetter; deadLetterCfg != nil {
+		queue, err := deadLetterCfg.NewQueue(scope.SubScope("dead-letter"), logger)
+		if err != nil {
+			logger.Fatal("unable to set up dead letter queue", zap.Error(err))
+		}
+		defer queue.Close()
+
+		logger.Info("spilling failed writes to dead letter queue",
+			zap.String("dir", deadLetterCfg.Dir),
+			zap.Bool("acknowledge", deadLetterCfg.Acknowledge))
+		deadLetter = deadletter.NewReplayer(queue, backendStorage)
+		backendStorage = deadletter.NewStorage(backendStorage, queue,
+			isDeadLetterWriteError, deadLetterCfg.Acknowledge, logger)
+	}
+
+	if cfg.DatabaseInit != nil {
+		if clusterClient == nil {
+			logger.Fatal("no configured cluster management config, " +
+				"must set this config for database init")
+		}
+
+		initializer, err := database.NewAutoInitializer(clusterClient,
+			cfg, runOpts.DBConfig)
+		if err != nil {
+			logger.Fatal("unable to set up database init", zap.Error(err))
+		}
+
+		go initDatabase(initializer, logger)
+	}
+
+	if err := placement.ValidateFailureDomains(cfg); err != nil {
+		logger.Fatal("invalid failure domains", zap.Error(err))
+	}
+	if clusterClient != nil {
+		// NB: A placement that violates the failure domains is not fatal so
+		// that it can be fixed with the placement API.
+		if err := placement.CheckPlacementFailureDomains(clusterClient, cfg); err != nil {
+			logger.Warn("placement does not satisfy failure domains", zap.Error(err))
+		}
+	}
+
+	var quotas tenant.Quotas
+	if tenancyCfg := cfg.Tenancy; tenancyCfg != nil {
+		if tenancyCfg.Source == tenant.SourceTLS && cfg.ListenTLS == nil {
+			logger.Fatal("tenants can only be derived from client certificates " +
+				"with listenTLS configured")
+		}
+
+		if quotasCfg := tenancyCfg.Quotas; quotasCfg != nil {
+			quotas, err = newTenantQuotas(*quotasCfg, clusterClient, scope)
+			if err != nil {
+				logger.Fatal("unable to set up tenant quotas", zap.Error(err))
+			}
+			defer quotas.Close()
+		}
+
+		logger.Info("scoping writes and queries to tenants",
+			zap.Stringer("source", tenancyCfg.Source),
+			zap.String("tagName", tenancyCfg.TagNameOrDefault()),
+			zap.Bool("quotas", quotas != nil))
+		backendStorage = tenant.NewStorage(backendStorage,
+			tenancyCfg.TagNameOrDefault(), quotas, scope.SubScope("tenant"))
+	}
+
+	if accessCfg := cfg.NamespaceAccess; accessCfg != nil && len(accessCfg.Labels) > 0 {
+		rules := access.LabelRules(accessCfg.Labels)
+		if err := rules.Validate(); err != nil {
+			logger.Fatal("invalid label access rules", zap.Error(err))
+		}
+
+		logger.Info("restricting the labels of queries",
+			zap.Int("rules", len(rules)))
+		backendStorage = access.NewLabelStorage(backendStorage, rules,
+			scope.SubScope("access"))
+	}
+
+	if samplingCfg := cfg.Sampling; samplingCfg != nil {
+		rules, err := samplingCfg.NewRules()
+		if err != nil {
+			logger.Fatal("invalid ingest sampling rules", zap.Error(err))
+		}
+
+		logger.Info("sampling writes",
+			zap.Int("rules", len(rules)),
+			zap.String("rateLabel", samplingCfg.RateLabelOrDefault()))
+		backendStorage = sampling.NewStorage(backendStorage, rules,
+			samplingCfg.RateLabelOrDefault(), scope.SubScope("sampling"))
+	}
+
 	engine := executor.NewEngine(backendStorage)
 
 	handler, err := httpd.NewHandler(backendStorage, downsampler, engine,
-		clusterClient, cfg, runOpts.DBConfig, scope)
+		clusterClient, quotas, queryLog, deadLetter, cfg, runOpts.DBConfig, scope)
 	if err != nil {
 		logger.Fatal("unable to set up handlers", zap.Error(err))
 	}
@@ -188,9 +366,26 @@ func Run(runOpts RunOptions) {
 
 	}()
 
+	listener, err := net.Listen("tcp", listenAddress)
+	if err != nil {
+		logger.Fatal("unable to listen", zap.String("address", listenAddress),
+			zap.Error(err))
+	}
+	if cfg.ListenTLS != nil {
+		listenTLS, err := cfg.ListenTLS.NewReloader(instrument.NewOptions().
+			SetMetricsScope(scope.SubScope("http")))
+		if err != nil {
+			logger.Fatal("unable to load http tls certificates", zap.Error(err))
+		}
+		defer listenTLS.Close()
+
+		listener = tls.NewListener(listener, listenTLS.ServerTLSConfig())
+	}
+
 	go func() {
-		logger.Info("starting server", zap.String("address", listenAddress))
-		if err := srv.ListenAndServe(); err != nil {
+		logger.Info("starting server", zap.String("address", listenAddress),
+			zap.Bool("tls", cfg.ListenTLS != nil))
+		if err := srv.Serve(listener); err != nil {
 			logger.Error("server error while listening",
 				zap.String("address", listenAddress), zap.Error(err))
 		}
@@ -210,12 +405,39 @@ func Run(runOpts RunOptions) {
 	}
 }
 
+// initDatabase initializes the database, retrying until it succeeds since the
+// cluster client and the database may not be available yet on start.
+func initDatabase(initializer *database.AutoInitializer, logger *zap.Logger) {
+	for {
+		err := initializer.Init()
+		if err == nil {
+			logger.Info("initialized database")
+			return
+		}
+
+		logger.Warn("unable to initialize database, retrying",
+			zap.Error(err), zap.Duration("retryInterval", databaseInitRetryInterval))
+		time.Sleep(databaseInitRetryInterval)
+	}
+}
+
+// isDeadLetterWriteError returns whether a failed write may succeed once
+// replayed, writes rejected for their timestamps, quotas or encoding are not.
+func isDeadLetterWriteError(err error) bool {
+	switch promremote.ClassifyWriteError(err) {
+	case promremote.WriteErrorCauseShardUnavailable, promremote.WriteErrorCauseUnknown:
+		return true
+	}
+	return false
+}
+
 // make connections to the m3db cluster(s) and generate sessions for those clusters along with the storage
 func newM3DBStorage(
 	runOpts RunOptions,
 	cfg config.Configuration,
 	logger *zap.Logger,
 	scope tally.Scope,
+	rpcTLS *xtls.Reloader,
 ) (storage.Storage, clusterclient.Client, downsample.Downsampler, cleanupFn, error) {
 	var clusterClientCh <-chan clusterclient.Client
 	if runOpts.ClusterClient != nil {
@@ -256,6 +478,26 @@ func newM3DBStorage(
 		return nil, nil, nil, nil, err
 	}
 
+	provisionCfg := cfg.AggregatedNamespaceProvisioning
+	provisioning := provisionCfg != nil && provisionCfg.Enabled
+	if provisioning {
+		if clusterManagementClient == nil {
+			return nil, nil, nil, nil, fmt.Errorf("no configured cluster management config, " +
+				"must set this config for aggregated namespace provisioning")
+		}
+
+		kvStore, err := clusterManagementClient.KV()
+		if err != nil {
+			return nil, nil, nil, nil, errors.Wrap(err, "unable to create KV store for aggregated namespace provisioning")
+		}
+
+		logger.Info("provisioning aggregated namespaces on demand",
+			zap.String("namespacePrefix", provisionCfg.NamespacePrefix))
+		provisioner := local.NewKVAggregatedNamespaceProvisioner(kvStore,
+			provisionCfg.NamespacePrefix)
+		clusters = local.NewProvisioningClusters(clusters, provisioner, logger)
+	}
+
 	workerPoolCount := cfg.DecompressWorkerPoolCount
 	if workerPoolCount == 0 {
 		workerPoolCount = defaultWorkerPoolCount
@@ -281,11 +523,75 @@ func newM3DBStorage(
 		return workerPool
 	})
 
-	fanoutStorage, storageCleanup, err := newStorages(logger, clusters, cfg, objectPool)
+	// Reject writes to namespaces that are read only or pending deletion
+	// when the namespace registry is available.
+	var namespaceStates local.NamespaceStates
+	if clusterManagementClient != nil {
+		kvStore, err := clusterManagementClient.KV()
+		if err != nil {
+			return nil, nil, nil, nil, errors.Wrap(err, "unable to create KV store for namespace states")
+		}
+
+		namespaceStates, err = local.NewNamespaceStates(kvStore)
+		if err != nil {
+			return nil, nil, nil, nil, errors.Wrap(err, "unable to watch namespace states")
+		}
+	}
+
+	fanoutStorage, storageCleanup, err := newStorages(logger, clusters, cfg,
+		objectPool, namespaceStates, rpcTLS)
 	if err != nil {
 		return nil, nil, nil, nil, errors.Wrap(err, "unable to set up storages")
 	}
 
+	var mirrorClusters local.Clusters
+	if mirrorCfg := cfg.Mirror; mirrorCfg != nil {
+		mirrorClusters, err = mirrorCfg.Clusters.NewClusters(
+			local.ClustersStaticConfigurationOptions{
+				AsyncSessions: true,
+			})
+		if err != nil {
+			return nil, nil, nil, nil, errors.Wrap(err, "unable to connect to mirror clusters")
+		}
+
+		for _, namespace := range mirrorClusters.ClusterNamespaces() {
+			logger.Info("mirroring writes to cluster namespace",
+				zap.String("namespace", namespace.NamespaceID().String()))
+		}
+		fanoutStorage = mirror.NewStorage(fanoutStorage,
+			local.NewStorage(mirrorClusters, objectPool),
+			mirror.Options{
+				QueueSize:    mirrorCfg.QueueSize,
+				Concurrency:  mirrorCfg.Concurrency,
+				WriteTimeout: mirrorCfg.WriteTimeout,
+				Scope:        scope.SubScope("mirror"),
+				Logger:       logger,
+			})
+	}
+
+	var shadowClusters local.Clusters
+	if shadowCfg := cfg.ReadShadow; shadowCfg != nil {
+		shadowClusters, err = shadowCfg.Clusters.NewClusters(
+			local.ClustersStaticConfigurationOptions{
+				AsyncSessions: true,
+			})
+		if err != nil {
+			return nil, nil, nil, nil, errors.Wrap(err, "unable to connect to read shadow clusters")
+		}
+
+		logger.Info("shadowing reads to clusters",
+			zap.Float64("sampleRate", shadowCfg.SampleRate))
+		fanoutStorage = shadow.NewStorage(fanoutStorage,
+			local.NewStorage(shadowClusters, objectPool),
+			shadow.Options{
+				SampleRate:  shadowCfg.SampleRate,
+				Concurrency: shadowCfg.Concurrency,
+				Timeout:     shadowCfg.Timeout,
+				Scope:       scope.SubScope("read-shadow"),
+				Logger:      logger,
+			})
+	}
+
 	var clusterClient clusterclient.Client
 	if clusterClientCh != nil {
 		// Only use a cluster client if we are going to receive one, that
@@ -299,7 +605,7 @@ func newM3DBStorage(
 		namespaces  = clusters.ClusterNamespaces()
 		downsampler downsample.Downsampler
 	)
-	if n := namespaces.NumAggregatedClusterNamespaces(); n > 0 {
+	if n := namespaces.NumAggregatedClusterNamespaces(); n > 0 || provisioning {
 		logger.Info("configuring downsampler to use with aggregated cluster namespaces",
 			zap.Int("numAggregatedClusterNamespaces", n))
 		autoMappingRules, err := newDownsamplerAutoMappingRules(namespaces)
@@ -307,25 +613,69 @@ func newM3DBStorage(
 			return nil, nil, nil, nil, err
 		}
 		downsampler, err = newDownsampler(clusterManagementClient,
-			fanoutStorage, autoMappingRules, instrumentOptions)
+			fanoutStorage, autoMappingRules, cfg.Downsample, instrumentOptions)
 		if err != nil {
 			return nil, nil, nil, nil, err
 		}
 	}
 
+	var heartbeats *canary.Canary
+	if canaryCfg := cfg.Canary; canaryCfg != nil {
+		heartbeats, err = newCanary(*canaryCfg, clusters.ClusterNamespaces(),
+			scope, logger)
+		if err != nil {
+			return nil, nil, nil, nil, err
+		}
+		heartbeats.Start()
+	}
+
 	cleanup := func() error {
+		if heartbeats != nil {
+			// Stop writing heartbeats before the sessions are closed.
+			heartbeats.Close()
+		}
+
 		lastErr := storageCleanup()
 		// Don't want to quit on the first error since the full cleanup is important
 		if lastErr != nil {
 			logger.Error("error during storage cleanup", zap.Error(lastErr))
 		}
 
+		if namespaceStates != nil {
+			if err := namespaceStates.Close(); err != nil {
+				lastErr = errors.Wrap(err, "unable to close namespace states watch")
+				logger.Error("error during namespace states cleanup", zap.Error(err))
+			}
+		}
+
 		if err := clusters.Close(); err != nil {
 			lastErr = errors.Wrap(err, "unable to close M3DB cluster sessions")
 			// Make sure the previous error is at least logged
 			logger.Error("error during cluster cleanup", zap.Error(err))
 		}
 
+		if mirrorClusters != nil || shadowClusters != nil {
+			// Wait for the queued writes to be mirrored before closing the
+			// mirror and shadow cluster sessions.
+			if err := fanoutStorage.Close(); err != nil {
+				logger.Error("error during mirror and shadow storage cleanup", zap.Error(err))
+			}
+		}
+
+		if mirrorClusters != nil {
+			if err := mirrorClusters.Close(); err != nil {
+				lastErr = errors.Wrap(err, "unable to close M3DB mirror cluster sessions")
+				logger.Error("error during mirror cluster cleanup", zap.Error(err))
+			}
+		}
+
+		if shadowClusters != nil {
+			if err := shadowClusters.Close(); err != nil {
+				lastErr = errors.Wrap(err, "unable to close M3DB read shadow cluster sessions")
+				logger.Error("error during read shadow cluster cleanup", zap.Error(err))
+			}
+		}
+
 		return lastErr
 	}
 
@@ -336,6 +686,7 @@ func newDownsampler(
 	clusterManagementClient clusterclient.Client,
 	storage storage.Storage,
 	autoMappingRules []downsample.MappingRule,
+	downsampleCfg config.DownsampleConfiguration,
 	instrumentOpts instrument.Options,
 ) (downsample.Downsampler, error) {
 	if clusterManagementClient == nil {
@@ -360,6 +711,25 @@ func newDownsampler(
 			SetMetricsScope(instrumentOpts.MetricsScope().
 				SubScope("tag-decoder-pool")))
 
+	flushJitters := make(downsample.FlushJitters, 0, len(downsampleCfg.FlushJitters))
+	for _, jitter := range downsampleCfg.FlushJitters {
+		flushJitters = append(flushJitters, downsample.FlushJitter{
+			Resolution:       jitter.Resolution,
+			MaxJitterPercent: jitter.MaxJitterPercent,
+			Offset:           jitter.Offset,
+		})
+	}
+
+	gapFills := make(downsample.GapFills, 0, len(downsampleCfg.GapFills))
+	for _, fill := range downsampleCfg.GapFills {
+		gapFills = append(gapFills, downsample.GapFill{
+			Resolution:   fill.Resolution,
+			Retention:    fill.Retention,
+			Mode:         fill.Mode,
+			MaxIntervals: fill.MaxIntervals,
+		})
+	}
+
 	downsampler, err := downsample.NewDownsampler(downsample.DownsamplerOptions{
 		Storage:               storage,
 		RulesKVStore:          kvStore,
@@ -370,6 +740,8 @@ func newDownsampler(
 		TagDecoderOptions:     tagDecoderOptions,
 		TagEncoderPoolOptions: tagEncoderPoolOptions,
 		TagDecoderPoolOptions: tagDecoderPoolOptions,
+		FlushJitters:          flushJitters,
+		GapFills:              gapFills,
 	})
 	if err != nil {
 		return nil, errors.Wrap(err, "unable to create downsampler")
@@ -458,15 +830,35 @@ func newStorages(
 	clusters local.Clusters,
 	cfg config.Configuration,
 	workerPool pool.ObjectPool,
+	namespaceStates local.NamespaceStates,
+	rpcTLS *xtls.Reloader,
 ) (storage.Storage, cleanupFn, error) {
 	cleanup := func() error { return nil }
 
-	localStorage := local.NewStorage(clusters, workerPool)
+	var accessPolicies *access.Policies
+	if accessCfg := cfg.NamespaceAccess; accessCfg != nil {
+		if cfg.Auth == nil && cfg.Tenancy == nil {
+			logger.Warn("namespace access configured without auth or tenancy, " +
+				"restricted namespaces cannot be accessed")
+		}
+		var err error
+		accessPolicies, err = accessCfg.NewPolicies()
+		if err != nil {
+			return nil, nil, err
+		}
+	}
+
+	localStorage := local.NewStorageWithOptions(clusters, workerPool, local.Options{
+		NamespaceStates: namespaceStates,
+		AccessPolicies:  accessPolicies,
+		ShardKeys:       local.NewShardKeys(cfg.ShardKeys),
+		FetchPageSize:   cfg.FetchPageSize,
+	})
 	stores := []storage.Storage{localStorage}
 	remoteEnabled := false
 	if cfg.RPC != nil && cfg.RPC.Enabled {
 		logger.Info("rpc enabled")
-		server, err := startGrpcServer(logger, localStorage, cfg.RPC)
+		server, err := startGrpcServer(logger, localStorage, cfg.RPC, rpcTLS)
 		if err != nil {
 			return nil, nil, err
 		}
@@ -476,7 +868,7 @@ func newStorages(
 			return nil
 		}
 
-		remoteStorage, enabled, err := remoteClient(cfg)
+		remoteStorage, enabled, err := remoteClient(cfg, rpcTLS)
 		if err != nil {
 			return nil, nil, err
 		}
@@ -496,13 +888,150 @@ func newStorages(
 	return fanoutStorage, cleanup, nil
 }
 
-func remoteClient(cfg config.Configuration) (storage.Storage, bool, error) {
+// newRPCTLSReloader returns the reloader of the TLS certificates of the
+// coordinator RPC server and clients, nil if TLS is not configured.
+func newRPCTLSReloader(
+	cfg *config.RPCConfiguration,
+	scope tally.Scope,
+) (*xtls.Reloader, error) {
+	if cfg == nil || cfg.TLS == nil {
+		return nil, nil
+	}
+
+	iopts := instrument.NewOptions().
+		SetMetricsScope(scope.SubScope("rpc"))
+	return cfg.TLS.NewReloader(iopts)
+}
+
+// newTenantQuotas returns the per-tenant quotas, with limits kept up to date
+// from KV when a cluster management client is available.
+func newTenantQuotas(
+	cfg tenant.QuotaConfiguration,
+	clusterClient clusterclient.Client,
+	scope tally.Scope,
+) (tenant.Quotas, error) {
+	opts := tenant.QuotaOptions{
+		Default:            cfg.Default,
+		ActiveSeriesWindow: cfg.ActiveSeriesWindow,
+		Scope:              scope.SubScope("tenant-quotas"),
+	}
+	if clusterClient != nil {
+		kvStore, err := clusterClient.KV()
+		if err != nil {
+			return nil, errors.Wrap(err, "unable to create KV store for tenant quotas")
+		}
+		opts.Store = kvStore
+	}
+	return tenant.NewQuotas(opts)
+}
+
+// newCanary returns a canary of the configured cluster namespaces.
+func newCanary(
+	cfg config.CanaryConfiguration,
+	namespaces local.ClusterNamespaces,
+	scope tally.Scope,
+	logger *zap.Logger,
+) (*canary.Canary, error) {
+	if len(cfg.Namespaces) > 0 {
+		byID := make(map[string]local.ClusterNamespace, len(namespaces))
+		for _, namespace := range namespaces {
+			byID[namespace.NamespaceID().String()] = namespace
+		}
+		namespaces = make(local.ClusterNamespaces, 0, len(cfg.Namespaces))
+		for _, id := range cfg.Namespaces {
+			namespace, ok := byID[id]
+			if !ok {
+				return nil, fmt.Errorf("canary namespace %s is not a cluster namespace", id)
+			}
+			namespaces = append(namespaces, namespace)
+		}
+	}
+
+	instance := cfg.Instance
+	if instance == "" {
+		hostname, err := os.Hostname()
+		if err != nil {
+			return nil, errors.Wrap(err, "unable to resolve canary instance")
+		}
+		instance = hostname
+	}
+
+	for _, namespace := range namespaces {
+		logger.Info("writing canary heartbeats to cluster namespace",
+			zap.String("namespace", namespace.NamespaceID().String()),
+			zap.String("instance", instance))
+	}
+	return canary.New(namespaces, canary.Options{
+		Interval:      cfg.Interval,
+		PollInterval:  cfg.PollInterval,
+		Timeout:       cfg.Timeout,
+		LatencyTarget: cfg.LatencyTarget,
+		MetricName:    cfg.MetricName,
+		Instance:      instance,
+		Scope:         scope.SubScope("canary"),
+		Logger:        logger,
+	}), nil
+}
+
+// newNodeGRPCStorage returns the storage of the namespace of the DB node
+// read and written through the node gRPC service.
+func newNodeGRPCStorage(
+	cfg *config.NodeGRPCConfiguration,
+	scope tally.Scope,
+) (storage.Storage, cleanupFn, error) {
+	if cfg == nil {
+		return nil, nil, errors.New("node grpc backend requires nodeGRPC configuration")
+	}
+
+	if cfg.TLS == nil {
+		nodeClient, err := node.NewClient(cfg.Address, cfg.BatchSize)
+		if err != nil {
+			return nil, nil, err
+		}
+		nodeStorage := dbnode.NewStorage(nodeClient, ident.StringID(cfg.Namespace))
+		return nodeStorage, nodeStorage.Close, nil
+	}
+
+	iopts := instrument.NewOptions().
+		SetMetricsScope(scope.SubScope("node-grpc"))
+	reloader, err := cfg.TLS.NewReloader(iopts)
+	if err != nil {
+		return nil, nil, errors.Wrap(err, "unable to load node grpc TLS")
+	}
+	nodeClient, err := node.NewClientWithTLS(cfg.Address, cfg.BatchSize,
+		reloader.ClientTLSConfig())
+	if err != nil {
+		reloader.Close()
+		return nil, nil, err
+	}
+	nodeStorage := dbnode.NewStorage(nodeClient, ident.StringID(cfg.Namespace))
+	cleanup := func() error {
+		err := nodeStorage.Close()
+		reloader.Close()
+		return err
+	}
+	return nodeStorage, cleanup, nil
+}
+
+func remoteClient(
+	cfg config.Configuration,
+	rpcTLS *xtls.Reloader,
+) (storage.Storage, bool, error) {
 	if cfg.RPC == nil {
 		return nil, false, nil
 	}
 
 	if remotes := cfg.RPC.RemoteListenAddresses; len(remotes) > 0 {
-		client, err := tsdbRemote.NewGrpcClient(remotes)
+		var (
+			client tsdbRemote.Client
+			err    error
+		)
+		if rpcTLS != nil {
+			client, err = tsdbRemote.NewGrpcClientWithTLS(remotes,
+				rpcTLS.ClientTLSConfig())
+		} else {
+			client, err = tsdbRemote.NewGrpcClient(remotes)
+		}
 		if err != nil {
 			return nil, false, err
 		}
@@ -514,9 +1043,19 @@ func remoteClient(cfg config.Configuration) (storage.Storage, bool, error) {
 	return nil, false, nil
 }
 
-func startGrpcServer(logger *zap.Logger, storage storage.Storage, cfg *config.RPCConfiguration) (*grpc.Server, error) {
+func startGrpcServer(
+	logger *zap.Logger,
+	storage storage.Storage,
+	cfg *config.RPCConfiguration,
+	rpcTLS *xtls.Reloader,
+) (*grpc.Server, error) {
 	logger.Info("creating gRPC server")
-	server := tsdbRemote.CreateNewGrpcServer(storage)
+	var opts []grpc.ServerOption
+	if rpcTLS != nil {
+		logger.Info("gRPC server tls enabled")
+		opts = append(opts, grpc.Creds(credentials.NewTLS(rpcTLS.ServerTLSConfig())))
+	}
+	server := tsdbRemote.CreateNewGrpcServer(storage, opts...)
 	waitForStart := make(chan struct{})
 	var startErr error
 	go func() {
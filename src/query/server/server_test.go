@@ -84,7 +84,7 @@ func TestRun(t *testing.T) {
 
 	session := client.NewMockSession(ctrl)
 	for _, value := range []float64{1, 2} {
-		session.EXPECT().WriteTagged(ident.NewIDMatcher("prometheus_metrics"),
+		session.EXPECT().WriteTaggedTraced(gomock.Any(), ident.NewIDMatcher("prometheus_metrics"),
 			ident.NewIDMatcher("__name__=first,biz=baz,foo=bar,"),
 			gomock.Any(),
 			gomock.Any(),
@@ -93,7 +93,7 @@ func TestRun(t *testing.T) {
 			nil)
 	}
 	for _, value := range []float64{3, 4} {
-		session.EXPECT().WriteTagged(ident.NewIDMatcher("prometheus_metrics"),
+		session.EXPECT().WriteTaggedTraced(gomock.Any(), ident.NewIDMatcher("prometheus_metrics"),
 			ident.NewIDMatcher("__name__=second,bar=baz,foo=qux,"),
 			gomock.Any(),
 			gomock.Any(),
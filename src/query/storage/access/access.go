@@ -0,0 +1,207 @@
+// Copyright (c) 2018 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+// Package access restricts which tenants and authenticated identities may
+// read or write each database namespace, so that namespaces holding
+// sensitive metrics can only be queried by those granted access, and which
+// labels they may query and see.
+package access
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/m3db/m3/src/query/storage/tenant"
+)
+
+var (
+	// ErrAccessDenied is returned when a request is denied access to a
+	// namespace.
+	ErrAccessDenied = errors.New("namespace access denied")
+
+	errNamespacePolicyNoNamespace = errors.New("namespace policy has no namespace")
+)
+
+// IsAccessDenied returns true if the error is, or is a multi error that
+// contains, the denial of access to a namespace or label.
+func IsAccessDenied(err error) bool {
+	if err == nil {
+		return false
+	}
+	msg := err.Error()
+	return strings.Contains(msg, ErrAccessDenied.Error()) ||
+		strings.Contains(msg, ErrLabelAccessDenied.Error())
+}
+
+// Principal is who a request is made by, either of its fields may be
+// empty if the coordinator does not authenticate or scope requests.
+type Principal struct {
+	// Tenant is the tenant ID of the request.
+	Tenant string
+	// Identity is the name of the identity of the bearer token of the request.
+	Identity string
+	// Roles are the roles granted to the identity.
+	Roles []string
+}
+
+type identityKey struct{}
+
+type identity struct {
+	name  string
+	roles []string
+}
+
+// NewContext returns a context with the authenticated identity of a request
+// and the roles it is granted.
+func NewContext(ctx context.Context, name string, roles []string) context.Context {
+	return context.WithValue(ctx, identityKey{}, identity{name: name, roles: roles})
+}
+
+// PrincipalFromContext returns the principal of the request of a context.
+func PrincipalFromContext(ctx context.Context) Principal {
+	var p Principal
+	p.Tenant, _ = tenant.FromContext(ctx)
+	if id, ok := ctx.Value(identityKey{}).(identity); ok {
+		p.Identity = id.name
+		p.Roles = id.roles
+	}
+	return p
+}
+
+// Rule lists the tenants, identities and roles granted an operation, an
+// operation is unrestricted if the rule lists none.
+type Rule struct {
+	// Tenants are the tenant IDs granted the operation.
+	Tenants []string `yaml:"tenants"`
+
+	// Identities are the names of the token identities granted the operation.
+	Identities []string `yaml:"identities"`
+
+	// Roles are the roles of the token identities granted the operation.
+	Roles []string `yaml:"roles"`
+}
+
+// Restricted returns whether the rule restricts the operation.
+func (r Rule) Restricted() bool {
+	return len(r.Tenants) > 0 || len(r.Identities) > 0 || len(r.Roles) > 0
+}
+
+// Allows returns whether the rule grants the operation to a principal.
+func (r Rule) Allows(p Principal) bool {
+	if !r.Restricted() {
+		return true
+	}
+	return r.lists(p)
+}
+
+// lists returns whether the rule lists the tenant, identity or a role of
+// a principal.
+func (r Rule) lists(p Principal) bool {
+	if p.Tenant != "" && contains(r.Tenants, p.Tenant) {
+		return true
+	}
+	if p.Identity != "" && contains(r.Identities, p.Identity) {
+		return true
+	}
+	for _, role := range p.Roles {
+		if contains(r.Roles, role) {
+			return true
+		}
+	}
+	return false
+}
+
+func contains(values []string, value string) bool {
+	for _, v := range values {
+		if v == value {
+			return true
+		}
+	}
+	return false
+}
+
+// NamespacePolicy is the access policy of a namespace.
+type NamespacePolicy struct {
+	// Namespace is the ID of the namespace.
+	Namespace string `yaml:"namespace"`
+
+	// Read is who may query the namespace.
+	Read Rule `yaml:"read"`
+
+	// Write is who may write to the namespace.
+	Write Rule `yaml:"write"`
+}
+
+// Policies are the access policies of the namespaces, namespaces without a
+// policy are unrestricted. A nil Policies allows all access.
+type Policies struct {
+	byNamespace map[string]NamespacePolicy
+}
+
+// NewPolicies returns the access policies of a set of namespaces.
+func NewPolicies(policies []NamespacePolicy) (*Policies, error) {
+	byNamespace := make(map[string]NamespacePolicy, len(policies))
+	for _, policy := range policies {
+		if policy.Namespace == "" {
+			return nil, errNamespacePolicyNoNamespace
+		}
+		if _, ok := byNamespace[policy.Namespace]; ok {
+			return nil, fmt.Errorf("duplicate access policy for namespace: %s",
+				policy.Namespace)
+		}
+		byNamespace[policy.Namespace] = policy
+	}
+	return &Policies{byNamespace: byNamespace}, nil
+}
+
+// Readable returns whether a principal may query a namespace.
+func (p *Policies) Readable(principal Principal, namespace string) bool {
+	if p == nil {
+		return true
+	}
+	policy, ok := p.byNamespace[namespace]
+	return !ok || policy.Read.Allows(principal)
+}
+
+// Writable returns whether a principal may write to a namespace.
+func (p *Policies) Writable(principal Principal, namespace string) bool {
+	if p == nil {
+		return true
+	}
+	policy, ok := p.byNamespace[namespace]
+	return !ok || policy.Write.Allows(principal)
+}
+
+// Configuration is the namespace access configuration of a coordinator.
+type Configuration struct {
+	// Namespaces are the access policies of the restricted namespaces.
+	Namespaces []NamespacePolicy `yaml:"namespaces"`
+
+	// Labels are the rules restricting the labels queries may match on
+	// and return.
+	Labels []LabelRule `yaml:"labels"`
+}
+
+// NewPolicies returns the configured access policies.
+func (c Configuration) NewPolicies() (*Policies, error) {
+	return NewPolicies(c.Namespaces)
+}
@@ -0,0 +1,108 @@
+// Copyright (c) 2018 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package access
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"testing"
+
+	"github.com/m3db/m3/src/query/storage/tenant"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestPolicies(t *testing.T) {
+	policies, err := NewPolicies([]NamespacePolicy{
+		{
+			Namespace: "billing",
+			Read: Rule{
+				Tenants:    []string{"finance"},
+				Identities: []string{"dashboards"},
+			},
+			Write: Rule{Identities: []string{"billing-collector"}},
+		},
+		{
+			Namespace: "shared",
+			Write:     Rule{Tenants: []string{"ops"}, Roles: []string{"admin"}},
+		},
+	})
+	require.NoError(t, err)
+
+	tests := []struct {
+		principal Principal
+		namespace string
+		readable  bool
+		writable  bool
+	}{
+		{Principal{}, "billing", false, false},
+		{Principal{Tenant: "finance"}, "billing", true, false},
+		{Principal{Identity: "dashboards"}, "billing", true, false},
+		{Principal{Tenant: "ops", Identity: "billing-collector"}, "billing", false, true},
+		{Principal{}, "shared", true, false},
+		{Principal{Tenant: "ops"}, "shared", true, true},
+		{Principal{}, "unrestricted", true, true},
+		{Principal{Roles: []string{"write", "admin"}}, "shared", true, true},
+	}
+	for _, test := range tests {
+		name := fmt.Sprintf("%+v %s", test.principal, test.namespace)
+		assert.Equal(t, test.readable,
+			policies.Readable(test.principal, test.namespace), name)
+		assert.Equal(t, test.writable,
+			policies.Writable(test.principal, test.namespace), name)
+	}
+
+	var nilPolicies *Policies
+	assert.True(t, nilPolicies.Readable(Principal{}, "billing"))
+	assert.True(t, nilPolicies.Writable(Principal{}, "billing"))
+}
+
+func TestNewPoliciesInvalid(t *testing.T) {
+	_, err := NewPolicies([]NamespacePolicy{{}})
+	assert.Equal(t, errNamespacePolicyNoNamespace, err)
+
+	_, err = NewPolicies([]NamespacePolicy{
+		{Namespace: "billing"},
+		{Namespace: "billing"},
+	})
+	assert.Error(t, err)
+}
+
+func TestPrincipalFromContext(t *testing.T) {
+	assert.Equal(t, Principal{}, PrincipalFromContext(context.Background()))
+
+	ctx := NewContext(tenant.NewContext(context.Background(), "finance"),
+		"dashboards", []string{"read"})
+	assert.Equal(t, Principal{
+		Tenant:   "finance",
+		Identity: "dashboards",
+		Roles:    []string{"read"},
+	}, PrincipalFromContext(ctx))
+}
+
+func TestIsAccessDenied(t *testing.T) {
+	assert.False(t, IsAccessDenied(nil))
+	assert.False(t, IsAccessDenied(errors.New("other")))
+	assert.True(t, IsAccessDenied(ErrAccessDenied))
+	assert.True(t, IsAccessDenied(fmt.Errorf("%v: billing", ErrAccessDenied)))
+}
@@ -0,0 +1,212 @@
+// Copyright (c) 2018 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package access
+
+import (
+	"context"
+
+	"github.com/m3db/m3/src/query/block"
+	"github.com/m3db/m3/src/query/storage"
+
+	"github.com/uber-go/tally"
+)
+
+type labelMetrics struct {
+	denied tally.Counter
+}
+
+func newLabelMetrics(scope tally.Scope) labelMetrics {
+	return labelMetrics{
+		denied: scope.Counter("label-denied"),
+	}
+}
+
+type labelStorage struct {
+	storage.Storage
+
+	rules   LabelRules
+	metrics labelMetrics
+}
+
+// NewLabelStorage returns a storage that restricts the labels the queries
+// of each principal may touch. Queries that match on a denied label are
+// rejected before any index matchers are built, and stripped labels are
+// removed from the series of query results before they are rendered.
+// Writes are passed through as is.
+func NewLabelStorage(
+	store storage.Storage,
+	rules LabelRules,
+	scope tally.Scope,
+) storage.Storage {
+	if scope == nil {
+		scope = tally.NoopScope
+	}
+	return &labelStorage{
+		Storage: store,
+		rules:   rules,
+		metrics: newLabelMetrics(scope),
+	}
+}
+
+func (s *labelStorage) checkQuery(
+	p Principal,
+	query *storage.FetchQuery,
+) error {
+	if err := s.rules.CheckMatchers(p, query.TagMatchers); err != nil {
+		s.metrics.denied.Inc(1)
+		return err
+	}
+	return nil
+}
+
+func (s *labelStorage) Fetch(
+	ctx context.Context,
+	query *storage.FetchQuery,
+	options *storage.FetchOptions,
+) (*storage.FetchResult, error) {
+	p := PrincipalFromContext(ctx)
+	if err := s.checkQuery(p, query); err != nil {
+		return nil, err
+	}
+
+	result, err := s.Storage.Fetch(ctx, query, options)
+	if err != nil || result == nil {
+		return result, err
+	}
+	for _, series := range result.SeriesList {
+		series.Tags = s.rules.StripTags(p, series.Tags)
+	}
+	return result, nil
+}
+
+func (s *labelStorage) FetchTags(
+	ctx context.Context,
+	query *storage.FetchQuery,
+	options *storage.FetchOptions,
+) (*storage.SearchResults, error) {
+	p := PrincipalFromContext(ctx)
+	if err := s.checkQuery(p, query); err != nil {
+		return nil, err
+	}
+
+	result, err := s.Storage.FetchTags(ctx, query, options)
+	if err != nil || result == nil {
+		return result, err
+	}
+	for _, metric := range result.Metrics {
+		metric.Tags = s.rules.StripTags(p, metric.Tags)
+	}
+	return result, nil
+}
+
+func (s *labelStorage) FetchBlocks(
+	ctx context.Context,
+	query *storage.FetchQuery,
+	options *storage.FetchOptions,
+) (block.Result, error) {
+	p := PrincipalFromContext(ctx)
+	if err := s.checkQuery(p, query); err != nil {
+		return block.Result{}, err
+	}
+
+	result, err := s.Storage.FetchBlocks(ctx, query, options)
+	if err != nil {
+		return result, err
+	}
+	for i, b := range result.Blocks {
+		result.Blocks[i] = &labelBlock{Block: b, rules: s.rules, principal: p}
+	}
+	return result, nil
+}
+
+// labelBlock strips labels from the metadata of the iterators of a block.
+type labelBlock struct {
+	block.Block
+
+	rules     LabelRules
+	principal Principal
+}
+
+func (b *labelBlock) stripMeta(
+	meta block.Metadata,
+	seriesMetas []block.SeriesMeta,
+) (block.Metadata, []block.SeriesMeta) {
+	meta.Tags = b.rules.StripTags(b.principal, meta.Tags)
+	stripped := make([]block.SeriesMeta, len(seriesMetas))
+	for i, seriesMeta := range seriesMetas {
+		seriesMeta.Tags = b.rules.StripTags(b.principal, seriesMeta.Tags)
+		stripped[i] = seriesMeta
+	}
+	return meta, stripped
+}
+
+func (b *labelBlock) StepIter() (block.StepIter, error) {
+	iter, err := b.Block.StepIter()
+	if err != nil {
+		return nil, err
+	}
+	meta, seriesMetas := b.stripMeta(iter.Meta(), iter.SeriesMeta())
+	return &labelStepIter{StepIter: iter, meta: meta, seriesMetas: seriesMetas}, nil
+}
+
+func (b *labelBlock) SeriesIter() (block.SeriesIter, error) {
+	iter, err := b.Block.SeriesIter()
+	if err != nil {
+		return nil, err
+	}
+	meta, seriesMetas := b.stripMeta(iter.Meta(), iter.SeriesMeta())
+	return &labelSeriesIter{
+		SeriesIter:  iter,
+		block:       b,
+		meta:        meta,
+		seriesMetas: seriesMetas,
+	}, nil
+}
+
+type labelStepIter struct {
+	block.StepIter
+
+	meta        block.Metadata
+	seriesMetas []block.SeriesMeta
+}
+
+func (it *labelStepIter) Meta() block.Metadata           { return it.meta }
+func (it *labelStepIter) SeriesMeta() []block.SeriesMeta { return it.seriesMetas }
+
+type labelSeriesIter struct {
+	block.SeriesIter
+
+	block       *labelBlock
+	meta        block.Metadata
+	seriesMetas []block.SeriesMeta
+}
+
+func (it *labelSeriesIter) Meta() block.Metadata           { return it.meta }
+func (it *labelSeriesIter) SeriesMeta() []block.SeriesMeta { return it.seriesMetas }
+
+func (it *labelSeriesIter) Current() (block.Series, error) {
+	series, err := it.SeriesIter.Current()
+	if err != nil {
+		return series, err
+	}
+	series.Meta.Tags = it.block.rules.StripTags(it.block.principal, series.Meta.Tags)
+	return series, nil
+}
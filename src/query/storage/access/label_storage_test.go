@@ -0,0 +1,133 @@
+// Copyright (c) 2018 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package access
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/m3db/m3/src/query/block"
+	"github.com/m3db/m3/src/query/models"
+	"github.com/m3db/m3/src/query/storage"
+	"github.com/m3db/m3/src/query/storage/mock"
+	"github.com/m3db/m3/src/query/test"
+	"github.com/m3db/m3/src/query/ts"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+var testLabelRules = LabelRules{
+	{Name: "email", Action: LabelActionStrip},
+	{
+		Name:      "customer",
+		Action:    LabelActionDeny,
+		AppliesTo: Rule{Roles: []string{"read"}},
+	},
+}
+
+func newTestFetchQuery(t *testing.T, name string) *storage.FetchQuery {
+	return &storage.FetchQuery{
+		TagMatchers: models.Matchers{mustMatcher(t, models.MatchEqual, name, "a")},
+		Start:       time.Now().Add(-time.Hour),
+		End:         time.Now(),
+	}
+}
+
+func TestLabelStorageFetch(t *testing.T) {
+	underlying := mock.NewMockStorage()
+	underlying.SetFetchResult(&storage.FetchResult{
+		SeriesList: ts.SeriesList{ts.NewSeries("foo", ts.NewFixedStepValues(
+			time.Second, 1, 1, time.Now()), models.Tags{
+			{Name: "email", Value: "a@b.c"},
+			{Name: "host", Value: "a"},
+		})},
+	}, nil)
+	store := NewLabelStorage(underlying, testLabelRules, nil)
+
+	ctx := NewContext(context.Background(), "dashboards", []string{"read"})
+	_, err := store.Fetch(ctx, newTestFetchQuery(t, "customer"), &storage.FetchOptions{})
+	require.Error(t, err)
+	assert.True(t, IsAccessDenied(err))
+
+	result, err := store.Fetch(ctx, newTestFetchQuery(t, "host"), &storage.FetchOptions{})
+	require.NoError(t, err)
+	require.Len(t, result.SeriesList, 1)
+	assert.Equal(t, models.Tags{{Name: "host", Value: "a"}},
+		result.SeriesList[0].Tags)
+}
+
+func TestLabelStorageFetchTags(t *testing.T) {
+	underlying := mock.NewMockStorage()
+	underlying.SetFetchTagsResult(&storage.SearchResults{
+		Metrics: models.Metrics{{
+			ID: "foo",
+			Tags: models.Tags{
+				{Name: "email", Value: "a@b.c"},
+				{Name: "host", Value: "a"},
+			},
+		}},
+	}, nil)
+	store := NewLabelStorage(underlying, testLabelRules, nil)
+
+	// The deny rule only applies to readers
+	result, err := store.FetchTags(context.Background(),
+		newTestFetchQuery(t, "customer"), &storage.FetchOptions{})
+	require.NoError(t, err)
+	require.Len(t, result.Metrics, 1)
+	assert.Equal(t, models.Tags{{Name: "host", Value: "a"}},
+		result.Metrics[0].Tags)
+}
+
+func TestLabelStorageFetchBlocks(t *testing.T) {
+	now := time.Now()
+	bounds := models.Bounds{Start: now, Duration: time.Minute, StepSize: time.Minute}
+	b := test.NewBlockFromValuesWithSeriesMeta(bounds, []block.SeriesMeta{{
+		Name: "foo",
+		Tags: models.Tags{
+			{Name: "email", Value: "a@b.c"},
+			{Name: "host", Value: "a"},
+		},
+	}}, [][]float64{{1}})
+
+	underlying := mock.NewMockStorage()
+	underlying.SetFetchBlocksResult(block.Result{Blocks: []block.Block{b}}, nil)
+	store := NewLabelStorage(underlying, testLabelRules, nil)
+
+	result, err := store.FetchBlocks(context.Background(),
+		newTestFetchQuery(t, "host"), &storage.FetchOptions{})
+	require.NoError(t, err)
+	require.Len(t, result.Blocks, 1)
+
+	expected := models.Tags{{Name: "host", Value: "a"}}
+	stepIter, err := result.Blocks[0].StepIter()
+	require.NoError(t, err)
+	assert.Equal(t, expected, stepIter.SeriesMeta()[0].Tags)
+
+	seriesIter, err := result.Blocks[0].SeriesIter()
+	require.NoError(t, err)
+	assert.Equal(t, expected, seriesIter.SeriesMeta()[0].Tags)
+	require.True(t, seriesIter.Next())
+	series, err := seriesIter.Current()
+	require.NoError(t, err)
+	assert.Equal(t, expected, series.Meta.Tags)
+}
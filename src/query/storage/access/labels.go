@@ -0,0 +1,210 @@
+// Copyright (c) 2018 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package access
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/m3db/m3/src/query/models"
+)
+
+var (
+	// ErrLabelAccessDenied is returned when a query matches on a label it is
+	// denied access to.
+	ErrLabelAccessDenied = errors.New("label access denied")
+
+	errLabelActionUnspecified = errors.New("label action unspecified")
+	errLabelRuleNoName        = errors.New("label rule has no label name")
+)
+
+// LabelAction is how a label rule restricts queries.
+type LabelAction uint
+
+const (
+	// LabelActionDeny rejects queries that match on the label.
+	LabelActionDeny LabelAction = iota
+	// LabelActionStrip removes the label from the series queries return.
+	LabelActionStrip
+)
+
+// ValidLabelActions returns the valid label actions.
+func ValidLabelActions() []LabelAction {
+	return []LabelAction{LabelActionDeny, LabelActionStrip}
+}
+
+func (a LabelAction) String() string {
+	switch a {
+	case LabelActionDeny:
+		return "deny"
+	case LabelActionStrip:
+		return "strip"
+	}
+	return "unknown"
+}
+
+// ParseLabelAction parses a LabelAction from a string.
+func ParseLabelAction(str string) (LabelAction, error) {
+	var a LabelAction
+	if str == "" {
+		return a, errLabelActionUnspecified
+	}
+	for _, valid := range ValidLabelActions() {
+		if str == valid.String() {
+			a = valid
+			return a, nil
+		}
+	}
+	return a, fmt.Errorf("invalid LabelAction '%s' valid actions are: %v",
+		str, ValidLabelActions())
+}
+
+// UnmarshalYAML unmarshals a LabelAction into a valid type from string.
+func (a *LabelAction) UnmarshalYAML(unmarshal func(interface{}) error) error {
+	var str string
+	if err := unmarshal(&str); err != nil {
+		return err
+	}
+	parsed, err := ParseLabelAction(str)
+	if err != nil {
+		return err
+	}
+	*a = parsed
+	return nil
+}
+
+// LabelRule restricts the queries of a set of principals that touch a label.
+type LabelRule struct {
+	// Name is the name of the label.
+	Name string `yaml:"name"`
+
+	// Values are the restricted values of the label, all values are
+	// restricted if empty.
+	Values []string `yaml:"values"`
+
+	// Action is how queries touching the label are restricted, "deny" or
+	// "strip".
+	Action LabelAction `yaml:"action"`
+
+	// AppliesTo lists the tenants, identities and roles the rule applies to,
+	// it applies to every request if it lists none.
+	AppliesTo Rule `yaml:"appliesTo"`
+}
+
+func (r LabelRule) appliesTo(p Principal) bool {
+	return !r.AppliesTo.Restricted() || r.AppliesTo.lists(p)
+}
+
+func (r LabelRule) matchesTag(tag models.Tag) bool {
+	return tag.Name == r.Name &&
+		(len(r.Values) == 0 || contains(r.Values, tag.Value))
+}
+
+// matchesMatcher returns whether a matcher may select series with a
+// restricted value of the label.
+func (r LabelRule) matchesMatcher(m *models.Matcher) (bool, error) {
+	if m.Name != r.Name {
+		return false, nil
+	}
+	if len(r.Values) == 0 {
+		return true, nil
+	}
+
+	// Rebuild the matcher as matchers decoded from JSON have no compiled
+	// regular expression.
+	var (
+		matcher *models.Matcher
+		err     error
+	)
+	if m.Type == models.MatchFuzzy {
+		matcher, err = models.NewFuzzyMatcher(m.Name, m.Value, m.MaxEdits)
+	} else {
+		matcher, err = models.NewMatcher(m.Type, m.Name, m.Value)
+	}
+	if err != nil {
+		return false, err
+	}
+	for _, value := range r.Values {
+		if matcher.Matches(value) {
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+// LabelRules are a set of label rules.
+type LabelRules []LabelRule
+
+// Validate validates the label rules.
+func (r LabelRules) Validate() error {
+	for _, rule := range r {
+		if rule.Name == "" {
+			return errLabelRuleNoName
+		}
+	}
+	return nil
+}
+
+// CheckMatchers returns an error if any of the matchers touch a label the
+// principal is denied from matching on.
+func (r LabelRules) CheckMatchers(p Principal, matchers models.Matchers) error {
+	for _, rule := range r {
+		if rule.Action != LabelActionDeny || !rule.appliesTo(p) {
+			continue
+		}
+		for _, m := range matchers {
+			matches, err := rule.matchesMatcher(m)
+			if err != nil {
+				return err
+			}
+			if matches {
+				return fmt.Errorf("%v: %s", ErrLabelAccessDenied, m.Name)
+			}
+		}
+	}
+	return nil
+}
+
+// StripTags returns the tags without the labels stripped for the principal,
+// the tags are returned as is if none are stripped.
+func (r LabelRules) StripTags(p Principal, tags models.Tags) models.Tags {
+	var stripped models.Tags
+	for i, tag := range tags {
+		strip := false
+		for _, rule := range r {
+			if rule.Action == LabelActionStrip && rule.appliesTo(p) &&
+				rule.matchesTag(tag) {
+				strip = true
+				break
+			}
+		}
+		if strip && stripped == nil {
+			stripped = make(models.Tags, 0, len(tags)-1)
+			stripped = append(stripped, tags[:i]...)
+		} else if !strip && stripped != nil {
+			stripped = append(stripped, tag)
+		}
+	}
+	if stripped == nil {
+		return tags
+	}
+	return stripped
+}
@@ -0,0 +1,120 @@
+// Copyright (c) 2018 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package access
+
+import (
+	"testing"
+
+	"github.com/m3db/m3/src/query/models"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	yaml "gopkg.in/yaml.v2"
+)
+
+func mustMatcher(t *testing.T, matchType models.MatchType, name, value string) *models.Matcher {
+	m, err := models.NewMatcher(matchType, name, value)
+	require.NoError(t, err)
+	return m
+}
+
+func TestLabelRulesCheckMatchers(t *testing.T) {
+	rules := LabelRules{
+		{Name: "email", Action: LabelActionDeny},
+		{
+			Name:      "customer",
+			Values:    []string{"acme"},
+			Action:    LabelActionDeny,
+			AppliesTo: Rule{Roles: []string{"read"}},
+		},
+	}
+	reader := Principal{Roles: []string{"read"}}
+
+	tests := []struct {
+		matcher *models.Matcher
+		denied  bool
+	}{
+		{mustMatcher(t, models.MatchEqual, "email", "a@b.c"), true},
+		{mustMatcher(t, models.MatchEqual, "customer", "acme"), true},
+		{mustMatcher(t, models.MatchEqual, "customer", "other"), false},
+		{mustMatcher(t, models.MatchNotEqual, "customer", "other"), true},
+		{mustMatcher(t, models.MatchRegexp, "customer", "ac.*"), true},
+		{mustMatcher(t, models.MatchRegexp, "customer", "b.*"), false},
+		// Matchers decoded from JSON have no compiled regular expression
+		{&models.Matcher{Type: models.MatchRegexp, Name: "customer", Value: "a.*"}, true},
+		{mustMatcher(t, models.MatchEqual, "host", "a"), false},
+	}
+	for _, test := range tests {
+		err := rules.CheckMatchers(reader, models.Matchers{test.matcher})
+		assert.Equal(t, test.denied, err != nil, test.matcher.String())
+		assert.Equal(t, test.denied, IsAccessDenied(err), test.matcher.String())
+	}
+
+	// The customer rule only applies to readers
+	matchers := models.Matchers{mustMatcher(t, models.MatchEqual, "customer", "acme")}
+	assert.NoError(t, rules.CheckMatchers(Principal{}, matchers))
+}
+
+func TestLabelRulesStripTags(t *testing.T) {
+	rules := LabelRules{
+		{Name: "email", Action: LabelActionStrip},
+		{Name: "customer", Values: []string{"acme"}, Action: LabelActionStrip},
+		{Name: "host", Action: LabelActionDeny},
+	}
+	tags := models.Tags{
+		{Name: "customer", Value: "acme"},
+		{Name: "email", Value: "a@b.c"},
+		{Name: "host", Value: "a"},
+		{Name: "region", Value: "us"},
+	}
+
+	stripped := rules.StripTags(Principal{}, tags)
+	assert.Equal(t, models.Tags{
+		{Name: "host", Value: "a"},
+		{Name: "region", Value: "us"},
+	}, stripped)
+
+	unchanged := models.Tags{{Name: "customer", Value: "other"}}
+	assert.Equal(t, unchanged, rules.StripTags(Principal{}, unchanged))
+}
+
+func TestLabelRulesUnmarshalYAML(t *testing.T) {
+	var cfg Configuration
+	require.NoError(t, yaml.Unmarshal([]byte(`
+labels:
+  - name: email
+    action: strip
+    appliesTo:
+      roles: [read]
+`), &cfg))
+	require.Len(t, cfg.Labels, 1)
+	assert.Equal(t, LabelActionStrip, cfg.Labels[0].Action)
+	assert.Equal(t, []string{"read"}, cfg.Labels[0].AppliesTo.Roles)
+
+	err := yaml.Unmarshal([]byte(`
+labels:
+  - name: email
+    action: hide
+`), &cfg)
+	assert.Error(t, err)
+
+	assert.Equal(t, errLabelRuleNoName, LabelRules{{}}.Validate())
+}
@@ -31,7 +31,8 @@ import (
 
 // FetchResultToBlockResult converts a fetch result into coordinator blocks
 func FetchResultToBlockResult(result *FetchResult, query *FetchQuery) (block.Result, error) {
-	alignedSeriesList, err := result.SeriesList.Align(query.Start, query.End, query.Interval)
+	alignedSeriesList, err := result.SeriesList.AlignWithLookback(query.Start, query.End,
+		query.Interval, query.Lookback)
 	if err != nil {
 		return block.Result{}, err
 	}
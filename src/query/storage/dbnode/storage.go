@@ -0,0 +1,211 @@
+// Copyright (c) 2018 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+// Package dbnode provides a storage that reads and writes a namespace of a
+// DB node through the node gRPC service.
+package dbnode
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/m3db/m3/src/dbnode/generated/proto/nodepb"
+	"github.com/m3db/m3/src/dbnode/network/server/grpc/node"
+	"github.com/m3db/m3/src/query/block"
+	queryerrors "github.com/m3db/m3/src/query/errors"
+	"github.com/m3db/m3/src/query/models"
+	"github.com/m3db/m3/src/query/storage"
+	"github.com/m3db/m3/src/query/ts"
+	"github.com/m3db/m3x/ident"
+)
+
+var (
+	errAggregatedWrite = errors.New(
+		"node storage only writes unaggregated metrics")
+)
+
+type nodeStorage struct {
+	client    node.Client
+	namespace ident.ID
+}
+
+// NewStorage returns a storage of the namespace of the node of the client,
+// series are decoded by the node and received in batches.
+func NewStorage(client node.Client, namespace ident.ID) storage.Storage {
+	return &nodeStorage{client: client, namespace: namespace}
+}
+
+func (s *nodeStorage) Fetch(
+	ctx context.Context,
+	query *storage.FetchQuery,
+	options *storage.FetchOptions,
+) (*storage.FetchResult, error) {
+	result := &storage.FetchResult{}
+	err := s.fetchTagged(ctx, query, options, true, func(series *nodepb.Series) {
+		datapoints := make(ts.Datapoints, 0, len(series.Datapoints))
+		for _, dp := range series.Datapoints {
+			datapoints = append(datapoints, ts.Datapoint{
+				Timestamp: time.Unix(0, dp.TimestampNanos),
+				Value:     dp.Value,
+			})
+		}
+		result.SeriesList = append(result.SeriesList,
+			ts.NewSeries(string(series.Id), datapoints, toTags(series.Tags)))
+	})
+	if err != nil {
+		return nil, err
+	}
+	return result, nil
+}
+
+func (s *nodeStorage) FetchTags(
+	ctx context.Context,
+	query *storage.FetchQuery,
+	options *storage.FetchOptions,
+) (*storage.SearchResults, error) {
+	var metrics models.Metrics
+	err := s.fetchTagged(ctx, query, options, false, func(series *nodepb.Series) {
+		metrics = append(metrics, &models.Metric{
+			Namespace: s.namespace.String(),
+			ID:        string(series.Id),
+			Tags:      toTags(series.Tags),
+		})
+	})
+	if err != nil {
+		return nil, err
+	}
+	return &storage.SearchResults{Metrics: metrics}, nil
+}
+
+func (s *nodeStorage) FetchBlocks(
+	ctx context.Context,
+	query *storage.FetchQuery,
+	options *storage.FetchOptions,
+) (block.Result, error) {
+	result, err := s.Fetch(ctx, query, options)
+	if err != nil {
+		return block.Result{}, err
+	}
+	return storage.FetchResultToBlockResult(result, query)
+}
+
+func (s *nodeStorage) fetchTagged(
+	ctx context.Context,
+	query *storage.FetchQuery,
+	options *storage.FetchOptions,
+	fetchData bool,
+	fn func(series *nodepb.Series),
+) error {
+	// Check if the query was interrupted.
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-options.KillChan:
+		return queryerrors.ErrQueryInterrupted
+	default:
+	}
+
+	q, err := storage.FetchQueryToM3Query(query)
+	if err != nil {
+		return err
+	}
+
+	opts := storage.FetchOptionsToM3Options(options, query)
+	_, err = s.client.FetchTagged(ctx, s.namespace, q, opts, fetchData,
+		func(batch []*nodepb.Series) error {
+			for _, series := range batch {
+				fn(series)
+			}
+			return nil
+		})
+	return err
+}
+
+func (s *nodeStorage) Write(ctx context.Context, query *storage.WriteQuery) error {
+	// Check if the query was interrupted.
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	default:
+	}
+
+	if query == nil {
+		return queryerrors.ErrNilWriteQuery
+	}
+	if query.Attributes.MetricsType != storage.UnaggregatedMetricsType {
+		return errAggregatedWrite
+	}
+
+	var (
+		id     = []byte(query.Tags.ID())
+		tags   = make([]*nodepb.Tag, 0, len(query.Tags))
+		writes = make([]*nodepb.WriteRequest, 0, len(query.Datapoints))
+	)
+	for _, tag := range query.Tags {
+		tags = append(tags, &nodepb.Tag{
+			Name:  []byte(tag.Name),
+			Value: []byte(tag.Value),
+		})
+	}
+	for _, dp := range query.Datapoints {
+		writes = append(writes, &nodepb.WriteRequest{
+			NameSpace: s.namespace.Bytes(),
+			Id:        id,
+			Tags:      tags,
+			Datapoint: &nodepb.Datapoint{
+				TimestampNanos: dp.Timestamp.UnixNano(),
+				Value:          dp.Value,
+				Annotation:     query.Annotation,
+			},
+			Unit: int32(query.Unit),
+		})
+	}
+
+	writeErrs, err := s.client.WriteBatch(ctx, writes)
+	if err != nil {
+		return err
+	}
+	if len(writeErrs) > 0 {
+		return fmt.Errorf("failed to write %d of %d datapoints, first error: %s",
+			len(writeErrs), len(writes), writeErrs[0].Message)
+	}
+	return nil
+}
+
+func (s *nodeStorage) Type() storage.Type {
+	return storage.TypeLocalDC
+}
+
+func (s *nodeStorage) Close() error {
+	return s.client.Close()
+}
+
+func toTags(tags []*nodepb.Tag) models.Tags {
+	result := make(models.Tags, 0, len(tags))
+	for _, tag := range tags {
+		result = append(result, models.Tag{
+			Name:  string(tag.Name),
+			Value: string(tag.Value),
+		})
+	}
+	return models.Normalize(result)
+}
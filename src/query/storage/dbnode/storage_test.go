@@ -0,0 +1,169 @@
+// Copyright (c) 2018 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package dbnode
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/m3db/m3/src/dbnode/generated/proto/nodepb"
+	"github.com/m3db/m3/src/dbnode/storage/index"
+	"github.com/m3db/m3/src/query/models"
+	"github.com/m3db/m3/src/query/storage"
+	"github.com/m3db/m3/src/query/ts"
+	"github.com/m3db/m3x/ident"
+	xtime "github.com/m3db/m3x/time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type testClient struct {
+	series    []*nodepb.Series
+	fetchData bool
+	opts      index.QueryOptions
+	writes    []*nodepb.WriteRequest
+	writeErrs []*nodepb.WriteError
+}
+
+func (c *testClient) Fetch(
+	_ context.Context,
+	_, _ ident.ID,
+	_, _ time.Time,
+	_ func(dps []*nodepb.Datapoint) error,
+) error {
+	return nil
+}
+
+func (c *testClient) FetchTagged(
+	_ context.Context,
+	_ ident.ID,
+	_ index.Query,
+	opts index.QueryOptions,
+	fetchData bool,
+	fn func(series []*nodepb.Series) error,
+) (bool, error) {
+	c.opts, c.fetchData = opts, fetchData
+	for _, series := range c.series {
+		if err := fn([]*nodepb.Series{series}); err != nil {
+			return false, err
+		}
+	}
+	return true, nil
+}
+
+func (c *testClient) WriteBatch(
+	_ context.Context,
+	writes []*nodepb.WriteRequest,
+) ([]*nodepb.WriteError, error) {
+	c.writes = writes
+	return c.writeErrs, nil
+}
+
+func (c *testClient) Close() error {
+	return nil
+}
+
+func newTestFetchQuery(start time.Time) *storage.FetchQuery {
+	return &storage.FetchQuery{
+		TagMatchers: models.Matchers{{
+			Type:  models.MatchEqual,
+			Name:  []byte("foo"),
+			Value: []byte("bar"),
+		}},
+		Start: start,
+		End:   start.Add(time.Minute),
+	}
+}
+
+func TestStorageFetch(t *testing.T) {
+	start := time.Unix(1500000000, 0)
+	client := &testClient{series: []*nodepb.Series{{
+		Id:   []byte("foo=bar"),
+		Tags: []*nodepb.Tag{{Name: []byte("foo"), Value: []byte("bar")}},
+		Datapoints: []*nodepb.Datapoint{
+			{TimestampNanos: start.UnixNano(), Value: 1},
+			{TimestampNanos: start.Add(time.Second).UnixNano(), Value: 2},
+		},
+	}}}
+	store := NewStorage(client, ident.StringID("metrics"))
+
+	result, err := store.Fetch(context.Background(), newTestFetchQuery(start),
+		&storage.FetchOptions{Limit: 10})
+	require.NoError(t, err)
+	assert.True(t, client.fetchData)
+	assert.Equal(t, 10, client.opts.Limit)
+	require.Equal(t, 1, len(result.SeriesList))
+
+	series := result.SeriesList[0]
+	assert.Equal(t, "foo=bar", series.Name())
+	assert.Equal(t, models.Tags{{Name: "foo", Value: "bar"}}, series.Tags)
+	assert.Equal(t, ts.Datapoints{
+		{Timestamp: start, Value: 1},
+		{Timestamp: start.Add(time.Second), Value: 2},
+	}, series.Values())
+}
+
+func TestStorageFetchTags(t *testing.T) {
+	client := &testClient{series: []*nodepb.Series{{
+		Id:   []byte("foo=bar"),
+		Tags: []*nodepb.Tag{{Name: []byte("foo"), Value: []byte("bar")}},
+	}}}
+	store := NewStorage(client, ident.StringID("metrics"))
+
+	result, err := store.FetchTags(context.Background(),
+		newTestFetchQuery(time.Unix(1500000000, 0)), &storage.FetchOptions{})
+	require.NoError(t, err)
+	assert.False(t, client.fetchData)
+	assert.Equal(t, models.Metrics{{
+		Namespace: "metrics",
+		ID:        "foo=bar",
+		Tags:      models.Tags{{Name: "foo", Value: "bar"}},
+	}}, result.Metrics)
+}
+
+func TestStorageWrite(t *testing.T) {
+	client := &testClient{}
+	store := NewStorage(client, ident.StringID("metrics"))
+
+	now := time.Unix(1500000000, 0)
+	query := &storage.WriteQuery{
+		Tags:       models.Tags{{Name: "foo", Value: "bar"}},
+		Datapoints: ts.Datapoints{{Timestamp: now, Value: 1}},
+		Unit:       xtime.Second,
+		Attributes: storage.Attributes{MetricsType: storage.UnaggregatedMetricsType},
+	}
+	require.NoError(t, store.Write(context.Background(), query))
+	require.Equal(t, 1, len(client.writes))
+	write := client.writes[0]
+	assert.Equal(t, []byte("metrics"), write.NameSpace)
+	assert.Equal(t, []byte(query.Tags.ID()), write.Id)
+	assert.Equal(t, []*nodepb.Tag{{Name: []byte("foo"), Value: []byte("bar")}}, write.Tags)
+	assert.Equal(t, now.UnixNano(), write.Datapoint.TimestampNanos)
+	assert.Equal(t, int32(xtime.Second), write.Unit)
+
+	client.writeErrs = []*nodepb.WriteError{{Index: 0, Message: "write failed"}}
+	assert.Error(t, store.Write(context.Background(), query))
+
+	query.Attributes.MetricsType = storage.AggregatedMetricsType
+	assert.Equal(t, errAggregatedWrite, store.Write(context.Background(), query))
+}
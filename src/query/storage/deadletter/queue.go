@@ -0,0 +1,474 @@
+// Copyright (c) 2018 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+// Package deadletter spills the writes that fail once the database client
+// has exhausted its retries to local files, so that they can be replayed
+// once the database recovers rather than being lost.
+package deadletter
+
+import (
+	"bufio"
+	"context"
+	"encoding/gob"
+	"errors"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/m3db/m3/src/query/models"
+	"github.com/m3db/m3/src/query/storage"
+	"github.com/m3db/m3/src/query/ts"
+	xtime "github.com/m3db/m3x/time"
+
+	"github.com/uber-go/tally"
+	"go.uber.org/zap"
+)
+
+const (
+	defaultMaxFileSize = 64 << 20
+	defaultMaxSize     = 1 << 30
+
+	filePrefix = "deadletter-"
+	fileSuffix = ".gob"
+)
+
+var (
+	errQueueFull   = errors.New("dead letter queue is full")
+	errQueueClosed = errors.New("dead letter queue is closed")
+	errNoDir       = errors.New("dead letter queue requires a directory")
+)
+
+// Options is the set of options for the dead letter queue.
+type Options struct {
+	// Dir is the directory the spill files are written to.
+	Dir string
+
+	// MaxFileSize is the size in bytes after which a spill file is closed
+	// and a new one started, defaults to 64MiB.
+	MaxFileSize int64
+
+	// MaxSize is the maximum size in bytes of all spill files, writes are
+	// dropped rather than spilled while it is exceeded, defaults to 1GiB.
+	MaxSize int64
+
+	// Scope is the metrics scope.
+	Scope tally.Scope
+
+	// Logger is the logger.
+	Logger *zap.Logger
+}
+
+// record is a spilled write.
+type record struct {
+	Tags       models.Tags
+	Datapoints ts.Datapoints
+	Unit       xtime.Unit
+	Annotation []byte
+	Attributes storage.Attributes
+}
+
+type queueMetrics struct {
+	spilled        tally.Counter
+	spillErrors    tally.Counter
+	dropped        tally.Counter
+	replayed       tally.Counter
+	replayFailures tally.Counter
+	size           tally.Gauge
+}
+
+func newQueueMetrics(scope tally.Scope) queueMetrics {
+	return queueMetrics{
+		spilled:        scope.Counter("spilled"),
+		spillErrors:    scope.Counter("spill-errors"),
+		dropped:        scope.Counter("dropped-queue-full"),
+		replayed:       scope.Counter("replayed"),
+		replayFailures: scope.Counter("replay-failures"),
+		size:           scope.Gauge("size-bytes"),
+	}
+}
+
+// Queue is a dead letter queue of writes spilled to files. Each spill file
+// is a stream of gob encoded writes, files are only appended to by the
+// process that created them and are replayed oldest first.
+type Queue struct {
+	sync.Mutex
+
+	dir         string
+	maxFileSize int64
+	maxSize     int64
+	logger      *zap.Logger
+	nowFn       func() time.Time
+
+	file    *spillFile
+	size    int64
+	closed  bool
+	replayM sync.Mutex
+
+	metrics queueMetrics
+}
+
+type spillFile struct {
+	path    string
+	file    *os.File
+	buf     *bufio.Writer
+	counter *countingWriter
+	encoder *gob.Encoder
+}
+
+type countingWriter struct {
+	w io.Writer
+	n int64
+}
+
+func (w *countingWriter) Write(p []byte) (int, error) {
+	n, err := w.w.Write(p)
+	w.n += int64(n)
+	return n, err
+}
+
+// NewQueue returns a dead letter queue spilling to the directory, spill
+// files left in the directory by previous processes are kept for replay.
+func NewQueue(opts Options) (*Queue, error) {
+	if opts.Dir == "" {
+		return nil, errNoDir
+	}
+	if opts.MaxFileSize <= 0 {
+		opts.MaxFileSize = defaultMaxFileSize
+	}
+	if opts.MaxSize <= 0 {
+		opts.MaxSize = defaultMaxSize
+	}
+	if opts.Scope == nil {
+		opts.Scope = tally.NoopScope
+	}
+	if opts.Logger == nil {
+		opts.Logger = zap.NewNop()
+	}
+	if err := os.MkdirAll(opts.Dir, 0755); err != nil {
+		return nil, err
+	}
+
+	q := &Queue{
+		dir:         opts.Dir,
+		maxFileSize: opts.MaxFileSize,
+		maxSize:     opts.MaxSize,
+		logger:      opts.Logger,
+		nowFn:       time.Now,
+		metrics:     newQueueMetrics(opts.Scope),
+	}
+	files, err := q.files()
+	if err != nil {
+		return nil, err
+	}
+	for _, f := range files {
+		q.size += f.Size
+	}
+	q.metrics.size.Update(float64(q.size))
+	return q, nil
+}
+
+// Spill appends a write to the current spill file.
+func (q *Queue) Spill(query *storage.WriteQuery) error {
+	q.Lock()
+	defer q.Unlock()
+	if q.closed {
+		return errQueueClosed
+	}
+	if q.size >= q.maxSize {
+		q.metrics.dropped.Inc(1)
+		return errQueueFull
+	}
+
+	if err := q.spillWithLock(query); err != nil {
+		q.metrics.spillErrors.Inc(1)
+		return err
+	}
+	q.metrics.spilled.Inc(1)
+	return nil
+}
+
+func (q *Queue) spillWithLock(query *storage.WriteQuery) error {
+	if q.file == nil {
+		file, err := q.newSpillFile()
+		if err != nil {
+			return err
+		}
+		q.file = file
+	}
+
+	before := q.file.counter.n
+	err := q.file.encoder.Encode(record{
+		Tags:       query.Tags,
+		Datapoints: query.Datapoints,
+		Unit:       query.Unit,
+		Annotation: query.Annotation,
+		Attributes: query.Attributes,
+	})
+	if err == nil {
+		// Flush each write so that spilled writes survive a crash.
+		err = q.file.buf.Flush()
+	}
+	q.size += q.file.counter.n - before
+	q.metrics.size.Update(float64(q.size))
+	if err != nil {
+		// The stream may be corrupt past the failed write, start a new file
+		// for the next write.
+		q.closeFileWithLock()
+		return err
+	}
+
+	if q.file.counter.n >= q.maxFileSize {
+		return q.closeFileWithLock()
+	}
+	return nil
+}
+
+func (q *Queue) newSpillFile() (*spillFile, error) {
+	// File names sort by creation time, which is the order they are
+	// replayed in.
+	nanos := q.nowFn().UnixNano()
+	for {
+		path := filepath.Join(q.dir, fmt.Sprintf("%s%020d%s", filePrefix, nanos, fileSuffix))
+		file, err := os.OpenFile(path, os.O_WRONLY|os.O_CREATE|os.O_EXCL, 0644)
+		if os.IsExist(err) {
+			nanos++
+			continue
+		}
+		if err != nil {
+			return nil, err
+		}
+		buf := bufio.NewWriter(file)
+		counter := &countingWriter{w: buf}
+		return &spillFile{
+			path:    path,
+			file:    file,
+			buf:     buf,
+			counter: counter,
+			encoder: gob.NewEncoder(counter),
+		}, nil
+	}
+}
+
+func (q *Queue) closeFileWithLock() error {
+	if q.file == nil {
+		return nil
+	}
+	file := q.file
+	q.file = nil
+	if err := file.buf.Flush(); err != nil {
+		file.file.Close()
+		return err
+	}
+	return file.file.Close()
+}
+
+// FileInfo is a spill file of the queue.
+type FileInfo struct {
+	Name string `json:"name"`
+	Size int64  `json:"size"`
+}
+
+// Files returns the spill files of the queue, oldest first.
+func (q *Queue) Files() ([]FileInfo, error) {
+	q.Lock()
+	defer q.Unlock()
+	return q.files()
+}
+
+func (q *Queue) files() ([]FileInfo, error) {
+	entries, err := ioutil.ReadDir(q.dir)
+	if err != nil {
+		return nil, err
+	}
+	var files []FileInfo
+	for _, entry := range entries {
+		name := entry.Name()
+		if entry.IsDir() || !strings.HasPrefix(name, filePrefix) ||
+			!strings.HasSuffix(name, fileSuffix) {
+			continue
+		}
+		files = append(files, FileInfo{Name: name, Size: entry.Size()})
+	}
+	sort.Slice(files, func(i, j int) bool {
+		return files[i].Name < files[j].Name
+	})
+	return files, nil
+}
+
+// ReplayResult is the result of replaying the spill files of the queue.
+type ReplayResult struct {
+	// Files is the number of spill files replayed.
+	Files int `json:"files"`
+
+	// Replayed is the number of writes replayed.
+	Replayed int `json:"replayed"`
+
+	// Failed is the number of writes that failed to replay, which are
+	// spilled again to be replayed later.
+	Failed int `json:"failed"`
+}
+
+// Replay writes the spilled writes to the storage, oldest first, and
+// removes each spill file once all its writes have been written or spilled
+// again. Writes spilled while replaying are left for the next replay.
+func (q *Queue) Replay(ctx context.Context, store storage.Storage) (ReplayResult, error) {
+	q.replayM.Lock()
+	defer q.replayM.Unlock()
+
+	// Close the current spill file so that it is replayed and new writes
+	// are spilled to a new file.
+	q.Lock()
+	if q.closed {
+		q.Unlock()
+		return ReplayResult{}, errQueueClosed
+	}
+	err := q.closeFileWithLock()
+	files, filesErr := q.files()
+	q.Unlock()
+	if err != nil {
+		return ReplayResult{}, err
+	}
+	if filesErr != nil {
+		return ReplayResult{}, filesErr
+	}
+
+	var result ReplayResult
+	for _, f := range files {
+		if err := ctx.Err(); err != nil {
+			return result, err
+		}
+		if err := q.replayFile(ctx, store, f, &result); err != nil {
+			return result, err
+		}
+		result.Files++
+	}
+	return result, nil
+}
+
+func (q *Queue) replayFile(
+	ctx context.Context,
+	store storage.Storage,
+	f FileInfo,
+	result *ReplayResult,
+) error {
+	path := filepath.Join(q.dir, f.Name)
+	file, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+
+	decoder := gob.NewDecoder(bufio.NewReader(file))
+	for {
+		var r record
+		err := decoder.Decode(&r)
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			// A file truncated by a crash while spilling is replayed up to
+			// the truncated write.
+			q.logger.Warn("dead letter spill file truncated",
+				zap.String("file", f.Name), zap.Error(err))
+			break
+		}
+
+		query := &storage.WriteQuery{
+			Tags:       r.Tags,
+			Datapoints: r.Datapoints,
+			Unit:       r.Unit,
+			Annotation: r.Annotation,
+			Attributes: r.Attributes,
+		}
+		if err := store.Write(ctx, query); err != nil {
+			q.metrics.replayFailures.Inc(1)
+			if spillErr := q.respill(query); spillErr != nil {
+				// Keep the file to replay it again rather than lose writes.
+				return fmt.Errorf("unable to spill write that failed to replay: %v", spillErr)
+			}
+			result.Failed++
+			continue
+		}
+		q.metrics.replayed.Inc(1)
+		result.Replayed++
+	}
+
+	q.Lock()
+	defer q.Unlock()
+	if err := os.Remove(path); err != nil {
+		return err
+	}
+	q.size -= f.Size
+	q.metrics.size.Update(float64(q.size))
+	return nil
+}
+
+// respill spills a write that failed to replay regardless of the max size,
+// since the file it is replayed from is removed once replayed.
+func (q *Queue) respill(query *storage.WriteQuery) error {
+	q.Lock()
+	defer q.Unlock()
+	if q.closed {
+		return errQueueClosed
+	}
+	return q.spillWithLock(query)
+}
+
+// Close closes the current spill file, writes are no longer spilled once
+// the queue is closed.
+func (q *Queue) Close() error {
+	q.Lock()
+	defer q.Unlock()
+	if q.closed {
+		return errQueueClosed
+	}
+	q.closed = true
+	return q.closeFileWithLock()
+}
+
+// Replayer replays the spilled writes of a queue to the storage they failed
+// to be written to.
+type Replayer struct {
+	queue *Queue
+	store storage.Storage
+}
+
+// NewReplayer returns a replayer of the queue to the storage, which must
+// not spill to the queue itself so that failed writes are only spilled
+// again once.
+func NewReplayer(queue *Queue, store storage.Storage) *Replayer {
+	return &Replayer{queue: queue, store: store}
+}
+
+// Files returns the spill files of the queue, oldest first.
+func (r *Replayer) Files() ([]FileInfo, error) {
+	return r.queue.Files()
+}
+
+// Replay replays the spilled writes of the queue to the storage.
+func (r *Replayer) Replay(ctx context.Context) (ReplayResult, error) {
+	return r.queue.Replay(ctx, r.store)
+}
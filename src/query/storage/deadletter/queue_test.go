@@ -0,0 +1,197 @@
+// Copyright (c) 2018 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package deadletter
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"math"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/m3db/m3/src/query/models"
+	"github.com/m3db/m3/src/query/storage"
+	"github.com/m3db/m3/src/query/storage/mock"
+	"github.com/m3db/m3/src/query/ts"
+	xtime "github.com/m3db/m3x/time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func newTestWrite(series int) *storage.WriteQuery {
+	return &storage.WriteQuery{
+		Tags: models.Normalize(models.Tags{
+			{Name: models.MetricName, Value: "foo"},
+			{Name: "series", Value: fmt.Sprint(series)},
+		}),
+		Datapoints: ts.Datapoints{
+			{Timestamp: time.Unix(1500000000, 0).UTC(), Value: float64(series)},
+			{Timestamp: time.Unix(1500000010, 0).UTC(), Value: math.NaN()},
+		},
+		Unit:       xtime.Millisecond,
+		Annotation: []byte("annotation"),
+		Attributes: storage.Attributes{
+			MetricsType: storage.AggregatedMetricsType,
+			Retention:   48 * time.Hour,
+			Resolution:  time.Minute,
+		},
+	}
+}
+
+func newTestQueue(t *testing.T, opts Options) (*Queue, func()) {
+	dir, err := ioutil.TempDir("", "deadletter")
+	require.NoError(t, err)
+	opts.Dir = dir
+	q, err := NewQueue(opts)
+	require.NoError(t, err)
+	return q, func() { os.RemoveAll(dir) }
+}
+
+func assertWritesEqual(t *testing.T, expected, actual *storage.WriteQuery) {
+	assert.Equal(t, expected.Tags, actual.Tags)
+	assert.Equal(t, expected.Unit, actual.Unit)
+	assert.Equal(t, expected.Annotation, actual.Annotation)
+	assert.Equal(t, expected.Attributes, actual.Attributes)
+	require.Equal(t, len(expected.Datapoints), len(actual.Datapoints))
+	for i, dp := range expected.Datapoints {
+		assert.True(t, dp.Timestamp.Equal(actual.Datapoints[i].Timestamp))
+		if math.IsNaN(dp.Value) {
+			assert.True(t, math.IsNaN(actual.Datapoints[i].Value))
+		} else {
+			assert.Equal(t, dp.Value, actual.Datapoints[i].Value)
+		}
+	}
+}
+
+func TestQueueSpillAndReplay(t *testing.T) {
+	q, cleanup := newTestQueue(t, Options{MaxFileSize: 512})
+	defer cleanup()
+
+	for i := 0; i < 10; i++ {
+		require.NoError(t, q.Spill(newTestWrite(i)))
+	}
+	files, err := q.Files()
+	require.NoError(t, err)
+	assert.True(t, len(files) > 1, "%d spill files", len(files))
+
+	store := mock.NewMockStorage()
+	result, err := q.Replay(context.Background(), store)
+	require.NoError(t, err)
+	assert.Equal(t, ReplayResult{Files: len(files), Replayed: 10}, result)
+
+	writes := store.Writes()
+	require.Len(t, writes, 10)
+	for i, write := range writes {
+		assertWritesEqual(t, newTestWrite(i), write)
+	}
+
+	files, err = q.Files()
+	require.NoError(t, err)
+	assert.Len(t, files, 0)
+	assert.Equal(t, int64(0), q.size)
+	require.NoError(t, q.Close())
+}
+
+func TestQueueReplayRespillsFailedWrites(t *testing.T) {
+	q, cleanup := newTestQueue(t, Options{})
+	defer cleanup()
+
+	for i := 0; i < 3; i++ {
+		require.NoError(t, q.Spill(newTestWrite(i)))
+	}
+
+	store := mock.NewMockStorage()
+	store.SetWriteResult(errors.New("failed to meet consistency level"))
+	result, err := q.Replay(context.Background(), store)
+	require.NoError(t, err)
+	assert.Equal(t, ReplayResult{Files: 1, Failed: 3}, result)
+
+	// The failed writes are replayed once the storage recovers.
+	store = mock.NewMockStorage()
+	result, err = q.Replay(context.Background(), store)
+	require.NoError(t, err)
+	assert.Equal(t, ReplayResult{Files: 1, Replayed: 3}, result)
+	require.Len(t, store.Writes(), 3)
+	assertWritesEqual(t, newTestWrite(2), store.Writes()[2])
+}
+
+func TestQueueReplaysFilesOfPreviousProcess(t *testing.T) {
+	q, cleanup := newTestQueue(t, Options{})
+	defer cleanup()
+
+	require.NoError(t, q.Spill(newTestWrite(0)))
+	require.NoError(t, q.Close())
+	assert.Equal(t, errQueueClosed, q.Spill(newTestWrite(1)))
+
+	reopened, err := NewQueue(Options{Dir: q.dir})
+	require.NoError(t, err)
+	assert.Equal(t, q.size, reopened.size)
+
+	store := mock.NewMockStorage()
+	result, err := reopened.Replay(context.Background(), store)
+	require.NoError(t, err)
+	assert.Equal(t, ReplayResult{Files: 1, Replayed: 1}, result)
+	require.Len(t, store.Writes(), 1)
+	assertWritesEqual(t, newTestWrite(0), store.Writes()[0])
+}
+
+func TestQueueReplaysTruncatedFile(t *testing.T) {
+	q, cleanup := newTestQueue(t, Options{})
+	defer cleanup()
+
+	require.NoError(t, q.Spill(newTestWrite(0)))
+	require.NoError(t, q.Spill(newTestWrite(1)))
+	path := q.file.path
+	require.NoError(t, q.Close())
+
+	info, err := os.Stat(path)
+	require.NoError(t, err)
+	require.NoError(t, os.Truncate(path, info.Size()-5))
+
+	reopened, err := NewQueue(Options{Dir: filepath.Dir(path)})
+	require.NoError(t, err)
+	store := mock.NewMockStorage()
+	result, err := reopened.Replay(context.Background(), store)
+	require.NoError(t, err)
+	assert.Equal(t, ReplayResult{Files: 1, Replayed: 1}, result)
+}
+
+func TestQueueFull(t *testing.T) {
+	q, cleanup := newTestQueue(t, Options{MaxSize: 1})
+	defer cleanup()
+
+	require.NoError(t, q.Spill(newTestWrite(0)))
+	assert.Equal(t, errQueueFull, q.Spill(newTestWrite(1)))
+
+	_, err := q.Replay(context.Background(), mock.NewMockStorage())
+	require.NoError(t, err)
+	assert.NoError(t, q.Spill(newTestWrite(1)))
+}
+
+func TestNewQueueRequiresDir(t *testing.T) {
+	_, err := NewQueue(Options{})
+	assert.Equal(t, errNoDir, err)
+}
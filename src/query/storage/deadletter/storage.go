@@ -0,0 +1,122 @@
+// Copyright (c) 2018 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package deadletter
+
+import (
+	"context"
+
+	"github.com/m3db/m3/src/query/storage"
+
+	"github.com/uber-go/tally"
+	"go.uber.org/zap"
+)
+
+// Configuration is the configuration of the dead letter queue.
+type Configuration struct {
+	// Dir is the directory the spill files are written to.
+	Dir string `yaml:"dir" validate:"nonzero"`
+
+	// MaxFileSize is the size in bytes after which a spill file is closed
+	// and a new one started.
+	MaxFileSize int64 `yaml:"maxFileSize"`
+
+	// MaxSize is the maximum size in bytes of all spill files.
+	MaxSize int64 `yaml:"maxSize"`
+
+	// Acknowledge acknowledges writes once they are spilled rather than
+	// failing them, clients then rely on the writes being replayed rather
+	// than retrying them.
+	Acknowledge bool `yaml:"acknowledge"`
+}
+
+// NewQueue returns the dead letter queue of the configuration.
+func (c Configuration) NewQueue(scope tally.Scope, logger *zap.Logger) (*Queue, error) {
+	return NewQueue(Options{
+		Dir:         c.Dir,
+		MaxFileSize: c.MaxFileSize,
+		MaxSize:     c.MaxSize,
+		Scope:       scope,
+		Logger:      logger,
+	})
+}
+
+// FilterFn returns whether a failed write is spilled, writes that will fail
+// however many times they are replayed should not be.
+type FilterFn func(err error) bool
+
+type deadLetterStorage struct {
+	storage.Storage
+
+	queue       *Queue
+	filter      FilterFn
+	acknowledge bool
+	logger      *zap.Logger
+}
+
+// NewStorage returns a storage that spills the writes that fail with an
+// error passing the filter to the queue, all queries are passed through.
+// Spilled writes are acknowledged if acknowledge is set, otherwise their
+// error is returned as is.
+func NewStorage(
+	store storage.Storage,
+	queue *Queue,
+	filter FilterFn,
+	acknowledge bool,
+	logger *zap.Logger,
+) storage.Storage {
+	if filter == nil {
+		filter = func(error) bool { return true }
+	}
+	if logger == nil {
+		logger = zap.NewNop()
+	}
+	return &deadLetterStorage{
+		Storage:     store,
+		queue:       queue,
+		filter:      filter,
+		acknowledge: acknowledge,
+		logger:      logger,
+	}
+}
+
+func (s *deadLetterStorage) Write(
+	ctx context.Context,
+	query *storage.WriteQuery,
+) error {
+	err := s.Storage.Write(ctx, query)
+	if err == nil || !s.filter(err) {
+		return err
+	}
+
+	if spillErr := s.queue.Spill(query); spillErr != nil {
+		// Writes dropped while the queue is full are only counted rather
+		// than logged, since all writes are dropped during a long outage.
+		if spillErr != errQueueFull {
+			s.logger.Warn("unable to spill failed write to dead letter queue",
+				zap.NamedError("writeError", err), zap.Error(spillErr))
+		}
+		return err
+	}
+	if s.acknowledge {
+		return nil
+	}
+	return err
+}
@@ -0,0 +1,77 @@
+// Copyright (c) 2018 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package deadletter
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/m3db/m3/src/query/storage/mock"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+var (
+	errTestUnavailable = errors.New("failed to meet consistency level")
+	errTestTooPast     = errors.New("datapoint is too far in the past")
+)
+
+func testFilter(err error) bool {
+	return err == errTestUnavailable
+}
+
+func TestStorageSpillsFilteredWrites(t *testing.T) {
+	q, cleanup := newTestQueue(t, Options{})
+	defer cleanup()
+
+	underlying := mock.NewMockStorage()
+	store := NewStorage(underlying, q, testFilter, false, nil)
+
+	require.NoError(t, store.Write(context.Background(), newTestWrite(0)))
+
+	underlying.SetWriteResult(errTestUnavailable)
+	assert.Equal(t, errTestUnavailable, store.Write(context.Background(), newTestWrite(1)))
+
+	underlying.SetWriteResult(errTestTooPast)
+	assert.Equal(t, errTestTooPast, store.Write(context.Background(), newTestWrite(2)))
+
+	replayed := mock.NewMockStorage()
+	result, err := q.Replay(context.Background(), replayed)
+	require.NoError(t, err)
+	assert.Equal(t, ReplayResult{Files: 1, Replayed: 1}, result)
+	assertWritesEqual(t, newTestWrite(1), replayed.Writes()[0])
+}
+
+func TestStorageAcknowledgesSpilledWrites(t *testing.T) {
+	q, cleanup := newTestQueue(t, Options{MaxSize: 1})
+	defer cleanup()
+
+	underlying := mock.NewMockStorage()
+	underlying.SetWriteResult(errTestUnavailable)
+	store := NewStorage(underlying, q, testFilter, true, nil)
+
+	assert.NoError(t, store.Write(context.Background(), newTestWrite(0)))
+
+	// Writes that are not spilled since the queue is full fail.
+	assert.Equal(t, errTestUnavailable, store.Write(context.Background(), newTestWrite(1)))
+}
@@ -0,0 +1,256 @@
+// Copyright (c) 2018 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package storage
+
+import (
+	"math"
+	"time"
+
+	"github.com/m3db/m3/src/dbnode/encoding"
+	"github.com/m3db/m3/src/query/block"
+	"github.com/m3db/m3/src/query/errors"
+	"github.com/m3db/m3/src/query/models"
+	"github.com/m3db/m3/src/query/ts"
+	"github.com/m3db/m3x/ident"
+)
+
+// SeriesIteratorsToBlockResult converts series iterators holding compressed
+// blocks into coordinator blocks, series are only decoded when first
+// iterated by the executor rather than eagerly on fetch.
+func SeriesIteratorsToBlockResult(
+	seriesIterators encoding.SeriesIterators,
+	namespace ident.ID,
+	query *FetchQuery,
+) (block.Result, error) {
+	encodedBlock, err := newEncodedBlock(seriesIterators, namespace, query)
+	if err != nil {
+		seriesIterators.Close()
+		return block.Result{}, err
+	}
+
+	return block.Result{
+		Blocks: []block.Block{encodedBlock},
+	}, nil
+}
+
+type encodedBlock struct {
+	seriesIterators encoding.SeriesIterators
+	seriesMetas     []block.SeriesMeta
+	meta            block.Metadata
+	lookback        models.LookbackOptions
+	// decoded holds the step aligned values of each series once decoded,
+	// iterators can only be consumed once so values are retained for
+	// subsequent iterations.
+	decoded [][]float64
+}
+
+func newEncodedBlock(
+	seriesIterators encoding.SeriesIterators,
+	namespace ident.ID,
+	query *FetchQuery,
+) (*encodedBlock, error) {
+	if query.Interval <= 0 {
+		return nil, errors.ErrZeroInterval
+	}
+
+	iters := seriesIterators.Iters()
+	metas := make([]block.SeriesMeta, len(iters))
+	for i, iter := range iters {
+		ns := namespace
+		if ns == nil {
+			ns = iter.Namespace()
+		}
+		metric, err := FromM3IdentToMetric(ns, iter.ID(), iter.Tags())
+		if err != nil {
+			return nil, err
+		}
+		metas[i] = block.SeriesMeta{Tags: metric.Tags, Name: metric.ID}
+	}
+
+	return &encodedBlock{
+		seriesIterators: seriesIterators,
+		seriesMetas:     metas,
+		meta: block.Metadata{
+			Bounds: models.Bounds{
+				Start:    query.Start,
+				Duration: query.End.Sub(query.Start),
+				StepSize: query.Interval,
+			},
+		},
+		lookback: query.Lookback,
+		decoded:  make([][]float64, len(iters)),
+	}, nil
+}
+
+func (b *encodedBlock) Meta() block.Metadata {
+	return b.meta
+}
+
+func (b *encodedBlock) StepCount() int {
+	return b.meta.Bounds.Steps()
+}
+
+func (b *encodedBlock) SeriesMeta() []block.SeriesMeta {
+	return b.seriesMetas
+}
+
+// seriesValues decodes the series at the given index into step aligned values.
+func (b *encodedBlock) seriesValues(idx int) ([]float64, error) {
+	if values := b.decoded[idx]; values != nil {
+		return values, nil
+	}
+
+	iter := b.seriesIterators.Iters()[idx]
+	datapoints := make(ts.Datapoints, 0, initRawFetchAllocSize)
+	for iter.Next() {
+		dp, _, _ := iter.Current()
+		datapoints = append(datapoints, ts.Datapoint{Timestamp: dp.Timestamp, Value: dp.Value})
+	}
+	if err := iter.Err(); err != nil {
+		return nil, err
+	}
+
+	bounds := b.meta.Bounds
+	fixed, err := ts.RawPointsToFixedStepWithLookback(datapoints, bounds.Start,
+		bounds.End(), bounds.StepSize, b.lookback.Lookback(b.seriesMetas[idx].Tags))
+	if err != nil {
+		return nil, err
+	}
+
+	values := make([]float64, b.StepCount())
+	for i := range values {
+		if i < fixed.Len() {
+			values[i] = fixed.ValueAt(i)
+		} else {
+			values[i] = math.NaN()
+		}
+	}
+
+	b.decoded[idx] = values
+	return values, nil
+}
+
+func (b *encodedBlock) StepIter() (block.StepIter, error) {
+	return &encodedBlockStepIter{block: b, index: -1}, nil
+}
+
+func (b *encodedBlock) SeriesIter() (block.SeriesIter, error) {
+	return &encodedBlockSeriesIter{block: b, index: -1}, nil
+}
+
+func (b *encodedBlock) Close() error {
+	b.seriesIterators.Close()
+	b.decoded = nil
+	return nil
+}
+
+type encodedBlockStepIter struct {
+	block  *encodedBlock
+	index  int
+	values [][]float64
+	err    error
+}
+
+func (it *encodedBlockStepIter) SeriesMeta() []block.SeriesMeta {
+	return it.block.SeriesMeta()
+}
+
+func (it *encodedBlockStepIter) Meta() block.Metadata {
+	return it.block.Meta()
+}
+
+func (it *encodedBlockStepIter) StepCount() int {
+	return it.block.StepCount()
+}
+
+func (it *encodedBlockStepIter) Next() bool {
+	if it.err != nil || len(it.block.seriesMetas) == 0 {
+		return false
+	}
+
+	// NB: Each step requires a value from every series, so all series are
+	// decoded the first time a step is requested.
+	if it.values == nil {
+		values := make([][]float64, len(it.block.seriesMetas))
+		for i := range values {
+			values[i], it.err = it.block.seriesValues(i)
+			if it.err != nil {
+				return false
+			}
+		}
+		it.values = values
+	}
+
+	it.index++
+	return it.index < it.StepCount()
+}
+
+func (it *encodedBlockStepIter) Current() (block.Step, error) {
+	if it.err != nil {
+		return nil, it.err
+	}
+
+	values := make([]float64, len(it.values))
+	for i, seriesValues := range it.values {
+		values[i] = seriesValues[it.index]
+	}
+
+	bounds := it.block.meta.Bounds
+	t := bounds.Start.Add(time.Duration(it.index) * bounds.StepSize)
+	return block.NewColStep(t, values), nil
+}
+
+func (it *encodedBlockStepIter) Close() {
+}
+
+type encodedBlockSeriesIter struct {
+	block *encodedBlock
+	index int
+}
+
+func (it *encodedBlockSeriesIter) Meta() block.Metadata {
+	return it.block.Meta()
+}
+
+func (it *encodedBlockSeriesIter) SeriesMeta() []block.SeriesMeta {
+	return it.block.SeriesMeta()
+}
+
+func (it *encodedBlockSeriesIter) SeriesCount() int {
+	return len(it.block.seriesMetas)
+}
+
+func (it *encodedBlockSeriesIter) Next() bool {
+	it.index++
+	return it.index < it.SeriesCount()
+}
+
+func (it *encodedBlockSeriesIter) Current() (block.Series, error) {
+	values, err := it.block.seriesValues(it.index)
+	if err != nil {
+		return block.Series{}, err
+	}
+
+	return block.NewSeries(values, it.block.seriesMetas[it.index]), nil
+}
+
+func (it *encodedBlockSeriesIter) Close() {
+}
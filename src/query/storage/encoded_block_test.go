@@ -0,0 +1,168 @@
+// Copyright (c) 2018 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package storage
+
+import (
+	"math"
+	"testing"
+	"time"
+
+	"github.com/m3db/m3/src/dbnode/encoding"
+	m3ts "github.com/m3db/m3/src/dbnode/ts"
+	"github.com/m3db/m3/src/query/models"
+	"github.com/m3db/m3/src/query/test/seriesiter"
+	"github.com/m3db/m3x/ident"
+	xtime "github.com/m3db/m3x/time"
+
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func newTestEncodedSeriesIter(
+	ctrl *gomock.Controller,
+	id string,
+	start time.Time,
+	values []float64,
+) *encoding.MockSeriesIterator {
+	tags := seriesiter.GenerateSingleSampleTagIterator(ctrl, seriesiter.GenerateTag())
+	iter := encoding.NewMockSeriesIterator(ctrl)
+	iter.EXPECT().ID().Return(ident.StringID(id))
+	iter.EXPECT().Tags().Return(tags)
+	for i, v := range values {
+		iter.EXPECT().Next().Return(true)
+		iter.EXPECT().Current().Return(m3ts.Datapoint{
+			Timestamp: start.Add(time.Duration(i) * time.Minute),
+			Value:     v,
+		}, xtime.Second, nil)
+	}
+	iter.EXPECT().Next().Return(false)
+	iter.EXPECT().Err().Return(nil)
+	iter.EXPECT().Close().Do(func() {
+		tags.Close()
+	})
+	return iter
+}
+
+func newTestEncodedBlock(
+	t *testing.T,
+	ctrl *gomock.Controller,
+	start time.Time,
+) (*encodedBlock, *encoding.MockSeriesIterators) {
+	iterList := []encoding.SeriesIterator{
+		newTestEncodedSeriesIter(ctrl, "foo", start, []float64{1, 2}),
+		newTestEncodedSeriesIter(ctrl, "bar", start, []float64{3, 4, 5}),
+	}
+	iters := encoding.NewMockSeriesIterators(ctrl)
+	iters.EXPECT().Iters().Return(iterList).AnyTimes()
+	iters.EXPECT().Close().Do(func() {
+		for _, iter := range iterList {
+			iter.Close()
+		}
+	})
+
+	result, err := SeriesIteratorsToBlockResult(iters, ident.StringID("ns"), &FetchQuery{
+		Start:    start,
+		End:      start.Add(3 * time.Minute),
+		Interval: time.Minute,
+	})
+	require.NoError(t, err)
+	require.Len(t, result.Blocks, 1)
+
+	b, ok := result.Blocks[0].(*encodedBlock)
+	require.True(t, ok)
+	return b, iters
+}
+
+func TestEncodedBlockSeriesIterDecodesLazily(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	start := time.Now().Truncate(time.Hour)
+	b, _ := newTestEncodedBlock(t, ctrl, start)
+
+	expectedTags := models.Tags{{"foo", "bar"}}
+	metas := b.SeriesMeta()
+	require.Len(t, metas, 2)
+	assert.Equal(t, "foo", metas[0].Name)
+	assert.Equal(t, expectedTags, metas[0].Tags)
+	assert.Equal(t, "bar", metas[1].Name)
+
+	// Nothing should be decoded until the series are iterated.
+	for _, decoded := range b.decoded {
+		assert.Nil(t, decoded)
+	}
+
+	iter, err := b.SeriesIter()
+	require.NoError(t, err)
+	assert.Equal(t, 2, iter.SeriesCount())
+
+	require.True(t, iter.Next())
+	series, err := iter.Current()
+	require.NoError(t, err)
+	require.Equal(t, 3, series.Len())
+	assert.Equal(t, 1.0, series.ValueAtStep(0))
+	assert.Equal(t, 2.0, series.ValueAtStep(1))
+	assert.True(t, math.IsNaN(series.ValueAtStep(2)))
+
+	require.True(t, iter.Next())
+	series, err = iter.Current()
+	require.NoError(t, err)
+	assert.Equal(t, []float64{3, 4, 5}, series.Values())
+
+	assert.False(t, iter.Next())
+	require.NoError(t, b.Close())
+}
+
+func TestEncodedBlockStepIter(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	start := time.Now().Truncate(time.Hour)
+	b, _ := newTestEncodedBlock(t, ctrl, start)
+
+	iter, err := b.StepIter()
+	require.NoError(t, err)
+	assert.Equal(t, 3, iter.StepCount())
+
+	var steps [][]float64
+	for iter.Next() {
+		step, err := iter.Current()
+		require.NoError(t, err)
+		assert.Equal(t, start.Add(time.Duration(len(steps))*time.Minute), step.Time())
+		steps = append(steps, step.Values())
+	}
+
+	require.Len(t, steps, 3)
+	assert.Equal(t, []float64{1, 3}, steps[0])
+	assert.Equal(t, []float64{2, 4}, steps[1])
+	assert.True(t, math.IsNaN(steps[2][0]))
+	assert.Equal(t, 5.0, steps[2][1])
+
+	// Iterating again reuses the decoded values.
+	seriesIter, err := b.SeriesIter()
+	require.NoError(t, err)
+	require.True(t, seriesIter.Next())
+	series, err := seriesIter.Current()
+	require.NoError(t, err)
+	assert.Equal(t, 1.0, series.ValueAtStep(0))
+	require.NoError(t, b.Close())
+}
@@ -47,7 +47,7 @@ func NewStorage(stores []storage.Storage, fetchFilter filter.Storage, writeFilte
 }
 
 func (s *fanoutStorage) Fetch(ctx context.Context, query *storage.FetchQuery, options *storage.FetchOptions) (*storage.FetchResult, error) {
-	stores := filterStores(s.stores, s.fetchFilter, query)
+	stores := filterFetchStores(s.stores, s.fetchFilter, query, options)
 	requests := make([]execution.Request, len(stores))
 	for idx, store := range stores {
 		requests[idx] = newFetchRequest(store, query, options)
@@ -87,7 +87,7 @@ func handleFetchResponses(requests []execution.Request) (*storage.FetchResult, e
 func (s *fanoutStorage) FetchTags(ctx context.Context, query *storage.FetchQuery, options *storage.FetchOptions) (*storage.SearchResults, error) {
 	var metrics models.Metrics
 
-	stores := filterStores(s.stores, s.fetchFilter, query)
+	stores := filterFetchStores(s.stores, s.fetchFilter, query, options)
 	for _, store := range stores {
 		results, err := store.FetchTags(ctx, query, options)
 		if err != nil {
@@ -117,7 +117,7 @@ func (s *fanoutStorage) Type() storage.Type {
 
 func (s *fanoutStorage) FetchBlocks(
 	ctx context.Context, query *storage.FetchQuery, options *storage.FetchOptions) (block.Result, error) {
-	stores := filterStores(s.stores, s.writeFilter, query)
+	stores := filterFetchStores(s.stores, s.writeFilter, query, options)
 	blockResult := block.Result{}
 	for _, store := range stores {
 		result, err := store.FetchBlocks(ctx, query, options)
@@ -154,6 +154,28 @@ func filterStores(stores []storage.Storage, filterPolicy filter.Storage, query s
 	return filtered
 }
 
+// filterFetchStores filters the stores with the policy, leaving out the
+// stores of remote zones if the fetch is local only.
+func filterFetchStores(
+	stores []storage.Storage,
+	filterPolicy filter.Storage,
+	query storage.Query,
+	options *storage.FetchOptions,
+) []storage.Storage {
+	filtered := filterStores(stores, filterPolicy, query)
+	if options == nil || !options.FetchControls.LocalOnly {
+		return filtered
+	}
+
+	local := filtered[:0]
+	for _, s := range filtered {
+		if s.Type() == storage.TypeLocalDC {
+			local = append(local, s)
+		}
+	}
+	return local
+}
+
 type fetchRequest struct {
 	store   storage.Storage
 	query   *storage.FetchQuery
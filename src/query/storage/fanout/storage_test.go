@@ -28,8 +28,10 @@ import (
 
 	"github.com/m3db/m3/src/dbnode/encoding"
 	"github.com/m3db/m3/src/query/errors"
+	"github.com/m3db/m3/src/query/models"
 	"github.com/m3db/m3/src/query/policy/filter"
 	"github.com/m3db/m3/src/query/storage"
+	"github.com/m3db/m3/src/query/storage/mock"
 	"github.com/m3db/m3/src/query/test/local"
 	"github.com/m3db/m3/src/query/test/seriesiter"
 	"github.com/m3db/m3/src/query/ts"
@@ -96,8 +98,8 @@ func setupFanoutWrite(t *testing.T, output bool, errs ...error) storage.Storage
 	ctrl := gomock.NewController(t)
 	store1, session1 := local.NewStorageAndSession(t, ctrl)
 	store2, session2 := local.NewStorageAndSession(t, ctrl)
-	session1.EXPECT().WriteTagged(gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any()).Return(errs[0])
-	session2.EXPECT().WriteTagged(gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any()).Return(errs[len(errs)-1])
+	session1.EXPECT().WriteTaggedTraced(gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any()).Return(errs[0])
+	session2.EXPECT().WriteTaggedTraced(gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any()).Return(errs[len(errs)-1])
 	stores := []storage.Storage{
 		store1, store2,
 	}
@@ -169,3 +171,32 @@ func TestFanoutWriteSuccess(t *testing.T) {
 	})
 	assert.NoError(t, err)
 }
+
+func TestFanoutReadLocalOnly(t *testing.T) {
+	setup()
+	localStore := mock.NewMockStorage()
+	localStore.SetTypeResult(storage.TypeLocalDC)
+	localStore.SetFetchResult(&storage.FetchResult{
+		SeriesList: ts.SeriesList{ts.NewSeries("local", ts.Datapoints{}, nil)},
+	}, nil)
+	remoteStore := mock.NewMockStorage()
+	remoteStore.SetTypeResult(storage.TypeRemoteDC)
+	remoteStore.SetFetchResult(&storage.FetchResult{
+		SeriesList: ts.SeriesList{ts.NewSeries("remote", ts.Datapoints{}, nil)},
+	}, nil)
+
+	store := NewStorage([]storage.Storage{localStore, remoteStore},
+		filterFunc(true), filterFunc(true))
+	res, err := store.Fetch(context.TODO(), &storage.FetchQuery{}, &storage.FetchOptions{})
+	require.NoError(t, err)
+	assert.Len(t, res.SeriesList, 2)
+	assert.False(t, res.LocalOnly)
+
+	res, err = store.Fetch(context.TODO(), &storage.FetchQuery{}, &storage.FetchOptions{
+		FetchControls: models.FetchControls{LocalOnly: true},
+	})
+	require.NoError(t, err)
+	require.Len(t, res.SeriesList, 1)
+	assert.Equal(t, "local", res.SeriesList[0].Name())
+	assert.True(t, res.LocalOnly)
+}
@@ -79,7 +79,9 @@ func TagsToIdentTagIterator(tags models.Tags) ident.TagIterator {
 func FetchOptionsToM3Options(fetchOptions *FetchOptions, fetchQuery *FetchQuery) index.QueryOptions {
 	return index.QueryOptions{
 		Limit:          fetchOptions.Limit,
-		StartInclusive: fetchQuery.Start,
+		DocsLimit:      fetchOptions.FetchControls.DocsLimit,
+		BytesLimit:     fetchOptions.FetchControls.BytesLimit,
+		StartInclusive: fetchQuery.LookbackStart(),
 		EndExclusive:   fetchQuery.End,
 	}
 }
@@ -128,6 +130,12 @@ func matcherToQuery(matcher *models.Matcher) (idx.Query, error) {
 		}
 		return query, nil
 
+	case models.MatchEqualCaseInsensitive:
+		return idx.NewCaseInsensitiveTermQuery([]byte(matcher.Name), []byte(matcher.Value))
+
+	case models.MatchFuzzy:
+		return idx.NewFuzzyQuery([]byte(matcher.Name), []byte(matcher.Value), matcher.MaxEdits)
+
 	default:
 		return idx.Query{}, fmt.Errorf("unsupported query type: %v", matcher)
 	}
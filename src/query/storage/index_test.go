@@ -118,6 +118,29 @@ func TestFetchQueryToM3Query(t *testing.T) {
 				},
 			},
 		},
+		{
+			name:     "case insensitive match",
+			expected: "conjunction(regexp(t1, [vV]1))",
+			matchers: models.Matchers{
+				{
+					Type:  models.MatchEqualCaseInsensitive,
+					Name:  "t1",
+					Value: "v1",
+				},
+			},
+		},
+		{
+			name:     "fuzzy match",
+			expected: "conjunction(regexp(t1, v1|.v1|.1|1|v.1|v.|v|v1.))",
+			matchers: models.Matchers{
+				{
+					Type:     models.MatchFuzzy,
+					Name:     "t1",
+					Value:    "v1",
+					MaxEdits: 1,
+				},
+			},
+		},
 	}
 
 	for _, test := range tests {
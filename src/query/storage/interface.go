@@ -70,16 +70,27 @@ type FetchQuery struct {
 	Start       time.Time       `json:"start"`
 	End         time.Time       `json:"end"`
 	Interval    time.Duration   `json:"interval"`
+	// Lookback is the duration the last datapoint of each series is carried
+	// forward to later steps when the series are aligned to the interval.
+	Lookback models.LookbackOptions `json:"lookback"`
 }
 
 func (q *FetchQuery) String() string {
 	return q.Raw
 }
 
+// LookbackStart returns the start of the range of datapoints to fetch, which
+// precedes the start by the longest lookback so the first steps can be filled.
+func (q *FetchQuery) LookbackStart() time.Time {
+	return q.Start.Add(-1 * q.Lookback.Max())
+}
+
 // FetchOptions represents the options for fetch query
 type FetchOptions struct {
 	Limit    int
 	KillChan chan struct{}
+	// FetchControls are the consistency and fanout controls of the fetch
+	FetchControls models.FetchControls
 }
 
 // Querier handles queries against a storage.
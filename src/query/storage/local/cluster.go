@@ -58,6 +58,11 @@ type Clusters interface {
 	// AggregatedClusterNamespace returns an aggregated cluster namespace
 	// at a specific retention and resolution.
 	AggregatedClusterNamespace(attrs RetentionResolution) (ClusterNamespace, bool)
+
+	// AddAggregatedClusterNamespace adds an aggregated cluster namespace,
+	// returning the existing cluster namespace if one already exists at
+	// the same retention and resolution.
+	AddAggregatedClusterNamespace(def AggregatedClusterNamespaceDefinition) (ClusterNamespace, error)
 }
 
 // RetentionResolution is a tuple of retention and resolution that describes
@@ -174,6 +179,7 @@ func (def AggregatedClusterNamespaceDefinition) Validate() error {
 }
 
 type clusters struct {
+	sync.RWMutex
 	namespaces            []ClusterNamespace
 	unaggregatedNamespace ClusterNamespace
 	aggregatedNamespaces  map[RetentionResolution]ClusterNamespace
@@ -227,7 +233,10 @@ func NewClusters(
 }
 
 func (c *clusters) ClusterNamespaces() ClusterNamespaces {
-	return c.namespaces
+	c.RLock()
+	namespaces := c.namespaces
+	c.RUnlock()
+	return namespaces
 }
 
 func (c *clusters) UnaggregatedClusterNamespace() ClusterNamespace {
@@ -237,10 +246,37 @@ func (c *clusters) UnaggregatedClusterNamespace() ClusterNamespace {
 func (c *clusters) AggregatedClusterNamespace(
 	attrs RetentionResolution,
 ) (ClusterNamespace, bool) {
+	c.RLock()
 	namespace, ok := c.aggregatedNamespaces[attrs]
+	c.RUnlock()
 	return namespace, ok
 }
 
+func (c *clusters) AddAggregatedClusterNamespace(
+	def AggregatedClusterNamespaceDefinition,
+) (ClusterNamespace, error) {
+	namespace, err := newAggregatedClusterNamespace(def)
+	if err != nil {
+		return nil, err
+	}
+
+	key := RetentionResolution{
+		Retention:  def.Retention,
+		Resolution: def.Resolution,
+	}
+
+	c.Lock()
+	defer c.Unlock()
+
+	if existing, ok := c.aggregatedNamespaces[key]; ok {
+		return existing, nil
+	}
+
+	c.namespaces = append(c.namespaces, namespace)
+	c.aggregatedNamespaces[key] = namespace
+	return namespace, nil
+}
+
 func (c *clusters) Close() error {
 	var (
 		wg             sync.WaitGroup
@@ -249,6 +285,7 @@ func (c *clusters) Close() error {
 	)
 	// Collect unique sessions, some namespaces may share same
 	// client session (same cluster)
+	c.RLock()
 	uniqueSessions = append(uniqueSessions, c.unaggregatedNamespace.Session())
 	for _, namespace := range c.aggregatedNamespaces {
 		unique := true
@@ -262,6 +299,7 @@ func (c *clusters) Close() error {
 			uniqueSessions = append(uniqueSessions, namespace.Session())
 		}
 	}
+	c.RUnlock()
 
 	for _, session := range uniqueSessions {
 		session := session // Capture for lambda
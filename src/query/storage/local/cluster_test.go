@@ -61,6 +61,43 @@ func TestNewClustersWithDuplicateAggregatedClusterNamespace(t *testing.T) {
 		fmt.Sprintf("unexpected error: %s", err.Error()))
 }
 
+func TestClustersAddAggregatedClusterNamespace(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	clusters, err := NewClusters(UnaggregatedClusterNamespaceDefinition{
+		NamespaceID: ident.StringID("metrics_unagg"),
+		Session:     client.NewMockSession(ctrl),
+		Retention:   2 * 24 * time.Hour,
+	})
+	require.NoError(t, err)
+
+	def := AggregatedClusterNamespaceDefinition{
+		NamespaceID: ident.StringID("metrics_agg0"),
+		Session:     client.NewMockSession(ctrl),
+		Retention:   7 * 24 * time.Hour,
+		Resolution:  time.Minute,
+	}
+	added, err := clusters.AddAggregatedClusterNamespace(def)
+	require.NoError(t, err)
+	assert.Equal(t, "metrics_agg0", added.NamespaceID().String())
+
+	namespace, ok := clusters.AggregatedClusterNamespace(RetentionResolution{
+		Retention:  7 * 24 * time.Hour,
+		Resolution: time.Minute,
+	})
+	require.True(t, ok)
+	assert.True(t, added == namespace)
+	assert.Equal(t, 2, len(clusters.ClusterNamespaces()))
+
+	// Adding at the same retention and resolution returns the existing namespace
+	def.NamespaceID = ident.StringID("metrics_agg1")
+	existing, err := clusters.AddAggregatedClusterNamespace(def)
+	require.NoError(t, err)
+	assert.True(t, added == existing)
+	assert.Equal(t, 2, len(clusters.ClusterNamespaces()))
+}
+
 func TestNewClustersFromConfig(t *testing.T) {
 	ctrl := gomock.NewController(t)
 	defer ctrl.Finish()
@@ -0,0 +1,114 @@
+// Copyright (c) 2018 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package local
+
+import (
+	"context"
+	"sync"
+
+	nsproto "github.com/m3db/m3/src/dbnode/generated/proto/namespace"
+	"github.com/m3db/m3/src/dbnode/kvconfig"
+	"github.com/m3db/m3/src/dbnode/storage/namespace"
+	"github.com/m3db/m3/src/query/util/logging"
+	"github.com/m3db/m3cluster/kv"
+	"github.com/m3db/m3x/ident"
+
+	"go.uber.org/zap"
+)
+
+// NamespaceStates provides the lifecycle states of the database namespaces.
+type NamespaceStates interface {
+	// Writable returns whether the namespace accepts writes.
+	Writable(namespace ident.ID) bool
+
+	// Close stops watching for namespace state changes.
+	Close() error
+}
+
+type namespaceStates struct {
+	sync.RWMutex
+	watch  kv.ValueWatch
+	states map[string]namespace.State
+}
+
+// NewNamespaceStates returns namespace states kept up to date by watching
+// the namespace registry in the given KV store. Namespaces missing from the
+// registry are considered writable.
+func NewNamespaceStates(store kv.Store) (NamespaceStates, error) {
+	watch, err := store.Watch(kvconfig.NamespacesKey)
+	if err != nil {
+		return nil, err
+	}
+
+	s := &namespaceStates{
+		watch:  watch,
+		states: make(map[string]namespace.State),
+	}
+	go s.run()
+	return s, nil
+}
+
+func (s *namespaceStates) run() {
+	logger := logging.WithContext(context.Background())
+	for range s.watch.C() {
+		val := s.watch.Get()
+		if val == nil {
+			s.update(nil)
+			continue
+		}
+
+		var registry nsproto.Registry
+		if err := val.Unmarshal(&registry); err != nil {
+			logger.Error("unable to parse namespace registry", zap.Any("error", err))
+			continue
+		}
+
+		s.update(&registry)
+	}
+}
+
+func (s *namespaceStates) update(registry *nsproto.Registry) {
+	states := make(map[string]namespace.State)
+	if registry != nil {
+		for name, opts := range registry.Namespaces {
+			if opts == nil {
+				continue
+			}
+			states[name] = namespace.State(opts.State)
+		}
+	}
+
+	s.Lock()
+	s.states = states
+	s.Unlock()
+}
+
+func (s *namespaceStates) Writable(namespace ident.ID) bool {
+	s.RLock()
+	state, ok := s.states[namespace.String()]
+	s.RUnlock()
+	return !ok || state.Writable()
+}
+
+func (s *namespaceStates) Close() error {
+	s.watch.Close()
+	return nil
+}
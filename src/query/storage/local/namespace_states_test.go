@@ -0,0 +1,93 @@
+// Copyright (c) 2018 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package local
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	nsproto "github.com/m3db/m3/src/dbnode/generated/proto/namespace"
+	"github.com/m3db/m3/src/dbnode/kvconfig"
+	"github.com/m3db/m3cluster/kv/mem"
+	xclock "github.com/m3db/m3x/clock"
+	"github.com/m3db/m3x/ident"
+
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestNamespaceStatesWatch(t *testing.T) {
+	store := mem.NewStore()
+	states, err := NewNamespaceStates(store)
+	require.NoError(t, err)
+	defer states.Close()
+
+	// namespaces missing from the registry are writable
+	assert.True(t, states.Writable(ident.StringID("metrics")))
+
+	_, err = store.Set(kvconfig.NamespacesKey, &nsproto.Registry{
+		Namespaces: map[string]*nsproto.NamespaceOptions{
+			"metrics":  &nsproto.NamespaceOptions{},
+			"readonly": &nsproto.NamespaceOptions{State: nsproto.NamespaceState_READ_ONLY},
+			"deleting": &nsproto.NamespaceOptions{State: nsproto.NamespaceState_PENDING_DELETE},
+		},
+	})
+	require.NoError(t, err)
+
+	require.True(t, xclock.WaitUntil(func() bool {
+		return !states.Writable(ident.StringID("readonly"))
+	}, time.Second))
+	assert.True(t, states.Writable(ident.StringID("metrics")))
+	assert.False(t, states.Writable(ident.StringID("deleting")))
+}
+
+func TestLocalWriteNamespaceNotWritable(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	store, _ := setup(t, ctrl)
+	kvStore := mem.NewStore()
+	states, err := NewNamespaceStates(kvStore)
+	require.NoError(t, err)
+	defer states.Close()
+
+	_, err = kvStore.Set(kvconfig.NamespacesKey, &nsproto.Registry{
+		Namespaces: map[string]*nsproto.NamespaceOptions{
+			"metrics_unaggregated": &nsproto.NamespaceOptions{
+				State: nsproto.NamespaceState_READ_ONLY,
+			},
+		},
+	})
+	require.NoError(t, err)
+	require.True(t, xclock.WaitUntil(func() bool {
+		return !states.Writable(ident.StringID("metrics_unaggregated"))
+	}, time.Second))
+
+	// the session is a strict mock so any write reaching it fails the test
+	localStore := store.(*localStorage)
+	store = NewStorageWithOptions(localStore.clusters, nil,
+		Options{NamespaceStates: states})
+	err = store.Write(context.TODO(), newWriteQuery())
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), errNamespaceNotWritable.Error())
+}
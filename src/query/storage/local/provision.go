@@ -0,0 +1,250 @@
+// Copyright (c) 2018 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package local
+
+import (
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+
+	nsproto "github.com/m3db/m3/src/dbnode/generated/proto/namespace"
+	"github.com/m3db/m3/src/dbnode/kvconfig"
+	"github.com/m3db/m3/src/dbnode/storage/namespace"
+	"github.com/m3db/m3cluster/kv"
+	"github.com/m3db/m3x/ident"
+
+	"go.uber.org/zap"
+)
+
+const (
+	// DefaultProvisionedNamespacePrefix is the default prefix used to name
+	// provisioned aggregated namespaces.
+	DefaultProvisionedNamespacePrefix = "aggregated"
+
+	// provisionRetryInterval is how long to wait after failing to provision
+	// an aggregated namespace before trying again, so that every write for
+	// a storage policy that cannot be provisioned does not reach the KV store.
+	provisionRetryInterval = time.Minute
+)
+
+// AggregatedNamespaceProvisioner provisions the M3DB namespace that stores
+// aggregated metrics at a specific retention and resolution.
+type AggregatedNamespaceProvisioner interface {
+	// Provision ensures a namespace exists for the retention and resolution
+	// and returns its ID.
+	Provision(attrs RetentionResolution) (ident.ID, error)
+}
+
+type kvAggregatedNamespaceProvisioner struct {
+	store  kv.Store
+	prefix string
+}
+
+// NewKVAggregatedNamespaceProvisioner returns a provisioner that registers
+// aggregated namespaces with the dynamic namespace registry stored in KV.
+// Namespaces apply to every instance in the placement, so the nodes pick up
+// the new namespace without any change to the placement.
+func NewKVAggregatedNamespaceProvisioner(
+	store kv.Store,
+	prefix string,
+) AggregatedNamespaceProvisioner {
+	if prefix == "" {
+		prefix = DefaultProvisionedNamespacePrefix
+	}
+	return &kvAggregatedNamespaceProvisioner{
+		store:  store,
+		prefix: prefix,
+	}
+}
+
+func (p *kvAggregatedNamespaceProvisioner) Provision(
+	attrs RetentionResolution,
+) (ident.ID, error) {
+	id := ident.StringID(AggregatedNamespaceName(p.prefix, attrs))
+
+	var (
+		metadatas []namespace.Metadata
+		version   int
+	)
+	value, err := p.store.Get(kvconfig.NamespacesKey)
+	switch err {
+	case nil:
+		var registry nsproto.Registry
+		if err := value.Unmarshal(&registry); err != nil {
+			return nil, fmt.Errorf("unable to parse namespaces: %v", err)
+		}
+		nsMap, err := namespace.FromProto(registry)
+		if err != nil {
+			return nil, err
+		}
+		metadatas, version = nsMap.Metadatas(), value.Version()
+	case kv.ErrNotFound:
+		// No namespaces registered yet
+	default:
+		return nil, err
+	}
+
+	for _, md := range metadatas {
+		if !md.ID().Equal(id) {
+			continue
+		}
+		retention := md.Options().RetentionOptions().RetentionPeriod()
+		if retention != attrs.Retention {
+			return nil, fmt.Errorf(
+				"namespace %s already exists with retention %v, expected %v",
+				id.String(), retention, attrs.Retention)
+		}
+		return id, nil
+	}
+
+	blockSize := namespace.RecommendedBlockSize(attrs.Retention)
+	opts := namespace.NewOptions().SetRepairEnabled(false)
+	opts = opts.
+		SetRetentionOptions(opts.RetentionOptions().
+			SetRetentionPeriod(attrs.Retention).
+			SetBlockSize(blockSize)).
+		SetIndexOptions(opts.IndexOptions().
+			SetEnabled(true).
+			SetBlockSize(blockSize))
+
+	md, err := namespace.NewMetadata(id, opts)
+	if err != nil {
+		return nil, err
+	}
+
+	nsMap, err := namespace.NewMap(append(metadatas, md))
+	if err != nil {
+		return nil, err
+	}
+
+	_, err = p.store.CheckAndSet(kvconfig.NamespacesKey, version,
+		namespace.ToProto(nsMap))
+	if err != nil {
+		return nil, fmt.Errorf("failed to add namespace %s: %v", id.String(), err)
+	}
+
+	return id, nil
+}
+
+// AggregatedNamespaceName returns the name of the provisioned aggregated
+// namespace for a retention and resolution, i.e. "aggregated_1m_720h".
+func AggregatedNamespaceName(prefix string, attrs RetentionResolution) string {
+	return fmt.Sprintf("%s_%s_%s", prefix,
+		durationName(attrs.Resolution), durationName(attrs.Retention))
+}
+
+func durationName(d time.Duration) string {
+	// Trim zero trailing units, i.e. "720h0m0s" becomes "720h"
+	str := d.String()
+	if strings.HasSuffix(str, "m0s") {
+		str = str[:len(str)-2]
+	}
+	if strings.HasSuffix(str, "h0m") {
+		str = str[:len(str)-2]
+	}
+	return str
+}
+
+type provisioningClusters struct {
+	sync.Mutex
+	Clusters
+
+	provisioner AggregatedNamespaceProvisioner
+	logger      *zap.Logger
+	nowFn       func() time.Time
+	failedAt    map[RetentionResolution]time.Time
+}
+
+// NewProvisioningClusters returns clusters that provision an aggregated
+// cluster namespace on demand for a retention and resolution that has none,
+// such as one targeted by a rollup or mapping rule. Provisioned namespaces
+// are created in the same cluster as the unaggregated namespace. Writes to
+// a provisioned namespace may fail until the nodes have picked it up from
+// the namespace registry.
+func NewProvisioningClusters(
+	clusters Clusters,
+	provisioner AggregatedNamespaceProvisioner,
+	logger *zap.Logger,
+) Clusters {
+	return &provisioningClusters{
+		Clusters:    clusters,
+		provisioner: provisioner,
+		logger:      logger,
+		nowFn:       time.Now,
+		failedAt:    make(map[RetentionResolution]time.Time),
+	}
+}
+
+func (c *provisioningClusters) AggregatedClusterNamespace(
+	attrs RetentionResolution,
+) (ClusterNamespace, bool) {
+	if namespace, ok := c.Clusters.AggregatedClusterNamespace(attrs); ok {
+		return namespace, true
+	}
+
+	// Only take the lock when the namespace is missing so that writes to
+	// existing namespaces are not serialized
+	c.Lock()
+	defer c.Unlock()
+
+	if namespace, ok := c.Clusters.AggregatedClusterNamespace(attrs); ok {
+		return namespace, true
+	}
+
+	now := c.nowFn()
+	if failedAt, ok := c.failedAt[attrs]; ok && now.Sub(failedAt) < provisionRetryInterval {
+		return nil, false
+	}
+
+	namespace, err := c.provision(attrs)
+	if err != nil {
+		c.failedAt[attrs] = now
+		c.logger.Error("unable to provision aggregated namespace",
+			zap.Duration("retention", attrs.Retention),
+			zap.Duration("resolution", attrs.Resolution),
+			zap.Error(err))
+		return nil, false
+	}
+
+	delete(c.failedAt, attrs)
+	c.logger.Info("provisioned aggregated namespace",
+		zap.String("namespace", namespace.NamespaceID().String()),
+		zap.Duration("retention", attrs.Retention),
+		zap.Duration("resolution", attrs.Resolution))
+	return namespace, true
+}
+
+func (c *provisioningClusters) provision(
+	attrs RetentionResolution,
+) (ClusterNamespace, error) {
+	id, err := c.provisioner.Provision(attrs)
+	if err != nil {
+		return nil, err
+	}
+
+	return c.Clusters.AddAggregatedClusterNamespace(AggregatedClusterNamespaceDefinition{
+		NamespaceID: id,
+		Session:     c.Clusters.UnaggregatedClusterNamespace().Session(),
+		Retention:   attrs.Retention,
+		Resolution:  attrs.Resolution,
+	})
+}
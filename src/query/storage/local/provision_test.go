@@ -0,0 +1,199 @@
+// Copyright (c) 2018 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package local
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/m3db/m3/src/dbnode/client"
+	nsproto "github.com/m3db/m3/src/dbnode/generated/proto/namespace"
+	"github.com/m3db/m3/src/dbnode/kvconfig"
+	"github.com/m3db/m3/src/dbnode/storage/namespace"
+	"github.com/m3db/m3cluster/kv/mem"
+	"github.com/m3db/m3x/ident"
+
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+)
+
+func TestAggregatedNamespaceName(t *testing.T) {
+	tests := []struct {
+		attrs    RetentionResolution
+		expected string
+	}{
+		{
+			attrs:    RetentionResolution{Retention: 720 * time.Hour, Resolution: time.Minute},
+			expected: "aggregated_1m_720h",
+		},
+		{
+			attrs:    RetentionResolution{Retention: 36 * time.Hour, Resolution: 10 * time.Second},
+			expected: "aggregated_10s_36h",
+		},
+		{
+			attrs:    RetentionResolution{Retention: 90 * time.Minute, Resolution: 90 * time.Second},
+			expected: "aggregated_1m30s_1h30m",
+		},
+	}
+
+	for _, test := range tests {
+		assert.Equal(t, test.expected,
+			AggregatedNamespaceName(DefaultProvisionedNamespacePrefix, test.attrs))
+	}
+}
+
+func TestKVAggregatedNamespaceProvisionerProvision(t *testing.T) {
+	store := mem.NewStore()
+	provisioner := NewKVAggregatedNamespaceProvisioner(store, "")
+
+	attrs := RetentionResolution{Retention: 720 * time.Hour, Resolution: time.Minute}
+	id, err := provisioner.Provision(attrs)
+	require.NoError(t, err)
+	assert.Equal(t, "aggregated_1m_720h", id.String())
+
+	value, err := store.Get(kvconfig.NamespacesKey)
+	require.NoError(t, err)
+	version := value.Version()
+
+	var registry nsproto.Registry
+	require.NoError(t, value.Unmarshal(&registry))
+	nsMap, err := namespace.FromProto(registry)
+	require.NoError(t, err)
+
+	md, err := nsMap.Get(id)
+	require.NoError(t, err)
+	ropts := md.Options().RetentionOptions()
+	assert.Equal(t, attrs.Retention, ropts.RetentionPeriod())
+	assert.Equal(t, 12*time.Hour, ropts.BlockSize())
+	assert.True(t, md.Options().IndexOptions().Enabled())
+	assert.Equal(t, 12*time.Hour, md.Options().IndexOptions().BlockSize())
+
+	// Provisioning again should not modify the registry
+	id, err = provisioner.Provision(attrs)
+	require.NoError(t, err)
+	assert.Equal(t, "aggregated_1m_720h", id.String())
+
+	value, err = store.Get(kvconfig.NamespacesKey)
+	require.NoError(t, err)
+	assert.Equal(t, version, value.Version())
+}
+
+func TestKVAggregatedNamespaceProvisionerRetentionMismatch(t *testing.T) {
+	store := mem.NewStore()
+
+	opts := namespace.NewOptions()
+	opts = opts.SetRetentionOptions(opts.RetentionOptions().
+		SetRetentionPeriod(48 * time.Hour))
+	md, err := namespace.NewMetadata(ident.StringID("agg_1m_720h"), opts)
+	require.NoError(t, err)
+	nsMap, err := namespace.NewMap([]namespace.Metadata{md})
+	require.NoError(t, err)
+	_, err = store.Set(kvconfig.NamespacesKey, namespace.ToProto(nsMap))
+	require.NoError(t, err)
+
+	provisioner := NewKVAggregatedNamespaceProvisioner(store, "agg")
+	_, err = provisioner.Provision(RetentionResolution{
+		Retention:  720 * time.Hour,
+		Resolution: time.Minute,
+	})
+	require.Error(t, err)
+}
+
+type testProvisioner struct {
+	calls int
+	err   error
+}
+
+func (p *testProvisioner) Provision(attrs RetentionResolution) (ident.ID, error) {
+	p.calls++
+	if p.err != nil {
+		return nil, p.err
+	}
+	return ident.StringID(AggregatedNamespaceName("test", attrs)), nil
+}
+
+func newTestProvisioningClusters(
+	t *testing.T,
+	ctrl *gomock.Controller,
+	provisioner AggregatedNamespaceProvisioner,
+) (*provisioningClusters, client.Session) {
+	session := client.NewMockSession(ctrl)
+	clusters, err := NewClusters(UnaggregatedClusterNamespaceDefinition{
+		NamespaceID: ident.StringID("metrics_unagg"),
+		Session:     session,
+		Retention:   2 * 24 * time.Hour,
+	})
+	require.NoError(t, err)
+
+	result := NewProvisioningClusters(clusters, provisioner, zap.NewNop())
+	return result.(*provisioningClusters), session
+}
+
+func TestProvisioningClustersProvisionsMissingNamespace(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	provisioner := &testProvisioner{}
+	clusters, session := newTestProvisioningClusters(t, ctrl, provisioner)
+
+	attrs := RetentionResolution{Retention: 720 * time.Hour, Resolution: time.Minute}
+	namespace, ok := clusters.AggregatedClusterNamespace(attrs)
+	require.True(t, ok)
+	assert.Equal(t, "test_1m_720h", namespace.NamespaceID().String())
+	assert.Equal(t, session, namespace.Session())
+	assert.Equal(t, 1, provisioner.calls)
+	assert.Equal(t, 1, clusters.ClusterNamespaces().NumAggregatedClusterNamespaces())
+
+	// Subsequent lookups should use the added cluster namespace
+	_, ok = clusters.AggregatedClusterNamespace(attrs)
+	require.True(t, ok)
+	assert.Equal(t, 1, provisioner.calls)
+}
+
+func TestProvisioningClustersRetriesAfterInterval(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	provisioner := &testProvisioner{err: errors.New("an error")}
+	clusters, _ := newTestProvisioningClusters(t, ctrl, provisioner)
+
+	now := time.Now()
+	clusters.nowFn = func() time.Time { return now }
+
+	attrs := RetentionResolution{Retention: 720 * time.Hour, Resolution: time.Minute}
+	_, ok := clusters.AggregatedClusterNamespace(attrs)
+	require.False(t, ok)
+	assert.Equal(t, 1, provisioner.calls)
+
+	// Should not retry within the retry interval
+	_, ok = clusters.AggregatedClusterNamespace(attrs)
+	require.False(t, ok)
+	assert.Equal(t, 1, provisioner.calls)
+
+	now = now.Add(provisionRetryInterval)
+	provisioner.err = nil
+	_, ok = clusters.AggregatedClusterNamespace(attrs)
+	require.True(t, ok)
+	assert.Equal(t, 2, provisioner.calls)
+}
@@ -0,0 +1,154 @@
+// Copyright (c) 2018 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package local
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/m3db/m3/src/dbnode/storage/index"
+	"github.com/m3db/m3/src/query/models"
+	"github.com/m3db/m3x/ident"
+)
+
+var (
+	errShardKeyLabels = errors.New("labels do not match the shard key of the metric")
+)
+
+// ShardKeyConfiguration is the configuration of the shard key of a metric.
+type ShardKeyConfiguration struct {
+	// Metric is the name of the metric the shard key applies to.
+	Metric string `yaml:"metric" validate:"nonzero"`
+
+	// Labels are the complete set of label names of the series of the
+	// metric other than the metric name, such as the instance labels.
+	Labels []string `yaml:"labels" validate:"nonzero"`
+}
+
+// NewShardKeys returns the shard keys of the configurations.
+func NewShardKeys(cfgs []ShardKeyConfiguration) ShardKeys {
+	keys := make(ShardKeys, len(cfgs))
+	for _, cfg := range cfgs {
+		keys[cfg.Metric] = cfg.Labels
+	}
+	return keys
+}
+
+// ShardKeys are the sets of label names by metric name that are the complete
+// label sets of the series of the metric. Since the shard of a series is
+// derived from the ID of all of its labels, a query matching the metric name
+// and every label of its shard key by equality pins the ID of a single
+// series and so can only match the series of a single shard.
+type ShardKeys map[string][]string
+
+// Validate returns an error if the tags are of a metric with a shard key and
+// their labels are not exactly the labels of the key. A series with a label
+// outside of the key has a different ID, and so may be in a different shard,
+// than the series the key pins, so it is rejected rather than written where
+// queries routed by the key would not find it.
+func (k ShardKeys) Validate(tags models.Tags) error {
+	if len(k) == 0 {
+		return nil
+	}
+
+	metric, ok := tags.Get(models.MetricName)
+	if !ok {
+		return nil
+	}
+	key, ok := k[metric]
+	if !ok {
+		return nil
+	}
+
+	if len(tags) != len(key)+1 {
+		return fmt.Errorf("%v: %s", errShardKeyLabels, metric)
+	}
+	for _, name := range key {
+		if _, ok := tags.Get(name); !ok {
+			return fmt.Errorf("%v: %s", errShardKeyLabels, metric)
+		}
+	}
+	return nil
+}
+
+// Tags returns the tags of the series the matchers pin if they match the
+// metric name and every label of the shard key of the metric by equality
+// and no other label.
+func (k ShardKeys) Tags(matchers models.Matchers) (models.Tags, bool) {
+	if len(k) == 0 || len(matchers) == 0 {
+		return nil, false
+	}
+
+	values := make(map[string]string, len(matchers))
+	for _, matcher := range matchers {
+		if matcher.Type != models.MatchEqual {
+			return nil, false
+		}
+		if _, ok := values[matcher.Name]; ok {
+			return nil, false
+		}
+		values[matcher.Name] = matcher.Value
+	}
+
+	metric, ok := values[models.MetricName]
+	if !ok {
+		return nil, false
+	}
+	key, ok := k[metric]
+	if !ok {
+		return nil, false
+	}
+
+	delete(values, models.MetricName)
+	tags := make(models.Tags, 0, len(matchers))
+	tags = append(tags, models.Tag{Name: models.MetricName, Value: metric})
+	for _, name := range key {
+		value, ok := values[name]
+		if !ok {
+			return nil, false
+		}
+		delete(values, name)
+		tags = append(tags, models.Tag{Name: name, Value: value})
+	}
+	if len(values) != 0 {
+		// A label that is not in the shard key.
+		return nil, false
+	}
+	return models.Normalize(tags), true
+}
+
+// withShards returns the query options restricted to the shard of the
+// series ID in the namespace, or the options as is if the shard is unknown.
+func withShards(
+	namespace ClusterNamespace,
+	opts index.QueryOptions,
+	id ident.ID,
+) index.QueryOptions {
+	if id == nil {
+		return opts
+	}
+	shard, err := namespace.Session().ShardID(id)
+	if err != nil {
+		return opts
+	}
+	opts.Shards = []uint32{shard}
+	return opts
+}
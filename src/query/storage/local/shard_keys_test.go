@@ -0,0 +1,106 @@
+// Copyright (c) 2018 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package local
+
+import (
+	"testing"
+
+	"github.com/m3db/m3/src/query/models"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestShardKeysTags(t *testing.T) {
+	keys := NewShardKeys([]ShardKeyConfiguration{
+		{Metric: "up", Labels: []string{"instance", "job"}},
+		{Metric: "requests", Labels: []string{"instance", "job", "code"}},
+	})
+	equal := func(name, value string) models.Matcher {
+		return models.Matcher{Type: models.MatchEqual, Name: name, Value: value}
+	}
+
+	tags, ok := keys.Tags(models.Matchers{
+		equal("job", "api"), equal("instance", "host1"), equal("__name__", "up"),
+	})
+	require.True(t, ok)
+	assert.Equal(t, models.Tags{
+		{Name: "__name__", Value: "up"},
+		{Name: "instance", Value: "host1"},
+		{Name: "job", Value: "api"},
+	}, tags)
+
+	for _, matchers := range []models.Matchers{
+		// missing a label of the key
+		{equal("__name__", "up"), equal("instance", "host1")},
+		// a label that is not in the key
+		{equal("__name__", "up"), equal("instance", "host1"), equal("job", "api"),
+			equal("dc", "east")},
+		// the labels of the key of another metric
+		{equal("__name__", "requests"), equal("instance", "host1"), equal("job", "api")},
+		// a metric without a key
+		{equal("__name__", "down"), equal("instance", "host1"), equal("job", "api")},
+		// no metric name
+		{equal("instance", "host1"), equal("job", "api")},
+		// not an equality matcher
+		{equal("__name__", "up"), equal("job", "api"),
+			{Type: models.MatchRegexp, Name: "instance", Value: "host.*"}},
+		// the same label twice
+		{equal("__name__", "up"), equal("instance", "host1"), equal("instance", "host2")},
+	} {
+		_, ok := keys.Tags(matchers)
+		assert.False(t, ok, matchers.String())
+	}
+
+	_, ok = ShardKeys(nil).Tags(models.Matchers{equal("__name__", "up")})
+	assert.False(t, ok)
+}
+
+func TestShardKeysValidate(t *testing.T) {
+	keys := NewShardKeys([]ShardKeyConfiguration{
+		{Metric: "up", Labels: []string{"instance", "job"}},
+	})
+
+	assert.NoError(t, keys.Validate(models.FromMap(map[string]string{
+		"__name__": "up", "instance": "host1", "job": "api",
+	})))
+	// metrics without a key and series without a metric name are not keyed
+	assert.NoError(t, keys.Validate(models.FromMap(map[string]string{
+		"__name__": "down", "instance": "host1", "dc": "east",
+	})))
+	assert.NoError(t, keys.Validate(models.FromMap(map[string]string{
+		"instance": "host1", "dc": "east",
+	})))
+	assert.NoError(t, ShardKeys(nil).Validate(models.FromMap(map[string]string{
+		"__name__": "up", "dc": "east",
+	})))
+
+	for _, tags := range []map[string]string{
+		// a label that is not in the key
+		{"__name__": "up", "instance": "host1", "job": "api", "dc": "east"},
+		// missing a label of the key
+		{"__name__": "up", "instance": "host1"},
+		// a label of the key replaced by another label
+		{"__name__": "up", "instance": "host1", "dc": "east"},
+	} {
+		assert.Error(t, keys.Validate(models.FromMap(tags)), "%v", tags)
+	}
+}
@@ -27,30 +27,83 @@ import (
 	"sync"
 	"time"
 
+	"github.com/m3db/m3/src/dbnode/client"
+	"github.com/m3db/m3/src/dbnode/encoding"
 	"github.com/m3db/m3/src/dbnode/storage/index"
 	"github.com/m3db/m3/src/query/block"
 	"github.com/m3db/m3/src/query/errors"
 	"github.com/m3db/m3/src/query/models"
 	"github.com/m3db/m3/src/query/storage"
+	"github.com/m3db/m3/src/query/storage/access"
 	"github.com/m3db/m3/src/query/util/execution"
+	"github.com/m3db/m3/src/query/util/querystats"
 	xerrors "github.com/m3db/m3x/errors"
 	"github.com/m3db/m3x/ident"
 	"github.com/m3db/m3x/pool"
 	xtime "github.com/m3db/m3x/time"
+
+	opentracing "github.com/opentracing/opentracing-go"
+)
+
+const (
+	// limitHitSuffix suffixes the type of the limit recorded in the
+	// statistics of a query when the results of a namespace exceed it,
+	// e.g. series-limit.
+	limitHitSuffix = "-limit"
 )
 
 var (
 	errNoLocalClustersFulfillsQuery = goerrors.New("no clusters can fulfill query")
+	errNamespaceNotWritable         = goerrors.New("namespace is not writable")
 )
 
 type localStorage struct {
-	clusters   Clusters
-	workerPool pool.ObjectPool
+	clusters        Clusters
+	workerPool      pool.ObjectPool
+	namespaceStates NamespaceStates
+	accessPolicies  *access.Policies
+	shardKeys       ShardKeys
+	fetchPageSize   int
+}
+
+// Options are the options of a local storage.
+type Options struct {
+	// NamespaceStates rejects writes to namespaces which are not writable
+	// if set.
+	NamespaceStates NamespaceStates
+	// AccessPolicies restricts reads from and writes to the namespaces the
+	// principal of a request is granted access to if set.
+	AccessPolicies *access.Policies
+	// ShardKeys fetches the series pinned by the equality matchers of a
+	// query from the nodes owning its shard only, and rejects writes of the
+	// series of a metric with a shard key with labels other than the key.
+	ShardKeys ShardKeys
+	// FetchPageSize fetches the series of a query from the nodes a page of
+	// at most FetchPageSize series at a time when positive, bounding the
+	// memory each node holds for a request. Each page is decoded before the
+	// next is fetched so that only a page of series is held compressed.
+	FetchPageSize int
 }
 
 // NewStorage creates a new local Storage instance.
 func NewStorage(clusters Clusters, workerPool pool.ObjectPool) storage.Storage {
-	return &localStorage{clusters: clusters, workerPool: workerPool}
+	return NewStorageWithOptions(clusters, workerPool, Options{})
+}
+
+// NewStorageWithOptions creates a new local Storage instance with options.
+func NewStorageWithOptions(
+	clusters Clusters,
+	workerPool pool.ObjectPool,
+	opts Options,
+) storage.Storage {
+	return &localStorage{
+		clusters:        clusters,
+		workerPool:      workerPool,
+		namespaceStates: opts.NamespaceStates,
+		accessPolicies:  opts.AccessPolicies,
+		shardKeys:       opts.ShardKeys,
+		fetchPageSize:   opts.FetchPageSize,
+	}
 }
 
 func (s *localStorage) Fetch(ctx context.Context, query *storage.FetchQuery, options *storage.FetchOptions) (*storage.FetchResult, error) {
@@ -63,7 +116,7 @@ func (s *localStorage) Fetch(ctx context.Context, query *storage.FetchQuery, opt
 	default:
 	}
 
-	m3query, err := storage.FetchQueryToM3Query(query)
+	m3query, shardKeyID, err := s.fetchQuery(query)
 	if err != nil {
 		return nil, err
 	}
@@ -73,10 +126,12 @@ func (s *localStorage) Fetch(ctx context.Context, query *storage.FetchQuery, opt
 	// highest resolution (most fine grained) results.
 	// This needs to be optimized, however this is a start.
 	var (
-		opts       = storage.FetchOptionsToM3Options(options, query)
+		opts       = s.queryOptions(options, query)
 		namespaces = s.clusters.ClusterNamespaces()
 		now        = time.Now()
+		principal  = access.PrincipalFromContext(ctx)
 		fetches    = 0
+		denied     = 0
 		result     multiFetchResult
 		wg         sync.WaitGroup
 	)
@@ -90,17 +145,28 @@ func (s *localStorage) Fetch(ctx context.Context, query *storage.FetchQuery, opt
 			continue
 		}
 
+		// Namespaces the principal is not granted access to are left out
+		// of the results rather than failing the query
+		if !s.accessPolicies.Readable(principal, namespace.NamespaceID().String()) {
+			denied++
+			continue
+		}
+
 		fetches++
 
 		wg.Add(1)
 		go func() {
-			r, err := s.fetch(namespace, m3query, opts)
+			nsOpts := withShards(namespace, opts, shardKeyID)
+			r, err := s.fetch(ctx, namespace, m3query, nsOpts, options)
 			result.add(namespace.Options().Attributes(), r, err)
 			wg.Done()
 		}()
 	}
 
 	if fetches == 0 {
+		if denied > 0 {
+			return nil, access.ErrAccessDenied
+		}
 		return nil, errNoLocalClustersFulfillsQuery
 	}
 
@@ -112,20 +178,42 @@ func (s *localStorage) Fetch(ctx context.Context, query *storage.FetchQuery, opt
 }
 
 func (s *localStorage) fetch(
+	ctx context.Context,
 	namespace ClusterNamespace,
 	query index.Query,
 	opts index.QueryOptions,
+	options *storage.FetchOptions,
 ) (*storage.FetchResult, error) {
 	namespaceID := namespace.NamespaceID()
-	session := namespace.Session()
+	session := readSession(namespace, options)
 
-	// TODO (nikunj): Handle second return param
-	iters, _, err := session.FetchTagged(namespaceID, query, opts)
+	opts = withReplicaVerification(ctx, namespace, opts, options)
+	opts = withLimitStats(ctx, namespace, opts)
+	if opts.PageSize <= 0 {
+		iters, _, err := session.FetchTagged(namespaceID, query, opts)
+		if err != nil {
+			return nil, err
+		}
+
+		return storage.SeriesIteratorsToFetchResult(iters, namespaceID, s.workerPool)
+	}
+
+	// NB: each page is decompressed and its iterators closed before the
+	// next page is fetched, so at most a page of series is held compressed.
+	result := &storage.FetchResult{}
+	_, err := session.FetchTaggedPages(namespaceID, query, opts,
+		func(page encoding.SeriesIterators) error {
+			pageResult, err := storage.SeriesIteratorsToFetchResult(page, namespaceID, s.workerPool)
+			if err != nil {
+				return err
+			}
+			result.SeriesList = append(result.SeriesList, pageResult.SeriesList...)
+			return nil
+		})
 	if err != nil {
 		return nil, err
 	}
-
-	return storage.SeriesIteratorsToFetchResult(iters, namespaceID, s.workerPool)
+	return result, nil
 }
 
 func (s *localStorage) FetchTags(ctx context.Context, query *storage.FetchQuery, options *storage.FetchOptions) (*storage.SearchResults, error) {
@@ -138,16 +226,18 @@ func (s *localStorage) FetchTags(ctx context.Context, query *storage.FetchQuery,
 	default:
 	}
 
-	m3query, err := storage.FetchQueryToM3Query(query)
+	m3query, shardKeyID, err := s.fetchQuery(query)
 	if err != nil {
 		return nil, err
 	}
 
 	var (
-		opts       = storage.FetchOptionsToM3Options(options, query)
+		opts       = s.queryOptions(options, query)
 		namespaces = s.clusters.ClusterNamespaces()
 		now        = time.Now()
+		principal  = access.PrincipalFromContext(ctx)
 		fetches    = 0
+		denied     = 0
 		result     multiFetchTagsResult
 		wg         sync.WaitGroup
 	)
@@ -161,16 +251,27 @@ func (s *localStorage) FetchTags(ctx context.Context, query *storage.FetchQuery,
 			continue
 		}
 
+		// Namespaces the principal is not granted access to are left out
+		// of the results rather than failing the query
+		if !s.accessPolicies.Readable(principal, namespace.NamespaceID().String()) {
+			denied++
+			continue
+		}
+
 		fetches++
 
 		wg.Add(1)
 		go func() {
-			result.add(s.fetchTags(namespace, m3query, opts))
+			nsOpts := withShards(namespace, opts, shardKeyID)
+			result.add(s.fetchTags(ctx, namespace, m3query, nsOpts, options))
 			wg.Done()
 		}()
 	}
 
 	if fetches == 0 {
+		if denied > 0 {
+			return nil, access.ErrAccessDenied
+		}
 		return nil, errNoLocalClustersFulfillsQuery
 	}
 
@@ -182,38 +283,62 @@ func (s *localStorage) FetchTags(ctx context.Context, query *storage.FetchQuery,
 }
 
 func (s *localStorage) fetchTags(
+	ctx context.Context,
 	namespace ClusterNamespace,
 	query index.Query,
 	opts index.QueryOptions,
+	options *storage.FetchOptions,
 ) (*storage.SearchResults, error) {
 	namespaceID := namespace.NamespaceID()
-	session := namespace.Session()
+	session := readSession(namespace, options)
 
-	// TODO (juchan): Handle second return param
-	iter, _, err := session.FetchTaggedIDs(namespaceID, query, opts)
-	if err != nil {
-		return nil, err
-	}
+	opts = withLimitStats(ctx, namespace, opts)
 
 	var metrics models.Metrics
-	for iter.Next() {
-		m, err := storage.FromM3IdentToMetric(iter.Current())
+	if opts.PageSize <= 0 {
+		iter, _, err := session.FetchTaggedIDs(namespaceID, query, opts)
 		if err != nil {
 			return nil, err
 		}
 
-		metrics = append(metrics, m)
-	}
-	if err := iter.Err(); err != nil {
-		return nil, err
+		metrics, err = appendMetrics(metrics, iter)
+		iter.Finalize()
+		if err != nil {
+			return nil, err
+		}
+	} else {
+		_, err := session.FetchTaggedIDsPages(namespaceID, query, opts,
+			func(page client.TaggedIDsIterator) error {
+				var err error
+				metrics, err = appendMetrics(metrics, page)
+				return err
+			})
+		if err != nil {
+			return nil, err
+		}
 	}
-	iter.Finalize()
 
 	return &storage.SearchResults{
 		Metrics: metrics,
 	}, nil
 }
 
+// appendMetrics appends the metrics of the tagged IDs to the metrics.
+func appendMetrics(
+	metrics models.Metrics,
+	iter client.TaggedIDsIterator,
+) (models.Metrics, error) {
+	for iter.Next() {
+		m, err := storage.FromM3IdentToMetric(iter.Current())
+		if err != nil {
+			return nil, err
+		}
+
+		metrics = append(metrics, m)
+	}
+	return metrics, iter.Err()
+}
+
 func (s *localStorage) Write(ctx context.Context, query *storage.WriteQuery) error {
 	// Check if the query was interrupted.
 	select {
@@ -225,10 +350,14 @@ func (s *localStorage) Write(ctx context.Context, query *storage.WriteQuery) err
 	if query == nil {
 		return errors.ErrNilWriteQuery
 	}
+	if err := s.shardKeys.Validate(query.Tags); err != nil {
+		return err
+	}
 
 	id := query.Tags.ID()
 	common := &writeRequestCommon{
 		store:       s,
+		principal:   access.PrincipalFromContext(ctx),
 		annotation:  query.Annotation,
 		unit:        query.Unit,
 		id:          id,
@@ -251,6 +380,17 @@ func (s *localStorage) FetchBlocks(
 	ctx context.Context,
 	query *storage.FetchQuery,
 	options *storage.FetchOptions) (block.Result, error) {
+	// NB: When a single cluster can fulfill the range there is no need to
+	// merge results across clusters, so the compressed blocks are handed
+	// to the executor as is and only decoded as they are iterated. The
+	// compressed blocks hold the series of every page until then, so
+	// paginated fetches decode each page as it is fetched instead.
+	if s.fetchPageSize <= 0 {
+		if namespace, ok := s.singleNamespaceFulfilling(ctx, query); ok {
+			return s.fetchBlocksCompressed(ctx, namespace, query, options)
+		}
+	}
+
 	fetchResult, err := s.Fetch(ctx, query, options)
 	if err != nil {
 		return block.Result{}, err
@@ -264,6 +404,144 @@ func (s *localStorage) FetchBlocks(
 	return res, nil
 }
 
+func (s *localStorage) singleNamespaceFulfilling(
+	ctx context.Context,
+	query *storage.FetchQuery,
+) (ClusterNamespace, bool) {
+	var (
+		now       = time.Now()
+		principal = access.PrincipalFromContext(ctx)
+		fulfilled ClusterNamespace
+		count     int
+	)
+	for _, namespace := range s.clusters.ClusterNamespaces() {
+		clusterStart := now.Add(-1 * namespace.Options().Attributes().Retention)
+		if clusterStart.After(query.Start) {
+			continue
+		}
+		if !s.accessPolicies.Readable(principal, namespace.NamespaceID().String()) {
+			continue
+		}
+		fulfilled = namespace
+		count++
+	}
+	return fulfilled, count == 1
+}
+
+func (s *localStorage) fetchBlocksCompressed(
+	ctx context.Context,
+	namespace ClusterNamespace,
+	query *storage.FetchQuery,
+	options *storage.FetchOptions,
+) (block.Result, error) {
+	// Check if the query was interrupted.
+	select {
+	case <-ctx.Done():
+		return block.Result{}, ctx.Err()
+	case <-options.KillChan:
+		return block.Result{}, errors.ErrQueryInterrupted
+	default:
+	}
+
+	m3query, shardKeyID, err := s.fetchQuery(query)
+	if err != nil {
+		return block.Result{}, err
+	}
+
+	var (
+		opts        = s.queryOptions(options, query)
+		namespaceID = namespace.NamespaceID()
+	)
+	opts = withShards(namespace, opts, shardKeyID)
+	opts = withReplicaVerification(ctx, namespace, opts, options)
+	opts = withLimitStats(ctx, namespace, opts)
+	iters, _, err := readSession(namespace, options).FetchTagged(namespaceID, m3query, opts)
+	if err != nil {
+		return block.Result{}, err
+	}
+
+	return storage.SeriesIteratorsToBlockResult(iters, namespaceID, query)
+}
+
+// queryOptions returns the index query options of the fetch options,
+// paginated by the fetch page size.
+func (s *localStorage) queryOptions(
+	options *storage.FetchOptions,
+	query *storage.FetchQuery,
+) index.QueryOptions {
+	opts := storage.FetchOptionsToM3Options(options, query)
+	opts.PageSize = s.fetchPageSize
+	return opts
+}
+
+// fetchQuery returns the index query of the fetch query. If the equality
+// matchers of the query pin the ID of a single series with a shard key, the
+// ID is returned so the fetch is restricted to the nodes owning its shard,
+// otherwise the ID is nil and the fetch fans out to every node. The index
+// query always keeps the matchers of the query, the ID only routes it.
+func (s *localStorage) fetchQuery(
+	query *storage.FetchQuery,
+) (index.Query, ident.ID, error) {
+	q, err := storage.FetchQueryToM3Query(query)
+	if err != nil {
+		return index.Query{}, nil, err
+	}
+
+	if tags, ok := s.shardKeys.Tags(query.TagMatchers); ok {
+		return q, ident.StringID(tags.ID()), nil
+	}
+	return q, nil, nil
+}
+
+// readSession returns the session of the namespace, with the read consistency
+// level of the fetch options if set.
+func readSession(namespace ClusterNamespace, options *storage.FetchOptions) client.Session {
+	session := namespace.Session()
+	if level := options.FetchControls.ReadConsistencyLevel; level != nil {
+		return session.WithReadConsistencyLevel(*level)
+	}
+	return session
+}
+
+// withLimitStats returns the query options with the limit that caused the
+// results of the namespace to not be exhaustive recorded as a limit hit and
+// a warning of the query.
+func withLimitStats(
+	ctx context.Context,
+	namespace ClusterNamespace,
+	opts index.QueryOptions,
+) index.QueryOptions {
+	namespaceID := namespace.NamespaceID().String()
+	opts.OnLimitExceeded = func(limit index.QueryLimitType) {
+		querystats.AddLimitHit(ctx, limit.String()+limitHitSuffix)
+		querystats.AddWarning(ctx, fmt.Sprintf(
+			"results not exhaustive: namespace=%s, limit=%s", namespaceID, limit))
+	}
+	return opts
+}
+
+// withReplicaVerification returns the query options with the blocks of the
+// fetched series compared between replicas if the fetch options verify
+// replicas, each block that differs is recorded as a warning of the query.
+func withReplicaVerification(
+	ctx context.Context,
+	namespace ClusterNamespace,
+	opts index.QueryOptions,
+	options *storage.FetchOptions,
+) index.QueryOptions {
+	if !options.FetchControls.VerifyReplicas {
+		return opts
+	}
+	namespaceID := namespace.NamespaceID().String()
+	opts.OnReplicaDivergence = func(d index.ReplicaDivergence) {
+		querystats.AddReplicaDivergence(ctx)
+		querystats.AddWarning(ctx, fmt.Sprintf(
+			"replica divergence: namespace=%s, series=%s, block=%s",
+			namespaceID, d.ID, d.BlockStart.UTC().Format(time.RFC3339)))
+	}
+	return opts
+}
+
 func (s *localStorage) Close() error {
 	return nil
 }
@@ -301,13 +579,26 @@ func (w *writeRequest) Process(ctx context.Context) error {
 	}
 
 	namespaceID := namespace.NamespaceID()
+	if states := store.namespaceStates; states != nil && !states.Writable(namespaceID) {
+		return fmt.Errorf("%v: %s", errNamespaceNotWritable, namespaceID.String())
+	}
+	if !store.accessPolicies.Writable(common.principal, namespaceID.String()) {
+		return fmt.Errorf("%v: %s", access.ErrAccessDenied, namespaceID.String())
+	}
+
+	var spanCtx opentracing.SpanContext
+	if span := opentracing.SpanFromContext(ctx); span != nil {
+		spanCtx = span.Context()
+	}
+
 	session := namespace.Session()
-	return session.WriteTagged(namespaceID, id, common.tagIterator,
+	return session.WriteTaggedTraced(spanCtx, namespaceID, id, common.tagIterator,
 		w.timestamp, w.value, common.unit, common.annotation)
 }
 
 type writeRequestCommon struct {
 	store       *localStorage
+	principal   access.Principal
 	annotation  []byte
 	unit        xtime.Unit
 	id          string
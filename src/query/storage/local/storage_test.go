@@ -24,16 +24,23 @@ import (
 	"context"
 	"fmt"
 	"math"
+	"sort"
 	"strings"
 	"testing"
 	"time"
 
 	"github.com/m3db/m3/src/dbnode/client"
+	"github.com/m3db/m3/src/dbnode/encoding"
+	"github.com/m3db/m3/src/dbnode/storage/index"
+	"github.com/m3db/m3/src/dbnode/topology"
 	"github.com/m3db/m3/src/query/models"
 	"github.com/m3db/m3/src/query/storage"
+	"github.com/m3db/m3/src/query/storage/access"
+	"github.com/m3db/m3/src/query/storage/tenant"
 	"github.com/m3db/m3/src/query/test/seriesiter"
 	"github.com/m3db/m3/src/query/ts"
 	"github.com/m3db/m3/src/query/util/logging"
+	"github.com/m3db/m3/src/query/util/querystats"
 	"github.com/m3db/m3x/ident"
 	xtime "github.com/m3db/m3x/time"
 
@@ -125,7 +132,7 @@ func newWriteQuery() *storage.WriteQuery {
 func setupLocalWrite(t *testing.T, ctrl *gomock.Controller) storage.Storage {
 	store, sessions := setup(t, ctrl)
 	session := sessions.unaggregated1MonthRetention
-	session.EXPECT().WriteTagged(gomock.Any(), gomock.Any(), gomock.Any(),
+	session.EXPECT().WriteTaggedTraced(gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any(),
 		gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any()).AnyTimes()
 	return store
 }
@@ -194,7 +201,7 @@ func TestLocalWriteAggregatedSuccess(t *testing.T) {
 	}
 
 	session := sessions.aggregated1MonthRetention1MinuteResolution
-	session.EXPECT().WriteTagged(gomock.Any(), gomock.Any(), gomock.Any(),
+	session.EXPECT().WriteTaggedTraced(gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any(),
 		gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any()).Times(len(writeQuery.Datapoints))
 
 	err := store.Write(context.TODO(), writeQuery)
@@ -223,6 +230,206 @@ func TestLocalRead(t *testing.T) {
 	assert.Equal(t, models.FromMap(tags), results.SeriesList[0].Tags)
 }
 
+func TestLocalReadWithReadConsistencyLevel(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+	store, sessions := setup(t, ctrl)
+	testTags := seriesiter.GenerateTag()
+	level := topology.ReadConsistencyLevelOne
+	sessions.forEach(func(session *client.MockSession) {
+		view := client.NewMockSession(ctrl)
+		session.EXPECT().WithReadConsistencyLevel(level).Return(view)
+		view.EXPECT().FetchTagged(gomock.Any(), gomock.Any(), gomock.Any()).
+			Return(seriesiter.NewMockSeriesIters(ctrl, testTags, 1, 2), true, nil)
+	})
+
+	results, err := store.Fetch(context.TODO(), newFetchReq(), &storage.FetchOptions{
+		Limit:         100,
+		FetchControls: models.FetchControls{ReadConsistencyLevel: &level},
+	})
+	require.NoError(t, err)
+	require.Len(t, results.SeriesList, 1)
+}
+
+func TestLocalReadPrunesToShardKeyShard(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+	store, sessions := setup(t, ctrl)
+	localStore := store.(*localStorage)
+	store = NewStorageWithOptions(localStore.clusters, nil, Options{
+		ShardKeys: ShardKeys{"up": {"foo", "biz"}},
+	})
+
+	const id = "__name__=up,biz=baz,foo=bar,"
+	query := newFetchReq()
+	query.TagMatchers = append(query.TagMatchers, models.Matcher{
+		Type:  models.MatchEqual,
+		Name:  models.MetricName,
+		Value: "up",
+	})
+
+	testTags := seriesiter.GenerateTag()
+	sessions.forEach(func(session *client.MockSession) {
+		session.EXPECT().ShardID(ident.NewIDMatcher(id)).Return(uint32(7), nil)
+		session.EXPECT().FetchTagged(gomock.Any(), gomock.Any(), gomock.Any()).
+			DoAndReturn(func(_ ident.ID, q index.Query, opts index.QueryOptions) (
+				encoding.SeriesIterators, bool, error) {
+				// The matchers of the query are kept, only the shards
+				// the query is routed to are restricted.
+				expected, err := storage.FetchQueryToM3Query(query)
+				require.NoError(t, err)
+				assert.True(t, expected.Query.Equal(q.Query))
+				assert.Equal(t, []uint32{7}, opts.Shards)
+				return seriesiter.NewMockSeriesIters(ctrl, testTags, 1, 2), true, nil
+			})
+	})
+
+	results, err := store.Fetch(context.TODO(), query, &storage.FetchOptions{Limit: 100})
+	require.NoError(t, err)
+	require.Len(t, results.SeriesList, 1)
+}
+
+func TestLocalReadFansOutWithoutShardKey(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+	store, sessions := setup(t, ctrl)
+	localStore := store.(*localStorage)
+	store = NewStorageWithOptions(localStore.clusters, nil, Options{
+		ShardKeys: ShardKeys{"up": {"foo", "biz"}},
+	})
+
+	// The matchers do not pin the metric name, so the series they match may
+	// live in any shard.
+	testTags := seriesiter.GenerateTag()
+	sessions.forEach(func(session *client.MockSession) {
+		session.EXPECT().FetchTagged(gomock.Any(), gomock.Any(), gomock.Any()).
+			DoAndReturn(func(_ ident.ID, _ index.Query, opts index.QueryOptions) (
+				encoding.SeriesIterators, bool, error) {
+				assert.Nil(t, opts.Shards)
+				return seriesiter.NewMockSeriesIters(ctrl, testTags, 1, 2), true, nil
+			})
+	})
+
+	results, err := store.Fetch(context.TODO(), newFetchReq(), &storage.FetchOptions{Limit: 100})
+	require.NoError(t, err)
+	require.Len(t, results.SeriesList, 1)
+}
+
+func TestLocalReadRecordsSeriesLimitHit(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+	store, sessions := setup(t, ctrl)
+	testTags := seriesiter.GenerateTag()
+	sessions.forEach(func(session *client.MockSession) {
+		session.EXPECT().FetchTagged(gomock.Any(), gomock.Any(), gomock.Any()).
+			DoAndReturn(func(_ ident.ID, _ index.Query, opts index.QueryOptions) (
+				encoding.SeriesIterators, bool, error) {
+				opts.OnLimitExceeded(index.QueryLimitSeries)
+				return seriesiter.NewMockSeriesIters(ctrl, testTags, 1, 2), false, nil
+			})
+	})
+
+	stats := &querystats.Stats{}
+	ctx := querystats.NewContext(context.TODO(), stats)
+	_, err := store.Fetch(ctx, newFetchReq(), &storage.FetchOptions{Limit: 1})
+	require.NoError(t, err)
+	assert.Equal(t, []string{"series-limit"}, stats.LimitsHit())
+}
+
+func TestLocalReadDocsLimitPerRequest(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+	store, sessions := setup(t, ctrl)
+	testTags := seriesiter.GenerateTag()
+	sessions.forEach(func(session *client.MockSession) {
+		session.EXPECT().FetchTagged(gomock.Any(), gomock.Any(), gomock.Any()).
+			DoAndReturn(func(_ ident.ID, _ index.Query, opts index.QueryOptions) (
+				encoding.SeriesIterators, bool, error) {
+				assert.Equal(t, 10, opts.DocsLimit)
+				assert.Equal(t, 2048, opts.BytesLimit)
+				opts.OnLimitExceeded(index.QueryLimitDocs)
+				return seriesiter.NewMockSeriesIters(ctrl, testTags, 1, 2), false, nil
+			})
+	})
+
+	stats := &querystats.Stats{}
+	ctx := querystats.NewContext(context.TODO(), stats)
+	_, err := store.Fetch(ctx, newFetchReq(), &storage.FetchOptions{
+		FetchControls: models.FetchControls{DocsLimit: 10, BytesLimit: 2048},
+	})
+	require.NoError(t, err)
+	assert.Equal(t, []string{"docs-limit"}, stats.LimitsHit())
+	require.NotEmpty(t, stats.Warnings())
+	assert.Contains(t, stats.Warnings()[0], "limit=docs")
+}
+
+func TestLocalReadFetchPageSize(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+	session := client.NewMockSession(ctrl)
+	clusters, err := NewClusters(UnaggregatedClusterNamespaceDefinition{
+		NamespaceID: ident.StringID("metrics_unaggregated"),
+		Session:     session,
+		Retention:   testRetention,
+	})
+	require.NoError(t, err)
+	store := NewStorageWithOptions(clusters, nil, Options{FetchPageSize: 1})
+
+	// Each page is decoded as it is fetched rather than once every page
+	// has been fetched.
+	testTags := seriesiter.GenerateTag()
+	session.EXPECT().FetchTaggedPages(gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any()).
+		DoAndReturn(func(_ ident.ID, _ index.Query, opts index.QueryOptions,
+			fn client.FetchTaggedPageFn) (bool, error) {
+			assert.Equal(t, 1, opts.PageSize)
+			for i := 0; i < 2; i++ {
+				if err := fn(seriesiter.NewMockSeriesIters(ctrl, testTags, 1, 2)); err != nil {
+					return false, err
+				}
+			}
+			return true, nil
+		})
+
+	results, err := store.Fetch(context.TODO(), newFetchReq(), &storage.FetchOptions{Limit: 100})
+	require.NoError(t, err)
+	require.Len(t, results.SeriesList, 2)
+}
+
+func TestLocalReadVerifyReplicasRecordsWarnings(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+	store, sessions := setup(t, ctrl)
+	testTags := seriesiter.GenerateTag()
+	blockStart := time.Unix(1500000000, 0)
+	sessions.forEach(func(session *client.MockSession) {
+		session.EXPECT().FetchTagged(gomock.Any(), gomock.Any(), gomock.Any()).
+			DoAndReturn(func(_ ident.ID, _ index.Query, opts index.QueryOptions) (
+				encoding.SeriesIterators, bool, error) {
+				require.NotNil(t, opts.OnReplicaDivergence)
+				opts.OnReplicaDivergence(index.ReplicaDivergence{
+					ID:         []byte("foo"),
+					BlockStart: blockStart,
+				})
+				return seriesiter.NewMockSeriesIters(ctrl, testTags, 1, 2), true, nil
+			})
+	})
+
+	stats := &querystats.Stats{}
+	ctx := querystats.NewContext(context.TODO(), stats)
+	_, err := store.Fetch(ctx, newFetchReq(), &storage.FetchOptions{
+		Limit:         100,
+		FetchControls: models.FetchControls{VerifyReplicas: true},
+	})
+	require.NoError(t, err)
+	assert.Equal(t, int64(2), stats.ReplicaDivergences())
+	warnings := stats.Warnings()
+	sort.Strings(warnings)
+	assert.Equal(t, []string{
+		"replica divergence: namespace=metrics_aggregated, series=foo, block=2017-07-14T02:40:00Z",
+		"replica divergence: namespace=metrics_unaggregated, series=foo, block=2017-07-14T02:40:00Z",
+	}, warnings)
+}
+
 func TestLocalReadNoClustersForTimeRangeError(t *testing.T) {
 	ctrl := gomock.NewController(t)
 	defer ctrl.Finish()
@@ -333,3 +540,78 @@ func TestLocalSearchSuccess(t *testing.T) {
 		}}, actual.Tags)
 	}
 }
+
+func setupWithAccessPolicies(
+	t *testing.T,
+	ctrl *gomock.Controller,
+) (storage.Storage, testSessions) {
+	store, sessions := setup(t, ctrl)
+	policies, err := access.NewPolicies([]access.NamespacePolicy{
+		{
+			Namespace: "metrics_aggregated",
+			Read:      access.Rule{Tenants: []string{"billing"}},
+		},
+		{
+			Namespace: "metrics_unaggregated",
+			Write:     access.Rule{Identities: []string{"collector"}},
+		},
+	})
+	require.NoError(t, err)
+
+	localStore := store.(*localStorage)
+	return NewStorageWithOptions(localStore.clusters, nil,
+		Options{AccessPolicies: policies}), sessions
+}
+
+func TestLocalReadSkipsDeniedNamespaces(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+	store, sessions := setupWithAccessPolicies(t, ctrl)
+
+	// Only the unaggregated namespace is fetched from, the aggregated
+	// session is a strict mock so fetching from it fails the test
+	testTags := seriesiter.GenerateTag()
+	sessions.unaggregated1MonthRetention.EXPECT().
+		FetchTagged(gomock.Any(), gomock.Any(), gomock.Any()).
+		Return(seriesiter.NewMockSeriesIters(ctrl, testTags, 1, 2), true, nil)
+
+	ctx := tenant.NewContext(context.TODO(), "other")
+	results, err := store.Fetch(ctx, newFetchReq(), &storage.FetchOptions{Limit: 100})
+	require.NoError(t, err)
+	require.Len(t, results.SeriesList, 1)
+}
+
+func TestLocalReadGrantedNamespaces(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+	store, sessions := setupWithAccessPolicies(t, ctrl)
+
+	testTags := seriesiter.GenerateTag()
+	sessions.forEach(func(session *client.MockSession) {
+		session.EXPECT().FetchTagged(gomock.Any(), gomock.Any(), gomock.Any()).
+			Return(seriesiter.NewMockSeriesIters(ctrl, testTags, 1, 2), true, nil)
+	})
+
+	ctx := tenant.NewContext(context.TODO(), "billing")
+	_, err := store.Fetch(ctx, newFetchReq(), &storage.FetchOptions{Limit: 100})
+	require.NoError(t, err)
+}
+
+func TestLocalWriteAccessDenied(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+	store, sessions := setupWithAccessPolicies(t, ctrl)
+
+	// the session is a strict mock so any write reaching it fails the test
+	err := store.Write(context.TODO(), newWriteQuery())
+	require.Error(t, err)
+	assert.True(t, access.IsAccessDenied(err))
+
+	writeQuery := newWriteQuery()
+	sessions.unaggregated1MonthRetention.EXPECT().
+		WriteTagged(gomock.Any(), gomock.Any(), gomock.Any(),
+			gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any()).
+		Times(len(writeQuery.Datapoints))
+	ctx := access.NewContext(context.TODO(), "collector", nil)
+	require.NoError(t, store.Write(ctx, writeQuery))
+}
@@ -0,0 +1,234 @@
+// Copyright (c) 2018 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package mirror
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"time"
+
+	"github.com/m3db/m3/src/query/block"
+	"github.com/m3db/m3/src/query/storage"
+
+	"github.com/uber-go/tally"
+	"go.uber.org/zap"
+)
+
+const (
+	defaultQueueSize    = 65536
+	defaultConcurrency  = 16
+	defaultWriteTimeout = 10 * time.Second
+)
+
+var (
+	errStorageClosed = errors.New("mirror storage is closed")
+)
+
+// Options is the set of options for the mirror storage.
+type Options struct {
+	// QueueSize is the maximum number of writes buffered for the mirror,
+	// writes are dropped while the queue is full.
+	QueueSize int
+
+	// Concurrency is the number of concurrent writes to the mirror.
+	Concurrency int
+
+	// WriteTimeout is the timeout for each write to the mirror.
+	WriteTimeout time.Duration
+
+	// Scope is the metrics scope.
+	Scope tally.Scope
+
+	// Logger is the logger.
+	Logger *zap.Logger
+}
+
+type mirrorWrite struct {
+	query      *storage.WriteQuery
+	enqueuedAt time.Time
+}
+
+type mirrorStorage struct {
+	sync.RWMutex
+
+	primary      storage.Storage
+	mirror       storage.Storage
+	queue        chan mirrorWrite
+	writeTimeout time.Duration
+	logger       *zap.Logger
+	nowFn        func() time.Time
+	closed       bool
+	wg           sync.WaitGroup
+
+	metrics mirrorMetrics
+}
+
+// NewStorage returns a storage that serves all reads and writes from the
+// primary storage and mirrors every successful write to the mirror storage.
+// Writes are mirrored asynchronously so the mirror never adds latency to
+// or fails writes to the primary, if the mirror falls behind and its queue
+// is full the writes are dropped instead.
+func NewStorage(
+	primary storage.Storage,
+	mirror storage.Storage,
+	opts Options,
+) storage.Storage {
+	if opts.QueueSize <= 0 {
+		opts.QueueSize = defaultQueueSize
+	}
+	if opts.Concurrency <= 0 {
+		opts.Concurrency = defaultConcurrency
+	}
+	if opts.WriteTimeout <= 0 {
+		opts.WriteTimeout = defaultWriteTimeout
+	}
+	if opts.Scope == nil {
+		opts.Scope = tally.NoopScope
+	}
+	if opts.Logger == nil {
+		opts.Logger = zap.NewNop()
+	}
+
+	s := &mirrorStorage{
+		primary:      primary,
+		mirror:       mirror,
+		queue:        make(chan mirrorWrite, opts.QueueSize),
+		writeTimeout: opts.WriteTimeout,
+		logger:       opts.Logger,
+		nowFn:        time.Now,
+		metrics:      newMirrorMetrics(opts.Scope),
+	}
+	s.wg.Add(opts.Concurrency)
+	for i := 0; i < opts.Concurrency; i++ {
+		go s.mirrorWrites()
+	}
+	return s
+}
+
+func (s *mirrorStorage) Fetch(
+	ctx context.Context,
+	query *storage.FetchQuery,
+	options *storage.FetchOptions,
+) (*storage.FetchResult, error) {
+	return s.primary.Fetch(ctx, query, options)
+}
+
+func (s *mirrorStorage) FetchTags(
+	ctx context.Context,
+	query *storage.FetchQuery,
+	options *storage.FetchOptions,
+) (*storage.SearchResults, error) {
+	return s.primary.FetchTags(ctx, query, options)
+}
+
+func (s *mirrorStorage) FetchBlocks(
+	ctx context.Context,
+	query *storage.FetchQuery,
+	options *storage.FetchOptions,
+) (block.Result, error) {
+	return s.primary.FetchBlocks(ctx, query, options)
+}
+
+func (s *mirrorStorage) Write(
+	ctx context.Context,
+	query *storage.WriteQuery,
+) error {
+	if err := s.primary.Write(ctx, query); err != nil {
+		return err
+	}
+
+	s.RLock()
+	defer s.RUnlock()
+	if s.closed {
+		return errStorageClosed
+	}
+
+	select {
+	case s.queue <- mirrorWrite{query: query, enqueuedAt: s.nowFn()}:
+		s.metrics.enqueued.Inc(1)
+	default:
+		s.metrics.dropped.Inc(1)
+	}
+	s.metrics.queueLength.Update(float64(len(s.queue)))
+	return nil
+}
+
+func (s *mirrorStorage) mirrorWrites() {
+	defer s.wg.Done()
+	for w := range s.queue {
+		ctx, cancel := context.WithTimeout(context.Background(), s.writeTimeout)
+		err := s.mirror.Write(ctx, w.query)
+		cancel()
+
+		s.metrics.lag.Record(s.nowFn().Sub(w.enqueuedAt))
+		if err != nil {
+			s.metrics.writeErrors.Inc(1)
+			s.logger.Debug("unable to mirror write", zap.Error(err))
+			continue
+		}
+		s.metrics.writeSuccess.Inc(1)
+	}
+}
+
+func (s *mirrorStorage) Type() storage.Type {
+	return s.primary.Type()
+}
+
+// Close stops accepting writes, waits for the queued writes to be mirrored
+// and then closes both storages.
+func (s *mirrorStorage) Close() error {
+	s.Lock()
+	if s.closed {
+		s.Unlock()
+		return errStorageClosed
+	}
+	s.closed = true
+	close(s.queue)
+	s.Unlock()
+
+	s.wg.Wait()
+	mirrorErr := s.mirror.Close()
+	if err := s.primary.Close(); err != nil {
+		return err
+	}
+	return mirrorErr
+}
+
+type mirrorMetrics struct {
+	enqueued     tally.Counter
+	dropped      tally.Counter
+	writeSuccess tally.Counter
+	writeErrors  tally.Counter
+	queueLength  tally.Gauge
+	lag          tally.Timer
+}
+
+func newMirrorMetrics(scope tally.Scope) mirrorMetrics {
+	return mirrorMetrics{
+		enqueued:     scope.Counter("enqueued"),
+		dropped:      scope.Counter("dropped"),
+		writeSuccess: scope.Tagged(map[string]string{"success": "true"}).Counter("write"),
+		writeErrors:  scope.Tagged(map[string]string{"success": "false"}).Counter("write"),
+		queueLength:  scope.Gauge("queue-length"),
+		lag:          scope.Timer("lag"),
+	}
+}
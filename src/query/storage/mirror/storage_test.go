@@ -0,0 +1,120 @@
+// Copyright (c) 2018 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package mirror
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/m3db/m3/src/query/models"
+	"github.com/m3db/m3/src/query/storage"
+	"github.com/m3db/m3/src/query/storage/mock"
+	"github.com/m3db/m3/src/query/ts"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/uber-go/tally"
+)
+
+func newTestWriteQuery(name string) *storage.WriteQuery {
+	return &storage.WriteQuery{
+		Tags: models.Tags{{Name: "__name__", Value: name}},
+		Datapoints: ts.Datapoints{ts.Datapoint{
+			Timestamp: time.Now(),
+			Value:     42,
+		}},
+	}
+}
+
+type blockingStorage struct {
+	mock.Storage
+
+	started chan struct{}
+	unblock chan struct{}
+}
+
+func (s *blockingStorage) Write(ctx context.Context, query *storage.WriteQuery) error {
+	s.started <- struct{}{}
+	<-s.unblock
+	return s.Storage.Write(ctx, query)
+}
+
+func TestMirrorStorageMirrorsWrites(t *testing.T) {
+	primary := mock.NewMockStorage()
+	mirror := mock.NewMockStorage()
+	s := NewStorage(primary, mirror, Options{})
+
+	for _, name := range []string{"foo", "bar", "baz"} {
+		require.NoError(t, s.Write(context.Background(), newTestWriteQuery(name)))
+	}
+	require.NoError(t, s.Close())
+
+	assert.Equal(t, 3, len(primary.Writes()))
+	assert.Equal(t, 3, len(mirror.Writes()))
+	assert.Equal(t, errStorageClosed, s.Write(context.Background(),
+		newTestWriteQuery("qux")))
+}
+
+func TestMirrorStorageDoesNotMirrorFailedWrites(t *testing.T) {
+	primary := mock.NewMockStorage()
+	primary.SetWriteResult(errors.New("write failed"))
+	mirror := mock.NewMockStorage()
+	s := NewStorage(primary, mirror, Options{})
+
+	require.Error(t, s.Write(context.Background(), newTestWriteQuery("foo")))
+	require.NoError(t, s.Close())
+	assert.Equal(t, 0, len(mirror.Writes()))
+}
+
+func TestMirrorStorageDropsWritesWhenQueueFull(t *testing.T) {
+	scope := tally.NewTestScope("", nil)
+	primary := mock.NewMockStorage()
+	mirror := &blockingStorage{
+		Storage: mock.NewMockStorage(),
+		started: make(chan struct{}, 3),
+		unblock: make(chan struct{}),
+	}
+	s := NewStorage(primary, mirror, Options{
+		QueueSize:   1,
+		Concurrency: 1,
+		Scope:       scope,
+	})
+
+	// The first write is taken by the only worker, the second fills the
+	// queue and the third is dropped.
+	require.NoError(t, s.Write(context.Background(), newTestWriteQuery("foo")))
+	<-mirror.started
+	require.NoError(t, s.Write(context.Background(), newTestWriteQuery("bar")))
+	require.NoError(t, s.Write(context.Background(), newTestWriteQuery("baz")))
+
+	close(mirror.unblock)
+	require.NoError(t, s.Close())
+
+	assert.Equal(t, 3, len(primary.Writes()))
+	assert.Equal(t, 2, len(mirror.Writes()))
+
+	counters := scope.Snapshot().Counters()
+	assert.Equal(t, int64(2), counters["enqueued+"].Value())
+	assert.Equal(t, int64(1), counters["dropped+"].Value())
+	assert.Equal(t, int64(2), counters["write+success=true"].Value())
+}
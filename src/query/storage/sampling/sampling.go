@@ -0,0 +1,188 @@
+// Copyright (c) 2018 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+// Package sampling samples the writes of high volume, low value metrics so
+// that only a fraction of their samples or series are stored.
+package sampling
+
+import (
+	"errors"
+	"fmt"
+	"regexp"
+	"strconv"
+
+	"github.com/m3db/m3/src/query/models"
+)
+
+const (
+	// DefaultRateLabel is the default label the sampling rate of sampled
+	// series is recorded as.
+	DefaultRateLabel = "sampling_rate"
+)
+
+var (
+	errModeUnspecified      = errors.New("sampling mode unspecified")
+	errRuleNoMetricName     = errors.New("sampling rule has no metric name")
+	errRuleRateNotPositive  = errors.New("sampling rule rate must be positive")
+	errConfigurationNoRules = errors.New("sampling requires at least one rule")
+)
+
+// Mode is what a sampling rule keeps one in every rate of.
+type Mode uint
+
+const (
+	// ModeSamples keeps one in every rate samples of a metric at random.
+	ModeSamples Mode = iota
+	// ModeSeries keeps every sample of one in every rate series of a metric,
+	// the series kept are picked by the hash of their tags so the same
+	// series are always kept.
+	ModeSeries
+)
+
+// ValidModes returns the valid sampling modes.
+func ValidModes() []Mode {
+	return []Mode{ModeSamples, ModeSeries}
+}
+
+func (m Mode) String() string {
+	switch m {
+	case ModeSamples:
+		return "samples"
+	case ModeSeries:
+		return "series"
+	}
+	return "unknown"
+}
+
+// ParseMode parses a Mode from a string.
+func ParseMode(str string) (Mode, error) {
+	var m Mode
+	if str == "" {
+		return m, errModeUnspecified
+	}
+	for _, valid := range ValidModes() {
+		if str == valid.String() {
+			m = valid
+			return m, nil
+		}
+	}
+	return m, fmt.Errorf("invalid sampling Mode '%s' valid modes are: %v",
+		str, ValidModes())
+}
+
+// UnmarshalYAML unmarshals a Mode into a valid type from string.
+func (m *Mode) UnmarshalYAML(unmarshal func(interface{}) error) error {
+	var str string
+	if err := unmarshal(&str); err != nil {
+		return err
+	}
+	parsed, err := ParseMode(str)
+	if err != nil {
+		return err
+	}
+	*m = parsed
+	return nil
+}
+
+// Configuration is the configuration of ingest sampling.
+type Configuration struct {
+	// Rules are the sampling rules, the first rule matching the metric name
+	// of a write samples it.
+	Rules []Rule `yaml:"rules"`
+
+	// RateLabel is the label the rate of sampled series is recorded as, so
+	// that queries can scale sampled values back up. Defaults to
+	// sampling_rate.
+	RateLabel string `yaml:"rateLabel"`
+}
+
+// RateLabelOrDefault returns the configured rate label or the default.
+func (c Configuration) RateLabelOrDefault() string {
+	if c.RateLabel == "" {
+		return DefaultRateLabel
+	}
+	return c.RateLabel
+}
+
+// NewRules returns the compiled rules of the configuration.
+func (c Configuration) NewRules() (Rules, error) {
+	if len(c.Rules) == 0 {
+		return nil, errConfigurationNoRules
+	}
+	rules := make(Rules, 0, len(c.Rules))
+	for _, rule := range c.Rules {
+		if err := rule.compile(); err != nil {
+			return nil, err
+		}
+		rules = append(rules, rule)
+	}
+	return rules, nil
+}
+
+// Rule keeps one in every rate samples or series of the metrics with names
+// matching a regular expression.
+type Rule struct {
+	// MetricName is the regular expression the whole metric name must match.
+	MetricName string `yaml:"metricName"`
+
+	// Mode is whether samples or series are sampled, "samples" or "series".
+	Mode Mode `yaml:"mode"`
+
+	// Rate is the one in every rate samples or series kept, a rate of one
+	// keeps everything.
+	Rate int `yaml:"rate"`
+
+	re    *regexp.Regexp
+	label string
+}
+
+func (r *Rule) compile() error {
+	if r.MetricName == "" {
+		return errRuleNoMetricName
+	}
+	if r.Rate <= 0 {
+		return fmt.Errorf("%v: %s", errRuleRateNotPositive, r.MetricName)
+	}
+	re, err := regexp.Compile("^(?:" + r.MetricName + ")$")
+	if err != nil {
+		return fmt.Errorf("invalid sampling rule metric name %s: %v",
+			r.MetricName, err)
+	}
+	r.re = re
+	r.label = strconv.Itoa(r.Rate)
+	return nil
+}
+
+// Rules are a set of compiled sampling rules.
+type Rules []Rule
+
+// Match returns the first rule matching the metric name of the tags.
+func (r Rules) Match(tags models.Tags) (Rule, bool) {
+	name, ok := tags.Get(models.MetricName)
+	if !ok {
+		return Rule{}, false
+	}
+	for _, rule := range r {
+		if rule.re.MatchString(name) {
+			return rule, true
+		}
+	}
+	return Rule{}, false
+}
@@ -0,0 +1,78 @@
+// Copyright (c) 2018 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package sampling
+
+import (
+	"testing"
+
+	"github.com/m3db/m3/src/query/models"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	yaml "gopkg.in/yaml.v2"
+)
+
+func TestConfigurationNewRules(t *testing.T) {
+	var cfg Configuration
+	require.NoError(t, yaml.Unmarshal([]byte(`
+rules:
+  - metricName: debug_.*
+    mode: series
+    rate: 10
+  - metricName: go_gc_duration_seconds
+    mode: samples
+    rate: 4
+`), &cfg))
+	assert.Equal(t, DefaultRateLabel, cfg.RateLabelOrDefault())
+
+	rules, err := cfg.NewRules()
+	require.NoError(t, err)
+
+	rule, ok := rules.Match(models.Tags{{Name: models.MetricName, Value: "debug_requests"}})
+	require.True(t, ok)
+	assert.Equal(t, ModeSeries, rule.Mode)
+	assert.Equal(t, "10", rule.label)
+
+	rule, ok = rules.Match(models.Tags{{Name: models.MetricName, Value: "go_gc_duration_seconds"}})
+	require.True(t, ok)
+	assert.Equal(t, ModeSamples, rule.Mode)
+
+	// Metric names must match as a whole.
+	_, ok = rules.Match(models.Tags{{Name: models.MetricName, Value: "app_debug_requests"}})
+	assert.False(t, ok)
+	_, ok = rules.Match(models.Tags{{Name: "foo", Value: "debug_requests"}})
+	assert.False(t, ok)
+}
+
+func TestConfigurationInvalidRules(t *testing.T) {
+	for _, cfg := range []Configuration{
+		{},
+		{Rules: []Rule{{Mode: ModeSeries, Rate: 2}}},
+		{Rules: []Rule{{MetricName: "debug_.*", Rate: 0}}},
+		{Rules: []Rule{{MetricName: "debug_(", Rate: 2}}},
+	} {
+		_, err := cfg.NewRules()
+		assert.Error(t, err)
+	}
+
+	var mode Mode
+	assert.Error(t, yaml.Unmarshal([]byte(`metrics`), &mode))
+}
@@ -0,0 +1,134 @@
+// Copyright (c) 2018 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package sampling
+
+import (
+	"context"
+	"math/rand"
+
+	"github.com/m3db/m3/src/query/models"
+	"github.com/m3db/m3/src/query/storage"
+	"github.com/m3db/m3/src/query/ts"
+
+	"github.com/cespare/xxhash"
+	"github.com/uber-go/tally"
+)
+
+type samplingMetrics struct {
+	sampledWrites tally.Counter
+	kept          tally.Counter
+	dropped       tally.Counter
+}
+
+func newSamplingMetrics(scope tally.Scope) samplingMetrics {
+	return samplingMetrics{
+		sampledWrites: scope.Counter("sampled-writes"),
+		kept:          scope.Counter("kept-datapoints"),
+		dropped:       scope.Counter("dropped-datapoints"),
+	}
+}
+
+type samplingStorage struct {
+	storage.Storage
+
+	rules     Rules
+	rateLabel string
+	keepFn    func(rate int) bool
+	metrics   samplingMetrics
+}
+
+// NewStorage returns a storage that samples the writes of metrics matching
+// the rules and passes through all other writes and all queries. The rate
+// label of sampled writes is set to the rate of their rule, overriding any
+// value supplied with the write, while dropped samples are not reported as
+// failed writes.
+func NewStorage(
+	store storage.Storage,
+	rules Rules,
+	rateLabel string,
+	scope tally.Scope,
+) storage.Storage {
+	if rateLabel == "" {
+		rateLabel = DefaultRateLabel
+	}
+	if scope == nil {
+		scope = tally.NoopScope
+	}
+	return &samplingStorage{
+		Storage:   store,
+		rules:     rules,
+		rateLabel: rateLabel,
+		keepFn: func(rate int) bool {
+			return rand.Intn(rate) == 0
+		},
+		metrics: newSamplingMetrics(scope),
+	}
+}
+
+func (s *samplingStorage) Write(
+	ctx context.Context,
+	query *storage.WriteQuery,
+) error {
+	rule, ok := s.rules.Match(query.Tags)
+	if !ok {
+		return s.Storage.Write(ctx, query)
+	}
+	s.metrics.sampledWrites.Inc(1)
+
+	var datapoints ts.Datapoints
+	switch rule.Mode {
+	case ModeSeries:
+		if seriesKept(query.Tags, rule.Rate) {
+			datapoints = query.Datapoints
+		}
+	default:
+		for _, dp := range query.Datapoints {
+			if s.keepFn(rule.Rate) {
+				datapoints = append(datapoints, dp)
+			}
+		}
+	}
+
+	s.metrics.kept.Inc(int64(len(datapoints)))
+	s.metrics.dropped.Inc(int64(len(query.Datapoints) - len(datapoints)))
+	if len(datapoints) == 0 {
+		return nil
+	}
+
+	tags := make(models.Tags, 0, len(query.Tags)+1)
+	for _, tag := range query.Tags {
+		if tag.Name != s.rateLabel {
+			tags = append(tags, tag)
+		}
+	}
+
+	sampled := *query
+	sampled.Tags = tags.AddTag(models.Tag{Name: s.rateLabel, Value: rule.label})
+	sampled.Datapoints = datapoints
+	return s.Storage.Write(ctx, &sampled)
+}
+
+// seriesKept returns whether the series with the tags is one of the one in
+// every rate series kept. The rate label is not set yet so the same series
+// is kept however the rate is changed.
+func seriesKept(tags models.Tags, rate int) bool {
+	return xxhash.Sum64String(tags.ID())%uint64(rate) == 0
+}
@@ -0,0 +1,123 @@
+// Copyright (c) 2018 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package sampling
+
+import (
+	"context"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/m3db/m3/src/query/models"
+	"github.com/m3db/m3/src/query/storage"
+	"github.com/m3db/m3/src/query/storage/mock"
+	"github.com/m3db/m3/src/query/ts"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func newTestWrite(name string, series int, numDatapoints int) *storage.WriteQuery {
+	datapoints := make(ts.Datapoints, 0, numDatapoints)
+	for i := 0; i < numDatapoints; i++ {
+		datapoints = append(datapoints, ts.Datapoint{
+			Timestamp: time.Unix(int64(i), 0),
+			Value:     float64(i),
+		})
+	}
+	return &storage.WriteQuery{
+		Tags: models.Normalize(models.Tags{
+			{Name: models.MetricName, Value: name},
+			{Name: "series", Value: fmt.Sprint(series)},
+			{Name: DefaultRateLabel, Value: "spoofed"},
+		}),
+		Datapoints: datapoints,
+	}
+}
+
+func newTestStorage(t *testing.T, underlying storage.Storage) *samplingStorage {
+	rules, err := Configuration{Rules: []Rule{
+		{MetricName: "debug_series", Mode: ModeSeries, Rate: 4},
+		{MetricName: "debug_samples", Mode: ModeSamples, Rate: 2},
+	}}.NewRules()
+	require.NoError(t, err)
+	return NewStorage(underlying, rules, "", nil).(*samplingStorage)
+}
+
+func TestStorageSamplesSeries(t *testing.T) {
+	underlying := mock.NewMockStorage()
+	store := newTestStorage(t, underlying)
+
+	for i := 0; i < 100; i++ {
+		require.NoError(t, store.Write(context.Background(), newTestWrite("debug_series", i, 2)))
+	}
+	writes := underlying.Writes()
+	assert.True(t, len(writes) > 0 && len(writes) < 100, "%d series kept", len(writes))
+	for _, write := range writes {
+		assert.Len(t, write.Datapoints, 2)
+		rate, ok := write.Tags.Get(DefaultRateLabel)
+		require.True(t, ok)
+		assert.Equal(t, "4", rate)
+	}
+
+	// The same series are kept on every write.
+	first := len(writes)
+	for i := 0; i < 100; i++ {
+		require.NoError(t, store.Write(context.Background(), newTestWrite("debug_series", i, 2)))
+	}
+	assert.Len(t, underlying.Writes(), 2*first)
+}
+
+func TestStorageSamplesDatapoints(t *testing.T) {
+	underlying := mock.NewMockStorage()
+	store := newTestStorage(t, underlying)
+
+	var calls int
+	store.keepFn = func(rate int) bool {
+		assert.Equal(t, 2, rate)
+		calls++
+		return calls%2 == 1
+	}
+	require.NoError(t, store.Write(context.Background(), newTestWrite("debug_samples", 0, 4)))
+	require.Len(t, underlying.Writes(), 1)
+	write := underlying.Writes()[0]
+	assert.Equal(t, ts.Datapoints{
+		{Timestamp: time.Unix(0, 0), Value: 0},
+		{Timestamp: time.Unix(2, 0), Value: 2},
+	}, write.Datapoints)
+	rate, _ := write.Tags.Get(DefaultRateLabel)
+	assert.Equal(t, "2", rate)
+
+	// Writes with every sample dropped are not written.
+	store.keepFn = func(int) bool { return false }
+	require.NoError(t, store.Write(context.Background(), newTestWrite("debug_samples", 0, 4)))
+	assert.Len(t, underlying.Writes(), 1)
+}
+
+func TestStoragePassesThroughUnmatchedWrites(t *testing.T) {
+	underlying := mock.NewMockStorage()
+	store := newTestStorage(t, underlying)
+
+	write := newTestWrite("requests", 0, 3)
+	require.NoError(t, store.Write(context.Background(), write))
+	require.Len(t, underlying.Writes(), 1)
+	assert.True(t, underlying.Writes()[0] == write)
+}
@@ -0,0 +1,318 @@
+// Copyright (c) 2018 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package shadow
+
+import (
+	"context"
+	"encoding/binary"
+	"hash/fnv"
+	"math"
+	"math/rand"
+	"time"
+
+	"github.com/m3db/m3/src/query/block"
+	"github.com/m3db/m3/src/query/storage"
+
+	"github.com/uber-go/tally"
+	"go.uber.org/zap"
+)
+
+const (
+	defaultConcurrency = 4
+	defaultTimeout     = 30 * time.Second
+)
+
+// Options is the set of options for the shadow storage.
+type Options struct {
+	// SampleRate is the fraction of queries, between 0 and 1, that are also
+	// sent to the secondary storage.
+	SampleRate float64
+
+	// Concurrency is the maximum number of shadow queries in flight, sampled
+	// queries are skipped while at the limit.
+	Concurrency int
+
+	// Timeout is the timeout for each shadow query.
+	Timeout time.Duration
+
+	// Scope is the metrics scope.
+	Scope tally.Scope
+
+	// Logger is the logger.
+	Logger *zap.Logger
+}
+
+type sampleFn func() bool
+
+type shadowStorage struct {
+	primary   storage.Storage
+	secondary storage.Storage
+	sampleFn  sampleFn
+	inflight  chan struct{}
+	timeout   time.Duration
+	logger    *zap.Logger
+	nowFn     func() time.Time
+
+	// doneFn is called once a shadow query completes, used by tests.
+	doneFn func()
+
+	metrics shadowMetrics
+}
+
+// NewStorage returns a storage that serves all queries and writes from the
+// primary storage and sends a sampled fraction of fetches to the secondary
+// storage as well. The results of the secondary storage are compared with
+// those of the primary storage, by series count and value checksums, and any
+// mismatches are reported. Shadow queries run in the background and never
+// affect the results or latency of the primary queries.
+func NewStorage(
+	primary storage.Storage,
+	secondary storage.Storage,
+	opts Options,
+) storage.Storage {
+	if opts.Concurrency <= 0 {
+		opts.Concurrency = defaultConcurrency
+	}
+	if opts.Timeout <= 0 {
+		opts.Timeout = defaultTimeout
+	}
+	if opts.Scope == nil {
+		opts.Scope = tally.NoopScope
+	}
+	if opts.Logger == nil {
+		opts.Logger = zap.NewNop()
+	}
+	sampleRate := opts.SampleRate
+	return &shadowStorage{
+		primary:   primary,
+		secondary: secondary,
+		sampleFn: func() bool {
+			return rand.Float64() < sampleRate
+		},
+		inflight: make(chan struct{}, opts.Concurrency),
+		timeout:  opts.Timeout,
+		logger:   opts.Logger,
+		nowFn:    time.Now,
+		doneFn:   func() {},
+		metrics:  newShadowMetrics(opts.Scope),
+	}
+}
+
+func (s *shadowStorage) Fetch(
+	ctx context.Context,
+	query *storage.FetchQuery,
+	options *storage.FetchOptions,
+) (*storage.FetchResult, error) {
+	result, err := s.primary.Fetch(ctx, query, options)
+	if err != nil || !s.sampleFn() {
+		return result, err
+	}
+
+	// Summarize the primary result before returning it since the caller
+	// is free to modify it.
+	expected := newFetchSummary(result)
+	s.shadow(func(ctx context.Context) {
+		actual, err := s.secondary.Fetch(ctx, query, options)
+		if err != nil {
+			s.metrics.errors.Inc(1)
+			s.logger.Debug("shadow fetch failed", zap.Error(err))
+			return
+		}
+		s.compare(query, expected, newFetchSummary(actual))
+	})
+	return result, nil
+}
+
+func (s *shadowStorage) FetchTags(
+	ctx context.Context,
+	query *storage.FetchQuery,
+	options *storage.FetchOptions,
+) (*storage.SearchResults, error) {
+	result, err := s.primary.FetchTags(ctx, query, options)
+	if err != nil || !s.sampleFn() {
+		return result, err
+	}
+
+	expected := newSearchSummary(result)
+	s.shadow(func(ctx context.Context) {
+		actual, err := s.secondary.FetchTags(ctx, query, options)
+		if err != nil {
+			s.metrics.errors.Inc(1)
+			s.logger.Debug("shadow fetch tags failed", zap.Error(err))
+			return
+		}
+		s.compare(query, expected, newSearchSummary(actual))
+	})
+	return result, nil
+}
+
+func (s *shadowStorage) FetchBlocks(
+	ctx context.Context,
+	query *storage.FetchQuery,
+	options *storage.FetchOptions,
+) (block.Result, error) {
+	return s.primary.FetchBlocks(ctx, query, options)
+}
+
+func (s *shadowStorage) Write(
+	ctx context.Context,
+	query *storage.WriteQuery,
+) error {
+	return s.primary.Write(ctx, query)
+}
+
+func (s *shadowStorage) Type() storage.Type {
+	return s.primary.Type()
+}
+
+func (s *shadowStorage) Close() error {
+	secondaryErr := s.secondary.Close()
+	if err := s.primary.Close(); err != nil {
+		return err
+	}
+	return secondaryErr
+}
+
+// shadow runs the shadow query in the background unless the maximum number
+// of shadow queries are already in flight.
+func (s *shadowStorage) shadow(fn func(ctx context.Context)) {
+	select {
+	case s.inflight <- struct{}{}:
+	default:
+		s.metrics.skipped.Inc(1)
+		return
+	}
+
+	s.metrics.sampled.Inc(1)
+	go func() {
+		defer func() {
+			<-s.inflight
+			s.doneFn()
+		}()
+
+		// NB: the shadow query must not be cancelled when the primary
+		// query's request completes.
+		ctx, cancel := context.WithTimeout(context.Background(), s.timeout)
+		defer cancel()
+
+		start := s.nowFn()
+		fn(ctx)
+		s.metrics.latency.Record(s.nowFn().Sub(start))
+	}()
+}
+
+func (s *shadowStorage) compare(
+	query *storage.FetchQuery,
+	expected resultSummary,
+	actual resultSummary,
+) {
+	s.metrics.compared.Inc(1)
+	if len(expected) != len(actual) {
+		s.metrics.seriesCountMismatches.Inc(1)
+		s.logger.Warn("shadow query series count mismatch",
+			zap.String("query", query.String()),
+			zap.Int("expected", len(expected)),
+			zap.Int("actual", len(actual)))
+		return
+	}
+
+	var mismatched int
+	for id, checksum := range expected {
+		if actualChecksum, ok := actual[id]; !ok || actualChecksum != checksum {
+			mismatched++
+		}
+	}
+	if mismatched > 0 {
+		s.metrics.checksumMismatches.Inc(1)
+		s.logger.Warn("shadow query checksum mismatch",
+			zap.String("query", query.String()),
+			zap.Int("series", len(expected)),
+			zap.Int("mismatched", mismatched))
+		return
+	}
+	s.metrics.matches.Inc(1)
+}
+
+// resultSummary is a checksum of the result for each series ID.
+type resultSummary map[string]uint64
+
+func newFetchSummary(result *storage.FetchResult) resultSummary {
+	if result == nil {
+		return resultSummary{}
+	}
+
+	var (
+		summary = make(resultSummary, len(result.SeriesList))
+		buf     [16]byte
+	)
+	for _, series := range result.SeriesList {
+		hash := fnv.New64a()
+		values := series.Values()
+		for i := 0; i < values.Len(); i++ {
+			dp := values.DatapointAt(i)
+			binary.LittleEndian.PutUint64(buf[:8], uint64(dp.Timestamp.UnixNano()))
+			binary.LittleEndian.PutUint64(buf[8:], math.Float64bits(dp.Value))
+			hash.Write(buf[:])
+		}
+		summary[series.Name()] = hash.Sum64()
+	}
+	return summary
+}
+
+func newSearchSummary(result *storage.SearchResults) resultSummary {
+	if result == nil {
+		return resultSummary{}
+	}
+
+	summary := make(resultSummary, len(result.Metrics))
+	for _, metric := range result.Metrics {
+		hash := fnv.New64a()
+		hash.Write([]byte(metric.Tags.ID()))
+		summary[metric.ID] = hash.Sum64()
+	}
+	return summary
+}
+
+type shadowMetrics struct {
+	sampled               tally.Counter
+	skipped               tally.Counter
+	errors                tally.Counter
+	compared              tally.Counter
+	matches               tally.Counter
+	seriesCountMismatches tally.Counter
+	checksumMismatches    tally.Counter
+	latency               tally.Timer
+}
+
+func newShadowMetrics(scope tally.Scope) shadowMetrics {
+	return shadowMetrics{
+		sampled:  scope.Counter("sampled"),
+		skipped:  scope.Counter("skipped"),
+		errors:   scope.Counter("errors"),
+		compared: scope.Counter("compared"),
+		matches:  scope.Counter("matches"),
+		seriesCountMismatches: scope.Tagged(
+			map[string]string{"reason": "series-count"}).Counter("mismatches"),
+		checksumMismatches: scope.Tagged(
+			map[string]string{"reason": "checksum"}).Counter("mismatches"),
+		latency: scope.Timer("latency"),
+	}
+}
@@ -0,0 +1,162 @@
+// Copyright (c) 2018 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package shadow
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/m3db/m3/src/query/models"
+	"github.com/m3db/m3/src/query/storage"
+	"github.com/m3db/m3/src/query/storage/mock"
+	"github.com/m3db/m3/src/query/ts"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/uber-go/tally"
+)
+
+var testStart = time.Unix(1500000000, 0)
+
+func newTestFetchResult(values map[string]float64) *storage.FetchResult {
+	result := &storage.FetchResult{LocalOnly: true}
+	for name, value := range values {
+		result.SeriesList = append(result.SeriesList, ts.NewSeries(name,
+			ts.Datapoints{ts.Datapoint{Timestamp: testStart, Value: value}},
+			models.Tags{{Name: "__name__", Value: name}}))
+	}
+	return result
+}
+
+func newTestShadowStorage(
+	primary storage.Storage,
+	secondary storage.Storage,
+	scope tally.Scope,
+) (*shadowStorage, *sync.WaitGroup) {
+	s := NewStorage(primary, secondary, Options{
+		SampleRate: 1,
+		Scope:      scope,
+	}).(*shadowStorage)
+	var wg sync.WaitGroup
+	s.doneFn = wg.Done
+	return s, &wg
+}
+
+func TestShadowStorageFetchComparesResults(t *testing.T) {
+	var (
+		primary   = mock.NewMockStorage()
+		secondary = mock.NewMockStorage()
+		scope     = tally.NewTestScope("", nil)
+		query     = &storage.FetchQuery{Raw: "foo"}
+	)
+	s, wg := newTestShadowStorage(primary, secondary, scope)
+
+	expected := newTestFetchResult(map[string]float64{"foo": 1, "bar": 2})
+	primary.SetFetchResult(expected, nil)
+
+	// Matching results.
+	secondary.SetFetchResult(newTestFetchResult(
+		map[string]float64{"bar": 2, "foo": 1}), nil)
+	wg.Add(1)
+	result, err := s.Fetch(context.Background(), query, nil)
+	require.NoError(t, err)
+	assert.Equal(t, expected, result)
+	wg.Wait()
+
+	// Mismatched value.
+	secondary.SetFetchResult(newTestFetchResult(
+		map[string]float64{"foo": 1, "bar": 3}), nil)
+	wg.Add(1)
+	_, err = s.Fetch(context.Background(), query, nil)
+	require.NoError(t, err)
+	wg.Wait()
+
+	// Missing series.
+	secondary.SetFetchResult(newTestFetchResult(
+		map[string]float64{"foo": 1}), nil)
+	wg.Add(1)
+	_, err = s.Fetch(context.Background(), query, nil)
+	require.NoError(t, err)
+	wg.Wait()
+
+	// Secondary failure does not affect the primary result.
+	secondary.SetFetchResult(nil, errors.New("unavailable"))
+	wg.Add(1)
+	result, err = s.Fetch(context.Background(), query, nil)
+	require.NoError(t, err)
+	assert.Equal(t, expected, result)
+	wg.Wait()
+
+	counters := scope.Snapshot().Counters()
+	assert.Equal(t, int64(4), counters["sampled+"].Value())
+	assert.Equal(t, int64(3), counters["compared+"].Value())
+	assert.Equal(t, int64(1), counters["matches+"].Value())
+	assert.Equal(t, int64(1), counters["mismatches+reason=checksum"].Value())
+	assert.Equal(t, int64(1), counters["mismatches+reason=series-count"].Value())
+	assert.Equal(t, int64(1), counters["errors+"].Value())
+}
+
+func TestShadowStorageFetchTagsComparesResults(t *testing.T) {
+	var (
+		primary   = mock.NewMockStorage()
+		secondary = mock.NewMockStorage()
+		scope     = tally.NewTestScope("", nil)
+		metric    = models.Metric{
+			ID:   "foo",
+			Tags: models.Tags{{Name: "__name__", Value: "foo"}},
+		}
+	)
+	s, wg := newTestShadowStorage(primary, secondary, scope)
+
+	primary.SetFetchTagsResult(&storage.SearchResults{
+		Metrics: models.Metrics{&metric},
+	}, nil)
+	secondary.SetFetchTagsResult(&storage.SearchResults{
+		Metrics: models.Metrics{&metric},
+	}, nil)
+
+	wg.Add(1)
+	_, err := s.FetchTags(context.Background(), &storage.FetchQuery{Raw: "foo"}, nil)
+	require.NoError(t, err)
+	wg.Wait()
+
+	counters := scope.Snapshot().Counters()
+	assert.Equal(t, int64(1), counters["matches+"].Value())
+}
+
+func TestShadowStorageNotSampled(t *testing.T) {
+	var (
+		primary   = mock.NewMockStorage()
+		secondary = mock.NewMockStorage()
+		scope     = tally.NewTestScope("", nil)
+	)
+	s := NewStorage(primary, secondary, Options{Scope: scope})
+
+	primary.SetFetchResult(newTestFetchResult(map[string]float64{"foo": 1}), nil)
+	_, err := s.Fetch(context.Background(), &storage.FetchQuery{Raw: "foo"}, nil)
+	require.NoError(t, err)
+
+	counters := scope.Snapshot().Counters()
+	assert.Equal(t, int64(0), counters["sampled+"].Value())
+}
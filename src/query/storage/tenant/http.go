@@ -0,0 +1,58 @@
+// Copyright (c) 2018 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package tenant
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+)
+
+var (
+	errNoClientCertificate = errors.New("no verified client certificate to derive tenant from")
+	errNoCommonName        = errors.New("client certificate has no common name to derive tenant from")
+)
+
+// FromRequest returns the tenant ID of a request derived from the source of
+// the configuration.
+func FromRequest(r *http.Request, cfg Configuration) (string, error) {
+	switch cfg.Source {
+	case SourceHeader:
+		header := cfg.HeaderOrDefault()
+		id := strings.TrimSpace(r.Header.Get(header))
+		if id == "" {
+			return "", fmt.Errorf("no tenant in %s header", header)
+		}
+		return id, nil
+	case SourceTLS:
+		if r.TLS == nil || len(r.TLS.VerifiedChains) == 0 ||
+			len(r.TLS.VerifiedChains[0]) == 0 {
+			return "", errNoClientCertificate
+		}
+		id := r.TLS.VerifiedChains[0][0].Subject.CommonName
+		if id == "" {
+			return "", errNoCommonName
+		}
+		return id, nil
+	}
+	return "", fmt.Errorf("unknown tenant source: %v", cfg.Source)
+}
@@ -0,0 +1,80 @@
+// Copyright (c) 2018 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package tenant
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestFromRequestHeader(t *testing.T) {
+	req := httptest.NewRequest("GET", "/api/v1/query_range", nil)
+	_, err := FromRequest(req, Configuration{})
+	assert.Error(t, err)
+
+	req.Header.Set(DefaultHeader, "acme")
+	id, err := FromRequest(req, Configuration{})
+	require.NoError(t, err)
+	assert.Equal(t, "acme", id)
+
+	req.Header.Set("X-Org", "other")
+	id, err = FromRequest(req, Configuration{Header: "X-Org"})
+	require.NoError(t, err)
+	assert.Equal(t, "other", id)
+}
+
+func TestFromRequestTLS(t *testing.T) {
+	cfg := Configuration{Source: SourceTLS}
+
+	req := httptest.NewRequest("GET", "/api/v1/query_range", nil)
+	req.Header.Set(DefaultHeader, "spoofed")
+	_, err := FromRequest(req, cfg)
+	assert.Equal(t, errNoClientCertificate, err)
+
+	cert := &x509.Certificate{Subject: pkix.Name{CommonName: "acme"}}
+	req.TLS = &tls.ConnectionState{
+		VerifiedChains: [][]*x509.Certificate{{cert}},
+	}
+	id, err := FromRequest(req, cfg)
+	require.NoError(t, err)
+	assert.Equal(t, "acme", id)
+
+	cert.Subject.CommonName = ""
+	_, err = FromRequest(req, cfg)
+	assert.Equal(t, errNoCommonName, err)
+}
+
+func TestParseSource(t *testing.T) {
+	for _, valid := range ValidSources() {
+		parsed, err := ParseSource(valid.String())
+		require.NoError(t, err)
+		assert.Equal(t, valid, parsed)
+	}
+
+	_, err := ParseSource("jwt")
+	assert.Error(t, err)
+}
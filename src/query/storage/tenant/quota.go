@@ -0,0 +1,518 @@
+// Copyright (c) 2018 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package tenant
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/m3db/m3/src/query/util/logging"
+	"github.com/m3db/m3cluster/generated/proto/commonpb"
+	"github.com/m3db/m3cluster/kv"
+
+	"github.com/cespare/xxhash"
+	"github.com/uber-go/tally"
+	"go.uber.org/zap"
+)
+
+const (
+	// QuotasKey is the KV key of the per-tenant quota limits.
+	QuotasKey = "m3coordinator.tenant-quotas"
+
+	// DefaultActiveSeriesWindow is the default duration a series is
+	// considered active for after it was last written to.
+	DefaultActiveSeriesWindow = time.Hour
+
+	// DefaultUsageInterval is the default interval that ingest and query
+	// rates are computed over.
+	DefaultUsageInterval = 10 * time.Second
+
+	errQuotaExceededPrefix = "tenant quota exceeded"
+)
+
+var (
+	errActiveSeriesExceeded = fmt.Errorf(
+		"%s: max active series reached", errQuotaExceededPrefix)
+	errSamplesPerSecondExceeded = fmt.Errorf(
+		"%s: max samples per second reached", errQuotaExceededPrefix)
+	errQueryBytesExceeded = fmt.Errorf(
+		"%s: max query bytes reached", errQuotaExceededPrefix)
+)
+
+// IsQuotaExceeded returns true if the error is, or is a multi error that
+// contains, the rejection of a request that exceeds the quota of its tenant.
+func IsQuotaExceeded(err error) bool {
+	return err != nil && strings.Contains(err.Error(), errQuotaExceededPrefix)
+}
+
+// Limits are the quota limits of a tenant, a zero limit is unlimited. Limits
+// are enforced by each coordinator separately rather than across the
+// cluster, so a tenant spread over N coordinators can consume up to N times
+// its limits.
+type Limits struct {
+	// MaxActiveSeries is the max number of series written to within the
+	// active series window.
+	MaxActiveSeries int64 `json:"maxActiveSeries" yaml:"maxActiveSeries"`
+
+	// MaxSamplesPerSecond is the max rate of samples written, bursts of up
+	// to a second of samples are allowed.
+	MaxSamplesPerSecond float64 `json:"maxSamplesPerSecond" yaml:"maxSamplesPerSecond"`
+
+	// MaxQueryBytes is the max number of bytes fetched by a single query, it
+	// also bounds the bytes limit of the index query of each fetch.
+	MaxQueryBytes int64 `json:"maxQueryBytes" yaml:"maxQueryBytes"`
+}
+
+// QuotaLimits are the quota limits of all tenants.
+type QuotaLimits struct {
+	// Default are the limits of tenants without limits of their own.
+	Default Limits `json:"default"`
+
+	// Tenants are the limits of individual tenants, which replace the
+	// default limits entirely.
+	Tenants map[string]Limits `json:"tenants,omitempty"`
+}
+
+// For returns the limits of a tenant.
+func (l QuotaLimits) For(id string) Limits {
+	if limits, ok := l.Tenants[id]; ok {
+		return limits
+	}
+	return l.Default
+}
+
+// ParseQuotaLimits parses quota limits from their JSON representation.
+func ParseQuotaLimits(value string) (QuotaLimits, error) {
+	var limits QuotaLimits
+	if err := json.Unmarshal([]byte(value), &limits); err != nil {
+		return QuotaLimits{}, err
+	}
+	return limits, nil
+}
+
+// String returns the JSON representation of the quota limits.
+func (l QuotaLimits) String() string {
+	b, err := json.Marshal(l)
+	if err != nil {
+		return ""
+	}
+	return string(b)
+}
+
+// Usage is the current resource consumption of a tenant.
+type Usage struct {
+	ActiveSeries        int64   `json:"activeSeries"`
+	SamplesPerSecond    float64 `json:"samplesPerSecond"`
+	QueryBytesPerSecond float64 `json:"queryBytesPerSecond"`
+	RejectedWrites      int64   `json:"rejectedWrites"`
+	RejectedQueries     int64   `json:"rejectedQueries"`
+}
+
+// QuotaConfiguration is the configuration of per-tenant quotas, which are
+// enforced per coordinator and not per cluster.
+type QuotaConfiguration struct {
+	// ActiveSeriesWindow is how long a series counts towards the active
+	// series of its tenant after it was last written to, defaults to 1h.
+	// Tenants without active series are no longer tracked once they have
+	// made no requests for the window.
+	ActiveSeriesWindow time.Duration `yaml:"activeSeriesWindow"`
+
+	// Default are the limits of all tenants while no limits are set in KV.
+	Default Limits `yaml:"default"`
+}
+
+// QuotaOptions are the options of per-tenant quotas.
+type QuotaOptions struct {
+	// Store is the KV store watched for quota limits, the default limits
+	// are used if not set or while no limits are set in the store.
+	Store kv.Store
+
+	// Default are the limits of all tenants while no limits are set in KV.
+	Default Limits
+
+	ActiveSeriesWindow time.Duration
+	UsageInterval      time.Duration
+	NowFn              func() time.Time
+	Scope              tally.Scope
+}
+
+// Quotas tracks the resource consumption of tenants and rejects requests
+// that exceed their limits. Consumption is tracked per coordinator, so
+// limits apply to each coordinator a tenant writes to or queries.
+type Quotas interface {
+	// AllowWrite returns an error if writing samples to a series would
+	// exceed the limits of the tenant, and records the write otherwise.
+	AllowWrite(id string, seriesID string, samples int) error
+
+	// AllowQuery records the bytes fetched by a query and returns an error
+	// if they exceed the limits of the tenant.
+	AllowQuery(id string, bytes int64) error
+
+	// Limits returns the current quota limits.
+	Limits() QuotaLimits
+
+	// Usage returns the current consumption of each tenant.
+	Usage() map[string]Usage
+
+	// Close stops tracking consumption and watching for limit changes.
+	Close() error
+}
+
+type quotaMetrics struct {
+	writesRejectedSeries  tally.Counter
+	writesRejectedSamples tally.Counter
+	queriesRejectedBytes  tally.Counter
+}
+
+func newQuotaMetrics(scope tally.Scope) quotaMetrics {
+	return quotaMetrics{
+		writesRejectedSeries: scope.Tagged(map[string]string{
+			"reason": "active-series",
+		}).Counter("writes-rejected"),
+		writesRejectedSamples: scope.Tagged(map[string]string{
+			"reason": "samples-per-second",
+		}).Counter("writes-rejected"),
+		queriesRejectedBytes: scope.Tagged(map[string]string{
+			"reason": "query-bytes",
+		}).Counter("queries-rejected"),
+	}
+}
+
+type tenantUsage struct {
+	sync.Mutex
+
+	// series is the time each active series was last written to, keyed by
+	// the hash of its ID.
+	series     map[uint64]time.Time
+	tokens     float64
+	lastRefill time.Time
+
+	// samples and queryBytes are reset every usage interval.
+	samples    int64
+	queryBytes int64
+
+	rejectedWrites  int64
+	rejectedQueries int64
+	rates           Usage
+
+	// lastRequest is the time of the last write or query of the tenant,
+	// and evicted is set once the tenant is no longer tracked.
+	lastRequest time.Time
+	evicted     bool
+}
+
+type quotas struct {
+	sync.RWMutex
+
+	defaults           Limits
+	limits             QuotaLimits
+	tenants            map[string]*tenantUsage
+	lastUsage          time.Time
+	activeSeriesWindow time.Duration
+	usageInterval      time.Duration
+	nowFn              func() time.Time
+	watch              kv.ValueWatch
+	metrics            quotaMetrics
+	closeCh            chan struct{}
+	wg                 sync.WaitGroup
+}
+
+// NewQuotas returns per-tenant quotas, kept up to date with the limits set
+// in the KV store if one is given.
+func NewQuotas(opts QuotaOptions) (Quotas, error) {
+	if opts.ActiveSeriesWindow <= 0 {
+		opts.ActiveSeriesWindow = DefaultActiveSeriesWindow
+	}
+	if opts.UsageInterval <= 0 {
+		opts.UsageInterval = DefaultUsageInterval
+	}
+	if opts.NowFn == nil {
+		opts.NowFn = time.Now
+	}
+	if opts.Scope == nil {
+		opts.Scope = tally.NoopScope
+	}
+
+	q := &quotas{
+		defaults:           opts.Default,
+		limits:             QuotaLimits{Default: opts.Default},
+		tenants:            make(map[string]*tenantUsage),
+		lastUsage:          opts.NowFn(),
+		activeSeriesWindow: opts.ActiveSeriesWindow,
+		usageInterval:      opts.UsageInterval,
+		nowFn:              opts.NowFn,
+		metrics:            newQuotaMetrics(opts.Scope),
+		closeCh:            make(chan struct{}),
+	}
+
+	if opts.Store != nil {
+		watch, err := opts.Store.Watch(QuotasKey)
+		if err != nil {
+			return nil, err
+		}
+		q.watch = watch
+		go q.watchLimits()
+	}
+
+	q.wg.Add(1)
+	go q.trackUsage()
+	return q, nil
+}
+
+func (q *quotas) watchLimits() {
+	logger := logging.WithContext(context.Background())
+	for range q.watch.C() {
+		val := q.watch.Get()
+		if val == nil {
+			q.setLimits(QuotaLimits{Default: q.defaults})
+			continue
+		}
+
+		var protoValue commonpb.StringProto
+		if err := val.Unmarshal(&protoValue); err != nil {
+			logger.Error("unable to unmarshal tenant quotas", zap.Any("error", err))
+			continue
+		}
+
+		limits, err := ParseQuotaLimits(protoValue.Value)
+		if err != nil {
+			logger.Error("unable to parse tenant quotas", zap.Any("error", err))
+			continue
+		}
+
+		q.setLimits(limits)
+	}
+}
+
+func (q *quotas) setLimits(limits QuotaLimits) {
+	q.Lock()
+	q.limits = limits
+	q.Unlock()
+}
+
+func (q *quotas) trackUsage() {
+	defer q.wg.Done()
+
+	ticker := time.NewTicker(q.usageInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ticker.C:
+			q.updateUsage(q.nowFn())
+		case <-q.closeCh:
+			return
+		}
+	}
+}
+
+// updateUsage computes the rates of each tenant since it was last called,
+// expires the series that are no longer active and stops tracking tenants
+// that have been idle for the active series window.
+func (q *quotas) updateUsage(now time.Time) {
+	q.Lock()
+	elapsed := now.Sub(q.lastUsage).Seconds()
+	q.lastUsage = now
+	tenants := make(map[string]*tenantUsage, len(q.tenants))
+	for id, t := range q.tenants {
+		tenants[id] = t
+	}
+	q.Unlock()
+
+	if elapsed <= 0 {
+		return
+	}
+
+	var (
+		expired = now.Add(-q.activeSeriesWindow)
+		idle    []string
+	)
+	for id, t := range tenants {
+		t.Lock()
+		for hash, lastWrite := range t.series {
+			if lastWrite.Before(expired) {
+				delete(t.series, hash)
+			}
+		}
+		t.rates.SamplesPerSecond = float64(t.samples) / elapsed
+		t.rates.QueryBytesPerSecond = float64(t.queryBytes) / elapsed
+		t.samples = 0
+		t.queryBytes = 0
+		if t.idle(expired) {
+			idle = append(idle, id)
+		}
+		t.Unlock()
+	}
+
+	if len(idle) == 0 {
+		return
+	}
+
+	q.Lock()
+	for _, id := range idle {
+		t, ok := q.tenants[id]
+		if !ok {
+			continue
+		}
+		// The tenant may have made a request since it was found idle.
+		t.Lock()
+		if t.idle(expired) {
+			t.evicted = true
+			delete(q.tenants, id)
+		}
+		t.Unlock()
+	}
+	q.Unlock()
+}
+
+// idle returns true if the tenant has no active series and has made no
+// requests since the given time, the tenant must be locked.
+func (t *tenantUsage) idle(since time.Time) bool {
+	return len(t.series) == 0 && t.lastRequest.Before(since)
+}
+
+func (q *quotas) tenant(id string) (*tenantUsage, Limits) {
+	q.RLock()
+	t, ok := q.tenants[id]
+	limits := q.limits.For(id)
+	q.RUnlock()
+	if ok {
+		return t, limits
+	}
+
+	q.Lock()
+	t, ok = q.tenants[id]
+	if !ok {
+		t = &tenantUsage{series: make(map[uint64]time.Time)}
+		q.tenants[id] = t
+	}
+	q.Unlock()
+	return t, limits
+}
+
+// lockTenant returns the locked usage of a tenant and its limits, retrying
+// if the usage is evicted before it is locked so no request is recorded to
+// usage that is no longer tracked.
+func (q *quotas) lockTenant(id string, now time.Time) (*tenantUsage, Limits) {
+	for {
+		t, limits := q.tenant(id)
+		t.Lock()
+		if !t.evicted {
+			t.lastRequest = now
+			return t, limits
+		}
+		t.Unlock()
+	}
+}
+
+func (q *quotas) AllowWrite(id string, seriesID string, samples int) error {
+	var (
+		hash      = xxhash.Sum64([]byte(seriesID))
+		now       = q.nowFn()
+		t, limits = q.lockTenant(id, now)
+	)
+	defer t.Unlock()
+
+	_, active := t.series[hash]
+	if !active && limits.MaxActiveSeries > 0 &&
+		int64(len(t.series)) >= limits.MaxActiveSeries {
+		t.rejectedWrites++
+		q.metrics.writesRejectedSeries.Inc(1)
+		return errActiveSeriesExceeded
+	}
+
+	if rate := limits.MaxSamplesPerSecond; rate > 0 {
+		// Refill the bucket for the time since the last write, allowing
+		// bursts of up to a second of samples.
+		if t.lastRefill.IsZero() {
+			t.tokens = rate
+		} else {
+			t.tokens += now.Sub(t.lastRefill).Seconds() * rate
+		}
+		if t.tokens > rate {
+			t.tokens = rate
+		}
+		t.lastRefill = now
+
+		if t.tokens < float64(samples) {
+			t.rejectedWrites++
+			q.metrics.writesRejectedSamples.Inc(1)
+			return errSamplesPerSecondExceeded
+		}
+		t.tokens -= float64(samples)
+	}
+
+	t.series[hash] = now
+	t.samples += int64(samples)
+	return nil
+}
+
+func (q *quotas) AllowQuery(id string, bytes int64) error {
+	t, limits := q.lockTenant(id, q.nowFn())
+	defer t.Unlock()
+
+	t.queryBytes += bytes
+	if limits.MaxQueryBytes > 0 && bytes > limits.MaxQueryBytes {
+		t.rejectedQueries++
+		q.metrics.queriesRejectedBytes.Inc(1)
+		return errQueryBytesExceeded
+	}
+	return nil
+}
+
+func (q *quotas) Limits() QuotaLimits {
+	q.RLock()
+	defer q.RUnlock()
+	return q.limits
+}
+
+func (q *quotas) Usage() map[string]Usage {
+	q.RLock()
+	tenants := make(map[string]*tenantUsage, len(q.tenants))
+	for id, t := range q.tenants {
+		tenants[id] = t
+	}
+	q.RUnlock()
+
+	usage := make(map[string]Usage, len(tenants))
+	for id, t := range tenants {
+		t.Lock()
+		u := t.rates
+		u.ActiveSeries = int64(len(t.series))
+		u.RejectedWrites = t.rejectedWrites
+		u.RejectedQueries = t.rejectedQueries
+		t.Unlock()
+		usage[id] = u
+	}
+	return usage
+}
+
+func (q *quotas) Close() error {
+	close(q.closeCh)
+	if q.watch != nil {
+		q.watch.Close()
+	}
+	q.wg.Wait()
+	return nil
+}
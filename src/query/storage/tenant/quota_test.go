@@ -0,0 +1,173 @@
+// Copyright (c) 2018 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package tenant
+
+import (
+	"testing"
+	"time"
+
+	"github.com/m3db/m3cluster/generated/proto/commonpb"
+	"github.com/m3db/m3cluster/kv/mem"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func newTestQuotas(
+	t *testing.T,
+	opts QuotaOptions,
+) (*quotas, *time.Time) {
+	now := time.Unix(1000, 0)
+	opts.NowFn = func() time.Time { return now }
+	q, err := NewQuotas(opts)
+	require.NoError(t, err)
+	return q.(*quotas), &now
+}
+
+func TestQuotasActiveSeries(t *testing.T) {
+	q, now := newTestQuotas(t, QuotaOptions{
+		Default:            Limits{MaxActiveSeries: 2},
+		ActiveSeriesWindow: time.Minute,
+	})
+	defer q.Close()
+
+	require.NoError(t, q.AllowWrite("acme", "a", 1))
+	require.NoError(t, q.AllowWrite("acme", "b", 1))
+	// Active series can still be written to.
+	require.NoError(t, q.AllowWrite("acme", "a", 1))
+	assert.Equal(t, errActiveSeriesExceeded, q.AllowWrite("acme", "c", 1))
+	// Limits apply to each tenant separately.
+	require.NoError(t, q.AllowWrite("other", "c", 1))
+
+	// Series expire once not written to for the active series window.
+	*now = now.Add(30 * time.Second)
+	require.NoError(t, q.AllowWrite("acme", "a", 1))
+	*now = now.Add(45 * time.Second)
+	q.updateUsage(*now)
+	require.NoError(t, q.AllowWrite("acme", "c", 1))
+
+	usage := q.Usage()["acme"]
+	assert.Equal(t, int64(2), usage.ActiveSeries)
+	assert.Equal(t, int64(1), usage.RejectedWrites)
+}
+
+func TestQuotasSamplesPerSecond(t *testing.T) {
+	q, now := newTestQuotas(t, QuotaOptions{
+		Default: Limits{MaxSamplesPerSecond: 10},
+	})
+	defer q.Close()
+
+	require.NoError(t, q.AllowWrite("acme", "a", 6))
+	require.NoError(t, q.AllowWrite("acme", "a", 4))
+	assert.Equal(t, errSamplesPerSecondExceeded, q.AllowWrite("acme", "a", 1))
+
+	*now = now.Add(500 * time.Millisecond)
+	require.NoError(t, q.AllowWrite("acme", "a", 5))
+	assert.Equal(t, errSamplesPerSecondExceeded, q.AllowWrite("acme", "a", 1))
+
+	*now = now.Add(1500 * time.Millisecond)
+	q.updateUsage(*now)
+	usage := q.Usage()["acme"]
+	assert.Equal(t, 7.5, usage.SamplesPerSecond)
+	assert.Equal(t, int64(2), usage.RejectedWrites)
+}
+
+func TestQuotasQueryBytes(t *testing.T) {
+	q, now := newTestQuotas(t, QuotaOptions{
+		Default: Limits{MaxQueryBytes: 100},
+	})
+	defer q.Close()
+
+	require.NoError(t, q.AllowQuery("acme", 100))
+	err := q.AllowQuery("acme", 101)
+	assert.Equal(t, errQueryBytesExceeded, err)
+	assert.True(t, IsQuotaExceeded(err))
+	assert.False(t, IsQuotaExceeded(errNoTenant))
+
+	*now = now.Add(time.Second)
+	q.updateUsage(*now)
+	usage := q.Usage()["acme"]
+	assert.Equal(t, float64(201), usage.QueryBytesPerSecond)
+	assert.Equal(t, int64(1), usage.RejectedQueries)
+}
+
+func TestQuotasEvictsIdleTenants(t *testing.T) {
+	q, now := newTestQuotas(t, QuotaOptions{
+		ActiveSeriesWindow: time.Minute,
+	})
+	defer q.Close()
+
+	require.NoError(t, q.AllowWrite("acme", "a", 1))
+	require.NoError(t, q.AllowQuery("other", 1))
+
+	*now = now.Add(45 * time.Second)
+	require.NoError(t, q.AllowQuery("other", 1))
+	q.updateUsage(*now)
+	assert.Len(t, q.Usage(), 2)
+
+	// Tenants are evicted once they have no active series and have made no
+	// requests for the active series window.
+	*now = now.Add(30 * time.Second)
+	q.updateUsage(*now)
+	usage := q.Usage()
+	assert.Len(t, usage, 1)
+	assert.Contains(t, usage, "other")
+
+	*now = now.Add(time.Minute)
+	q.updateUsage(*now)
+	assert.Len(t, q.Usage(), 0)
+
+	// Evicted tenants are tracked again once they make a request.
+	require.NoError(t, q.AllowWrite("acme", "a", 1))
+	assert.Equal(t, int64(1), q.Usage()["acme"].ActiveSeries)
+}
+
+func TestQuotasWatchesLimits(t *testing.T) {
+	store := mem.NewStore()
+	q, _ := newTestQuotas(t, QuotaOptions{
+		Store:   store,
+		Default: Limits{MaxActiveSeries: 1},
+	})
+	defer q.Close()
+
+	limits := QuotaLimits{
+		Default: Limits{MaxActiveSeries: 5},
+		Tenants: map[string]Limits{"acme": Limits{MaxQueryBytes: 10}},
+	}
+	_, err := store.Set(QuotasKey, &commonpb.StringProto{Value: limits.String()})
+	require.NoError(t, err)
+	require.True(t, waitFor(func() bool {
+		return q.Limits().Default.MaxActiveSeries == 5
+	}))
+	assert.Equal(t, limits, q.Limits())
+	assert.Equal(t, int64(10), q.Limits().For("acme").MaxQueryBytes)
+	assert.Equal(t, int64(5), q.Limits().For("other").MaxActiveSeries)
+}
+
+func waitFor(fn func() bool) bool {
+	for i := 0; i < 100; i++ {
+		if fn() {
+			return true
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+	return false
+}
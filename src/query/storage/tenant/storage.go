@@ -0,0 +1,282 @@
+// Copyright (c) 2018 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package tenant
+
+import (
+	"context"
+	"errors"
+
+	"github.com/m3db/m3/src/query/block"
+	"github.com/m3db/m3/src/query/models"
+	"github.com/m3db/m3/src/query/storage"
+	"github.com/m3db/m3/src/query/util/querystats"
+
+	"github.com/uber-go/tally"
+)
+
+const (
+	// bytesPerDatapoint is the size of a timestamp and a value, used to
+	// estimate the bytes fetched by a query.
+	bytesPerDatapoint = 16
+
+	// queryBytesLimitHit is the limit recorded in the statistics of a query
+	// rejected for exceeding the query bytes quota of its tenant.
+	queryBytesLimitHit = "tenant-query-bytes"
+)
+
+var (
+	errNoTenant = errors.New("no tenant for request")
+)
+
+type tenantMetrics struct {
+	writes   tally.Counter
+	fetches  tally.Counter
+	rejected tally.Counter
+}
+
+func newTenantMetrics(scope tally.Scope) tenantMetrics {
+	return tenantMetrics{
+		writes:   scope.Counter("writes"),
+		fetches:  scope.Counter("fetches"),
+		rejected: scope.Counter("rejected-no-tenant"),
+	}
+}
+
+type tenantStorage struct {
+	storage.Storage
+
+	tagName string
+	quotas  Quotas
+	metrics tenantMetrics
+}
+
+// NewStorage returns a storage that scopes all writes and queries to the
+// tenant of their context. Writes have the tenant tag set to the tenant ID,
+// overriding any value supplied with the write, and queries only match
+// series with the tenant tag of the tenant. Requests without a tenant are
+// rejected, as are requests that exceed the quotas of their tenant if
+// quotas are given. The query bytes quota also bounds the index query bytes
+// limit of each fetch, and is then checked against the estimated size of the
+// fetched series.
+func NewStorage(
+	store storage.Storage,
+	tagName string,
+	quotas Quotas,
+	scope tally.Scope,
+) storage.Storage {
+	if tagName == "" {
+		tagName = DefaultTagName
+	}
+	if scope == nil {
+		scope = tally.NoopScope
+	}
+	return &tenantStorage{
+		Storage: store,
+		tagName: tagName,
+		quotas:  quotas,
+		metrics: newTenantMetrics(scope),
+	}
+}
+
+// scopeQuery returns a copy of the query that only matches the series of
+// the tenant of the context. Other matchers on the tenant tag are left in
+// place since all matchers must match, so they cannot widen the query.
+func (s *tenantStorage) scopeQuery(
+	ctx context.Context,
+	query *storage.FetchQuery,
+) (*storage.FetchQuery, error) {
+	id, ok := FromContext(ctx)
+	if !ok {
+		s.metrics.rejected.Inc(1)
+		return nil, errNoTenant
+	}
+
+	matcher, err := models.NewMatcher(models.MatchEqual, s.tagName, id)
+	if err != nil {
+		return nil, err
+	}
+
+	scoped := *query
+	scoped.TagMatchers = make(models.Matchers, 0, len(query.TagMatchers)+1)
+	scoped.TagMatchers = append(scoped.TagMatchers, query.TagMatchers...)
+	scoped.TagMatchers = append(scoped.TagMatchers, matcher)
+	s.metrics.fetches.Inc(1)
+	return &scoped, nil
+}
+
+func (s *tenantStorage) Fetch(
+	ctx context.Context,
+	query *storage.FetchQuery,
+	options *storage.FetchOptions,
+) (*storage.FetchResult, error) {
+	scoped, err := s.scopeQuery(ctx, query)
+	if err != nil {
+		return nil, err
+	}
+
+	result, err := s.Storage.Fetch(ctx, scoped, s.limitOptions(ctx, options))
+	if err != nil || result == nil || s.quotas == nil {
+		return result, err
+	}
+
+	var bytes int64
+	for _, series := range result.SeriesList {
+		bytes += int64(series.Len())*bytesPerDatapoint + tagsBytes(series.Tags)
+	}
+	if err := s.allowQuery(ctx, bytes); err != nil {
+		return nil, err
+	}
+	return result, nil
+}
+
+// limitOptions returns a copy of the fetch options with the index query
+// bytes limit bounded by the query bytes quota of the tenant of the context,
+// so a query that matches too many series is cut short by the nodes rather
+// than rejected once fully fetched.
+func (s *tenantStorage) limitOptions(
+	ctx context.Context,
+	options *storage.FetchOptions,
+) *storage.FetchOptions {
+	if s.quotas == nil {
+		return options
+	}
+	id, _ := FromContext(ctx)
+	maxBytes := s.quotas.Limits().For(id).MaxQueryBytes
+	if maxBytes <= 0 {
+		return options
+	}
+
+	var limited storage.FetchOptions
+	if options != nil {
+		limited = *options
+	}
+	bytesLimit := int64(limited.FetchControls.BytesLimit)
+	if bytesLimit <= 0 || bytesLimit > maxBytes {
+		limited.FetchControls.BytesLimit = int(maxBytes)
+	}
+	return &limited
+}
+
+// allowQuery checks the bytes fetched by a query against the query bytes
+// quota of the tenant of the context.
+func (s *tenantStorage) allowQuery(ctx context.Context, bytes int64) error {
+	id, _ := FromContext(ctx)
+	if err := s.quotas.AllowQuery(id, bytes); err != nil {
+		querystats.AddLimitHit(ctx, queryBytesLimitHit)
+		return err
+	}
+	return nil
+}
+
+func tagsBytes(tags models.Tags) int64 {
+	var bytes int64
+	for _, tag := range tags {
+		bytes += int64(len(tag.Name) + len(tag.Value))
+	}
+	return bytes
+}
+
+// blocksBytes estimates the bytes fetched for the blocks from the number of
+// series and steps of each block, without decoding the values of the series.
+func blocksBytes(blocks []block.Block) (int64, error) {
+	var bytes int64
+	for _, b := range blocks {
+		iter, err := b.SeriesIter()
+		if err != nil {
+			return 0, err
+		}
+		steps := int64(iter.Meta().Bounds.Steps())
+		for _, meta := range iter.SeriesMeta() {
+			bytes += steps*bytesPerDatapoint + tagsBytes(meta.Tags)
+		}
+		iter.Close()
+	}
+	return bytes, nil
+}
+
+func (s *tenantStorage) FetchTags(
+	ctx context.Context,
+	query *storage.FetchQuery,
+	options *storage.FetchOptions,
+) (*storage.SearchResults, error) {
+	scoped, err := s.scopeQuery(ctx, query)
+	if err != nil {
+		return nil, err
+	}
+	return s.Storage.FetchTags(ctx, scoped, s.limitOptions(ctx, options))
+}
+
+func (s *tenantStorage) FetchBlocks(
+	ctx context.Context,
+	query *storage.FetchQuery,
+	options *storage.FetchOptions,
+) (block.Result, error) {
+	scoped, err := s.scopeQuery(ctx, query)
+	if err != nil {
+		return block.Result{}, err
+	}
+
+	result, err := s.Storage.FetchBlocks(ctx, scoped, s.limitOptions(ctx, options))
+	if err != nil || s.quotas == nil {
+		return result, err
+	}
+
+	bytes, err := blocksBytes(result.Blocks)
+	if err == nil {
+		err = s.allowQuery(ctx, bytes)
+	}
+	if err != nil {
+		for _, b := range result.Blocks {
+			b.Close()
+		}
+		return block.Result{}, err
+	}
+	return result, nil
+}
+
+func (s *tenantStorage) Write(
+	ctx context.Context,
+	query *storage.WriteQuery,
+) error {
+	id, ok := FromContext(ctx)
+	if !ok {
+		s.metrics.rejected.Inc(1)
+		return errNoTenant
+	}
+
+	tags := make(models.Tags, 0, len(query.Tags)+1)
+	for _, tag := range query.Tags {
+		if tag.Name != s.tagName {
+			tags = append(tags, tag)
+		}
+	}
+
+	scoped := *query
+	scoped.Tags = tags.AddTag(models.Tag{Name: s.tagName, Value: id})
+	if s.quotas != nil {
+		err := s.quotas.AllowWrite(id, scoped.Tags.ID(), len(scoped.Datapoints))
+		if err != nil {
+			return err
+		}
+	}
+	s.metrics.writes.Inc(1)
+	return s.Storage.Write(ctx, &scoped)
+}
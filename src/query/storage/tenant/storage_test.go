@@ -0,0 +1,259 @@
+// Copyright (c) 2018 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package tenant
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/m3db/m3/src/query/block"
+	"github.com/m3db/m3/src/query/models"
+	"github.com/m3db/m3/src/query/storage"
+	"github.com/m3db/m3/src/query/storage/mock"
+	"github.com/m3db/m3/src/query/ts"
+	"github.com/m3db/m3/src/query/util/querystats"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// recordingStorage records the fetch queries it receives.
+type recordingStorage struct {
+	mock.Storage
+
+	fetches []*storage.FetchQuery
+	options []*storage.FetchOptions
+}
+
+func (s *recordingStorage) Fetch(
+	ctx context.Context,
+	query *storage.FetchQuery,
+	options *storage.FetchOptions,
+) (*storage.FetchResult, error) {
+	s.fetches = append(s.fetches, query)
+	s.options = append(s.options, options)
+	return s.Storage.Fetch(ctx, query, options)
+}
+
+func (s *recordingStorage) FetchTags(
+	ctx context.Context,
+	query *storage.FetchQuery,
+	options *storage.FetchOptions,
+) (*storage.SearchResults, error) {
+	s.fetches = append(s.fetches, query)
+	s.options = append(s.options, options)
+	return s.Storage.FetchTags(ctx, query, options)
+}
+
+func newTestMatchers(t *testing.T) models.Matchers {
+	nameMatcher, err := models.NewMatcher(models.MatchEqual, models.MetricName, "requests")
+	require.NoError(t, err)
+	// A matcher on the tenant tag cannot widen the query to other tenants.
+	tenantMatcher, err := models.NewMatcher(models.MatchRegexp, DefaultTagName, ".*")
+	require.NoError(t, err)
+	return models.Matchers{nameMatcher, tenantMatcher}
+}
+
+func TestStorageScopesFetches(t *testing.T) {
+	underlying := &recordingStorage{Storage: mock.NewMockStorage()}
+	store := NewStorage(underlying, "", nil, nil)
+
+	ctx := NewContext(context.Background(), "acme")
+	query := &storage.FetchQuery{TagMatchers: newTestMatchers(t)}
+
+	_, err := store.Fetch(ctx, query, nil)
+	require.NoError(t, err)
+	_, err = store.FetchTags(ctx, query, nil)
+	require.NoError(t, err)
+
+	require.Len(t, underlying.fetches, 2)
+	for _, fetch := range underlying.fetches {
+		require.Len(t, fetch.TagMatchers, 3)
+		last := fetch.TagMatchers[2]
+		assert.Equal(t, models.MatchEqual, last.Type)
+		assert.Equal(t, DefaultTagName, last.Name)
+		assert.Equal(t, "acme", last.Value)
+	}
+
+	// The query of the caller is not modified.
+	assert.Len(t, query.TagMatchers, 2)
+}
+
+func TestStorageScopesWrites(t *testing.T) {
+	underlying := mock.NewMockStorage()
+	store := NewStorage(underlying, "org", nil, nil)
+
+	ctx := NewContext(context.Background(), "acme")
+	query := &storage.WriteQuery{
+		Tags: models.Tags{
+			{Name: models.MetricName, Value: "requests"},
+			{Name: "org", Value: "other"},
+		},
+	}
+	require.NoError(t, store.Write(ctx, query))
+
+	require.Len(t, underlying.Writes(), 1)
+	assert.Equal(t, models.Tags{
+		{Name: models.MetricName, Value: "requests"},
+		{Name: "org", Value: "acme"},
+	}, underlying.Writes()[0].Tags)
+
+	// The write of the caller is not modified.
+	value, ok := query.Tags.Get("org")
+	require.True(t, ok)
+	assert.Equal(t, "other", value)
+}
+
+func TestStorageRejectsWithoutTenant(t *testing.T) {
+	underlying := mock.NewMockStorage()
+	store := NewStorage(underlying, "", nil, nil)
+
+	ctx := context.Background()
+	_, err := store.Fetch(ctx, &storage.FetchQuery{}, nil)
+	assert.Equal(t, errNoTenant, err)
+	_, err = store.FetchTags(ctx, &storage.FetchQuery{}, nil)
+	assert.Equal(t, errNoTenant, err)
+	_, err = store.FetchBlocks(ctx, &storage.FetchQuery{}, nil)
+	assert.Equal(t, errNoTenant, err)
+	assert.Equal(t, errNoTenant, store.Write(ctx, &storage.WriteQuery{}))
+	assert.Empty(t, underlying.Writes())
+
+	_, err = store.Fetch(NewContext(ctx, ""), &storage.FetchQuery{}, nil)
+	assert.Equal(t, errNoTenant, err)
+}
+
+func TestStorageEnforcesQuotas(t *testing.T) {
+	quotas, err := NewQuotas(QuotaOptions{
+		Default: Limits{MaxActiveSeries: 1, MaxQueryBytes: 32},
+	})
+	require.NoError(t, err)
+	defer quotas.Close()
+
+	underlying := mock.NewMockStorage()
+	store := NewStorage(underlying, "", quotas, nil)
+
+	ctx := NewContext(context.Background(), "acme")
+	write := func(name string) error {
+		return store.Write(ctx, &storage.WriteQuery{
+			Tags:       models.Tags{{Name: models.MetricName, Value: name}},
+			Datapoints: ts.Datapoints{{Timestamp: time.Now(), Value: 1}},
+		})
+	}
+	require.NoError(t, write("requests"))
+	require.NoError(t, write("requests"))
+	err = write("errors")
+	require.Error(t, err)
+	assert.True(t, IsQuotaExceeded(err))
+	assert.Len(t, underlying.Writes(), 2)
+
+	// Two datapoints are within the query bytes limit, three are not.
+	values := ts.Datapoints{
+		{Timestamp: time.Now(), Value: 1},
+		{Timestamp: time.Now(), Value: 2},
+	}
+	underlying.SetFetchResult(&storage.FetchResult{
+		SeriesList: ts.SeriesList{ts.NewSeries("requests", values, nil)},
+	}, nil)
+	_, err = store.Fetch(ctx, &storage.FetchQuery{}, nil)
+	require.NoError(t, err)
+
+	values = append(values, ts.Datapoint{Timestamp: time.Now(), Value: 3})
+	underlying.SetFetchResult(&storage.FetchResult{
+		SeriesList: ts.SeriesList{ts.NewSeries("requests", values, nil)},
+	}, nil)
+	stats := &querystats.Stats{}
+	_, err = store.Fetch(querystats.NewContext(ctx, stats), &storage.FetchQuery{}, nil)
+	require.Error(t, err)
+	assert.True(t, IsQuotaExceeded(err))
+	assert.Equal(t, []string{queryBytesLimitHit}, stats.LimitsHit())
+}
+
+func TestStorageEnforcesQuotasOnFetchBlocks(t *testing.T) {
+	quotas, err := NewQuotas(QuotaOptions{
+		Default: Limits{MaxQueryBytes: 32},
+	})
+	require.NoError(t, err)
+	defer quotas.Close()
+
+	underlying := mock.NewMockStorage()
+	store := NewStorage(underlying, "", quotas, nil)
+	ctx := NewContext(context.Background(), "acme")
+
+	newResult := func(steps int) block.Result {
+		meta := block.Metadata{Bounds: models.Bounds{
+			Start:    time.Now(),
+			Duration: time.Duration(steps) * time.Minute,
+			StepSize: time.Minute,
+		}}
+		builder := block.NewColumnBlockBuilder(meta, []block.SeriesMeta{{}})
+		require.NoError(t, builder.AddCols(steps))
+		for i := 0; i < steps; i++ {
+			require.NoError(t, builder.AppendValue(i, float64(i)))
+		}
+		return block.Result{Blocks: []block.Block{builder.Build()}}
+	}
+
+	// Two steps of a series are within the query bytes limit, three are not.
+	underlying.SetFetchBlocksResult(newResult(2), nil)
+	result, err := store.FetchBlocks(ctx, &storage.FetchQuery{}, nil)
+	require.NoError(t, err)
+	assert.Len(t, result.Blocks, 1)
+
+	underlying.SetFetchBlocksResult(newResult(3), nil)
+	stats := &querystats.Stats{}
+	_, err = store.FetchBlocks(querystats.NewContext(ctx, stats), &storage.FetchQuery{}, nil)
+	require.Error(t, err)
+	assert.True(t, IsQuotaExceeded(err))
+	assert.Equal(t, []string{queryBytesLimitHit}, stats.LimitsHit())
+}
+
+func TestStorageBoundsFetchBytesLimitByQuota(t *testing.T) {
+	quotas, err := NewQuotas(QuotaOptions{
+		Default: Limits{MaxQueryBytes: 32},
+	})
+	require.NoError(t, err)
+	defer quotas.Close()
+
+	underlying := &recordingStorage{Storage: mock.NewMockStorage()}
+	store := NewStorage(underlying, "", quotas, nil)
+	ctx := NewContext(context.Background(), "acme")
+
+	_, err = store.Fetch(ctx, &storage.FetchQuery{}, nil)
+	require.NoError(t, err)
+
+	options := &storage.FetchOptions{Limit: 10}
+	options.FetchControls.BytesLimit = 64
+	_, err = store.FetchTags(ctx, &storage.FetchQuery{}, options)
+	require.NoError(t, err)
+
+	options.FetchControls.BytesLimit = 16
+	_, err = store.Fetch(ctx, &storage.FetchQuery{}, options)
+	require.NoError(t, err)
+
+	require.Len(t, underlying.options, 3)
+	assert.Equal(t, 32, underlying.options[0].FetchControls.BytesLimit)
+	assert.Equal(t, 32, underlying.options[1].FetchControls.BytesLimit)
+	assert.Equal(t, 10, underlying.options[1].Limit)
+	assert.Equal(t, 16, underlying.options[2].FetchControls.BytesLimit)
+	// The options of the caller are left untouched.
+	assert.Equal(t, 16, options.FetchControls.BytesLimit)
+}
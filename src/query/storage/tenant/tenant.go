@@ -0,0 +1,148 @@
+// Copyright (c) 2018 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+// Package tenant scopes the writes and queries of a coordinator to the
+// tenant of each request, so that tenants sharing a cluster cannot read
+// each other's series.
+package tenant
+
+import (
+	"context"
+	"errors"
+	"fmt"
+)
+
+const (
+	// DefaultHeader is the default header with the tenant ID of a request.
+	DefaultHeader = "M3-Tenant"
+
+	// DefaultTagName is the default name of the tag that holds the tenant
+	// ID of every series.
+	DefaultTagName = "tenant"
+)
+
+var (
+	errSourceUnspecified = errors.New("tenant source unspecified")
+)
+
+// Source is where the tenant ID of a request is derived from.
+type Source uint
+
+const (
+	// SourceHeader derives the tenant ID from a request header, which must
+	// only be set by a trusted proxy.
+	SourceHeader Source = iota
+	// SourceTLS derives the tenant ID from the common name of the verified
+	// client certificate of the request.
+	SourceTLS
+)
+
+// ValidSources returns the valid tenant sources.
+func ValidSources() []Source {
+	return []Source{SourceHeader, SourceTLS}
+}
+
+func (s Source) String() string {
+	switch s {
+	case SourceHeader:
+		return "header"
+	case SourceTLS:
+		return "tls"
+	}
+	return "unknown"
+}
+
+// ParseSource parses a Source from a string.
+func ParseSource(str string) (Source, error) {
+	var s Source
+	if str == "" {
+		return s, errSourceUnspecified
+	}
+	for _, valid := range ValidSources() {
+		if str == valid.String() {
+			s = valid
+			return s, nil
+		}
+	}
+	return s, fmt.Errorf("invalid tenant Source '%s' valid sources are: %v",
+		str, ValidSources())
+}
+
+// UnmarshalYAML unmarshals a Source into a valid type from string.
+func (s *Source) UnmarshalYAML(unmarshal func(interface{}) error) error {
+	var str string
+	if err := unmarshal(&str); err != nil {
+		return err
+	}
+	parsed, err := ParseSource(str)
+	if err != nil {
+		return err
+	}
+	*s = parsed
+	return nil
+}
+
+// Configuration is the multi-tenancy configuration of a coordinator.
+type Configuration struct {
+	// Source is where the tenant ID of a request is derived from, "header"
+	// or "tls".
+	Source Source `yaml:"source"`
+
+	// Header is the header with the tenant ID when the source is "header",
+	// defaults to M3-Tenant.
+	Header string `yaml:"header"`
+
+	// TagName is the name of the tag that holds the tenant ID of every
+	// series, defaults to "tenant".
+	TagName string `yaml:"tagName"`
+
+	// Quotas are the per-tenant quotas enforced by this coordinator, tenants
+	// are unlimited if not set.
+	Quotas *QuotaConfiguration `yaml:"quotas"`
+}
+
+// HeaderOrDefault returns the header with the tenant ID.
+func (c Configuration) HeaderOrDefault() string {
+	if c.Header == "" {
+		return DefaultHeader
+	}
+	return c.Header
+}
+
+// TagNameOrDefault returns the name of the tag that holds the tenant ID.
+func (c Configuration) TagNameOrDefault() string {
+	if c.TagName == "" {
+		return DefaultTagName
+	}
+	return c.TagName
+}
+
+type tenantKey struct{}
+
+// NewContext returns a context with the tenant ID of a request.
+func NewContext(ctx context.Context, id string) context.Context {
+	return context.WithValue(ctx, tenantKey{}, id)
+}
+
+// FromContext returns the tenant ID of a context, if any.
+func FromContext(ctx context.Context) (string, bool) {
+	id, ok := ctx.Value(tenantKey{}).(string)
+	return id, ok && id != ""
+}
@@ -29,8 +29,11 @@ import (
 	"github.com/m3db/m3/src/dbnode/client"
 	"github.com/m3db/m3/src/dbnode/encoding"
 	"github.com/m3db/m3/src/dbnode/storage/index"
+	"github.com/m3db/m3/src/dbnode/topology"
 	"github.com/m3db/m3x/ident"
 	xtime "github.com/m3db/m3x/time"
+
+	opentracing "github.com/opentracing/opentracing-go"
 )
 
 const (
@@ -114,6 +117,29 @@ func (s *AsyncSession) WriteTagged(namespace, id ident.ID, tags ident.TagIterato
 	return s.session.WriteTagged(namespace, id, tags, t, value, unit, annotation)
 }
 
+// WriteTaggedTraced writes a value to the database for an ID and given tags
+// as part of a trace
+func (s *AsyncSession) WriteTaggedTraced(spanCtx opentracing.SpanContext, namespace, id ident.ID, tags ident.TagIterator, t time.Time, value float64, unit xtime.Unit, annotation []byte) error {
+	s.RLock()
+	defer s.RUnlock()
+	if s.err != nil {
+		return s.err
+	}
+
+	return s.session.WriteTaggedTraced(spanCtx, namespace, id, tags, t, value, unit, annotation)
+}
+
+// WriteAsync writes a batch of datapoints without blocking
+func (s *AsyncSession) WriteAsync(namespace ident.ID, writes []client.AsyncWrite, fn client.AsyncWriteCompletionFn) error {
+	s.RLock()
+	defer s.RUnlock()
+	if s.err != nil {
+		return s.err
+	}
+
+	return s.session.WriteAsync(namespace, writes, fn)
+}
+
 // Fetch fetches values from the database for an ID
 func (s *AsyncSession) Fetch(namespace, id ident.ID, startInclusive, endExclusive time.Time) (encoding.SeriesIterator, error) {
 	s.RLock()
@@ -158,6 +184,67 @@ func (s *AsyncSession) FetchTaggedIDs(namespace ident.ID, q index.Query, opts in
 	return s.session.FetchTaggedIDs(namespace, q, opts)
 }
 
+// FetchTaggedPages resolves the provided query to known IDs, and fetches the
+// data for them a page at a time.
+func (s *AsyncSession) FetchTaggedPages(namespace ident.ID, q index.Query, opts index.QueryOptions, fn client.FetchTaggedPageFn) (bool, error) {
+	s.RLock()
+	defer s.RUnlock()
+	if s.err != nil {
+		return false, s.err
+	}
+
+	return s.session.FetchTaggedPages(namespace, q, opts, fn)
+}
+
+// FetchTaggedIDsPages resolves the provided query to known IDs a page at a time.
+func (s *AsyncSession) FetchTaggedIDsPages(namespace ident.ID, q index.Query, opts index.QueryOptions, fn client.FetchTaggedIDsPageFn) (bool, error) {
+	s.RLock()
+	defer s.RUnlock()
+	if s.err != nil {
+		return false, s.err
+	}
+
+	return s.session.FetchTaggedIDsPages(namespace, q, opts, fn)
+}
+
+// FetchTaggedStream resolves the provided query to known IDs, and streams the
+// data for them shard by shard.
+func (s *AsyncSession) FetchTaggedStream(namespace ident.ID, q index.Query, opts index.QueryOptions, streamOpts client.FetchTaggedStreamOptions) (client.FetchTaggedStreamIterator, error) {
+	s.RLock()
+	defer s.RUnlock()
+	if s.err != nil {
+		return nil, s.err
+	}
+
+	return s.session.FetchTaggedStream(namespace, q, opts, streamOpts)
+}
+
+// WithWriteConsistencyLevel returns a view of the session whose writes use
+// the write consistency level rather than that of the session, the view
+// fails every call if the session is not initialized when it is created.
+func (s *AsyncSession) WithWriteConsistencyLevel(level topology.ConsistencyLevel) client.Session {
+	s.RLock()
+	defer s.RUnlock()
+	if s.err != nil {
+		return &AsyncSession{err: s.err}
+	}
+
+	return s.session.WithWriteConsistencyLevel(level)
+}
+
+// WithReadConsistencyLevel returns a view of the session whose fetches use
+// the read consistency level rather than that of the session, the view
+// fails every call if the session is not initialized when it is created.
+func (s *AsyncSession) WithReadConsistencyLevel(level topology.ReadConsistencyLevel) client.Session {
+	s.RLock()
+	defer s.RUnlock()
+	if s.err != nil {
+		return &AsyncSession{err: s.err}
+	}
+
+	return s.session.WithReadConsistencyLevel(level)
+}
+
 // ShardID returns the given shard for an ID for callers
 // to easily discern what shard is failing when operations
 // for given IDs begin failing
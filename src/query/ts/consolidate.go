@@ -0,0 +1,136 @@
+// Copyright (c) 2018 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package ts
+
+import (
+	"math"
+	"time"
+
+	"github.com/m3db/m3/src/query/errors"
+	"github.com/m3db/m3/src/query/models"
+)
+
+// Consolidate returns the series with at most maxDatapoints values from the
+// start, consolidating consecutive values with the consolidation type. Values
+// before the start are dropped, and series with at most maxDatapoints values
+// from the start are returned as is.
+func (seriesList SeriesList) Consolidate(
+	start time.Time,
+	maxDatapoints int,
+	consolidation models.ConsolidationType,
+) (SeriesList, error) {
+	if maxDatapoints <= 0 {
+		return seriesList, nil
+	}
+
+	consolidatedList := make(SeriesList, len(seriesList))
+	for i, s := range seriesList {
+		fixedRes, ok := s.Values().(FixedResolutionMutableValues)
+		if !ok {
+			return nil, errors.ErrOnlyFixedResSupported
+		}
+
+		vals := consolidateValues(fixedRes, start, maxDatapoints, consolidation)
+		consolidatedList[i] = NewSeries(s.Name(), vals, s.Tags)
+	}
+
+	return consolidatedList, nil
+}
+
+func consolidateValues(
+	values FixedResolutionMutableValues,
+	start time.Time,
+	maxDatapoints int,
+	consolidation models.ConsolidationType,
+) FixedResolutionMutableValues {
+	first := 0
+	if values.StartTime().Before(start) {
+		first = values.StepAtTime(start)
+		if values.StartTimeForStep(first).Before(start) {
+			first++
+		}
+	}
+
+	numValues := values.Len() - first
+	if numValues <= maxDatapoints {
+		return values
+	}
+
+	// NB: Each consolidated value covers the same number of steps, so the
+	// result has a fixed resolution which is a multiple of the original.
+	factor := (numValues + maxDatapoints - 1) / maxDatapoints
+	numSteps := (numValues + factor - 1) / factor
+	consolidated := newFixedStepValues(values.Resolution()*time.Duration(factor),
+		numSteps, math.NaN(), values.StartTimeForStep(first))
+	for step := 0; step < numSteps; step++ {
+		from := first + step*factor
+		to := from + factor
+		if to > values.Len() {
+			to = values.Len()
+		}
+
+		consolidated.values[step] = consolidateRange(values, from, to, consolidation)
+	}
+
+	return consolidated
+}
+
+// consolidateRange consolidates the values of the steps in [from, to),
+// ignoring NaN values.
+func consolidateRange(
+	values Values,
+	from, to int,
+	consolidation models.ConsolidationType,
+) float64 {
+	var (
+		result = math.NaN()
+		sum    float64
+		count  int
+	)
+	for i := from; i < to; i++ {
+		v := values.ValueAt(i)
+		if math.IsNaN(v) {
+			continue
+		}
+
+		switch consolidation {
+		case models.ConsolidationMin:
+			if count == 0 || v < result {
+				result = v
+			}
+		case models.ConsolidationMax:
+			if count == 0 || v > result {
+				result = v
+			}
+		case models.ConsolidationLast:
+			result = v
+		case models.ConsolidationAvg:
+			sum += v
+		}
+		count++
+	}
+
+	if consolidation == models.ConsolidationAvg && count > 0 {
+		return sum / float64(count)
+	}
+
+	return result
+}
@@ -0,0 +1,84 @@
+// Copyright (c) 2018 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package ts
+
+import (
+	"math"
+	"testing"
+	"time"
+
+	"github.com/m3db/m3/src/query/models"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestConsolidate(t *testing.T) {
+	start := time.Unix(1500000000, 0)
+	values := NewFixedStepValues(time.Minute, 8, math.NaN(), start.Add(-time.Minute))
+	for i, v := range []float64{100, 1, 2, math.NaN(), 4, 5, 6, 7} {
+		values.SetValueAt(i, v)
+	}
+	seriesList := SeriesList{NewSeries("foo", values, models.Tags{})}
+
+	tests := []struct {
+		consolidation models.ConsolidationType
+		expected      []float64
+	}{
+		{models.ConsolidationAvg, []float64{1.5, 5, 7}},
+		{models.ConsolidationMin, []float64{1, 4, 7}},
+		{models.ConsolidationMax, []float64{2, 6, 7}},
+		{models.ConsolidationLast, []float64{2, 6, 7}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.consolidation.String(), func(t *testing.T) {
+			consolidated, err := seriesList.Consolidate(start, 3, tt.consolidation)
+			require.NoError(t, err)
+			require.Len(t, consolidated, 1)
+
+			vals, ok := consolidated[0].Values().(FixedResolutionMutableValues)
+			require.True(t, ok)
+			assert.Equal(t, 3*time.Minute, vals.Resolution())
+			assert.Equal(t, start, vals.StartTime())
+
+			actual := make([]float64, vals.Len())
+			for i := range actual {
+				actual[i] = vals.ValueAt(i)
+			}
+			assert.InDeltaSlice(t, tt.expected, actual, 0.0001)
+		})
+	}
+}
+
+func TestConsolidateWithinMaxDatapoints(t *testing.T) {
+	start := time.Unix(1500000000, 0)
+	values := NewFixedStepValues(time.Minute, 5, 1, start)
+	seriesList := SeriesList{NewSeries("foo", values, models.Tags{})}
+
+	consolidated, err := seriesList.Consolidate(start, 5, models.ConsolidationAvg)
+	require.NoError(t, err)
+	assert.Equal(t, values, consolidated[0].Values())
+
+	_, err = SeriesList{NewSeries("foo", Datapoints{}, models.Tags{})}.
+		Consolidate(start, 5, models.ConsolidationAvg)
+	assert.Error(t, err)
+}
@@ -56,7 +56,13 @@ func (s *Series) Values() Values { return s.vals }
 
 // Align adjusts the datapoints to start, end and a fixed interval
 func (s *Series) Align(start, end time.Time, interval time.Duration) (*Series, error) {
-	fixedVals, err := alignValues(s.Values(), start, end, interval)
+	return s.AlignWithLookback(start, end, interval, 0)
+}
+
+// AlignWithLookback adjusts the datapoints to start, end and a fixed interval, carrying each datapoint
+// forward for at most the lookback
+func (s *Series) AlignWithLookback(start, end time.Time, interval, lookback time.Duration) (*Series, error) {
+	fixedVals, err := alignValues(s.Values(), start, end, interval, lookback)
 	if err != nil {
 		return nil, err
 	}
@@ -64,10 +70,10 @@ func (s *Series) Align(start, end time.Time, interval time.Duration) (*Series, e
 	return NewSeries(s.name, fixedVals, s.Tags), nil
 }
 
-func alignValues(values Values, start, end time.Time, interval time.Duration) (FixedResolutionMutableValues, error) {
+func alignValues(values Values, start, end time.Time, interval, lookback time.Duration) (FixedResolutionMutableValues, error) {
 	switch vals := values.(type) {
 	case Datapoints:
-		return RawPointsToFixedStep(vals, start, end, interval)
+		return RawPointsToFixedStepWithLookback(vals, start, end, interval, lookback)
 	case FixedResolutionMutableValues:
 		// TODO: Align fixed resolution as well once storages can return those directly
 		return vals, nil
@@ -114,3 +120,23 @@ func (seriesList SeriesList) Align(start, end time.Time, interval time.Duration)
 
 	return alignedList, nil
 }
+
+// AlignWithLookback aligns each series to the given start, end and step, carrying datapoints forward for
+// at most the lookback of the metric type of each series.
+func (seriesList SeriesList) AlignWithLookback(
+	start, end time.Time,
+	interval time.Duration,
+	lookback models.LookbackOptions,
+) (SeriesList, error) {
+	alignedList := make(SeriesList, len(seriesList))
+	for i, s := range seriesList {
+		alignedSeries, err := s.AlignWithLookback(start, end, interval, lookback.Lookback(s.Tags))
+		if err != nil {
+			return nil, err
+		}
+
+		alignedList[i] = alignedSeries
+	}
+
+	return alignedList, nil
+}
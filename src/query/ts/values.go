@@ -142,6 +142,19 @@ func newFixedStepValues(resolution time.Duration, numSteps int, initialValue flo
 
 // RawPointsToFixedStep converts raw datapoints into the interval required within the bounds specified. For every time step, it finds the closest point.
 func RawPointsToFixedStep(datapoints Datapoints, start time.Time, end time.Time, interval time.Duration) (FixedResolutionMutableValues, error) {
+	return RawPointsToFixedStepWithLookback(datapoints, start, end, interval, 0)
+}
+
+// RawPointsToFixedStepWithLookback converts raw datapoints into the interval required within the bounds specified,
+// each step takes the value of the latest datapoint at or before it that is within the lookback. A zero lookback
+// keeps the behavior of RawPointsToFixedStep.
+func RawPointsToFixedStepWithLookback(
+	datapoints Datapoints,
+	start time.Time,
+	end time.Time,
+	interval time.Duration,
+	lookback time.Duration,
+) (FixedResolutionMutableValues, error) {
 	if end.Before(start) {
 		return nil, fmt.Errorf("start cannot be after end, start: %v, end: %v", start, end)
 	}
@@ -158,6 +171,11 @@ func RawPointsToFixedStep(datapoints Datapoints, start time.Time, end time.Time,
 	}
 
 	fixStepValues := newFixedStepValues(interval, numSteps, math.NaN(), start)
+	if lookback > 0 {
+		fillWithLookback(fixStepValues.values, datapoints, start, interval, lookback)
+		return fixStepValues, nil
+	}
+
 	fixedResIdx := 0
 	dpIdx := 0
 	numPoints := len(datapoints)
@@ -186,3 +204,34 @@ func RawPointsToFixedStep(datapoints Datapoints, start time.Time, end time.Time,
 
 	return fixStepValues, nil
 }
+
+// fillWithLookback sets each step to the value of the latest datapoint at or before the step and no older than
+// the lookback, steps without such a datapoint are left as is.
+func fillWithLookback(
+	values []float64,
+	datapoints Datapoints,
+	start time.Time,
+	interval time.Duration,
+	lookback time.Duration,
+) {
+	dpIdx := 0
+	numPoints := len(datapoints)
+	for i := range values {
+		t := start.Add(time.Duration(i) * interval)
+		// Find the first datapoint after time t, the one before it is the latest at or before t
+		for ; dpIdx < numPoints; dpIdx++ {
+			if datapoints.DatapointAt(dpIdx).Timestamp.After(t) {
+				break
+			}
+		}
+
+		if dpIdx == 0 {
+			continue
+		}
+
+		dp := datapoints.DatapointAt(dpIdx - 1)
+		if t.Sub(dp.Timestamp) <= lookback {
+			values[i] = dp.Value
+		}
+	}
+}
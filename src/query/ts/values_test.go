@@ -112,3 +112,25 @@ func TestRawPointsToFixedStep(t *testing.T) {
 		}
 	}
 }
+
+func TestRawPointsToFixedStepWithLookback(t *testing.T) {
+	start := time.Unix(0, 0)
+	// Datapoints at 0s, 10s, 20s and 30s, then a gap until 60s
+	input := append(generateDatapoints(start, 10*time.Second, 4),
+		Datapoint{Timestamp: start.Add(60 * time.Second), Value: 6})
+
+	fixed, err := RawPointsToFixedStepWithLookback(input, start.Add(5*time.Second),
+		start.Add(75*time.Second), 10*time.Second, 15*time.Second)
+	require.NoError(t, err)
+
+	values := fixed.(*fixedResolutionValues).values
+	expected := []float64{0, 1, 2, 3, 3, math.NaN(), 6}
+	require.Len(t, values, len(expected))
+	for i, v := range expected {
+		if math.IsNaN(v) {
+			assert.True(t, math.IsNaN(values[i]), "index %d: %v", i, values)
+		} else {
+			assert.Equal(t, v, values[i], "index %d: %v", i, values)
+		}
+	}
+}
@@ -22,6 +22,7 @@ package remote
 
 import (
 	"context"
+	"crypto/tls"
 	"io"
 
 	"github.com/m3db/m3/src/query/block"
@@ -32,6 +33,7 @@ import (
 	"github.com/m3db/m3/src/query/util/logging"
 
 	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials"
 )
 
 // Client is an interface
@@ -48,12 +50,31 @@ type grpcClient struct {
 
 // NewGrpcClient creates grpc client
 func NewGrpcClient(addresses []string, additionalDialOpts ...grpc.DialOption) (Client, error) {
+	return newGrpcClient(addresses, grpc.WithInsecure(), additionalDialOpts)
+}
+
+// NewGrpcClientWithTLS creates grpc client that connects to the remote
+// servers with TLS
+func NewGrpcClientWithTLS(
+	addresses []string,
+	tlsConfig *tls.Config,
+	additionalDialOpts ...grpc.DialOption,
+) (Client, error) {
+	creds := grpc.WithTransportCredentials(credentials.NewTLS(tlsConfig))
+	return newGrpcClient(addresses, creds, additionalDialOpts)
+}
+
+func newGrpcClient(
+	addresses []string,
+	securityDialOpt grpc.DialOption,
+	additionalDialOpts []grpc.DialOption,
+) (Client, error) {
 	if len(addresses) == 0 {
 		return nil, errors.ErrNoClientAddresses
 	}
 	resolver := newStaticResolver(addresses)
 	balancer := grpc.RoundRobin(resolver)
-	dialOptions := []grpc.DialOption{grpc.WithBalancer(balancer), grpc.WithInsecure()}
+	dialOptions := []grpc.DialOption{grpc.WithBalancer(balancer), securityDialOpt}
 	dialOptions = append(dialOptions, additionalDialOpts...)
 
 	cc, err := grpc.Dial("", dialOptions...)
@@ -72,7 +93,7 @@ func NewGrpcClient(addresses []string, additionalDialOpts ...grpc.DialOption) (C
 func (c *grpcClient) Fetch(ctx context.Context, query *storage.FetchQuery, options *storage.FetchOptions) (*storage.FetchResult, error) {
 	// Send the id from the client to the remote server so that provides logging
 	id := logging.ReadContextID(ctx)
-	fetchClient, err := c.client.Fetch(ctx, EncodeFetchMessage(query, id))
+	fetchClient, err := c.client.Fetch(ctx, EncodeFetchMessage(query, options, id))
 	if err != nil {
 		return nil, err
 	}
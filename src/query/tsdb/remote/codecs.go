@@ -25,6 +25,7 @@ import (
 	"fmt"
 	"time"
 
+	"github.com/m3db/m3/src/dbnode/topology"
 	rpc "github.com/m3db/m3/src/query/generated/proto/rpcpb"
 	"github.com/m3db/m3/src/query/models"
 	"github.com/m3db/m3/src/query/storage"
@@ -153,12 +154,15 @@ func decodeRawTs(r *rpc.Series) ts.Datapoints {
 }
 
 // EncodeFetchMessage encodes fetch query and fetch options into rpc WriteMessage
-func EncodeFetchMessage(query *storage.FetchQuery, queryID string) *rpc.FetchMessage {
+func EncodeFetchMessage(
+	query *storage.FetchQuery,
+	options *storage.FetchOptions,
+	queryID string,
+) *rpc.FetchMessage {
 	return &rpc.FetchMessage{
 		Query:   encodeFetchQuery(query),
-		Options: encodeFetchOptions(queryID),
+		Options: encodeFetchOptions(options, queryID),
 	}
-
 }
 
 func encodeFetchQuery(query *storage.FetchQuery) *rpc.FetchQuery {
@@ -173,28 +177,84 @@ func encodeTagMatchers(modelMatchers models.Matchers) []*rpc.Matcher {
 	matchers := make([]*rpc.Matcher, len(modelMatchers))
 	for i, matcher := range modelMatchers {
 		matchers[i] = &rpc.Matcher{
-			Name:  matcher.Name,
-			Value: matcher.Value,
-			Type:  int64(matcher.Type),
+			Name:        matcher.Name,
+			Value:       matcher.Value,
+			Type:        int64(matcher.Type),
+			MaxEdits:    int64(matcher.MaxEdits),
+			HasMaxEdits: matcher.Type == models.MatchFuzzy,
 		}
 	}
 
 	return matchers
 }
 
-func encodeFetchOptions(queryID string) *rpc.FetchOptions {
-	return &rpc.FetchOptions{
+func encodeFetchOptions(options *storage.FetchOptions, queryID string) *rpc.FetchOptions {
+	encoded := &rpc.FetchOptions{
 		Id: queryID,
 	}
+	if options == nil {
+		return encoded
+	}
+	if level := options.FetchControls.ReadConsistencyLevel; level != nil {
+		encoded.ReadConsistencyLevel = encodeReadConsistencyLevel(*level)
+	}
+	encoded.DocsLimit = int64(options.FetchControls.DocsLimit)
+	encoded.BytesLimit = int64(options.FetchControls.BytesLimit)
+	return encoded
+}
+
+// encodeReadConsistencyLevel encodes the read consistency level, the levels
+// are offset by one so that the zero value of the rpc level means not set.
+func encodeReadConsistencyLevel(level topology.ReadConsistencyLevel) rpc.ReadConsistencyLevel {
+	return rpc.ReadConsistencyLevel(level + 1)
+}
+
+func decodeReadConsistencyLevel(
+	level rpc.ReadConsistencyLevel,
+) (*topology.ReadConsistencyLevel, error) {
+	if level == rpc.ReadConsistencyLevel_NOT_SET {
+		return nil, nil
+	}
+	if _, ok := rpc.ReadConsistencyLevel_name[int32(level)]; !ok {
+		return nil, fmt.Errorf("unknown read consistency level: %d", level)
+	}
+	decoded := topology.ReadConsistencyLevel(level - 1)
+	return &decoded, nil
 }
 
 // DecodeFetchMessage decodes rpc fetch message to read query and read options
-func DecodeFetchMessage(message *rpc.FetchMessage) (*storage.FetchQuery, string, error) {
+func DecodeFetchMessage(
+	message *rpc.FetchMessage,
+) (*storage.FetchQuery, *storage.FetchOptions, string, error) {
 	query, err := decodeFetchQuery(message.GetQuery())
 	if err != nil {
-		return nil, "", err
+		return nil, nil, "", err
 	}
-	return query, message.GetOptions().GetId(), nil
+
+	options, err := decodeFetchOptions(message.GetOptions())
+	if err != nil {
+		return nil, nil, "", err
+	}
+
+	return query, options, message.GetOptions().GetId(), nil
+}
+
+func decodeFetchOptions(options *rpc.FetchOptions) (*storage.FetchOptions, error) {
+	level, err := decodeReadConsistencyLevel(options.GetReadConsistencyLevel())
+	if err != nil {
+		return nil, err
+	}
+
+	return &storage.FetchOptions{
+		KillChan: make(chan struct{}),
+		FetchControls: models.FetchControls{
+			ReadConsistencyLevel: level,
+			// Fetches served for remote zones are always local to this zone.
+			LocalOnly:  true,
+			DocsLimit:  int(options.GetDocsLimit()),
+			BytesLimit: int(options.GetBytesLimit()),
+		},
+	}, nil
 }
 
 func decodeFetchQuery(query *rpc.FetchQuery) (*storage.FetchQuery, error) {
@@ -214,7 +274,17 @@ func decodeTagMatchers(rpcMatchers []*rpc.Matcher) (models.Matchers, error) {
 	matchers := make([]*models.Matcher, len(rpcMatchers))
 	for i, matcher := range rpcMatchers {
 		matchType, name, value := models.MatchType(matcher.GetType()), matcher.GetName(), matcher.GetValue()
-		mMatcher, err := models.NewMatcher(matchType, name, value)
+		var (
+			mMatcher *models.Matcher
+			err      error
+		)
+		if matchType == models.MatchFuzzy && matcher.GetHasMaxEdits() {
+			mMatcher, err = models.NewFuzzyMatcher(name, value, int(matcher.GetMaxEdits()))
+		} else {
+			// NB: Fuzzy matchers of zones that do not send the edit distance
+			// use the default edit distance.
+			mMatcher, err = models.NewMatcher(matchType, name, value)
+		}
 		if err != nil {
 			return matchers, err
 		}
@@ -25,6 +25,7 @@ import (
 	"testing"
 	"time"
 
+	"github.com/m3db/m3/src/dbnode/topology"
 	rpc "github.com/m3db/m3/src/query/generated/proto/rpcpb"
 	"github.com/m3db/m3/src/query/models"
 	"github.com/m3db/m3/src/query/storage"
@@ -176,7 +177,7 @@ func createStorageFetchQuery(t *testing.T) (*storage.FetchQuery, time.Time, time
 func TestEncodeFetchMessage(t *testing.T) {
 	rQ, start, end := createStorageFetchQuery(t)
 
-	grpcQ := EncodeFetchMessage(rQ, id)
+	grpcQ := EncodeFetchMessage(rQ, nil, id)
 	require.NotNil(t, grpcQ)
 	assert.Equal(t, fromTime(start), grpcQ.GetQuery().GetStart())
 	assert.Equal(t, fromTime(end), grpcQ.GetQuery().GetEnd())
@@ -189,21 +190,103 @@ func TestEncodeFetchMessage(t *testing.T) {
 	assert.Equal(t, val1, mRPC[1].GetValue())
 	assert.Equal(t, models.MatchEqual, models.MatchType(mRPC[1].GetType()))
 	assert.Equal(t, id, grpcQ.GetOptions().GetId())
+	assert.Equal(t, rpc.ReadConsistencyLevel_NOT_SET,
+		grpcQ.GetOptions().GetReadConsistencyLevel())
 }
 
 func TestEncodeDecodeFetchQuery(t *testing.T) {
 	rQ, _, _ := createStorageFetchQuery(t)
-	gq := EncodeFetchMessage(rQ, id)
-	reverted, decodeID, err := DecodeFetchMessage(gq)
+	gq := EncodeFetchMessage(rQ, nil, id)
+	reverted, options, decodeID, err := DecodeFetchMessage(gq)
 	require.Nil(t, err)
 	assert.Equal(t, id, decodeID)
 	readQueriesAreEqual(t, rQ, reverted)
+	assert.Nil(t, options.FetchControls.ReadConsistencyLevel)
+	assert.True(t, options.FetchControls.LocalOnly)
 
 	// Encode again
-	gqr := EncodeFetchMessage(reverted, decodeID)
+	gqr := EncodeFetchMessage(reverted, nil, decodeID)
 	assert.Equal(t, gq, gqr)
 }
 
+func TestEncodeDecodeFetchFuzzyMatcher(t *testing.T) {
+	m, err := models.NewFuzzyMatcher(string(name0), "bar", 2)
+	require.NoError(t, err)
+
+	gq := EncodeFetchMessage(&storage.FetchQuery{
+		TagMatchers: models.Matchers{m},
+	}, nil, id)
+	assert.Equal(t, int64(2), gq.GetQuery().GetTagMatchers()[0].GetMaxEdits())
+
+	reverted, _, _, err := DecodeFetchMessage(gq)
+	require.NoError(t, err)
+	require.Equal(t, 1, len(reverted.TagMatchers))
+	assert.Equal(t, models.MatchFuzzy, reverted.TagMatchers[0].Type)
+	assert.Equal(t, 2, reverted.TagMatchers[0].MaxEdits)
+	assert.True(t, reverted.TagMatchers[0].Matches("bz"))
+}
+
+func TestEncodeDecodeFetchFuzzyMatcherZeroEdits(t *testing.T) {
+	m, err := models.NewFuzzyMatcher(string(name0), "bar", 0)
+	require.NoError(t, err)
+
+	gq := EncodeFetchMessage(&storage.FetchQuery{
+		TagMatchers: models.Matchers{m},
+	}, nil, id)
+	assert.True(t, gq.GetQuery().GetTagMatchers()[0].GetHasMaxEdits())
+
+	reverted, _, _, err := DecodeFetchMessage(gq)
+	require.NoError(t, err)
+	require.Equal(t, 1, len(reverted.TagMatchers))
+	assert.Equal(t, 0, reverted.TagMatchers[0].MaxEdits)
+	assert.True(t, reverted.TagMatchers[0].Matches("bar"))
+	assert.False(t, reverted.TagMatchers[0].Matches("baz"))
+
+	// Zones that do not send the edit distance get the default.
+	gq.GetQuery().GetTagMatchers()[0].HasMaxEdits = false
+	reverted, _, _, err = DecodeFetchMessage(gq)
+	require.NoError(t, err)
+	assert.Equal(t, models.DefaultFuzzyEdits, reverted.TagMatchers[0].MaxEdits)
+}
+
+func TestEncodeDecodeFetchReadConsistencyLevel(t *testing.T) {
+	rQ, _, _ := createStorageFetchQuery(t)
+	for _, level := range topology.ValidReadConsistencyLevels() {
+		level := level
+		gq := EncodeFetchMessage(rQ, &storage.FetchOptions{
+			FetchControls: models.FetchControls{ReadConsistencyLevel: &level},
+		}, id)
+		assert.NotEqual(t, rpc.ReadConsistencyLevel_NOT_SET,
+			gq.GetOptions().GetReadConsistencyLevel())
+
+		_, options, _, err := DecodeFetchMessage(gq)
+		require.NoError(t, err)
+		require.NotNil(t, options.FetchControls.ReadConsistencyLevel)
+		assert.Equal(t, level, *options.FetchControls.ReadConsistencyLevel)
+	}
+}
+
+func TestEncodeDecodeFetchLimits(t *testing.T) {
+	rQ, _, _ := createStorageFetchQuery(t)
+	gq := EncodeFetchMessage(rQ, &storage.FetchOptions{
+		FetchControls: models.FetchControls{DocsLimit: 10, BytesLimit: 2048},
+	}, id)
+
+	_, options, _, err := DecodeFetchMessage(gq)
+	require.NoError(t, err)
+	assert.Equal(t, 10, options.FetchControls.DocsLimit)
+	assert.Equal(t, 2048, options.FetchControls.BytesLimit)
+}
+
+func TestDecodeFetchUnknownReadConsistencyLevel(t *testing.T) {
+	rQ, _, _ := createStorageFetchQuery(t)
+	gq := EncodeFetchMessage(rQ, nil, id)
+	gq.Options.ReadConsistencyLevel = rpc.ReadConsistencyLevel(100)
+
+	_, _, _, err := DecodeFetchMessage(gq)
+	assert.Error(t, err)
+}
+
 func createStorageWriteQuery(t *testing.T) (*storage.WriteQuery, ts.Datapoints) {
 	t0, t1 := parseTimes(t)
 	points := []ts.Datapoint{
@@ -42,9 +42,10 @@ func newServer(store storage.Storage) *grpcServer {
 	}
 }
 
-// CreateNewGrpcServer creates server, given context local storage
-func CreateNewGrpcServer(store storage.Storage) *grpc.Server {
-	server := grpc.NewServer()
+// CreateNewGrpcServer creates server, given context local storage and
+// server options such as the TLS credentials
+func CreateNewGrpcServer(store storage.Storage, opts ...grpc.ServerOption) *grpc.Server {
+	server := grpc.NewServer(opts...)
 	grpcServer := newServer(store)
 	rpc.RegisterQueryServer(server, grpcServer)
 
@@ -63,7 +64,7 @@ func StartNewGrpcServer(server *grpc.Server, address string, waitForStart chan<-
 
 // Fetch reads from local storage
 func (s *grpcServer) Fetch(message *rpc.FetchMessage, stream rpc.Query_FetchServer) error {
-	storeQuery, id, err := DecodeFetchMessage(message)
+	storeQuery, fetchOptions, id, err := DecodeFetchMessage(message)
 	ctx := logging.NewContextWithID(stream.Context(), id)
 	logger := logging.WithContext(ctx)
 
@@ -74,7 +75,7 @@ func (s *grpcServer) Fetch(message *rpc.FetchMessage, stream rpc.Query_FetchServ
 
 	// Iterate while there are more results
 	for {
-		result, err := s.storage.Fetch(ctx, storeQuery, nil)
+		result, err := s.storage.Fetch(ctx, storeQuery, fetchOptions)
 
 		if err != nil {
 			logger.Error("unable to fetch local query", zap.Any("error", err))
@@ -0,0 +1,175 @@
+// Copyright (c) 2018 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+// Package querystats carries the statistics of a query through its request
+// context, so that handlers can report what a query returned to the
+// middleware that records it.
+package querystats
+
+import (
+	"context"
+	"sync"
+	"sync/atomic"
+)
+
+type statsKeyType int
+
+const (
+	statsKey statsKeyType = iota
+)
+
+// Stats are the statistics of a single query.
+type Stats struct {
+	series             int64
+	replicaDivergences int64
+
+	mu        sync.Mutex
+	query     string
+	limitsHit []string
+	warnings  []string
+}
+
+// AddSeries adds to the number of series returned by the query.
+func (s *Stats) AddSeries(n int) {
+	atomic.AddInt64(&s.series, int64(n))
+}
+
+// Series returns the number of series returned by the query.
+func (s *Stats) Series() int64 {
+	return atomic.LoadInt64(&s.series)
+}
+
+// SetQuery sets the text of the query.
+func (s *Stats) SetQuery(query string) {
+	s.mu.Lock()
+	s.query = query
+	s.mu.Unlock()
+}
+
+// Query returns the text of the query, empty if it was not set.
+func (s *Stats) Query() string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.query
+}
+
+// AddLimitHit records that the query hit a limit, each limit is recorded
+// once however many times it was hit.
+func (s *Stats) AddLimitHit(limit string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for _, hit := range s.limitsHit {
+		if hit == limit {
+			return
+		}
+	}
+	s.limitsHit = append(s.limitsHit, limit)
+}
+
+// LimitsHit returns the limits the query hit in the order they were hit.
+func (s *Stats) LimitsHit() []string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return append([]string(nil), s.limitsHit...)
+}
+
+// AddWarning records a warning of the query to return with its results,
+// each warning is recorded once however many times it was added.
+func (s *Stats) AddWarning(warning string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for _, w := range s.warnings {
+		if w == warning {
+			return
+		}
+	}
+	s.warnings = append(s.warnings, warning)
+}
+
+// Warnings returns the warnings of the query in the order they were added.
+func (s *Stats) Warnings() []string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return append([]string(nil), s.warnings...)
+}
+
+// AddReplicaDivergence records a block of a series fetched by the query
+// that differs between replicas.
+func (s *Stats) AddReplicaDivergence() {
+	atomic.AddInt64(&s.replicaDivergences, 1)
+}
+
+// ReplicaDivergences returns the number of blocks fetched by the query
+// that differ between replicas.
+func (s *Stats) ReplicaDivergences() int64 {
+	return atomic.LoadInt64(&s.replicaDivergences)
+}
+
+// NewContext returns a context that carries the statistics of a query.
+func NewContext(ctx context.Context, stats *Stats) context.Context {
+	return context.WithValue(ctx, statsKey, stats)
+}
+
+// FromContext returns the statistics of the query of a context, if any.
+func FromContext(ctx context.Context) (*Stats, bool) {
+	stats, ok := ctx.Value(statsKey).(*Stats)
+	return stats, ok
+}
+
+// AddSeries adds to the number of series returned by the query of a
+// context, it is a no-op if the context carries no statistics.
+func AddSeries(ctx context.Context, n int) {
+	if stats, ok := FromContext(ctx); ok {
+		stats.AddSeries(n)
+	}
+}
+
+// SetQuery sets the text of the query of a context, it is a no-op if the
+// context carries no statistics.
+func SetQuery(ctx context.Context, query string) {
+	if stats, ok := FromContext(ctx); ok {
+		stats.SetQuery(query)
+	}
+}
+
+// AddLimitHit records that the query of a context hit a limit, it is a
+// no-op if the context carries no statistics.
+func AddLimitHit(ctx context.Context, limit string) {
+	if stats, ok := FromContext(ctx); ok {
+		stats.AddLimitHit(limit)
+	}
+}
+
+// AddWarning records a warning of the query of a context, it is a no-op if
+// the context carries no statistics.
+func AddWarning(ctx context.Context, warning string) {
+	if stats, ok := FromContext(ctx); ok {
+		stats.AddWarning(warning)
+	}
+}
+
+// AddReplicaDivergence records a block that differs between replicas
+// fetched by the query of a context, it is a no-op if the context carries
+// no statistics.
+func AddReplicaDivergence(ctx context.Context) {
+	if stats, ok := FromContext(ctx); ok {
+		stats.AddReplicaDivergence()
+	}
+}
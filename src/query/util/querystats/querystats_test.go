@@ -0,0 +1,76 @@
+// Copyright (c) 2018 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package querystats
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestAddSeries(t *testing.T) {
+	// Adding to a context without statistics is a no-op.
+	AddSeries(context.Background(), 3)
+
+	ctx := NewContext(context.Background(), &Stats{})
+	AddSeries(ctx, 3)
+	AddSeries(ctx, 4)
+
+	stats, ok := FromContext(ctx)
+	require.True(t, ok)
+	assert.Equal(t, int64(7), stats.Series())
+}
+
+func TestQueryAndLimitsHit(t *testing.T) {
+	// Setting on a context without statistics is a no-op.
+	SetQuery(context.Background(), "up")
+	AddLimitHit(context.Background(), "series")
+
+	ctx := NewContext(context.Background(), &Stats{})
+	SetQuery(ctx, "up")
+	AddLimitHit(ctx, "series")
+	AddLimitHit(ctx, "bytes")
+	AddLimitHit(ctx, "series")
+
+	stats, ok := FromContext(ctx)
+	require.True(t, ok)
+	assert.Equal(t, "up", stats.Query())
+	assert.Equal(t, []string{"series", "bytes"}, stats.LimitsHit())
+}
+
+func TestWarningsAndReplicaDivergences(t *testing.T) {
+	AddWarning(context.Background(), "divergent")
+	AddReplicaDivergence(context.Background())
+
+	ctx := NewContext(context.Background(), &Stats{})
+	AddWarning(ctx, "a")
+	AddWarning(ctx, "b")
+	AddWarning(ctx, "a")
+	AddReplicaDivergence(ctx)
+	AddReplicaDivergence(ctx)
+
+	stats, ok := FromContext(ctx)
+	require.True(t, ok)
+	assert.Equal(t, []string{"a", "b"}, stats.Warnings())
+	assert.Equal(t, int64(2), stats.ReplicaDivergences())
+}
@@ -0,0 +1,445 @@
+// Copyright (c) 2018 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+// Package loglevel provides loggers whose level can be changed at runtime
+// per subsystem, and debug logging targeted at a shard or series for a
+// bounded duration.
+package loglevel
+
+import (
+	"errors"
+	"fmt"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	xlog "github.com/m3db/m3x/log"
+)
+
+const (
+	// SubsystemDefault is the subsystem of loggers of no other subsystem.
+	SubsystemDefault = "default"
+
+	// SubsystemIndex is the subsystem of the namespace indexes.
+	SubsystemIndex = "index"
+
+	// SubsystemBootstrap is the subsystem of bootstrapping.
+	SubsystemBootstrap = "bootstrap"
+
+	// SubsystemFlush is the subsystem of flushes and snapshots.
+	SubsystemFlush = "flush"
+
+	// SubsystemQuery is the subsystem of the node reads and queries.
+	SubsystemQuery = "query"
+
+	// ShardField is the key of the log field with the shard of a message,
+	// which debug targets are matched against.
+	ShardField = "shard"
+
+	// SeriesIDField is the key of the log field with the series ID of a
+	// message, which debug targets are matched against.
+	SeriesIDField = "id"
+)
+
+var (
+	// Subsystems are the subsystems with levels of their own.
+	Subsystems = []string{
+		SubsystemDefault,
+		SubsystemIndex,
+		SubsystemBootstrap,
+		SubsystemFlush,
+		SubsystemQuery,
+	}
+
+	errTargetNoShardOrSeries = errors.New("debug target requires a shard or a series ID")
+	errTargetNoDuration      = errors.New("debug target requires a positive duration")
+)
+
+// Target enables debug logging of the messages of a shard, a series or a
+// series of a shard until it expires.
+type Target struct {
+	Shard    *uint32   `json:"shard,omitempty"`
+	SeriesID string    `json:"seriesID,omitempty"`
+	Expires  time.Time `json:"expires"`
+}
+
+func (t Target) matches(hasShard bool, shard uint32, id string) bool {
+	if t.Shard != nil && (!hasShard || *t.Shard != shard) {
+		return false
+	}
+	if t.SeriesID != "" && t.SeriesID != id {
+		return false
+	}
+	return true
+}
+
+// Registry holds the log levels of the subsystems and the debug targets, and
+// returns the loggers that log at them.
+type Registry struct {
+	sync.Mutex
+
+	base         xlog.Logger
+	defaultLevel xlog.Level
+	nowFn        func() time.Time
+
+	// levels is a map[string]xlog.Level replaced on each change so that it
+	// can be read without locking.
+	levels atomic.Value
+	// targets is a []Target replaced on each change.
+	targets    atomic.Value
+	numTargets int32
+}
+
+// NewRegistry returns a registry whose loggers log to the base logger, with
+// every subsystem at the default level until changed. The base logger must
+// log at the debug level, messages are filtered by the registry loggers.
+func NewRegistry(base xlog.Logger, defaultLevel xlog.Level) *Registry {
+	r := &Registry{
+		base:         base,
+		defaultLevel: defaultLevel,
+		nowFn:        time.Now,
+	}
+	r.levels.Store(map[string]xlog.Level{})
+	r.targets.Store([]Target(nil))
+	return r
+}
+
+// Logger returns the logger of a subsystem.
+func (r *Registry) Logger(subsystem string) xlog.Logger {
+	return &logger{registry: r, base: r.base, subsystem: subsystem}
+}
+
+// Level returns the level of a subsystem.
+func (r *Registry) Level(subsystem string) xlog.Level {
+	if level, ok := r.levels.Load().(map[string]xlog.Level)[subsystem]; ok {
+		return level
+	}
+	return r.defaultLevel
+}
+
+// Levels returns the level of each subsystem.
+func (r *Registry) Levels() map[string]xlog.Level {
+	levels := make(map[string]xlog.Level, len(Subsystems))
+	for _, subsystem := range Subsystems {
+		levels[subsystem] = r.Level(subsystem)
+	}
+	return levels
+}
+
+// SetLevel sets the level of a subsystem.
+func (r *Registry) SetLevel(subsystem string, level xlog.Level) error {
+	if !isSubsystem(subsystem) {
+		return fmt.Errorf("unknown log subsystem: %s", subsystem)
+	}
+	r.updateLevels(func(levels map[string]xlog.Level) {
+		levels[subsystem] = level
+	})
+	return nil
+}
+
+// ResetLevel resets the level of a subsystem to the default level.
+func (r *Registry) ResetLevel(subsystem string) error {
+	if !isSubsystem(subsystem) {
+		return fmt.Errorf("unknown log subsystem: %s", subsystem)
+	}
+	r.updateLevels(func(levels map[string]xlog.Level) {
+		delete(levels, subsystem)
+	})
+	return nil
+}
+
+func (r *Registry) updateLevels(fn func(map[string]xlog.Level)) {
+	r.Lock()
+	current := r.levels.Load().(map[string]xlog.Level)
+	levels := make(map[string]xlog.Level, len(current)+1)
+	for subsystem, level := range current {
+		levels[subsystem] = level
+	}
+	fn(levels)
+	r.levels.Store(levels)
+	r.Unlock()
+}
+
+// AddTarget enables debug logging of the messages of a shard, a series or
+// a series of a shard for a duration, and returns the target added.
+func (r *Registry) AddTarget(
+	shard *uint32,
+	seriesID string,
+	duration time.Duration,
+) (Target, error) {
+	if shard == nil && seriesID == "" {
+		return Target{}, errTargetNoShardOrSeries
+	}
+	if duration <= 0 {
+		return Target{}, errTargetNoDuration
+	}
+
+	target := Target{
+		Shard:    shard,
+		SeriesID: seriesID,
+		Expires:  r.nowFn().Add(duration),
+	}
+	r.Lock()
+	targets := append(r.unexpiredTargetsWithLock(), target)
+	r.targets.Store(targets)
+	atomic.StoreInt32(&r.numTargets, int32(len(targets)))
+	r.Unlock()
+	return target, nil
+}
+
+// Targets returns the debug targets that have not expired.
+func (r *Registry) Targets() []Target {
+	r.Lock()
+	targets := r.unexpiredTargetsWithLock()
+	r.targets.Store(targets)
+	atomic.StoreInt32(&r.numTargets, int32(len(targets)))
+	r.Unlock()
+	return append([]Target(nil), targets...)
+}
+
+// ClearTargets removes all debug targets.
+func (r *Registry) ClearTargets() {
+	r.Lock()
+	r.targets.Store([]Target(nil))
+	atomic.StoreInt32(&r.numTargets, 0)
+	r.Unlock()
+}
+
+func (r *Registry) unexpiredTargetsWithLock() []Target {
+	var (
+		now     = r.nowFn()
+		current = r.targets.Load().([]Target)
+		targets = make([]Target, 0, len(current)+1)
+	)
+	for _, target := range current {
+		if target.Expires.After(now) {
+			targets = append(targets, target)
+		}
+	}
+	return targets
+}
+
+func (r *Registry) enabled(subsystem string, level xlog.Level) bool {
+	return rank(level) >= rank(r.Level(subsystem))
+}
+
+func (r *Registry) targeted(hasShard bool, shard uint32, id string) bool {
+	// Fast path for the common case of no debug targets.
+	if atomic.LoadInt32(&r.numTargets) == 0 {
+		return false
+	}
+	now := r.nowFn()
+	for _, target := range r.targets.Load().([]Target) {
+		if target.Expires.After(now) && target.matches(hasShard, shard, id) {
+			return true
+		}
+	}
+	return false
+}
+
+// ForSubsystem returns the logger of a subsystem with the fields of the
+// logger if the logger was returned by a registry, or the logger itself
+// otherwise.
+func ForSubsystem(l xlog.Logger, subsystem string) xlog.Logger {
+	rl, ok := l.(*logger)
+	if !ok {
+		return l
+	}
+	result := *rl
+	result.subsystem = subsystem
+	return &result
+}
+
+// DebugTargeted returns whether debug messages of a series of a shard are
+// logged, either because the logger logs at the debug level or because of a
+// debug target. It is cheap enough to guard debug messages on hot paths.
+func DebugTargeted(l xlog.Logger, shard uint32, id []byte) bool {
+	rl, ok := l.(*logger)
+	if !ok {
+		return l.Enabled(xlog.LevelDebug)
+	}
+	if rl.registry.enabled(rl.subsystem, xlog.LevelDebug) {
+		return true
+	}
+	if atomic.LoadInt32(&rl.registry.numTargets) == 0 {
+		return false
+	}
+	return rl.registry.targeted(true, shard, string(id))
+}
+
+// ParseLevel parses a log level name such as "debug" or "info".
+func ParseLevel(name string) (xlog.Level, error) {
+	for _, level := range []xlog.Level{
+		xlog.LevelDebug,
+		xlog.LevelInfo,
+		xlog.LevelWarn,
+		xlog.LevelError,
+		xlog.LevelFatal,
+	} {
+		if level.String() == name {
+			return level, nil
+		}
+	}
+	return xlog.LevelInfo, fmt.Errorf("unknown log level: %s", name)
+}
+
+func isSubsystem(subsystem string) bool {
+	for _, s := range Subsystems {
+		if s == subsystem {
+			return true
+		}
+	}
+	return false
+}
+
+// rank orders the levels by severity.
+func rank(level xlog.Level) int {
+	switch level {
+	case xlog.LevelDebug:
+		return 0
+	case xlog.LevelInfo:
+		return 1
+	case xlog.LevelWarn:
+		return 2
+	case xlog.LevelError:
+		return 3
+	}
+	return 4
+}
+
+// logger filters the messages of its subsystem by the level of the
+// subsystem, debug messages with shard or series ID fields matching a debug
+// target are logged regardless.
+type logger struct {
+	registry  *Registry
+	base      xlog.Logger
+	subsystem string
+	hasShard  bool
+	shard     uint32
+	id        string
+}
+
+func (l *logger) Enabled(level xlog.Level) bool {
+	if l.registry.enabled(l.subsystem, level) {
+		return true
+	}
+	return level == xlog.LevelDebug &&
+		l.registry.targeted(l.hasShard, l.shard, l.id)
+}
+
+func (l *logger) Fatalf(msg string, args ...interface{}) {
+	l.base.Fatalf(msg, args...)
+}
+
+func (l *logger) Fatal(msg string) {
+	l.base.Fatal(msg)
+}
+
+func (l *logger) Errorf(msg string, args ...interface{}) {
+	if l.Enabled(xlog.LevelError) {
+		l.base.Errorf(msg, args...)
+	}
+}
+
+func (l *logger) Error(msg string) {
+	if l.Enabled(xlog.LevelError) {
+		l.base.Error(msg)
+	}
+}
+
+func (l *logger) Warnf(msg string, args ...interface{}) {
+	if l.Enabled(xlog.LevelWarn) {
+		l.base.Warnf(msg, args...)
+	}
+}
+
+func (l *logger) Warn(msg string) {
+	if l.Enabled(xlog.LevelWarn) {
+		l.base.Warn(msg)
+	}
+}
+
+func (l *logger) Infof(msg string, args ...interface{}) {
+	if l.Enabled(xlog.LevelInfo) {
+		l.base.Infof(msg, args...)
+	}
+}
+
+func (l *logger) Info(msg string) {
+	if l.Enabled(xlog.LevelInfo) {
+		l.base.Info(msg)
+	}
+}
+
+func (l *logger) Debugf(msg string, args ...interface{}) {
+	if l.Enabled(xlog.LevelDebug) {
+		l.base.Debugf(msg, args...)
+	}
+}
+
+func (l *logger) Debug(msg string) {
+	if l.Enabled(xlog.LevelDebug) {
+		l.base.Debug(msg)
+	}
+}
+
+func (l *logger) Fields() xlog.LoggerFields {
+	return l.base.Fields()
+}
+
+func (l *logger) WithFields(fields ...xlog.Field) xlog.Logger {
+	result := *l
+	result.base = l.base.WithFields(fields...)
+	for _, field := range fields {
+		switch field.Key() {
+		case ShardField:
+			if shard, ok := shardValue(field.Value()); ok {
+				result.hasShard, result.shard = true, shard
+			}
+		case SeriesIDField:
+			result.id = idValue(field.Value())
+		}
+	}
+	return &result
+}
+
+func idValue(value interface{}) string {
+	switch v := value.(type) {
+	case string:
+		return v
+	case []byte:
+		return string(v)
+	}
+	return fmt.Sprint(value)
+}
+
+func shardValue(value interface{}) (uint32, bool) {
+	switch v := value.(type) {
+	case uint32:
+		return v, true
+	case int:
+		return uint32(v), true
+	case int64:
+		return uint32(v), true
+	case uint64:
+		return uint32(v), true
+	}
+	return 0, false
+}
@@ -0,0 +1,111 @@
+// Copyright (c) 2018 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package loglevel
+
+import (
+	"bytes"
+	"testing"
+	"time"
+
+	xlog "github.com/m3db/m3x/log"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRegistrySubsystemLevels(t *testing.T) {
+	var buf bytes.Buffer
+	r := NewRegistry(xlog.NewLogger(&buf), xlog.LevelWarn)
+
+	index := r.Logger(SubsystemIndex)
+	flush := ForSubsystem(r.Logger(SubsystemDefault), SubsystemFlush)
+
+	index.Info("index info")
+	flush.Info("flush info")
+	assert.Equal(t, "", buf.String())
+
+	require.NoError(t, r.SetLevel(SubsystemIndex, xlog.LevelInfo))
+	index.Info("index info")
+	flush.Info("flush info")
+	assert.Contains(t, buf.String(), "index info")
+	assert.NotContains(t, buf.String(), "flush info")
+	assert.Equal(t, xlog.LevelInfo, r.Levels()[SubsystemIndex])
+	assert.Equal(t, xlog.LevelWarn, r.Levels()[SubsystemFlush])
+
+	require.NoError(t, r.ResetLevel(SubsystemIndex))
+	assert.False(t, index.Enabled(xlog.LevelInfo))
+	assert.True(t, index.Enabled(xlog.LevelError))
+
+	assert.Error(t, r.SetLevel("compaction", xlog.LevelDebug))
+}
+
+func TestRegistryDebugTargets(t *testing.T) {
+	var (
+		now = time.Unix(1500000000, 0)
+		r   = NewRegistry(xlog.NullLogger, xlog.LevelInfo)
+	)
+	r.nowFn = func() time.Time { return now }
+
+	shard := uint32(3)
+	_, err := r.AddTarget(&shard, "", time.Minute)
+	require.NoError(t, err)
+	_, err = r.AddTarget(nil, "foo", 2*time.Minute)
+	require.NoError(t, err)
+
+	_, err = r.AddTarget(nil, "", time.Minute)
+	assert.Error(t, err)
+	_, err = r.AddTarget(&shard, "", 0)
+	assert.Error(t, err)
+
+	l := r.Logger(SubsystemDefault)
+	assert.False(t, l.Enabled(xlog.LevelDebug))
+	assert.True(t, l.WithFields(xlog.NewField(ShardField, uint32(3))).Enabled(xlog.LevelDebug))
+	assert.False(t, l.WithFields(xlog.NewField(ShardField, uint32(4))).Enabled(xlog.LevelDebug))
+	assert.True(t, l.WithFields(xlog.NewField(SeriesIDField, "foo")).Enabled(xlog.LevelDebug))
+
+	assert.True(t, DebugTargeted(l, 3, []byte("bar")))
+	assert.True(t, DebugTargeted(l, 4, []byte("foo")))
+	assert.False(t, DebugTargeted(l, 4, []byte("bar")))
+
+	// The shard target expires first.
+	now = now.Add(90 * time.Second)
+	assert.False(t, DebugTargeted(l, 3, []byte("bar")))
+	assert.True(t, DebugTargeted(l, 4, []byte("foo")))
+	require.Len(t, r.Targets(), 1)
+	assert.Equal(t, "foo", r.Targets()[0].SeriesID)
+
+	r.ClearTargets()
+	assert.False(t, DebugTargeted(l, 4, []byte("foo")))
+	assert.Len(t, r.Targets(), 0)
+
+	require.NoError(t, r.SetLevel(SubsystemDefault, xlog.LevelDebug))
+	assert.True(t, DebugTargeted(l, 4, []byte("bar")))
+}
+
+func TestParseLevel(t *testing.T) {
+	for _, level := range []xlog.Level{xlog.LevelDebug, xlog.LevelInfo, xlog.LevelError} {
+		parsed, err := ParseLevel(level.String())
+		require.NoError(t, err)
+		assert.Equal(t, level, parsed)
+	}
+	_, err := ParseLevel("verbose")
+	assert.Error(t, err)
+}
@@ -52,8 +52,27 @@ type Options struct {
 	Write bool
 	// hugeTLB is the mmap huge TLB options
 	HugeTLB HugeTLBOptions
+	// advice is the madvise hint to apply to the mapping on platforms
+	// that support it
+	Advice Advice
 }
 
+// Advice is an madvise hint describing the expected access pattern of
+// an mmap'd region.
+type Advice int
+
+const (
+	// AdviceNormal applies no special treatment, the default.
+	AdviceNormal Advice = iota
+	// AdviceRandom expects page references in random order, disabling
+	// readahead so that only the pages touched are read into page cache.
+	AdviceRandom
+	// AdviceSequential expects page references in sequential order.
+	AdviceSequential
+	// AdviceWillNeed expects access in the near future.
+	AdviceWillNeed
+)
+
 // Result contains the results of a successful mmap
 type Result struct {
 	Result  []byte
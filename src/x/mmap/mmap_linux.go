@@ -93,9 +93,29 @@ func mmap(fd, offset, length int64, flags int, opts Options) (Result, error) {
 		return Result{}, fmt.Errorf("mmap error: %v", err)
 	}
 
+	// Failing to apply the access pattern hint is not fatal, the mapping
+	// is still usable so propagate back to the caller as a warning.
+	if advice, ok := madviseAdvice(opts.Advice); ok {
+		if err := syscall.Madvise(b, advice); err != nil {
+			warning = fmt.Errorf("error while trying to madvise: %s", err.Error())
+		}
+	}
+
 	return Result{Result: b, Warning: warning}, nil
 }
 
+func madviseAdvice(advice Advice) (int, bool) {
+	switch advice {
+	case AdviceRandom:
+		return syscall.MADV_RANDOM, true
+	case AdviceSequential:
+		return syscall.MADV_SEQUENTIAL, true
+	case AdviceWillNeed:
+		return syscall.MADV_WILLNEED, true
+	}
+	return 0, false
+}
+
 // Munmap munmaps a byte slice that is backed by an mmap
 func Munmap(b []byte) error {
 	if len(b) == 0 {
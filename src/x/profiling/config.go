@@ -0,0 +1,164 @@
+// Copyright (c) 2018 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+// Package profiling provides continuous profiling, periodically capturing
+// CPU and heap profiles of a service and uploading them to a Pyroscope
+// compatible ingestion endpoint labelled with the component and instance.
+package profiling
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"time"
+
+	"github.com/m3db/m3x/instrument"
+)
+
+const (
+	// DefaultInterval is the default interval profiles are captured at.
+	DefaultInterval = time.Minute
+
+	// DefaultCPUDuration is the default duration of each CPU profile.
+	DefaultCPUDuration = 10 * time.Second
+
+	// DefaultTimeout is the default timeout of upload requests.
+	DefaultTimeout = 10 * time.Second
+
+	// ComponentLabel is the label of the component profiles are captured from.
+	ComponentLabel = "component"
+
+	// InstanceLabel is the label of the instance profiles are captured from.
+	InstanceLabel = "instance"
+)
+
+// ProfileType is a type of profile that is captured.
+type ProfileType string
+
+const (
+	// CPUProfile is the CPU profile of the process.
+	CPUProfile ProfileType = "cpu"
+
+	// HeapProfile is the heap profile of the process.
+	HeapProfile ProfileType = "heap"
+)
+
+var (
+	// DefaultProfiles are the profiles captured by default.
+	DefaultProfiles = []ProfileType{CPUProfile, HeapProfile}
+
+	errNoEndpoint         = errors.New("profiling endpoint must be set")
+	errCPUDurationTooLong = errors.New("profiling cpu duration must not exceed the interval")
+)
+
+// Configuration is the continuous profiling configuration of a service.
+type Configuration struct {
+	// ApplicationName is the name profiles are uploaded under, defaults to
+	// the name of the component.
+	ApplicationName string `yaml:"applicationName"`
+
+	// Endpoint is the ingestion endpoint profiles are uploaded to, e.g.
+	// http://localhost:4040/ingest.
+	Endpoint string `yaml:"endpoint" validate:"nonzero"`
+
+	// Headers are sent with every upload request, e.g. for authentication.
+	Headers map[string]string `yaml:"headers"`
+
+	// Labels are added to the component and instance labels of every
+	// profile, and take precedence over them. The instance defaults to the
+	// hostname.
+	Labels map[string]string `yaml:"labels"`
+
+	// Profiles are the types of profiles captured, defaults to cpu and heap.
+	Profiles []ProfileType `yaml:"profiles"`
+
+	// Interval is the interval profiles are captured at, defaults to 1m.
+	Interval time.Duration `yaml:"interval"`
+
+	// CPUDuration is the duration of each CPU profile, defaults to 10s.
+	CPUDuration time.Duration `yaml:"cpuDuration"`
+
+	// Timeout is the timeout of upload requests, defaults to 10s.
+	Timeout time.Duration `yaml:"timeout"`
+}
+
+// NewProfiler returns a started profiler of the configuration, profiles are
+// uploaded under the component name unless the configuration sets an
+// application name.
+func (c Configuration) NewProfiler(
+	component string,
+	iOpts instrument.Options,
+) (*Profiler, error) {
+	if c.Endpoint == "" {
+		return nil, errNoEndpoint
+	}
+
+	profiles := c.Profiles
+	if len(profiles) == 0 {
+		profiles = DefaultProfiles
+	}
+	for _, p := range profiles {
+		if p != CPUProfile && p != HeapProfile {
+			return nil, fmt.Errorf("unknown profile type: %s", p)
+		}
+	}
+	interval := c.Interval
+	if interval <= 0 {
+		interval = DefaultInterval
+	}
+	cpuDuration := c.CPUDuration
+	if cpuDuration <= 0 {
+		cpuDuration = DefaultCPUDuration
+	}
+	if cpuDuration > interval {
+		return nil, errCPUDurationTooLong
+	}
+	timeout := c.Timeout
+	if timeout <= 0 {
+		timeout = DefaultTimeout
+	}
+
+	name := c.ApplicationName
+	if name == "" {
+		name = component
+	}
+
+	labels := map[string]string{ComponentLabel: component}
+	if hostname, err := os.Hostname(); err == nil {
+		labels[InstanceLabel] = hostname
+	}
+	for k, v := range c.Labels {
+		labels[k] = v
+	}
+
+	p := newProfiler(profilerOptions{
+		name:           name,
+		endpoint:       c.Endpoint,
+		headers:        c.Headers,
+		labels:         labels,
+		profiles:       profiles,
+		interval:       interval,
+		cpuDuration:    cpuDuration,
+		timeout:        timeout,
+		instrumentOpts: iOpts,
+	})
+	p.start()
+	return p, nil
+}
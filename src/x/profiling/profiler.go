@@ -0,0 +1,284 @@
+// Copyright (c) 2018 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package profiling
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"mime/multipart"
+	"net/http"
+	"net/url"
+	"runtime/pprof"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/m3db/m3x/instrument"
+
+	"github.com/uber-go/tally"
+)
+
+const (
+	// cpuSampleRate is the sampling frequency of CPU profiles in hertz.
+	cpuSampleRate = 100
+)
+
+var (
+	errProfilerClosed = errors.New("profiler closed")
+)
+
+type profilerOptions struct {
+	name           string
+	endpoint       string
+	headers        map[string]string
+	labels         map[string]string
+	profiles       []ProfileType
+	interval       time.Duration
+	cpuDuration    time.Duration
+	timeout        time.Duration
+	instrumentOpts instrument.Options
+}
+
+type profileMetrics struct {
+	captured      tally.Counter
+	captureErrors tally.Counter
+	uploaded      tally.Counter
+	uploadErrors  tally.Counter
+}
+
+func newProfileMetrics(scope tally.Scope, profile ProfileType) profileMetrics {
+	scope = scope.Tagged(map[string]string{"profile": string(profile)})
+	return profileMetrics{
+		captured:      scope.Counter("captured"),
+		captureErrors: scope.Counter("capture-errors"),
+		uploaded:      scope.Counter("uploaded"),
+		uploadErrors:  scope.Counter("upload-errors"),
+	}
+}
+
+// Profiler periodically captures profiles of the process and uploads them.
+type Profiler struct {
+	sync.Mutex
+
+	opts    profilerOptions
+	client  *http.Client
+	metrics map[ProfileType]profileMetrics
+	nowFn   func() time.Time
+
+	closed  bool
+	closeCh chan struct{}
+	wg      sync.WaitGroup
+}
+
+func newProfiler(opts profilerOptions) *Profiler {
+	if opts.instrumentOpts == nil {
+		opts.instrumentOpts = instrument.NewOptions()
+	}
+
+	scope := opts.instrumentOpts.MetricsScope().SubScope("profiling")
+	metrics := make(map[ProfileType]profileMetrics, len(opts.profiles))
+	for _, profile := range opts.profiles {
+		metrics[profile] = newProfileMetrics(scope, profile)
+	}
+
+	return &Profiler{
+		opts:    opts,
+		client:  &http.Client{Timeout: opts.timeout},
+		metrics: metrics,
+		nowFn:   time.Now,
+		closeCh: make(chan struct{}),
+	}
+}
+
+func (p *Profiler) start() {
+	p.wg.Add(1)
+	go p.run()
+}
+
+func (p *Profiler) run() {
+	defer p.wg.Done()
+
+	ticker := time.NewTicker(p.opts.interval)
+	defer ticker.Stop()
+
+	for {
+		p.captureAll()
+
+		select {
+		case <-ticker.C:
+		case <-p.closeCh:
+			return
+		}
+	}
+}
+
+func (p *Profiler) captureAll() {
+	for _, profile := range p.opts.profiles {
+		start := p.nowFn()
+		data, err := p.capture(profile)
+		if err == errProfilerClosed {
+			return
+		}
+
+		metrics := p.metrics[profile]
+		if err != nil {
+			metrics.captureErrors.Inc(1)
+			p.opts.instrumentOpts.Logger().Warnf("unable to capture %s profile: %v",
+				profile, err)
+			continue
+		}
+		metrics.captured.Inc(1)
+
+		if err := p.upload(profile, data, start, p.nowFn()); err != nil {
+			metrics.uploadErrors.Inc(1)
+			p.opts.instrumentOpts.Logger().Errorf("unable to upload %s profile to %s: %v",
+				profile, p.opts.endpoint, err)
+			continue
+		}
+		metrics.uploaded.Inc(1)
+	}
+}
+
+// capture returns the gzipped protobuf encoding of a profile, CPU profiles
+// fail if a CPU profile is already being captured, e.g. by the debug
+// endpoints.
+func (p *Profiler) capture(profile ProfileType) ([]byte, error) {
+	var buf bytes.Buffer
+	switch profile {
+	case CPUProfile:
+		if err := pprof.StartCPUProfile(&buf); err != nil {
+			return nil, err
+		}
+
+		timer := time.NewTimer(p.opts.cpuDuration)
+		defer timer.Stop()
+
+		select {
+		case <-timer.C:
+			pprof.StopCPUProfile()
+		case <-p.closeCh:
+			pprof.StopCPUProfile()
+			return nil, errProfilerClosed
+		}
+	case HeapProfile:
+		if err := pprof.Lookup("heap").WriteTo(&buf, 0); err != nil {
+			return nil, err
+		}
+	default:
+		return nil, fmt.Errorf("unknown profile type: %s", profile)
+	}
+	return buf.Bytes(), nil
+}
+
+func (p *Profiler) upload(
+	profile ProfileType,
+	data []byte,
+	from, until time.Time,
+) error {
+	var body bytes.Buffer
+	form := multipart.NewWriter(&body)
+	part, err := form.CreateFormFile("profile", "profile.pprof")
+	if err != nil {
+		return err
+	}
+	if _, err := part.Write(data); err != nil {
+		return err
+	}
+	if err := form.Close(); err != nil {
+		return err
+	}
+
+	query := url.Values{}
+	query.Set("name", applicationName(p.opts.name, profile, p.opts.labels))
+	query.Set("from", strconv.FormatInt(from.Unix(), 10))
+	query.Set("until", strconv.FormatInt(until.Unix(), 10))
+	query.Set("format", "pprof")
+	query.Set("spyName", "gospy")
+	if profile == CPUProfile {
+		query.Set("sampleRate", strconv.Itoa(cpuSampleRate))
+	}
+
+	endpoint, err := url.Parse(p.opts.endpoint)
+	if err != nil {
+		return err
+	}
+	endpoint.RawQuery = query.Encode()
+
+	req, err := http.NewRequest(http.MethodPost, endpoint.String(), &body)
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", form.FormDataContentType())
+	for name, value := range p.opts.headers {
+		req.Header.Set(name, value)
+	}
+
+	resp, err := p.client.Do(req)
+	if err != nil {
+		return err
+	}
+	resp.Body.Close()
+
+	if resp.StatusCode/100 != 2 {
+		return fmt.Errorf("endpoint returned status %d", resp.StatusCode)
+	}
+	return nil
+}
+
+// applicationName returns the name of the series of a profile in the
+// Pyroscope format, e.g. m3dbnode.cpu{component=m3dbnode,instance=host-a},
+// with the labels sorted by name.
+func applicationName(
+	name string,
+	profile ProfileType,
+	labels map[string]string,
+) string {
+	names := make([]string, 0, len(labels))
+	for k := range labels {
+		names = append(names, k)
+	}
+	sort.Strings(names)
+
+	pairs := make([]string, 0, len(names))
+	for _, k := range names {
+		pairs = append(pairs, k+"="+labels[k])
+	}
+	return fmt.Sprintf("%s.%s{%s}", name, profile, strings.Join(pairs, ","))
+}
+
+// Close stops capturing profiles, a CPU profile being captured is
+// discarded.
+func (p *Profiler) Close() error {
+	p.Lock()
+	if p.closed {
+		p.Unlock()
+		return errProfilerClosed
+	}
+	p.closed = true
+	close(p.closeCh)
+	p.Unlock()
+
+	p.wg.Wait()
+	return nil
+}
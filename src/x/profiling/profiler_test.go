@@ -0,0 +1,198 @@
+// Copyright (c) 2018 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package profiling
+
+import (
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/m3db/m3x/instrument"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/uber-go/tally"
+)
+
+type testUpload struct {
+	query   map[string]string
+	header  http.Header
+	profile []byte
+}
+
+func newTestEndpoint(t *testing.T, status int) (*httptest.Server, <-chan testUpload) {
+	uploads := make(chan testUpload, 16)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		file, _, err := r.FormFile("profile")
+		require.NoError(t, err)
+		data, err := ioutil.ReadAll(file)
+		require.NoError(t, err)
+
+		query := make(map[string]string)
+		for k := range r.URL.Query() {
+			query[k] = r.URL.Query().Get(k)
+		}
+		uploads <- testUpload{query: query, header: r.Header, profile: data}
+		w.WriteHeader(status)
+	}))
+	return server, uploads
+}
+
+func newTestProfiler(
+	endpoint string,
+	scope tally.Scope,
+	profiles ...ProfileType,
+) *Profiler {
+	return newProfiler(profilerOptions{
+		name:     "m3dbnode",
+		endpoint: endpoint + "/ingest",
+		headers:  map[string]string{"Authorization": "Bearer secret"},
+		labels: map[string]string{
+			ComponentLabel: "m3dbnode",
+			InstanceLabel:  "host-a",
+		},
+		profiles:       profiles,
+		interval:       time.Hour,
+		cpuDuration:    50 * time.Millisecond,
+		timeout:        time.Second,
+		instrumentOpts: instrument.NewOptions().SetMetricsScope(scope),
+	})
+}
+
+func TestProfilerUploadsProfiles(t *testing.T) {
+	server, uploads := newTestEndpoint(t, http.StatusOK)
+	defer server.Close()
+
+	scope := tally.NewTestScope("", nil)
+	profiler := newTestProfiler(server.URL, scope, CPUProfile, HeapProfile)
+	profiler.start()
+
+	var received []testUpload
+	for len(received) < 2 {
+		select {
+		case upload := <-uploads:
+			received = append(received, upload)
+		case <-time.After(10 * time.Second):
+			require.FailNow(t, "profiles not uploaded")
+		}
+	}
+	require.NoError(t, profiler.Close())
+
+	cpu, heap := received[0], received[1]
+	assert.Equal(t, "m3dbnode.cpu{component=m3dbnode,instance=host-a}", cpu.query["name"])
+	assert.Equal(t, "100", cpu.query["sampleRate"])
+	assert.Equal(t, "m3dbnode.heap{component=m3dbnode,instance=host-a}", heap.query["name"])
+	assert.Equal(t, "", heap.query["sampleRate"])
+
+	for _, upload := range received {
+		assert.Equal(t, "pprof", upload.query["format"])
+		assert.NotEmpty(t, upload.query["from"])
+		assert.NotEmpty(t, upload.query["until"])
+		assert.Equal(t, "Bearer secret", upload.header.Get("Authorization"))
+
+		// Profiles are gzipped protobufs.
+		require.True(t, len(upload.profile) > 2)
+		assert.Equal(t, []byte{0x1f, 0x8b}, upload.profile[:2])
+	}
+
+	counters := scope.Snapshot().Counters()
+	for _, id := range []string{
+		"profiling.captured+profile=cpu",
+		"profiling.uploaded+profile=cpu",
+		"profiling.captured+profile=heap",
+		"profiling.uploaded+profile=heap",
+	} {
+		require.NotNil(t, counters[id], id)
+		assert.Equal(t, int64(1), counters[id].Value(), id)
+	}
+}
+
+func TestProfilerUploadErrors(t *testing.T) {
+	server, uploads := newTestEndpoint(t, http.StatusInternalServerError)
+	defer server.Close()
+
+	scope := tally.NewTestScope("", nil)
+	profiler := newTestProfiler(server.URL, scope, HeapProfile)
+	profiler.start()
+
+	select {
+	case <-uploads:
+	case <-time.After(10 * time.Second):
+		require.FailNow(t, "profile not uploaded")
+	}
+	require.NoError(t, profiler.Close())
+
+	counters := scope.Snapshot().Counters()
+	require.NotNil(t, counters["profiling.upload-errors+profile=heap"])
+	assert.Equal(t, int64(1), counters["profiling.upload-errors+profile=heap"].Value())
+}
+
+func TestProfilerCloseDiscardsCPUProfile(t *testing.T) {
+	server, uploads := newTestEndpoint(t, http.StatusOK)
+	defer server.Close()
+
+	profiler := newTestProfiler(server.URL, tally.NoopScope, CPUProfile)
+	profiler.opts.cpuDuration = time.Hour
+	profiler.start()
+
+	require.NoError(t, profiler.Close())
+	assert.Equal(t, errProfilerClosed, profiler.Close())
+	assert.Len(t, uploads, 0)
+}
+
+func TestConfigurationNewProfiler(t *testing.T) {
+	_, err := Configuration{}.NewProfiler("m3dbnode", instrument.NewOptions())
+	assert.Equal(t, errNoEndpoint, err)
+
+	_, err = Configuration{
+		Endpoint: "http://localhost:4040/ingest",
+		Profiles: []ProfileType{"goroutine"},
+	}.NewProfiler("m3dbnode", instrument.NewOptions())
+	assert.Error(t, err)
+
+	_, err = Configuration{
+		Endpoint:    "http://localhost:4040/ingest",
+		Interval:    time.Second,
+		CPUDuration: time.Minute,
+	}.NewProfiler("m3dbnode", instrument.NewOptions())
+	assert.Equal(t, errCPUDurationTooLong, err)
+
+	server, _ := newTestEndpoint(t, http.StatusOK)
+	defer server.Close()
+
+	profiler, err := Configuration{
+		Endpoint: server.URL + "/ingest",
+		Profiles: []ProfileType{HeapProfile},
+		Labels:   map[string]string{InstanceLabel: "host-b", "region": "us-east"},
+	}.NewProfiler("m3dbnode", instrument.NewOptions())
+	require.NoError(t, err)
+	defer profiler.Close()
+
+	assert.Equal(t, "m3dbnode", profiler.opts.name)
+	assert.Equal(t, DefaultInterval, profiler.opts.interval)
+	assert.Equal(t, map[string]string{
+		ComponentLabel: "m3dbnode",
+		InstanceLabel:  "host-b",
+		"region":       "us-east",
+	}, profiler.opts.labels)
+}
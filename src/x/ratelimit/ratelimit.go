@@ -0,0 +1,71 @@
+// Copyright (c) 2018 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+// Package ratelimit provides a token bucket rate limiter.
+package ratelimit
+
+import (
+	"sync"
+	"time"
+)
+
+// TokenBucket is a token bucket that allows a request every interval after
+// an initial burst.
+type TokenBucket struct {
+	sync.Mutex
+
+	interval time.Duration
+	burst    int
+	tokens   float64
+	last     time.Time
+	nowFn    func() time.Time
+}
+
+// NewTokenBucket returns a new token bucket, the bucket starts full.
+func NewTokenBucket(interval time.Duration, burst int) *TokenBucket {
+	return &TokenBucket{
+		interval: interval,
+		burst:    burst,
+		tokens:   float64(burst),
+		nowFn:    time.Now,
+	}
+}
+
+// Allow returns whether a request is allowed now, and if not how long until
+// the next request is allowed.
+func (l *TokenBucket) Allow() (bool, time.Duration) {
+	l.Lock()
+	defer l.Unlock()
+
+	now := l.nowFn()
+	if !l.last.IsZero() {
+		l.tokens += float64(now.Sub(l.last)) / float64(l.interval)
+		if l.tokens > float64(l.burst) {
+			l.tokens = float64(l.burst)
+		}
+	}
+	l.last = now
+
+	if l.tokens < 1 {
+		return false, time.Duration((1 - l.tokens) * float64(l.interval))
+	}
+	l.tokens--
+	return true, 0
+}
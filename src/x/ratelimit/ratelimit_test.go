@@ -0,0 +1,60 @@
+// Copyright (c) 2018 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package ratelimit
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestTokenBucket(t *testing.T) {
+	now := time.Now()
+	l := NewTokenBucket(10*time.Second, 2)
+	l.nowFn = func() time.Time { return now }
+
+	ok, _ := l.Allow()
+	assert.True(t, ok)
+	ok, _ = l.Allow()
+	assert.True(t, ok)
+	ok, retryAfter := l.Allow()
+	assert.False(t, ok)
+	assert.Equal(t, 10*time.Second, retryAfter)
+
+	now = now.Add(5 * time.Second)
+	ok, retryAfter = l.Allow()
+	assert.False(t, ok)
+	assert.Equal(t, 5*time.Second, retryAfter)
+
+	now = now.Add(5 * time.Second)
+	ok, _ = l.Allow()
+	assert.True(t, ok)
+
+	// The bucket refills up to the burst only.
+	now = now.Add(time.Hour)
+	for i := 0; i < 2; i++ {
+		ok, _ = l.Allow()
+		assert.True(t, ok)
+	}
+	ok, _ = l.Allow()
+	assert.False(t, ok)
+}
@@ -0,0 +1,64 @@
+// Copyright (c) 2018 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package resource
+
+import (
+	"os"
+	"path/filepath"
+	"syscall"
+)
+
+const procFDDir = "/proc/self/fd"
+
+// fileDescriptorUsage returns the open file descriptors by category, or nil
+// if the platform has no procfs.
+func (r *Reporter) fileDescriptorUsage() (*FileDescriptorUsage, error) {
+	dir, err := os.Open(procFDDir)
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	names, err := dir.Readdirnames(-1)
+	dir.Close()
+	if err != nil {
+		return nil, err
+	}
+
+	usage := &FileDescriptorUsage{ByCategory: make(map[string]int)}
+	for _, name := range names {
+		target, err := os.Readlink(filepath.Join(procFDDir, name))
+		if err != nil {
+			// The descriptor was closed after the directory was read,
+			// including the descriptor of the directory itself.
+			continue
+		}
+		usage.Open++
+		usage.ByCategory[r.category(target)]++
+	}
+
+	var limit syscall.Rlimit
+	if err := syscall.Getrlimit(syscall.RLIMIT_NOFILE, &limit); err == nil {
+		usage.Limit = limit.Cur
+	}
+	return usage, nil
+}
@@ -0,0 +1,264 @@
+// Copyright (c) 2018 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+// Package resource reports the memory, file descriptors and goroutines used
+// by a process broken down by pool, category and subsystem, so that the
+// capacity of a process can be debugged without taking heap dumps.
+package resource
+
+import (
+	"runtime"
+	"sort"
+	"strings"
+	"sync"
+)
+
+const (
+	// OtherCategory is the category of file descriptors and goroutines that
+	// do not belong to a registered directory or subsystem.
+	OtherCategory = "other"
+
+	// maxGoroutineProfileAttempts is the number of times the goroutine
+	// profile is retried if goroutines are started while it is taken.
+	maxGoroutineProfileAttempts = 3
+)
+
+// PoolUsage is the memory used by a pool or arena.
+type PoolUsage struct {
+	// Name is the name of the pool.
+	Name string `json:"name"`
+
+	// Objects is the number of objects the pool holds.
+	Objects int64 `json:"objects"`
+
+	// Bytes is the number of bytes the pool holds, zero if unknown.
+	Bytes int64 `json:"bytes"`
+
+	// Estimated is true if the usage is estimated from the configured size
+	// of the pool rather than measured.
+	Estimated bool `json:"estimated"`
+}
+
+// PoolUsageFn returns the current memory used by a pool.
+type PoolUsageFn func() PoolUsage
+
+// MemoryUsage is the memory used by the process.
+type MemoryUsage struct {
+	HeapAllocBytes  uint64      `json:"heapAllocBytes"`
+	HeapInuseBytes  uint64      `json:"heapInuseBytes"`
+	HeapIdleBytes   uint64      `json:"heapIdleBytes"`
+	StackInuseBytes uint64      `json:"stackInuseBytes"`
+	SysBytes        uint64      `json:"sysBytes"`
+	NumGC           uint32      `json:"numGC"`
+	Pools           []PoolUsage `json:"pools"`
+}
+
+// FileDescriptorUsage is the number of open file descriptors of the process.
+type FileDescriptorUsage struct {
+	Open       int            `json:"open"`
+	Limit      uint64         `json:"limit"`
+	ByCategory map[string]int `json:"byCategory"`
+}
+
+// GoroutineUsage is the number of goroutines of the process.
+type GoroutineUsage struct {
+	Total       int            `json:"total"`
+	BySubsystem map[string]int `json:"bySubsystem"`
+}
+
+// Usage is the resource usage of the process.
+type Usage struct {
+	Memory MemoryUsage `json:"memory"`
+
+	// FileDescriptors is nil on platforms without a procfs.
+	FileDescriptors *FileDescriptorUsage `json:"fileDescriptors"`
+
+	Goroutines GoroutineUsage `json:"goroutines"`
+}
+
+type pool struct {
+	name string
+	fn   PoolUsageFn
+}
+
+type directory struct {
+	category string
+	path     string
+}
+
+type subsystem struct {
+	name   string
+	prefix string
+}
+
+// Reporter reports the resource usage of the process by the pools,
+// directories and subsystems registered with it.
+type Reporter struct {
+	sync.RWMutex
+	pools       []pool
+	directories []directory
+	subsystems  []subsystem
+}
+
+// NewReporter returns a new reporter with no pools, directories or
+// subsystems registered.
+func NewReporter() *Reporter {
+	return &Reporter{}
+}
+
+// RegisterPool registers a pool whose memory is reported under name.
+func (r *Reporter) RegisterPool(name string, fn PoolUsageFn) {
+	r.Lock()
+	r.pools = append(r.pools, pool{name: name, fn: fn})
+	r.Unlock()
+}
+
+// RegisterDirectory registers a directory whose open files are counted
+// under category, files in nested registered directories are counted under
+// the category of the most nested directory.
+func (r *Reporter) RegisterDirectory(category, path string) {
+	path = strings.TrimSuffix(path, "/") + "/"
+	r.Lock()
+	r.directories = append(r.directories, directory{category: category, path: path})
+	sort.SliceStable(r.directories, func(i, j int) bool {
+		return len(r.directories[i].path) > len(r.directories[j].path)
+	})
+	r.Unlock()
+}
+
+// RegisterSubsystem registers a subsystem whose goroutines are counted
+// under name. A goroutine belongs to the subsystem with the longest package
+// prefix matching the innermost function of its stack that matches any
+// registered prefix.
+func (r *Reporter) RegisterSubsystem(name string, packagePrefixes ...string) {
+	r.Lock()
+	for _, prefix := range packagePrefixes {
+		r.subsystems = append(r.subsystems, subsystem{name: name, prefix: prefix})
+	}
+	sort.SliceStable(r.subsystems, func(i, j int) bool {
+		return len(r.subsystems[i].prefix) > len(r.subsystems[j].prefix)
+	})
+	r.Unlock()
+}
+
+// Usage returns the current resource usage of the process.
+func (r *Reporter) Usage() (Usage, error) {
+	r.RLock()
+	defer r.RUnlock()
+
+	fds, err := r.fileDescriptorUsage()
+	if err != nil {
+		return Usage{}, err
+	}
+	return Usage{
+		Memory:          r.memoryUsage(),
+		FileDescriptors: fds,
+		Goroutines:      r.goroutineUsage(),
+	}, nil
+}
+
+func (r *Reporter) memoryUsage() MemoryUsage {
+	var stats runtime.MemStats
+	runtime.ReadMemStats(&stats)
+
+	usage := MemoryUsage{
+		HeapAllocBytes:  stats.HeapAlloc,
+		HeapInuseBytes:  stats.HeapInuse,
+		HeapIdleBytes:   stats.HeapIdle,
+		StackInuseBytes: stats.StackInuse,
+		SysBytes:        stats.Sys,
+		NumGC:           stats.NumGC,
+		Pools:           make([]PoolUsage, 0, len(r.pools)),
+	}
+	for _, p := range r.pools {
+		pu := p.fn()
+		pu.Name = p.name
+		usage.Pools = append(usage.Pools, pu)
+	}
+	return usage
+}
+
+func (r *Reporter) goroutineUsage() GoroutineUsage {
+	var (
+		records []runtime.StackRecord
+		n       = runtime.NumGoroutine()
+	)
+	for i := 0; i < maxGoroutineProfileAttempts; i++ {
+		// Leave room for goroutines started while the profile is taken.
+		records = make([]runtime.StackRecord, n+n/10+10)
+		var ok bool
+		if n, ok = runtime.GoroutineProfile(records); ok {
+			records = records[:n]
+			break
+		}
+		records = nil
+	}
+
+	usage := GoroutineUsage{
+		Total:       runtime.NumGoroutine(),
+		BySubsystem: make(map[string]int),
+	}
+	if records == nil {
+		usage.BySubsystem[OtherCategory] = usage.Total
+		return usage
+	}
+
+	usage.Total = len(records)
+	for _, record := range records {
+		usage.BySubsystem[r.subsystem(record.Stack())]++
+	}
+	return usage
+}
+
+// subsystem returns the subsystem of the innermost function of a stack
+// that belongs to a registered subsystem.
+func (r *Reporter) subsystem(stack []uintptr) string {
+	frames := runtime.CallersFrames(stack)
+	for {
+		frame, more := frames.Next()
+		for _, s := range r.subsystems {
+			if strings.HasPrefix(frame.Function, s.prefix) {
+				return s.name
+			}
+		}
+		if !more {
+			return OtherCategory
+		}
+	}
+}
+
+// category returns the category of an open file descriptor from the target
+// of its procfs link.
+func (r *Reporter) category(target string) string {
+	for _, d := range r.directories {
+		if strings.HasPrefix(target, d.path) {
+			return d.category
+		}
+	}
+	switch {
+	case strings.HasPrefix(target, "socket:"):
+		return "socket"
+	case strings.HasPrefix(target, "pipe:"):
+		return "pipe"
+	case strings.HasPrefix(target, "anon_inode:"):
+		return "anon_inode"
+	}
+	return OtherCategory
+}
@@ -0,0 +1,120 @@
+// Copyright (c) 2018 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package resource
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestReporterPools(t *testing.T) {
+	r := NewReporter()
+	r.RegisterPool("bytes-pool", func() PoolUsage {
+		return PoolUsage{Objects: 4, Bytes: 1024, Estimated: true}
+	})
+
+	usage, err := r.Usage()
+	require.NoError(t, err)
+	require.Len(t, usage.Memory.Pools, 1)
+	assert.Equal(t, PoolUsage{
+		Name:      "bytes-pool",
+		Objects:   4,
+		Bytes:     1024,
+		Estimated: true,
+	}, usage.Memory.Pools[0])
+	assert.True(t, usage.Memory.HeapAllocBytes > 0)
+}
+
+func TestReporterGoroutines(t *testing.T) {
+	r := NewReporter()
+	r.RegisterSubsystem("resource", "github.com/m3db/m3/src/x/resource.")
+	r.RegisterSubsystem("testing", "testing.")
+
+	doneCh := make(chan struct{})
+	defer close(doneCh)
+	startedCh := make(chan struct{})
+	go func() {
+		close(startedCh)
+		<-doneCh
+	}()
+	<-startedCh
+
+	usage, err := r.Usage()
+	require.NoError(t, err)
+
+	// Both this test and the goroutine it started are in the resource
+	// package, the innermost function of the stack takes precedence over
+	// the testing package.
+	assert.True(t, usage.Goroutines.BySubsystem["resource"] >= 2)
+	total := 0
+	for _, n := range usage.Goroutines.BySubsystem {
+		total += n
+	}
+	assert.Equal(t, usage.Goroutines.Total, total)
+}
+
+func TestReporterFileDescriptors(t *testing.T) {
+	if _, err := os.Stat(procFDDir); err != nil {
+		t.Skip("no procfs")
+	}
+
+	dir, err := ioutil.TempDir("", "resource")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+	require.NoError(t, os.MkdirAll(filepath.Join(dir, "index"), 0755))
+
+	f1, err := os.Create(filepath.Join(dir, "data"))
+	require.NoError(t, err)
+	defer f1.Close()
+	f2, err := os.Create(filepath.Join(dir, "index", "segment"))
+	require.NoError(t, err)
+	defer f2.Close()
+
+	r := NewReporter()
+	r.RegisterDirectory("data", dir)
+	r.RegisterDirectory("index", filepath.Join(dir, "index"))
+
+	usage, err := r.Usage()
+	require.NoError(t, err)
+	require.NotNil(t, usage.FileDescriptors)
+	assert.Equal(t, 1, usage.FileDescriptors.ByCategory["data"])
+	assert.Equal(t, 1, usage.FileDescriptors.ByCategory["index"])
+	assert.True(t, usage.FileDescriptors.Open >= 2)
+}
+
+func TestReporterCategory(t *testing.T) {
+	r := NewReporter()
+	r.RegisterDirectory("data", "/var/lib/m3db/data/")
+	r.RegisterDirectory("commitlog", "/var/lib/m3db/commitlogs")
+
+	assert.Equal(t, "data", r.category("/var/lib/m3db/data/default/0/fileset-0-0-data.db"))
+	assert.Equal(t, "commitlog", r.category("/var/lib/m3db/commitlogs/commitlog-0-0.db"))
+	assert.Equal(t, OtherCategory, r.category("/var/lib/m3db/commitlogs-old"))
+	assert.Equal(t, "socket", r.category("socket:[12345]"))
+	assert.Equal(t, "pipe", r.category("pipe:[12345]"))
+	assert.Equal(t, "anon_inode", r.category("anon_inode:[eventpoll]"))
+	assert.Equal(t, OtherCategory, r.category("/dev/null"))
+}
@@ -0,0 +1,122 @@
+// Copyright (c) 2018 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+// Package tracing provides an OpenTracing tracer that propagates trace
+// context in the W3C trace context format and exports spans to an
+// OpenTelemetry collector over OTLP/HTTP, so that a single trace follows a
+// request across the M3 components.
+package tracing
+
+import (
+	"errors"
+	"time"
+
+	"github.com/m3db/m3x/instrument"
+)
+
+const (
+	// DefaultSampleRate is the default ratio of the traces started by a
+	// service that are sampled.
+	DefaultSampleRate = 0.01
+
+	// DefaultBatchSize is the default max number of spans exported at once.
+	DefaultBatchSize = 512
+
+	// DefaultFlushInterval is the default interval spans are exported at.
+	DefaultFlushInterval = 5 * time.Second
+
+	// DefaultQueueSize is the default number of spans queued to be exported
+	// before spans are dropped.
+	DefaultQueueSize = 8192
+
+	// DefaultTimeout is the default timeout of export requests.
+	DefaultTimeout = 10 * time.Second
+)
+
+var (
+	errNoEndpoint        = errors.New("tracing endpoint must be set")
+	errInvalidSampleRate = errors.New("tracing sample rate must be between 0 and 1")
+)
+
+// Configuration is the tracing configuration of a service.
+type Configuration struct {
+	// ServiceName is the name spans are exported under, defaults to the name
+	// of the service.
+	ServiceName string `yaml:"serviceName"`
+
+	// Endpoint is the OTLP/HTTP traces endpoint of the collector, e.g.
+	// http://localhost:4318/v1/traces.
+	Endpoint string `yaml:"endpoint" validate:"nonzero"`
+
+	// Headers are sent with every export request, e.g. for authentication.
+	Headers map[string]string `yaml:"headers"`
+
+	// SampleRate is the ratio of the traces started by the service that are
+	// sampled, defaults to 0.01. Traces continued from a caller are sampled
+	// if the caller sampled them.
+	SampleRate *float64 `yaml:"sampleRate"`
+
+	// BatchSize is the max number of spans exported at once, defaults to
+	// 512.
+	BatchSize int `yaml:"batchSize"`
+
+	// FlushInterval is the interval spans are exported at, defaults to 5s.
+	FlushInterval time.Duration `yaml:"flushInterval"`
+
+	// QueueSize is the number of spans queued to be exported before spans
+	// are dropped, defaults to 8192.
+	QueueSize int `yaml:"queueSize"`
+
+	// Timeout is the timeout of export requests, defaults to 10s.
+	Timeout time.Duration `yaml:"timeout"`
+}
+
+// NewTracer returns the tracer of the configuration, spans are exported
+// under the service name unless the configuration sets one.
+func (c Configuration) NewTracer(
+	serviceName string,
+	iOpts instrument.Options,
+) (*Tracer, error) {
+	if c.Endpoint == "" {
+		return nil, errNoEndpoint
+	}
+	sampleRate := DefaultSampleRate
+	if c.SampleRate != nil {
+		sampleRate = *c.SampleRate
+	}
+	if sampleRate < 0 || sampleRate > 1 {
+		return nil, errInvalidSampleRate
+	}
+	if c.ServiceName != "" {
+		serviceName = c.ServiceName
+	}
+
+	exporter := NewOTLPExporter(OTLPExporterOptions{
+		Endpoint:       c.Endpoint,
+		Headers:        c.Headers,
+		ServiceName:    serviceName,
+		BatchSize:      c.BatchSize,
+		FlushInterval:  c.FlushInterval,
+		QueueSize:      c.QueueSize,
+		Timeout:        c.Timeout,
+		InstrumentOpts: iOpts,
+	})
+	return NewTracer(sampleRate, exporter), nil
+}
@@ -0,0 +1,422 @@
+// Copyright (c) 2018 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package tracing
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/m3db/m3x/instrument"
+
+	"github.com/opentracing/opentracing-go/ext"
+	"github.com/opentracing/opentracing-go/log"
+	"github.com/uber-go/tally"
+)
+
+// OTLP span kinds and status codes.
+const (
+	otlpSpanKindInternal = 1
+	otlpSpanKindServer   = 2
+	otlpSpanKindClient   = 3
+	otlpSpanKindProducer = 4
+	otlpSpanKindConsumer = 5
+
+	otlpStatusCodeError = 2
+
+	instrumentationScope = "github.com/m3db/m3/src/x/tracing"
+)
+
+var (
+	errOTLPExporterClosed = errors.New("otlp exporter closed")
+)
+
+// OTLPExporterOptions are the options of an exporter that sends spans to an
+// OpenTelemetry collector over OTLP/HTTP with JSON encoding.
+type OTLPExporterOptions struct {
+	// Endpoint is the OTLP/HTTP traces endpoint of the collector.
+	Endpoint string
+
+	// Headers are sent with every export request.
+	Headers map[string]string
+
+	// ServiceName is the service.name resource attribute of the spans.
+	ServiceName string
+
+	BatchSize      int
+	FlushInterval  time.Duration
+	QueueSize      int
+	Timeout        time.Duration
+	InstrumentOpts instrument.Options
+}
+
+type otlpExporterMetrics struct {
+	exported     tally.Counter
+	dropped      tally.Counter
+	exportErrors tally.Counter
+}
+
+func newOTLPExporterMetrics(scope tally.Scope) otlpExporterMetrics {
+	return otlpExporterMetrics{
+		exported:     scope.Counter("exported"),
+		dropped:      scope.Counter("dropped"),
+		exportErrors: scope.Counter("export-errors"),
+	}
+}
+
+type otlpExporter struct {
+	opts    OTLPExporterOptions
+	client  *http.Client
+	queue   chan SpanData
+	metrics otlpExporterMetrics
+
+	closeLock sync.RWMutex
+	closed    bool
+	wg        sync.WaitGroup
+}
+
+// NewOTLPExporter returns an exporter that sends spans to an OpenTelemetry
+// collector in batches in the background, spans are dropped while the queue
+// is full.
+func NewOTLPExporter(opts OTLPExporterOptions) Exporter {
+	if opts.BatchSize <= 0 {
+		opts.BatchSize = DefaultBatchSize
+	}
+	if opts.FlushInterval <= 0 {
+		opts.FlushInterval = DefaultFlushInterval
+	}
+	if opts.QueueSize <= 0 {
+		opts.QueueSize = DefaultQueueSize
+	}
+	if opts.Timeout <= 0 {
+		opts.Timeout = DefaultTimeout
+	}
+	if opts.InstrumentOpts == nil {
+		opts.InstrumentOpts = instrument.NewOptions()
+	}
+
+	e := &otlpExporter{
+		opts:    opts,
+		client:  &http.Client{Timeout: opts.Timeout},
+		queue:   make(chan SpanData, opts.QueueSize),
+		metrics: newOTLPExporterMetrics(opts.InstrumentOpts.MetricsScope().SubScope("tracing")),
+	}
+	e.wg.Add(1)
+	go e.run()
+	return e
+}
+
+func (e *otlpExporter) Export(span SpanData) {
+	e.closeLock.RLock()
+	defer e.closeLock.RUnlock()
+	if e.closed {
+		e.metrics.dropped.Inc(1)
+		return
+	}
+
+	select {
+	case e.queue <- span:
+	default:
+		e.metrics.dropped.Inc(1)
+	}
+}
+
+func (e *otlpExporter) run() {
+	defer e.wg.Done()
+
+	var (
+		ticker = time.NewTicker(e.opts.FlushInterval)
+		batch  = make([]SpanData, 0, e.opts.BatchSize)
+	)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case span, ok := <-e.queue:
+			if !ok {
+				e.flush(batch)
+				return
+			}
+			batch = append(batch, span)
+			if len(batch) >= e.opts.BatchSize {
+				e.flush(batch)
+				batch = batch[:0]
+			}
+		case <-ticker.C:
+			e.flush(batch)
+			batch = batch[:0]
+		}
+	}
+}
+
+func (e *otlpExporter) flush(batch []SpanData) {
+	if len(batch) == 0 {
+		return
+	}
+	if err := e.send(batch); err != nil {
+		e.metrics.exportErrors.Inc(1)
+		e.metrics.dropped.Inc(int64(len(batch)))
+		e.opts.InstrumentOpts.Logger().Errorf("unable to export %d spans to %s: %v",
+			len(batch), e.opts.Endpoint, err)
+		return
+	}
+	e.metrics.exported.Inc(int64(len(batch)))
+}
+
+func (e *otlpExporter) send(batch []SpanData) error {
+	body, err := json.Marshal(newOTLPTracesRequest(e.opts.ServiceName, batch))
+	if err != nil {
+		return err
+	}
+
+	req, err := http.NewRequest(http.MethodPost, e.opts.Endpoint, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	for name, value := range e.opts.Headers {
+		req.Header.Set(name, value)
+	}
+
+	resp, err := e.client.Do(req)
+	if err != nil {
+		return err
+	}
+	resp.Body.Close()
+
+	if resp.StatusCode/100 != 2 {
+		return fmt.Errorf("collector returned status %d", resp.StatusCode)
+	}
+	return nil
+}
+
+func (e *otlpExporter) Close() error {
+	e.closeLock.Lock()
+	if e.closed {
+		e.closeLock.Unlock()
+		return errOTLPExporterClosed
+	}
+	e.closed = true
+	close(e.queue)
+	e.closeLock.Unlock()
+
+	e.wg.Wait()
+	return nil
+}
+
+// The OTLP/HTTP JSON encoding of trace export requests, with IDs as hex and
+// 64 bit integers as strings.
+type otlpTracesRequest struct {
+	ResourceSpans []otlpResourceSpans `json:"resourceSpans"`
+}
+
+type otlpResourceSpans struct {
+	Resource   otlpResource     `json:"resource"`
+	ScopeSpans []otlpScopeSpans `json:"scopeSpans"`
+}
+
+type otlpResource struct {
+	Attributes []otlpKeyValue `json:"attributes"`
+}
+
+type otlpScopeSpans struct {
+	Scope otlpScope  `json:"scope"`
+	Spans []otlpSpan `json:"spans"`
+}
+
+type otlpScope struct {
+	Name string `json:"name"`
+}
+
+type otlpSpan struct {
+	TraceID           string         `json:"traceId"`
+	SpanID            string         `json:"spanId"`
+	ParentSpanID      string         `json:"parentSpanId,omitempty"`
+	Name              string         `json:"name"`
+	Kind              int            `json:"kind"`
+	StartTimeUnixNano string         `json:"startTimeUnixNano"`
+	EndTimeUnixNano   string         `json:"endTimeUnixNano"`
+	Attributes        []otlpKeyValue `json:"attributes,omitempty"`
+	Events            []otlpEvent    `json:"events,omitempty"`
+	Links             []otlpLink     `json:"links,omitempty"`
+	Status            *otlpStatus    `json:"status,omitempty"`
+}
+
+type otlpEvent struct {
+	TimeUnixNano string         `json:"timeUnixNano"`
+	Name         string         `json:"name"`
+	Attributes   []otlpKeyValue `json:"attributes,omitempty"`
+}
+
+type otlpLink struct {
+	TraceID string `json:"traceId"`
+	SpanID  string `json:"spanId"`
+}
+
+type otlpStatus struct {
+	Code int `json:"code"`
+}
+
+type otlpKeyValue struct {
+	Key   string       `json:"key"`
+	Value otlpAnyValue `json:"value"`
+}
+
+type otlpAnyValue struct {
+	StringValue *string  `json:"stringValue,omitempty"`
+	BoolValue   *bool    `json:"boolValue,omitempty"`
+	IntValue    *string  `json:"intValue,omitempty"`
+	DoubleValue *float64 `json:"doubleValue,omitempty"`
+}
+
+func newOTLPTracesRequest(serviceName string, batch []SpanData) otlpTracesRequest {
+	spans := make([]otlpSpan, 0, len(batch))
+	for _, data := range batch {
+		spans = append(spans, newOTLPSpan(data))
+	}
+	return otlpTracesRequest{
+		ResourceSpans: []otlpResourceSpans{
+			{
+				Resource: otlpResource{
+					Attributes: []otlpKeyValue{newOTLPKeyValue("service.name", serviceName)},
+				},
+				ScopeSpans: []otlpScopeSpans{
+					{
+						Scope: otlpScope{Name: instrumentationScope},
+						Spans: spans,
+					},
+				},
+			},
+		},
+	}
+}
+
+func newOTLPSpan(data SpanData) otlpSpan {
+	span := otlpSpan{
+		TraceID:           data.Context.TraceID.String(),
+		SpanID:            data.Context.SpanID.String(),
+		Name:              data.Name,
+		Kind:              otlpSpanKindInternal,
+		StartTimeUnixNano: unixNano(data.Start),
+		EndTimeUnixNano:   unixNano(data.End),
+	}
+	if !data.ParentSpanID.IsZero() {
+		span.ParentSpanID = data.ParentSpanID.String()
+	}
+
+	for key, value := range data.Tags {
+		switch key {
+		case string(ext.SpanKind):
+			span.Kind = otlpSpanKind(value)
+			continue
+		case string(ext.Error):
+			if isErr, ok := value.(bool); ok && isErr {
+				span.Status = &otlpStatus{Code: otlpStatusCodeError}
+			}
+			continue
+		}
+		span.Attributes = append(span.Attributes, newOTLPKeyValue(key, value))
+	}
+
+	for _, record := range data.Logs {
+		event := otlpEvent{
+			TimeUnixNano: unixNano(record.Timestamp),
+			Name:         "log",
+		}
+		for _, field := range record.Fields {
+			if field.Key() == "event" {
+				event.Name = fmt.Sprint(field.Value())
+				continue
+			}
+			event.Attributes = append(event.Attributes, newOTLPKeyValueFromField(field))
+		}
+		span.Events = append(span.Events, event)
+	}
+
+	for _, link := range data.Links {
+		span.Links = append(span.Links, otlpLink{
+			TraceID: link.TraceID.String(),
+			SpanID:  link.SpanID.String(),
+		})
+	}
+	return span
+}
+
+func otlpSpanKind(value interface{}) int {
+	switch fmt.Sprint(value) {
+	case string(ext.SpanKindRPCServerEnum):
+		return otlpSpanKindServer
+	case string(ext.SpanKindRPCClientEnum):
+		return otlpSpanKindClient
+	case string(ext.SpanKindProducerEnum):
+		return otlpSpanKindProducer
+	case string(ext.SpanKindConsumerEnum):
+		return otlpSpanKindConsumer
+	}
+	return otlpSpanKindInternal
+}
+
+func unixNano(t time.Time) string {
+	return strconv.FormatInt(t.UnixNano(), 10)
+}
+
+func newOTLPKeyValue(key string, value interface{}) otlpKeyValue {
+	var v otlpAnyValue
+	switch value := value.(type) {
+	case string:
+		v.StringValue = &value
+	case bool:
+		v.BoolValue = &value
+	case int:
+		s := strconv.FormatInt(int64(value), 10)
+		v.IntValue = &s
+	case int32:
+		s := strconv.FormatInt(int64(value), 10)
+		v.IntValue = &s
+	case int64:
+		s := strconv.FormatInt(value, 10)
+		v.IntValue = &s
+	case uint16:
+		s := strconv.FormatUint(uint64(value), 10)
+		v.IntValue = &s
+	case uint32:
+		s := strconv.FormatUint(uint64(value), 10)
+		v.IntValue = &s
+	case float32:
+		f := float64(value)
+		v.DoubleValue = &f
+	case float64:
+		v.DoubleValue = &value
+	default:
+		s := fmt.Sprint(value)
+		v.StringValue = &s
+	}
+	return otlpKeyValue{Key: key, Value: v}
+}
+
+func newOTLPKeyValueFromField(field log.Field) otlpKeyValue {
+	return newOTLPKeyValue(field.Key(), field.Value())
+}
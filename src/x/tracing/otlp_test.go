@@ -0,0 +1,125 @@
+// Copyright (c) 2018 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package tracing
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+
+	opentracing "github.com/opentracing/opentracing-go"
+	"github.com/opentracing/opentracing-go/ext"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestOTLPExporter(t *testing.T) {
+	var (
+		lock     sync.Mutex
+		requests []otlpTracesRequest
+	)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "application/json", r.Header.Get("Content-Type"))
+		assert.Equal(t, "secret", r.Header.Get("X-Api-Key"))
+
+		var req otlpTracesRequest
+		require.NoError(t, json.NewDecoder(r.Body).Decode(&req))
+		lock.Lock()
+		requests = append(requests, req)
+		lock.Unlock()
+	}))
+	defer server.Close()
+
+	exporter := NewOTLPExporter(OTLPExporterOptions{
+		Endpoint:      server.URL + "/v1/traces",
+		Headers:       map[string]string{"X-Api-Key": "secret"},
+		ServiceName:   "m3coordinator",
+		FlushInterval: time.Hour,
+	})
+	tracer := NewTracer(1, exporter)
+
+	parent := tracer.StartSpan("remote-write")
+	ext.SpanKindRPCServer.Set(parent)
+	child := tracer.StartSpan("storage-write", opentracing.ChildOf(parent.Context()))
+	child.SetTag("series", 10)
+	ext.Error.Set(child, true)
+	child.LogKV("event", "retry", "attempt", 2)
+	child.Finish()
+	parent.Finish()
+
+	// Closing exports the remaining spans.
+	require.NoError(t, tracer.Close())
+
+	require.Len(t, requests, 1)
+	resourceSpans := requests[0].ResourceSpans
+	require.Len(t, resourceSpans, 1)
+	assert.Equal(t, "service.name", resourceSpans[0].Resource.Attributes[0].Key)
+	assert.Equal(t, "m3coordinator", *resourceSpans[0].Resource.Attributes[0].Value.StringValue)
+
+	spans := resourceSpans[0].ScopeSpans[0].Spans
+	require.Len(t, spans, 2)
+
+	storageSpan, writeSpan := spans[0], spans[1]
+	parentCtx := parent.Context().(SpanContext)
+	assert.Equal(t, "remote-write", writeSpan.Name)
+	assert.Equal(t, otlpSpanKindServer, writeSpan.Kind)
+	assert.Empty(t, writeSpan.ParentSpanID)
+	assert.Equal(t, parentCtx.TraceID.String(), writeSpan.TraceID)
+
+	assert.Equal(t, "storage-write", storageSpan.Name)
+	assert.Equal(t, otlpSpanKindInternal, storageSpan.Kind)
+	assert.Equal(t, parentCtx.TraceID.String(), storageSpan.TraceID)
+	assert.Equal(t, parentCtx.SpanID.String(), storageSpan.ParentSpanID)
+	require.NotNil(t, storageSpan.Status)
+	assert.Equal(t, otlpStatusCodeError, storageSpan.Status.Code)
+	require.Len(t, storageSpan.Attributes, 1)
+	assert.Equal(t, "series", storageSpan.Attributes[0].Key)
+	assert.Equal(t, "10", *storageSpan.Attributes[0].Value.IntValue)
+	require.Len(t, storageSpan.Events, 1)
+	assert.Equal(t, "retry", storageSpan.Events[0].Name)
+	assert.Equal(t, "attempt", storageSpan.Events[0].Attributes[0].Key)
+
+	// Spans finished after the exporter is closed are dropped.
+	tracer.StartSpan("late").Finish()
+	assert.Error(t, exporter.Close())
+}
+
+func TestConfigurationNewTracer(t *testing.T) {
+	_, err := Configuration{}.NewTracer("m3dbnode", nil)
+	assert.Equal(t, errNoEndpoint, err)
+
+	rate := 2.0
+	_, err = Configuration{
+		Endpoint:   "http://localhost:4318/v1/traces",
+		SampleRate: &rate,
+	}.NewTracer("m3dbnode", nil)
+	assert.Equal(t, errInvalidSampleRate, err)
+
+	tracer, err := Configuration{
+		Endpoint: "http://localhost:4318/v1/traces",
+	}.NewTracer("m3dbnode", nil)
+	require.NoError(t, err)
+	assert.Equal(t, DefaultSampleRate, tracer.sampleRate)
+	require.NoError(t, tracer.Close())
+}
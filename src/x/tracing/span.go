@@ -0,0 +1,169 @@
+// Copyright (c) 2018 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package tracing
+
+import (
+	"sync"
+	"time"
+
+	opentracing "github.com/opentracing/opentracing-go"
+	"github.com/opentracing/opentracing-go/log"
+)
+
+// SpanData is the record of a finished span.
+type SpanData struct {
+	Context      SpanContext
+	ParentSpanID SpanID
+	Links        []SpanContext
+	Name         string
+	Start        time.Time
+	End          time.Time
+	Tags         map[string]interface{}
+	Logs         []opentracing.LogRecord
+}
+
+// span records its tags and logs only if its trace is sampled, so that
+// spans of traces that are not sampled are cheap.
+type span struct {
+	sync.Mutex
+
+	tracer   *Tracer
+	data     SpanData
+	finished bool
+}
+
+func (s *span) Finish() {
+	s.FinishWithOptions(opentracing.FinishOptions{})
+}
+
+func (s *span) FinishWithOptions(opts opentracing.FinishOptions) {
+	s.Lock()
+	if s.finished {
+		s.Unlock()
+		return
+	}
+	s.finished = true
+	if !s.data.Context.Sampled {
+		s.Unlock()
+		return
+	}
+
+	s.data.End = opts.FinishTime
+	if s.data.End.IsZero() {
+		s.data.End = s.tracer.nowFn()
+	}
+	s.data.Logs = append(s.data.Logs, opts.LogRecords...)
+	for _, ld := range opts.BulkLogData {
+		s.data.Logs = append(s.data.Logs, ld.ToLogRecord())
+	}
+	data := s.data
+	s.Unlock()
+
+	s.tracer.exporter.Export(data)
+}
+
+func (s *span) Context() opentracing.SpanContext {
+	s.Lock()
+	defer s.Unlock()
+	return s.data.Context
+}
+
+func (s *span) SetOperationName(operationName string) opentracing.Span {
+	s.Lock()
+	s.data.Name = operationName
+	s.Unlock()
+	return s
+}
+
+func (s *span) SetTag(key string, value interface{}) opentracing.Span {
+	s.Lock()
+	defer s.Unlock()
+	if !s.data.Context.Sampled || s.finished {
+		return s
+	}
+	if s.data.Tags == nil {
+		s.data.Tags = make(map[string]interface{})
+	}
+	s.data.Tags[key] = value
+	return s
+}
+
+func (s *span) LogFields(fields ...log.Field) {
+	s.Lock()
+	defer s.Unlock()
+	if !s.data.Context.Sampled || s.finished {
+		return
+	}
+	s.data.Logs = append(s.data.Logs, opentracing.LogRecord{
+		Timestamp: s.tracer.nowFn(),
+		Fields:    fields,
+	})
+}
+
+func (s *span) LogKV(alternatingKeyValues ...interface{}) {
+	fields, err := log.InterleavedKVToFields(alternatingKeyValues...)
+	if err != nil {
+		s.LogFields(log.Error(err), log.String("function", "LogKV"))
+		return
+	}
+	s.LogFields(fields...)
+}
+
+func (s *span) SetBaggageItem(restrictedKey, value string) opentracing.Span {
+	s.Lock()
+	defer s.Unlock()
+	// Copy on write as the baggage is shared with the child spans.
+	baggage := make(map[string]string, len(s.data.Context.baggage)+1)
+	for k, v := range s.data.Context.baggage {
+		baggage[k] = v
+	}
+	baggage[restrictedKey] = value
+	s.data.Context.baggage = baggage
+	return s
+}
+
+func (s *span) BaggageItem(restrictedKey string) string {
+	s.Lock()
+	defer s.Unlock()
+	return s.data.Context.baggage[restrictedKey]
+}
+
+func (s *span) Tracer() opentracing.Tracer {
+	return s.tracer
+}
+
+func (s *span) LogEvent(event string) {
+	s.LogFields(log.String("event", event))
+}
+
+func (s *span) LogEventWithPayload(event string, payload interface{}) {
+	s.LogFields(log.String("event", event), log.Object("payload", payload))
+}
+
+func (s *span) Log(ld opentracing.LogData) {
+	record := ld.ToLogRecord()
+	s.Lock()
+	defer s.Unlock()
+	if !s.data.Context.Sampled || s.finished {
+		return
+	}
+	s.data.Logs = append(s.data.Logs, record)
+}
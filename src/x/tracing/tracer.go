@@ -0,0 +1,318 @@
+// Copyright (c) 2018 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package tracing
+
+import (
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"math/rand"
+	"strings"
+	"sync"
+	"time"
+
+	opentracing "github.com/opentracing/opentracing-go"
+)
+
+const (
+	// TraceParentHeader is the W3C trace context header.
+	TraceParentHeader = "traceparent"
+
+	traceParentVersion = "00"
+	sampledFlag        = 0x01
+)
+
+var (
+	errTraceParentInvalid = errors.New("invalid traceparent")
+)
+
+// TraceID is the 16 byte ID of a trace.
+type TraceID [16]byte
+
+// String returns the hex encoding of the trace ID.
+func (id TraceID) String() string {
+	return hex.EncodeToString(id[:])
+}
+
+// IsZero returns whether the trace ID is all zeroes, which is invalid.
+func (id TraceID) IsZero() bool {
+	return id == TraceID{}
+}
+
+// SpanID is the 8 byte ID of a span.
+type SpanID [8]byte
+
+// String returns the hex encoding of the span ID.
+func (id SpanID) String() string {
+	return hex.EncodeToString(id[:])
+}
+
+// IsZero returns whether the span ID is all zeroes, which is invalid.
+func (id SpanID) IsZero() bool {
+	return id == SpanID{}
+}
+
+// SpanContext is the context of a span that is propagated across process
+// boundaries.
+type SpanContext struct {
+	TraceID TraceID
+	SpanID  SpanID
+	Sampled bool
+
+	// baggage is only propagated within a process.
+	baggage map[string]string
+}
+
+// ForeachBaggageItem calls the handler with each baggage item until it
+// returns false.
+func (c SpanContext) ForeachBaggageItem(handler func(k, v string) bool) {
+	for k, v := range c.baggage {
+		if !handler(k, v) {
+			return
+		}
+	}
+}
+
+// TraceParent returns the W3C traceparent header value of the context.
+func (c SpanContext) TraceParent() string {
+	var flags byte
+	if c.Sampled {
+		flags |= sampledFlag
+	}
+	return fmt.Sprintf("%s-%s-%s-%02x", traceParentVersion, c.TraceID, c.SpanID, flags)
+}
+
+// ParseTraceParent parses a W3C traceparent header value.
+func ParseTraceParent(value string) (SpanContext, error) {
+	parts := strings.Split(strings.TrimSpace(value), "-")
+	if len(parts) < 4 || len(parts[0]) != 2 || parts[0] == "ff" {
+		return SpanContext{}, errTraceParentInvalid
+	}
+	// Versions after 00 may append fields but keep the first four.
+	if parts[0] == traceParentVersion && len(parts) != 4 {
+		return SpanContext{}, errTraceParentInvalid
+	}
+
+	var (
+		ctx   SpanContext
+		flags [1]byte
+	)
+	if err := decodeHex(ctx.TraceID[:], parts[1]); err != nil {
+		return SpanContext{}, err
+	}
+	if err := decodeHex(ctx.SpanID[:], parts[2]); err != nil {
+		return SpanContext{}, err
+	}
+	if err := decodeHex(flags[:], parts[3]); err != nil {
+		return SpanContext{}, err
+	}
+	if ctx.TraceID.IsZero() || ctx.SpanID.IsZero() {
+		return SpanContext{}, errTraceParentInvalid
+	}
+	ctx.Sampled = flags[0]&sampledFlag != 0
+	return ctx, nil
+}
+
+func decodeHex(dst []byte, s string) error {
+	if hex.EncodedLen(len(dst)) != len(s) {
+		return errTraceParentInvalid
+	}
+	if _, err := hex.Decode(dst, []byte(s)); err != nil {
+		return errTraceParentInvalid
+	}
+	return nil
+}
+
+// Exporter exports the finished spans of sampled traces.
+type Exporter interface {
+	// Export exports a finished span, it must not block.
+	Export(span SpanData)
+
+	// Close exports the remaining spans and stops the exporter.
+	Close() error
+}
+
+// Tracer is an OpenTracing tracer that samples a ratio of the traces it
+// starts and exports their spans.
+type Tracer struct {
+	sampleRate float64
+	exporter   Exporter
+	nowFn      func() time.Time
+
+	randLock sync.Mutex
+	rand     *rand.Rand
+}
+
+// NewTracer returns a new tracer that samples the ratio of the traces it
+// starts and exports the spans of sampled traces with the exporter.
+func NewTracer(sampleRate float64, exporter Exporter) *Tracer {
+	return &Tracer{
+		sampleRate: sampleRate,
+		exporter:   exporter,
+		nowFn:      time.Now,
+		rand:       rand.New(rand.NewSource(time.Now().UnixNano())),
+	}
+}
+
+// StartSpan starts a span, the first of the references that is sampled, or
+// else the first reference, is its parent and the others are its links.
+func (t *Tracer) StartSpan(
+	operationName string,
+	opts ...opentracing.StartSpanOption,
+) opentracing.Span {
+	var o opentracing.StartSpanOptions
+	for _, opt := range opts {
+		opt.Apply(&o)
+	}
+
+	s := &span{
+		tracer: t,
+		data: SpanData{
+			Name:  operationName,
+			Start: o.StartTime,
+		},
+	}
+	if s.data.Start.IsZero() {
+		s.data.Start = t.nowFn()
+	}
+
+	var (
+		refs   = make([]SpanContext, 0, len(o.References))
+		parent = -1
+	)
+	for _, ref := range o.References {
+		ctx, ok := ref.ReferencedContext.(SpanContext)
+		if !ok {
+			continue
+		}
+		if parent == -1 || (ctx.Sampled && !refs[parent].Sampled) {
+			parent = len(refs)
+		}
+		refs = append(refs, ctx)
+	}
+
+	if parent >= 0 {
+		s.data.Context.TraceID = refs[parent].TraceID
+		s.data.Context.Sampled = refs[parent].Sampled
+		s.data.Context.baggage = refs[parent].baggage
+		s.data.ParentSpanID = refs[parent].SpanID
+		for i, ref := range refs {
+			if i != parent {
+				s.data.Links = append(s.data.Links, ref)
+			}
+		}
+	}
+
+	t.randLock.Lock()
+	if parent < 0 {
+		t.randomID(s.data.Context.TraceID[:])
+		s.data.Context.Sampled = t.rand.Float64() < t.sampleRate
+	}
+	t.randomID(s.data.Context.SpanID[:])
+	t.randLock.Unlock()
+
+	if s.data.Context.Sampled && len(o.Tags) > 0 {
+		s.data.Tags = make(map[string]interface{}, len(o.Tags))
+		for k, v := range o.Tags {
+			s.data.Tags[k] = v
+		}
+	}
+	return s
+}
+
+// randomID fills an ID with random bytes that are not all zeroes, the rand
+// lock must be held.
+func (t *Tracer) randomID(id []byte) {
+	for {
+		t.rand.Read(id)
+		for _, b := range id {
+			if b != 0 {
+				return
+			}
+		}
+	}
+}
+
+// Inject writes the span context to a TextMap or HTTPHeaders carrier as a
+// W3C traceparent header.
+func (t *Tracer) Inject(
+	sm opentracing.SpanContext,
+	format interface{},
+	carrier interface{},
+) error {
+	ctx, ok := sm.(SpanContext)
+	if !ok {
+		return opentracing.ErrInvalidSpanContext
+	}
+	switch format {
+	case opentracing.TextMap, opentracing.HTTPHeaders:
+	default:
+		return opentracing.ErrUnsupportedFormat
+	}
+	writer, ok := carrier.(opentracing.TextMapWriter)
+	if !ok {
+		return opentracing.ErrInvalidCarrier
+	}
+	writer.Set(TraceParentHeader, ctx.TraceParent())
+	return nil
+}
+
+// Extract reads the span context of a W3C traceparent header from a TextMap
+// or HTTPHeaders carrier.
+func (t *Tracer) Extract(
+	format interface{},
+	carrier interface{},
+) (opentracing.SpanContext, error) {
+	switch format {
+	case opentracing.TextMap, opentracing.HTTPHeaders:
+	default:
+		return nil, opentracing.ErrUnsupportedFormat
+	}
+	reader, ok := carrier.(opentracing.TextMapReader)
+	if !ok {
+		return nil, opentracing.ErrInvalidCarrier
+	}
+
+	var traceParent string
+	if err := reader.ForeachKey(func(k, v string) error {
+		if strings.EqualFold(k, TraceParentHeader) {
+			traceParent = v
+		}
+		return nil
+	}); err != nil {
+		return nil, err
+	}
+	if traceParent == "" {
+		return nil, opentracing.ErrSpanContextNotFound
+	}
+
+	ctx, err := ParseTraceParent(traceParent)
+	if err != nil {
+		return nil, opentracing.ErrSpanContextCorrupted
+	}
+	return ctx, nil
+}
+
+// Close exports the remaining spans and stops the tracer, spans must not be
+// finished after it is closed.
+func (t *Tracer) Close() error {
+	return t.exporter.Close()
+}
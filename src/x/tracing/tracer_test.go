@@ -0,0 +1,170 @@
+// Copyright (c) 2018 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package tracing
+
+import (
+	"net/http"
+	"sync"
+	"testing"
+
+	opentracing "github.com/opentracing/opentracing-go"
+	"github.com/opentracing/opentracing-go/ext"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type memoryExporter struct {
+	sync.Mutex
+	spans []SpanData
+}
+
+func (e *memoryExporter) Export(span SpanData) {
+	e.Lock()
+	e.spans = append(e.spans, span)
+	e.Unlock()
+}
+
+func (e *memoryExporter) Close() error {
+	return nil
+}
+
+func TestParseTraceParent(t *testing.T) {
+	ctx, err := ParseTraceParent("00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01")
+	require.NoError(t, err)
+	assert.Equal(t, "4bf92f3577b34da6a3ce929d0e0e4736", ctx.TraceID.String())
+	assert.Equal(t, "00f067aa0ba902b7", ctx.SpanID.String())
+	assert.True(t, ctx.Sampled)
+	assert.Equal(t, "00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01", ctx.TraceParent())
+
+	ctx, err = ParseTraceParent("00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-00")
+	require.NoError(t, err)
+	assert.False(t, ctx.Sampled)
+
+	for _, invalid := range []string{
+		"",
+		"00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7",
+		"00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01-extra",
+		"ff-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01",
+		"00-00000000000000000000000000000000-00f067aa0ba902b7-01",
+		"00-4bf92f3577b34da6a3ce929d0e0e4736-0000000000000000-01",
+		"00-4bf92f3577b34da6-00f067aa0ba902b7-01",
+		"00-zzf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01",
+	} {
+		_, err := ParseTraceParent(invalid)
+		assert.Error(t, err, invalid)
+	}
+}
+
+func TestTracerInjectExtract(t *testing.T) {
+	tracer := NewTracer(1, &memoryExporter{})
+	span := tracer.StartSpan("write")
+	defer span.Finish()
+
+	headers := http.Header{}
+	require.NoError(t, tracer.Inject(span.Context(), opentracing.HTTPHeaders,
+		opentracing.HTTPHeadersCarrier(headers)))
+	assert.Equal(t, span.Context().(SpanContext).TraceParent(), headers.Get(TraceParentHeader))
+
+	extracted, err := tracer.Extract(opentracing.HTTPHeaders,
+		opentracing.HTTPHeadersCarrier(headers))
+	require.NoError(t, err)
+	assert.Equal(t, span.Context().(SpanContext).TraceID, extracted.(SpanContext).TraceID)
+	assert.Equal(t, span.Context().(SpanContext).SpanID, extracted.(SpanContext).SpanID)
+	assert.True(t, extracted.(SpanContext).Sampled)
+
+	_, err = tracer.Extract(opentracing.TextMap, opentracing.TextMapCarrier{})
+	assert.Equal(t, opentracing.ErrSpanContextNotFound, err)
+
+	_, err = tracer.Extract(opentracing.TextMap,
+		opentracing.TextMapCarrier{TraceParentHeader: "invalid"})
+	assert.Equal(t, opentracing.ErrSpanContextCorrupted, err)
+
+	_, err = tracer.Extract(opentracing.Binary, nil)
+	assert.Equal(t, opentracing.ErrUnsupportedFormat, err)
+}
+
+func TestTracerSampling(t *testing.T) {
+	exporter := &memoryExporter{}
+	tracer := NewTracer(0, exporter)
+
+	// Traces started by the tracer are not sampled at a zero rate.
+	root := tracer.StartSpan("root")
+	root.SetTag("ignored", true)
+	root.Finish()
+	assert.False(t, root.Context().(SpanContext).Sampled)
+	assert.Empty(t, exporter.spans)
+
+	// Traces continued from a caller are sampled if the caller sampled them.
+	parent, err := ParseTraceParent("00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01")
+	require.NoError(t, err)
+	child := tracer.StartSpan("child", ext.RPCServerOption(parent),
+		opentracing.Tag{Key: "namespace", Value: "metrics"})
+	child.SetTag("batch", 128)
+	child.LogKV("event", "flushed")
+	child.Finish()
+	child.Finish()
+
+	require.Len(t, exporter.spans, 1)
+	data := exporter.spans[0]
+	assert.Equal(t, "child", data.Name)
+	assert.Equal(t, parent.TraceID, data.Context.TraceID)
+	assert.Equal(t, parent.SpanID, data.ParentSpanID)
+	assert.NotEqual(t, parent.SpanID, data.Context.SpanID)
+	assert.Equal(t, "metrics", data.Tags["namespace"])
+	assert.Equal(t, 128, data.Tags["batch"])
+	assert.Equal(t, ext.SpanKindRPCServerEnum, data.Tags[string(ext.SpanKind)])
+	require.Len(t, data.Logs, 1)
+	assert.False(t, data.End.Before(data.Start))
+}
+
+func TestTracerFollowsFromSampledReference(t *testing.T) {
+	exporter := &memoryExporter{}
+	tracer := NewTracer(0, exporter)
+
+	unsampled := tracer.StartSpan("unsampled").Context()
+	sampled, err := ParseTraceParent("00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01")
+	require.NoError(t, err)
+
+	// The first sampled reference is the parent and the others are links.
+	batch := tracer.StartSpan("batch",
+		opentracing.FollowsFrom(unsampled), opentracing.FollowsFrom(sampled))
+	batch.Finish()
+
+	require.Len(t, exporter.spans, 1)
+	data := exporter.spans[0]
+	assert.Equal(t, sampled.TraceID, data.Context.TraceID)
+	assert.Equal(t, sampled.SpanID, data.ParentSpanID)
+	require.Len(t, data.Links, 1)
+	assert.Equal(t, unsampled.(SpanContext).TraceID, data.Links[0].TraceID)
+}
+
+func TestSpanBaggage(t *testing.T) {
+	tracer := NewTracer(1, &memoryExporter{})
+	parent := tracer.StartSpan("parent")
+	parent.SetBaggageItem("tenant", "acme")
+
+	child := tracer.StartSpan("child", opentracing.ChildOf(parent.Context()))
+	assert.Equal(t, "acme", child.BaggageItem("tenant"))
+
+	// Baggage set on the child is not visible to the parent.
+	child.SetBaggageItem("shard", "12")
+	assert.Empty(t, parent.BaggageItem("shard"))
+}
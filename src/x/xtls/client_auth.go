@@ -0,0 +1,115 @@
+// Copyright (c) 2018 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package xtls
+
+import (
+	"crypto/tls"
+	"errors"
+	"fmt"
+)
+
+var (
+	errClientAuthUnspecified = errors.New("tls client auth type unspecified")
+)
+
+// ClientAuthType is the policy of a server for verifying client
+// certificates.
+type ClientAuthType uint
+
+const (
+	// ClientAuthRequireAndVerify requires clients to present a certificate
+	// signed by the CA.
+	ClientAuthRequireAndVerify ClientAuthType = iota
+	// ClientAuthVerifyIfGiven verifies client certificates against the CA
+	// if presented.
+	ClientAuthVerifyIfGiven
+	// ClientAuthNone does not request client certificates.
+	ClientAuthNone
+
+	// DefaultClientAuthType is the default client auth type, mutual TLS.
+	DefaultClientAuthType = ClientAuthRequireAndVerify
+)
+
+// ValidClientAuthTypes returns the valid client auth types.
+func ValidClientAuthTypes() []ClientAuthType {
+	return []ClientAuthType{
+		ClientAuthRequireAndVerify,
+		ClientAuthVerifyIfGiven,
+		ClientAuthNone,
+	}
+}
+
+func (t ClientAuthType) String() string {
+	switch t {
+	case ClientAuthRequireAndVerify:
+		return "require_and_verify"
+	case ClientAuthVerifyIfGiven:
+		return "verify_if_given"
+	case ClientAuthNone:
+		return "none"
+	}
+	return "unknown"
+}
+
+// TLSClientAuthType returns the crypto/tls client auth type.
+func (t ClientAuthType) TLSClientAuthType() tls.ClientAuthType {
+	switch t {
+	case ClientAuthVerifyIfGiven:
+		return tls.VerifyClientCertIfGiven
+	case ClientAuthNone:
+		return tls.NoClientCert
+	}
+	return tls.RequireAndVerifyClientCert
+}
+
+// ParseClientAuthType parses a ClientAuthType from a string.
+func ParseClientAuthType(str string) (ClientAuthType, error) {
+	var t ClientAuthType
+	if str == "" {
+		return t, errClientAuthUnspecified
+	}
+	for _, valid := range ValidClientAuthTypes() {
+		if str == valid.String() {
+			t = valid
+			return t, nil
+		}
+	}
+	return t, fmt.Errorf("invalid tls ClientAuthType '%s' valid types are: %v",
+		str, ValidClientAuthTypes())
+}
+
+// UnmarshalYAML unmarshals a ClientAuthType into a valid type from string.
+func (t *ClientAuthType) UnmarshalYAML(unmarshal func(interface{}) error) error {
+	var str string
+	if err := unmarshal(&str); err != nil {
+		return err
+	}
+	if str == "" {
+		*t = DefaultClientAuthType
+		return nil
+	}
+	parsed, err := ParseClientAuthType(str)
+	if err != nil {
+		return err
+	}
+	*t = parsed
+	return nil
+}
@@ -0,0 +1,83 @@
+// Copyright (c) 2018 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+// Package xtls provides mutual TLS configuration for the gRPC servers and
+// clients of M3, with certificates that are rotated by reloading them from
+// disk. The tchannel Thrift RPC is not covered.
+package xtls
+
+import (
+	"time"
+
+	"github.com/m3db/m3x/instrument"
+)
+
+// Configuration is the TLS configuration of an RPC server or client.
+type Configuration struct {
+	// CertFile is the PEM encoded certificate presented to peers.
+	CertFile string `yaml:"certFile" validate:"nonzero"`
+
+	// KeyFile is the PEM encoded private key of the certificate.
+	KeyFile string `yaml:"keyFile" validate:"nonzero"`
+
+	// CAFile is the PEM encoded CA bundle that peer certificates are verified
+	// against, the system roots are used if not set.
+	CAFile string `yaml:"caFile"`
+
+	// ClientAuth is the policy of servers for verifying client certificates,
+	// defaults to require_and_verify.
+	ClientAuth ClientAuthType `yaml:"clientAuth"`
+
+	// ServerName is the name clients verify server certificates against,
+	// server certificates are only verified against the CA and the allowed
+	// names if not set.
+	ServerName string `yaml:"serverName"`
+
+	// AllowedNames restricts the peers accepted to those with a certificate
+	// with a DNS name or common name in the list, any peer with a certificate
+	// signed by the CA is accepted if empty.
+	AllowedNames []string `yaml:"allowedNames"`
+
+	// InsecureSkipVerify disables the verification of server certificates
+	// by clients, for testing only.
+	InsecureSkipVerify bool `yaml:"insecureSkipVerify"`
+
+	// ReloadInterval is how often the certificate, key and CA files are
+	// checked for changes and reloaded.
+	ReloadInterval time.Duration `yaml:"reloadInterval"`
+}
+
+// NewReloader loads the certificates of the configuration and returns a
+// reloader that reloads them as the files change.
+func (c Configuration) NewReloader(iopts instrument.Options) (*Reloader, error) {
+	opts := NewOptions().
+		SetInstrumentOptions(iopts).
+		SetCertFile(c.CertFile).
+		SetKeyFile(c.KeyFile).
+		SetCAFile(c.CAFile).
+		SetClientAuthType(c.ClientAuth).
+		SetServerName(c.ServerName).
+		SetAllowedNames(c.AllowedNames).
+		SetInsecureSkipVerify(c.InsecureSkipVerify)
+	if c.ReloadInterval > 0 {
+		opts = opts.SetReloadInterval(c.ReloadInterval)
+	}
+	return NewReloader(opts)
+}
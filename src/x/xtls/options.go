@@ -0,0 +1,232 @@
+// Copyright (c) 2018 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package xtls
+
+import (
+	"errors"
+	"time"
+
+	"github.com/m3db/m3x/instrument"
+)
+
+const (
+	defaultReloadInterval = time.Minute
+)
+
+var (
+	errNoCertFile            = errors.New("tls cert file not set")
+	errNoKeyFile             = errors.New("tls key file not set")
+	errInvalidReloadInterval = errors.New("tls reload interval must be positive")
+	errInvalidClientAuthType = errors.New("invalid tls client auth type")
+)
+
+// Options are the options for TLS certificate reloading and verification.
+type Options interface {
+	// Validate validates the options.
+	Validate() error
+
+	// SetInstrumentOptions sets the instrument options.
+	SetInstrumentOptions(value instrument.Options) Options
+
+	// InstrumentOptions returns the instrument options.
+	InstrumentOptions() instrument.Options
+
+	// SetCertFile sets the PEM encoded certificate file.
+	SetCertFile(value string) Options
+
+	// CertFile returns the PEM encoded certificate file.
+	CertFile() string
+
+	// SetKeyFile sets the PEM encoded private key file.
+	SetKeyFile(value string) Options
+
+	// KeyFile returns the PEM encoded private key file.
+	KeyFile() string
+
+	// SetCAFile sets the PEM encoded CA bundle file.
+	SetCAFile(value string) Options
+
+	// CAFile returns the PEM encoded CA bundle file.
+	CAFile() string
+
+	// SetClientAuthType sets the policy of servers for verifying client
+	// certificates.
+	SetClientAuthType(value ClientAuthType) Options
+
+	// ClientAuthType returns the policy of servers for verifying client
+	// certificates.
+	ClientAuthType() ClientAuthType
+
+	// SetServerName sets the name clients verify server certificates against.
+	SetServerName(value string) Options
+
+	// ServerName returns the name clients verify server certificates against.
+	ServerName() string
+
+	// SetAllowedNames sets the names of the peers accepted.
+	SetAllowedNames(value []string) Options
+
+	// AllowedNames returns the names of the peers accepted.
+	AllowedNames() []string
+
+	// SetInsecureSkipVerify sets whether clients skip verifying server
+	// certificates.
+	SetInsecureSkipVerify(value bool) Options
+
+	// InsecureSkipVerify returns whether clients skip verifying server
+	// certificates.
+	InsecureSkipVerify() bool
+
+	// SetReloadInterval sets how often the files are checked for changes.
+	SetReloadInterval(value time.Duration) Options
+
+	// ReloadInterval returns how often the files are checked for changes.
+	ReloadInterval() time.Duration
+}
+
+type options struct {
+	iopts              instrument.Options
+	certFile           string
+	keyFile            string
+	caFile             string
+	clientAuthType     ClientAuthType
+	serverName         string
+	allowedNames       []string
+	insecureSkipVerify bool
+	reloadInterval     time.Duration
+}
+
+// NewOptions returns new TLS options.
+func NewOptions() Options {
+	return &options{
+		iopts:          instrument.NewOptions(),
+		clientAuthType: DefaultClientAuthType,
+		reloadInterval: defaultReloadInterval,
+	}
+}
+
+func (o *options) Validate() error {
+	if o.certFile == "" {
+		return errNoCertFile
+	}
+	if o.keyFile == "" {
+		return errNoKeyFile
+	}
+	if o.reloadInterval <= 0 {
+		return errInvalidReloadInterval
+	}
+	for _, valid := range ValidClientAuthTypes() {
+		if o.clientAuthType == valid {
+			return nil
+		}
+	}
+	return errInvalidClientAuthType
+}
+
+func (o *options) SetInstrumentOptions(value instrument.Options) Options {
+	opts := *o
+	opts.iopts = value
+	return &opts
+}
+
+func (o *options) InstrumentOptions() instrument.Options {
+	return o.iopts
+}
+
+func (o *options) SetCertFile(value string) Options {
+	opts := *o
+	opts.certFile = value
+	return &opts
+}
+
+func (o *options) CertFile() string {
+	return o.certFile
+}
+
+func (o *options) SetKeyFile(value string) Options {
+	opts := *o
+	opts.keyFile = value
+	return &opts
+}
+
+func (o *options) KeyFile() string {
+	return o.keyFile
+}
+
+func (o *options) SetCAFile(value string) Options {
+	opts := *o
+	opts.caFile = value
+	return &opts
+}
+
+func (o *options) CAFile() string {
+	return o.caFile
+}
+
+func (o *options) SetClientAuthType(value ClientAuthType) Options {
+	opts := *o
+	opts.clientAuthType = value
+	return &opts
+}
+
+func (o *options) ClientAuthType() ClientAuthType {
+	return o.clientAuthType
+}
+
+func (o *options) SetServerName(value string) Options {
+	opts := *o
+	opts.serverName = value
+	return &opts
+}
+
+func (o *options) ServerName() string {
+	return o.serverName
+}
+
+func (o *options) SetAllowedNames(value []string) Options {
+	opts := *o
+	opts.allowedNames = value
+	return &opts
+}
+
+func (o *options) AllowedNames() []string {
+	return o.allowedNames
+}
+
+func (o *options) SetInsecureSkipVerify(value bool) Options {
+	opts := *o
+	opts.insecureSkipVerify = value
+	return &opts
+}
+
+func (o *options) InsecureSkipVerify() bool {
+	return o.insecureSkipVerify
+}
+
+func (o *options) SetReloadInterval(value time.Duration) Options {
+	opts := *o
+	opts.reloadInterval = value
+	return &opts
+}
+
+func (o *options) ReloadInterval() time.Duration {
+	return o.reloadInterval
+}
@@ -0,0 +1,335 @@
+// Copyright (c) 2018 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package xtls
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"sync"
+	"time"
+
+	"github.com/uber-go/tally"
+)
+
+var (
+	errNoCACertificates  = errors.New("no certificates found in tls CA file")
+	errNoPeerCertificate = errors.New("peer presented no certificate")
+)
+
+type reloaderMetrics struct {
+	reloads      tally.Counter
+	reloadErrors tally.Counter
+	rejected     tally.Counter
+}
+
+func newReloaderMetrics(scope tally.Scope) reloaderMetrics {
+	scope = scope.SubScope("tls")
+	return reloaderMetrics{
+		reloads:      scope.Counter("reloads"),
+		reloadErrors: scope.Counter("reload-errors"),
+		rejected:     scope.Counter("peers-rejected"),
+	}
+}
+
+// Reloader holds the current certificate and CA pool loaded from the files
+// of its options, the files are checked for changes every reload interval
+// so that certificates can be rotated without restarting. The TLS configs
+// it returns always use the latest certificates loaded.
+type Reloader struct {
+	sync.RWMutex
+
+	opts    Options
+	metrics reloaderMetrics
+	nowFn   func() time.Time
+
+	cert     *tls.Certificate
+	caPool   *x509.CertPool
+	modTimes map[string]time.Time
+
+	closed  bool
+	closeCh chan struct{}
+	doneCh  chan struct{}
+}
+
+// NewReloader loads the certificates of the options and returns a reloader
+// that reloads them as the files change.
+func NewReloader(opts Options) (*Reloader, error) {
+	if err := opts.Validate(); err != nil {
+		return nil, err
+	}
+
+	r := &Reloader{
+		opts:     opts,
+		metrics:  newReloaderMetrics(opts.InstrumentOptions().MetricsScope()),
+		nowFn:    time.Now,
+		modTimes: make(map[string]time.Time),
+		closeCh:  make(chan struct{}),
+		doneCh:   make(chan struct{}),
+	}
+	if err := r.Reload(); err != nil {
+		return nil, err
+	}
+
+	go r.reloadLoop()
+	return r, nil
+}
+
+// Reload reloads the certificate, key and CA files if any of them changed
+// since last loaded, the current certificates are kept if they fail to
+// load.
+func (r *Reloader) Reload() error {
+	modTimes, changed, err := r.fileModTimes()
+	if err != nil {
+		return err
+	}
+	if !changed {
+		return nil
+	}
+
+	cert, err := tls.LoadX509KeyPair(r.opts.CertFile(), r.opts.KeyFile())
+	if err != nil {
+		return fmt.Errorf("unable to load tls key pair: %v", err)
+	}
+
+	var caPool *x509.CertPool
+	if caFile := r.opts.CAFile(); caFile != "" {
+		data, err := ioutil.ReadFile(caFile)
+		if err != nil {
+			return fmt.Errorf("unable to read tls CA file: %v", err)
+		}
+		caPool = x509.NewCertPool()
+		if !caPool.AppendCertsFromPEM(data) {
+			return errNoCACertificates
+		}
+	}
+
+	r.Lock()
+	r.cert = &cert
+	r.caPool = caPool
+	r.modTimes = modTimes
+	r.Unlock()
+
+	r.metrics.reloads.Inc(1)
+	return nil
+}
+
+// fileModTimes returns the modification times of the files and whether any
+// changed since last loaded.
+func (r *Reloader) fileModTimes() (map[string]time.Time, bool, error) {
+	files := []string{r.opts.CertFile(), r.opts.KeyFile()}
+	if caFile := r.opts.CAFile(); caFile != "" {
+		files = append(files, caFile)
+	}
+
+	r.RLock()
+	current := r.modTimes
+	r.RUnlock()
+
+	var (
+		modTimes = make(map[string]time.Time, len(files))
+		changed  bool
+	)
+	for _, file := range files {
+		info, err := os.Stat(file)
+		if err != nil {
+			return nil, false, err
+		}
+		modTimes[file] = info.ModTime()
+		if prev, ok := current[file]; !ok || !prev.Equal(info.ModTime()) {
+			changed = true
+		}
+	}
+	return modTimes, changed, nil
+}
+
+func (r *Reloader) reloadLoop() {
+	defer close(r.doneCh)
+
+	ticker := time.NewTicker(r.opts.ReloadInterval())
+	defer ticker.Stop()
+
+	logger := r.opts.InstrumentOptions().Logger()
+	for {
+		select {
+		case <-ticker.C:
+			if err := r.Reload(); err != nil {
+				r.metrics.reloadErrors.Inc(1)
+				logger.Errorf("unable to reload tls certificates, keeping current: %v", err)
+			}
+		case <-r.closeCh:
+			return
+		}
+	}
+}
+
+func (r *Reloader) current() (*tls.Certificate, *x509.CertPool) {
+	r.RLock()
+	cert, caPool := r.cert, r.caPool
+	r.RUnlock()
+	return cert, caPool
+}
+
+// ServerTLSConfig returns the TLS config of a server, each connection uses
+// the certificate and client CA pool current at the time of the handshake.
+func (r *Reloader) ServerTLSConfig() *tls.Config {
+	clientAuth := r.opts.ClientAuthType().TLSClientAuthType()
+	return &tls.Config{
+		MinVersion: tls.VersionTLS12,
+		ClientAuth: clientAuth,
+		GetConfigForClient: func(*tls.ClientHelloInfo) (*tls.Config, error) {
+			cert, caPool := r.current()
+			return &tls.Config{
+				MinVersion:            tls.VersionTLS12,
+				Certificates:          []tls.Certificate{*cert},
+				ClientAuth:            clientAuth,
+				ClientCAs:             caPool,
+				VerifyPeerCertificate: r.verifyClient,
+			}, nil
+		},
+	}
+}
+
+// ClientTLSConfig returns the TLS config of a client, each connection
+// presents the current certificate and verifies the server against the
+// current CA pool.
+func (r *Reloader) ClientTLSConfig() *tls.Config {
+	return &tls.Config{
+		MinVersion: tls.VersionTLS12,
+		GetClientCertificate: func(*tls.CertificateRequestInfo) (*tls.Certificate, error) {
+			cert, _ := r.current()
+			return cert, nil
+		},
+		// The server certificate is verified by verifyServer instead since
+		// the roots of a config cannot change once in use.
+		InsecureSkipVerify:    true,
+		VerifyPeerCertificate: r.verifyServer,
+	}
+}
+
+// verifyClient verifies that the name of a client certificate is allowed,
+// the certificate chain has already been verified by the handshake if
+// required.
+func (r *Reloader) verifyClient(rawCerts [][]byte, _ [][]*x509.Certificate) error {
+	if len(rawCerts) == 0 {
+		// Only possible if client certificates are not required.
+		if len(r.opts.AllowedNames()) > 0 {
+			r.metrics.rejected.Inc(1)
+			return errNoPeerCertificate
+		}
+		return nil
+	}
+	leaf, err := x509.ParseCertificate(rawCerts[0])
+	if err != nil {
+		r.metrics.rejected.Inc(1)
+		return err
+	}
+	if err := r.verifyAllowedName(leaf); err != nil {
+		r.metrics.rejected.Inc(1)
+		return err
+	}
+	return nil
+}
+
+// verifyServer verifies a server certificate chain against the current CA
+// pool and the server and allowed names.
+func (r *Reloader) verifyServer(rawCerts [][]byte, _ [][]*x509.Certificate) error {
+	if r.opts.InsecureSkipVerify() {
+		return nil
+	}
+	if err := r.verifyServerChain(rawCerts); err != nil {
+		r.metrics.rejected.Inc(1)
+		return err
+	}
+	return nil
+}
+
+func (r *Reloader) verifyServerChain(rawCerts [][]byte) error {
+	if len(rawCerts) == 0 {
+		return errNoPeerCertificate
+	}
+
+	certs := make([]*x509.Certificate, 0, len(rawCerts))
+	for _, raw := range rawCerts {
+		cert, err := x509.ParseCertificate(raw)
+		if err != nil {
+			return err
+		}
+		certs = append(certs, cert)
+	}
+
+	intermediates := x509.NewCertPool()
+	for _, cert := range certs[1:] {
+		intermediates.AddCert(cert)
+	}
+
+	_, caPool := r.current()
+	leaf := certs[0]
+	if _, err := leaf.Verify(x509.VerifyOptions{
+		Roots:         caPool,
+		Intermediates: intermediates,
+		CurrentTime:   r.nowFn(),
+		DNSName:       r.opts.ServerName(),
+		KeyUsages:     []x509.ExtKeyUsage{x509.ExtKeyUsageServerAuth},
+	}); err != nil {
+		return err
+	}
+
+	return r.verifyAllowedName(leaf)
+}
+
+// verifyAllowedName returns an error if there are allowed names and the
+// certificate has none of them as a DNS name or common name.
+func (r *Reloader) verifyAllowedName(cert *x509.Certificate) error {
+	allowed := r.opts.AllowedNames()
+	if len(allowed) == 0 {
+		return nil
+	}
+	for _, name := range allowed {
+		if cert.Subject.CommonName == name {
+			return nil
+		}
+		for _, dnsName := range cert.DNSNames {
+			if dnsName == name {
+				return nil
+			}
+		}
+	}
+	return fmt.Errorf("peer certificate %q is not in the allowed names",
+		cert.Subject.CommonName)
+}
+
+// Close stops reloading the certificates.
+func (r *Reloader) Close() {
+	r.Lock()
+	if r.closed {
+		r.Unlock()
+		return
+	}
+	r.closed = true
+	r.Unlock()
+
+	close(r.closeCh)
+	<-r.doneCh
+}
@@ -0,0 +1,241 @@
+// Copyright (c) 2018 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package xtls
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"io/ioutil"
+	"math/big"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type testCert struct {
+	cert *x509.Certificate
+	key  *ecdsa.PrivateKey
+	der  []byte
+}
+
+func newTestCert(t *testing.T, name string, serial int64, parent *testCert) *testCert {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	require.NoError(t, err)
+
+	tmpl := &x509.Certificate{
+		SerialNumber: big.NewInt(serial),
+		Subject:      pkix.Name{CommonName: name},
+		NotBefore:    time.Now().Add(-time.Hour),
+		NotAfter:     time.Now().Add(time.Hour),
+		KeyUsage:     x509.KeyUsageDigitalSignature | x509.KeyUsageKeyEncipherment,
+		ExtKeyUsage: []x509.ExtKeyUsage{
+			x509.ExtKeyUsageServerAuth,
+			x509.ExtKeyUsageClientAuth,
+		},
+		DNSNames: []string{name},
+	}
+
+	signerCert, signerKey := tmpl, key
+	if parent == nil {
+		tmpl.IsCA = true
+		tmpl.BasicConstraintsValid = true
+		tmpl.KeyUsage |= x509.KeyUsageCertSign
+	} else {
+		signerCert, signerKey = parent.cert, parent.key
+	}
+
+	der, err := x509.CreateCertificate(rand.Reader, tmpl, signerCert, &key.PublicKey, signerKey)
+	require.NoError(t, err)
+	cert, err := x509.ParseCertificate(der)
+	require.NoError(t, err)
+	return &testCert{cert: cert, key: key, der: der}
+}
+
+func writeTestCert(t *testing.T, dir, name string, cert, ca *testCert) Options {
+	keyDER, err := x509.MarshalECPrivateKey(cert.key)
+	require.NoError(t, err)
+
+	var (
+		certFile = filepath.Join(dir, name+".crt")
+		keyFile  = filepath.Join(dir, name+".key")
+		caFile   = filepath.Join(dir, name+"-ca.crt")
+	)
+	require.NoError(t, ioutil.WriteFile(certFile,
+		pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: cert.der}), 0600))
+	require.NoError(t, ioutil.WriteFile(keyFile,
+		pem.EncodeToMemory(&pem.Block{Type: "EC PRIVATE KEY", Bytes: keyDER}), 0600))
+	require.NoError(t, ioutil.WriteFile(caFile,
+		pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: ca.der}), 0600))
+
+	return NewOptions().
+		SetCertFile(certFile).
+		SetKeyFile(keyFile).
+		SetCAFile(caFile).
+		SetReloadInterval(time.Hour)
+}
+
+// handshake returns the certificate the server presented to the client.
+func handshake(t *testing.T, server, client *tls.Config) (*x509.Certificate, error) {
+	listener, err := tls.Listen("tcp", "127.0.0.1:0", server)
+	require.NoError(t, err)
+	defer listener.Close()
+
+	serverErrCh := make(chan error, 1)
+	go func() {
+		conn, err := listener.Accept()
+		if err != nil {
+			serverErrCh <- err
+			return
+		}
+		defer conn.Close()
+		serverErrCh <- conn.(*tls.Conn).Handshake()
+	}()
+
+	conn, err := tls.Dial("tcp", listener.Addr().String(), client)
+	if err != nil {
+		<-serverErrCh
+		return nil, err
+	}
+	defer conn.Close()
+
+	if err := <-serverErrCh; err != nil {
+		return nil, err
+	}
+	return conn.ConnectionState().PeerCertificates[0], nil
+}
+
+func newTestReloaders(t *testing.T, dir string) (*Reloader, *Reloader) {
+	ca := newTestCert(t, "ca", 1, nil)
+	serverOpts := writeTestCert(t, dir, "server", newTestCert(t, "server", 2, ca), ca)
+	clientOpts := writeTestCert(t, dir, "client", newTestCert(t, "client", 3, ca), ca)
+
+	server, err := NewReloader(serverOpts.SetAllowedNames([]string{"client"}))
+	require.NoError(t, err)
+	client, err := NewReloader(clientOpts.SetServerName("server"))
+	require.NoError(t, err)
+	return server, client
+}
+
+func TestReloaderMutualTLS(t *testing.T) {
+	dir, err := ioutil.TempDir("", "xtls")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	server, client := newTestReloaders(t, dir)
+	defer server.Close()
+	defer client.Close()
+
+	peer, err := handshake(t, server.ServerTLSConfig(), client.ClientTLSConfig())
+	require.NoError(t, err)
+	assert.Equal(t, "server", peer.Subject.CommonName)
+
+	// Clients without a certificate are rejected.
+	noCert := client.ClientTLSConfig()
+	noCert.GetClientCertificate = nil
+	_, err = handshake(t, server.ServerTLSConfig(), noCert)
+	assert.Error(t, err)
+}
+
+func TestReloaderRejectsPeers(t *testing.T) {
+	dir, err := ioutil.TempDir("", "xtls")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	server, client := newTestReloaders(t, dir)
+	defer server.Close()
+	defer client.Close()
+
+	// Client names not in the allowed names are rejected.
+	strictServer, err := NewReloader(server.opts.SetAllowedNames([]string{"other"}))
+	require.NoError(t, err)
+	defer strictServer.Close()
+	_, err = handshake(t, strictServer.ServerTLSConfig(), client.ClientTLSConfig())
+	assert.Error(t, err)
+
+	// Servers with an unexpected name are rejected.
+	wrongName, err := NewReloader(client.opts.SetServerName("other"))
+	require.NoError(t, err)
+	defer wrongName.Close()
+	_, err = handshake(t, server.ServerTLSConfig(), wrongName.ClientTLSConfig())
+	assert.Error(t, err)
+}
+
+func TestReloaderReload(t *testing.T) {
+	dir, err := ioutil.TempDir("", "xtls")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	server, client := newTestReloaders(t, dir)
+	defer server.Close()
+	defer client.Close()
+
+	// Configs created before the rotation use the rotated certificates.
+	serverConfig, clientConfig := server.ServerTLSConfig(), client.ClientTLSConfig()
+
+	// Rotate the certificates and CAs of both, the old CA no longer trusted.
+	ca := newTestCert(t, "ca", 10, nil)
+	writeTestCert(t, dir, "server", newTestCert(t, "server", 11, ca), ca)
+	writeTestCert(t, dir, "client", newTestCert(t, "client", 12, ca), ca)
+
+	// Ensure the modification times differ on filesystems with coarse
+	// timestamps.
+	future := time.Now().Add(time.Minute)
+	for _, opts := range []Options{server.opts, client.opts} {
+		for _, file := range []string{opts.CertFile(), opts.KeyFile(), opts.CAFile()} {
+			require.NoError(t, os.Chtimes(file, future, future))
+		}
+	}
+
+	require.NoError(t, server.Reload())
+	require.NoError(t, client.Reload())
+
+	peer, err := handshake(t, serverConfig, clientConfig)
+	require.NoError(t, err)
+	assert.Equal(t, int64(11), peer.SerialNumber.Int64())
+
+	// A failed reload keeps the current certificates.
+	require.NoError(t, ioutil.WriteFile(server.opts.CertFile(), []byte("invalid"), 0600))
+	assert.Error(t, server.Reload())
+	_, err = handshake(t, serverConfig, clientConfig)
+	assert.NoError(t, err)
+}
+
+func TestParseClientAuthType(t *testing.T) {
+	for _, valid := range ValidClientAuthTypes() {
+		parsed, err := ParseClientAuthType(valid.String())
+		require.NoError(t, err)
+		assert.Equal(t, valid, parsed)
+	}
+
+	_, err := ParseClientAuthType("")
+	assert.Error(t, err)
+	_, err = ParseClientAuthType("always")
+	assert.Error(t, err)
+}